@@ -0,0 +1,145 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The AuditLog service is declared by hand like the SearchIndexHealth service. It returns the audit trail of the
+// namespace of the caller, the operations done on its projects and collections and on the namespace itself, as a
+// JSON encoded AuditLogsResponse in the HttpBody.
+
+const auditLogServiceName = "tigrisdata.v1.AuditLog"
+
+const (
+	// DefaultAuditLogsLimit is the number of the entries returned by an AuditLogs request without a limit.
+	DefaultAuditLogsLimit = 100
+	// MaxAuditLogsLimit caps the number of the entries returned by an AuditLogs request.
+	MaxAuditLogsLimit = 10000
+)
+
+// AuditEntry is an operation retained in the audit trail of a namespace. The entries of a namespace are chained, each
+// one is numbered after the previous one and its hash covers the entry and the hash of the previous one, so that a
+// modified, a removed or an inserted entry breaks the chain.
+type AuditEntry struct {
+	// Seq is the position of the entry in the trail of the namespace.
+	Seq int64     `json:"seq"`
+	At  time.Time `json:"at"`
+	// Sub is the subject of the access token of the caller, Role the role of the caller.
+	Sub  string `json:"sub,omitempty"`
+	Role string `json:"role,omitempty"`
+	// Operation is the full gRPC method name of the operation.
+	Operation  string `json:"operation"`
+	Project    string `json:"project,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Collection string `json:"collection,omitempty"`
+	// Filter is the filter of the reads, the updates and the deletes, as sent.
+	Filter string `json:"filter,omitempty"`
+	// Peer is the address of the caller, the first address of the X-Forwarded-For header if it is set.
+	Peer string `json:"peer,omitempty"`
+	// Code is the gRPC status code of the operation.
+	Code     string `json:"code"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// AuditLogsResponse is the audit trail of a namespace, the latest entries first.
+type AuditLogsResponse struct {
+	Entries []*AuditEntry `json:"entries"`
+	// Verified is true if the hashes of the entries read to answer the request match the chain, including the entries
+	// filtered out by the request.
+	Verified bool `json:"verified"`
+}
+
+// AuditQuery selects the entries returned by an AuditLogs request, the zero values don't restrict the entries.
+type AuditQuery struct {
+	Since      time.Time
+	Until      time.Time
+	Project    string
+	Collection string
+	Limit      int
+}
+
+// AuditLogClient is the client API for the AuditLog service.
+type AuditLogClient interface {
+	// AuditLogs returns the audit trail of the namespace of the caller, filtered by the Tigris-Audit headers.
+	AuditLogs(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type auditLogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuditLogClient(cc grpc.ClientConnInterface) AuditLogClient {
+	return &auditLogClient{cc}
+}
+
+func (c *auditLogClient) AuditLogs(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, AuditLogsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// AuditLogServer is the server API for the AuditLog service.
+type AuditLogServer interface {
+	// AuditLogs returns the audit trail of the namespace of the caller, the latest entries first.
+	AuditLogs(context.Context, *ListProjectsRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterAuditLogServer(s grpc.ServiceRegistrar, srv AuditLogServer) {
+	s.RegisterService(&AuditLog_ServiceDesc, srv)
+}
+
+func _AuditLog_AuditLogs_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ListProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditLogServer).AuditLogs(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuditLogsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(AuditLogServer).AuditLogs(ctx, req.(*ListProjectsRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuditLog_ServiceDesc is the grpc.ServiceDesc for the AuditLog service.
+var AuditLog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: auditLogServiceName,
+	HandlerType: (*AuditLogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AuditLogs",
+			Handler:    _AuditLog_AuditLogs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/audit_log.go",
+}
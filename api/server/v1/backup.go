@@ -0,0 +1,198 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The Backup service is declared by hand like the BranchDiff service. The Backup and Restore requests start a job in
+// the background and return it, the BackupStatus requests return the jobs of the project. The jobs are returned as a
+// JSON encoded BackupJob or BackupJobsResponse in the HttpBody.
+//
+// A backup writes the schemas, the index definitions and the documents of the collections of a branch to the target
+// of the Tigris-Backup-Target header. A restore reads the archive of the Tigris-Backup-Source header into the project
+// and the branch of the request, which are created if they don't exist.
+//
+// A RestoreToTimestamp undoes the mutations retained in the changelogs of the collections of the branch since the
+// moment of the Tigris-Restore-Timestamp header, restricted to the collection of the Tigris-Restore-Collection header
+// if it is set.
+
+const backupServiceName = "tigrisdata.v1.Backup"
+
+// The kinds of the backup jobs.
+const (
+	BackupKindBackup  = "backup"
+	BackupKindRestore = "restore"
+	// BackupKindPointInTime is a restore of the collections to an earlier moment from their changelogs.
+	BackupKindPointInTime = "restore_to_timestamp"
+)
+
+// The states of the backup jobs.
+const (
+	BackupRunning = "RUNNING"
+	BackupDone    = "DONE"
+	BackupFailed  = "FAILED"
+)
+
+// BackupJob is the progress of a backup or a restore.
+type BackupJob struct {
+	Id      string `json:"id"`
+	Kind    string `json:"kind"`
+	State   string `json:"state"`
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+	// Target is the archive written by a backup or read by a restore, the moment restored to by a point in time
+	// restore.
+	Target string `json:"target"`
+	// Collection is the collection being copied.
+	Collection  string `json:"collection,omitempty"`
+	Collections int64  `json:"collections"`
+	Documents   int64  `json:"documents"`
+	Bytes       int64  `json:"bytes"`
+	StartedAt   string `json:"started_at"`
+	FinishedAt  string `json:"finished_at,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BackupJobsResponse is the backup jobs of a project, the most recent first.
+type BackupJobsResponse struct {
+	Jobs []*BackupJob `json:"jobs"`
+}
+
+// BackupClient is the client API for the Backup service.
+type BackupClient interface {
+	// Backup starts a backup of the branch of the request.
+	Backup(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Restore starts a restore of an archive into the branch of the request.
+	Restore(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// BackupStatus returns the backup and restore jobs of the project.
+	BackupStatus(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// RestoreToTimestamp starts a restore of the branch of the request to an earlier moment.
+	RestoreToTimestamp(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type backupClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBackupClient(cc grpc.ClientConnInterface) BackupClient {
+	return &backupClient{cc}
+}
+
+func (c *backupClient) Backup(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, BackupMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *backupClient) Restore(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, RestoreMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *backupClient) BackupStatus(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, BackupStatusMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *backupClient) RestoreToTimestamp(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, RestoreToTimestampMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// BackupServer is the server API for the Backup service.
+type BackupServer interface {
+	// Backup starts a backup of the branch of the request to the target of the Tigris-Backup-Target header.
+	Backup(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+	// Restore starts a restore of the archive of the Tigris-Backup-Source header into the branch of the request.
+	Restore(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+	// BackupStatus returns the backup and restore jobs of the project, or the job of the Tigris-Backup-Id header.
+	BackupStatus(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+	// RestoreToTimestamp starts a restore of the branch to the moment of the Tigris-Restore-Timestamp header.
+	RestoreToTimestamp(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterBackupServer(s grpc.ServiceRegistrar, srv BackupServer) {
+	s.RegisterService(&Backup_ServiceDesc, srv)
+}
+
+// backupHandler returns the handler of a method of the Backup service, they all take a DescribeDatabaseRequest.
+func backupHandler(fullMethod string, call func(BackupServer, context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error),
+) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(DescribeDatabaseRequest)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(BackupServer), ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: fullMethod,
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return call(srv.(BackupServer), ctx, req.(*DescribeDatabaseRequest))
+		}
+
+		return interceptor(ctx, in, info, handler)
+	}
+}
+
+// Backup_ServiceDesc is the grpc.ServiceDesc for the Backup service.
+var Backup_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: backupServiceName,
+	HandlerType: (*BackupServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Backup",
+			Handler:    backupHandler(BackupMethodName, BackupServer.Backup),
+		},
+		{
+			MethodName: "Restore",
+			Handler:    backupHandler(RestoreMethodName, BackupServer.Restore),
+		},
+		{
+			MethodName: "BackupStatus",
+			Handler:    backupHandler(BackupStatusMethodName, BackupServer.BackupStatus),
+		},
+		{
+			MethodName: "RestoreToTimestamp",
+			Handler:    backupHandler(RestoreToTimestampMethodName, BackupServer.RestoreToTimestamp),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/backup.go",
+}
@@ -0,0 +1,40 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// The states of the updates run in batches, an update running in the background can also be canceled.
+const (
+	UpdateRunning  = "RUNNING"
+	UpdateDone     = "DONE"
+	UpdateFailed   = "FAILED"
+	UpdateCanceled = "CANCELED"
+)
+
+// UpdateProgress is the progress of an Update request run in batches in the background, see HeaderUpdateBatchSize.
+type UpdateProgress struct {
+	Id         string `json:"id"`
+	State      string `json:"state"`
+	Project    string `json:"project"`
+	Branch     string `json:"branch"`
+	Collection string `json:"collection"`
+	// Updated is the number of the documents updated by the batches committed so far.
+	Updated int64 `json:"updated"`
+	// Scanned is the number of the documents read by the batches committed so far.
+	Scanned    int64  `json:"scanned"`
+	Batches    int64  `json:"batches"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
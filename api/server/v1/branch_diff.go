@@ -0,0 +1,137 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The BranchDiff service is declared by hand like the IndexConsistency service. It compares the branch of the request
+// with a base branch, the main branch by default, and returns the schema changes and the number of the documents
+// added, modified and deleted in each collection, to preview the changes of a branch before merging it. The report is
+// returned as a JSON encoded BranchDiffResponse in the HttpBody.
+
+const branchDiffServiceName = "tigrisdata.v1.BranchDiff"
+
+// MaxBranchDiffSample is the maximum number of the changed documents returned for each collection.
+const MaxBranchDiffSample = 100
+
+// The changes of the collections and the documents of a branch relative to the base branch.
+const (
+	BranchDiffAdded     = "added"
+	BranchDiffModified  = "modified"
+	BranchDiffDeleted   = "deleted"
+	BranchDiffUnchanged = "unchanged"
+)
+
+// DocumentDiff is a document changed in the branch. Base is the document in the base branch and Branch is the
+// document in the branch, only one of them is set for the added and the deleted documents.
+type DocumentDiff struct {
+	Change string              `json:"change"`
+	Base   jsoniter.RawMessage `json:"base,omitempty"`
+	Branch jsoniter.RawMessage `json:"branch,omitempty"`
+}
+
+// CollectionDiff is the difference of a collection between the branches. The schemas are only returned if they differ.
+type CollectionDiff struct {
+	Collection   string              `json:"collection"`
+	Schema       string              `json:"schema"`
+	BaseSchema   jsoniter.RawMessage `json:"base_schema,omitempty"`
+	BranchSchema jsoniter.RawMessage `json:"branch_schema,omitempty"`
+	Added        int64               `json:"added"`
+	Modified     int64               `json:"modified"`
+	Deleted      int64               `json:"deleted"`
+	// Sample is up to the requested number of the changed documents, in the primary key order.
+	Sample []*DocumentDiff `json:"sample,omitempty"`
+}
+
+// BranchDiffResponse is the difference of every collection of the branches, the collections are ordered by name.
+type BranchDiffResponse struct {
+	Base        string            `json:"base"`
+	Branch      string            `json:"branch"`
+	Collections []*CollectionDiff `json:"collections"`
+}
+
+// BranchDiffClient is the client API for the BranchDiff service.
+type BranchDiffClient interface {
+	// DiffBranches compares the branch of the request with the base branch.
+	DiffBranches(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type branchDiffClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBranchDiffClient(cc grpc.ClientConnInterface) BranchDiffClient {
+	return &branchDiffClient{cc}
+}
+
+func (c *branchDiffClient) DiffBranches(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, DiffBranchesMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// BranchDiffServer is the server API for the BranchDiff service.
+type BranchDiffServer interface {
+	// DiffBranches compares the branch of the request with the branch of the Tigris-Diff-Base header. The
+	// Tigris-Diff-Sample header sets the number of the changed documents returned for each collection.
+	DiffBranches(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterBranchDiffServer(s grpc.ServiceRegistrar, srv BranchDiffServer) {
+	s.RegisterService(&BranchDiff_ServiceDesc, srv)
+}
+
+func _BranchDiff_DiffBranches_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BranchDiffServer).DiffBranches(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DiffBranchesMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(BranchDiffServer).DiffBranches(ctx, req.(*DescribeDatabaseRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// BranchDiff_ServiceDesc is the grpc.ServiceDesc for the BranchDiff service.
+var BranchDiff_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: branchDiffServiceName,
+	HandlerType: (*BranchDiffServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DiffBranches",
+			Handler:    _BranchDiff_DiffBranches_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/branch_diff.go",
+}
@@ -0,0 +1,110 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The BranchLifetime service is declared by hand like the BranchDiff service. It lists the branches of a project with
+// the remaining lifetime of the ephemeral ones, the branches created with the Tigris-Branch-Expires-After header. The
+// list is returned as a JSON encoded BranchLifetimesResponse in the HttpBody.
+
+const branchLifetimeServiceName = "tigrisdata.v1.BranchLifetime"
+
+// BranchLifetime is the lifetime of a branch. ExpiresAt and ExpiresIn are only set for the ephemeral branches.
+type BranchLifetime struct {
+	Branch    string `json:"branch"`
+	Ephemeral bool   `json:"ephemeral"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// ExpiresIn is the number of seconds until the branch is deleted, zero once it is expired and waiting for the
+	// cleanup.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+}
+
+// BranchLifetimesResponse is the branches of a project, the main branch first and then the others by name.
+type BranchLifetimesResponse struct {
+	Branches []*BranchLifetime `json:"branches"`
+}
+
+// BranchLifetimeClient is the client API for the BranchLifetime service.
+type BranchLifetimeClient interface {
+	// ListBranchLifetimes lists the branches of the project with their lifetime.
+	ListBranchLifetimes(ctx context.Context, in *ListBranchesRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type branchLifetimeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBranchLifetimeClient(cc grpc.ClientConnInterface) BranchLifetimeClient {
+	return &branchLifetimeClient{cc}
+}
+
+func (c *branchLifetimeClient) ListBranchLifetimes(ctx context.Context, in *ListBranchesRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, ListBranchLifetimesMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// BranchLifetimeServer is the server API for the BranchLifetime service.
+type BranchLifetimeServer interface {
+	// ListBranchLifetimes lists the branches of the project with the remaining lifetime of the ephemeral ones.
+	ListBranchLifetimes(context.Context, *ListBranchesRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterBranchLifetimeServer(s grpc.ServiceRegistrar, srv BranchLifetimeServer) {
+	s.RegisterService(&BranchLifetime_ServiceDesc, srv)
+}
+
+func _BranchLifetime_ListBranchLifetimes_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ListBranchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BranchLifetimeServer).ListBranchLifetimes(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ListBranchLifetimesMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(BranchLifetimeServer).ListBranchLifetimes(ctx, req.(*ListBranchesRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// BranchLifetime_ServiceDesc is the grpc.ServiceDesc for the BranchLifetime service.
+var BranchLifetime_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: branchLifetimeServiceName,
+	HandlerType: (*BranchLifetimeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBranchLifetimes",
+			Handler:    _BranchLifetime_ListBranchLifetimes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/branch_lifetime.go",
+}
@@ -0,0 +1,166 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The BranchMerge service is declared by hand like the BranchDiff service. It applies the changes of a branch to the
+// main branch in batches and streams the progress of the merge as JSON encoded BranchMergeStatus messages.
+
+const branchMergeServiceName = "tigrisdata.v1.BranchMerge"
+
+// The conflict policies of a merge. A conflict is a collection or a document that exists in both branches with a
+// different schema or value. With MergePolicySourceWins the value of the branch replaces the value of the main
+// branch, with MergePolicyTargetWins the value of the main branch is kept and with MergePolicyFail the merge fails
+// without changing the main branch, reporting the conflicts.
+const (
+	MergePolicySourceWins = "source_wins"
+	MergePolicyTargetWins = "target_wins"
+	MergePolicyFail       = "fail"
+)
+
+// The states of a merge. The branches are compared first, then the schemas of the new and the modified collections
+// are applied and the documents are merged collection by collection.
+const (
+	BranchMergePlanning = "PLANNING"
+	BranchMergeSchemas  = "SCHEMAS"
+	BranchMergeMerging  = "MERGING"
+	BranchMergeDone     = "DONE"
+	BranchMergeFailed   = "FAILED"
+)
+
+// BranchMergeStatus is the progress of a merge.
+type BranchMergeStatus struct {
+	// State is one of the BranchMerge states.
+	State  string `json:"state"`
+	Branch string `json:"branch"`
+	Policy string `json:"policy"`
+	// Collection is the collection being merged.
+	Collection string `json:"collection,omitempty"`
+	// Processed is the number of the documents of the branch merged so far, Inserted of them didn't exist in the main
+	// branch, Replaced of them replaced a conflicting document and Skipped of them were equal to the document of the
+	// main branch or lost a conflict.
+	Processed int64 `json:"processed"`
+	Inserted  int64 `json:"inserted"`
+	Replaced  int64 `json:"replaced"`
+	Skipped   int64 `json:"skipped"`
+	Conflicts int64 `json:"conflicts"`
+	// ConflictReport is the conflicting collections of a merge failed by the MergePolicyFail policy, with a sample of
+	// their conflicting documents.
+	ConflictReport []*CollectionDiff `json:"conflict_report,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// BranchMergeClient is the client API for the BranchMerge service.
+type BranchMergeClient interface {
+	// MergeBranch merges the branch into the main branch and streams the progress of the merge.
+	MergeBranch(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (BranchMerge_MergeBranchClient, error)
+}
+
+type branchMergeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBranchMergeClient(cc grpc.ClientConnInterface) BranchMergeClient {
+	return &branchMergeClient{cc}
+}
+
+func (c *branchMergeClient) MergeBranch(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (BranchMerge_MergeBranchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BranchMerge_ServiceDesc.Streams[0], MergeBranchMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &branchMergeMergeBranchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+type BranchMerge_MergeBranchClient interface {
+	Recv() (*httpbody.HttpBody, error)
+	grpc.ClientStream
+}
+
+type branchMergeMergeBranchClient struct {
+	grpc.ClientStream
+}
+
+func (x *branchMergeMergeBranchClient) Recv() (*httpbody.HttpBody, error) {
+	m := new(httpbody.HttpBody)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// BranchMergeServer is the server API for the BranchMerge service.
+type BranchMergeServer interface {
+	// MergeBranch merges the branch of the request into the main branch with the conflict policy of the
+	// Tigris-Merge-Policy header, the progress is sent after every batch of documents.
+	MergeBranch(*DescribeDatabaseRequest, BranchMerge_MergeBranchServer) error
+}
+
+func RegisterBranchMergeServer(s grpc.ServiceRegistrar, srv BranchMergeServer) {
+	s.RegisterService(&BranchMerge_ServiceDesc, srv)
+}
+
+func _BranchMerge_MergeBranch_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(DescribeDatabaseRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return srv.(BranchMergeServer).MergeBranch(m, &branchMergeMergeBranchServer{stream})
+}
+
+type BranchMerge_MergeBranchServer interface {
+	Send(*httpbody.HttpBody) error
+	grpc.ServerStream
+}
+
+type branchMergeMergeBranchServer struct {
+	grpc.ServerStream
+}
+
+func (x *branchMergeMergeBranchServer) Send(m *httpbody.HttpBody) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// BranchMerge_ServiceDesc is the grpc.ServiceDesc for the BranchMerge service.
+var BranchMerge_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: branchMergeServiceName,
+	HandlerType: (*BranchMergeServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "MergeBranch",
+			Handler:       _BranchMerge_MergeBranch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/v1/branch_merge.go",
+}
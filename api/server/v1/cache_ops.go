@@ -0,0 +1,353 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The CacheOps service is declared by hand like the Queues service, it extends the Cache service with the atomic
+// operations on the keys of a cache. Incr, Decr and Expire take a SetRequest, the value of Incr and Decr is the
+// integer added to or subtracted from the value of the key and the ex and px of Expire are the new TTL of the key.
+// GetDel takes a GetRequest. CompareAndSwap takes a GetSetRequest whose value is set only if the key has the value of
+// the Tigris-Cache-Expected-Bin header. The values are compared byte for byte, as they were set. The responses are
+// the JSON encoded IncrResponse, ExpireResponse, GetDelResponse and CompareAndSwapResponse in the HttpBody.
+//
+// CacheKeys takes a KeysRequest and streams the keys matching its glob pattern, a page of CacheKeysResponse per
+// HttpBody, from the cursor of the request. Each page carries the cursor to resume the listing from after it. With
+// the Tigris-Cache-Count-Estimate header set to "true" the stream is a single CacheKeysResponse with the number of
+// the matching keys instead.
+
+const cacheOpsServiceName = "tigrisdata.cache.v1.CacheOps"
+
+// IncrResponse is the value of the key after an increment or a decrement.
+type IncrResponse struct {
+	Value int64 `json:"value"`
+}
+
+// ExpireResponse is the result of the update of the TTL of a key.
+type ExpireResponse struct {
+	Status string `json:"status"`
+}
+
+// GetDelResponse is the value of the deleted key.
+type GetDelResponse struct {
+	Value jsoniter.RawMessage `json:"value"`
+}
+
+// CompareAndSwapResponse is the result of a compare-and-swap, the previous value is empty if the key didn't exist.
+type CompareAndSwapResponse struct {
+	Swapped  bool                `json:"swapped"`
+	OldValue jsoniter.RawMessage `json:"old_value,omitempty"`
+}
+
+// CacheKeysResponse is a page of the keys of a cache, or the count of the keys of a count estimate. Count is exact if
+// Exact is set, otherwise it's extrapolated from a part of the keyspace.
+type CacheKeysResponse struct {
+	Keys   []string `json:"keys,omitempty"`
+	Cursor uint64   `json:"cursor"`
+	Count  *int64   `json:"count,omitempty"`
+	Exact  bool     `json:"exact,omitempty"`
+}
+
+// CacheOpsClient is the client API for the CacheOps service.
+type CacheOpsClient interface {
+	// Incr adds the value of the request to the integer value of the key.
+	Incr(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Decr subtracts the value of the request from the integer value of the key.
+	Decr(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Expire sets the TTL of the key.
+	Expire(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// GetDel returns the value of the key and deletes the key.
+	GetDel(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// CompareAndSwap sets the value of the key if its value is the expected value of the request.
+	CompareAndSwap(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// CacheKeys streams the keys of the cache matching the pattern of the request.
+	CacheKeys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (CacheOps_CacheKeysClient, error)
+}
+
+type cacheOpsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCacheOpsClient(cc grpc.ClientConnInterface) CacheOpsClient {
+	return &cacheOpsClient{cc}
+}
+
+func (c *cacheOpsClient) Incr(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, IncrMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *cacheOpsClient) Decr(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, DecrMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *cacheOpsClient) Expire(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, ExpireMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *cacheOpsClient) GetDel(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, GetDelMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *cacheOpsClient) CompareAndSwap(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, CompareAndSwapMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *cacheOpsClient) CacheKeys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (CacheOps_CacheKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CacheOps_ServiceDesc.Streams[0], CacheKeysMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &cacheOpsCacheKeysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+type CacheOps_CacheKeysClient interface {
+	Recv() (*httpbody.HttpBody, error)
+	grpc.ClientStream
+}
+
+type cacheOpsCacheKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheOpsCacheKeysClient) Recv() (*httpbody.HttpBody, error) {
+	m := new(httpbody.HttpBody)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// CacheOpsServer is the server API for the CacheOps service.
+type CacheOpsServer interface {
+	// Incr adds the value of the request, 1 if it's empty, to the integer value of the key and returns the result. A
+	// missing key is set to the value of the request, the TTL of an existing key is kept.
+	Incr(context.Context, *SetRequest) (*httpbody.HttpBody, error)
+	// Decr is Incr subtracting the value of the request, 1 if it's empty.
+	Decr(context.Context, *SetRequest) (*httpbody.HttpBody, error)
+	// Expire sets the TTL of the key to the ex or px of the request, zero removes the TTL of the key.
+	Expire(context.Context, *SetRequest) (*httpbody.HttpBody, error)
+	// GetDel returns the value of the key and deletes the key atomically.
+	GetDel(context.Context, *GetRequest) (*httpbody.HttpBody, error)
+	// CompareAndSwap sets the value of the key to the value of the request only if the current value of the key is
+	// the expected value of the Tigris-Cache-Expected-Bin header, or if the key doesn't exist when the header isn't
+	// set. The previous value is returned whether the value is set or not.
+	CompareAndSwap(context.Context, *GetSetRequest) (*httpbody.HttpBody, error)
+	// CacheKeys streams the pages of the keys matching the pattern of the request, scanning the keyspace a page at a
+	// time, or the count estimate of the keys if the Tigris-Cache-Count-Estimate header is set.
+	CacheKeys(*KeysRequest, CacheOps_CacheKeysServer) error
+}
+
+func RegisterCacheOpsServer(s grpc.ServiceRegistrar, srv CacheOpsServer) {
+	s.RegisterService(&CacheOps_ServiceDesc, srv)
+}
+
+func _CacheOps_Incr_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheOpsServer).Incr(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IncrMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CacheOpsServer).Incr(ctx, req.(*SetRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheOps_Decr_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheOpsServer).Decr(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DecrMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CacheOpsServer).Decr(ctx, req.(*SetRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheOps_Expire_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheOpsServer).Expire(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpireMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CacheOpsServer).Expire(ctx, req.(*SetRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheOps_GetDel_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheOpsServer).GetDel(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetDelMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CacheOpsServer).GetDel(ctx, req.(*GetRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheOps_CompareAndSwap_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheOpsServer).CompareAndSwap(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompareAndSwapMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CacheOpsServer).CompareAndSwap(ctx, req.(*GetSetRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheOps_CacheKeys_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(KeysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return srv.(CacheOpsServer).CacheKeys(m, &cacheOpsCacheKeysServer{stream})
+}
+
+type CacheOps_CacheKeysServer interface {
+	Send(*httpbody.HttpBody) error
+	grpc.ServerStream
+}
+
+type cacheOpsCacheKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheOpsCacheKeysServer) Send(m *httpbody.HttpBody) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CacheOps_ServiceDesc is the grpc.ServiceDesc for the CacheOps service.
+var CacheOps_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: cacheOpsServiceName,
+	HandlerType: (*CacheOpsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Incr",
+			Handler:    _CacheOps_Incr_Handler,
+		},
+		{
+			MethodName: "Decr",
+			Handler:    _CacheOps_Decr_Handler,
+		},
+		{
+			MethodName: "Expire",
+			Handler:    _CacheOps_Expire_Handler,
+		},
+		{
+			MethodName: "GetDel",
+			Handler:    _CacheOps_GetDel_Handler,
+		},
+		{
+			MethodName: "CompareAndSwap",
+			Handler:    _CacheOps_CompareAndSwap_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CacheKeys",
+			Handler:       _CacheOps_CacheKeys_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/v1/cache_ops.go",
+}
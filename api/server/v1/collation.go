@@ -14,8 +14,32 @@
 
 package api
 
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
 const CollationKey string = "collation"
 
+// CollationOptionSeparator separates the options of a collation. A collation is a list of options, for example "ci",
+// "tr", "de@phonebook" or "ci,ai,tr". An option is either one of the SupportedCollations, one of the modifiers below
+// or a locale.
+const CollationOptionSeparator = ","
+
+const (
+	// AccentInsensitive ignores the diacritics while comparing the strings, i.e. "é" is equal to "e".
+	AccentInsensitive = "ai"
+	// NumericOrdering compares the sequences of digits by their numeric value, i.e. "file9" sorts before "file10".
+	NumericOrdering = "numeric"
+)
+
+// JSON attributes of the collation object that enable the modifiers.
+const (
+	AccentInsensitiveKey = "accent_insensitive"
+	NumericOrderingKey   = "numeric_ordering"
+)
+
 type CollationType uint8
 
 const (
@@ -31,20 +55,117 @@ var SupportedCollations = [...]string{
 	CollationSortKey: "csk",
 }
 
+// icuCollationTypes maps the ICU collation keywords to the BCP 47 collation types.
+var icuCollationTypes = map[string]string{
+	"big5han":     "big5han",
+	"dictionary":  "dict",
+	"ducet":       "ducet",
+	"emoji":       "emoji",
+	"eor":         "eor",
+	"gb2312":      "gb2312",
+	"phonebook":   "phonebk",
+	"phonetic":    "phonetic",
+	"pinyin":      "pinyin",
+	"reformed":    "reformed",
+	"search":      "search",
+	"searchjl":    "searchjl",
+	"standard":    "standard",
+	"stroke":      "stroke",
+	"traditional": "trad",
+	"unihan":      "unihan",
+	"zhuyin":      "zhuyin",
+}
+
+func (x *Collation) options() []string {
+	if len(x.Case) == 0 {
+		return nil
+	}
+
+	return strings.Split(x.Case, CollationOptionSeparator)
+}
+
+// CollationOptions are the options of a collation, each one parsed from the list of options of the collation.
+type CollationOptions struct {
+	Case CollationType
+	// Locale is empty if the collation is not locale-aware.
+	Locale            string
+	AccentInsensitive bool
+	NumericOrdering   bool
+}
+
+// Options parses the list of options of the collation. The collation is validated as part of the request validation,
+// so the options which are not valid are ignored.
+func (x *Collation) Options() CollationOptions {
+	var options CollationOptions
+	for _, o := range x.options() {
+		switch {
+		case o == AccentInsensitive:
+			options.AccentInsensitive = true
+		case o == NumericOrdering:
+			options.NumericOrdering = true
+		case ToCollationType(o) != Undefined:
+			if options.Case == Undefined {
+				options.Case = ToCollationType(o)
+			}
+		case len(options.Locale) == 0:
+			options.Locale = o
+		}
+	}
+
+	return options
+}
+
 func (x *Collation) IsCaseSensitive() bool {
-	return x.Case == SupportedCollations[CaseSensitive]
+	return x.Options().Case == CaseSensitive
 }
 
 func (x *Collation) IsCaseInsensitive() bool {
-	return x.Case == SupportedCollations[CaseInsensitive]
+	return x.Options().Case == CaseInsensitive
 }
 
 func (x *Collation) IsCollationSortKey() bool {
-	return x.Case == SupportedCollations[CollationSortKey]
+	return x.Options().Case == CollationSortKey
+}
+
+func (x *Collation) IsAccentInsensitive() bool {
+	return x.Options().AccentInsensitive
+}
+
+func (x *Collation) IsNumericOrdering() bool {
+	return x.Options().NumericOrdering
+}
+
+// GetLocale returns the locale of the collation or an empty string if the collation is not locale-aware.
+func (x *Collation) GetLocale() string {
+	return x.Options().Locale
 }
 
 func (x *Collation) IsValid() error {
-	if caseType := ToCollationType(x.Case); caseType == Undefined {
+	var caseFound, localeFound, modifierFound bool
+	for _, o := range x.options() {
+		if o == AccentInsensitive || o == NumericOrdering {
+			modifierFound = true
+			continue
+		}
+
+		if ToCollationType(o) != Undefined {
+			if caseFound {
+				return Errorf(Code_INVALID_ARGUMENT, "collation '%s' has more than one case option", x.Case)
+			}
+			caseFound = true
+			continue
+		}
+
+		if localeFound {
+			return Errorf(Code_INVALID_ARGUMENT, "collation '%s' has more than one locale", x.Case)
+		}
+		if _, err := ParseLocale(o); err != nil {
+			return Errorf(Code_INVALID_ARGUMENT, "collation '%s' is not supported", x.Case)
+		}
+		localeFound = true
+	}
+
+	if !caseFound && !localeFound && !modifierFound {
 		return Errorf(Code_INVALID_ARGUMENT, "collation '%s' is not supported", x.Case)
 	}
 
@@ -60,3 +181,20 @@ func ToCollationType(userCollation string) CollationType {
 
 	return Undefined
 }
+
+// ParseLocale parses the locale of a collation. Both the BCP 47 tags like "sv-u-co-trad" and the ICU locale ids like
+// "de@phonebook" or "de@collation=phonebook" are accepted.
+func ParseLocale(locale string) (language.Tag, error) {
+	base, keyword, found := strings.Cut(locale, "@")
+	if !found {
+		return language.Parse(locale)
+	}
+
+	keyword = strings.TrimPrefix(keyword, "collation=")
+	co, ok := icuCollationTypes[keyword]
+	if !ok {
+		return language.Und, Errorf(Code_INVALID_ARGUMENT, "collation type '%s' is not supported", keyword)
+	}
+
+	return language.Parse(base + "-u-co-" + co)
+}
@@ -0,0 +1,108 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The CollectionCopy service is declared by hand like the SearchIndexHealth service. It copies the schema and the
+// documents of the collection of the request to the collection of the Tigris-Copy-Target-Project,
+// Tigris-Copy-Target-Branch and Tigris-Copy-Target-Collection headers, the result is returned as a JSON encoded
+// CopyCollectionResponse in the HttpBody.
+
+const collectionCopyServiceName = "tigrisdata.v1.CollectionCopy"
+
+// CopyCollectionResponse is the result of a copy of a collection.
+type CopyCollectionResponse struct {
+	Project    string `json:"project"`
+	Branch     string `json:"branch"`
+	Collection string `json:"collection"`
+	// Documents is the number of the documents copied.
+	Documents int64 `json:"documents"`
+	// Indexes is true if the secondary indexes of the collection were copied with its schema.
+	Indexes bool `json:"indexes"`
+}
+
+// CollectionCopyClient is the client API for the CollectionCopy service.
+type CollectionCopyClient interface {
+	// CopyCollection copies the collection of the request to the target of the request headers.
+	CopyCollection(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type collectionCopyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollectionCopyClient(cc grpc.ClientConnInterface) CollectionCopyClient {
+	return &collectionCopyClient{cc}
+}
+
+func (c *collectionCopyClient) CopyCollection(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, CopyCollectionMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// CollectionCopyServer is the server API for the CollectionCopy service.
+type CollectionCopyServer interface {
+	// CopyCollection creates the target collection with the schema of the collection of the request and copies its
+	// documents, as of the start of the copy.
+	CopyCollection(context.Context, *DescribeCollectionRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterCollectionCopyServer(s grpc.ServiceRegistrar, srv CollectionCopyServer) {
+	s.RegisterService(&CollectionCopy_ServiceDesc, srv)
+}
+
+func _CollectionCopy_CopyCollection_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectionCopyServer).CopyCollection(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CopyCollectionMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(CollectionCopyServer).CopyCollection(ctx, req.(*DescribeCollectionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// CollectionCopy_ServiceDesc is the grpc.ServiceDesc for the CollectionCopy service.
+var CollectionCopy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: collectionCopyServiceName,
+	HandlerType: (*CollectionCopyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CopyCollection",
+			Handler:    _CollectionCopy_CopyCollection_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/collection_copy.go",
+}
@@ -0,0 +1,38 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// CollectionStorageStats is the storage used by a collection, returned by the DescribeCollection responses in the
+// Tigris-Collection-Stats header. The document count and the stored bytes are the counters maintained by the writes,
+// the on-disk sizes are the estimates of the storage and lag behind the recent writes.
+type CollectionStorageStats struct {
+	// Documents is the estimated number of the documents of the collection.
+	Documents int64 `json:"documents"`
+	// StoredBytes is the size of the documents as written, before the compression of the storage.
+	StoredBytes int64 `json:"stored_bytes"`
+	// OnDiskSize is the estimated size of the documents on disk.
+	OnDiskSize int64 `json:"on_disk_size"`
+	// AverageDocumentSize is the stored bytes per document.
+	AverageDocumentSize int64 `json:"average_document_size"`
+	// IndexesSize is the estimated size on disk of all the secondary indexes of the collection.
+	IndexesSize int64                `json:"indexes_size"`
+	Indexes     []*IndexStorageStats `json:"indexes"`
+}
+
+// IndexStorageStats is the estimated size on disk of a secondary index.
+type IndexStorageStats struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
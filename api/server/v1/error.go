@@ -17,6 +17,7 @@ package api
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang/protobuf/proto" //nolint:staticcheck
@@ -114,6 +115,199 @@ func (e *TigrisError) RetryDelay() time.Duration {
 	return dur
 }
 
+// ConflictDomain is the domain of the errdetails.ErrorInfo details which describe the key ranges a transaction
+// conflicted on.
+const ConflictDomain = "conflict.tigrisdata.com"
+
+// ConflictInfo describes a key range a transaction conflicted on. Collection and Key are the collection and the
+// document key, as a JSON array of the key fields, the range belongs to if it is the key of a document. Begin and End
+// are the printable FoundationDB keys of the range.
+type ConflictInfo struct {
+	Operation  string `json:"operation,omitempty"`
+	Collection string `json:"collection,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Begin      string `json:"begin,omitempty"`
+	End        string `json:"end,omitempty"`
+}
+
+// NewConflictInfo returns the error detail of a conflicting key range.
+func NewConflictInfo(c *ConflictInfo) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: CodeToString(Code_ABORTED),
+		Domain: ConflictDomain,
+		Metadata: map[string]string{
+			"operation":  c.Operation,
+			"collection": c.Collection,
+			"key":        c.Key,
+			"begin":      c.Begin,
+			"end":        c.End,
+		},
+	}
+}
+
+func conflictInfoFromDetail(ei *errdetails.ErrorInfo) *ConflictInfo {
+	return &ConflictInfo{
+		Operation:  ei.Metadata["operation"],
+		Collection: ei.Metadata["collection"],
+		Key:        ei.Metadata["key"],
+		Begin:      ei.Metadata["begin"],
+		End:        ei.Metadata["end"],
+	}
+}
+
+// Conflicts returns the key ranges the transaction conflicted on, if they are attached to the error.
+func (e *TigrisError) Conflicts() []*ConflictInfo {
+	var conflicts []*ConflictInfo
+	for _, d := range e.Details {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok && ei.Domain == ConflictDomain {
+			conflicts = append(conflicts, conflictInfoFromDetail(ei))
+		}
+	}
+
+	return conflicts
+}
+
+// ThrottleDomain is the domain of the errdetails.ErrorInfo details which describe the quota a request was throttled by.
+const ThrottleDomain = "throttle.tigrisdata.com"
+
+// ThrottleInfo describes the quota a request was throttled by. Scope is the entity the quota applies to, for example
+// "collection", Resource is the limited resource, like "read_units", and Limit is its per second limit, or the maximum
+// number of the concurrent requests or the open streams for the concurrency limits.
+type ThrottleInfo struct {
+	Scope      string `json:"scope,omitempty"`
+	Project    string `json:"project,omitempty"`
+	Collection string `json:"collection,omitempty"`
+	Resource   string `json:"resource,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// NewThrottleInfo returns the error detail of the quota a request was throttled by.
+func NewThrottleInfo(t *ThrottleInfo) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: CodeToString(Code_RESOURCE_EXHAUSTED),
+		Domain: ThrottleDomain,
+		Metadata: map[string]string{
+			"scope":      t.Scope,
+			"project":    t.Project,
+			"collection": t.Collection,
+			"resource":   t.Resource,
+			"limit":      strconv.Itoa(t.Limit),
+		},
+	}
+}
+
+func throttleInfoFromDetail(ei *errdetails.ErrorInfo) *ThrottleInfo {
+	limit, _ := strconv.Atoi(ei.Metadata["limit"])
+
+	return &ThrottleInfo{
+		Scope:      ei.Metadata["scope"],
+		Project:    ei.Metadata["project"],
+		Collection: ei.Metadata["collection"],
+		Resource:   ei.Metadata["resource"],
+		Limit:      limit,
+	}
+}
+
+// Throttle returns the quota the request was throttled by, if it is attached to the error.
+func (e *TigrisError) Throttle() *ThrottleInfo {
+	for _, d := range e.Details {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok && ei.Domain == ThrottleDomain {
+			return throttleInfoFromDetail(ei)
+		}
+	}
+
+	return nil
+}
+
+// UniqueViolationDomain is the domain of the errdetails.ErrorInfo details which describe the violation of a unique
+// index.
+const UniqueViolationDomain = "unique.tigrisdata.com"
+
+// UniqueViolationInfo describes the violation of a unique index. Value is the duplicate value, the values of the fields
+// separated by commas for a composite index, and Key is the key of the document the value already exists in, as a JSON
+// array of the key fields.
+type UniqueViolationInfo struct {
+	Collection string `json:"collection,omitempty"`
+	Index      string `json:"index,omitempty"`
+	Value      string `json:"value,omitempty"`
+	Key        string `json:"key,omitempty"`
+}
+
+// NewUniqueViolationInfo returns the error detail of the violation of a unique index.
+func NewUniqueViolationInfo(u *UniqueViolationInfo) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: CodeToString(Code_ALREADY_EXISTS),
+		Domain: UniqueViolationDomain,
+		Metadata: map[string]string{
+			"collection": u.Collection,
+			"index":      u.Index,
+			"value":      u.Value,
+			"key":        u.Key,
+		},
+	}
+}
+
+func uniqueViolationInfoFromDetail(ei *errdetails.ErrorInfo) *UniqueViolationInfo {
+	return &UniqueViolationInfo{
+		Collection: ei.Metadata["collection"],
+		Index:      ei.Metadata["index"],
+		Value:      ei.Metadata["value"],
+		Key:        ei.Metadata["key"],
+	}
+}
+
+// UniqueViolation returns the violation of the unique index, if it is attached to the error.
+func (e *TigrisError) UniqueViolation() *UniqueViolationInfo {
+	for _, d := range e.Details {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok && ei.Domain == UniqueViolationDomain {
+			return uniqueViolationInfoFromDetail(ei)
+		}
+	}
+
+	return nil
+}
+
+// NetworkDeniedDomain is the domain of the errdetails.ErrorInfo details which describe the network allowlist a
+// request was rejected by.
+const NetworkDeniedDomain = "network.tigrisdata.com"
+
+// NetworkDeniedInfo describes the network allowlist a request was rejected by. Scope is "namespace" or "key", Address
+// is the client address the request was made from.
+type NetworkDeniedInfo struct {
+	Scope   string `json:"scope,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// NewNetworkDeniedInfo returns the error detail of the network allowlist a request was rejected by.
+func NewNetworkDeniedInfo(n *NetworkDeniedInfo) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: CodeToString(Code_PERMISSION_DENIED),
+		Domain: NetworkDeniedDomain,
+		Metadata: map[string]string{
+			"scope":   n.Scope,
+			"address": n.Address,
+		},
+	}
+}
+
+func networkDeniedInfoFromDetail(ei *errdetails.ErrorInfo) *NetworkDeniedInfo {
+	return &NetworkDeniedInfo{
+		Scope:   ei.Metadata["scope"],
+		Address: ei.Metadata["address"],
+	}
+}
+
+// NetworkDenied returns the network allowlist the request was rejected by, if it is attached to the error.
+func (e *TigrisError) NetworkDenied() *NetworkDeniedInfo {
+	for _, d := range e.Details {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok && ei.Domain == NetworkDeniedDomain {
+			return networkDeniedInfoFromDetail(ei)
+		}
+	}
+
+	return nil
+}
+
 // ToGRPCCode converts Tigris error code to GRPC code
 // Extended codes converted to 'Unknown' GRPC code.
 func ToGRPCCode(code Code) codes.Code {
@@ -244,7 +438,17 @@ func ToAPIError(err error) *Error {
 // MarshalStatus marshal status object.
 func MarshalStatus(status *spb.Status) ([]byte, error) {
 	resp := struct {
-		Error ErrorDetails `json:"error"`
+		Error struct {
+			ErrorDetails
+			// Conflicts are the key ranges a transaction conflicted on, see ConflictInfo.
+			Conflicts []*ConflictInfo `json:"conflicts,omitempty"`
+			// Throttle is the quota the request was throttled by, see ThrottleInfo.
+			Throttle *ThrottleInfo `json:"throttle,omitempty"`
+			// Unique is the violated unique index, see UniqueViolationInfo.
+			Unique *UniqueViolationInfo `json:"unique,omitempty"`
+			// Network is the network allowlist the request was rejected by, see NetworkDeniedInfo.
+			Network *NetworkDeniedInfo `json:"network,omitempty"`
+		} `json:"error"`
 	}{}
 
 	resp.Error.Message = status.Message
@@ -260,6 +464,18 @@ func MarshalStatus(status *spb.Status) ([]byte, error) {
 				return nil, err
 			}
 			resp.Error.Code = ei.Reason
+			if ei.Domain == ConflictDomain {
+				resp.Error.Conflicts = append(resp.Error.Conflicts, conflictInfoFromDetail(&ei))
+			}
+			if ei.Domain == ThrottleDomain {
+				resp.Error.Throttle = throttleInfoFromDetail(&ei)
+			}
+			if ei.Domain == UniqueViolationDomain {
+				resp.Error.Unique = uniqueViolationInfoFromDetail(&ei)
+			}
+			if ei.Domain == NetworkDeniedDomain {
+				resp.Error.Network = networkDeniedInfoFromDetail(&ei)
+			}
 		}
 		var ri errdetails.RetryInfo
 		if d.MessageIs(&ri) {
@@ -321,6 +537,10 @@ func FromStatusError(err error) *TigrisError {
 		switch d := v.(type) {
 		case *errdetails.ErrorInfo:
 			code = CodeFromString(d.Reason)
+			if d.Domain == ConflictDomain || d.Domain == ThrottleDomain || d.Domain == UniqueViolationDomain ||
+				d.Domain == NetworkDeniedDomain {
+				details = append(details, d)
+			}
 		case *errdetails.RetryInfo:
 			details = append(details, &errdetails.RetryInfo{RetryDelay: d.RetryDelay})
 		}
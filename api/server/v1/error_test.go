@@ -85,3 +85,69 @@ func TestTigrisError(t *testing.T) {
 
 	require.Equal(t, (*TigrisError)(nil), Errorf(Code_OK, "err msg"))
 }
+
+func TestConflictError(t *testing.T) {
+	conflict := &ConflictInfo{Operation: "Update", Collection: "orders", Key: "[1]", Begin: "b", End: "b\\x00"}
+	err := Errorf(Code_ABORTED, "conflict").WithDetails(NewConflictInfo(conflict))
+	require.Equal(t, []*ConflictInfo{conflict}, err.Conflicts())
+
+	st, err1 := MarshalStatus(err.GRPCStatus().Proto())
+	require.NoError(t, err1)
+	require.JSONEq(t, `{"error":{"code":"ABORTED","message":"conflict","conflicts":[
+		{"operation":"Update","collection":"orders","key":"[1]","begin":"b","end":"b\\x00"}
+	]}}`, string(st))
+
+	te := FromStatusError(err.GRPCStatus().Err())
+	require.Equal(t, Code_ABORTED, te.Code)
+	require.Equal(t, []*ConflictInfo{conflict}, te.Conflicts())
+}
+
+func TestThrottleError(t *testing.T) {
+	throttle := &ThrottleInfo{Scope: "collection", Project: "p1", Collection: "orders", Resource: "write_units", Limit: 10}
+	err := Errorf(Code_RESOURCE_EXHAUSTED, "throttled").WithRetry(150 * time.Millisecond).
+		WithDetails(NewThrottleInfo(throttle))
+	require.Equal(t, throttle, err.Throttle())
+
+	st, err1 := MarshalStatus(err.GRPCStatus().Proto())
+	require.NoError(t, err1)
+	require.JSONEq(t, `{"error":{"code":"RESOURCE_EXHAUSTED","message":"throttled","retry":{"delay":150},"throttle":
+		{"scope":"collection","project":"p1","collection":"orders","resource":"write_units","limit":10}
+	}}`, string(st))
+
+	te := FromStatusError(err.GRPCStatus().Err())
+	require.Equal(t, Code_RESOURCE_EXHAUSTED, te.Code)
+	require.Equal(t, throttle, te.Throttle())
+	require.Equal(t, 150*time.Millisecond, te.RetryDelay())
+}
+
+func TestUniqueViolationError(t *testing.T) {
+	violation := &UniqueViolationInfo{Collection: "users", Index: "email", Value: "a@b.c", Key: "[1]"}
+	err := Errorf(Code_ALREADY_EXISTS, "duplicate").WithDetails(NewUniqueViolationInfo(violation))
+	require.Equal(t, violation, err.UniqueViolation())
+
+	st, err1 := MarshalStatus(err.GRPCStatus().Proto())
+	require.NoError(t, err1)
+	require.JSONEq(t, `{"error":{"code":"ALREADY_EXISTS","message":"duplicate","unique":
+		{"collection":"users","index":"email","value":"a@b.c","key":"[1]"}
+	}}`, string(st))
+
+	te := FromStatusError(err.GRPCStatus().Err())
+	require.Equal(t, Code_ALREADY_EXISTS, te.Code)
+	require.Equal(t, violation, te.UniqueViolation())
+}
+
+func TestNetworkDeniedError(t *testing.T) {
+	denied := &NetworkDeniedInfo{Scope: "key", Address: "10.0.0.1"}
+	err := Errorf(Code_PERMISSION_DENIED, "denied").WithDetails(NewNetworkDeniedInfo(denied))
+	require.Equal(t, denied, err.NetworkDenied())
+
+	st, err1 := MarshalStatus(err.GRPCStatus().Proto())
+	require.NoError(t, err1)
+	require.JSONEq(t, `{"error":{"code":"PERMISSION_DENIED","message":"denied","network":
+		{"scope":"key","address":"10.0.0.1"}
+	}}`, string(st))
+
+	te := FromStatusError(err.GRPCStatus().Err())
+	require.Equal(t, Code_PERMISSION_DENIED, te.Code)
+	require.Equal(t, denied, te.NetworkDenied())
+}
@@ -0,0 +1,168 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The EventStream service is declared by hand like the MultiWrite service. An event stream is an append-only sequence
+// of events of a database branch, named by its stream key in the Collection of the requests. AppendStream takes an
+// InsertRequest whose documents are the events, ReadStream a ReadRequest whose options limit the events returned and
+// carry the position to read after in the offset. The responses are the JSON encoded AppendStreamResponse and
+// ReadStreamResponse in the HttpBody.
+
+const eventStreamServiceName = "tigrisdata.v1.EventStream"
+
+// StreamEvent is an event of an event stream.
+type StreamEvent struct {
+	// Version is the number of the event in its stream, the first event of a stream is at version 1 and every event
+	// is numbered after the previous one.
+	Version int64 `json:"version"`
+	// Position orders the events of all the streams by their commit, it is the versionstamp of the commit followed by
+	// the version of the event, hex encoded. The events committed later have greater positions.
+	Position   string              `json:"position"`
+	Data       jsoniter.RawMessage `json:"data"`
+	AppendedAt time.Time           `json:"appended_at"`
+}
+
+// AppendStreamResponse is the result of an append to an event stream.
+type AppendStreamResponse struct {
+	Status string `json:"status"`
+	Stream string `json:"stream"`
+	// FirstVersion and Version are the versions of the first and of the last events appended, Version is the version
+	// of the stream after the append.
+	FirstVersion int64 `json:"first_version"`
+	Version      int64 `json:"version"`
+}
+
+// ReadStreamResponse is a page of the events of a stream, in their order in the stream.
+type ReadStreamResponse struct {
+	Stream string `json:"stream"`
+	// Version is the version of the stream, the version of its last event.
+	Version int64          `json:"version"`
+	Events  []*StreamEvent `json:"events"`
+}
+
+// EventStreamClient is the client API for the EventStream service.
+type EventStreamClient interface {
+	// AppendStream appends the events to the stream, after checking the version of the stream expected by the
+	// Tigris-Expected-Version header, if any.
+	AppendStream(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// ReadStream returns the events of the stream after the position of the offset.
+	ReadStream(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type eventStreamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventStreamClient(cc grpc.ClientConnInterface) EventStreamClient {
+	return &eventStreamClient{cc}
+}
+
+func (c *eventStreamClient) AppendStream(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, AppendStreamMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *eventStreamClient) ReadStream(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, ReadStreamMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// EventStreamServer is the server API for the EventStream service.
+type EventStreamServer interface {
+	// AppendStream appends the events to the stream in the transaction of the request, the events of the stream are
+	// numbered in the order of their commits.
+	AppendStream(context.Context, *InsertRequest) (*httpbody.HttpBody, error)
+	// ReadStream returns the events of the stream in their order.
+	ReadStream(context.Context, *ReadRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterEventStreamServer(s grpc.ServiceRegistrar, srv EventStreamServer) {
+	s.RegisterService(&EventStream_ServiceDesc, srv)
+}
+
+func _EventStream_AppendStream_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(InsertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventStreamServer).AppendStream(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppendStreamMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(EventStreamServer).AppendStream(ctx, req.(*InsertRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventStream_ReadStream_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventStreamServer).ReadStream(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReadStreamMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(EventStreamServer).ReadStream(ctx, req.(*ReadRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// EventStream_ServiceDesc is the grpc.ServiceDesc for the EventStream service.
+var EventStream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: eventStreamServiceName,
+	HandlerType: (*EventStreamServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AppendStream",
+			Handler:    _EventStream_AppendStream_Handler,
+		},
+		{
+			MethodName: "ReadStream",
+			Handler:    _EventStream_ReadStream_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/event_stream.go",
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The Export service is declared by hand like the Ingest service. The export takes a ReadRequest and streams the
+// exported file in chunks of HttpBody, which the HTTP gateway passes through as the raw response body.
+
+const exportServiceName = "tigrisdata.v1.Export"
+
+// Export formats, selected by the Tigris-Export-Format header.
+const (
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// ExportClient is the client API for the Export service.
+type ExportClient interface {
+	// Export streams the documents matching the read request as a NDJSON or Parquet file.
+	Export(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Export_ExportClient, error)
+}
+
+type exportClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExportClient(cc grpc.ClientConnInterface) ExportClient {
+	return &exportClient{cc}
+}
+
+func (c *exportClient) Export(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (Export_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Export_ServiceDesc.Streams[0], ExportMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &exportExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+type Export_ExportClient interface {
+	Recv() (*httpbody.HttpBody, error)
+	grpc.ClientStream
+}
+
+type exportExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *exportExportClient) Recv() (*httpbody.HttpBody, error) {
+	m := new(httpbody.HttpBody)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ExportServer is the server API for the Export service.
+type ExportServer interface {
+	// Export streams the documents matching the read request, read from a consistent snapshot of the collection.
+	Export(*ReadRequest, Export_ExportServer) error
+}
+
+func RegisterExportServer(s grpc.ServiceRegistrar, srv ExportServer) {
+	s.RegisterService(&Export_ServiceDesc, srv)
+}
+
+func _Export_Export_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return srv.(ExportServer).Export(m, &exportExportServer{stream})
+}
+
+type Export_ExportServer interface {
+	Send(*httpbody.HttpBody) error
+	grpc.ServerStream
+}
+
+type exportExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *exportExportServer) Send(m *httpbody.HttpBody) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Export_ServiceDesc is the grpc.ServiceDesc for the Export service.
+var Export_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: exportServiceName,
+	HandlerType: (*ExportServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Export",
+			Handler:       _Export_Export_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/v1/export.go",
+}
@@ -0,0 +1,73 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The unknown field of the range bucket of the facet counts, see getUnknownJSON.
+const facetCountBucketField protowire.Number = 1000
+
+// FacetBucket is the bucket of a count of a range facet, the count is the number of the documents with the value of
+// the field in the range of the bucket and the stats are of the values in the bucket.
+type FacetBucket struct {
+	From  jsoniter.RawMessage `json:"from,omitempty"`
+	To    jsoniter.RawMessage `json:"to,omitempty"`
+	Stats *FacetStats         `json:"stats,omitempty"`
+}
+
+// GetBucket returns the range bucket of the count, nil for the counts of the distinct values of a field.
+func (x *FacetCount) GetBucket() *FacetBucket {
+	var b *FacetBucket
+	if x == nil || !getUnknownJSON(x, facetCountBucketField, &b) {
+		return nil
+	}
+
+	return b
+}
+
+// SetBucket sets the range bucket of the count, nil removes it.
+func (x *FacetCount) SetBucket(b *FacetBucket) {
+	if b == nil {
+		setUnknownJSON(x, facetCountBucketField, nil)
+		return
+	}
+
+	setUnknownJSON(x, facetCountBucketField, b)
+}
+
+// MarshalJSON returns the count of a range bucket with the bounds and the stats of the bucket, the label of the bucket
+// is the value of the count.
+func (x *FacetCount) MarshalJSON() ([]byte, error) {
+	resp := struct {
+		Count int64               `json:"count"`
+		Value string              `json:"value,omitempty"`
+		From  jsoniter.RawMessage `json:"from,omitempty"`
+		To    jsoniter.RawMessage `json:"to,omitempty"`
+		Stats *FacetStats         `json:"stats,omitempty"`
+	}{
+		Count: x.Count,
+		Value: x.Value,
+	}
+	if b := x.GetBucket(); b != nil {
+		resp.From = b.From
+		resp.To = b.To
+		resp.Stats = b.Stats
+	}
+
+	return jsoniter.Marshal(resp)
+}
@@ -0,0 +1,197 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// FieldMask selects the fields of the documents returned in a response. Every key is a document field, a nil value
+// selects the whole field and a non-nil value selects fields of the nested object, or of the objects of a nested
+// array.
+type FieldMask map[string]FieldMask
+
+// ParseFieldMask parses a comma separated list of dotted field paths, for example "id,name,address.city". An empty
+// string returns a nil mask, which doesn't trim anything.
+func ParseFieldMask(s string) (FieldMask, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	mask := FieldMask{}
+	for _, path := range strings.Split(s, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		m := mask
+		keys := strings.Split(path, ".")
+		for i, key := range keys {
+			if key == "" {
+				return nil, Errorf(Code_INVALID_ARGUMENT, "invalid field mask path '%s'", path)
+			}
+
+			if i == len(keys)-1 {
+				// the whole field is selected, which includes any nested path selected before
+				m[key] = nil
+				break
+			}
+
+			sub, ok := m[key]
+			if ok && sub == nil {
+				// the whole field is already selected
+				break
+			}
+			if !ok {
+				sub = FieldMask{}
+				m[key] = sub
+			}
+			m = sub
+		}
+	}
+
+	if len(mask) == 0 {
+		return nil, nil
+	}
+
+	return mask, nil
+}
+
+// Apply returns the document with only the fields selected by the mask. The order of the fields is preserved.
+func (m FieldMask) Apply(doc []byte) ([]byte, error) {
+	if m == nil || len(doc) == 0 {
+		return doc, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(doc))
+	if err := m.apply(&buf, doc); err != nil {
+		return nil, Errorf(Code_INTERNAL, "failed to apply the field mask: %s", err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (m FieldMask) apply(buf *bytes.Buffer, doc []byte) error {
+	buf.WriteByte('{')
+	first := true
+	err := jsonparser.ObjectEach(doc, func(key []byte, value []byte, dataType jsonparser.ValueType, _ int) error {
+		sub, ok := m[string(key)]
+		if !ok {
+			return nil
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		k, err := jsoniter.Marshal(string(key))
+		if err != nil {
+			return err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+
+		return writeMasked(buf, sub, value, dataType)
+	})
+	buf.WriteByte('}')
+
+	return err
+}
+
+func writeMasked(buf *bytes.Buffer, m FieldMask, value []byte, dataType jsonparser.ValueType) error {
+	switch {
+	case m != nil && dataType == jsonparser.Object:
+		return m.apply(buf, value)
+	case m != nil && dataType == jsonparser.Array:
+		var err error
+		first := true
+		buf.WriteByte('[')
+		_, arrErr := jsonparser.ArrayEach(value, func(v []byte, dt jsonparser.ValueType, _ int, _ error) {
+			if err != nil {
+				return
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			err = writeMasked(buf, m, v, dt)
+		})
+		buf.WriteByte(']')
+		if err != nil {
+			return err
+		}
+		return arrErr
+	case dataType == jsonparser.String:
+		// jsonparser returns the string content without the quotes
+		buf.WriteByte('"')
+		buf.Write(value)
+		buf.WriteByte('"')
+	default:
+		buf.Write(value)
+	}
+
+	return nil
+}
+
+// ApplyFieldMask trims the documents of a response with the field mask. Responses that don't carry documents are
+// left as they are.
+func ApplyFieldMask(resp any, mask FieldMask) error {
+	if mask == nil {
+		return nil
+	}
+
+	var err error
+	switch r := resp.(type) {
+	case *ReadResponse:
+		r.Data, err = mask.Apply(r.Data)
+	case *SearchResponse:
+		err = applyFieldMaskToHits(mask, r.Hits, r.Group)
+	case *SearchIndexResponse:
+		err = applyFieldMaskToHits(mask, r.Hits, r.Group)
+	case *GetDocumentResponse:
+		err = applyFieldMaskToHits(mask, r.Documents, nil)
+	}
+
+	return err
+}
+
+func applyFieldMaskToHits(mask FieldMask, hits []*SearchHit, groups []*GroupedSearchHits) error {
+	for _, h := range hits {
+		if h == nil {
+			continue
+		}
+
+		data, err := mask.Apply(h.Data)
+		if err != nil {
+			return err
+		}
+		h.Data = data
+	}
+
+	for _, g := range groups {
+		if err := applyFieldMaskToHits(mask, g.Hits, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldMask(t *testing.T) {
+	mask, err := ParseFieldMask(" id, address.city,address.zip ,tags.name,meta,meta.created")
+	require.NoError(t, err)
+	require.Equal(t, FieldMask{
+		"id":      nil,
+		"address": FieldMask{"city": nil, "zip": nil},
+		"tags":    FieldMask{"name": nil},
+		"meta":    nil,
+	}, mask)
+
+	mask, err = ParseFieldMask("")
+	require.NoError(t, err)
+	require.Nil(t, mask)
+
+	_, err = ParseFieldMask("address..city")
+	require.Equal(t, Errorf(Code_INVALID_ARGUMENT, "invalid field mask path 'address..city'"), err)
+}
+
+func TestFieldMaskApply(t *testing.T) {
+	mask, err := ParseFieldMask("id,name,address.city,tags.name,missing")
+	require.NoError(t, err)
+
+	doc, err := mask.Apply([]byte(`{"id":1,"name":"a \"quoted\" é","age":3,"address":{"street":"x","city":"Paris"},"tags":[{"name":"t1","v":1},{"v":2},"s"],"extra":{"id":2}}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"id":1,"name":"a \"quoted\" é","address":{"city":"Paris"},"tags":[{"name":"t1"},{},"s"]}`, string(doc))
+
+	doc, err = FieldMask(nil).Apply([]byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"id":1}`, string(doc))
+}
+
+func TestApplyFieldMask(t *testing.T) {
+	mask := FieldMask{"id": nil}
+
+	read := &ReadResponse{Data: []byte(`{"id":1,"name":"a"}`)}
+	require.NoError(t, ApplyFieldMask(read, mask))
+	require.Equal(t, `{"id":1}`, string(read.Data))
+
+	search := &SearchResponse{
+		Hits:  []*SearchHit{{Data: []byte(`{"id":1,"name":"a"}`)}},
+		Group: []*GroupedSearchHits{{Hits: []*SearchHit{{Data: []byte(`{"name":"b","id":2}`)}}}},
+	}
+	require.NoError(t, ApplyFieldMask(search, mask))
+	require.Equal(t, `{"id":1}`, string(search.Hits[0].Data))
+	require.Equal(t, `{"id":2}`, string(search.Group[0].Hits[0].Data))
+
+	insert := &InsertResponse{Status: "inserted"}
+	require.NoError(t, ApplyFieldMask(insert, mask))
+	require.Equal(t, "inserted", insert.Status)
+}
@@ -0,0 +1,41 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// The unknown field of the geo distances of the hits, see getUnknownJSON.
+const searchHitMetaGeoDistanceField protowire.Number = 1002
+
+// GetGeoDistance returns the distance in meters of the geopoint fields of the hit from the point of the geo distance
+// sort, keyed by the field name. It is empty if the search request doesn't sort on the geo distance.
+func (x *SearchHitMeta) GetGeoDistance() map[string]int64 {
+	var distance map[string]int64
+	if x == nil || !getUnknownJSON(x, searchHitMetaGeoDistanceField, &distance) {
+		return nil
+	}
+
+	return distance
+}
+
+// SetGeoDistance sets the geo distances of the hit, empty removes them.
+func (x *SearchHitMeta) SetGeoDistance(distance map[string]int64) {
+	if len(distance) == 0 {
+		setUnknownJSON(x, searchHitMetaGeoDistanceField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchHitMetaGeoDistanceField, distance)
+}
@@ -0,0 +1,63 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// The unknown fields of the group limit option of the search requests, see getUnknownJSON.
+const (
+	searchRequestGroupLimitField      protowire.Number = 1002
+	searchIndexRequestGroupLimitField protowire.Number = 1002
+)
+
+// GetGroupLimit returns the maximum number of the hits returned per group of a group by search, zero if not set.
+func (x *SearchRequest) GetGroupLimit() int32 {
+	var limit int32
+	if x == nil || !getUnknownJSON(x, searchRequestGroupLimitField, &limit) {
+		return 0
+	}
+
+	return limit
+}
+
+// SetGroupLimit sets the maximum number of the hits returned per group, zero removes it.
+func (x *SearchRequest) SetGroupLimit(limit int32) {
+	if limit == 0 {
+		setUnknownJSON(x, searchRequestGroupLimitField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestGroupLimitField, limit)
+}
+
+// GetGroupLimit returns the maximum number of the hits returned per group of a group by search, zero if not set.
+func (x *SearchIndexRequest) GetGroupLimit() int32 {
+	var limit int32
+	if x == nil || !getUnknownJSON(x, searchIndexRequestGroupLimitField, &limit) {
+		return 0
+	}
+
+	return limit
+}
+
+// SetGroupLimit sets the maximum number of the hits returned per group, zero removes it.
+func (x *SearchIndexRequest) SetGroupLimit(limit int32) {
+	if limit == 0 {
+		setUnknownJSON(x, searchIndexRequestGroupLimitField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchIndexRequestGroupLimitField, limit)
+}
@@ -37,6 +37,11 @@ const (
 	SetCookie = "Set-Cookie"
 	Cookie    = "Cookie"
 
+	// HeaderTraceparent and HeaderTracestate are the W3C trace context headers, the spans of a request carrying them
+	// are part of the trace of the caller.
+	HeaderTraceparent = "Traceparent"
+	HeaderTracestate  = "Tracestate"
+
 	HeaderPrefix = "Tigris-"
 
 	HeaderTxID                      = "Tigris-Tx-Id"
@@ -45,12 +50,194 @@ const (
 	HeaderSchemaSignOff             = "Tigris-Schema-Sign-Off"
 	HeaderBypassAuthCache           = "Tigris-Bypass-Auth-Cache" // #nosec G101
 	HeaderReadSearchDataFromStorage = "Tigris-Search-Read-From-Storage"
+	// HeaderSchemaVersion pins the request to a specific collection schema version. DescribeCollection returns the
+	// schema as it was at this version and Read only returns documents written under this version.
+	HeaderSchemaVersion = "Tigris-Schema-Version"
+	// HeaderFields is a comma separated list of the document fields to return, see ParseFieldMask. The documents of
+	// the response are trimmed to these fields before they are marshaled.
+	HeaderFields = "Tigris-Fields"
+	// HeaderImportPrefix prefixes the options of ImportDocuments, for ex, Tigris-Import-Format.
+	HeaderImportPrefix = "Tigris-Import-"
+	// HeaderImportResumeToken returns the token which resumes an interrupted import, it is sent back with the same
+	// header to resume.
+	HeaderImportResumeToken = HeaderImportPrefix + "Resume-Token"
+	// HeaderImportImported returns the number of the records imported by the request.
+	HeaderImportImported = HeaderImportPrefix + "Imported"
+	// HeaderExportFormat selects the format of an export, ndjson (default) or parquet.
+	HeaderExportFormat = "Tigris-Export-Format"
+	// HeaderExportSnapshot set to false lets a long export read from consecutive snapshots instead of failing
+	// when it outlives the transaction time limit.
+	HeaderExportSnapshot = "Tigris-Export-Snapshot"
+	// HeaderReadStaleness is the read preference of the reads outside of transactions, the number of milliseconds
+	// the snapshot read may lag behind the latest commit. Zero, the default, reads the latest commit.
+	HeaderReadStaleness = "Tigris-Read-Staleness-Ms"
+	// HeaderReadSession pins the Read and Count requests outside of transactions to the same snapshot. A request with
+	// the "new" value reads the latest snapshot and returns the session token in the same header, the requests with
+	// the token read the same snapshot until the session expires.
+	HeaderReadSession = "Tigris-Read-Session"
+	// HeaderReadReplica is the address of a read-only replica returned with the reads eligible to be served by the
+	// replicas, the clients can send the next reads tolerating the same staleness to it.
+	HeaderReadReplica = "Tigris-Read-Replica"
+	// HeaderAllowPartialResults set to "true" makes the Read and Search requests stop shortly before their deadline and
+	// succeed with the results found so far, instead of failing with the deadline exceeded error.
+	HeaderAllowPartialResults = "Tigris-Allow-Partial-Results"
+	// HeaderPartialResults is the trailer set to "true" when a read allowing partial results was stopped by the
+	// deadline before it read all the results.
+	HeaderPartialResults = "Tigris-Partial-Results"
+	// HeaderSavepoint is the name of the savepoint of the Savepoint and RollbackToSavepoint requests.
+	HeaderSavepoint = "Tigris-Savepoint"
+	// HeaderIfRevision makes the writes fail with the precondition error if the document being replaced, updated or
+	// deleted is not at this revision. Zero means that the document must not exist or predates the revisions.
+	HeaderIfRevision = "Tigris-If-Revision"
+	// HeaderExpectedVersion makes the appends to an event stream fail with the precondition error if the stream is not
+	// at this version. Zero means that the stream must have no events.
+	HeaderExpectedVersion = "Tigris-Expected-Version"
+	// HeaderRevision returns the revision of the document written by a single document write.
+	HeaderRevision = "Tigris-Revision"
+	// HeaderIncludeRevision set to true returns the revision of the documents inside the body of the read responses.
+	HeaderIncludeRevision = "Tigris-Include-Revision"
+	// HeaderIncludeQueryStats set to true returns the statistics of the execution of the Read and Search requests in
+	// the Tigris-Query-Stats trailer.
+	HeaderIncludeQueryStats = "Tigris-Include-Query-Stats"
+	// HeaderQueryStats returns the statistics of the execution of a read or a search, as a JSON encoded QueryStats.
+	HeaderQueryStats = "Tigris-Query-Stats"
+	// HeaderIndexUsage returns the usage statistics of the secondary indexes in the DescribeCollection responses, as
+	// a JSON encoded array of IndexUsageStats.
+	HeaderIndexUsage = "Tigris-Index-Usage"
+	// HeaderCollectionStats returns the storage used by the collection and its secondary indexes in the
+	// DescribeCollection responses, as a JSON encoded CollectionStorageStats.
+	HeaderCollectionStats = "Tigris-Collection-Stats"
+	// HeaderIndexUnusedFor is the duration, for example 24h, after which an index that is not read is reported as
+	// unused by the IndexUsageStats requests. By default only the indexes that were never read are unused.
+	HeaderIndexUnusedFor = "Tigris-Index-Unused-For"
+	// HeaderIndexRepair set to true repairs the inconsistent entries found by the CheckIndexConsistency requests.
+	HeaderIndexRepair = "Tigris-Index-Repair"
+	// HeaderSearchConsistency is the search consistency of a write, SearchConsistencyStrong or
+	// SearchConsistencyEventual. It overrides the search consistency of the collection and of the server.
+	HeaderSearchConsistency = "Tigris-Search-Consistency"
+	// HeaderDiffBase is the branch the DiffBranches requests compare the branch of the request with, the main branch
+	// by default.
+	HeaderDiffBase = "Tigris-Diff-Base"
+	// HeaderDiffSample is the number of the changed documents of each collection returned by the DiffBranches
+	// requests, none by default.
+	HeaderDiffSample = "Tigris-Diff-Sample"
+	// HeaderMergePolicy is the conflict policy of the MergeBranch requests, MergePolicyFail by default.
+	HeaderMergePolicy = "Tigris-Merge-Policy"
+	// HeaderBranchAt makes the CreateBranch requests copy the documents of the main branch as of a point in time, a
+	// RFC3339 timestamp or a version of the store.
+	HeaderBranchAt = "Tigris-Branch-At"
+	// HeaderBranchExpiresAfter makes the branch created by the CreateBranch requests ephemeral, it is deleted once the
+	// duration, e.g. "2h30m", has passed.
+	HeaderBranchExpiresAfter = "Tigris-Branch-Expires-After"
+	// HeaderBackupTarget is the target the Backup requests write the archive to, "file://<name>" or an object store
+	// URL.
+	HeaderBackupTarget = "Tigris-Backup-Target"
+	// HeaderBackupSource is the archive the Restore requests read, a target of a previous backup.
+	HeaderBackupSource = "Tigris-Backup-Source"
+	// HeaderBackupId restricts the BackupStatus requests to a single job.
+	HeaderBackupId = "Tigris-Backup-Id"
+	// HeaderRestoreTimestamp is the moment, a RFC3339 timestamp, the RestoreToTimestamp requests bring the collections
+	// back to.
+	HeaderRestoreTimestamp = "Tigris-Restore-Timestamp"
+	// HeaderRestoreCollection restricts the RestoreToTimestamp requests to a single collection of the branch.
+	HeaderRestoreCollection = "Tigris-Restore-Collection"
+	// HeaderCopyTargetProject is the project the CopyCollection requests copy the collection to, the project of the
+	// request by default.
+	HeaderCopyTargetProject = "Tigris-Copy-Target-Project"
+	// HeaderCopyTargetBranch is the branch the CopyCollection requests copy the collection to, the main branch by
+	// default if the target project is set and the branch of the request otherwise.
+	HeaderCopyTargetBranch = "Tigris-Copy-Target-Branch"
+	// HeaderCopyTargetCollection is the name of the copy of the collection, the name of the collection by default.
+	HeaderCopyTargetCollection = "Tigris-Copy-Target-Collection"
+	// HeaderCopyIndexes set to "false" copies the collection without its secondary indexes.
+	HeaderCopyIndexes = "Tigris-Copy-Indexes"
+	// HeaderQueueVisibilityTimeout is the time the messages received from the queue created by the CreateQueue
+	// requests are hidden from the other receivers, a duration like "30s".
+	HeaderQueueVisibilityTimeout = "Tigris-Queue-Visibility-Timeout"
+	// HeaderQueueMaxReceives is the number of the times a message of the queue is received before it is moved to the
+	// dead-letter queue, unlimited by default.
+	HeaderQueueMaxReceives = "Tigris-Queue-Max-Receives"
+	// HeaderQueueDeadLetter is the queue the messages received more than the max receives times are moved to.
+	HeaderQueueDeadLetter = "Tigris-Queue-Dead-Letter"
+	// HeaderCacheExpected is the value the CompareAndSwap requests expect the key to have, the key is expected not to
+	// exist if it isn't set. It's a binary header, so the value is sent as is.
+	HeaderCacheExpected = "Tigris-Cache-Expected-Bin"
+	// HeaderCacheCountEstimate set to "true" makes the CacheKeys requests return the number of the matching keys
+	// instead of the keys.
+	HeaderCacheCountEstimate = "Tigris-Cache-Count-Estimate"
+	// HeaderDeleteBatchSize makes the Delete requests outside of explicit transactions delete the documents matching
+	// the filter in batches of at most this many documents, each batch in its own transaction. The delete isn't atomic,
+	// a failure leaves the batches committed before it deleted.
+	HeaderDeleteBatchSize = "Tigris-Delete-Batch-Size"
+	// HeaderDeleteDryRun set to "true" makes the Delete requests return the number of the documents they would delete
+	// without deleting them.
+	HeaderDeleteDryRun = "Tigris-Delete-Dry-Run"
+	// HeaderDeleteId identifies a batched delete, the progress of the delete is returned by the delete status API
+	// while it runs. The server generates the id if the request doesn't set it.
+	HeaderDeleteId = "Tigris-Delete-Id"
+	// HeaderUpdateBatchSize makes the Update requests outside of explicit transactions run in the background, the
+	// documents matching the filter are updated in batches of at most this many documents in the order of their
+	// primary keys, each batch in its own transaction. The request returns once the update is started, its progress
+	// is returned by the update status API and it can be canceled.
+	HeaderUpdateBatchSize = "Tigris-Update-Batch-Size"
+	// HeaderUpdateId identifies a batched update, it is returned by the Update requests starting one. The server
+	// generates the id if the request doesn't set it.
+	HeaderUpdateId = "Tigris-Update-Id"
+	// HeaderReturnDocument set to "before" or "after" makes the Update, Replace and Delete requests return the
+	// documents they modified as they were before or after the write, in the HeaderDocuments header. Delete only
+	// returns the documents before the write.
+	HeaderReturnDocument = "Tigris-Return-Document"
+	// HeaderDocuments is the JSON array of the documents returned by the writes with the HeaderReturnDocument header.
+	// A document replaced by a Replace request which didn't exist before is returned as null.
+	HeaderDocuments = "Tigris-Documents"
+	// HeaderAuditSince and HeaderAuditUntil restrict the AuditLogs requests to the operations done in a time range,
+	// RFC3339 timestamps.
+	HeaderAuditSince = "Tigris-Audit-Since"
+	HeaderAuditUntil = "Tigris-Audit-Until"
+	// HeaderAuditProject and HeaderAuditCollection restrict the AuditLogs requests to the operations on a project or
+	// a collection.
+	HeaderAuditProject    = "Tigris-Audit-Project"
+	HeaderAuditCollection = "Tigris-Audit-Collection"
+	// HeaderAuditLimit is the maximum number of the entries returned by the AuditLogs requests, the latest first.
+	HeaderAuditLimit = "Tigris-Audit-Limit"
+	// HeaderUsageStart and HeaderUsageEnd are the time range of the Usage requests, RFC3339 timestamps. The range
+	// defaults to the last 24 hours and is widened to the periods of the rollup it overlaps.
+	HeaderUsageStart = "Tigris-Usage-Start"
+	HeaderUsageEnd   = "Tigris-Usage-End"
+	// HeaderUsageRollup is the period the Usage requests aggregate the consumption by, "hour" or "day".
+	HeaderUsageRollup = "Tigris-Usage-Rollup"
+	// HeaderUsageProject and HeaderUsageCollection restrict the Usage requests to a project or a collection.
+	HeaderUsageProject    = "Tigris-Usage-Project"
+	HeaderUsageCollection = "Tigris-Usage-Collection"
+	// HeaderClientIdentity carries the identity of the verified client certificate of the HTTP requests to the
+	// in-process gRPC interceptors. The HTTP server overwrites the value sent by the client.
+	HeaderClientIdentity = "Tigris-Client-Identity"
+	// HeaderClientAddr carries the address of the client of the HTTP requests, and of the Mongo and PostgreSQL wire
+	// protocol connections, to the in-process gRPC interceptors. The HTTP server overwrites the value sent by the client.
+	HeaderClientAddr = "Tigris-Client-Addr"
+)
+
+// ReadSessionNew is the HeaderReadSession value starting a read session.
+const ReadSessionNew = "new"
+
+// The images of the modified documents returned by the writes.
+const (
+	ReturnDocumentBefore = "before"
+	ReturnDocumentAfter  = "after"
+)
+
+// The search consistency of the writes. The strong writes return once the written documents are searchable, the
+// eventual writes return after the commit and their documents are indexed in the background.
+const (
+	SearchConsistencyStrong   = "strong"
+	SearchConsistencyEventual = "eventual"
 )
 
 func CustomMatcher(key string) (string, bool) {
 	key = textproto.CanonicalMIMEHeaderKey(key)
 	switch key {
-	case HeaderRequestTimeout, HeaderAccessControlAllowOrigin, SetCookie, Cookie, HeaderAccept:
+	case HeaderRequestTimeout, HeaderAccessControlAllowOrigin, SetCookie, Cookie, HeaderAccept, HeaderTraceparent,
+		HeaderTracestate:
 		return key, true
 	default:
 		if strings.HasPrefix(key, HeaderPrefix) {
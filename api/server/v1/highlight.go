@@ -0,0 +1,104 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// The unknown fields of the highlight option of the search requests and the highlighted snippets of the hits, see
+// getUnknownJSON.
+const (
+	searchRequestHighlightField protowire.Number = 1000
+	searchHitMetaHighlightField protowire.Number = 1000
+)
+
+const (
+	DefaultHighlightStartTag = "<mark>"
+	DefaultHighlightEndTag   = "</mark>"
+)
+
+// SearchHighlight is the "highlight" option of the search request. The hits of the request return the snippets of
+// the listed fields with the matched tokens wrapped inside the start and end tags, all the searched fields are
+// highlighted if no field is listed.
+type SearchHighlight struct {
+	Fields   []string `json:"fields,omitempty"`
+	StartTag string   `json:"start_tag,omitempty"`
+	EndTag   string   `json:"end_tag,omitempty"`
+}
+
+// GetStartTag returns the tag inserted before the matched tokens.
+func (h *SearchHighlight) GetStartTag() string {
+	if h == nil || len(h.StartTag) == 0 {
+		return DefaultHighlightStartTag
+	}
+
+	return h.StartTag
+}
+
+// GetEndTag returns the tag inserted after the matched tokens.
+func (h *SearchHighlight) GetEndTag() string {
+	if h == nil || len(h.EndTag) == 0 {
+		return DefaultHighlightEndTag
+	}
+
+	return h.EndTag
+}
+
+// HighlightSnippet is the highlighted value of a field of the hit. Snippet is set for the string fields and Snippets
+// for the arrays of strings, it has one snippet per matched element of the array.
+type HighlightSnippet struct {
+	Field    string   `json:"field"`
+	Snippet  string   `json:"snippet,omitempty"`
+	Snippets []string `json:"snippets,omitempty"`
+}
+
+// GetHighlight returns the highlight option of the request, nil if highlighting is not requested.
+func (x *SearchRequest) GetHighlight() *SearchHighlight {
+	var h *SearchHighlight
+	if x == nil || !getUnknownJSON(x, searchRequestHighlightField, &h) {
+		return nil
+	}
+
+	return h
+}
+
+// SetHighlight sets the highlight option of the request, nil removes it.
+func (x *SearchRequest) SetHighlight(h *SearchHighlight) {
+	if h == nil {
+		setUnknownJSON(x, searchRequestHighlightField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestHighlightField, h)
+}
+
+// GetHighlights returns the highlighted snippets of the hit.
+func (x *SearchHitMeta) GetHighlights() []*HighlightSnippet {
+	var snippets []*HighlightSnippet
+	if x == nil || !getUnknownJSON(x, searchHitMetaHighlightField, &snippets) {
+		return nil
+	}
+
+	return snippets
+}
+
+// SetHighlights sets the highlighted snippets of the hit.
+func (x *SearchHitMeta) SetHighlights(snippets []*HighlightSnippet) {
+	if len(snippets) == 0 {
+		setUnknownJSON(x, searchHitMetaHighlightField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchHitMetaHighlightField, snippets)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The IndexAdvisor service is declared by hand like the IndexUsage service. It returns the indexes suggested for the
+// collections of a project branch from the filters of the queries which were served by full scans, as a JSON encoded
+// IndexSuggestionsResponse in the HttpBody.
+
+const indexAdvisorServiceName = "tigrisdata.v1.IndexAdvisor"
+
+// IndexSuggestionField is a field of a suggested index, in the format of the fields of the composite indexes.
+type IndexSuggestionField struct {
+	Field string `json:"field"`
+	Sort  string `json:"sort,omitempty"`
+}
+
+// IndexSuggestion is an index which would serve the queries of the collection which were served by full scans.
+type IndexSuggestion struct {
+	Collection string                  `json:"collection"`
+	Fields     []*IndexSuggestionField `json:"fields"`
+	// FullScans is the number of the recent full scans the index would have avoided, the older scans count less.
+	FullScans int64 `json:"full_scans"`
+	// EstimatedBenefit is the percentage of the recent full scans of the collection the index would have avoided.
+	EstimatedBenefit float64 `json:"estimated_benefit"`
+	// Shapes are the normalized filters of the queries the index would serve, the values are replaced by "?".
+	Shapes []string `json:"shapes"`
+}
+
+// IndexSuggestionsResponse is the ranked list of the indexes suggested for the collections of a project branch, the
+// suggestions which avoid the most full scans are first.
+type IndexSuggestionsResponse struct {
+	Project     string             `json:"project"`
+	Branch      string             `json:"branch,omitempty"`
+	GeneratedAt string             `json:"generated_at,omitempty"`
+	Suggestions []*IndexSuggestion `json:"suggestions"`
+}
+
+// IndexAdvisorClient is the client API for the IndexAdvisor service.
+type IndexAdvisorClient interface {
+	// IndexSuggestions returns the indexes suggested for the collections of the project branch.
+	IndexSuggestions(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type indexAdvisorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIndexAdvisorClient(cc grpc.ClientConnInterface) IndexAdvisorClient {
+	return &indexAdvisorClient{cc}
+}
+
+func (c *indexAdvisorClient) IndexSuggestions(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, IndexSuggestionsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// IndexAdvisorServer is the server API for the IndexAdvisor service.
+type IndexAdvisorServer interface {
+	// IndexSuggestions returns the indexes suggested for the collections of the project branch, the suggestions are
+	// ranked periodically so the recent queries may not be reflected yet.
+	IndexSuggestions(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterIndexAdvisorServer(s grpc.ServiceRegistrar, srv IndexAdvisorServer) {
+	s.RegisterService(&IndexAdvisor_ServiceDesc, srv)
+}
+
+func _IndexAdvisor_IndexSuggestions_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexAdvisorServer).IndexSuggestions(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IndexSuggestionsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(IndexAdvisorServer).IndexSuggestions(ctx, req.(*DescribeDatabaseRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// IndexAdvisor_ServiceDesc is the grpc.ServiceDesc for the IndexAdvisor service.
+var IndexAdvisor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: indexAdvisorServiceName,
+	HandlerType: (*IndexAdvisorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IndexSuggestions",
+			Handler:    _IndexAdvisor_IndexSuggestions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/index_advisor.go",
+}
@@ -0,0 +1,125 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The IndexBuilds service is declared by hand like the Export service. It reports the progress of the background
+// builds of the secondary indexes, the status is returned as a JSON encoded BuildIndexStatusResponse in the HttpBody.
+
+const indexBuildsServiceName = "tigrisdata.v1.IndexBuilds"
+
+// The states of the build of a secondary index. A new index is backfilled from the existing documents, then catches up
+// with the documents changed while the backfill was running and becomes READY to serve the queries.
+const (
+	IndexBuildPending  = "PENDING"
+	IndexBuildBackfill = "BACKFILL"
+	IndexBuildCatchUp  = "CATCH_UP"
+	IndexBuildReady    = "READY"
+	IndexBuildFailed   = "FAILED"
+)
+
+// IndexBuildStatus is the progress of the build of a secondary index.
+type IndexBuildStatus struct {
+	Name string `json:"name"`
+	// State is one of the IndexBuild states.
+	State string `json:"state"`
+	// Percent is the share of the documents of the collection indexed so far, it stays below 100 until the index is
+	// READY.
+	Percent   int32  `json:"percent"`
+	Processed int64  `json:"processed,omitempty"`
+	Error     string `json:"error,omitempty"`
+	StartedAt string `json:"started_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// BuildIndexStatusResponse is the status of the secondary indexes of a collection.
+type BuildIndexStatusResponse struct {
+	Collection string              `json:"collection"`
+	Indexes    []*IndexBuildStatus `json:"indexes"`
+}
+
+// IndexBuildsClient is the client API for the IndexBuilds service.
+type IndexBuildsClient interface {
+	// BuildIndexStatus returns the build progress of the secondary indexes of the collection.
+	BuildIndexStatus(ctx context.Context, in *BuildCollectionIndexRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type indexBuildsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIndexBuildsClient(cc grpc.ClientConnInterface) IndexBuildsClient {
+	return &indexBuildsClient{cc}
+}
+
+func (c *indexBuildsClient) BuildIndexStatus(ctx context.Context, in *BuildCollectionIndexRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, BuildIndexStatusMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// IndexBuildsServer is the server API for the IndexBuilds service.
+type IndexBuildsServer interface {
+	// BuildIndexStatus returns the build progress of the secondary indexes of the collection, the indexes that are
+	// already built are reported as READY.
+	BuildIndexStatus(context.Context, *BuildCollectionIndexRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterIndexBuildsServer(s grpc.ServiceRegistrar, srv IndexBuildsServer) {
+	s.RegisterService(&IndexBuilds_ServiceDesc, srv)
+}
+
+func _IndexBuilds_BuildIndexStatus_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(BuildCollectionIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexBuildsServer).BuildIndexStatus(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BuildIndexStatusMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(IndexBuildsServer).BuildIndexStatus(ctx, req.(*BuildCollectionIndexRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// IndexBuilds_ServiceDesc is the grpc.ServiceDesc for the IndexBuilds service.
+var IndexBuilds_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: indexBuildsServiceName,
+	HandlerType: (*IndexBuildsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BuildIndexStatus",
+			Handler:    _IndexBuilds_BuildIndexStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/index_build.go",
+}
@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The IndexConsistency service is declared by hand like the IndexBuilds service. It compares the secondary indexes of
+// a collection with its documents, to find the index entries that are missing for a document or that are left behind
+// by a document that was changed or deleted. The report is returned as a JSON encoded IndexConsistencyResponse in the
+// HttpBody.
+
+const indexConsistencyServiceName = "tigrisdata.v1.IndexConsistency"
+
+// IndexConsistencyStats is the result of the check of a secondary index.
+type IndexConsistencyStats struct {
+	Name string `json:"name"`
+	// Missing is the number of the entries the documents should have in the index but don't.
+	Missing int64 `json:"missing"`
+	// Orphaned is the number of the entries of the index that don't match a document.
+	Orphaned int64 `json:"orphaned"`
+	// Repaired is the number of the missing entries added and the orphaned entries removed by the repair.
+	Repaired int64 `json:"repaired"`
+}
+
+// IndexConsistencyResponse is the result of the check of the secondary indexes of a collection. The indexes that are
+// being built are not checked.
+type IndexConsistencyResponse struct {
+	Collection string                   `json:"collection"`
+	Repair     bool                     `json:"repair"`
+	Documents  int64                    `json:"documents"`
+	Entries    int64                    `json:"entries"`
+	Consistent bool                     `json:"consistent"`
+	Indexes    []*IndexConsistencyStats `json:"indexes"`
+}
+
+// IndexConsistencyClient is the client API for the IndexConsistency service.
+type IndexConsistencyClient interface {
+	// CheckIndexConsistency checks the secondary indexes of the collection.
+	CheckIndexConsistency(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type indexConsistencyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIndexConsistencyClient(cc grpc.ClientConnInterface) IndexConsistencyClient {
+	return &indexConsistencyClient{cc}
+}
+
+func (c *indexConsistencyClient) CheckIndexConsistency(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, CheckIndexConsistencyMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// IndexConsistencyServer is the server API for the IndexConsistency service.
+type IndexConsistencyServer interface {
+	// CheckIndexConsistency checks the secondary indexes of the collection against its documents. The inconsistent
+	// entries are repaired if the Tigris-Index-Repair header is set to true.
+	CheckIndexConsistency(context.Context, *DescribeCollectionRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterIndexConsistencyServer(s grpc.ServiceRegistrar, srv IndexConsistencyServer) {
+	s.RegisterService(&IndexConsistency_ServiceDesc, srv)
+}
+
+func _IndexConsistency_CheckIndexConsistency_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexConsistencyServer).CheckIndexConsistency(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckIndexConsistencyMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(IndexConsistencyServer).CheckIndexConsistency(ctx, req.(*DescribeCollectionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// IndexConsistency_ServiceDesc is the grpc.ServiceDesc for the IndexConsistency service.
+var IndexConsistency_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: indexConsistencyServiceName,
+	HandlerType: (*IndexConsistencyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckIndexConsistency",
+			Handler:    _IndexConsistency_CheckIndexConsistency_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/index_consistency.go",
+}
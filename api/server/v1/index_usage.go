@@ -0,0 +1,121 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The IndexUsage service is declared by hand like the IndexBuilds service. It reports how the secondary indexes of a
+// collection are used, so that the indexes which are not worth their write cost can be found and dropped. The usage
+// is returned as a JSON encoded IndexUsageStatsResponse in the HttpBody.
+
+const indexUsageServiceName = "tigrisdata.v1.IndexUsage"
+
+// IndexUsageStats is the usage of a secondary index observed by the server since it started.
+type IndexUsageStats struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// Reads is the number of the queries served by the index.
+	Reads      int64  `json:"reads"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	// EntriesWritten is the number of the index entries added or removed by the document writes.
+	EntriesWritten   int64 `json:"entries_written"`
+	DocumentsWritten int64 `json:"documents_written"`
+	// WriteAmplification is the average number of the index entries written per document write.
+	WriteAmplification float64 `json:"write_amplification"`
+	Unused             bool    `json:"unused"`
+}
+
+// IndexUsageStatsResponse is the usage of the secondary indexes of a collection. The usage is tracked in memory by
+// each server, Since is the time from which the server that handled the request tracks it.
+type IndexUsageStatsResponse struct {
+	Collection string             `json:"collection"`
+	Since      string             `json:"since"`
+	Indexes    []*IndexUsageStats `json:"indexes"`
+	// Unused are the names of the indexes that were not read for the duration of the Tigris-Index-Unused-For header,
+	// or at all if the header is not set.
+	Unused []string `json:"unused"`
+}
+
+// IndexUsageClient is the client API for the IndexUsage service.
+type IndexUsageClient interface {
+	// IndexUsageStats returns the usage of the secondary indexes of the collection.
+	IndexUsageStats(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type indexUsageClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIndexUsageClient(cc grpc.ClientConnInterface) IndexUsageClient {
+	return &indexUsageClient{cc}
+}
+
+func (c *indexUsageClient) IndexUsageStats(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, IndexUsageStatsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// IndexUsageServer is the server API for the IndexUsage service.
+type IndexUsageServer interface {
+	// IndexUsageStats returns the usage of the secondary indexes of the collection and the indexes that are unused.
+	IndexUsageStats(context.Context, *DescribeCollectionRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterIndexUsageServer(s grpc.ServiceRegistrar, srv IndexUsageServer) {
+	s.RegisterService(&IndexUsage_ServiceDesc, srv)
+}
+
+func _IndexUsage_IndexUsageStats_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexUsageServer).IndexUsageStats(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IndexUsageStatsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(IndexUsageServer).IndexUsageStats(ctx, req.(*DescribeCollectionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// IndexUsage_ServiceDesc is the grpc.ServiceDesc for the IndexUsage service.
+var IndexUsage_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: indexUsageServiceName,
+	HandlerType: (*IndexUsageServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IndexUsageStats",
+			Handler:    _IndexUsage_IndexUsageStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/index_usage.go",
+}
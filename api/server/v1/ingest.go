@@ -0,0 +1,199 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// The Ingest service is declared by hand rather than generated, it reuses the messages of the Tigris service so
+// that the documents of an InsertStream are validated, metered and inserted the same way as a unary Insert.
+
+const ingestServiceName = "tigrisdata.v1.Ingest"
+
+// IngestClient is the client API for the Ingest service.
+type IngestClient interface {
+	// InsertStream opens a stream of insert requests. The server batches the documents into transactions and sends
+	// back one InsertResponse per committed batch.
+	InsertStream(ctx context.Context, opts ...grpc.CallOption) (Ingest_InsertStreamClient, error)
+	// ImportDocuments streams a NDJSON or CSV file, chunked in the documents of the import requests, into a
+	// collection.
+	ImportDocuments(ctx context.Context, opts ...grpc.CallOption) (Ingest_ImportDocumentsClient, error)
+}
+
+type ingestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIngestClient(cc grpc.ClientConnInterface) IngestClient {
+	return &ingestClient{cc}
+}
+
+func (c *ingestClient) InsertStream(ctx context.Context, opts ...grpc.CallOption) (Ingest_InsertStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ingest_ServiceDesc.Streams[0], InsertStreamMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingestInsertStreamClient{stream}, nil
+}
+
+type Ingest_InsertStreamClient interface {
+	Send(*InsertRequest) error
+	Recv() (*InsertResponse, error)
+	grpc.ClientStream
+}
+
+type ingestInsertStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingestInsertStreamClient) Send(m *InsertRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ingestInsertStreamClient) Recv() (*InsertResponse, error) {
+	m := new(InsertResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (c *ingestClient) ImportDocuments(ctx context.Context, opts ...grpc.CallOption) (Ingest_ImportDocumentsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ingest_ServiceDesc.Streams[1], ImportDocumentsMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingestImportDocumentsClient{stream}, nil
+}
+
+type Ingest_ImportDocumentsClient interface {
+	Send(*ImportRequest) error
+	CloseAndRecv() (*ImportResponse, error)
+	grpc.ClientStream
+}
+
+type ingestImportDocumentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingestImportDocumentsClient) Send(m *ImportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ingestImportDocumentsClient) CloseAndRecv() (*ImportResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	m := new(ImportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// IngestServer is the server API for the Ingest service.
+type IngestServer interface {
+	// InsertStream accepts a continuous stream of insert requests and acknowledges every committed batch.
+	InsertStream(Ingest_InsertStreamServer) error
+	// ImportDocuments imports the streamed file and returns the resume token in the trailer.
+	ImportDocuments(Ingest_ImportDocumentsServer) error
+}
+
+func RegisterIngestServer(s grpc.ServiceRegistrar, srv IngestServer) {
+	s.RegisterService(&Ingest_ServiceDesc, srv)
+}
+
+func _Ingest_InsertStream_Handler(srv any, stream grpc.ServerStream) error {
+	return srv.(IngestServer).InsertStream(&ingestInsertStreamServer{stream})
+}
+
+type Ingest_InsertStreamServer interface {
+	Send(*InsertResponse) error
+	Recv() (*InsertRequest, error)
+	grpc.ServerStream
+}
+
+type ingestInsertStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingestInsertStreamServer) Send(m *InsertResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ingestInsertStreamServer) Recv() (*InsertRequest, error) {
+	m := new(InsertRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func _Ingest_ImportDocuments_Handler(srv any, stream grpc.ServerStream) error {
+	return srv.(IngestServer).ImportDocuments(&ingestImportDocumentsServer{stream})
+}
+
+type Ingest_ImportDocumentsServer interface {
+	SendAndClose(*ImportResponse) error
+	Recv() (*ImportRequest, error)
+	grpc.ServerStream
+}
+
+type ingestImportDocumentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingestImportDocumentsServer) SendAndClose(m *ImportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ingestImportDocumentsServer) Recv() (*ImportRequest, error) {
+	m := new(ImportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Ingest_ServiceDesc is the grpc.ServiceDesc for the Ingest service.
+var Ingest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ingestServiceName,
+	HandlerType: (*IngestServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InsertStream",
+			Handler:       _Ingest_InsertStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ImportDocuments",
+			Handler:       _Ingest_ImportDocuments_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "server/v1/ingest.go",
+}
@@ -206,6 +206,17 @@ func (x *SearchRequest) UnmarshalJSON(data []byte) error {
 			// delaying the vector deserialization
 			x.Vector = value
 			continue
+		case "group_by":
+			// delaying the group by deserialization
+			x.GroupBy = value
+			continue
+		case "group_limit":
+			var limit int32
+			if err := jsoniter.Unmarshal(value, &limit); err != nil {
+				return err
+			}
+			x.SetGroupLimit(limit)
+			continue
 		case "include_fields":
 			v = &x.IncludeFields
 		case "exclude_fields":
@@ -216,6 +227,41 @@ func (x *SearchRequest) UnmarshalJSON(data []byte) error {
 			v = &x.Page
 		case "collation":
 			v = &x.Collation
+		case "typo_tolerance":
+			var t *TypoTolerance
+			if err := jsoniter.Unmarshal(value, &t); err != nil {
+				return err
+			}
+			x.SetTypoTolerance(t)
+			continue
+		case "field_weights":
+			var w map[string]int32
+			if err := jsoniter.Unmarshal(value, &w); err != nil {
+				return err
+			}
+			x.SetFieldWeights(w)
+			continue
+		case "boost":
+			var b *SearchBoost
+			if err := jsoniter.Unmarshal(value, &b); err != nil {
+				return err
+			}
+			x.SetBoost(b)
+			continue
+		case "highlight":
+			var h *SearchHighlight
+			if err := jsoniter.Unmarshal(value, &h); err != nil {
+				return err
+			}
+			x.SetHighlight(h)
+			continue
+		case "sources":
+			var sources []*MultiSearchSource
+			if err := jsoniter.Unmarshal(value, &sources); err != nil {
+				return err
+			}
+			x.SetSources(sources)
+			continue
 		default:
 			continue
 		}
@@ -988,10 +1034,12 @@ func (x *SearchResponse) MarshalJSON() ([]byte, error) {
 		Hits   []*SearchHit            `json:"hits"`
 		Facets map[string]*SearchFacet `json:"facets"`
 		Meta   *SearchMetadata         `json:"meta"`
+		Group  []*GroupedSearchHits    `json:"group,omitempty"`
 	}{
 		Hits:   x.Hits,
 		Facets: x.Facets,
 		Meta:   x.Meta,
+		Group:  x.Group,
 	}
 
 	if resp.Hits == nil {
@@ -1069,6 +1117,12 @@ type SearchHitMetadata struct {
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 	Match     *Match     `json:"match,omitempty"`
+	// Highlights are the highlighted snippets of the hit, only returned if the search request asks for highlighting.
+	Highlights []*HighlightSnippet `json:"highlights,omitempty"`
+	// Source is the label of the collection or the search index of the hit of a multi search.
+	Source string `json:"source,omitempty"`
+	// GeoDistance is the distance in meters of the geopoint fields from the point of the geo distance sort.
+	GeoDistance map[string]int64 `json:"geo_distance,omitempty"`
 }
 
 type Metadata struct {
@@ -1113,6 +1167,9 @@ func CreateMDFromSearchMD(x *SearchHitMeta) *SearchHitMetadata {
 		md.UpdatedAt = &tm
 	}
 	md.Match = x.Match
+	md.Highlights = x.GetHighlights()
+	md.Source = x.GetSource()
+	md.GeoDistance = x.GetGeoDistance()
 
 	return &md
 }
@@ -1174,3 +1231,8 @@ func RawMessageToByte(arr []jsoniter.RawMessage) [][]byte {
 	ptr := unsafe.Pointer(&arr)
 	return *(*[][]byte)(ptr)
 }
+
+func ByteToRawMessage(arr [][]byte) []jsoniter.RawMessage {
+	ptr := unsafe.Pointer(&arr)
+	return *(*[]jsoniter.RawMessage)(ptr)
+}
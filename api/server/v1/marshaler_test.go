@@ -19,6 +19,7 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestJSONEncoding(t *testing.T) {
@@ -75,6 +76,182 @@ func TestJSONEncoding(t *testing.T) {
 		require.NoError(t, err)
 		require.JSONEq(t, `{"hits":[],"facets":{"myField":{"counts":[{"count":32,"value":"adidas"}],"stats":{"avg":40,"count":50}}},"meta":{"found":1234, "matched_fields":null, "total_pages":0,"page":{"current":2,"size":10}}}`, string(r))
 	})
+
+	t.Run("relevance SearchRequest", func(t *testing.T) {
+		req := &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","field_weights":{"title":3},"boost":{"buckets":10,"fields":["popularity"],"filter":{"featured":true}}}`), req))
+		b, err := proto.Marshal(req)
+		require.NoError(t, err)
+		decoded := &SearchRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, map[string]int32{"title": 3}, decoded.GetFieldWeights())
+		require.Equal(t, &SearchBoost{Buckets: 10, Fields: []string{"popularity"}, Filter: []byte(`{"featured":true}`)}, decoded.GetBoost())
+		require.NoError(t, decoded.GetBoost().Validate())
+
+		indexReq := &SearchIndexRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","field_weights":{"title":2},"boost":{"fields":["a","b","c"]}}`), indexReq))
+		require.Equal(t, map[string]int32{"title": 2}, indexReq.GetFieldWeights())
+		require.Equal(t, Errorf(Code_INVALID_ARGUMENT, "boost supports up to 2 fields and filter in total"), indexReq.GetBoost().Validate())
+		require.Nil(t, (&SearchIndexRequest{}).GetBoost())
+	})
+
+	t.Run("marshal range facet", func(t *testing.T) {
+		minV, maxV := float64(5), float64(45)
+		count := &FacetCount{Count: 20, Value: "0-50"}
+		count.SetBucket(&FacetBucket{From: []byte(`0`), To: []byte(`50`), Stats: &FacetStats{Min: &minV, Max: &maxV, Count: 4}})
+		b, err := proto.Marshal(count)
+		require.NoError(t, err)
+		decoded := &FacetCount{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+
+		r, err := jsoniter.Marshal(&SearchFacet{Counts: []*FacetCount{decoded, {Value: "50-*"}}, Stats: &FacetStats{Count: 20}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"counts":[{"count":20,"value":"0-50","from":0,"to":50,"stats":{"min":5,"max":45,"count":4}},{"count":0,"value":"50-*"}],"stats":{"count":20}}`, string(r))
+	})
+
+	t.Run("highlight SearchRequest", func(t *testing.T) {
+		req := &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","highlight":{"fields":["title"],"start_tag":"<b>","end_tag":"</b>"}}`), req))
+		require.Equal(t, "dino", req.GetQ())
+		require.Equal(t, &SearchHighlight{Fields: []string{"title"}, StartTag: "<b>", EndTag: "</b>"}, req.GetHighlight())
+
+		// the option survives the protobuf encoding between the gateway and the server
+		b, err := proto.Marshal(req)
+		require.NoError(t, err)
+		decoded := &SearchRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, "dino", decoded.GetQ())
+		require.Equal(t, req.GetHighlight(), decoded.GetHighlight())
+
+		req = &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","highlight":{}}`), req))
+		require.Equal(t, DefaultHighlightStartTag, req.GetHighlight().GetStartTag())
+		require.Equal(t, DefaultHighlightEndTag, req.GetHighlight().GetEndTag())
+
+		req.SetHighlight(nil)
+		require.Nil(t, req.GetHighlight())
+		require.Nil(t, (&SearchRequest{}).GetHighlight())
+	})
+
+	t.Run("typo tolerance SearchRequest", func(t *testing.T) {
+		req := &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","typo_tolerance":{"num_typos":1,"prefix":false}}`), req))
+		require.Equal(t, int32(1), *req.GetTypoTolerance().NumTypos)
+		require.False(t, *req.GetTypoTolerance().Prefix)
+		require.Nil(t, req.GetTypoTolerance().ExhaustiveSearch)
+
+		indexReq := &SearchIndexRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","typo_tolerance":{"exhaustive_search":true}}`), indexReq))
+		b, err := proto.Marshal(indexReq)
+		require.NoError(t, err)
+		decoded := &SearchIndexRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.True(t, *decoded.GetTypoTolerance().ExhaustiveSearch)
+		require.Nil(t, (&SearchIndexRequest{}).GetTypoTolerance())
+	})
+
+	t.Run("group by SearchRequest", func(t *testing.T) {
+		req := &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"shoe","group_by":"product_id","group_limit":2}`), req))
+		require.Equal(t, []byte(`"product_id"`), req.GroupBy)
+		b, err := proto.Marshal(req)
+		require.NoError(t, err)
+		decoded := &SearchRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, int32(2), decoded.GetGroupLimit())
+		require.Equal(t, int32(0), (&SearchRequest{}).GetGroupLimit())
+
+		indexReq := &SearchIndexRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"shoe","group_by":{"fields":["brand"]},"group_limit":5}`), indexReq))
+		require.Equal(t, int32(5), indexReq.GetGroupLimit())
+
+		r, err := jsoniter.Marshal(&SearchResponse{
+			Group: []*GroupedSearchHits{{
+				GroupKeys: []string{"p1"},
+				Hits:      []*SearchHit{{Data: []byte(`{"product_id":"p1"}`)}},
+			}},
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"hits":[],"facets":{},"meta":null,"group":[{"group_keys":["p1"],"hits":[{"data":{"product_id":"p1"}}]}]}`, string(r))
+	})
+
+	t.Run("marshal SearchHit highlights", func(t *testing.T) {
+		meta := &SearchHitMeta{}
+		meta.SetHighlights([]*HighlightSnippet{
+			{Field: "tags", Snippets: []string{"<mark>dino</mark>"}},
+			{Field: "title", Snippet: "the <mark>dino</mark> park"},
+		})
+
+		b, err := proto.Marshal(&SearchHit{Data: []byte(`{"title":"the dino park"}`), Metadata: meta})
+		require.NoError(t, err)
+		hit := &SearchHit{}
+		require.NoError(t, proto.Unmarshal(b, hit))
+
+		r, err := jsoniter.Marshal(hit)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"data":{"title":"the dino park"},"metadata":{"highlights":[{"field":"tags","snippets":["<mark>dino</mark>"]},{"field":"title","snippet":"the <mark>dino</mark> park"}]}}`, string(r))
+
+		r, err = jsoniter.Marshal(&SearchHit{Data: []byte(`{}`), Metadata: &SearchHitMeta{}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"data":{},"metadata":{}}`, string(r))
+	})
+
+	t.Run("multi search SearchRequest", func(t *testing.T) {
+		req := &SearchRequest{}
+		require.NoError(t, jsoniter.Unmarshal([]byte(`{"q":"dino","sources":[{"collection":"toys","branch":"staging"},{"index":"articles","label":"blog","filter":{"draft":false},"search_fields":["title"]}]}`), req))
+		b, err := proto.Marshal(req)
+		require.NoError(t, err)
+		decoded := &SearchRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, []*MultiSearchSource{
+			{Collection: "toys", Branch: "staging"},
+			{Index: "articles", Label: "blog", Filter: []byte(`{"draft":false}`), SearchFields: []string{"title"}},
+		}, decoded.GetSources())
+		require.Equal(t, "toys", decoded.GetSources()[0].GetLabel())
+		require.Equal(t, "blog", decoded.GetSources()[1].GetLabel())
+		require.Nil(t, (&SearchRequest{}).GetSources())
+
+		meta := &SearchHitMeta{Match: &Match{Score: "100"}}
+		meta.SetSource("blog")
+		r, err := jsoniter.Marshal(&SearchHit{Data: []byte(`{"title":"dino"}`), Metadata: meta})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"data":{"title":"dino"},"metadata":{"match":{"score":"100"},"source":"blog"}}`, string(r))
+	})
+
+	t.Run("multi write CommitTransactionRequest", func(t *testing.T) {
+		req := &CommitTransactionRequest{Project: "p1"}
+		req.SetWrites([]*MultiWriteOp{
+			{Collection: "orders", Op: MultiWriteInsert, Documents: []jsoniter.RawMessage{[]byte(`{"id":1}`)}},
+			{Collection: "order_items", Op: MultiWriteInsert, Documents: []jsoniter.RawMessage{[]byte(`{"order_id":1}`)}},
+			{Collection: "orders", Op: MultiWriteUpdate, Filter: []byte(`{"id":1}`), Fields: []byte(`{"$set":{"items":1}}`)},
+		})
+		b, err := proto.Marshal(req)
+		require.NoError(t, err)
+		decoded := &CommitTransactionRequest{}
+		require.NoError(t, proto.Unmarshal(b, decoded))
+		require.Equal(t, "p1", decoded.Project)
+		require.Equal(t, req.GetWrites(), decoded.GetWrites())
+		require.Equal(t, []string{"orders", "order_items"}, decoded.GetWriteCollections())
+
+		decoded.SetWrites(nil)
+		require.Nil(t, decoded.GetWrites())
+		require.Nil(t, (&CommitTransactionRequest{}).GetWriteCollections())
+	})
+
+	t.Run("marshal SearchHit geo distance", func(t *testing.T) {
+		meta := &SearchHitMeta{}
+		meta.SetGeoDistance(map[string]int64{"loc": 3215})
+
+		b, err := proto.Marshal(&SearchHit{Data: []byte(`{"loc":[48.8584,2.2945]}`), Metadata: meta})
+		require.NoError(t, err)
+		hit := &SearchHit{}
+		require.NoError(t, proto.Unmarshal(b, hit))
+		require.Equal(t, map[string]int64{"loc": 3215}, hit.Metadata.GetGeoDistance())
+
+		r, err := jsoniter.Marshal(hit)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"data":{"loc":[48.8584,2.2945]},"metadata":{"geo_distance":{"loc":3215}}}`, string(r))
+	})
 }
 
 func TestQueryMetricsRequest(t *testing.T) {
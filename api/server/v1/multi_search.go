@@ -0,0 +1,171 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The MultiSearch service is declared by hand like the SearchDictionary service. It runs the query of a SearchRequest
+// across the collections and the search indexes of a project listed in the "sources" of the request, for example,
+// {"q": "dino", "sources": [{"collection": "toys"}, {"index": "articles", "label": "blog"}]}, and returns the hits of
+// all the sources in one SearchResponse ordered by the relevance score. The metadata of each hit has the label of the
+// source it came from.
+
+const multiSearchServiceName = "tigrisdata.v1.MultiSearch"
+
+// The unknown fields of the multi search options, see getUnknownJSON.
+const (
+	searchRequestSourcesField protowire.Number = 1003
+	searchHitMetaSourceField  protowire.Number = 1001
+)
+
+// MultiSearchSource is a collection or a search index searched by a multi search. The filter and the fields of the
+// source take precedence over the ones of the request, as the sources usually have different schemas.
+type MultiSearchSource struct {
+	Collection string `json:"collection,omitempty"`
+	// Branch is the database branch of the collection, the main branch if not set.
+	Branch string `json:"branch,omitempty"`
+	Index  string `json:"index,omitempty"`
+	// Label is returned in the metadata of the hits of the source, the collection or the index name by default.
+	Label         string              `json:"label,omitempty"`
+	Filter        jsoniter.RawMessage `json:"filter,omitempty"`
+	SearchFields  []string            `json:"search_fields,omitempty"`
+	IncludeFields []string            `json:"include_fields,omitempty"`
+	ExcludeFields []string            `json:"exclude_fields,omitempty"`
+}
+
+// GetLabel returns the label of the hits of the source.
+func (x *MultiSearchSource) GetLabel() string {
+	switch {
+	case len(x.Label) > 0:
+		return x.Label
+	case len(x.Collection) > 0:
+		return x.Collection
+	default:
+		return x.Index
+	}
+}
+
+// GetSources returns the sources of a multi search request.
+func (x *SearchRequest) GetSources() []*MultiSearchSource {
+	var sources []*MultiSearchSource
+	if x == nil || !getUnknownJSON(x, searchRequestSourcesField, &sources) {
+		return nil
+	}
+
+	return sources
+}
+
+// SetSources sets the sources of a multi search request, empty removes them.
+func (x *SearchRequest) SetSources(sources []*MultiSearchSource) {
+	if len(sources) == 0 {
+		setUnknownJSON(x, searchRequestSourcesField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestSourcesField, sources)
+}
+
+// GetSource returns the label of the source of the hit of a multi search, empty for the other searches.
+func (x *SearchHitMeta) GetSource() string {
+	var source string
+	if x == nil || !getUnknownJSON(x, searchHitMetaSourceField, &source) {
+		return ""
+	}
+
+	return source
+}
+
+// SetSource sets the label of the source of the hit.
+func (x *SearchHitMeta) SetSource(source string) {
+	if len(source) == 0 {
+		setUnknownJSON(x, searchHitMetaSourceField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchHitMetaSourceField, source)
+}
+
+// MultiSearchClient is the client API for the MultiSearch service.
+type MultiSearchClient interface {
+	// MultiSearch searches the sources of the request and returns their hits merged by the relevance score.
+	MultiSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+}
+
+type multiSearchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMultiSearchClient(cc grpc.ClientConnInterface) MultiSearchClient {
+	return &multiSearchClient{cc}
+}
+
+func (c *multiSearchClient) MultiSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	if err := c.cc.Invoke(ctx, MultiSearchMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// MultiSearchServer is the server API for the MultiSearch service.
+type MultiSearchServer interface {
+	// MultiSearch searches the sources of the request and returns their hits merged by the relevance score.
+	MultiSearch(context.Context, *SearchRequest) (*SearchResponse, error)
+}
+
+func RegisterMultiSearchServer(s grpc.ServiceRegistrar, srv MultiSearchServer) {
+	s.RegisterService(&MultiSearch_ServiceDesc, srv)
+}
+
+func _MultiSearch_MultiSearch_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MultiSearchServer).MultiSearch(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MultiSearchMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MultiSearchServer).MultiSearch(ctx, req.(*SearchRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// MultiSearch_ServiceDesc is the grpc.ServiceDesc for the MultiSearch service.
+var MultiSearch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: multiSearchServiceName,
+	HandlerType: (*MultiSearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MultiSearch",
+			Handler:    _MultiSearch_MultiSearch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/multi_search.go",
+}
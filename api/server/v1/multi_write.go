@@ -0,0 +1,174 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The MultiWrite service is declared by hand like the MultiSearch service. It runs the writes listed in the "writes"
+// of a CommitTransactionRequest across the collections of the database branch of the request atomically, for
+// example, {"writes": [{"collection": "orders", "op": "insert", "documents": [...]}, {"collection": "order_items",
+// "op": "insert", "documents": [...]}]}. The results of the writes are returned as a JSON encoded MultiWriteResponse
+// in the HttpBody.
+
+const multiWriteServiceName = "tigrisdata.v1.MultiWrite"
+
+// The unknown fields of the multi write, see getUnknownJSON.
+const commitTransactionRequestWritesField protowire.Number = 1000
+
+// The operations of the writes of a multi write.
+const (
+	MultiWriteInsert  = "insert"
+	MultiWriteReplace = "replace"
+	MultiWriteUpdate  = "update"
+	MultiWriteDelete  = "delete"
+)
+
+// MultiWriteOp is a write of a multi write, it has the fields of the request of its operation.
+type MultiWriteOp struct {
+	Collection string `json:"collection"`
+	// Op is "insert", "replace", "update" or "delete".
+	Op string `json:"op"`
+	// Documents are the documents inserted or replaced.
+	Documents []jsoniter.RawMessage `json:"documents,omitempty"`
+	// Filter selects the documents updated or deleted.
+	Filter jsoniter.RawMessage `json:"filter,omitempty"`
+	// Fields are the update operators of an update, for example, {"$set": {"status": "paid"}}.
+	Fields jsoniter.RawMessage `json:"fields,omitempty"`
+}
+
+// MultiWriteResult is the result of a write of a multi write, in the order of the writes of the request.
+type MultiWriteResult struct {
+	Collection string `json:"collection"`
+	Op         string `json:"op"`
+	// Keys are the primary keys of the documents inserted or replaced.
+	Keys          []jsoniter.RawMessage `json:"keys,omitempty"`
+	ModifiedCount int32                 `json:"modified_count,omitempty"`
+}
+
+// MultiWriteResponse is the result of a multi write, all the writes are committed together.
+type MultiWriteResponse struct {
+	Status  string              `json:"status"`
+	Results []*MultiWriteResult `json:"results"`
+}
+
+// GetWrites returns the writes of a multi write request.
+func (x *CommitTransactionRequest) GetWrites() []*MultiWriteOp {
+	var writes []*MultiWriteOp
+	if x == nil || !getUnknownJSON(x, commitTransactionRequestWritesField, &writes) {
+		return nil
+	}
+
+	return writes
+}
+
+// SetWrites sets the writes of a multi write request, empty removes them.
+func (x *CommitTransactionRequest) SetWrites(writes []*MultiWriteOp) {
+	if len(writes) == 0 {
+		setUnknownJSON(x, commitTransactionRequestWritesField, nil)
+		return
+	}
+
+	setUnknownJSON(x, commitTransactionRequestWritesField, writes)
+}
+
+// GetWriteCollections returns the collections written by a multi write request, each collection once.
+func (x *CommitTransactionRequest) GetWriteCollections() []string {
+	var collections []string
+	seen := map[string]struct{}{}
+	for _, w := range x.GetWrites() {
+		if _, ok := seen[w.Collection]; ok {
+			continue
+		}
+		seen[w.Collection] = struct{}{}
+		collections = append(collections, w.Collection)
+	}
+
+	return collections
+}
+
+// MultiWriteClient is the client API for the MultiWrite service.
+type MultiWriteClient interface {
+	// MultiWrite runs the writes of the request in one transaction.
+	MultiWrite(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type multiWriteClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMultiWriteClient(cc grpc.ClientConnInterface) MultiWriteClient {
+	return &multiWriteClient{cc}
+}
+
+func (c *multiWriteClient) MultiWrite(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, MultiWriteMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// MultiWriteServer is the server API for the MultiWrite service.
+type MultiWriteServer interface {
+	// MultiWrite runs the writes of the request across the collections of the branch in one transaction, either all
+	// of them are committed or none is.
+	MultiWrite(context.Context, *CommitTransactionRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterMultiWriteServer(s grpc.ServiceRegistrar, srv MultiWriteServer) {
+	s.RegisterService(&MultiWrite_ServiceDesc, srv)
+}
+
+func _MultiWrite_MultiWrite_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CommitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MultiWriteServer).MultiWrite(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MultiWriteMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(MultiWriteServer).MultiWrite(ctx, req.(*CommitTransactionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// MultiWrite_ServiceDesc is the grpc.ServiceDesc for the MultiWrite service.
+var MultiWrite_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: multiWriteServiceName,
+	HandlerType: (*MultiWriteServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MultiWrite",
+			Handler:    _MultiWrite_MultiWrite_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/multi_write.go",
+}
@@ -0,0 +1,94 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// The Outbox service is declared by hand like the Export service. AppendEvents takes an InsertRequest whose
+// documents are the events and returns an InsertResponse with the ids of the events as the keys.
+
+const outboxServiceName = "tigrisdata.v1.Outbox"
+
+// OutboxClient is the client API for the Outbox service.
+type OutboxClient interface {
+	// AppendEvents appends events to the change stream of a collection in the transaction of the request.
+	AppendEvents(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*InsertResponse, error)
+}
+
+type outboxClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOutboxClient(cc grpc.ClientConnInterface) OutboxClient {
+	return &outboxClient{cc}
+}
+
+func (c *outboxClient) AppendEvents(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*InsertResponse, error) {
+	out := new(InsertResponse)
+	if err := c.cc.Invoke(ctx, AppendEventsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// OutboxServer is the server API for the Outbox service.
+type OutboxServer interface {
+	// AppendEvents appends the events to the change stream of the collection, they are committed atomically with the
+	// writes of the transaction of the request and are not stored as documents.
+	AppendEvents(context.Context, *InsertRequest) (*InsertResponse, error)
+}
+
+func RegisterOutboxServer(s grpc.ServiceRegistrar, srv OutboxServer) {
+	s.RegisterService(&Outbox_ServiceDesc, srv)
+}
+
+func _Outbox_AppendEvents_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(InsertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OutboxServer).AppendEvents(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppendEventsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(OutboxServer).AppendEvents(ctx, req.(*InsertRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// Outbox_ServiceDesc is the grpc.ServiceDesc for the Outbox service.
+var Outbox_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: outboxServiceName,
+	HandlerType: (*OutboxServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AppendEvents",
+			Handler:    _Outbox_AppendEvents_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/outbox.go",
+}
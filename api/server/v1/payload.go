@@ -0,0 +1,258 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Content types that can be used in place of JSON for the request and the response payloads. The request payload type
+// is selected by the "Content-Type" header and the response payload type by the "Accept" header, if the "Accept"
+// header is not set then the response is returned in the same type as the request.
+const (
+	MIMEMsgpack    = "application/msgpack"
+	MIMEMsgpackAlt = "application/x-msgpack"
+	MIMEProtobuf   = "application/x-protobuf"
+)
+
+var (
+	msgpackHandle    codec.MsgpackHandle
+	jsonNumberConfig = jsoniter.Config{UseNumber: true}.Froze()
+)
+
+func init() {
+	msgpackHandle.WriteExt = true // Encodes Byte as binary.
+	msgpackHandle.Canonical = true
+	msgpackHandle.MapType = reflect.TypeOf(map[string]any(nil))
+}
+
+// MsgpackMarshaler accepts and returns the payloads as MessagePack. The payload is converted to JSON and back by the
+// CustomMarshaler so the requests and responses follow the same rules as the JSON payloads. Binary values in the
+// MessagePack payload are converted to base64 encoded strings, which is how the byte fields are represented in JSON.
+type MsgpackMarshaler struct {
+	JSON *CustomMarshaler
+}
+
+func NewMsgpackMarshaler() *MsgpackMarshaler {
+	return &MsgpackMarshaler{
+		JSON: &CustomMarshaler{JSONBuiltin: &runtime.JSONBuiltin{}},
+	}
+}
+
+func (*MsgpackMarshaler) ContentType(_ any) string {
+	return MIMEMsgpack
+}
+
+func (m *MsgpackMarshaler) Marshal(v any) ([]byte, error) {
+	js, err := m.JSON.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return JSONToMsgpack(js)
+}
+
+func (m *MsgpackMarshaler) Unmarshal(data []byte, v any) error {
+	js, err := MsgpackToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return m.JSON.NewDecoder(bytes.NewReader(js)).Decode(v)
+}
+
+func (m *MsgpackMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return m.Unmarshal(data, v)
+	})
+}
+
+func (m *MsgpackMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v any) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// Delimiter returns an empty delimiter for the streaming responses as MessagePack values are self-delimiting.
+func (*MsgpackMarshaler) Delimiter() []byte {
+	return nil
+}
+
+// ProtobufMarshaler accepts and returns the payloads as a binary encoded google.protobuf.Struct. Similar to
+// MsgpackMarshaler the payload is converted to JSON and back. The numbers in a Struct are doubles, so the integers
+// larger than 2^53 lose precision, MessagePack should be used if this matters. The messages of a streaming response
+// are prefixed with their length encoded as a varint.
+type ProtobufMarshaler struct {
+	JSON *CustomMarshaler
+}
+
+func NewProtobufMarshaler() *ProtobufMarshaler {
+	return &ProtobufMarshaler{
+		JSON: &CustomMarshaler{JSONBuiltin: &runtime.JSONBuiltin{}},
+	}
+}
+
+func (*ProtobufMarshaler) ContentType(_ any) string {
+	return MIMEProtobuf
+}
+
+func (m *ProtobufMarshaler) Marshal(v any) ([]byte, error) {
+	js, err := m.JSON.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var s structpb.Struct
+	if err = protojson.Unmarshal(js, &s); err != nil {
+		return nil, err
+	}
+
+	data, err := proto.Marshal(&s)
+	if err != nil {
+		return nil, err
+	}
+
+	if isStreamChunk(v) {
+		return append(binary.AppendUvarint(nil, uint64(len(data))), data...), nil
+	}
+
+	return data, nil
+}
+
+func (m *ProtobufMarshaler) Unmarshal(data []byte, v any) error {
+	var s structpb.Struct
+	if err := proto.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	js, err := protojson.Marshal(&s)
+	if err != nil {
+		return err
+	}
+
+	return m.JSON.NewDecoder(bytes.NewReader(js)).Decode(v)
+}
+
+func (m *ProtobufMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return m.Unmarshal(data, v)
+	})
+}
+
+func (m *ProtobufMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v any) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// Delimiter returns an empty delimiter for the streaming responses as the messages are length prefixed.
+func (*ProtobufMarshaler) Delimiter() []byte {
+	return nil
+}
+
+// isStreamChunk returns true for the values that grpc-gateway passes to the marshaler for every message of a
+// streaming response.
+func isStreamChunk(v any) bool {
+	switch v.(type) {
+	case map[string]any, map[string]proto.Message:
+		return true
+	}
+
+	return false
+}
+
+// JSONToMsgpack converts a JSON payload to MessagePack. The integers are kept as integers.
+func JSONToMsgpack(js []byte) ([]byte, error) {
+	var obj any
+	if err := jsonNumberConfig.Unmarshal(js, &obj); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, &msgpackHandle).Encode(convertJSONNumbers(obj)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MsgpackToJSON converts a MessagePack payload to JSON.
+func MsgpackToJSON(data []byte) ([]byte, error) {
+	var obj any
+	if err := codec.NewDecoderBytes(data, &msgpackHandle).Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	return jsoniter.Marshal(obj)
+}
+
+func convertJSONNumbers(v any) any {
+	switch ty := v.(type) {
+	case map[string]any:
+		for k, e := range ty {
+			ty[k] = convertJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range ty {
+			ty[i] = convertJSONNumbers(e)
+		}
+	case json.Number:
+		if i, err := ty.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(ty.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := ty.Float64(); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
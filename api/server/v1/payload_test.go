@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMsgpackPayload(t *testing.T) {
+	m := NewMsgpackMarshaler()
+
+	t.Run("unmarshal InsertRequest", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, codec.NewEncoder(&buf, &msgpackHandle).Encode(map[string]any{
+			"project":    "p1",
+			"collection": "c1",
+			"documents": []any{
+				map[string]any{"id": int64(9007199254740993), "name": "foo", "bin": []byte{1, 2}},
+			},
+		}))
+
+		var req InsertRequest
+		require.NoError(t, m.NewDecoder(&buf).Decode(&req))
+		require.Equal(t, "p1", req.Project)
+		require.Equal(t, "c1", req.Collection)
+		require.Len(t, req.Documents, 1)
+		require.JSONEq(t, `{"id":9007199254740993,"name":"foo","bin":"AQI="}`, string(req.Documents[0]))
+	})
+
+	t.Run("marshal ReadResponse", func(t *testing.T) {
+		data, err := m.Marshal(&ReadResponse{Data: []byte(`{"id":9007199254740993,"price":1.5,"tags":["a"]}`)})
+		require.NoError(t, err)
+
+		var resp map[string]any
+		require.NoError(t, codec.NewDecoderBytes(data, &msgpackHandle).Decode(&resp))
+		require.Equal(t, map[string]any{
+			"id":    int64(9007199254740993),
+			"price": 1.5,
+			"tags":  []any{"a"},
+		}, resp["data"])
+	})
+
+	t.Run("stream chunks", func(t *testing.T) {
+		require.Empty(t, m.Delimiter())
+
+		data, err := m.Marshal(map[string]any{"result": &ReadResponse{Data: []byte(`{"a":1}`)}})
+		require.NoError(t, err)
+
+		js, err := MsgpackToJSON(data)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"result":{"data":{"a":1}}}`, string(js))
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		var req InsertRequest
+		require.Error(t, m.Unmarshal([]byte{0xc1}, &req))
+	})
+}
+
+func TestProtobufPayload(t *testing.T) {
+	m := NewProtobufMarshaler()
+
+	t.Run("unmarshal ReadRequest", func(t *testing.T) {
+		s, err := structpb.NewStruct(map[string]any{
+			"project":    "p1",
+			"collection": "c1",
+			"filter":     map[string]any{"name": "foo"},
+		})
+		require.NoError(t, err)
+		data, err := proto.Marshal(s)
+		require.NoError(t, err)
+
+		var req ReadRequest
+		require.NoError(t, m.Unmarshal(data, &req))
+		require.Equal(t, "p1", req.Project)
+		require.Equal(t, "c1", req.Collection)
+		require.JSONEq(t, `{"name":"foo"}`, string(req.Filter))
+	})
+
+	t.Run("marshal ReadResponse", func(t *testing.T) {
+		data, err := m.Marshal(&ReadResponse{Data: []byte(`{"id":1,"name":"foo"}`)})
+		require.NoError(t, err)
+
+		var s structpb.Struct
+		require.NoError(t, proto.Unmarshal(data, &s))
+		require.Equal(t, map[string]any{"id": float64(1), "name": "foo"}, s.AsMap()["data"])
+	})
+
+	t.Run("stream chunks are length prefixed", func(t *testing.T) {
+		data, err := m.Marshal(map[string]any{"result": &ReadResponse{Data: []byte(`{"a":1}`)}})
+		require.NoError(t, err)
+
+		l, n := binary.Uvarint(data)
+		require.Equal(t, len(data)-n, int(l))
+
+		var s structpb.Struct
+		require.NoError(t, proto.Unmarshal(data[n:], &s))
+		require.Equal(t, map[string]any{"data": map[string]any{"a": float64(1)}}, s.AsMap()["result"])
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// QueryStats is the execution of a read or a search, returned in the Tigris-Query-Stats trailer of the Read and Search
+// responses when the Tigris-Include-Query-Stats header of the request is set, to investigate the performance of the
+// queries from the client.
+type QueryStats struct {
+	// KeysScanned is the number of the keys read from the storage, the entries of the secondary index for the reads
+	// of an index and the documents otherwise.
+	KeysScanned int64 `json:"keys_scanned"`
+	// DocsExamined is the number of the documents matched against the filter, for a search the number of the
+	// documents found.
+	DocsExamined int64 `json:"docs_examined"`
+	DocsReturned int64 `json:"docs_returned"`
+	// Index is the way the query was served, "pkey", "secondary", "covering", "full_scan" or "search", followed by the
+	// name of the index for the reads of a secondary index.
+	Index string     `json:"index"`
+	Time  *QueryTime `json:"time"`
+	// ResultCache is set for the reads of the collections whose results are cached.
+	ResultCache *ResultCacheStats `json:"result_cache,omitempty"`
+}
+
+// ResultCacheStats tells whether the result of the read was served from the result cache, and how long ago it was
+// cached.
+type ResultCacheStats struct {
+	Hit   bool  `json:"hit"`
+	AgeUs int64 `json:"age_us,omitempty"`
+}
+
+// QueryTime is the time spent by the server on the query, in microseconds. The planning is the parsing of the request
+// and the choice of the index, the execution is the rest, i.e. the reads of the storage and the sending of the
+// results.
+type QueryTime struct {
+	PlanningUs  int64 `json:"planning_us"`
+	ExecutionUs int64 `json:"execution_us"`
+	TotalUs     int64 `json:"total_us"`
+}
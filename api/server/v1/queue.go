@@ -0,0 +1,266 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The Queues service is declared by hand like the EventStream service. A queue is a named at-least-once message queue
+// of a database branch, named by the Collection of the requests. CreateQueue takes a DescribeCollectionRequest and
+// the options of the queue in the Tigris-Queue-Visibility-Timeout, Tigris-Queue-Max-Receives and
+// Tigris-Queue-Dead-Letter headers. Enqueue takes an InsertRequest whose documents are the messages, Receive a
+// ReadRequest whose options limit the messages returned and Ack an InsertRequest whose documents are the receipts of
+// the messages, {"receipt": "..."}. The responses are the JSON encoded QueueResponse, EnqueueResponse,
+// ReceiveResponse and AckResponse in the HttpBody.
+//
+// A received message is hidden from the other receivers for the visibility timeout of the queue and is received again
+// once the timeout expires unless it is acknowledged before. A message received more than the max receives of the
+// queue is moved to its dead-letter queue, or dropped if the queue has none.
+
+const queuesServiceName = "tigrisdata.v1.Queues"
+
+// Queue is a queue and its options.
+type Queue struct {
+	Name string `json:"name"`
+	// VisibilityTimeout is the time a received message is hidden from the other receivers, a duration like "30s".
+	VisibilityTimeout string `json:"visibility_timeout"`
+	// MaxReceives is the number of the times a message is received before it is dead-lettered, zero for no limit.
+	MaxReceives int `json:"max_receives,omitempty"`
+	// DeadLetterQueue is the queue the messages received more than MaxReceives times are moved to.
+	DeadLetterQueue string    `json:"dead_letter_queue,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// QueueResponse is the result of the creation of a queue.
+type QueueResponse struct {
+	Status string `json:"status"`
+	Queue  *Queue `json:"queue"`
+}
+
+// EnqueueResponse is the result of an enqueue, the ids of the messages in the order of the request.
+type EnqueueResponse struct {
+	Status string   `json:"status"`
+	Ids    []string `json:"ids"`
+}
+
+// QueueMessage is a message received from a queue.
+type QueueMessage struct {
+	Id string `json:"id"`
+	// Receipt acknowledges the message, it is valid until the message is received again.
+	Receipt string              `json:"receipt"`
+	Data    jsoniter.RawMessage `json:"data"`
+	// Receives is the number of the times the message was received, including this one.
+	Receives   int       `json:"receives"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	// VisibleAt is the time the message is received again if it isn't acknowledged before.
+	VisibleAt time.Time `json:"visible_at"`
+}
+
+// ReceiveResponse is the messages received from a queue, empty if the queue has no visible messages.
+type ReceiveResponse struct {
+	Messages []*QueueMessage `json:"messages"`
+}
+
+// AckResponse is the result of an acknowledgement, the number of the messages deleted. The messages acknowledged
+// already are not counted.
+type AckResponse struct {
+	Status string `json:"status"`
+	Acked  int    `json:"acked"`
+}
+
+// QueuesClient is the client API for the Queues service.
+type QueuesClient interface {
+	// CreateQueue creates the queue of the request with the options of the request headers.
+	CreateQueue(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Enqueue adds the messages to the queue.
+	Enqueue(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Receive returns the visible messages of the queue and hides them for the visibility timeout of the queue.
+	Receive(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// Ack deletes the received messages of the receipts.
+	Ack(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type queuesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueuesClient(cc grpc.ClientConnInterface) QueuesClient {
+	return &queuesClient{cc}
+}
+
+func (c *queuesClient) CreateQueue(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, CreateQueueMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *queuesClient) Enqueue(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, EnqueueMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *queuesClient) Receive(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, ReceiveMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *queuesClient) Ack(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, AckMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// QueuesServer is the server API for the Queues service.
+type QueuesServer interface {
+	// CreateQueue creates the queue, the dead-letter queue of its options must exist.
+	CreateQueue(context.Context, *DescribeCollectionRequest) (*httpbody.HttpBody, error)
+	// Enqueue adds the messages to the queue in the transaction of the request, they are visible once it commits.
+	Enqueue(context.Context, *InsertRequest) (*httpbody.HttpBody, error)
+	// Receive returns at most the limit of the options of the visible messages of the queue, each with a new receipt,
+	// and hides them until the visibility timeout of the queue expires.
+	Receive(context.Context, *ReadRequest) (*httpbody.HttpBody, error)
+	// Ack deletes the messages of the receipts, a receipt is valid until its message is received again.
+	Ack(context.Context, *InsertRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterQueuesServer(s grpc.ServiceRegistrar, srv QueuesServer) {
+	s.RegisterService(&Queues_ServiceDesc, srv)
+}
+
+func _Queues_CreateQueue_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueuesServer).CreateQueue(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreateQueueMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(QueuesServer).CreateQueue(ctx, req.(*DescribeCollectionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Queues_Enqueue_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(InsertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueuesServer).Enqueue(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EnqueueMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(QueuesServer).Enqueue(ctx, req.(*InsertRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Queues_Receive_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueuesServer).Receive(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReceiveMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(QueuesServer).Receive(ctx, req.(*ReadRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Queues_Ack_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(InsertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueuesServer).Ack(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AckMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(QueuesServer).Ack(ctx, req.(*InsertRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// Queues_ServiceDesc is the grpc.ServiceDesc for the Queues service.
+var Queues_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: queuesServiceName,
+	HandlerType: (*QueuesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateQueue",
+			Handler:    _Queues_CreateQueue_Handler,
+		},
+		{
+			MethodName: "Enqueue",
+			Handler:    _Queues_Enqueue_Handler,
+		},
+		{
+			MethodName: "Receive",
+			Handler:    _Queues_Receive_Handler,
+		},
+		{
+			MethodName: "Ack",
+			Handler:    _Queues_Ack_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/queue.go",
+}
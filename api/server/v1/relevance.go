@@ -0,0 +1,165 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The unknown fields of the relevance tuning options of the search requests, see getUnknownJSON.
+const (
+	searchRequestFieldWeightsField      protowire.Number = 1004
+	searchRequestBoostField             protowire.Number = 1005
+	searchIndexRequestFieldWeightsField protowire.Number = 1004
+	searchIndexRequestBoostField        protowire.Number = 1005
+)
+
+const (
+	// MaxFieldWeight is the maximum weight of a search field.
+	MaxFieldWeight = 127
+	// MaxBoosts is the maximum number of the boost fields and filter, the search store ranks the hits by the text
+	// match score and up to two more values.
+	MaxBoosts = 2
+)
+
+// SearchBoost ranks the hits higher by the values of the documents on top of the text match score, for example, by
+// the popularity or the recency of the documents. The hits are divided into the buckets of similar text match scores,
+// and the hits of a bucket are ordered by the boost fields and filter, so that a small difference of the text match
+// score doesn't outweigh a large difference in popularity. Without buckets the boost only orders the hits with the
+// same text match score.
+type SearchBoost struct {
+	// Buckets is the number of buckets the hits are divided into by their text match score.
+	Buckets int32 `json:"buckets,omitempty"`
+	// Fields are the numeric or date-time fields, the hits with the higher values rank higher.
+	Fields []string `json:"fields,omitempty"`
+	// Filter ranks the hits matching the filter higher.
+	Filter jsoniter.RawMessage `json:"filter,omitempty"`
+}
+
+func (x *SearchBoost) Validate() error {
+	if x == nil {
+		return nil
+	}
+
+	boosts := len(x.Fields)
+	if len(x.Filter) > 0 {
+		boosts++
+	}
+	if boosts == 0 {
+		return Errorf(Code_INVALID_ARGUMENT, "boost requires either fields or filter")
+	}
+	if boosts > MaxBoosts {
+		return Errorf(Code_INVALID_ARGUMENT, "boost supports up to %d fields and filter in total", MaxBoosts)
+	}
+	if x.Buckets < 0 {
+		return Errorf(Code_INVALID_ARGUMENT, "boost buckets can't be negative")
+	}
+
+	return nil
+}
+
+// ValidateFieldWeights checks the weights of the search fields of the request.
+func ValidateFieldWeights(weights map[string]int32) error {
+	for field, w := range weights {
+		if w < 1 || w > MaxFieldWeight {
+			return Errorf(Code_INVALID_ARGUMENT, "weight of field '%s' should be between 1 and %d", field, MaxFieldWeight)
+		}
+	}
+
+	return nil
+}
+
+// GetFieldWeights returns the weights of the search fields of the request, a match in a field with a higher weight
+// ranks higher.
+func (x *SearchRequest) GetFieldWeights() map[string]int32 {
+	var w map[string]int32
+	if x == nil || !getUnknownJSON(x, searchRequestFieldWeightsField, &w) {
+		return nil
+	}
+
+	return w
+}
+
+// SetFieldWeights sets the weights of the search fields of the request, empty removes them.
+func (x *SearchRequest) SetFieldWeights(w map[string]int32) {
+	if len(w) == 0 {
+		setUnknownJSON(x, searchRequestFieldWeightsField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestFieldWeightsField, w)
+}
+
+// GetBoost returns the boost option of the request, nil if it is not set.
+func (x *SearchRequest) GetBoost() *SearchBoost {
+	var b *SearchBoost
+	if x == nil || !getUnknownJSON(x, searchRequestBoostField, &b) {
+		return nil
+	}
+
+	return b
+}
+
+// SetBoost sets the boost option of the request, nil removes it.
+func (x *SearchRequest) SetBoost(b *SearchBoost) {
+	if b == nil {
+		setUnknownJSON(x, searchRequestBoostField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestBoostField, b)
+}
+
+// GetFieldWeights returns the weights of the search fields of the request, a match in a field with a higher weight
+// ranks higher.
+func (x *SearchIndexRequest) GetFieldWeights() map[string]int32 {
+	var w map[string]int32
+	if x == nil || !getUnknownJSON(x, searchIndexRequestFieldWeightsField, &w) {
+		return nil
+	}
+
+	return w
+}
+
+// SetFieldWeights sets the weights of the search fields of the request, empty removes them.
+func (x *SearchIndexRequest) SetFieldWeights(w map[string]int32) {
+	if len(w) == 0 {
+		setUnknownJSON(x, searchIndexRequestFieldWeightsField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchIndexRequestFieldWeightsField, w)
+}
+
+// GetBoost returns the boost option of the request, nil if it is not set.
+func (x *SearchIndexRequest) GetBoost() *SearchBoost {
+	var b *SearchBoost
+	if x == nil || !getUnknownJSON(x, searchIndexRequestBoostField, &b) {
+		return nil
+	}
+
+	return b
+}
+
+// SetBoost sets the boost option of the request, nil removes it.
+func (x *SearchIndexRequest) SetBoost(b *SearchBoost) {
+	if b == nil {
+		setUnknownJSON(x, searchIndexRequestBoostField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchIndexRequestBoostField, b)
+}
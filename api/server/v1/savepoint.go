@@ -0,0 +1,131 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// The Savepoints service is declared by hand like the Export service. Its methods apply to the explicit transaction
+// of the transaction headers of the request, the name of the savepoint is passed in the Tigris-Savepoint header.
+
+const savepointsServiceName = "tigrisdata.v1.Savepoints"
+
+// SavepointsClient is the client API for the Savepoints service.
+type SavepointsClient interface {
+	// Savepoint sets a savepoint in the transaction.
+	Savepoint(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error)
+	// RollbackToSavepoint undoes the writes done in the transaction after the savepoint.
+	RollbackToSavepoint(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error)
+}
+
+type savepointsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSavepointsClient(cc grpc.ClientConnInterface) SavepointsClient {
+	return &savepointsClient{cc}
+}
+
+func (c *savepointsClient) Savepoint(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error) {
+	out := new(CommitTransactionResponse)
+	if err := c.cc.Invoke(ctx, SavepointMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *savepointsClient) RollbackToSavepoint(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error) {
+	out := new(CommitTransactionResponse)
+	if err := c.cc.Invoke(ctx, RollbackToSavepointMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SavepointsServer is the server API for the Savepoints service.
+type SavepointsServer interface {
+	// Savepoint marks the state of the transaction, the transaction can later roll back to it.
+	Savepoint(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error)
+	// RollbackToSavepoint undoes the writes done after the savepoint without aborting the transaction, the savepoint
+	// is kept and the savepoints set after it are released.
+	RollbackToSavepoint(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error)
+}
+
+func RegisterSavepointsServer(s grpc.ServiceRegistrar, srv SavepointsServer) {
+	s.RegisterService(&Savepoints_ServiceDesc, srv)
+}
+
+func _Savepoints_Savepoint_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CommitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SavepointsServer).Savepoint(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SavepointMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SavepointsServer).Savepoint(ctx, req.(*CommitTransactionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Savepoints_RollbackToSavepoint_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CommitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SavepointsServer).RollbackToSavepoint(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RollbackToSavepointMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SavepointsServer).RollbackToSavepoint(ctx, req.(*CommitTransactionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// Savepoints_ServiceDesc is the grpc.ServiceDesc for the Savepoints service.
+var Savepoints_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: savepointsServiceName,
+	HandlerType: (*SavepointsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Savepoint",
+			Handler:    _Savepoints_Savepoint_Handler,
+		},
+		{
+			MethodName: "RollbackToSavepoint",
+			Handler:    _Savepoints_RollbackToSavepoint_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/savepoint.go",
+}
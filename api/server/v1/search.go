@@ -360,6 +360,34 @@ func (x *SearchIndexRequest) UnmarshalJSON(data []byte) error {
 			// delaying the sort deserialization
 			x.GroupBy = value
 			continue
+		case "group_limit":
+			var limit int32
+			if err := jsoniter.Unmarshal(value, &limit); err != nil {
+				return err
+			}
+			x.SetGroupLimit(limit)
+			continue
+		case "typo_tolerance":
+			var t *TypoTolerance
+			if err := jsoniter.Unmarshal(value, &t); err != nil {
+				return err
+			}
+			x.SetTypoTolerance(t)
+			continue
+		case "field_weights":
+			var w map[string]int32
+			if err := jsoniter.Unmarshal(value, &w); err != nil {
+				return err
+			}
+			x.SetFieldWeights(w)
+			continue
+		case "boost":
+			var b *SearchBoost
+			if err := jsoniter.Unmarshal(value, &b); err != nil {
+				return err
+			}
+			x.SetBoost(b)
+			continue
 		case "vector":
 			// delaying the vector deserialization
 			x.Vector = value
@@ -0,0 +1,280 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// The SearchDictionary service is declared by hand like the Export service. It manages the synonym sets and the
+// stopword lists of the search indexes. The sets and the lists are passed as the documents of the search document
+// requests, for example, {"id": "shoes", "synonyms": ["sneaker", "trainer"]} and
+// {"id": "common", "stopwords": ["the", "a"]}, and are returned as the documents of the responses.
+
+const searchDictionaryServiceName = "tigrisdata.search.v1.SearchDictionary"
+
+// SearchDictionaryClient is the client API for the SearchDictionary service.
+type SearchDictionaryClient interface {
+	// CreateOrReplaceSynonyms creates or replaces the synonym sets of the documents of the request.
+	CreateOrReplaceSynonyms(ctx context.Context, in *CreateOrReplaceDocumentRequest, opts ...grpc.CallOption) (*CreateOrReplaceDocumentResponse, error)
+	// GetSynonyms returns the synonym sets with the ids of the request, all the sets if no id is passed.
+	GetSynonyms(ctx context.Context, in *GetDocumentRequest, opts ...grpc.CallOption) (*GetDocumentResponse, error)
+	// DeleteSynonyms deletes the synonym sets with the ids of the request.
+	DeleteSynonyms(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error)
+	// CreateOrReplaceStopwords creates or replaces the stopword lists of the documents of the request.
+	CreateOrReplaceStopwords(ctx context.Context, in *CreateOrReplaceDocumentRequest, opts ...grpc.CallOption) (*CreateOrReplaceDocumentResponse, error)
+	// GetStopwords returns the stopword lists with the ids of the request, all the lists if no id is passed.
+	GetStopwords(ctx context.Context, in *GetDocumentRequest, opts ...grpc.CallOption) (*GetDocumentResponse, error)
+	// DeleteStopwords deletes the stopword lists with the ids of the request.
+	DeleteStopwords(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error)
+}
+
+type searchDictionaryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSearchDictionaryClient(cc grpc.ClientConnInterface) SearchDictionaryClient {
+	return &searchDictionaryClient{cc}
+}
+
+func (c *searchDictionaryClient) CreateOrReplaceSynonyms(ctx context.Context, in *CreateOrReplaceDocumentRequest, opts ...grpc.CallOption) (*CreateOrReplaceDocumentResponse, error) {
+	out := new(CreateOrReplaceDocumentResponse)
+	if err := c.cc.Invoke(ctx, CreateOrReplaceSynonymsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchDictionaryClient) GetSynonyms(ctx context.Context, in *GetDocumentRequest, opts ...grpc.CallOption) (*GetDocumentResponse, error) {
+	out := new(GetDocumentResponse)
+	if err := c.cc.Invoke(ctx, GetSynonymsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchDictionaryClient) DeleteSynonyms(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error) {
+	out := new(DeleteDocumentResponse)
+	if err := c.cc.Invoke(ctx, DeleteSynonymsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchDictionaryClient) CreateOrReplaceStopwords(ctx context.Context, in *CreateOrReplaceDocumentRequest, opts ...grpc.CallOption) (*CreateOrReplaceDocumentResponse, error) {
+	out := new(CreateOrReplaceDocumentResponse)
+	if err := c.cc.Invoke(ctx, CreateOrReplaceStopwordsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchDictionaryClient) GetStopwords(ctx context.Context, in *GetDocumentRequest, opts ...grpc.CallOption) (*GetDocumentResponse, error) {
+	out := new(GetDocumentResponse)
+	if err := c.cc.Invoke(ctx, GetStopwordsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchDictionaryClient) DeleteStopwords(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error) {
+	out := new(DeleteDocumentResponse)
+	if err := c.cc.Invoke(ctx, DeleteStopwordsMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SearchDictionaryServer is the server API for the SearchDictionary service.
+type SearchDictionaryServer interface {
+	// CreateOrReplaceSynonyms creates or replaces the synonym sets of the documents of the request.
+	CreateOrReplaceSynonyms(context.Context, *CreateOrReplaceDocumentRequest) (*CreateOrReplaceDocumentResponse, error)
+	// GetSynonyms returns the synonym sets with the ids of the request, all the sets if no id is passed.
+	GetSynonyms(context.Context, *GetDocumentRequest) (*GetDocumentResponse, error)
+	// DeleteSynonyms deletes the synonym sets with the ids of the request.
+	DeleteSynonyms(context.Context, *DeleteDocumentRequest) (*DeleteDocumentResponse, error)
+	// CreateOrReplaceStopwords creates or replaces the stopword lists of the documents of the request.
+	CreateOrReplaceStopwords(context.Context, *CreateOrReplaceDocumentRequest) (*CreateOrReplaceDocumentResponse, error)
+	// GetStopwords returns the stopword lists with the ids of the request, all the lists if no id is passed.
+	GetStopwords(context.Context, *GetDocumentRequest) (*GetDocumentResponse, error)
+	// DeleteStopwords deletes the stopword lists with the ids of the request.
+	DeleteStopwords(context.Context, *DeleteDocumentRequest) (*DeleteDocumentResponse, error)
+}
+
+func RegisterSearchDictionaryServer(s grpc.ServiceRegistrar, srv SearchDictionaryServer) {
+	s.RegisterService(&SearchDictionary_ServiceDesc, srv)
+}
+
+func _SearchDictionary_CreateOrReplaceSynonyms_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CreateOrReplaceDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).CreateOrReplaceSynonyms(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreateOrReplaceSynonymsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).CreateOrReplaceSynonyms(ctx, req.(*CreateOrReplaceDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchDictionary_GetSynonyms_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).GetSynonyms(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetSynonymsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).GetSynonyms(ctx, req.(*GetDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchDictionary_DeleteSynonyms_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DeleteDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).DeleteSynonyms(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeleteSynonymsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).DeleteSynonyms(ctx, req.(*DeleteDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchDictionary_CreateOrReplaceStopwords_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(CreateOrReplaceDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).CreateOrReplaceStopwords(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreateOrReplaceStopwordsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).CreateOrReplaceStopwords(ctx, req.(*CreateOrReplaceDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchDictionary_GetStopwords_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(GetDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).GetStopwords(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetStopwordsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).GetStopwords(ctx, req.(*GetDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchDictionary_DeleteStopwords_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DeleteDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchDictionaryServer).DeleteStopwords(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeleteStopwordsMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchDictionaryServer).DeleteStopwords(ctx, req.(*DeleteDocumentRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// SearchDictionary_ServiceDesc is the grpc.ServiceDesc for the SearchDictionary service.
+var SearchDictionary_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: searchDictionaryServiceName,
+	HandlerType: (*SearchDictionaryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateOrReplaceSynonyms",
+			Handler:    _SearchDictionary_CreateOrReplaceSynonyms_Handler,
+		},
+		{
+			MethodName: "GetSynonyms",
+			Handler:    _SearchDictionary_GetSynonyms_Handler,
+		},
+		{
+			MethodName: "DeleteSynonyms",
+			Handler:    _SearchDictionary_DeleteSynonyms_Handler,
+		},
+		{
+			MethodName: "CreateOrReplaceStopwords",
+			Handler:    _SearchDictionary_CreateOrReplaceStopwords_Handler,
+		},
+		{
+			MethodName: "GetStopwords",
+			Handler:    _SearchDictionary_GetStopwords_Handler,
+		},
+		{
+			MethodName: "DeleteStopwords",
+			Handler:    _SearchDictionary_DeleteStopwords_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/search_dictionary.go",
+}
@@ -0,0 +1,205 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The SearchIndexHealth service is declared by hand like the IndexBuilds service. It reports how far the search index
+// of a collection has drifted from the documents of the collection and rebuilds it from the documents. The status is
+// returned as a JSON encoded SearchIndexStatusResponse in the HttpBody, the rebuild streams its progress as JSON
+// encoded SearchIndexRebuildStatus messages.
+
+const searchIndexHealthServiceName = "tigrisdata.v1.SearchIndexHealth"
+
+// The states of the rebuild of a search index. The search index is dropped and created again, then the documents of
+// the collection are indexed in batches.
+const (
+	SearchIndexRebuildRecreate = "RECREATE"
+	SearchIndexRebuildIndexing = "INDEXING"
+	SearchIndexRebuildReady    = "READY"
+	SearchIndexRebuildFailed   = "FAILED"
+)
+
+// SearchIndexRebuildStatus is the progress of the rebuild of a search index.
+type SearchIndexRebuildStatus struct {
+	// State is one of the SearchIndexRebuild states.
+	State string `json:"state"`
+	// Percent is the share of the documents of the collection indexed so far, it stays below 100 until the rebuild is
+	// READY.
+	Percent   int32  `json:"percent"`
+	Processed int64  `json:"processed,omitempty"`
+	Error     string `json:"error,omitempty"`
+	StartedAt string `json:"started_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// SearchIndexStatusResponse is the health of the search index of a collection.
+type SearchIndexStatusResponse struct {
+	Collection string `json:"collection"`
+	// Documents is the number of the documents in the collection and SearchDocuments is the number of the documents in
+	// its search index, Drift is the difference between them.
+	Documents       int64 `json:"documents"`
+	SearchDocuments int64 `json:"search_documents"`
+	Drift           int64 `json:"drift"`
+	// Pending is the number of the committed writes this server failed to apply to the search index since the last
+	// rebuild.
+	Pending int64 `json:"pending"`
+	// LastSyncedAt is the time this server last applied a write to the search index.
+	LastSyncedAt string `json:"last_synced_at,omitempty"`
+	// InSync is true if there is no drift and no pending writes.
+	InSync bool `json:"in_sync"`
+	// Rebuild is the progress of the last rebuild started on this server.
+	Rebuild *SearchIndexRebuildStatus `json:"rebuild,omitempty"`
+}
+
+// SearchIndexHealthClient is the client API for the SearchIndexHealth service.
+type SearchIndexHealthClient interface {
+	// SearchIndexStatus returns the health of the search index of the collection.
+	SearchIndexStatus(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// RebuildSearchIndex rebuilds the search index of the collection and streams the progress of the rebuild.
+	RebuildSearchIndex(ctx context.Context, in *BuildCollectionSearchIndexRequest, opts ...grpc.CallOption) (SearchIndexHealth_RebuildSearchIndexClient, error)
+}
+
+type searchIndexHealthClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSearchIndexHealthClient(cc grpc.ClientConnInterface) SearchIndexHealthClient {
+	return &searchIndexHealthClient{cc}
+}
+
+func (c *searchIndexHealthClient) SearchIndexStatus(ctx context.Context, in *DescribeCollectionRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, SearchIndexStatusMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *searchIndexHealthClient) RebuildSearchIndex(ctx context.Context, in *BuildCollectionSearchIndexRequest, opts ...grpc.CallOption) (SearchIndexHealth_RebuildSearchIndexClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SearchIndexHealth_ServiceDesc.Streams[0], RebuildSearchIndexMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &searchIndexHealthRebuildSearchIndexClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+type SearchIndexHealth_RebuildSearchIndexClient interface {
+	Recv() (*httpbody.HttpBody, error)
+	grpc.ClientStream
+}
+
+type searchIndexHealthRebuildSearchIndexClient struct {
+	grpc.ClientStream
+}
+
+func (x *searchIndexHealthRebuildSearchIndexClient) Recv() (*httpbody.HttpBody, error) {
+	m := new(httpbody.HttpBody)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// SearchIndexHealthServer is the server API for the SearchIndexHealth service.
+type SearchIndexHealthServer interface {
+	// SearchIndexStatus compares the search index of the collection with the collection.
+	SearchIndexStatus(context.Context, *DescribeCollectionRequest) (*httpbody.HttpBody, error)
+	// RebuildSearchIndex drops the search index of the collection and indexes all the documents of the collection
+	// again, the progress is sent after every batch of documents.
+	RebuildSearchIndex(*BuildCollectionSearchIndexRequest, SearchIndexHealth_RebuildSearchIndexServer) error
+}
+
+func RegisterSearchIndexHealthServer(s grpc.ServiceRegistrar, srv SearchIndexHealthServer) {
+	s.RegisterService(&SearchIndexHealth_ServiceDesc, srv)
+}
+
+func _SearchIndexHealth_SearchIndexStatus_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchIndexHealthServer).SearchIndexStatus(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchIndexStatusMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SearchIndexHealthServer).SearchIndexStatus(ctx, req.(*DescribeCollectionRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchIndexHealth_RebuildSearchIndex_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(BuildCollectionSearchIndexRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return srv.(SearchIndexHealthServer).RebuildSearchIndex(m, &searchIndexHealthRebuildSearchIndexServer{stream})
+}
+
+type SearchIndexHealth_RebuildSearchIndexServer interface {
+	Send(*httpbody.HttpBody) error
+	grpc.ServerStream
+}
+
+type searchIndexHealthRebuildSearchIndexServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchIndexHealthRebuildSearchIndexServer) Send(m *httpbody.HttpBody) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SearchIndexHealth_ServiceDesc is the grpc.ServiceDesc for the SearchIndexHealth service.
+var SearchIndexHealth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: searchIndexHealthServiceName,
+	HandlerType: (*SearchIndexHealthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchIndexStatus",
+			Handler:    _SearchIndexHealth_SearchIndexStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RebuildSearchIndex",
+			Handler:       _SearchIndexHealth_RebuildSearchIndex_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/v1/search_index_health.go",
+}
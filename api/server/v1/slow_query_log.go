@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The SlowQueryLog service is declared by hand like the IndexAdvisor service. It returns the reads of a project branch
+// which took longer than the threshold of the slow query log, as a JSON encoded SlowQueriesResponse in the HttpBody.
+
+const slowQueryLogServiceName = "tigrisdata.v1.SlowQueryLog"
+
+// SlowQuery is a read which took longer than the threshold of the slow query log.
+type SlowQuery struct {
+	Collection string `json:"collection"`
+	// Filter is the normalized filter of the read, the values are replaced by "?".
+	Filter string `json:"filter"`
+	Sort   string `json:"sort,omitempty"`
+	// Plan is the way the read was served, "pkey", "secondary", "covering", "full_scan" or "search", followed by the
+	// name of the index for the reads of a secondary index.
+	Plan string `json:"plan"`
+	// Scanned is the number of the documents or the index entries read, Returned is the number of the documents
+	// returned once filtered.
+	Scanned    int64  `json:"scanned"`
+	Returned   int64  `json:"returned"`
+	DurationMs int64  `json:"duration_ms"`
+	At         string `json:"at"`
+}
+
+// SlowQueriesResponse is the list of the slow reads of a project branch recorded by the server, the latest first.
+type SlowQueriesResponse struct {
+	Project   string       `json:"project"`
+	Branch    string       `json:"branch,omitempty"`
+	Threshold string       `json:"threshold"`
+	Queries   []*SlowQuery `json:"queries"`
+}
+
+// SlowQueryLogClient is the client API for the SlowQueryLog service.
+type SlowQueryLogClient interface {
+	// SlowQueries returns the slow reads of the project branch.
+	SlowQueries(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type slowQueryLogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSlowQueryLogClient(cc grpc.ClientConnInterface) SlowQueryLogClient {
+	return &slowQueryLogClient{cc}
+}
+
+func (c *slowQueryLogClient) SlowQueries(ctx context.Context, in *DescribeDatabaseRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, SlowQueriesMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SlowQueryLogServer is the server API for the SlowQueryLog service.
+type SlowQueryLogServer interface {
+	// SlowQueries returns the slow reads of the project branch recorded by the server handling the request.
+	SlowQueries(context.Context, *DescribeDatabaseRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterSlowQueryLogServer(s grpc.ServiceRegistrar, srv SlowQueryLogServer) {
+	s.RegisterService(&SlowQueryLog_ServiceDesc, srv)
+}
+
+func _SlowQueryLog_SlowQueries_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(DescribeDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlowQueryLogServer).SlowQueries(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SlowQueriesMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(SlowQueryLogServer).SlowQueries(ctx, req.(*DescribeDatabaseRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// SlowQueryLog_ServiceDesc is the grpc.ServiceDesc for the SlowQueryLog service.
+var SlowQueryLog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: slowQueryLogServiceName,
+	HandlerType: (*SlowQueryLogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SlowQueries",
+			Handler:    _SlowQueryLog_SlowQueries_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/slow_query_log.go",
+}
@@ -0,0 +1,124 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// The ChangeStream service is declared by hand like the Export service. The subscription takes a ReadRequest with
+// the collection and the resume token in the offset of the options, and streams the changes as ReadResponses with
+// the JSON encoded change in the data and the token to resume the stream after the change in the resume token.
+
+const changeStreamServiceName = "tigrisdata.v1.ChangeStream"
+
+// ChangeStreamClient is the client API for the ChangeStream service.
+type ChangeStreamClient interface {
+	// Subscribe streams the inserts, updates and deletes of the documents of a collection or of all the collections
+	// of a branch.
+	Subscribe(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (ChangeStream_SubscribeClient, error)
+}
+
+type changeStreamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChangeStreamClient(cc grpc.ClientConnInterface) ChangeStreamClient {
+	return &changeStreamClient{cc}
+}
+
+func (c *changeStreamClient) Subscribe(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (ChangeStream_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ChangeStream_ServiceDesc.Streams[0], SubscribeMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &changeStreamSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+type ChangeStream_SubscribeClient interface {
+	Recv() (*ReadResponse, error)
+	grpc.ClientStream
+}
+
+type changeStreamSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeStreamSubscribeClient) Recv() (*ReadResponse, error) {
+	m := new(ReadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ChangeStreamServer is the server API for the ChangeStream service.
+type ChangeStreamServer interface {
+	// Subscribe streams the changes committed after the subscription or after the resume token.
+	Subscribe(*ReadRequest, ChangeStream_SubscribeServer) error
+}
+
+func RegisterChangeStreamServer(s grpc.ServiceRegistrar, srv ChangeStreamServer) {
+	s.RegisterService(&ChangeStream_ServiceDesc, srv)
+}
+
+func _ChangeStream_Subscribe_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return srv.(ChangeStreamServer).Subscribe(m, &changeStreamSubscribeServer{stream})
+}
+
+type ChangeStream_SubscribeServer interface {
+	Send(*ReadResponse) error
+	grpc.ServerStream
+}
+
+type changeStreamSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeStreamSubscribeServer) Send(m *ReadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ChangeStream_ServiceDesc is the grpc.ServiceDesc for the ChangeStream service.
+var ChangeStream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: changeStreamServiceName,
+	HandlerType: (*ChangeStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _ChangeStream_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/v1/subscribe.go",
+}
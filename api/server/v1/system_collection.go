@@ -0,0 +1,35 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "strings"
+
+// The system collections are the read-only collections of the catalog metadata of a database branch. They are read
+// with the Read API like the user collections, the collection names of the users can't contain a dot, so they don't
+// collide with them.
+const (
+	SystemCollectionPrefix = "_tigris."
+	// SystemCollections has a document per collection of the branch.
+	SystemCollections = SystemCollectionPrefix + "collections"
+	// SystemIndexes has a document per primary key and secondary index of the collections of the branch.
+	SystemIndexes = SystemCollectionPrefix + "indexes"
+	// SystemBranches has a document per branch of the database.
+	SystemBranches = SystemCollectionPrefix + "branches"
+)
+
+// IsSystemCollection returns true if the name is in the namespace of the system collections.
+func IsSystemCollection(name string) bool {
+	return strings.HasPrefix(name, SystemCollectionPrefix)
+}
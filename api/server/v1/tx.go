@@ -21,17 +21,43 @@ import (
 )
 
 const (
-	apiMethodPrefix           = "/tigrisdata.v1.Tigris/"
-	authMethodPrefix          = "/tigrisdata.auth.v1.Auth/"
-	billingMethodPrefix       = "/tigrisdata.billing.v1.Billing/"
-	cacheMethodPrefix         = "/tigrisdata.cache.v1.Cache/"
-	ManagementMethodPrefix    = "/tigrisdata.management.v1.Management/"
-	ObservabilityMethodPrefix = "/tigrisdata.observability.v1.Observability/"
-	realtimeMethodPrefix      = "/tigrisdata.realtime.v1.Realtime/"
+	apiMethodPrefix               = "/tigrisdata.v1.Tigris/"
+	ingestMethodPrefix            = "/" + ingestServiceName + "/"
+	exportMethodPrefix            = "/" + exportServiceName + "/"
+	changeStreamMethodPrefix      = "/" + changeStreamServiceName + "/"
+	outboxMethodPrefix            = "/" + outboxServiceName + "/"
+	savepointsMethodPrefix        = "/" + savepointsServiceName + "/"
+	indexBuildsMethodPrefix       = "/" + indexBuildsServiceName + "/"
+	indexUsageMethodPrefix        = "/" + indexUsageServiceName + "/"
+	indexAdvisorMethodPrefix      = "/" + indexAdvisorServiceName + "/"
+	indexConsistencyMethodPrefix  = "/" + indexConsistencyServiceName + "/"
+	searchDictionaryMethodPrefix  = "/" + searchDictionaryServiceName + "/"
+	multiSearchMethodPrefix       = "/" + multiSearchServiceName + "/"
+	multiWriteMethodPrefix        = "/" + multiWriteServiceName + "/"
+	eventStreamMethodPrefix       = "/" + eventStreamServiceName + "/"
+	queuesMethodPrefix            = "/" + queuesServiceName + "/"
+	searchIndexHealthMethodPrefix = "/" + searchIndexHealthServiceName + "/"
+	branchDiffMethodPrefix        = "/" + branchDiffServiceName + "/"
+	branchMergeMethodPrefix       = "/" + branchMergeServiceName + "/"
+	branchLifetimeMethodPrefix    = "/" + branchLifetimeServiceName + "/"
+	backupMethodPrefix            = "/" + backupServiceName + "/"
+	collectionCopyMethodPrefix    = "/" + collectionCopyServiceName + "/"
+	slowQueryLogMethodPrefix      = "/" + slowQueryLogServiceName + "/"
+	auditLogMethodPrefix          = "/" + auditLogServiceName + "/"
+	usageMeteringMethodPrefix     = "/" + usageMeteringServiceName + "/"
+	authMethodPrefix              = "/tigrisdata.auth.v1.Auth/"
+	billingMethodPrefix           = "/tigrisdata.billing.v1.Billing/"
+	cacheMethodPrefix             = "/tigrisdata.cache.v1.Cache/"
+	cacheOpsMethodPrefix          = "/" + cacheOpsServiceName + "/"
+	ManagementMethodPrefix        = "/tigrisdata.management.v1.Management/"
+	ObservabilityMethodPrefix     = "/tigrisdata.observability.v1.Observability/"
+	realtimeMethodPrefix          = "/tigrisdata.realtime.v1.Realtime/"
 
 	BeginTransactionMethodName    = apiMethodPrefix + "BeginTransaction"
 	CommitTransactionMethodName   = apiMethodPrefix + "CommitTransaction"
 	RollbackTransactionMethodName = apiMethodPrefix + "RollbackTransaction"
+	SavepointMethodName           = savepointsMethodPrefix + "Savepoint"
+	RollbackToSavepointMethodName = savepointsMethodPrefix + "RollbackToSavepoint"
 
 	InsertMethodName  = apiMethodPrefix + "Insert"
 	ReplaceMethodName = apiMethodPrefix + "Replace"
@@ -40,12 +66,30 @@ const (
 	ReadMethodName    = apiMethodPrefix + "Read"
 	CountMethodName   = apiMethodPrefix + "Count"
 
-	BuildCollectionIndexMethodName = apiMethodPrefix + "BuildCollectionIndex"
-	ExplainMethodName              = apiMethodPrefix + "Explain"
+	BuildCollectionIndexMethodName  = apiMethodPrefix + "BuildCollectionIndex"
+	BuildIndexStatusMethodName      = indexBuildsMethodPrefix + "BuildIndexStatus"
+	IndexUsageStatsMethodName       = indexUsageMethodPrefix + "IndexUsageStats"
+	IndexSuggestionsMethodName      = indexAdvisorMethodPrefix + "IndexSuggestions"
+	CheckIndexConsistencyMethodName = indexConsistencyMethodPrefix + "CheckIndexConsistency"
+	SlowQueriesMethodName           = slowQueryLogMethodPrefix + "SlowQueries"
+	ExplainMethodName               = apiMethodPrefix + "Explain"
 
 	SearchMethodName = apiMethodPrefix + "Search"
 	ImportMethodName = apiMethodPrefix + "Import"
 
+	InsertStreamMethodName    = ingestMethodPrefix + "InsertStream"
+	ImportDocumentsMethodName = ingestMethodPrefix + "ImportDocuments"
+	ExportMethodName          = exportMethodPrefix + "Export"
+	SubscribeMethodName       = changeStreamMethodPrefix + "Subscribe"
+	AppendEventsMethodName    = outboxMethodPrefix + "AppendEvents"
+	MultiWriteMethodName      = multiWriteMethodPrefix + "MultiWrite"
+	AppendStreamMethodName    = eventStreamMethodPrefix + "AppendStream"
+	ReadStreamMethodName      = eventStreamMethodPrefix + "ReadStream"
+	CreateQueueMethodName     = queuesMethodPrefix + "CreateQueue"
+	EnqueueMethodName         = queuesMethodPrefix + "Enqueue"
+	ReceiveMethodName         = queuesMethodPrefix + "Receive"
+	AckMethodName             = queuesMethodPrefix + "Ack"
+
 	IndexCollection                 = apiMethodPrefix + "IndexCollection"
 	SearchIndexCollectionMethodName = apiMethodPrefix + "BuildSearchIndex"
 
@@ -99,8 +143,51 @@ const (
 	DelMethodName         = cacheMethodPrefix + "Del"
 	KeysMethodName        = cacheMethodPrefix + "Keys"
 
+	IncrMethodName           = cacheOpsMethodPrefix + "Incr"
+	DecrMethodName           = cacheOpsMethodPrefix + "Decr"
+	ExpireMethodName         = cacheOpsMethodPrefix + "Expire"
+	GetDelMethodName         = cacheOpsMethodPrefix + "GetDel"
+	CompareAndSwapMethodName = cacheOpsMethodPrefix + "CompareAndSwap"
+	CacheKeysMethodName      = cacheOpsMethodPrefix + "CacheKeys"
+
+	// Search dictionary.
+	CreateOrReplaceSynonymsMethodName  = searchDictionaryMethodPrefix + "CreateOrReplaceSynonyms"
+	GetSynonymsMethodName              = searchDictionaryMethodPrefix + "GetSynonyms"
+	DeleteSynonymsMethodName           = searchDictionaryMethodPrefix + "DeleteSynonyms"
+	CreateOrReplaceStopwordsMethodName = searchDictionaryMethodPrefix + "CreateOrReplaceStopwords"
+	GetStopwordsMethodName             = searchDictionaryMethodPrefix + "GetStopwords"
+	DeleteStopwordsMethodName          = searchDictionaryMethodPrefix + "DeleteStopwords"
+
+	// Multi search.
+	MultiSearchMethodName = multiSearchMethodPrefix + "MultiSearch"
+
+	// Search index health.
+	SearchIndexStatusMethodName  = searchIndexHealthMethodPrefix + "SearchIndexStatus"
+	RebuildSearchIndexMethodName = searchIndexHealthMethodPrefix + "RebuildSearchIndex"
+
+	// Collection copy.
+	CopyCollectionMethodName = collectionCopyMethodPrefix + "CopyCollection"
+
+	// Branch diff, merge and lifetime.
+	DiffBranchesMethodName        = branchDiffMethodPrefix + "DiffBranches"
+	MergeBranchMethodName         = branchMergeMethodPrefix + "MergeBranch"
+	ListBranchLifetimesMethodName = branchLifetimeMethodPrefix + "ListBranchLifetimes"
+	BackupMethodName              = backupMethodPrefix + "Backup"
+	RestoreMethodName             = backupMethodPrefix + "Restore"
+	BackupStatusMethodName        = backupMethodPrefix + "BackupStatus"
+	RestoreToTimestampMethodName  = backupMethodPrefix + "RestoreToTimestamp"
+
+	// Audit log.
+	AuditLogsMethodName = auditLogMethodPrefix + "AuditLogs"
+
+	// Usage metering.
+	UsageMethodName = usageMeteringMethodPrefix + "Usage"
+
 	// Health.
 	HealthMethodName = "/HealthAPI/Health"
+	// The standard gRPC health checking protocol, served along with the HealthAPI.
+	GRPCHealthCheckMethodName = "/grpc.health.v1.Health/Check"
+	GRPCHealthWatchMethodName = "/grpc.health.v1.Health/Watch"
 
 	// Management.
 	CreateNamespaceMethodName         = ManagementMethodPrefix + "CreateNamespace"
@@ -130,8 +217,10 @@ func IsTxSupported(ctx context.Context) bool {
 	m, _ := grpc.Method(ctx)
 	switch m {
 	case InsertMethodName, ReplaceMethodName, UpdateMethodName, DeleteMethodName, ReadMethodName,
-		CommitTransactionMethodName, RollbackTransactionMethodName,
-		DropCollectionMethodName, ListCollectionsMethodName, CreateOrUpdateCollectionMethodName:
+		CommitTransactionMethodName, RollbackTransactionMethodName, SavepointMethodName, RollbackToSavepointMethodName,
+		DropCollectionMethodName, ListCollectionsMethodName, CreateOrUpdateCollectionMethodName,
+		AppendEventsMethodName, MultiWriteMethodName, AppendStreamMethodName, ReadStreamMethodName,
+		EnqueueMethodName, AckMethodName:
 		return true
 	default:
 		return false
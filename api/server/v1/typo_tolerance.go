@@ -0,0 +1,133 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// The unknown fields of the typo tolerance option of the search requests, see getUnknownJSON.
+const (
+	searchRequestTypoToleranceField      protowire.Number = 1001
+	searchIndexRequestTypoToleranceField protowire.Number = 1001
+)
+
+// MaxNumTypos is the maximum number of the typographical errors tolerated in a word of the query.
+const MaxNumTypos = 2
+
+// TypoTolerance controls the fuzzy matching of the full text search. The unset options fall back to the defaults of
+// the search index and then to the defaults of the search store.
+type TypoTolerance struct {
+	// NumTypos is the maximum number of typos, 0 to 2, tolerated in a word of the query.
+	NumTypos *int32 `json:"num_typos,omitempty"`
+	// MinLen1Typo is the minimum length of a word for one typo to be tolerated.
+	MinLen1Typo *int32 `json:"min_len_1typo,omitempty"`
+	// MinLen2Typo is the minimum length of a word for two typos to be tolerated.
+	MinLen2Typo *int32 `json:"min_len_2typo,omitempty"`
+	// Prefix treats the last word of the query as a prefix, enabled by default.
+	Prefix *bool `json:"prefix,omitempty"`
+	// ExhaustiveSearch considers all the prefixes and typo corrections of the words of the query instead of stopping
+	// early once enough results are found.
+	ExhaustiveSearch *bool `json:"exhaustive_search,omitempty"`
+}
+
+func (x *TypoTolerance) Validate() error {
+	if x == nil {
+		return nil
+	}
+
+	if x.NumTypos != nil && (*x.NumTypos < 0 || *x.NumTypos > MaxNumTypos) {
+		return Errorf(Code_INVALID_ARGUMENT, "num_typos should be between 0 and %d", MaxNumTypos)
+	}
+	if x.MinLen1Typo != nil && *x.MinLen1Typo < 0 {
+		return Errorf(Code_INVALID_ARGUMENT, "min_len_1typo can't be negative")
+	}
+	if x.MinLen2Typo != nil && *x.MinLen2Typo < 0 {
+		return Errorf(Code_INVALID_ARGUMENT, "min_len_2typo can't be negative")
+	}
+	if x.MinLen1Typo != nil && x.MinLen2Typo != nil && *x.MinLen2Typo < *x.MinLen1Typo {
+		return Errorf(Code_INVALID_ARGUMENT, "min_len_2typo can't be less than min_len_1typo")
+	}
+
+	return nil
+}
+
+// WithDefaults returns the typo tolerance with the options that are not set taken from the defaults, nil if neither
+// is set.
+func (x *TypoTolerance) WithDefaults(defaults *TypoTolerance) *TypoTolerance {
+	if x == nil {
+		return defaults
+	}
+	if defaults == nil {
+		return x
+	}
+
+	merged := *x
+	if merged.NumTypos == nil {
+		merged.NumTypos = defaults.NumTypos
+	}
+	if merged.MinLen1Typo == nil {
+		merged.MinLen1Typo = defaults.MinLen1Typo
+	}
+	if merged.MinLen2Typo == nil {
+		merged.MinLen2Typo = defaults.MinLen2Typo
+	}
+	if merged.Prefix == nil {
+		merged.Prefix = defaults.Prefix
+	}
+	if merged.ExhaustiveSearch == nil {
+		merged.ExhaustiveSearch = defaults.ExhaustiveSearch
+	}
+
+	return &merged
+}
+
+// GetTypoTolerance returns the typo tolerance option of the request, nil if it is not set.
+func (x *SearchRequest) GetTypoTolerance() *TypoTolerance {
+	var t *TypoTolerance
+	if x == nil || !getUnknownJSON(x, searchRequestTypoToleranceField, &t) {
+		return nil
+	}
+
+	return t
+}
+
+// SetTypoTolerance sets the typo tolerance option of the request, nil removes it.
+func (x *SearchRequest) SetTypoTolerance(t *TypoTolerance) {
+	if t == nil {
+		setUnknownJSON(x, searchRequestTypoToleranceField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchRequestTypoToleranceField, t)
+}
+
+// GetTypoTolerance returns the typo tolerance option of the request, nil if it is not set.
+func (x *SearchIndexRequest) GetTypoTolerance() *TypoTolerance {
+	var t *TypoTolerance
+	if x == nil || !getUnknownJSON(x, searchIndexRequestTypoToleranceField, &t) {
+		return nil
+	}
+
+	return t
+}
+
+// SetTypoTolerance sets the typo tolerance option of the request, nil removes it.
+func (x *SearchIndexRequest) SetTypoTolerance(t *TypoTolerance) {
+	if t == nil {
+		setUnknownJSON(x, searchIndexRequestTypoToleranceField, nil)
+		return
+	}
+
+	setUnknownJSON(x, searchIndexRequestTypoToleranceField, t)
+}
@@ -0,0 +1,63 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypoTolerance(t *testing.T) {
+	i32 := func(v int32) *int32 { return &v }
+	b := func(v bool) *bool { return &v }
+
+	t.Run("validate", func(t *testing.T) {
+		cases := []struct {
+			typo   *TypoTolerance
+			expErr string
+		}{
+			{nil, ""},
+			{&TypoTolerance{}, ""},
+			{&TypoTolerance{NumTypos: i32(0), MinLen1Typo: i32(4), MinLen2Typo: i32(7)}, ""},
+			{&TypoTolerance{NumTypos: i32(3)}, "num_typos should be between 0 and 2"},
+			{&TypoTolerance{NumTypos: i32(-1)}, "num_typos should be between 0 and 2"},
+			{&TypoTolerance{MinLen1Typo: i32(-1)}, "min_len_1typo can't be negative"},
+			{&TypoTolerance{MinLen2Typo: i32(-1)}, "min_len_2typo can't be negative"},
+			{&TypoTolerance{MinLen1Typo: i32(5), MinLen2Typo: i32(4)}, "min_len_2typo can't be less than min_len_1typo"},
+		}
+		for _, c := range cases {
+			err := c.typo.Validate()
+			if len(c.expErr) > 0 {
+				require.ErrorContains(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+		}
+	})
+
+	t.Run("with defaults", func(t *testing.T) {
+		defaults := &TypoTolerance{NumTypos: i32(1), Prefix: b(false)}
+
+		require.Nil(t, (*TypoTolerance)(nil).WithDefaults(nil))
+		require.Equal(t, defaults, (*TypoTolerance)(nil).WithDefaults(defaults))
+
+		req := &TypoTolerance{NumTypos: i32(2), ExhaustiveSearch: b(true)}
+		require.Equal(t, req, req.WithDefaults(nil))
+		require.Equal(t, &TypoTolerance{NumTypos: i32(2), Prefix: b(false), ExhaustiveSearch: b(true)}, req.WithDefaults(defaults))
+		// the defaults are not modified
+		require.Equal(t, &TypoTolerance{NumTypos: i32(1), Prefix: b(false)}, defaults)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// The options that are not part of the published protobuf messages yet travel as unknown fields of the messages so
+// that they survive the gRPC hops between the gateway and the server. The fields carry the JSON encoding of the values
+// and use the field numbers starting from 1000 to stay clear of the fields of the messages.
+
+// getUnknownJSON decodes the JSON value of the unknown bytes field num of the message into v. Returns false if the
+// message doesn't have the field or the value can't be decoded.
+func getUnknownJSON(m proto.Message, num protowire.Number, v any) bool {
+	found := false
+	for b := m.ProtoReflect().GetUnknown(); len(b) > 0; {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return false
+		}
+		b = b[l:]
+
+		if n == num && typ == protowire.BytesType {
+			value, vl := protowire.ConsumeBytes(b)
+			if vl < 0 {
+				return false
+			}
+			found = jsoniter.Unmarshal(value, v) == nil
+			b = b[vl:]
+			continue
+		}
+
+		if l = protowire.ConsumeFieldValue(n, typ, b); l < 0 {
+			return false
+		}
+		b = b[l:]
+	}
+
+	return found
+}
+
+// setUnknownJSON replaces the unknown field num of the message with the JSON encoding of v, nil v removes the field.
+func setUnknownJSON(m proto.Message, num protowire.Number, v any) {
+	var kept []byte
+	for b := m.ProtoReflect().GetUnknown(); len(b) > 0; {
+		n, _, l := protowire.ConsumeField(b)
+		if l < 0 {
+			break
+		}
+		if n != num {
+			kept = append(kept, b[:l]...)
+		}
+		b = b[l:]
+	}
+
+	if v != nil {
+		if value, err := jsoniter.Marshal(v); err == nil {
+			kept = protowire.AppendTag(kept, num, protowire.BytesType)
+			kept = protowire.AppendBytes(kept, value)
+		}
+	}
+
+	m.ProtoReflect().SetUnknown(kept)
+}
@@ -0,0 +1,139 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+)
+
+// The UsageMetering service is declared by hand like the AuditLog service. It returns the consumption of the namespace
+// of the caller aggregated by project and collection, as a JSON encoded UsageResponse in the HttpBody, so that the
+// platform teams can charge it back internally.
+
+const usageMeteringServiceName = "tigrisdata.v1.UsageMetering"
+
+// The periods the consumption is aggregated by.
+const (
+	UsageRollupHour = "hour"
+	UsageRollupDay  = "day"
+)
+
+// UsageBucket is the consumption of a collection in a period. The buckets of the totals of the namespace have no
+// project and collection.
+type UsageBucket struct {
+	// Start is the start of the period, in UTC.
+	Start      time.Time `json:"start"`
+	Project    string    `json:"project,omitempty"`
+	Collection string    `json:"collection,omitempty"`
+	ReadUnits  int64     `json:"read_units"`
+	WriteUnits int64     `json:"write_units"`
+	// SearchUnits counts both the search requests and the collection search requests.
+	SearchUnits int64 `json:"search_units"`
+	// StorageBytes is the peak of the hourly samples of the stored bytes of the collection in the period, summed over
+	// its branches.
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+// UsageResponse is the consumption of a namespace over a time range.
+type UsageResponse struct {
+	Namespace string    `json:"namespace"`
+	Rollup    string    `json:"rollup"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	// Buckets is the consumption by period, project and collection, ordered by period.
+	Buckets []*UsageBucket `json:"buckets"`
+	// Totals is the consumption of the namespace by period, the sum of the buckets of the period.
+	Totals []*UsageBucket `json:"totals"`
+}
+
+// UsageQuery selects the consumption returned by a Usage request, the empty project and collection don't restrict it.
+type UsageQuery struct {
+	Start      time.Time
+	End        time.Time
+	Rollup     string
+	Project    string
+	Collection string
+}
+
+// UsageMeteringClient is the client API for the UsageMetering service.
+type UsageMeteringClient interface {
+	// Usage returns the consumption of the namespace of the caller, selected by the Tigris-Usage headers.
+	Usage(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+}
+
+type usageMeteringClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUsageMeteringClient(cc grpc.ClientConnInterface) UsageMeteringClient {
+	return &usageMeteringClient{cc}
+}
+
+func (c *usageMeteringClient) Usage(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	if err := c.cc.Invoke(ctx, UsageMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// UsageMeteringServer is the server API for the UsageMetering service.
+type UsageMeteringServer interface {
+	// Usage returns the consumption of the namespace of the caller by period, project and collection.
+	Usage(context.Context, *ListProjectsRequest) (*httpbody.HttpBody, error)
+}
+
+func RegisterUsageMeteringServer(s grpc.ServiceRegistrar, srv UsageMeteringServer) {
+	s.RegisterService(&UsageMetering_ServiceDesc, srv)
+}
+
+func _UsageMetering_Usage_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(ListProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsageMeteringServer).Usage(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UsageMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(UsageMeteringServer).Usage(ctx, req.(*ListProjectsRequest))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+// UsageMetering_ServiceDesc is the grpc.ServiceDesc for the UsageMetering service.
+var UsageMetering_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: usageMeteringServiceName,
+	HandlerType: (*UsageMeteringServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Usage",
+			Handler:    _UsageMetering_Usage_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/v1/usage.go",
+}
@@ -95,7 +95,11 @@ func (x *DeleteRequest) Validate() error {
 }
 
 func (x *ReadRequest) Validate() error {
-	if err := isValidCollectionAndDatabase(x.Collection, x.Project); err != nil {
+	if IsSystemCollection(x.Collection) {
+		if err := isValidDatabase(x.Project); err != nil {
+			return err
+		}
+	} else if err := isValidCollectionAndDatabase(x.Collection, x.Project); err != nil {
 		return err
 	}
 
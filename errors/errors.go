@@ -63,6 +63,12 @@ func DeadlineExceeded(format string, args ...any) error {
 		format, args...)
 }
 
+// Canceled constructs canceled error (HTTP: 499).
+func Canceled(format string, args ...any) error {
+	return api.Errorf(api.Code_CANCELLED,
+		format, args...)
+}
+
 // ContentTooLarge constructs content too large error (HTTP: 413).
 func ContentTooLarge(format string, args ...any) error {
 	return api.Errorf(api.Code_CONTENT_TOO_LARGE,
@@ -81,6 +87,12 @@ func MethodNotAllowed(format string, args ...any) error {
 		format, args...)
 }
 
+// FailedPrecondition constructs precondition failed error (HTTP: 412).
+func FailedPrecondition(format string, args ...any) error {
+	return api.Errorf(api.Code_FAILED_PRECONDITION,
+		format, args...)
+}
+
 // Aborted constructs conflict error (HTTP: 409).
 func Aborted(format string, args ...any) error {
 	return api.Errorf(api.Code_ABORTED,
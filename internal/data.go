@@ -28,11 +28,17 @@ import (
 )
 
 var (
-	UserTableKeyPrefix      = []byte("data")
-	SecondaryTableKeyPrefix = []byte("idx")
-	SearchTableKeyPrefix    = []byte("sea")
-	PartitionKeyPrefix      = []byte("part")
-	CacheKeyPrefix          = "cache"
+	UserTableKeyPrefix        = []byte("data")
+	SecondaryTableKeyPrefix   = []byte("idx")
+	SearchTableKeyPrefix      = []byte("sea")
+	PartitionKeyPrefix        = []byte("part")
+	ChangelogTableKeyPrefix   = []byte("clog")
+	AuditLogTableKeyPrefix    = []byte("audt")
+	UsageTableKeyPrefix       = []byte("usag")
+	EventStreamTableKeyPrefix = []byte("evst")
+	QueueTableKeyPrefix       = []byte("queu")
+	RealtimeHistoryKeyPrefix  = []byte("rtmh")
+	CacheKeyPrefix            = "cache"
 )
 
 var bh codec.BincHandle
@@ -125,6 +131,7 @@ func (x *TableData) CloneWithAttributesOnly(newRawData []byte) *TableData {
 		Compression: x.Compression,
 		RawData:     newRawData,
 		RawSize:     x.RawSize,
+		Revision:    x.Revision,
 	}
 }
 
@@ -0,0 +1,71 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@yearly",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2023, 3, 15, 10, 30, 20, 0, time.UTC) // Wednesday
+
+	cases := []struct {
+		expr string
+		exp  time.Time
+	}{
+		{"* * * * *", time.Date(2023, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2023, 3, 15, 11, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2023, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2023, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2023, 3, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 8,22 * * *", time.Date(2023, 3, 15, 22, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either the day of the month or the day of the week matches
+		{"0 0 1 * 5", time.Date(2023, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, c.exp, s.Next(from), c.expr)
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kafka is a minimal Kafka producer. It discovers the partition leaders of the topics with metadata requests
+// and sends the records in uncompressed record batches, acknowledged by all the in-sync replicas. The producer isn't
+// idempotent, a retried publish may duplicate the records.
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config of the producer.
+type Config struct {
+	// Brokers are the host:port addresses used to discover the cluster.
+	Brokers  []string
+	ClientID string
+	// Timeout of a single request to a broker.
+	Timeout time.Duration
+}
+
+type partition struct {
+	id     int32
+	leader int32
+}
+
+// Producer publishes the records to the topics. It is safe for concurrent use.
+type Producer struct {
+	cfg Config
+
+	mu      sync.Mutex
+	brokers map[int32]string
+	topics  map[string][]partition
+	conns   map[string]*conn
+	next    uint32
+}
+
+func NewProducer(cfg Config) *Producer {
+	return &Producer{
+		cfg:     cfg,
+		brokers: make(map[int32]string),
+		topics:  make(map[string][]partition),
+		conns:   make(map[string]*conn),
+	}
+}
+
+// Close closes the connections to the brokers.
+func (p *Producer) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, c := range p.conns {
+		_ = c.Close()
+		delete(p.conns, addr)
+	}
+}
+
+// Produce publishes the records to the topic. The records with a key are sent to the partition of the hash of the
+// key, the records without a key are sent to a partition picked in turn for each call. It returns after the records
+// are acknowledged by all the in-sync replicas, on error none, some or all the records may have been published.
+func (p *Producer) Produce(ctx context.Context, topic string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	partitions, err := p.partitions(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	sticky := partitions[p.next%uint32(len(partitions))]
+	p.next++
+	p.mu.Unlock()
+
+	byPartition := make(map[partition][]Record)
+	var order []partition
+	for _, r := range records {
+		part := sticky
+		if r.Key != nil {
+			part = partitions[int(murmur2(r.Key)&0x7fffffff)%len(partitions)]
+		}
+		if _, ok := byPartition[part]; !ok {
+			order = append(order, part)
+		}
+		byPartition[part] = append(byPartition[part], r)
+	}
+
+	for _, part := range order {
+		if err = p.produce(ctx, topic, part, byPartition[part]); err != nil {
+			p.forget(topic)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Producer) produce(ctx context.Context, topic string, part partition, records []Record) error {
+	p.mu.Lock()
+	addr, ok := p.brokers[part.leader]
+	p.mu.Unlock()
+	if !ok {
+		return ErrLeaderNotAvailable
+	}
+
+	req := &encoder{}
+	req.nullString() // transactional id
+	req.int16(-1)    // acks from all the in-sync replicas
+	req.int32(int32(p.cfg.Timeout.Milliseconds()))
+	req.arrayLen(1)
+	req.string(topic)
+	req.arrayLen(1)
+	req.int32(part.id)
+	req.bytes(encodeBatch(records))
+
+	resp, err := p.roundTrip(ctx, addr, apiKeyProduce, produceVersion, req.b)
+	if err != nil {
+		return err
+	}
+
+	for i := resp.arrayLen(); i > 0; i-- {
+		_ = resp.string()
+		for j := resp.arrayLen(); j > 0; j-- {
+			_ = resp.int32()
+			code := resp.int16()
+			_ = resp.int64() // base offset
+			_ = resp.int64() // log append time
+			if code != 0 && resp.err == nil {
+				return Error(code)
+			}
+		}
+	}
+
+	return resp.err
+}
+
+// partitions returns the partitions of the topic, it fetches the metadata of the topic if it isn't known.
+func (p *Producer) partitions(ctx context.Context, topic string) ([]partition, error) {
+	p.mu.Lock()
+	partitions, ok := p.topics[topic]
+	p.mu.Unlock()
+	if ok {
+		return partitions, nil
+	}
+
+	if err := p.refresh(ctx, topic); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.topics[topic], nil
+}
+
+// forget drops the metadata of the topic, so that it is fetched again on the next publish.
+func (p *Producer) forget(topic string) {
+	p.mu.Lock()
+	delete(p.topics, topic)
+	p.mu.Unlock()
+}
+
+// refresh fetches the metadata of the topic from the first bootstrap broker that responds.
+func (p *Producer) refresh(ctx context.Context, topic string) error {
+	req := &encoder{}
+	req.arrayLen(1)
+	req.string(topic)
+	req.int8(0) // don't create the topic
+
+	var err error
+	for _, addr := range p.cfg.Brokers {
+		var resp *decoder
+		if resp, err = p.roundTrip(ctx, addr, apiKeyMetadata, metadataVersion, req.b); err != nil {
+			continue
+		}
+
+		return p.updateMetadata(topic, resp)
+	}
+	if err == nil {
+		err = fmt.Errorf("kafka: no brokers configured")
+	}
+
+	return err
+}
+
+func (p *Producer) updateMetadata(topic string, resp *decoder) error {
+	_ = resp.int32() // throttle time
+
+	brokers := make(map[int32]string)
+	for i := resp.arrayLen(); i > 0; i-- {
+		id := resp.int32()
+		host := resp.string()
+		port := resp.int32()
+		_ = resp.string() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	_ = resp.string() // cluster id
+	_ = resp.int32()  // controller id
+
+	var partitions []partition
+	var topicErr Error
+	for i := resp.arrayLen(); i > 0; i-- {
+		code := resp.int16()
+		name := resp.string()
+		_ = resp.int8() // is internal
+		for j := resp.arrayLen(); j > 0; j-- {
+			_ = resp.int16()
+			part := partition{id: resp.int32(), leader: resp.int32()}
+			for k := resp.arrayLen(); k > 0; k-- {
+				_ = resp.int32() // replicas
+			}
+			for k := resp.arrayLen(); k > 0; k-- {
+				_ = resp.int32() // in-sync replicas
+			}
+			if name == topic {
+				partitions = append(partitions, part)
+			}
+		}
+		if name == topic && code != 0 {
+			topicErr = Error(code)
+		}
+	}
+	if resp.err != nil {
+		return resp.err
+	}
+	if topicErr != 0 {
+		return topicErr
+	}
+	if len(partitions) == 0 {
+		return ErrUnknownTopicOrPartition
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, addr := range brokers {
+		p.brokers[id] = addr
+	}
+	p.topics[topic] = partitions
+
+	return nil
+}
+
+// roundTrip sends the request to the broker and returns the response body. The connection is dropped on error.
+func (p *Producer) roundTrip(ctx context.Context, addr string, apiKey int16, version int16, body []byte) (*decoder, error) {
+	c, err := p.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.roundTrip(ctx, p.cfg, apiKey, version, body)
+	if err != nil {
+		p.mu.Lock()
+		if p.conns[addr] == c {
+			delete(p.conns, addr)
+		}
+		p.mu.Unlock()
+		_ = c.Close()
+
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (p *Producer) conn(ctx context.Context, addr string) (*conn, error) {
+	p.mu.Lock()
+	c, ok := p.conns[addr]
+	p.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	dialer := &net.Dialer{Timeout: p.cfg.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c = &conn{Conn: nc}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.conns[addr]; ok {
+		_ = nc.Close()
+		return existing, nil
+	}
+	p.conns[addr] = c
+
+	return c, nil
+}
+
+// maxResponseSize guards against the allocation of a corrupted response size.
+const maxResponseSize = 64 << 20
+
+// conn is a connection to a broker with a single request in flight.
+type conn struct {
+	net.Conn
+
+	mu            sync.Mutex
+	correlationID int32
+}
+
+func (c *conn) roundTrip(ctx context.Context, cfg Config, apiKey int16, version int16, body []byte) (*decoder, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := time.Now().Add(cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	c.correlationID++
+	req := &encoder{b: make([]byte, 4, 4+10+len(cfg.ClientID)+len(body))}
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(c.correlationID)
+	req.string(cfg.ClientID)
+	req.b = append(req.b, body...)
+	binary.BigEndian.PutUint32(req.b, uint32(len(req.b)-4))
+
+	if _, err := c.Write(req.b); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxResponseSize {
+		return nil, fmt.Errorf("kafka: response of %d bytes exceeds the limit", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c, resp); err != nil {
+		return nil, err
+	}
+
+	d := &decoder{b: resp}
+	if id := d.int32(); d.err == nil && id != c.correlationID {
+		return nil, fmt.Errorf("kafka: unexpected correlation id %d, expected %d", id, c.correlationID)
+	}
+
+	return d, d.err
+}
+
+// murmur2 is the hash of the keys of the default partitioner of the Java client, so that the records with the same
+// key land on the same partition whichever client publishes them.
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+
+	return h
+}
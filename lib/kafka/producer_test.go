@@ -0,0 +1,254 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMurmur2(t *testing.T) {
+	// hashes of the Java client
+	for key, hash := range map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	} {
+		require.Equal(t, hash, int32(murmur2([]byte(key))), key)
+	}
+}
+
+// fakeBroker serves the metadata and the produce requests of a single broker cluster.
+type fakeBroker struct {
+	t          *testing.T
+	ln         net.Listener
+	partitions int32
+	errorCode  int16
+
+	mu       sync.Mutex
+	produced map[int32][]Record
+}
+
+func newFakeBroker(t *testing.T, partitions int32) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakeBroker{t: t, ln: ln, partitions: partitions, produced: make(map[int32][]Record)}
+	go b.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		c, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(c)
+	}
+}
+
+func (b *fakeBroker) handle(c net.Conn) {
+	defer func() { _ = c.Close() }()
+
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(c, buf); err != nil {
+			return
+		}
+
+		req := &decoder{b: buf}
+		apiKey, _, correlationID := req.int16(), req.int16(), req.int32()
+		_ = req.string()
+
+		resp := &encoder{b: make([]byte, 4)}
+		resp.int32(correlationID)
+		switch apiKey {
+		case apiKeyMetadata:
+			b.metadata(req, resp)
+		case apiKeyProduce:
+			b.produce(req, resp)
+		}
+		binary.BigEndian.PutUint32(resp.b, uint32(len(resp.b)-4))
+
+		if _, err := c.Write(resp.b); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) metadata(req *decoder, resp *encoder) {
+	topic := ""
+	for i := req.arrayLen(); i > 0; i-- {
+		topic = req.string()
+	}
+
+	host, port, _ := net.SplitHostPort(b.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	resp.int32(0)
+	resp.arrayLen(1)
+	resp.int32(1)
+	resp.string(host)
+	resp.int32(int32(p))
+	resp.nullString()
+	resp.nullString()
+	resp.int32(1)
+	resp.arrayLen(1)
+	resp.int16(0)
+	resp.string(topic)
+	resp.int8(0)
+	resp.arrayLen(int(b.partitions))
+	for i := int32(0); i < b.partitions; i++ {
+		resp.int16(0)
+		resp.int32(i)
+		resp.int32(1)
+		resp.arrayLen(1)
+		resp.int32(1)
+		resp.arrayLen(1)
+		resp.int32(1)
+	}
+}
+
+func (b *fakeBroker) produce(req *decoder, resp *encoder) {
+	_ = req.string()
+	require.Equal(b.t, int16(-1), req.int16())
+	_ = req.int32()
+
+	resp.arrayLen(req.arrayLen())
+	topic := req.string()
+	resp.string(topic)
+	n := req.arrayLen()
+	resp.arrayLen(n)
+	for ; n > 0; n-- {
+		part := req.int32()
+		size := int(req.int32())
+		batch := req.b[:size]
+		req.b = req.b[size:]
+
+		b.mu.Lock()
+		b.produced[part] = append(b.produced[part], decodeBatch(b.t, batch)...)
+		code := b.errorCode
+		b.mu.Unlock()
+
+		resp.int32(part)
+		resp.int16(code)
+		resp.int64(0)
+		resp.int64(-1)
+	}
+	resp.int32(0)
+}
+
+func decodeBatch(t *testing.T, batch []byte) []Record {
+	d := &decoder{b: batch}
+	require.Equal(t, int64(0), d.int64())
+	require.Equal(t, int(d.int32()), len(d.b))
+	_ = d.int32()
+	require.Equal(t, int8(2), d.int8())
+	require.Equal(t, crc32.Checksum(d.b[4:], castagnoli), uint32(d.int32()))
+	_ = d.int16()
+	_ = d.int32()
+	first := d.int64()
+	_ = d.int64()
+	_, _, _ = d.int64(), d.int16(), d.int32()
+
+	varint := func() int64 {
+		v, n := binary.Varint(d.b)
+		d.b = d.b[n:]
+		return v
+	}
+	varBytes := func() []byte {
+		n := varint()
+		if n < 0 {
+			return nil
+		}
+		v := d.b[:n]
+		d.b = d.b[n:]
+		return v
+	}
+
+	var records []Record
+	for i := d.int32(); i > 0; i-- {
+		_ = varint()
+		_ = d.int8()
+		r := Record{Time: time.UnixMilli(first + varint())}
+		_ = varint()
+		r.Key, r.Value = varBytes(), varBytes()
+		for h := varint(); h > 0; h-- {
+			r.Headers = append(r.Headers, Header{Key: string(varBytes()), Value: varBytes()})
+		}
+		records = append(records, r)
+	}
+	require.NoError(t, d.err)
+
+	return records
+}
+
+func TestProducer(t *testing.T) {
+	broker := newFakeBroker(t, 4)
+	p := NewProducer(Config{Brokers: []string{broker.ln.Addr().String()}, ClientID: "test", Timeout: time.Second})
+	defer p.Close()
+
+	now := time.UnixMilli(time.Now().UnixMilli())
+	records := []Record{
+		{Key: []byte("21"), Value: []byte(`{"a":1}`), Time: now, Headers: []Header{{Key: "op", Value: []byte("insert")}}},
+		{Key: []byte("foobar"), Value: nil, Time: now.Add(time.Millisecond)},
+		{Key: []byte("21"), Value: []byte(`{"a":2}`), Time: now.Add(2 * time.Millisecond)},
+		{Value: []byte(`{"b":1}`), Time: now},
+	}
+	require.NoError(t, p.Produce(context.Background(), "orders", records))
+
+	keyed := func(part uint32) []Record {
+		var records []Record
+		for _, r := range broker.produced[int32(part&0x7fffffff)%4] {
+			if r.Key != nil {
+				records = append(records, r)
+			}
+		}
+		return records
+	}
+
+	broker.mu.Lock()
+	require.Equal(t, []Record{records[0], records[2]}, keyed(murmur2([]byte("21"))))
+	require.Equal(t, []Record{records[1]}, keyed(murmur2([]byte("foobar"))))
+
+	total := 0
+	for _, r := range broker.produced {
+		total += len(r)
+	}
+	require.Equal(t, len(records), total)
+	broker.errorCode = int16(ErrNotLeaderForPartition)
+	broker.mu.Unlock()
+
+	require.Equal(t, ErrNotLeaderForPartition, p.Produce(context.Background(), "orders", records[:1]))
+}
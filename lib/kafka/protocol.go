@@ -0,0 +1,202 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// API keys and versions of the requests sent by the producer. The versions are the oldest ones supported by the
+// current brokers, they don't use the flexible encoding.
+const (
+	apiKeyProduce  int16 = 0
+	apiKeyMetadata int16 = 3
+
+	produceVersion  int16 = 3
+	metadataVersion int16 = 4
+)
+
+// Error is an error code returned by a broker.
+type Error int16
+
+const (
+	ErrUnknownTopicOrPartition Error = 3
+	ErrLeaderNotAvailable      Error = 5
+	ErrNotLeaderForPartition   Error = 6
+	ErrRequestTimedOut         Error = 7
+	ErrMessageTooLarge         Error = 10
+	ErrTopicAuthorization      Error = 29
+)
+
+func (e Error) Error() string {
+	switch e {
+	case ErrUnknownTopicOrPartition:
+		return "kafka: unknown topic or partition"
+	case ErrLeaderNotAvailable:
+		return "kafka: leader not available"
+	case ErrNotLeaderForPartition:
+		return "kafka: not leader for partition"
+	case ErrRequestTimedOut:
+		return "kafka: request timed out"
+	case ErrMessageTooLarge:
+		return "kafka: message too large"
+	case ErrTopicAuthorization:
+		return "kafka: topic authorization failed"
+	default:
+		return fmt.Sprintf("kafka: error code %d", int16(e))
+	}
+}
+
+// encoder appends the big-endian encoded fields of a request.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) int8(v int8) {
+	e.b = append(e.b, byte(v))
+}
+
+func (e *encoder) int16(v int16) {
+	e.b = binary.BigEndian.AppendUint16(e.b, uint16(v))
+}
+
+func (e *encoder) int32(v int32) {
+	e.b = binary.BigEndian.AppendUint32(e.b, uint32(v))
+}
+
+func (e *encoder) int64(v int64) {
+	e.b = binary.BigEndian.AppendUint64(e.b, uint64(v))
+}
+
+func (e *encoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.b = append(e.b, v...)
+}
+
+func (e *encoder) nullString() {
+	e.int16(-1)
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.b = append(e.b, v...)
+}
+
+func (e *encoder) arrayLen(n int) {
+	e.int32(int32(n))
+}
+
+// varint appends a zigzag encoded variable length integer, as used by the records.
+func (e *encoder) varint(v int64) {
+	e.b = binary.AppendVarint(e.b, v)
+}
+
+// varBytes appends bytes prefixed with their varint length, -1 for nil.
+func (e *encoder) varBytes(v []byte) {
+	if v == nil {
+		e.varint(-1)
+		return
+	}
+
+	e.varint(int64(len(v)))
+	e.b = append(e.b, v...)
+}
+
+// decoder reads the big-endian encoded fields of a response. The first error is kept and the following reads return
+// zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) need(n int) bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.b) < n {
+		d.err = fmt.Errorf("kafka: truncated response")
+		return false
+	}
+
+	return true
+}
+
+func (d *decoder) int8() int8 {
+	if !d.need(1) {
+		return 0
+	}
+	v := int8(d.b[0])
+	d.b = d.b[1:]
+
+	return v
+}
+
+func (d *decoder) int16() int16 {
+	if !d.need(2) {
+		return 0
+	}
+	v := int16(binary.BigEndian.Uint16(d.b))
+	d.b = d.b[2:]
+
+	return v
+}
+
+func (d *decoder) int32() int32 {
+	if !d.need(4) {
+		return 0
+	}
+	v := int32(binary.BigEndian.Uint32(d.b))
+	d.b = d.b[4:]
+
+	return v
+}
+
+func (d *decoder) int64() int64 {
+	if !d.need(8) {
+		return 0
+	}
+	v := int64(binary.BigEndian.Uint64(d.b))
+	d.b = d.b[8:]
+
+	return v
+}
+
+// string reads a string, a null string is read as empty.
+func (d *decoder) string() string {
+	n := int(d.int16())
+	if n < 0 || !d.need(n) {
+		return ""
+	}
+	v := string(d.b[:n])
+	d.b = d.b[n:]
+
+	return v
+}
+
+// arrayLen reads the length of an array, a null array is read as empty.
+func (d *decoder) arrayLen() int {
+	n := int(d.int32())
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	if n > len(d.b) {
+		// every element takes at least a byte
+		d.err = fmt.Errorf("kafka: truncated response")
+		return 0
+	}
+
+	return n
+}
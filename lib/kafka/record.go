@@ -0,0 +1,89 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Header is a header of a record.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a message published to a topic. A nil key spreads the records across the partitions, a nil value is a
+// tombstone.
+type Record struct {
+	Key     []byte
+	Value   []byte
+	Headers []Header
+	Time    time.Time
+}
+
+// encodeBatch encodes the records as an uncompressed record batch of the version 2 of the message format.
+func encodeBatch(records []Record) []byte {
+	first := records[0].Time.UnixMilli()
+	last := first
+
+	body := &encoder{}
+	body.int16(0)                       // attributes
+	body.int32(int32(len(records) - 1)) // last offset delta
+	body.int64(first)
+	maxTs := len(body.b)
+	body.int64(0)  // max timestamp, set below
+	body.int64(-1) // producer id
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.arrayLen(len(records))
+
+	rec := &encoder{}
+	for i, r := range records {
+		ts := r.Time.UnixMilli()
+		if ts > last {
+			last = ts
+		}
+
+		rec.b = rec.b[:0]
+		rec.int8(0) // attributes
+		rec.varint(ts - first)
+		rec.varint(int64(i))
+		rec.varBytes(r.Key)
+		rec.varBytes(r.Value)
+		rec.varint(int64(len(r.Headers)))
+		for _, h := range r.Headers {
+			rec.varBytes([]byte(h.Key))
+			rec.varBytes(h.Value)
+		}
+
+		body.varint(int64(len(rec.b)))
+		body.b = append(body.b, rec.b...)
+	}
+	binary.BigEndian.PutUint64(body.b[maxTs:], uint64(last))
+
+	batch := &encoder{b: make([]byte, 0, 21+len(body.b))}
+	batch.int64(0)                              // base offset
+	batch.int32(int32(4 + 1 + 4 + len(body.b))) // length of the batch after this field
+	batch.int32(-1)                             // partition leader epoch
+	batch.int8(2)                               // magic
+	batch.int32(int32(crc32.Checksum(body.b, castagnoli)))
+	batch.b = append(batch.b, body.b...)
+
+	return batch.b
+}
@@ -0,0 +1,113 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parquet
+
+import (
+	"encoding/binary"
+)
+
+// Thrift compact protocol types, the Parquet metadata is serialized with the compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter serializes the metadata structures field by field, the fields of a struct must be written in
+// ascending order of their ids.
+type thriftWriter struct {
+	buf []byte
+	// last field id of the structs being written, innermost last
+	last []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(uint64(uint16((id << 1) ^ (id >> 15))))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(uint64(uint32((v << 1) ^ (v >> 31))))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) binary(id int16, b []byte) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(b)))
+	t.buf = append(t.buf, b...)
+}
+
+func (t *thriftWriter) list(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xf0|elemType)
+		t.varint(uint64(size))
+	}
+}
+
+func (t *thriftWriter) i32List(id int16, values ...int32) {
+	t.list(id, thriftI32, len(values))
+	for _, v := range values {
+		t.varint(uint64(uint32((v << 1) ^ (v >> 31))))
+	}
+}
+
+func (t *thriftWriter) binaryList(id int16, values ...string) {
+	t.list(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.varint(uint64(len(v)))
+		t.buf = append(t.buf, v...)
+	}
+}
+
+// structField starts a struct valued field, it is closed with end.
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// begin starts a struct written as a list element.
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost struct, or the top level one.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
@@ -0,0 +1,496 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parquet writes flat Apache Parquet files. Every column is optional and written as a single PLAIN encoded
+// data page per row group, which keeps the writer small while producing files readable by any Parquet reader.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Type is the logical type of a column.
+type Type int
+
+const (
+	Boolean Type = iota
+	Int64
+	Double
+	// String is an UTF-8 string.
+	String
+	// Bytes is an opaque byte array.
+	Bytes
+	// JSON is a JSON encoded value.
+	JSON
+	// Timestamp is a point in time stored with microsecond precision.
+	Timestamp
+)
+
+// Codec compresses the data pages.
+type Codec int
+
+const (
+	Uncompressed Codec = iota
+	Snappy
+	Gzip
+	Zstd
+)
+
+// DefaultRowGroupSize is the number of rows buffered before a row group is written.
+const DefaultRowGroupSize = 10000
+
+// maxRowGroupBytes flushes a row group early when its values get large.
+const maxRowGroupBytes = 64 * 1024 * 1024
+
+// Physical types, field repetitions, converted types, encodings, codecs and page types of the Parquet format.
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+	codecZstd         = 6
+
+	pageData = 0
+)
+
+var (
+	magic     = []byte("PAR1")
+	errClosed = fmt.Errorf("parquet writer is closed")
+)
+
+// Column describes a column of the file.
+type Column struct {
+	Name string
+	Type Type
+}
+
+func (c Column) physical() int32 {
+	switch c.Type {
+	case Boolean:
+		return physicalBoolean
+	case Int64, Timestamp:
+		return physicalInt64
+	case Double:
+		return physicalDouble
+	default:
+		return physicalByteArray
+	}
+}
+
+func (c Column) converted() (int32, bool) {
+	switch c.Type {
+	case String:
+		return convertedUTF8, true
+	case JSON:
+		return convertedJSON, true
+	case Timestamp:
+		return convertedTimestampMicros, true
+	default:
+		return 0, false
+	}
+}
+
+type columnBuffer struct {
+	// definition level of every row, 0 for null and 1 for a value
+	levels []byte
+	values bytes.Buffer
+	bools  []bool
+}
+
+type columnChunk struct {
+	offset           int64
+	values           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+	bytes   int64
+}
+
+// Writer writes the rows of a Parquet file. The file is complete once Close is called.
+type Writer struct {
+	w       io.Writer
+	offset  int64
+	columns []Column
+	codec   Codec
+
+	// RowGroupSize is the number of rows of a row group.
+	RowGroupSize int
+
+	buffers   []columnBuffer
+	rows      int
+	rowGroups []rowGroup
+	zstd      *zstd.Encoder
+	err       error
+}
+
+// NewWriter returns a writer of a file with the given columns.
+func NewWriter(w io.Writer, columns []Column, codec Codec) *Writer {
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		codec:        codec,
+		RowGroupSize: DefaultRowGroupSize,
+		buffers:      make([]columnBuffer, len(columns)),
+	}
+}
+
+// Write appends a row, it has a value per column. The value of a column is nil, meaning null, or of the Go type
+// matching the column type: bool, int64, float64, string (or []byte) for String and JSON columns, []byte for Bytes
+// and time.Time for Timestamp.
+func (w *Writer) Write(row []any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(row), len(w.columns))
+	}
+
+	// check the whole row first, so that a rejected row doesn't leave values in some columns
+	for i, v := range row {
+		if !w.columns[i].accepts(v) {
+			return fmt.Errorf("unexpected value of type %T for column '%s'", v, w.columns[i].Name)
+		}
+	}
+	for i, v := range row {
+		w.buffers[i].append(v)
+	}
+
+	w.rows++
+	if w.rows >= w.RowGroupSize || w.bufferedBytes() >= maxRowGroupBytes {
+		w.err = w.flush()
+	}
+
+	return w.err
+}
+
+func (c Column) accepts(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	switch v.(type) {
+	case bool:
+		return c.Type == Boolean
+	case int64:
+		return c.Type == Int64
+	case float64:
+		return c.Type == Double
+	case time.Time:
+		return c.Type == Timestamp
+	case string:
+		return c.Type == String || c.Type == JSON
+	case []byte:
+		return c.Type == String || c.Type == JSON || c.Type == Bytes
+	default:
+		return false
+	}
+}
+
+func (b *columnBuffer) append(v any) {
+	if v == nil {
+		b.levels = append(b.levels, 0)
+		return
+	}
+
+	b.levels = append(b.levels, 1)
+	switch val := v.(type) {
+	case bool:
+		b.bools = append(b.bools, val)
+	case int64:
+		b.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(val)))
+	case float64:
+		b.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(val)))
+	case time.Time:
+		b.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(val.UnixMicro())))
+	case string:
+		b.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(val))))
+		b.values.WriteString(val)
+	case []byte:
+		b.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(val))))
+		b.values.Write(val)
+	}
+}
+
+func (w *Writer) bufferedBytes() int {
+	size := 0
+	for i := range w.buffers {
+		size += w.buffers[i].values.Len()
+	}
+
+	return size
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+
+	return err
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+
+	if w.offset == 0 {
+		if err := w.write(magic); err != nil {
+			return err
+		}
+	}
+
+	group := rowGroup{rows: int64(w.rows), columns: make([]columnChunk, len(w.columns))}
+	for i := range w.buffers {
+		chunk, err := w.writePage(&w.buffers[i])
+		if err != nil {
+			return err
+		}
+
+		group.columns[i] = chunk
+		group.bytes += chunk.uncompressedSize
+		w.buffers[i] = columnBuffer{}
+	}
+
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows = 0
+
+	return nil
+}
+
+func (w *Writer) writePage(b *columnBuffer) (columnChunk, error) {
+	levels := encodeLevels(b.levels)
+
+	var page bytes.Buffer
+	page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+	page.Write(levels)
+	if len(b.bools) > 0 {
+		page.Write(packBools(b.bools))
+	} else {
+		page.Write(b.values.Bytes())
+	}
+
+	data, err := w.compress(page.Bytes())
+	if err != nil {
+		return columnChunk{}, err
+	}
+
+	header := newThriftWriter()
+	header.i32(1, pageData)
+	header.i32(2, int32(page.Len()))
+	header.i32(3, int32(len(data)))
+	header.structField(5)
+	header.i32(1, int32(len(b.levels)))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.end()
+	header.end()
+
+	chunk := columnChunk{
+		offset:           w.offset,
+		values:           int64(len(b.levels)),
+		uncompressedSize: int64(len(header.buf) + page.Len()),
+		compressedSize:   int64(len(header.buf) + len(data)),
+	}
+
+	if err = w.write(header.buf); err != nil {
+		return chunk, err
+	}
+
+	return chunk, w.write(data)
+}
+
+// encodeLevels encodes the definition levels, of bit width 1, with the RLE runs of the RLE/bit-packing hybrid.
+func encodeLevels(levels []byte) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf = binary.AppendUvarint(buf, uint64(j-i)<<1)
+		buf = append(buf, levels[i])
+		i = j
+	}
+
+	return buf
+}
+
+func packBools(values []bool) []byte {
+	buf := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			buf[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	return buf
+}
+
+func (w *Writer) compress(data []byte) ([]byte, error) {
+	switch w.codec {
+	case Snappy:
+		return s2.EncodeSnappy(nil, data), nil
+	case Gzip:
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		if w.zstd == nil {
+			var err error
+			if w.zstd, err = zstd.NewWriter(nil); err != nil {
+				return nil, err
+			}
+		}
+		return w.zstd.EncodeAll(data, nil), nil
+	default:
+		return data, nil
+	}
+}
+
+func (w *Writer) codecID() int32 {
+	switch w.codec {
+	case Snappy:
+		return codecSnappy
+	case Gzip:
+		return codecGzip
+	case Zstd:
+		return codecZstd
+	default:
+		return codecUncompressed
+	}
+}
+
+// Close writes the remaining rows and the file footer, it doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.flush(); w.err != nil {
+		return w.err
+	}
+	if w.offset == 0 {
+		if w.err = w.write(magic); w.err != nil {
+			return w.err
+		}
+	}
+
+	footer := w.footer()
+	if w.err = w.write(footer); w.err != nil {
+		return w.err
+	}
+	if w.err = w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); w.err != nil {
+		return w.err
+	}
+	if w.err = w.write(magic); w.err != nil {
+		return w.err
+	}
+
+	if w.zstd != nil {
+		w.zstd.Close()
+	}
+	w.err = errClosed
+
+	return nil
+}
+
+// footer serializes the FileMetaData of the file.
+func (w *Writer) footer() []byte {
+	var rows int64
+	for _, g := range w.rowGroups {
+		rows += g.rows
+	}
+
+	t := newThriftWriter()
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(w.columns)+1)
+	t.begin()
+	t.binary(4, []byte("schema"))
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, c := range w.columns {
+		t.begin()
+		t.i32(1, c.physical())
+		t.i32(3, repetitionOptional)
+		t.binary(4, []byte(c.Name))
+		if conv, ok := c.converted(); ok {
+			t.i32(6, conv)
+		}
+		t.end()
+	}
+
+	t.i64(3, rows)
+
+	t.list(4, thriftStruct, len(w.rowGroups))
+	for _, g := range w.rowGroups {
+		t.begin()
+		t.list(1, thriftStruct, len(g.columns))
+		for i, chunk := range g.columns {
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, w.columns[i].physical())
+			t.i32List(2, encodingPlain, encodingRLE)
+			t.binaryList(3, w.columns[i].Name)
+			t.i32(4, w.codecID())
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.uncompressedSize)
+			t.i64(7, chunk.compressedSize)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, g.bytes)
+		t.i64(3, g.rows)
+		t.end()
+	}
+
+	t.binary(6, []byte("tigris"))
+	t.end()
+
+	return t.buf
+}
@@ -0,0 +1,271 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+// tstruct is a decoded thrift struct, by field id.
+type tstruct map[int16]any
+
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		r.pos += n
+		return r.b[r.pos-n : r.pos]
+	case thriftList:
+		h := r.b[r.pos]
+		r.pos++
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	default:
+		panic("unexpected thrift type")
+	}
+}
+
+func (r *thriftReader) structure() tstruct {
+	s := tstruct{}
+	var last int16
+	for {
+		h := r.b[r.pos]
+		r.pos++
+		if h == 0 {
+			return s
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.zigzag())
+		}
+		s[last] = r.value(h & 0x0f)
+	}
+}
+
+func decompress(t *testing.T, codec int64, data []byte) []byte {
+	switch codec {
+	case codecSnappy:
+		out, err := s2.Decode(nil, data)
+		require.NoError(t, err)
+		return out
+	case codecGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		out, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		return out
+	case codecZstd:
+		dec, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		defer dec.Close()
+		out, err := dec.DecodeAll(data, nil)
+		require.NoError(t, err)
+		return out
+	default:
+		return data
+	}
+}
+
+// readFile decodes the files produced by the writer, it returns the schema elements and the values of the rows.
+func readFile(t *testing.T, file []byte) ([]tstruct, [][]any) {
+	require.Equal(t, magic, file[:4])
+	require.Equal(t, magic, file[len(file)-4:])
+
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	r := &thriftReader{b: file[len(file)-8-footerLen : len(file)-8]}
+	meta := r.structure()
+	require.Equal(t, footerLen, r.pos)
+	require.Equal(t, int64(1), meta[1])
+	require.Equal(t, []byte("tigris"), meta[6])
+
+	var schema []tstruct
+	for _, e := range meta[2].([]any) {
+		schema = append(schema, e.(tstruct))
+	}
+	columns := schema[1:]
+
+	var rows [][]any
+	for _, g := range meta[4].([]any) {
+		group := g.(tstruct)
+		numRows := int(group[3].(int64))
+		groupRows := make([][]any, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]any, len(columns))
+		}
+
+		for c, cc := range group[1].([]any) {
+			md := cc.(tstruct)[3].(tstruct)
+			require.Equal(t, []any{[]byte(columns[c][4].([]byte))}, md[3])
+			require.Equal(t, cc.(tstruct)[2], md[9])
+
+			pr := &thriftReader{b: file, pos: int(md[9].(int64))}
+			header := pr.structure()
+			require.Equal(t, int64(pageData), header[1])
+			data := decompress(t, md[4].(int64), file[pr.pos:pr.pos+int(header[3].(int64))])
+			require.Len(t, data, int(header[2].(int64)))
+			require.Equal(t, md[7], int64(pr.pos)+header[3].(int64)-md[9].(int64))
+
+			levelsLen := int(binary.LittleEndian.Uint32(data))
+			lr := &thriftReader{b: data[4 : 4+levelsLen]}
+			var levels []byte
+			for lr.pos < len(lr.b) {
+				run := int(lr.varint() >> 1)
+				levels = append(levels, bytes.Repeat([]byte{lr.b[lr.pos]}, run)...)
+				lr.pos++
+			}
+			require.Len(t, levels, numRows)
+
+			values, bit := data[4+levelsLen:], 0
+			for i, l := range levels {
+				if l == 0 {
+					continue
+				}
+				switch columns[c][1].(int64) {
+				case physicalBoolean:
+					groupRows[i][c] = values[bit/8]&(1<<(bit%8)) != 0
+					bit++
+				case physicalInt64:
+					groupRows[i][c] = int64(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case physicalDouble:
+					groupRows[i][c] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case physicalByteArray:
+					n := binary.LittleEndian.Uint32(values)
+					groupRows[i][c] = string(values[4 : 4+n])
+					values = values[4+n:]
+				}
+			}
+		}
+
+		rows = append(rows, groupRows...)
+	}
+	require.Equal(t, int64(len(rows)), meta[3])
+
+	return schema, rows
+}
+
+func TestWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "name", Type: String},
+		{Name: "score", Type: Double},
+		{Name: "active", Type: Boolean},
+		{Name: "tags", Type: JSON},
+		{Name: "created", Type: Timestamp},
+		{Name: "raw", Type: Bytes},
+	}
+	created := time.Date(2023, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	input := [][]any{
+		{int64(1), "a", 1.5, true, `["x"]`, created, []byte{0, 1}},
+		{int64(2), nil, nil, false, nil, nil, nil},
+		{nil, "c", -2.0, nil, `{}`, created, []byte{}},
+	}
+	for i := 0; i < 20; i++ {
+		input = append(input, []any{int64(i), "row", nil, i%3 == 0, nil, nil, nil})
+	}
+
+	for _, codec := range []Codec{Uncompressed, Snappy, Gzip, Zstd} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, columns, codec)
+		w.RowGroupSize = 7
+		for _, row := range input {
+			require.NoError(t, w.Write(row))
+		}
+		require.NoError(t, w.Close())
+		require.Error(t, w.Write(input[0]))
+
+		schema, rows := readFile(t, buf.Bytes())
+		require.Equal(t, tstruct{4: []byte("schema"), 5: int64(7)}, schema[0])
+		require.Equal(t, tstruct{1: int64(physicalInt64), 3: int64(repetitionOptional), 4: []byte("id")}, schema[1])
+		require.Equal(t, tstruct{1: int64(physicalByteArray), 3: int64(repetitionOptional), 4: []byte("name"), 6: int64(convertedUTF8)}, schema[2])
+		require.Equal(t, int64(convertedJSON), schema[5][6])
+		require.Equal(t, int64(convertedTimestampMicros), schema[6][6])
+
+		require.Len(t, rows, len(input))
+		require.Equal(t, []any{int64(1), "a", 1.5, true, `["x"]`, created.UnixMicro(), "\x00\x01"}, rows[0])
+		require.Equal(t, []any{int64(2), nil, nil, false, nil, nil, nil}, rows[1])
+		require.Equal(t, []any{nil, "c", -2.0, nil, `{}`, created.UnixMicro(), ""}, rows[2])
+		require.Equal(t, []any{int64(19), "row", nil, false, nil, nil, nil}, rows[22])
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}}, Snappy)
+	require.NoError(t, w.Close())
+
+	schema, rows := readFile(t, buf.Bytes())
+	require.Len(t, schema, 2)
+	require.Empty(t, rows)
+}
+
+func TestWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}, {Name: "raw", Type: Bytes}}, Uncompressed)
+	require.Error(t, w.Write([]any{int64(1)}))
+	require.Error(t, w.Write([]any{"1", nil}))
+	require.Error(t, w.Write([]any{int64(1), "not bytes"}))
+
+	// the rejected rows are not written
+	require.NoError(t, w.Write([]any{int64(2), []byte("b")}))
+	require.NoError(t, w.Close())
+
+	_, rows := readFile(t, buf.Bytes())
+	require.Equal(t, [][]any{{int64(2), "b"}}, rows)
+}
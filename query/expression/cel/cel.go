@@ -0,0 +1,239 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cel compiles and evaluates the CEL (Common Expression Language) expressions used by the collection schemas,
+// the computed default values of the fields, the values of the row-level security policies and the write hooks, so
+// all of them share the same syntax and the same evaluator instead of a mini-language each.
+package cel
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Key is the key of the JSON object written in place of a value to compute it with an expression, for example
+// {"$cel": "doc.first + ' ' + doc.last"}.
+const Key = "$cel"
+
+// Variables of the expressions.
+const (
+	// VarDoc is the document, an object keyed by the names of its fields.
+	VarDoc = "doc"
+	// VarToken are the claims of the access token the request is authenticated with, empty if auth is disabled.
+	VarToken = "token"
+	// VarNow is the time the expression is evaluated at.
+	VarNow = "now"
+)
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+
+	cacheOnce sync.Once
+	programs  gcache.Cache
+)
+
+// Program is a compiled expression, it is safe to evaluate it concurrently.
+type Program struct {
+	// Expr is the source of the expression.
+	Expr string
+
+	program cel.Program
+	output  *cel.Type
+}
+
+func getEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable(VarDoc, cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable(VarToken, cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable(VarNow, cel.TimestampType),
+			ext.Strings(),
+		)
+	})
+
+	return env, envErr
+}
+
+func getCache() gcache.Cache {
+	cacheOnce.Do(func() {
+		programs = gcache.New(config.DefaultConfig.CEL.CacheSize).LRU().Build()
+	})
+
+	return programs
+}
+
+// Compile returns the program of the expression. The programs are cached by the expression, the same expression used
+// by many collections, or evaluated for every document of a write, is compiled once.
+func Compile(expr string) (*Program, error) {
+	if p, err := getCache().Get(expr); err == nil {
+		return p.(*Program), nil
+	}
+
+	e, err := getEnv()
+	if err != nil {
+		return nil, errors.Internal("failed to create the expression environment: %s", err.Error())
+	}
+
+	ast, issues := e.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.InvalidArgument("invalid expression '%s': %s", expr, issues.Err().Error())
+	}
+
+	opts := []cel.ProgramOption{cel.InterruptCheckFrequency(100)}
+	if limit := config.DefaultConfig.CEL.CostLimit; limit > 0 {
+		opts = append(opts, cel.CostLimit(limit))
+	}
+	prg, err := e.Program(ast, opts...)
+	if err != nil {
+		return nil, errors.InvalidArgument("invalid expression '%s': %s", expr, err.Error())
+	}
+
+	p := &Program{Expr: expr, program: prg, output: ast.OutputType()}
+	_ = getCache().Set(expr, p)
+
+	return p, nil
+}
+
+// CompileBool compiles the expression which value is a boolean, like the conditions of the write hooks.
+func CompileBool(expr string) (*Program, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.output.String(); t != cel.BoolType.String() && t != cel.DynType.String() {
+		return nil, errors.InvalidArgument("expression '%s' should be a condition, its type is '%s'", expr, t)
+	}
+
+	return p, nil
+}
+
+// Vars are the values of the variables the expression is evaluated with.
+type Vars struct {
+	Doc   map[string]any
+	Token map[string]any
+}
+
+// Eval evaluates the expression and returns its value as a JSON value, i.e. nil, a bool, an int64, a float64, a
+// string, a []any or a map[string]any. A timestamp is returned in the RFC 3339 format. The evaluation is aborted if
+// it exceeds the configured cost limit or if the context is done.
+func (p *Program) Eval(ctx context.Context, vars Vars) (any, error) {
+	if vars.Doc == nil {
+		vars.Doc = map[string]any{}
+	}
+	if vars.Token == nil {
+		vars.Token = map[string]any{}
+	}
+
+	out, _, err := p.program.ContextEval(ctx, map[string]any{
+		VarDoc:   normalize(vars.Doc),
+		VarToken: normalize(vars.Token),
+		VarNow:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, errors.InvalidArgument("failed to evaluate the expression '%s': %s", p.Expr, err.Error())
+	}
+
+	return toJSONValue(out)
+}
+
+// EvalBool evaluates the condition, a value which is not a boolean fails the evaluation.
+func (p *Program) EvalBool(ctx context.Context, vars Vars) (bool, error) {
+	v, err := p.Eval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.InvalidArgument("expression '%s' should be a condition, its value is '%v'", p.Expr, v)
+	}
+
+	return b, nil
+}
+
+// Snapshot returns a copy of the document as the expressions see it, it is used to evaluate the expressions with a
+// document which is modified while they are evaluated.
+func Snapshot(doc map[string]any) map[string]any {
+	return normalize(doc).(map[string]any)
+}
+
+// normalize returns the value with the numbers decoded as json.Number converted to int64 or float64, the maps and the
+// arrays are copied so that the document of the caller is not modified.
+func normalize(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[k] = normalize(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(t))
+		for i, e := range t {
+			a[i] = normalize(e)
+		}
+		return a
+	}
+
+	return v
+}
+
+var jsonValueType = reflect.TypeOf(&structpb.Value{})
+
+func toJSONValue(v ref.Val) (any, error) {
+	switch t := v.(type) {
+	case types.Null:
+		return nil, nil
+	case types.Bool:
+		return bool(t), nil
+	case types.Int:
+		return int64(t), nil
+	case types.Uint:
+		return int64(t), nil
+	case types.Double:
+		return float64(t), nil
+	case types.String:
+		return string(t), nil
+	case types.Timestamp:
+		return t.Time.UTC().Format(time.RFC3339Nano), nil
+	}
+
+	native, err := v.ConvertToNative(jsonValueType)
+	if err != nil {
+		return nil, errors.InvalidArgument("unsupported value of type '%s' of an expression", v.Type().TypeName())
+	}
+
+	return native.(*structpb.Value).AsInterface(), nil
+}
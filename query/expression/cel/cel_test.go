@@ -0,0 +1,135 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cel
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestEval(t *testing.T) {
+	vars := Vars{
+		Doc: map[string]any{
+			"first": "Ann", "last": "Lee", "code": "ab", "total": json.Number("10"), "discount": json.Number("2.5"),
+			"tags": []any{"a", "b"},
+		},
+		Token: map[string]any{"tenant": "t1", "regions": []any{"US", "Eu"}},
+	}
+
+	cases := []struct {
+		expr     string
+		expected any
+	}{
+		{"doc.first + ' ' + doc.last", "Ann Lee"},
+		{"doc.code.upperAscii()", "AB"},
+		{"doc.discount <= doc.total", true},
+		{"doc.total * 2", int64(20)},
+		{"doc.discount * 2.0", float64(5)},
+		{"doc.tags.size()", int64(2)},
+		{"has(doc.missing) ? doc.missing : null", nil},
+		{"token.tenant", "t1"},
+		{"token.regions.map(r, r.lowerAscii())", []any{"us", "eu"}},
+		{"{'tenant': token.tenant, 'tags': doc.tags}", map[string]any{"tenant": "t1", "tags": []any{"a", "b"}}},
+		{"timestamp('2023-01-02T03:04:05Z')", "2023-01-02T03:04:05Z"},
+	}
+	for _, c := range cases {
+		p, err := Compile(c.expr)
+		require.NoError(t, err, c.expr)
+
+		v, err := p.Eval(context.Background(), vars)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, c.expected, v, c.expr)
+	}
+
+	t.Run("now", func(t *testing.T) {
+		p, err := Compile("now")
+		require.NoError(t, err)
+
+		v, err := p.Eval(context.Background(), Vars{})
+		require.NoError(t, err)
+		now, err := time.Parse(time.RFC3339Nano, v.(string))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), now, time.Minute)
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		p, err := Compile("token.sub")
+		require.NoError(t, err)
+
+		_, err = p.Eval(context.Background(), Vars{})
+		require.Error(t, err)
+	})
+
+	t.Run("document is not modified", func(t *testing.T) {
+		doc := map[string]any{"total": json.Number("10"), "nested": map[string]any{"n": json.Number("1")}}
+		snapshot := Snapshot(doc)
+		require.Equal(t, map[string]any{"total": int64(10), "nested": map[string]any{"n": int64(1)}}, snapshot)
+		require.Equal(t, json.Number("10"), doc["total"])
+		require.Equal(t, json.Number("1"), doc["nested"].(map[string]any)["n"])
+	})
+}
+
+func TestCompile(t *testing.T) {
+	p, err := Compile("doc.a + doc.b")
+	require.NoError(t, err)
+
+	cached, err := Compile("doc.a + doc.b")
+	require.NoError(t, err)
+	require.Same(t, p, cached)
+
+	for _, invalid := range []string{"doc.a +", "unknown.a", "doc.a.noSuchFunction()"} {
+		_, err = Compile(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	_, err = CompileBool("doc.a > 1")
+	require.NoError(t, err)
+	// the type of the fields is only known when the expression is evaluated
+	p, err = CompileBool("doc.a")
+	require.NoError(t, err)
+
+	_, err = p.EvalBool(context.Background(), Vars{Doc: map[string]any{"a": "x"}})
+	require.Equal(t, errors.InvalidArgument("expression 'doc.a' should be a condition, its value is 'x'"), err)
+
+	_, err = CompileBool("'x' + doc.a")
+	require.Equal(t, errors.InvalidArgument("expression ''x' + doc.a' should be a condition, its type is 'string'"), err)
+}
+
+func TestCostLimit(t *testing.T) {
+	limit := config.DefaultConfig.CEL.CostLimit
+	config.DefaultConfig.CEL.CostLimit = 1000
+	defer func() { config.DefaultConfig.CEL.CostLimit = limit }()
+
+	p, err := Compile("doc.a.map(x, doc.a.map(y, x * y)).size()")
+	require.NoError(t, err)
+
+	a := make([]any, 100)
+	for i := range a {
+		a[i] = int64(i)
+	}
+
+	_, err = p.Eval(context.Background(), Vars{Doc: map[string]any{"a": a}})
+	require.Error(t, err)
+
+	v, err := p.Eval(context.Background(), Vars{Doc: map[string]any{"a": []any{int64(1), int64(2)}}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), v)
+}
@@ -0,0 +1,80 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// Evaluate computes the value of the index expression for the document. The same evaluation is used to build the
+// entries of an expression index and to match the documents against the filters on the expression, so a document is
+// found by the index if and only if it matches the filter. A missing or null field, or a date time that can't be
+// parsed, evaluates to null.
+func Evaluate(expr *schema.IndexExpression, doc []byte, collation *value.Collation) (value.Value, error) {
+	raw, dt, _, err := jsonparser.Get(doc, strings.Split(expr.Field, ".")...)
+	if dt == jsonparser.NotExist || dt == jsonparser.Null {
+		return value.NewNullValue(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dt != jsonparser.String {
+		return nil, errors.InvalidArgument("function '%s' expects a string value of the field '%s'", expr.Function, expr.Field)
+	}
+
+	str, err := jsonparser.ParseString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return Apply(expr, str, collation), nil
+}
+
+// Apply computes the value of the index expression for the string value of its field.
+func Apply(expr *schema.IndexExpression, str string, collation *value.Collation) value.Value {
+	switch expr.Function {
+	case schema.ExprLower:
+		return value.NewStringValue(strings.ToLower(str), collation)
+	case schema.ExprUpper:
+		return value.NewStringValue(strings.ToUpper(str), collation)
+	}
+
+	t, ok := value.NewDateTimeValue(str).Time()
+	if !ok {
+		return value.NewNullValue()
+	}
+
+	return value.NewIntValue(datePart(expr.Function, t))
+}
+
+// datePart returns the part of the time in the offset with which the value was stored.
+func datePart(function string, t time.Time) int64 {
+	switch function {
+	case schema.ExprExtractYear:
+		return int64(t.Year())
+	case schema.ExprExtractMonth:
+		return int64(t.Month())
+	case schema.ExprExtractDay:
+		return int64(t.Day())
+	default:
+		return int64(t.Hour())
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+func TestEvaluate(t *testing.T) {
+	doc := []byte(`{"email": "Jane.Doe@Example.COM", "created": "2023-02-05T23:30:00-08:00", "address": {"city": "Paris"}, "bad_date": "yesterday", "age": 5}`)
+
+	cases := []struct {
+		expr     string
+		expValue value.Value
+	}{
+		{"lower(email)", value.NewStringValue("jane.doe@example.com", nil)},
+		{"upper(address.city)", value.NewStringValue("PARIS", nil)},
+		{"extract_year(created)", value.NewIntValue(2023)},
+		{"extract_month(created)", value.NewIntValue(2)},
+		// the parts are in the offset with which the date time is stored
+		{"extract_day(created)", value.NewIntValue(5)},
+		{"extract_hour(created)", value.NewIntValue(23)},
+		{"extract_year(bad_date)", value.NewNullValue()},
+		{"lower(missing)", value.NewNullValue()},
+	}
+	for _, c := range cases {
+		expr, err := schema.ParseIndexExpression(c.expr)
+		require.NoError(t, err)
+
+		v, err := Evaluate(expr, doc, nil)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, c.expValue, v, c.expr)
+	}
+
+	expr, err := schema.ParseIndexExpression("lower(age)")
+	require.NoError(t, err)
+	_, err = Evaluate(expr, doc, nil)
+	require.Error(t, err)
+}
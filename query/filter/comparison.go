@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 
 	"github.com/tigrisdata/tigris/errors"
@@ -354,6 +355,30 @@ func (*RegexMatcher) Type() string {
 	return "$regex"
 }
 
+// AnchoredPrefix returns the literal text the strings matched by the regex start with, it is empty if the regex is not
+// anchored at the beginning of the text. The case of the prefix is ignored by a case-insensitive regex.
+func (c *RegexMatcher) AnchoredPrefix() string {
+	re, err := syntax.Parse(c.regex.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+
+	var prefix strings.Builder
+	for _, sub := range re.Sub[1:] {
+		if sub.Op != syntax.OpLiteral {
+			break
+		}
+		prefix.WriteString(string(sub.Rune))
+	}
+
+	return prefix.String()
+}
+
 func (c *RegexMatcher) String() string {
 	return fmt.Sprintf("{regex:%v}", c.regex.String())
 }
@@ -382,6 +407,9 @@ func (c *ContainsMatcher) Matches(docValue any) bool {
 			}
 		}
 	case []byte:
+		if c.collation.IsAccentInsensitive() {
+			return StringContains(string(dv), c.value, c.collation)
+		}
 		if c.collation.IsCaseInsensitive() {
 			return bytes.Contains(bytes.ToLower(dv), bytes.ToLower([]byte(c.value)))
 		}
@@ -423,6 +451,9 @@ func (n *NotMatcher) Matches(docValue any) bool {
 		}
 		return true
 	case []byte:
+		if n.collation.IsAccentInsensitive() {
+			return !StringContains(string(dv), n.value, n.collation)
+		}
 		if n.collation.IsCaseInsensitive() {
 			return !bytes.Contains(bytes.ToLower(dv), bytes.ToLower([]byte(n.value)))
 		}
@@ -440,12 +471,17 @@ func (n *NotMatcher) String() string {
 }
 
 func StringContains(s string, substr string, collation *value.Collation) bool {
-	if collation.IsCaseInsensitive() {
-		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	if collation.IsCaseInsensitive() || collation.IsAccentInsensitive() {
+		return strings.Contains(collation.Fold(s), collation.Fold(substr))
 	}
 	return strings.Contains(s, substr)
 }
 
 func MatcherForArray(matcher ValueMatcher) bool {
+	if _, ok := matcher.(SetMatcher); ok {
+		// the set of values is compared with the elements of an array field
+		return false
+	}
+
 	return matcher.GetValue().DataType() == schema.ArrayType
 }
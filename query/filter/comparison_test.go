@@ -219,6 +219,25 @@ func TestLikeMatcher(t *testing.T) {
 			require.Equal(t, c.expMatch, r.Matches(c.input))
 		}
 	})
+	t.Run("anchored prefix", func(t *testing.T) {
+		cases := []struct {
+			regex  string
+			prefix string
+		}{
+			{"^abc", "abc"},
+			{"^abc.*", "abc"},
+			{"^ab+c", "a"},
+			{"(?i)^AbC", "ABC"},
+			{"abc", ""},
+			{"^a|^b", ""},
+			{"^(abc)", ""},
+		}
+		for _, c := range cases {
+			r, err := NewRegexMatcher(c.regex, value.NewCollation())
+			require.NoError(t, err)
+			require.Equal(t, c.prefix, r.(*RegexMatcher).AnchoredPrefix(), c.regex)
+		}
+	})
 }
 
 func mustMatcher(key string, v value.Value) ValueMatcher {
@@ -0,0 +1,226 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	ulog "github.com/tigrisdata/tigris/util/log"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// Date part operators extract a part of a date time in the offset with which the value was stored and compare it
+// with an integer, for example {"created": {"$dayOfWeek": 1}} or {"created": {"$hour": {"$gte": 9, "$lt": 17}}}.
+const (
+	YearOP       = "$year"
+	MonthOP      = "$month"
+	DayOfMonthOP = "$dayOfMonth"
+	DayOfWeekOP  = "$dayOfWeek"
+	DayOfYearOP  = "$dayOfYear"
+	HourOP       = "$hour"
+	MinuteOP     = "$minute"
+	SecondOP     = "$second"
+)
+
+// datePartExtractors returns the part of the time. The day of the week starts from Sunday i.e. 1 is Sunday and 7 is
+// Saturday. Month, day of the month and day of the year also start from 1.
+var datePartExtractors = map[string]func(t time.Time) int64{
+	YearOP:       func(t time.Time) int64 { return int64(t.Year()) },
+	MonthOP:      func(t time.Time) int64 { return int64(t.Month()) },
+	DayOfMonthOP: func(t time.Time) int64 { return int64(t.Day()) },
+	DayOfWeekOP:  func(t time.Time) int64 { return int64(t.Weekday()) + 1 },
+	DayOfYearOP:  func(t time.Time) int64 { return int64(t.YearDay()) },
+	HourOP:       func(t time.Time) int64 { return int64(t.Hour()) },
+	MinuteOP:     func(t time.Time) int64 { return int64(t.Minute()) },
+	SecondOP:     func(t time.Time) int64 { return int64(t.Second()) },
+}
+
+// DatePartMatcher matches a single part of a date time against all the comparison matchers.
+type DatePartMatcher struct {
+	Part     string
+	Matchers []ValueMatcher
+}
+
+func (d *DatePartMatcher) Matches(t time.Time) bool {
+	part := value.NewIntValue(datePartExtractors[d.Part](t))
+	for _, m := range d.Matchers {
+		if !m.Matches(part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *DatePartMatcher) String() string {
+	return fmt.Sprintf("{%s:%v}", d.Part, d.Matchers)
+}
+
+// DatePartFilter is a filter on the parts of a date time field. Similar to LikeFilter, it is not used to create any
+// key, it is always used to post-process the records.
+type DatePartFilter struct {
+	Field    *schema.QueryableField
+	Matchers []*DatePartMatcher
+}
+
+func NewDatePartFilter(field *schema.QueryableField, matchers []*DatePartMatcher) *DatePartFilter {
+	return &DatePartFilter{
+		Field:    field,
+		Matchers: matchers,
+	}
+}
+
+func (d *DatePartFilter) MatchesDoc(doc map[string]any) bool {
+	v, ok := doc[d.Field.Name()].(string)
+	if !ok {
+		return true
+	}
+
+	return d.matches(value.NewDateTimeValue(v))
+}
+
+// Matches returns true if the date time value in the doc matches all the date part matchers.
+func (d *DatePartFilter) Matches(doc []byte, metadata []byte) bool {
+	docValue, dtp, err := getJSONField(doc, metadata, d.Field.FieldName, d.Field.KeyPath())
+	if dtp == jsonparser.NotExist || dtp == jsonparser.Null {
+		return false
+	}
+	if ulog.E(err) {
+		return false
+	}
+
+	return d.matches(value.NewDateTimeValue(string(docValue)))
+}
+
+func (d *DatePartFilter) matches(v *value.DateTimeValue) bool {
+	t, ok := v.Time()
+	if !ok {
+		return false
+	}
+
+	for _, m := range d.Matchers {
+		if !m.Matches(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (*DatePartFilter) ToSearchFilter() string {
+	return ""
+}
+
+func (*DatePartFilter) IsSearchIndexed() bool {
+	return false
+}
+
+func (d *DatePartFilter) String() string {
+	return fmt.Sprintf("{%v:%v}", d.Field.Name(), d.Matchers)
+}
+
+func isDatePartOperator(key string) bool {
+	_, ok := datePartExtractors[key]
+	return ok
+}
+
+// hasDatePartOperator returns true if the object passed as a value of a selector contains a date part operator.
+func hasDatePartOperator(input jsoniter.RawMessage) bool {
+	found := false
+	_ = jsonparser.ObjectEach(input, func(key []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		found = found || isDatePartOperator(string(key))
+		return nil
+	})
+
+	return found
+}
+
+// buildDatePartFilter builds the filter from an object like {"$dayOfWeek": 2, "$hour": {"$gte": 9}}. The value of
+// the date part operator is either an integer, which is an equality match, or an object of comparison operators.
+func buildDatePartFilter(field *schema.QueryableField, input jsoniter.RawMessage) (Filter, error) {
+	if field.DataType != schema.DateTimeType {
+		return nil, errors.InvalidArgument("date part operators are only supported on date time fields, found field '%s' of type '%s'",
+			field.FieldName, schema.FieldNames[field.DataType])
+	}
+
+	var matchers []*DatePartMatcher
+	err := jsonparser.ObjectEach(input, func(key []byte, v []byte, dataType jsonparser.ValueType, _ int) error {
+		part := string(key)
+		if !isDatePartOperator(part) {
+			return errors.InvalidArgument("date part operators can't be combined with '%s'", part)
+		}
+
+		m := &DatePartMatcher{Part: part}
+		switch dataType {
+		case jsonparser.Number:
+			val, err := datePartValue(part, v)
+			if err != nil {
+				return err
+			}
+			m.Matchers = append(m.Matchers, NewEqualityMatcher(val))
+		case jsonparser.Object:
+			if err := jsonparser.ObjectEach(v, func(op []byte, opValue []byte, opType jsonparser.ValueType, _ int) error {
+				if opType != jsonparser.Number {
+					return errors.InvalidArgument("date part operator '%s' expects an integer value", part)
+				}
+				val, err := datePartValue(part, opValue)
+				if err != nil {
+					return err
+				}
+
+				switch string(op) {
+				case EQ, GT, GTE, LT, LTE:
+					matcher, err := NewMatcher(string(op), val)
+					if err != nil {
+						return err
+					}
+					m.Matchers = append(m.Matchers, matcher)
+				default:
+					return errors.InvalidArgument("unsupported operand '%s' for date part operator '%s'", string(op), part)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if len(m.Matchers) == 0 {
+				return errors.InvalidArgument("empty object for date part operator '%s'", part)
+			}
+		default:
+			return errors.InvalidArgument("date part operator '%s' expects an integer or an object of comparison operators", part)
+		}
+
+		matchers = append(matchers, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDatePartFilter(field, matchers), nil
+}
+
+func datePartValue(part string, v []byte) (value.Value, error) {
+	i, err := jsonparser.ParseInt(v)
+	if err != nil {
+		return nil, errors.InvalidArgument("date part operator '%s' expects an integer value", part)
+	}
+
+	return value.NewIntValue(i), nil
+}
@@ -16,6 +16,7 @@ package filter
 
 import (
 	"bytes"
+	"encoding/base64"
 	"strings"
 
 	"github.com/buger/jsonparser"
@@ -106,10 +107,57 @@ func (w *WrappedFilter) IsSearchIndexed() bool {
 	return w.Filter.IsSearchIndexed()
 }
 
+// FieldOperators returns the operators of the conditions of the filter on the field, including the conditions nested
+// in the logical filters.
+func (w *WrappedFilter) FieldOperators(field string) []string {
+	return fieldOperators(w.Filter, field, nil)
+}
+
+func fieldOperators(f Filter, field string, operators []string) []string {
+	switch ft := f.(type) {
+	case *Selector:
+		if ft.Field.Name() == field {
+			operators = append(operators, ft.Matcher.Type())
+		}
+	case LogicalFilter:
+		for _, nested := range ft.GetFilters() {
+			operators = fieldOperators(nested, field, operators)
+		}
+	}
+
+	return operators
+}
+
 func None(reqFilter []byte) bool {
 	return len(reqFilter) == 0 || bytes.Equal(reqFilter, filterNone)
 }
 
+// HasOrderingCollation returns true if a condition of the filter has a collation which changes the default ordering
+// of the strings, i.e. a locale, accent insensitive or numeric collation. Such a condition can't be served by the
+// secondary indexes as their keys are the sort keys of the default collation.
+func HasOrderingCollation(reqFilter []byte) bool {
+	found := false
+	_ = jsonparser.ObjectEach(reqFilter, func(k []byte, v []byte, dataType jsonparser.ValueType, _ int) error {
+		switch {
+		case string(k) == api.CollationKey:
+			if collation, err := buildCollation(reqFilter, nil, false); err == nil && collation.HasOrderingOptions() {
+				found = true
+			}
+		case dataType == jsonparser.Object:
+			found = found || HasOrderingCollation(v)
+		case dataType == jsonparser.Array:
+			_, _ = jsonparser.ArrayEach(v, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+				if itemType == jsonparser.Object {
+					found = found || HasOrderingCollation(item)
+				}
+			})
+		}
+		return nil
+	})
+
+	return found
+}
+
 type Factory struct {
 	fields    []*schema.QueryableField
 	collation *value.Collation
@@ -272,6 +320,10 @@ func (factory *Factory) filterToQueryableField(filterField string) (*schema.Quer
 // call this because if it is not logical then it is simply a Selector filter.
 func (factory *Factory) ParseSelector(k []byte, v []byte, dataType jsonparser.ValueType) (Filter, error) {
 	filterField := string(k)
+	if schema.IsIndexExpression(filterField) {
+		return factory.parseExpressionSelector(filterField, v, dataType)
+	}
+
 	field, parent := factory.filterToQueryableField(filterField)
 	if field == nil {
 		// try level - 1
@@ -291,10 +343,19 @@ func (factory *Factory) ParseSelector(k []byte, v []byte, dataType jsonparser.Va
 	if field == nil {
 		return nil, errors.InvalidArgument("querying on non schema field '%s'", string(k))
 	}
+	if field.Encrypted {
+		return nil, errors.InvalidArgument("filtering on encrypted field '%s' is not supported", string(k))
+	}
+	if field.DataType == schema.GeoPointType && (dataType != jsonparser.Object || !hasGeoOperator(v)) {
+		return nil, errors.InvalidArgument("only geo operators are supported on geopoint field '%s'", string(k))
+	}
 
 	switch dataType {
 	case jsonparser.Boolean, jsonparser.Number, jsonparser.String, jsonparser.Array, jsonparser.Null:
 		tigrisType := toTigrisType(field, dataType)
+		if err := validateBytesLiteral(field, tigrisType, v, dataType); err != nil {
+			return nil, err
+		}
 
 		if dataType == jsonparser.Null {
 			// need to explicitly set as nil otherwise, jsonparser is setting it as []byte{null}
@@ -314,6 +375,13 @@ func (factory *Factory) ParseSelector(k []byte, v []byte, dataType jsonparser.Va
 
 		return NewSelector(parent, field, NewEqualityMatcher(val), factory.collation), nil
 	case jsonparser.Object:
+		if hasDatePartOperator(v) {
+			return buildDatePartFilter(field, v)
+		}
+		if hasGeoOperator(v) {
+			return buildGeoFilter(field, v)
+		}
+
 		valueMatcher, likeMatcher, collation, err := buildValueMatcher(v, field, factory.collation, factory.buildForSecondaryIndex)
 		if err != nil {
 			return nil, err
@@ -331,6 +399,53 @@ func (factory *Factory) ParseSelector(k []byte, v []byte, dataType jsonparser.Va
 	}
 }
 
+// parseExpressionSelector parses the filter on an expression like {"lower(email)": "a@b.c"}, the value of the
+// expression is computed from the documents, see schema.IndexExpression. Only the comparison operators are supported.
+func (factory *Factory) parseExpressionSelector(filterField string, v []byte, dataType jsonparser.ValueType) (Filter, error) {
+	expr, err := schema.ParseIndexExpression(filterField)
+	if err != nil {
+		return nil, err
+	}
+
+	// the filters used to plan the secondary index queries have the expressions of the indexes as fields
+	field, _ := factory.filterToQueryableField(expr.String())
+	if field == nil {
+		arg, _ := factory.filterToQueryableField(expr.Field)
+		if arg == nil {
+			return nil, errors.InvalidArgument("querying on non schema field '%s'", expr.Field)
+		}
+		if arg.Encrypted {
+			return nil, errors.InvalidArgument("filtering on encrypted field '%s' is not supported", expr.Field)
+		}
+		if err = expr.Validate(arg.DataType); err != nil {
+			return nil, err
+		}
+		field = schema.NewExpressionQueryableField(expr)
+	}
+
+	var valueMatcher ValueMatcher
+	switch dataType {
+	case jsonparser.Boolean, jsonparser.Number, jsonparser.String, jsonparser.Null:
+		val, err := buildFilterValue(field, v, dataType, factory.collation, factory.collation, factory.buildForSecondaryIndex)
+		if err != nil {
+			return nil, err
+		}
+		valueMatcher = NewEqualityMatcher(val)
+	case jsonparser.Object:
+		var likeMatcher LikeMatcher
+		if valueMatcher, likeMatcher, _, err = buildValueMatcher(v, field, factory.collation, factory.buildForSecondaryIndex); err != nil {
+			return nil, err
+		}
+		if likeMatcher != nil || valueMatcher == nil {
+			return nil, errors.InvalidArgument("only comparison operators are supported on the expression '%s'", filterField)
+		}
+	default:
+		return nil, errors.InvalidArgument("unable to parse the comparison operator")
+	}
+
+	return NewSelector(nil, field, valueMatcher, factory.collation), nil
+}
+
 // buildValueMatcher is a helper method to create a value matcher object when the value of a Selector is an object
 // instead of a simple JSON value. Apart from comparison operators, this object can have its own collation, which
 // needs to be honored at the field level. Therefore, the caller needs to check if the collation returned by the
@@ -359,24 +474,51 @@ func buildValueMatcher(input jsoniter.RawMessage, field *schema.QueryableField,
 		case EQ, GT, GTE, LT, LTE:
 			switch dataType {
 			case jsonparser.Boolean, jsonparser.Number, jsonparser.String, jsonparser.Null, jsonparser.Array:
-				tigrisType := toTigrisType(field, dataType)
-
 				var val value.Value
-				//nolint:gocritic
-				if buildForSecondaryIndex {
-					val, err = value.NewValueUsingCollation(tigrisType, v, factoryCollation)
-				} else if collation != nil {
-					val, err = value.NewValueUsingCollation(tigrisType, v, collation)
-				} else {
-					val, err = value.NewValue(tigrisType, v)
-				}
-				if err != nil {
+				if val, err = buildFilterValue(field, v, dataType, factoryCollation, collation, buildForSecondaryIndex); err != nil {
 					return err
 				}
 
 				valueMatcher, err = NewMatcher(string(key), val)
 				return err
 			}
+		case IN, ALL:
+			if dataType != jsonparser.Array {
+				return errors.InvalidArgument("'%s' expects an array of values", string(key))
+			}
+
+			var (
+				values   []value.Value
+				parseErr error
+			)
+			if _, parseErr = jsonparser.ArrayEach(v, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+				if err != nil {
+					return
+				}
+
+				switch itemType {
+				case jsonparser.Boolean, jsonparser.Number, jsonparser.String, jsonparser.Null:
+					var val value.Value
+					if val, err = buildFilterValue(field, item, itemType, factoryCollation, collation, buildForSecondaryIndex); err == nil {
+						values = append(values, val)
+					}
+				default:
+					err = errors.InvalidArgument("'%s' only supports an array of primitive values", string(key))
+				}
+			}); parseErr != nil {
+				return parseErr
+			}
+			if err != nil {
+				return err
+			}
+
+			var list []any
+			if err = jsoniter.Unmarshal(v, &list); err != nil {
+				return err
+			}
+
+			valueMatcher, err = NewSetMatcher(string(key), value.NewArrayValue(v, list), values)
+			return err
 		case REGEX, CONTAINS, NOT:
 			if dataType != jsonparser.String {
 				return errors.InvalidArgument("string is only supported type for 'regex/contains/not' filters")
@@ -397,6 +539,24 @@ func buildValueMatcher(input jsoniter.RawMessage, field *schema.QueryableField,
 	return valueMatcher, LikeMatcher, collation, err
 }
 
+// buildFilterValue converts the value passed in the filter to the type of the field, for an array field it is the type
+// of the elements unless the value is itself an array.
+func buildFilterValue(field *schema.QueryableField, v []byte, dataType jsonparser.ValueType, factoryCollation *value.Collation, collation *value.Collation, buildForSecondaryIndex bool) (value.Value, error) {
+	tigrisType := toTigrisType(field, dataType)
+	if err := validateBytesLiteral(field, tigrisType, v, dataType); err != nil {
+		return nil, err
+	}
+
+	//nolint:gocritic
+	if buildForSecondaryIndex {
+		return value.NewValueUsingCollation(tigrisType, v, factoryCollation)
+	} else if collation != nil {
+		return value.NewValueUsingCollation(tigrisType, v, collation)
+	}
+
+	return value.NewValue(tigrisType, v)
+}
+
 func buildCollation(input jsoniter.RawMessage, factoryCollation *value.Collation, buildForSecondaryIndex bool) (*value.Collation, error) {
 	c, dt, _, _ := jsonparser.Get(input, api.CollationKey)
 	if dt == jsonparser.NotExist {
@@ -411,18 +571,49 @@ func buildCollation(input jsoniter.RawMessage, factoryCollation *value.Collation
 	if err = jsoniter.Unmarshal(c, &apiCollation); err != nil {
 		return nil, err
 	}
-	if err = apiCollation.IsValid(); err != nil {
-		return nil, err
+	if apiCollation == nil {
+		apiCollation = &api.Collation{}
+	}
+
+	options := apiCollation.Options()
+	if ai, err := jsonparser.GetBoolean(c, api.AccentInsensitiveKey); err == nil && ai {
+		options.AccentInsensitive = true
+	}
+	if numeric, err := jsonparser.GetBoolean(c, api.NumericOrderingKey); err == nil && numeric {
+		options.NumericOrdering = true
+	}
+	// the modifiers set by the attributes are enough for a collation, otherwise its list of options is validated
+	if len(apiCollation.Case) > 0 || (!options.AccentInsensitive && !options.NumericOrdering) {
+		if err = apiCollation.IsValid(); err != nil {
+			return nil, err
+		}
 	}
 
-	collation := value.NewCollationFrom(apiCollation)
+	collation := value.NewCollationFromOptions(apiCollation, options)
 	if buildForSecondaryIndex && collation.IsCaseInsensitive() {
 		return nil, errors.InvalidArgument("found case insensitive collation")
 	}
+	if buildForSecondaryIndex && collation.HasOrderingOptions() {
+		return nil, errors.InvalidArgument("found collation with ordering options")
+	}
 
 	return collation, nil
 }
 
+// validateBytesLiteral checks that the filter value of a byte field is base64 encoded. The comparisons on the byte
+// fields are performed on the decoded bytes, so a value that can't be decoded can't match anything.
+func validateBytesLiteral(field *schema.QueryableField, tigrisType schema.FieldType, v []byte, dataType jsonparser.ValueType) error {
+	if tigrisType != schema.ByteType || dataType != jsonparser.String {
+		return nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(string(v)); err != nil {
+		return errors.InvalidArgument("filter on byte field '%s' expects a base64 encoded value", field.FieldName)
+	}
+
+	return nil
+}
+
 func toTigrisType(field *schema.QueryableField, jsonType jsonparser.ValueType) schema.FieldType {
 	switch field.DataType {
 	case schema.ArrayType:
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/schema"
 )
 
@@ -167,6 +168,185 @@ func TestFilterDuplicateKey(t *testing.T) {
 	require.NotNil(t, filters)
 }
 
+func TestFilterOnEncryptedField(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "a", DataType: schema.Int64Type},
+			{FieldName: "ssn", DataType: schema.StringType, Encrypted: true},
+		},
+	}
+	_, err := factory.Factorize([]byte(`{"a": 10, "ssn": "123"}`))
+	require.Equal(t, errors.InvalidArgument("filtering on encrypted field 'ssn' is not supported"), err)
+}
+
+func TestFilterOnBytes(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "b", DataType: schema.ByteType},
+		},
+	}
+
+	// "AQI=" is [1, 2], "AQM=" is [1, 3] and "Ag==" is [2]
+	doc := []byte(`{"b": "AQM="}`)
+	cases := []struct {
+		filter   string
+		expMatch bool
+	}{
+		{`{"b": "AQM="}`, true},
+		{`{"b": "AQI="}`, false},
+		{`{"b": {"$gt": "AQI="}}`, true},
+		{`{"b": {"$gte": "AQM="}}`, true},
+		{`{"b": {"$lt": "Ag=="}}`, true},
+		{`{"b": {"$gt": "Ag=="}}`, false},
+		{`{"$and": [{"b": {"$gt": "AQI="}}, {"b": {"$lt": "Ag=="}}]}`, true},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches(doc, nil), c.filter)
+	}
+
+	_, err := factory.Factorize([]byte(`{"b": {"$gt": "not base64!"}}`))
+	require.Equal(t, errors.InvalidArgument("filter on byte field 'b' expects a base64 encoded value"), err)
+}
+
+func TestFilterOnDateParts(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "ts", DataType: schema.DateTimeType},
+			{FieldName: "s", DataType: schema.StringType},
+		},
+	}
+
+	// Saturday 23:30 in New York is Sunday 04:30 in UTC
+	doc := []byte(`{"ts": "2023-01-07T23:30:00-05:00"}`)
+	cases := []struct {
+		filter   string
+		expMatch bool
+	}{
+		{`{"ts": {"$dayOfWeek": 7}}`, true},
+		{`{"ts": {"$dayOfWeek": 1}}`, false},
+		{`{"ts": {"$dayOfMonth": 7, "$month": 1, "$year": 2023}}`, true},
+		{`{"ts": {"$hour": {"$gte": 9, "$lt": 17}}}`, false},
+		{`{"ts": {"$hour": {"$gt": 22}}}`, true},
+		{`{"ts": {"$dayOfYear": 7, "$minute": 30, "$second": 0}}`, true},
+		{`{"$and": [{"ts": {"$dayOfWeek": 7}}, {"ts": {"$gt": "2023-01-08T04:00:00Z"}}]}`, true},
+		{`{"ts": "2023-01-08T04:30:00Z"}`, true},
+		{`{"ts": {"$lt": "2023-01-08T00:00:00Z"}}`, false},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches(doc, nil), c.filter)
+	}
+
+	errCases := []struct {
+		filter string
+		expErr error
+	}{
+		{`{"s": {"$dayOfWeek": 1}}`, errors.InvalidArgument("date part operators are only supported on date time fields, found field 's' of type 'string'")},
+		{`{"ts": {"$dayOfWeek": 1, "$gt": "2023-01-08T04:00:00Z"}}`, errors.InvalidArgument("date part operators can't be combined with '$gt'")},
+		{`{"ts": {"$dayOfWeek": "monday"}}`, errors.InvalidArgument("date part operator '$dayOfWeek' expects an integer or an object of comparison operators")},
+		{`{"ts": {"$hour": {"$regex": 1}}}`, errors.InvalidArgument("unsupported operand '$regex' for date part operator '$hour'")},
+	}
+	for _, c := range errCases {
+		_, err := factory.Factorize([]byte(c.filter))
+		require.Equal(t, c.expErr, err, c.filter)
+	}
+}
+
+func TestFilterOnGeoPoint(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "loc", InMemoryAlias: "loc", DataType: schema.GeoPointType, SearchIndexed: true},
+			{FieldName: "s", DataType: schema.StringType},
+		},
+	}
+
+	// Eiffel Tower, Louvre is ~3.2km away
+	doc := []byte(`{"loc": [48.8584, 2.2945]}`)
+	cases := []struct {
+		filter       string
+		expMatch     bool
+		searchFilter string
+	}{
+		{`{"loc": {"$geo_radius": {"lat": 48.8606, "lng": 2.3376, "radius_km": 5}}}`, true, "loc:(48.8606, 2.3376, 5 km)"},
+		{`{"loc": {"$geo_radius": {"lat": 48.8606, "lng": 2.3376, "radius_km": 2.5}}}`, false, "loc:(48.8606, 2.3376, 2.5 km)"},
+		{`{"loc": {"$geo_polygon": [[48.87, 2.28], [48.84, 2.28], [48.84, 2.31], [48.87, 2.31]]}}`, true, "loc:(48.87, 2.28, 48.84, 2.28, 48.84, 2.31, 48.87, 2.31)"},
+		{`{"loc": {"$geo_polygon": [[48.87, 2.32], [48.84, 2.32], [48.84, 2.35]]}}`, false, "loc:(48.87, 2.32, 48.84, 2.32, 48.84, 2.35)"},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches(doc, nil), c.filter)
+		require.Equal(t, c.expMatch, filters[0].MatchesDoc(map[string]any{"loc": []any{48.8584, 2.2945}}), c.filter)
+		require.Equal(t, c.searchFilter, filters[0].ToSearchFilter())
+		require.True(t, filters[0].IsSearchIndexed())
+	}
+
+	errCases := []struct {
+		filter string
+		expErr error
+	}{
+		{`{"s": {"$geo_radius": {"lat": 1, "lng": 1, "radius_km": 1}}}`, errors.InvalidArgument("geo operators are only supported on geopoint fields, found field 's' of type 'string'")},
+		{`{"loc": [48.8584, 2.2945]}`, errors.InvalidArgument("only geo operators are supported on geopoint field 'loc'")},
+		{`{"loc": {"$geo_radius": {"lat": 91, "lng": 1, "radius_km": 1}}}`, errors.InvalidArgument("latitude '91' should be between -90 and 90")},
+		{`{"loc": {"$geo_radius": {"lat": 1, "lng": 1}}}`, errors.InvalidArgument("'$geo_radius' requires a positive 'radius_km'")},
+		{`{"loc": {"$geo_radius": {"lng": 1, "radius_km": 1}}}`, errors.InvalidArgument("geo point requires both 'lat' and 'lng'")},
+		{`{"loc": {"$geo_polygon": [[1, 1], [2, 2]]}}`, errors.InvalidArgument("'$geo_polygon' requires between 3 and 64 points, found '2'")},
+		{`{"loc": {"$geo_polygon": [[1, 1], [2, 2], [3]]}}`, errors.InvalidArgument("'$geo_polygon' expects an array of [latitude, longitude] points")},
+		{`{"loc": {"$geo_radius": {"lat": 1, "lng": 1, "radius_km": 1}, "$gt": 1}}`, errors.InvalidArgument("geo operators can't be combined with '$gt'")},
+	}
+	for _, c := range errCases {
+		_, err := factory.Factorize([]byte(c.filter))
+		require.Equal(t, c.expErr, err, c.filter)
+	}
+}
+
+func TestFilterOnExpressions(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "email", DataType: schema.StringType},
+			{FieldName: "ts", DataType: schema.DateTimeType},
+		},
+	}
+
+	doc := []byte(`{"email": "Jane@Example.com", "ts": "2023-01-07T23:30:00Z"}`)
+	cases := []struct {
+		filter   string
+		expMatch bool
+	}{
+		{`{"lower(email)": "jane@example.com"}`, true},
+		{`{"upper(email)": "jane@example.com"}`, false},
+		{`{"lower(email)": {"$gte": "j", "$lt": "k"}}`, true},
+		{`{"extract_year(ts)": 2023}`, true},
+		{`{"extract_month(ts)": {"$gt": 1}}`, false},
+		{`{"$or": [{"extract_day(ts)": 8}, {"lower(email)": "jane@example.com"}]}`, true},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches(doc, nil), c.filter)
+	}
+
+	errCases := []struct {
+		filter string
+		expErr error
+	}{
+		{`{"lower(name)": "jane"}`, errors.InvalidArgument("querying on non schema field 'name'")},
+		{`{"lower(ts)": "jane"}`, errors.InvalidArgument("function 'lower' expects a field of type 'string', found field 'ts' of type 'datetime'")},
+		{`{"lower(email)": {"$regex": "^j"}}`, errors.InvalidArgument("only comparison operators are supported on the expression 'lower(email)'")},
+	}
+	for _, c := range errCases {
+		_, err := factory.Factorize([]byte(c.filter))
+		require.Equal(t, c.expErr, err, c.filter)
+	}
+}
+
 func TestFiltersWithCollation(t *testing.T) {
 	factory := Factory{
 		fields: []*schema.QueryableField{
@@ -183,3 +363,66 @@ func TestFiltersWithCollation(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, filters)
 }
+
+func TestFiltersWithCollationOptions(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "c", DataType: schema.StringType},
+		},
+	}
+
+	cases := []struct {
+		filter   string
+		doc      string
+		expMatch bool
+	}{
+		{`{"c": {"$eq": "cafe"}}`, `{"c": "café"}`, false},
+		{`{"c": {"$eq": "cafe", "collation": {"accent_insensitive": true}}}`, `{"c": "café"}`, true},
+		{`{"c": {"$eq": "CAFE", "collation": {"case": "ci", "accent_insensitive": true}}}`, `{"c": "café"}`, true},
+		{`{"c": {"$contains": "cafe", "collation": {"case": "ci,ai"}}}`, `{"c": "Le Café"}`, true},
+		{`{"c": {"$gt": "file9"}}`, `{"c": "file10"}`, false},
+		{`{"c": {"$gt": "file9", "collation": {"numeric_ordering": true}}}`, `{"c": "file10"}`, true},
+		{`{"c": {"$eq": "ärger", "collation": {"case": "de@phonebook"}}}`, `{"c": "ärger"}`, true},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches([]byte(c.doc), nil), c.filter)
+	}
+
+	_, err := factory.Factorize([]byte(`{"c": {"$eq": "cafe", "collation": {"case": "tr,de"}}}`))
+	require.Error(t, err)
+}
+
+func TestHasOrderingCollation(t *testing.T) {
+	for reqFilter, expected := range map[string]bool{
+		`{"c": "hello"}`: false,
+		`{"c": {"$eq": "hello", "collation": {"case": "ci"}}}`:                           false,
+		`{"c": {"$eq": "hello", "collation": {"case": "tr"}}}`:                           true,
+		`{"c": {"$gt": "file9", "collation": {"case": "cs,numeric"}}}`:                   true,
+		`{"$or": [{"a": 1}, {"c": {"$eq": "cafe", "collation": {"case": "ci,ai,de"}}}]}`: true,
+		`{"$and": [{"a": 1}, {"c": "hello"}]}`:                                           false,
+	} {
+		require.Equal(t, expected, HasOrderingCollation([]byte(reqFilter)), reqFilter)
+	}
+}
+
+func TestFieldOperators(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "a", DataType: schema.Int64Type},
+			{FieldName: "b", DataType: schema.StringType},
+		},
+	}
+
+	wrapped, err := factory.WrappedFilter([]byte(`{"a": {"$gt": 10}, "$or": [{"a": {"$in": [1, 2]}}, {"b": {"$regex": "^x"}}], "b": "y"}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{GT, IN}, wrapped.FieldOperators("a"))
+	require.Equal(t, []string{EQ}, wrapped.FieldOperators("b"))
+	require.Empty(t, wrapped.FieldOperators("c"))
+
+	wrapped, err = factory.WrappedFilter(nil)
+	require.NoError(t, err)
+	require.Empty(t, wrapped.FieldOperators("a"))
+}
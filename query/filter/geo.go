@@ -0,0 +1,256 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	ulog "github.com/tigrisdata/tigris/util/log"
+)
+
+// Geo operators filter the documents on a geopoint field, either within a radius of a point like
+// {"loc": {"$geo_radius": {"lat": 48.85, "lng": 2.34, "radius_km": 5}}} or inside a polygon like
+// {"loc": {"$geo_polygon": [[48.86, 2.32], [48.85, 2.32], [48.85, 2.34]]}}.
+const (
+	GeoRadiusOP  = "$geo_radius"
+	GeoPolygonOP = "$geo_polygon"
+)
+
+const (
+	// EarthRadiusKm is the mean radius of the earth used to compute the distance between two points.
+	EarthRadiusKm = 6371.0
+	// MaxGeoPolygonPoints is the maximum number of vertices allowed in a polygon.
+	MaxGeoPolygonPoints = 64
+)
+
+type geoRadius struct {
+	schema.GeoPoint
+	RadiusKm *float64 `json:"radius_km"`
+}
+
+// GeoFilter is a filter on a geopoint field. The search store evaluates it natively, it is not used to create any
+// key and when the records are not read from the search store they are post-processed using the same semantics.
+type GeoFilter struct {
+	Field *schema.QueryableField
+	// Center and RadiusKm are set for the radius filter.
+	Center   []float64
+	RadiusKm float64
+	// Polygon is set for the polygon filter, each vertex is [latitude, longitude].
+	Polygon [][]float64
+}
+
+func NewGeoRadiusFilter(field *schema.QueryableField, lat float64, lng float64, radiusKm float64) *GeoFilter {
+	return &GeoFilter{
+		Field:    field,
+		Center:   []float64{lat, lng},
+		RadiusKm: radiusKm,
+	}
+}
+
+func NewGeoPolygonFilter(field *schema.QueryableField, polygon [][]float64) *GeoFilter {
+	return &GeoFilter{
+		Field:   field,
+		Polygon: polygon,
+	}
+}
+
+func (g *GeoFilter) MatchesDoc(doc map[string]any) bool {
+	v, ok := doc[g.Field.Name()]
+	if !ok {
+		return true
+	}
+
+	lat, lng, err := schema.ParseGeoPoint(v)
+	if err != nil {
+		return true
+	}
+
+	return g.matches(lat, lng)
+}
+
+// Matches returns true if the geopoint in the doc is inside the radius or the polygon.
+func (g *GeoFilter) Matches(doc []byte, metadata []byte) bool {
+	docValue, dtp, err := getJSONField(doc, metadata, g.Field.FieldName, g.Field.KeyPath())
+	if dtp == jsonparser.NotExist || dtp == jsonparser.Null {
+		return false
+	}
+	if ulog.E(err) {
+		return false
+	}
+
+	var point []any
+	if err = jsoniter.Unmarshal(docValue, &point); err != nil {
+		return false
+	}
+
+	lat, lng, err := schema.ParseGeoPoint(point)
+	if err != nil {
+		return false
+	}
+
+	return g.matches(lat, lng)
+}
+
+func (g *GeoFilter) matches(lat float64, lng float64) bool {
+	if len(g.Polygon) > 0 {
+		return insidePolygon(g.Polygon, lat, lng)
+	}
+
+	return HaversineKm(g.Center[0], g.Center[1], lat, lng) <= g.RadiusKm
+}
+
+// ToSearchFilter returns the geo filter in the search store format i.e. "loc:(48.85, 2.34, 5 km)" for the radius
+// and "loc:(48.86, 2.32, 48.85, 2.32, 48.85, 2.34)" for the polygon.
+func (g *GeoFilter) ToSearchFilter() string {
+	var coordinates []string
+	if len(g.Polygon) > 0 {
+		for _, p := range g.Polygon {
+			coordinates = append(coordinates, formatCoordinate(p[0]), formatCoordinate(p[1]))
+		}
+	} else {
+		coordinates = append(coordinates, formatCoordinate(g.Center[0]), formatCoordinate(g.Center[1]),
+			formatCoordinate(g.RadiusKm)+" km")
+	}
+
+	return fmt.Sprintf("%s:(%s)", g.Field.InMemoryName(), strings.Join(coordinates, ", "))
+}
+
+func (g *GeoFilter) IsSearchIndexed() bool {
+	return g.Field.SearchIndexed
+}
+
+func (g *GeoFilter) String() string {
+	if len(g.Polygon) > 0 {
+		return fmt.Sprintf("{%v:{%s:%v}}", g.Field.Name(), GeoPolygonOP, g.Polygon)
+	}
+	return fmt.Sprintf("{%v:{%s:%v,%vkm}}", g.Field.Name(), GeoRadiusOP, g.Center, g.RadiusKm)
+}
+
+// HaversineKm returns the great circle distance between two points in kilometers.
+func HaversineKm(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	toRadians := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// insidePolygon uses ray casting with longitude as x and latitude as y.
+func insidePolygon(polygon [][]float64, lat float64, lng float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		yi, xi := polygon[i][0], polygon[i][1]
+		yj, xj := polygon[j][0], polygon[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func formatCoordinate(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// hasGeoOperator returns true if the object passed as a value of a selector contains a geo operator.
+func hasGeoOperator(input jsoniter.RawMessage) bool {
+	found := false
+	_ = jsonparser.ObjectEach(input, func(key []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		found = found || string(key) == GeoRadiusOP || string(key) == GeoPolygonOP
+		return nil
+	})
+
+	return found
+}
+
+// buildGeoFilter builds the filter from an object like {"$geo_radius": {"lat": 48.85, "lng": 2.34, "radius_km": 5}}
+// or {"$geo_polygon": [[48.86, 2.32], [48.85, 2.32], [48.85, 2.34]]}.
+func buildGeoFilter(field *schema.QueryableField, input jsoniter.RawMessage) (Filter, error) {
+	if field.DataType != schema.GeoPointType {
+		return nil, errors.InvalidArgument("geo operators are only supported on geopoint fields, found field '%s' of type '%s'",
+			field.FieldName, schema.FieldNames[field.DataType])
+	}
+
+	var filter Filter
+	err := jsonparser.ObjectEach(input, func(key []byte, v []byte, _ jsonparser.ValueType, _ int) error {
+		op := string(key)
+		if op != GeoRadiusOP && op != GeoPolygonOP {
+			return errors.InvalidArgument("geo operators can't be combined with '%s'", op)
+		}
+		if filter != nil {
+			return errors.InvalidArgument("only one geo operator is allowed on field '%s'", field.FieldName)
+		}
+
+		var err error
+		if op == GeoRadiusOP {
+			filter, err = buildGeoRadiusFilter(field, v)
+		} else {
+			filter, err = buildGeoPolygonFilter(field, v)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func buildGeoRadiusFilter(field *schema.QueryableField, v []byte) (Filter, error) {
+	var radius geoRadius
+	if err := jsoniter.Unmarshal(v, &radius); err != nil {
+		return nil, errors.InvalidArgument("'%s' expects an object of 'lat', 'lng' and 'radius_km'", GeoRadiusOP)
+	}
+	if err := radius.Validate(); err != nil {
+		return nil, err
+	}
+	if radius.RadiusKm == nil || *radius.RadiusKm <= 0 {
+		return nil, errors.InvalidArgument("'%s' requires a positive 'radius_km'", GeoRadiusOP)
+	}
+
+	return NewGeoRadiusFilter(field, *radius.Lat, *radius.Lng, *radius.RadiusKm), nil
+}
+
+func buildGeoPolygonFilter(field *schema.QueryableField, v []byte) (Filter, error) {
+	var polygon [][]float64
+	if err := jsoniter.Unmarshal(v, &polygon); err != nil {
+		return nil, errors.InvalidArgument("'%s' expects an array of [latitude, longitude] points", GeoPolygonOP)
+	}
+	if len(polygon) < 3 || len(polygon) > MaxGeoPolygonPoints {
+		return nil, errors.InvalidArgument("'%s' requires between 3 and %d points, found '%d'", GeoPolygonOP,
+			MaxGeoPolygonPoints, len(polygon))
+	}
+	for _, p := range polygon {
+		if len(p) != 2 {
+			return nil, errors.InvalidArgument("'%s' expects an array of [latitude, longitude] points", GeoPolygonOP)
+		}
+		if err := schema.ValidateGeoPoint(p[0], p[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewGeoPolygonFilter(field, polygon), nil
+}
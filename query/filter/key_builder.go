@@ -257,7 +257,7 @@ func (s *StrictEqKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys
 	for _, k := range userDefinedKeys {
 		var repeatedFields []*Selector
 		for _, sel := range selectors {
-			if sel.Matcher.Type() == EQ {
+			if s.isEqual(sel) {
 				if k.Name() == sel.Field.Name() {
 					repeatedFields = append(repeatedFields, sel)
 				}
@@ -289,7 +289,10 @@ func (s *StrictEqKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys
 				compositeKeys = append(compositeKeys, keyPartsCopy)    //nolint:makezero
 			}
 		} else {
-			compositeKeys = append(compositeKeys, [][]*Selector{repeatedFields}...) //nolint:makezero
+			// every condition is a lookup of its own, the other conditions on the field are applied on the documents
+			for _, sel := range repeatedFields {
+				compositeKeys = append(compositeKeys, []*Selector{sel}) //nolint:makezero
+			}
 		}
 	}
 
@@ -298,6 +301,16 @@ func (s *StrictEqKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys
 	for _, k := range compositeKeys {
 		switch parent {
 		case AndOP:
+			if len(k) == 1 {
+				lookupKeys, err := s.lookupKeys(k[0])
+				if err != nil {
+					return nil, err
+				}
+
+				queryPlans = append(queryPlans, NewQueryPlan(EQUAL, k[0].Field.Name(), k[0].Field.DataType, lookupKeys, s.indexType))
+				continue
+			}
+
 			var keyParts []any
 			for _, sel := range k {
 				newParts := s.buildIndexPartsFunc(sel.Field.Name(), sel.Matcher.GetValue())
@@ -322,14 +335,12 @@ func (s *StrictEqKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys
 					return nil, errors.InvalidArgument("OR is not supported with composite primary keys")
 				}
 
-				primaryKeyParts := s.buildIndexPartsFunc(sel.Field.Name(), sel.Matcher.GetValue())
-
-				key, err := s.keyEncodingFunc(primaryKeyParts...)
+				lookupKeys, err := s.lookupKeys(sel)
 				if err != nil {
 					return nil, err
 				}
 
-				queryPlans = append(queryPlans, NewQueryPlan(EQUAL, sel.Field.Name(), sel.Field.DataType, []keys.Key{key}, s.indexType))
+				queryPlans = append(queryPlans, NewQueryPlan(EQUAL, sel.Field.Name(), sel.Field.DataType, lookupKeys, s.indexType))
 			}
 		}
 	}
@@ -337,6 +348,47 @@ func (s *StrictEqKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys
 	return queryPlans, nil
 }
 
+// isEqual returns true if the selector can be served by equality lookups. Apart from "$eq", the secondary index
+// supports "$in" and "$all", an array field is looked up by its elements so comparing it with an array is not an
+// equality lookup.
+func (s *StrictEqKeyComposer[F]) isEqual(sel *Selector) bool {
+	if s.matchAll {
+		return sel.Matcher.Type() == EQ
+	}
+
+	switch sel.Matcher.(type) {
+	case *EqualityMatcher:
+		return sel.Field.DataType != schema.ArrayType || !MatcherForArray(sel.Matcher)
+	case SetMatcher:
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupKeys returns the keys to look up for a selector. The "$in" needs a lookup per value of the set, whereas any
+// value of the "$all" is enough to find the documents having all the values, so only the first one is looked up.
+func (s *StrictEqKeyComposer[F]) lookupKeys(sel *Selector) ([]keys.Key, error) {
+	values := []value.Value{sel.Matcher.GetValue()}
+	if set, ok := sel.Matcher.(SetMatcher); ok {
+		values = set.GetValues()
+		if set.Type() == ALL {
+			values = values[:1]
+		}
+	}
+
+	lookupKeys := make([]keys.Key, 0, len(values))
+	for _, v := range values {
+		key, err := s.keyEncodingFunc(s.buildIndexPartsFunc(sel.Field.Name(), v)...)
+		if err != nil {
+			return nil, err
+		}
+		lookupKeys = append(lookupKeys, key)
+	}
+
+	return lookupKeys, nil
+}
+
 // RangeKeyComposer will generate a range key set on the user defined keys
 // It will set the KeyQuery to `FullRange` if the start or end key is not defined in the query
 // if there is a defined start and end key for a range then `Range` is set.
@@ -418,6 +470,10 @@ func (s *RangeKeyComposer[F]) Compose(selectors []*Selector, userDefinedKeys []F
 }
 
 func (s *RangeKeyComposer[F]) isRange(selector *Selector) bool {
+	if selector.Field.DataType == schema.ArrayType && MatcherForArray(selector.Matcher) {
+		// the elements of an array are indexed, not the array
+		return false
+	}
 	if s.isGreater(selector) || s.isLess(selector) {
 		return true
 	}
@@ -463,6 +519,10 @@ func QueryPlanFromSort(sortFields *[]tsort.SortField, indexableFields []*schema.
 	if field == nil {
 		return nil, errors.InvalidArgument("Sort field is not indexed")
 	}
+	if field.DataType == schema.ArrayType {
+		// there is an index entry per element of the array, the entries are not in the order of the documents
+		return nil, errors.InvalidArgument("Sort field '%s' is an array", field.FieldName)
+	}
 
 	min, err := encoder(buildIndexParts(field.FieldName, value.MinOrderValue())...)
 	if err != nil {
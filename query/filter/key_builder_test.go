@@ -226,6 +226,49 @@ func TestKeyBuilderSecondaryEq(t *testing.T) {
 			nil,
 			[]QueryPlan{NewQueryPlan(EQUAL, "a", schema.Int64Type, []keys.Key{keys.NewKey(nil, int64(10))}, SecondaryIndex)},
 		},
+		{
+			// a lookup per distinct value of $in
+			[]*schema.QueryableField{{FieldName: "a", DataType: schema.Int64Type}, {FieldName: "b", DataType: schema.Int64Type}},
+			[]*schema.Field{{FieldName: "a", DataType: schema.Int64Type}},
+			[]byte(`{"a": {"$in": [3, 1, 3]}}`),
+			nil,
+			[]QueryPlan{NewQueryPlan(EQUAL, "a", schema.Int64Type, []keys.Key{keys.NewKey(nil, int64(1)), keys.NewKey(nil, int64(3))}, SecondaryIndex)},
+		},
+		{
+			// an element of an array
+			[]*schema.QueryableField{{FieldName: "tags", DataType: schema.ArrayType, SubType: schema.StringType}},
+			[]*schema.Field{{FieldName: "tags", DataType: schema.ArrayType}},
+			[]byte(`{"tags": "golang"}`),
+			nil,
+			[]QueryPlan{NewQueryPlan(EQUAL, "tags", schema.ArrayType, []keys.Key{keys.NewKey(nil, encodeString("golang"))}, SecondaryIndex)},
+		},
+		{
+			// the first value of $all is looked up, the documents are checked for the other values
+			[]*schema.QueryableField{{FieldName: "tags", DataType: schema.ArrayType, SubType: schema.StringType}},
+			[]*schema.Field{{FieldName: "tags", DataType: schema.ArrayType}},
+			[]byte(`{"tags": {"$all": ["rust", "golang"]}}`),
+			nil,
+			[]QueryPlan{NewQueryPlan(EQUAL, "tags", schema.ArrayType, []keys.Key{keys.NewKey(nil, encodeString("golang"))}, SecondaryIndex)},
+		},
+		{
+			// the conditions on the elements of an array are lookups of their own
+			[]*schema.QueryableField{{FieldName: "tags", DataType: schema.ArrayType, SubType: schema.StringType}},
+			[]*schema.Field{{FieldName: "tags", DataType: schema.ArrayType}},
+			[]byte(`{"$and": [{"tags": "golang"}, {"tags": "rust"}]}`),
+			nil,
+			[]QueryPlan{
+				NewQueryPlan(EQUAL, "tags", schema.ArrayType, []keys.Key{keys.NewKey(nil, encodeString("golang"))}, SecondaryIndex),
+				NewQueryPlan(EQUAL, "tags", schema.ArrayType, []keys.Key{keys.NewKey(nil, encodeString("rust"))}, SecondaryIndex),
+			},
+		},
+		{
+			// the array itself is not indexed
+			[]*schema.QueryableField{{FieldName: "tags", DataType: schema.ArrayType, SubType: schema.StringType}},
+			[]*schema.Field{{FieldName: "tags", DataType: schema.ArrayType}},
+			[]byte(`{"tags": ["golang", "rust"]}`),
+			nil,
+			nil,
+		},
 		// NOT SUPPORTED YET
 		// {
 		// 	// simple OR filter
@@ -16,9 +16,11 @@ package filter
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metrics"
 	ulog "github.com/tigrisdata/tigris/util/log"
 )
 
@@ -45,8 +47,21 @@ func (s *LikeFilter) MatchesDoc(doc map[string]any) bool {
 	return s.Matcher.Matches(v)
 }
 
-// Matches returns true if the doc value matches this filter.
+// Matches returns true if the doc value matches this filter, the evaluation is measured if the filter metrics are
+// enabled.
 func (s *LikeFilter) Matches(doc []byte, metadata []byte) bool {
+	if !metrics.FilterMetricsEnabled() {
+		return s.matches(doc, metadata)
+	}
+
+	start := time.Now()
+	matched := s.matches(doc, metadata)
+	metrics.FilterEvaluation(s.Matcher.Type(), matched, time.Since(start))
+
+	return matched
+}
+
+func (s *LikeFilter) matches(doc []byte, metadata []byte) bool {
 	docValue, dtp, err := getJSONField(doc, metadata, s.Field.FieldName, s.Field.KeyPath())
 
 	if dtp == jsonparser.NotExist {
@@ -0,0 +1,176 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/value"
+)
+
+const (
+	IN  = "$in"
+	ALL = "$all"
+)
+
+// SetMatcher is a ValueMatcher operating on a set of values instead of a single value, the membership operators
+// "$in" and "$all" implement it. On an array field, the values are compared with the elements of the array.
+type SetMatcher interface {
+	ValueMatcher
+
+	// GetValues returns the distinct values of the set in ascending order
+	GetValues() []value.Value
+}
+
+// NewSetMatcher returns SetMatcher that is derived from the key. The set is the array passed in the filter, "list" is
+// this array as passed by the user, and it is returned by GetValue.
+func NewSetMatcher(key string, list *value.ArrayValue, values []value.Value) (SetMatcher, error) {
+	if len(values) == 0 {
+		return nil, errors.InvalidArgument("'%s' expects a non-empty array of values", key)
+	}
+
+	values = distinctValues(values)
+	switch key {
+	case IN:
+		return &InMatcher{List: list, Values: values}, nil
+	case ALL:
+		return &AllMatcher{List: list, Values: values}, nil
+	default:
+		return nil, errors.InvalidArgument("unsupported operand '%s'", key)
+	}
+}
+
+// distinctValues sorts the values and removes the duplicates, so that an index lookup is done once per value and in
+// the order of the index.
+func distinctValues(values []value.Value) []value.Value {
+	sort.SliceStable(values, func(i, j int) bool {
+		res, _ := values[i].CompareTo(values[j])
+		return res < 0
+	})
+
+	distinct := values[:1]
+	for _, v := range values[1:] {
+		if res, _ := distinct[len(distinct)-1].CompareTo(v); res != 0 {
+			distinct = append(distinct, v)
+		}
+	}
+
+	return distinct
+}
+
+// InMatcher implements "$in" operand, it matches if the value is equal to any value of the set. On an array field,
+// it matches if any element of the array is in the set.
+type InMatcher struct {
+	List   *value.ArrayValue
+	Values []value.Value
+}
+
+func (i *InMatcher) GetValue() value.Value {
+	return i.List
+}
+
+func (i *InMatcher) GetValues() []value.Value {
+	return i.Values
+}
+
+func (i *InMatcher) Matches(input value.Value) bool {
+	for _, v := range i.Values {
+		if res, _ := input.CompareTo(v); res == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *InMatcher) ArrMatches(arr []any) bool {
+	for _, v := range i.Values {
+		if arrayContains(arr, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (*InMatcher) Type() string {
+	return "$in"
+}
+
+func (i *InMatcher) String() string {
+	return fmt.Sprintf("{$in:%v}", i.Values)
+}
+
+// AllMatcher implements "$all" operand, it matches an array field if every value of the set is an element of the
+// array. On a non-array field, it matches if the value is equal to every value of the set.
+type AllMatcher struct {
+	List   *value.ArrayValue
+	Values []value.Value
+}
+
+func (a *AllMatcher) GetValue() value.Value {
+	return a.List
+}
+
+func (a *AllMatcher) GetValues() []value.Value {
+	return a.Values
+}
+
+func (a *AllMatcher) Matches(input value.Value) bool {
+	for _, v := range a.Values {
+		if res, _ := input.CompareTo(v); res != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *AllMatcher) ArrMatches(arr []any) bool {
+	for _, v := range a.Values {
+		if !arrayContains(arr, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (*AllMatcher) Type() string {
+	return "$all"
+}
+
+func (a *AllMatcher) String() string {
+	return fmt.Sprintf("{$all:%v}", a.Values)
+}
+
+// arrayContains returns true if the value is an element of the array, or of an array nested in it.
+func arrayContains(arr []any, v value.Value) bool {
+	for _, element := range arr {
+		if nestedArr, ok := element.([]any); ok {
+			for _, ne := range nestedArr {
+				if value.AnyCompare(ne, v) == 0 {
+					return true
+				}
+			}
+		} else if value.AnyCompare(element, v) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
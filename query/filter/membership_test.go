@@ -0,0 +1,134 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+func mustSetMatcher(op string, values ...value.Value) SetMatcher {
+	s, err := NewSetMatcher(op, nil, values)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestNewSetMatcher(t *testing.T) {
+	matcher, err := NewSetMatcher(IN, nil, []value.Value{value.NewIntValue(3), value.NewIntValue(1), value.NewIntValue(3)})
+	require.NoError(t, err)
+	require.Equal(t, []value.Value{value.NewIntValue(1), value.NewIntValue(3)}, matcher.GetValues())
+
+	_, err = NewSetMatcher(ALL, nil, nil)
+	require.Equal(t, errors.InvalidArgument("'$all' expects a non-empty array of values"), err)
+
+	_, err = NewSetMatcher("foo", nil, []value.Value{value.NewIntValue(1)})
+	require.Equal(t, errors.InvalidArgument("unsupported operand 'foo'"), err)
+}
+
+func TestSetMatcher(t *testing.T) {
+	cases := []struct {
+		arrV     []any
+		matcher  SetMatcher
+		expMatch bool
+	}{
+		{
+			[]any{"golang", "rust"},
+			mustSetMatcher(IN, value.NewStringValue("java", nil), value.NewStringValue("rust", nil)),
+			true,
+		}, {
+			[]any{"golang", "rust"},
+			mustSetMatcher(IN, value.NewStringValue("java", nil), value.NewStringValue("c", nil)),
+			false,
+		}, {
+			[]any{"golang", "rust", "golang"},
+			mustSetMatcher(ALL, value.NewStringValue("rust", nil), value.NewStringValue("golang", nil)),
+			true,
+		}, {
+			[]any{"golang", "java"},
+			mustSetMatcher(ALL, value.NewStringValue("rust", nil), value.NewStringValue("golang", nil)),
+			false,
+		}, {
+			[]any{[]any{int64(1), int64(2)}, int64(5)},
+			mustSetMatcher(ALL, value.NewIntValue(2), value.NewIntValue(5)),
+			true,
+		},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expMatch, c.matcher.ArrMatches(c.arrV))
+	}
+
+	in := mustSetMatcher(IN, value.NewIntValue(1), value.NewIntValue(2))
+	require.True(t, in.Matches(value.NewIntValue(2)))
+	require.False(t, in.Matches(value.NewIntValue(3)))
+
+	all := mustSetMatcher(ALL, value.NewIntValue(1), value.NewIntValue(1))
+	require.True(t, all.Matches(value.NewIntValue(1)))
+	all = mustSetMatcher(ALL, value.NewIntValue(1), value.NewIntValue(2))
+	require.False(t, all.Matches(value.NewIntValue(1)))
+}
+
+func TestSetFilter(t *testing.T) {
+	factory := Factory{
+		fields: []*schema.QueryableField{
+			{FieldName: "id", DataType: schema.Int64Type},
+			{FieldName: "tags", DataType: schema.ArrayType, SubType: schema.StringType},
+		},
+	}
+	doc := []byte(`{"id": 2, "tags": ["golang", "database", "golang"]}`)
+
+	cases := []struct {
+		filter   string
+		expMatch bool
+	}{
+		{`{"tags": "golang"}`, true},
+		{`{"tags": {"$in": ["rust", "database"]}}`, true},
+		{`{"tags": {"$in": ["rust", "java"]}}`, false},
+		{`{"tags": {"$all": ["database", "golang"]}}`, true},
+		{`{"tags": {"$all": ["database", "rust"]}}`, false},
+		{`{"id": {"$in": [1, 2, 3]}}`, true},
+		{`{"id": {"$in": [1, 3]}}`, false},
+	}
+	for _, c := range cases {
+		filters, err := factory.Factorize([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Equal(t, c.expMatch, filters[0].Matches(doc, nil), c.filter)
+	}
+
+	_, err := factory.Factorize([]byte(`{"tags": {"$in": "golang"}}`))
+	require.Equal(t, errors.InvalidArgument("'$in' expects an array of values"), err)
+
+	_, err = factory.Factorize([]byte(`{"tags": {"$all": [["golang"]]}}`))
+	require.Equal(t, errors.InvalidArgument("'$all' only supports an array of primitive values"), err)
+
+	_, err = factory.Factorize([]byte(`{"tags": {"$in": []}}`))
+	require.Equal(t, errors.InvalidArgument("'$in' expects a non-empty array of values"), err)
+}
+
+func TestSetToSearchFilter(t *testing.T) {
+	field := &schema.QueryableField{FieldName: "tags", InMemoryAlias: "tags", DataType: schema.ArrayType, SubType: schema.StringType}
+
+	in := NewSelector(nil, field, mustSetMatcher(IN, value.NewStringValue("b", nil), value.NewStringValue("a", nil)), nil)
+	require.Equal(t, "tags:=[a,b]", in.ToSearchFilter())
+
+	all := NewSelector(nil, field, mustSetMatcher(ALL, value.NewStringValue("b", nil), value.NewStringValue("a", nil)), nil)
+	require.Equal(t, "tags:=a&&tags:=b", all.ToSearchFilter())
+}
@@ -18,12 +18,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
+	"time"
 
 	"github.com/buger/jsonparser"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/lib/date"
+	"github.com/tigrisdata/tigris/query/expression"
 	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metrics"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/tigrisdata/tigris/value"
 )
@@ -56,6 +60,14 @@ func NewSelector(parent *schema.QueryableField, field *schema.QueryableField, ma
 }
 
 func (s *Selector) MatchesDoc(doc map[string]any) bool {
+	if s.Field.Expression != nil {
+		str, ok := doc[s.Field.Expression.Field].(string)
+		if !ok {
+			return true
+		}
+		return s.Matcher.Matches(expression.Apply(s.Field.Expression, str, s.Collation))
+	}
+
 	// we don't need any special handling for arrays in this case because this is
 	// already taken care by search store
 	v, ok := doc[s.Field.Name()]
@@ -84,12 +96,34 @@ func (s *Selector) MatchesDoc(doc map[string]any) bool {
 	return s.Matcher.Matches(val)
 }
 
-// Matches returns true if the input doc matches this filter.
+// Matches returns true if the input doc matches this filter, the evaluation is measured if the filter metrics are
+// enabled.
+func (s *Selector) Matches(doc []byte, metadata []byte) bool {
+	if !metrics.FilterMetricsEnabled() {
+		return s.matches(doc, metadata)
+	}
+
+	start := time.Now()
+	matched := s.matches(doc, metadata)
+	metrics.FilterEvaluation(s.Matcher.Type(), matched, time.Since(start))
+
+	return matched
+}
+
+// matches returns true if the input doc matches this filter.
 // To note around the order of checking for not exist and error logging
 // An error is returned if the field does not exist
 // and that is an acceptable error, so return false
 // Only log an error that is unexpected.
-func (s *Selector) Matches(doc []byte, metadata []byte) bool {
+func (s *Selector) matches(doc []byte, metadata []byte) bool {
+	if s.Field.Expression != nil {
+		val, err := expression.Evaluate(s.Field.Expression, doc, s.Collation)
+		if ulog.E(err) {
+			return false
+		}
+		return s.Matcher.Matches(val)
+	}
+
 	if ((s.Parent != nil && s.Parent.DataType == schema.ArrayType) || (s.Field.DataType == schema.ArrayType)) && !MatcherForArray(s.Matcher) {
 		// The second condition is on matcher i.e. if filter has received an array then we don't need
 		// "ArrMatches" comparison because then "Matches" can simply compare both the arrays. Here we
@@ -133,6 +167,13 @@ func (s *Selector) Matches(doc []byte, metadata []byte) bool {
 }
 
 func (s *Selector) ToSearchFilter() string {
+	if s.Field.Expression != nil {
+		return ""
+	}
+	if set, ok := s.Matcher.(SetMatcher); ok {
+		return s.setToSearchFilter(set)
+	}
+
 	var op string
 	switch s.Matcher.Type() {
 	case EQ:
@@ -172,8 +213,43 @@ func (s *Selector) ToSearchFilter() string {
 	return fmt.Sprintf(op, s.Field.InMemoryName(), v.AsInterface())
 }
 
+// setToSearchFilter returns the search filter of "$in" as a match on any of the values, and of "$all" as a match on
+// each of the values.
+func (s *Selector) setToSearchFilter(set SetMatcher) string {
+	values := make([]string, len(set.GetValues()))
+	for i, v := range set.GetValues() {
+		values[i] = s.searchFilterValue(v)
+	}
+
+	if set.Type() == IN {
+		return fmt.Sprintf("%s:=[%s]", s.Field.InMemoryName(), strings.Join(values, ","))
+	}
+
+	filters := make([]string, len(values))
+	for i, v := range values {
+		filters[i] = fmt.Sprintf("%s:=%s", s.Field.InMemoryName(), v)
+	}
+	return strings.Join(filters, "&&")
+}
+
+func (s *Selector) searchFilterValue(v value.Value) string {
+	switch v.DataType() {
+	case schema.DoubleType:
+		return v.String()
+	case schema.DateTimeType:
+		if nsec, err := date.ToUnixNano(schema.DateTimeFormat, v.String()); err == nil {
+			return fmt.Sprint(nsec)
+		}
+	}
+
+	return fmt.Sprint(v.AsInterface())
+}
+
 func (s *Selector) IsSearchIndexed() bool {
 	switch {
+	case s.Field.Expression != nil:
+		// the expressions are computed from the documents, they are not in the search index
+		return false
 	case s.Field.DataType == schema.DoubleType:
 		v, ok := s.Matcher.GetValue().(*value.DoubleValue)
 		if !ok {
@@ -15,28 +15,221 @@
 package search
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/buger/jsonparser"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/date"
+	"github.com/tigrisdata/tigris/schema"
 )
 
 const (
 	defaultFacetSize = 10
+	// MaxFacetRanges is the maximum number of the buckets of a range facet, each bucket is a search of the store.
+	MaxFacetRanges = 16
 )
 
 type Facets struct {
 	Fields []FacetField
 }
 
+// QueryField returns the field the facet values of which are searched, the second return value is false if none of
+// the facets has a query.
+func (f Facets) QueryField() (FacetField, bool) {
+	for _, ff := range f.Fields {
+		if len(ff.Query) > 0 {
+			return ff, true
+		}
+	}
+
+	return FacetField{}, false
+}
+
+// RangeFields returns the fields with the range buckets.
+func (f Facets) RangeFields() []FacetField {
+	var fields []FacetField
+	for _, ff := range f.Fields {
+		if ff.IsRange() {
+			fields = append(fields, ff)
+		}
+	}
+
+	return fields
+}
+
+// NumRanges returns the number of the range buckets of all the fields.
+func (f Facets) NumRanges() int {
+	var n int
+	for _, ff := range f.Fields {
+		n += len(ff.Ranges)
+	}
+
+	return n
+}
+
 type FacetField struct {
 	Name string
 	Type string
 	Size int
+	// Ranges are the buckets of a range facet, the documents are counted by the bucket their value falls in instead of
+	// by the distinct values of the field.
+	Ranges []FacetRange
+	// RangeStats is set if the field is faceted in the search store, which returns the stats of the values of the
+	// buckets. The date-time fields are not faceted, so only the counts of their buckets are returned.
+	RangeStats bool
+	// Query searches the values of the facet, only the values with a word starting with the query are counted. It is
+	// used to type-ahead the values of a facet with many distinct values.
+	Query string
+}
+
+// IsRange returns true if the facet counts the documents in the range buckets.
+func (f FacetField) IsRange() bool {
+	return len(f.Ranges) > 0
+}
+
+// ValidateRanges checks that the bounds of the range buckets match the type of the field, the numeric fields take
+// numbers and the date-time fields take the RFC 3339 formatted dates.
+func (f FacetField) ValidateRanges(name string, dataType schema.FieldType) error {
+	isNumeric := dataType == schema.Int32Type || dataType == schema.Int64Type || dataType == schema.DoubleType
+	if !isNumeric && dataType != schema.DateTimeType {
+		return errors.InvalidArgument("Cannot generate range facets for `%s`. Only numeric and date-time fields support ranges", name)
+	}
+
+	for _, r := range f.Ranges {
+		if r.isDate != (dataType == schema.DateTimeType) {
+			if isNumeric {
+				return errors.InvalidArgument("range bounds of `%s` should be numbers", name)
+			}
+			return errors.InvalidArgument("range bounds of `%s` should be RFC 3339 formatted dates", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateQuery checks that the values of the field can be searched, only the string fields and the arrays of strings
+// support the facet query.
+func (f FacetField) ValidateQuery(name string, dataType schema.FieldType, subType schema.FieldType) error {
+	if len(f.Query) == 0 {
+		return nil
+	}
+
+	if dataType != schema.StringType && (dataType != schema.ArrayType || subType != schema.StringType) {
+		return errors.InvalidArgument("Cannot search the facet values of `%s`. Only string fields support facet query", name)
+	}
+
+	return nil
+}
+
+// FacetRange is a bucket of a range facet, a value is in the bucket if it is greater than or equal to From and less
+// than To. A bucket without From or To is open on that side.
+type FacetRange struct {
+	Label string
+	// From and To are the bounds as they are in the request.
+	From jsoniter.RawMessage
+	To   jsoniter.RawMessage
+
+	// from and to are the bounds as they are in the search store, the dates are stored as unix nanoseconds.
+	from   string
+	to     string
+	isDate bool
+}
+
+func newFacetRange(value jsoniter.RawMessage) (FacetRange, error) {
+	type rangeValue struct {
+		Label string
+		From  jsoniter.RawMessage
+		To    jsoniter.RawMessage
+	}
+
+	var v rangeValue
+	if err := jsoniter.Unmarshal(value, &v); err != nil {
+		return FacetRange{}, err
+	}
+	if len(v.From) == 0 && len(v.To) == 0 {
+		return FacetRange{}, errors.InvalidArgument("range facet bucket should have either 'from' or 'to'")
+	}
+
+	r := FacetRange{Label: v.Label, From: v.From, To: v.To}
+	var (
+		fromDate, toDate bool
+		err              error
+	)
+	if r.from, fromDate, err = rangeBound(v.From); err != nil {
+		return FacetRange{}, err
+	}
+	if r.to, toDate, err = rangeBound(v.To); err != nil {
+		return FacetRange{}, err
+	}
+	if len(v.From) > 0 && len(v.To) > 0 && fromDate != toDate {
+		return FacetRange{}, errors.InvalidArgument("range facet bucket bounds should be of the same type")
+	}
+	r.isDate = fromDate || toDate
+
+	if len(r.Label) == 0 {
+		r.Label = fmt.Sprintf("%s-%s", rangeLabel(v.From), rangeLabel(v.To))
+	}
+
+	return r, nil
+}
+
+// rangeBound returns the search store value of a range bound and whether the bound is a date.
+func rangeBound(bound jsoniter.RawMessage) (string, bool, error) {
+	if len(bound) == 0 {
+		return "", false, nil
+	}
+
+	switch jsoniter.Get(bound).ValueType() {
+	case jsoniter.NumberValue:
+		return string(bound), false, nil
+	case jsoniter.StringValue:
+		nsec, err := date.ToUnixNano(schema.DateTimeFormat, jsoniter.Get(bound).ToString())
+		if err != nil {
+			return "", false, errors.InvalidArgument("range facet bound %s is not a RFC 3339 formatted date", string(bound))
+		}
+		return fmt.Sprint(nsec), true, nil
+	default:
+		return "", false, errors.InvalidArgument("range facet bound should be a number or a date, found %s", string(bound))
+	}
+}
+
+func rangeLabel(bound jsoniter.RawMessage) string {
+	if len(bound) == 0 {
+		return "*"
+	}
+
+	return strings.Trim(string(bound), `"`)
+}
+
+// Bounds returns the bounds of the bucket as they are in the search store, empty if the bucket is open on that side.
+func (r FacetRange) Bounds() (string, string) {
+	return r.from, r.to
+}
+
+// ToSearchFilter returns the search store filter of the values of the field in the bucket.
+func (r FacetRange) ToSearchFilter(field string) string {
+	var filters []string
+	if len(r.from) > 0 {
+		filters = append(filters, fmt.Sprintf("%s:>=%s", field, r.from))
+	}
+	if len(r.to) > 0 {
+		filters = append(filters, fmt.Sprintf("%s:<%s", field, r.to))
+	}
+
+	return strings.Join(filters, "&&")
 }
 
 func NewFacetField(name string, value jsoniter.RawMessage) (FacetField, error) {
+	if jsoniter.Get(value).ValueType() == jsoniter.ArrayValue {
+		return newRangeFacetField(name, value)
+	}
+
 	type facetValue struct {
-		Type string
-		Size int
+		Type  string
+		Size  int
+		Query string
 	}
 
 	var v facetValue
@@ -48,12 +241,42 @@ func NewFacetField(name string, value jsoniter.RawMessage) (FacetField, error) {
 	}
 
 	return FacetField{
-		Name: name,
-		Type: v.Type,
-		Size: v.Size,
+		Name:  name,
+		Type:  v.Type,
+		Size:  v.Size,
+		Query: v.Query,
 	}, nil
 }
 
+// newRangeFacetField returns the facet of the array of the range buckets, for example,
+// [{"from":0,"to":50},{"from":50,"to":100},{"from":100}].
+func newRangeFacetField(name string, value jsoniter.RawMessage) (FacetField, error) {
+	var buckets []jsoniter.RawMessage
+	if err := jsoniter.Unmarshal(value, &buckets); err != nil {
+		return FacetField{}, err
+	}
+	if len(buckets) == 0 {
+		return FacetField{}, errors.InvalidArgument("range facet of `%s` should have at least one bucket", name)
+	}
+	if len(buckets) > MaxFacetRanges {
+		return FacetField{}, errors.InvalidArgument("range facet of `%s` supports up to %d buckets", name, MaxFacetRanges)
+	}
+
+	f := FacetField{Name: name, Size: len(buckets)}
+	for _, b := range buckets {
+		r, err := newFacetRange(b)
+		if err != nil {
+			return FacetField{}, err
+		}
+		f.Ranges = append(f.Ranges, r)
+	}
+
+	return f, nil
+}
+
+// UnmarshalFacet parses the facets of the request, an object of the faceted fields, or an array of them, for example,
+// [{"brand":{"size":5}}, {"price":[{"from":0,"to":50},{"from":50,"to":100}]}]. The values of a facet are searched by
+// setting its query, for example, {"brand":{"size":5,"query":"sam"}}.
 func UnmarshalFacet(input jsoniter.RawMessage) (Facets, error) {
 	facets := Facets{}
 	var err error
@@ -61,6 +284,22 @@ func UnmarshalFacet(input jsoniter.RawMessage) (Facets, error) {
 		return facets, nil
 	}
 
+	if jsoniter.Get(input).ValueType() == jsoniter.ArrayValue {
+		var objects []jsoniter.RawMessage
+		if err = jsoniter.Unmarshal(input, &objects); err != nil {
+			return facets, err
+		}
+		for _, o := range objects {
+			f, err := UnmarshalFacet(o)
+			if err != nil {
+				return facets, err
+			}
+			facets.Fields = append(facets.Fields, f.Fields...)
+		}
+
+		return facets, validateFacetQueries(facets)
+	}
+
 	err = jsonparser.ObjectEach(input, func(k []byte, v []byte, jsonDataType jsonparser.ValueType, offset int) error {
 		if err != nil {
 			return err
@@ -74,6 +313,26 @@ func UnmarshalFacet(input jsoniter.RawMessage) (Facets, error) {
 		facets.Fields = append(facets.Fields, facetField)
 		return nil
 	})
+	if err != nil {
+		return facets, err
+	}
+
+	return facets, validateFacetQueries(facets)
+}
+
+// validateFacetQueries checks that the values of at most one facet are searched, as the search store takes a single
+// facet query.
+func validateFacetQueries(facets Facets) error {
+	var queried string
+	for _, ff := range facets.Fields {
+		if len(ff.Query) == 0 {
+			continue
+		}
+		if len(queried) > 0 && queried != ff.Name {
+			return errors.InvalidArgument("facet query is supported on one field only, found `%s` and `%s`", queried, ff.Name)
+		}
+		queried = ff.Name
+	}
 
-	return facets, err
+	return nil
 }
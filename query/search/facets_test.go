@@ -0,0 +1,95 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+)
+
+func TestUnmarshalFacet(t *testing.T) {
+	facets, err := UnmarshalFacet([]byte(`{"brand":{"size":5},"price":[{"from":0,"to":50},{"from":50.5,"label":"expensive"}]}`))
+	require.NoError(t, err)
+	require.Len(t, facets.Fields, 2)
+	require.Equal(t, FacetField{Name: "brand", Size: 5}, facets.Fields[0])
+
+	price := facets.Fields[1]
+	require.True(t, price.IsRange())
+	require.Equal(t, "0-50", price.Ranges[0].Label)
+	require.Equal(t, "price:>=0&&price:<50", price.Ranges[0].ToSearchFilter("price"))
+	require.Equal(t, "expensive", price.Ranges[1].Label)
+	require.Equal(t, "price:>=50.5", price.Ranges[1].ToSearchFilter("price"))
+	require.NoError(t, price.ValidateRanges("price", schema.DoubleType))
+	require.Equal(t, []FacetField{price}, facets.RangeFields())
+	require.Equal(t, 2, facets.NumRanges())
+
+	q := &Query{Facets: facets}
+	require.Equal(t, "brand", q.ToSearchFacets())
+	require.Equal(t, 5, q.ToSearchFacetSize())
+
+	// the array of the faceted fields
+	facets, err = UnmarshalFacet([]byte(`[{"created":[{"to":"2023-01-01T00:00:00Z"}]}]`))
+	require.NoError(t, err)
+	created := facets.Fields[0]
+	require.Equal(t, "*-2023-01-01T00:00:00Z", created.Ranges[0].Label)
+	require.Equal(t, "created:<1672531200000000000", created.Ranges[0].ToSearchFilter("created"))
+	require.NoError(t, created.ValidateRanges("created", schema.DateTimeType))
+	require.Equal(t, errors.InvalidArgument("range bounds of `created` should be numbers"), created.ValidateRanges("created", schema.Int64Type))
+
+	q = &Query{Facets: facets}
+	require.Equal(t, "", q.ToSearchFacets())
+	require.Equal(t, 0, q.ToSearchFacetSize())
+	require.Equal(t, "", q.ToSearchFacetQuery())
+
+	// the facet values search
+	facets, err = UnmarshalFacet([]byte(`[{"brand":{"size":5,"query":"sam"}},{"category":{}}]`))
+	require.NoError(t, err)
+	require.Equal(t, FacetField{Name: "brand", Size: 5, Query: "sam"}, facets.Fields[0])
+	require.NoError(t, facets.Fields[0].ValidateQuery("brand", schema.StringType, schema.UnknownType))
+	require.NoError(t, facets.Fields[0].ValidateQuery("brand", schema.ArrayType, schema.StringType))
+	require.Equal(t, errors.InvalidArgument("Cannot search the facet values of `brand`. Only string fields support facet query"),
+		facets.Fields[0].ValidateQuery("brand", schema.Int64Type, schema.UnknownType))
+	require.NoError(t, facets.Fields[1].ValidateQuery("category", schema.Int64Type, schema.UnknownType))
+
+	q = &Query{Facets: facets}
+	require.Equal(t, "brand,category", q.ToSearchFacets())
+	require.Equal(t, "brand:sam", q.ToSearchFacetQuery())
+}
+
+func TestUnmarshalFacetErrors(t *testing.T) {
+	cases := []struct {
+		input string
+		err   error
+	}{
+		{`{"price":[]}`, errors.InvalidArgument("range facet of `price` should have at least one bucket")},
+		{`{"price":[{"label":"all"}]}`, errors.InvalidArgument("range facet bucket should have either 'from' or 'to'")},
+		{`{"price":[{"from":true}]}`, errors.InvalidArgument("range facet bound should be a number or a date, found true")},
+		{`{"price":[{"from":"yesterday"}]}`, errors.InvalidArgument(`range facet bound "yesterday" is not a RFC 3339 formatted date`)},
+		{`{"price":[{"from":0,"to":"2023-01-01T00:00:00Z"}]}`, errors.InvalidArgument("range facet bucket bounds should be of the same type")},
+		{`{"brand":{"query":"sam"},"category":{"query":"to"}}`, errors.InvalidArgument("facet query is supported on one field only, found `brand` and `category`")},
+		{`[{"brand":{"query":"sam"}},{"category":{"query":"to"}}]`, errors.InvalidArgument("facet query is supported on one field only, found `brand` and `category`")},
+		{`{"price":[{"from":0},{"from":1},{"from":2},{"from":3},{"from":4},{"from":5},{"from":6},{"from":7},{"from":8},{"from":9},{"from":10},{"from":11},{"from":12},{"from":13},{"from":14},{"from":15},{"from":16}]}`, errors.InvalidArgument("range facet of `price` supports up to 16 buckets")},
+	}
+	for _, c := range cases {
+		_, err := UnmarshalFacet([]byte(c.input))
+		require.Equal(t, c.err, err, c.input)
+	}
+
+	f := FacetField{Name: "brand", Ranges: []FacetRange{{}}}
+	require.Equal(t, errors.InvalidArgument("Cannot generate range facets for `brand`. Only numeric and date-time fields support ranges"), f.ValidateRanges("brand", schema.StringType))
+}
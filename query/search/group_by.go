@@ -16,22 +16,50 @@ package search
 
 import (
 	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
 )
 
+// MaxGroupLimit is the maximum number of the hits that can be returned per group.
+const MaxGroupLimit = 99
+
 type GroupBy struct {
 	Fields []string
 	Limit  *int64
 }
 
+// UnmarshalGroupBy decodes the group by of a search request. Apart from the `{"fields": ["city"], "limit": 2}` form,
+// a single field name or an array of field names can be passed as a shorthand.
 func UnmarshalGroupBy(input jsoniter.RawMessage) (GroupBy, error) {
 	if len(input) == 0 {
 		return GroupBy{}, nil
 	}
 
 	var g GroupBy
-	if err := jsoniter.Unmarshal(input, &g); err != nil {
-		return GroupBy{}, err
+	switch jsoniter.Get(input).ValueType() {
+	case jsoniter.StringValue:
+		var field string
+		if err := jsoniter.Unmarshal(input, &field); err != nil {
+			return GroupBy{}, err
+		}
+		g.Fields = []string{field}
+	case jsoniter.ArrayValue:
+		if err := jsoniter.Unmarshal(input, &g.Fields); err != nil {
+			return GroupBy{}, err
+		}
+	default:
+		if err := jsoniter.Unmarshal(input, &g); err != nil {
+			return GroupBy{}, err
+		}
 	}
 
 	return g, nil
 }
+
+// Validate ensures that the number of the hits per group is within the supported range.
+func (g GroupBy) Validate() error {
+	if g.Limit != nil && (*g.Limit < 1 || *g.Limit > MaxGroupLimit) {
+		return errors.InvalidArgument("group limit should be between 1 and %d", MaxGroupLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalGroupBy(t *testing.T) {
+	limit := int64(2)
+	cases := []struct {
+		input string
+		exp   GroupBy
+	}{
+		{`"product_id"`, GroupBy{Fields: []string{"product_id"}}},
+		{`["brand", "city"]`, GroupBy{Fields: []string{"brand", "city"}}},
+		{`{"fields": ["brand"], "limit": 2}`, GroupBy{Fields: []string{"brand"}, Limit: &limit}},
+	}
+	for _, c := range cases {
+		g, err := UnmarshalGroupBy([]byte(c.input))
+		require.NoError(t, err)
+		require.Equal(t, c.exp, g)
+		require.NoError(t, g.Validate())
+	}
+
+	g, err := UnmarshalGroupBy(nil)
+	require.NoError(t, err)
+	require.False(t, (&Query{GroupBy: g}).IsGroupByQuery())
+
+	_, err = UnmarshalGroupBy([]byte(`10`))
+	require.Error(t, err)
+
+	limit = MaxGroupLimit + 1
+	require.Error(t, GroupBy{Fields: []string{"brand"}, Limit: &limit}.Validate())
+}
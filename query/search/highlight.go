@@ -0,0 +1,37 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import "strings"
+
+// Highlight asks the search store to return the snippets of the matched fields of the hits. The matched tokens of
+// the snippets are wrapped inside StartTag and EndTag. All the search fields are highlighted if Fields is empty.
+type Highlight struct {
+	Fields   []string
+	StartTag string
+	EndTag   string
+}
+
+func (q *Query) IsHighlightQuery() bool {
+	return q.Highlight != nil
+}
+
+func (q *Query) ToSearchHighlightFields() string {
+	if q.Highlight == nil {
+		return ""
+	}
+
+	return strings.Join(q.Highlight.Fields, ",")
+}
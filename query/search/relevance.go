@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const textMatchSort = "_text_match"
+
+// Relevance tunes the ranking of the hits of the query by the text match score.
+type Relevance struct {
+	// FieldWeights are the weights of the search fields, a field without a weight has the weight of 1.
+	FieldWeights map[string]int
+	Boost        *Boost
+}
+
+// Boost orders the hits of the buckets of similar text match scores by the values of the Fields, and then by whether
+// the hits match the Filter. The Filter is in the search store syntax.
+type Boost struct {
+	Buckets int
+	Fields  []string
+	Filter  string
+}
+
+// ToSearchFieldWeights returns the weights of the search fields in the order of the search fields.
+func (q *Query) ToSearchFieldWeights() string {
+	if q.Relevance == nil || len(q.Relevance.FieldWeights) == 0 {
+		return ""
+	}
+
+	weights := make([]string, len(q.SearchFields))
+	for i, f := range q.SearchFields {
+		w, ok := q.Relevance.FieldWeights[f]
+		if !ok {
+			w = 1
+		}
+		weights[i] = strconv.Itoa(w)
+	}
+
+	return strings.Join(weights, ",")
+}
+
+// toBoostSortFields returns the sort of the boosted query, the text match score comes first so the boost only orders
+// the hits of the same bucket.
+func (q *Query) toBoostSortFields() string {
+	b := q.Relevance.Boost
+
+	textMatch := textMatchSort
+	if b.Buckets > 0 {
+		textMatch = fmt.Sprintf("%s(buckets: %d)", textMatchSort, b.Buckets)
+	}
+
+	sortBy := []string{textMatch + ":desc"}
+	for _, f := range b.Fields {
+		sortBy = append(sortBy, fmt.Sprintf("%s(missing_values: last):desc", f))
+	}
+	if len(b.Filter) > 0 {
+		sortBy = append(sortBy, fmt.Sprintf("_eval(%s):desc", b.Filter))
+	}
+
+	return strings.Join(sortBy, ",")
+}
+
+func (q *Query) IsBoostQuery() bool {
+	return q.Relevance != nil && q.Relevance.Boost != nil
+}
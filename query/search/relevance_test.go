@@ -0,0 +1,44 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/query/sort"
+)
+
+func TestRelevance(t *testing.T) {
+	q := NewBuilder().SearchFields([]string{"title", "description", "tags"}).Build()
+	require.Equal(t, "", q.ToSearchFieldWeights())
+	require.Equal(t, "", q.ToSortFields())
+
+	q.Relevance = &Relevance{FieldWeights: map[string]int{"title": 5, "tags": 2}}
+	require.Equal(t, "5,1,2", q.ToSearchFieldWeights())
+	require.False(t, q.IsBoostQuery())
+	require.Equal(t, "", q.ToSortFields())
+
+	q.Relevance.Boost = &Boost{Fields: []string{"popularity"}}
+	require.True(t, q.IsBoostQuery())
+	require.Equal(t, "_text_match:desc,popularity(missing_values: last):desc", q.ToSortFields())
+
+	q.Relevance.Boost = &Boost{Buckets: 10, Fields: []string{"created_at"}, Filter: "featured:true"}
+	require.Equal(t, "_text_match(buckets: 10):desc,created_at(missing_values: last):desc,_eval(featured:true):desc", q.ToSortFields())
+
+	// the sort of the request takes precedence
+	q.SortOrder = &sort.Ordering{{Name: "price", Ascending: true}}
+	require.Equal(t, "price(missing_values: last):asc", q.ToSortFields())
+}
@@ -16,7 +16,9 @@ package search
 
 import (
 	"fmt"
+	"strconv"
 
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/query/filter"
 	"github.com/tigrisdata/tigris/query/read"
 	"github.com/tigrisdata/tigris/query/sort"
@@ -36,31 +38,35 @@ type Query struct {
 	SortOrder    *sort.Ordering
 	GroupBy      GroupBy
 	VectorS      VectorSearch
+	Highlight    *Highlight
+	Typo         *api.TypoTolerance
+	Relevance    *Relevance
 }
 
 func (q *Query) ToSearchFacetSize() int {
 	maxSize := 0
 	for _, f := range q.Facets.Fields {
-		if maxSize < f.Size {
+		if !f.IsRange() && maxSize < f.Size {
 			maxSize = f.Size
 		}
 	}
 
-	if len(q.Facets.Fields) > 0 && maxSize == 0 {
+	if len(q.Facets.Fields) > len(q.Facets.RangeFields()) && maxSize == 0 {
 		return defaultFacetSize
 	}
 
 	return maxSize
 }
 
+// ToSearchFacets returns the fields faceted by their distinct values, the range facets are searched separately for
+// each bucket.
 func (q *Query) ToSearchFacets() string {
-	if len(q.Facets.Fields) == 0 {
-		return ""
-	}
-
 	var facets string
-	for i, f := range q.Facets.Fields {
-		if i != 0 {
+	for _, f := range q.Facets.Fields {
+		if f.IsRange() {
+			continue
+		}
+		if len(facets) > 0 {
 			facets += ","
 		}
 		facets += f.Name
@@ -69,6 +75,16 @@ func (q *Query) ToSearchFacets() string {
 	return facets
 }
 
+// ToSearchFacetQuery returns the search store facet query, empty if the values of none of the facets are searched.
+func (q *Query) ToSearchFacetQuery() string {
+	f, ok := q.Facets.QueryField()
+	if !ok {
+		return ""
+	}
+
+	return f.Name + ":" + f.Query
+}
+
 func (q *Query) ToSearchFields() string {
 	var fields string
 	for i, f := range q.SearchFields {
@@ -83,6 +99,9 @@ func (q *Query) ToSearchFields() string {
 func (q *Query) ToSortFields() string {
 	var sortBy string
 	if q.SortOrder == nil {
+		if q.IsBoostQuery() {
+			return q.toBoostSortFields()
+		}
 		return sortBy
 	}
 
@@ -90,14 +109,20 @@ func (q *Query) ToSortFields() string {
 		if i != 0 {
 			sortBy += ","
 		}
-		missingValue := "last"
-		if f.MissingValuesFirst {
-			missingValue = "first"
-		}
 		order := "desc"
 		if f.Ascending {
 			order = "asc"
 		}
+		if f.GeoDistance != nil {
+			sortBy += fmt.Sprintf("%s(%s, %s):%s", f.Name, strconv.FormatFloat(f.GeoDistance.Lat, 'f', -1, 64),
+				strconv.FormatFloat(f.GeoDistance.Lng, 'f', -1, 64), order)
+			continue
+		}
+
+		missingValue := "last"
+		if f.MissingValuesFirst {
+			missingValue = "first"
+		}
 
 		sortBy += fmt.Sprintf("%s(missing_values: %s):%s", f.Name, missingValue, order)
 	}
@@ -200,6 +225,21 @@ func (b *Builder) VectorSearch(vecSearch VectorSearch) *Builder {
 	return b
 }
 
+func (b *Builder) Highlight(h *Highlight) *Builder {
+	b.query.Highlight = h
+	return b
+}
+
+func (b *Builder) TypoTolerance(t *api.TypoTolerance) *Builder {
+	b.query.Typo = t
+	return b
+}
+
+func (b *Builder) Relevance(r *Relevance) *Builder {
+	b.query.Relevance = r
+	return b
+}
+
 func (b *Builder) PageSize(s int) *Builder {
 	b.query.PageSize = s
 	return b
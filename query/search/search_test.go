@@ -77,4 +77,27 @@ func TestQuery_ToSortFields(t *testing.T) {
 		sortBy := q.ToSortFields()
 		assert.Equal(t, expected, sortBy)
 	})
+
+	t.Run("with geo distance sort order", func(t *testing.T) {
+		ordering := &sort.Ordering{
+			{Name: "loc", Ascending: true, GeoDistance: &sort.GeoDistance{Lat: 48.8584, Lng: 2.2945}},
+			{Name: "field_1"},
+		}
+
+		q := NewBuilder().SortOrder(ordering).Build()
+		assert.Equal(t, "loc(48.8584, 2.2945):asc,field_1(missing_values: last):desc", q.ToSortFields())
+	})
+}
+
+func TestQuery_ToSearchHighlightFields(t *testing.T) {
+	q := NewBuilder().Build()
+	assert.False(t, q.IsHighlightQuery())
+	assert.Equal(t, "", q.ToSearchHighlightFields())
+
+	q = NewBuilder().Highlight(&Highlight{StartTag: "<b>", EndTag: "</b>"}).Build()
+	assert.True(t, q.IsHighlightQuery())
+	assert.Equal(t, "", q.ToSearchHighlightFields())
+
+	q = NewBuilder().Highlight(&Highlight{Fields: []string{"title", "parent.summary"}}).Build()
+	assert.Equal(t, "title,parent.summary", q.ToSearchHighlightFields())
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/buger/jsonparser"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
 )
 
 // TODO: Update this to 3 once https://github.com/typesense/typesense/issues/690 is resolved.
@@ -28,6 +29,10 @@ const (
 	DESC = "$desc"
 )
 
+// GeoDistanceOP sorts on the distance of a geopoint field from a point, nearest first by default, for example
+// {"loc": {"$geo_distance": {"lat": 48.85, "lng": 2.34, "order": "$desc"}}}.
+const GeoDistanceOP = "$geo_distance"
+
 type Ordering = []SortField
 
 type SortField struct {
@@ -38,11 +43,27 @@ type SortField struct {
 	// Optional; True if missing/empty/null values to be presented at the top of sort order,
 	// else they are sorted to the end by default
 	MissingValuesFirst bool
+	// Optional; Set when sorting on the distance of a geopoint field from this point
+	GeoDistance *GeoDistance
+}
+
+type GeoDistance struct {
+	Lat float64
+	Lng float64
+}
+
+type geoDistanceOrder struct {
+	schema.GeoPoint
+	Order string `json:"order"`
 }
 
 func newSortField(order jsoniter.RawMessage) (SortField, error) {
 	var s SortField
 	err := jsonparser.ObjectEach(order, func(k []byte, v []byte, vt jsonparser.ValueType, offset int) error {
+		if vt == jsonparser.Object {
+			return newGeoDistanceSortField(&s, string(k), v)
+		}
+
 		switch string(v) {
 		case ASC:
 			s.Ascending = true
@@ -61,9 +82,46 @@ func newSortField(order jsoniter.RawMessage) (SortField, error) {
 	return s, nil
 }
 
+func newGeoDistanceSortField(s *SortField, name string, input jsoniter.RawMessage) error {
+	var geo *geoDistanceOrder
+	err := jsonparser.ObjectEach(input, func(k []byte, v []byte, _ jsonparser.ValueType, _ int) error {
+		if string(k) != GeoDistanceOP {
+			return errors.InvalidArgument("Sort operator can only be `%s`", GeoDistanceOP)
+		}
+		if err := jsoniter.Unmarshal(v, &geo); err != nil {
+			return errors.InvalidArgument("`%s` expects an object of `lat` and `lng`", GeoDistanceOP)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if geo == nil {
+		return errors.InvalidArgument("`%s` expects an object of `lat` and `lng`", GeoDistanceOP)
+	}
+	if err = geo.Validate(); err != nil {
+		return err
+	}
+
+	switch geo.Order {
+	case "", ASC:
+		s.Ascending = true
+	case DESC:
+		s.Ascending = false
+	default:
+		return errors.InvalidArgument("Sort order can only be `%s` or `%s`", ASC, DESC)
+	}
+
+	s.Name = name
+	s.MissingValuesFirst = false
+	s.GeoDistance = &GeoDistance{Lat: *geo.Lat, Lng: *geo.Lng}
+	return nil
+}
+
 // UnmarshalSort expects a json array input. Examples:
 //
 //	[{"field_1": "$asc"}, {"field_2": "$desc"}]
+//	[{"loc": {"$geo_distance": {"lat": 48.85, "lng": 2.34}}}]
 //	[]
 func UnmarshalSort(input jsoniter.RawMessage) (*Ordering, error) {
 	if len(input) == 0 {
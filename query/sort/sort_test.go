@@ -112,4 +112,30 @@ func TestUnmarshalSort(t *testing.T) {
 		assert.ErrorContains(t, err, "Invalid value for `sort`")
 		assert.Nil(t, sort)
 	})
+	t.Run("with geo distance", func(t *testing.T) {
+		sort, err := UnmarshalSort([]byte(`[{"loc":{"$geo_distance":{"lat":48.85,"lng":2.34}}},{"field_1":"$desc"}]`))
+		assert.NoError(t, err)
+		assert.Exactly(t, Ordering{
+			{Name: "loc", Ascending: true, GeoDistance: &GeoDistance{Lat: 48.85, Lng: 2.34}},
+			{Name: "field_1"},
+		}, *sort)
+
+		sort, err = UnmarshalSort([]byte(`[{"loc":{"$geo_distance":{"lat":48.85,"lng":2.34,"order":"$desc"}}}]`))
+		assert.NoError(t, err)
+		assert.False(t, (*sort)[0].Ascending)
+	})
+
+	t.Run("with invalid geo distance", func(t *testing.T) {
+		_, err := UnmarshalSort([]byte(`[{"loc":{"$near":{"lat":48.85,"lng":2.34}}}]`))
+		assert.ErrorContains(t, err, "Sort operator can only be `$geo_distance`")
+
+		_, err = UnmarshalSort([]byte(`[{"loc":{"$geo_distance":{"lat":48.85}}}]`))
+		assert.ErrorContains(t, err, "geo point requires both 'lat' and 'lng'")
+
+		_, err = UnmarshalSort([]byte(`[{"loc":{"$geo_distance":{"lat":48.85,"lng":200}}}]`))
+		assert.ErrorContains(t, err, "longitude '200' should be between -180 and 180")
+
+		_, err = UnmarshalSort([]byte(`[{"loc":{"$geo_distance":{"lat":48.85,"lng":2.34,"order":"asc"}}}]`))
+		assert.ErrorContains(t, err, "Sort order can only be `$asc` or `$desc`")
+	})
 }
@@ -0,0 +1,92 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"sort"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// AnalyzerStandard is the default analyzer, it splits the text on whitespaces and punctuations.
+const AnalyzerStandard = "standard"
+
+// searchAnalyzers maps the analyzers that can be set on the string fields to the locale of the tokenizer of the search
+// store. The locales other than the standard one switch the search store to the language aware tokenization and
+// normalization. Stemming analyzers like "en-stem" are not listed as the search store client doesn't support them yet.
+var searchAnalyzers = map[string]string{
+	AnalyzerStandard: "",
+	"ar":             "ar",
+	"be":             "be",
+	"cs":             "cs",
+	"da":             "da",
+	"de":             "de",
+	"el":             "el",
+	"en":             "en",
+	"es":             "es",
+	"fi":             "fi",
+	"fr":             "fr",
+	"he":             "he",
+	"hi":             "hi",
+	"hu":             "hu",
+	"id":             "id",
+	"it":             "it",
+	"ja":             "ja",
+	"ko":             "ko",
+	"nl":             "nl",
+	"no":             "no",
+	"pl":             "pl",
+	"pt":             "pt",
+	"ro":             "ro",
+	"ru":             "ru",
+	"sr":             "sr",
+	"sv":             "sv",
+	"th":             "th",
+	"tr":             "tr",
+	"uk":             "uk",
+	"vi":             "vi",
+	"zh":             "zh",
+}
+
+// SupportedAnalyzers returns the sorted names of the analyzers that can be set on the fields.
+func SupportedAnalyzers() []string {
+	names := make([]string, 0, len(searchAnalyzers))
+	for name := range searchAnalyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AnalyzerLocale returns the locale of the search store tokenizer for the analyzer, empty for the standard analyzer.
+func AnalyzerLocale(analyzer string) string {
+	return searchAnalyzers[analyzer]
+}
+
+// validateAnalyzer ensures that the analyzer is known and is only set on the string fields that are indexed in search.
+func validateAnalyzer(f *Field, subType FieldType) error {
+	if _, ok := searchAnalyzers[*f.Analyzer]; !ok {
+		return errors.InvalidArgument("unsupported analyzer '%s' on field '%s', supported analyzers are %q", *f.Analyzer, f.FieldName, SupportedAnalyzers())
+	}
+	if f.DataType != StringType && (f.DataType != ArrayType || subType != StringType) {
+		return errors.InvalidArgument("Cannot set analyzer on field '%s' of type '%s'. Only string fields can have an analyzer", f.FieldName, FieldNames[f.DataType])
+	}
+	if f.SearchIndexed != nil && !*f.SearchIndexed {
+		return errors.InvalidArgument("Cannot set analyzer on field '%s' that is not search indexed", f.FieldName)
+	}
+
+	return nil
+}
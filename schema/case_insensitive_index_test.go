@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestCaseInsensitiveIndexes(t *testing.T) {
+	build := func(indexes string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {"id": {"type": "integer"}, "org": {"type": "string"}, "email": {"type": "string"}, "age": {"type": "integer"}},
+			"primary_key": ["id"],
+			"indexes": `+indexes+`
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`[{"name": "email_ci", "fields": [{"field": "email"}], "case_insensitive": true}, {"name": "org_age", "fields": [{"field": "org"}, {"field": "age"}]}]`)
+		require.NoError(t, err)
+
+		emailCI := FindIndex(factory.Indexes.All, "email_ci")
+		require.NotNil(t, emailCI)
+		require.True(t, emailCI.CaseInsensitive)
+		require.True(t, emailCI.IsComposite())
+		require.Len(t, emailCI.Fields, 1)
+		require.False(t, FindIndex(factory.Indexes.All, "org_age").CaseInsensitive)
+	})
+
+	cases := []struct {
+		indexes string
+		err     error
+	}{
+		{
+			`[{"name": "email_ci", "fields": [], "case_insensitive": true}]`,
+			errors.InvalidArgument("index 'email_ci' should have at least one field"),
+		}, {
+			`[{"name": "age_ci", "fields": [{"field": "age"}], "case_insensitive": true}]`,
+			errors.InvalidArgument("index 'age_ci' is case insensitive, it should have a string field"),
+		}, {
+			`[{"name": "org_email_ci", "fields": [{"field": "org"}, {"field": "email", "sort": "desc"}], "case_insensitive": true}]`,
+			errors.InvalidArgument("index 'org_email_ci' is case insensitive, its field 'email' can't be sorted in the descending order"),
+		}, {
+			`[{"name": "email_ci", "fields": [{"field": "email"}]}]`,
+			errors.InvalidArgument("index 'email_ci' should have at least two fields, use the 'index' attribute of the field to index a single field"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.indexes)
+		require.Equal(t, c.err, err, c.indexes)
+	}
+}
+
+func TestCaseInsensitiveIndexSchemaValidator(t *testing.T) {
+	build := func(caseInsensitive string) *Factory {
+		factory, err := NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {"id": {"type": "integer"}, "org": {"type": "string"}, "email": {"type": "string"}},
+			"primary_key": ["id"],
+			"indexes": [{"name": "org_email", "fields": [{"field": "org"}, {"field": "email"}]`+caseInsensitive+`}]
+		}`))
+		require.NoError(t, err)
+
+		return factory
+	}
+
+	existing, err := NewDefaultCollection(1, 1, build(""), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, errors.InvalidArgument("case sensitivity of existing index 'org_email' can't be changed, drop the index and create it again"),
+		(&CaseInsensitiveIndexSchemaValidator{}).Validate(existing, build(`, "case_insensitive": true`)))
+
+	existing, err = NewDefaultCollection(1, 1, build(`, "case_insensitive": true`), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, (&CaseInsensitiveIndexSchemaValidator{}).Validate(existing, build(`, "case_insensitive": true`)))
+}
@@ -78,9 +78,28 @@ type DefaultCollection struct {
 	int64FieldsPath *int64PathBuilder
 	// This is the existing fields in search
 	FieldsInSearch []tsApi.Field
+	// EncryptedFields are the top level fields that are encrypted before persisting the document.
+	EncryptedFields []string
+	// FieldEncryptor is attached by the metadata layer for the collections that have encrypted fields.
+	FieldEncryptor FieldEncryptor
+	// MaskedFields are the fields that have a masking policy attached to them.
+	MaskedFields []maskedField
+	// Webhooks receive the changes of the collection documents.
+	Webhooks []*Webhook
+	// KafkaSinks publish the changes of the collection documents to Kafka topics.
+	KafkaSinks []*KafkaSink
+	// Compression is the compression algorithm of the collection documents, empty for the server default.
+	Compression string
+	// SearchConsistency is the search consistency of the writes to the collection, empty for the server default.
+	SearchConsistency string
+	// Policies are the row-level security filters of the collection keyed by the role.
+	Policies map[string]jsoniter.RawMessage
+	// WriteHooks check and modify the documents before they are written.
+	WriteHooks []*WriteHook
 
 	fieldsWithInsertDefaults map[string]struct{}
 	fieldsWithUpdateDefaults map[string]struct{}
+	computedDefaults         bool
 }
 
 type CollectionType string
@@ -194,6 +213,14 @@ func NewDefaultCollection(id uint32, schVer uint32, factory *Factory, schemas Ve
 		SchemaDeltas:             schemaDeltas,
 		FieldVersions:            fieldVersions,
 		int64FieldsPath:          buildInt64Path(factory.Fields),
+		EncryptedFields:          getEncryptedFields(factory.Fields),
+		MaskedFields:             buildMaskedFields(nil, factory.Fields),
+		Webhooks:                 factory.Webhooks,
+		KafkaSinks:               factory.KafkaSinks,
+		Compression:              factory.Compression,
+		SearchConsistency:        factory.SearchConsistency,
+		Policies:                 factory.Policies,
+		WriteHooks:               factory.WriteHooks,
 	}
 
 	// set fieldDefaulter for default fields
@@ -247,6 +274,48 @@ func (d *DefaultCollection) GetActiveIndexedFields() []*QueryableField {
 	return indexed
 }
 
+// GetCompositeIndexes returns the composite indexes, the writes update them in any state.
+func (d *DefaultCollection) GetCompositeIndexes() []*Index {
+	var indexes []*Index
+	for _, index := range d.SecondaryIndexes.All {
+		if index.IsComposite() {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// GetActiveCompositeIndexes returns the composite indexes that can be used for queries.
+func (d *DefaultCollection) GetActiveCompositeIndexes() []*Index {
+	var indexes []*Index
+	for _, index := range d.GetCompositeIndexes() {
+		if index.State == INDEX_ACTIVE {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// GetActiveCompositeIndexedFields returns the fields of the composite indexes that can be used for queries, and the
+// expressions of the expression indexes.
+func (d *DefaultCollection) GetActiveCompositeIndexedFields() []*QueryableField {
+	var indexed []*QueryableField
+	for _, q := range d.QueryableFields {
+		for _, index := range d.GetActiveCompositeIndexes() {
+			if FindIndexField(index, q.FieldName) >= 0 {
+				indexed = append(indexed, q)
+				break
+			}
+		}
+	}
+	for _, index := range d.GetActiveCompositeIndexes() {
+		if index.Expression != nil {
+			indexed = append(indexed, NewExpressionQueryableField(index.Expression))
+		}
+	}
+	return indexed
+}
+
 func (d *DefaultCollection) GetWriteModeIndexes() []*QueryableField {
 	var indexed []*QueryableField
 	for _, q := range d.QueryableFields {
@@ -344,10 +413,16 @@ func (d *DefaultCollection) setFieldsForDefaults(parent string, fields []*Field)
 			} else {
 				d.fieldsWithInsertDefaults[buildPath(parent, f.FieldName)] = struct{}{}
 			}
+			d.computedDefaults = d.computedDefaults || f.Defaulter.IsComputed()
 		}
 	}
 }
 
+// HasComputedDefaults returns true if the default value of a field is computed from the document.
+func (d *DefaultCollection) HasComputedDefaults() bool {
+	return d.computedDefaults
+}
+
 func buildPath(parent string, field string) string {
 	if len(parent) > 0 {
 		if len(field) > 0 {
@@ -391,6 +466,14 @@ func init() {
 		_, err := parseInt(i)
 		return err == nil
 	}
+	jsonschema.Formats[FieldNames[GeoPointType]] = func(i any) bool {
+		if i == nil {
+			return true
+		}
+
+		_, _, err := ParseGeoPoint(i)
+		return err == nil
+	}
 }
 
 func parseInt(i any) (int64, error) {
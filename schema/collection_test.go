@@ -63,6 +63,13 @@ func TestCollection_SchemaValidate(t *testing.T) {
 			"price": {
 				"type": "number"
 			},
+			"loc": {
+				"type": "array",
+				"format": "geopoint",
+				"items": {
+					"type": "number"
+				}
+			},
 			"simple_items": {
 				"type": "array",
 				"items": {
@@ -217,6 +224,18 @@ func TestCollection_SchemaValidate(t *testing.T) {
 			document: []byte(`{"id": 1, "ts": null, "product": null, "simple_object": null, "simple_items": null}`),
 			expError: "",
 		},
+		{
+			document: []byte(`{"id": 1, "loc": [48.8584, 2.2945]}`),
+			expError: "",
+		},
+		{
+			document: []byte(`{"id": 1, "loc": [48.8584, 182.2945]}`),
+			expError: "is not valid 'geopoint'",
+		},
+		{
+			document: []byte(`{"id": 1, "loc": [48.8584]}`),
+			expError: "is not valid 'geopoint'",
+		},
 		{
 			document: []byte(`{"id": 1, "ts": null, "product": null, "simple_object": {"name": null}, "simple_items": [1, null], "simple_items_string": ["1", null]}`),
 			expError: "",
@@ -418,3 +437,134 @@ func TestCollection_Int64(t *testing.T) {
 	_, ok = int64Paths["array_simple_items"]
 	require.True(t, ok)
 }
+
+type testFieldEncryptor struct{}
+
+func (testFieldEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	return append([]byte("enc:"), plaintext...), nil
+}
+
+func (testFieldEncryptor) Decrypt(ciphertext []byte) ([]byte, error) {
+	return ciphertext[len("enc:"):], nil
+}
+
+func TestCollection_EncryptedFields(t *testing.T) {
+	reqSchema := []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string" },
+			"ssn": { "type": "string", "encrypted": true },
+			"salary": { "type": "number", "encrypted": true }
+		},
+		"primary_key": ["id"]
+	}`)
+
+	schFactory, err := NewFactoryBuilder(true).Build("t1", reqSchema)
+	require.NoError(t, err)
+	coll, err := NewDefaultCollection(1, 1, schFactory, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssn", "salary"}, coll.EncryptedFields)
+
+	doc := []byte(`{"id":1,"name":"alice","ssn":"123-\"45\"-6789","salary":1000.5}`)
+	_, err = coll.EncryptFields(doc)
+	require.Error(t, err)
+
+	coll.FieldEncryptor = testFieldEncryptor{}
+	encrypted, err := coll.EncryptFields(doc)
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted), "6789")
+	require.Contains(t, string(encrypted), `"name":"alice"`)
+
+	decrypted, err := coll.DecryptFields(encrypted)
+	require.NoError(t, err)
+	require.JSONEq(t, string(doc), string(decrypted))
+
+	// null and missing values are not encrypted
+	encrypted, err = coll.EncryptFields([]byte(`{"id":1,"ssn":null}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"ssn":null}`, string(encrypted))
+}
+
+func TestCollection_MaskedFields(t *testing.T) {
+	reqSchema := []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"ssn": { "type": "string", "mask": { "strategy": "partial", "reveal": 4 } },
+			"email": { "type": "string", "mask": { "strategy": "hash", "unmaskedRoles": ["o", "e"] } },
+			"address": {
+				"type": "object",
+				"properties": {
+					"street": { "type": "string", "mask": { "strategy": "null" } },
+					"city": { "type": "string" }
+				}
+			}
+		},
+		"primary_key": ["id"]
+	}`)
+
+	schFactory, err := NewFactoryBuilder(true).Build("t1", reqSchema)
+	require.NoError(t, err)
+	coll, err := NewDefaultCollection(1, 1, schFactory, nil, nil)
+	require.NoError(t, err)
+
+	require.Nil(t, coll.NewFieldMasker("o"))
+
+	doc := []byte(`{"id":1,"ssn":"123-45-6789","email":"a@b.com","address":{"street":"1 main st","city":"sf"}}`)
+
+	masked, err := coll.NewFieldMasker("e").Mask(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"ssn":"*******6789","email":"a@b.com","address":{"street":null,"city":"sf"}}`, string(masked))
+
+	masked, err = coll.NewFieldMasker("ro").Mask(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"ssn":"*******6789","email":"48ca994b60d10bc54082ebd10e69412ab92549f681e6578d549bed59b27d5763","address":{"street":null,"city":"sf"}}`, string(masked))
+
+	masker := coll.NewFieldMasker("e")
+	require.True(t, masker.IsMasked("ssn"))
+	require.True(t, masker.IsMasked("address.street"))
+	require.False(t, masker.IsMasked("email"))
+	require.False(t, masker.IsMasked("address.city"))
+	require.False(t, masker.IsMasked("ssn_verified"))
+}
+
+func TestCollection_ReferenceFields(t *testing.T) {
+	users := []byte(`{"title":"users","properties":{"id":{"type":"string"},"name":{"type":"string"}},"primary_key":["id"]}`)
+	orders := []byte(`{"title":"orders","properties":{"id":{"type":"integer"},"user_id":{"type":"string","reference":{"collection":"users","onDelete":"restrict"}}},"primary_key":["id"]}`)
+
+	usersFactory, err := NewFactoryBuilder(true).Build("users", users)
+	require.NoError(t, err)
+	usersColl, err := NewDefaultCollection(1, 1, usersFactory, nil, nil)
+	require.NoError(t, err)
+
+	ordersFactory, err := NewFactoryBuilder(true).Build("orders", orders)
+	require.NoError(t, err)
+	ordersColl, err := NewDefaultCollection(2, 1, ordersFactory, nil, nil)
+	require.NoError(t, err)
+
+	refs := ordersColl.GetReferenceFields()
+	require.Len(t, refs, 1)
+	require.Equal(t, "user_id", refs[0].FieldName)
+	require.True(t, refs[0].IsIndexable())
+
+	lookup := func(name string) *DefaultCollection {
+		if name == "users" {
+			return usersColl
+		}
+		return nil
+	}
+	require.NoError(t, ValidateReferences(ordersFactory, lookup))
+	require.ErrorContains(t, ValidateReferences(ordersFactory, func(string) *DefaultCollection { return nil }),
+		"referenced collection 'users' doesn't exist for field 'user_id'")
+
+	mismatch := []byte(`{"title":"orders","properties":{"id":{"type":"integer"},"user_id":{"type":"integer","reference":{"collection":"users"}}},"primary_key":["id"]}`)
+	mismatchFactory, err := NewFactoryBuilder(true).Build("orders", mismatch)
+	require.NoError(t, err)
+	require.ErrorContains(t, ValidateReferences(mismatchFactory, lookup),
+		"type of the field 'user_id' doesn't match the primary key of the referenced collection 'users'")
+
+	referencing := GetReferencingFields("users", []*DefaultCollection{usersColl, ordersColl})
+	require.Len(t, referencing, 1)
+	require.Equal(t, "orders", referencing[0].Collection.Name)
+}
@@ -0,0 +1,273 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Sort orders of the fields of a composite index.
+const (
+	IndexSortAsc  = "asc"
+	IndexSortDesc = "desc"
+)
+
+// CompositeIndex is a secondary index over multiple fields. It is defined by the "indexes" of the collection schema:
+//
+//	"indexes": [{"name": "city_age", "fields": [{"field": "address.city"}, {"field": "age", "sort": "desc"}]}]
+//
+// The index keys are ordered by the fields in the order they are declared, each field in its own sort order, so the
+// index is used by the queries filtering or sorting on a prefix of its fields.
+//
+// The "include" fields are not part of the index key, their values are stored in the index entries along with the
+// values of the indexed fields, so the queries reading only these fields are served by the index without reading the
+// documents:
+//
+//	"indexes": [{"name": "city_age", "fields": [{"field": "address.city"}, {"field": "age"}], "include": ["name"]}]
+//
+// The "shards" spread the keys of the index over the hash buckets of the value of the first field, it avoids a hot
+// write shard when the first field is monotonically increasing, like a timestamp, the reads scan all the buckets:
+//
+//	"indexes": [{"name": "ts_kind", "fields": [{"field": "ts"}, {"field": "kind"}], "shards": 16}]
+//
+// A "case_insensitive" index has the case folded values of its string fields in its keys, so the reads with the
+// case-insensitive collation filtering on the equality of the fields, or on a prefix of the last one with an anchored
+// "$regex", are served by the index. The entries keep the original values of the fields. Such an index can have a
+// single field:
+//
+//	"indexes": [{"name": "email_ci", "fields": [{"field": "email"}], "case_insensitive": true}]
+//
+// An index with an "expression" instead of the fields is an expression index, see IndexExpression.
+type CompositeIndex struct {
+	Name            string                 `json:"name"`
+	Fields          []*CompositeIndexField `json:"fields"`
+	Unique          bool                   `json:"unique,omitempty"`
+	Include         []string               `json:"include,omitempty"`
+	Expression      string                 `json:"expression,omitempty"`
+	Shards          int                    `json:"shards,omitempty"`
+	CaseInsensitive bool                   `json:"case_insensitive,omitempty"`
+}
+
+type CompositeIndexField struct {
+	Field string `json:"field"`
+	Sort  string `json:"sort,omitempty"`
+}
+
+// buildCompositeIndexes converts the composite indexes of the schema to the secondary indexes of the collection.
+func buildCompositeIndexes(composite []*CompositeIndex, fields []*Field) ([]*Index, error) {
+	indexes := make([]*Index, 0, len(composite))
+	for _, c := range composite {
+		index := &Index{Name: c.Name, IdxType: SECONDARY_INDEX, State: UNKNOWN, Unique: c.Unique, Include: c.Include, Shards: c.Shards, CaseInsensitive: c.CaseInsensitive}
+		if c.Expression != "" {
+			expr, err := buildIndexExpression(c, fields)
+			if err != nil {
+				return nil, err
+			}
+
+			// the expression is the single field of the index, its values are computed from the documents
+			index.Expression = expr
+			index.Fields = []*Field{{FieldName: expr.String(), DataType: expr.ResultType()}}
+			indexes = append(indexes, index)
+			continue
+		}
+
+		for _, f := range c.Fields {
+			field := findFieldByPath(fields, f.Field)
+			if field == nil {
+				return nil, errors.InvalidArgument("index '%s' field '%s' doesn't exist in the schema", c.Name, f.Field)
+			}
+
+			// the field of the index is identified by its full path
+			indexField := *field
+			indexField.FieldName = f.Field
+			index.Fields = append(index.Fields, &indexField)
+			index.Descending = append(index.Descending, f.Sort == IndexSortDesc)
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+func buildIndexExpression(c *CompositeIndex, fields []*Field) (*IndexExpression, error) {
+	expr, err := ParseIndexExpression(c.Expression)
+	if err != nil {
+		return nil, errors.InvalidArgument("index '%s': %s", c.Name, err.Error())
+	}
+
+	field := findFieldByPath(fields, expr.Field)
+	if field == nil {
+		return nil, errors.InvalidArgument("index '%s' field '%s' doesn't exist in the schema", c.Name, expr.Field)
+	}
+	if field.IsEncrypted() {
+		return nil, errors.InvalidArgument("index '%s' field '%s' is encrypted and can't be indexed", c.Name, expr.Field)
+	}
+	if err = expr.Validate(field.DataType); err != nil {
+		return nil, errors.InvalidArgument("index '%s': %s", c.Name, err.Error())
+	}
+
+	return expr, nil
+}
+
+// FindIndexField returns the position of the field in the index or -1 if the field is not part of the index.
+func FindIndexField(index *Index, name string) int {
+	for i, f := range index.Fields {
+		if f.FieldName == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func findFieldByPath(fields []*Field, path string) *Field {
+	var field *Field
+	for _, name := range strings.Split(path, ".") {
+		field = nil
+		for _, f := range fields {
+			if f.FieldName == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil
+		}
+		fields = field.Fields
+	}
+
+	return field
+}
+
+// validateCaseInsensitiveIndex checks that the case-insensitive index has a string field. The keys of the index are
+// ordered by the folded values, so its fields can't be sorted in the descending order.
+func validateCaseInsensitiveIndex(c *CompositeIndex, fields []*Field) error {
+	if len(c.Fields) == 0 {
+		return errors.InvalidArgument("index '%s' should have at least one field", c.Name)
+	}
+
+	hasString := false
+	for _, f := range c.Fields {
+		if f.Sort == IndexSortDesc {
+			return errors.InvalidArgument("index '%s' is case insensitive, its field '%s' can't be sorted in the descending order", c.Name, f.Field)
+		}
+		if field := findFieldByPath(fields, f.Field); field != nil && field.DataType == StringType {
+			hasString = true
+		}
+	}
+	if !hasString {
+		return errors.InvalidArgument("index '%s' is case insensitive, it should have a string field", c.Name)
+	}
+
+	return nil
+}
+
+func validateCompositeIndexes(composite []*CompositeIndex, indexes []*Index, fields []*Field) error {
+	declared := make(map[string]struct{}, len(composite))
+	for _, c := range composite {
+		declared[c.Name] = struct{}{}
+	}
+
+	// the names of the single field indexes
+	names := make(map[string]struct{})
+	for _, index := range indexes {
+		if _, ok := declared[index.Name]; !ok {
+			names[index.Name] = struct{}{}
+		}
+	}
+
+	for _, c := range composite {
+		if c.Name == "" {
+			return errors.InvalidArgument("index name is required")
+		}
+		if c.Name == PrimaryKeyIndexName || IsReservedField(c.Name) || findFieldByPath(fields, c.Name) != nil {
+			return errors.InvalidArgument("index name '%s' conflicts with a field or a reserved name", c.Name)
+		}
+		if _, ok := names[c.Name]; ok {
+			return errors.InvalidArgument("duplicate index '%s'", c.Name)
+		}
+		names[c.Name] = struct{}{}
+
+		if c.Shards != 0 {
+			if err := validateIndexShards(c.Name, c.Shards); err != nil {
+				return err
+			}
+		}
+
+		if c.Expression != "" {
+			if len(c.Fields) > 0 || len(c.Include) > 0 {
+				return errors.InvalidArgument("index '%s' with an expression can't have fields or included fields", c.Name)
+			}
+			if c.CaseInsensitive {
+				return errors.InvalidArgument("index '%s' with an expression can't be case insensitive", c.Name)
+			}
+			continue
+		}
+
+		if c.CaseInsensitive {
+			if err := validateCaseInsensitiveIndex(c, fields); err != nil {
+				return err
+			}
+		} else if len(c.Fields) < 2 {
+			return errors.InvalidArgument("index '%s' should have at least two fields, use the 'index' attribute of the field to index a single field", c.Name)
+		}
+
+		seen := make(map[string]struct{})
+		for _, f := range c.Fields {
+			if _, ok := seen[f.Field]; ok {
+				return errors.InvalidArgument("index '%s' has duplicate field '%s'", c.Name, f.Field)
+			}
+			seen[f.Field] = struct{}{}
+
+			if f.Sort != "" && f.Sort != IndexSortAsc && f.Sort != IndexSortDesc {
+				return errors.InvalidArgument("index '%s' field '%s' has unsupported sort order '%s'", c.Name, f.Field, f.Sort)
+			}
+		}
+
+		for _, include := range c.Include {
+			if _, ok := seen[include]; ok {
+				return errors.InvalidArgument("index '%s' field '%s' is already part of the index", c.Name, include)
+			}
+			seen[include] = struct{}{}
+
+			field := findFieldByPath(fields, include)
+			if field == nil {
+				return errors.InvalidArgument("index '%s' included field '%s' doesn't exist in the schema", c.Name, include)
+			}
+			if field.IsEncrypted() {
+				return errors.InvalidArgument("index '%s' included field '%s' is encrypted", c.Name, include)
+			}
+		}
+	}
+
+	for _, index := range indexes {
+		if !index.IsComposite() {
+			continue
+		}
+
+		for _, f := range index.Fields {
+			if !SupportedIndexableType(f.DataType) {
+				return errors.InvalidArgument("index '%s' field '%s' of type '%s' can't be indexed", index.Name, f.FieldName, FieldNames[f.DataType])
+			}
+			if f.IsEncrypted() {
+				return errors.InvalidArgument("index '%s' field '%s' is encrypted and can't be indexed", index.Name, f.FieldName)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestCompositeIndexes(t *testing.T) {
+	build := func(indexes string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {
+				"id": {"type": "integer"},
+				"age": {"type": "integer", "index": true},
+				"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"secret": {"type": "string", "encrypted": true}
+			},
+			"primary_key": ["id"],
+			"indexes": `+indexes+`
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`[{"name": "city_age", "fields": [{"field": "address.city"}, {"field": "age", "sort": "desc"}]}]`)
+		require.NoError(t, err)
+
+		coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+		require.NoError(t, err)
+
+		composite := coll.GetCompositeIndexes()
+		require.Len(t, composite, 1)
+		require.Equal(t, "city_age", composite[0].Name)
+		require.Equal(t, "address.city", composite[0].Fields[0].FieldName)
+		require.Equal(t, StringType, composite[0].Fields[0].DataType)
+		require.Equal(t, "age", composite[0].Fields[1].FieldName)
+		require.False(t, composite[0].IsDescending(0))
+		require.True(t, composite[0].IsDescending(1))
+		require.Equal(t, 1, FindIndexField(composite[0], "age"))
+		require.Equal(t, -1, FindIndexField(composite[0], "id"))
+
+		// single field index is still there
+		require.NotNil(t, FindIndex(coll.SecondaryIndexes.All, "age"))
+		require.Empty(t, coll.GetActiveCompositeIndexes())
+
+		composite[0].State = INDEX_ACTIVE
+		var fields []string
+		for _, f := range coll.GetActiveCompositeIndexedFields() {
+			fields = append(fields, f.FieldName)
+		}
+		require.ElementsMatch(t, []string{"address.city", "age"}, fields)
+	})
+
+	t.Run("include", func(t *testing.T) {
+		factory, err := build(`[{"name": "city_age", "fields": [{"field": "address.city"}, {"field": "age"}], "include": ["tags", "address"]}]`)
+		require.NoError(t, err)
+
+		index := FindIndex(factory.Indexes.All, "city_age")
+		require.Equal(t, []string{"tags", "address"}, index.Include)
+	})
+
+	cases := []struct {
+		indexes string
+		err     error
+	}{
+		{
+			`[{"fields": [{"field": "age"}, {"field": "id"}]}]`,
+			errors.InvalidArgument("index name is required"),
+		}, {
+			`[{"name": "age", "fields": [{"field": "age"}, {"field": "id"}]}]`,
+			errors.InvalidArgument("index name 'age' conflicts with a field or a reserved name"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "id"}]}, {"name": "i1", "fields": [{"field": "id"}, {"field": "age"}]}]`,
+			errors.InvalidArgument("duplicate index 'i1'"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}]}]`,
+			errors.InvalidArgument("index 'i1' should have at least two fields, use the 'index' attribute of the field to index a single field"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "age"}]}]`,
+			errors.InvalidArgument("index 'i1' has duplicate field 'age'"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age", "sort": "up"}, {"field": "id"}]}]`,
+			errors.InvalidArgument("index 'i1' field 'age' has unsupported sort order 'up'"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "zip"}]}]`,
+			errors.InvalidArgument("index 'i1' field 'zip' doesn't exist in the schema"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "tags"}]}]`,
+			errors.InvalidArgument("index 'i1' field 'tags' of type 'array' can't be indexed"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "secret"}]}]`,
+			errors.InvalidArgument("index 'i1' field 'secret' is encrypted and can't be indexed"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "id"}], "include": ["age"]}]`,
+			errors.InvalidArgument("index 'i1' field 'age' is already part of the index"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "id"}], "include": ["tags", "tags"]}]`,
+			errors.InvalidArgument("index 'i1' field 'tags' is already part of the index"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "id"}], "include": ["zip"]}]`,
+			errors.InvalidArgument("index 'i1' included field 'zip' doesn't exist in the schema"),
+		}, {
+			`[{"name": "i1", "fields": [{"field": "age"}, {"field": "id"}], "include": ["secret"]}]`,
+			errors.InvalidArgument("index 'i1' included field 'secret' is encrypted"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.indexes)
+		require.Equal(t, c.err, err, c.indexes)
+	}
+}
+
+func TestCompositeIndexSchemaValidator(t *testing.T) {
+	build := func(sort string) *Factory {
+		factory, err := NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {"id": {"type": "integer"}, "age": {"type": "integer"}, "name": {"type": "string"}},
+			"primary_key": ["id"],
+			"indexes": [{"name": "i1", "fields": [{"field": "age", "sort": "`+sort+`"}, {"field": "name"}]}]
+		}`))
+		require.NoError(t, err)
+
+		return factory
+	}
+
+	existing, err := NewDefaultCollection(1, 1, build(IndexSortAsc), nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, (&CompositeIndexSchemaValidator{}).Validate(existing, build(IndexSortAsc)))
+	require.Error(t, (&CompositeIndexSchemaValidator{}).Validate(existing, build(IndexSortDesc)))
+
+	included := build(IndexSortAsc)
+	FindIndex(included.Indexes.All, "i1").Include = []string{"id"}
+	require.Error(t, (&CompositeIndexSchemaValidator{}).Validate(existing, included))
+}
+
+func TestUniqueIndexes(t *testing.T) {
+	build := func(properties string, indexes string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": `+properties+`,
+			"primary_key": ["id"],
+			"indexes": `+indexes+`
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`{"id": {"type": "integer"}, "email": {"type": "string", "unique": true}, "name": {"type": "string", "index": true}}`,
+			`[{"name": "id_name", "unique": true, "fields": [{"field": "id"}, {"field": "name"}]}]`)
+		require.NoError(t, err)
+
+		email := FindIndex(factory.Indexes.All, "email")
+		require.NotNil(t, email)
+		require.True(t, email.Unique)
+		require.True(t, email.Fields[0].IsIndexed())
+		require.False(t, FindIndex(factory.Indexes.All, "name").Unique)
+		require.True(t, FindIndex(factory.Indexes.All, "id_name").Unique)
+	})
+
+	cases := []struct {
+		properties string
+		err        error
+	}{
+		{
+			`{"id": {"type": "integer"}, "email": {"type": "string", "unique": true, "index": false}}`,
+			errors.InvalidArgument("Cannot set unique on field 'email' that is not indexed"),
+		}, {
+			`{"id": {"type": "integer"}, "tags": {"type": "array", "items": {"type": "string"}, "unique": true}}`,
+			errors.InvalidArgument("Cannot set unique on field 'tags' of type 'array'"),
+		}, {
+			`{"id": {"type": "integer"}, "address": {"type": "object", "properties": {"zip": {"type": "string", "unique": true}}}}`,
+			errors.InvalidArgument("Cannot set unique on nested field 'zip'. Only top level fields can be unique"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.properties, `[]`)
+		require.Equal(t, c.err, err, c.properties)
+	}
+}
+
+func TestUniqueIndexSchemaValidator(t *testing.T) {
+	build := func(unique bool) *Factory {
+		u := "false"
+		if unique {
+			u = "true"
+		}
+
+		factory, err := NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {"id": {"type": "integer"}, "email": {"type": "string", "index": true, "unique": `+u+`}},
+			"primary_key": ["id"]
+		}`))
+		require.NoError(t, err)
+
+		return factory
+	}
+
+	existing, err := NewDefaultCollection(1, 1, build(false), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, errors.InvalidArgument("existing index 'email' can't be made unique, drop the index and create it again"),
+		(&UniqueIndexSchemaValidator{}).Validate(existing, build(true)))
+
+	existing, err = NewDefaultCollection(1, 1, build(true), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, (&UniqueIndexSchemaValidator{}).Validate(existing, build(false)))
+}
@@ -0,0 +1,41 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Compression algorithms of the documents of a collection. It is defined by the "compression" of the collection
+// schema:
+//
+//	"compression": "snappy"
+//
+// The collection uses the server default if it is not set. A change of the setting applies to the new writes, the
+// existing documents are rewritten by a background job.
+const (
+	CompressionNone   = "none"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+)
+
+func validateCompression(compression string) error {
+	switch compression {
+	case "", CompressionNone, CompressionZstd, CompressionSnappy:
+		return nil
+	default:
+		return errors.InvalidArgument("unsupported compression '%s'", compression)
+	}
+}
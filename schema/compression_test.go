@@ -0,0 +1,45 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestCompression(t *testing.T) {
+	build := func(compression string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("orders", []byte(`{
+			"title": "orders",
+			"properties": {"id": {"type": "integer"}, "status": {"type": "string"}},
+			"primary_key": ["id"],
+			"compression": "`+compression+`"
+		}`))
+	}
+
+	for _, compression := range []string{"", CompressionNone, CompressionZstd, CompressionSnappy} {
+		factory, err := build(compression)
+		require.NoError(t, err)
+
+		coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, compression, coll.Compression)
+	}
+
+	_, err := build("lz4")
+	require.Equal(t, errors.InvalidArgument("unsupported compression 'lz4'"), err)
+}
@@ -0,0 +1,56 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// CopySchema returns the schema of a copy of a collection with the name. Without indexes the secondary indexes of the
+// collection are not copied, the "index" and the "expireAfter" attributes of the fields are removed along with the
+// "indexes" of the collection. The unique fields are kept, as they are constraints of the documents.
+func CopySchema(reqSchema []byte, name string, indexes bool) ([]byte, error) {
+	var coll map[string]any
+	if err := jsoniter.Unmarshal(reqSchema, &coll); err != nil {
+		return nil, errors.InvalidArgument("invalid schema '%s'", err.Error())
+	}
+
+	coll["title"] = name
+	if !indexes {
+		delete(coll, "indexes")
+		removeFieldIndexes(coll)
+	}
+
+	return jsoniter.Marshal(coll)
+}
+
+// removeFieldIndexes removes the index attributes of the fields of the object and its nested objects and arrays.
+func removeFieldIndexes(object map[string]any) {
+	props, _ := object["properties"].(map[string]any)
+	for _, p := range props {
+		field, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		delete(field, "index")
+		delete(field, "expireAfter")
+		removeFieldIndexes(field)
+		if items, ok := field["items"].(map[string]any); ok {
+			removeFieldIndexes(items)
+		}
+	}
+}
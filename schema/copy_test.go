@@ -0,0 +1,75 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopySchema(t *testing.T) {
+	reqSchema := []byte(`{
+		"title": "users",
+		"properties": {
+			"id": {"type": "integer"},
+			"email": {"type": "string", "unique": true},
+			"name": {"type": "string", "index": true},
+			"seen": {"type": "string", "format": "date-time", "index": true, "expireAfter": "24h"},
+			"address": {"type": "object", "properties": {"city": {"type": "string", "index": true}}},
+			"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string", "index": true}}}}
+		},
+		"primary_key": ["id"],
+		"indexes": [{"name": "city_name", "fields": [{"field": "address.city"}, {"field": "name"}]}]
+	}`)
+
+	t.Run("with_indexes", func(t *testing.T) {
+		copied, err := CopySchema(reqSchema, "users_copy", true)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"title": "users_copy",
+			"properties": {
+				"id": {"type": "integer"},
+				"email": {"type": "string", "unique": true},
+				"name": {"type": "string", "index": true},
+				"seen": {"type": "string", "format": "date-time", "index": true, "expireAfter": "24h"},
+				"address": {"type": "object", "properties": {"city": {"type": "string", "index": true}}},
+				"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string", "index": true}}}}
+			},
+			"primary_key": ["id"],
+			"indexes": [{"name": "city_name", "fields": [{"field": "address.city"}, {"field": "name"}]}]
+		}`, string(copied))
+	})
+	t.Run("without_indexes", func(t *testing.T) {
+		copied, err := CopySchema(reqSchema, "users_copy", false)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"title": "users_copy",
+			"properties": {
+				"id": {"type": "integer"},
+				"email": {"type": "string", "unique": true},
+				"name": {"type": "string"},
+				"seen": {"type": "string", "format": "date-time"},
+				"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+				"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}}}}
+			},
+			"primary_key": ["id"]
+		}`, string(copied))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := CopySchema([]byte(`{`), "users_copy", false)
+		require.Error(t, err)
+	})
+}
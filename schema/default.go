@@ -15,12 +15,14 @@
 package schema
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
 	"github.com/lucsky/cuid"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/lib/uuid"
+	"github.com/tigrisdata/tigris/query/expression/cel"
 )
 
 const (
@@ -50,7 +52,9 @@ func getDefaultValue(fieldType FieldType) any {
 }
 
 type FieldDefaulter struct {
-	value     any
+	value any
+	// expr computes the value from the document, it is the default written as {"$cel": "<expression>"}
+	expr      *cel.Program
 	createdAt bool
 	updatedAt bool
 }
@@ -115,6 +119,15 @@ func newDefaulter(createdAt *bool, updatedAt *bool, name string, dataType FieldT
 		if dataType != ArrayType {
 			return nil, errors.InvalidArgument("default value is not supported for '%s' type: '%s'", name, FieldNames[dataType])
 		}
+	case map[string]any:
+		expr, ok := celExpression(ty)
+		if !ok {
+			return nil, errors.InvalidArgument("default value of field '%s' should be an expression {\"%s\": \"...\"}", name, cel.Key)
+		}
+		if defaulter.expr, err = cel.Compile(expr); err != nil {
+			return nil, err
+		}
+		return defaulter, nil
 	default:
 		return nil, errors.InvalidArgument("unsupported default value for field '%s' type: '%s'", name, FieldNames[dataType])
 	}
@@ -127,6 +140,11 @@ func newDefaulter(createdAt *bool, updatedAt *bool, name string, dataType FieldT
 	return defaulter, nil
 }
 
+// IsComputed returns true if the default value is computed from the document.
+func (defaulter *FieldDefaulter) IsComputed() bool {
+	return defaulter.expr != nil
+}
+
 func (defaulter *FieldDefaulter) TaggedWithUpdatedAt() bool {
 	return defaulter.updatedAt
 }
@@ -135,6 +153,17 @@ func (defaulter *FieldDefaulter) TaggedWithCreatedAt() bool {
 	return defaulter.createdAt
 }
 
+// Evaluate returns the default value of the field of the document. The expression of a computed default is evaluated
+// with the document as it is received, the evaluation fails if the fields it uses are missing unless it checks them
+// with has().
+func (defaulter *FieldDefaulter) Evaluate(ctx context.Context, doc map[string]any) (any, error) {
+	if defaulter.expr == nil {
+		return defaulter.GetValue(), nil
+	}
+
+	return defaulter.expr.Eval(ctx, cel.Vars{Doc: doc})
+}
+
 // GetValue returns the value if there is default tag set for a field. For functions, it will execute it and return the
 // value. If the function is now() then it is converted to createdAt during the construction of the defaulter.
 func (defaulter *FieldDefaulter) GetValue() any {
@@ -0,0 +1,122 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// FieldEncryptor is used by the collection to encrypt the fields tagged as "encrypted" in the schema. The collection
+// doesn't own the keys, the encryptor is attached by the metadata layer once the collection data key is unwrapped.
+type FieldEncryptor interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// HasEncryptedFields returns true if any of the top level fields is tagged as encrypted.
+func HasEncryptedFields(fields []*Field) bool {
+	for _, f := range fields {
+		if f.IsEncrypted() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getEncryptedFields(fields []*Field) []string {
+	var encrypted []string
+	for _, f := range fields {
+		if f.IsEncrypted() {
+			encrypted = append(encrypted, f.FieldName)
+		}
+	}
+
+	return encrypted
+}
+
+func (d *DefaultCollection) HasEncryptedFields() bool {
+	return len(d.EncryptedFields) > 0
+}
+
+// EncryptFields replaces the value of every encrypted field in the document with the base64 encoded ciphertext of
+// the JSON value. Null and missing values are left as-is.
+func (d *DefaultCollection) EncryptFields(doc []byte) ([]byte, error) {
+	if !d.HasEncryptedFields() {
+		return doc, nil
+	}
+	if d.FieldEncryptor == nil {
+		return nil, errors.Internal("encryption key is not loaded for collection '%s'", d.Name)
+	}
+
+	var err error
+	for _, name := range d.EncryptedFields {
+		value, dataType, _, getErr := jsonparser.Get(doc, name)
+		if getErr != nil || dataType == jsonparser.NotExist || dataType == jsonparser.Null {
+			continue
+		}
+		if dataType == jsonparser.String {
+			// jsonparser strips the quotes, add them back so that decryption restores the exact JSON value
+			value = append(append([]byte{'"'}, value...), '"')
+		}
+
+		ciphertext, encErr := d.FieldEncryptor.Encrypt(value)
+		if encErr != nil {
+			return nil, errors.Internal("failed to encrypt field '%s'", name)
+		}
+
+		encoded := strconv.Quote(base64.StdEncoding.EncodeToString(ciphertext))
+		if doc, err = jsonparser.Set(doc, []byte(encoded), name); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// DecryptFields is the reverse of EncryptFields, it restores the original JSON value of every encrypted field.
+func (d *DefaultCollection) DecryptFields(doc []byte) ([]byte, error) {
+	if !d.HasEncryptedFields() || d.FieldEncryptor == nil {
+		return doc, nil
+	}
+
+	var err error
+	for _, name := range d.EncryptedFields {
+		value, dataType, _, getErr := jsonparser.Get(doc, name)
+		if getErr != nil || dataType != jsonparser.String {
+			continue
+		}
+
+		ciphertext, decErr := base64.StdEncoding.DecodeString(string(value))
+		if decErr != nil {
+			return nil, errors.Internal("failed to decode encrypted field '%s'", name)
+		}
+
+		plaintext, decErr := d.FieldEncryptor.Decrypt(ciphertext)
+		if decErr != nil {
+			return nil, errors.Internal("failed to decrypt field '%s'", name)
+		}
+
+		if doc, err = jsonparser.Set(doc, plaintext, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
@@ -49,6 +50,12 @@ const (
 	ArrayType
 	ObjectType
 	VectorType
+	// BigIntType is an arbitrary precision integer.
+	BigIntType
+	// DecimalType is an arbitrary precision decimal number.
+	DecimalType
+	// GeoPointType is a location stored as an array of [latitude, longitude].
+	GeoPointType
 	// For internal querying usage.
 	MaxType
 )
@@ -67,6 +74,9 @@ var FieldNames = [...]string{
 	ArrayType:    "array",
 	ObjectType:   "object",
 	VectorType:   "vector",
+	BigIntType:   "bigint",
+	DecimalType:  "decimal",
+	GeoPointType: "geopoint",
 }
 
 var (
@@ -93,6 +103,7 @@ const (
 	jsonSpecFormatInt32    = "int32"
 	jsonSpecFormatInt64    = "int64"
 	jsonSpecFormatVector   = "vector"
+	jsonSpecFormatGeoPoint = "geopoint"
 )
 
 func ToFieldType(jsonType string, encoding string, format string) FieldType {
@@ -144,8 +155,11 @@ func ToFieldType(jsonType string, encoding string, format string) FieldType {
 
 		return StringType
 	case jsonSpecArray:
-		if format == jsonSpecFormatVector {
+		switch format {
+		case jsonSpecFormatVector:
 			return VectorType
+		case jsonSpecFormatGeoPoint:
+			return GeoPointType
 		}
 		return ArrayType
 	case jsonSpecObject:
@@ -172,9 +186,12 @@ func IsPrimitiveType(fieldType FieldType) bool {
 	return false
 }
 
+// SupportedIndexableType returns true for the types that can have a secondary index. Byte fields are indexed on the
+// decoded bytes, collections that were created before byte fields became indexable need BuildCollectionIndex to add
+// the existing byte values to the index.
 func SupportedIndexableType(fieldType FieldType) bool {
 	switch fieldType {
-	case BoolType, Int32Type, Int64Type, UUIDType, StringType, DateTimeType, DoubleType:
+	case BoolType, Int32Type, Int64Type, UUIDType, StringType, DateTimeType, DoubleType, ByteType:
 		return true
 	default:
 		return false
@@ -183,7 +200,7 @@ func SupportedIndexableType(fieldType FieldType) bool {
 
 func SupportedSearchIndexableType(fieldType FieldType, subType FieldType) bool {
 	switch fieldType {
-	case BoolType, Int32Type, Int64Type, UUIDType, StringType, DateTimeType, DoubleType, ArrayType, VectorType:
+	case BoolType, Int32Type, Int64Type, UUIDType, StringType, DateTimeType, DoubleType, ArrayType, VectorType, GeoPointType:
 		return true
 	case ObjectType:
 		return subType == UnknownType
@@ -239,6 +256,8 @@ func toSearchFieldType(fieldType FieldType, subType FieldType) string {
 		return FieldNames[ObjectType]
 	case VectorType:
 		return searchDoubleType + "[]"
+	case GeoPointType:
+		return FieldNames[GeoPointType]
 	case ArrayType:
 		switch subType {
 		case BoolType:
@@ -287,6 +306,13 @@ var SupportedFieldProperties = container.NewHashSet(
 	"additionalProperties",
 	"dimensions",
 	"id",
+	"encrypted",
+	"mask",
+	"reference",
+	"unique",
+	"expireAfter",
+	"indexShards",
+	"analyzer",
 )
 
 // Indexes is to wrap different index that a collection can have.
@@ -329,6 +355,12 @@ const (
 	INDEX_ACTIVE
 )
 
+// The supported range of the number of the shards of a sharded index, see Index.Shards.
+const (
+	MinIndexShards = 2
+	MaxIndexShards = 256
+)
+
 // Index can be composite, so it has a list of fields, each index has name and encoded id. The encoded is used for key
 // building.
 type Index struct {
@@ -347,6 +379,49 @@ type Index struct {
 	State IndexState
 	// Either a PrimaryKey index or a Secondary Key index
 	IdxType IndexType
+	// KeyEncoding is the version of the encoding used to build the keys of a secondary index. Zero means the index
+	// was built before the encoding was versioned.
+	KeyEncoding uint8
+	// Descending is the sort order of the fields of a composite index, the fields are ascending if it is empty.
+	Descending []bool `json:",omitempty"`
+	// Unique rejects the writes of a value that is already indexed for another document. Null and missing values
+	// are not considered duplicates.
+	Unique bool `json:",omitempty"`
+	// BuildError is the reason the background build of the index failed, the index stays in the write mode until it
+	// is built again.
+	BuildError string `json:",omitempty"`
+	// ExpireAfter makes it a TTL index, the documents are deleted in the background once the date-time value of the
+	// indexed field is older than the duration.
+	ExpireAfter time.Duration `json:",omitempty"`
+	// Include is the paths of the fields which values are stored in the entries of a composite index in addition to
+	// the indexed fields, so the reads of only these fields are served by the index, see CompositeIndex.
+	Include []string `json:",omitempty"`
+	// Expression makes it an expression index, the result of the expression is indexed instead of the value of a
+	// field. It has a single field named as the expression, and it is maintained like a composite index.
+	Expression *IndexExpression `json:",omitempty"`
+	// Shards is the number of the hash buckets the keys of the index are spread over, zero if the index is not
+	// sharded. The bucket of a key is computed from the value of the first field, so the writes of a monotonically
+	// increasing value don't all land at the end of the index.
+	Shards int `json:",omitempty"`
+	// CaseInsensitive indexes the case folded values of the string fields, the entries keep the original values of
+	// the fields. It is maintained like a composite index even if it has a single field.
+	CaseInsensitive bool `json:",omitempty"`
+}
+
+// IsSharded returns true if the keys of the index are spread over the hash buckets, see Shards.
+func (i *Index) IsSharded() bool {
+	return i.Shards > 1
+}
+
+// IsComposite returns true if it is a secondary index over multiple fields, see CompositeIndex. An expression index
+// is also a composite index.
+func (i *Index) IsComposite() bool {
+	return i.IsSecondaryIndex() && (len(i.Fields) > 1 || i.Expression != nil || i.CaseInsensitive)
+}
+
+// IsDescending returns true if the field at the position is sorted in the descending order in the index.
+func (i *Index) IsDescending(pos int) bool {
+	return pos < len(i.Descending) && i.Descending[pos]
 }
 
 func (i *Index) IsSecondaryIndex() bool {
@@ -392,6 +467,19 @@ func (i *Index) IsCompatible(i1 *Index) error {
 		if err := i.Fields[j].IsCompatible("", i1.Fields[j]); err != nil {
 			return err
 		}
+
+		if i.IsDescending(j) != i1.IsDescending(j) {
+			return errors.InvalidArgument("index fields sort order modified %q", i.Fields[j].FieldName)
+		}
+	}
+
+	if len(i.Include) != len(i1.Include) {
+		return errors.InvalidArgument("number of included fields changed")
+	}
+	for j := range i.Include {
+		if i.Include[j] != i1.Include[j] {
+			return errors.InvalidArgument("included fields modified expected %q, found %q", i.Include[j], i1.Include[j])
+		}
 	}
 
 	return nil
@@ -488,9 +576,16 @@ type FieldBuilder struct {
 	Auto                 *bool               `json:"autoGenerate,omitempty"`
 	Sorted               *bool               `json:"sort,omitempty"`
 	Index                *bool               `json:"index,omitempty"`
+	Unique               *bool               `json:"unique,omitempty"`
+	ExpireAfter          *string             `json:"expireAfter,omitempty"`
+	IndexShards          *int                `json:"indexShards,omitempty"`
 	Facet                *bool               `json:"facet,omitempty"`
+	Analyzer             *string             `json:"analyzer,omitempty"`
 	ID                   *bool               `json:"id,omitempty"`
 	SearchIndex          *bool               `json:"searchIndex,omitempty"`
+	Encrypted            *bool               `json:"encrypted,omitempty"`
+	Mask                 *FieldMask          `json:"mask,omitempty"`
+	Reference            *FieldReference     `json:"reference,omitempty"`
 	Dimensions           *int                `json:"dimensions,omitempty"`
 	Items                *FieldBuilder       `json:"items,omitempty"`
 	Properties           jsoniter.RawMessage `json:"properties,omitempty"`
@@ -509,7 +604,29 @@ func (f *FieldBuilder) Type() FieldType {
 
 func (f *FieldBuilder) Build(setSearchDefaults bool) (*Field, error) {
 	fieldType := f.Type()
-	if setSearchDefaults {
+	if f.Reference != nil && f.Index == nil {
+		// reference fields are always indexed so that the referencing documents can be looked up efficiently when the
+		// referenced document is deleted.
+		ptrTrue := true
+		f.Index = &ptrTrue
+	}
+	if f.Unique != nil && *f.Unique && f.Index == nil {
+		// a unique field is enforced by its secondary index
+		ptrTrue := true
+		f.Index = &ptrTrue
+	}
+	if f.ExpireAfter != nil && f.Index == nil {
+		// the expired documents are found using the secondary index of the field
+		ptrTrue := true
+		f.Index = &ptrTrue
+	}
+	if f.IndexShards != nil && f.Index == nil {
+		// the shards are the layout of the secondary index of the field
+		ptrTrue := true
+		f.Index = &ptrTrue
+	}
+
+	if setSearchDefaults && (f.Encrypted == nil || !*f.Encrypted) {
 		// for search indexes, any field in schema is search indexable if it is not set explicitly.
 		// Similarly, we also tag it with sort if it is numeric.
 		if f.supportableFieldForSearchAttributes(fieldType) {
@@ -531,13 +648,20 @@ func (f *FieldBuilder) Build(setSearchDefaults bool) (*Field, error) {
 		Fields:               f.Fields,
 		Sorted:               f.Sorted,
 		Indexed:              f.Index,
+		Unique:               f.Unique,
+		ExpireAfter:          f.ExpireAfter,
+		IndexShards:          f.IndexShards,
 		Faceted:              f.Facet,
+		Analyzer:             f.Analyzer,
 		SearchIndexed:        f.SearchIndex,
 		PrimaryKeyField:      f.Primary,
 		AutoGenerated:        f.Auto,
 		Dimensions:           f.Dimensions,
 		AdditionalProperties: f.AdditionalProperties,
 		SearchIdField:        f.ID,
+		Encrypted:            f.Encrypted,
+		Mask:                 f.Mask,
+		Reference:            f.Reference,
 	}
 
 	if f.CreatedAt != nil || f.UpdatedAt != nil || f.Default != nil {
@@ -567,9 +691,16 @@ type Field struct {
 	AutoGenerated   *bool
 	Sorted          *bool
 	Indexed         *bool
+	Unique          *bool
+	ExpireAfter     *string
+	IndexShards     *int
 	Faceted         *bool
+	Analyzer        *string
 	SearchIndexed   *bool
 	SearchIdField   *bool
+	Encrypted       *bool
+	Mask            *FieldMask
+	Reference       *FieldReference
 	Dimensions      *int
 	// Nested fields are the fields where we know the schema of nested attributes like if properties are
 	Fields               []*Field
@@ -600,6 +731,33 @@ func (f *Field) IsIndexed() bool {
 	return f.Indexed != nil && *f.Indexed
 }
 
+func (f *Field) IsUnique() bool {
+	return f.Unique != nil && *f.Unique
+}
+
+// ExpiresAfter returns the duration after which the documents expire if the field has a TTL index, zero otherwise.
+func (f *Field) ExpiresAfter() time.Duration {
+	if f.ExpireAfter == nil {
+		return 0
+	}
+
+	d, err := time.ParseDuration(*f.ExpireAfter)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+// GetIndexShards returns the number of the hash buckets of the index of the field, zero if it is not sharded.
+func (f *Field) GetIndexShards() int {
+	if f.IndexShards == nil {
+		return 0
+	}
+
+	return *f.IndexShards
+}
+
 func (f *Field) IsSearchId() bool {
 	return f.SearchIdField != nil && *f.SearchIdField
 }
@@ -612,6 +770,19 @@ func (f *Field) IsFaceted() bool {
 	return f.Faceted != nil && *f.Faceted
 }
 
+// SearchLocale returns the locale of the search store tokenizer of the field, empty if the field has no analyzer.
+func (f *Field) SearchLocale() string {
+	if f.Analyzer == nil {
+		return ""
+	}
+
+	return AnalyzerLocale(*f.Analyzer)
+}
+
+func (f *Field) IsEncrypted() bool {
+	return f.Encrypted != nil && *f.Encrypted
+}
+
 func (f *Field) IsMap() bool {
 	return f.AdditionalProperties != nil && *f.AdditionalProperties
 }
@@ -625,6 +796,14 @@ func (f *Field) IsCompatible(keyPath string, f1 *Field) error {
 		return errors.InvalidArgument("primary key changes are not allowed %q", keyPath+f.FieldName)
 	}
 
+	if f.Reference != nil && f1.Reference != nil && f.Reference.Collection != f1.Reference.Collection {
+		return errors.InvalidArgument("changing the referenced collection is not allowed %q", keyPath+f.FieldName)
+	}
+
+	if f.IsEncrypted() != f1.IsEncrypted() {
+		return errors.InvalidArgument("encryption changes are not allowed %q", keyPath+f.FieldName)
+	}
+
 	if f.MaxLength != nil && f1.MaxLength != nil {
 		if *f.MaxLength > *f1.MaxLength && !config.DefaultConfig.Schema.AllowIncompatible {
 			return errors.InvalidArgument("reducing length of an existing field is not allowed %q", keyPath+f.FieldName)
@@ -649,13 +828,20 @@ func (f *Field) GetDefaulter() *FieldDefaulter {
 }
 
 func (f *Field) IsIndexable() bool {
-	if f.Indexed != nil && *f.Indexed && SupportedIndexableType(f.DataType) {
+	if f.Indexed != nil && *f.Indexed && (SupportedIndexableType(f.DataType) || f.IsMultikey()) {
 		return true
 	}
 
 	return false
 }
 
+// IsMultikey returns true for an array of the types that can be indexed, every distinct element of the array is an
+// entry of the index so that the membership queries like {"tags": "golang"} can be served by the index.
+func (f *Field) IsMultikey() bool {
+	return f.DataType == ArrayType && len(f.Fields) > 0 && f.Fields[0].DataType != ByteType &&
+		SupportedIndexableType(f.Fields[0].DataType)
+}
+
 func (f *Field) GetDimensions() int {
 	if f.Dimensions != nil {
 		return *f.Dimensions
@@ -78,7 +78,15 @@ func TestFieldBuilder_Build(t *testing.T) {
 			},
 			{
 				[]byte(`{"unique": true}`),
-				errors.InvalidArgument("unsupported property found 'unique'"),
+				nil,
+			},
+			{
+				[]byte(`{"expireAfter": "24h"}`),
+				nil,
+			},
+			{
+				[]byte(`{"uniqueItems": true}`),
+				errors.InvalidArgument("unsupported property found 'uniqueItems'"),
 			},
 			{
 				[]byte(`{"max_length": 100}`),
@@ -117,13 +125,18 @@ func TestIndexableFieldsAreChecked(t *testing.T) {
 		expErr error
 	}{
 		{
-			// cannot set a byte field as indexable
+			// a byte field is indexed on the decoded bytes
 			[]byte(`{"title": "t1", "properties": { "id": { "type": "integer"}, "s": { "type": "string", "index": true}, "b": {"type": "string", "format":"byte", "index": true}}}`),
-			errors.InvalidArgument("Cannot enable index on field 'b' of type 'byte'. Only top level non-byte fields can be indexed."),
+			nil,
 		},
 		{
-			// cannot index an array
+			// an array of strings is indexed element by element
 			[]byte(`{"title": "t1", "properties": { "id": { "type": "integer"}, "s": { "type": "string", "index": true}, "arr": {"type": "array", "items":{"type": "string"}, "index": true}}}`),
+			nil,
+		},
+		{
+			// cannot index an array of bytes
+			[]byte(`{"title": "t1", "properties": { "id": { "type": "integer"}, "s": { "type": "string", "index": true}, "arr": {"type": "array", "items":{"type": "string", "format":"byte"}, "index": true}}}`),
 			errors.InvalidArgument("Cannot enable index on field 'arr' of type 'array'. Only top level non-byte fields can be indexed."),
 		},
 		{
@@ -137,8 +150,8 @@ func TestIndexableFieldsAreChecked(t *testing.T) {
 			errors.InvalidArgument("Cannot enable index on nested field 'name'"),
 		},
 		{
-			// cannot index a subfield on an array
-			[]byte(`{"title": "t1", "properties": { "id": { "type": "integer"}, "s": { "type": "string", "index": true},"arr": {"type": "array", "items":{"type": "string"}, "index": true}}}`),
+			// cannot index an array of arrays
+			[]byte(`{"title": "t1", "properties": { "id": { "type": "integer"}, "s": { "type": "string", "index": true},"arr": {"type": "array", "items":{"type": "array", "items":{"type": "string"}}, "index": true}}}`),
 			errors.InvalidArgument("Cannot enable index on field 'arr' of type 'array'. Only top level non-byte fields can be indexed."),
 		},
 	}
@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// GeoPoint is a location used in the geo filters and the geo distance sort i.e. {"lat": 48.85, "lng": 2.34}.
+type GeoPoint struct {
+	Lat *float64 `json:"lat"`
+	Lng *float64 `json:"lng"`
+}
+
+// Validate checks that both the coordinates are present and are within their range.
+func (g *GeoPoint) Validate() error {
+	if g.Lat == nil || g.Lng == nil {
+		return errors.InvalidArgument("geo point requires both 'lat' and 'lng'")
+	}
+
+	return ValidateGeoPoint(*g.Lat, *g.Lng)
+}
+
+// ParseGeoPoint returns the latitude and longitude of a geopoint value. A geopoint is stored in the document as an
+// array of two numbers i.e. [latitude, longitude].
+func ParseGeoPoint(i any) (float64, float64, error) {
+	arr, ok := i.([]any)
+	if !ok || len(arr) != 2 {
+		return 0, 0, errors.InvalidArgument("geopoint should be an array of [latitude, longitude]")
+	}
+
+	lat, err := parseGeoCoordinate(arr[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	lng, err := parseGeoCoordinate(arr[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lng, ValidateGeoPoint(lat, lng)
+}
+
+// ValidateGeoPoint checks that latitude and longitude are within their range.
+func ValidateGeoPoint(lat float64, lng float64) error {
+	if lat < -90 || lat > 90 {
+		return errors.InvalidArgument("latitude '%v' should be between -90 and 90", lat)
+	}
+	if lng < -180 || lng > 180 {
+		return errors.InvalidArgument("longitude '%v' should be between -180 and 180", lng)
+	}
+
+	return nil
+}
+
+func parseGeoCoordinate(i any) (float64, error) {
+	switch v := i.(type) {
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case int64:
+		return float64(v), nil
+	}
+
+	return 0, errors.InvalidArgument("geopoint coordinate should be a number but found %T", i)
+}
@@ -0,0 +1,113 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Functions of the index expressions.
+const (
+	ExprLower        = "lower"
+	ExprUpper        = "upper"
+	ExprExtractYear  = "extract_year"
+	ExprExtractMonth = "extract_month"
+	ExprExtractDay   = "extract_day"
+	ExprExtractHour  = "extract_hour"
+)
+
+// indexExpressionFunctions are the type of the argument and the type of the result of the functions.
+var indexExpressionFunctions = map[string]struct {
+	argType    FieldType
+	resultType FieldType
+}{
+	ExprLower:        {StringType, StringType},
+	ExprUpper:        {StringType, StringType},
+	ExprExtractYear:  {DateTimeType, Int64Type},
+	ExprExtractMonth: {DateTimeType, Int64Type},
+	ExprExtractDay:   {DateTimeType, Int64Type},
+	ExprExtractHour:  {DateTimeType, Int64Type},
+}
+
+var indexExpressionPattern = regexp.MustCompile(`^\s*([a-z_]+)\s*\(\s*([a-zA-Z0-9_$.]+)\s*\)\s*$`)
+
+// IndexExpression is a function of a field, the result of the function is indexed instead of the value of the field.
+// It is the "expression" of an index in the "indexes" of the collection schema:
+//
+//	"indexes": [{"name": "email_lower", "expression": "lower(email)"}]
+//
+// The filters on the same expression, like {"lower(email)": "a@b.c"}, are served by the index.
+type IndexExpression struct {
+	Function string
+	Field    string
+}
+
+// IsIndexExpression returns true if the name is written as an expression instead of the path of a field.
+func IsIndexExpression(name string) bool {
+	return strings.Contains(name, "(")
+}
+
+// ParseIndexExpression parses the expression written as "function(field)".
+func ParseIndexExpression(expr string) (*IndexExpression, error) {
+	matches := indexExpressionPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return nil, errors.InvalidArgument("invalid expression '%s', expected 'function(field)'", expr)
+	}
+	if _, ok := indexExpressionFunctions[matches[1]]; !ok {
+		return nil, errors.InvalidArgument("unsupported function '%s' in the expression '%s'", matches[1], expr)
+	}
+
+	return &IndexExpression{Function: matches[1], Field: matches[2]}, nil
+}
+
+// String returns the expression in its canonical form, it is the name of the field of an expression index.
+func (e *IndexExpression) String() string {
+	return e.Function + "(" + e.Field + ")"
+}
+
+// ArgType is the type of the field the function is applied to.
+func (e *IndexExpression) ArgType() FieldType {
+	return indexExpressionFunctions[e.Function].argType
+}
+
+// ResultType is the type of the indexed values.
+func (e *IndexExpression) ResultType() FieldType {
+	return indexExpressionFunctions[e.Function].resultType
+}
+
+// Validate checks that the function can be applied to the field of the type.
+func (e *IndexExpression) Validate(fieldType FieldType) error {
+	if fieldType != e.ArgType() {
+		return errors.InvalidArgument("function '%s' expects a field of type '%s', found field '%s' of type '%s'",
+			e.Function, FieldNames[e.ArgType()], e.Field, FieldNames[fieldType])
+	}
+
+	return nil
+}
+
+// NewExpressionQueryableField returns the field to filter on the expression, the values are computed from the
+// documents.
+func NewExpressionQueryableField(expr *IndexExpression) *QueryableField {
+	return &QueryableField{
+		FieldName:     expr.String(),
+		InMemoryAlias: expr.String(),
+		UnFlattenName: expr.String(),
+		DataType:      expr.ResultType(),
+		Expression:    expr,
+	}
+}
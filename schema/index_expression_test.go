@@ -0,0 +1,116 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestParseIndexExpression(t *testing.T) {
+	expr, err := ParseIndexExpression(" lower( address.email ) ")
+	require.NoError(t, err)
+	require.Equal(t, &IndexExpression{Function: ExprLower, Field: "address.email"}, expr)
+	require.Equal(t, "lower(address.email)", expr.String())
+	require.Equal(t, StringType, expr.ResultType())
+
+	expr, err = ParseIndexExpression("extract_year(created_at)")
+	require.NoError(t, err)
+	require.Equal(t, DateTimeType, expr.ArgType())
+	require.Equal(t, Int64Type, expr.ResultType())
+
+	_, err = ParseIndexExpression("lower(email")
+	require.Equal(t, errors.InvalidArgument("invalid expression 'lower(email', expected 'function(field)'"), err)
+
+	_, err = ParseIndexExpression("trim(email)")
+	require.Equal(t, errors.InvalidArgument("unsupported function 'trim' in the expression 'trim(email)'"), err)
+
+	require.True(t, IsIndexExpression("lower(email)"))
+	require.False(t, IsIndexExpression("address.email"))
+}
+
+func TestExpressionIndexes(t *testing.T) {
+	build := func(indexes string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("users", []byte(`{
+			"title": "users",
+			"properties": {
+				"id": {"type": "integer"},
+				"email": {"type": "string"},
+				"created_at": {"type": "string", "format": "date-time"},
+				"secret": {"type": "string", "encrypted": true}
+			},
+			"primary_key": ["id"],
+			"indexes": `+indexes+`
+		}`))
+	}
+
+	factory, err := build(`[{"name": "email_lower", "expression": "lower(email)", "unique": true}]`)
+	require.NoError(t, err)
+
+	coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	index := FindIndex(coll.SecondaryIndexes.All, "email_lower")
+	require.True(t, index.IsComposite())
+	require.True(t, index.Unique)
+	require.Equal(t, &IndexExpression{Function: ExprLower, Field: "email"}, index.Expression)
+	require.Equal(t, "lower(email)", index.Fields[0].FieldName)
+	require.Equal(t, StringType, index.Fields[0].DataType)
+
+	index.State = INDEX_ACTIVE
+	fields := coll.GetActiveCompositeIndexedFields()
+	require.Len(t, fields, 1)
+	require.Equal(t, "lower(email)", fields[0].FieldName)
+	require.Equal(t, index.Expression, fields[0].Expression)
+
+	cases := []struct {
+		indexes string
+		err     error
+	}{
+		{
+			`[{"name": "year", "expression": "extract_year(created_at)"}]`,
+			nil,
+		}, {
+			`[{"name": "year", "expression": "extract_year(email)"}]`,
+			errors.InvalidArgument("index 'year': function 'extract_year' expects a field of type 'datetime', found field 'email' of type 'string'"),
+		}, {
+			`[{"name": "lower", "expression": "lower(name)"}]`,
+			errors.InvalidArgument("index 'lower' field 'name' doesn't exist in the schema"),
+		}, {
+			`[{"name": "lower", "expression": "lower(secret)"}]`,
+			errors.InvalidArgument("index 'lower' field 'secret' is encrypted and can't be indexed"),
+		}, {
+			`[{"name": "lower", "expression": "lower email"}]`,
+			errors.InvalidArgument("index 'lower': invalid expression 'lower email', expected 'function(field)'"),
+		}, {
+			`[{"name": "lower", "expression": "lower(email)", "fields": [{"field": "email"}]}]`,
+			errors.InvalidArgument("index 'lower' with an expression can't have fields or included fields"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.indexes)
+		require.Equal(t, c.err, err, c.indexes)
+	}
+}
+
+func TestExpressionIndexCompatibility(t *testing.T) {
+	existing := &Index{Name: "e", Expression: &IndexExpression{Function: ExprLower, Field: "email"}, Fields: []*Field{{FieldName: "lower(email)", DataType: StringType}}}
+	updated := &Index{Name: "e", Expression: &IndexExpression{Function: ExprUpper, Field: "email"}, Fields: []*Field{{FieldName: "upper(email)", DataType: StringType}}}
+
+	require.NoError(t, existing.IsCompatible(existing))
+	require.Equal(t, errors.InvalidArgument("index fields modified expected \"lower(email)\", found \"upper(email)\""), existing.IsCompatible(updated))
+}
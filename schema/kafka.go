@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"regexp"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Formats of the key of the Kafka records.
+const (
+	KafkaKeyPrimaryKey = "primary_key"
+	KafkaKeyNone       = "none"
+)
+
+// Formats of the value of the Kafka records.
+const (
+	KafkaFormatJSON = "json"
+	KafkaFormatAvro = "avro"
+)
+
+var validKafkaTopicPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// KafkaSink publishes the changes of the collection documents to a Kafka topic. It is defined in the "kafka" list of
+// the collection schema:
+//
+//	"kafka": [{"topic": "orders", "key": "primary_key", "format": "avro"}]
+type KafkaSink struct {
+	// Topic is the Kafka topic the changes are published to.
+	Topic string `json:"topic"`
+	// Key is the key of the records, "primary_key" for the JSON array of the primary key values, which is the
+	// default, or "none" to spread the records across the partitions of the topic.
+	Key string `json:"key,omitempty"`
+	// Format is the format of the value of the records, "json" for the change event, which is the default, or "avro"
+	// for the document after the change, a delete is published as a tombstone.
+	Format string `json:"format,omitempty"`
+}
+
+func (k *KafkaSink) validate() error {
+	if !validKafkaTopicPattern.MatchString(k.Topic) || k.Topic == "." || k.Topic == ".." {
+		return errors.InvalidArgument("invalid kafka topic '%s'", k.Topic)
+	}
+
+	switch k.Key {
+	case "", KafkaKeyPrimaryKey, KafkaKeyNone:
+	default:
+		return errors.InvalidArgument("unsupported kafka key '%s' of topic '%s'", k.Key, k.Topic)
+	}
+
+	switch k.Format {
+	case "", KafkaFormatJSON, KafkaFormatAvro:
+	default:
+		return errors.InvalidArgument("unsupported kafka format '%s' of topic '%s'", k.Format, k.Topic)
+	}
+
+	return nil
+}
+
+// GetKey returns the key format of the records of the sink.
+func (k *KafkaSink) GetKey() string {
+	if len(k.Key) > 0 {
+		return k.Key
+	}
+
+	return KafkaKeyPrimaryKey
+}
+
+// GetFormat returns the value format of the records of the sink.
+func (k *KafkaSink) GetFormat() string {
+	if len(k.Format) > 0 {
+		return k.Format
+	}
+
+	return KafkaFormatJSON
+}
+
+// HasKafkaSinks returns true if the changes of the collection are published to Kafka.
+func (d *DefaultCollection) HasKafkaSinks() bool {
+	return len(d.KafkaSinks) > 0
+}
@@ -0,0 +1,57 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestKafkaSinks(t *testing.T) {
+	build := func(sinks string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("orders", []byte(`{
+			"title": "orders",
+			"properties": {"id": {"type": "integer"}, "status": {"type": "string"}},
+			"primary_key": ["id"],
+			"kafka": `+sinks+`
+		}`))
+	}
+
+	factory, err := build(`[{"topic": "orders"}, {"topic": "orders.avro", "key": "none", "format": "avro"}]`)
+	require.NoError(t, err)
+
+	coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+	require.True(t, coll.HasKafkaSinks())
+	require.Len(t, coll.KafkaSinks, 2)
+	require.Equal(t, KafkaKeyPrimaryKey, coll.KafkaSinks[0].GetKey())
+	require.Equal(t, KafkaFormatJSON, coll.KafkaSinks[0].GetFormat())
+	require.Equal(t, KafkaKeyNone, coll.KafkaSinks[1].GetKey())
+	require.Equal(t, KafkaFormatAvro, coll.KafkaSinks[1].GetFormat())
+
+	for sinks, expErr := range map[string]error{
+		`[{"topic": ""}]`:                        errors.InvalidArgument("invalid kafka topic ''"),
+		`[{"topic": "a/b"}]`:                     errors.InvalidArgument("invalid kafka topic 'a/b'"),
+		`[{"topic": ".."}]`:                      errors.InvalidArgument("invalid kafka topic '..'"),
+		`[{"topic": "a", "key": "id"}]`:          errors.InvalidArgument("unsupported kafka key 'id' of topic 'a'"),
+		`[{"topic": "a", "format": "protobuf"}]`: errors.InvalidArgument("unsupported kafka format 'protobuf' of topic 'a'"),
+		`[{"topic": "a"}, {"topic": "a"}]`:       errors.InvalidArgument("duplicate kafka topic 'a'"),
+	} {
+		_, err = build(sinks)
+		require.Equal(t, expErr, err, sinks)
+	}
+}
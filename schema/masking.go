@@ -0,0 +1,184 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+const (
+	// MaskNull replaces the value with null.
+	MaskNull = "null"
+	// MaskHash replaces the value with the hex encoded SHA-256 of the value.
+	MaskHash = "hash"
+	// MaskPartial replaces all but the last "reveal" characters of a string with "*".
+	MaskPartial = "partial"
+
+	maskChar = '*'
+)
+
+// FieldMask is the masking policy of a field. The value of the field is redacted in the read responses for the callers
+// whose role is not part of the unmasked roles.
+type FieldMask struct {
+	Strategy string `json:"strategy"`
+	// Reveal is the number of trailing characters left as-is by the partial strategy.
+	Reveal int `json:"reveal,omitempty"`
+	// UnmaskedRoles are the roles that see the raw value, if not set then the server default is used.
+	UnmaskedRoles []string `json:"unmaskedRoles,omitempty"`
+}
+
+func (m *FieldMask) validate(f *Field) error {
+	switch m.Strategy {
+	case MaskNull, MaskHash:
+	case MaskPartial:
+		if f.DataType != StringType {
+			return errors.InvalidArgument("partial mask is only supported on string fields '%s'", f.FieldName)
+		}
+		if m.Reveal < 0 {
+			return errors.InvalidArgument("reveal can't be negative for the mask on field '%s'", f.FieldName)
+		}
+	default:
+		return errors.InvalidArgument("unsupported mask strategy '%s' on field '%s'", m.Strategy, f.FieldName)
+	}
+
+	return nil
+}
+
+func (m *FieldMask) isUnmasked(role string) bool {
+	roles := m.UnmaskedRoles
+	if len(roles) == 0 {
+		roles = config.DefaultConfig.Masking.UnmaskedRoles
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *FieldMask) apply(value []byte, dataType jsonparser.ValueType) ([]byte, error) {
+	switch m.Strategy {
+	case MaskHash:
+		h := sha256.Sum256(value)
+		return jsoniter.Marshal(hex.EncodeToString(h[:]))
+	case MaskPartial:
+		if dataType != jsonparser.String {
+			return []byte(jsonSpecNull), nil
+		}
+
+		// strip the quotes before unescaping the string
+		str, err := jsonparser.ParseString(value[1 : len(value)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		runes := []rune(str)
+		for i := 0; i < len(runes)-m.Reveal; i++ {
+			runes[i] = maskChar
+		}
+
+		return jsoniter.Marshal(string(runes))
+	default:
+		return []byte(jsonSpecNull), nil
+	}
+}
+
+type maskedField struct {
+	keyPath []string
+	mask    *FieldMask
+}
+
+func buildMaskedFields(parent []string, fields []*Field) []maskedField {
+	var masked []maskedField
+	for _, f := range fields {
+		keyPath := append(append([]string{}, parent...), f.FieldName)
+		switch {
+		case f.Mask != nil:
+			masked = append(masked, maskedField{keyPath: keyPath, mask: f.Mask})
+		case f.DataType == ObjectType && len(f.Fields) > 0:
+			masked = append(masked, buildMaskedFields(keyPath, f.Fields)...)
+		}
+	}
+
+	return masked
+}
+
+// FieldMasker redacts the masked fields of a document for a single caller.
+type FieldMasker struct {
+	fields []maskedField
+}
+
+// NewFieldMasker returns a masker with the fields that the role is not allowed to see, nil if there is nothing to mask.
+func (d *DefaultCollection) NewFieldMasker(role string) *FieldMasker {
+	var masked []maskedField
+	for _, f := range d.MaskedFields {
+		if !f.mask.isUnmasked(role) {
+			masked = append(masked, f)
+		}
+	}
+
+	if len(masked) == 0 {
+		return nil
+	}
+
+	return &FieldMasker{fields: masked}
+}
+
+func (m *FieldMasker) Mask(doc []byte) ([]byte, error) {
+	var err error
+	for _, f := range m.fields {
+		value, dataType, _, getErr := jsonparser.Get(doc, f.keyPath...)
+		if getErr != nil || dataType == jsonparser.NotExist || dataType == jsonparser.Null {
+			continue
+		}
+		if dataType == jsonparser.String {
+			// jsonparser strips the quotes, add them back so that the value is a valid JSON
+			value = append(append([]byte{'"'}, value...), '"')
+		}
+
+		masked, maskErr := f.mask.apply(value, dataType)
+		if maskErr != nil {
+			return nil, maskErr
+		}
+
+		if doc, err = jsonparser.Set(doc, masked, f.keyPath...); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// IsMasked returns true if the value of the field, the dotted path of the field, is redacted for the caller.
+func (m *FieldMasker) IsMasked(field string) bool {
+	for _, f := range m.fields {
+		path := strings.Join(f.keyPath, ".")
+		if field == path || strings.HasPrefix(field, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"context"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/expression/cel"
+)
+
+// TokenVariablePrefix is the prefix of the template variables of the policies, the rest of the variable is the path of
+// the claim in the access token.
+const TokenVariablePrefix = "$$token."
+
+// validatePolicies validates the "policies" of the collection schema, the row-level security filters keyed by the
+// role:
+//
+//	"policies": {"e": {"tenant_id": "$$token.tenant"}}
+//
+// The filter of the caller's role is ANDed into the filter of every read, count, search, update and delete request.
+// The values written as expressions, {"$cel": "token.tenant"}, are computed from the claims of the access token the
+// request is authenticated with, the "token" variable of the expression. The "$$token.<claim>" values are the claims
+// as they are:
+//
+//	"policies": {"e": {"region": {"$in": {"$cel": "token.regions.map(r, r.lowerAscii())"}}}}
+func validatePolicies(policies map[string]jsoniter.RawMessage) error {
+	for role, policy := range policies {
+		var filter map[string]any
+		if err := jsoniter.Unmarshal(policy, &filter); err != nil || len(filter) == 0 {
+			return errors.InvalidArgument("policy of the role '%s' must be a non empty filter object", role)
+		}
+		if err := validatePolicyExpressions(filter); err != nil {
+			return errors.InvalidArgument("policy of the role '%s': %s", role, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func validatePolicyExpressions(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if expr, ok := celExpression(v); ok {
+			_, err := cel.Compile(expr)
+			return err
+		}
+		for _, elem := range v {
+			if err := validatePolicyExpressions(elem); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, elem := range v {
+			if err := validatePolicyExpressions(elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// celExpression returns the expression of the value written as {"$cel": "<expression>"}.
+func celExpression(v map[string]any) (string, bool) {
+	expr, ok := v[cel.Key].(string)
+	return expr, ok && len(v) == 1
+}
+
+// HasPolicies returns true if the collection has any row-level security policy.
+func (d *DefaultCollection) HasPolicies() bool {
+	return len(d.Policies) > 0
+}
+
+// PolicyFilter returns the row-level security filter of the role with the template variables resolved from the token
+// claims, nil if the collection has no policy for the role. A missing claim rejects the request rather than matching
+// the documents that don't have the field.
+func (d *DefaultCollection) PolicyFilter(role string, claims map[string]any) ([]byte, error) {
+	policy, ok := d.Policies[role]
+	if !ok {
+		return nil, nil
+	}
+
+	var filter any
+	if err := jsoniter.Unmarshal(policy, &filter); err != nil {
+		return nil, errors.Internal("invalid policy of the collection '%s'", d.Name)
+	}
+
+	resolved, err := resolveTokenVariables(filter, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsoniter.Marshal(resolved)
+}
+
+func resolveTokenVariables(value any, claims map[string]any) (any, error) {
+	var err error
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, TokenVariablePrefix) {
+			return v, nil
+		}
+
+		claim, ok := LookupClaim(claims, strings.TrimPrefix(v, TokenVariablePrefix))
+		if !ok {
+			return nil, errors.PermissionDenied("access token doesn't have the claim '%s' required by the policy",
+				strings.TrimPrefix(v, TokenVariablePrefix))
+		}
+		return claim, nil
+	case map[string]any:
+		if expr, ok := celExpression(v); ok {
+			return evalPolicyExpression(expr, claims)
+		}
+		for k, elem := range v {
+			if v[k], err = resolveTokenVariables(elem, claims); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			if v[i], err = resolveTokenVariables(elem, claims); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+// evalPolicyExpression computes the value of the policy from the claims. The request is rejected if the expression
+// can't be evaluated, for example because the token doesn't have a claim it uses.
+func evalPolicyExpression(expr string, claims map[string]any) (any, error) {
+	p, err := cel.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := p.Eval(context.Background(), cel.Vars{Token: claims})
+	if err != nil {
+		return nil, errors.PermissionDenied("access token doesn't satisfy the policy expression '%s'", expr)
+	}
+
+	return v, nil
+}
+
+// LookupClaim returns the claim at the dot separated path. The claims that have dots in their name, like the
+// namespaced claims "https://tigris", are matched by the longest prefix of the path.
+func LookupClaim(claims map[string]any, path string) (any, bool) {
+	if claim, ok := claims[path]; ok {
+		return claim, true
+	}
+
+	for i := strings.LastIndexByte(path, '.'); i > 0; i = strings.LastIndexByte(path[:i], '.') {
+		nested, ok := claims[path[:i]].(map[string]any)
+		if !ok {
+			continue
+		}
+		if claim, ok := LookupClaim(nested, path[i+1:]); ok {
+			return claim, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,96 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestPolicies(t *testing.T) {
+	build := func(policies string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("orders", []byte(`{
+			"title": "orders",
+			"properties": {"id": {"type": "integer"}, "tenant_id": {"type": "string"}, "owner": {"type": "string"}},
+			"primary_key": ["id"],
+			"policies": `+policies+`
+		}`))
+	}
+
+	factory, err := build(`{"e": {"tenant_id": "$$token.tenant"}, "ro": {"$or": [{"owner": "$$token.sub"}, {"owner": "shared"}]}}`)
+	require.NoError(t, err)
+
+	coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+	require.True(t, coll.HasPolicies())
+
+	claims := map[string]any{"sub": "u1", "tenant": "t1"}
+
+	policy, err := coll.PolicyFilter("e", claims)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"tenant_id": "t1"}`, string(policy))
+
+	policy, err = coll.PolicyFilter("ro", claims)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"$or": [{"owner": "u1"}, {"owner": "shared"}]}`, string(policy))
+
+	policy, err = coll.PolicyFilter("o", claims)
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	_, err = coll.PolicyFilter("e", map[string]any{"sub": "u1"})
+	require.Equal(t, errors.PermissionDenied("access token doesn't have the claim 'tenant' required by the policy"), err)
+
+	t.Run("expressions", func(t *testing.T) {
+		factory, err := build(`{"e": {"$and": [{"tenant_id": {"$cel": "token.tenant.upperAscii()"}}, {"owner": {"$in": {"$cel": "token.groups.map(g, 'group:' + g)"}}}]}}`)
+		require.NoError(t, err)
+
+		coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+		require.NoError(t, err)
+
+		policy, err := coll.PolicyFilter("e", map[string]any{"tenant": "t1", "groups": []any{"a", "b"}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"$and": [{"tenant_id": "T1"}, {"owner": {"$in": ["group:a", "group:b"]}}]}`, string(policy))
+
+		_, err = coll.PolicyFilter("e", map[string]any{"tenant": "t1"})
+		require.Equal(t, errors.PermissionDenied("access token doesn't satisfy the policy expression 'token.groups.map(g, 'group:' + g)'"), err)
+	})
+
+	for _, invalid := range []string{`{"e": {}}`, `{"e": "tenant_id"}`, `{"e": [{"tenant_id": "t1"}]}`, `{"e": {"tenant_id": {"$cel": "token.tenant +"}}}`} {
+		_, err = build(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLookupClaim(t *testing.T) {
+	claims := map[string]any{
+		"tenant":         "t1",
+		"org":            map[string]any{"id": "o1"},
+		"https://tigris": map[string]any{"nc": "ns1"},
+	}
+
+	for path, expected := range map[string]any{"tenant": "t1", "org.id": "o1", "https://tigris.nc": "ns1"} {
+		claim, ok := LookupClaim(claims, path)
+		require.True(t, ok, path)
+		require.Equal(t, expected, claim, path)
+	}
+
+	for _, path := range []string{"missing", "org.name", "tenant.id"} {
+		_, ok := LookupClaim(claims, path)
+		require.False(t, ok, path)
+	}
+}
@@ -39,10 +39,16 @@ type QueryableField struct {
 	DoNotFlatten   bool
 	Dimensions     *int
 	SearchIdField  bool
+	// Encrypted fields are stored as ciphertext and therefore can't be filtered, sorted or indexed.
+	Encrypted bool
+	// Locale is the locale of the search store tokenizer derived from the analyzer of the field, empty by default.
+	Locale string
 	// This is not stored in flattened form in search
 	// but will allow filtering on array of objects.
 	// ToDo: With secondary indexes on array of objects we need to revisit this.
 	AllowedNestedQFields []*QueryableField
+	// Expression is set for a field computed from the documents, see IndexExpression.
+	Expression *IndexExpression
 }
 
 // InMemoryName returns key name that is used to index this field in the indexing store. For example, an "id" key is indexed with
@@ -139,12 +145,14 @@ func (*QueryableFieldsBuilder) NewQueryableField(name string, f *Field, fieldsIn
 	q := &QueryableField{
 		FieldName:      name,
 		SearchType:     searchType,
+		Locale:         f.SearchLocale(),
 		DataType:       f.DataType,
 		SubType:        subType,
 		packThis:       packThis,
 		Indexed:        f.IsIndexed(),
 		PrimaryIndexed: f.IsPrimaryKey(),
 		SearchIdField:  f.IsSearchId(),
+		Encrypted:      f.IsEncrypted(),
 		Dimensions:     f.Dimensions,
 		UnFlattenName:  f.Name(),
 	}
@@ -0,0 +1,125 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"github.com/tigrisdata/tigris/errors"
+)
+
+const (
+	// OnDeleteNone leaves the referencing documents as-is when the referenced document is deleted.
+	OnDeleteNone = "none"
+	// OnDeleteRestrict fails the delete if the document is still referenced.
+	OnDeleteRestrict = "restrict"
+	// OnDeleteCascade deletes the referencing documents along with the referenced document.
+	OnDeleteCascade = "cascade"
+)
+
+// FieldReference marks a field as a reference to the primary key of a document in another collection of the same
+// database branch. Reference fields are implicitly indexed.
+type FieldReference struct {
+	// Collection is the name of the referenced collection.
+	Collection string `json:"collection"`
+	// Validate enables the existence check of the referenced document on writes.
+	Validate bool `json:"validate,omitempty"`
+	// OnDelete is the action taken on the referencing documents when the referenced document is deleted.
+	OnDelete string `json:"onDelete,omitempty"`
+}
+
+func (r *FieldReference) validate(f *Field) error {
+	if len(r.Collection) == 0 {
+		return errors.InvalidArgument("missing referenced collection for field '%s'", f.FieldName)
+	}
+
+	switch r.OnDelete {
+	case "", OnDeleteNone, OnDeleteRestrict, OnDeleteCascade:
+	default:
+		return errors.InvalidArgument("unsupported onDelete action '%s' for field '%s'", r.OnDelete, f.FieldName)
+	}
+
+	if !SupportedIndexableType(f.DataType) {
+		return errors.InvalidArgument("Cannot set reference on field '%s' of type '%s'", f.FieldName, FieldNames[f.DataType])
+	}
+	if f.IsEncrypted() {
+		return errors.InvalidArgument("Cannot set reference on encrypted field '%s'", f.FieldName)
+	}
+
+	return nil
+}
+
+// ReferenceField is a field of a collection that references another collection.
+type ReferenceField struct {
+	Collection *DefaultCollection
+	Field      *Field
+}
+
+// GetReferenceFields returns the top level fields that have a reference to another collection.
+func (d *DefaultCollection) GetReferenceFields() []*Field {
+	var fields []*Field
+	for _, f := range d.Fields {
+		if f.Reference != nil {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// ValidateReferences checks that the referenced collections exist and that the type of the reference field is same as
+// the primary key of the referenced collection. The lookup is used to find the referenced collection by name.
+func ValidateReferences(factory *Factory, lookup func(name string) *DefaultCollection) error {
+	for _, f := range factory.Fields {
+		if f.Reference == nil {
+			continue
+		}
+
+		var pkFields []*Field
+		if f.Reference.Collection == factory.Name {
+			pkFields = factory.PrimaryKey.Fields
+		} else {
+			referenced := lookup(f.Reference.Collection)
+			if referenced == nil {
+				return errors.InvalidArgument("referenced collection '%s' doesn't exist for field '%s'",
+					f.Reference.Collection, f.FieldName)
+			}
+			pkFields = referenced.GetPrimaryKey().Fields
+		}
+
+		if len(pkFields) != 1 {
+			return errors.InvalidArgument("referenced collection '%s' must have a single field primary key",
+				f.Reference.Collection)
+		}
+		if pkFields[0].DataType != f.DataType {
+			return errors.InvalidArgument("type of the field '%s' doesn't match the primary key of the referenced collection '%s'",
+				f.FieldName, f.Reference.Collection)
+		}
+	}
+
+	return nil
+}
+
+// GetReferencingFields returns the fields of the collections that reference the collection with the given name.
+func GetReferencingFields(name string, collections []*DefaultCollection) []*ReferenceField {
+	var referencing []*ReferenceField
+	for _, c := range collections {
+		for _, f := range c.GetReferenceFields() {
+			if f.Reference.Collection == name {
+				referencing = append(referencing, &ReferenceField{Collection: c, Field: f})
+			}
+		}
+	}
+
+	return referencing
+}
@@ -28,6 +28,7 @@ const (
 	DateSearchKeyPrefix
 	SearchArrNullItem
 	SearchNullKeys
+	Revision
 )
 
 var ReservedFields = [...]string{
@@ -38,6 +39,7 @@ var ReservedFields = [...]string{
 	DateSearchKeyPrefix: "_tigris_date_",
 	SearchArrNullItem:   "_tigris_null",
 	SearchNullKeys:      "_tigris_null_keys",
+	Revision:            "_tigris_revision",
 }
 
 func IsReservedField(name string) bool {
@@ -15,6 +15,8 @@
 package schema
 
 import (
+	"time"
+
 	jsoniter "github.com/json-iterator/go"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/server/config"
@@ -28,6 +30,10 @@ var (
 var validators = []Validator{
 	&PrimaryIndexSchemaValidator{},
 	&FieldSchemaValidator{},
+	&CompositeIndexSchemaValidator{},
+	&UniqueIndexSchemaValidator{},
+	&ShardedIndexSchemaValidator{},
+	&CaseInsensitiveIndexSchemaValidator{},
 }
 
 var searchIndexValidators = []SearchIndexValidator{
@@ -58,6 +64,76 @@ func (*PrimaryIndexSchemaValidator) Validate(existing *DefaultCollection, curren
 	return existing.GetPrimaryKey().IsCompatible(current.PrimaryKey)
 }
 
+// CompositeIndexSchemaValidator rejects the changes of the fields of the existing composite indexes, such an index
+// needs to be rebuilt, so a new index should be created instead.
+type CompositeIndexSchemaValidator struct{}
+
+func (*CompositeIndexSchemaValidator) Validate(existing *DefaultCollection, current *Factory) error {
+	for _, index := range existing.SecondaryIndexes.All {
+		if !index.IsComposite() {
+			continue
+		}
+
+		updated := FindIndex(current.Indexes.All, index.Name)
+		if updated == nil {
+			// dropping an index is allowed
+			continue
+		}
+
+		if err := index.IsCompatible(updated); err != nil {
+			return errors.InvalidArgument("index '%s' can't be modified: %s", index.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// UniqueIndexSchemaValidator rejects making an existing index unique. The values already indexed are not checked for
+// duplicates, so the index needs to be dropped and created again as a unique index, it is then built from the existing
+// documents and the build fails on a duplicate.
+type UniqueIndexSchemaValidator struct{}
+
+func (*UniqueIndexSchemaValidator) Validate(existing *DefaultCollection, current *Factory) error {
+	for _, index := range existing.SecondaryIndexes.All {
+		updated := FindIndex(current.Indexes.All, index.Name)
+		if updated != nil && updated.Unique && !index.Unique {
+			return errors.InvalidArgument("existing index '%s' can't be made unique, drop the index and create it again", index.Name)
+		}
+	}
+
+	return nil
+}
+
+// ShardedIndexSchemaValidator rejects changing the number of the shards of an existing index, the keys of the index
+// are laid out by the shards, so the index needs to be dropped and created again.
+type ShardedIndexSchemaValidator struct{}
+
+func (*ShardedIndexSchemaValidator) Validate(existing *DefaultCollection, current *Factory) error {
+	for _, index := range existing.SecondaryIndexes.All {
+		updated := FindIndex(current.Indexes.All, index.Name)
+		if updated != nil && updated.Shards != index.Shards {
+			return errors.InvalidArgument("shards of existing index '%s' can't be changed, drop the index and create it again", index.Name)
+		}
+	}
+
+	return nil
+}
+
+// CaseInsensitiveIndexSchemaValidator rejects making an existing index case insensitive or case sensitive, the keys
+// of the index have the values as they are or case folded, so the index needs to be dropped and created again.
+type CaseInsensitiveIndexSchemaValidator struct{}
+
+func (*CaseInsensitiveIndexSchemaValidator) Validate(existing *DefaultCollection, current *Factory) error {
+	for _, index := range existing.SecondaryIndexes.All {
+		updated := FindIndex(current.Indexes.All, index.Name)
+		if updated != nil && updated.CaseInsensitive != index.CaseInsensitive {
+			return errors.InvalidArgument("case sensitivity of existing index '%s' can't be changed, drop the index and create it again", index.Name)
+		}
+	}
+
+	return nil
+}
+
 type FieldSchemaValidator struct{}
 
 func (v *FieldSchemaValidator) validateLow(keyPath string, existing []*Field, current []*Field, isMap bool) error {
@@ -283,6 +359,39 @@ func ValidateFieldAttributes(isSearch bool, field *Field) error {
 		return errors.InvalidArgument("following reserved fields are not allowed %q", ReservedFields)
 	}
 
+	if field.IsEncrypted() {
+		if err := validateEncryptedField(isSearch, field); err != nil {
+			return err
+		}
+	}
+
+	if field.Reference != nil {
+		if isSearch {
+			return errors.InvalidArgument("reference is not supported on search index field '%s'", field.Name())
+		}
+		if err := field.Reference.validate(field); err != nil {
+			return err
+		}
+	}
+
+	if field.IsUnique() {
+		if err := validateUniqueField(isSearch, field); err != nil {
+			return err
+		}
+	}
+
+	if field.ExpireAfter != nil {
+		if err := validateExpireAfterField(isSearch, field); err != nil {
+			return err
+		}
+	}
+
+	if field.IndexShards != nil {
+		if err := validateIndexShardsField(isSearch, field); err != nil {
+			return err
+		}
+	}
+
 	if isSearch {
 		if field.IsPrimaryKey() {
 			return errors.InvalidArgument("setting primary key is not supported on search index '%s'", field.Name())
@@ -306,6 +415,16 @@ func ValidateFieldAttributes(isSearch bool, field *Field) error {
 	}
 
 	if field.DataType == ObjectType {
+		if field.Mask != nil {
+			if err := field.Mask.validate(field); err != nil {
+				return err
+			}
+		}
+
+		if field.Analyzer != nil {
+			return errors.InvalidArgument("Cannot set analyzer on object '%s'. Only string fields can have an analyzer", field.Name())
+		}
+
 		if hasIndexingAttributes(field) {
 			if field.IsIndexed() {
 				return errors.InvalidArgument("Cannot enable index on object '%s' or object fields", field.Name())
@@ -327,9 +446,102 @@ func ValidateFieldAttributes(isSearch bool, field *Field) error {
 	return validateFields(field, false)
 }
 
+// validateEncryptedField ensures that encryption is only enabled on fields where the ciphertext never needs to be
+// interpreted by the server i.e. no indexing, searching, sorting or faceting on these fields.
+func validateEncryptedField(isSearch bool, field *Field) error {
+	if isSearch {
+		return errors.InvalidArgument("encryption is not supported on search index field '%s'", field.Name())
+	}
+	if field.IsPrimaryKey() {
+		return errors.InvalidArgument("Cannot enable encryption on primary key field '%s'", field.Name())
+	}
+	if field.DataType == ObjectType || field.DataType == ArrayType || field.DataType == VectorType || field.DataType == GeoPointType {
+		return errors.InvalidArgument("Cannot enable encryption on field '%s' of type '%s'. Only primitive fields can be encrypted",
+			field.Name(), FieldNames[field.DataType])
+	}
+	if hasIndexingAttributes(field) {
+		return errors.InvalidArgument("Cannot enable index, search, sort or facet on encrypted field '%s'", field.Name())
+	}
+
+	return nil
+}
+
+// validateUniqueField ensures that a unique field is a top level field of a type that can be indexed, the uniqueness
+// is enforced by the secondary index of the field.
+func validateUniqueField(isSearch bool, field *Field) error {
+	if isSearch {
+		return errors.InvalidArgument("unique is not supported on search index field '%s'", field.Name())
+	}
+	if !field.IsIndexed() {
+		return errors.InvalidArgument("Cannot set unique on field '%s' that is not indexed", field.Name())
+	}
+	if !SupportedIndexableType(field.DataType) {
+		return errors.InvalidArgument("Cannot set unique on field '%s' of type '%s'", field.Name(), FieldNames[field.DataType])
+	}
+
+	return nil
+}
+
+// validateExpireAfterField ensures that a TTL index is on a top level date-time field and the expiration is a positive
+// duration like "24h" or "90m".
+func validateExpireAfterField(isSearch bool, field *Field) error {
+	if isSearch {
+		return errors.InvalidArgument("expireAfter is not supported on search index field '%s'", field.Name())
+	}
+	if field.DataType != DateTimeType {
+		return errors.InvalidArgument("Cannot set expireAfter on field '%s' of type '%s'. Only date-time fields can expire", field.Name(), FieldNames[field.DataType])
+	}
+	if !field.IsIndexed() {
+		return errors.InvalidArgument("Cannot set expireAfter on field '%s' that is not indexed", field.Name())
+	}
+	if d, err := time.ParseDuration(*field.ExpireAfter); err != nil || d <= 0 {
+		return errors.InvalidArgument("Invalid expireAfter '%s' of field '%s', it should be a positive duration like '24h'", *field.ExpireAfter, field.Name())
+	}
+
+	return nil
+}
+
+// validateIndexShardsField ensures that the shards are only set on an indexed field and the number of the shards is
+// within the supported range.
+func validateIndexShardsField(isSearch bool, field *Field) error {
+	if isSearch {
+		return errors.InvalidArgument("indexShards is not supported on search index field '%s'", field.Name())
+	}
+	if !field.IsIndexed() {
+		return errors.InvalidArgument("Cannot set indexShards on field '%s' that is not indexed", field.Name())
+	}
+
+	return validateIndexShards(field.Name(), *field.IndexShards)
+}
+
+func validateIndexShards(name string, shards int) error {
+	if shards < MinIndexShards || shards > MaxIndexShards {
+		return errors.InvalidArgument("Invalid shards '%d' of index '%s', it should be between %d and %d", shards, name, MinIndexShards, MaxIndexShards)
+	}
+
+	return nil
+}
+
 func validateObjectFields(f *Field, notSupported bool) error {
 	for _, nested := range f.Fields {
+		if nested.ExpireAfter != nil {
+			return errors.InvalidArgument("Cannot set expireAfter on nested field '%s'. Only top level fields can expire", nested.Name())
+		}
+		if nested.IsUnique() {
+			return errors.InvalidArgument("Cannot set unique on nested field '%s'. Only top level fields can be unique", nested.Name())
+		}
+		if nested.IsEncrypted() {
+			return errors.InvalidArgument("Cannot enable encryption on nested field '%s'. Only top level fields can be encrypted", nested.Name())
+		}
+		if nested.Reference != nil {
+			return errors.InvalidArgument("Cannot set reference on nested field '%s'. Only top level fields can be a reference", nested.Name())
+		}
+
 		if nested.DataType == ObjectType {
+			if err := validateFieldMask(nested, notSupported); err != nil {
+				return err
+			}
+
 			if hasIndexingAttributes(nested) {
 				if nested.IsIndexed() {
 					return errors.InvalidArgument("Cannot enable index on object '%s' or object fields", nested.Name())
@@ -375,6 +587,14 @@ func validateFields(f *Field, notSupported bool) error {
 			return nil
 		}
 
+		if f.Fields[0].Reference != nil {
+			return errors.InvalidArgument("Cannot set reference on array items of '%s'", f.FieldName)
+		}
+
+		if f.Fields[0].IsEncrypted() {
+			return errors.InvalidArgument("Cannot enable encryption on array items of '%s'", f.FieldName)
+		}
+
 		if f.Fields[0].DataType != ObjectType {
 			return validateFieldAttribute(f, notSupported)
 		}
@@ -401,9 +621,13 @@ func validateFieldAttribute(f *Field, notSupported bool) error {
 		return errors.InvalidArgument("Cannot enable index or search on an array of objects '%s'", f.FieldName)
 	}
 
+	if err := validateFieldMask(f, notSupported); err != nil {
+		return err
+	}
+
 	subType := UnknownType
 	if f.DataType == ArrayType {
-		if hasIndexingAttributes(f.Fields[0]) {
+		if hasIndexingAttributes(f.Fields[0]) || f.Fields[0].Mask != nil {
 			return errors.InvalidArgument("Attributes for primitive arrays needs to be set on array level '%s'", f.FieldName)
 		}
 
@@ -421,6 +645,9 @@ func validateFieldAttribute(f *Field, notSupported bool) error {
 			return errors.InvalidArgument("only search index attribute is supported on vector field '%s'", f.FieldName)
 		}
 	}
+	if f.DataType == GeoPointType && (f.IsIndexed() || f.IsFaceted() || f.IsSorted()) {
+		return errors.InvalidArgument("only search index attribute is supported on geopoint field '%s'", f.FieldName)
+	}
 	if f.IsIndexed() && !f.IsIndexable() {
 		return errors.InvalidArgument("Cannot enable index on field '%s' of type '%s'. Only top level non-byte fields can be indexed.", f.FieldName, FieldNames[f.DataType])
 	}
@@ -436,12 +663,26 @@ func validateFieldAttribute(f *Field, notSupported bool) error {
 	if f.IsSorted() && !IsPrimitiveType(f.DataType) {
 		return errors.InvalidArgument("Cannot enable sorting on field '%s' of type '%s'", f.FieldName, FieldNames[f.DataType])
 	}
+	if f.Analyzer != nil {
+		return validateAnalyzer(f, subType)
+	}
 
 	return nil
 }
 
+func validateFieldMask(f *Field, notSupported bool) error {
+	if f.Mask == nil {
+		return nil
+	}
+	if notSupported {
+		return errors.InvalidArgument("Cannot set mask on the fields inside an array of objects '%s'", f.FieldName)
+	}
+
+	return f.Mask.validate(f)
+}
+
 func hasIndexingAttributes(f *Field) bool {
-	return f.IsIndexed() || f.IsSearchIndexed() || f.IsFaceted() || f.IsSorted()
+	return f.IsIndexed() || f.IsSearchIndexed() || f.IsFaceted() || f.IsSorted() || f.Analyzer != nil
 }
 
 // ValidateSupportedProperties to validate what all JSON tagging is allowed on a field. We can extend this to also
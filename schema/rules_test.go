@@ -320,6 +320,9 @@ func TestIndexAttributeOnFields(t *testing.T) {
 			"",
 		}, {
 			[]byte(`{"title":"test","properties":{"arr":{"type":"array","items":{"type":"string"},"index":true}}}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"arr":{"type":"array","items":{"type":"array","items":{"type":"string"}},"index":true}}}`),
 			"Cannot enable index on field 'arr' of type 'array'",
 		}, {
 			[]byte(`{"title":"test","properties":{"obj":{"type":"object","index":true}}}`),
@@ -341,3 +344,143 @@ func TestIndexAttributeOnFields(t *testing.T) {
 		}
 	}
 }
+
+func TestEncryptedAttributeOnFields(t *testing.T) {
+	cases := []struct {
+		schema      []byte
+		expErrorMsg string
+	}{
+		{
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"ssn":{"type":"string","encrypted":true}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string","encrypted":true}},"primary_key":["id"]}`),
+			"Cannot enable encryption on primary key field 'id'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"ssn":{"type":"string","encrypted":true,"index":true}},"primary_key":["id"]}`),
+			"Cannot enable index, search, sort or facet on encrypted field 'ssn'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"ssn":{"type":"string","encrypted":true,"searchIndex":true}},"primary_key":["id"]}`),
+			"Cannot enable index, search, sort or facet on encrypted field 'ssn'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","encrypted":true}},"primary_key":["id"]}`),
+			"Cannot enable encryption on field 'obj' of type 'object'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","properties":{"ssn":{"type":"string","encrypted":true}}}},"primary_key":["id"]}`),
+			"Cannot enable encryption on nested field 'ssn'",
+		},
+	}
+	for _, c := range cases {
+		_, err := NewFactoryBuilder(true).Build("test", c.schema)
+		if len(c.expErrorMsg) > 0 {
+			require.Contains(t, err.Error(), c.expErrorMsg)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestMaskAttributeOnFields(t *testing.T) {
+	cases := []struct {
+		schema      []byte
+		expErrorMsg string
+	}{
+		{
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"ssn":{"type":"string","mask":{"strategy":"partial","reveal":4}}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","properties":{"email":{"type":"string","mask":{"strategy":"hash"}}}}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"age":{"type":"integer","mask":{"strategy":"partial"}}},"primary_key":["id"]}`),
+			"partial mask is only supported on string fields 'age'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"ssn":{"type":"string","mask":{"strategy":"shuffle"}}},"primary_key":["id"]}`),
+			"unsupported mask strategy 'shuffle' on field 'ssn'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"arr":{"type":"array","items":{"type":"object","properties":{"ssn":{"type":"string","mask":{"strategy":"null"}}}}}},"primary_key":["id"]}`),
+			"Cannot set mask on the fields inside an array of objects 'ssn'",
+		},
+	}
+	for _, c := range cases {
+		_, err := NewFactoryBuilder(true).Build("test", c.schema)
+		if len(c.expErrorMsg) > 0 {
+			require.Contains(t, err.Error(), c.expErrorMsg)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestAnalyzerAttributeOnFields(t *testing.T) {
+	cases := []struct {
+		schema      []byte
+		expErrorMsg string
+	}{
+		{
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"title":{"type":"string","analyzer":"ja"}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","properties":{"body":{"type":"string","analyzer":"de"}}}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"tags":{"type":"array","items":{"type":"string"},"analyzer":"standard"}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"title":{"type":"string","analyzer":"klingon"}},"primary_key":["id"]}`),
+			"unsupported analyzer 'klingon' on field 'title'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"tags":{"type":"array","items":{"type":"string","analyzer":"ja"}}},"primary_key":["id"]}`),
+			"Attributes for primitive arrays needs to be set on array level 'tags'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","analyzer":"ja"}},"primary_key":["id"]}`),
+			"Cannot set analyzer on object 'obj'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"title":{"type":"string","encrypted":true,"analyzer":"ja"}},"primary_key":["id"]}`),
+			"Cannot enable index, search, sort or facet on encrypted field 'title'",
+		},
+	}
+	for _, c := range cases {
+		_, err := NewFactoryBuilder(true).Build("test", c.schema)
+		if len(c.expErrorMsg) > 0 {
+			require.Contains(t, err.Error(), c.expErrorMsg)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestReferenceAttributeOnFields(t *testing.T) {
+	cases := []struct {
+		schema      []byte
+		expErrorMsg string
+	}{
+		{
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"user_id":{"type":"string","reference":{"collection":"users","validate":true,"onDelete":"cascade"}}},"primary_key":["id"]}`),
+			"",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"user_id":{"type":"string","reference":{"collection":"users","onDelete":"nullify"}}},"primary_key":["id"]}`),
+			"unsupported onDelete action 'nullify' for field 'user_id'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"user_id":{"type":"string","reference":{"onDelete":"restrict"}}},"primary_key":["id"]}`),
+			"missing referenced collection for field 'user_id'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"obj":{"type":"object","properties":{"user_id":{"type":"string","reference":{"collection":"users"}}}}},"primary_key":["id"]}`),
+			"Cannot set reference on nested field 'user_id'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"users":{"type":"array","items":{"type":"string","reference":{"collection":"users"}}}},"primary_key":["id"]}`),
+			"Cannot set reference on array items of 'users'",
+		}, {
+			[]byte(`{"title":"test","properties":{"id":{"type":"string"},"user":{"type":"object","reference":{"collection":"users"}}},"primary_key":["id"]}`),
+			"Cannot set reference on field 'user' of type 'object'",
+		},
+	}
+	for _, c := range cases {
+		_, err := NewFactoryBuilder(true).Build("test", c.schema)
+		if len(c.expErrorMsg) > 0 {
+			require.Contains(t, err.Error(), c.expErrorMsg)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
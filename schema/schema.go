@@ -110,12 +110,21 @@ const (
 )
 
 type JSONSchema struct {
-	Name           string              `json:"title,omitempty"`
-	Description    string              `json:"description,omitempty"`
-	Properties     jsoniter.RawMessage `json:"properties,omitempty"`
-	PrimaryKeys    []string            `json:"primary_key,omitempty"`
-	CollectionType string              `json:"collection_type,omitempty"`
-	Version        uint32              `json:"version,omitempty"`
+	Name              string              `json:"title,omitempty"`
+	Description       string              `json:"description,omitempty"`
+	Properties        jsoniter.RawMessage `json:"properties,omitempty"`
+	PrimaryKeys       []string            `json:"primary_key,omitempty"`
+	CollectionType    string              `json:"collection_type,omitempty"`
+	Version           uint32              `json:"version,omitempty"`
+	Webhooks          []*Webhook          `json:"webhooks,omitempty"`
+	KafkaSinks        []*KafkaSink        `json:"kafka,omitempty"`
+	Compression       string              `json:"compression,omitempty"`
+	SearchConsistency string              `json:"search_consistency,omitempty"`
+	Indexes           []*CompositeIndex   `json:"indexes,omitempty"`
+	// Policies are the row-level security filters of the collection keyed by the role.
+	Policies map[string]jsoniter.RawMessage `json:"policies,omitempty"`
+	// WriteHooks check and modify the documents before they are written.
+	WriteHooks []*WriteHook `json:"hooks,omitempty"`
 }
 
 // Factory is used as an intermediate step so that collection can be initialized with properly encoded values.
@@ -135,6 +144,18 @@ type Factory struct {
 	// CollectionType is the type of the collection. Only two types of collections are supported "messages" and "documents"
 	CollectionType CollectionType
 	Version        uint32
+	// Webhooks receive the changes of the collection documents.
+	Webhooks []*Webhook
+	// KafkaSinks publish the changes of the collection documents to Kafka topics.
+	KafkaSinks []*KafkaSink
+	// Compression is the compression algorithm of the collection documents, empty for the server default.
+	Compression string
+	// SearchConsistency is the search consistency of the writes to the collection, empty for the server default.
+	SearchConsistency string
+	// Policies are the row-level security filters of the collection keyed by the role.
+	Policies map[string]jsoniter.RawMessage
+	// WriteHooks check and modify the documents before they are written.
+	WriteHooks []*WriteHook
 }
 
 func (f *Factory) SecondaryIndexes() []*Index {
@@ -216,10 +237,16 @@ func (fb *FactoryBuilder) Build(collection string, reqSchema jsoniter.RawMessage
 	}
 	for _, field := range fields {
 		if field.Indexed != nil && *field.Indexed {
-			secondaryIndex = append(secondaryIndex, &Index{Name: field.Name(), IdxType: SECONDARY_INDEX, State: UNKNOWN, Fields: []*Field{field}})
+			secondaryIndex = append(secondaryIndex, &Index{Name: field.Name(), IdxType: SECONDARY_INDEX, State: UNKNOWN, Fields: []*Field{field}, Unique: field.IsUnique(), ExpireAfter: field.ExpiresAfter(), Shards: field.GetIndexShards()})
 		}
 	}
 
+	compositeIndexes, err := buildCompositeIndexes(schema.Indexes, fields)
+	if err != nil {
+		return nil, err
+	}
+	secondaryIndex = append(secondaryIndex, compositeIndexes...)
+
 	factory := &Factory{
 		Fields: fields,
 		PrimaryKey: &Index{
@@ -231,16 +258,26 @@ func (fb *FactoryBuilder) Build(collection string, reqSchema jsoniter.RawMessage
 		Indexes: &Indexes{
 			All: secondaryIndex,
 		},
-		Name:           collection,
-		Schema:         reqSchema,
-		CollectionType: cType,
-		Version:        schema.Version,
+		Name:              collection,
+		Schema:            reqSchema,
+		CollectionType:    cType,
+		Version:           schema.Version,
+		Webhooks:          schema.Webhooks,
+		KafkaSinks:        schema.KafkaSinks,
+		Compression:       schema.Compression,
+		SearchConsistency: schema.SearchConsistency,
+		Policies:          schema.Policies,
+		WriteHooks:        schema.WriteHooks,
 	}
 
 	if fb.onUserRequest {
 		if err = fb.validateSchema(factory); err != nil {
 			return nil, err
 		}
+
+		if err = validateCompositeIndexes(schema.Indexes, factory.Indexes.All, fields); err != nil {
+			return nil, err
+		}
 	}
 
 	return factory, nil
@@ -253,7 +290,36 @@ func (*FactoryBuilder) validateSchema(factory *Factory) error {
 		}
 	}
 
-	return nil
+	for _, w := range factory.Webhooks {
+		if err := w.validate(factory.Name); err != nil {
+			return err
+		}
+	}
+
+	topics := make(map[string]struct{})
+	for _, k := range factory.KafkaSinks {
+		if err := k.validate(); err != nil {
+			return err
+		}
+		if _, ok := topics[k.Topic]; ok {
+			return errors.InvalidArgument("duplicate kafka topic '%s'", k.Topic)
+		}
+		topics[k.Topic] = struct{}{}
+	}
+
+	if err := validateCompression(factory.Compression); err != nil {
+		return err
+	}
+
+	if err := validateSearchConsistency(factory.SearchConsistency); err != nil {
+		return err
+	}
+
+	if err := validatePolicies(factory.Policies); err != nil {
+		return err
+	}
+
+	return validateWriteHooks(factory.WriteHooks, factory.Fields)
 }
 
 func setPrimaryKey(reqSchema jsoniter.RawMessage, format string, ifMissing bool) (jsoniter.RawMessage, error) {
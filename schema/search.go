@@ -46,6 +46,8 @@ type SearchJSONSchema struct {
 	Description string              `json:"description,omitempty"`
 	Properties  jsoniter.RawMessage `json:"properties,omitempty"`
 	Source      *SearchSource       `json:"source,omitempty"`
+	// TypoTolerance is the default typo tolerance of the searches of the index.
+	TypoTolerance *api.TypoTolerance `json:"typo_tolerance,omitempty"`
 }
 
 // SearchFactory is used as an intermediate step so that collection can be initialized with properly encoded values.
@@ -58,6 +60,8 @@ type SearchFactory struct {
 	Schema jsoniter.RawMessage
 	Sub    string
 	Source SearchSource
+	// TypoTolerance is the default typo tolerance of the searches of the index, the requests can override it.
+	TypoTolerance *api.TypoTolerance
 }
 
 func (fb *FactoryBuilder) BuildSearch(index string, reqSchema jsoniter.RawMessage) (*SearchFactory, error) {
@@ -103,10 +107,11 @@ func (fb *FactoryBuilder) BuildSearch(index string, reqSchema jsoniter.RawMessag
 	}
 
 	factory := &SearchFactory{
-		Name:   index,
-		Fields: fields,
-		Schema: searchSchema,
-		Source: source,
+		Name:          index,
+		Fields:        fields,
+		Schema:        searchSchema,
+		Source:        source,
+		TypoTolerance: schema.TypoTolerance,
 	}
 
 	idFound := false
@@ -143,7 +148,7 @@ func (*FactoryBuilder) validateSearchSchema(factory *SearchFactory) error {
 		}
 	}
 
-	return nil
+	return factory.TypoTolerance.Validate()
 }
 
 // SearchIndex is to manage search index created by the user.
@@ -165,6 +170,10 @@ type SearchIndex struct {
 	// Source of this index
 	Source        SearchSource
 	SearchIDField *QueryableField
+	// TypoTolerance is the default typo tolerance of the searches of the index.
+	TypoTolerance *api.TypoTolerance
+	// Dictionary has the synonym sets and the stopword lists of the index, it is managed separately from the schema.
+	Dictionary *SearchDictionary
 	// Track all the int64 paths in the collection. For example, if top level object has an int64 field then key would be
 	// obj.fieldName so that caller can easily navigate to this field.
 	int64FieldsPath *int64PathBuilder
@@ -186,6 +195,7 @@ func NewSearchIndex(ver uint32, searchStoreName string, factory *SearchFactory,
 		Schema:          factory.Schema,
 		Source:          factory.Source,
 		SearchIDField:   searchIdField,
+		TypoTolerance:   factory.TypoTolerance,
 		QueryableFields: queryableFields,
 		int64FieldsPath: buildInt64Path(factory.Fields),
 	}
@@ -224,7 +234,7 @@ func (s *SearchIndex) GetQueryableField(name string) (*QueryableField, error) {
 
 func (s *SearchIndex) Validate(doc map[string]any) error {
 	for _, f := range s.QueryableFields {
-		if f.DataType == VectorType {
+		if f.DataType == VectorType || f.DataType == GeoPointType {
 			keys := f.KeyPath()
 			value, ok := doc[keys[0]]
 			if !ok {
@@ -252,7 +262,16 @@ func (s *SearchIndex) validateLow(value any, keys []string, field *Field) error
 			return s.validateLow(conv[nested.FieldName], keys[1:], nested)
 		}
 	}
-	if value == nil || field.DataType != VectorType {
+	if value == nil {
+		return nil
+	}
+	if field.DataType == GeoPointType {
+		if _, _, err := ParseGeoPoint(value); err != nil {
+			return errors.InvalidArgument("invalid geopoint field '%s': %s", field.FieldName, err.Error())
+		}
+		return nil
+	}
+	if field.DataType != VectorType {
 		return nil
 	}
 
@@ -280,6 +299,7 @@ func (s *SearchIndex) buildSearchSchema(name string) {
 			Sort:     &s.Sortable,
 			Optional: &ptrTrue,
 			NumDim:   s.Dimensions,
+			Locale:   toSearchLocale(s.Locale),
 		})
 
 		if s.InMemoryName() != s.Name() {
@@ -291,6 +311,7 @@ func (s *SearchIndex) buildSearchSchema(name string) {
 				Index:    &s.SearchIndexed,
 				Sort:     &s.Sortable,
 				Optional: &ptrTrue,
+				Locale:   toSearchLocale(s.Locale),
 			})
 		}
 
@@ -333,11 +354,12 @@ func (s *SearchIndex) GetSearchDeltaFields(existingFields []*QueryableField, fie
 		e := existingFieldMap[f.FieldName]
 		delete(existingFieldMap, f.FieldName)
 
-		if e != nil && f.SearchType == e.SearchType && f.SearchIndexed == e.SearchIndexed && f.Faceted == e.Faceted && f.Sortable == e.Sortable {
+		if e != nil && f.SearchType == e.SearchType && f.SearchIndexed == e.SearchIndexed && f.Faceted == e.Faceted && f.Sortable == e.Sortable && f.Locale == e.Locale {
 			continue
 		}
 
-		// attribute changed, drop the field first
+		// attribute changed, drop the field first. Adding it back makes the search store re-index the existing
+		// documents for this field, which is how a changed analyzer gets applied to the already indexed text.
 		if e != nil {
 			tsFields = append(tsFields, tsApi.Field{
 				Name: f.FieldName,
@@ -362,6 +384,7 @@ func (s *SearchIndex) GetSearchDeltaFields(existingFields []*QueryableField, fie
 			Sort:     &f.Sortable,
 			Optional: &ptrTrue,
 			NumDim:   f.Dimensions,
+			Locale:   toSearchLocale(f.Locale),
 		})
 	}
 
@@ -378,6 +401,24 @@ func (s *SearchIndex) GetSearchDeltaFields(existingFields []*QueryableField, fie
 	return tsFields
 }
 
+// toSearchLocale returns nil for the default locale so that the search schema of the fields without an analyzer stays
+// as-is.
+func toSearchLocale(locale string) *string {
+	if len(locale) == 0 {
+		return nil
+	}
+
+	return &locale
+}
+
+func fromSearchLocale(locale *string) string {
+	if locale == nil {
+		return ""
+	}
+
+	return *locale
+}
+
 func (s *SearchIndex) GetInt64FieldsPath() map[string]struct{} {
 	return s.int64FieldsPath.get()
 }
@@ -441,6 +482,7 @@ func (s *ImplicitSearchIndex) buildSearchSchema(searchStoreName string) {
 			Sort:     &shouldSort,
 			Optional: &ptrTrue,
 			NumDim:   f.Dimensions,
+			Locale:   toSearchLocale(f.Locale),
 		})
 
 		if f.InMemoryName() != f.Name() {
@@ -452,6 +494,7 @@ func (s *ImplicitSearchIndex) buildSearchSchema(searchStoreName string) {
 				Index:    &shouldIndex,
 				Sort:     &shouldSort,
 				Optional: &ptrTrue,
+				Locale:   toSearchLocale(f.Locale),
 			})
 		}
 		// Save original date as string to disk
@@ -515,6 +558,10 @@ func (s *ImplicitSearchIndex) GetSearchDeltaFields(existingFields []*QueryableFi
 			if found && inSearchState.Sort != nil && *inSearchState.Sort != shouldSort {
 				stateChanged = true
 			}
+			if found && fromSearchLocale(inSearchState.Locale) != f.Locale {
+				// the analyzer changed, the field is re-indexed by the search store once it is added back
+				stateChanged = true
+			}
 
 			if !stateChanged {
 				continue
@@ -546,6 +593,7 @@ func (s *ImplicitSearchIndex) GetSearchDeltaFields(existingFields []*QueryableFi
 			Sort:     &shouldSort,
 			Optional: &ptrTrue,
 			NumDim:   f.Dimensions,
+			Locale:   toSearchLocale(f.Locale),
 		})
 	}
 
@@ -0,0 +1,35 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// validateSearchConsistency validates the "search_consistency" of the collection schema:
+//
+//	"search_consistency": "strong"
+//
+// The strong writes return once the written documents are searchable. The collection uses the server default if it
+// is not set, and the requests can override it with the Tigris-Search-Consistency header.
+func validateSearchConsistency(consistency string) error {
+	switch consistency {
+	case "", api.SearchConsistencyStrong, api.SearchConsistencyEventual:
+		return nil
+	default:
+		return errors.InvalidArgument("unsupported search consistency '%s'", consistency)
+	}
+}
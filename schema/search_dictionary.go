@@ -0,0 +1,214 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// SearchSynonym is a synonym set of a search index. Without a root the words of the set are synonyms of each other,
+// for example, "sneaker" and "trainer". With a root, only the searches for the root, for example, "shoe", match the
+// documents with the synonyms of the set.
+type SearchSynonym struct {
+	ID       string   `json:"id"`
+	Root     string   `json:"root,omitempty"`
+	Synonyms []string `json:"synonyms"`
+}
+
+func (s *SearchSynonym) Validate() error {
+	if len(s.ID) == 0 {
+		return errors.InvalidArgument("synonym set is missing the 'id'")
+	}
+	if len(s.Root) == 0 && len(s.Synonyms) < 2 {
+		return errors.InvalidArgument("synonym set '%s' should have at least two synonyms", s.ID)
+	}
+	if len(s.Synonyms) == 0 {
+		return errors.InvalidArgument("synonym set '%s' should have at least one synonym", s.ID)
+	}
+	for _, w := range s.Synonyms {
+		if len(strings.TrimSpace(w)) == 0 {
+			return errors.InvalidArgument("synonym set '%s' has an empty synonym", s.ID)
+		}
+	}
+
+	return nil
+}
+
+// SearchStopwords is a stopword list of a search index. The stopwords are removed from the queries of the searches of
+// the index.
+type SearchStopwords struct {
+	ID        string   `json:"id"`
+	Stopwords []string `json:"stopwords"`
+}
+
+func (s *SearchStopwords) Validate() error {
+	if len(s.ID) == 0 {
+		return errors.InvalidArgument("stopword list is missing the 'id'")
+	}
+	if len(s.Stopwords) == 0 {
+		return errors.InvalidArgument("stopword list '%s' should have at least one stopword", s.ID)
+	}
+	for _, w := range s.Stopwords {
+		if len(strings.Fields(w)) != 1 {
+			return errors.InvalidArgument("stopword list '%s' has the invalid stopword '%s', a stopword is a single word", s.ID, w)
+		}
+	}
+
+	return nil
+}
+
+// SearchDictionary holds the synonym sets and the stopword lists of a search index. The dictionary of an index is
+// never modified in place, the updates are applied to a copy of it, see Clone.
+type SearchDictionary struct {
+	Synonyms  []*SearchSynonym   `json:"synonyms,omitempty"`
+	Stopwords []*SearchStopwords `json:"stopwords,omitempty"`
+
+	stopwords map[string]struct{}
+}
+
+func (d *SearchDictionary) Clone() *SearchDictionary {
+	cloned := &SearchDictionary{}
+	if d == nil {
+		return cloned
+	}
+
+	cloned.Synonyms = append(cloned.Synonyms, d.Synonyms...)
+	cloned.Stopwords = append(cloned.Stopwords, d.Stopwords...)
+
+	return cloned
+}
+
+func (d *SearchDictionary) IsEmpty() bool {
+	return d == nil || (len(d.Synonyms) == 0 && len(d.Stopwords) == 0)
+}
+
+// GetSynonym returns the synonym set with the id, nil if it doesn't exist.
+func (d *SearchDictionary) GetSynonym(id string) *SearchSynonym {
+	if d == nil {
+		return nil
+	}
+
+	for _, s := range d.Synonyms {
+		if s.ID == id {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// UpsertSynonym adds the synonym set or replaces the existing set with the same id.
+func (d *SearchDictionary) UpsertSynonym(synonym *SearchSynonym) {
+	for i, s := range d.Synonyms {
+		if s.ID == synonym.ID {
+			d.Synonyms[i] = synonym
+			return
+		}
+	}
+
+	d.Synonyms = append(d.Synonyms, synonym)
+}
+
+// DeleteSynonym removes the synonym set with the id, returns false if it doesn't exist.
+func (d *SearchDictionary) DeleteSynonym(id string) bool {
+	for i, s := range d.Synonyms {
+		if s.ID == id {
+			d.Synonyms = append(d.Synonyms[:i], d.Synonyms[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetStopwords returns the stopword list with the id, nil if it doesn't exist.
+func (d *SearchDictionary) GetStopwords(id string) *SearchStopwords {
+	if d == nil {
+		return nil
+	}
+
+	for _, s := range d.Stopwords {
+		if s.ID == id {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// UpsertStopwords adds the stopword list or replaces the existing list with the same id.
+func (d *SearchDictionary) UpsertStopwords(stopwords *SearchStopwords) {
+	for i, s := range d.Stopwords {
+		if s.ID == stopwords.ID {
+			d.Stopwords[i] = stopwords
+			return
+		}
+	}
+
+	d.Stopwords = append(d.Stopwords, stopwords)
+}
+
+// DeleteStopwords removes the stopword list with the id, returns false if it doesn't exist.
+func (d *SearchDictionary) DeleteStopwords(id string) bool {
+	for i, s := range d.Stopwords {
+		if s.ID == id {
+			d.Stopwords = append(d.Stopwords[:i], d.Stopwords[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetDictionary attaches the dictionary to the index, the dictionary must not be modified afterwards.
+func (s *SearchIndex) SetDictionary(d *SearchDictionary) {
+	if d != nil {
+		d.buildStopwords()
+	}
+
+	s.Dictionary = d
+}
+
+func (d *SearchDictionary) buildStopwords() {
+	d.stopwords = make(map[string]struct{})
+	for _, s := range d.Stopwords {
+		for _, w := range s.Stopwords {
+			d.stopwords[strings.ToLower(w)] = struct{}{}
+		}
+	}
+}
+
+// RemoveStopwords removes the stopwords of the dictionary attached to the index from the words of the query. The query is returned as-is if all of its words
+// are stopwords so that such a query still matches the documents containing them.
+func (d *SearchDictionary) RemoveStopwords(q string) string {
+	if d == nil || len(d.stopwords) == 0 {
+		return q
+	}
+
+	words := strings.Fields(q)
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if _, ok := d.stopwords[strings.ToLower(w)]; !ok {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 || len(kept) == len(words) {
+		return q
+	}
+
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,113 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchDictionary_Validate(t *testing.T) {
+	synonyms := []struct {
+		synonym *SearchSynonym
+		expErr  string
+	}{
+		{&SearchSynonym{ID: "shoes", Synonyms: []string{"sneaker", "trainer"}}, ""},
+		{&SearchSynonym{ID: "shoes", Root: "shoe", Synonyms: []string{"sneaker"}}, ""},
+		{&SearchSynonym{Synonyms: []string{"sneaker", "trainer"}}, "synonym set is missing the 'id'"},
+		{&SearchSynonym{ID: "shoes", Synonyms: []string{"sneaker"}}, "synonym set 'shoes' should have at least two synonyms"},
+		{&SearchSynonym{ID: "shoes", Root: "shoe"}, "synonym set 'shoes' should have at least one synonym"},
+		{&SearchSynonym{ID: "shoes", Synonyms: []string{"sneaker", " "}}, "synonym set 'shoes' has an empty synonym"},
+	}
+	for _, c := range synonyms {
+		err := c.synonym.Validate()
+		if len(c.expErr) > 0 {
+			require.ErrorContains(t, err, c.expErr)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	stopwords := []struct {
+		stopwords *SearchStopwords
+		expErr    string
+	}{
+		{&SearchStopwords{ID: "common", Stopwords: []string{"the", "a"}}, ""},
+		{&SearchStopwords{Stopwords: []string{"the"}}, "stopword list is missing the 'id'"},
+		{&SearchStopwords{ID: "common"}, "stopword list 'common' should have at least one stopword"},
+		{&SearchStopwords{ID: "common", Stopwords: []string{"of the"}}, "stopword list 'common' has the invalid stopword 'of the'"},
+		{&SearchStopwords{ID: "common", Stopwords: []string{""}}, "stopword list 'common' has the invalid stopword ''"},
+	}
+	for _, c := range stopwords {
+		err := c.stopwords.Validate()
+		if len(c.expErr) > 0 {
+			require.ErrorContains(t, err, c.expErr)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestSearchDictionary_Entries(t *testing.T) {
+	var empty *SearchDictionary
+	require.True(t, empty.IsEmpty())
+	require.Nil(t, empty.GetSynonym("shoes"))
+	require.Nil(t, empty.GetStopwords("common"))
+
+	d := empty.Clone()
+	shoes := &SearchSynonym{ID: "shoes", Synonyms: []string{"sneaker", "trainer"}}
+	d.UpsertSynonym(shoes)
+	d.UpsertSynonym(&SearchSynonym{ID: "cars", Synonyms: []string{"car", "automobile"}})
+	d.UpsertStopwords(&SearchStopwords{ID: "common", Stopwords: []string{"the"}})
+	require.False(t, d.IsEmpty())
+	require.Equal(t, shoes, d.GetSynonym("shoes"))
+
+	// the clone is not affected by the updates of the original dictionary
+	cloned := d.Clone()
+	replaced := &SearchSynonym{ID: "shoes", Root: "shoe", Synonyms: []string{"sneaker"}}
+	cloned.UpsertSynonym(replaced)
+	require.True(t, cloned.DeleteSynonym("cars"))
+	require.False(t, cloned.DeleteSynonym("cars"))
+	require.True(t, cloned.DeleteStopwords("common"))
+	require.False(t, cloned.DeleteStopwords("common"))
+
+	require.Equal(t, replaced, cloned.GetSynonym("shoes"))
+	require.Nil(t, cloned.GetSynonym("cars"))
+	require.Nil(t, cloned.GetStopwords("common"))
+	require.Equal(t, shoes, d.GetSynonym("shoes"))
+	require.NotNil(t, d.GetSynonym("cars"))
+	require.NotNil(t, d.GetStopwords("common"))
+	require.Len(t, d.Synonyms, 2)
+}
+
+func TestSearchDictionary_RemoveStopwords(t *testing.T) {
+	var index SearchIndex
+	require.Equal(t, "the shoe", index.Dictionary.RemoveStopwords("the shoe"))
+
+	index.SetDictionary(&SearchDictionary{
+		Stopwords: []*SearchStopwords{
+			{ID: "articles", Stopwords: []string{"the", "A"}},
+			{ID: "prepositions", Stopwords: []string{"of"}},
+		},
+	})
+
+	require.Equal(t, "shoe", index.Dictionary.RemoveStopwords("the shoe"))
+	require.Equal(t, "pair shoes", index.Dictionary.RemoveStopwords("A  pair of The shoes"))
+	require.Equal(t, "shoe", index.Dictionary.RemoveStopwords("shoe"))
+	// a query of only stopwords is kept
+	require.Equal(t, "the of", index.Dictionary.RemoveStopwords("the of"))
+	require.Equal(t, "*", index.Dictionary.RemoveStopwords("*"))
+}
@@ -120,6 +120,28 @@ func TestSearchIndex_CollectionSchema(t *testing.T) {
 	}
 }
 
+func TestSearchIndex_CollectionAnalyzerChange(t *testing.T) {
+	schFactory, err := NewFactoryBuilder(true).Build("t1", []byte(`{"title": "t1", "properties": { "id": {"type": "integer"}, "name": {"type": "string"}}, "primary_key": ["id"]}`))
+	require.NoError(t, err)
+
+	existing := NewImplicitSearchIndex("t1", "t1", schFactory.Fields, nil)
+
+	schFactory, err = NewFactoryBuilder(true).Build("t1", []byte(`{"title": "t1", "properties": { "id": {"type": "integer"}, "name": {"type": "string", "analyzer": "ko"}}, "primary_key": ["id"]}`))
+	require.NoError(t, err)
+
+	updated := NewImplicitSearchIndex("t1", "t1", schFactory.Fields, existing.StoreSchema.Fields)
+	deltaFields := updated.GetSearchDeltaFields(existing.QueryableFields, schFactory.Fields)
+	require.Len(t, deltaFields, 2)
+	require.Equal(t, "name", deltaFields[0].Name)
+	require.True(t, *deltaFields[0].Drop)
+	require.Equal(t, "name", deltaFields[1].Name)
+	require.Equal(t, "ko", *deltaFields[1].Locale)
+
+	// no change in the analyzer, nothing to re-index
+	updated = NewImplicitSearchIndex("t1", "t1", schFactory.Fields, updated.StoreSchema.Fields)
+	require.Empty(t, updated.GetSearchDeltaFields(updated.QueryableFields, schFactory.Fields))
+}
+
 func TestSearchIndex_Schema(t *testing.T) {
 	cases := []struct {
 		schema      []byte
@@ -153,6 +175,14 @@ func TestSearchIndex_Schema(t *testing.T) {
 			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "b": {"type": "array", "format": "vector", "dimensions": 4}}}`),
 			"",
 		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "loc": {"type": "array", "format": "geopoint"}}}`),
+			"",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "loc": {"type": "array", "format": "geopoint", "sort": true}}}`),
+			"only search index attribute is supported on geopoint field 'loc'",
+		},
 		{
 			[]byte(`{"title": "t1", "properties": { "a": {"type": "string", "id": true}, "b": {"type": "array", "items": {"type": "integer"}}}}`),
 			"",
@@ -184,6 +214,30 @@ func TestSearchIndex_Schema(t *testing.T) {
 			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "b": {"type": "array", "items": {"type": "integer"}, "id": true}}}`),
 			"Cannot have field 'b' as 'id'. Only string type is supported as 'id' field",
 		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}}, "typo_tolerance": {"num_typos": 1, "min_len_1typo": 3, "prefix": false}}`),
+			"",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}}, "typo_tolerance": {"num_typos": 3}}`),
+			"num_typos should be between 0 and 2",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string"}}, "typo_tolerance": {"min_len_1typo": 5, "min_len_2typo": 4}}`),
+			"min_len_2typo can't be less than min_len_1typo",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string", "analyzer": "ja"}, "b": {"type": "array", "items": {"type": "string"}, "analyzer": "de"}}}`),
+			"",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "string", "searchIndex": false, "analyzer": "ja"}}}`),
+			"Cannot set analyzer on field 'a' that is not search indexed",
+		},
+		{
+			[]byte(`{"title": "t1", "properties": { "a": {"type": "integer", "analyzer": "ja"}}}`),
+			"Cannot set analyzer on field 'a' of type 'int64'",
+		},
 	}
 	for _, c := range cases {
 		_, err := NewFactoryBuilder(true).BuildSearch("t1", c.schema)
@@ -195,6 +249,32 @@ func TestSearchIndex_Schema(t *testing.T) {
 	}
 }
 
+func TestSearchIndex_AnalyzerChange(t *testing.T) {
+	factory, err := NewFactoryBuilder(true).BuildSearch("t1", []byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "b": {"type": "string", "analyzer": "en"}}}`))
+	require.NoError(t, err)
+
+	existing := NewSearchIndex(1, "t1", factory, nil)
+	for _, f := range existing.StoreSchema.Fields {
+		switch f.Name {
+		case "a":
+			require.Nil(t, f.Locale)
+		case "b":
+			require.Equal(t, "en", *f.Locale)
+		}
+	}
+
+	factory, err = NewFactoryBuilder(true).BuildSearch("t1", []byte(`{"title": "t1", "properties": { "a": {"type": "string", "analyzer": "ja"}, "b": {"type": "string", "analyzer": "en"}}}`))
+	require.NoError(t, err)
+
+	updated := NewSearchIndex(2, "t1", factory, existing.StoreSchema.Fields)
+	deltaFields := updated.GetSearchDeltaFields(existing.QueryableFields, existing.StoreSchema.Fields)
+	require.Len(t, deltaFields, 2)
+	require.Equal(t, "a", deltaFields[0].Name)
+	require.True(t, *deltaFields[0].Drop)
+	require.Equal(t, "a", deltaFields[1].Name)
+	require.Equal(t, "ja", *deltaFields[1].Locale)
+}
+
 func TestSearchIndex_Validate(t *testing.T) {
 	reqSchema := []byte(`{
   "title": "t1",
@@ -303,4 +383,33 @@ func TestSearchIndex_Validate(t *testing.T) {
 			require.NoError(t, idx.Validate(mp))
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestSearchIndex_ValidateGeoPoint(t *testing.T) {
+	reqSchema := []byte(`{"title": "t1", "properties": { "a": {"type": "string"}, "loc": {"type": "array", "format": "geopoint"}, "o": {"type": "object", "properties": {"loc": {"type": "array", "format": "geopoint"}}}}}`)
+	f, err := NewFactoryBuilder(true).BuildSearch("t1", reqSchema)
+	require.NoError(t, err)
+
+	idx := NewSearchIndex(0, "test", f, nil)
+	require.Equal(t, "geopoint", idx.QueryableFields[1].SearchType)
+
+	cases := []struct {
+		document []byte
+		expError string
+	}{
+		{[]byte(`{"a": "foo", "loc": [48.8584, 2.2945], "o": {"loc": [-33.8568, 151.2153]}}`), ""},
+		{[]byte(`{"a": "foo"}`), ""},
+		{[]byte(`{"a": "foo", "loc": [48.8584]}`), "invalid geopoint field 'loc': geopoint should be an array of [latitude, longitude]"},
+		{[]byte(`{"a": "foo", "loc": [98.8584, 2.2945]}`), "invalid geopoint field 'loc': latitude '98.8584' should be between -90 and 90"},
+		{[]byte(`{"a": "foo", "o": {"loc": [48.8584, "2.2945"]}}`), "invalid geopoint field 'loc': geopoint coordinate should be a number but found string"},
+	}
+	for _, c := range cases {
+		mp, err := util.JSONToMap(c.document)
+		require.NoError(t, err)
+		if len(c.expError) > 0 {
+			require.ErrorContains(t, idx.Validate(mp), c.expError)
+		} else {
+			require.NoError(t, idx.Validate(mp))
+		}
+	}
+}
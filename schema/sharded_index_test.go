@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestShardedIndexes(t *testing.T) {
+	build := func(properties string, indexes string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("events", []byte(`{
+			"title": "events",
+			"properties": `+properties+`,
+			"primary_key": ["id"],
+			"indexes": `+indexes+`
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`{"id": {"type": "integer"}, "ts": {"type": "string", "format": "date-time", "indexShards": 16}, "kind": {"type": "string", "index": true}}`,
+			`[{"name": "ts_kind", "shards": 8, "fields": [{"field": "ts"}, {"field": "kind"}]}]`)
+		require.NoError(t, err)
+
+		ts := FindIndex(factory.Indexes.All, "ts")
+		require.NotNil(t, ts)
+		require.Equal(t, 16, ts.Shards)
+		require.True(t, ts.IsSharded())
+		require.True(t, ts.Fields[0].IsIndexed())
+		require.False(t, FindIndex(factory.Indexes.All, "kind").IsSharded())
+		require.Equal(t, 8, FindIndex(factory.Indexes.All, "ts_kind").Shards)
+	})
+
+	cases := []struct {
+		properties string
+		indexes    string
+		err        error
+	}{
+		{
+			`{"id": {"type": "integer"}, "ts": {"type": "string", "format": "date-time", "indexShards": 16, "index": false}}`,
+			`[]`,
+			errors.InvalidArgument("Cannot set indexShards on field 'ts' that is not indexed"),
+		}, {
+			`{"id": {"type": "integer"}, "ts": {"type": "string", "format": "date-time", "indexShards": 1}}`,
+			`[]`,
+			errors.InvalidArgument("Invalid shards '1' of index 'ts', it should be between 2 and 256"),
+		}, {
+			`{"id": {"type": "integer"}, "ts": {"type": "string"}, "kind": {"type": "string"}}`,
+			`[{"name": "ts_kind", "shards": 1000, "fields": [{"field": "ts"}, {"field": "kind"}]}]`,
+			errors.InvalidArgument("Invalid shards '1000' of index 'ts_kind', it should be between 2 and 256"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.properties, c.indexes)
+		require.Equal(t, c.err, err, c.properties)
+	}
+}
+
+func TestShardedIndexSchemaValidator(t *testing.T) {
+	build := func(shards string) *Factory {
+		factory, err := NewFactoryBuilder(true).Build("events", []byte(`{
+			"title": "events",
+			"properties": {"id": {"type": "integer"}, "ts": {"type": "string", "format": "date-time", "index": true`+shards+`}},
+			"primary_key": ["id"]
+		}`))
+		require.NoError(t, err)
+
+		return factory
+	}
+
+	existing, err := NewDefaultCollection(1, 1, build(""), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, errors.InvalidArgument("shards of existing index 'ts' can't be changed, drop the index and create it again"),
+		(&ShardedIndexSchemaValidator{}).Validate(existing, build(`, "indexShards": 8`)))
+
+	existing, err = NewDefaultCollection(1, 1, build(`, "indexShards": 8`), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, (&ShardedIndexSchemaValidator{}).Validate(existing, build(`, "indexShards": 8`)))
+}
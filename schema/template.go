@@ -0,0 +1,100 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"reflect"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// TemplateKey is the top level schema attribute used by a collection to extend a schema template.
+const TemplateKey = "extends"
+
+// GetTemplateName returns the name of the schema template extended by the collection schema, or an empty string if the
+// schema doesn't extend any template.
+func GetTemplateName(reqSchema []byte) string {
+	name, err := jsonparser.GetString(reqSchema, TemplateKey)
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// ValidateTemplate checks that the template schema is a valid schema that a collection can extend. A template has the
+// same structure as a collection schema, but it can't extend another template.
+func ValidateTemplate(name string, template []byte) error {
+	var tmpl map[string]any
+	if err := jsoniter.Unmarshal(template, &tmpl); err != nil {
+		return errors.InvalidArgument("invalid template schema '%s'", err.Error())
+	}
+
+	if _, ok := tmpl[TemplateKey]; ok {
+		return errors.InvalidArgument("template '%s' can't extend another template", name)
+	}
+	if props, ok := tmpl["properties"].(map[string]any); !ok || len(props) == 0 {
+		return errors.InvalidArgument("missing properties field in template '%s'", name)
+	}
+
+	tmpl["title"] = name
+	reqSchema, err := jsoniter.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+
+	_, err = NewFactoryBuilder(true).Build(name, reqSchema)
+
+	return err
+}
+
+// ApplyTemplate merges the properties and the primary key of the template into the collection schema. The collection
+// can only add new fields on top of the template unless override is set, in which case the template definition of
+// a field replaces the collection definition. Override is used to fan out an updated template to the collections
+// that are already extending it.
+func ApplyTemplate(reqSchema []byte, template []byte, override bool) ([]byte, error) {
+	var coll, tmpl map[string]any
+	if err := jsoniter.Unmarshal(reqSchema, &coll); err != nil {
+		return nil, errors.InvalidArgument("invalid schema '%s'", err.Error())
+	}
+	if err := jsoniter.Unmarshal(template, &tmpl); err != nil {
+		return nil, errors.InvalidArgument("invalid template schema '%s'", err.Error())
+	}
+
+	name, _ := coll[TemplateKey].(string)
+	tmplProps, _ := tmpl["properties"].(map[string]any)
+	collProps, _ := coll["properties"].(map[string]any)
+	if collProps == nil {
+		collProps = make(map[string]any)
+	}
+
+	for field, def := range tmplProps {
+		if existing, ok := collProps[field]; ok && !override && !reflect.DeepEqual(existing, def) {
+			return nil, errors.InvalidArgument("field '%s' is already defined by the template '%s'", field, name)
+		}
+		collProps[field] = def
+	}
+	coll["properties"] = collProps
+
+	if _, ok := coll["primary_key"]; !ok {
+		if pk, ok := tmpl["primary_key"]; ok {
+			coll["primary_key"] = pk
+		}
+	}
+
+	return jsoniter.Marshal(coll)
+}
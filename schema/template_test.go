@@ -0,0 +1,72 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	template := []byte(`{"properties":{"id":{"type":"string"},"created":{"type":"string","format":"date-time"}},"primary_key":["id"]}`)
+	require.NoError(t, ValidateTemplate("base", template))
+
+	t.Run("merge", func(t *testing.T) {
+		reqSchema := []byte(`{"title":"orders","extends":"base","properties":{"amount":{"type":"number"}}}`)
+		require.Equal(t, "base", GetTemplateName(reqSchema))
+
+		merged, err := ApplyTemplate(reqSchema, template, false)
+		require.NoError(t, err)
+		require.Equal(t, "base", GetTemplateName(merged))
+
+		factory, err := NewFactoryBuilder(true).Build("orders", merged)
+		require.NoError(t, err)
+		require.Len(t, factory.Fields, 3)
+		require.Equal(t, "id", factory.PrimaryKey.Fields[0].FieldName)
+	})
+	t.Run("conflict", func(t *testing.T) {
+		reqSchema := []byte(`{"title":"orders","extends":"base","properties":{"created":{"type":"integer"}}}`)
+		_, err := ApplyTemplate(reqSchema, template, false)
+		require.ErrorContains(t, err, "field 'created' is already defined by the template 'base'")
+
+		// identical definition is allowed, so the merged schema can be sent back as-is
+		reqSchema = []byte(`{"title":"orders","extends":"base","properties":{"created":{"type":"string","format":"date-time"}}}`)
+		_, err = ApplyTemplate(reqSchema, template, false)
+		require.NoError(t, err)
+	})
+	t.Run("override", func(t *testing.T) {
+		updated := []byte(`{"properties":{"id":{"type":"string"},"created":{"type":"string","format":"date-time"},"tenant":{"type":"string"}},"primary_key":["id"]}`)
+		existing := []byte(`{"title":"orders","extends":"base","properties":{"id":{"type":"string"},"created":{"type":"string","format":"date-time"},"amount":{"type":"number"}},"primary_key":["id"]}`)
+
+		merged, err := ApplyTemplate(existing, updated, true)
+		require.NoError(t, err)
+
+		var props map[string]any
+		require.NoError(t, jsoniter.Unmarshal([]byte(jsoniter.Get(merged, "properties").ToString()), &props))
+		require.Len(t, props, 4)
+		require.Contains(t, props, "tenant")
+		require.Contains(t, props, "amount")
+	})
+}
+
+func TestValidateTemplate(t *testing.T) {
+	require.ErrorContains(t, ValidateTemplate("base", []byte(`{"extends":"other","properties":{"id":{"type":"string"}}}`)),
+		"template 'base' can't extend another template")
+	require.ErrorContains(t, ValidateTemplate("base", []byte(`{"primary_key":["id"]}`)),
+		"missing properties field in template 'base'")
+	require.Error(t, ValidateTemplate("base", []byte(`{"properties":{"id":{"type":"unknown"}}}`)))
+}
@@ -0,0 +1,70 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestTTLIndexes(t *testing.T) {
+	build := func(properties string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("sessions", []byte(`{
+			"title": "sessions",
+			"properties": `+properties+`,
+			"primary_key": ["id"]
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`{"id": {"type": "integer"}, "seen_at": {"type": "string", "format": "date-time", "expireAfter": "24h"}, "name": {"type": "string", "index": true}}`)
+		require.NoError(t, err)
+
+		seenAt := FindIndex(factory.Indexes.All, "seen_at")
+		require.NotNil(t, seenAt)
+		require.Equal(t, 24*time.Hour, seenAt.ExpireAfter)
+		require.True(t, seenAt.Fields[0].IsIndexed())
+		require.Zero(t, FindIndex(factory.Indexes.All, "name").ExpireAfter)
+	})
+
+	cases := []struct {
+		properties string
+		err        error
+	}{
+		{
+			`{"id": {"type": "integer"}, "seen_at": {"type": "string", "expireAfter": "24h"}}`,
+			errors.InvalidArgument("Cannot set expireAfter on field 'seen_at' of type 'string'. Only date-time fields can expire"),
+		}, {
+			`{"id": {"type": "integer"}, "seen_at": {"type": "string", "format": "date-time", "expireAfter": "24h", "index": false}}`,
+			errors.InvalidArgument("Cannot set expireAfter on field 'seen_at' that is not indexed"),
+		}, {
+			`{"id": {"type": "integer"}, "seen_at": {"type": "string", "format": "date-time", "expireAfter": "1 day"}}`,
+			errors.InvalidArgument("Invalid expireAfter '1 day' of field 'seen_at', it should be a positive duration like '24h'"),
+		}, {
+			`{"id": {"type": "integer"}, "seen_at": {"type": "string", "format": "date-time", "expireAfter": "-1h"}}`,
+			errors.InvalidArgument("Invalid expireAfter '-1h' of field 'seen_at', it should be a positive duration like '24h'"),
+		}, {
+			`{"id": {"type": "integer"}, "login": {"type": "object", "properties": {"at": {"type": "string", "format": "date-time", "expireAfter": "1h"}}}}`,
+			errors.InvalidArgument("Cannot set expireAfter on nested field 'at'. Only top level fields can expire"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.properties)
+		require.Equal(t, c.err, err, c.properties)
+	}
+}
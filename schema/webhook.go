@@ -0,0 +1,75 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"net/url"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Webhook posts the changes of the collection documents to an HTTP endpoint. It is defined in the "webhooks" list of
+// the collection schema:
+//
+//	"webhooks": [{"url": "https://example.com/hook", "secret": "...", "filter": {"status": "active"}}]
+type Webhook struct {
+	// URL is the http or https endpoint the change events are posted to.
+	URL string `json:"url"`
+	// Secret is the key of the HMAC-SHA256 signature of the posted events, the events are not signed without it.
+	Secret string `json:"secret,omitempty"`
+	// Filter selects the documents whose inserts, updates and replaces are posted, it uses the syntax of the read
+	// filters. Deletes are always posted as the deleted document isn't available to match.
+	Filter jsoniter.RawMessage `json:"filter,omitempty"`
+	// DeadLetterCollection receives the events that couldn't be delivered, it is created in the same database branch
+	// on the first failure. The default is the collection name with the "_dead_letters" suffix.
+	DeadLetterCollection string `json:"dead_letter_collection,omitempty"`
+}
+
+const deadLetterSuffix = "_dead_letters"
+
+func (w *Webhook) validate(collection string) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.InvalidArgument("invalid webhook url '%s', expected an http or https url", w.URL)
+	}
+
+	if len(w.Filter) > 0 {
+		if _, dataType, _, err := jsonparser.Get(w.Filter); err != nil || dataType != jsonparser.Object {
+			return errors.InvalidArgument("webhook filter of '%s' should be an object", w.URL)
+		}
+	}
+
+	if w.GetDeadLetterCollection(collection) == collection {
+		return errors.InvalidArgument("webhook dead letter collection should be different from the collection")
+	}
+
+	return nil
+}
+
+// GetDeadLetterCollection returns the name of the dead letter collection of the webhook of the collection.
+func (w *Webhook) GetDeadLetterCollection(collection string) string {
+	if len(w.DeadLetterCollection) > 0 {
+		return w.DeadLetterCollection
+	}
+
+	return collection + deadLetterSuffix
+}
+
+// HasWebhooks returns true if the changes of the collection are posted to webhooks.
+func (d *DefaultCollection) HasWebhooks() bool {
+	return len(d.Webhooks) > 0
+}
@@ -0,0 +1,55 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestWebhooks(t *testing.T) {
+	build := func(webhooks string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("orders", []byte(`{
+			"title": "orders",
+			"properties": {"id": {"type": "integer"}, "status": {"type": "string"}},
+			"primary_key": ["id"],
+			"webhooks": `+webhooks+`
+		}`))
+	}
+
+	factory, err := build(`[{"url": "https://example.com/hook", "secret": "s", "filter": {"status": "paid"}}, {"url": "http://localhost:8080", "dead_letter_collection": "failed"}]`)
+	require.NoError(t, err)
+
+	coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+	require.True(t, coll.HasWebhooks())
+	require.Len(t, coll.Webhooks, 2)
+	require.Equal(t, "s", coll.Webhooks[0].Secret)
+	require.JSONEq(t, `{"status": "paid"}`, string(coll.Webhooks[0].Filter))
+	require.Equal(t, "orders_dead_letters", coll.Webhooks[0].GetDeadLetterCollection(coll.Name))
+	require.Equal(t, "failed", coll.Webhooks[1].GetDeadLetterCollection(coll.Name))
+
+	for webhooks, expErr := range map[string]error{
+		`[{"url": "ftp://example.com"}]`:                                       errors.InvalidArgument("invalid webhook url 'ftp://example.com', expected an http or https url"),
+		`[{"url": "example.com/hook"}]`:                                        errors.InvalidArgument("invalid webhook url 'example.com/hook', expected an http or https url"),
+		`[{"url": "https://example.com", "filter": [1]}]`:                      errors.InvalidArgument("webhook filter of 'https://example.com' should be an object"),
+		`[{"url": "https://example.com", "dead_letter_collection": "orders"}]`: errors.InvalidArgument("webhook dead letter collection should be different from the collection"),
+	} {
+		_, err = build(webhooks)
+		require.Equal(t, expErr, err, webhooks)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"regexp"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/expression/cel"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+// Writes the write hooks run on.
+const (
+	// WriteHookOnInsert runs the hook on the documents of the inserts and the replaces.
+	WriteHookOnInsert = "insert"
+	// WriteHookOnUpdate runs the hook on the documents after the update is applied.
+	WriteHookOnUpdate = "update"
+)
+
+// WriteHookExprKey is the key of the object computing the value of a field set by a write hook from an expression.
+const WriteHookExprKey = "$expr"
+
+var validWriteHookNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]{0,63}$`)
+
+// WriteHook checks and modifies the documents before they are written, so that the business rules are enforced by
+// the server for all the clients. It is defined in the "hooks" list of the collection schema:
+//
+//	"hooks": [
+//	  {"name": "normalize", "on": ["insert", "update"], "set": {"email": {"$expr": "lower(email)"}}},
+//	  {"name": "paid", "on": ["update"], "when": {"status": "paid"}, "require": {"total": {"$gt": 0}}, "message": "a paid order has a total"},
+//	  {"name": "discount", "on": ["insert"], "require": "doc.discount <= doc.total", "set": {"code": {"$cel": "doc.code.upperAscii()"}}}
+//	]
+//
+// The conditions are either filters or CEL expressions of the "doc" variable, see the cel package. The hooks run in
+// the order they are defined, a hook sees the values set by the previous ones. A write is rejected if any of its
+// documents doesn't match the "require" condition of a hook. The documents are validated against the schema after
+// the hooks run.
+type WriteHook struct {
+	// Name identifies the hook in the errors and in the metrics.
+	Name string `json:"name"`
+	// On are the writes the hook runs on, "insert" and "update".
+	On []string `json:"on"`
+	// When selects the documents the hook runs on, it is a filter or a CEL condition. The hook runs on all the
+	// documents without it.
+	When jsoniter.RawMessage `json:"when,omitempty"`
+	// Set are the values set on the documents keyed by the path of the field. A value is either a JSON value, an
+	// object {"$expr": "lower(email)"} computing it from the document with the functions of the index expressions,
+	// or an object {"$cel": "<expression>"} computing it with a CEL expression.
+	Set map[string]jsoniter.RawMessage `json:"set,omitempty"`
+	// Require is the filter or the CEL condition the documents must match after the values are set, the write is
+	// rejected otherwise.
+	Require jsoniter.RawMessage `json:"require,omitempty"`
+	// Message is returned to the client when the write is rejected by the hook.
+	Message string `json:"message,omitempty"`
+}
+
+func validateWriteHooks(hooks []*WriteHook, fields []*Field) error {
+	if len(hooks) > config.DefaultConfig.WriteHooks.MaxHooks {
+		return errors.InvalidArgument("collection can have at most %d hooks", config.DefaultConfig.WriteHooks.MaxHooks)
+	}
+
+	names := make(map[string]struct{}, len(hooks))
+	for _, h := range hooks {
+		if err := h.validate(fields); err != nil {
+			return err
+		}
+		if _, ok := names[h.Name]; ok {
+			return errors.InvalidArgument("duplicate hook '%s'", h.Name)
+		}
+		names[h.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+func (h *WriteHook) validate(fields []*Field) error {
+	if !validWriteHookNamePattern.MatchString(h.Name) {
+		return errors.InvalidArgument("invalid hook name '%s'", h.Name)
+	}
+
+	if len(h.On) == 0 {
+		return errors.InvalidArgument("hook '%s' should run on 'insert' or 'update'", h.Name)
+	}
+	for _, on := range h.On {
+		if on != WriteHookOnInsert && on != WriteHookOnUpdate {
+			return errors.InvalidArgument("unsupported write '%s' of hook '%s'", on, h.Name)
+		}
+	}
+
+	if len(h.Set) == 0 && len(h.Require) == 0 {
+		return errors.InvalidArgument("hook '%s' should either set fields or require a filter", h.Name)
+	}
+	for attr, f := range map[string]jsoniter.RawMessage{"when": h.When, "require": h.Require} {
+		if len(f) == 0 {
+			continue
+		}
+		if expr, ok := ConditionExpression(f); ok {
+			if _, err := cel.CompileBool(expr); err != nil {
+				return errors.InvalidArgument("%s condition of hook '%s': %s", attr, h.Name, err.Error())
+			}
+			continue
+		}
+		if _, dataType, _, err := jsonparser.Get(f); err != nil || dataType != jsonparser.Object {
+			return errors.InvalidArgument("%s condition of hook '%s' should be a filter object or an expression", attr, h.Name)
+		}
+	}
+
+	for path, v := range h.Set {
+		field := findFieldByPath(fields, path)
+		if field == nil {
+			return errors.InvalidArgument("hook '%s' sets the field '%s' which is not in the schema", h.Name, path)
+		}
+		if field.IsPrimaryKey() {
+			return errors.InvalidArgument("hook '%s' can't set the primary key field '%s'", h.Name, path)
+		}
+
+		if expr, ok := SetCELExpression(v); ok {
+			if _, err := cel.Compile(expr); err != nil {
+				return errors.InvalidArgument("hook '%s': %s", h.Name, err.Error())
+			}
+			continue
+		}
+
+		expr, err := h.SetExpression(v)
+		if err != nil {
+			return err
+		}
+		if expr == nil {
+			continue
+		}
+
+		arg := findFieldByPath(fields, expr.Field)
+		if arg == nil {
+			return errors.InvalidArgument("hook '%s': field '%s' of the expression '%s' is not in the schema", h.Name, expr.Field, expr)
+		}
+		if err = expr.Validate(arg.DataType); err != nil {
+			return errors.InvalidArgument("hook '%s': %s", h.Name, err.Error())
+		}
+		if expr.ResultType() != field.DataType {
+			return errors.InvalidArgument("hook '%s': expression '%s' of type '%s' can't set the field '%s' of type '%s'",
+				h.Name, expr, FieldNames[expr.ResultType()], path, FieldNames[field.DataType])
+		}
+	}
+
+	return nil
+}
+
+// SetExpression returns the expression of the value set by the hook, nil if the value is a JSON value.
+func (h *WriteHook) SetExpression(v jsoniter.RawMessage) (*IndexExpression, error) {
+	// the values which are not objects don't have the key either
+	raw, dataType, _, _ := jsonparser.Get(v, WriteHookExprKey)
+	if dataType == jsonparser.NotExist {
+		return nil, nil
+	}
+	if dataType != jsonparser.String {
+		return nil, errors.InvalidArgument("'%s' of hook '%s' should be a string", WriteHookExprKey, h.Name)
+	}
+
+	expr, err := ParseIndexExpression(string(raw))
+	if err != nil {
+		return nil, errors.InvalidArgument("hook '%s': %s", h.Name, err.Error())
+	}
+
+	return expr, nil
+}
+
+// ConditionExpression returns the CEL expression of the condition, false if the condition is a filter.
+func ConditionExpression(condition jsoniter.RawMessage) (string, bool) {
+	raw, dataType, _, err := jsonparser.Get(condition)
+	if err != nil || dataType != jsonparser.String {
+		return "", false
+	}
+
+	expr, err := jsonparser.ParseString(raw)
+	return expr, err == nil
+}
+
+// SetCELExpression returns the CEL expression of the value set by the hook, false if the value is not computed by one.
+func SetCELExpression(v jsoniter.RawMessage) (string, bool) {
+	raw, dataType, _, _ := jsonparser.Get(v, cel.Key)
+	if dataType != jsonparser.String {
+		return "", false
+	}
+
+	expr, err := jsonparser.ParseString(raw)
+	return expr, err == nil
+}
+
+// RunsOn returns true if the hook runs on the write.
+func (h *WriteHook) RunsOn(write string) bool {
+	for _, on := range h.On {
+		if on == write {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWriteHooks returns true if the collection has hooks running on the write.
+func (d *DefaultCollection) HasWriteHooks(write string) bool {
+	for _, h := range d.WriteHooks {
+		if h.RunsOn(write) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestWriteHooks(t *testing.T) {
+	build := func(hooks string) (*Factory, error) {
+		return NewFactoryBuilder(true).Build("orders", []byte(`{
+			"title": "orders",
+			"properties": {
+				"id": {"type": "integer"},
+				"email": {"type": "string"},
+				"status": {"type": "string"},
+				"total": {"type": "number"},
+				"created": {"type": "string", "format": "date-time"},
+				"year": {"type": "integer"}
+			},
+			"primary_key": ["id"],
+			"hooks": `+hooks+`
+		}`))
+	}
+
+	t.Run("build", func(t *testing.T) {
+		factory, err := build(`[
+			{"name": "normalize", "on": ["insert", "update"], "set": {"email": {"$expr": "lower(email)"}, "year": {"$expr": "extract_year(created)"}}},
+			{"name": "paid", "on": ["update"], "when": {"status": "paid"}, "require": {"total": {"$gt": 0}}, "message": "a paid order has a total"}
+		]`)
+		require.NoError(t, err)
+
+		coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, coll.WriteHooks, 2)
+		require.True(t, coll.HasWriteHooks(WriteHookOnInsert))
+		require.True(t, coll.HasWriteHooks(WriteHookOnUpdate))
+		require.False(t, coll.WriteHooks[1].RunsOn(WriteHookOnInsert))
+
+		expr, err := coll.WriteHooks[0].SetExpression(coll.WriteHooks[0].Set["email"])
+		require.NoError(t, err)
+		require.Equal(t, &IndexExpression{Function: ExprLower, Field: "email"}, expr)
+
+		expr, err = coll.WriteHooks[0].SetExpression([]byte(`"new"`))
+		require.NoError(t, err)
+		require.Nil(t, expr)
+	})
+
+	t.Run("expressions", func(t *testing.T) {
+		factory, err := build(`[
+			{"name": "paid", "on": ["insert"], "when": "doc.status == 'paid'", "require": "doc.total > 0", "set": {"email": {"$cel": "doc.email.lowerAscii()"}}}
+		]`)
+		require.NoError(t, err)
+
+		coll, err := NewDefaultCollection(1, 1, factory, nil, nil)
+		require.NoError(t, err)
+
+		expr, ok := ConditionExpression(coll.WriteHooks[0].When)
+		require.True(t, ok)
+		require.Equal(t, "doc.status == 'paid'", expr)
+
+		expr, ok = SetCELExpression(coll.WriteHooks[0].Set["email"])
+		require.True(t, ok)
+		require.Equal(t, "doc.email.lowerAscii()", expr)
+
+		_, ok = SetCELExpression([]byte(`{"$expr": "lower(email)"}`))
+		require.False(t, ok)
+
+		for _, invalid := range []string{
+			`[{"name": "h", "on": ["insert"], "require": "doc.total >"}]`,
+			`[{"name": "h", "on": ["insert"], "set": {"email": {"$cel": "doc.email +"}}}]`,
+		} {
+			_, err = build(invalid)
+			require.Error(t, err, invalid)
+		}
+	})
+
+	cases := []struct {
+		hooks string
+		err   error
+	}{
+		{
+			`[{"name": "a b", "on": ["insert"], "require": {"total": {"$gt": 0}}}]`,
+			errors.InvalidArgument("invalid hook name 'a b'"),
+		}, {
+			`[{"name": "h", "require": {"total": {"$gt": 0}}}]`,
+			errors.InvalidArgument("hook 'h' should run on 'insert' or 'update'"),
+		}, {
+			`[{"name": "h", "on": ["delete"], "require": {"total": {"$gt": 0}}}]`,
+			errors.InvalidArgument("unsupported write 'delete' of hook 'h'"),
+		}, {
+			`[{"name": "h", "on": ["insert"]}]`,
+			errors.InvalidArgument("hook 'h' should either set fields or require a filter"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "require": []}]`,
+			errors.InvalidArgument("require condition of hook 'h' should be a filter object or an expression"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "require": "doc.email.lowerAscii()"}]`,
+			errors.InvalidArgument("require condition of hook 'h': expression 'doc.email.lowerAscii()' should be a condition, its type is 'string'"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "set": {"missing": 1}}]`,
+			errors.InvalidArgument("hook 'h' sets the field 'missing' which is not in the schema"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "set": {"id": 1}}]`,
+			errors.InvalidArgument("hook 'h' can't set the primary key field 'id'"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "set": {"email": {"$expr": "reverse(email)"}}}]`,
+			errors.InvalidArgument("hook 'h': unsupported function 'reverse' in the expression 'reverse(email)'"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "set": {"year": {"$expr": "lower(email)"}}}]`,
+			errors.InvalidArgument("hook 'h': expression 'lower(email)' of type 'string' can't set the field 'year' of type 'int64'"),
+		}, {
+			`[{"name": "h", "on": ["insert"], "set": {"status": "new"}}, {"name": "h", "on": ["update"], "set": {"status": "new"}}]`,
+			errors.InvalidArgument("duplicate hook 'h'"),
+		},
+	}
+	for _, c := range cases {
+		_, err := build(c.hooks)
+		require.Equal(t, c.err, err, c.hooks)
+	}
+}
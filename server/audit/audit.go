@@ -0,0 +1,166 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit keeps the audit trail of the namespaces, the operations done by the callers with who did them, when
+// and from where. The trail of a namespace is stored in its own table as a hash chain: each entry is numbered after the
+// previous one and its hash covers its content and the hash of the previous entry, so a modified, a removed or an
+// inserted entry is detected when the trail is read back.
+//
+// The entries are buffered and written in batches in the background, the writers of the servers are serialized by the
+// conflicts on the last entry of the trail. The entries older than the retention are pruned from the start of the
+// trail, the first retained entry anchors the chain.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+type pending struct {
+	namespace string
+	entry     *api.AuditEntry
+}
+
+// Trail buffers the audited operations and appends them to the trails of their namespaces.
+type Trail struct {
+	cfg     config.AuditConfig
+	tenants metadata.TenantGetter
+	txMgr   *transaction.Manager
+	pending chan *pending
+	now     func() time.Time
+}
+
+var trail *Trail
+
+func NewTrail(cfg config.AuditConfig, tenants metadata.TenantGetter, txMgr *transaction.Manager) *Trail {
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = 10000
+	}
+
+	return &Trail{
+		cfg:     cfg,
+		tenants: tenants,
+		txMgr:   txMgr,
+		pending: make(chan *pending, size),
+		now:     time.Now,
+	}
+}
+
+// Init starts the trail of the server, the operations are not audited if it is disabled.
+func Init(cfg config.AuditConfig, tenants metadata.TenantGetter, txMgr *transaction.Manager) {
+	if !cfg.Enabled {
+		return
+	}
+
+	trail = NewTrail(cfg, tenants, txMgr)
+	trail.Start()
+}
+
+// Enabled returns true if the operations are audited.
+func Enabled() bool {
+	return trail != nil
+}
+
+// AuditReads returns true if the reads are audited along with the writes and the admin operations.
+func AuditReads() bool {
+	return trail != nil && trail.cfg.Reads
+}
+
+// Record buffers the entry to be appended to the trail of the namespace.
+func Record(namespace string, entry *api.AuditEntry) {
+	if trail != nil {
+		trail.Record(namespace, entry)
+	}
+}
+
+// Query returns the entries of the trail of the namespace matching the query, the latest first.
+func Query(ctx context.Context, namespace string, q *api.AuditQuery) (*api.AuditLogsResponse, error) {
+	if trail == nil {
+		return nil, errors.Unimplemented("audit trail is disabled")
+	}
+
+	return trail.Query(ctx, namespace, q)
+}
+
+func (t *Trail) Start() {
+	go t.loop()
+	go t.pruneLoop()
+}
+
+// Record buffers the entry, it is dropped and logged if the buffer is full.
+func (t *Trail) Record(namespace string, entry *api.AuditEntry) {
+	if entry.At.IsZero() {
+		entry.At = t.now().UTC()
+	}
+
+	select {
+	case t.pending <- &pending{namespace: namespace, entry: entry}:
+	default:
+		log.Error().Str("ns", namespace).Str("operation", entry.Operation).Str("sub", entry.Sub).
+			Msg("audit buffer is full, dropping the entry")
+	}
+}
+
+func (t *Trail) loop() {
+	interval := t.cfg.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	log.Info().Dur("interval", interval).Dur("retention", t.cfg.Retention).Msg("Starting audit trail")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.flush(context.Background())
+	}
+}
+
+// flush appends the buffered entries to the trails of their namespaces, in the order they were recorded.
+func (t *Trail) flush(ctx context.Context) {
+	batches := map[string][]*api.AuditEntry{}
+	for drained := false; !drained; {
+		select {
+		case p := <-t.pending:
+			batches[p.namespace] = append(batches[p.namespace], p.entry)
+		default:
+			drained = true
+		}
+	}
+
+	for namespace, entries := range batches {
+		if err := t.append(ctx, namespace, entries); err != nil {
+			log.Err(err).Str("ns", namespace).Int("entries", len(entries)).Msg("failed to append to the audit trail")
+		}
+	}
+}
+
+func (t *Trail) table(ctx context.Context, namespace string) ([]byte, error) {
+	tenant, err := t.tenants.GetTenant(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, errors.NotFound("namespace '%s' not found", namespace)
+	}
+
+	return tenant.Encoder.EncodeAuditLogTableName(tenant.GetNamespace()), nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// maxAppendAttempts is the number of the attempts to append a batch to the trail of a namespace, the batches of the
+// servers appending to the same trail at the same time conflict on its last entry.
+const maxAppendAttempts = 5
+
+// hash returns the hash of the entry, which covers all its fields but the hash itself. The hash of the previous entry
+// is one of them, so the hash of an entry vouches for all the entries before it.
+func hash(entry *api.AuditEntry) (string, error) {
+	unsigned := *entry
+	unsigned.Hash = ""
+
+	data, err := jsoniter.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// chain numbers and hashes the entries after the last entry of the trail, nil if the trail is empty.
+func chain(last *api.AuditEntry, entries []*api.AuditEntry) error {
+	var (
+		seq  int64
+		prev string
+	)
+	if last != nil {
+		seq, prev = last.Seq, last.Hash
+	}
+
+	for _, entry := range entries {
+		seq++
+		entry.Seq, entry.PrevHash = seq, prev
+
+		h, err := hash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash, prev = h, h
+	}
+
+	return nil
+}
+
+// verify returns true if the entry is intact and it is the entry right before the newer entry of the trail, newer is
+// nil for the last entry read.
+func verify(newer *api.AuditEntry, entry *api.AuditEntry) bool {
+	if h, err := hash(entry); err != nil || h != entry.Hash {
+		return false
+	}
+	if newer == nil {
+		return true
+	}
+
+	return newer.Seq == entry.Seq+1 && newer.PrevHash == entry.Hash
+}
+
+func entryKey(table []byte, seq int64) keys.Key {
+	return keys.NewKey(table, seq)
+}
+
+func readEntry(row *kv.KeyValue) (*api.AuditEntry, error) {
+	var entry api.AuditEntry
+	if err := jsoniter.Unmarshal(row.Data.RawData, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// append appends the entries to the trail of the namespace, retrying when another server appended to it concurrently.
+func (t *Trail) append(ctx context.Context, namespace string, entries []*api.AuditEntry) error {
+	table, err := t.table(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= maxAppendAttempts; attempt++ {
+		if err = t.appendBatch(ctx, table, entries); !kv.IsConflict(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (t *Trail) appendBatch(ctx context.Context, table []byte, entries []*api.AuditEntry) error {
+	tx, err := t.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = t.insert(ctx, tx, table, entries); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (*Trail) insert(ctx context.Context, tx transaction.Tx, table []byte, entries []*api.AuditEntry) error {
+	// the read of the last entry is not a snapshot read, the appends of the other servers conflict with it
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table), nil, false, true)
+	if err != nil {
+		return err
+	}
+
+	var (
+		row  kv.KeyValue
+		last *api.AuditEntry
+	)
+	if iter.Next(&row) {
+		if last, err = readEntry(&row); err != nil {
+			return err
+		}
+	}
+	if err = iter.Err(); err != nil {
+		return err
+	}
+
+	if err = chain(last, entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := jsoniter.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err = tx.Insert(ctx, entryKey(table, entry.Seq), internal.NewTableData(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
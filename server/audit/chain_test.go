@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestChain(t *testing.T) {
+	at := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	newEntries := func(n int) []*api.AuditEntry {
+		var entries []*api.AuditEntry
+		for i := 0; i < n; i++ {
+			entries = append(entries, &api.AuditEntry{
+				At:         at.Add(time.Duration(i) * time.Second),
+				Sub:        "user1",
+				Operation:  api.DeleteMethodName,
+				Project:    "p1",
+				Collection: "users",
+				Filter:     `{"id":1}`,
+				Code:       "OK",
+			})
+		}
+		return entries
+	}
+
+	entries := newEntries(3)
+	require.NoError(t, chain(nil, entries))
+	require.Equal(t, int64(1), entries[0].Seq)
+	require.Equal(t, "", entries[0].PrevHash)
+	require.Equal(t, entries[0].Hash, entries[1].PrevHash)
+
+	next := newEntries(1)
+	require.NoError(t, chain(entries[2], next))
+	require.Equal(t, int64(4), next[0].Seq)
+	require.Equal(t, entries[2].Hash, next[0].PrevHash)
+	entries = append(entries, next...)
+
+	// the trail is read backwards
+	verifyAll := func(entries []*api.AuditEntry) bool {
+		var newer *api.AuditEntry
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !verify(newer, entries[i]) {
+				return false
+			}
+			newer = entries[i]
+		}
+		return true
+	}
+	require.True(t, verifyAll(entries))
+
+	t.Run("modified", func(t *testing.T) {
+		modified := *entries[1]
+		modified.Sub = "user2"
+		require.False(t, verifyAll([]*api.AuditEntry{entries[0], &modified, entries[2], entries[3]}))
+	})
+	t.Run("removed", func(t *testing.T) {
+		require.False(t, verifyAll([]*api.AuditEntry{entries[0], entries[2], entries[3]}))
+	})
+	t.Run("pruned", func(t *testing.T) {
+		// the first retained entry anchors the chain
+		require.True(t, verifyAll(entries[2:]))
+	})
+}
+
+func TestMatches(t *testing.T) {
+	at := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	entry := &api.AuditEntry{At: at, Project: "p1", Collection: "users"}
+
+	require.True(t, matches(&api.AuditQuery{}, entry))
+	require.True(t, matches(&api.AuditQuery{Project: "p1", Collection: "users", Until: at}, entry))
+	require.False(t, matches(&api.AuditQuery{Project: "p2"}, entry))
+	require.False(t, matches(&api.AuditQuery{Collection: "orders"}, entry))
+	require.False(t, matches(&api.AuditQuery{Until: at.Add(-time.Second)}, entry))
+}
@@ -0,0 +1,104 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func (t *Trail) pruneLoop() {
+	if t.cfg.Retention <= 0 || t.cfg.PruneInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(t.cfg.PruneInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.pruneAll(context.Background())
+	}
+}
+
+// pruneAll deletes the entries older than the retention from the trails of all the namespaces.
+func (t *Trail) pruneAll(ctx context.Context) {
+	cutoff := t.now().UTC().Add(-t.cfg.Retention)
+
+	for _, tenant := range t.tenants.AllTenants(ctx) {
+		table := tenant.Encoder.EncodeAuditLogTableName(tenant.GetNamespace())
+		if err := t.prune(ctx, table, cutoff); err != nil {
+			log.Err(err).Str("ns", tenant.GetNamespace().StrId()).Msg("failed to prune audit trail")
+		}
+	}
+}
+
+// prune deletes the entries recorded before the cutoff from the start of the trail, in batches each in its own
+// transaction.
+func (t *Trail) prune(ctx context.Context, table []byte, cutoff time.Time) error {
+	batchSize := t.cfg.PruneBatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	for {
+		deleted, err := t.pruneBatch(ctx, table, cutoff, batchSize)
+		if err != nil || deleted < batchSize {
+			return err
+		}
+	}
+}
+
+func (t *Trail) pruneBatch(ctx context.Context, table []byte, cutoff time.Time, batchSize int) (int, error) {
+	tx, err := t.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table), nil, false, false)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	var (
+		row     kv.KeyValue
+		deleted int
+	)
+	for deleted < batchSize && iter.Next(&row) {
+		entry, err := readEntry(&row)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, err
+		}
+		if !entry.At.Before(cutoff) {
+			break
+		}
+
+		if err = tx.Delete(ctx, entryKey(table, entry.Seq)); err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, err
+		}
+		deleted++
+	}
+	if err = iter.Err(); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	return deleted, tx.Commit(ctx)
+}
@@ -0,0 +1,88 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// Query reads the trail of the namespace backwards from its last entry and returns the entries matching the query. The
+// chain is verified over all the entries read, the entries are ordered by their position in the trail which follows the
+// clocks of the servers recording them.
+func (t *Trail) Query(ctx context.Context, namespace string, q *api.AuditQuery) (*api.AuditLogsResponse, error) {
+	table, err := t.table(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = api.DefaultAuditLogsLimit
+	}
+	if limit > api.MaxAuditLogsLimit {
+		limit = api.MaxAuditLogsLimit
+	}
+
+	tx, err := t.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table), nil, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		row   kv.KeyValue
+		newer *api.AuditEntry
+	)
+	resp := &api.AuditLogsResponse{Entries: []*api.AuditEntry{}, Verified: true}
+	for len(resp.Entries) < limit && iter.Next(&row) {
+		entry, err := readEntry(&row)
+		if err != nil {
+			return nil, err
+		}
+		if !verify(newer, entry) {
+			resp.Verified = false
+		}
+		newer = entry
+
+		if !q.Since.IsZero() && entry.At.Before(q.Since) {
+			break
+		}
+		if matches(q, entry) {
+			resp.Entries = append(resp.Entries, entry)
+		}
+	}
+
+	return resp, iter.Err()
+}
+
+func matches(q *api.AuditQuery, entry *api.AuditEntry) bool {
+	if !q.Until.IsZero() && entry.At.After(q.Until) {
+		return false
+	}
+	if q.Project != "" && entry.Project != q.Project {
+		return false
+	}
+
+	return q.Collection == "" || entry.Collection == q.Collection
+}
@@ -15,6 +15,7 @@
 package cdc
 
 import (
+	"bytes"
 	"context"
 
 	jsoniter "github.com/json-iterator/go"
@@ -23,6 +24,8 @@ import (
 	"github.com/tigrisdata/tigris/store/kv"
 )
 
+// Tx is a part of the changes of a committed transaction. The changes of a transaction are split in parts that fit in
+// a single value.
 type Tx struct {
 	Id  []byte
 	Ops []*kv.Event
@@ -30,31 +33,64 @@ type Tx struct {
 
 const cdcValueVersion = 1
 
+// maxPartSize keeps the encoded parts under the value size limit of FoundationDB.
+const maxPartSize = 90 * 1024
+
 func (p *Publisher) OnCommit(ctx context.Context, tx transaction.Tx, listener kv.EventListener) error {
 	events := listener.GetEvents()
 	if len(events) == 0 {
 		return nil
 	}
 
-	json, err := jsoniter.Marshal(&Tx{
-		Ops: events,
-	})
-	if err != nil {
-		return err
-	}
+	var userVersion uint16
+	part := &bytes.Buffer{}
+	flush := func() error {
+		if part.Len() == 0 {
+			return nil
+		}
+
+		key, err := p.keySpace.getNextKey(userVersion)
+		if err != nil {
+			return err
+		}
+		userVersion++
 
-	key, err := p.keySpace.getNextKey()
-	if err != nil {
-		return err
+		enc, err := internal.Encode(internal.NewTableDataWithVersion(append(part.Bytes(), "]}"...), cdcValueVersion))
+		if err != nil {
+			return err
+		}
+		part.Reset()
+
+		return tx.SetVersionstampedKey(ctx, key, enc)
 	}
 
-	td := internal.NewTableDataWithVersion(json, cdcValueVersion)
-	enc, err := internal.Encode(td)
-	if err != nil {
-		return err
+	for _, event := range events {
+		data, err := jsoniter.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if len(data) > maxPartSize {
+			// the subscribers receive the key of the changed document without its images
+			if data, err = jsoniter.Marshal(&kv.Event{Op: event.Op, Table: event.Table, Key: event.Key, Last: event.Last}); err != nil {
+				return err
+			}
+		}
+
+		if part.Len()+len(data) > maxPartSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+
+		if part.Len() == 0 {
+			part.WriteString(`{"Ops":[`)
+		} else {
+			part.WriteByte(',')
+		}
+		part.Write(data)
 	}
 
-	return tx.SetVersionstampedKey(ctx, key, enc)
+	return flush()
 }
 
 func (*Publisher) OnRollback(_ context.Context, _ kv.EventListener) {}
@@ -16,6 +16,7 @@ package cdc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/tigrisdata/tigris/server/metadata"
@@ -37,14 +38,15 @@ func NewManager() *Manager {
 	}
 }
 
-func (m *Manager) GetPublisher(dbName string) *Publisher {
+func (m *Manager) GetPublisher(namespaceId uint32, dbName string) *Publisher {
 	m.Lock()
 	defer m.Unlock()
 
-	if m.pubs[dbName] == nil {
-		m.pubs[dbName] = NewPublisher(dbName)
+	key := fmt.Sprintf("%d_%s", namespaceId, dbName)
+	if m.pubs[key] == nil {
+		m.pubs[key] = NewPublisher(namespaceId, dbName)
 	}
-	return m.pubs[dbName]
+	return m.pubs[key]
 }
 
 func (*Manager) WrapContext(ctx context.Context, dbName string) context.Context {
@@ -54,13 +56,13 @@ func (*Manager) WrapContext(ctx context.Context, dbName string) context.Context
 	return context.WithValue(ctx, DatabaseNameCtxKey{}, dbName)
 }
 
-func (m *Manager) OnPreCommit(ctx context.Context, _ *metadata.Tenant, tx transaction.Tx, events kv.EventListener) error {
+func (m *Manager) OnPreCommit(ctx context.Context, tenant *metadata.Tenant, tx transaction.Tx, events kv.EventListener) error {
 	dbName := GetDatabaseName(ctx)
 	if len(dbName) == 0 {
 		return nil
 	}
 
-	p := m.GetPublisher(dbName)
+	p := m.GetPublisher(tenant.GetNamespace().Id(), dbName)
 	return p.OnCommit(ctx, tx, events)
 }
 
@@ -68,13 +70,13 @@ func (*Manager) OnPostCommit(_ context.Context, _ *metadata.Tenant, _ kv.EventLi
 	return nil
 }
 
-func (m *Manager) OnRollback(ctx context.Context, _ *metadata.Tenant, events kv.EventListener) {
+func (m *Manager) OnRollback(ctx context.Context, tenant *metadata.Tenant, events kv.EventListener) {
 	dbName := GetDatabaseName(ctx)
 	if len(dbName) == 0 {
 		return
 	}
 
-	p := m.GetPublisher(dbName)
+	p := m.GetPublisher(tenant.GetNamespace().Id(), dbName)
 	p.OnRollback(ctx, events)
 }
 
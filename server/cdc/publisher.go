@@ -15,6 +15,8 @@
 package cdc
 
 import (
+	"fmt"
+
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
@@ -33,8 +35,10 @@ type PublisherKeySpace struct {
 	endKey   fdb.Key
 }
 
-func NewPublisherKeySpace(dbName string) *PublisherKeySpace {
-	cdcBytes := []byte("cdc_" + dbName)
+// NewPublisherKeySpace returns the key space of the changes of a database branch. The database names are only unique
+// within a namespace, so the namespace is part of the key space.
+func NewPublisherKeySpace(namespaceId uint32, dbName string) *PublisherKeySpace {
+	cdcBytes := []byte(fmt.Sprintf("cdc_%d_%s", namespaceId, dbName))
 	return &PublisherKeySpace{
 		cdcBytes: cdcBytes,
 		beginKey: getKey(cdcBytes, [10]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}),
@@ -50,20 +54,47 @@ func getKey(cdcBytes []byte, tv [10]byte) fdb.Key {
 	return k
 }
 
-func (p *PublisherKeySpace) getNextKey() (fdb.Key, error) {
+// getNextKey returns the key of a part of the changes of the committing transaction, the parts are ordered by the
+// user version.
+func (p *PublisherKeySpace) getNextKey(userVersion uint16) (fdb.Key, error) {
 	s := subspace.FromBytes(p.cdcBytes)
-	v := tuple.IncompleteVersionstamp(0)
+	v := tuple.IncompleteVersionstamp(userVersion)
 	t := []tuple.TupleElement{v}
 	return s.PackWithVersionstamp(t)
 }
 
-func NewPublisher(dbName string) *Publisher {
+// getVersionKey returns the key of the part of the changes committed at the version.
+func (p *PublisherKeySpace) getVersionKey(version tuple.Versionstamp) fdb.Key {
+	return subspace.FromBytes(p.cdcBytes).Pack(tuple.Tuple{version})
+}
+
+// getVersion returns the version of the key of a part of the changes.
+func (p *PublisherKeySpace) getVersion(key fdb.Key) (tuple.Versionstamp, error) {
+	t, err := subspace.FromBytes(p.cdcBytes).Unpack(key)
+	if err != nil {
+		return tuple.Versionstamp{}, err
+	}
+	if len(t) != 1 {
+		return tuple.Versionstamp{}, fmt.Errorf("invalid change stream key")
+	}
+
+	v, ok := t[0].(tuple.Versionstamp)
+	if !ok {
+		return tuple.Versionstamp{}, fmt.Errorf("invalid change stream key")
+	}
+
+	return v, nil
+}
+
+func NewPublisher(namespaceId uint32, dbName string) *Publisher {
 	return &Publisher{
-		keySpace: NewPublisherKeySpace(dbName),
+		keySpace: NewPublisherKeySpace(namespaceId, dbName),
 	}
 }
 
-func (p *Publisher) NewStreamer(kvStore kv.TxStore) (*Streamer, error) {
+// NewStreamer starts streaming the changes published after the resume token, or the changes published from now on
+// if the token is nil.
+func (p *Publisher) NewStreamer(kvStore kv.TxStore, from *ResumeToken) (*Streamer, error) {
 	intDb, err := kvStore.GetInternalDatabase()
 	if ulog.E(err) {
 		return nil, err
@@ -74,7 +105,7 @@ func (p *Publisher) NewStreamer(kvStore kv.TxStore) (*Streamer, error) {
 		cfg:      config.DefaultConfig.Cdc,
 	}
 
-	if err = s.start(); err != nil {
+	if err = s.start(from); err != nil {
 		return nil, err
 	}
 
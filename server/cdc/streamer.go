@@ -15,64 +15,115 @@
 package cdc
 
 import (
-	"bytes"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/server/config"
 )
 
+// ErrSlowSubscriber ends the streams whose subscriber doesn't keep up with the changes.
+var ErrSlowSubscriber = errors.ResourceExhausted("subscriber is too slow to consume the changes, resume from the last received change")
+
+var errStreamerClosed = fmt.Errorf("streamer closed")
+
+// Streamer polls the published changes and passes them to the subscriber through a bounded buffer. The polling stops
+// while the buffer is full, so a slow subscriber doesn't grow the memory of the server. The stream ends if the buffer
+// stays full longer than the slow subscriber timeout.
 type Streamer struct {
 	db       fdb.Database
-	lastKey  fdb.Key
 	cfg      config.CdcConfig
 	keySpace *PublisherKeySpace
-	ticker   *time.Ticker
+	begin    fdb.KeySelector
+	done     chan struct{}
+	once     sync.Once
+	err      error
 	Txs      chan Tx
 }
 
-func (s *Streamer) start() error {
-	key, err := s.db.ReadTransact(func(rtx fdb.ReadTransaction) (any, error) {
-		kr := fdb.KeyRange{Begin: s.keySpace.beginKey, End: s.keySpace.endKey}
-		r := rtx.GetRange(kr, fdb.RangeOptions{Limit: 1, Reverse: true})
-
-		i := r.Iterator()
-		if i.Advance() {
-			kv, err := i.Get()
-			if err != nil {
-				return nil, err
+func (s *Streamer) start(from *ResumeToken) error {
+	if from != nil {
+		s.begin = fdb.FirstGreaterOrEqual(s.keySpace.getVersionKey(from.Version))
+	} else {
+		key, err := s.db.ReadTransact(func(rtx fdb.ReadTransaction) (any, error) {
+			kr := fdb.KeyRange{Begin: s.keySpace.beginKey, End: s.keySpace.endKey}
+			r := rtx.GetRange(kr, fdb.RangeOptions{Limit: 1, Reverse: true})
+
+			i := r.Iterator()
+			if i.Advance() {
+				kv, err := i.Get()
+				if err != nil {
+					return nil, err
+				}
+				return kv.Key, nil
 			}
-			return kv.Key, nil
+			return s.keySpace.beginKey, nil
+		})
+		if err != nil {
+			return err
 		}
-		return s.keySpace.beginKey, nil
-	})
-	if err != nil {
-		return err
+		s.begin = fdb.FirstGreaterThan(key.(fdb.Key))
 	}
 
-	s.lastKey = key.(fdb.Key)
 	s.Txs = make(chan Tx, s.cfg.StreamBuffer)
-	s.ticker = time.NewTicker(s.cfg.StreamInterval)
-	go func() {
-		for range s.ticker.C {
-			if err := s.read(); err != nil {
-				log.Err(err).Msg("read failed")
+	s.done = make(chan struct{})
+	go s.run()
+
+	return nil
+}
+
+func (s *Streamer) run() {
+	defer close(s.Txs)
+
+	ticker := time.NewTicker(s.cfg.StreamInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		txs, err := s.read()
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		for _, tx := range txs {
+			if err = s.send(tx); err != nil {
+				if err != errStreamerClosed {
+					s.err = err
+				}
 				return
 			}
+			s.begin = fdb.FirstGreaterThan(fdb.Key(tx.Id))
 		}
-	}()
 
-	return nil
+		if len(txs) < s.cfg.StreamBatch {
+			// caught up, wait for the next poll
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}
 }
 
-func (s *Streamer) read() error {
-	_, err := s.db.ReadTransact(func(rtx fdb.ReadTransaction) (any, error) {
-		kr := fdb.KeyRange{Begin: s.lastKey, End: s.keySpace.endKey}
+// read returns the next batch of the published changes. The changes are passed to the subscriber after the read
+// transaction completes, so that a slow subscriber doesn't hit the transaction time limit.
+func (s *Streamer) read() ([]Tx, error) {
+	txs, err := s.db.ReadTransact(func(rtx fdb.ReadTransaction) (any, error) {
+		kr := fdb.SelectorRange{Begin: s.begin, End: fdb.FirstGreaterOrEqual(s.keySpace.endKey)}
 		r := rtx.GetRange(kr, fdb.RangeOptions{Limit: s.cfg.StreamBatch})
 
+		var txs []Tx
 		i := r.Iterator()
 		for i.Advance() {
 			kv, err := i.Get()
@@ -80,39 +131,56 @@ func (s *Streamer) read() error {
 				return nil, err
 			}
 
-			if bytes.Equal(s.lastKey, kv.Key) {
-				continue
-			}
-
 			data, err := internal.Decode(kv.Value)
 			if err != nil {
 				return nil, err
 			}
 
 			tx := Tx{}
-			err = jsoniter.Unmarshal(data.RawData, &tx)
-			if err != nil {
+			if err = jsoniter.Unmarshal(data.RawData, &tx); err != nil {
 				return nil, err
 			}
-
 			tx.Id = kv.Key
 
-			if len(s.Txs) >= cap(s.Txs) {
-				// buffer overflow
-				close(s.Txs)
-				break
-			}
-
-			s.lastKey = kv.Key
-			s.Txs <- tx
+			txs = append(txs, tx)
 		}
 
-		return nil, nil
+		return txs, nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return txs.([]Tx), nil
+}
+
+func (s *Streamer) send(tx Tx) error {
+	select {
+	case s.Txs <- tx:
+		return nil
+	default:
+	}
 
-	return err
+	timer := time.NewTimer(s.cfg.SlowSubscriberTimeout)
+	defer timer.Stop()
+
+	select {
+	case s.Txs <- tx:
+		return nil
+	case <-s.done:
+		return errStreamerClosed
+	case <-timer.C:
+		return ErrSlowSubscriber
+	}
+}
+
+// Err returns the error that ended the stream, it is valid once Txs is closed.
+func (s *Streamer) Err() error {
+	return s.err
 }
 
 func (s *Streamer) Close() {
-	s.ticker.Stop()
+	s.once.Do(func() {
+		close(s.done)
+	})
 }
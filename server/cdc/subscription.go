@@ -0,0 +1,178 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// Change is a change of a document, or an outbox event, streamed to the subscribers.
+type Change struct {
+	Op         string              `json:"op"`
+	Collection string              `json:"collection"`
+	Key        jsoniter.RawMessage `json:"key"`
+	// Before is the document before the replace, update or delete.
+	Before jsoniter.RawMessage `json:"before,omitempty"`
+	// After is the document after the insert, replace or update, or the payload of an outbox event.
+	After jsoniter.RawMessage `json:"after,omitempty"`
+	// Truncated is set if the document was too large to be published with the change.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TableDecoder returns the database and the collection name of the table of a change.
+type TableDecoder interface {
+	DecodeTableName(table []byte) (*metadata.Database, string, bool)
+}
+
+// Subscription selects the changes streamed to a subscriber of a database branch.
+type Subscription struct {
+	NamespaceId uint32
+	// Database is the internal name of the database branch.
+	Database string
+	// Collection limits the stream to the changes of a collection, the changes of all the collections are streamed
+	// if it is empty.
+	Collection string
+	// From resumes the stream after the change of the token, the stream starts with the changes committed after
+	// the subscription if it is nil.
+	From *ResumeToken
+	// Decrypt returns the plaintext of the encrypted fields, otherwise the subscriber receives the ciphertext.
+	Decrypt bool
+	// Mask applies the masking policies of the collections for the role of the subscriber to the documents.
+	Mask bool
+	Role string
+}
+
+// SendFunc sends a change of the collection to the subscriber along with its resume token.
+type SendFunc func(collection *schema.DefaultCollection, change *Change, token *ResumeToken) error
+
+// Subscribe streams the changes of the subscription until the context is done or the stream fails.
+func (m *Manager) Subscribe(ctx context.Context, kvStore kv.TxStore, tables TableDecoder, sub *Subscription, send SendFunc) error {
+	pub := m.GetPublisher(sub.NamespaceId, sub.Database)
+	streamer, err := pub.NewStreamer(kvStore, sub.From)
+	if err != nil {
+		return err
+	}
+	defer streamer.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case tx, ok := <-streamer.Txs:
+			if !ok {
+				return streamer.Err()
+			}
+
+			version, err := pub.keySpace.getVersion(tx.Id)
+			if err != nil {
+				return err
+			}
+
+			for i, event := range tx.Ops {
+				if sub.From != nil && sub.From.covers(version, i) {
+					continue
+				}
+
+				collection, change, err := sub.change(tables, event)
+				if err != nil {
+					return err
+				}
+				if change == nil {
+					continue
+				}
+
+				if err = send(collection, change, &ResumeToken{Version: version, Index: uint32(i)}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// change returns the collection and the change of the event, nil if the event isn't streamed to the subscriber.
+func (sub *Subscription) change(tables TableDecoder, event *kv.Event) (*schema.DefaultCollection, *Change, error) {
+	if event.Key == nil {
+		// event.Key == nil if event comes from drop table
+		return nil, nil, nil
+	}
+
+	db, collName, ok := tables.DecodeTableName(event.Table)
+	if !ok || (len(sub.Collection) > 0 && collName != sub.Collection) {
+		return nil, nil, nil
+	}
+	collection := db.GetCollection(collName)
+	if collection == nil {
+		return nil, nil, nil
+	}
+
+	// the zeroth element of the key is the index name
+	key, err := jsoniter.Marshal(event.Key[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	change := &Change{Op: event.Op, Collection: collName, Key: key}
+	if event.Op == kv.OutboxEvent {
+		// the payload of the outbox events is not a document of the collection
+		if event.Data != nil {
+			change.After = event.Data.RawData
+		} else {
+			change.Truncated = true
+		}
+
+		return collection, change, nil
+	}
+
+	if event.Before != nil {
+		if change.Before, err = sub.image(collection, event.Before.RawData); err != nil {
+			return nil, nil, err
+		}
+	}
+	if event.Data != nil {
+		if change.After, err = sub.image(collection, event.Data.RawData); err != nil {
+			return nil, nil, err
+		}
+	} else if event.Op != kv.DeleteEvent {
+		change.Truncated = true
+	}
+
+	return collection, change, nil
+}
+
+func (sub *Subscription) image(collection *schema.DefaultCollection, doc []byte) ([]byte, error) {
+	var err error
+	if sub.Decrypt && collection.HasEncryptedFields() {
+		if doc, err = collection.DecryptFields(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if !sub.Mask {
+		return doc, nil
+	}
+
+	if masker := collection.NewFieldMasker(sub.Role); masker != nil {
+		if doc, err = masker.Mask(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
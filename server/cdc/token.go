@@ -0,0 +1,72 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+const (
+	resumeTokenVersion = 1
+	resumeTokenSize    = 1 + 12 + 4
+)
+
+// ResumeToken is the position of a change in the stream. The version is the versionstamp of the part of the
+// committed transaction that has the change and the index is the position of the change in the part.
+type ResumeToken struct {
+	Version tuple.Versionstamp
+	Index   uint32
+}
+
+// Encode returns the opaque token sent to the subscribers.
+func (t *ResumeToken) Encode() []byte {
+	b := make([]byte, 0, resumeTokenSize)
+	b = append(b, resumeTokenVersion)
+	b = append(b, t.Version.Bytes()...)
+
+	return binary.BigEndian.AppendUint32(b, t.Index)
+}
+
+// DecodeResumeToken decodes the token sent by a subscriber to resume the stream after the change.
+func DecodeResumeToken(b []byte) (*ResumeToken, error) {
+	if len(b) != resumeTokenSize || b[0] != resumeTokenVersion {
+		return nil, errors.InvalidArgument("invalid resume token")
+	}
+
+	t := &ResumeToken{}
+	copy(t.Version.TransactionVersion[:], b[1:11])
+	t.Version.UserVersion = binary.BigEndian.Uint16(b[11:13])
+	t.Index = binary.BigEndian.Uint32(b[13:])
+
+	return t, nil
+}
+
+// covers returns true if the change of the part the stream resumes from was received before the token.
+func (t *ResumeToken) covers(version tuple.Versionstamp, index int) bool {
+	return version == t.Version && uint32(index) <= t.Index
+}
+
+// After returns true if the change of the token comes after the change of the other token.
+func (t *ResumeToken) After(other *ResumeToken) bool {
+	if c := bytes.Compare(t.Version.Bytes(), other.Version.Bytes()); c != 0 {
+		return c > 0
+	}
+
+	return t.Index > other.Index
+}
@@ -0,0 +1,58 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"testing"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeToken(t *testing.T) {
+	version := tuple.Versionstamp{TransactionVersion: [10]byte{0, 0, 0, 1, 2, 3, 4, 5, 6, 7}, UserVersion: 3}
+	token := &ResumeToken{Version: version, Index: 5}
+
+	decoded, err := DecodeResumeToken(token.Encode())
+	require.NoError(t, err)
+	require.Equal(t, token, decoded)
+
+	require.True(t, decoded.covers(version, 0))
+	require.True(t, decoded.covers(version, 5))
+	require.False(t, decoded.covers(version, 6))
+
+	next := version
+	next.UserVersion++
+	require.False(t, decoded.covers(next, 0))
+
+	_, err = DecodeResumeToken([]byte("invalid"))
+	require.Error(t, err)
+
+	invalid := token.Encode()
+	invalid[0] = 0
+	_, err = DecodeResumeToken(invalid)
+	require.Error(t, err)
+}
+
+func TestResumeTokenAfter(t *testing.T) {
+	v1 := tuple.Versionstamp{TransactionVersion: [10]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0}, UserVersion: 2}
+	v2 := tuple.Versionstamp{TransactionVersion: [10]byte{0, 0, 0, 0, 0, 0, 0, 2, 0, 0}, UserVersion: 0}
+
+	require.True(t, (&ResumeToken{Version: v2}).After(&ResumeToken{Version: v1, Index: 10}))
+	require.False(t, (&ResumeToken{Version: v1, Index: 10}).After(&ResumeToken{Version: v2}))
+	require.True(t, (&ResumeToken{Version: v1, Index: 1}).After(&ResumeToken{Version: v1}))
+	require.False(t, (&ResumeToken{Version: v1}).After(&ResumeToken{Version: v1}))
+	require.True(t, (&ResumeToken{Version: v1}).After(&ResumeToken{}))
+}
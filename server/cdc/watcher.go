@@ -0,0 +1,87 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// Watcher follows the changes of the databases to keep the state derived from their data, like the caches, up to
+// date with the writes committed on the other nodes. The changes of a database are streamed from the first Watch of
+// the database. If the stream ends the changes may have been missed, onEnd is called to drop the derived state and the
+// stream is restarted by the next Watch.
+type Watcher struct {
+	sync.Mutex
+
+	mgr      *Manager
+	kvStore  kv.TxStore
+	streams  map[string]*Streamer
+	onChange func(context.Context, Tx)
+	onEnd    func(context.Context)
+}
+
+// NewWatcher returns the watcher calling onChange for the transactions committed to the watched databases.
+func (m *Manager) NewWatcher(kvStore kv.TxStore, onChange func(context.Context, Tx), onEnd func(context.Context)) *Watcher {
+	return &Watcher{
+		mgr:      m,
+		kvStore:  kvStore,
+		streams:  make(map[string]*Streamer),
+		onChange: onChange,
+		onEnd:    onEnd,
+	}
+}
+
+// Watch makes sure the changes of the database are streamed, the state derived from the database must not be kept if
+// it returns an error.
+func (w *Watcher) Watch(namespaceId uint32, dbName string) error {
+	name := fmt.Sprintf("%d_%s", namespaceId, dbName)
+
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.streams[name]; ok {
+		return nil
+	}
+
+	streamer, err := w.mgr.GetPublisher(namespaceId, dbName).NewStreamer(w.kvStore, nil)
+	if err != nil {
+		return err
+	}
+
+	w.streams[name] = streamer
+	go w.run(name, streamer)
+
+	return nil
+}
+
+func (w *Watcher) run(name string, streamer *Streamer) {
+	ctx := context.Background()
+	for tx := range streamer.Txs {
+		w.onChange(ctx, tx)
+	}
+
+	log.Warn().Err(streamer.Err()).Str("database", name).Msg("Watched change stream ended")
+
+	w.Lock()
+	delete(w.streams, name)
+	w.Unlock()
+
+	w.onEnd(ctx)
+}
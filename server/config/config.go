@@ -106,6 +106,8 @@ func LoadConfig(config any) {
 	b, err := yaml.Marshal(config)
 	log.Err(err).Msg("marshal config")
 	log.Debug().RawJSON("config", b).Msg("default config")
+	// the defaults are kept to read the configuration again on reload
+	defaultsYAML = b
 	br := bytes.NewBuffer(b)
 	err = viper.MergeConfig(br)
 	log.Err(err).Msg("merge config")
@@ -0,0 +1,127 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FlagState is the state of a runtime feature flag on the node.
+type FlagState struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Overridden is true if the flag is set on the node by the admin API, the configured value is ignored then
+	Overridden bool `json:"overridden"`
+}
+
+// The runtime flags are read from the configuration and reloaded with it. The flags overridden on the node are kept
+// across the reloads until they are cleared or the node is restarted.
+var flags struct {
+	sync.RWMutex
+
+	configured map[string]bool
+	overrides  map[string]bool
+}
+
+func init() {
+	OnReload("flags", func(c *Config) any { return c.Flags }, func(c *Config) error {
+		setConfiguredFlags(c.Flags)
+		return nil
+	})
+}
+
+// the names are case-insensitive as the keys of the configuration.
+func flagName(name string) string {
+	return strings.ToLower(name)
+}
+
+func setConfiguredFlags(configured map[string]bool) {
+	m := make(map[string]bool, len(configured))
+	for k, v := range configured {
+		m[flagName(k)] = v
+	}
+
+	flags.Lock()
+	defer flags.Unlock()
+
+	flags.configured = m
+}
+
+// configuredFlags must be called under the lock.
+func configuredFlags() map[string]bool {
+	if flags.configured == nil {
+		return DefaultConfig.Flags
+	}
+
+	return flags.configured
+}
+
+// FlagEnabled returns true if the runtime flag is enabled on the node. The flags are disabled unless enabled by the
+// configuration or the admin API.
+func FlagEnabled(name string) bool {
+	name = flagName(name)
+
+	flags.RLock()
+	defer flags.RUnlock()
+
+	if enabled, ok := flags.overrides[name]; ok {
+		return enabled
+	}
+
+	return configuredFlags()[name]
+}
+
+// SetFlag overrides the flag on the node until it is cleared or the node is restarted.
+func SetFlag(name string, enabled bool) {
+	flags.Lock()
+	defer flags.Unlock()
+
+	if flags.overrides == nil {
+		flags.overrides = make(map[string]bool)
+	}
+	flags.overrides[flagName(name)] = enabled
+}
+
+// ClearFlag drops the override of the flag, so the configured value is used again.
+func ClearFlag(name string) {
+	flags.Lock()
+	defer flags.Unlock()
+
+	delete(flags.overrides, flagName(name))
+}
+
+// ListFlags returns the configured and the overridden flags sorted by name.
+func ListFlags() []FlagState {
+	flags.RLock()
+	defer flags.RUnlock()
+
+	states := make(map[string]FlagState)
+	for name, enabled := range configuredFlags() {
+		states[flagName(name)] = FlagState{Name: flagName(name), Enabled: enabled}
+	}
+	for name, enabled := range flags.overrides {
+		states[name] = FlagState{Name: name, Enabled: enabled, Overridden: true}
+	}
+
+	list := make([]FlagState, 0, len(states))
+	for _, s := range states {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
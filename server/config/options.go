@@ -15,6 +15,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/auth0/go-jwt-middleware/v2/validator"
@@ -32,6 +33,159 @@ type ServerConfig struct {
 	Type         string `mapstructure:"type" yaml:"type" json:"type"`
 	FDBHardDrop  bool   `mapstructure:"fdb_hard_drop" yaml:"fdb_hard_drop" json:"fdb_hard_drop"`
 	RealtimePort int16  `mapstructure:"realtime_port" yaml:"realtime_port" json:"realtime_port"`
+	// MongoPort enables the MongoDB wire protocol listener on the given port, zero disables it.
+	MongoPort int16 `mapstructure:"mongo_port" yaml:"mongo_port" json:"mongo_port"`
+	// PostgresPort enables the PostgreSQL wire protocol listener on the given port, zero disables it.
+	PostgresPort int16 `mapstructure:"postgres_port" yaml:"postgres_port" json:"postgres_port"`
+	// Compression of the gRPC and HTTP responses, negotiated with the client.
+	Compression CompressionConfig `mapstructure:"compression" yaml:"compression" json:"compression"`
+	// InsertStream controls how the documents of a streaming insert are grouped into transactions.
+	InsertStream InsertStreamConfig `mapstructure:"insert_stream" yaml:"insert_stream" json:"insert_stream"`
+	// TxRetry is the retry policy of the transactions of the requests outside of explicit transactions which
+	// conflict with other transactions.
+	TxRetry TxRetryConfig `mapstructure:"tx_retry" yaml:"tx_retry" json:"tx_retry"`
+	// InsertCoalescing groups the concurrent insert requests for the same collection into fewer transactions.
+	InsertCoalescing InsertCoalescingConfig `mapstructure:"insert_coalescing" yaml:"insert_coalescing" json:"insert_coalescing"`
+	// ParallelScan splits the filtered full scans of the collections stored across multiple shards into key ranges
+	// which are read concurrently.
+	ParallelScan ParallelScanConfig `mapstructure:"parallel_scan" yaml:"parallel_scan" json:"parallel_scan"`
+	// SlowQueryLog records the reads which take longer than a threshold.
+	SlowQueryLog SlowQueryLogConfig `mapstructure:"slow_query_log" yaml:"slow_query_log" json:"slow_query_log"`
+	// TLS of the gRPC and HTTP listener, it verifies the client certificates for the mTLS authentication.
+	TLS TLSConfig `mapstructure:"tls" yaml:"tls" json:"tls"`
+	// BatchDelete bounds the deletes run in batches of the Tigris-Delete-Batch-Size header.
+	BatchDelete BatchDeleteConfig `mapstructure:"batch_delete" yaml:"batch_delete" json:"batch_delete"`
+	// BatchUpdate bounds the updates run in batches of the Tigris-Update-Batch-Size header.
+	BatchUpdate BatchUpdateConfig `mapstructure:"batch_update" yaml:"batch_update" json:"batch_update"`
+	// MultiWrite bounds the writes across the collections run in one transaction by the MultiWrite API.
+	MultiWrite MultiWriteConfig `mapstructure:"multi_write" yaml:"multi_write" json:"multi_write"`
+	// EventStream bounds the appends to the event streams and the reads of their events.
+	EventStream EventStreamConfig `mapstructure:"event_stream" yaml:"event_stream" json:"event_stream"`
+	// Queue bounds the options of the queues and the messages enqueued and received by a request.
+	Queue QueueConfig `mapstructure:"queue" yaml:"queue" json:"queue"`
+	// MaxReturnedDocuments is the maximum number of the documents a write returns with the Tigris-Return-Document
+	// header, the writes modifying more documents fail.
+	MaxReturnedDocuments int `mapstructure:"max_returned_documents" yaml:"max_returned_documents" json:"max_returned_documents"`
+	// PartialResultsMargin is the time before the deadline the reads allowing partial results are stopped at, to leave
+	// them the time to return the results found so far.
+	PartialResultsMargin time.Duration `mapstructure:"partial_results_margin" yaml:"partial_results_margin" json:"partial_results_margin"`
+}
+
+// BatchDeleteConfig bounds the batches of the deletes run across multiple transactions, so that each transaction
+// stays within the limits of FoundationDB. The progress of the last MaxTracked deletes of each namespace is kept in
+// memory by the server running them.
+type BatchDeleteConfig struct {
+	MaxBatchSize int `mapstructure:"max_batch_size" yaml:"max_batch_size" json:"max_batch_size"`
+	MaxTracked   int `mapstructure:"max_tracked" yaml:"max_tracked" json:"max_tracked"`
+}
+
+// BatchUpdateConfig bounds the batches of the updates run in the background across multiple transactions, like the
+// BatchDeleteConfig does for the deletes.
+type BatchUpdateConfig struct {
+	MaxBatchSize int `mapstructure:"max_batch_size" yaml:"max_batch_size" json:"max_batch_size"`
+	MaxTracked   int `mapstructure:"max_tracked" yaml:"max_tracked" json:"max_tracked"`
+	// MaxScanned bounds the documents read by a batch, so that a selective filter doesn't make a batch read the whole
+	// collection.
+	MaxScanned int `mapstructure:"max_scanned" yaml:"max_scanned" json:"max_scanned"`
+	// Rate is the maximum number of the documents per second updated by a batched update, zero is unlimited.
+	Rate int `mapstructure:"rate" yaml:"rate" json:"rate"`
+	// Timeout bounds the run of a batched update in the background.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+}
+
+// MultiWriteConfig bounds a multi write, all its writes are done in one transaction which must stay within the limits
+// of FoundationDB.
+type MultiWriteConfig struct {
+	MaxWrites int `mapstructure:"max_writes" yaml:"max_writes" json:"max_writes"`
+	// MaxDocuments is the maximum number of the documents inserted or replaced by all the writes.
+	MaxDocuments int `mapstructure:"max_documents" yaml:"max_documents" json:"max_documents"`
+}
+
+// EventStreamConfig bounds the events appended to an event stream by a request and the events returned by a read.
+type EventStreamConfig struct {
+	MaxAppendEvents int `mapstructure:"max_append_events" yaml:"max_append_events" json:"max_append_events"`
+	// DefaultReadLimit is the number of the events returned by a read without a limit, MaxReadLimit caps the limit.
+	DefaultReadLimit int `mapstructure:"default_read_limit" yaml:"default_read_limit" json:"default_read_limit"`
+	MaxReadLimit     int `mapstructure:"max_read_limit" yaml:"max_read_limit" json:"max_read_limit"`
+}
+
+// QueueConfig bounds the options of the queues and the messages enqueued and received by a request.
+type QueueConfig struct {
+	// DefaultVisibilityTimeout is the visibility timeout of the queues created without one, MaxVisibilityTimeout caps
+	// the visibility timeout of the queues.
+	DefaultVisibilityTimeout time.Duration `mapstructure:"default_visibility_timeout" yaml:"default_visibility_timeout" json:"default_visibility_timeout"`
+	MaxVisibilityTimeout     time.Duration `mapstructure:"max_visibility_timeout" yaml:"max_visibility_timeout" json:"max_visibility_timeout"`
+	MaxEnqueueMessages       int           `mapstructure:"max_enqueue_messages" yaml:"max_enqueue_messages" json:"max_enqueue_messages"`
+	// DefaultReceiveLimit is the number of the messages returned by a receive without a limit, MaxReceiveLimit caps the
+	// limit.
+	DefaultReceiveLimit int `mapstructure:"default_receive_limit" yaml:"default_receive_limit" json:"default_receive_limit"`
+	MaxReceiveLimit     int `mapstructure:"max_receive_limit" yaml:"max_receive_limit" json:"max_receive_limit"`
+}
+
+// Client certificate verification modes of the TLSConfig.
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+// TLSConfig terminates the TLS on the server port. The client certificates are verified against ClientCAFile, with
+// ClientAuthRequest the clients may still connect without a certificate and authenticate with a token.
+type TLSConfig struct {
+	Enabled      bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	CertFile     string `mapstructure:"cert_file" yaml:"cert_file" json:"cert_file"`
+	KeyFile      string `mapstructure:"key_file" yaml:"key_file" json:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file" yaml:"client_ca_file" json:"client_ca_file"`
+	ClientAuth   string `mapstructure:"client_auth" yaml:"client_auth" json:"client_auth"`
+}
+
+// SlowQueryLogConfig records the reads taking longer than Threshold with their normalized filter, the plan and the
+// number of the rows scanned and returned. The last MaxEntries of them are kept in memory by each server and each one
+// is also logged.
+type SlowQueryLogConfig struct {
+	Enabled    bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Threshold  time.Duration `mapstructure:"threshold" yaml:"threshold" json:"threshold"`
+	MaxEntries int           `mapstructure:"max_entries" yaml:"max_entries" json:"max_entries"`
+}
+
+// InsertCoalescingConfig enables the group commit of the insert requests outside of explicit transactions. The
+// requests of a caller for the same collection arriving within the window are inserted in a single transaction, a
+// group is inserted before the window elapses once it reaches any of the limits.
+type InsertCoalescingConfig struct {
+	Enabled  bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Window   time.Duration `mapstructure:"window" yaml:"window" json:"window"`
+	MaxDocs  int           `mapstructure:"max_docs" yaml:"max_docs" json:"max_docs"`
+	MaxBytes int           `mapstructure:"max_bytes" yaml:"max_bytes" json:"max_bytes"`
+}
+
+// ParallelScanConfig bounds the number of key ranges read concurrently by a scan and the number of rows buffered for
+// each range ahead of the rows returned to the client.
+type ParallelScanConfig struct {
+	Enabled   bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	MaxRanges int  `mapstructure:"max_ranges" yaml:"max_ranges" json:"max_ranges"`
+	Buffer    int  `mapstructure:"buffer" yaml:"buffer" json:"buffer"`
+}
+
+// TxRetryConfig retries a conflicting transaction with an exponential backoff, up to MaxAttempts attempts or until the
+// deadline of the request if MaxAttempts is zero. The sleep before a retry is a random duration up to the backoff.
+type TxRetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts" yaml:"max_attempts" json:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"max_backoff"`
+}
+
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Level of the HTTP response compression, from 1 (fastest) to 9 (best compression).
+	Level int `mapstructure:"level" yaml:"level" json:"level"`
+}
+
+// InsertStreamConfig bounds a batch of a streaming insert, the batch is committed as soon as any of the limits
+// is reached.
+type InsertStreamConfig struct {
+	BatchDocs     int           `mapstructure:"batch_docs" yaml:"batch_docs" json:"batch_docs"`
+	BatchBytes    int           `mapstructure:"batch_bytes" yaml:"batch_bytes" json:"batch_bytes"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
 }
 
 type Config struct {
@@ -54,6 +208,25 @@ type Config struct {
 	Management      ManagementConfig    `yaml:"management" json:"management"`
 	GlobalStatus    GlobalStatusConfig  `yaml:"global_status" json:"global_status"`
 	Schema          SchemaConfig
+	Encryption      EncryptionConfig    `yaml:"encryption" json:"encryption"`
+	Masking         MaskingConfig       `yaml:"masking" json:"masking"`
+	Webhook         WebhookConfig       `yaml:"webhook" json:"webhook"`
+	WriteHooks      WriteHooksConfig    `mapstructure:"write_hooks" yaml:"write_hooks" json:"write_hooks"`
+	CEL             CELConfig           `mapstructure:"cel" yaml:"cel" json:"cel"`
+	Kafka           KafkaConfig         `yaml:"kafka" json:"kafka"`
+	Branch          BranchConfig        `yaml:"branch" json:"branch"`
+	Backup          BackupConfig        `yaml:"backup" json:"backup"`
+	Audit           AuditConfig         `yaml:"audit" json:"audit"`
+	Usage           UsageConfig         `yaml:"usage" json:"usage"`
+	Health          HealthConfig        `yaml:"health" json:"health"`
+	DocumentCache   DocumentCacheConfig `mapstructure:"document_cache" yaml:"document_cache" json:"document_cache"`
+	ResultCache     ResultCacheConfig   `mapstructure:"result_cache" yaml:"result_cache" json:"result_cache"`
+	Scheduler       SchedulerConfig     `mapstructure:"scheduler" yaml:"scheduler" json:"scheduler"`
+	Replica         ReplicaConfig       `mapstructure:"replica" yaml:"replica" json:"replica"`
+	Realtime        RealtimeConfig      `mapstructure:"realtime" yaml:"realtime" json:"realtime"`
+	// Flags are the runtime feature flags, they are reloaded with the configuration and can be overridden on a
+	// running node by the admin API.
+	Flags map[string]bool `yaml:"flags" json:"flags"`
 }
 
 type Gotrue struct {
@@ -71,6 +244,27 @@ type AuthzConfig struct {
 	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 }
 
+// RBACConfig enables the project level roles. The subjects bound to a role of a project are limited to the
+// permissions the role grants on the databases and the collections of the project.
+type RBACConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// DecisionCacheSize is the number of the authorization decisions cached on every node
+	DecisionCacheSize int `mapstructure:"decision_cache_size" yaml:"decision_cache_size" json:"decision_cache_size"`
+	// DecisionCacheTTL is how long a decision is cached, the changes of the roles made on other nodes are enforced
+	// after it expires
+	DecisionCacheTTL time.Duration `mapstructure:"decision_cache_ttl" yaml:"decision_cache_ttl" json:"decision_cache_ttl"`
+}
+
+// AppKeysConfig configures the scopes of the app keys and the overlap of the rotated keys.
+type AppKeysConfig struct {
+	// ScopeCacheSize is the number of the app key scopes cached on every node
+	ScopeCacheSize int `mapstructure:"scope_cache_size" yaml:"scope_cache_size" json:"scope_cache_size"`
+	// ScopeCacheTTL is how long a scope is cached, the changes made on other nodes are enforced after it expires
+	ScopeCacheTTL time.Duration `mapstructure:"scope_cache_ttl" yaml:"scope_cache_ttl" json:"scope_cache_ttl"`
+	// RolloverOverlap is how long the old key keeps working after the rollover if the request doesn't set it
+	RolloverOverlap time.Duration `mapstructure:"rollover_overlap" yaml:"rollover_overlap" json:"rollover_overlap"`
+}
+
 type AuthConfig struct {
 	Enabled                    bool                  `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 	Validators                 []ValidatorConfig     `mapstructure:"validators" yaml:"validators" json:"validators"`
@@ -95,6 +289,35 @@ type AuthConfig struct {
 	EnableNamespaceCreation    bool                  `mapstructure:"enable_namespace_creation" yaml:"enable_namespace_creation" json:"enable_namespace_creation"`
 	UserInvitations            Invitation            `mapstructure:"user_invitations" yaml:"user_invitations" json:"user_invitations"`
 	Authz                      AuthzConfig           `mapstructure:"authz" yaml:"authz" json:"authz"`
+	RBAC                       RBACConfig            `mapstructure:"rbac" yaml:"rbac" json:"rbac"`
+	AppKeys                    AppKeysConfig         `mapstructure:"app_keys" yaml:"app_keys" json:"app_keys"`
+	MTLS                       MTLSConfig            `mapstructure:"mtls" yaml:"mtls" json:"mtls"`
+	IPAllowlist                IPAllowlistConfig     `mapstructure:"ip_allowlist" yaml:"ip_allowlist" json:"ip_allowlist"`
+}
+
+// IPAllowlistConfig enforces the network allowlists of the namespaces and the app keys.
+type IPAllowlistConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// TrustForwardedFor takes the client address from the X-Forwarded-For header, it must only be enabled behind a
+	// proxy which overwrites the header
+	TrustForwardedFor bool `mapstructure:"trust_forwarded_for" yaml:"trust_forwarded_for" json:"trust_forwarded_for"`
+}
+
+// MTLSConfig authenticates the requests without a bearer token by the verified client certificate of the connection.
+// The identity of the certificate is its SPIFFE ID, the URI SAN with the spiffe scheme, or its subject common name.
+type MTLSConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Identities are evaluated in order, the certificates which don't match any of them are rejected
+	Identities []CertIdentityConfig `mapstructure:"identities" yaml:"identities" json:"identities"`
+}
+
+// CertIdentityConfig maps the certificates to a namespace and a role. SPIFFEID and CommonName match exactly, or by
+// prefix if they end with "*".
+type CertIdentityConfig struct {
+	SPIFFEID   string `mapstructure:"spiffe_id" yaml:"spiffe_id" json:"spiffe_id"`
+	CommonName string `mapstructure:"common_name" yaml:"common_name" json:"common_name"`
+	Namespace  string `mapstructure:"namespace" yaml:"namespace" json:"namespace"`
+	Role       string `mapstructure:"role" yaml:"role" json:"role"`
 }
 
 type Invitation struct {
@@ -113,6 +336,36 @@ type ValidatorConfig struct {
 	Issuer    string                       `mapstructure:"issuer" yaml:"issuer" json:"issuer"`
 	Algorithm validator.SignatureAlgorithm `mapstructure:"algorithm" yaml:"algorithm" json:"algorithm"`
 	Audience  string                       `mapstructure:"audience" yaml:"audience" json:"audience"`
+	// JWKSURL overrides the keys endpoint discovered from the issuer's well-known configuration
+	JWKSURL string `mapstructure:"jwks_url" yaml:"jwks_url" json:"jwks_url"`
+	// JWKSCacheTimeout is how long the issuer's keys are cached, AuthConfig.JWKSCacheTimeout is used if not set
+	JWKSCacheTimeout time.Duration `mapstructure:"jwks_cache_timeout" yaml:"jwks_cache_timeout" json:"jwks_cache_timeout"`
+	// ClaimMapping is set for the external OIDC providers, like Keycloak, Okta or Azure AD, whose tokens don't carry
+	// the Tigris claims
+	ClaimMapping *ClaimMappingConfig `mapstructure:"claim_mapping" yaml:"claim_mapping" json:"claim_mapping"`
+}
+
+// ClaimMappingConfig maps the claims of the tokens issued by an external OIDC provider to the Tigris namespace and
+// role. The claim names are dot separated paths to address the nested claims, like "realm_access.roles".
+type ClaimMappingConfig struct {
+	// NamespaceClaim is the claim carrying the namespace code
+	NamespaceClaim string `mapstructure:"namespace_claim" yaml:"namespace_claim" json:"namespace_claim"`
+	// Namespace is used when the token doesn't have the NamespaceClaim, it allows pinning all the users of the
+	// issuer to a single namespace
+	Namespace string `mapstructure:"namespace" yaml:"namespace" json:"namespace"`
+	// RoleClaim is the claim carrying the role or the list of the groups of the user
+	RoleClaim string `mapstructure:"role_claim" yaml:"role_claim" json:"role_claim"`
+	// Roles are evaluated in order, the first rule whose value is in the RoleClaim assigns the role
+	Roles []RoleMappingRule `mapstructure:"roles" yaml:"roles" json:"roles"`
+	// DefaultRole is assigned when none of the rules match, the token is rejected if it's empty
+	DefaultRole string `mapstructure:"default_role" yaml:"default_role" json:"default_role"`
+	// EmailClaim is the claim identifying the human users, the tokens without it are treated as the machine tokens
+	EmailClaim string `mapstructure:"email_claim" yaml:"email_claim" json:"email_claim"`
+}
+
+type RoleMappingRule struct {
+	Value string `mapstructure:"value" yaml:"value" json:"value"`
+	Role  string `mapstructure:"role" yaml:"role" json:"role"`
 }
 
 type CdcConfig struct {
@@ -120,12 +373,16 @@ type CdcConfig struct {
 	StreamInterval time.Duration
 	StreamBatch    int
 	StreamBuffer   int
+	// SlowSubscriberTimeout ends the subscriptions that don't consume the buffered changes within the timeout, the
+	// subscriber can resume from the last change it received.
+	SlowSubscriberTimeout time.Duration
 }
 
 type TracingConfig struct {
 	Enabled bool                 `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 	Datadog DatadogTracingConfig `mapstructure:"datadog" yaml:"datadog" json:"datadog"`
 	Jaeger  JaegerTracingConfig  `mapstructure:"jaeger" yaml:"jaeger" json:"jaeger"`
+	OTLP    OTLPTracingConfig    `mapstructure:"otlp" yaml:"otlp" json:"otlp"`
 }
 
 type DatadogTracingConfig struct {
@@ -144,6 +401,20 @@ type JaegerTracingConfig struct {
 	SampleRate float64 `mapstructure:"sample_rate" yaml:"sample_rate" json:"sample_rate"`
 }
 
+// OTLPTracingConfig exports the OpenTelemetry spans to an OTLP collector over gRPC. The requests carrying a W3C
+// traceparent header are traced as part of the trace of the caller, if the caller sampled it, the other requests are
+// sampled with SampleRate.
+type OTLPTracingConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Endpoint is the host:port of the collector.
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`
+	// Insecure disables the TLS of the connection to the collector.
+	Insecure   bool    `mapstructure:"insecure" yaml:"insecure" json:"insecure"`
+	SampleRate float64 `mapstructure:"sample_rate" yaml:"sample_rate" json:"sample_rate"`
+	// Headers are sent with each export, e.g. the API key of a hosted collector.
+	Headers map[string]string `mapstructure:"headers" yaml:"headers" json:"headers"`
+}
+
 type MetricsConfig struct {
 	Enabled           bool                        `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 	DebugMessages     bool                        `mapstructure:"debug_messages" yaml:"debug_messages" json:"debug_messages"`
@@ -157,6 +428,7 @@ type MetricsConfig struct {
 	Network           NetworkMetricGroupConfig    `mapstructure:"network" yaml:"network" json:"network"`
 	Auth              AuthMetricsConfig           `mapstructure:"auth" yaml:"auth" json:"auth"`
 	SecondaryIndex    SecondaryIndexMetricsConfig `mapstructure:"secondary_index" yaml:"secondary_index" json:"secondary_index"`
+	Filter            FilterMetricsConfig         `mapstructure:"filter" yaml:"filter" json:"filter"`
 }
 
 type TimerConfig struct {
@@ -222,6 +494,13 @@ type SecondaryIndexMetricsConfig struct {
 	FilteredTags []string      `mapstructure:"filtered_tags" yaml:"filtered_tags" json:"filtered_tags"`
 }
 
+// FilterMetricsConfig controls the metrics of the evaluation of the filters, the evaluation time and the count of the
+// conditions by operator, evaluated in memory or pushed down to an index. It is disabled by default as every condition
+// evaluated on every document read is timed.
+type FilterMetricsConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+}
+
 type ProfilingConfig struct {
 	Enabled         bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
 	EnableCPU       bool `mapstructure:"enable_cpu" yaml:"enable_cpu" json:"enable_cpu"`
@@ -285,6 +564,68 @@ var DefaultConfig = Config{
 		Port:         8081,
 		RealtimePort: 8083,
 		FDBHardDrop:  true,
+		Compression: CompressionConfig{
+			Enabled: true,
+			Level:   5,
+		},
+		TLS: TLSConfig{
+			ClientAuth: ClientAuthNone,
+		},
+		InsertStream: InsertStreamConfig{
+			BatchDocs:     512,
+			BatchBytes:    1024 * 1024,
+			FlushInterval: 100 * time.Millisecond,
+		},
+		TxRetry: TxRetryConfig{
+			MaxAttempts:    0,
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     200 * time.Millisecond,
+		},
+		BatchDelete: BatchDeleteConfig{
+			MaxBatchSize: 1000,
+			MaxTracked:   20,
+		},
+		BatchUpdate: BatchUpdateConfig{
+			MaxBatchSize: 1000,
+			MaxTracked:   20,
+			MaxScanned:   10000,
+			Rate:         0,
+			Timeout:      24 * time.Hour,
+		},
+		MultiWrite: MultiWriteConfig{
+			MaxWrites:    32,
+			MaxDocuments: 1000,
+		},
+		EventStream: EventStreamConfig{
+			MaxAppendEvents:  1000,
+			DefaultReadLimit: 100,
+			MaxReadLimit:     1000,
+		},
+		Queue: QueueConfig{
+			DefaultVisibilityTimeout: 30 * time.Second,
+			MaxVisibilityTimeout:     12 * time.Hour,
+			MaxEnqueueMessages:       1000,
+			DefaultReceiveLimit:      1,
+			MaxReceiveLimit:          100,
+		},
+		MaxReturnedDocuments: 100,
+		PartialResultsMargin: 100 * time.Millisecond,
+		InsertCoalescing: InsertCoalescingConfig{
+			Enabled:  false,
+			Window:   5 * time.Millisecond,
+			MaxDocs:  512,
+			MaxBytes: 1024 * 1024,
+		},
+		ParallelScan: ParallelScanConfig{
+			Enabled:   true,
+			MaxRanges: 8,
+			Buffer:    256,
+		},
+		SlowQueryLog: SlowQueryLogConfig{
+			Enabled:    true,
+			Threshold:  500 * time.Millisecond,
+			MaxEntries: 1000,
+		},
 	},
 	Auth: AuthConfig{
 		Enabled: false,
@@ -310,6 +651,16 @@ var DefaultConfig = Config{
 			ExpireAfterSec: 259200, // 3days
 		},
 		Authz: AuthzConfig{Enabled: false},
+		RBAC: RBACConfig{
+			Enabled:           false,
+			DecisionCacheSize: 10000,
+			DecisionCacheTTL:  30 * time.Second,
+		},
+		AppKeys: AppKeysConfig{
+			ScopeCacheSize:  10000,
+			ScopeCacheTTL:   30 * time.Second,
+			RolloverOverlap: 24 * time.Hour,
+		},
 	},
 	Billing: Billing{
 		Metronome: Metronome{
@@ -325,33 +676,60 @@ var DefaultConfig = Config{
 		},
 	},
 	Cdc: CdcConfig{
-		Enabled:        false,
-		StreamInterval: 500 * time.Millisecond,
-		StreamBatch:    100,
-		StreamBuffer:   200,
+		Enabled:               false,
+		StreamInterval:        500 * time.Millisecond,
+		StreamBatch:           100,
+		StreamBuffer:          200,
+		SlowSubscriberTimeout: 30 * time.Second,
 	},
 	Search: SearchConfig{
-		Host:           "localhost",
-		Port:           8108,
-		ReadEnabled:    true,
-		WriteEnabled:   true,
-		StorageEnabled: true,
-		Chunking:       true,
-		Compression:    false,
+		Backend:           "typesense",
+		Host:              "localhost",
+		Port:              8108,
+		ReadEnabled:       true,
+		WriteEnabled:      true,
+		StorageEnabled:    true,
+		Chunking:          true,
+		Compression:       false,
+		RebuildBatchSize:  500,
+		Consistency:       "strong",
+		IndexingWorkers:   4,
+		IndexingQueueSize: 1024,
+		SortFallbackLimit: 10000,
 	},
 	KV: KVConfig{
-		Chunking:    false,
-		Compression: false,
+		Backend:           "foundationdb",
+		Chunking:          true,
+		Compression:       false,
+		MaxReadStaleness:  2 * time.Second,
+		ReadSessionMaxAge: 4 * time.Second,
+		ReportConflicts:   true,
 	},
 	SecondaryIndex: SecondaryIndexConfig{
-		ReadEnabled:   true,
-		WriteEnabled:  true,
-		MutateEnabled: false,
+		ReadEnabled:     true,
+		WriteEnabled:    true,
+		MutateEnabled:   false,
+		BackgroundBuild: true,
+		BuildBatchSize:  500,
+		Expiration: ExpirationConfig{
+			Enabled:    true,
+			Interval:   time.Minute,
+			BatchSize:  100,
+			MaxBatches: 100,
+		},
+		Advisor: IndexAdvisorConfig{
+			Enabled:        true,
+			Interval:       10 * time.Minute,
+			MaxShapes:      10000,
+			MinScans:       10,
+			MaxSuggestions: 5,
+		},
 	},
 	Cache: CacheConfig{
-		Host:    "0.0.0.0",
-		Port:    6379,
-		MaxScan: 500,
+		Host:               "0.0.0.0",
+		Port:               6379,
+		MaxScan:            500,
+		EstimateMaxScanned: 100000,
 	},
 	Tracing: TracingConfig{
 		Enabled: false,
@@ -366,6 +744,12 @@ var DefaultConfig = Config{
 			Url:        "http://tigris_jaeger:14268/api/traces",
 			SampleRate: 0.01,
 		},
+		OTLP: OTLPTracingConfig{
+			Enabled:    false,
+			Endpoint:   "localhost:4317",
+			Insecure:   true,
+			SampleRate: 0.01,
+		},
 	},
 	Metrics: MetricsConfig{
 		Enabled:           true,
@@ -480,6 +864,16 @@ var DefaultConfig = Config{
 				Hysteresis: 10,
 			},
 		},
+		Concurrency: ConcurrencyLimitsConfig{
+			Enabled: false,
+			Default: ConcurrencyLimits{
+				Requests: 64,
+				Streams:  32,
+				Queue:    128,
+			},
+			NodeRequests: 1024,
+			QueueTimeout: 1 * time.Second,
+		},
 		Storage: StorageLimitsConfig{
 			Enabled:         false,
 			DataSizeLimit:   100 * 1024 * 1024,
@@ -505,6 +899,129 @@ var DefaultConfig = Config{
 	MetadataCluster: ClusterConfig{
 		Url: "https://api.global.tigrisdata.cloud",
 	},
+	Encryption: EncryptionConfig{
+		Enabled:      false,
+		Provider:     "local",
+		DecryptRoles: []string{"o", "e", "cluster_admin"},
+	},
+	Masking: MaskingConfig{
+		UnmaskedRoles: []string{"o", "cluster_admin"},
+	},
+	Webhook: WebhookConfig{
+		Enabled:        false,
+		Workers:        8,
+		QueueSize:      10000,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+	},
+	WriteHooks: WriteHooksConfig{
+		Enabled:     true,
+		MaxHooks:    16,
+		MaxDuration: 10 * time.Millisecond,
+	},
+	CEL: CELConfig{
+		CacheSize: 1000,
+		CostLimit: 100000,
+	},
+	Kafka: KafkaConfig{
+		Enabled:            false,
+		ClientID:           "tigris",
+		Timeout:            30 * time.Second,
+		BatchSize:          500,
+		CheckpointInterval: time.Second,
+		InitialBackoff:     time.Second,
+		MaxBackoff:         time.Minute,
+	},
+	Branch: BranchConfig{
+		Expiration: BranchExpirationConfig{
+			Enabled:  true,
+			Interval: time.Minute,
+		},
+	},
+	Backup: BackupConfig{
+		Enabled: false,
+		Dir:     "/var/lib/tigris/backups",
+		Timeout: time.Hour,
+		Continuous: ContinuousBackupConfig{
+			Enabled:        false,
+			Retention:      7 * 24 * time.Hour,
+			PruneInterval:  time.Hour,
+			PruneBatchSize: 1000,
+		},
+	},
+	Audit: AuditConfig{
+		Enabled:        false,
+		Reads:          false,
+		BufferSize:     10000,
+		FlushInterval:  time.Second,
+		Retention:      365 * 24 * time.Hour,
+		PruneInterval:  time.Hour,
+		PruneBatchSize: 1000,
+	},
+	Usage: UsageConfig{
+		Enabled:         false,
+		FlushInterval:   10 * time.Second,
+		StorageInterval: time.Hour,
+	},
+	Health: HealthConfig{
+		CheckInterval:       5 * time.Second,
+		Timeout:             2 * time.Second,
+		FDBLatencyThreshold: 100 * time.Millisecond,
+		LatencyThreshold:    500 * time.Millisecond,
+	},
+	DocumentCache: DocumentCacheConfig{
+		Backend: DocumentCacheMemory,
+		Size:    100000,
+		TTL:     time.Minute,
+	},
+	ResultCache: ResultCacheConfig{
+		Size:    10000,
+		MaxRows: 100,
+		TTL:     30 * time.Second,
+	},
+	Scheduler: SchedulerConfig{
+		Interval:         10 * time.Second,
+		LeaseTTL:         30 * time.Second,
+		HistorySize:      20,
+		DeleteBatchSize:  1000,
+		DeleteMaxBatches: 100,
+	},
+	Replica: ReplicaConfig{
+		HeartbeatInterval: 1 * time.Second,
+		MaxLag:            2 * time.Second,
+		TTL:               time.Minute,
+	},
+	Realtime: RealtimeConfig{
+		History: RealtimeHistoryConfig{
+			Enabled:        false,
+			Retention:      24 * time.Hour,
+			MaxReplay:      1000,
+			PruneInterval:  10 * time.Minute,
+			PruneBatchSize: 1000,
+		},
+	},
+}
+
+// RealtimeConfig configures the realtime channels.
+type RealtimeConfig struct {
+	History RealtimeHistoryConfig `mapstructure:"history" yaml:"history" json:"history"`
+}
+
+// RealtimeHistoryConfig controls the durable history of the messages of the realtime channels. The messages published
+// to the channels are retained in the key-value store for the retention, so the subscribers can replay them from a
+// position after the channel was dropped from the cache.
+type RealtimeHistoryConfig struct {
+	Enabled   bool          `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Retention time.Duration `mapstructure:"retention" yaml:"retention" json:"retention"`
+	// MaxReplay is the maximum number of the messages replayed from the history by a subscription, the subscription
+	// continues after the last message replayed.
+	MaxReplay int `mapstructure:"max_replay" yaml:"max_replay" json:"max_replay"`
+	// PruneInterval is how often the messages older than the retention are deleted, PruneBatchSize is the number of
+	// the messages deleted in a transaction.
+	PruneInterval  time.Duration `mapstructure:"prune_interval" yaml:"prune_interval" json:"prune_interval"`
+	PruneBatchSize int           `mapstructure:"prune_batch_size" yaml:"prune_batch_size" json:"prune_batch_size"`
 }
 
 // SchemaConfig contains schema related settings.
@@ -524,12 +1041,311 @@ type SchemaConfig struct {
 	AllowIncompatible bool `mapstructure:"allow_incompatible" json:"allow_incompatible" yaml:"allow_incompatible"`
 }
 
+// EncryptionConfig contains settings for field level encryption at rest. Every collection that has encrypted fields
+// gets its own data key which is wrapped by the configured key management provider before it is persisted.
+type EncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Provider is the key management system used to wrap and unwrap the collection data keys.
+	Provider string `mapstructure:"provider" yaml:"provider" json:"provider"`
+	// MasterKey is the base64 encoded 256-bit key used by the "local" provider.
+	MasterKey string `mapstructure:"master_key" yaml:"master_key" json:"master_key"`
+	// DecryptRoles are the roles that are allowed to read the decrypted value of the encrypted fields. Callers with any
+	// other role receive the ciphertext.
+	DecryptRoles []string `mapstructure:"decrypt_roles" yaml:"decrypt_roles" json:"decrypt_roles"`
+}
+
+// MaskingConfig contains the defaults for the field masking policies defined in the collection schemas.
+type MaskingConfig struct {
+	// UnmaskedRoles are the roles that see the raw value of a masked field when the field doesn't explicitly list them.
+	UnmaskedRoles []string `mapstructure:"unmasked_roles" yaml:"unmasked_roles" json:"unmasked_roles"`
+}
+
+// WebhookConfig controls the delivery of the collection changes to the webhooks defined in the collection schemas.
+type WebhookConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Workers is the number of the concurrent deliveries.
+	Workers int `mapstructure:"workers" yaml:"workers" json:"workers"`
+	// QueueSize bounds the number of the events waiting for delivery, the events that don't fit are dead-lettered.
+	QueueSize int `mapstructure:"queue_size" yaml:"queue_size" json:"queue_size"`
+	// Timeout of a single delivery attempt.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// MaxAttempts is the number of the delivery attempts before the event is dead-lettered.
+	MaxAttempts int `mapstructure:"max_attempts" yaml:"max_attempts" json:"max_attempts"`
+	// InitialBackoff is the delay before the first retry, it doubles on every retry up to MaxBackoff.
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"max_backoff"`
+}
+
+// WriteHooksConfig controls the write hooks defined in the collection schemas, which check and modify the documents
+// before they are inserted or updated.
+type WriteHooksConfig struct {
+	// Enabled runs the write hooks, the documents are written without running them if it is false.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// MaxHooks is the maximum number of the write hooks of a collection.
+	MaxHooks int `mapstructure:"max_hooks" yaml:"max_hooks" json:"max_hooks"`
+	// MaxDuration bounds the time spent running the hooks of the collection on a document, the write is rejected
+	// once it is exceeded.
+	MaxDuration time.Duration `mapstructure:"max_duration" yaml:"max_duration" json:"max_duration"`
+}
+
+// CELConfig controls the evaluation of the CEL expressions of the collection schemas.
+type CELConfig struct {
+	// CacheSize is the number of the compiled expressions kept in memory.
+	CacheSize int `mapstructure:"cache_size" yaml:"cache_size" json:"cache_size"`
+	// CostLimit bounds the cost of an evaluation, roughly the number of the operations, the evaluations exceeding it
+	// fail. Zero disables the limit.
+	CostLimit uint64 `mapstructure:"cost_limit" yaml:"cost_limit" json:"cost_limit"`
+}
+
+// BranchConfig contains the database branch related settings.
+type BranchConfig struct {
+	// Expiration deletes the ephemeral branches once their TTL is over.
+	Expiration BranchExpirationConfig `mapstructure:"expiration" yaml:"expiration" json:"expiration"`
+}
+
+type BranchExpirationConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Interval is the time between the scans of the branches for the expired ones.
+	Interval time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`
+}
+
+// BackupConfig controls the logical backups of the projects and their restore. The archives are written to and read
+// from the targets of the requests, either a file under Dir, "file://<name>", or an object store URL,
+// "https://<host>/<path>" usually pre-signed, whose host must be one of the AllowedHosts.
+type BackupConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Dir is the directory of the file targets, it also holds the archives while they are uploaded.
+	Dir string `mapstructure:"dir" yaml:"dir" json:"dir"`
+	// AllowedHosts are the hosts of the object store targets, the object store targets are rejected if it is empty.
+	AllowedHosts []string `mapstructure:"allowed_hosts" yaml:"allowed_hosts" json:"allowed_hosts"`
+	// Timeout bounds a backup or a restore job including the transfer of the archive.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// Continuous retains the committed mutations of the collections for the point in time restores.
+	Continuous ContinuousBackupConfig `mapstructure:"continuous" yaml:"continuous" json:"continuous"`
+}
+
+// ContinuousBackupConfig controls the changelog of the collections, the committed mutations with the value of the
+// documents before the change, which the RestoreToTimestamp requests undo to bring a collection back to an earlier
+// moment. The changelog is only written while it is enabled, a collection can't be restored to a moment before that.
+type ContinuousBackupConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Retention is how long the mutations are retained, the window of the point in time restores.
+	Retention time.Duration `mapstructure:"retention" yaml:"retention" json:"retention"`
+	// PruneInterval is how often the mutations older than the retention are deleted.
+	PruneInterval time.Duration `mapstructure:"prune_interval" yaml:"prune_interval" json:"prune_interval"`
+	// PruneBatchSize is the number of the mutations deleted in a transaction.
+	PruneBatchSize int `mapstructure:"prune_batch_size" yaml:"prune_batch_size" json:"prune_batch_size"`
+}
+
+// UsageConfig controls the metering of the consumption of the namespaces, the read, the write and the search units
+// and the stored bytes of their collections, aggregated by hour to answer the Usage requests. The units are counted
+// from the request status, so the metering requires the global status and the metrics or the tracing to be enabled.
+type UsageConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// FlushInterval is how often the units counted by the server are added to the hourly totals.
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// StorageInterval is how often the stored bytes of the collections are sampled, the hourly value is the last
+	// sample of the hour.
+	StorageInterval time.Duration `mapstructure:"storage_interval" yaml:"storage_interval" json:"storage_interval"`
+}
+
+// HealthConfig controls the checks of the dependencies of the server reported by the /healthz and the /readyz
+// endpoints and by the gRPC health service. A dependency slower than its latency threshold is degraded, one that fails
+// or doesn't answer within the timeout is down.
+type HealthConfig struct {
+	// CheckInterval is how often the dependencies are checked, the endpoints report the last check.
+	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval" json:"check_interval"`
+	Timeout       time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// FDBLatencyThreshold is the latency threshold of the read of the metadata version from FoundationDB.
+	FDBLatencyThreshold time.Duration `mapstructure:"fdb_latency_threshold" yaml:"fdb_latency_threshold" json:"fdb_latency_threshold"`
+	// LatencyThreshold is the latency threshold of the search backend and the cache.
+	LatencyThreshold time.Duration `mapstructure:"latency_threshold" yaml:"latency_threshold" json:"latency_threshold"`
+}
+
+// DocumentCacheConfig enables the read-through cache of the documents read by their primary key outside of the
+// explicit transactions. The cached documents are invalidated by the writes committed on the node and, if the change
+// streams are enabled, by the changes committed on the other nodes. The TTL bounds how stale a document can be
+// otherwise.
+type DocumentCacheConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Backend is "memory" for the cache of every node or "redis" for the cache shared by the nodes, which is stored
+	// by the server configured by the cache section.
+	Backend string `mapstructure:"backend" yaml:"backend" json:"backend"`
+	// Size is the maximum number of the documents cached in memory by every node.
+	Size int           `mapstructure:"size" yaml:"size" json:"size"`
+	TTL  time.Duration `mapstructure:"ttl" yaml:"ttl" json:"ttl"`
+	// Default enables the cache for all the collections, except the ones disabled by Collections.
+	Default bool `mapstructure:"default" yaml:"default" json:"default"`
+	// Collections enables or disables the cache for the individual collections, keyed by
+	// "{namespace}/{project}/{collection}".
+	Collections map[string]bool `mapstructure:"collections" yaml:"collections" json:"collections"`
+}
+
+const (
+	DocumentCacheMemory = "memory"
+	DocumentCacheRedis  = "redis"
+)
+
+// CollectionEnabled returns true if the documents of the collection are cached.
+func (c *DocumentCacheConfig) CollectionEnabled(ns string, project string, collection string) bool {
+	if enabled, ok := c.Collections[ns+"/"+project+"/"+collection]; ok {
+		return enabled
+	}
+
+	return c.Default
+}
+
+// ResultCacheConfig enables the cache of the results of the small reads repeated with the same filter, projection,
+// sort and options, outside of the explicit transactions. The results of a collection are invalidated by any write
+// to the collection committed on the node and, if the change streams are enabled, on the other nodes.
+type ResultCacheConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Size is the maximum number of the results cached by every node.
+	Size int `mapstructure:"size" yaml:"size" json:"size"`
+	// MaxRows is the maximum number of the documents read by a read whose result is cached.
+	MaxRows int           `mapstructure:"max_rows" yaml:"max_rows" json:"max_rows"`
+	TTL     time.Duration `mapstructure:"ttl" yaml:"ttl" json:"ttl"`
+	// Default enables the cache for all the collections, except the ones disabled by Collections.
+	Default bool `mapstructure:"default" yaml:"default" json:"default"`
+	// Collections enables or disables the cache for the individual collections, keyed by
+	// "{namespace}/{project}/{collection}".
+	Collections map[string]bool `mapstructure:"collections" yaml:"collections" json:"collections"`
+}
+
+// CollectionEnabled returns true if the results of the reads of the collection are cached.
+func (c *ResultCacheConfig) CollectionEnabled(ns string, project string, collection string) bool {
+	if enabled, ok := c.Collections[ns+"/"+project+"/"+collection]; ok {
+		return enabled
+	}
+
+	return c.Default
+}
+
+// SchedulerConfig controls the scheduled jobs of the projects. The servers compete for the lease of the scheduler, only
+// the server holding the lease runs the jobs, another server takes over once the lease expires.
+type SchedulerConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Interval is the time between the checks of the schedules of the jobs, the lease is renewed by every check.
+	Interval time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`
+	// LeaseTTL is the time after which the lease of a server that stopped renewing it expires, it must be longer
+	// than the interval.
+	LeaseTTL time.Duration `mapstructure:"lease_ttl" yaml:"lease_ttl" json:"lease_ttl"`
+	// HistorySize is the number of the last runs kept for every job.
+	HistorySize int `mapstructure:"history_size" yaml:"history_size" json:"history_size"`
+	// DeleteBatchSize is the number of the documents deleted in a transaction by the delete jobs, DeleteMaxBatches
+	// bounds the batches of a run, the rest is deleted by the next runs.
+	DeleteBatchSize  int `mapstructure:"delete_batch_size" yaml:"delete_batch_size" json:"delete_batch_size"`
+	DeleteMaxBatches int `mapstructure:"delete_max_batches" yaml:"delete_max_batches" json:"delete_max_batches"`
+}
+
+// The routing of the eligible reads to the read-only replicas.
+const (
+	// ReplicaRoutingHint serves the reads and returns the address of a replica in the Tigris-Read-Replica header, for
+	// the clients to send the next reads to it.
+	ReplicaRoutingHint = "hint"
+	// ReplicaRoutingProxy forwards the reads to a replica and returns its responses.
+	ReplicaRoutingProxy = "proxy"
+)
+
+// ReplicaConfig controls the read-only replicas. A read-only server rejects the writes and registers itself in the
+// metadata with its lag, the servers with the routing enabled send the eligible reads to the registered replicas. Only
+// the reads outside of transactions tolerating the staleness, by the Tigris-Read-Staleness-Ms header, are eligible,
+// and only to the replicas lagging less than the tolerated staleness.
+type ReplicaConfig struct {
+	// ReadOnly makes the server a read-only replica.
+	ReadOnly bool `mapstructure:"read_only" yaml:"read_only" json:"read_only"`
+	// Address is the "host:port" the other servers reach the replica at, the origin of the server and the server
+	// port by default.
+	Address string `mapstructure:"address" yaml:"address" json:"address"`
+	// Routing is "hint" or "proxy" to route the eligible reads to the replicas, the reads are not routed if empty.
+	Routing string `mapstructure:"routing" yaml:"routing" json:"routing"`
+	// HeartbeatInterval is how often the replicas report their lag and the routing servers refresh the replicas.
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval" yaml:"heartbeat_interval" json:"heartbeat_interval"`
+	// MaxLag excludes the replicas lagging more from the routing, whatever the staleness tolerated by the reads.
+	MaxLag time.Duration `mapstructure:"max_lag" yaml:"max_lag" json:"max_lag"`
+	// TTL is the time after which a replica which stopped reporting its lag is removed from the metadata.
+	TTL time.Duration `mapstructure:"ttl" yaml:"ttl" json:"ttl"`
+}
+
+// Validate returns an error if the routing is unknown or if the read-only replica also routes the reads.
+func (c *ReplicaConfig) Validate() error {
+	switch c.Routing {
+	case "", ReplicaRoutingHint, ReplicaRoutingProxy:
+	default:
+		return fmt.Errorf("unknown replica routing '%s', allowed values are hint and proxy", c.Routing)
+	}
+
+	if c.ReadOnly && len(c.Routing) > 0 {
+		return fmt.Errorf("read-only replica can't route the reads")
+	}
+
+	if (c.ReadOnly || len(c.Routing) > 0) && (c.HeartbeatInterval <= 0 || c.TTL <= c.HeartbeatInterval) {
+		return fmt.Errorf("replica heartbeat interval should be positive and shorter than the ttl")
+	}
+
+	return nil
+}
+
+// AuditConfig controls the audit trail of the namespaces, the operations done by the callers with who did them, when
+// and from where. The entries are written in the background in batches, a server that is shut down before writing
+// them loses the entries still buffered.
+type AuditConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Reads also audits the reads, the searches and the describe and list requests, by default only the writes and
+	// the admin operations are audited.
+	Reads bool `mapstructure:"reads" yaml:"reads" json:"reads"`
+	// BufferSize is the number of the entries waiting to be written, the entries recorded while it is full are
+	// dropped and logged.
+	BufferSize int `mapstructure:"buffer_size" yaml:"buffer_size" json:"buffer_size"`
+	// FlushInterval is how often the buffered entries are written.
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" json:"flush_interval"`
+	// Retention is how long the entries are retained.
+	Retention time.Duration `mapstructure:"retention" yaml:"retention" json:"retention"`
+	// PruneInterval is how often the entries older than the retention are deleted.
+	PruneInterval time.Duration `mapstructure:"prune_interval" yaml:"prune_interval" json:"prune_interval"`
+	// PruneBatchSize is the number of the entries deleted in a transaction.
+	PruneBatchSize int `mapstructure:"prune_batch_size" yaml:"prune_batch_size" json:"prune_batch_size"`
+}
+
+// KafkaConfig controls the publishing of the collection changes to the Kafka topics defined in the collection schemas.
+// The changes are read from the change streams, so it requires the cdc to be enabled.
+type KafkaConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Brokers are the host:port addresses used to discover the Kafka cluster.
+	Brokers  []string `mapstructure:"brokers" yaml:"brokers" json:"brokers"`
+	ClientID string   `mapstructure:"client_id" yaml:"client_id" json:"client_id"`
+	// Timeout of a single request to a broker.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// BatchSize is the maximum number of the records sent to a topic in a single produce request.
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	// CheckpointInterval is the minimum interval between the saves of the offsets of a database branch.
+	CheckpointInterval time.Duration `mapstructure:"checkpoint_interval" yaml:"checkpoint_interval" json:"checkpoint_interval"`
+	// InitialBackoff is the delay before the first retry of a failed publish, it doubles on every retry up to
+	// MaxBackoff. A publish is retried until it succeeds.
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" yaml:"max_backoff" json:"max_backoff"`
+}
+
 // KVConfig keeps KV store configuration parameters.
 type KVConfig struct {
+	// Backend is the storage of the data, "foundationdb" (default) or "ephemeral". The ephemeral backend keeps the data
+	// in the memory of the process and loses it on restart, it is meant for the tests and throwaway development
+	// servers which don't have a FoundationDB cluster. It still needs the FoundationDB client library and can't be
+	// used with CDC.
+	Backend string `mapstructure:"backend" yaml:"backend" json:"backend"`
 	// Chunking allows us to persist bigger payload in storage.
 	Chunking bool `mapstructure:"chunking" yaml:"chunking" json:"chunking"`
 	// Compression allows us to compress payload before storing in storage.
 	Compression bool `mapstructure:"compression" yaml:"compression" json:"compression"`
+	// MaxReadStaleness bounds the staleness requested by the reads, it should leave the reads enough time to complete
+	// before the read version falls out of the five seconds MVCC window of FoundationDB.
+	MaxReadStaleness time.Duration `mapstructure:"max_read_staleness" yaml:"max_read_staleness" json:"max_read_staleness"`
+	// ReadSessionMaxAge is the time the reads of a read session can read its snapshot for, the session should expire
+	// before the snapshot falls out of the MVCC window of FoundationDB.
+	ReadSessionMaxAge time.Duration `mapstructure:"read_session_max_age" yaml:"read_session_max_age" json:"read_session_max_age"`
+	// ReportConflicts makes the transactions report the key ranges they conflicted on, they are returned to the
+	// users when the retries of a request are exhausted.
+	ReportConflicts bool `mapstructure:"report_conflicts" yaml:"report_conflicts" json:"report_conflicts"`
 }
 
 // FoundationDBConfig keeps FoundationDB configuration parameters.
@@ -538,6 +1354,9 @@ type FoundationDBConfig struct {
 }
 
 type SearchConfig struct {
+	// Backend is the search store, "typesense" or "embedded" to keep the search indexes in the memory of the server
+	// for the development and the single node deployments.
+	Backend      string `mapstructure:"backend" json:"backend" yaml:"backend"`
 	Host         string `mapstructure:"host" json:"host" yaml:"host"`
 	Port         int16  `mapstructure:"port" json:"port" yaml:"port"`
 	AuthKey      string `mapstructure:"auth_key" json:"auth_key" yaml:"auth_key"`
@@ -549,18 +1368,69 @@ type SearchConfig struct {
 	Chunking bool `mapstructure:"chunking" yaml:"chunking" json:"chunking"`
 	// Compression allows us to compress payload before storing in storage.
 	Compression bool `mapstructure:"compression" yaml:"compression" json:"compression"`
+	// RebuildBatchSize is the number of documents read in a transaction and indexed together by the rebuild of the
+	// search index of a collection.
+	RebuildBatchSize int `mapstructure:"rebuild_batch_size" yaml:"rebuild_batch_size" json:"rebuild_batch_size"`
+	// Consistency is the default search consistency of the writes to the collections, "strong" to return once the
+	// documents are searchable or "eventual" to index them in the background. The collections and the requests can
+	// override it.
+	Consistency string `mapstructure:"consistency" yaml:"consistency" json:"consistency"`
+	// IndexingWorkers is the number of the workers applying the writes to the search indexes, the writes of a
+	// collection are applied in order by the same worker.
+	IndexingWorkers int `mapstructure:"indexing_workers" yaml:"indexing_workers" json:"indexing_workers"`
+	// IndexingQueueSize is the number of the writes queued by each worker, the writes block once it is full.
+	IndexingQueueSize int `mapstructure:"indexing_queue_size" yaml:"indexing_queue_size" json:"indexing_queue_size"`
+	// SortFallbackLimit is the maximum number of the documents sorted in memory by the reads which sort can't be
+	// served by the search index, either because their filter can't be or because the index is catching up.
+	SortFallbackLimit int `mapstructure:"sort_fallback_limit" yaml:"sort_fallback_limit" json:"sort_fallback_limit"`
 }
 
 type SecondaryIndexConfig struct {
 	ReadEnabled   bool `mapstructure:"read_enabled" yaml:"read_enabled" json:"read_enabled"`
 	WriteEnabled  bool `mapstructure:"write_enabled" yaml:"write_enabled" json:"write_enabled"`
 	MutateEnabled bool `mapstructure:"mutate_enabled" yaml:"mutate_iterator" json:"mutate_enabled"`
+	// BackgroundBuild builds the indexes added to an existing collection in the background once the schema update is
+	// committed, otherwise the indexes stay in the write mode until the BuildCollectionIndex API is called.
+	BackgroundBuild bool `mapstructure:"background_build" yaml:"background_build" json:"background_build"`
+	// BuildBatchSize is the number of documents indexed in a transaction by the background build.
+	BuildBatchSize int `mapstructure:"build_batch_size" yaml:"build_batch_size" json:"build_batch_size"`
+	// Expiration deletes the documents expired by the TTL indexes in the background.
+	Expiration ExpirationConfig `mapstructure:"expiration" yaml:"expiration" json:"expiration"`
+	// Advisor suggests indexes from the filters of the queries served by full scans.
+	Advisor IndexAdvisorConfig `mapstructure:"advisor" yaml:"advisor" json:"advisor"`
+}
+
+type IndexAdvisorConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Interval is the time between the rankings of the suggestions, the counts of the recorded filters decay by half
+	// at each ranking so that the suggestions follow the recent queries.
+	Interval time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`
+	// MaxShapes is the number of distinct filter shapes recorded, the new shapes are dropped once it is reached.
+	MaxShapes int `mapstructure:"max_shapes" yaml:"max_shapes" json:"max_shapes"`
+	// MinScans is the number of full scans an index has to avoid to be suggested.
+	MinScans int64 `mapstructure:"min_scans" yaml:"min_scans" json:"min_scans"`
+	// MaxSuggestions is the number of indexes suggested per collection.
+	MaxSuggestions int `mapstructure:"max_suggestions" yaml:"max_suggestions" json:"max_suggestions"`
+}
+
+type ExpirationConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Interval is the time between the scans of the collections with the TTL indexes.
+	Interval time.Duration `mapstructure:"interval" yaml:"interval" json:"interval"`
+	// BatchSize is the number of documents deleted in a transaction.
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size" json:"batch_size"`
+	// MaxBatches is the number of batches deleted from a collection in a scan, so that a collection with a large
+	// backlog doesn't hold up the others, the rest is deleted by the next scans.
+	MaxBatches int `mapstructure:"max_batches" yaml:"max_batches" json:"max_batches"`
 }
 
 type CacheConfig struct {
 	Host    string `mapstructure:"host" json:"host" yaml:"host"`
 	Port    int16  `mapstructure:"port" json:"port" yaml:"port"`
 	MaxScan int64  `mapstructure:"max_scan" json:"max_scan" yaml:"max_scan"`
+	// EstimateMaxScanned is the number of the keys scanned by a count estimate of the keys of a cache, the count of a
+	// larger keyspace is extrapolated.
+	EstimateMaxScanned int64 `mapstructure:"estimate_max_scanned" json:"estimate_max_scanned" yaml:"estimate_max_scanned"`
 }
 
 type LimitsConfig struct {
@@ -596,6 +1466,59 @@ func (n *NamespaceLimitsConfig) NamespaceLimits(ns string) *LimitsConfig {
 	return &n.Default
 }
 
+// CollectionLimitsConfig limits the rates of the requests of the individual collections on every node. A zero limit
+// doesn't limit the rate.
+type CollectionLimitsConfig struct {
+	Enabled bool
+	Default CollectionLimits // default per collection limits
+	// Collections overrides the limits of the individual collections, keyed by "{namespace}/{project}/{collection}".
+	Collections map[string]CollectionLimits
+}
+
+type CollectionLimits struct {
+	ReadUnits      int `mapstructure:"read_units" yaml:"read_units" json:"read_units"`
+	WriteUnits     int `mapstructure:"write_units" yaml:"write_units" json:"write_units"`
+	SearchRequests int `mapstructure:"search_requests" yaml:"search_requests" json:"search_requests"`
+}
+
+func (c *CollectionLimitsConfig) CollectionLimits(ns string, project string, collection string) *CollectionLimits {
+	cfg, ok := c.Collections[ns+"/"+project+"/"+collection]
+	if ok {
+		return &cfg
+	}
+	return &c.Default
+}
+
+// ConcurrencyLimitsConfig limits the in-flight requests and the open streams of the namespaces on every node. The
+// requests over the limit wait in the queue of their namespace. Once the node limit is reached the queues are served
+// round-robin, so a namespace with a deep queue doesn't starve the others. A zero limit doesn't limit.
+type ConcurrencyLimitsConfig struct {
+	Enabled bool
+	Default ConcurrencyLimits // default per namespace limits
+	// Namespaces overrides the limits of the individual namespaces.
+	Namespaces map[string]ConcurrencyLimits
+	// NodeRequests is the maximum number of the in-flight requests of all the namespaces on the node.
+	NodeRequests int `mapstructure:"node_requests" yaml:"node_requests" json:"node_requests"`
+	// QueueTimeout is how long a request waits in the queue before it's rejected.
+	QueueTimeout time.Duration `mapstructure:"queue_timeout" yaml:"queue_timeout" json:"queue_timeout"`
+}
+
+type ConcurrencyLimits struct {
+	Requests int `mapstructure:"requests" yaml:"requests" json:"requests"`
+	Streams  int `mapstructure:"streams" yaml:"streams" json:"streams"`
+	// Queue is the maximum number of the waiting requests, the requests beyond it are rejected right away. Zero
+	// disables the queueing.
+	Queue int `mapstructure:"queue" yaml:"queue" json:"queue"`
+}
+
+func (c *ConcurrencyLimitsConfig) NamespaceLimits(ns string) *ConcurrencyLimits {
+	cfg, ok := c.Namespaces[ns]
+	if ok {
+		return &cfg
+	}
+	return &c.Default
+}
+
 type NamespaceStorageLimitsConfig struct {
 	Size int64
 }
@@ -628,9 +1551,11 @@ type QuotaRegulator struct {
 }
 
 type QuotaConfig struct {
-	Node      LimitsConfig          // maximum rates per node. protects the node from overloading
-	Namespace NamespaceLimitsConfig // user quota across all the nodes
-	Storage   StorageLimitsConfig
+	Node        LimitsConfig            // maximum rates per node. protects the node from overloading
+	Namespace   NamespaceLimitsConfig   // user quota across all the nodes
+	Collection  CollectionLimitsConfig  // per collection quota on every node
+	Concurrency ConcurrencyLimitsConfig // per namespace in-flight requests and streams on every node
+	Storage     StorageLimitsConfig
 
 	WriteUnitSize int
 	ReadUnitSize  int
@@ -0,0 +1,132 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	ulog "github.com/tigrisdata/tigris/util/log"
+)
+
+// The sections of the configuration registered by OnReload can be changed on a running server, by sending SIGHUP to
+// it or by the admin API. Reload reads the configuration file and the environment the same way LoadConfig does and
+// calls the handlers of the changed sections. The rest of the configuration takes effect on restart only. The reload
+// doesn't restart the listeners, so the open connections and the interactive transactions are not interrupted.
+
+// defaultsYAML are the defaults of the configuration, the reloaded configuration is read on top of them.
+var defaultsYAML []byte
+
+type reloadHandler struct {
+	name    string
+	section func(*Config) any
+	apply   func(*Config) error
+}
+
+var reloader struct {
+	sync.Mutex
+
+	handlers []reloadHandler
+	// applied is the last reloaded configuration, nil until the first reload
+	applied *Config
+}
+
+func init() {
+	OnReload("log", func(c *Config) any { return c.Log.Level }, func(c *Config) error {
+		return ulog.SetLevel(c.Log.Level)
+	})
+}
+
+// OnReload registers the handler of the named section of the configuration. On reload, the handler is called with
+// the new configuration if the value returned by the section function has changed. The handler must validate the new
+// values before applying any of them.
+func OnReload(name string, section func(*Config) any, apply func(*Config) error) {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	reloader.handlers = append(reloader.handlers, reloadHandler{name: name, section: section, apply: apply})
+}
+
+// Reload reads the configuration again and applies the sections which can be changed at runtime. It returns the
+// names of the applied sections.
+func Reload() ([]string, error) {
+	next := &Config{}
+	if err := readConfig(next); err != nil {
+		return nil, err
+	}
+
+	return applyConfig(next)
+}
+
+func applyConfig(next *Config) ([]string, error) {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	prev := reloader.applied
+	if prev == nil {
+		prev = &DefaultConfig
+	}
+
+	changed := []string{}
+	for _, h := range reloader.handlers {
+		if reflect.DeepEqual(h.section(prev), h.section(next)) {
+			continue
+		}
+
+		if err := h.apply(next); err != nil {
+			return changed, fmt.Errorf("error reloading '%s' configuration: %w", h.name, err)
+		}
+
+		log.Info().Str("section", h.name).Msg("Configuration reloaded")
+		changed = append(changed, h.name)
+	}
+
+	reloader.applied = next
+
+	return changed, nil
+}
+
+// readConfig reads the configuration file used by LoadConfig and the environment into the config.
+func readConfig(config any) error {
+	v := viper.New()
+
+	v.SetConfigType("yaml")
+	if err := v.MergeConfig(bytes.NewBuffer(defaultsYAML)); err != nil {
+		return err
+	}
+
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetEnvPrefix(envPrefix)
+	v.AutomaticEnv()
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		v.SetConfigFile(file)
+		if ext := strings.TrimPrefix(filepath.Ext(file), "."); ext != "" {
+			v.SetConfigType(ext)
+		}
+
+		if err := v.MergeInConfig(); err != nil {
+			return err
+		}
+	}
+
+	return v.Unmarshal(config)
+}
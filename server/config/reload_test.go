@@ -0,0 +1,86 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	var applied []int64
+	OnReload("test_cache", func(c *Config) any { return c.Cache.MaxScan }, func(c *Config) error {
+		if c.Cache.MaxScan < 0 {
+			return fmt.Errorf("negative max scan")
+		}
+		applied = append(applied, c.Cache.MaxScan)
+		return nil
+	})
+
+	next := DefaultConfig
+	next.Flags = map[string]bool{"Feature_A": true}
+
+	changed, err := applyConfig(&next)
+	require.NoError(t, err)
+	require.Equal(t, []string{"flags"}, changed)
+	require.Empty(t, applied)
+	require.True(t, FlagEnabled("feature_a"))
+
+	next2 := next
+	next2.Cache.MaxScan = next.Cache.MaxScan + 1
+	changed, err = applyConfig(&next2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test_cache"}, changed)
+	require.Equal(t, []int64{next2.Cache.MaxScan}, applied)
+
+	next3 := next2
+	next3.Cache.MaxScan = -1
+	_, err = applyConfig(&next3)
+	require.Error(t, err)
+
+	// the failed reload is not recorded as applied
+	changed, err = applyConfig(&next2)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+}
+
+func TestFlags(t *testing.T) {
+	setConfiguredFlags(map[string]bool{"a": true, "b": false})
+
+	require.True(t, FlagEnabled("a"))
+	require.False(t, FlagEnabled("b"))
+	require.False(t, FlagEnabled("c"))
+
+	SetFlag("B", true)
+	SetFlag("a", false)
+	require.False(t, FlagEnabled("a"))
+	require.True(t, FlagEnabled("b"))
+
+	// the overrides are kept across the reloads
+	setConfiguredFlags(map[string]bool{"a": true})
+	require.False(t, FlagEnabled("a"))
+	require.Equal(t, []FlagState{
+		{Name: "a", Enabled: false, Overridden: true},
+		{Name: "b", Enabled: true, Overridden: true},
+	}, ListFlags())
+
+	ClearFlag("a")
+	ClearFlag("b")
+	require.True(t, FlagEnabled("a"))
+	require.False(t, FlagEnabled("b"))
+	require.Equal(t, []FlagState{{Name: "a", Enabled: true}}, ListFlags())
+}
@@ -0,0 +1,282 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+type avroType int
+
+const (
+	avroBoolean avroType = iota
+	avroLong
+	avroDouble
+	avroString
+	avroBytes
+	avroTimestamp
+	// avroJSON is a nested object or an array, encoded as a JSON string.
+	avroJSON
+)
+
+type avroField struct {
+	// property is the name of the field in the document, name is the name of the field in the Avro schema.
+	property string
+	name     string
+	typ      avroType
+}
+
+// avroSchema is an Avro record with a nullable field per top level field of the collection schema, in the order of
+// the collection schema. The nested objects and the arrays are encoded as JSON strings.
+type avroSchema struct {
+	fields []avroField
+	// json is the schema sent in the header of the records.
+	json []byte
+	// fingerprint is the CRC-64-AVRO of the canonical form of the schema.
+	fingerprint uint64
+}
+
+func newAvroSchema(collection string, schema []byte) (*avroSchema, error) {
+	var fields []avroField
+	err := jsonparser.ObjectEach(schema, func(key []byte, value []byte, _ jsonparser.ValueType, _ int) error {
+		fields = append(fields, avroField{property: string(key), name: avroName(string(key)), typ: avroFieldType(value)})
+		return nil
+	}, "properties")
+	if err != nil {
+		return nil, errors.Internal("failed to parse the collection schema: %s", err.Error())
+	}
+
+	s := &avroSchema{fields: fields}
+	full, canonical := s.marshal(avroName(collection))
+	s.json = full
+	s.fingerprint = avroFingerprint(canonical)
+
+	return s, nil
+}
+
+func avroFieldType(property []byte) avroType {
+	typ, _ := jsonparser.GetString(property, "type")
+	format, _ := jsonparser.GetString(property, "format")
+
+	switch typ {
+	case "boolean":
+		return avroBoolean
+	case "integer":
+		return avroLong
+	case "number":
+		return avroDouble
+	case "string":
+		switch format {
+		case "date-time":
+			return avroTimestamp
+		case "byte":
+			return avroBytes
+		default:
+			return avroString
+		}
+	default:
+		return avroJSON
+	}
+}
+
+// avroName replaces the characters that are not allowed in the Avro names.
+func avroName(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		switch {
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			c = '_'
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
+
+// marshal returns the schema and its parsing canonical form, which drops the logical types and the defaults.
+func (s *avroSchema) marshal(name string) ([]byte, []byte) {
+	type field struct {
+		Name    string `json:"name"`
+		Type    []any  `json:"type"`
+		Default *int   `json:"default"`
+	}
+	type canonicalField struct {
+		Name string `json:"name"`
+		Type []any  `json:"type"`
+	}
+
+	fields := make([]field, 0, len(s.fields))
+	canonicalFields := make([]canonicalField, 0, len(s.fields))
+	for _, f := range s.fields {
+		var typ any
+		var canonical string
+		switch f.typ {
+		case avroBoolean:
+			canonical = "boolean"
+		case avroLong:
+			canonical = "long"
+		case avroDouble:
+			canonical = "double"
+		case avroBytes:
+			canonical = "bytes"
+		case avroTimestamp:
+			canonical = "long"
+			typ = map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+		default:
+			canonical = "string"
+		}
+		if typ == nil {
+			typ = canonical
+		}
+
+		fields = append(fields, field{Name: f.name, Type: []any{"null", typ}})
+		canonicalFields = append(canonicalFields, canonicalField{Name: f.name, Type: []any{"null", canonical}})
+	}
+
+	full, _ := jsoniter.Marshal(struct {
+		Type   string  `json:"type"`
+		Name   string  `json:"name"`
+		Fields []field `json:"fields"`
+	}{"record", name, fields})
+	canonical, _ := jsoniter.Marshal(struct {
+		Name   string           `json:"name"`
+		Type   string           `json:"type"`
+		Fields []canonicalField `json:"fields"`
+	}{name, "record", canonicalFields})
+
+	return full, canonical
+}
+
+// encode returns the document in the Avro single object encoding: the marker, the fingerprint of the schema and the
+// binary encoded record.
+func (s *avroSchema) encode(doc []byte) ([]byte, error) {
+	b := []byte{0xc3, 0x01}
+	b = binary.LittleEndian.AppendUint64(b, s.fingerprint)
+
+	for _, f := range s.fields {
+		value, dataType, _, err := jsonparser.Get(doc, f.property)
+		if dataType == jsonparser.NotExist || dataType == jsonparser.Null {
+			b = binary.AppendVarint(b, 0)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Internal("failed to read field '%s' of the document: %s", f.property, err.Error())
+		}
+
+		b = binary.AppendVarint(b, 1)
+		if b, err = appendAvroValue(b, f.typ, value, dataType); err != nil {
+			return nil, errors.Internal("failed to convert field '%s' of the document: %s", f.property, err.Error())
+		}
+	}
+
+	return b, nil
+}
+
+func appendAvroValue(b []byte, typ avroType, value []byte, dataType jsonparser.ValueType) ([]byte, error) {
+	switch typ {
+	case avroBoolean:
+		v, err := jsonparser.ParseBoolean(value)
+		if err != nil {
+			return nil, err
+		}
+		if v {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case avroLong:
+		v, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return binary.AppendVarint(b, v), nil
+	case avroDouble:
+		v, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case avroString:
+		v, err := jsonparser.ParseString(value)
+		if err != nil {
+			return nil, err
+		}
+		return appendAvroBytes(b, []byte(v)), nil
+	case avroBytes:
+		s, err := jsonparser.ParseString(value)
+		if err != nil {
+			return nil, err
+		}
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return appendAvroBytes(b, v), nil
+	case avroTimestamp:
+		s, err := jsonparser.ParseString(value)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return binary.AppendVarint(b, t.UnixMicro()), nil
+	default:
+		if dataType == jsonparser.String {
+			// jsonparser strips the quotes of the strings, the value is still escaped
+			value = append(append([]byte{'"'}, value...), '"')
+		}
+		return appendAvroBytes(b, value), nil
+	}
+}
+
+func appendAvroBytes(b []byte, v []byte) []byte {
+	b = binary.AppendVarint(b, int64(len(v)))
+	return append(b, v...)
+}
+
+const avroEmptyFingerprint uint64 = 0xc15d213aa4d7a795
+
+var avroFingerprintTable = func() (table [256]uint64) {
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (avroEmptyFingerprint & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return
+}()
+
+// avroFingerprint is the CRC-64-AVRO fingerprint of the specification.
+func avroFingerprint(b []byte) uint64 {
+	fp := avroEmptyFingerprint
+	for _, c := range b {
+		fp = (fp >> 8) ^ avroFingerprintTable[byte(fp)^c]
+	}
+
+	return fp
+}
@@ -0,0 +1,170 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/cdc"
+	"google.golang.org/grpc/metadata"
+)
+
+// OffsetsCollection is the system collection of a database branch that keeps the position of the change stream of
+// the branch published to each topic.
+const OffsetsCollection = "_tigris_kafka_offsets"
+
+// Checkpoints store the offsets of the topics of a database branch. An offset is the resume token of the change
+// stream up to which the changes of the branch are published to the topic.
+type Checkpoints interface {
+	Load(ctx context.Context, project string, branch string) (map[string]*cdc.ResumeToken, error)
+	Save(ctx context.Context, project string, branch string, offsets map[string]*cdc.ResumeToken) error
+}
+
+// ReadFunc reads the offsets collection.
+type ReadFunc func(r *api.ReadRequest, stream api.Tigris_ReadServer) error
+
+// ReplaceFunc writes the offsets to the offsets collection.
+type ReplaceFunc func(ctx context.Context, r *api.ReplaceRequest) (*api.ReplaceResponse, error)
+
+// ImportFunc creates the offsets collection with the first offsets of the branch.
+type ImportFunc func(ctx context.Context, r *api.ImportRequest) (*api.ImportResponse, error)
+
+type offset struct {
+	Topic     string    `json:"topic"`
+	Token     string    `json:"token"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CollectionCheckpoints keeps the offsets in the offsets collection of the database branch, a document per topic.
+type CollectionCheckpoints struct {
+	read    ReadFunc
+	replace ReplaceFunc
+	create  ImportFunc
+}
+
+func NewCollectionCheckpoints(read ReadFunc, replace ReplaceFunc, create ImportFunc) *CollectionCheckpoints {
+	return &CollectionCheckpoints{read: read, replace: replace, create: create}
+}
+
+func (c *CollectionCheckpoints) Load(ctx context.Context, project string, branch string) (map[string]*cdc.ResumeToken, error) {
+	stream := &offsetsStream{ctx: ctx, offsets: make(map[string]*cdc.ResumeToken)}
+	err := c.read(&api.ReadRequest{
+		Project:    project,
+		Branch:     branch,
+		Collection: OffsetsCollection,
+		Filter:     []byte(`{}`),
+	}, stream)
+	if isNotFound(err) {
+		return stream.offsets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.offsets, stream.err
+}
+
+func (c *CollectionCheckpoints) Save(ctx context.Context, project string, branch string, offsets map[string]*cdc.ResumeToken) error {
+	now := time.Now().UTC()
+	docs := make([][]byte, 0, len(offsets))
+	for topic, token := range offsets {
+		doc, err := jsoniter.Marshal(&offset{
+			Topic:     topic,
+			Token:     base64.StdEncoding.EncodeToString(token.Encode()),
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+
+	_, err := c.replace(ctx, &api.ReplaceRequest{
+		Project:    project,
+		Branch:     branch,
+		Collection: OffsetsCollection,
+		Documents:  docs,
+	})
+	if isNotFound(err) {
+		_, err = c.create(ctx, &api.ImportRequest{
+			Project:          project,
+			Branch:           branch,
+			Collection:       OffsetsCollection,
+			Documents:        docs,
+			PrimaryKey:       []string{"topic"},
+			CreateCollection: true,
+		})
+	}
+
+	return err
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*api.TigrisError)
+	return ok && e.Code == api.Code_NOT_FOUND
+}
+
+// offsetsStream collects the offsets read from the offsets collection.
+type offsetsStream struct {
+	ctx     context.Context
+	offsets map[string]*cdc.ResumeToken
+	err     error
+}
+
+func (*offsetsStream) SetHeader(_ metadata.MD) error {
+	return nil
+}
+
+func (*offsetsStream) SendHeader(_ metadata.MD) error {
+	return nil
+}
+
+func (*offsetsStream) SetTrailer(_ metadata.MD) {}
+
+func (s *offsetsStream) Context() context.Context {
+	return s.ctx
+}
+
+func (*offsetsStream) SendMsg(_ any) error {
+	return nil
+}
+
+func (*offsetsStream) RecvMsg(_ any) error {
+	return nil
+}
+
+func (s *offsetsStream) Send(r *api.ReadResponse) error {
+	var o offset
+	if err := jsoniter.Unmarshal(r.Data, &o); err != nil {
+		s.err = err
+		return err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(o.Token)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if s.offsets[o.Topic], err = cdc.DecodeResumeToken(b); err != nil {
+		s.err = err
+		return err
+	}
+
+	return nil
+}
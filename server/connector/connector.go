@@ -0,0 +1,434 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connector publishes the changes of the collections to the Kafka topics defined in the collection schemas.
+// The changes of a database branch are read from its change stream by a connector that is started on the first
+// write to a collection with a Kafka sink. The connector publishes the changes at least once: it keeps the offset of
+// each topic in the offsets collection of the branch and resumes from the oldest offset after a failure or a
+// restart, skipping the changes that every topic already has.
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/lib/kafka"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/cdc"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// Headers of the published records.
+const (
+	HeaderOp         = "tigris-op"
+	HeaderCollection = "tigris-collection"
+	// HeaderAvroSchema is the Avro schema of the value of the records in the Avro format.
+	HeaderAvroSchema = "tigris-avro-schema"
+)
+
+// Producer publishes the records to a topic.
+type Producer interface {
+	Produce(ctx context.Context, topic string, records []kafka.Record) error
+}
+
+// Sink is a transaction listener that starts the connectors of the database branches with Kafka sinks.
+type Sink struct {
+	sync.WaitGroup
+
+	cfg         config.KafkaConfig
+	cdcMgr      *cdc.Manager
+	kvStore     kv.TxStore
+	tables      cdc.TableDecoder
+	checkpoints Checkpoints
+	producer    Producer
+
+	mu         sync.Mutex
+	connectors map[string]*connector
+	closed     chan struct{}
+}
+
+func NewSink(cfg config.KafkaConfig, cdcMgr *cdc.Manager, kvStore kv.TxStore, tables cdc.TableDecoder, checkpoints Checkpoints, producer Producer) *Sink {
+	return &Sink{
+		cfg:         cfg,
+		cdcMgr:      cdcMgr,
+		kvStore:     kvStore,
+		tables:      tables,
+		checkpoints: checkpoints,
+		producer:    producer,
+		connectors:  make(map[string]*connector),
+		closed:      make(chan struct{}),
+	}
+}
+
+// Close stops the connectors, the changes buffered but not published are published again on the next start.
+func (s *Sink) Close() {
+	close(s.closed)
+	s.Wait()
+}
+
+func (*Sink) OnPreCommit(context.Context, *metadata.Tenant, transaction.Tx, kv.EventListener) error {
+	return nil
+}
+
+func (s *Sink) OnPostCommit(ctx context.Context, tenant *metadata.Tenant, listener kv.EventListener) error {
+	for _, event := range listener.GetEvents() {
+		db, collName, ok := s.tables.DecodeTableName(event.Table)
+		if !ok || event.Key == nil {
+			// event.Key == nil if event comes from drop table
+			continue
+		}
+
+		collection := db.GetCollection(collName)
+		if collection == nil || !collection.HasKafkaSinks() {
+			continue
+		}
+
+		s.start(ctx, tenant.GetNamespace().Id(), db)
+	}
+
+	return nil
+}
+
+func (*Sink) OnRollback(context.Context, *metadata.Tenant, kv.EventListener) {}
+
+// start starts the connector of the database branch if it isn't running.
+func (s *Sink) start(ctx context.Context, namespaceId uint32, db *metadata.Database) {
+	key := fmt.Sprintf("%d_%s", namespaceId, db.Name())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.connectors[key]; ok {
+		return
+	}
+
+	c := &connector{
+		sink:        s,
+		ctx:         detach(ctx),
+		namespaceId: namespaceId,
+		database:    db.Name(),
+		project:     db.DbName(),
+		branch:      db.BranchName(),
+		offsets:     make(map[string]*cdc.ResumeToken),
+		pending:     make(map[string][]kafka.Record),
+		schemas:     make(map[string]*avroSchema),
+	}
+	s.connectors[key] = c
+
+	s.Add(1)
+	go c.run()
+}
+
+// detach returns a context that outlives the request and keeps its metadata, the offsets are read and written with
+// the namespace of the request.
+func detach(ctx context.Context) context.Context {
+	md, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return context.Background()
+	}
+
+	return md.SaveToContext(context.Background())
+}
+
+// connector publishes the change stream of a database branch.
+type connector struct {
+	sink *Sink
+	ctx  context.Context
+
+	namespaceId uint32
+	database    string
+	project     string
+	branch      string
+
+	mu sync.Mutex
+	// offsets are the tokens up to which the changes are published to the topics.
+	offsets map[string]*cdc.ResumeToken
+	// pending are the records buffered for the topics, last is the token of the last change received.
+	pending  map[string][]kafka.Record
+	buffered int
+	last     *cdc.ResumeToken
+	dirty    bool
+	saved    time.Time
+	// schemas are the Avro schemas of the collections, by the collection name and the version of the schema.
+	schemas map[string]*avroSchema
+}
+
+func (c *connector) run() {
+	defer c.sink.Done()
+
+	cfg := c.sink.cfg
+	backoff := cfg.InitialBackoff
+	loaded := false
+	for {
+		if !loaded {
+			offsets, err := c.sink.checkpoints.Load(c.ctx, c.project, c.branch)
+			if err == nil {
+				c.offsets, loaded = offsets, true
+			} else {
+				log.Err(err).Str("project", c.project).Str("branch", c.branch).Msg("failed to load the kafka offsets")
+			}
+		}
+
+		if loaded {
+			published, err := c.stream()
+			if published {
+				backoff = cfg.InitialBackoff
+			}
+			if err != nil {
+				log.Err(err).Str("project", c.project).Str("branch", c.branch).Msg("kafka connector failed")
+			}
+		}
+
+		select {
+		case <-c.sink.closed:
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
+
+// stream publishes the change stream from the oldest offset until the sink is closed or the publish fails. It returns
+// whether any change was published.
+func (c *connector) stream() (bool, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	var flushErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(c.sink.cfg.CheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.sink.closed:
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.flush(ctx); err != nil {
+					flushErr = err
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	sub := &cdc.Subscription{
+		NamespaceId: c.namespaceId,
+		Database:    c.database,
+		From:        c.from(),
+	}
+	start := c.position()
+	err := c.sink.cdcMgr.Subscribe(ctx, c.sink.kvStore, c.sink.tables, sub, c.send)
+	cancel()
+	wg.Wait()
+
+	if err == nil {
+		err = flushErr
+	}
+	if err == nil {
+		// the sink is closed, publish what is buffered before the connector stops
+		err = c.flush(c.ctx)
+	} else {
+		c.discard()
+	}
+
+	return c.position() != start, err
+}
+
+// from returns the oldest offset of the topics, the stream starts from the beginning of the retained changes if the
+// branch has no offsets.
+func (c *connector) from() *cdc.ResumeToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var from *cdc.ResumeToken
+	for _, token := range c.offsets {
+		if from == nil || from.After(token) {
+			from = token
+		}
+	}
+	if from == nil {
+		return &cdc.ResumeToken{}
+	}
+
+	return from
+}
+
+func (c *connector) position() *cdc.ResumeToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.last
+}
+
+// send buffers the records of the change for the topics that don't have it yet.
+func (c *connector) send(collection *schema.DefaultCollection, change *cdc.Change, token *cdc.ResumeToken) error {
+	c.mu.Lock()
+	for _, sink := range collection.KafkaSinks {
+		if offset, ok := c.offsets[sink.Topic]; ok && !token.After(offset) {
+			continue
+		}
+
+		record, err := c.record(collection, sink, change)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if record == nil {
+			continue
+		}
+
+		c.pending[sink.Topic] = append(c.pending[sink.Topic], *record)
+		c.buffered++
+	}
+	c.last = token
+	full := c.buffered >= c.sink.cfg.BatchSize
+	c.mu.Unlock()
+
+	if full {
+		return c.flush(c.ctx)
+	}
+
+	return nil
+}
+
+func (c *connector) record(collection *schema.DefaultCollection, sink *schema.KafkaSink, change *cdc.Change) (*kafka.Record, error) {
+	record := &kafka.Record{
+		Time: time.Now(),
+		Headers: []kafka.Header{
+			{Key: HeaderOp, Value: []byte(change.Op)},
+			{Key: HeaderCollection, Value: []byte(change.Collection)},
+		},
+	}
+	if sink.GetKey() == schema.KafkaKeyPrimaryKey {
+		record.Key = change.Key
+	}
+
+	if sink.GetFormat() == schema.KafkaFormatJSON {
+		value, err := jsoniter.Marshal(change)
+		if err != nil {
+			return nil, err
+		}
+		record.Value = value
+
+		return record, nil
+	}
+
+	if change.Op == kv.OutboxEvent {
+		// the outbox events don't have the schema of the collection
+		return nil, nil
+	}
+	if change.Truncated {
+		log.Error().Str("collection", change.Collection).Str("topic", sink.Topic).
+			Msg("skipping the change of a document too large for the change stream")
+		return nil, nil
+	}
+
+	avro, err := c.avroSchema(collection)
+	if err != nil {
+		return nil, err
+	}
+	record.Headers = append(record.Headers, kafka.Header{Key: HeaderAvroSchema, Value: avro.json})
+	if change.After != nil {
+		// the deletes are tombstones
+		if record.Value, err = avro.encode(change.After); err != nil {
+			return nil, err
+		}
+	}
+
+	return record, nil
+}
+
+func (c *connector) avroSchema(collection *schema.DefaultCollection) (*avroSchema, error) {
+	key := fmt.Sprintf("%s_%d", collection.Name, collection.GetVersion())
+	if s, ok := c.schemas[key]; ok {
+		return s, nil
+	}
+
+	s, err := newAvroSchema(collection.Name, collection.Schema)
+	if err != nil {
+		return nil, err
+	}
+	c.schemas[key] = s
+
+	return s, nil
+}
+
+// flush publishes the buffered records and advances the offsets of the topics to the last change received, the
+// offsets are saved at most once per checkpoint interval.
+func (c *connector) flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for topic, records := range c.pending {
+		for i := 0; i < len(records); i += c.sink.cfg.BatchSize {
+			end := i + c.sink.cfg.BatchSize
+			if end > len(records) {
+				end = len(records)
+			}
+			if err := c.sink.producer.Produce(ctx, topic, records[i:end]); err != nil {
+				return err
+			}
+		}
+		c.offsets[topic] = c.last
+		delete(c.pending, topic)
+		c.dirty = true
+	}
+	c.buffered = 0
+
+	if c.last != nil {
+		for topic, offset := range c.offsets {
+			if c.last.After(offset) {
+				c.offsets[topic] = c.last
+				c.dirty = true
+			}
+		}
+	}
+
+	if !c.dirty || time.Since(c.saved) < c.sink.cfg.CheckpointInterval {
+		return nil
+	}
+	if err := c.sink.checkpoints.Save(ctx, c.project, c.branch, c.offsets); err != nil {
+		return err
+	}
+	c.dirty, c.saved = false, time.Now()
+
+	return nil
+}
+
+// discard drops the buffered records after a failure, they are published again from the offsets.
+func (c *connector) discard() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = make(map[string][]kafka.Record)
+	c.buffered = 0
+}
@@ -0,0 +1,181 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/lib/kafka"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/cdc"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func testCollection(t *testing.T) *schema.DefaultCollection {
+	factory, err := schema.NewFactoryBuilder(true).Build("orders", []byte(`{
+		"title": "orders",
+		"properties": {
+			"id": {"type": "integer"},
+			"total": {"type": "number"},
+			"paid": {"type": "boolean"},
+			"status": {"type": "string"},
+			"created": {"type": "string", "format": "date-time"},
+			"items": {"type": "array", "items": {"type": "string"}}
+		},
+		"primary_key": ["id"],
+		"kafka": [{"topic": "orders"}, {"topic": "orders.avro", "key": "none", "format": "avro"}]
+	}`))
+	require.NoError(t, err)
+
+	coll, err := schema.NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	return coll
+}
+
+func TestAvroSchema(t *testing.T) {
+	s, err := newAvroSchema("orders", testCollection(t).Schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "record", "name": "orders", "fields": [
+		{"name": "id", "type": ["null", "long"], "default": null},
+		{"name": "total", "type": ["null", "double"], "default": null},
+		{"name": "paid", "type": ["null", "boolean"], "default": null},
+		{"name": "status", "type": ["null", "string"], "default": null},
+		{"name": "created", "type": ["null", {"type": "long", "logicalType": "timestamp-micros"}], "default": null},
+		{"name": "items", "type": ["null", "string"], "default": null}
+	]}`, string(s.json))
+
+	b, err := s.encode([]byte(`{"id": -3, "total": 1.5, "paid": true, "status": "new\"", "created": "2023-01-02T03:04:05.000006Z", "items": ["a"]}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{0xc3, 0x01}, b[:2])
+	require.Equal(t, s.fingerprint, binary.LittleEndian.Uint64(b[2:]))
+	b = b[10:]
+
+	varint := func() int64 {
+		v, n := binary.Varint(b)
+		b = b[n:]
+		return v
+	}
+	str := func() string {
+		n := varint()
+		v := string(b[:n])
+		b = b[n:]
+		return v
+	}
+
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, int64(-3), varint())
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, 1.5, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	b = b[8:]
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, byte(1), b[0])
+	b = b[1:]
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, `new"`, str())
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 6000, time.UTC).UnixMicro(), varint())
+	require.Equal(t, int64(1), varint())
+	require.Equal(t, `["a"]`, str())
+	require.Empty(t, b)
+
+	b, err = s.encode([]byte(`{"id": 1}`))
+	require.NoError(t, err)
+	require.Equal(t, []byte{2, 2, 0, 0, 0, 0, 0}, b[10:])
+
+	require.Equal(t, "a_b_1", avroName("a$b_1"))
+	require.Equal(t, "_1", avroName("11"))
+}
+
+type testProducer struct {
+	records map[string][]kafka.Record
+	err     error
+}
+
+func (p *testProducer) Produce(_ context.Context, topic string, records []kafka.Record) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.records[topic] = append(p.records[topic], records...)
+
+	return nil
+}
+
+type testCheckpoints struct {
+	saved map[string]*cdc.ResumeToken
+}
+
+func (*testCheckpoints) Load(context.Context, string, string) (map[string]*cdc.ResumeToken, error) {
+	return nil, nil
+}
+
+func (c *testCheckpoints) Save(_ context.Context, _ string, _ string, offsets map[string]*cdc.ResumeToken) error {
+	c.saved = make(map[string]*cdc.ResumeToken)
+	for topic, token := range offsets {
+		c.saved[topic] = token
+	}
+
+	return nil
+}
+
+func token(version byte, index uint32) *cdc.ResumeToken {
+	return &cdc.ResumeToken{Version: tuple.Versionstamp{TransactionVersion: [10]byte{0, 0, 0, 0, 0, 0, 0, version}}, Index: index}
+}
+
+func TestConnector(t *testing.T) {
+	coll := testCollection(t)
+	producer := &testProducer{records: make(map[string][]kafka.Record)}
+	checkpoints := &testCheckpoints{}
+	cfg := config.KafkaConfig{BatchSize: 10}
+	c := &connector{
+		sink:    &Sink{cfg: cfg, producer: producer, checkpoints: checkpoints},
+		ctx:     context.Background(),
+		offsets: map[string]*cdc.ResumeToken{"orders": token(1, 1)},
+		pending: make(map[string][]kafka.Record),
+		schemas: make(map[string]*avroSchema),
+	}
+
+	insert := &cdc.Change{Op: "insert", Collection: "orders", Key: []byte(`[1]`), After: []byte(`{"id": 1}`)}
+	del := &cdc.Change{Op: "delete", Collection: "orders", Key: []byte(`[1]`), Before: []byte(`{"id": 1}`)}
+
+	// the json topic already has the first change
+	require.NoError(t, c.send(coll, insert, token(1, 1)))
+	require.NoError(t, c.send(coll, del, token(2, 0)))
+	require.Empty(t, producer.records)
+	require.Equal(t, 3, c.buffered)
+
+	require.NoError(t, c.flush(context.Background()))
+	require.Len(t, producer.records["orders"], 1)
+	require.Equal(t, []byte(`[1]`), producer.records["orders"][0].Key)
+	require.JSONEq(t, `{"op": "delete", "collection": "orders", "key": [1], "before": {"id": 1}}`, string(producer.records["orders"][0].Value))
+	require.Equal(t, []kafka.Header{{Key: HeaderOp, Value: []byte("delete")}, {Key: HeaderCollection, Value: []byte("orders")}}, producer.records["orders"][0].Headers)
+
+	avro := producer.records["orders.avro"]
+	require.Len(t, avro, 2)
+	require.Nil(t, avro[0].Key)
+	require.NotNil(t, avro[0].Value)
+	require.Equal(t, HeaderAvroSchema, avro[0].Headers[2].Key)
+	require.Nil(t, avro[1].Value)
+
+	require.Equal(t, map[string]*cdc.ResumeToken{"orders": token(2, 0), "orders.avro": token(2, 0)}, checkpoints.saved)
+	require.Equal(t, token(2, 0), c.from())
+}
@@ -0,0 +1,150 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doccache
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/cache"
+)
+
+// redisTable is the table of the documents cached in Redis.
+const redisTable = "doc_cache"
+
+// Backend stores the cached documents.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, entry []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+	// Purge drops all the cached documents
+	Purge(ctx context.Context)
+}
+
+func newBackend(cfg *config.Config) Backend {
+	if cfg.DocumentCache.Backend == config.DocumentCacheRedis {
+		return &redisBackend{cache: cache.NewCache(&cfg.Cache)}
+	}
+
+	return NewMemoryBackend(cfg.DocumentCache.Size)
+}
+
+// memoryBackend caches the documents in the memory of the node, the least recently used documents are evicted once
+// the cache is full.
+type memoryBackend struct {
+	lru gcache.Cache
+}
+
+// NewMemoryBackend returns the backend caching up to size documents in memory.
+func NewMemoryBackend(size int) Backend {
+	return &memoryBackend{lru: gcache.New(size).LRU().Build()}
+}
+
+func (m *memoryBackend) Get(_ context.Context, key string) ([]byte, bool) {
+	v, err := m.lru.Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	return v.([]byte), true
+}
+
+func (m *memoryBackend) Set(_ context.Context, key string, entry []byte, ttl time.Duration) {
+	if ttl > 0 {
+		_ = m.lru.SetWithExpire(key, entry, ttl)
+	} else {
+		_ = m.lru.Set(key, entry)
+	}
+}
+
+func (m *memoryBackend) Delete(_ context.Context, keys ...string) {
+	for _, k := range keys {
+		m.lru.Remove(k)
+	}
+}
+
+func (m *memoryBackend) Purge(context.Context) {
+	m.lru.Purge()
+}
+
+// redisBackend caches the documents in Redis, shared by all the nodes. The keys are hex encoded as the cache keys
+// contain the separators of the Redis keys.
+type redisBackend struct {
+	cache cache.Cache
+}
+
+func (r *redisBackend) Get(ctx context.Context, key string) ([]byte, bool) {
+	data, err := r.cache.Get(ctx, redisTable, hex.EncodeToString([]byte(key)), nil)
+	if err != nil {
+		if err != cache.ErrKeyNotFound {
+			log.Err(err).Msg("Failed to read the document cache")
+		}
+		return nil, false
+	}
+
+	return data.RawData, true
+}
+
+func (r *redisBackend) Set(ctx context.Context, key string, entry []byte, ttl time.Duration) {
+	err := r.cache.Set(ctx, redisTable, hex.EncodeToString([]byte(key)), internal.NewCacheData(entry),
+		&cache.SetOptions{PX: uint64(ttl.Milliseconds())})
+	if err != nil {
+		log.Err(err).Msg("Failed to fill the document cache")
+	}
+}
+
+func (r *redisBackend) Delete(ctx context.Context, keys ...string) {
+	encoded := make([]string, len(keys))
+	for i, k := range keys {
+		encoded[i] = hex.EncodeToString([]byte(k))
+	}
+
+	if _, err := r.cache.Delete(ctx, redisTable, encoded...); err != nil {
+		log.Err(err).Msg("Failed to invalidate the document cache")
+	}
+}
+
+func (r *redisBackend) Purge(ctx context.Context) {
+	var (
+		keys   []string
+		cursor uint64
+		err    error
+	)
+	for {
+		if keys, cursor, err = r.cache.Scan(ctx, redisTable, cursor, config.DefaultConfig.Cache.MaxScan, "*"); err != nil {
+			log.Err(err).Msg("Failed to purge the document cache")
+			return
+		}
+		for i := range keys {
+			keys[i] = strings.TrimPrefix(keys[i], redisTable+":")
+		}
+
+		if len(keys) > 0 {
+			if _, err := r.cache.Delete(ctx, redisTable, keys...); err != nil {
+				log.Err(err).Msg("Failed to purge the document cache")
+			}
+		}
+
+		if cursor == 0 {
+			return
+		}
+	}
+}
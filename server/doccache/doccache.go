@@ -0,0 +1,286 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doccache caches the documents read by their primary key outside of the explicit transactions, so that the
+// reads of the hot documents don't all go to the storage. The documents are cached on the read and invalidated after
+// the writes changing them commit, on the node by the write path and on the other nodes by the change streams of the
+// databases of the cached documents.
+//
+// A read that misses the cache takes a token before reading the document, the document is only cached if none of the
+// documents of the stripe of its key is invalidated since. So a document read before a write commits is never cached
+// after the invalidation of the write.
+package doccache
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/cdc"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+const (
+	stripes = 1024
+
+	sourceWrite = "write"
+	sourceCDC   = "cdc"
+)
+
+// Collection identifies the collection of the documents looked up in the cache.
+type Collection struct {
+	NamespaceId uint32
+	Namespace   string
+	Project     string
+	Branch      string
+	// Database is the name of the database branch, its changes are streamed to invalidate the documents
+	Database string
+	Name     string
+}
+
+// Token is taken by the lookup missing the cache, the document read after the lookup is only cached if the
+// documents of the stripe of its key aren't invalidated in between.
+type Token struct {
+	stripe int
+	gen    uint64
+	valid  bool
+}
+
+// Cache is the read-through cache of the documents keyed by their primary key.
+type Cache struct {
+	cfg     atomic.Pointer[config.DocumentCacheConfig]
+	backend Backend
+	gens    [stripes]atomic.Uint64
+	watcher *cdc.Watcher
+}
+
+// NewCache returns the cache of the documents stored by the backend. The documents are only invalidated by the
+// writes done on this node unless Watch is called.
+func NewCache(cfg *config.DocumentCacheConfig, backend Backend) *Cache {
+	c := &Cache{backend: backend}
+	c.cfg.Store(cfg)
+
+	return c
+}
+
+// Watch invalidates the documents changed on the other nodes by streaming the changes of the databases of the cached
+// documents.
+func (c *Cache) Watch(kvStore kv.TxStore, cdcMgr *cdc.Manager) {
+	c.watcher = cdcMgr.NewWatcher(kvStore, func(ctx context.Context, tx cdc.Tx) {
+		c.invalidate(ctx, sourceCDC, tx.Ops)
+	}, c.purge)
+}
+
+// watch makes sure the changes of the database of the collection are streamed, the documents of the collection must
+// not be cached otherwise.
+func (c *Cache) watch(coll *Collection) bool {
+	if c.watcher == nil {
+		return true
+	}
+
+	if err := c.watcher.Watch(coll.NamespaceId, coll.Database); err != nil {
+		log.Err(err).Str("database", coll.Database).Msg("Failed to stream the changes for the document cache")
+		return false
+	}
+
+	return true
+}
+
+var docCache *Cache
+
+// Init enables the document cache, if it's enabled by the configuration.
+func Init(cfg *config.Config, kvStore kv.TxStore, cdcMgr *cdc.Manager) {
+	if !cfg.DocumentCache.Enabled {
+		return
+	}
+
+	c := NewCache(&cfg.DocumentCache, newBackend(cfg))
+	if cfg.Cdc.Enabled {
+		c.Watch(kvStore, cdcMgr)
+	}
+	docCache = c
+
+	config.OnReload("document_cache", func(c *config.Config) any {
+		return []any{c.DocumentCache.Default, c.DocumentCache.Collections, c.DocumentCache.TTL}
+	}, func(next *config.Config) error {
+		// the backend and the size take effect on restart
+		c.cfg.Store(&next.DocumentCache)
+		return nil
+	})
+
+	log.Info().Str("backend", cfg.DocumentCache.Backend).Msg("Initialized document cache")
+}
+
+// Get returns the cache of the documents of the collection, nil if the collection isn't cached.
+func Get(coll *Collection) *Cache {
+	if docCache == nil || !docCache.cfg.Load().CollectionEnabled(coll.Namespace, coll.Project, coll.Name) {
+		return nil
+	}
+
+	return docCache
+}
+
+// cacheKey is the key of the document in the backend. The key is built from the JSON of the primary key, the way the
+// key of a change is decoded from the change stream, so that the changes received from the stream invalidate the
+// same documents. Distinct keys may map to the same cache key, so the exact key is stored with the document.
+func cacheKey(table []byte, parts []any) (string, error) {
+	enc, err := jsoniter.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+
+	var decoded []any
+	if err = jsoniter.Unmarshal(enc, &decoded); err != nil {
+		return "", err
+	}
+
+	if enc, err = jsoniter.Marshal(decoded); err != nil {
+		return "", err
+	}
+
+	return string(table) + "\x00" + string(enc), nil
+}
+
+func eventKey(event *kv.Event) (string, error) {
+	parts := make([]any, len(event.Key))
+	for i, p := range event.Key {
+		parts[i] = p
+	}
+
+	return cacheKey(event.Table, parts)
+}
+
+func stripe(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % stripes)
+}
+
+// encodeEntry prefixes the encoded document with its exact key.
+func encodeEntry(exact []byte, doc *internal.TableData) ([]byte, error) {
+	enc, err := internal.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(exact)+len(enc)), uint64(len(exact)))
+	entry = append(entry, exact...)
+
+	return append(entry, enc...), nil
+}
+
+// decodeEntry returns the document of the entry if the entry is for the exact key.
+func decodeEntry(exact []byte, entry []byte) (*internal.TableData, bool) {
+	n, l := binary.Uvarint(entry)
+	if l <= 0 || uint64(len(entry)-l) < n || string(entry[l:l+int(n)]) != string(exact) {
+		return nil, false
+	}
+
+	doc, err := internal.Decode(entry[l+int(n):])
+	if err != nil {
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// Lookup returns the cached document of the key, nil if the document isn't cached. The token of the lookup is passed
+// to Fill to cache the document read from the storage.
+func (c *Cache) Lookup(ctx context.Context, coll *Collection, key keys.Key) (*internal.TableData, Token) {
+	ck, err := cacheKey(key.Table(), key.IndexParts())
+	if err != nil {
+		return nil, Token{}
+	}
+
+	s := stripe(ck)
+	token := Token{stripe: s, gen: c.gens[s].Load(), valid: c.watch(coll)}
+
+	if entry, ok := c.backend.Get(ctx, ck); ok {
+		if doc, ok := decodeEntry(key.SerializeToBytes(), entry); ok {
+			metrics.DocumentCacheLookup(coll.Namespace, coll.Project, coll.Branch, coll.Name, true)
+			return doc, token
+		}
+	}
+	metrics.DocumentCacheLookup(coll.Namespace, coll.Project, coll.Branch, coll.Name, false)
+
+	return nil, token
+}
+
+// Fill caches the document read from the storage after the lookup of the token, unless the documents of the stripe
+// of the key have been invalidated since.
+func (c *Cache) Fill(ctx context.Context, key keys.Key, doc *internal.TableData, token Token) {
+	if !token.valid || c.gens[token.stripe].Load() != token.gen {
+		return
+	}
+
+	ck, err := cacheKey(key.Table(), key.IndexParts())
+	if err != nil {
+		return
+	}
+
+	entry, err := encodeEntry(key.SerializeToBytes(), doc)
+	if err != nil {
+		return
+	}
+
+	c.backend.Set(ctx, ck, entry, c.cfg.Load().TTL)
+
+	// the invalidation racing with the fill bumps the stripe before deleting the key, so either the invalidation
+	// deletes the document or it is deleted here
+	if c.gens[token.stripe].Load() != token.gen {
+		c.backend.Delete(ctx, ck)
+	}
+}
+
+// invalidate drops the documents of the keys changed by a committed write.
+func (c *Cache) invalidate(ctx context.Context, source string, events []*kv.Event) {
+	cks := make([]string, 0, len(events))
+	for _, event := range events {
+		if event.Key == nil || event.Op == kv.OutboxEvent {
+			// event.Key == nil if event comes from drop table, the outbox events don't change the documents
+			continue
+		}
+
+		ck, err := eventKey(event)
+		if err != nil {
+			log.Err(err).Msg("Failed to build the document cache key of the change")
+			continue
+		}
+
+		c.gens[stripe(ck)].Add(1)
+		cks = append(cks, ck)
+	}
+
+	if len(cks) > 0 {
+		c.backend.Delete(ctx, cks...)
+		metrics.DocumentCacheInvalidated(source, len(cks))
+	}
+}
+
+// purge drops all the documents, it's done when the changes of a database may have been missed.
+func (c *Cache) purge(ctx context.Context) {
+	for i := range c.gens {
+		c.gens[i].Add(1)
+	}
+
+	c.backend.Purge(ctx)
+}
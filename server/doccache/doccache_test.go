@@ -0,0 +1,117 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doccache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func testCache() *Cache {
+	return NewCache(&config.DocumentCacheConfig{Enabled: true, Default: true, TTL: time.Minute}, NewMemoryBackend(10))
+}
+
+func TestCacheFill(t *testing.T) {
+	ctx := context.Background()
+	c := testCache()
+	coll := &Collection{Namespace: "ns1", Project: "p1", Branch: "main", Name: "c1"}
+	key := keys.NewKey([]byte("t1"), int64(1))
+
+	doc, token := c.Lookup(ctx, coll, key)
+	require.Nil(t, doc)
+
+	c.Fill(ctx, key, internal.NewTableData([]byte(`{"a":1}`)), token)
+	doc, _ = c.Lookup(ctx, coll, key)
+	require.NotNil(t, doc)
+	require.Equal(t, []byte(`{"a":1}`), doc.RawData)
+
+	// the other keys of the table aren't cached
+	doc, _ = c.Lookup(ctx, coll, keys.NewKey([]byte("t1"), int64(2)))
+	require.Nil(t, doc)
+
+	c.invalidate(ctx, sourceWrite, []*kv.Event{{Op: kv.UpdateEvent, Table: []byte("t1"), Key: kv.BuildKey(int64(1))}})
+	doc, _ = c.Lookup(ctx, coll, key)
+	require.Nil(t, doc)
+}
+
+func TestCacheFillRace(t *testing.T) {
+	ctx := context.Background()
+	c := testCache()
+	coll := &Collection{Namespace: "ns1", Project: "p1", Branch: "main", Name: "c1"}
+	key := keys.NewKey([]byte("t1"), "id1")
+
+	// the document is read before the write commits and is filled after its invalidation
+	_, token := c.Lookup(ctx, coll, key)
+	c.invalidate(ctx, sourceWrite, []*kv.Event{{Op: kv.DeleteEvent, Table: []byte("t1"), Key: kv.BuildKey("id1")}})
+	c.Fill(ctx, key, internal.NewTableData([]byte(`{"a":1}`)), token)
+
+	doc, _ := c.Lookup(ctx, coll, key)
+	require.Nil(t, doc)
+
+	// the token taken by the lookup after the invalidation fills the cache
+	_, token = c.Lookup(ctx, coll, key)
+	c.Fill(ctx, key, internal.NewTableData([]byte(`{"a":2}`)), token)
+
+	doc, _ = c.Lookup(ctx, coll, key)
+	require.Equal(t, []byte(`{"a":2}`), doc.RawData)
+
+	c.purge(ctx)
+	doc, _ = c.Lookup(ctx, coll, key)
+	require.Nil(t, doc)
+}
+
+func TestCacheChangeStreamKeys(t *testing.T) {
+	ctx := context.Background()
+	c := testCache()
+	coll := &Collection{Namespace: "ns1", Project: "p1", Branch: "main", Name: "c1"}
+	key := keys.NewKey([]byte("t1"), int64(5), []byte{1, 2})
+
+	_, token := c.Lookup(ctx, coll, key)
+	c.Fill(ctx, key, internal.NewTableData([]byte(`{"a":1}`)), token)
+
+	// the keys of the events received from the change streams are decoded from JSON
+	enc, err := jsoniter.Marshal(&kv.Event{Op: kv.ReplaceEvent, Table: []byte("t1"), Key: kv.BuildKey(int64(5), []byte{1, 2})})
+	require.NoError(t, err)
+
+	var event kv.Event
+	require.NoError(t, jsoniter.Unmarshal(enc, &event))
+
+	c.invalidate(ctx, sourceCDC, []*kv.Event{&event})
+	doc, _ := c.Lookup(ctx, coll, key)
+	require.Nil(t, doc)
+}
+
+func TestCacheEntry(t *testing.T) {
+	entry, err := encodeEntry([]byte("key1"), internal.NewTableData([]byte(`{"a":1}`)))
+	require.NoError(t, err)
+
+	doc, ok := decodeEntry([]byte("key1"), entry)
+	require.True(t, ok)
+	require.Equal(t, []byte(`{"a":1}`), doc.RawData)
+
+	_, ok = decodeEntry([]byte("key2"), entry)
+	require.False(t, ok)
+
+	_, ok = decodeEntry([]byte("key1"), entry[:3])
+	require.False(t, ok)
+}
@@ -0,0 +1,40 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doccache
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// Invalidator drops the cached documents changed by the transactions committed on this node, it is registered as a
+// listener of the transactions once the cache is initialized.
+type Invalidator struct{}
+
+func (*Invalidator) OnPreCommit(context.Context, *metadata.Tenant, transaction.Tx, kv.EventListener) error {
+	return nil
+}
+
+func (*Invalidator) OnPostCommit(ctx context.Context, _ *metadata.Tenant, listener kv.EventListener) error {
+	if docCache != nil {
+		docCache.invalidate(ctx, sourceWrite, listener.GetEvents())
+	}
+	return nil
+}
+
+func (*Invalidator) OnRollback(context.Context, *metadata.Tenant, kv.EventListener) {}
@@ -0,0 +1,123 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expiration
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// DeleteBranchFunc deletes an expired branch.
+type DeleteBranchFunc func(ctx context.Context, r *api.DeleteBranchRequest) (*api.DeleteBranchResponse, error)
+
+// expiredBranch is an ephemeral branch past its expiration time.
+type expiredBranch struct {
+	namespace string
+	project   string
+	branch    string
+}
+
+// BranchReaper deletes the ephemeral branches once they expire. The branches are deleted through the DeleteBranch API,
+// which drops their collections, secondary indexes and search indexes like for any other deleted branch.
+type BranchReaper struct {
+	cfg     config.BranchExpirationConfig
+	tenants metadata.TenantGetter
+	delete  DeleteBranchFunc
+	now     func() time.Time
+}
+
+func NewBranchReaper(cfg config.BranchExpirationConfig, tenants metadata.TenantGetter, deleteFn DeleteBranchFunc) *BranchReaper {
+	return &BranchReaper{
+		cfg:     cfg,
+		tenants: tenants,
+		delete:  deleteFn,
+		now:     time.Now,
+	}
+}
+
+func (r *BranchReaper) Start() {
+	if r.cfg.Enabled {
+		go r.loop()
+	}
+}
+
+func (r *BranchReaper) loop() {
+	log.Info().Dur("interval", r.cfg.Interval).Msg("Starting ephemeral branch expiration")
+	t := time.NewTicker(r.cfg.Interval)
+	defer t.Stop()
+	for range t.C {
+		r.scan(context.Background())
+	}
+}
+
+// scan deletes the expired branches of all the tenants.
+func (r *BranchReaper) scan(ctx context.Context) {
+	for _, b := range r.expired(ctx) {
+		if err := r.reap(ctx, b); err != nil {
+			log.Err(err).Str("ns", b.namespace).Str("project", b.project).Str("branch", b.branch).
+				Msg("failed to delete expired branch")
+		}
+	}
+}
+
+// expired returns the ephemeral branches of all the tenants past their expiration time.
+func (r *BranchReaper) expired(ctx context.Context) []expiredBranch {
+	now := r.now().Unix()
+
+	var branches []expiredBranch
+	for _, tenant := range r.tenants.AllTenants(ctx) {
+		for _, name := range tenant.ListProjects(ctx) {
+			project, err := tenant.GetProject(name)
+			if err != nil {
+				continue
+			}
+
+			for _, db := range project.GetDatabaseWithBranches() {
+				if expiresAt := db.ExpiresAt(); expiresAt > 0 && expiresAt <= now {
+					branches = append(branches, expiredBranch{
+						namespace: tenant.GetNamespace().StrId(),
+						project:   name,
+						branch:    db.BranchName(),
+					})
+				}
+			}
+		}
+	}
+
+	return branches
+}
+
+// reap deletes the branch. A branch already deleted, e.g. by another server, is not an error.
+func (r *BranchReaper) reap(ctx context.Context, b expiredBranch) error {
+	md := request.Metadata{}
+	md.SetNamespace(ctx, b.namespace)
+	ctx = md.SaveToContext(ctx)
+
+	_, err := r.delete(ctx, &api.DeleteBranchRequest{Project: b.project, Branch: b.branch})
+	if err != nil && api.FromStatusError(err).Code == api.Code_NOT_FOUND {
+		return nil
+	}
+	if err == nil {
+		log.Info().Str("ns", b.namespace).Str("project", b.project).Str("branch", b.branch).Msg("deleted expired branch")
+	}
+
+	return err
+}
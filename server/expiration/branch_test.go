@@ -0,0 +1,54 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expiration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+func TestBranchReaper(t *testing.T) {
+	var (
+		requests []*api.DeleteBranchRequest
+		err      error
+	)
+	deleteFn := func(ctx context.Context, r *api.DeleteBranchRequest) (*api.DeleteBranchResponse, error) {
+		ns, nsErr := request.GetNamespace(ctx)
+		require.NoError(t, nsErr)
+		require.Equal(t, "ns1", ns)
+
+		requests = append(requests, r)
+		return &api.DeleteBranchResponse{}, err
+	}
+
+	r := NewBranchReaper(config.BranchExpirationConfig{Enabled: true}, nil, deleteFn)
+	b := expiredBranch{namespace: "ns1", project: "p1", branch: "ci-1"}
+
+	require.NoError(t, r.reap(context.Background(), b))
+	require.Equal(t, []*api.DeleteBranchRequest{{Project: "p1", Branch: "ci-1"}}, requests)
+
+	// the branch was deleted by another server
+	err = errors.NotFound("branch doesn't exist")
+	require.NoError(t, r.reap(context.Background(), b))
+
+	err = errors.Internal("failed")
+	require.Equal(t, errors.Internal("failed"), r.reap(context.Background(), b))
+}
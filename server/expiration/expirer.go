@@ -0,0 +1,180 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expiration deletes the documents expired by the TTL indexes and the expired ephemeral branches. A TTL index
+// is a secondary index over a date-time field with the "expireAfter" attribute, the documents are deleted in the
+// background once the value of the field is older than the duration. The deletes go through the Delete API in small
+// batches, each in its own transaction, so the expired documents are removed from the secondary and the search indexes
+// like any other delete.
+package expiration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// DeleteFunc deletes the expired documents of a collection.
+type DeleteFunc func(ctx context.Context, r *api.DeleteRequest) (*api.DeleteResponse, error)
+
+// target is a collection with an active TTL index.
+type target struct {
+	namespace   string
+	project     string
+	branch      string
+	collection  string
+	field       string
+	expireAfter time.Duration
+}
+
+func (t target) key() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", t.namespace, t.project, t.branch, t.collection, t.field)
+}
+
+// Expirer scans the collections with the TTL indexes periodically and deletes their expired documents.
+type Expirer struct {
+	cfg     config.ExpirationConfig
+	tenants metadata.TenantGetter
+	delete  DeleteFunc
+	now     func() time.Time
+	// caughtUp is the start of the last scan of a TTL index which deleted all the documents expired by then, the
+	// expiration lag is measured from it.
+	caughtUp map[string]time.Time
+}
+
+func NewExpirer(cfg config.ExpirationConfig, tenants metadata.TenantGetter, deleteFn DeleteFunc) *Expirer {
+	return &Expirer{
+		cfg:      cfg,
+		tenants:  tenants,
+		delete:   deleteFn,
+		now:      time.Now,
+		caughtUp: make(map[string]time.Time),
+	}
+}
+
+func (e *Expirer) Start() {
+	if e.cfg.Enabled {
+		go e.loop()
+	}
+}
+
+func (e *Expirer) loop() {
+	log.Info().Dur("interval", e.cfg.Interval).Msg("Starting TTL index expiration")
+	t := time.NewTicker(e.cfg.Interval)
+	defer t.Stop()
+	for range t.C {
+		e.scan(context.Background())
+	}
+}
+
+// scan deletes the expired documents of all the collections with the TTL indexes.
+func (e *Expirer) scan(ctx context.Context) {
+	for _, t := range e.targets(ctx) {
+		if _, err := e.expire(ctx, t); err != nil {
+			log.Err(err).Str("ns", t.namespace).Str("project", t.project).Str("branch", t.branch).
+				Str("collection", t.collection).Msg("failed to delete expired documents")
+		}
+	}
+}
+
+// targets returns the active TTL indexes of the collections of all the tenants, the indexes still being built are
+// skipped as the scan would read the whole collection.
+func (e *Expirer) targets(ctx context.Context) []target {
+	var targets []target
+	for _, tenant := range e.tenants.AllTenants(ctx) {
+		for _, name := range tenant.ListProjects(ctx) {
+			project, err := tenant.GetProject(name)
+			if err != nil {
+				continue
+			}
+
+			for _, db := range project.GetDatabaseWithBranches() {
+				for _, coll := range db.ListCollection() {
+					for _, index := range coll.SecondaryIndexes.All {
+						if index.ExpireAfter <= 0 || index.State != schema.INDEX_ACTIVE {
+							continue
+						}
+
+						targets = append(targets, target{
+							namespace:   tenant.GetNamespace().StrId(),
+							project:     name,
+							branch:      db.BranchName(),
+							collection:  coll.Name,
+							field:       index.Name,
+							expireAfter: index.ExpireAfter,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return targets
+}
+
+// expire deletes the documents of the collection that expired by the start of the scan. It stops after the
+// configured number of batches, the rest is deleted by the next scans.
+func (e *Expirer) expire(ctx context.Context, t target) (int64, error) {
+	start := e.now().UTC()
+	cutoff := start.Add(-t.expireAfter)
+
+	md := request.Metadata{}
+	md.SetNamespace(ctx, t.namespace)
+	ctx = md.SaveToContext(ctx)
+
+	var (
+		deleted  int64
+		complete bool
+	)
+	for i := 0; i < e.cfg.MaxBatches && !complete; i++ {
+		resp, err := e.delete(ctx, &api.DeleteRequest{
+			Project:    t.project,
+			Branch:     t.branch,
+			Collection: t.collection,
+			Filter:     []byte(fmt.Sprintf(`{"%s": {"$lt": "%s"}}`, t.field, cutoff.Format(time.RFC3339Nano))),
+			Options:    &api.DeleteRequestOptions{Limit: int64(e.cfg.BatchSize)},
+		})
+		if err != nil {
+			metrics.ExpirationFailed(t.project, t.branch, t.collection)
+			return deleted, err
+		}
+
+		deleted += int64(resp.DeletedCount)
+		complete = resp.DeletedCount < int32(e.cfg.BatchSize)
+	}
+
+	metrics.UpdateExpirationMetrics(t.project, t.branch, t.collection, deleted, e.lag(t, start, complete))
+
+	return deleted, nil
+}
+
+// lag returns the time since the start of the last scan which caught up with the expired documents of the index.
+func (e *Expirer) lag(t target, start time.Time, complete bool) time.Duration {
+	caughtUp, ok := e.caughtUp[t.key()]
+	if complete || !ok {
+		// the first scan of an index is the reference until it catches up
+		caughtUp = start
+		e.caughtUp[t.key()] = start
+	}
+
+	return e.now().UTC().Sub(caughtUp)
+}
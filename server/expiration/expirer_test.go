@@ -0,0 +1,118 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expiration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// fakeCollection deletes the documents with the expiry time before the filter.
+type fakeCollection struct {
+	expired  int
+	requests []*api.DeleteRequest
+	err      error
+}
+
+func (c *fakeCollection) delete(ctx context.Context, r *api.DeleteRequest) (*api.DeleteResponse, error) {
+	if _, err := request.GetNamespace(ctx); err != nil {
+		return nil, err
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.requests = append(c.requests, r)
+	deleted := c.expired
+	if int64(deleted) > r.Options.Limit {
+		deleted = int(r.Options.Limit)
+	}
+	c.expired -= deleted
+
+	return &api.DeleteResponse{DeletedCount: int32(deleted)}, nil
+}
+
+func TestExpirer(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.ExpirationConfig{Enabled: true, Interval: time.Minute, BatchSize: 10, MaxBatches: 3}
+	tgt := target{namespace: "ns1", project: "p1", branch: "main", collection: "sessions", field: "seen_at", expireAfter: time.Hour}
+
+	newExpirer := func(coll *fakeCollection) *Expirer {
+		e := NewExpirer(cfg, nil, coll.delete)
+		e.now = func() time.Time { return now }
+		return e
+	}
+
+	t.Run("batches", func(t *testing.T) {
+		coll := &fakeCollection{expired: 25}
+		e := newExpirer(coll)
+
+		deleted, err := e.expire(context.Background(), tgt)
+		require.NoError(t, err)
+		require.Equal(t, int64(25), deleted)
+		require.Len(t, coll.requests, 3)
+		for _, r := range coll.requests {
+			require.Equal(t, "p1", r.Project)
+			require.Equal(t, "main", r.Branch)
+			require.Equal(t, "sessions", r.Collection)
+			require.Equal(t, int64(10), r.Options.Limit)
+			require.JSONEq(t, `{"seen_at": {"$lt": "2023-05-01T11:00:00Z"}}`, string(r.Filter))
+		}
+		require.Zero(t, e.lag(tgt, now, true))
+	})
+
+	t.Run("backlog", func(t *testing.T) {
+		coll := &fakeCollection{expired: 45}
+		e := newExpirer(coll)
+
+		start := now
+		deleted, err := e.expire(context.Background(), tgt)
+		require.NoError(t, err)
+		require.Equal(t, int64(30), deleted)
+		require.Equal(t, start, e.caughtUp[tgt.key()])
+
+		// the next scan catches up
+		now = now.Add(time.Minute)
+		deleted, err = e.expire(context.Background(), tgt)
+		require.NoError(t, err)
+		require.Equal(t, int64(15), deleted)
+		require.Equal(t, now, e.caughtUp[tgt.key()])
+	})
+
+	t.Run("lag", func(t *testing.T) {
+		e := newExpirer(&fakeCollection{})
+		start := now
+		require.Zero(t, e.lag(tgt, start, false))
+
+		now = now.Add(5 * time.Minute)
+		require.Equal(t, 5*time.Minute, e.lag(tgt, now, false))
+		require.Zero(t, e.lag(tgt, now, true))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		coll := &fakeCollection{expired: 5, err: errors.Internal("failed")}
+		e := newExpirer(coll)
+
+		_, err := e.expire(context.Background(), tgt)
+		require.Equal(t, errors.Internal("failed"), err)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/cache"
+	"github.com/tigrisdata/tigris/store/search"
+)
+
+// probeName is the search index and the cache table looked up by the checks, they don't exist and the lookups only
+// check that the backends answer.
+const probeName = "_tigris_health_probe"
+
+// FDB checks FoundationDB by reading the metadata version in its own transaction.
+func FDB(cfg config.HealthConfig, txMgr *transaction.Manager) *Dependency {
+	versionH := &metadata.VersionHandler{}
+
+	return &Dependency{
+		Name:             "fdb",
+		Critical:         true,
+		LatencyThreshold: cfg.FDBLatencyThreshold,
+		Check: func(ctx context.Context) error {
+			_, err := versionH.ReadInOwnTxn(ctx, txMgr, false)
+			return err
+		},
+	}
+}
+
+// Search checks the search backend by describing a missing index.
+func Search(cfg config.HealthConfig, store search.Store) *Dependency {
+	return &Dependency{
+		Name:             "search",
+		LatencyThreshold: cfg.LatencyThreshold,
+		Check: func(ctx context.Context) error {
+			if _, err := store.DescribeCollection(ctx, probeName); err != nil && !search.IsErrNotFound(err) {
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// Cache checks the cache by looking up a missing key.
+func Cache(cfg config.HealthConfig, c cache.Cache) *Dependency {
+	return &Dependency{
+		Name:             "cache",
+		LatencyThreshold: cfg.LatencyThreshold,
+		Check: func(ctx context.Context) error {
+			_, err := c.Exists(ctx, probeName, probeName)
+			return err
+		},
+	}
+}
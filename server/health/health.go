@@ -0,0 +1,260 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health checks the dependencies of the server, FoundationDB, the search backend and the cache, for the
+// /healthz and the /readyz endpoints and the gRPC health service. The dependencies are checked periodically in the
+// background, so the endpoints answer from the last check and don't load the dependencies.
+//
+// A dependency is down if its check fails or times out and degraded if it is slower than its latency threshold. The
+// server is down if a critical dependency is down, FoundationDB being the only one, and degraded if any other
+// dependency is not ok: a degraded server still serves the requests which don't need the missing dependencies.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Dependency is a dependency of the server and its check.
+type Dependency struct {
+	Name string
+	// Critical is true if the server can't serve any request without the dependency.
+	Critical bool
+	// LatencyThreshold is the latency above which the dependency is degraded, zero to never degrade it.
+	LatencyThreshold time.Duration
+	Check            func(ctx context.Context) error
+}
+
+// DependencyStatus is the result of the last check of a dependency.
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the status of the server and of its dependencies at the last check.
+type Report struct {
+	Status       Status              `json:"status"`
+	CheckedAt    time.Time           `json:"checked_at"`
+	Dependencies []*DependencyStatus `json:"dependencies"`
+}
+
+// Checker checks the dependencies periodically and keeps the last report, also published to the gRPC health service:
+// the server and each dependency, by its name, are serving unless they are down.
+type Checker struct {
+	cfg  config.HealthConfig
+	deps []*Dependency
+	grpc *health.Server
+	now  func() time.Time
+
+	sync.RWMutex
+	report *Report
+}
+
+func NewChecker(cfg config.HealthConfig, deps ...*Dependency) *Checker {
+	grpcHealth := health.NewServer()
+	grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Checker{
+		cfg:  cfg,
+		deps: deps,
+		grpc: grpcHealth,
+		now:  time.Now,
+	}
+}
+
+// Start checks the dependencies in the background, the server is reported down until the first check is done.
+func (c *Checker) Start() {
+	go c.loop()
+}
+
+// GRPCServer returns the gRPC health service of the checker.
+func (c *Checker) GRPCServer() healthpb.HealthServer {
+	return c.grpc
+}
+
+// Report returns the report of the last check, a report without dependencies if no check is done yet.
+func (c *Checker) Report() *Report {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.report == nil {
+		return &Report{Status: StatusDown, Dependencies: []*DependencyStatus{}}
+	}
+
+	return c.report
+}
+
+// Stale returns true if the last check is too old, the checks being stuck.
+func (c *Checker) Stale() bool {
+	report := c.Report()
+	if report.CheckedAt.IsZero() {
+		return false
+	}
+
+	return c.now().Sub(report.CheckedAt) > 3*c.interval()+c.cfg.Timeout
+}
+
+func (c *Checker) interval() time.Duration {
+	if c.cfg.CheckInterval <= 0 {
+		return 5 * time.Second
+	}
+
+	return c.cfg.CheckInterval
+}
+
+func (c *Checker) loop() {
+	c.Check(context.Background())
+
+	ticker := time.NewTicker(c.interval())
+	defer ticker.Stop()
+	for range ticker.C {
+		c.Check(context.Background())
+	}
+}
+
+// Check checks the dependencies concurrently and returns the new report.
+func (c *Checker) Check(ctx context.Context) *Report {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	statuses := make([]*DependencyStatus, len(c.deps))
+	var wg sync.WaitGroup
+	for i, dep := range c.deps {
+		wg.Add(1)
+		go func(i int, dep *Dependency) {
+			defer wg.Done()
+			statuses[i] = c.check(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	report := &Report{Status: overall(statuses), CheckedAt: c.now().UTC(), Dependencies: statuses}
+	c.publish(report)
+
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, dep *Dependency) *DependencyStatus {
+	status := &DependencyStatus{Name: dep.Name, Status: StatusOK, Critical: dep.Critical}
+
+	start := c.now()
+	err := dep.Check(ctx)
+	if err == nil {
+		err = ctx.Err()
+	}
+	latency := c.now().Sub(start)
+	status.LatencyMs = float64(latency.Microseconds()) / 1000
+
+	switch {
+	case err != nil:
+		status.Status = StatusDown
+		status.Error = err.Error()
+	case dep.LatencyThreshold > 0 && latency > dep.LatencyThreshold:
+		status.Status = StatusDegraded
+	}
+
+	return status
+}
+
+// overall returns the status of the server from the statuses of its dependencies.
+func overall(statuses []*DependencyStatus) Status {
+	status := StatusOK
+	for _, s := range statuses {
+		if s.Status == StatusDown && s.Critical {
+			return StatusDown
+		}
+		if s.Status != StatusOK {
+			status = StatusDegraded
+		}
+	}
+
+	return status
+}
+
+func (c *Checker) publish(report *Report) {
+	c.Lock()
+	previous := c.report
+	c.report = report
+	c.Unlock()
+
+	if previous == nil || previous.Status != report.Status {
+		log.Info().Str("status", string(report.Status)).Msg("health status changed")
+	}
+
+	c.grpc.SetServingStatus("", servingStatus(report.Status))
+	for _, s := range report.Dependencies {
+		c.grpc.SetServingStatus(s.Name, servingStatus(s.Status))
+	}
+}
+
+func servingStatus(status Status) healthpb.HealthCheckResponse_ServingStatus {
+	if status == StatusDown {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// Healthz is the liveness endpoint, it fails only if the checks are stuck: the dependencies being down doesn't make
+// restarting the server useful.
+func (c *Checker) Healthz(w http.ResponseWriter, _ *http.Request) {
+	code := http.StatusOK
+	if c.Stale() {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeReport(w, code, c.Report())
+}
+
+// Readyz is the readiness endpoint, it fails if the server is down. A degraded server is ready, unless the "strict"
+// query parameter is set for the callers which prefer to route the requests to the servers that are fully ok.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := c.Report()
+
+	code := http.StatusOK
+	if report.Status == StatusDown || (report.Status == StatusDegraded && r.URL.Query().Has("strict")) {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeReport(w, code, report)
+}
+
+func writeReport(w http.ResponseWriter, code int, report *Report) {
+	data, _ := jsoniter.Marshal(report)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
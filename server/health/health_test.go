@@ -0,0 +1,104 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestChecker(t *testing.T) {
+	var fdbErr, cacheErr error
+	var searchDelay time.Duration
+
+	c := NewChecker(config.HealthConfig{CheckInterval: time.Second, Timeout: time.Second},
+		&Dependency{Name: "fdb", Critical: true, Check: func(context.Context) error { return fdbErr }},
+		&Dependency{Name: "search", LatencyThreshold: 10 * time.Millisecond, Check: func(context.Context) error {
+			time.Sleep(searchDelay)
+			return nil
+		}},
+		&Dependency{Name: "cache", Check: func(context.Context) error { return cacheErr }},
+	)
+
+	ready := func(strict bool) int {
+		target := "/readyz"
+		if strict {
+			target += "?strict"
+		}
+		w := httptest.NewRecorder()
+		c.Readyz(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+	serving := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := c.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// not checked yet
+	require.Equal(t, StatusDown, c.Report().Status)
+	require.Equal(t, http.StatusServiceUnavailable, ready(false))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, serving(""))
+
+	report := c.Check(context.Background())
+	require.Equal(t, StatusOK, report.Status)
+	require.Len(t, report.Dependencies, 3)
+	require.Equal(t, http.StatusOK, ready(true))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, serving(""))
+
+	// a slow dependency degrades the server
+	searchDelay = 20 * time.Millisecond
+	report = c.Check(context.Background())
+	require.Equal(t, StatusDegraded, report.Status)
+	require.Equal(t, StatusDegraded, report.Dependencies[1].Status)
+	require.Equal(t, http.StatusOK, ready(false))
+	require.Equal(t, http.StatusServiceUnavailable, ready(true))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, serving("search"))
+
+	// a non critical dependency down degrades the server
+	searchDelay = 0
+	cacheErr = fmt.Errorf("connection refused")
+	report = c.Check(context.Background())
+	require.Equal(t, StatusDegraded, report.Status)
+	require.Equal(t, "connection refused", report.Dependencies[2].Error)
+	require.Equal(t, http.StatusOK, ready(false))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, serving(""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, serving("cache"))
+
+	// a critical dependency down takes the server down
+	fdbErr = fmt.Errorf("timeout")
+	report = c.Check(context.Background())
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, http.StatusServiceUnavailable, ready(false))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, serving(""))
+
+	// the liveness only fails if the checks are stuck
+	w := httptest.NewRecorder()
+	c.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	c.now = func() time.Time { return time.Now().Add(time.Minute) }
+	w = httptest.NewRecorder()
+	c.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,280 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indexadvisor suggests the secondary indexes of the collections from the queries which were served by full
+// scans. The filters of these queries are recorded as shapes, the normalized filters without the values, and ranked
+// periodically. Each shape has a candidate index with the fields compared for equality first, then the sort fields and
+// then a field compared with a range. The candidates serving the most recorded full scans are suggested, a candidate
+// serves the shapes which candidates are a prefix of its fields.
+//
+// The shapes are recorded in memory by each server and their counts decay by half at each ranking, so the suggestions
+// follow the recent queries of the server.
+package indexadvisor
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	qsort "github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+type collectionKey struct {
+	namespace  string
+	project    string
+	branch     string
+	collection string
+}
+
+type shapeStats struct {
+	shape string
+	index []*api.IndexSuggestionField
+	scans float64
+}
+
+// Advisor records the shapes of the filters of the full scans and ranks the indexes serving them.
+type Advisor struct {
+	sync.Mutex
+
+	cfg    config.IndexAdvisorConfig
+	shapes map[collectionKey]map[string]*shapeStats
+	count  int
+
+	suggestions map[collectionKey][]*api.IndexSuggestion
+	generatedAt time.Time
+	now         func() time.Time
+}
+
+var advisor = NewAdvisor(config.IndexAdvisorConfig{})
+
+func NewAdvisor(cfg config.IndexAdvisorConfig) *Advisor {
+	return &Advisor{
+		cfg:         cfg,
+		shapes:      make(map[collectionKey]map[string]*shapeStats),
+		suggestions: make(map[collectionKey][]*api.IndexSuggestion),
+		now:         time.Now,
+	}
+}
+
+// Init starts the advisor of the server, the full scans are not recorded if it is disabled.
+func Init(cfg config.IndexAdvisorConfig) {
+	advisor = NewAdvisor(cfg)
+	advisor.Start()
+}
+
+func (a *Advisor) Start() {
+	if a.cfg.Enabled {
+		go a.loop()
+	}
+}
+
+func (a *Advisor) loop() {
+	log.Info().Dur("interval", a.cfg.Interval).Msg("Starting index advisor")
+	t := time.NewTicker(a.cfg.Interval)
+	defer t.Stop()
+	for range t.C {
+		a.rank()
+	}
+}
+
+// RecordFullScan records the filter and the sort of a query of the collection served by a full scan.
+func RecordFullScan(namespace string, project string, branch string, collection string, filter []byte, reqSort []byte) {
+	advisor.RecordFullScan(namespace, project, branch, collection, filter, reqSort)
+}
+
+// Suggestions returns the suggestions of the last ranking for the collections of the project branch and the time of
+// the ranking.
+func Suggestions(namespace string, project string, branch string) ([]*api.IndexSuggestion, time.Time) {
+	return advisor.Suggestions(namespace, project, branch)
+}
+
+func (a *Advisor) RecordFullScan(namespace string, project string, branch string, collection string, filter []byte, reqSort []byte) {
+	if !a.cfg.Enabled || len(filter) == 0 {
+		return
+	}
+
+	conjuncts, err := parseConjuncts(filter)
+	if err != nil {
+		return
+	}
+
+	var ordering qsort.Ordering
+	if len(reqSort) > 0 {
+		if o, err := qsort.UnmarshalSort(reqSort); err == nil && o != nil {
+			ordering = *o
+		}
+	}
+
+	key := collectionKey{namespace: namespace, project: project, branch: branch, collection: collection}
+
+	a.Lock()
+	defer a.Unlock()
+
+	for _, c := range conjuncts {
+		if len(c) == 0 {
+			continue
+		}
+
+		shape := c.shape()
+		if len(ordering) > 0 {
+			shape += " sort " + sortShape(ordering)
+		}
+
+		shapes, ok := a.shapes[key]
+		if !ok {
+			shapes = make(map[string]*shapeStats)
+			a.shapes[key] = shapes
+		}
+
+		stats, ok := shapes[shape]
+		if !ok {
+			if a.count >= a.cfg.MaxShapes {
+				continue
+			}
+
+			stats = &shapeStats{shape: shape, index: candidateIndex(c, ordering)}
+			shapes[shape] = stats
+			a.count++
+		}
+		stats.scans++
+	}
+}
+
+func (a *Advisor) Suggestions(namespace string, project string, branch string) ([]*api.IndexSuggestion, time.Time) {
+	a.Lock()
+	defer a.Unlock()
+
+	var suggestions []*api.IndexSuggestion
+	for key, s := range a.suggestions {
+		if key.namespace == namespace && key.project == project && key.branch == branch {
+			suggestions = append(suggestions, s...)
+		}
+	}
+	sortSuggestions(suggestions)
+
+	return suggestions, a.generatedAt
+}
+
+// rank replaces the suggestions with the ones of the shapes recorded so far and decays the counts of the shapes.
+func (a *Advisor) rank() {
+	a.Lock()
+	defer a.Unlock()
+
+	suggestions := make(map[collectionKey][]*api.IndexSuggestion)
+	for key, shapes := range a.shapes {
+		if s := a.suggest(key.collection, shapes); len(s) > 0 {
+			suggestions[key] = s
+		}
+
+		for shape, stats := range shapes {
+			if stats.scans /= 2; stats.scans < 1 {
+				delete(shapes, shape)
+				a.count--
+			}
+		}
+		if len(shapes) == 0 {
+			delete(a.shapes, key)
+		}
+	}
+
+	a.suggestions = suggestions
+	a.generatedAt = a.now().UTC()
+}
+
+// suggest picks the candidate indexes of the collection which serve the most full scans not served by the candidates
+// picked before it, until the candidates serve less than the minimum number of scans.
+func (a *Advisor) suggest(collection string, shapes map[string]*shapeStats) []*api.IndexSuggestion {
+	var (
+		total      float64
+		candidates []*shapeStats
+	)
+	for _, stats := range shapes {
+		total += stats.scans
+		if len(stats.index) > 0 {
+			candidates = append(candidates, stats)
+		}
+	}
+	// the candidates are visited in the same order for the ties to be stable
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].shape < candidates[j].shape
+	})
+
+	var (
+		suggestions []*api.IndexSuggestion
+		served      = make(map[string]bool)
+	)
+	for len(suggestions) < a.cfg.MaxSuggestions {
+		var (
+			best      *shapeStats
+			bestScans float64
+		)
+		for _, candidate := range candidates {
+			var scans float64
+			for _, stats := range candidates {
+				if !served[stats.shape] && isPrefix(stats.index, candidate.index) {
+					scans += stats.scans
+				}
+			}
+			if scans > bestScans || (scans == bestScans && best != nil && len(candidate.index) < len(best.index)) {
+				best, bestScans = candidate, scans
+			}
+		}
+		if best == nil || bestScans < float64(a.cfg.MinScans) {
+			break
+		}
+
+		suggestion := &api.IndexSuggestion{
+			Collection:       collection,
+			Fields:           best.index,
+			FullScans:        int64(math.Round(bestScans)),
+			EstimatedBenefit: math.Round(1000*bestScans/total) / 10,
+		}
+		for _, stats := range candidates {
+			if !served[stats.shape] && isPrefix(stats.index, best.index) {
+				served[stats.shape] = true
+				suggestion.Shapes = append(suggestion.Shapes, stats.shape)
+			}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions
+}
+
+// sortSuggestions orders the suggestions by the number of the full scans they avoid, then by the collection and the
+// fields.
+func sortSuggestions(suggestions []*api.IndexSuggestion) {
+	fieldNames := func(s *api.IndexSuggestion) string {
+		names := make([]string, len(s.Fields))
+		for i, f := range s.Fields {
+			names[i] = f.Field
+		}
+		return strings.Join(names, ",")
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].FullScans != suggestions[j].FullScans {
+			return suggestions[i].FullScans > suggestions[j].FullScans
+		}
+		if suggestions[i].Collection != suggestions[j].Collection {
+			return suggestions[i].Collection < suggestions[j].Collection
+		}
+		return fieldNames(suggestions[i]) < fieldNames(suggestions[j])
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexadvisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestAdvisor(t *testing.T) {
+	a := NewAdvisor(config.IndexAdvisorConfig{Enabled: true, MaxShapes: 4, MinScans: 3, MaxSuggestions: 5})
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	record := func(collection string, filter string, sort string, times int) {
+		for i := 0; i < times; i++ {
+			a.RecordFullScan("ns1", "p1", "main", collection, []byte(filter), []byte(sort))
+		}
+	}
+
+	record("users", `{"city": "sf"}`, "", 4)
+	record("users", `{"age": {"$gt": 20}, "city": "la"}`, "", 6)
+	record("users", `{"name": {"$regex": "a"}}`, "", 2)
+	record("orders", `{"status": "open"}`, `[{"created": "$desc"}]`, 3)
+	// the limit of the shapes is reached, the new shapes are dropped
+	record("orders", `{"total": 10}`, "", 10)
+	// the full scans of the other projects are separate
+	a.RecordFullScan("ns1", "p2", "main", "users", []byte(`{"city": "sf"}`), nil)
+
+	suggestions, generatedAt := a.Suggestions("ns1", "p1", "main")
+	require.Empty(t, suggestions)
+	require.True(t, generatedAt.IsZero())
+
+	a.rank()
+	suggestions, generatedAt = a.Suggestions("ns1", "p1", "main")
+	require.Equal(t, now, generatedAt)
+	require.Equal(t, []*api.IndexSuggestion{
+		{
+			Collection:       "users",
+			Fields:           []*api.IndexSuggestionField{{Field: "city"}, {Field: "age"}},
+			FullScans:        10,
+			EstimatedBenefit: 83.3,
+			Shapes:           []string{`{"age":{"$gt":"?"},"city":"?"}`, `{"city":"?"}`},
+		},
+		{
+			Collection:       "orders",
+			Fields:           []*api.IndexSuggestionField{{Field: "status"}, {Field: "created", Sort: "desc"}},
+			FullScans:        3,
+			EstimatedBenefit: 100,
+			Shapes:           []string{`{"status":"?"} sort [{"created":"$desc"}]`},
+		},
+	}, suggestions)
+
+	// the counts decay at each ranking, the suggestions without enough recent scans are dropped
+	a.rank()
+	suggestions, _ = a.Suggestions("ns1", "p1", "main")
+	require.Len(t, suggestions, 1)
+	require.Equal(t, int64(5), suggestions[0].FullScans)
+
+	a.rank()
+	a.rank()
+	suggestions, _ = a.Suggestions("ns1", "p1", "main")
+	require.Empty(t, suggestions)
+}
+
+func TestAdvisorDisabled(t *testing.T) {
+	a := NewAdvisor(config.IndexAdvisorConfig{MaxShapes: 10, MinScans: 1, MaxSuggestions: 5})
+	a.RecordFullScan("ns1", "p1", "main", "users", []byte(`{"city": "sf"}`), nil)
+	a.rank()
+
+	suggestions, _ := a.Suggestions("ns1", "p1", "main")
+	require.Empty(t, suggestions)
+}
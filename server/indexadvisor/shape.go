@@ -0,0 +1,277 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexadvisor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+	qsort "github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+)
+
+// maxConjuncts bounds the number of the conjunctions a filter with nested "$or" expands to.
+const maxConjuncts = 16
+
+// conjunct is a conjunction of the conditions of a filter, the operators applied to each field. The equality is
+// recorded as the "$eq" operator.
+type conjunct map[string][]string
+
+func (c conjunct) add(field string, ops ...string) {
+	for _, op := range ops {
+		if !contains(c[field], op) {
+			c[field] = append(c[field], op)
+		}
+	}
+	sort.Strings(c[field])
+}
+
+func (c conjunct) merge(other conjunct) conjunct {
+	merged := make(conjunct, len(c)+len(other))
+	for field, ops := range c {
+		merged.add(field, ops...)
+	}
+	for field, ops := range other {
+		merged.add(field, ops...)
+	}
+
+	return merged
+}
+
+// shape returns the normalized filter of the conjunction, the fields are sorted and the values are replaced by "?".
+func (c conjunct) shape() string {
+	fields := make([]string, 0, len(c))
+	for field := range c {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		name, _ := jsoniter.MarshalToString(field)
+		sb.WriteString(name + ":")
+
+		ops := c[field]
+		if len(ops) == 1 && ops[0] == filter.EQ {
+			sb.WriteString(`"?"`)
+			continue
+		}
+
+		sb.WriteString("{")
+		for j, op := range ops {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(`"` + op + `":"?"`)
+		}
+		sb.WriteString("}")
+	}
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+// sortShape returns the normalized sort of the query.
+func sortShape(ordering qsort.Ordering) string {
+	parts := make([]string, len(ordering))
+	for i, s := range ordering {
+		name, _ := jsoniter.MarshalToString(s.Name)
+		order := qsort.ASC
+		if !s.Ascending {
+			order = qsort.DESC
+		}
+		parts[i] = "{" + name + `:"` + order + `"}`
+	}
+
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// parseConjuncts returns the conjunctions of the filter, a filter with "$or" has a conjunction for each alternative.
+func parseConjuncts(reqFilter []byte) ([]conjunct, error) {
+	result := []conjunct{{}}
+	err := jsonparser.ObjectEach(reqFilter, func(k []byte, v []byte, dataType jsonparser.ValueType, _ int) error {
+		switch key := string(k); key {
+		case string(filter.AndOP), string(filter.OrOP):
+			var children []conjunct
+			if key == string(filter.AndOP) {
+				children = []conjunct{{}}
+			}
+
+			var err error
+			_, _ = jsonparser.ArrayEach(v, func(child []byte, _ jsonparser.ValueType, _ int, _ error) {
+				if err != nil {
+					return
+				}
+
+				var parsed []conjunct
+				if parsed, err = parseConjuncts(child); err != nil {
+					return
+				}
+				if key == string(filter.AndOP) {
+					children = product(children, parsed)
+				} else {
+					children = append(children, parsed...)
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			result = product(result, children)
+		default:
+			ops := []string{filter.EQ}
+			if dataType == jsonparser.Object {
+				ops = fieldOperators(v)
+			}
+			for _, c := range result {
+				c.add(key, ops...)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// fieldOperators returns the operators of the object value of a field, an object without operators is compared for
+// equality.
+func fieldOperators(v []byte) []string {
+	var ops []string
+	_ = jsonparser.ObjectEach(v, func(k []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		if op := string(k); strings.HasPrefix(op, "$") {
+			ops = append(ops, op)
+		}
+		return nil
+	})
+	if len(ops) == 0 {
+		return []string{filter.EQ}
+	}
+
+	return ops
+}
+
+func product(a []conjunct, b []conjunct) []conjunct {
+	result := make([]conjunct, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			if len(result) == maxConjuncts {
+				return result
+			}
+			result = append(result, x.merge(y))
+		}
+	}
+
+	return result
+}
+
+// candidateIndex returns the fields of the index serving the conjunction and the sort. The fields compared for
+// equality are first, then the sort fields and then a field compared with a range, so that the query reads a single
+// range of the index in the order of the sort. The fields with the other operators, like "$regex", are not indexed.
+func candidateIndex(c conjunct, ordering qsort.Ordering) []*api.IndexSuggestionField {
+	var (
+		equal  []string
+		ranges []string
+	)
+	for field, ops := range c {
+		switch {
+		case containsAny(ops, filter.EQ, filter.IN):
+			equal = append(equal, field)
+		case containsAny(ops, filter.GT, filter.GTE, filter.LT, filter.LTE):
+			ranges = append(ranges, field)
+		}
+	}
+	sort.Strings(equal)
+	sort.Strings(ranges)
+
+	fields := make([]*api.IndexSuggestionField, 0, len(equal)+len(ordering)+1)
+	for _, field := range equal {
+		fields = append(fields, &api.IndexSuggestionField{Field: field})
+	}
+	for _, s := range ordering {
+		if contains(equal, s.Name) {
+			continue
+		}
+
+		f := &api.IndexSuggestionField{Field: s.Name}
+		if !s.Ascending {
+			f.Sort = schema.IndexSortDesc
+		}
+		fields = append(fields, f)
+	}
+	for _, field := range ranges {
+		if !hasField(fields, field) {
+			fields = append(fields, &api.IndexSuggestionField{Field: field})
+			break
+		}
+	}
+
+	return fields
+}
+
+// isPrefix returns true if the index with the fields a is served by the index with the fields b.
+func isPrefix(a []*api.IndexSuggestionField, b []*api.IndexSuggestionField) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Field != b[i].Field || a[i].Sort != b[i].Sort {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasField(fields []*api.IndexSuggestionField, field string) bool {
+	for _, f := range fields {
+		if f.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(values []string, wanted ...string) bool {
+	for _, v := range wanted {
+		if contains(values, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexadvisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	qsort "github.com/tigrisdata/tigris/query/sort"
+)
+
+func TestParseConjuncts(t *testing.T) {
+	cases := []struct {
+		filter string
+		shapes []string
+	}{
+		{`{"city": "sf", "age": {"$gt": 10, "$lte": 20}}`, []string{`{"age":{"$gt":"?","$lte":"?"},"city":"?"}`}},
+		// "$eq" has the same shape as the implicit equality
+		{`{"address.city": {"$eq": "sf"}, "tags": {"$in": ["a", "b"]}}`, []string{`{"address.city":"?","tags":{"$in":"?"}}`}},
+		{`{"obj": {"a": 1}}`, []string{`{"obj":"?"}`}},
+		{`{"$and": [{"a": 1}, {"b": {"$regex": "x"}}]}`, []string{`{"a":"?","b":{"$regex":"?"}}`}},
+		{`{"a": 1, "$or": [{"b": 1}, {"c": {"$lt": 2}}]}`, []string{`{"a":"?","b":"?"}`, `{"a":"?","c":{"$lt":"?"}}`}},
+	}
+	for _, c := range cases {
+		conjuncts, err := parseConjuncts([]byte(c.filter))
+		require.NoError(t, err, c.filter)
+
+		var shapes []string
+		for _, conj := range conjuncts {
+			shapes = append(shapes, conj.shape())
+		}
+		require.Equal(t, c.shapes, shapes, c.filter)
+	}
+
+	_, err := parseConjuncts([]byte(`{"a": `))
+	require.Error(t, err)
+}
+
+func TestCandidateIndex(t *testing.T) {
+	cases := []struct {
+		filter   string
+		ordering qsort.Ordering
+		expected []*api.IndexSuggestionField
+	}{
+		{
+			`{"age": {"$gt": 10}, "city": "sf", "country": "us"}`,
+			nil,
+			[]*api.IndexSuggestionField{{Field: "city"}, {Field: "country"}, {Field: "age"}},
+		}, {
+			`{"age": {"$gt": 10}, "city": "sf"}`,
+			qsort.Ordering{{Name: "created", Ascending: false}, {Name: "city", Ascending: true}},
+			[]*api.IndexSuggestionField{{Field: "city"}, {Field: "created", Sort: "desc"}, {Field: "age"}},
+		}, {
+			`{"age": {"$gt": 10, "$lt": 20}, "name": {"$regex": "^a"}, "score": {"$lt": 5}}`,
+			nil,
+			[]*api.IndexSuggestionField{{Field: "age"}},
+		}, {
+			`{"name": {"$regex": "^a"}}`,
+			nil,
+			[]*api.IndexSuggestionField{},
+		},
+	}
+	for _, c := range cases {
+		conjuncts, err := parseConjuncts([]byte(c.filter))
+		require.NoError(t, err)
+		require.Len(t, conjuncts, 1)
+		require.Equal(t, c.expected, candidateIndex(conjuncts[0], c.ordering), c.filter)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+const dataKeySize = 32
+
+// GenerateDataKey returns a new random 256-bit data key.
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// FieldCipher encrypts and decrypts field values using AES-256-GCM. The random nonce is prepended to the ciphertext.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+func NewFieldCipher(dataKey []byte) (*FieldCipher, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FieldCipher{aead: aead}, nil
+}
+
+func (c *FieldCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return seal(c.aead, plaintext)
+}
+
+func (c *FieldCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return open(c.aead, ciphertext)
+}
+
+// LocalKeyManager wraps the data keys using a master key that is passed through the server configuration. It is
+// meant for self-hosted deployments that don't have access to an external key management system.
+type LocalKeyManager struct {
+	aead cipher.AEAD
+}
+
+func NewLocalKeyManager(masterKey string) (*LocalKeyManager, error) {
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("master key is not base64 encoded: %w", err)
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, found %d", dataKeySize, len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalKeyManager{aead: aead}, nil
+}
+
+func (l *LocalKeyManager) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return seal(l.aead, dataKey)
+}
+
+func (l *LocalKeyManager) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return open(l.aead, wrapped)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, sealed, nil)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+const ProviderLocal = "local"
+
+// KeyManager is the envelope encryption interface to a key management system. The data keys that are used to encrypt
+// the fields are never persisted in plain text, they are wrapped by the key manager and only the wrapped form is
+// stored alongside the collection metadata.
+type KeyManager interface {
+	// WrapKey encrypts the data key with the master key.
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts the wrapped data key with the master key.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// ProviderFactory creates a key manager from the encryption config.
+type ProviderFactory func(cfg *config.EncryptionConfig) (KeyManager, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		ProviderLocal: func(cfg *config.EncryptionConfig) (KeyManager, error) {
+			return NewLocalKeyManager(cfg.MasterKey)
+		},
+	}
+
+	manager KeyManager
+)
+
+// RegisterProvider allows plugging in an external key management system.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[name] = factory
+}
+
+// NewKeyManager returns the key manager for the provider configured in the encryption config.
+func NewKeyManager(cfg *config.EncryptionConfig) (KeyManager, error) {
+	providersMu.RLock()
+	factory, ok := providers[cfg.Provider]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported kms provider '%s'", cfg.Provider)
+	}
+
+	return factory(cfg)
+}
+
+// Init initializes the key manager used by the server. It is a no-op if encryption is disabled.
+func Init(cfg *config.Config) error {
+	if !cfg.Encryption.Enabled {
+		manager = nil
+		return nil
+	}
+
+	m, err := NewKeyManager(&cfg.Encryption)
+	if err != nil {
+		return err
+	}
+
+	manager = m
+
+	return nil
+}
+
+// Get returns the key manager initialized by Init, nil if encryption is not enabled.
+func Get() KeyManager {
+	return manager
+}
@@ -0,0 +1,71 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestLocalKeyManager(t *testing.T) {
+	masterKey, err := GenerateDataKey()
+	require.NoError(t, err)
+
+	km, err := NewKeyManager(&config.EncryptionConfig{
+		Provider:  ProviderLocal,
+		MasterKey: base64.StdEncoding.EncodeToString(masterKey),
+	})
+	require.NoError(t, err)
+
+	dataKey, err := GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := km.WrapKey(context.TODO(), dataKey)
+	require.NoError(t, err)
+	require.NotEqual(t, dataKey, wrapped)
+
+	unwrapped, err := km.UnwrapKey(context.TODO(), wrapped)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unwrapped)
+
+	_, err = NewKeyManager(&config.EncryptionConfig{Provider: ProviderLocal, MasterKey: "c2hvcnQ="})
+	require.Error(t, err)
+
+	_, err = NewKeyManager(&config.EncryptionConfig{Provider: "unknown"})
+	require.Error(t, err)
+}
+
+func TestFieldCipher(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	require.NoError(t, err)
+
+	c, err := NewFieldCipher(dataKey)
+	require.NoError(t, err)
+
+	ciphertext, err := c.Encrypt([]byte(`"123-45-6789"`))
+	require.NoError(t, err)
+
+	plaintext, err := c.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, `"123-45-6789"`, string(plaintext))
+
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	_, err = c.Decrypt(ciphertext)
+	require.Error(t, err)
+}
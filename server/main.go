@@ -21,16 +21,21 @@ import (
 	"syscall"
 
 	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/server/audit"
 	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/kms"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/middleware"
 	"github.com/tigrisdata/tigris/server/muxer"
 	"github.com/tigrisdata/tigris/server/quota"
+	"github.com/tigrisdata/tigris/server/replica"
 	"github.com/tigrisdata/tigris/server/request"
 	"github.com/tigrisdata/tigris/server/services/v1/billing"
 	"github.com/tigrisdata/tigris/server/tracing"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/server/types"
+	"github.com/tigrisdata/tigris/server/usage"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/store/search"
 	"github.com/tigrisdata/tigris/util"
@@ -103,6 +108,11 @@ func mainWithCode() int {
 	forSearchTxMgr := transaction.NewManager(kvStoreForSearch)
 	log.Info().Msg("initialized transaction manager for search")
 
+	if err = kms.Init(defaultConfig); err != nil {
+		log.Error().Err(err).Msg("error initializing key manager")
+		return 1
+	}
+
 	tenantMgr := metadata.NewTenantManager(kvStoreForDatabase, searchStore, txMgr)
 	log.Info().Msg("initialized tenant manager")
 
@@ -114,8 +124,26 @@ func mainWithCode() int {
 
 	cfg := &config.DefaultConfig
 	request.Init(tenantMgr)
+	middleware.InitSearchKeys(tenantMgr)
+	middleware.InitRBAC(&cfg.Auth.RBAC, tenantMgr)
+	middleware.InitAppKeyScopes(&cfg.Auth.AppKeys, tenantMgr)
+	if cfg.Auth.IPAllowlist.Enabled {
+		middleware.InitIPAllowlist(&cfg.Auth.IPAllowlist, tenantMgr)
+	}
 	_ = quota.Init(tenantMgr, cfg)
 	defer quota.Cleanup()
+	audit.Init(cfg.Audit, tenantMgr, txMgr)
+	usage.Init(cfg.Usage, kvStoreForDatabase, tenantMgr)
+	if err = cfg.Replica.Validate(); err != nil {
+		log.Error().Err(err).Msg("invalid replica configuration")
+		return 1
+	}
+	replicas := replica.NewManager(cfg.Replica, cfg.Server.Port, txMgr)
+	replicas.Start()
+	if len(cfg.Replica.Routing) > 0 {
+		middleware.InitReplicaRouting(replicas)
+	}
+	handleReloadSignal()
 
 	bProvider := billing.NewProvider()
 
@@ -140,3 +168,22 @@ func mainWithCode() int {
 	log.Info().Msg("Shutdown")
 	return 0
 }
+
+// handleReloadSignal reloads the configuration on SIGHUP, the sections which can't be changed at runtime take effect
+// on restart.
+func handleReloadSignal() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			sections, err := config.Reload()
+			if err != nil {
+				log.Error().Err(err).Msg("error reloading configuration")
+				continue
+			}
+
+			log.Info().Strs("sections", sections).Msg("Reloaded configuration")
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"time"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// AppKeyScopeGetter reads the scopes of the app keys, it is used to authorize the requests made with the app keys.
+type AppKeyScopeGetter interface {
+	GetAppKeyScope(ctx context.Context, namespaceId string, project string, id string) (*AppKeyScope, error)
+}
+
+// ValidateAppKeyScope returns an error if the scope grants an unknown permission, expires in the past or has an
+// invalid network allowlist.
+func ValidateAppKeyScope(scope *AppKeyScope) error {
+	if len(scope.Id) == 0 {
+		return errors.InvalidArgument("app key id is required")
+	}
+	if scope.ExpiresAt != 0 && scope.ExpiresAt <= time.Now().Unix() {
+		return errors.InvalidArgument("app key expiry must be in the future")
+	}
+
+	if err := ValidateCIDRs(scope.AllowedCIDRs); err != nil {
+		return err
+	}
+
+	return validateGrants(scope.Grants)
+}
+
+// Allows returns true if any grant of the scope covers the permission on the branch and the collection. A scope
+// without grants only sets the expiry of the key and allows everything.
+func (s *AppKeyScope) Allows(permission string, branch string, collection string) bool {
+	if len(s.Grants) == 0 {
+		return true
+	}
+
+	for i := range s.Grants {
+		if s.Grants[i].Allows(permission, branch, collection) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExpired returns true if the key is past its expiry.
+func (s *AppKeyScope) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != 0 && now.Unix() >= s.ExpiresAt
+}
+
+// GetAppKeyScope reads the scope of the app key from the storage rather than from the cached tenant, so the changes
+// made on other nodes are enforced without waiting for the metadata reload. It returns nil if the key has no scope.
+func (m *TenantManager) GetAppKeyScope(ctx context.Context, namespaceId string, project string, id string) (*AppKeyScope, error) {
+	tenant, err := m.GetTenant(ctx, namespaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	return tenant.GetAppKeyScope(ctx, tx, project, id)
+}
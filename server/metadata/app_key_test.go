@@ -0,0 +1,46 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppKeyScope(t *testing.T) {
+	scope := &AppKeyScope{
+		Id:     "tid_k1",
+		Grants: []Grant{{Permission: PermissionWrite, Collection: "orders"}},
+	}
+	require.NoError(t, ValidateAppKeyScope(scope))
+
+	require.True(t, scope.Allows(PermissionRead, "main", "orders"))
+	require.True(t, scope.Allows(PermissionWrite, "feature", "orders"))
+	require.False(t, scope.Allows(PermissionRead, "main", "users"))
+	require.False(t, scope.Allows(PermissionAdmin, "main", ""))
+
+	// the scope without grants only sets the expiry
+	unlimited := &AppKeyScope{Id: "tid_k2", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	require.NoError(t, ValidateAppKeyScope(unlimited))
+	require.True(t, unlimited.Allows(PermissionAdmin, "main", ""))
+	require.False(t, unlimited.IsExpired(time.Now()))
+	require.True(t, unlimited.IsExpired(time.Now().Add(2*time.Hour)))
+
+	require.Error(t, ValidateAppKeyScope(&AppKeyScope{}))
+	require.Error(t, ValidateAppKeyScope(&AppKeyScope{Id: "tid_k3", ExpiresAt: time.Now().Add(-time.Hour).Unix()}))
+	require.Error(t, ValidateAppKeyScope(&AppKeyScope{Id: "tid_k4", Grants: []Grant{{Permission: "delete"}}}))
+}
@@ -25,12 +25,15 @@ import (
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/transaction"
 	ulog "github.com/tigrisdata/tigris/util/log"
+	"github.com/tigrisdata/tigris/value/keyencoding"
 )
 
 // CollectionMetadata contains collection wide metadata.
 type CollectionMetadata struct {
 	ID      uint32          `json:"id,omitempty"`
 	Indexes []*schema.Index `json:"indexes"`
+	// EncryptionKey is the wrapped data key used to encrypt the fields of this collection.
+	EncryptionKey []byte `json:"encryption_key,omitempty"`
 }
 
 // CollectionSubspace is used to store metadata about Tigris collections.
@@ -63,11 +66,12 @@ func (c *CollectionSubspace) Create(ctx context.Context, tx transaction.Tx, nsID
 		// The indexes are created when the collection is created which means we do not need to
 		// do any background building, the index is already up to date and can be used for queries
 		index.State = schema.INDEX_ACTIVE
+		keyencoding.SetCurrent(index)
 	}
 
 	meta := &CollectionMetadata{
-		id,
-		indexes,
+		ID:      id,
+		Indexes: indexes,
 	}
 
 	if err := c.insert(ctx, tx, nsID, dbID, name, meta); err != nil {
@@ -83,9 +87,14 @@ func (c *CollectionSubspace) updateMetadataIndexes(ctx context.Context, tx trans
 		if existingIdx != nil {
 			if updateIdx.State == schema.UNKNOWN {
 				updateIdx.State = existingIdx.State
+				updateIdx.BuildError = existingIdx.BuildError
+			}
+			if updateIdx.KeyEncoding == 0 {
+				updateIdx.KeyEncoding = existingIdx.KeyEncoding
 			}
 		} else {
 			updateIdx.State = schema.INDEX_WRITE_MODE
+			keyencoding.SetCurrent(updateIdx)
 			if err := c.createBuildIndexTask(ctx, tx, nsID, dbID, name, id, updateIdx); err != nil {
 				return err
 			}
@@ -121,6 +130,28 @@ func (c *CollectionSubspace) Update(ctx context.Context, tx transaction.Tx, nsID
 	return metadata, nil
 }
 
+// SetEncryptionKey persists the wrapped data key of the collection.
+func (c *CollectionSubspace) SetEncryptionKey(ctx context.Context, tx transaction.Tx, nsID uint32, dbID uint32, name string, wrappedKey []byte,
+) (*CollectionMetadata, error) {
+	metadata, err := c.Get(ctx, tx, nsID, dbID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.EncryptionKey = wrappedKey
+
+	if err = c.updateMetadata(ctx, tx,
+		c.validateArgs(nsID, dbID, name, &metadata),
+		c.getKey(nsID, dbID, name),
+		collMetaValueVersion,
+		metadata,
+	); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
 func (*CollectionSubspace) createBuildIndexTask(_ context.Context, _ transaction.Tx, _ uint32, _ uint32, _ string, _ uint32, _ *schema.Index) error {
 	return nil
 }
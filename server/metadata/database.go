@@ -39,6 +39,8 @@ type DatabaseMetadata struct {
 
 	SchemaVersion    uint32 `json:"schema_version"`
 	MinSchemaVersion uint32 `json:"min_schema_version"` // explicit version less than this value will be rejected
+	// ExpiresAt is the Unix time an ephemeral branch is deleted at, zero if the branch doesn't expire.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // DatabaseName represents a primary database and its branch name.
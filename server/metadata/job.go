@@ -0,0 +1,196 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/lib/cron"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	ulog "github.com/tigrisdata/tigris/util/log"
+)
+
+// The tasks of the scheduled jobs.
+const (
+	JobTaskDelete  = "delete"
+	JobTaskExport  = "export"
+	JobTaskReindex = "reindex"
+)
+
+// The states of the runs of the scheduled jobs.
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+const (
+	jobMetaValueVersion int32 = 1
+	jobMetaKeyVersion   byte  = 1
+
+	jobLeaseKey = "lease"
+	jobRunKey   = "run"
+)
+
+// ValidateJob returns an error if the job has no name, an invalid schedule or misses the arguments of its task.
+func ValidateJob(job *Job) error {
+	if len(job.Name) == 0 {
+		return errors.InvalidArgument("job name is required")
+	}
+	if _, err := cron.Parse(job.Schedule); err != nil {
+		return errors.InvalidArgument("invalid job schedule: %s", err.Error())
+	}
+
+	switch job.Task {
+	case JobTaskDelete:
+		if len(job.Collection) == 0 || len(job.Filter) == 0 {
+			return errors.InvalidArgument("delete job requires a collection and a filter")
+		}
+		if !jsoniter.Valid(job.Filter) {
+			return errors.InvalidArgument("delete job filter is not a valid JSON")
+		}
+	case JobTaskExport:
+		if len(job.Target) == 0 {
+			return errors.InvalidArgument("export job requires a target")
+		}
+	case JobTaskReindex:
+		if len(job.Collection) == 0 {
+			return errors.InvalidArgument("reindex job requires a collection")
+		}
+	default:
+		return errors.InvalidArgument("unknown job task '%s', allowed values are delete, export and reindex", job.Task)
+	}
+
+	return nil
+}
+
+// JobRun is a run of a scheduled job. The run of a scheduled time is recorded once, by the server running it.
+type JobRun struct {
+	Job         string `json:"job"`
+	Task        string `json:"task"`
+	ScheduledAt int64  `json:"scheduled_at"`
+	StartedAt   int64  `json:"started_at"`
+	FinishedAt  int64  `json:"finished_at,omitempty"`
+	State       string `json:"state"`
+	// Result is a summary of the work done by the run, like the number of the documents deleted
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Node is the server which ran the job
+	Node string `json:"node"`
+}
+
+// SchedulerLease is held by the server running the scheduled jobs, the other servers take it over once it expires.
+type SchedulerLease struct {
+	Holder    string
+	ExpiresAt int64
+}
+
+// JobSubspace stores the runs of the scheduled jobs and the lease of the scheduler.
+type JobSubspace struct {
+	metadataSubspace
+}
+
+func NewJobStore(nameRegistry *NameRegistry) *JobSubspace {
+	return &JobSubspace{
+		metadataSubspace{
+			SubspaceName: nameRegistry.JobSubspaceName(),
+			KeyVersion:   []byte{jobMetaKeyVersion},
+		},
+	}
+}
+
+func (j *JobSubspace) leaseKey() keys.Key {
+	return keys.NewKey(j.SubspaceName, j.KeyVersion, jobLeaseKey)
+}
+
+func (j *JobSubspace) runsKey(namespaceId uint32, project string, job string) keys.Key {
+	return keys.NewKey(j.SubspaceName, j.KeyVersion, jobRunKey, namespaceId, project, job)
+}
+
+func (j *JobSubspace) runKey(namespaceId uint32, project string, job string, scheduledAt int64) keys.Key {
+	return keys.NewKey(j.SubspaceName, j.KeyVersion, jobRunKey, namespaceId, project, job, scheduledAt)
+}
+
+// AcquireLease takes the lease of the scheduler for the holder, or extends it if the holder already has it. It returns
+// false if another holder has the lease.
+func (j *JobSubspace) AcquireLease(ctx context.Context, tx transaction.Tx, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	var lease SchedulerLease
+	err := j.getMetadata(ctx, tx, nil, j.leaseKey(), &lease)
+	if err != nil && err != errors.ErrNotFound {
+		return false, err
+	}
+
+	if err == nil && lease.Holder != holder && lease.ExpiresAt > now.UnixMilli() {
+		return false, nil
+	}
+
+	lease = SchedulerLease{Holder: holder, ExpiresAt: now.Add(ttl).UnixMilli()}
+	if err = j.updateMetadata(ctx, tx, nil, j.leaseKey(), jobMetaValueVersion, &lease); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// InsertRun records the start of the run, it fails if the run of the same scheduled time is already recorded.
+func (j *JobSubspace) InsertRun(ctx context.Context, tx transaction.Tx, namespaceId uint32, project string, run *JobRun) error {
+	return j.insertMetadata(ctx, tx, nil, j.runKey(namespaceId, project, run.Job, run.ScheduledAt), jobMetaValueVersion, run)
+}
+
+// UpdateRun records the outcome of the run.
+func (j *JobSubspace) UpdateRun(ctx context.Context, tx transaction.Tx, namespaceId uint32, project string, run *JobRun) error {
+	return j.updateMetadata(ctx, tx, nil, j.runKey(namespaceId, project, run.Job, run.ScheduledAt), jobMetaValueVersion, run)
+}
+
+// ListRuns returns the last runs of the job, the most recent first. All the runs are returned if limit is zero.
+func (j *JobSubspace) ListRuns(ctx context.Context, tx transaction.Tx, namespaceId uint32, project string, job string, limit int) ([]JobRun, error) {
+	it, err := tx.Read(ctx, j.runsKey(namespaceId, project, job), true)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []JobRun{}
+	var row kv.KeyValue
+	for (limit == 0 || len(runs) < limit) && it.Next(&row) {
+		var run JobRun
+		if err = jsoniter.Unmarshal(row.Data.RawData, &run); ulog.E(err) {
+			return nil, errors.Internal("failed to unmarshal job run")
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, it.Err()
+}
+
+// PruneRuns deletes the runs of the job older than the last keep ones.
+func (j *JobSubspace) PruneRuns(ctx context.Context, tx transaction.Tx, namespaceId uint32, project string, job string, keep int) error {
+	runs, err := j.ListRuns(ctx, tx, namespaceId, project, job, 0)
+	if err != nil {
+		return err
+	}
+
+	for i := keep; i < len(runs); i++ {
+		if err = j.deleteMetadata(ctx, tx, nil, j.runKey(namespaceId, project, job, runs[i].ScheduledAt)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
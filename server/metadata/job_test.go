@@ -0,0 +1,135 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func initJobTest(t *testing.T) (*JobSubspace, transaction.Tx, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	j := NewJobStore(newTestNameRegistry(t))
+
+	_ = kvStore.DropTable(ctx, j.SubspaceName)
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+
+	return j, tx, func() {
+		assert.NoError(t, tx.Rollback(ctx))
+
+		_ = kvStore.DropTable(ctx, j.SubspaceName)
+	}
+}
+
+func TestValidateJob(t *testing.T) {
+	valid := []Job{
+		{Name: "j1", Schedule: "@daily", Task: JobTaskDelete, Collection: "c1", Filter: []byte(`{"a":1}`)},
+		{Name: "j2", Schedule: "*/5 * * * *", Task: JobTaskExport, Target: "file://backup-{time}"},
+		{Name: "j3", Schedule: "0 3 * * 0", Task: JobTaskReindex, Collection: "c1"},
+	}
+	for i := range valid {
+		require.NoError(t, ValidateJob(&valid[i]))
+	}
+
+	invalid := []Job{
+		{Schedule: "@daily", Task: JobTaskReindex, Collection: "c1"},
+		{Name: "j1", Schedule: "* * *", Task: JobTaskReindex, Collection: "c1"},
+		{Name: "j1", Schedule: "@daily", Task: "compact"},
+		{Name: "j1", Schedule: "@daily", Task: JobTaskDelete, Collection: "c1"},
+		{Name: "j1", Schedule: "@daily", Task: JobTaskDelete, Collection: "c1", Filter: []byte(`{"a":`)},
+		{Name: "j1", Schedule: "@daily", Task: JobTaskExport},
+		{Name: "j1", Schedule: "@daily", Task: JobTaskReindex},
+	}
+	for i := range invalid {
+		require.Error(t, ValidateJob(&invalid[i]))
+	}
+}
+
+func TestJobSubspace(t *testing.T) {
+	t.Run("lease", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		j, tx, cleanup := initJobTest(t)
+		defer cleanup()
+
+		now := time.Now()
+		ok, err := j.AcquireLease(ctx, tx, "node1", 10*time.Second, now)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = j.AcquireLease(ctx, tx, "node2", 10*time.Second, now.Add(5*time.Second))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// renewed by the holder
+		ok, err = j.AcquireLease(ctx, tx, "node1", 10*time.Second, now.Add(5*time.Second))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = j.AcquireLease(ctx, tx, "node2", 10*time.Second, now.Add(12*time.Second))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// taken over once expired
+		ok, err = j.AcquireLease(ctx, tx, "node2", 10*time.Second, now.Add(16*time.Second))
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("runs", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		j, tx, cleanup := initJobTest(t)
+		defer cleanup()
+
+		for i := int64(1); i <= 3; i++ {
+			require.NoError(t, j.InsertRun(ctx, tx, 1, "p1", &JobRun{Job: "j1", ScheduledAt: i, State: JobRunRunning}))
+		}
+		require.NoError(t, j.InsertRun(ctx, tx, 1, "p1", &JobRun{Job: "j10", ScheduledAt: 1, State: JobRunRunning}))
+
+		// the run of a scheduled time is only recorded once
+		require.Error(t, j.InsertRun(ctx, tx, 1, "p1", &JobRun{Job: "j1", ScheduledAt: 2}))
+
+		require.NoError(t, j.UpdateRun(ctx, tx, 1, "p1", &JobRun{Job: "j1", ScheduledAt: 3, State: JobRunSucceeded}))
+
+		runs, err := j.ListRuns(ctx, tx, 1, "p1", "j1", 2)
+		require.NoError(t, err)
+		require.Equal(t, []JobRun{
+			{Job: "j1", ScheduledAt: 3, State: JobRunSucceeded},
+			{Job: "j1", ScheduledAt: 2, State: JobRunRunning},
+		}, runs)
+
+		require.NoError(t, j.PruneRuns(ctx, tx, 1, "p1", "j1", 1))
+		runs, err = j.ListRuns(ctx, tx, 1, "p1", "j1", 0)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+
+		runs, err = j.ListRuns(ctx, tx, 1, "p1", "j10", 0)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+	})
+}
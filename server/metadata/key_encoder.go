@@ -54,6 +54,23 @@ type Encoder interface {
 	// EncodeSecondaryIndexTableName returns encoded bytes for the table name of a collections secondary index.
 	EncodeSecondaryIndexTableName(ns Namespace, db *Database, coll *schema.DefaultCollection) ([]byte, error)
 	EncodePartitionTableName(ns Namespace, db *Database, coll *schema.DefaultCollection) ([]byte, error)
+	// EncodeChangelogTableName returns encoded bytes for the table name of the changelog of a collection, the
+	// mutations retained for the point in time restores.
+	EncodeChangelogTableName(ns Namespace, db *Database, coll *schema.DefaultCollection) ([]byte, error)
+	// ChangelogTableName returns the table name of the changelog of the collection of the encoded table name, false if
+	// it is not the table of a collection.
+	ChangelogTableName(tableName []byte) ([]byte, bool)
+	// EncodeAuditLogTableName returns encoded bytes for the table name of the audit trail of a namespace.
+	EncodeAuditLogTableName(ns Namespace) []byte
+	// EncodeUsageTableName returns encoded bytes for the table name of the hourly usage of a namespace.
+	EncodeUsageTableName(ns Namespace) []byte
+	// EncodeEventStreamTableName returns encoded bytes for the table name of the event streams of a database branch.
+	EncodeEventStreamTableName(ns Namespace, db *Database) []byte
+	// EncodeQueueTableName returns encoded bytes for the table name of the queues of a database branch.
+	EncodeQueueTableName(ns Namespace, db *Database) []byte
+	// EncodeRealtimeHistoryTableName returns encoded bytes for the table name of the history of the realtime channels
+	// of a project, db is the main database of the project.
+	EncodeRealtimeHistoryTableName(ns Namespace, db *Database) []byte
 	// EncodeIndexName returns encoded bytes for the index name
 	EncodeIndexName(idx *schema.Index) []byte
 	// EncodeKey returns encoded bytes of the key which will be used to store the values in fdb. The Key return by this
@@ -97,6 +114,39 @@ func (d *DictKeyEncoder) EncodePartitionTableName(ns Namespace, db *Database, co
 	return d.encodedTableName(ns, db, coll, internal.PartitionKeyPrefix), nil
 }
 
+func (d *DictKeyEncoder) EncodeChangelogTableName(ns Namespace, db *Database, coll *schema.DefaultCollection) ([]byte, error) {
+	return d.encodedTableName(ns, db, coll, internal.ChangelogTableKeyPrefix), nil
+}
+
+func (*DictKeyEncoder) ChangelogTableName(tableName []byte) ([]byte, bool) {
+	// the partitions of the partitioned collections are not retained
+	if len(tableName) != 16 || !bytes.Equal(tableName[0:4], internal.UserTableKeyPrefix) {
+		return nil, false
+	}
+
+	return append(append([]byte{}, internal.ChangelogTableKeyPrefix...), tableName[4:]...), true
+}
+
+func (d *DictKeyEncoder) EncodeAuditLogTableName(ns Namespace) []byte {
+	return d.encodedTableName(ns, nil, nil, internal.AuditLogTableKeyPrefix)
+}
+
+func (d *DictKeyEncoder) EncodeUsageTableName(ns Namespace) []byte {
+	return d.encodedTableName(ns, nil, nil, internal.UsageTableKeyPrefix)
+}
+
+func (d *DictKeyEncoder) EncodeEventStreamTableName(ns Namespace, db *Database) []byte {
+	return d.encodedTableName(ns, db, nil, internal.EventStreamTableKeyPrefix)
+}
+
+func (d *DictKeyEncoder) EncodeQueueTableName(ns Namespace, db *Database) []byte {
+	return d.encodedTableName(ns, db, nil, internal.QueueTableKeyPrefix)
+}
+
+func (d *DictKeyEncoder) EncodeRealtimeHistoryTableName(ns Namespace, db *Database) []byte {
+	return d.encodedTableName(ns, db, nil, internal.RealtimeHistoryKeyPrefix)
+}
+
 func (d *DictKeyEncoder) EncodeIndexName(idx *schema.Index) []byte {
 	return d.encodedIdxName(idx)
 }
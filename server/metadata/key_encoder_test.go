@@ -78,6 +78,63 @@ func TestEncodeDecodeKey(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestChangelogTableName(t *testing.T) {
+	coll := &schema.DefaultCollection{Id: 5, Name: "test_coll"}
+	ns := NewTenantNamespace("test_ns", NewNamespaceMetadata(1, "test_ns", "test_ns-display_name"))
+	db := &Database{id: 3, name: NewDatabaseName("test_db")}
+
+	k := NewEncoder()
+	encodedTable, err := k.EncodeTableName(ns, db, coll)
+	require.NoError(t, err)
+	changelog, err := k.EncodeChangelogTableName(ns, db, coll)
+	require.NoError(t, err)
+	require.Equal(t, internal.ChangelogTableKeyPrefix, changelog[0:4])
+	require.Equal(t, encodedTable[4:], changelog[4:])
+
+	name, ok := k.ChangelogTableName(encodedTable)
+	require.True(t, ok)
+	require.Equal(t, changelog, name)
+
+	partition, err := k.EncodePartitionTableName(ns, db, coll)
+	require.NoError(t, err)
+	_, ok = k.ChangelogTableName(partition)
+	require.False(t, ok)
+
+	index, err := k.EncodeSecondaryIndexTableName(ns, db, coll)
+	require.NoError(t, err)
+	_, ok = k.ChangelogTableName(index)
+	require.False(t, ok)
+}
+
+func TestEventStreamTableName(t *testing.T) {
+	ns := NewTenantNamespace("test_ns", NewNamespaceMetadata(1, "test_ns", "test_ns-display_name"))
+	db := &Database{id: 3, name: NewDatabaseName("test_db")}
+	branch := &Database{id: 4, name: NewDatabaseNameWithBranch("test_db", "b1")}
+
+	k := NewEncoder()
+	table := k.EncodeEventStreamTableName(ns, db)
+	require.Equal(t, internal.EventStreamTableKeyPrefix, table[0:4])
+	require.Equal(t, uint32(1), ByteToUInt32(table[4:8]))
+	require.Equal(t, uint32(3), ByteToUInt32(table[8:12]))
+	require.Len(t, table, 12)
+
+	// the branches have their own streams
+	require.NotEqual(t, table, k.EncodeEventStreamTableName(ns, branch))
+}
+
+func TestQueueTableName(t *testing.T) {
+	ns := NewTenantNamespace("test_ns", NewNamespaceMetadata(1, "test_ns", "test_ns-display_name"))
+	db := &Database{id: 3, name: NewDatabaseName("test_db")}
+
+	k := NewEncoder()
+	table := k.EncodeQueueTableName(ns, db)
+	require.Equal(t, internal.QueueTableKeyPrefix, table[0:4])
+	require.Equal(t, uint32(1), ByteToUInt32(table[4:8]))
+	require.Equal(t, uint32(3), ByteToUInt32(table[8:12]))
+	require.NotEqual(t, table, k.EncodeEventStreamTableName(ns, db))
+	require.Equal(t, internal.RealtimeHistoryKeyPrefix, k.EncodeRealtimeHistoryTableName(ns, db)[0:4])
+}
+
 func TestCacheEncoderKeyConversion(t *testing.T) {
 	cacheEncoder := NewCacheEncoder()
 
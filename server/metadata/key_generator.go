@@ -55,7 +55,7 @@ func (g *TableKeyGenerator) GenerateCounter(ctx context.Context, txMgr *transact
 		if err = tx.Commit(ctx); err == nil {
 			return valueI32, nil
 		}
-		if err != kv.ErrConflictingTransaction {
+		if !kv.IsConflict(err) {
 			return -1, err
 		}
 	}
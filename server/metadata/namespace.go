@@ -17,8 +17,10 @@ package metadata
 import (
 	"context"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/defaults"
 	"github.com/tigrisdata/tigris/server/transaction"
 )
@@ -45,6 +47,8 @@ type NamespaceMetadata struct {
 	Name string
 	// external accounts
 	Accounts AccountIntegrations
+	// AllowedCIDRs restricts the client addresses the requests to the namespace are accepted from, empty allows all
+	AllowedCIDRs []string `json:",omitempty"`
 }
 
 // DefaultNamespace is for "default" namespace in the cluster. This is useful when there is no need to logically group
@@ -60,6 +64,12 @@ type ProjectMetadata struct {
 	CreatedAt      int64
 	CachesMetadata []CacheMetadata
 	SearchMetadata []SearchMetadata
+	Templates      []SchemaTemplate
+	SearchKeys     []SearchKey   `json:",omitempty"`
+	Roles          []Role        `json:",omitempty"`
+	RoleBindings   []RoleBinding `json:",omitempty"`
+	AppKeyScopes   []AppKeyScope `json:",omitempty"`
+	Jobs           []Job         `json:",omitempty"`
 }
 
 type CacheMetadata struct {
@@ -68,10 +78,94 @@ type CacheMetadata struct {
 	CreatedAt int64
 }
 
+// SchemaTemplate is a named schema at the project level that collections can extend.
+type SchemaTemplate struct {
+	Name      string
+	Schema    jsoniter.RawMessage
+	Version   uint32
+	Creator   string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+// SearchKey is a search-only credential of the project. It can only run search queries against the collections and
+// the search indexes listed in Indexes. Only the hash of the secret is stored.
+type SearchKey struct {
+	Id         string
+	Name       string
+	SecretHash string
+	Indexes    []string
+	// QPS is the queries per second allowed to the key on every node, zero means no per key limit.
+	QPS       int
+	Creator   string
+	CreatedAt int64
+	// ExpiresAt is the unix time after which the key is rejected, zero means the key doesn't expire.
+	ExpiresAt int64 `json:",omitempty"`
+}
+
+// Role is a named set of the permissions on the databases and the collections of the project.
+type Role struct {
+	Name      string
+	Grants    []Grant
+	Creator   string
+	CreatedAt int64
+}
+
+// Grant gives the permission on a database branch and a collection, an empty Branch or Collection matches all of them.
+type Grant struct {
+	Permission string
+	Branch     string `json:",omitempty"`
+	Collection string `json:",omitempty"`
+}
+
+// RoleBinding assigns the role of the project to the subject of the access token.
+type RoleBinding struct {
+	Sub       string
+	Role      string
+	Creator   string
+	CreatedAt int64
+}
+
+// AppKeyScope limits the app key of the project to the grants on the databases and the collections of the project.
+// The key is rejected after ExpiresAt, zero means the key doesn't expire.
+type AppKeyScope struct {
+	Id        string
+	Grants    []Grant `json:",omitempty"`
+	ExpiresAt int64   `json:",omitempty"`
+	// AllowedCIDRs restricts the client addresses the key is accepted from, empty allows all
+	AllowedCIDRs []string `json:",omitempty"`
+	Creator      string
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+// Job is a task of the project run by the server on a cron schedule. The runs of the jobs are recorded in the job
+// subspace.
+type Job struct {
+	Name string
+	// Schedule is the cron expression of the times the job runs at, in UTC
+	Schedule string
+	// Task is what the job runs, "delete", "export" or "reindex"
+	Task       string
+	Branch     string `json:",omitempty"`
+	Collection string `json:",omitempty"`
+	// Filter selects the documents deleted by the delete task
+	Filter jsoniter.RawMessage `json:",omitempty"`
+	// Target is where the export task writes the backup of the branch, "{time}" is replaced by the time of the run
+	Target string `json:",omitempty"`
+	// Paused jobs are not run until they are resumed
+	Paused    bool `json:",omitempty"`
+	Creator   string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
 type SearchMetadata struct {
 	Name      string
 	Creator   string
 	CreatedAt int64
+	// Dictionary has the synonym sets and the stopword lists of the search index.
+	Dictionary *schema.SearchDictionary `json:",omitempty"`
 }
 
 // StrId returns id assigned to the namespace.
@@ -0,0 +1,76 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// MaxAllowedCIDRs is the maximum number of the entries of a network allowlist.
+const MaxAllowedCIDRs = 64
+
+// ValidateCIDRs returns an error if any entry of the allowlist isn't a CIDR block or an IP address.
+func ValidateCIDRs(cidrs []string) error {
+	if len(cidrs) > MaxAllowedCIDRs {
+		return errors.InvalidArgument("allowlist can have at most %d entries", MaxAllowedCIDRs)
+	}
+
+	for _, c := range cidrs {
+		if _, err := parseCIDR(c); err != nil {
+			return errors.InvalidArgument("invalid allowlist entry '%s'", c)
+		}
+	}
+
+	return nil
+}
+
+// AllowsAddr returns true if the allowlist is empty or any of its entries contains the address. The invalid entries
+// are skipped, they are rejected when the allowlist is set.
+func AllowsAddr(cidrs []string, addr netip.Addr) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+
+	addr = addr.Unmap()
+	for _, c := range cidrs {
+		if prefix, err := parseCIDR(c); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCIDR parses a CIDR block, an IP address without the prefix length is a single host block.
+func parseCIDR(cidr string) (netip.Prefix, error) {
+	if !strings.Contains(cidr, "/") {
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return prefix.Masked(), nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCIDRs(t *testing.T) {
+	require.NoError(t, ValidateCIDRs(nil))
+	require.NoError(t, ValidateCIDRs([]string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32", "::1"}))
+	require.Error(t, ValidateCIDRs([]string{"10.0.0.0/33"}))
+	require.Error(t, ValidateCIDRs([]string{"example.com"}))
+	require.Error(t, ValidateCIDRs(make([]string, MaxAllowedCIDRs+1)))
+}
+
+func TestAllowsAddr(t *testing.T) {
+	cidrs := []string{"10.1.0.0/16", "192.168.1.10", "2001:db8::/32"}
+
+	cases := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"10.1.2.3", true},
+		{"10.2.0.1", false},
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"::ffff:10.1.0.1", true},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.allowed, AllowsAddr(cidrs, netip.MustParseAddr(c.addr)), c.addr)
+	}
+
+	require.True(t, AllowsAddr(nil, netip.MustParseAddr("1.2.3.4")))
+}
@@ -0,0 +1,133 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// The permissions of the project level roles, every permission includes the ones before it.
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+	PermissionAdmin = "admin"
+)
+
+var permissionLevels = map[string]int{
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionAdmin: 3,
+}
+
+// ProjectRBAC is the roles and the role bindings of a project.
+type ProjectRBAC struct {
+	Roles    []Role
+	Bindings []RoleBinding
+}
+
+// RBACGetter reads the roles and the role bindings of the projects, it is used to authorize the requests.
+type RBACGetter interface {
+	GetProjectRBAC(ctx context.Context, namespaceId string, project string) (*ProjectRBAC, error)
+}
+
+// ValidateRole returns an error if the role has no name or grants an unknown permission.
+func ValidateRole(role *Role) error {
+	if len(role.Name) == 0 {
+		return errors.InvalidArgument("role name is required")
+	}
+	if len(role.Grants) == 0 {
+		return errors.InvalidArgument("role requires at least one grant")
+	}
+
+	return validateGrants(role.Grants)
+}
+
+func validateGrants(grants []Grant) error {
+	for _, g := range grants {
+		if _, ok := permissionLevels[g.Permission]; !ok {
+			return errors.InvalidArgument("unknown permission '%s', allowed values are read, write and admin", g.Permission)
+		}
+		if len(g.Collection) > 0 && g.Permission == PermissionAdmin {
+			return errors.InvalidArgument("admin permission can't be granted on a collection")
+		}
+	}
+
+	return nil
+}
+
+// Allows returns true if the grant covers the permission on the branch and the collection. The requests that don't
+// target a collection, like listing the collections, are allowed by the read and the write grants on any collection
+// of the branch.
+func (g *Grant) Allows(permission string, branch string, collection string) bool {
+	if permissionLevels[g.Permission] < permissionLevels[permission] {
+		return false
+	}
+	if len(g.Branch) > 0 && g.Branch != branch {
+		return false
+	}
+
+	return len(g.Collection) == 0 || g.Collection == collection || (len(collection) == 0 && permission != PermissionAdmin)
+}
+
+// Allows returns true if any grant of the role covers the permission on the branch and the collection.
+func (r *Role) Allows(permission string, branch string, collection string) bool {
+	for i := range r.Grants {
+		if r.Grants[i].Allows(permission, branch, collection) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize returns whether the subject is allowed the permission on the branch and the collection by the roles it is
+// bound to. The second return value is false when the subject isn't bound to any role of the project, the access of
+// such subjects is decided by their namespace role only.
+func (p *ProjectRBAC) Authorize(sub string, permission string, branch string, collection string) (bool, bool) {
+	bound := false
+	for _, b := range p.Bindings {
+		if b.Sub != sub {
+			continue
+		}
+
+		bound = true
+		for i := range p.Roles {
+			if p.Roles[i].Name == b.Role && p.Roles[i].Allows(permission, branch, collection) {
+				return true, true
+			}
+		}
+	}
+
+	return false, bound
+}
+
+// GetProjectRBAC reads the roles of the project from the storage rather than from the cached tenant, so the changes
+// made on other nodes are enforced without waiting for the metadata reload.
+func (m *TenantManager) GetProjectRBAC(ctx context.Context, namespaceId string, project string) (*ProjectRBAC, error) {
+	tenant, err := m.GetTenant(ctx, namespaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	return tenant.GetProjectRBAC(ctx, tx, project)
+}
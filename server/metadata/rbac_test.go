@@ -0,0 +1,73 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRole(t *testing.T) {
+	require.NoError(t, ValidateRole(&Role{Name: "r", Grants: []Grant{{Permission: PermissionRead, Collection: "c1"}}}))
+	require.NoError(t, ValidateRole(&Role{Name: "r", Grants: []Grant{{Permission: PermissionAdmin, Branch: "main"}}}))
+
+	require.Error(t, ValidateRole(&Role{Grants: []Grant{{Permission: PermissionRead}}}))
+	require.Error(t, ValidateRole(&Role{Name: "r"}))
+	require.Error(t, ValidateRole(&Role{Name: "r", Grants: []Grant{{Permission: "delete"}}}))
+	require.Error(t, ValidateRole(&Role{Name: "r", Grants: []Grant{{Permission: PermissionAdmin, Collection: "c1"}}}))
+}
+
+func TestProjectRBACAuthorize(t *testing.T) {
+	rbac := &ProjectRBAC{
+		Roles: []Role{
+			{Name: "orders_writer", Grants: []Grant{
+				{Permission: PermissionWrite, Branch: "main", Collection: "orders"},
+				{Permission: PermissionRead, Collection: "users"},
+			}},
+			{Name: "admin", Grants: []Grant{{Permission: PermissionAdmin}}},
+		},
+		Bindings: []RoleBinding{
+			{Sub: "u1", Role: "orders_writer"},
+			{Sub: "u2", Role: "admin"},
+		},
+	}
+
+	cases := []struct {
+		sub        string
+		permission string
+		branch     string
+		collection string
+		allowed    bool
+		bound      bool
+	}{
+		{"u1", PermissionRead, "main", "orders", true, true},
+		{"u1", PermissionWrite, "main", "orders", true, true},
+		{"u1", PermissionWrite, "feature", "orders", false, true},
+		{"u1", PermissionRead, "feature", "users", true, true},
+		{"u1", PermissionWrite, "main", "users", false, true},
+		{"u1", PermissionRead, "main", "products", false, true},
+		{"u1", PermissionRead, "main", "", true, true},
+		{"u1", PermissionAdmin, "main", "", false, true},
+		{"u2", PermissionAdmin, "feature", "", true, true},
+		{"u2", PermissionWrite, "main", "products", true, true},
+		{"u3", PermissionRead, "main", "orders", false, false},
+	}
+	for _, c := range cases {
+		allowed, bound := rbac.Authorize(c.sub, c.permission, c.branch, c.collection)
+		require.Equal(t, c.allowed, allowed, "%+v", c)
+		require.Equal(t, c.bound, bound, "%+v", c)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	ulog "github.com/tigrisdata/tigris/util/log"
+)
+
+const (
+	replicaMetaValueVersion int32 = 1
+	replicaMetaKeyVersion   byte  = 1
+
+	replicaClockKey = "clock"
+	replicaNodeKey  = "node"
+)
+
+// Replica is a read-only server serving the reads routed to it by the other servers.
+type Replica struct {
+	Id string `json:"id"`
+	// Address is the "host:port" the replica is reached at
+	Address string `json:"address"`
+	// Lag is the time in milliseconds the data read by the replica was behind the routing servers at the heartbeat,
+	// negative if unknown.
+	Lag         int64 `json:"lag"`
+	HeartbeatAt int64 `json:"heartbeat_at"`
+}
+
+// EffectiveLag is the lag of the replica at the time, the lag reported by its last heartbeat plus the time since then.
+// It is negative if the lag is unknown.
+func (r *Replica) EffectiveLag(now time.Time) time.Duration {
+	if r.Lag < 0 {
+		return -1
+	}
+
+	since := now.UnixMilli() - r.HeartbeatAt
+	if since < 0 {
+		since = 0
+	}
+
+	return time.Duration(r.Lag+since) * time.Millisecond
+}
+
+// ReplicaClock is written by the servers routing the reads to the replicas, the replicas measure their lag by the time
+// of the last clock they observe.
+type ReplicaClock struct {
+	At int64 `json:"at"`
+}
+
+// ReplicaSubspace stores the read-only replicas and the clock of the routing servers.
+type ReplicaSubspace struct {
+	metadataSubspace
+}
+
+func NewReplicaStore(nameRegistry *NameRegistry) *ReplicaSubspace {
+	return &ReplicaSubspace{
+		metadataSubspace{
+			SubspaceName: nameRegistry.ReplicaSubspaceName(),
+			KeyVersion:   []byte{replicaMetaKeyVersion},
+		},
+	}
+}
+
+func (r *ReplicaSubspace) clockKey() keys.Key {
+	return keys.NewKey(r.SubspaceName, r.KeyVersion, replicaClockKey)
+}
+
+func (r *ReplicaSubspace) nodesKey() keys.Key {
+	return keys.NewKey(r.SubspaceName, r.KeyVersion, replicaNodeKey)
+}
+
+func (r *ReplicaSubspace) nodeKey(id string) keys.Key {
+	return keys.NewKey(r.SubspaceName, r.KeyVersion, replicaNodeKey, id)
+}
+
+// WriteClock records the time of the clock.
+func (r *ReplicaSubspace) WriteClock(ctx context.Context, tx transaction.Tx, now time.Time) error {
+	return r.updateMetadata(ctx, tx, nil, r.clockKey(), replicaMetaValueVersion, &ReplicaClock{At: now.UnixMilli()})
+}
+
+// ReadClock returns the time of the last clock, errors.ErrNotFound if no server routes the reads.
+func (r *ReplicaSubspace) ReadClock(ctx context.Context, tx transaction.Tx) (time.Time, error) {
+	var clock ReplicaClock
+	if err := r.getMetadata(ctx, tx, nil, r.clockKey(), &clock); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(clock.At), nil
+}
+
+// Heartbeat registers the replica or refreshes its registration.
+func (r *ReplicaSubspace) Heartbeat(ctx context.Context, tx transaction.Tx, replica *Replica) error {
+	return r.updateMetadata(ctx, tx, nil, r.nodeKey(replica.Id), replicaMetaValueVersion, replica)
+}
+
+// Deregister removes the replica.
+func (r *ReplicaSubspace) Deregister(ctx context.Context, tx transaction.Tx, id string) error {
+	return r.deleteMetadata(ctx, tx, nil, r.nodeKey(id))
+}
+
+// List returns the registered replicas.
+func (r *ReplicaSubspace) List(ctx context.Context, tx transaction.Tx) ([]Replica, error) {
+	it, err := tx.Read(ctx, r.nodesKey(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := []Replica{}
+	var row kv.KeyValue
+	for it.Next(&row) {
+		var replica Replica
+		if err = jsoniter.Unmarshal(row.Data.RawData, &replica); ulog.E(err) {
+			return nil, errors.Internal("failed to unmarshal replica")
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return replicas, it.Err()
+}
+
+// Expire removes the replicas which haven't sent a heartbeat for the ttl and returns the remaining ones.
+func (r *ReplicaSubspace) Expire(ctx context.Context, tx transaction.Tx, ttl time.Duration, now time.Time) ([]Replica, error) {
+	replicas, err := r.List(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make([]Replica, 0, len(replicas))
+	for _, replica := range replicas {
+		if replica.HeartbeatAt+ttl.Milliseconds() >= now.UnixMilli() {
+			alive = append(alive, replica)
+			continue
+		}
+
+		if err = r.Deregister(ctx, tx, replica.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return alive, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func initReplicaTest(t *testing.T) (*ReplicaSubspace, transaction.Tx, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := NewReplicaStore(newTestNameRegistry(t))
+
+	_ = kvStore.DropTable(ctx, r.SubspaceName)
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+
+	return r, tx, func() {
+		assert.NoError(t, tx.Rollback(ctx))
+
+		_ = kvStore.DropTable(ctx, r.SubspaceName)
+	}
+}
+
+func TestReplicaSubspace(t *testing.T) {
+	t.Run("clock", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		r, tx, cleanup := initReplicaTest(t)
+		defer cleanup()
+
+		_, err := r.ReadClock(ctx, tx)
+		require.Equal(t, errors.ErrNotFound, err)
+
+		now := time.UnixMilli(time.Now().UnixMilli())
+		require.NoError(t, r.WriteClock(ctx, tx, now))
+
+		clock, err := r.ReadClock(ctx, tx)
+		require.NoError(t, err)
+		require.Equal(t, now, clock)
+	})
+
+	t.Run("replicas", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		r, tx, cleanup := initReplicaTest(t)
+		defer cleanup()
+
+		now := time.Now()
+		require.NoError(t, r.Heartbeat(ctx, tx, &Replica{Id: "r1", Address: "host1:8081", Lag: 100, HeartbeatAt: now.UnixMilli()}))
+		require.NoError(t, r.Heartbeat(ctx, tx, &Replica{Id: "r2", Address: "host2:8081", Lag: -1, HeartbeatAt: now.Add(-time.Minute).UnixMilli()}))
+
+		// refreshed by the next heartbeat
+		require.NoError(t, r.Heartbeat(ctx, tx, &Replica{Id: "r1", Address: "host1:8081", Lag: 50, HeartbeatAt: now.UnixMilli()}))
+
+		replicas, err := r.List(ctx, tx)
+		require.NoError(t, err)
+		require.Len(t, replicas, 2)
+		require.Equal(t, int64(50), replicas[0].Lag)
+
+		replicas, err = r.Expire(ctx, tx, 30*time.Second, now)
+		require.NoError(t, err)
+		require.Len(t, replicas, 1)
+		require.Equal(t, "r1", replicas[0].Id)
+
+		replicas, err = r.List(ctx, tx)
+		require.NoError(t, err)
+		require.Len(t, replicas, 1)
+
+		require.NoError(t, r.Deregister(ctx, tx, "r1"))
+		replicas, err = r.List(ctx, tx)
+		require.NoError(t, err)
+		require.Empty(t, replicas)
+	})
+}
+
+func TestReplicaEffectiveLag(t *testing.T) {
+	now := time.Now()
+
+	r := Replica{Lag: 100, HeartbeatAt: now.Add(-time.Second).UnixMilli()}
+	require.Equal(t, 1100*time.Millisecond, r.EffectiveLag(now))
+
+	r = Replica{Lag: 100, HeartbeatAt: now.Add(time.Second).UnixMilli()}
+	require.Equal(t, 100*time.Millisecond, r.EffectiveLag(now))
+
+	r = Replica{Lag: -1, HeartbeatAt: now.UnixMilli()}
+	require.Less(t, r.EffectiveLag(now), time.Duration(0))
+}
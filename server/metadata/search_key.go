@@ -0,0 +1,152 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// SearchKeyPrefix is the prefix of the search-only keys, it distinguishes them from the JWT access tokens in the
+// authorization header. The key is "tsk_" followed by the base64 encoded "namespace/project/id" and the secret
+// separated by a dot.
+const SearchKeyPrefix = "tsk_"
+
+const (
+	searchKeyIdLen     = 8
+	searchKeySecretLen = 24
+)
+
+var ErrInvalidSearchKey = errors.Unauthenticated("invalid search key")
+
+// SearchKeyGetter reads the search-only keys of the projects, it is used to authenticate the requests made with them.
+type SearchKeyGetter interface {
+	GetSearchKey(ctx context.Context, namespaceId string, project string, id string) (*SearchKey, error)
+}
+
+// NewSearchKey returns a new search key with a random id and the secret of the key. The secret is not stored, so it
+// is only returned to the caller creating the key.
+func NewSearchKey(name string, indexes []string, qps int, expiresAt int64) (*SearchKey, string, error) {
+	id, err := randomHex(searchKeyIdLen)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomHex(searchKeySecretLen)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &SearchKey{
+		Id:         id,
+		Name:       name,
+		SecretHash: hashSearchKeySecret(secret),
+		Indexes:    indexes,
+		QPS:        qps,
+		ExpiresAt:  expiresAt,
+	}, secret, nil
+}
+
+// EncodeSearchKey returns the key the clients put in the authorization header.
+func EncodeSearchKey(namespaceId string, project string, id string, secret string) string {
+	scope := base64.RawURLEncoding.EncodeToString([]byte(namespaceId + "/" + project + "/" + id))
+
+	return SearchKeyPrefix + scope + "." + secret
+}
+
+// DecodeSearchKey returns the namespace, the project, the id and the secret of the key.
+func DecodeSearchKey(key string) (string, string, string, string, error) {
+	if !strings.HasPrefix(key, SearchKeyPrefix) {
+		return "", "", "", "", ErrInvalidSearchKey
+	}
+
+	encoded, secret, found := strings.Cut(strings.TrimPrefix(key, SearchKeyPrefix), ".")
+	if !found || len(secret) == 0 {
+		return "", "", "", "", ErrInvalidSearchKey
+	}
+
+	scope, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", "", "", ErrInvalidSearchKey
+	}
+
+	parts := strings.Split(string(scope), "/")
+	if len(parts) != 3 || len(parts[0]) == 0 || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		return "", "", "", "", ErrInvalidSearchKey
+	}
+
+	return parts[0], parts[1], parts[2], secret, nil
+}
+
+// Verify returns true if the secret belongs to the key.
+func (k *SearchKey) Verify(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.SecretHash), []byte(hashSearchKeySecret(secret))) == 1
+}
+
+// IsExpired returns true if the key can't be used anymore.
+func (k *SearchKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt > 0 && now.Unix() >= k.ExpiresAt
+}
+
+// Allows returns true if the key can search the collection or the search index.
+func (k *SearchKey) Allows(index string) bool {
+	for _, i := range k.Indexes {
+		if i == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashSearchKeySecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(h[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Internal("failed to generate search key")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// GetSearchKey reads the search key of the project from the storage rather than from the cached tenant, so a deleted
+// key is rejected on every node without waiting for the metadata reload.
+func (m *TenantManager) GetSearchKey(ctx context.Context, namespaceId string, project string, id string) (*SearchKey, error) {
+	tenant, err := m.GetTenant(ctx, namespaceId)
+	if err != nil {
+		return nil, ErrInvalidSearchKey
+	}
+
+	tx, err := m.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	return tenant.GetSearchKey(ctx, tx, project, id)
+}
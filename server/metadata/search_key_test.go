@@ -0,0 +1,51 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchKey(t *testing.T) {
+	key, secret, err := NewSearchKey("web", []string{"products"}, 10, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, key.SecretHash)
+
+	encoded := EncodeSearchKey("ns1", "p1", key.Id, secret)
+	require.True(t, len(encoded) > len(SearchKeyPrefix))
+
+	ns, project, id, decodedSecret, err := DecodeSearchKey(encoded)
+	require.NoError(t, err)
+	require.Equal(t, "ns1", ns)
+	require.Equal(t, "p1", project)
+	require.Equal(t, key.Id, id)
+	require.True(t, key.Verify(decodedSecret))
+	require.False(t, key.Verify(decodedSecret+"x"))
+
+	require.True(t, key.Allows("products"))
+	require.False(t, key.Allows("users"))
+
+	require.False(t, key.IsExpired(time.Now()))
+	key.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	require.True(t, key.IsExpired(time.Now()))
+
+	for _, invalid := range []string{"", "tsk_", "tsk_abc", "eyJhbGciOi.x.y", SearchKeyPrefix + "bnMx.secret"} {
+		_, _, _, _, err = DecodeSearchKey(invalid)
+		require.Equal(t, ErrInvalidSearchKey, err, invalid)
+	}
+}
@@ -48,6 +48,10 @@ type NameRegistry struct {
 	ClusterSB   string
 	VersionKey  string
 	QueueSB     string
+	// JobSB is the name of the table(subspace) of the runs of the scheduled jobs and of the lease of the scheduler
+	JobSB string
+	// ReplicaSB is the name of the table(subspace) of the read-only replicas and of the clock of the routing servers
+	ReplicaSB string
 
 	BaseCounterValue uint32
 }
@@ -61,6 +65,8 @@ var DefaultNameRegistry = &NameRegistry{
 	NamespaceSB: "namespace",
 	ClusterSB:   "cluster",
 	QueueSB:     "queue",
+	JobSB:       "job",
+	ReplicaSB:   "replica",
 
 	BaseCounterValue: reservedBaseValue,
 }
@@ -97,6 +103,14 @@ func (d *NameRegistry) QueueSubspaceName() []byte {
 	return []byte(d.QueueSB)
 }
 
+func (d *NameRegistry) JobSubspaceName() []byte {
+	return []byte(d.JobSB)
+}
+
+func (d *NameRegistry) ReplicaSubspaceName() []byte {
+	return []byte(d.ReplicaSB)
+}
+
 func (d *NameRegistry) GetVersionKey() []byte {
 	return []byte(d.VersionKey)
 }
@@ -114,6 +128,8 @@ func newTestNameRegistry(t *testing.T) *NameRegistry {
 		NamespaceSB: "test_namespace_" + s,
 		ClusterSB:   "test_cluster_" + s,
 		QueueSB:     "test_queue_" + s,
+		JobSB:       "test_job_" + s,
+		ReplicaSB:   "test_replica_" + s,
 		VersionKey:  "test_version_key" + s,
 
 		BaseCounterValue: r.Uint32(),
@@ -28,6 +28,7 @@ import (
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/kms"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/store/search"
@@ -153,7 +154,7 @@ func (m *TenantManager) EnsureDefaultNamespace() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		_, err = m.CreateOrGetTenant(ctx, NewDefaultNamespace())
 		cancel()
-		if err != kv.ErrConflictingTransaction {
+		if !kv.IsConflict(err) {
 			return err
 		}
 		time.Sleep(1 * time.Second)
@@ -663,6 +664,7 @@ func (tenant *Tenant) reload(ctx context.Context, tx transaction.Tx, currentVers
 		if ulog.E(err) {
 			return err
 		}
+		database.expiresAt = meta.ExpiresAt
 
 		project := tenant.projects[database.DbName()] // get the parent project or parent db using DbName()
 		if database.IsBranch() {
@@ -745,6 +747,12 @@ func (tenant *Tenant) reloadDatabase(ctx context.Context, tx transaction.Tx, dbN
 			continue
 		}
 
+		if err = tenant.attachFieldEncryptor(ctx, tx, database, collection, meta.EncryptionKey); err != nil {
+			database.needFixingCollections[coll] = struct{}{}
+			log.Error().Err(err).Str("collection", coll).Msg("skipping loading collection")
+			continue
+		}
+
 		encName, err := tenant.Encoder.EncodeTableName(tenant.namespace, database, collection)
 		if err != nil {
 			return nil, err
@@ -790,7 +798,9 @@ func (tenant *Tenant) reloadSearch(ctx context.Context, tx transaction.Tx, proje
 		if searchIndexInStore, ok := searchSchemasSnapshot[searchStoreIndexName]; ok {
 			fieldsInSearchStore = searchIndexInStore.Fields
 		}
-		searchObj.indexes[searchMD.Name] = schema.NewSearchIndex(schV.Version, searchStoreIndexName, searchFactory, fieldsInSearchStore)
+		index := schema.NewSearchIndex(schV.Version, searchStoreIndexName, searchFactory, fieldsInSearchStore)
+		index.SetDictionary(searchMD.Dictionary)
+		searchObj.indexes[searchMD.Name] = index
 	}
 
 	return searchObj, nil
@@ -872,6 +882,7 @@ func (tenant *Tenant) updateSearchIndex(ctx context.Context, tx transaction.Tx,
 	}
 
 	updatedIndex := schema.NewSearchIndex(version, index.StoreIndexName(), factory, previousIndexInStore.Fields)
+	updatedIndex.SetDictionary(index.Dictionary)
 
 	// update indexing store schema if there is a change
 	if deltaFields := updatedIndex.GetSearchDeltaFields(index.QueryableFields, previousIndexInStore.Fields); len(deltaFields) > 0 {
@@ -886,6 +897,89 @@ func (tenant *Tenant) updateSearchIndex(ctx context.Context, tx transaction.Tx,
 	return nil
 }
 
+// UpdateSearchDictionary applies the update to a copy of the synonym sets and the stopword lists of the search index,
+// persists the result in the project metadata and syncs the changed synonym sets to the search store.
+func (tenant *Tenant) UpdateSearchDictionary(ctx context.Context, tx transaction.Tx, project *Project, indexName string, update func(*schema.SearchDictionary) error) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	index, ok := project.search.GetIndex(indexName)
+	if !ok {
+		return NewSearchIndexNotFoundErr(indexName)
+	}
+
+	dictionary := index.Dictionary.Clone()
+	if err := update(dictionary); err != nil {
+		return err
+	}
+
+	metadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project.Name())
+	if err != nil {
+		return errors.Internal("failed to get project metadata for project %s", project.Name())
+	}
+
+	found := false
+	for i := range metadata.SearchMetadata {
+		if metadata.SearchMetadata[i].Name == indexName {
+			metadata.SearchMetadata[i].Dictionary = dictionary
+			if dictionary.IsEmpty() {
+				metadata.SearchMetadata[i].Dictionary = nil
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return NewSearchIndexNotFoundErr(indexName)
+	}
+
+	if err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project.Name(), metadata); err != nil {
+		return errors.Internal("failed to update project metadata for search index dictionary")
+	}
+
+	if err = tenant.syncSearchSynonyms(ctx, index, dictionary); err != nil {
+		return err
+	}
+
+	updatedIndex := *index
+	updatedIndex.SetDictionary(dictionary)
+	project.search.AddIndex(&updatedIndex)
+
+	return nil
+}
+
+// syncSearchSynonyms pushes the synonym sets that are added or replaced in the dictionary to the search store and
+// deletes the removed ones from it.
+func (tenant *Tenant) syncSearchSynonyms(ctx context.Context, index *schema.SearchIndex, dictionary *schema.SearchDictionary) error {
+	for _, s := range dictionary.Synonyms {
+		if index.Dictionary.GetSynonym(s.ID) == s {
+			continue
+		}
+
+		synonym := &tsApi.SearchSynonymSchema{Synonyms: s.Synonyms}
+		if len(s.Root) > 0 {
+			synonym.Root = &s.Root
+		}
+		if err := tenant.searchStore.UpsertSynonym(ctx, index.StoreIndexName(), s.ID, synonym); err != nil {
+			return err
+		}
+	}
+
+	if index.Dictionary == nil {
+		return nil
+	}
+	for _, s := range index.Dictionary.Synonyms {
+		if dictionary.GetSynonym(s.ID) != nil {
+			continue
+		}
+		if err := tenant.searchStore.DeleteSynonym(ctx, index.StoreIndexName(), s.ID); err != nil && !search.IsErrNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (tenant *Tenant) GetSearchIndex(_ context.Context, _ transaction.Tx, project *Project, indexName string) (*schema.SearchIndex, error) {
 	tenant.Lock()
 	defer tenant.Unlock()
@@ -1041,6 +1135,537 @@ func (tenant *Tenant) DeleteCache(ctx context.Context, tx transaction.Tx, projec
 	return true, nil
 }
 
+// CreateOrUpdateSchemaTemplate creates the schema template in the project or replaces the schema of the existing
+// template. The version of the template is incremented on every update.
+func (tenant *Tenant) CreateOrUpdateSchemaTemplate(ctx context.Context, tx transaction.Tx, project string, name string,
+	sch []byte, currentSub string,
+) (*SchemaTemplate, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var template *SchemaTemplate
+	for i := range projMetadata.Templates {
+		if projMetadata.Templates[i].Name == name {
+			template = &projMetadata.Templates[i]
+		}
+	}
+
+	now := time.Now().Unix()
+	if template == nil {
+		projMetadata.Templates = append(projMetadata.Templates, SchemaTemplate{
+			Name:      name,
+			Creator:   currentSub,
+			CreatedAt: now,
+		})
+		template = &projMetadata.Templates[len(projMetadata.Templates)-1]
+	}
+	template.Schema = sch
+	template.Version++
+	template.UpdatedAt = now
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return nil, errors.Internal("Failed to update project metadata for schema template")
+	}
+
+	return template, nil
+}
+
+// GetSchemaTemplate returns the schema template of the project.
+func (tenant *Tenant) GetSchemaTemplate(ctx context.Context, tx transaction.Tx, project string, name string) (*SchemaTemplate, error) {
+	templates, err := tenant.ListSchemaTemplates(ctx, tx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+
+	return nil, errors.NotFound("schema template not found '%s'", name)
+}
+
+// ListSchemaTemplates returns all the schema templates of the project.
+func (tenant *Tenant) ListSchemaTemplates(ctx context.Context, tx transaction.Tx, project string) ([]SchemaTemplate, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+	if projMetadata.Templates == nil {
+		return []SchemaTemplate{}, nil
+	}
+
+	return projMetadata.Templates, nil
+}
+
+// DeleteSchemaTemplate removes the schema template from the project. A template can't be removed while there are
+// collections extending it in any branch of the project.
+func (tenant *Tenant) DeleteSchemaTemplate(ctx context.Context, tx transaction.Tx, project string, name string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	if proj, ok := tenant.projects[project]; ok {
+		for _, db := range proj.GetDatabaseWithBranches() {
+			for _, coll := range db.ListCollection() {
+				if schema.GetTemplateName(coll.Schema) == name {
+					return errors.InvalidArgument("schema template '%s' is extended by the collection '%s'", name, coll.Name)
+				}
+			}
+		}
+	}
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var templates []SchemaTemplate
+	for i := range projMetadata.Templates {
+		if projMetadata.Templates[i].Name != name {
+			templates = append(templates, projMetadata.Templates[i])
+		}
+	}
+	if len(templates) == len(projMetadata.Templates) {
+		return errors.NotFound("schema template not found '%s'", name)
+	}
+	projMetadata.Templates = templates
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for schema template deletion")
+	}
+
+	return nil
+}
+
+// CreateSearchKey adds the search-only key to the project.
+func (tenant *Tenant) CreateSearchKey(ctx context.Context, tx transaction.Tx, project string, key *SearchKey, currentSub string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	for i := range projMetadata.SearchKeys {
+		if len(key.Name) > 0 && projMetadata.SearchKeys[i].Name == key.Name {
+			return errors.AlreadyExists("search key with the same name already exists '%s'", key.Name)
+		}
+	}
+
+	key.Creator = currentSub
+	key.CreatedAt = time.Now().Unix()
+	projMetadata.SearchKeys = append(projMetadata.SearchKeys, *key)
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for search key creation")
+	}
+
+	return nil
+}
+
+// GetSearchKey returns the search-only key of the project.
+func (tenant *Tenant) GetSearchKey(ctx context.Context, tx transaction.Tx, project string, id string) (*SearchKey, error) {
+	keys, err := tenant.ListSearchKeys(ctx, tx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		if keys[i].Id == id {
+			return &keys[i], nil
+		}
+	}
+
+	return nil, errors.NotFound("search key not found '%s'", id)
+}
+
+// ListSearchKeys returns all the search-only keys of the project.
+func (tenant *Tenant) ListSearchKeys(ctx context.Context, tx transaction.Tx, project string) ([]SearchKey, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+	if projMetadata.SearchKeys == nil {
+		return []SearchKey{}, nil
+	}
+
+	return projMetadata.SearchKeys, nil
+}
+
+// DeleteSearchKey removes the search-only key from the project.
+func (tenant *Tenant) DeleteSearchKey(ctx context.Context, tx transaction.Tx, project string, id string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var keys []SearchKey
+	for i := range projMetadata.SearchKeys {
+		if projMetadata.SearchKeys[i].Id != id {
+			keys = append(keys, projMetadata.SearchKeys[i])
+		}
+	}
+	if len(keys) == len(projMetadata.SearchKeys) {
+		return errors.NotFound("search key not found '%s'", id)
+	}
+	projMetadata.SearchKeys = keys
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for search key deletion")
+	}
+
+	return nil
+}
+
+// CreateOrUpdateRole adds the role to the project or replaces the grants of the existing role with the same name. It
+// returns true if the role is created.
+func (tenant *Tenant) CreateOrUpdateRole(ctx context.Context, tx transaction.Tx, project string, role *Role, currentSub string) (bool, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return false, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	created := true
+	for i := range projMetadata.Roles {
+		if projMetadata.Roles[i].Name == role.Name {
+			projMetadata.Roles[i].Grants = role.Grants
+			*role = projMetadata.Roles[i]
+			created = false
+			break
+		}
+	}
+
+	if created {
+		role.Creator = currentSub
+		role.CreatedAt = time.Now().Unix()
+		projMetadata.Roles = append(projMetadata.Roles, *role)
+	}
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return false, errors.Internal("Failed to update project metadata for role creation")
+	}
+
+	return created, nil
+}
+
+// DeleteRole removes the role from the project. The role can't be deleted while it is bound to any subject.
+func (tenant *Tenant) DeleteRole(ctx context.Context, tx transaction.Tx, project string, name string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	for i := range projMetadata.RoleBindings {
+		if projMetadata.RoleBindings[i].Role == name {
+			return errors.InvalidArgument("role '%s' is bound to '%s', delete the binding first", name,
+				projMetadata.RoleBindings[i].Sub)
+		}
+	}
+
+	var roles []Role
+	for i := range projMetadata.Roles {
+		if projMetadata.Roles[i].Name != name {
+			roles = append(roles, projMetadata.Roles[i])
+		}
+	}
+	if len(roles) == len(projMetadata.Roles) {
+		return errors.NotFound("role not found '%s'", name)
+	}
+	projMetadata.Roles = roles
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for role deletion")
+	}
+
+	return nil
+}
+
+// CreateRoleBinding binds the role of the project to the subject.
+func (tenant *Tenant) CreateRoleBinding(ctx context.Context, tx transaction.Tx, project string, binding *RoleBinding, currentSub string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	found := false
+	for i := range projMetadata.Roles {
+		if projMetadata.Roles[i].Name == binding.Role {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.NotFound("role not found '%s'", binding.Role)
+	}
+
+	for i := range projMetadata.RoleBindings {
+		if projMetadata.RoleBindings[i].Sub == binding.Sub && projMetadata.RoleBindings[i].Role == binding.Role {
+			return errors.AlreadyExists("role '%s' is already bound to '%s'", binding.Role, binding.Sub)
+		}
+	}
+
+	binding.Creator = currentSub
+	binding.CreatedAt = time.Now().Unix()
+	projMetadata.RoleBindings = append(projMetadata.RoleBindings, *binding)
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for role binding creation")
+	}
+
+	return nil
+}
+
+// DeleteRoleBinding removes the binding of the role to the subject.
+func (tenant *Tenant) DeleteRoleBinding(ctx context.Context, tx transaction.Tx, project string, sub string, role string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var bindings []RoleBinding
+	for i := range projMetadata.RoleBindings {
+		if projMetadata.RoleBindings[i].Sub != sub || projMetadata.RoleBindings[i].Role != role {
+			bindings = append(bindings, projMetadata.RoleBindings[i])
+		}
+	}
+	if len(bindings) == len(projMetadata.RoleBindings) {
+		return errors.NotFound("role '%s' is not bound to '%s'", role, sub)
+	}
+	projMetadata.RoleBindings = bindings
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for role binding deletion")
+	}
+
+	return nil
+}
+
+// CreateOrUpdateJob adds the scheduled job to the project or replaces the existing job with the same name. It returns
+// true if the job is created.
+func (tenant *Tenant) CreateOrUpdateJob(ctx context.Context, tx transaction.Tx, project string, job *Job, currentSub string) (bool, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return false, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	now := time.Now().Unix()
+	created := true
+	for i := range projMetadata.Jobs {
+		if projMetadata.Jobs[i].Name == job.Name {
+			job.Creator = projMetadata.Jobs[i].Creator
+			job.CreatedAt = projMetadata.Jobs[i].CreatedAt
+			job.UpdatedAt = now
+			projMetadata.Jobs[i] = *job
+			created = false
+			break
+		}
+	}
+
+	if created {
+		job.Creator = currentSub
+		job.CreatedAt = now
+		job.UpdatedAt = now
+		projMetadata.Jobs = append(projMetadata.Jobs, *job)
+	}
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return false, errors.Internal("Failed to update project metadata for job creation")
+	}
+
+	return created, nil
+}
+
+// ListJobs returns all the scheduled jobs of the project.
+func (tenant *Tenant) ListJobs(ctx context.Context, tx transaction.Tx, project string) ([]Job, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+	if projMetadata.Jobs == nil {
+		return []Job{}, nil
+	}
+
+	return projMetadata.Jobs, nil
+}
+
+// DeleteJob removes the scheduled job from the project, the history of its runs is kept until it's pruned.
+func (tenant *Tenant) DeleteJob(ctx context.Context, tx transaction.Tx, project string, name string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var jobs []Job
+	for i := range projMetadata.Jobs {
+		if projMetadata.Jobs[i].Name != name {
+			jobs = append(jobs, projMetadata.Jobs[i])
+		}
+	}
+	if len(jobs) == len(projMetadata.Jobs) {
+		return errors.NotFound("job not found '%s'", name)
+	}
+	projMetadata.Jobs = jobs
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for job deletion")
+	}
+
+	return nil
+}
+
+// GetProjectRBAC returns the roles and the role bindings of the project.
+func (tenant *Tenant) GetProjectRBAC(ctx context.Context, tx transaction.Tx, project string) (*ProjectRBAC, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	rbac := &ProjectRBAC{Roles: projMetadata.Roles, Bindings: projMetadata.RoleBindings}
+	if rbac.Roles == nil {
+		rbac.Roles = []Role{}
+	}
+	if rbac.Bindings == nil {
+		rbac.Bindings = []RoleBinding{}
+	}
+
+	return rbac, nil
+}
+
+// SetAppKeyScope sets the scope of the app key of the project, replacing the existing one.
+func (tenant *Tenant) SetAppKeyScope(ctx context.Context, tx transaction.Tx, project string, scope *AppKeyScope, currentSub string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	now := time.Now().Unix()
+	scope.Creator = currentSub
+	scope.CreatedAt = now
+	scope.UpdatedAt = now
+
+	found := false
+	for i := range projMetadata.AppKeyScopes {
+		if projMetadata.AppKeyScopes[i].Id == scope.Id {
+			scope.Creator = projMetadata.AppKeyScopes[i].Creator
+			scope.CreatedAt = projMetadata.AppKeyScopes[i].CreatedAt
+			projMetadata.AppKeyScopes[i] = *scope
+			found = true
+			break
+		}
+	}
+	if !found {
+		projMetadata.AppKeyScopes = append(projMetadata.AppKeyScopes, *scope)
+	}
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for app key scope")
+	}
+
+	return nil
+}
+
+// GetAppKeyScope returns the scope of the app key of the project, nil if the key has no scope.
+func (tenant *Tenant) GetAppKeyScope(ctx context.Context, tx transaction.Tx, project string, id string) (*AppKeyScope, error) {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return nil, errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	for i := range projMetadata.AppKeyScopes {
+		if projMetadata.AppKeyScopes[i].Id == id {
+			return &projMetadata.AppKeyScopes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// DeleteAppKeyScope removes the scope of the app key, the key is no longer limited by it.
+func (tenant *Tenant) DeleteAppKeyScope(ctx context.Context, tx transaction.Tx, project string, id string) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	projMetadata, err := tenant.namespaceStore.GetProjectMetadata(ctx, tx, tenant.namespace.Id(), project)
+	if err != nil {
+		return errors.Internal("Failed to get project metadata for project %s", project)
+	}
+
+	var scopes []AppKeyScope
+	for i := range projMetadata.AppKeyScopes {
+		if projMetadata.AppKeyScopes[i].Id != id {
+			scopes = append(scopes, projMetadata.AppKeyScopes[i])
+		}
+	}
+	if len(scopes) == len(projMetadata.AppKeyScopes) {
+		return errors.NotFound("app key scope not found '%s'", id)
+	}
+	projMetadata.AppKeyScopes = scopes
+
+	err = tenant.namespaceStore.UpdateProjectMetadata(ctx, tx, tenant.namespace.Id(), project, projMetadata)
+	if err != nil {
+		return errors.Internal("Failed to update project metadata for app key scope deletion")
+	}
+
+	return nil
+}
+
 // CreateProject is responsible for creating a Project. This includes creating a dictionary encoding entry for the main
 // database that will be attached to this project. This method is not adding the entry to the tenant because the outer
 // layer may still roll back the transaction. The session manager is bumping the metadata version once the commit is
@@ -1114,6 +1739,18 @@ func (tenant *Tenant) DeleteProject(ctx context.Context, tx transaction.Tx, proj
 		}
 	}
 
+	if err := tenant.dropEventStreams(ctx, proj.database); err != nil {
+		return true, err
+	}
+	if err := tenant.dropQueues(ctx, proj.database); err != nil {
+		return true, err
+	}
+	if config.DefaultConfig.Server.FDBHardDrop {
+		if err := tenant.kvStore.DropTable(ctx, tenant.Encoder.EncodeRealtimeHistoryTableName(tenant.namespace, proj.database)); err != nil {
+			return true, err
+		}
+	}
+
 	for key := range proj.search.indexes {
 		if err := tenant.deleteSearchIndex(ctx, tx, proj, proj.search.indexes[key]); err != nil {
 			return true, err
@@ -1219,6 +1856,25 @@ func (tenant *Tenant) CreateBranch(ctx context.Context, tx transaction.Tx, projN
 	return nil
 }
 
+// SetBranchExpiration makes the branch ephemeral, it is deleted once the Unix time expiresAt is passed.
+func (tenant *Tenant) SetBranchExpiration(ctx context.Context, tx transaction.Tx, projName string, dbBranch *DatabaseName, expiresAt int64) error {
+	tenant.Lock()
+	defer tenant.Unlock()
+
+	if dbBranch.IsMainBranch() {
+		return NewMetadataError(ErrCodeCannotDeleteBranch, "'main' database cannot expire.")
+	}
+
+	meta, err := tenant.MetaStore.Database().Get(ctx, tx, tenant.namespace.Id(), dbBranch.Name())
+	if err != nil {
+		return err
+	}
+
+	meta.ExpiresAt = expiresAt
+
+	return tenant.MetaStore.Database().Update(ctx, tx, tenant.namespace.Id(), dbBranch.Name(), meta)
+}
+
 // DeleteBranch is responsible for deleting a database branch. Throws error if database/branch does not exist
 // or if 'main' branch is being deleted.
 func (tenant *Tenant) DeleteBranch(ctx context.Context, tx transaction.Tx, projName string, dbBranch *DatabaseName) error {
@@ -1261,7 +1917,30 @@ func (tenant *Tenant) deleteBranch(ctx context.Context, tx transaction.Tx, proje
 			}
 		}
 	}
-	return nil
+
+	if err := tenant.dropEventStreams(ctx, branch); err != nil {
+		return err
+	}
+
+	return tenant.dropQueues(ctx, branch)
+}
+
+// dropEventStreams deletes the events of the streams of the database branch.
+func (tenant *Tenant) dropEventStreams(ctx context.Context, db *Database) error {
+	if !config.DefaultConfig.Server.FDBHardDrop {
+		return nil
+	}
+
+	return tenant.kvStore.DropTable(ctx, tenant.Encoder.EncodeEventStreamTableName(tenant.namespace, db))
+}
+
+// dropQueues deletes the queues of the database branch and their messages.
+func (tenant *Tenant) dropQueues(ctx context.Context, db *Database) error {
+	if !config.DefaultConfig.Server.FDBHardDrop {
+		return nil
+	}
+
+	return tenant.kvStore.DropTable(ctx, tenant.Encoder.EncodeQueueTableName(tenant.namespace, db))
 }
 
 // ListDatabaseBranches returns an array of branch names associated with this database including "main" branch.
@@ -1298,6 +1977,10 @@ func (tenant *Tenant) createCollection(ctx context.Context, tx transaction.Tx, d
 		return errors.NotFound("database missing")
 	}
 
+	if err := schema.ValidateReferences(schFactory, database.GetCollection); err != nil {
+		return err
+	}
+
 	// first check if we need to run update collection
 	if c, ok := database.collections[schFactory.Name]; ok {
 		// not current version provided in the request.
@@ -1377,6 +2060,10 @@ func (tenant *Tenant) createCollection(ctx context.Context, tx transaction.Tx, d
 		return err
 	}
 
+	if err = tenant.attachFieldEncryptor(ctx, tx, database, collection, collMeta.EncryptionKey); err != nil {
+		return err
+	}
+
 	encName, err := tenant.Encoder.EncodeTableName(tenant.namespace, database, collection)
 	if err != nil {
 		return err
@@ -1476,6 +2163,10 @@ func (tenant *Tenant) updateCollection(ctx context.Context, tx transaction.Tx, d
 		return err
 	}
 
+	if err = tenant.attachFieldEncryptor(ctx, tx, database, collection, collMeta.EncryptionKey); err != nil {
+		return err
+	}
+
 	encName, err := tenant.Encoder.EncodeTableName(tenant.namespace, database, collection)
 	if err != nil {
 		return err
@@ -1506,6 +2197,51 @@ func (tenant *Tenant) updateCollection(ctx context.Context, tx transaction.Tx, d
 	return nil
 }
 
+// attachFieldEncryptor unwraps the data key of the collection and attaches the field encryptor to it. The data key is
+// generated and persisted the first time a collection with encrypted fields is created.
+func (tenant *Tenant) attachFieldEncryptor(ctx context.Context, tx transaction.Tx, database *Database,
+	collection *schema.DefaultCollection, wrappedKey []byte,
+) error {
+	if !collection.HasEncryptedFields() {
+		return nil
+	}
+
+	km := kms.Get()
+	if km == nil {
+		return errors.InvalidArgument("encrypted fields are not supported, encryption is not enabled on the server")
+	}
+
+	if len(wrappedKey) == 0 {
+		dataKey, err := kms.GenerateDataKey()
+		if ulog.E(err) {
+			return errors.Internal("failed to generate collection encryption key")
+		}
+
+		if wrappedKey, err = km.WrapKey(ctx, dataKey); ulog.E(err) {
+			return errors.Internal("failed to wrap collection encryption key")
+		}
+
+		if _, err = tenant.MetaStore.Collection().SetEncryptionKey(ctx, tx, tenant.namespace.Id(), database.id,
+			collection.Name, wrappedKey); err != nil {
+			return err
+		}
+	}
+
+	dataKey, err := km.UnwrapKey(ctx, wrappedKey)
+	if ulog.E(err) {
+		return errors.Internal("failed to unwrap collection encryption key")
+	}
+
+	fieldCipher, err := kms.NewFieldCipher(dataKey)
+	if ulog.E(err) {
+		return errors.Internal("failed to initialize collection encryption")
+	}
+
+	collection.FieldEncryptor = fieldCipher
+
+	return nil
+}
+
 // UpdateCollectionIndexes Updates the indexes for a collection.
 func (tenant *Tenant) UpdateCollectionIndexes(ctx context.Context, tx transaction.Tx, db *Database, collectionName string, indexes []*schema.Index) error {
 	tenant.Lock()
@@ -1523,6 +2259,17 @@ func (tenant *Tenant) UpdateCollectionIndexes(ctx context.Context, tx transactio
 	return nil
 }
 
+// PublishCollectionIndexes persists the indexes of the collection like UpdateCollectionIndexes and bumps the metadata
+// version, so that the servers reload the collection and the queries observe the new state of the indexes. It is used
+// by the background jobs which, unlike the DDL requests, don't bump the version on commit.
+func (tenant *Tenant) PublishCollectionIndexes(ctx context.Context, tx transaction.Tx, db *Database, collectionName string, indexes []*schema.Index) error {
+	if err := tenant.UpdateCollectionIndexes(ctx, tx, db, collectionName, indexes); err != nil {
+		return err
+	}
+
+	return tenant.versionH.Increment(ctx, tx)
+}
+
 func (tenant *Tenant) GetCollectionMetadata(ctx context.Context, tx transaction.Tx, db *Database, collectionName string) (*CollectionMetadata, error) {
 	metadata, err := tenant.MetaStore.Collection().Get(ctx, tx, tenant.namespace.Id(), db.id, collectionName)
 	if err != nil {
@@ -1532,12 +2279,50 @@ func (tenant *Tenant) GetCollectionMetadata(ctx context.Context, tx transaction.
 	return metadata, nil
 }
 
+// GetCollectionSchemaVersions returns all the schema versions persisted for the collection, sorted in ascending
+// version order.
+func (tenant *Tenant) GetCollectionSchemaVersions(ctx context.Context, tx transaction.Tx, db *Database, collectionName string) (schema.Versions, error) {
+	coll := db.GetCollection(collectionName)
+	if coll == nil {
+		return nil, errors.NotFound("collection doesn't exists '%s'", collectionName)
+	}
+
+	return tenant.schemaStore.Get(ctx, tx, tenant.namespace.Id(), db.id, coll.Id)
+}
+
+// GetCollectionSchemaVersion returns the schema of the collection as it was persisted at the requested version.
+func (tenant *Tenant) GetCollectionSchemaVersion(ctx context.Context, tx transaction.Tx, db *Database, collectionName string, version uint32) (*schema.Version, error) {
+	coll := db.GetCollection(collectionName)
+	if coll == nil {
+		return nil, errors.NotFound("collection doesn't exists '%s'", collectionName)
+	}
+
+	if version == coll.GetVersion() {
+		return &schema.Version{Version: coll.GetVersion(), Schema: coll.Schema}, nil
+	}
+
+	if version > coll.GetVersion() {
+		return nil, errors.NotFound("schema version %d not found", version)
+	}
+
+	return tenant.schemaStore.GetVersion(ctx, tx, tenant.namespace.Id(), db.id, coll.Id, version)
+}
+
 // DropCollection is to drop a collection and its associated indexes. It removes the "created" entry from the encoding
 // subspace and adds a "dropped" entry for the same collection key.
 func (tenant *Tenant) DropCollection(ctx context.Context, tx transaction.Tx, db *Database, collectionName string) error {
 	tenant.Lock()
 	defer tenant.Unlock()
 
+	if db != nil {
+		for _, ref := range schema.GetReferencingFields(collectionName, db.ListCollection()) {
+			if ref.Collection.Name != collectionName {
+				return errors.InvalidArgument("collection '%s' is referenced by the field '%s' of collection '%s'",
+					collectionName, ref.Field.FieldName, ref.Collection.Name)
+			}
+		}
+	}
+
 	err := tenant.dropCollection(ctx, tx, db, collectionName)
 	if err != nil {
 		return err
@@ -1593,6 +2378,14 @@ func (tenant *Tenant) dropCollection(ctx context.Context, tx transaction.Tx, db
 		if err = tenant.kvStore.DropTable(ctx, tableName); err != nil {
 			return err
 		}
+
+		changelog, err := tenant.Encoder.EncodeChangelogTableName(tenant.namespace, db, cHolder.collection)
+		if err != nil {
+			return err
+		}
+		if err = tenant.kvStore.DropTable(ctx, changelog); err != nil {
+			return err
+		}
 	}
 
 	if config.DefaultConfig.Search.WriteEnabled {
@@ -1731,6 +2524,15 @@ func (tenant *Tenant) CollectionSize(ctx context.Context, db *Database, coll *sc
 	return stats, nil
 }
 
+// CollectionBoundaryKeys returns up to limit keys of the collection at which the shards of the storage start.
+func (tenant *Tenant) CollectionBoundaryKeys(ctx context.Context, db *Database, coll *schema.DefaultCollection, limit int) ([][]byte, error) {
+	tenant.Lock()
+	nsName, _ := tenant.Encoder.EncodeTableName(tenant.namespace, db, coll)
+	tenant.Unlock()
+
+	return tenant.kvStore.GetBoundaryKeys(ctx, nsName, limit)
+}
+
 func (tenant *Tenant) CollectionIndexSize(ctx context.Context, db *Database, coll *schema.DefaultCollection) (*kv.TableStats, error) {
 	tenant.Lock()
 	nsName, _ := tenant.Encoder.EncodeSecondaryIndexTableName(tenant.namespace, db, coll)
@@ -1869,6 +2671,9 @@ type Database struct {
 	CurrentSchemaVersion uint32
 
 	MetadataChange bool
+
+	// expiresAt is the Unix time an ephemeral branch expires at.
+	expiresAt int64
 }
 
 func NewDatabase(id uint32, name string) *Database {
@@ -1897,6 +2702,7 @@ func (d *Database) Clone() *Database {
 	for k, v := range d.idToCollectionMap {
 		copyDB.idToCollectionMap[k] = v
 	}
+	copyDB.expiresAt = d.expiresAt
 
 	return &copyDB
 }
@@ -1948,6 +2754,14 @@ func (d *Database) SameBranch(branch string) bool {
 	return d.BranchName() == branch || branch == "" && !d.IsBranch()
 }
 
+// ExpiresAt returns the Unix time the branch expires at, zero if it doesn't expire.
+func (d *Database) ExpiresAt() int64 {
+	d.RLock()
+	defer d.RUnlock()
+
+	return d.expiresAt
+}
+
 func (d *Database) IsBranch() bool {
 	return !d.name.IsMainBranch()
 }
@@ -2000,6 +2814,7 @@ func (c *collectionHolder) clone() *collectionHolder {
 	}
 
 	copyC.collection.SchemaDeltas = c.collection.SchemaDeltas
+	copyC.collection.FieldEncryptor = c.collection.FieldEncryptor
 	copyC.collection.EncodedName = c.collection.EncodedName
 	copyC.collection.EncodedTableIndexName = c.collection.EncodedTableIndexName
 
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/config"
@@ -526,6 +527,56 @@ func TestTenantManager_DropCollection(t *testing.T) {
 	})
 }
 
+func TestTenantManager_CollectionSchemaVersions(t *testing.T) {
+	tm := transaction.NewManager(kvStore)
+	m, ctx, cancel := NewTestTenantMgr(t, kvStore)
+	defer cancel()
+
+	_, err := m.CreateOrGetTenant(ctx, &TenantNamespace{"ns-test1", 2, NewNamespaceMetadata(2, "ns-test1", "ns-test1-display_name")})
+	require.NoError(t, err)
+
+	tenant := m.tenants["ns-test1"]
+
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tenant.CreateProject(ctx, tx, tenantProj1, nil))
+	require.NoError(t, tenant.reload(ctx, tx, nil, nil))
+
+	proj1, err := tenant.GetProject(tenantProj1)
+	require.NoError(t, err)
+	db1 := proj1.database
+
+	v1 := []byte(`{"title": "test_collection", "properties": {"K1": {"type": "string"}}, "primary_key": ["K1"]}`)
+	v2 := []byte(`{"title": "test_collection", "properties": {"K1": {"type": "string"}, "K2": {"type": "integer"}}, "primary_key": ["K1"]}`)
+
+	for _, sch := range [][]byte{v1, v2} {
+		factory, err := schema.NewFactoryBuilder(true).Build("test_collection", sch)
+		require.NoError(t, err)
+		require.NoError(t, tenant.CreateCollection(ctx, tx, db1, factory))
+	}
+
+	versions, err := tenant.GetCollectionSchemaVersions(ctx, tx, db1, "test_collection")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, uint32(1), versions[0].Version)
+	require.Equal(t, uint32(2), versions[1].Version)
+
+	ver, err := tenant.GetCollectionSchemaVersion(ctx, tx, db1, "test_collection", 1)
+	require.NoError(t, err)
+	require.JSONEq(t, string(v1), string(ver.Schema))
+
+	ver, err = tenant.GetCollectionSchemaVersion(ctx, tx, db1, "test_collection", 2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), ver.Version)
+
+	_, err = tenant.GetCollectionSchemaVersion(ctx, tx, db1, "test_collection", 3)
+	require.Equal(t, errors.NotFound("schema version %d not found", 3), err)
+
+	require.NoError(t, tx.Commit(ctx))
+
+	testClearDictionary(ctx, m.metaStore, m.kvStore)
+}
+
 func TestTenantManager_SearchIndexes(t *testing.T) {
 	tm := transaction.NewManager(kvStore)
 	m, ctx, cancel := NewTestTenantMgr(t, kvStore)
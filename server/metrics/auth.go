@@ -61,3 +61,13 @@ func initializeAuthScopes() {
 	AuthRespTime = AuthMetrics.SubScope("response")
 	AuthErrorRespTime = AuthMetrics.SubScope("error_response")
 }
+
+// NetworkRejected counts the requests rejected by the network allowlist of the namespace or the app key.
+func NetworkRejected(namespace string, scope string) {
+	if AuthMetrics != nil {
+		AuthMetrics.Tagged(map[string]string{
+			"tigris_tenant": namespace,
+			"scope":         scope,
+		}).Counter("network_rejected").Inc(1)
+	}
+}
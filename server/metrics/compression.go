@@ -0,0 +1,25 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+// UpdateCompressionMetrics records the size of a payload before and after the compression, the ratio of the
+// "stored_bytes" to the "raw_bytes" counters is the compression ratio of the algorithm.
+func UpdateCompressionMetrics(algorithm string, rawBytes int, storedBytes int) {
+	if CompressionMetrics != nil {
+		scope := CompressionMetrics.Tagged(map[string]string{"compression": algorithm})
+		scope.Counter("raw_bytes").Inc(int64(rawBytes))
+		scope.Counter("stored_bytes").Inc(int64(storedBytes))
+	}
+}
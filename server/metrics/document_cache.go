@@ -0,0 +1,44 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+func getDocumentCacheTags(namespace string, project string, branch string, collection string) map[string]string {
+	return mergeTags(getQuotaUsageTags(namespace), GetProjectBranchCollTags(project, branch, collection))
+}
+
+// DocumentCacheLookup counts the documents read by their primary key which are served from the document cache, the
+// hit rate is hits / (hits + misses).
+func DocumentCacheLookup(namespace string, project string, branch string, collection string, hit bool) {
+	if DocumentCacheMetrics == nil {
+		return
+	}
+
+	counter := "misses"
+	if hit {
+		counter = "hits"
+	}
+
+	DocumentCacheMetrics.Tagged(getDocumentCacheTags(namespace, project, branch, collection)).Counter(counter).Inc(1)
+}
+
+// DocumentCacheInvalidated counts the documents invalidated by the writes, the source is "write" for the writes
+// committed on the node or "cdc" for the changes received from the change streams.
+func DocumentCacheInvalidated(source string, count int) {
+	if DocumentCacheMetrics == nil {
+		return
+	}
+
+	DocumentCacheMetrics.Tagged(map[string]string{"source": source}).Counter("invalidations").Inc(int64(count))
+}
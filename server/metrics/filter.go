@@ -0,0 +1,66 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// The outcomes of the filter conditions: pushed down to the primary or a secondary index, which reads only the
+// matching keys, or evaluated in memory on each document read.
+const (
+	FilterOutcomePushdown = "pushdown"
+	FilterOutcomeInMemory = "in_memory"
+)
+
+// filterEvaluationBuckets spans from a microsecond to a quarter of second, the regexes on long strings end up in the
+// upper buckets.
+var filterEvaluationBuckets = tally.MustMakeExponentialDurationBuckets(time.Microsecond, 4, 10)
+
+// FilterMetricsEnabled returns true if the evaluations of the filters are measured, the callers skip timing the
+// evaluations otherwise.
+func FilterMetricsEnabled() bool {
+	return FilterMetrics != nil
+}
+
+// FilterEvaluation records the in memory evaluation of a filter condition on a document, the operator is the type of
+// the matcher of the condition.
+func FilterEvaluation(operator string, matched bool, duration time.Duration) {
+	if FilterMetrics == nil {
+		return
+	}
+
+	result := "no_match"
+	if matched {
+		result = "match"
+	}
+
+	scope := FilterMetrics.Tagged(map[string]string{"operator": operator, "outcome": FilterOutcomeInMemory})
+	scope.Histogram("evaluation_time", filterEvaluationBuckets).RecordDuration(duration)
+	scope.Tagged(map[string]string{"result": result}).Counter("evaluations").Inc(1)
+}
+
+// FilterPushdown records a filter condition served by an index, once per query.
+func FilterPushdown(operator string, index string) {
+	if FilterMetrics != nil {
+		FilterMetrics.Tagged(map[string]string{
+			"operator": operator,
+			"outcome":  FilterOutcomePushdown,
+			"index":    index,
+		}).Counter("pushdowns").Inc(1)
+	}
+}
@@ -332,6 +332,16 @@ func (r *RequestStatus) GetWriteBytes() int64 {
 	return r.writeBytes
 }
 
+// GetReadUnits returns the read units of the request, the read bytes rounded up to the read unit size.
+func (r *RequestStatus) GetReadUnits() int64 {
+	return getUnitsFromBytes(r.readBytes, config.ReadUnitSize)
+}
+
+// GetWriteUnits returns the write units of the request, the written bytes rounded up to the write unit size.
+func (r *RequestStatus) GetWriteUnits() int64 {
+	return getUnitsFromBytes(r.writeBytes, config.WriteUnitSize)
+}
+
 func (r *RequestStatus) GetDDLDropUnits() int64 {
 	return r.ddlDropUnits
 }
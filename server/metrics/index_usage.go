@@ -0,0 +1,136 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The usage of the secondary indexes is tracked by each server in memory, from the time the server started. The
+// counters are also reported to the "index_usage" scope, so the usage of the whole cluster can be aggregated from the
+// metrics.
+var (
+	indexUsage      sync.Map
+	indexUsageSince = time.Now().UTC()
+)
+
+// IndexUsage is the usage of a secondary index observed by this server.
+type IndexUsage struct {
+	// Reads is the number of the queries which read the index.
+	Reads int64
+	// LastUsed is the time of the last query which read the index, it is zero if the index wasn't read.
+	LastUsed time.Time
+	// EntriesWritten is the number of the index entries added or removed by the writes of the documents.
+	EntriesWritten int64
+	// DocumentsWritten is the number of the document writes which maintained the index.
+	DocumentsWritten int64
+}
+
+// WriteAmplification is the average number of the index entries written per document write.
+func (u IndexUsage) WriteAmplification() float64 {
+	if u.DocumentsWritten == 0 {
+		return 0
+	}
+
+	return float64(u.EntriesWritten) / float64(u.DocumentsWritten)
+}
+
+type indexUsageCounters struct {
+	reads    atomic.Int64
+	lastUsed atomic.Int64
+	entries  atomic.Int64
+	docs     atomic.Int64
+}
+
+// indexUsageKey identifies the index by the encoded name of the collection, which is unique across the namespaces,
+// the projects and the branches, so that a recreated collection doesn't inherit the usage of the dropped one.
+func indexUsageKey(encodedColl []byte, index string) string {
+	return string(encodedColl) + "\x00" + index
+}
+
+func getIndexUsageCounters(encodedColl []byte, index string) *indexUsageCounters {
+	key := indexUsageKey(encodedColl, index)
+	if c, ok := indexUsage.Load(key); ok {
+		return c.(*indexUsageCounters)
+	}
+
+	c, _ := indexUsage.LoadOrStore(key, &indexUsageCounters{})
+	return c.(*indexUsageCounters)
+}
+
+func getIndexUsageTags(project string, branch string, collection string, index string) map[string]string {
+	tags := GetProjectBranchCollTags(project, branch, collection)
+	tags["index"] = index
+	return tags
+}
+
+// IndexRead records a query served by the secondary index.
+func IndexRead(project string, branch string, collection string, encodedColl []byte, index string) {
+	c := getIndexUsageCounters(encodedColl, index)
+	c.reads.Add(1)
+	c.lastUsed.Store(time.Now().UnixNano())
+
+	if IndexUsageMetrics != nil {
+		IndexUsageMetrics.Tagged(getIndexUsageTags(project, branch, collection, index)).Counter("reads").Inc(1)
+	}
+}
+
+// IndexWrites records a document write which maintained the indexes, the entries are the number of the index entries
+// added or removed by the write, keyed by the name of the index.
+func IndexWrites(project string, branch string, collection string, encodedColl []byte, indexes []string, entries map[string]int64) {
+	for _, index := range indexes {
+		c := getIndexUsageCounters(encodedColl, index)
+		c.docs.Add(1)
+		c.entries.Add(entries[index])
+
+		if IndexUsageMetrics != nil {
+			scope := IndexUsageMetrics.Tagged(getIndexUsageTags(project, branch, collection, index))
+			scope.Counter("documents_written").Inc(1)
+			scope.Counter("entries_written").Inc(entries[index])
+		}
+	}
+}
+
+// GetIndexUsage returns the usage of the secondary index observed by this server.
+func GetIndexUsage(encodedColl []byte, index string) IndexUsage {
+	c, ok := indexUsage.Load(indexUsageKey(encodedColl, index))
+	if !ok {
+		return IndexUsage{}
+	}
+
+	counters := c.(*indexUsageCounters)
+	usage := IndexUsage{
+		Reads:            counters.reads.Load(),
+		EntriesWritten:   counters.entries.Load(),
+		DocumentsWritten: counters.docs.Load(),
+	}
+	if lastUsed := counters.lastUsed.Load(); lastUsed > 0 {
+		usage.LastUsed = time.Unix(0, lastUsed).UTC()
+	}
+
+	return usage
+}
+
+// IndexUsageSince returns the time from which this server tracks the usage of the indexes.
+func IndexUsageSince() time.Time {
+	return indexUsageSince
+}
+
+// ResetIndexUsage forgets the usage of the index, it is called when the index is dropped.
+func ResetIndexUsage(encodedColl []byte, index string) {
+	indexUsage.Delete(indexUsageKey(encodedColl, index))
+}
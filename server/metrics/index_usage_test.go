@@ -0,0 +1,52 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestIndexUsage(t *testing.T) {
+	config.DefaultConfig.Metrics.Enabled = true
+	InitializeMetrics()
+
+	coll := []byte("usage_coll1")
+	require.Equal(t, IndexUsage{}, GetIndexUsage(coll, "name"))
+
+	IndexWrites("proj1", "main", "coll1", coll, []string{"name", "tags"}, map[string]int64{"tags": 3})
+	IndexWrites("proj1", "main", "coll1", coll, []string{"name", "tags"}, map[string]int64{"name": 2, "tags": 1})
+	IndexRead("proj1", "main", "coll1", coll, "name")
+
+	usage := GetIndexUsage(coll, "name")
+	require.Equal(t, int64(1), usage.Reads)
+	require.False(t, usage.LastUsed.IsZero())
+	require.Equal(t, int64(2), usage.EntriesWritten)
+	require.Equal(t, int64(2), usage.DocumentsWritten)
+	require.Equal(t, 1.0, usage.WriteAmplification())
+
+	usage = GetIndexUsage(coll, "tags")
+	require.Equal(t, int64(0), usage.Reads)
+	require.True(t, usage.LastUsed.IsZero())
+	require.Equal(t, 2.0, usage.WriteAmplification())
+
+	// the usage of the other collections is separate
+	require.Equal(t, IndexUsage{}, GetIndexUsage([]byte("usage_coll2"), "name"))
+
+	ResetIndexUsage(coll, "name")
+	require.Equal(t, IndexUsage{}, GetIndexUsage(coll, "name"))
+}
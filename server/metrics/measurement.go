@@ -27,6 +27,7 @@ import (
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/uber-go/tally"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	opentrace "go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/status"
 	ddtracer "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -38,6 +39,7 @@ const (
 	SessionManagerServiceName string = "session"
 	SecondaryIndexServiceName string = "secondaryindex"
 	MetronomeServiceName      string = "metronome"
+	QueryPlannerServiceName   string = "queryplanner"
 	GrpcSpanType              string = "grpc"
 	FdbSpanType               string = "fdb"
 	SearchSpanType            string = "search"
@@ -45,6 +47,7 @@ const (
 	SessionSpanType           string = "session"
 	AuthSpanType              string = "auth"
 	MetronomeSpanType         string = "metronome"
+	QueryPlanningSpanType     string = "query_planning"
 )
 
 type Measurement struct {
@@ -286,7 +289,7 @@ func (m *Measurement) StartTracing(ctx context.Context, childOnly bool) context.
 	}
 	//}
 
-	if tracing.IsJaegerTracingEnabled(&config.DefaultConfig) {
+	if tracing.IsOpenTelemetryEnabled(&config.DefaultConfig) {
 		var tags []attribute.KeyValue
 		for k, v := range m.tags {
 			tags = append(tags, attribute.KeyValue{Key: attribute.Key(k), Value: attribute.StringValue(v)})
@@ -421,17 +424,22 @@ func (m *Measurement) FinishWithError(ctx context.Context, err error) context.Co
 		return ctx
 	}
 	errCode := status.Code(err)
-	m.datadogSpan.SetTag("grpc.code", errCode.String())
-	errTags := getTagsForError(err)
-	for k, v := range errTags {
-		m.datadogSpan.SetTag(k, v)
-	}
-	finishOptions := []ddtracer.FinishOption{ddtracer.WithError(err)}
-
 	if m.datadogSpan != nil {
+		m.datadogSpan.SetTag("grpc.code", errCode.String())
+		errTags := getTagsForError(err)
+		for k, v := range errTags {
+			m.datadogSpan.SetTag(k, v)
+		}
+		finishOptions := []ddtracer.FinishOption{ddtracer.WithError(err)}
 		m.datadogSpan.Finish(finishOptions...)
 	}
 
+	if m.jaegerSpan != nil {
+		m.jaegerSpan.RecordError(err)
+		m.jaegerSpan.SetStatus(codes.Error, errCode.String())
+		m.jaegerSpan.End()
+	}
+
 	if m.parent != nil {
 		var err error
 		ctx, err = m.parent.SaveMeasurementToContext(ctx)
@@ -39,6 +39,13 @@ var (
 	NetworkMetrics        tally.Scope
 	AuthMetrics           tally.Scope
 	SchemaMetrics         tally.Scope
+	CompressionMetrics    tally.Scope
+	ExpirationMetrics     tally.Scope
+	IndexUsageMetrics     tally.Scope
+	DocumentCacheMetrics  tally.Scope
+	ResultCacheMetrics    tally.Scope
+	WriteHookMetrics      tally.Scope
+	FilterMetrics         tally.Scope
 	MetronomeMetrics      tally.Scope
 	GlobalSt              *GlobalStatus
 )
@@ -148,6 +155,11 @@ func InitializeMetrics() func() {
 			initializeSecondaryIndexScopes()
 		}
 
+		if cfg.Filter.Enabled {
+			// Filter evaluation metrics
+			FilterMetrics = root.SubScope("filter")
+		}
+
 		// Metrics for Metronome - external billing service
 		MetronomeMetrics = root.SubScope("metronome")
 		initializeMetronomeScopes()
@@ -155,6 +167,12 @@ func InitializeMetrics() func() {
 		initializeQuotaScopes()
 
 		SchemaMetrics = root.SubScope("schema")
+		CompressionMetrics = root.SubScope("compression")
+		ExpirationMetrics = root.SubScope("expiration")
+		IndexUsageMetrics = root.SubScope("index_usage")
+		DocumentCacheMetrics = root.SubScope("document_cache")
+		ResultCacheMetrics = root.SubScope("result_cache")
+		WriteHookMetrics = root.SubScope("write_hooks")
 		GlobalSt = NewGlobalStatus()
 	}
 
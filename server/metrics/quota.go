@@ -15,6 +15,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/uber-go/tally"
 )
 
@@ -98,3 +100,50 @@ func UpdateQuotaCurrentRatesReceivedLimit(namespaceName string, value int, isWri
 
 	QuotaCurRates.Tagged(getQuotaUsageTags(namespaceName)).Gauge(counter).Update(float64(value))
 }
+
+func getCollectionQuotaTags(namespaceName string, project string, collection string) map[string]string {
+	return map[string]string{
+		"tigris_tenant": namespaceName,
+		"project":       project,
+		"collection":    collection,
+	}
+}
+
+// UpdateCollectionQuotaUsage counts the units of the resource, like "read_units" or "search_requests", consumed by
+// the requests of the collection.
+func UpdateCollectionQuotaUsage(namespaceName string, project string, collection string, resource string, value int) {
+	if QuotaUsage == nil {
+		return
+	}
+
+	QuotaUsage.Tagged(getCollectionQuotaTags(namespaceName, project, collection)).Counter("collection_" + resource).Inc(int64(value))
+}
+
+// UpdateCollectionQuotaThrottled counts the units of the resource of the requests of the collection rejected by the
+// collection quota.
+func UpdateCollectionQuotaThrottled(namespaceName string, project string, collection string, resource string, value int) {
+	if QuotaThrottled == nil {
+		return
+	}
+
+	QuotaThrottled.Tagged(getCollectionQuotaTags(namespaceName, project, collection)).Counter("collection_" + resource).Inc(int64(value))
+}
+
+// UpdateConcurrencyQueued records how long a request of the namespace waited for an in-flight slot.
+func UpdateConcurrencyQueued(namespaceName string, wait time.Duration) {
+	if QuotaUsage == nil {
+		return
+	}
+
+	QuotaUsage.Tagged(getQuotaUsageTags(namespaceName)).Timer("concurrency_queued").Record(wait)
+}
+
+// UpdateConcurrencyThrottled counts the requests or the streams of the namespace rejected by the concurrency limits,
+// the resource is "concurrent_requests" or "streams".
+func UpdateConcurrencyThrottled(namespaceName string, resource string) {
+	if QuotaThrottled == nil {
+		return
+	}
+
+	QuotaThrottled.Tagged(getQuotaUsageTags(namespaceName)).Counter(resource).Inc(1)
+}
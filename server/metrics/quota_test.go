@@ -39,6 +39,9 @@ func TestQuotaMetrics(t *testing.T) {
 
 		UpdateQuotaCurrentNodeLimit(testNamespace, testSize, false)
 		UpdateQuotaCurrentNodeLimit(testNamespace, testSize, true)
+
+		UpdateCollectionQuotaUsage(testNamespace, "p1", "c1", "read_units", testSize)
+		UpdateCollectionQuotaThrottled(testNamespace, "p1", "c1", "search_requests", 1)
 	})
 
 	t.Run("disabled", func(t *testing.T) {
@@ -57,5 +60,8 @@ func TestQuotaMetrics(t *testing.T) {
 
 		UpdateQuotaCurrentNodeLimit(testNamespace, testSize, false)
 		UpdateQuotaCurrentNodeLimit(testNamespace, testSize, true)
+
+		UpdateCollectionQuotaUsage(testNamespace, "p1", "c1", "read_units", testSize)
+		UpdateCollectionQuotaThrottled(testNamespace, "p1", "c1", "search_requests", 1)
 	})
 }
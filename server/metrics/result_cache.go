@@ -0,0 +1,40 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+// ResultCacheLookup counts the reads whose result is served from the result cache, the hit rate is
+// hits / (hits + misses).
+func ResultCacheLookup(namespace string, project string, branch string, collection string, hit bool) {
+	if ResultCacheMetrics == nil {
+		return
+	}
+
+	counter := "misses"
+	if hit {
+		counter = "hits"
+	}
+
+	ResultCacheMetrics.Tagged(getDocumentCacheTags(namespace, project, branch, collection)).Counter(counter).Inc(1)
+}
+
+// ResultCacheInvalidated counts the collections whose results are invalidated by the writes, the source is "write"
+// for the writes committed on the node or "cdc" for the changes received from the change streams.
+func ResultCacheInvalidated(source string, count int) {
+	if ResultCacheMetrics == nil {
+		return
+	}
+
+	ResultCacheMetrics.Tagged(map[string]string{"source": source}).Counter("invalidations").Inc(int64(count))
+}
@@ -0,0 +1,36 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+)
+
+// UpdateExpirationMetrics records the documents deleted by a scan of a TTL index and the expiration lag of the
+// collection, the time since the documents expiring at the last fully processed point became eligible for deletion.
+func UpdateExpirationMetrics(project string, branch string, collection string, deleted int64, lag time.Duration) {
+	if ExpirationMetrics != nil {
+		scope := ExpirationMetrics.Tagged(GetProjectBranchCollTags(project, branch, collection))
+		scope.Counter("deleted").Inc(deleted)
+		scope.Gauge("lag_seconds").Update(lag.Seconds())
+	}
+}
+
+// ExpirationFailed counts the failed scans of a TTL index.
+func ExpirationFailed(project string, branch string, collection string) {
+	if ExpirationMetrics != nil {
+		ExpirationMetrics.Tagged(GetProjectBranchCollTags(project, branch, collection)).Counter("error").Inc(1)
+	}
+}
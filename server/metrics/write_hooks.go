@@ -0,0 +1,46 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// Outcomes of running a write hook on a document.
+const (
+	WriteHookSkipped  = "skipped"
+	WriteHookApplied  = "applied"
+	WriteHookRejected = "rejected"
+	WriteHookFailed   = "failed"
+)
+
+// writeHookDurationBuckets spans from a microsecond to a quarter of second, the hooks are bounded by the configured
+// maximum duration.
+var writeHookDurationBuckets = tally.MustMakeExponentialDurationBuckets(time.Microsecond, 4, 10)
+
+// WriteHookRun records the outcome of running the write hook of the collection on a document and the time it took.
+func WriteHookRun(project string, branch string, collection string, hook string, outcome string, duration time.Duration) {
+	if WriteHookMetrics == nil {
+		return
+	}
+
+	tags := GetProjectBranchCollTags(project, branch, collection)
+	tags["hook"] = hook
+	scope := WriteHookMetrics.Tagged(tags)
+	scope.Histogram("duration", writeHookDurationBuckets).RecordDuration(duration)
+	scope.Tagged(map[string]string{"outcome": outcome}).Counter("runs").Inc(1)
+}
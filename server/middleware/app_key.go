@@ -0,0 +1,192 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluele/gcache"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+)
+
+// The app keys can be limited to the grants on the databases and the collections of their project and can expire,
+// the requests made with the access tokens of the keys are checked here.
+
+const (
+	// appKeyIdPrefix is the prefix of the client ids of the app keys, the gotrue tokens carry it in the email claim
+	appKeyIdPrefix = "tid_"
+	// appKeySubSuffix is the suffix of the subject of the client credential tokens issued by auth0
+	appKeySubSuffix = "@clients"
+)
+
+var (
+	appKeyScopes metadata.AppKeyScopeGetter
+	// scopeCache holds the gcache.Cache of the scopes, it is replaced when the cache is resized on reload
+	scopeCache atomic.Value
+)
+
+// InitAppKeyScopes sets the source of the scopes of the app keys, the keys are not limited if it is not set.
+func InitAppKeyScopes(cfg *config.AppKeysConfig, g metadata.AppKeyScopeGetter) {
+	appKeyScopes = g
+	scopeCache.Store(newAppKeyScopeCache(cfg))
+
+	config.OnReload("app_key_scope_cache", func(c *config.Config) any {
+		return []any{c.Auth.AppKeys.ScopeCacheSize, c.Auth.AppKeys.ScopeCacheTTL}
+	}, func(c *config.Config) error {
+		scopeCache.Store(newAppKeyScopeCache(&c.Auth.AppKeys))
+		return nil
+	})
+}
+
+func newAppKeyScopeCache(cfg *config.AppKeysConfig) gcache.Cache {
+	return gcache.New(cfg.ScopeCacheSize).Expiration(cfg.ScopeCacheTTL).LRU().Build()
+}
+
+func appKeyScopeCache() gcache.Cache {
+	c, _ := scopeCache.Load().(gcache.Cache)
+	return c
+}
+
+// PurgeAppKeyScopes drops the cached scopes, so the changes of the scopes are enforced right away on this node.
+func PurgeAppKeyScopes() {
+	if c := appKeyScopeCache(); c != nil {
+		c.Purge()
+	}
+}
+
+// getAppKeyId returns the client id of the app key the token is issued for, empty if the token is issued for a user.
+func getAppKeyId(claims map[string]any) string {
+	if email, ok := claims["email"].(string); ok && strings.HasPrefix(email, appKeyIdPrefix) {
+		id, _, _ := strings.Cut(email, "@")
+		return id
+	}
+
+	if sub, ok := claims["sub"].(string); ok && strings.HasSuffix(sub, appKeySubSuffix) {
+		return strings.TrimSuffix(sub, appKeySubSuffix)
+	}
+
+	return ""
+}
+
+// appKeyScope is cached for the keys without a scope too, so they don't read the project metadata on every request.
+type appKeyScope struct {
+	scope *metadata.AppKeyScope
+}
+
+// checkAppKeyScope returns an error if the request is made with an expired app key, from an address outside of the
+// allowlist of the key or the scope of the key doesn't allow the permission the method requires on the branch and the
+// collection of the request.
+func checkAppKeyScope(ctx context.Context, fullMethod string, req any) error {
+	if appKeyScopes == nil {
+		return nil
+	}
+
+	project, branch, _ := request.GetProjectAndBranchAndColl(req)
+	if len(project) == 0 {
+		return nil
+	}
+
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil
+	}
+
+	id := getAppKeyId(request.GetTokenClaims(ctx))
+	if len(id) == 0 {
+		return nil
+	}
+
+	key := strings.Join([]string{namespace, project, id}, "\x00")
+	cached, ok := getCachedAppKeyScope(key)
+	if !ok {
+		scope, err := appKeyScopes.GetAppKeyScope(ctx, namespace, project, id)
+		if err != nil {
+			log.Err(err).Str("ns", namespace).Str("project", project).Msg("Failed to get app key scope")
+			return errors.Internal("failed to authorize the request")
+		}
+
+		cached = &appKeyScope{scope: scope}
+		if err = appKeyScopeCache().Set(key, cached); err != nil {
+			log.Warn().Err(err).Msg("Failed to set the cache entry for app key scope cache")
+		}
+	}
+
+	if cached.scope == nil {
+		return nil
+	}
+	if cached.scope.IsExpired(time.Now()) {
+		return errors.Unauthenticated("app key is expired")
+	}
+	if ipAllowlistEnabled() {
+		if err = checkNetworkAllowlist(ctx, namespace, networkScopeKey, cached.scope.AllowedCIDRs); err != nil {
+			return err
+		}
+	}
+	for _, collection := range request.GetCollections(req) {
+		if !cached.scope.Allows(rbacPermission(fullMethod), branch, collection) {
+			return errors.PermissionDenied("app key is not allowed to perform operation: %s", fullMethod)
+		}
+	}
+
+	return nil
+}
+
+func getCachedAppKeyScope(key string) (*appKeyScope, bool) {
+	cached, err := appKeyScopeCache().Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	scope, ok := cached.(*appKeyScope)
+	return scope, ok
+}
+
+func appKeyScopeUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkAppKeyScope(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type appKeyScopeStream struct {
+	fullMethod string
+	*middleware.WrappedServerStream
+}
+
+func appKeyScopeStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &appKeyScopeStream{fullMethod: info.FullMethod, WrappedServerStream: middleware.WrapServerStream(stream)})
+	}
+}
+
+func (w *appKeyScopeStream) RecvMsg(req any) error {
+	if err := w.ServerStream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return checkAppKeyScope(w.Context(), w.fullMethod, req)
+}
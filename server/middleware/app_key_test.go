@@ -0,0 +1,28 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAppKeyId(t *testing.T) {
+	require.Equal(t, "tid_abc", getAppKeyId(map[string]any{"email": "tid_abc@m2m.tigrisdata.com", "sub": "uuid"}))
+	require.Equal(t, "client1", getAppKeyId(map[string]any{"sub": "client1@clients"}))
+	require.Equal(t, "", getAppKeyId(map[string]any{"email": "user@example.com", "sub": "auth0|user"}))
+	require.Equal(t, "", getAppKeyId(map[string]any{}))
+}
@@ -0,0 +1,122 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/audit"
+	"github.com/tigrisdata/tigris/server/defaults"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// filterRequest is implemented by the requests selecting the documents with a filter.
+type filterRequest interface {
+	GetFilter() []byte
+}
+
+// audited returns true if the operation is recorded in the audit trail, the reads are only recorded on request. The
+// reads of the audit trail itself and the management operations are always recorded.
+func audited(ctx context.Context, method string) bool {
+	switch {
+	case isHealthMethod(method):
+		return false
+	case method == api.AuditLogsMethodName, strings.HasPrefix(method, api.ManagementMethodPrefix):
+		return true
+	default:
+		return audit.AuditReads() || !request.IsRead(ctx)
+	}
+}
+
+// auditEntry returns the entry of the operation, the request metadata is completed by the interceptors running after
+// the audit interceptor, so it is read once the operation is done.
+func auditEntry(ctx context.Context, method string, req any, err error) (string, *api.AuditEntry) {
+	reqMetadata, mdErr := request.GetRequestMetadataFromContext(ctx)
+	if mdErr != nil {
+		return "", nil
+	}
+
+	entry := &api.AuditEntry{
+		Sub:        reqMetadata.Sub,
+		Role:       reqMetadata.GetRole(),
+		Operation:  method,
+		Project:    reqMetadata.GetProject(),
+		Branch:     reqMetadata.GetBranch(),
+		Collection: reqMetadata.GetCollection(),
+		Peer:       peerAddress(ctx),
+		Code:       status.Code(err).String(),
+	}
+	if sub, err := request.GetCurrentSub(ctx); err == nil {
+		entry.Sub = sub
+	}
+	if fr, ok := req.(filterRequest); ok {
+		entry.Filter = string(fr.GetFilter())
+	}
+
+	return reqMetadata.GetNamespace(), entry
+}
+
+// peerAddress returns the address of the caller, the HTTP gateway and the proxies in front of the server set the
+// X-Forwarded-For header.
+func peerAddress(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 && forwarded[0] != "" {
+			return strings.TrimSpace(strings.Split(forwarded[0], ",")[0])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+func recordAudit(ctx context.Context, method string, req any, err error) {
+	namespace, entry := auditEntry(ctx, method, req, err)
+	// the requests failing the authentication don't have a namespace to be audited in
+	if entry == nil || namespace == "" || namespace == defaults.UnknownValue {
+		return
+	}
+
+	audit.Record(namespace, entry)
+}
+
+func auditUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if audited(ctx, info.FullMethod) {
+			recordAudit(ctx, info.FullMethod, req, err)
+		}
+
+		return resp, err
+	}
+}
+
+func auditStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, stream)
+		if ctx := stream.Context(); audited(ctx, info.FullMethod) {
+			recordAudit(ctx, info.FullMethod, nil, err)
+		}
+
+		return err
+	}
+}
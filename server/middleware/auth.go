@@ -24,12 +24,14 @@ import (
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/bluele/gcache"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/lib/container"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/defaults"
+	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
 	"github.com/tigrisdata/tigris/server/request"
 	"github.com/tigrisdata/tigris/server/types"
@@ -42,6 +44,8 @@ var (
 	headerAuthorize           = "authorization"
 	BypassAuthForTheseMethods = container.NewHashSet(
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 		api.GetAccessTokenMethodName,
 	)
 )
@@ -67,6 +71,27 @@ func (c CustomClaim) Validate(_ context.Context) error {
 	return nil
 }
 
+// MappedClaim is the custom claim of the tokens issued by the external OIDC providers. The token is valid if its
+// claims map to a Tigris namespace and role.
+type MappedClaim struct {
+	mapping *config.ClaimMappingConfig
+	claims  map[string]any
+	mapped  *request.MappedClaims
+}
+
+func (c *MappedClaim) UnmarshalJSON(data []byte) error {
+	return jsoniter.Unmarshal(data, &c.claims)
+}
+
+func (c *MappedClaim) Validate(_ context.Context) error {
+	mapped, err := request.MapClaims(c.mapping, c.claims)
+	if err != nil {
+		return err
+	}
+	c.mapped = mapped
+	return nil
+}
+
 type TigrisClaims struct {
 	NamespaceCode        string `json:"nc"`
 	NamespaceDisplayName string `json:"nd"`
@@ -104,7 +129,19 @@ func GetJWTValidators(config *config.Config) []*validator.Validator {
 		var keyFunc func(ctx context.Context) (any, error)
 		switch validatorCfg.Algorithm {
 		case validator.RS256:
-			provider := jwks.NewCachingProvider(issuerURL, config.Auth.JWKSCacheTimeout)
+			cacheTimeout := validatorCfg.JWKSCacheTimeout
+			if cacheTimeout == 0 {
+				cacheTimeout = config.Auth.JWKSCacheTimeout
+			}
+			var opts []jwks.ProviderOption
+			if len(validatorCfg.JWKSURL) > 0 {
+				jwksURL, err := url.Parse(validatorCfg.JWKSURL)
+				if err != nil {
+					log.Fatal().Err(err).Str("issuer", validatorCfg.Issuer).Msg("Invalid JWKS URL")
+				}
+				opts = append(opts, jwks.WithCustomJWKSURI(jwksURL))
+			}
+			provider := jwks.NewCachingProvider(issuerURL, cacheTimeout, opts...)
 			keyFunc = provider.KeyFunc
 		case validator.HS256:
 			keyFunc = func(ctx context.Context) (any, error) {
@@ -120,11 +157,7 @@ func GetJWTValidators(config *config.Config) []*validator.Validator {
 			issuerURL.String(),
 			[]string{validatorCfg.Audience},
 			validator.WithAllowedClockSkew(time.Duration(config.Auth.TokenClockSkewDurationSec)*time.Second),
-			validator.WithCustomClaims(
-				func() validator.CustomClaims {
-					return &CustomClaim{}
-				},
-			),
+			validator.WithCustomClaims(customClaimsFactory(validatorCfg.ClaimMapping)),
 		)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to configure JWTValidator")
@@ -134,6 +167,18 @@ func GetJWTValidators(config *config.Config) []*validator.Validator {
 	return jwtValidators
 }
 
+func customClaimsFactory(mapping *config.ClaimMappingConfig) func() validator.CustomClaims {
+	if mapping != nil {
+		return func() validator.CustomClaims {
+			return &MappedClaim{mapping: mapping}
+		}
+	}
+
+	return func() validator.CustomClaims {
+		return &CustomClaim{}
+	}
+}
+
 func measuredAuthFunction(ctx context.Context, jwtValidators []*validator.Validator, config *config.Config, cache gcache.Cache) (context.Context, error) {
 	measurement := metrics.NewMeasurement("auth", "auth", metrics.AuthSpanType, metrics.GetAuthBaseTags(ctx))
 	measurement.StartTracing(ctx, true)
@@ -175,9 +220,19 @@ func authFunction(ctx context.Context, jwtValidators []*validator.Validator, con
 	}
 	tkn, err := AuthFromMD(ctx, "bearer")
 	if err != nil {
+		// the bearer token takes precedence over the client certificate
+		if config.Auth.MTLS.Enabled {
+			if identity := getClientIdentity(ctx); len(identity) > 0 {
+				return authClientCert(ctx, reqMetadata, identity, &config.Auth.MTLS)
+			}
+		}
 		return ctx, err
 	}
 
+	if strings.HasPrefix(tkn, metadata.SearchKeyPrefix) {
+		return authSearchKey(ctx, reqMetadata, tkn, cache)
+	}
+
 	validatedToken := getCachedToken(ctx, tkn, cache)
 
 	// if not found from cache
@@ -237,6 +292,18 @@ func authFunction(ctx context.Context, jwtValidators []*validator.Validator, con
 			}
 			return ctx, nil
 		}
+
+		if mappedClaims, ok := validatedClaims.CustomClaims.(*MappedClaim); ok {
+			log.Debug().Str("issuer", validatedClaims.RegisteredClaims.Issuer).Msg("Valid external token received")
+			reqMetadata.SetAccessToken(&types.AccessToken{
+				Namespace: mappedClaims.mapped.Namespace,
+				Sub:       validatedClaims.RegisteredClaims.Subject,
+			})
+			if err := cache.Set(tkn, validatedToken); err != nil {
+				log.Warn().Err(err).Msg("Failed to set the cache entry for token validation cache")
+			}
+			return ctx, nil
+		}
 	}
 	// this should never happen.
 	return ctx, errors.Unauthenticated("You are not authorized to perform this action")
@@ -32,14 +32,27 @@ var (
 	editorRoleName       = "e"
 	ownerRoleName        = "o"
 	ClusterAdminRoleName = "cluster_admin"
+	searchOnlyRoleName   = request.SearchOnlyRole
 
 	adminNamespaces = container.NewHashSet(config.DefaultConfig.Auth.AdminNamespaces...)
 	readonlyMethods = container.NewHashSet(
 		// db
 		api.ReadMethodName,
+		api.ExportMethodName,
+		api.SubscribeMethodName,
 		api.CountMethodName,
+		api.ReadStreamMethodName,
 		api.ExplainMethodName,
+		api.BuildIndexStatusMethodName,
+		api.IndexUsageStatsMethodName,
+		api.GetSynonymsMethodName,
+		api.GetStopwordsMethodName,
 		api.SearchMethodName,
+		api.MultiSearchMethodName,
+		api.SearchIndexStatusMethodName,
+		api.DiffBranchesMethodName,
+		api.ListBranchLifetimesMethodName,
+		api.BackupStatusMethodName,
 		api.ListProjectsMethodName,
 		api.DescribeDatabaseMethodName,
 		api.DescribeCollectionMethodName,
@@ -53,9 +66,12 @@ var (
 		api.ListCachesMethodName,
 		api.GetMethodName,
 		api.KeysMethodName,
+		api.CacheKeysMethodName,
 
 		// health
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 
 		// management
 		api.GetUserMetadataMethodName,
@@ -76,16 +92,48 @@ var (
 		api.BeginTransactionMethodName,
 		api.CommitTransactionMethodName,
 		api.RollbackTransactionMethodName,
+		api.SavepointMethodName,
+		api.RollbackToSavepointMethodName,
 		api.InsertMethodName,
+		api.AppendEventsMethodName,
+		api.MultiWriteMethodName,
+		api.AppendStreamMethodName,
+		api.CreateQueueMethodName,
+		api.EnqueueMethodName,
+		api.ReceiveMethodName,
+		api.AckMethodName,
+		api.InsertStreamMethodName,
 		api.ReplaceMethodName,
 		api.DeleteMethodName,
 		api.UpdateMethodName,
 		api.ReadMethodName,
+		api.ExportMethodName,
+		api.SubscribeMethodName,
 		api.CountMethodName,
+		api.ReadStreamMethodName,
 		api.BuildCollectionIndexMethodName,
 		api.ExplainMethodName,
+		api.BuildIndexStatusMethodName,
+		api.IndexUsageStatsMethodName,
+		api.IndexSuggestionsMethodName,
+		api.SlowQueriesMethodName,
+		api.CreateOrReplaceSynonymsMethodName,
+		api.GetSynonymsMethodName,
+		api.DeleteSynonymsMethodName,
+		api.CreateOrReplaceStopwordsMethodName,
+		api.GetStopwordsMethodName,
+		api.DeleteStopwordsMethodName,
 		api.SearchMethodName,
+		api.MultiSearchMethodName,
+		api.SearchIndexStatusMethodName,
+		api.DiffBranchesMethodName,
+		api.ListBranchLifetimesMethodName,
+		api.MergeBranchMethodName,
+		api.CopyCollectionMethodName,
+		api.BackupMethodName,
+		api.BackupStatusMethodName,
 		api.ImportMethodName,
+		api.ImportDocumentsMethodName,
 		api.CreateOrUpdateCollectionMethodName,
 		api.CreateOrUpdateCollectionsMethodName,
 		api.DropCollectionMethodName,
@@ -121,9 +169,17 @@ var (
 		api.GetMethodName,
 		api.DelMethodName,
 		api.KeysMethodName,
+		api.IncrMethodName,
+		api.DecrMethodName,
+		api.ExpireMethodName,
+		api.GetDelMethodName,
+		api.CompareAndSwapMethodName,
+		api.CacheKeysMethodName,
 
 		// health
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 
 		// management
 		api.InsertUserMetadataMethodName,
@@ -151,16 +207,54 @@ var (
 		api.BeginTransactionMethodName,
 		api.CommitTransactionMethodName,
 		api.RollbackTransactionMethodName,
+		api.SavepointMethodName,
+		api.RollbackToSavepointMethodName,
 		api.InsertMethodName,
+		api.AppendEventsMethodName,
+		api.MultiWriteMethodName,
+		api.AppendStreamMethodName,
+		api.CreateQueueMethodName,
+		api.EnqueueMethodName,
+		api.ReceiveMethodName,
+		api.AckMethodName,
+		api.InsertStreamMethodName,
 		api.ReplaceMethodName,
 		api.DeleteMethodName,
 		api.UpdateMethodName,
 		api.ReadMethodName,
+		api.ExportMethodName,
+		api.SubscribeMethodName,
 		api.CountMethodName,
+		api.ReadStreamMethodName,
 		api.BuildCollectionIndexMethodName,
 		api.ExplainMethodName,
+		api.BuildIndexStatusMethodName,
+		api.IndexUsageStatsMethodName,
+		api.IndexSuggestionsMethodName,
+		api.SlowQueriesMethodName,
+		api.CreateOrReplaceSynonymsMethodName,
+		api.GetSynonymsMethodName,
+		api.DeleteSynonymsMethodName,
+		api.CreateOrReplaceStopwordsMethodName,
+		api.GetStopwordsMethodName,
+		api.DeleteStopwordsMethodName,
+		api.CheckIndexConsistencyMethodName,
 		api.SearchMethodName,
+		api.MultiSearchMethodName,
+		api.SearchIndexStatusMethodName,
+		api.DiffBranchesMethodName,
+		api.ListBranchLifetimesMethodName,
+		api.MergeBranchMethodName,
+		api.CopyCollectionMethodName,
+		api.BackupMethodName,
+		api.RestoreMethodName,
+		api.RestoreToTimestampMethodName,
+		api.BackupStatusMethodName,
+		api.AuditLogsMethodName,
+		api.UsageMethodName,
+		api.RebuildSearchIndexMethodName,
 		api.ImportMethodName,
+		api.ImportDocumentsMethodName,
 		api.CreateOrUpdateCollectionMethodName,
 		api.CreateOrUpdateCollectionsMethodName,
 
@@ -206,9 +300,17 @@ var (
 		api.GetMethodName,
 		api.DelMethodName,
 		api.KeysMethodName,
+		api.IncrMethodName,
+		api.DecrMethodName,
+		api.ExpireMethodName,
+		api.GetDelMethodName,
+		api.CompareAndSwapMethodName,
+		api.CacheKeysMethodName,
 
 		// health
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 
 		// management
 		api.InsertUserMetadataMethodName,
@@ -235,16 +337,54 @@ var (
 		api.BeginTransactionMethodName,
 		api.CommitTransactionMethodName,
 		api.RollbackTransactionMethodName,
+		api.SavepointMethodName,
+		api.RollbackToSavepointMethodName,
 		api.InsertMethodName,
+		api.AppendEventsMethodName,
+		api.MultiWriteMethodName,
+		api.AppendStreamMethodName,
+		api.CreateQueueMethodName,
+		api.EnqueueMethodName,
+		api.ReceiveMethodName,
+		api.AckMethodName,
+		api.InsertStreamMethodName,
 		api.ReplaceMethodName,
 		api.DeleteMethodName,
 		api.UpdateMethodName,
 		api.ReadMethodName,
+		api.ExportMethodName,
+		api.SubscribeMethodName,
 		api.CountMethodName,
+		api.ReadStreamMethodName,
 		api.BuildCollectionIndexMethodName,
 		api.ExplainMethodName,
+		api.BuildIndexStatusMethodName,
+		api.IndexUsageStatsMethodName,
+		api.IndexSuggestionsMethodName,
+		api.SlowQueriesMethodName,
+		api.CreateOrReplaceSynonymsMethodName,
+		api.GetSynonymsMethodName,
+		api.DeleteSynonymsMethodName,
+		api.CreateOrReplaceStopwordsMethodName,
+		api.GetStopwordsMethodName,
+		api.DeleteStopwordsMethodName,
+		api.CheckIndexConsistencyMethodName,
 		api.SearchMethodName,
+		api.MultiSearchMethodName,
+		api.SearchIndexStatusMethodName,
+		api.DiffBranchesMethodName,
+		api.ListBranchLifetimesMethodName,
+		api.MergeBranchMethodName,
+		api.CopyCollectionMethodName,
+		api.BackupMethodName,
+		api.RestoreMethodName,
+		api.RestoreToTimestampMethodName,
+		api.BackupStatusMethodName,
+		api.AuditLogsMethodName,
+		api.UsageMethodName,
+		api.RebuildSearchIndexMethodName,
 		api.ImportMethodName,
+		api.ImportDocumentsMethodName,
 		api.CreateOrUpdateCollectionMethodName,
 		api.DropCollectionMethodName,
 		api.ListProjectsMethodName,
@@ -282,9 +422,17 @@ var (
 		api.GetMethodName,
 		api.DelMethodName,
 		api.KeysMethodName,
+		api.IncrMethodName,
+		api.DecrMethodName,
+		api.ExpireMethodName,
+		api.GetDelMethodName,
+		api.CompareAndSwapMethodName,
+		api.CacheKeysMethodName,
 
 		// health
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 
 		// management
 		api.InsertUserMetadataMethodName,
@@ -367,12 +515,19 @@ func getMethodsForRole(role string) *container.HashSet {
 		return &editorMethods
 	case readOnlyRoleName:
 		return &readonlyMethods
+	case searchOnlyRoleName:
+		return &searchOnlyMethods
 	}
 	return nil
 }
 
 func getRole(reqMetadata *request.Metadata) string {
-	if isAdminNamespace(reqMetadata.GetNamespace()) {
+	// the search-only keys never get the cluster admin role, even in the admin namespaces
+	if reqMetadata != nil && reqMetadata.GetRole() == searchOnlyRoleName {
+		return searchOnlyRoleName
+	}
+
+	if IsAdminNamespace(reqMetadata.GetNamespace()) {
 		return ClusterAdminRoleName
 	}
 
@@ -383,6 +538,7 @@ func getRole(reqMetadata *request.Metadata) string {
 	return reqMetadata.GetRole()
 }
 
-func isAdminNamespace(incomingNamespace string) bool {
+// IsAdminNamespace returns true if the namespace is one of the cluster admin namespaces.
+func IsAdminNamespace(incomingNamespace string) bool {
 	return adminNamespaces.Contains(incomingNamespace)
 }
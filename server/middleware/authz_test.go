@@ -26,6 +26,11 @@ func TestAuthzOwnerRole(t *testing.T) {
 	require.True(t, isAuthorized(api.CommitTransactionMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.RollbackTransactionMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.InsertMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.MultiWriteMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.AppendStreamMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.EnqueueMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.ReceiveMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.ReadStreamMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.ReplaceMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.DeleteMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.UpdateMethodName, ownerRoleName))
@@ -33,7 +38,27 @@ func TestAuthzOwnerRole(t *testing.T) {
 	require.True(t, isAuthorized(api.CountMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.BuildCollectionIndexMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.ExplainMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.BuildIndexStatusMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.IndexUsageStatsMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.IndexSuggestionsMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.SlowQueriesMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.CheckIndexConsistencyMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.CreateOrReplaceSynonymsMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.DeleteStopwordsMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.SearchMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.MultiSearchMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.SearchIndexStatusMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.DiffBranchesMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.ListBranchLifetimesMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.MergeBranchMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.CopyCollectionMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.BackupMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.RestoreMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.RestoreToTimestampMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.BackupStatusMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.AuditLogsMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.UsageMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.RebuildSearchIndexMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.ImportMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.CreateOrUpdateCollectionMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.CreateOrUpdateCollectionsMethodName, ownerRoleName))
@@ -79,6 +104,9 @@ func TestAuthzOwnerRole(t *testing.T) {
 	require.True(t, isAuthorized(api.GetMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.DelMethodName, ownerRoleName))
 	require.True(t, isAuthorized(api.KeysMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.CacheKeysMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.IncrMethodName, ownerRoleName))
+	require.True(t, isAuthorized(api.CompareAndSwapMethodName, ownerRoleName))
 
 	// health
 	require.True(t, isAuthorized(api.HealthMethodName, ownerRoleName))
@@ -116,6 +144,11 @@ func TestAuthzEditorRole(t *testing.T) {
 	require.True(t, isAuthorized(api.CommitTransactionMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.RollbackTransactionMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.InsertMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.MultiWriteMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.AppendStreamMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.EnqueueMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.ReceiveMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.ReadStreamMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.ReplaceMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.DeleteMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.UpdateMethodName, editorRoleName))
@@ -123,7 +156,27 @@ func TestAuthzEditorRole(t *testing.T) {
 	require.True(t, isAuthorized(api.CountMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.BuildCollectionIndexMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.ExplainMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.BuildIndexStatusMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.IndexUsageStatsMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.IndexSuggestionsMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.SlowQueriesMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.CheckIndexConsistencyMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.CreateOrReplaceSynonymsMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.DeleteStopwordsMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.SearchMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.MultiSearchMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.SearchIndexStatusMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.DiffBranchesMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.ListBranchLifetimesMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.MergeBranchMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.CopyCollectionMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.BackupMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.RestoreMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.RestoreToTimestampMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.BackupStatusMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.AuditLogsMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.UsageMethodName, editorRoleName))
+	require.False(t, isAuthorized(api.RebuildSearchIndexMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.ImportMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.CreateOrUpdateCollectionMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.CreateOrUpdateCollectionsMethodName, ownerRoleName))
@@ -160,6 +213,9 @@ func TestAuthzEditorRole(t *testing.T) {
 	require.True(t, isAuthorized(api.GetMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.DelMethodName, editorRoleName))
 	require.True(t, isAuthorized(api.KeysMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.CacheKeysMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.IncrMethodName, editorRoleName))
+	require.True(t, isAuthorized(api.CompareAndSwapMethodName, editorRoleName))
 
 	// health
 	require.True(t, isAuthorized(api.HealthMethodName, editorRoleName))
@@ -203,7 +259,29 @@ func TestAuthzReadOnlyRole(t *testing.T) {
 	require.True(t, isAuthorized(api.ReadMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.CountMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.ExplainMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.BuildIndexStatusMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.IndexUsageStatsMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.IndexSuggestionsMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.SlowQueriesMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.CheckIndexConsistencyMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.GetSynonymsMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.GetStopwordsMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.CreateOrReplaceSynonymsMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.DeleteStopwordsMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.SearchMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.MultiSearchMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.SearchIndexStatusMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.DiffBranchesMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.ListBranchLifetimesMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.MergeBranchMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.CopyCollectionMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.BackupMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.RestoreMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.RestoreToTimestampMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.BackupStatusMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.AuditLogsMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.UsageMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.RebuildSearchIndexMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.ListProjectsMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.DescribeDatabaseMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.DescribeCollectionMethodName, readOnlyRoleName))
@@ -217,6 +295,7 @@ func TestAuthzReadOnlyRole(t *testing.T) {
 	require.True(t, isAuthorized(api.ListCachesMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.GetMethodName, readOnlyRoleName))
 	require.True(t, isAuthorized(api.KeysMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.CacheKeysMethodName, readOnlyRoleName))
 
 	// health
 	require.True(t, isAuthorized(api.HealthMethodName, readOnlyRoleName))
@@ -238,6 +317,11 @@ func TestAuthzReadOnlyRole(t *testing.T) {
 	require.False(t, isAuthorized(api.CommitTransactionMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.RollbackTransactionMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.InsertMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.MultiWriteMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.AppendStreamMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.EnqueueMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.ReceiveMethodName, readOnlyRoleName))
+	require.True(t, isAuthorized(api.ReadStreamMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.UpdateMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.DeleteMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.CreateProjectMethodName, readOnlyRoleName))
@@ -249,6 +333,8 @@ func TestAuthzReadOnlyRole(t *testing.T) {
 	require.False(t, isAuthorized(api.SetMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.GetSetMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.DelMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.IncrMethodName, readOnlyRoleName))
+	require.False(t, isAuthorized(api.GetDelMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.CreateBranchMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.DeleteBranchMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.CreateAppKeyMethodName, readOnlyRoleName))
@@ -270,3 +356,28 @@ func TestAuthzReadOnlyRole(t *testing.T) {
 	require.False(t, isAuthorized(api.ListGlobalAppKeysMethodName, readOnlyRoleName))
 	require.False(t, isAuthorized(api.RotateGlobalAppKeySecretMethodName, readOnlyRoleName))
 }
+
+func TestAuthzSearchOnlyRole(t *testing.T) {
+	require.True(t, isAuthorized(api.SearchMethodName, searchOnlyRoleName))
+	require.True(t, isAuthorized(api.MultiSearchMethodName, searchOnlyRoleName))
+	require.True(t, isAuthorized(api.Search_Search_FullMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.ReadMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.InsertMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.MultiWriteMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.AppendStreamMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.EnqueueMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.ReadStreamMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.SearchIndexStatusMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.DiffBranchesMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.ListBranchLifetimesMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.MergeBranchMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.CopyCollectionMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.BackupMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.RestoreMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.RestoreToTimestampMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.BackupStatusMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.AuditLogsMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.UsageMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.ListProjectsMethodName, searchOnlyRoleName))
+	require.False(t, isAuthorized(api.CreateAppKeyMethodName, searchOnlyRoleName))
+}
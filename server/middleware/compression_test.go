@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestZstdCompressor(t *testing.T) {
+	c := encoding.GetCompressor(zstdCompressorName)
+	require.NotNil(t, c)
+	require.NotNil(t, encoding.GetCompressor("gzip"))
+
+	// the encoders and decoders are reused, so run a few messages through them
+	for i := 0; i < 3; i++ {
+		msg := []byte(strings.Repeat("tigris", 100*(i+1)))
+
+		var buf bytes.Buffer
+		w, err := c.Compress(&buf)
+		require.NoError(t, err)
+		_, err = w.Write(msg)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Less(t, buf.Len(), len(msg))
+
+		r, err := c.Decompress(&buf)
+		require.NoError(t, err)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, msg, out)
+	}
+}
+
+type sendStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	sent []any
+}
+
+func (s *sendStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *sendStream) SendMsg(m any) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func TestFieldMaskInterceptors(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderFields, "id"))
+
+	resp, err := fieldMaskUnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		return &api.ReadResponse{Data: []byte(`{"id":1,"name":"a"}`)}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"id":1}`, string(resp.(*api.ReadResponse).Data))
+
+	stream := &sendStream{ctx: ctx}
+	err = fieldMaskStreamServerInterceptor()(nil, stream, &grpc.StreamServerInfo{}, func(srv any, stream grpc.ServerStream) error {
+		return stream.SendMsg(&api.ReadResponse{Data: []byte(`{"name":"b","id":2}`)})
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"id":2}`, string(stream.sent[0].(*api.ReadResponse).Data))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderFields, "a..b"))
+	_, err = fieldMaskUnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+)
+
+type fieldMaskStream struct {
+	mask api.FieldMask
+	*middleware.WrappedServerStream
+}
+
+func fieldMaskFromContext(ctx context.Context) (api.FieldMask, error) {
+	return api.ParseFieldMask(api.GetHeader(ctx, api.HeaderFields))
+}
+
+// fieldMaskUnaryServerInterceptor trims the documents of the response to the fields requested in the Tigris-Fields
+// header.
+func fieldMaskUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		mask, err := fieldMaskFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil || mask == nil {
+			return resp, err
+		}
+
+		if err = api.ApplyFieldMask(resp, mask); err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
+}
+
+func fieldMaskStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		mask, err := fieldMaskFromContext(stream.Context())
+		if err != nil {
+			return err
+		}
+		if mask == nil {
+			return handler(srv, stream)
+		}
+
+		return handler(srv, &fieldMaskStream{
+			mask:                mask,
+			WrappedServerStream: middleware.WrapServerStream(stream),
+		})
+	}
+}
+
+func (w *fieldMaskStream) SendMsg(m any) error {
+	if err := api.ApplyFieldMask(m, w.mask); err != nil {
+		return err
+	}
+
+	return w.ServerStream.SendMsg(m)
+}
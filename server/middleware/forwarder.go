@@ -27,6 +27,7 @@ import (
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/types"
 	"github.com/tigrisdata/tigris/util"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
@@ -55,6 +56,8 @@ func requestToResponse(method string) (proto.Message, proto.Message) {
 		return &api.ReadRequest{}, &api.ReadResponse{}
 	case "Search":
 		return &api.SearchRequest{}, &api.SearchResponse{}
+	case "Count":
+		return &api.CountRequest{}, &api.CountResponse{}
 	case "CreateOrUpdateCollection":
 		return &api.CreateOrUpdateCollectionRequest{}, &api.CreateOrUpdateCollectionResponse{}
 	case "DropCollection":
@@ -63,13 +66,34 @@ func requestToResponse(method string) (proto.Message, proto.Message) {
 		return &api.CommitTransactionRequest{}, &api.CommitTransactionResponse{}
 	case "RollbackTransaction":
 		return &api.RollbackTransactionRequest{}, &api.RollbackTransactionResponse{}
+	case api.SavepointMethodName, api.RollbackToSavepointMethodName:
+		return &api.CommitTransactionRequest{}, &api.CommitTransactionResponse{}
+	case api.AppendEventsMethodName:
+		return &api.InsertRequest{}, &api.InsertResponse{}
+	case api.MultiWriteMethodName:
+		return &api.CommitTransactionRequest{}, &httpbody.HttpBody{}
+	case api.AppendStreamMethodName:
+		return &api.InsertRequest{}, &httpbody.HttpBody{}
+	case api.ReadStreamMethodName:
+		return &api.ReadRequest{}, &httpbody.HttpBody{}
+	case api.CreateQueueMethodName:
+		return &api.DescribeCollectionRequest{}, &httpbody.HttpBody{}
+	case api.EnqueueMethodName, api.AckMethodName:
+		return &api.InsertRequest{}, &httpbody.HttpBody{}
+	case api.ReceiveMethodName:
+		return &api.ReadRequest{}, &httpbody.HttpBody{}
 	}
 	return nil, nil
 }
 
-// TODO: Sweep unused connections periodically.
 func getClient(ctx context.Context, origin string) (*grpc.ClientConn, error) {
-	if c, ok := forwarder.clients.Load(origin); ok {
+	return getClientAt(ctx, fmt.Sprintf("%s:%d", origin, config.DefaultConfig.Server.Port))
+}
+
+// getClientAt returns the connection to the "host:port" address.
+// TODO: Sweep unused connections periodically.
+func getClientAt(ctx context.Context, target string) (*grpc.ClientConn, error) {
+	if c, ok := forwarder.clients.Load(target); ok {
 		return c.(*grpc.ClientConn), nil
 	}
 
@@ -80,20 +104,39 @@ func getClient(ctx context.Context, origin string) (*grpc.ClientConn, error) {
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	}
 
-	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s:%d", origin, config.DefaultConfig.Server.Port), opts...)
+	conn, err := grpc.DialContext(ctx, target, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	forwarder.clients.Store(origin, conn)
+	forwarder.clients.Store(target, conn)
 
 	return conn, nil
 }
 
 //revive:disable:function-result-limit
+
+// director returns the connection the request of the method is forwarded to, with the outgoing context and the
+// messages of the method.
+type director func(ctx context.Context, method string) (context.Context, *grpc.ClientConn, proto.Message, proto.Message, error)
+
+// proxyDirector forwards the requests to the origin of their transaction.
 func proxyDirector(ctx context.Context, method string) (context.Context, *grpc.ClientConn, proto.Message, proto.Message, error) {
 	client, err := getClient(ctx, api.GetTransaction(ctx).GetOrigin())
 
+	return directTo(ctx, method, client, err)
+}
+
+// targetDirector forwards the requests to the "host:port" address.
+func targetDirector(target string) director {
+	return func(ctx context.Context, method string) (context.Context, *grpc.ClientConn, proto.Message, proto.Message, error) {
+		client, err := getClientAt(ctx, target)
+
+		return directTo(ctx, method, client, err)
+	}
+}
+
+func directTo(ctx context.Context, method string, client *grpc.ClientConn, err error) (context.Context, *grpc.ClientConn, proto.Message, proto.Message, error) {
 	req, resp := requestToResponse(method)
 	md, _ := metadata.FromIncomingContext(ctx)
 
@@ -115,7 +158,11 @@ func forwarderStreamServerInterceptor() grpc.StreamServerInterceptor {
 }
 
 func forwardRequest(ctx context.Context, method string, req proto.Message) (any, error) {
-	oCtx, client, _, resp, err := proxyDirector(ctx, method)
+	return forwardRequestWith(ctx, proxyDirector, method, req)
+}
+
+func forwardRequestWith(ctx context.Context, direct director, method string, req proto.Message) (any, error) {
+	oCtx, client, _, resp, err := direct(ctx, method)
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +189,16 @@ func forwarderUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 }
 
 func proxyHandler(_ any, serverStream grpc.ServerStream) error {
+	return proxyStream(serverStream, proxyDirector)
+}
+
+func proxyStream(serverStream grpc.ServerStream, direct director) error {
 	fullMethodName, ok := grpc.MethodFromServerStream(serverStream)
 	if !ok {
 		return errors.Internal("failed to determine method name")
 	}
 
-	outgoingCtx, backendConn, req, resp, err := proxyDirector(serverStream.Context(), fullMethodName)
+	outgoingCtx, backendConn, req, resp, err := direct(serverStream.Context(), fullMethodName)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,182 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/defaults"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// The namespaces and the app keys can be pinned to the network ranges the clients connect from, the requests from
+// the other addresses are rejected here.
+
+const (
+	networkScopeNamespace = "namespace"
+	networkScopeKey       = "key"
+
+	headerForwardedFor = "X-Forwarded-For"
+)
+
+var (
+	ipAllowlistTenants metadata.TenantGetter
+	trustForwardedFor  bool
+)
+
+// InitIPAllowlist sets the source of the allowlists of the namespaces, the allowlists are not enforced if it is not set.
+func InitIPAllowlist(cfg *config.IPAllowlistConfig, g metadata.TenantGetter) {
+	ipAllowlistTenants = g
+	trustForwardedFor = cfg.TrustForwardedFor
+}
+
+func ipAllowlistEnabled() bool {
+	return ipAllowlistTenants != nil
+}
+
+// parseClientAddr parses the address with or without the port.
+func parseClientAddr(s string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap(), true
+	}
+
+	return netip.Addr{}, false
+}
+
+// firstForwardedFor returns the original client of the X-Forwarded-For chain.
+func firstForwardedFor(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(first)
+}
+
+// getClientAddr returns the address the request is made from.
+func getClientAddr(ctx context.Context) (netip.Addr, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}, false
+	}
+
+	if p.Addr.Network() == inprocNetwork {
+		// set by HTTPClientAddrMiddleware from the connection of the HTTP request
+		return parseClientAddr(api.GetNonGRPCGatewayHeader(ctx, api.HeaderClientAddr))
+	}
+
+	if trustForwardedFor {
+		if forwarded := api.GetNonGRPCGatewayHeader(ctx, headerForwardedFor); len(forwarded) > 0 {
+			return parseClientAddr(firstForwardedFor(forwarded))
+		}
+	}
+
+	return parseClientAddr(p.Addr.String())
+}
+
+// HTTPClientAddrMiddleware replaces the client address header of the HTTP requests with the address of the client
+// of the connection, or the first address of the X-Forwarded-For header if it is trusted.
+func HTTPClientAddrMiddleware(cfg *config.IPAllowlistConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			addr := r.RemoteAddr
+			if forwarded := r.Header.Get(headerForwardedFor); cfg.TrustForwardedFor && len(forwarded) > 0 {
+				addr = firstForwardedFor(forwarded)
+			} else if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			r.Header.Set(api.HeaderClientAddr, addr)
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// checkNetworkAllowlist returns an error if the allowlist doesn't contain the address of the client. The request is
+// rejected if its address is unknown.
+func checkNetworkAllowlist(ctx context.Context, namespace string, scope string, cidrs []string) error {
+	if len(cidrs) == 0 {
+		return nil
+	}
+
+	addr, ok := getClientAddr(ctx)
+	if ok && metadata.AllowsAddr(cidrs, addr) {
+		return nil
+	}
+
+	address := ""
+	if ok {
+		address = addr.String()
+	}
+	metrics.NetworkRejected(namespace, scope)
+
+	return api.Errorf(api.Code_PERMISSION_DENIED, "requests from '%s' are not allowed by the %s network allowlist", address, scope).
+		WithDetails(api.NewNetworkDeniedInfo(&api.NetworkDeniedInfo{Scope: scope, Address: address}))
+}
+
+// checkNamespaceAllowlist returns an error if the namespace of the request has an allowlist which doesn't contain the
+// address of the client.
+func checkNamespaceAllowlist(ctx context.Context, fullMethod string) error {
+	if !ipAllowlistEnabled() || BypassAuthForTheseMethods.Contains(fullMethod) {
+		return nil
+	}
+
+	reqMetadata, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	namespace := reqMetadata.GetNamespace()
+	if len(namespace) == 0 || namespace == defaults.UnknownValue {
+		return nil
+	}
+
+	tenant, err := ipAllowlistTenants.GetTenant(ctx, namespace)
+	if err != nil || tenant == nil {
+		return nil
+	}
+
+	return checkNetworkAllowlist(ctx, namespace, networkScopeNamespace, tenant.GetNamespace().Metadata().AllowedCIDRs)
+}
+
+func ipAllowlistUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkNamespaceAllowlist(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func ipAllowlistStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkNamespaceAllowlist(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
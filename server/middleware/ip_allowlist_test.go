@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGetClientAddr(t *testing.T) {
+	defer func() { trustForwardedFor = false }()
+
+	md := metadata.Pairs(api.HeaderClientAddr, "10.0.0.2", headerForwardedFor, "10.0.0.3, 10.0.0.4")
+	withPeer := func(addr net.Addr) context.Context {
+		return peer.NewContext(metadata.NewIncomingContext(context.Background(), md), &peer.Peer{Addr: addr})
+	}
+
+	_, ok := getClientAddr(context.Background())
+	require.False(t, ok)
+
+	addr, ok := getClientAddr(withPeer(&tcpAddr{"10.0.0.1:5000"}))
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("10.0.0.1"), addr)
+
+	trustForwardedFor = true
+	addr, ok = getClientAddr(withPeer(&tcpAddr{"10.0.0.1:5000"}))
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("10.0.0.3"), addr)
+
+	// the in-process requests of the HTTP server always take the address set by the HTTP middleware
+	addr, ok = getClientAddr(withPeer(testAddr(inprocNetwork)))
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("10.0.0.2"), addr)
+}
+
+type tcpAddr struct {
+	addr string
+}
+
+func (a *tcpAddr) Network() string { return "tcp" }
+func (a *tcpAddr) String() string  { return a.addr }
+
+func TestCheckNetworkAllowlist(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &tcpAddr{"[::ffff:10.1.2.3]:5000"}})
+
+	require.NoError(t, checkNetworkAllowlist(ctx, "ns1", networkScopeKey, nil))
+	require.NoError(t, checkNetworkAllowlist(ctx, "ns1", networkScopeKey, []string{"10.1.0.0/16"}))
+
+	err := checkNetworkAllowlist(ctx, "ns1", networkScopeKey, []string{"192.168.0.0/16"})
+	var te *api.TigrisError
+	require.ErrorAs(t, err, &te)
+	require.Equal(t, api.Code_PERMISSION_DENIED, te.Code)
+	require.Equal(t, &api.NetworkDeniedInfo{Scope: networkScopeKey, Address: "10.1.2.3"}, te.NetworkDenied())
+
+	// unknown address is rejected
+	err = checkNetworkAllowlist(context.Background(), "ns1", networkScopeNamespace, []string{"10.1.0.0/16"})
+	require.ErrorAs(t, err, &te)
+	require.Equal(t, &api.NetworkDeniedInfo{Scope: networkScopeNamespace}, te.NetworkDenied())
+}
+
+func TestWireProtocolClientAddrAllowlist(t *testing.T) {
+	// the Mongo and PostgreSQL listeners forward the address of the connection with its port
+	inproc := func(md metadata.MD) context.Context {
+		return peer.NewContext(metadata.NewIncomingContext(context.Background(), md), &peer.Peer{Addr: testAddr(inprocNetwork)})
+	}
+	ctx := inproc(metadata.Pairs(api.HeaderClientAddr, "10.1.2.3:5000"))
+
+	require.NoError(t, checkNetworkAllowlist(ctx, "ns1", networkScopeNamespace, []string{"10.1.0.0/16"}))
+
+	err := checkNetworkAllowlist(ctx, "ns1", networkScopeNamespace, []string{"192.168.0.0/16"})
+	var te *api.TigrisError
+	require.ErrorAs(t, err, &te)
+	require.Equal(t, &api.NetworkDeniedInfo{Scope: networkScopeNamespace, Address: "10.1.2.3"}, te.NetworkDenied())
+
+	// an in-process request without the address is rejected
+	err = checkNetworkAllowlist(inproc(metadata.MD{}), "ns1", networkScopeNamespace, []string{"10.1.0.0/16"})
+	require.ErrorAs(t, err, &te)
+	require.Equal(t, &api.NetworkDeniedInfo{Scope: networkScopeNamespace}, te.NetworkDenied())
+}
+
+func TestHTTPClientAddrMiddleware(t *testing.T) {
+	var addr string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr = r.Header.Get(api.HeaderClientAddr)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/projects", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set(api.HeaderClientAddr, "1.2.3.4")
+	r.Header.Set(headerForwardedFor, "10.0.0.3, 10.0.0.4")
+
+	HTTPClientAddrMiddleware(&config.IPAllowlistConfig{})(next).ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, "10.0.0.1", addr)
+
+	HTTPClientAddrMiddleware(&config.IPAllowlistConfig{TrustForwardedFor: true})(next).ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, "10.0.0.3", addr)
+}
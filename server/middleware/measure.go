@@ -26,6 +26,8 @@ import (
 	"github.com/tigrisdata/tigris/server/defaults"
 	"github.com/tigrisdata/tigris/server/metrics"
 	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/tracing"
+	"github.com/tigrisdata/tigris/server/usage"
 	"github.com/tigrisdata/tigris/util"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"google.golang.org/grpc"
@@ -42,6 +44,8 @@ type wrappedStream struct {
 func getNoMeasurementMethods() []string {
 	return []string{
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 	}
 }
 
@@ -54,6 +58,16 @@ func measureMethod(fullMethod string) bool {
 	return true
 }
 
+// isHealthMethod returns true for the health checks, they are not subject to the quotas and are not audited.
+func isHealthMethod(fullMethod string) bool {
+	switch fullMethod {
+	case api.HealthMethodName, api.GRPCHealthCheckMethodName, api.GRPCHealthWatchMethodName:
+		return true
+	default:
+		return false
+	}
+}
+
 func logError(ctx context.Context, err error, reqType string) {
 	httpCode := 999 // Integer value for the unknown response code
 	userAgent := defaults.UnknownValue
@@ -88,7 +102,7 @@ func measureUnary() func(ctx context.Context, req any, info *grpc.UnaryServerInf
 			"sub":   reqMetadata.Sub,
 			"human": strconv.FormatBool(reqMetadata.IsHuman),
 		})
-		ctx = measurement.StartTracing(ctx, false)
+		ctx = measurement.StartTracing(tracing.ExtractIncoming(ctx), false)
 		ctx = reqStatus.SaveRequestStatusToContext(ctx)
 		resp, err := handler(ctx, req)
 		if err != nil {
@@ -109,6 +123,7 @@ func measureUnary() func(ctx context.Context, req any, info *grpc.UnaryServerInf
 		// Global status and metrics related to them, config switches are handled inside these
 		metrics.GlobalSt.RecordRequestToActiveChunk(reqStatus, reqMetadata.GetNamespace())
 		measurement.CountUnits(reqStatus, measurement.GetGlobalStatusTags())
+		usage.Record(reqMetadata.GetNamespace(), reqMetadata.GetProject(), reqMetadata.GetCollection(), reqStatus)
 		return resp, err
 	}
 }
@@ -132,7 +147,7 @@ func measureStream() grpc.StreamServerInterceptor {
 		wrapped.measurement = measurement
 		wrapped.reqStatus = reqStatus
 		wrapped.WrappedContext = reqStatus.SaveRequestStatusToContext(wrapped.WrappedContext)
-		wrapped.WrappedContext = measurement.StartTracing(wrapped.WrappedContext, false)
+		wrapped.WrappedContext = measurement.StartTracing(tracing.ExtractIncoming(wrapped.WrappedContext), false)
 		err = handler(srv, wrapped)
 		if err != nil {
 			measurement.CountErrorForScope(metrics.RequestsErrorCount, measurement.GetRequestErrorTags(err))
@@ -148,6 +163,7 @@ func measureStream() grpc.StreamServerInterceptor {
 		// Global status and metrics related to them, config switches are handled inside these
 		metrics.GlobalSt.RecordRequestToActiveChunk(reqStatus, reqMetadata.GetNamespace())
 		measurement.CountUnits(reqStatus, measurement.GetGlobalStatusTags())
+		usage.Record(reqMetadata.GetNamespace(), reqMetadata.GetProject(), reqMetadata.GetCollection(), reqStatus)
 		wrapped.WrappedContext = reqStatus.SaveRequestStatusToContext(wrapped.WrappedContext)
 		return err
 	}
@@ -50,25 +50,53 @@ func Get(cfg *config.Config) (grpc.UnaryServerInterceptor, grpc.StreamServerInte
 		metadataExtractorStream(),
 	}
 
+	if cfg.Audit.Enabled {
+		// records the requests rejected by the interceptors below, like the unauthorized ones
+		streamInterceptors = append(streamInterceptors, auditStreamServerInterceptor())
+	}
+
+	if cfg.Server.Compression.Enabled {
+		streamInterceptors = append(streamInterceptors, compressionStreamServerInterceptor())
+	}
+
 	if cfg.Metrics.Enabled || cfg.Tracing.Enabled {
 		streamInterceptors = append(streamInterceptors, measureStream())
 	}
 
 	streamInterceptors = append(streamInterceptors, forwarderStreamServerInterceptor())
 
+	if cfg.Replica.ReadOnly {
+		streamInterceptors = append(streamInterceptors, readOnlyReplicaStreamServerInterceptor())
+	}
+
+	if len(cfg.Replica.Routing) > 0 {
+		streamInterceptors = append(streamInterceptors, replicaRoutingStreamServerInterceptor(cfg.Replica.Routing))
+	}
+
 	if authFunc != nil {
-		streamInterceptors = append(streamInterceptors, grpcAuth.StreamServerInterceptor(authFunc))
+		streamInterceptors = append(streamInterceptors, grpcAuth.StreamServerInterceptor(authFunc), searchKeyStreamServerInterceptor(),
+			appKeyScopeStreamServerInterceptor())
+	}
+
+	if cfg.Auth.IPAllowlist.Enabled {
+		streamInterceptors = append(streamInterceptors, ipAllowlistStreamServerInterceptor())
 	}
 
 	if cfg.Auth.Authz.Enabled {
 		streamInterceptors = append(streamInterceptors, authzStreamServerInterceptor())
 	}
 
+	if cfg.Auth.RBAC.Enabled {
+		streamInterceptors = append(streamInterceptors, rbacStreamServerInterceptor())
+	}
+
 	streamInterceptors = append(streamInterceptors, []grpc.StreamServerInterceptor{
 		namespaceSetterStreamServerInterceptor(cfg.Auth.EnableNamespaceIsolation),
 		quotaStreamServerInterceptor(),
 		grpcLogging.StreamServerInterceptor(grpcZerolog.InterceptorLogger(sampledTaggedLogger), []grpcLogging.Option{}...),
 		validatorStreamServerInterceptor(),
+		timeoutStreamServerInterceptor(),
+		fieldMaskStreamServerInterceptor(),
 		grpcRecovery.StreamServerInterceptor(grpcRecovery.WithRecoveryHandler(recoveryHandler)),
 		headersStreamServerInterceptor(),
 	}...)
@@ -84,20 +112,46 @@ func Get(cfg *config.Config) (grpc.UnaryServerInterceptor, grpc.StreamServerInte
 		metadataExtractorUnary(),
 	}
 
+	if cfg.Audit.Enabled {
+		// records the requests rejected by the interceptors below, like the unauthorized ones
+		unaryInterceptors = append(unaryInterceptors, auditUnaryServerInterceptor())
+	}
+
+	if cfg.Server.Compression.Enabled {
+		unaryInterceptors = append(unaryInterceptors, compressionUnaryServerInterceptor())
+	}
+
 	if cfg.Metrics.Enabled || cfg.Tracing.Enabled {
 		unaryInterceptors = append(unaryInterceptors, measureUnary())
 	}
 
 	unaryInterceptors = append(unaryInterceptors, forwarderUnaryServerInterceptor())
 
+	if cfg.Replica.ReadOnly {
+		unaryInterceptors = append(unaryInterceptors, readOnlyReplicaUnaryServerInterceptor())
+	}
+
+	if len(cfg.Replica.Routing) > 0 {
+		unaryInterceptors = append(unaryInterceptors, replicaRoutingUnaryServerInterceptor(cfg.Replica.Routing))
+	}
+
 	if authFunc != nil {
-		unaryInterceptors = append(unaryInterceptors, grpcAuth.UnaryServerInterceptor(authFunc))
+		unaryInterceptors = append(unaryInterceptors, grpcAuth.UnaryServerInterceptor(authFunc), searchKeyUnaryServerInterceptor(),
+			appKeyScopeUnaryServerInterceptor())
+	}
+
+	if cfg.Auth.IPAllowlist.Enabled {
+		unaryInterceptors = append(unaryInterceptors, ipAllowlistUnaryServerInterceptor())
 	}
 
 	if cfg.Auth.Authz.Enabled {
 		unaryInterceptors = append(unaryInterceptors, authzUnaryServerInterceptor())
 	}
 
+	if cfg.Auth.RBAC.Enabled {
+		unaryInterceptors = append(unaryInterceptors, rbacUnaryServerInterceptor())
+	}
+
 	unaryInterceptors = append(unaryInterceptors, []grpc.UnaryServerInterceptor{
 		namespaceSetterUnaryServerInterceptor(cfg.Auth.EnableNamespaceIsolation),
 		pprofUnaryServerInterceptor(),
@@ -105,6 +159,7 @@ func Get(cfg *config.Config) (grpc.UnaryServerInterceptor, grpc.StreamServerInte
 		grpcLogging.UnaryServerInterceptor(grpcZerolog.InterceptorLogger(sampledTaggedLogger)),
 		validatorUnaryServerInterceptor(),
 		timeoutUnaryServerInterceptor(DefaultTimeout),
+		fieldMaskUnaryServerInterceptor(),
 		grpcRecovery.UnaryServerInterceptor(grpcRecovery.WithRecoveryHandler(recoveryHandler)),
 		headersUnaryServerInterceptor(),
 	}...)
@@ -0,0 +1,154 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/types"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	spiffeScheme = "spiffe"
+	// inprocNetwork is the network of the peer of the requests made through the in-process channel of the HTTP server
+	inprocNetwork = "inproc"
+)
+
+type clientCertCtxKey struct{}
+
+// WithClientCertificate saves the verified client certificate of the HTTP connection in the context.
+func WithClientCertificate(ctx context.Context, cert *x509.Certificate) context.Context {
+	return context.WithValue(ctx, clientCertCtxKey{}, cert)
+}
+
+// VerifiedClientCertificate returns the leaf of the verified client certificate chain, nil if the client didn't
+// present a certificate.
+func VerifiedClientCertificate(state tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return state.VerifiedChains[0][0]
+}
+
+// certIdentity returns the SPIFFE ID of the certificate, or its subject common name if it doesn't have one.
+func certIdentity(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == spiffeScheme {
+			return uri.String()
+		}
+	}
+
+	return cert.Subject.CommonName
+}
+
+// getClientIdentity returns the identity of the verified client certificate the request is made with, empty if the
+// connection isn't authenticated with a certificate.
+func getClientIdentity(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if cert := VerifiedClientCertificate(info.State); cert != nil {
+				return certIdentity(cert)
+			}
+			return ""
+		}
+		if p.Addr != nil && p.Addr.Network() == inprocNetwork {
+			// set by HTTPClientIdentityMiddleware from the certificate of the HTTP connection
+			return api.GetNonGRPCGatewayHeader(ctx, api.HeaderClientIdentity)
+		}
+	}
+
+	if cert, ok := ctx.Value(clientCertCtxKey{}).(*x509.Certificate); ok {
+		return certIdentity(cert)
+	}
+
+	return ""
+}
+
+// HTTPClientIdentityMiddleware replaces the client identity header of the HTTP requests with the identity of the
+// verified client certificate of the connection, so that the clients can't impersonate other identities.
+func HTTPClientIdentityMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Del(api.HeaderClientIdentity)
+			if cert, ok := r.Context().Value(clientCertCtxKey{}).(*x509.Certificate); ok {
+				r.Header.Set(api.HeaderClientIdentity, certIdentity(cert))
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// matchCertIdentity returns the first mapping of the identity, nil if none of them match.
+func matchCertIdentity(identities []config.CertIdentityConfig, identity string) *config.CertIdentityConfig {
+	isSPIFFE := strings.HasPrefix(identity, spiffeScheme+"://")
+	for i := range identities {
+		pattern := identities[i].CommonName
+		if isSPIFFE {
+			pattern = identities[i].SPIFFEID
+		}
+		if matchIdentityPattern(pattern, identity) {
+			return &identities[i]
+		}
+	}
+
+	return nil
+}
+
+func matchIdentityPattern(pattern string, identity string) bool {
+	if len(pattern) == 0 {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(identity, prefix)
+	}
+
+	return pattern == identity
+}
+
+// authClientCert authenticates the request by the identity of its client certificate.
+func authClientCert(ctx context.Context, reqMetadata *request.Metadata, identity string, cfg *config.MTLSConfig) (context.Context, error) {
+	if reqMetadata == nil {
+		return ctx, errors.Unauthenticated("request unauthenticated with client certificate")
+	}
+
+	mapping := matchCertIdentity(cfg.Identities, identity)
+	if mapping == nil {
+		return ctx, errors.Unauthenticated("client certificate '%s' is not mapped to a namespace", identity)
+	}
+
+	reqMetadata.SetAccessToken(&types.AccessToken{
+		Namespace: mapping.Namespace,
+		Sub:       identity,
+	})
+	reqMetadata.SetNamespace(ctx, mapping.Namespace)
+	reqMetadata.Sub = identity
+	reqMetadata.Role = mapping.Role
+	reqMetadata.IsHuman = false
+
+	return ctx, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestCertIdentity(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/prod/sa/orders")
+	other, _ := url.Parse("https://example.org")
+
+	require.Equal(t, "spiffe://example.org/ns/prod/sa/orders", certIdentity(&x509.Certificate{
+		Subject: pkix.Name{CommonName: "orders"},
+		URIs:    []*url.URL{other, spiffe},
+	}))
+	require.Equal(t, "orders", certIdentity(&x509.Certificate{
+		Subject: pkix.Name{CommonName: "orders"},
+		URIs:    []*url.URL{other},
+	}))
+}
+
+func TestMatchCertIdentity(t *testing.T) {
+	identities := []config.CertIdentityConfig{
+		{SPIFFEID: "spiffe://example.org/ns/prod/*", Namespace: "prod", Role: "e"},
+		{CommonName: "billing", Namespace: "billing", Role: "ro"},
+		{CommonName: "svc-*", Namespace: "svc", Role: "o"},
+	}
+
+	require.Equal(t, &identities[0], matchCertIdentity(identities, "spiffe://example.org/ns/prod/sa/orders"))
+	require.Nil(t, matchCertIdentity(identities, "spiffe://example.org/ns/dev/sa/orders"))
+	require.Equal(t, &identities[1], matchCertIdentity(identities, "billing"))
+	require.Nil(t, matchCertIdentity(identities, "billing2"))
+	require.Equal(t, &identities[2], matchCertIdentity(identities, "svc-orders"))
+	// the common name patterns don't match the SPIFFE IDs
+	require.Nil(t, matchCertIdentity(identities, "spiffe://svc-orders"))
+}
+
+type testAddr string
+
+func (a testAddr) Network() string { return string(a) }
+func (a testAddr) String() string  { return string(a) }
+
+func TestGetClientIdentity(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "orders"}}
+	header := func(ctx context.Context) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(api.HeaderClientIdentity, "billing"))
+	}
+
+	t.Run("tls peer", func(t *testing.T) {
+		ctx := peer.NewContext(header(context.Background()), &peer.Peer{
+			Addr:     testAddr("tcp"),
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+		})
+		require.Equal(t, "orders", getClientIdentity(ctx))
+	})
+	t.Run("tls peer without certificate ignores the header", func(t *testing.T) {
+		ctx := peer.NewContext(header(context.Background()), &peer.Peer{
+			Addr:     testAddr("tcp"),
+			AuthInfo: credentials.TLSInfo{},
+		})
+		require.Equal(t, "", getClientIdentity(ctx))
+	})
+	t.Run("plain text peer ignores the header", func(t *testing.T) {
+		ctx := peer.NewContext(header(context.Background()), &peer.Peer{Addr: testAddr("tcp")})
+		require.Equal(t, "", getClientIdentity(ctx))
+	})
+	t.Run("in-process peer", func(t *testing.T) {
+		ctx := peer.NewContext(header(context.Background()), &peer.Peer{Addr: testAddr(inprocNetwork)})
+		require.Equal(t, "billing", getClientIdentity(ctx))
+	})
+	t.Run("http connection", func(t *testing.T) {
+		require.Equal(t, "orders", getClientIdentity(WithClientCertificate(context.Background(), cert)))
+	})
+}
+
+func TestHTTPClientIdentityMiddleware(t *testing.T) {
+	var identity string
+	handler := HTTPClientIdentityMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = r.Header.Get(api.HeaderClientIdentity)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/projects", nil)
+	r.Header.Set(api.HeaderClientIdentity, "forged")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, "", identity)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "orders"}}
+	r = r.WithContext(WithClientCertificate(r.Context(), cert))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, "orders", identity)
+}
@@ -29,6 +29,8 @@ import (
 var (
 	excludedMethods = container.NewHashSet(
 		api.HealthMethodName,
+		api.GRPCHealthCheckMethodName,
+		api.GRPCHealthWatchMethodName,
 	)
 
 	namespaceExtractor = &request.AccessTokenNamespaceExtractor{}
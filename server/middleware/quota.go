@@ -27,17 +27,78 @@ import (
 
 type quotaStream struct {
 	namespace string
+	method    string
+	// collection of the request received by the stream, the collection quota of the responses is checked against
+	collection *quota.CollectionRequest
 	*middleware.WrappedServerStream
 }
 
+// collectionRequest is implemented by the requests of the collection APIs.
+type collectionRequest interface {
+	GetProject() string
+	GetCollection() string
+}
+
+// toCollectionRequest returns the collection quota request of the request, it returns nil if the request doesn't
+// target a collection.
+func toCollectionRequest(ctx context.Context, namespace string, method string, req any) *quota.CollectionRequest {
+	cr, ok := req.(collectionRequest)
+	if !ok || cr.GetCollection() == "" {
+		return nil
+	}
+
+	return &quota.CollectionRequest{
+		Namespace:  namespace,
+		Project:    cr.GetProject(),
+		Collection: cr.GetCollection(),
+		Size:       proto.Size(req.(proto.Message)),
+		IsWrite:    request.IsWrite(ctx),
+		IsSearch:   method == api.SearchMethodName,
+	}
+}
+
+// toSearchKeyRequest returns the search key quota request of the request, it returns nil if the request is not made
+// with a search-only key.
+func toSearchKeyRequest(ctx context.Context, namespace string) *quota.SearchKeyRequest {
+	key, ok := request.GetSearchKey(ctx)
+	if !ok {
+		return nil
+	}
+
+	return &quota.SearchKeyRequest{
+		Namespace: namespace,
+		Project:   key.Project,
+		KeyId:     key.Id,
+		QPS:       key.QPS,
+	}
+}
+
 func quotaUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		ns, _ := request.GetNamespace(ctx)
 
-		if m := info.FullMethod; m != api.HealthMethodName && !request.IsAdminApi(m) {
+		if m := info.FullMethod; !isHealthMethod(m) && !request.IsAdminApi(m) {
+			if sk := toSearchKeyRequest(ctx, ns); sk != nil {
+				if err := quota.AllowSearchKey(ctx, sk); err != nil {
+					return nil, err
+				}
+			}
+
 			if err := quota.Allow(ctx, ns, proto.Size(req.(proto.Message)), request.IsWrite(ctx)); err != nil {
 				return nil, err
 			}
+
+			if cr := toCollectionRequest(ctx, ns, m, req); cr != nil {
+				if err := quota.AllowCollection(ctx, cr); err != nil {
+					return nil, err
+				}
+			}
+
+			release, err := quota.AcquireRequest(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
 		}
 
 		return handler(ctx, req)
@@ -46,11 +107,18 @@ func quotaUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 func quotaStreamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if m := info.FullMethod; m != api.HealthMethodName && !request.IsAdminApi(m) {
+		if m := info.FullMethod; !isHealthMethod(m) && !request.IsAdminApi(m) {
 			ns, _ := request.GetNamespace(stream.Context())
+			release, err := quota.AcquireStream(stream.Context(), ns)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			wrapped := &quotaStream{
 				WrappedServerStream: middleware.WrapServerStream(stream),
 				namespace:           ns,
+				method:              m,
 			}
 			return handler(srv, wrapped)
 		}
@@ -60,11 +128,28 @@ func quotaStreamServerInterceptor() grpc.StreamServerInterceptor {
 }
 
 func (w *quotaStream) RecvMsg(req any) error {
+	if sk := toSearchKeyRequest(w.Context(), w.namespace); sk != nil {
+		if err := quota.AllowSearchKey(w.Context(), sk); err != nil {
+			return err
+		}
+	}
+
 	if err := quota.Allow(w.Context(), w.namespace, proto.Size(req.(proto.Message)), request.IsWrite(w.Context())); err != nil {
 		return err
 	}
 
-	return w.ServerStream.RecvMsg(req)
+	if err := w.ServerStream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	if cr := toCollectionRequest(w.Context(), w.namespace, w.method, req); cr != nil {
+		w.collection = cr
+		if err := quota.AllowCollection(w.Context(), cr); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (w *quotaStream) SendMsg(req any) error {
@@ -72,5 +157,19 @@ func (w *quotaStream) SendMsg(req any) error {
 		return err
 	}
 
+	// the responses of the streamed reads consume the read units of the collection, the writes are accounted
+	// by the size of the received requests only.
+	if cr := w.collection; cr != nil && !cr.IsWrite {
+		resp := &quota.CollectionRequest{
+			Namespace:  cr.Namespace,
+			Project:    cr.Project,
+			Collection: cr.Collection,
+			Size:       proto.Size(req.(proto.Message)),
+		}
+		if err := quota.WaitCollection(w.Context(), resp); err != nil {
+			return err
+		}
+	}
+
 	return w.ServerStream.SendMsg(req)
 }
@@ -0,0 +1,214 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bluele/gcache"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/container"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+)
+
+// The project level roles narrow down the access of the subjects bound to them to the permissions the roles grant on
+// the databases and the collections of the project. The subjects that aren't bound to any role of the project keep
+// the access of their namespace role, and the namespace owners are never restricted so that they can't lock
+// themselves out.
+
+var (
+	rbacGetter metadata.RBACGetter
+	// rbacDecisions holds the gcache.Cache of the decisions, it is replaced when the cache is resized on reload
+	rbacDecisions atomic.Value
+
+	// the methods changing the schemas, the branches or the credentials of the project require the admin permission
+	rbacAdminMethods = container.NewHashSet(
+		api.CreateOrUpdateCollectionMethodName,
+		api.CreateOrUpdateCollectionsMethodName,
+		api.DropCollectionMethodName,
+		api.DeleteProjectMethodName,
+		api.CreateBranchMethodName,
+		api.DeleteBranchMethodName,
+		api.MergeBranchMethodName,
+		api.BuildCollectionIndexMethodName,
+		api.SearchIndexCollectionMethodName,
+		api.RebuildSearchIndexMethodName,
+		api.CopyCollectionMethodName,
+		api.BackupMethodName,
+		api.RestoreMethodName,
+		api.RestoreToTimestampMethodName,
+		api.CreateOrReplaceSynonymsMethodName,
+		api.DeleteSynonymsMethodName,
+		api.CreateOrReplaceStopwordsMethodName,
+		api.DeleteStopwordsMethodName,
+		api.CreateAppKeyMethodName,
+		api.UpdateAppKeyMethodName,
+		api.DeleteAppKeyMethodName,
+		api.ListAppKeysMethodName,
+		api.RotateAppKeySecretMethodName,
+	)
+)
+
+// InitRBAC sets the source of the project roles and the cache of the authorization decisions.
+func InitRBAC(cfg *config.RBACConfig, g metadata.RBACGetter) {
+	rbacGetter = g
+	rbacDecisions.Store(newRBACDecisionCache(cfg))
+
+	config.OnReload("rbac_decision_cache", func(c *config.Config) any {
+		return []any{c.Auth.RBAC.DecisionCacheSize, c.Auth.RBAC.DecisionCacheTTL}
+	}, func(c *config.Config) error {
+		rbacDecisions.Store(newRBACDecisionCache(&c.Auth.RBAC))
+		return nil
+	})
+}
+
+func newRBACDecisionCache(cfg *config.RBACConfig) gcache.Cache {
+	return gcache.New(cfg.DecisionCacheSize).Expiration(cfg.DecisionCacheTTL).LRU().Build()
+}
+
+func rbacDecisionCache() gcache.Cache {
+	c, _ := rbacDecisions.Load().(gcache.Cache)
+	return c
+}
+
+// PurgeRBACDecisions drops the cached authorization decisions, so the changes of the roles are enforced right away on
+// this node. The other nodes enforce them once their cached decisions expire.
+func PurgeRBACDecisions() {
+	if c := rbacDecisionCache(); c != nil {
+		c.Purge()
+	}
+}
+
+// rbacPermission returns the permission the method requires on the project.
+func rbacPermission(fullMethod string) string {
+	switch {
+	case rbacAdminMethods.Contains(fullMethod):
+		return metadata.PermissionAdmin
+	case request.IsReadMethod(fullMethod):
+		return metadata.PermissionRead
+	default:
+		return metadata.PermissionWrite
+	}
+}
+
+// authorizeRBAC returns an error if the caller is bound to the roles of the project and none of them grants the
+// permission the method requires on the branch and the collection of the request.
+func authorizeRBAC(ctx context.Context, fullMethod string, req any) error {
+	if rbacGetter == nil {
+		return nil
+	}
+
+	project, branch, _ := request.GetProjectAndBranchAndColl(req)
+	if len(project) == 0 {
+		return nil
+	}
+
+	reqMetadata, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	switch getRole(reqMetadata) {
+	case ownerRoleName, ClusterAdminRoleName, searchOnlyRoleName:
+		// the search-only keys are already limited to the indexes of the key
+		return nil
+	}
+
+	sub, err := request.GetCurrentSub(ctx)
+	if err != nil || len(sub) == 0 {
+		return nil
+	}
+
+	namespace := reqMetadata.GetNamespace()
+	permission := rbacPermission(fullMethod)
+	// a multi write needs the permission on all the collections it writes
+	for _, collection := range request.GetCollections(req) {
+		key := strings.Join([]string{namespace, project, sub, permission, branch, collection}, "\x00")
+
+		allowed, ok := getCachedRBACDecision(key)
+		if !ok {
+			rbac, err := rbacGetter.GetProjectRBAC(ctx, namespace, project)
+			if err != nil {
+				log.Err(err).Str("ns", namespace).Str("project", project).Msg("Failed to get project roles")
+				return errors.Internal("failed to authorize the request")
+			}
+
+			var bound bool
+			if allowed, bound = rbac.Authorize(sub, permission, branch, collection); !bound {
+				allowed = true
+			}
+			if err = rbacDecisionCache().Set(key, allowed); err != nil {
+				log.Warn().Err(err).Msg("Failed to set the cache entry for rbac decision cache")
+			}
+		}
+
+		if !allowed {
+			if len(collection) > 0 {
+				return errors.PermissionDenied("You are not allowed to perform operation: %s on collection '%s'", fullMethod, collection)
+			}
+			return errors.PermissionDenied("You are not allowed to perform operation: %s on project '%s'", fullMethod, project)
+		}
+	}
+
+	return nil
+}
+
+func getCachedRBACDecision(key string) (bool, bool) {
+	cached, err := rbacDecisionCache().Get(key)
+	if err != nil {
+		return false, false
+	}
+
+	allowed, ok := cached.(bool)
+	return allowed, ok
+}
+
+func rbacUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorizeRBAC(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type rbacStream struct {
+	fullMethod string
+	*middleware.WrappedServerStream
+}
+
+func rbacStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &rbacStream{fullMethod: info.FullMethod, WrappedServerStream: middleware.WrapServerStream(stream)})
+	}
+}
+
+// RecvMsg authorizes every message of the stream, the project and the collection are only known from the messages.
+func (w *rbacStream) RecvMsg(req any) error {
+	if err := w.ServerStream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return authorizeRBAC(w.Context(), w.fullMethod, req)
+}
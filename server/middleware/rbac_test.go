@@ -0,0 +1,97 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/types"
+)
+
+type testRBACGetter struct {
+	rbac  *metadata.ProjectRBAC
+	calls int
+}
+
+func (g *testRBACGetter) GetProjectRBAC(_ context.Context, _ string, _ string) (*metadata.ProjectRBAC, error) {
+	g.calls++
+	return g.rbac, nil
+}
+
+func TestRBACPermission(t *testing.T) {
+	require.Equal(t, metadata.PermissionRead, rbacPermission(api.ReadMethodName))
+	require.Equal(t, metadata.PermissionRead, rbacPermission(api.SearchMethodName))
+	require.Equal(t, metadata.PermissionWrite, rbacPermission(api.InsertMethodName))
+	require.Equal(t, metadata.PermissionWrite, rbacPermission(api.CommitTransactionMethodName))
+	require.Equal(t, metadata.PermissionAdmin, rbacPermission(api.DropCollectionMethodName))
+	require.Equal(t, metadata.PermissionAdmin, rbacPermission(api.CreateBranchMethodName))
+}
+
+func TestAuthorizeRBAC(t *testing.T) {
+	getter := &testRBACGetter{rbac: &metadata.ProjectRBAC{
+		Roles: []metadata.Role{
+			{Name: "orders_reader", Grants: []metadata.Grant{{Permission: metadata.PermissionRead, Collection: "orders"}}},
+			{Name: "orders_writer", Grants: []metadata.Grant{{Permission: metadata.PermissionWrite, Collection: "orders"}}},
+		},
+		Bindings: []metadata.RoleBinding{{Sub: "u1", Role: "orders_reader"}, {Sub: "u3", Role: "orders_writer"}},
+	}}
+	InitRBAC(&config.RBACConfig{DecisionCacheSize: 10, DecisionCacheTTL: time.Minute}, getter)
+	defer func() { rbacGetter = nil }()
+
+	ctxFor := func(sub string, role string) context.Context {
+		md := &request.Metadata{Sub: sub, Role: role}
+		md.SetAccessToken(&types.AccessToken{Namespace: "ns1", Sub: sub})
+		return md.SaveToContext(context.Background())
+	}
+
+	u1 := ctxFor("u1", editorRoleName)
+	require.NoError(t, authorizeRBAC(u1, api.ReadMethodName, &api.ReadRequest{Project: "p1", Collection: "orders"}))
+	require.Error(t, authorizeRBAC(u1, api.InsertMethodName, &api.InsertRequest{Project: "p1", Collection: "orders"}))
+	require.Error(t, authorizeRBAC(u1, api.ReadMethodName, &api.ReadRequest{Project: "p1", Collection: "users"}))
+
+	// the decision is cached
+	calls := getter.calls
+	require.NoError(t, authorizeRBAC(u1, api.ReadMethodName, &api.ReadRequest{Project: "p1", Collection: "orders"}))
+	require.Equal(t, calls, getter.calls)
+
+	// the subjects without bindings keep their namespace role and the owners are never restricted
+	require.NoError(t, authorizeRBAC(ctxFor("u2", editorRoleName), api.InsertMethodName, &api.InsertRequest{Project: "p1", Collection: "users"}))
+	require.NoError(t, authorizeRBAC(ctxFor("u1", ownerRoleName), api.InsertMethodName, &api.InsertRequest{Project: "p1", Collection: "users"}))
+
+	// a multi write is authorized on all the collections it writes
+	multiWrite := func(collections ...string) *api.CommitTransactionRequest {
+		req := &api.CommitTransactionRequest{Project: "p1"}
+		var writes []*api.MultiWriteOp
+		for _, c := range collections {
+			writes = append(writes, &api.MultiWriteOp{Collection: c, Op: api.MultiWriteInsert})
+		}
+		req.SetWrites(writes)
+		return req
+	}
+	u3 := ctxFor("u3", editorRoleName)
+	require.NoError(t, authorizeRBAC(u3, api.MultiWriteMethodName, multiWrite("orders", "orders")))
+	require.Error(t, authorizeRBAC(u3, api.MultiWriteMethodName, multiWrite("orders", "order_items")))
+
+	PurgeRBACDecisions()
+	getter.rbac.Bindings = nil
+	require.NoError(t, authorizeRBAC(u1, api.InsertMethodName, &api.InsertRequest{Project: "p1", Collection: "orders"}))
+}
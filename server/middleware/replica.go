@@ -0,0 +1,145 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// The read-only replicas reject the writes here. The servers routing the reads either forward the eligible reads to a
+// replica, or serve them and return the address of a replica in the Tigris-Read-Replica header for the clients to send
+// the next reads to it.
+
+// ReplicaPicker picks the replica serving a read tolerating the staleness.
+type ReplicaPicker interface {
+	Pick(staleness time.Duration) (string, bool)
+}
+
+var replicaPicker ReplicaPicker
+
+// InitReplicaRouting sets the picker of the replicas, the reads are not routed if it is not set.
+func InitReplicaRouting(p ReplicaPicker) {
+	replicaPicker = p
+}
+
+// isReplicaMethod returns true if the method is served by a read-only replica.
+func isReplicaMethod(method string) bool {
+	switch method {
+	case api.CountMethodName, api.ExplainMethodName, api.HealthMethodName, api.GetAccessTokenMethodName:
+		return true
+	}
+
+	return request.IsReadMethod(method) && !strings.HasPrefix(method, api.ManagementMethodPrefix)
+}
+
+func readOnlyReplicaCheck(method string) error {
+	if !isReplicaMethod(method) {
+		return errors.FailedPrecondition("the server is a read-only replica, it doesn't serve '%s'", method)
+	}
+
+	return nil
+}
+
+// routedReplica returns the replica the read is routed to. Only the Read, Search and Count requests outside of
+// transactions tolerating the staleness are routed, to a replica lagging less than the tolerated staleness.
+func routedReplica(ctx context.Context, method string) (string, bool) {
+	if replicaPicker == nil {
+		return "", false
+	}
+
+	switch method {
+	case api.ReadMethodName, api.SearchMethodName, api.CountMethodName:
+	default:
+		return "", false
+	}
+
+	if api.GetTransaction(ctx) != nil {
+		return "", false
+	}
+
+	staleness, err := request.GetReadStaleness(ctx)
+	if err != nil || staleness == 0 {
+		return "", false
+	}
+
+	return replicaPicker.Pick(staleness)
+}
+
+func readOnlyReplicaUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := readOnlyReplicaCheck(info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func readOnlyReplicaStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := readOnlyReplicaCheck(info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+func replicaRoutingUnaryServerInterceptor(routing string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		target, ok := routedReplica(ctx, info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if routing == config.ReplicaRoutingHint {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(api.HeaderReadReplica, target))
+			return handler(ctx, req)
+		}
+
+		resp, err := forwardRequestWith(ctx, targetDirector(target), info.FullMethod, req.(proto.Message))
+		log.Debug().Err(err).Str("method", info.FullMethod).Str("replica", target).Msg("routed request to replica")
+		return resp, err
+	}
+}
+
+func replicaRoutingStreamServerInterceptor(routing string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		target, ok := routedReplica(stream.Context(), info.FullMethod)
+		if !ok {
+			return handler(srv, stream)
+		}
+
+		if routing == config.ReplicaRoutingHint {
+			_ = stream.SetHeader(metadata.Pairs(api.HeaderReadReplica, target))
+			return handler(srv, stream)
+		}
+
+		err := proxyStream(stream, targetDirector(target))
+		log.Debug().Err(err).Str("method", info.FullMethod).Str("replica", target).Msg("routed stream request to replica")
+		return err
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+type testReplicaPicker struct {
+	staleness time.Duration
+}
+
+func (p *testReplicaPicker) Pick(staleness time.Duration) (string, bool) {
+	p.staleness = staleness
+	return "replica1:8081", staleness >= time.Second
+}
+
+func TestReadOnlyReplicaCheck(t *testing.T) {
+	for _, m := range []string{api.ReadMethodName, api.SearchMethodName, api.CountMethodName, api.ExplainMethodName,
+		api.DescribeCollectionMethodName, api.ListCollectionsMethodName, api.HealthMethodName, api.GetAccessTokenMethodName} {
+		require.NoError(t, readOnlyReplicaCheck(m), m)
+	}
+
+	for _, m := range []string{api.InsertMethodName, api.UpdateMethodName, api.DeleteMethodName, api.BeginTransactionMethodName,
+		api.MultiWriteMethodName, api.CreateOrUpdateCollectionMethodName, api.ManagementMethodPrefix + "CreateNamespace"} {
+		require.Equal(t, "the server is a read-only replica, it doesn't serve '"+m+"'", readOnlyReplicaCheck(m).(*api.TigrisError).Message)
+	}
+}
+
+func TestRoutedReplica(t *testing.T) {
+	p := &testReplicaPicker{}
+	InitReplicaRouting(p)
+	defer InitReplicaRouting(nil)
+
+	ctx := func(headers map[string]string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.New(headers))
+	}
+
+	target, ok := routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "1500"}), api.ReadMethodName)
+	require.True(t, ok)
+	require.Equal(t, "replica1:8081", target)
+	require.Equal(t, 1500*time.Millisecond, p.staleness)
+
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "1500"}), api.CountMethodName)
+	require.True(t, ok)
+
+	// no replica lags less than tolerated
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "500"}), api.SearchMethodName)
+	require.False(t, ok)
+
+	// the reads of the latest commit are not routed
+	_, ok = routedReplica(ctx(map[string]string{}), api.ReadMethodName)
+	require.False(t, ok)
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "invalid"}), api.ReadMethodName)
+	require.False(t, ok)
+
+	// nor the reads in transactions
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "1500", api.HeaderTxOrigin: "origin1"}), api.ReadMethodName)
+	require.False(t, ok)
+
+	// nor the other methods
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "1500"}), api.InsertMethodName)
+	require.False(t, ok)
+
+	InitReplicaRouting(nil)
+	_, ok = routedReplica(ctx(map[string]string{api.HeaderReadStaleness: "1500"}), api.ReadMethodName)
+	require.False(t, ok)
+}
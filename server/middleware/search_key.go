@@ -0,0 +1,175 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluele/gcache"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/container"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/types"
+	"google.golang.org/grpc"
+)
+
+// The search-only keys are the credentials of a project that can only run the search queries against the collections
+// and the search indexes of the key, so they can be embedded in the browser clients. The keys are checked here
+// regardless of the authz being enabled.
+
+var (
+	searchKeys metadata.SearchKeyGetter
+
+	searchOnlyMethods = container.NewHashSet(
+		api.SearchMethodName,
+		api.MultiSearchMethodName,
+		api.Search_Search_FullMethodName,
+	)
+)
+
+// InitSearchKeys sets the source of the search-only keys, the keys are rejected if it is not set.
+func InitSearchKeys(g metadata.SearchKeyGetter) {
+	searchKeys = g
+}
+
+// authSearchKey authenticates the request made with a search-only key. The verified keys are cached the same way as
+// the validated access tokens.
+func authSearchKey(ctx context.Context, reqMetadata *request.Metadata, tkn string, cache gcache.Cache) (context.Context, error) {
+	if reqMetadata == nil || searchKeys == nil {
+		return ctx, metadata.ErrInvalidSearchKey
+	}
+
+	namespace, project, id, secret, err := metadata.DecodeSearchKey(tkn)
+	if err != nil {
+		return ctx, err
+	}
+
+	key, ok := getCachedToken(ctx, tkn, cache).(*metadata.SearchKey)
+	if !ok {
+		if key, err = searchKeys.GetSearchKey(ctx, namespace, project, id); err != nil {
+			log.Debug().Err(err).Str("ns", namespace).Str("project", project).Msg("Failed to get search key")
+			return ctx, metadata.ErrInvalidSearchKey
+		}
+		if !key.Verify(secret) {
+			return ctx, metadata.ErrInvalidSearchKey
+		}
+		if err = cache.Set(tkn, key); err != nil {
+			log.Warn().Err(err).Msg("Failed to set the cache entry for search key validation cache")
+		}
+	}
+
+	if key.IsExpired(time.Now()) {
+		_ = cache.Remove(tkn)
+		return ctx, errors.Unauthenticated("search key is expired")
+	}
+
+	reqMetadata.SetAccessToken(&types.AccessToken{
+		Namespace: namespace,
+		Sub:       metadata.SearchKeyPrefix + id,
+	})
+	reqMetadata.SetSearchKey(&request.SearchKey{Project: project, SearchKey: key})
+
+	return ctx, nil
+}
+
+// checkSearchKeyScope returns an error if the request made with the search-only key is not a search request against
+// the collections or the search indexes of the key.
+func checkSearchKeyScope(key *request.SearchKey, req any) error {
+	var (
+		project string
+		indexes []string
+	)
+
+	switch r := req.(type) {
+	case *api.SearchIndexRequest:
+		project, indexes = r.GetProject(), []string{r.GetIndex()}
+	case *api.SearchRequest:
+		project = r.GetProject()
+		if sources := r.GetSources(); len(sources) > 0 {
+			for _, src := range sources {
+				if len(src.Collection) > 0 || len(src.Index) == 0 {
+					indexes = append(indexes, src.Collection)
+				}
+				if len(src.Index) > 0 {
+					indexes = append(indexes, src.Index)
+				}
+			}
+		} else {
+			indexes = []string{r.GetCollection()}
+		}
+	default:
+		return errors.PermissionDenied("search key is only allowed to run search queries")
+	}
+
+	if project != key.Project {
+		return errors.PermissionDenied("search key is not allowed to access the project '%s'", project)
+	}
+
+	for _, index := range indexes {
+		if !key.Allows(index) {
+			return errors.PermissionDenied("search key is not allowed to search '%s'", index)
+		}
+	}
+
+	return nil
+}
+
+func searchKeyUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if key, ok := request.GetSearchKey(ctx); ok {
+			if !searchOnlyMethods.Contains(info.FullMethod) {
+				return nil, errors.PermissionDenied("You are not allowed to perform operation: %s", info.FullMethod)
+			}
+
+			if err := checkSearchKeyScope(key, req); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type searchKeyStream struct {
+	key *request.SearchKey
+	*middleware.WrappedServerStream
+}
+
+func searchKeyStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if key, ok := request.GetSearchKey(stream.Context()); ok {
+			if !searchOnlyMethods.Contains(info.FullMethod) {
+				return errors.PermissionDenied("You are not allowed to perform operation: %s", info.FullMethod)
+			}
+
+			return handler(srv, &searchKeyStream{key: key, WrappedServerStream: middleware.WrapServerStream(stream)})
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+func (w *searchKeyStream) RecvMsg(req any) error {
+	if err := w.ServerStream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return checkSearchKeyScope(w.key, req)
+}
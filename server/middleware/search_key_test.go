@@ -0,0 +1,46 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+func TestSearchKeyScope(t *testing.T) {
+	key := &request.SearchKey{
+		Project:   "p1",
+		SearchKey: &metadata.SearchKey{Id: "k1", Indexes: []string{"products", "articles"}},
+	}
+
+	require.NoError(t, checkSearchKeyScope(key, &api.SearchRequest{Project: "p1", Collection: "products"}))
+	require.NoError(t, checkSearchKeyScope(key, &api.SearchIndexRequest{Project: "p1", Index: "articles"}))
+
+	require.Error(t, checkSearchKeyScope(key, &api.SearchRequest{Project: "p1", Collection: "users"}))
+	require.Error(t, checkSearchKeyScope(key, &api.SearchRequest{Project: "p2", Collection: "products"}))
+	require.Error(t, checkSearchKeyScope(key, &api.SearchIndexRequest{Project: "p1", Index: "users"}))
+	require.Error(t, checkSearchKeyScope(key, &api.ReadRequest{Project: "p1", Collection: "products"}))
+
+	multi := &api.SearchRequest{Project: "p1"}
+	multi.SetSources([]*api.MultiSearchSource{{Collection: "products"}, {Index: "articles"}})
+	require.NoError(t, checkSearchKeyScope(key, multi))
+
+	multi.SetSources([]*api.MultiSearchSource{{Collection: "products"}, {Index: "users"}})
+	require.Error(t, checkSearchKeyScope(key, multi))
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"time"
 
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
 	"google.golang.org/grpc"
@@ -65,6 +66,29 @@ func timeoutUnaryServerInterceptor(timeout time.Duration) grpc.UnaryServerInterc
 	}
 }
 
+// timeoutStreamServerInterceptor returns a new stream server interceptor that sets the request timeout of the header.
+// Unlike the unary requests, the streams have no default timeout, they run until the client cancels them.
+func timeoutStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, cancel := setDeadlineUsingHeader(stream.Context())
+		if cancel == nil {
+			return handler(srv, stream)
+		}
+
+		defer func() {
+			cancel()
+			if ctx.Err() == context.DeadlineExceeded {
+				err = errors.DeadlineExceeded("the server is taking too long to process the request")
+			}
+		}()
+
+		wrapped := middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+
+		return handler(srv, wrapped)
+	}
+}
+
 func isLongRunningAPI(method string) bool {
 	return method == api.IndexCollection || method == api.SearchIndexCollectionMethodName
 }
@@ -35,7 +35,11 @@ func NewGRPCServer(cfg *config.Config) *GRPCServer {
 	s := &GRPCServer{}
 
 	unary, stream := middleware.Get(cfg)
-	s.Server = grpc.NewServer(grpc.StreamInterceptor(stream), grpc.UnaryInterceptor(unary), grpc.MaxRecvMsgSize(defaultTigrisServerMaxReceiveMessageSize))
+	opts := []grpc.ServerOption{grpc.StreamInterceptor(stream), grpc.UnaryInterceptor(unary), grpc.MaxRecvMsgSize(defaultTigrisServerMaxReceiveMessageSize)}
+	if cfg.Server.TLS.Enabled {
+		opts = append(opts, grpc.Creds(handshakenTLSCredentials{}))
+	}
+	s.Server = grpc.NewServer(opts...)
 	reflection.Register(s)
 	return s
 }
@@ -15,6 +15,7 @@
 package muxer
 
 import (
+	"io"
 	"net/http"
 	"time"
 
@@ -22,8 +23,10 @@ import (
 	"github.com/go-chi/chi/v5"
 	chi_middleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/klauspost/compress/zstd"
 	"github.com/rs/zerolog/log"
 	"github.com/soheilhy/cmux"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/middleware"
 )
@@ -55,17 +58,43 @@ func (s *HTTPServer) SetupMiddlewares(cfg *config.Config) {
 	s.Inproc.WithServerStreamInterceptor(stream)
 	s.Inproc.WithServerUnaryInterceptor(unary)
 
+	s.Router.Use(middleware.HTTPClientIdentityMiddleware())
+	s.Router.Use(middleware.HTTPClientAddrMiddleware(&cfg.Auth.IPAllowlist))
 	s.Router.Use(cors.AllowAll().Handler)
+	if cfg.Server.Compression.Enabled {
+		s.Router.Use(newCompressor(cfg.Server.Compression.Level).Handler)
+	}
 	if cfg.Server.Type == config.RealtimeServerType {
 		s.Router.Use(middleware.HTTPMetadataExtractorMiddleware(cfg))
 		s.Router.Use(middleware.HTTPAuthMiddleware(cfg))
 	}
 }
 
+// newCompressor returns the middleware compressing the HTTP responses with the encoding negotiated through the
+// Accept-Encoding header. Zstd is preferred over gzip and deflate.
+func newCompressor(level int) *chi_middleware.Compressor {
+	c := chi_middleware.NewCompressor(level,
+		"application/json",
+		"text/plain",
+		api.MIMEMsgpack,
+		api.MIMEMsgpackAlt,
+		api.MIMEProtobuf,
+	)
+	c.SetEncoder("zstd", func(w io.Writer, level int) io.Writer {
+		enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		if err != nil {
+			return nil
+		}
+		return enc
+	})
+
+	return c
+}
+
 func (s *HTTPServer) Start(mux cmux.CMux) error {
 	match := mux.Match(cmux.HTTP1Fast("PATCH"), cmux.HTTP1HeaderField("Upgrade", "websocket"))
 	go func() {
-		srv := &http.Server{Handler: s.Router, ReadHeaderTimeout: readHeaderTimeout}
+		srv := &http.Server{Handler: s.Router, ReadHeaderTimeout: readHeaderTimeout, ConnContext: clientCertContext}
 		err := srv.Serve(match)
 		log.Fatal().Err(err).Msg("start http server")
 	}()
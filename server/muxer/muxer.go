@@ -15,15 +15,19 @@
 package muxer
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 
 	"github.com/rs/zerolog/log"
 	"github.com/soheilhy/cmux"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/metadata"
 	v1 "github.com/tigrisdata/tigris/server/services/v1"
 	"github.com/tigrisdata/tigris/server/services/v1/billing"
+	"github.com/tigrisdata/tigris/server/services/v1/mongo"
+	"github.com/tigrisdata/tigris/server/services/v1/pgwire"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/store/search"
@@ -34,12 +38,50 @@ type Server interface {
 	Start(mux cmux.CMux) error
 }
 
+// ProtocolServer serves a wire protocol other than HTTP and GRPC on its own port.
+type ProtocolServer interface {
+	Serve(l net.Listener) error
+}
+
+type protocolListener struct {
+	name   string
+	port   int16
+	server ProtocolServer
+}
+
 type Muxer struct {
-	servers []Server
+	servers   []Server
+	listeners []protocolListener
+	tlsConfig *tls.Config
 }
 
 func NewMuxer(cfg *config.Config) *Muxer {
-	return &Muxer{servers: []Server{NewHTTPServer(cfg), NewGRPCServer(cfg)}}
+	httpServer := NewHTTPServer(cfg)
+	m := &Muxer{servers: []Server{httpServer, NewGRPCServer(cfg)}}
+
+	if cfg.Server.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(&cfg.Server.TLS)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to configure TLS")
+		}
+		m.tlsConfig = tlsConfig
+	}
+
+	if cfg.Server.Type != config.DatabaseServerType {
+		return m
+	}
+
+	// the wire protocol listeners call the Tigris API through the in-process channel of the HTTP server, which has
+	// the same interceptors as the GRPC server
+	client := api.NewTigrisClient(httpServer.Inproc)
+	if cfg.Server.MongoPort > 0 {
+		m.listeners = append(m.listeners, protocolListener{name: "mongo", port: cfg.Server.MongoPort, server: mongo.NewServer(client)})
+	}
+	if cfg.Server.PostgresPort > 0 {
+		m.listeners = append(m.listeners, protocolListener{name: "postgres", port: cfg.Server.PostgresPort, server: pgwire.NewServer(client, cfg.Auth.Enabled)})
+	}
+
+	return m
 }
 
 func (m *Muxer) RegisterServices(cfg *config.ServerConfig, kvStore kv.TxStore, searchStore search.Store, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager, forSearchTxMgr *transaction.Manager, biller billing.Provider) {
@@ -72,6 +114,24 @@ func (m *Muxer) Start(host string, port int16) error {
 		log.Fatal().Err(err).Msg("listening failed ")
 	}
 
+	for _, pl := range m.listeners {
+		log.Info().Int16("port", pl.port).Str("protocol", pl.name).Msg("initializing wire protocol listener")
+
+		wl, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, pl.port))
+		if err != nil {
+			log.Fatal().Err(err).Str("protocol", pl.name).Msg("listening failed")
+		}
+		go func(pl protocolListener) {
+			err := pl.server.Serve(wl)
+			log.Fatal().Err(err).Str("protocol", pl.name).Msg("start wire protocol listener")
+		}(pl)
+	}
+
+	if m.tlsConfig != nil {
+		// the TLS is terminated before the connection muxer matches the protocol of the decrypted stream
+		l = tls.NewListener(l, m.tlsConfig)
+	}
+
 	cm := cmux.New(l)
 	for _, s := range m.servers {
 		_ = s.Start(cm)
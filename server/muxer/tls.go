@@ -0,0 +1,123 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muxer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/soheilhy/cmux"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/middleware"
+	"google.golang.org/grpc/credentials"
+)
+
+// newTLSConfig returns the TLS configuration of the server port. HTTP/1.1 is preferred over HTTP/2 in the protocol
+// negotiation, the HTTP/2 connections are served by the gRPC server only.
+func newTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"http/1.1", "h2"},
+	}
+
+	switch cfg.ClientAuth {
+	case "", config.ClientAuthNone:
+		return tlsConfig, nil
+	case config.ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case config.ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unsupported client auth mode '%s'", cfg.ClientAuth)
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in '%s'", cfg.ClientCAFile)
+	}
+
+	return tlsConfig, nil
+}
+
+// tlsConn returns the TLS connection wrapped by the connection muxer, nil for the plain text connections.
+func tlsConn(conn net.Conn) *tls.Conn {
+	if mc, ok := conn.(*cmux.MuxConn); ok {
+		conn = mc.Conn
+	}
+	tc, _ := conn.(*tls.Conn)
+
+	return tc
+}
+
+// clientCertContext saves the verified client certificate of the HTTP connection in the context of its requests.
+func clientCertContext(ctx context.Context, conn net.Conn) context.Context {
+	if tc := tlsConn(conn); tc != nil {
+		if cert := middleware.VerifiedClientCertificate(tc.ConnectionState()); cert != nil {
+			return middleware.WithClientCertificate(ctx, cert)
+		}
+	}
+
+	return ctx
+}
+
+// handshakenTLSCredentials exposes the state of the TLS connections, terminated by the listener before the connection
+// muxer, as the auth info of the gRPC peers.
+type handshakenTLSCredentials struct{}
+
+func (handshakenTLSCredentials) ClientHandshake(_ context.Context, _ string, _ net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, fmt.Errorf("client handshake is not supported")
+}
+
+func (handshakenTLSCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	tc := tlsConn(conn)
+	if tc == nil {
+		return nil, nil, fmt.Errorf("not a TLS connection")
+	}
+	if err := tc.Handshake(); err != nil {
+		return nil, nil, err
+	}
+
+	// the muxer's connection is returned as it has buffered the bytes read while matching the connection
+	return conn, credentials.TLSInfo{
+		State:          tc.ConnectionState(),
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+	}, nil
+}
+
+func (handshakenTLSCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2"}
+}
+
+func (c handshakenTLSCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+func (handshakenTLSCredentials) OverrideServerName(_ string) error {
+	return nil
+}
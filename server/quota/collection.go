@@ -0,0 +1,214 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"golang.org/x/time/rate"
+)
+
+const (
+	ResourceReadUnits      = "read_units"
+	ResourceWriteUnits     = "write_units"
+	ResourceSearchRequests = "search_requests"
+)
+
+// This limiter enforces the per collection limits of the read units, the write units and the search requests
+// on every node. Unlike the namespace limiter it is not coordinated across the nodes.
+
+type collectionState struct {
+	read   *rate.Limiter
+	write  *rate.Limiter
+	search *rate.Limiter
+}
+
+type collection struct {
+	states sync.Map
+	cfg    atomic.Pointer[config.CollectionLimitsConfig]
+}
+
+// CollectionRequest is the part of a request checked by the collection quota.
+type CollectionRequest struct {
+	Namespace  string
+	Project    string
+	Collection string
+	Size       int
+	IsWrite    bool
+	IsSearch   bool
+}
+
+func (r *CollectionRequest) key() string {
+	return r.Namespace + "/" + r.Project + "/" + r.Collection
+}
+
+func newCollectionLimiter(limit int) *rate.Limiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(limit), limit)
+}
+
+func (c *collection) getState(r *CollectionRequest) *collectionState {
+	s, ok := c.states.Load(r.key())
+	if !ok {
+		limits := c.cfg.Load().CollectionLimits(r.Namespace, r.Project, r.Collection)
+		s, _ = c.states.LoadOrStore(r.key(), &collectionState{
+			read:   newCollectionLimiter(limits.ReadUnits),
+			write:  newCollectionLimiter(limits.WriteUnits),
+			search: newCollectionLimiter(limits.SearchRequests),
+		})
+	}
+
+	return s.(*collectionState)
+}
+
+// reservation is the tokens taken from a limiter of the collection by a request.
+type reservation struct {
+	resource string
+	units    int
+	limiter  *rate.Limiter
+	rt       *rate.Reservation
+}
+
+func (c *collection) allowOrWait(ctx context.Context, r *CollectionRequest, isWait bool) error {
+	s := c.getState(r)
+
+	units := toUnits(r.Size, r.IsWrite)
+	reservations := []reservation{{resource: ResourceReadUnits, units: units, limiter: s.read}}
+	if r.IsWrite {
+		reservations[0] = reservation{resource: ResourceWriteUnits, units: units, limiter: s.write}
+	}
+	if r.IsSearch {
+		reservations = append(reservations, reservation{resource: ResourceSearchRequests, units: 1, limiter: s.search})
+	}
+
+	now := time.Now()
+
+	maxWait := time.Duration(0)
+	if isWait {
+		maxWait = maxWaitDuration(ctx, now)
+	}
+
+	var delay time.Duration
+	for i := range reservations {
+		res := &reservations[i]
+		if res.limiter == nil {
+			continue
+		}
+
+		if res.units > res.limiter.Burst() {
+			cancelReservations(reservations, now)
+			return ErrMaxRequestSizeExceeded
+		}
+
+		res.rt = res.limiter.ReserveN(now, res.units)
+		if d := res.rt.DelayFrom(now); d > maxWait {
+			cancelReservations(reservations, now)
+			return c.throttled(r, res, d)
+		} else if d > delay {
+			delay = d
+		}
+	}
+
+	for _, res := range reservations {
+		metrics.UpdateCollectionQuotaUsage(r.Namespace, r.Project, r.Collection, res.resource, res.units)
+	}
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			cancelReservations(reservations, now)
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// maxWaitDuration returns how long the request can be delayed to not violate the limits.
+func maxWaitDuration(ctx context.Context, now time.Time) time.Duration {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return maxWait
+	}
+
+	return d.Sub(now) - waitDelta
+}
+
+func cancelReservations(reservations []reservation, now time.Time) {
+	for _, res := range reservations {
+		if res.rt != nil {
+			res.rt.CancelAt(now)
+		}
+	}
+}
+
+// throttled returns the error of the request exceeding the limit of the resource, the error carries the quota
+// and the delay after which the request can be retried.
+func (c *collection) throttled(r *CollectionRequest, res *reservation, delay time.Duration) error {
+	metrics.UpdateCollectionQuotaThrottled(r.Namespace, r.Project, r.Collection, res.resource, res.units)
+
+	log.Debug().Str("ns", r.Namespace).Str("project", r.Project).Str("collection", r.Collection).
+		Str("resource", res.resource).Dur("retry_after", delay).Msg("Collection quota exceeded")
+
+	return errors.ResourceExhausted("collection '%s' %s rate exceeded", r.Collection, res.resource).
+		WithRetry(delay).
+		WithDetails(api.NewThrottleInfo(&api.ThrottleInfo{
+			Scope:      "collection",
+			Project:    r.Project,
+			Collection: r.Collection,
+			Resource:   res.resource,
+			Limit:      int(res.limiter.Limit()),
+		}))
+}
+
+func (c *collection) Allow(ctx context.Context, r *CollectionRequest) error {
+	return c.allowOrWait(ctx, r, false)
+}
+
+func (c *collection) Wait(ctx context.Context, r *CollectionRequest) error {
+	return c.allowOrWait(ctx, r, true)
+}
+
+func initCollection(cfg *config.QuotaConfig) *collection {
+	log.Debug().Msg("Initializing per collection quota manager")
+
+	c := &collection{}
+	c.cfg.Store(&cfg.Collection)
+
+	return c
+}
+
+// reload applies the new limits to the collections. The limiters of the collections are dropped, so the requests
+// start with the full burst of the new limits.
+func (c *collection) reload(cfg *config.QuotaConfig) {
+	c.cfg.Store(&cfg.Collection)
+
+	c.states.Range(func(key, _ any) bool {
+		c.states.Delete(key)
+		return true
+	})
+}
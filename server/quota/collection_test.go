@@ -0,0 +1,132 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestCollectionQuota(t *testing.T) {
+	c := initCollection(&config.QuotaConfig{
+		Collection: config.CollectionLimitsConfig{
+			Enabled: true,
+			Default: config.CollectionLimits{
+				ReadUnits:      10,
+				WriteUnits:     5,
+				SearchRequests: 2,
+			},
+			Collections: map[string]config.CollectionLimits{
+				"ns1/p1/unlimited": {},
+			},
+		},
+	})
+
+	ctx := context.Background()
+	read := func(coll string, size int) *CollectionRequest {
+		return &CollectionRequest{Namespace: "ns1", Project: "p1", Collection: coll, Size: size}
+	}
+
+	t.Run("read_write", func(t *testing.T) {
+		require.NoError(t, c.Allow(ctx, read("c1", 10*config.ReadUnitSize)))
+
+		err := c.Allow(ctx, read("c1", 1))
+		require.Error(t, err)
+
+		te := api.FromStatusError(err)
+		require.Equal(t, api.Code_RESOURCE_EXHAUSTED, te.Code)
+		require.Equal(t, &api.ThrottleInfo{
+			Scope: "collection", Project: "p1", Collection: "c1", Resource: ResourceReadUnits, Limit: 10,
+		}, te.Throttle())
+		require.Greater(t, te.RetryDelay(), time.Duration(0))
+		require.LessOrEqual(t, te.RetryDelay(), 100*time.Millisecond)
+
+		// writes and the other collections have their own limits
+		require.NoError(t, c.Allow(ctx, &CollectionRequest{
+			Namespace: "ns1", Project: "p1", Collection: "c1", Size: 5 * config.WriteUnitSize, IsWrite: true,
+		}))
+		require.NoError(t, c.Allow(ctx, read("c2", 10*config.ReadUnitSize)))
+		require.NoError(t, c.Allow(ctx, &CollectionRequest{Namespace: "ns2", Project: "p1", Collection: "c1", Size: 1}))
+	})
+
+	t.Run("max_size", func(t *testing.T) {
+		require.Equal(t, ErrMaxRequestSizeExceeded, c.Allow(ctx, read("c3", 11*config.ReadUnitSize)))
+		// the rejected request doesn't consume the units
+		require.NoError(t, c.Allow(ctx, read("c3", 10*config.ReadUnitSize)))
+	})
+
+	t.Run("search", func(t *testing.T) {
+		search := &CollectionRequest{Namespace: "ns1", Project: "p1", Collection: "c4", Size: 1, IsSearch: true}
+		require.NoError(t, c.Allow(ctx, search))
+		require.NoError(t, c.Allow(ctx, search))
+
+		err := c.Allow(ctx, search)
+		require.Equal(t, ResourceSearchRequests, api.FromStatusError(err).Throttle().Resource)
+
+		// the throttled search request doesn't consume the read units
+		require.NoError(t, c.Allow(ctx, read("c4", 8*config.ReadUnitSize)))
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		require.NoError(t, c.Allow(ctx, read("c5", 10*config.ReadUnitSize)))
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		start := time.Now()
+		require.NoError(t, c.Wait(ctx, read("c5", 2*config.ReadUnitSize)))
+		require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+		ctx1, cancel1 := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel1()
+
+		err := c.Wait(ctx1, read("c5", 10*config.ReadUnitSize))
+		require.Equal(t, api.Code_RESOURCE_EXHAUSTED, api.FromStatusError(err).Code)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Allow(ctx, read("unlimited", 100*config.ReadUnitSize)))
+		}
+	})
+}
+
+func TestCollectionQuotaReload(t *testing.T) {
+	cfg := &config.QuotaConfig{
+		Collection: config.CollectionLimitsConfig{
+			Enabled: true,
+			Default: config.CollectionLimits{ReadUnits: 10, WriteUnits: 5},
+		},
+	}
+	c := initCollection(cfg)
+
+	ctx := context.Background()
+	r := &CollectionRequest{Namespace: "ns1", Project: "p1", Collection: "c1", Size: 20 * config.ReadUnitSize}
+	require.Equal(t, ErrMaxRequestSizeExceeded, c.Allow(ctx, r))
+
+	c.reload(&config.QuotaConfig{
+		Collection: config.CollectionLimitsConfig{
+			Enabled: true,
+			Default: config.CollectionLimits{ReadUnits: 20, WriteUnits: 5},
+		},
+	})
+	require.NoError(t, c.Allow(ctx, r))
+	require.Error(t, c.Allow(ctx, r))
+}
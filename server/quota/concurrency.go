@@ -0,0 +1,276 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metrics"
+)
+
+const (
+	ResourceConcurrentRequests = "concurrent_requests"
+	ResourceStreams            = "streams"
+)
+
+// This limiter admits the requests of the namespaces up to their concurrency limits on every node. The requests over
+// the limits of their namespace, or over the node limit, wait in the FIFO queue of the namespace. The released slots
+// are handed to the queued requests of the namespaces in turns.
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+type namespaceConcurrency struct {
+	inFlight int
+	streams  int
+	waiters  []*waiter
+	// scheduled is true while the namespace is in the round-robin ring
+	scheduled bool
+}
+
+type concurrency struct {
+	sync.Mutex
+
+	cfg          atomic.Pointer[config.ConcurrencyLimitsConfig]
+	nodeInFlight int
+	namespaces   map[string]*namespaceConcurrency
+	// ring is the round-robin order of the namespaces with the queued requests
+	ring []string
+}
+
+func initConcurrency(cfg *config.QuotaConfig) *concurrency {
+	log.Debug().Msg("Initializing per namespace concurrency limiter")
+
+	c := &concurrency{namespaces: make(map[string]*namespaceConcurrency)}
+	c.cfg.Store(&cfg.Concurrency)
+
+	return c
+}
+
+// reload applies the new limits, the queued requests are admitted right away if the limits are raised. The requests
+// in flight over the lowered limits are not interrupted.
+func (c *concurrency) reload(cfg *config.QuotaConfig) {
+	c.cfg.Store(&cfg.Concurrency)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.dispatch()
+}
+
+func (c *concurrency) getState(namespace string) *namespaceConcurrency {
+	s, ok := c.namespaces[namespace]
+	if !ok {
+		s = &namespaceConcurrency{}
+		c.namespaces[namespace] = s
+	}
+
+	return s
+}
+
+func underLimit(count int, limit int) bool {
+	return limit <= 0 || count < limit
+}
+
+// admit takes an in-flight slot for the namespace, must be called under the lock.
+func (c *concurrency) admit(s *namespaceConcurrency) {
+	s.inFlight++
+	c.nodeInFlight++
+}
+
+// AcquireRequest admits the request of the namespace, waiting in the queue of the namespace if its limit or the node
+// limit is reached. The returned function releases the slot once the request is done.
+func (c *concurrency) AcquireRequest(ctx context.Context, namespace string) (func(), error) {
+	cfg := c.cfg.Load()
+	limits := cfg.NamespaceLimits(namespace)
+
+	c.Lock()
+	s := c.getState(namespace)
+	if len(s.waiters) == 0 && underLimit(s.inFlight, limits.Requests) && underLimit(c.nodeInFlight, cfg.NodeRequests) {
+		c.admit(s)
+		c.Unlock()
+		return func() { c.release(namespace) }, nil
+	}
+
+	if len(s.waiters) >= limits.Queue {
+		c.Unlock()
+		return nil, c.throttled(namespace, ResourceConcurrentRequests, limits.Requests)
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	s.waiters = append(s.waiters, w)
+	c.schedule(namespace, s)
+	c.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(cfg.QueueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+	case <-timer.C:
+		err = c.throttled(namespace, ResourceConcurrentRequests, limits.Requests)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil && !c.cancel(namespace, w) {
+		// the slot was granted while the wait was ending
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.UpdateConcurrencyQueued(namespace, time.Since(start))
+
+	return func() { c.release(namespace) }, nil
+}
+
+// cancel removes the waiter from the queue, it returns false if the waiter has already been granted a slot.
+func (c *concurrency) cancel(namespace string, w *waiter) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if w.granted {
+		return false
+	}
+
+	s := c.namespaces[namespace]
+	for i := range s.waiters {
+		if s.waiters[i] == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			break
+		}
+	}
+	c.cleanup(namespace, s)
+
+	return true
+}
+
+func (c *concurrency) release(namespace string) {
+	c.Lock()
+	defer c.Unlock()
+
+	s := c.namespaces[namespace]
+	s.inFlight--
+	c.nodeInFlight--
+
+	c.schedule(namespace, s)
+	c.dispatch()
+	c.cleanup(namespace, s)
+}
+
+// schedule adds the namespace with the queued requests to the end of the ring, must be called under the lock.
+func (c *concurrency) schedule(namespace string, s *namespaceConcurrency) {
+	if len(s.waiters) > 0 && !s.scheduled {
+		s.scheduled = true
+		c.ring = append(c.ring, namespace)
+	}
+}
+
+// cleanup drops the state of the idle namespace, must be called under the lock.
+func (c *concurrency) cleanup(namespace string, s *namespaceConcurrency) {
+	if s.inFlight == 0 && s.streams == 0 && len(s.waiters) == 0 && !s.scheduled {
+		delete(c.namespaces, namespace)
+	}
+}
+
+// dispatch hands the free slots to the queued requests, one request of a namespace at a time in the order of the
+// ring, must be called under the lock. The namespaces at their own limit keep their place in the ring.
+func (c *concurrency) dispatch() {
+	cfg := c.cfg.Load()
+	for skipped := 0; len(c.ring) > 0 && skipped < len(c.ring) && underLimit(c.nodeInFlight, cfg.NodeRequests); {
+		namespace := c.ring[0]
+		c.ring = c.ring[1:]
+
+		s := c.namespaces[namespace]
+		if len(s.waiters) == 0 {
+			// the queued requests were canceled
+			s.scheduled = false
+			c.cleanup(namespace, s)
+			continue
+		}
+		if !underLimit(s.inFlight, cfg.NamespaceLimits(namespace).Requests) {
+			c.ring = append(c.ring, namespace)
+			skipped++
+			continue
+		}
+
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		w.granted = true
+		c.admit(s)
+		close(w.ready)
+		skipped = 0
+
+		if len(s.waiters) > 0 {
+			c.ring = append(c.ring, namespace)
+		} else {
+			s.scheduled = false
+		}
+	}
+}
+
+// AcquireStream admits the streaming request of the namespace if it's below the limit of the open streams. The
+// streams are not queued, they are rejected right away. The returned function releases the stream once it's closed.
+func (c *concurrency) AcquireStream(_ context.Context, namespace string) (func(), error) {
+	limits := c.cfg.Load().NamespaceLimits(namespace)
+
+	c.Lock()
+	defer c.Unlock()
+
+	s := c.getState(namespace)
+	if !underLimit(s.streams, limits.Streams) {
+		c.cleanup(namespace, s)
+		return nil, c.throttled(namespace, ResourceStreams, limits.Streams)
+	}
+	s.streams++
+
+	return func() {
+		c.Lock()
+		defer c.Unlock()
+
+		s := c.namespaces[namespace]
+		s.streams--
+		c.cleanup(namespace, s)
+	}, nil
+}
+
+// throttled returns the error of the request exceeding the concurrency limit of the namespace, the error carries the
+// limit and the delay after which the request can be retried.
+func (c *concurrency) throttled(namespace string, resource string, limit int) error {
+	metrics.UpdateConcurrencyThrottled(namespace, resource)
+
+	log.Debug().Str("ns", namespace).Str("resource", resource).Int("limit", limit).Msg("Concurrency limit exceeded")
+
+	return errors.ResourceExhausted("too many %s of the namespace, the limit is %d", resource, limit).
+		WithRetry(c.cfg.Load().QueueTimeout).
+		WithDetails(api.NewThrottleInfo(&api.ThrottleInfo{
+			Scope:    "namespace",
+			Resource: resource,
+			Limit:    limit,
+		}))
+}
@@ -0,0 +1,188 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func newTestConcurrency(nodeRequests int, queueTimeout time.Duration) *concurrency {
+	return initConcurrency(&config.QuotaConfig{
+		Concurrency: config.ConcurrencyLimitsConfig{
+			Enabled:      true,
+			Default:      config.ConcurrencyLimits{Requests: 2, Streams: 1, Queue: 2},
+			NodeRequests: nodeRequests,
+			QueueTimeout: queueTimeout,
+		},
+	})
+}
+
+// acquireAsync starts a request which waits for a slot, the result is sent to the returned channel.
+func acquireAsync(ctx context.Context, c *concurrency, namespace string) chan error {
+	ch := make(chan error, 1)
+	go func() {
+		_, err := c.AcquireRequest(ctx, namespace)
+		ch <- err
+	}()
+
+	return ch
+}
+
+// waitQueued waits until the namespace has n queued requests.
+func waitQueued(t *testing.T, c *concurrency, namespace string, n int) {
+	require.Eventually(t, func() bool {
+		c.Lock()
+		defer c.Unlock()
+
+		s, ok := c.namespaces[namespace]
+		return ok && len(s.waiters) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestConcurrencyRequests(t *testing.T) {
+	ctx := context.Background()
+	c := newTestConcurrency(0, time.Minute)
+
+	release1, err := c.AcquireRequest(ctx, "ns1")
+	require.NoError(t, err)
+	release2, err := c.AcquireRequest(ctx, "ns1")
+	require.NoError(t, err)
+
+	// the other namespaces are not affected
+	release3, err := c.AcquireRequest(ctx, "ns2")
+	require.NoError(t, err)
+	release3()
+
+	queued1 := acquireAsync(ctx, c, "ns1")
+	waitQueued(t, c, "ns1", 1)
+	queued2 := acquireAsync(ctx, c, "ns1")
+	waitQueued(t, c, "ns1", 2)
+
+	// the queue is full
+	_, err = c.AcquireRequest(ctx, "ns1")
+	te := api.FromStatusError(err)
+	require.Equal(t, api.Code_RESOURCE_EXHAUSTED, te.Code)
+	require.Equal(t, &api.ThrottleInfo{Scope: "namespace", Resource: ResourceConcurrentRequests, Limit: 2}, te.Throttle())
+	require.Equal(t, time.Minute, te.RetryDelay())
+
+	release1()
+	require.NoError(t, <-queued1)
+	release2()
+	require.NoError(t, <-queued2)
+}
+
+func TestConcurrencyQueueTimeout(t *testing.T) {
+	ctx := context.Background()
+	c := newTestConcurrency(0, 10*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.AcquireRequest(ctx, "ns1")
+		require.NoError(t, err)
+	}
+
+	_, err := c.AcquireRequest(ctx, "ns1")
+	require.Equal(t, api.Code_RESOURCE_EXHAUSTED, api.FromStatusError(err).Code)
+
+	cctx, cancel := context.WithCancel(ctx)
+	queued := acquireAsync(cctx, c, "ns1")
+	cancel()
+	require.Equal(t, context.Canceled, <-queued)
+
+	c.Lock()
+	defer c.Unlock()
+	require.Empty(t, c.namespaces["ns1"].waiters)
+}
+
+func TestConcurrencyFairQueuing(t *testing.T) {
+	ctx := context.Background()
+	c := newTestConcurrency(1, time.Minute)
+
+	release, err := c.AcquireRequest(ctx, "ns1")
+	require.NoError(t, err)
+
+	ns1First := acquireAsync(ctx, c, "ns1")
+	waitQueued(t, c, "ns1", 1)
+	ns1Second := acquireAsync(ctx, c, "ns1")
+	waitQueued(t, c, "ns1", 2)
+	ns2 := acquireAsync(ctx, c, "ns2")
+	waitQueued(t, c, "ns2", 1)
+
+	release()
+	require.NoError(t, <-ns1First)
+
+	// ns2 is served before the second request of ns1 though it was queued later
+	c.release("ns1")
+	require.NoError(t, <-ns2)
+
+	c.release("ns2")
+	require.NoError(t, <-ns1Second)
+	c.release("ns1")
+
+	c.Lock()
+	defer c.Unlock()
+	require.Equal(t, 0, c.nodeInFlight)
+	require.Empty(t, c.namespaces)
+	require.Empty(t, c.ring)
+}
+
+func TestConcurrencyStreams(t *testing.T) {
+	ctx := context.Background()
+	c := newTestConcurrency(0, time.Minute)
+
+	release, err := c.AcquireStream(ctx, "ns1")
+	require.NoError(t, err)
+
+	_, err = c.AcquireStream(ctx, "ns1")
+	require.Equal(t, &api.ThrottleInfo{Scope: "namespace", Resource: ResourceStreams, Limit: 1}, api.FromStatusError(err).Throttle())
+
+	_, err = c.AcquireStream(ctx, "ns2")
+	require.NoError(t, err)
+
+	release()
+	_, err = c.AcquireStream(ctx, "ns1")
+	require.NoError(t, err)
+}
+
+func TestConcurrencyReload(t *testing.T) {
+	c := newTestConcurrency(0, 5*time.Second)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := c.AcquireRequest(ctx, "ns1")
+		require.NoError(t, err)
+	}
+
+	queued := acquireAsync(ctx, c, "ns1")
+	waitQueued(t, c, "ns1", 1)
+
+	// raising the limit admits the queued request right away
+	c.reload(&config.QuotaConfig{
+		Concurrency: config.ConcurrencyLimitsConfig{
+			Enabled:      true,
+			Default:      config.ConcurrencyLimits{Requests: 3, Streams: 1, Queue: 2},
+			QueueTimeout: 5 * time.Second,
+		},
+	})
+	require.NoError(t, <-queued)
+
+	_, err := c.AcquireRequest(ctx, "ns2")
+	require.NoError(t, err)
+}
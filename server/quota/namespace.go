@@ -51,7 +51,7 @@ type namespace struct {
 	tenantQuota sync.Map
 	tenantMgr   *metadata.TenantManager
 
-	cfg *config.NamespaceLimitsConfig
+	cfg atomic.Pointer[config.NamespaceLimitsConfig]
 
 	wg     sync.WaitGroup
 	ctx    context.Context
@@ -97,11 +97,12 @@ func isBlacklistedNamespace(namespace string, cfg *config.NamespaceLimitsConfig)
 }
 
 func (i *namespace) allowOrWait(ctx context.Context, namespace string, size int, isWrite bool, isWait bool) error {
-	if unlimitedDefaultNamespace(namespace, i.cfg) {
+	cfg := i.cfg.Load()
+	if unlimitedDefaultNamespace(namespace, cfg) {
 		return nil
 	}
 
-	if isBlacklistedNamespace(namespace, i.cfg) {
+	if isBlacklistedNamespace(namespace, cfg) {
 		if isWrite {
 			return ErrWriteUnitsExceeded
 		}
@@ -110,7 +111,7 @@ func (i *namespace) allowOrWait(ctx context.Context, namespace string, size int,
 
 	units := toUnits(size, isWrite)
 
-	if err := checkMaxSize(units, namespace, isWrite, i.cfg); err != nil {
+	if err := checkMaxSize(units, namespace, isWrite, cfg); err != nil {
 		return err
 	}
 
@@ -153,14 +154,15 @@ func getHumanUserNamespace(namespace string) string {
 }
 
 func (i *namespace) getLimits(namespace string) (int, int) {
-	cfg, ok := i.cfg.Namespaces[namespace]
+	nsCfg := i.cfg.Load()
+	cfg, ok := nsCfg.Namespaces[namespace]
 	if !ok {
-		cfg = i.cfg.Default
+		cfg = nsCfg.Default
 	}
 
 	// guarantee that hysteresis band is above promised
 	// per namespace limit.
-	h := i.cfg.Regulator.Hysteresis
+	h := nsCfg.Regulator.Hysteresis
 	ru, wu := cfg.ReadUnits+2*h, cfg.WriteUnits+2*h
 
 	if isHumanUserNamespace(namespace) {
@@ -176,11 +178,12 @@ func (i *namespace) getState(namespace string) *instanceState {
 	if !ok {
 		ru, wu := i.getLimits(namespace)
 		// do allow more then maximum per node quota
-		if ru > i.cfg.Node.ReadUnits {
-			ru = i.cfg.Node.ReadUnits
+		nodeLimits := i.cfg.Load().Node
+		if ru > nodeLimits.ReadUnits {
+			ru = nodeLimits.ReadUnits
 		}
-		if wu > i.cfg.Node.WriteUnits {
-			wu = i.cfg.Node.WriteUnits
+		if wu > nodeLimits.WriteUnits {
+			wu = nodeLimits.WriteUnits
 		}
 		// Create new state if didn't exist before
 		is = &instanceState{
@@ -239,10 +242,11 @@ func calcLimit(setNodeLimit int64, maxNodeLimit int64, curNamespace int64, maxNa
 
 func (i *namespace) updateLimits(ns string, is *instanceState, curRead int64, curWrite int64) {
 	ru, wu := i.getLimits(ns)
+	cfg := i.cfg.Load()
 
 	// calculate read limits
-	readLimit := calcLimit(is.setReadLimit.Load(), int64(i.cfg.Node.ReadUnits), curRead, int64(ru),
-		int64(i.cfg.Regulator.Hysteresis), int64(i.cfg.Regulator.Increment))
+	readLimit := calcLimit(is.setReadLimit.Load(), int64(cfg.Node.ReadUnits), curRead, int64(ru),
+		int64(cfg.Regulator.Hysteresis), int64(cfg.Regulator.Increment))
 	// update read limiter config
 	if readLimit != is.setReadLimit.Load() {
 		is.Read.SetLimit(int(readLimit))
@@ -253,8 +257,8 @@ func (i *namespace) updateLimits(ns string, is *instanceState, curRead int64, cu
 	}
 
 	// calculate write limits
-	writeLimit := calcLimit(is.setWriteLimit.Load(), int64(i.cfg.Node.WriteUnits), curWrite, int64(wu),
-		int64(i.cfg.Regulator.Hysteresis), int64(i.cfg.Regulator.Increment))
+	writeLimit := calcLimit(is.setWriteLimit.Load(), int64(cfg.Node.WriteUnits), curWrite, int64(wu),
+		int64(cfg.Regulator.Hysteresis), int64(cfg.Regulator.Increment))
 	// update write limiter config
 	if writeLimit != is.setWriteLimit.Load() {
 		is.Write.SetLimit(int(writeLimit))
@@ -271,9 +275,10 @@ func initNamespace(tm *metadata.TenantManager, cfg *config.QuotaConfig, backend
 	ctx, cancel := context.WithCancel(context.Background())
 
 	i := &namespace{
-		cfg: &cfg.Namespace, tenantMgr: tm, ctx: ctx, cancel: cancel,
+		tenantMgr: tm, ctx: ctx, cancel: cancel,
 		backend: backend,
 	}
+	i.cfg.Store(&cfg.Namespace)
 
 	i.wg.Add(1)
 
@@ -282,6 +287,12 @@ func initNamespace(tm *metadata.TenantManager, cfg *config.QuotaConfig, backend
 	return i
 }
 
+// reload applies the new limits to the namespaces on the next refresh of their rates. The refresh interval is not
+// changed until restart.
+func (i *namespace) reload(cfg *config.QuotaConfig) {
+	i.cfg.Store(&cfg.Namespace)
+}
+
 func (i *namespace) Cleanup() {
 	i.cancel()
 	i.wg.Wait()
@@ -290,9 +301,10 @@ func (i *namespace) Cleanup() {
 func (i *namespace) refreshLoop() {
 	defer i.wg.Done()
 
-	log.Debug().Dur("refresh_interval", i.cfg.RefreshInterval).Msg("Initializing namespace refresh loop")
+	interval := i.cfg.Load().RefreshInterval
+	log.Debug().Dur("refresh_interval", interval).Msg("Initializing namespace refresh loop")
 
-	t := time.NewTicker(i.cfg.RefreshInterval)
+	t := time.NewTicker(interval)
 	defer t.Stop()
 
 	for {
@@ -16,6 +16,7 @@ package quota
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/rs/zerolog/log"
 	"github.com/tigrisdata/tigris/server/config"
@@ -28,14 +29,14 @@ import (
 
 type node struct {
 	state *State
-	cfg   *config.QuotaConfig
+	cfg   atomic.Pointer[config.LimitsConfig]
 }
 
 func (c *node) Allow(_ context.Context, namespace string, size int, isWrite bool) error {
 	units := toUnits(size, isWrite)
 
 	// Request size is bigger then entire node quota
-	if units > c.cfg.Node.Limit(isWrite) {
+	if units > c.cfg.Load().Limit(isWrite) {
 		return ErrMaxRequestSizeExceeded
 	}
 
@@ -46,7 +47,7 @@ func (c *node) Wait(ctx context.Context, namespace string, size int, isWrite boo
 	units := toUnits(size, isWrite)
 
 	// Request size is bigger then entire node quota
-	if units > c.cfg.Node.Limit(isWrite) {
+	if units > c.cfg.Load().Limit(isWrite) {
 		return ErrMaxRequestSizeExceeded
 	}
 
@@ -72,5 +73,18 @@ func initNode(cfg *config.QuotaConfig) *node {
 		},
 	}
 
-	return &node{cfg: cfg, state: is}
+	n := &node{state: is}
+	n.cfg.Store(&cfg.Node)
+
+	return n
+}
+
+// reload applies the new limits of the node.
+func (c *node) reload(cfg *config.QuotaConfig) {
+	c.cfg.Store(&cfg.Node)
+
+	c.state.Write.SetLimit(cfg.Node.WriteUnits)
+	c.state.Write.SetBurst(cfg.Node.WriteUnits)
+	c.state.Read.SetLimit(cfg.Node.ReadUnits)
+	c.state.Read.SetBurst(cfg.Node.ReadUnits)
 }
@@ -59,7 +59,10 @@ func (s *State) Wait(ctx context.Context, size int, isWrite bool) error {
 }
 
 type Manager struct {
-	quota []Quota
+	quota       []Quota
+	collection  *collection
+	searchKey   *searchKey
+	concurrency *concurrency
 }
 
 var mgr Manager
@@ -100,15 +103,54 @@ func initManager(tm *metadata.TenantManager, cfg *config.Config) *Manager {
 		}
 	}
 
-	return &Manager{quota: q}
+	m := &Manager{quota: q, searchKey: &searchKey{}}
+
+	if cfg.Quota.Collection.Enabled {
+		m.collection = initCollection(&cfg.Quota)
+	}
+
+	if cfg.Quota.Concurrency.Enabled {
+		m.concurrency = initConcurrency(&cfg.Quota)
+	}
+
+	return m
 }
 
 func Init(tm *metadata.TenantManager, cfg *config.Config) error {
 	mgr = *initManager(tm, cfg)
 
+	config.OnReload("quota", func(c *config.Config) any { return c.Quota }, func(c *config.Config) error {
+		mgr.reload(&c.Quota)
+		return nil
+	})
+
 	return nil
 }
 
+// reloadable is implemented by the limiters which apply the new limits without restart.
+type reloadable interface {
+	reload(cfg *config.QuotaConfig)
+}
+
+// reload applies the new limits to the enabled limiters, enabling or disabling a limiter takes effect on restart.
+func (m *Manager) reload(cfg *config.QuotaConfig) {
+	for _, q := range m.quota {
+		if r, ok := q.(reloadable); ok {
+			r.reload(cfg)
+		}
+	}
+
+	if m.collection != nil {
+		m.collection.reload(cfg)
+	}
+
+	if m.concurrency != nil {
+		m.concurrency.reload(cfg)
+	}
+
+	log.Debug().Msg("Reloaded quota limits")
+}
+
 func (*Manager) cleanup() {
 	for _, q := range mgr.quota {
 		q.Cleanup()
@@ -170,3 +212,57 @@ func Wait(ctx context.Context, namespace string, size int, isWrite bool) error {
 
 	return nil
 }
+
+// AllowCollection checks the read, write and search rates of the collection of the request and returns error if at
+// least one of them is exceeded. The error carries the exceeded quota and the delay after which the request can be
+// retried.
+func AllowCollection(ctx context.Context, r *CollectionRequest) error {
+	if mgr.collection == nil {
+		return nil
+	}
+
+	return mgr.collection.Allow(ctx, r)
+}
+
+// WaitCollection is AllowCollection which delays the request, up to the context timeout, instead of rejecting it if
+// the rates are exceeded at the moment.
+func WaitCollection(ctx context.Context, r *CollectionRequest) error {
+	if mgr.collection == nil {
+		return nil
+	}
+
+	return mgr.collection.Wait(ctx, r)
+}
+
+// AllowSearchKey checks the queries per second of the search-only key the request is made with and returns error if
+// it is exceeded.
+func AllowSearchKey(ctx context.Context, r *SearchKeyRequest) error {
+	if mgr.searchKey == nil {
+		return nil
+	}
+
+	return mgr.searchKey.Allow(ctx, r)
+}
+
+func noopRelease() {}
+
+// AcquireRequest admits the request of the namespace if it is within the concurrency limits, the request waits in
+// the queue of the namespace up to the queue timeout otherwise. The returned function must be called once the request
+// is done.
+func AcquireRequest(ctx context.Context, namespace string) (func(), error) {
+	if mgr.concurrency == nil {
+		return noopRelease, nil
+	}
+
+	return mgr.concurrency.AcquireRequest(ctx, namespace)
+}
+
+// AcquireStream admits the streaming request of the namespace if it is within the limit of the open streams. The
+// returned function must be called once the stream is closed.
+func AcquireStream(ctx context.Context, namespace string) (func(), error) {
+	if mgr.concurrency == nil {
+		return noopRelease, nil
+	}
+
+	return mgr.concurrency.AcquireStream(ctx, namespace)
+}
@@ -0,0 +1,82 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"golang.org/x/time/rate"
+)
+
+// This limiter enforces the queries per second of the search-only keys on every node. The limit is set when the key
+// is created, the keys without the limit are only subject to the namespace and the collection quotas.
+
+type searchKey struct {
+	limiters sync.Map
+}
+
+// SearchKeyRequest is a search request made with a search-only key.
+type SearchKeyRequest struct {
+	Namespace string
+	Project   string
+	KeyId     string
+	QPS       int
+}
+
+func (r *SearchKeyRequest) key() string {
+	return r.Namespace + "/" + r.Project + "/" + r.KeyId
+}
+
+func (s *searchKey) getLimiter(r *SearchKeyRequest) *rate.Limiter {
+	l, ok := s.limiters.Load(r.key())
+	if !ok {
+		l, _ = s.limiters.LoadOrStore(r.key(), rate.NewLimiter(rate.Limit(r.QPS), r.QPS))
+	}
+
+	return l.(*rate.Limiter)
+}
+
+func (s *searchKey) Allow(_ context.Context, r *SearchKeyRequest) error {
+	if r.QPS <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	l := s.getLimiter(r)
+	rt := l.ReserveN(now, 1)
+	if d := rt.DelayFrom(now); d > 0 {
+		rt.CancelAt(now)
+
+		log.Debug().Str("ns", r.Namespace).Str("project", r.Project).Str("key", r.KeyId).
+			Dur("retry_after", d).Msg("Search key quota exceeded")
+
+		return errors.ResourceExhausted("search key '%s' %s rate exceeded", r.KeyId, ResourceSearchRequests).
+			WithRetry(d).
+			WithDetails(api.NewThrottleInfo(&api.ThrottleInfo{
+				Scope:    "search_key",
+				Project:  r.Project,
+				Resource: ResourceSearchRequests,
+				Limit:    int(l.Limit()),
+			}))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestSearchKeyQuota(t *testing.T) {
+	s := &searchKey{}
+	ctx := context.Background()
+
+	req := &SearchKeyRequest{Namespace: "ns1", Project: "p1", KeyId: "k1", QPS: 2}
+	require.NoError(t, s.Allow(ctx, req))
+	require.NoError(t, s.Allow(ctx, req))
+
+	err := s.Allow(ctx, req)
+	require.Error(t, err)
+
+	te := api.FromStatusError(err)
+	require.Equal(t, api.Code_RESOURCE_EXHAUSTED, te.Code)
+	require.Equal(t, &api.ThrottleInfo{
+		Scope: "search_key", Project: "p1", Resource: ResourceSearchRequests, Limit: 2,
+	}, te.Throttle())
+	require.Greater(t, te.RetryDelay(), time.Duration(0))
+
+	// the other keys have their own limits
+	require.NoError(t, s.Allow(ctx, &SearchKeyRequest{Namespace: "ns1", Project: "p1", KeyId: "k2", QPS: 1}))
+
+	// the keys without the limit are not throttled
+	unlimited := &SearchKeyRequest{Namespace: "ns1", Project: "p1", KeyId: "k3"}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, s.Allow(ctx, unlimited))
+	}
+}
@@ -34,6 +34,8 @@ type storage struct {
 	tenantQuota sync.Map
 	cfg         *config.QuotaConfig
 	tenantMgr   *metadata.TenantManager
+	// limits are the size limits which are changed on reload
+	limits atomic.Pointer[config.StorageLimitsConfig]
 
 	wg     sync.WaitGroup
 	ctx    context.Context
@@ -94,8 +96,9 @@ func (s *storage) getState(namespace string) *storageState {
 func (s *storage) checkStorage(namespace string, ss *storageState, size int) error {
 	sz := ss.Size.Load()
 
-	sizeLimit := s.cfg.Storage.DataSizeLimit
-	nsLimit, ok := s.cfg.Storage.Namespaces[namespace]
+	limits := s.limits.Load()
+	sizeLimit := limits.DataSizeLimit
+	nsLimit, ok := limits.Namespaces[namespace]
 	if ok {
 		sizeLimit = nsLimit.Size
 	}
@@ -113,6 +116,7 @@ func initStorage(tm *metadata.TenantManager, cfg *config.QuotaConfig) *storage {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s := &storage{tenantMgr: tm, ctx: ctx, cancel: cancel, cfg: cfg}
+	s.limits.Store(&cfg.Storage)
 
 	s.wg.Add(1)
 
@@ -218,6 +222,12 @@ func (s *storage) updateAllMetrics(ctx context.Context) {
 	}
 }
 
+// reload applies the new size limits, enabling or disabling the storage quota and the refresh interval take effect
+// on restart.
+func (s *storage) reload(cfg *config.QuotaConfig) {
+	s.limits.Store(&cfg.Storage)
+}
+
 func (s *storage) refreshLoop() {
 	defer s.wg.Done()
 
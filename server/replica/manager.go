@@ -0,0 +1,185 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replica keeps track of the read-only replicas. The replicas report their lag in the metadata on every
+// heartbeat, the servers routing the reads write the clock the replicas measure their lag by and balance the eligible
+// reads across the replicas lagging less than the reads tolerate.
+package replica
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/server/types"
+)
+
+// Manager registers the server as a replica if it is read-only and refreshes the replicas if it routes the reads.
+type Manager struct {
+	sync.RWMutex
+
+	cfg     config.ReplicaConfig
+	txMgr   *transaction.Manager
+	store   *metadata.ReplicaSubspace
+	id      string
+	address string
+	now     func() time.Time
+	// replicas are the replicas read by the last refresh
+	replicas []metadata.Replica
+	next     atomic.Uint64
+}
+
+func NewManager(cfg config.ReplicaConfig, port int16, txMgr *transaction.Manager) *Manager {
+	address := cfg.Address
+	if len(address) == 0 {
+		address = fmt.Sprintf("%s:%d", types.MyOrigin, port)
+	}
+
+	return &Manager{
+		cfg:     cfg,
+		txMgr:   txMgr,
+		store:   metadata.NewReplicaStore(metadata.DefaultNameRegistry),
+		id:      fmt.Sprintf("%s/%s", types.MyOrigin, uuid.New().String()),
+		address: address,
+		now:     time.Now,
+	}
+}
+
+func (m *Manager) Start() {
+	if m.cfg.ReadOnly || len(m.cfg.Routing) > 0 {
+		go m.loop()
+	}
+}
+
+func (m *Manager) loop() {
+	log.Info().Dur("interval", m.cfg.HeartbeatInterval).Str("address", m.address).Bool("read_only", m.cfg.ReadOnly).
+		Str("routing", m.cfg.Routing).Msg("Starting replica manager")
+	t := time.NewTicker(m.cfg.HeartbeatInterval)
+	defer t.Stop()
+	for range t.C {
+		m.tick(context.Background())
+	}
+}
+
+func (m *Manager) tick(ctx context.Context) {
+	if m.cfg.ReadOnly {
+		if err := m.heartbeat(ctx); err != nil {
+			log.Err(err).Msg("failed to send the replica heartbeat")
+		}
+	}
+
+	if len(m.cfg.Routing) > 0 {
+		if err := m.refresh(ctx); err != nil {
+			log.Err(err).Msg("failed to refresh the replicas")
+		}
+	}
+}
+
+// heartbeat reports the lag of the replica, which is the age of the last clock written by the routing servers the
+// replica observes. The lag is unknown until a routing server writes the clock.
+func (m *Manager) heartbeat(ctx context.Context) error {
+	tx, err := m.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	lag := int64(-1)
+	clock, err := m.store.ReadClock(ctx, tx)
+	switch {
+	case err == nil:
+		lag = now.Sub(clock).Milliseconds()
+		if lag < 0 {
+			lag = 0
+		}
+	case err == errors.ErrNotFound:
+		err = nil
+	}
+	if err == nil {
+		err = m.store.Heartbeat(ctx, tx, &metadata.Replica{Id: m.id, Address: m.address, Lag: lag, HeartbeatAt: now.UnixMilli()})
+	}
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// refresh writes the clock and reads the replicas, removing the ones which stopped sending the heartbeats.
+func (m *Manager) refresh(ctx context.Context) error {
+	tx, err := m.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	var replicas []metadata.Replica
+	if err = m.store.WriteClock(ctx, tx, now); err == nil {
+		replicas, err = m.store.Expire(ctx, tx, m.cfg.TTL, now)
+	}
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	m.setReplicas(replicas)
+
+	return nil
+}
+
+func (m *Manager) setReplicas(replicas []metadata.Replica) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.replicas = replicas
+}
+
+// Pick returns the address of a replica lagging less than the tolerated staleness and the maximum lag, the replicas
+// are picked in turns. It returns false if no replica is eligible.
+func (m *Manager) Pick(staleness time.Duration) (string, bool) {
+	limit := m.cfg.MaxLag
+	if staleness < limit {
+		limit = staleness
+	}
+
+	now := m.now()
+
+	m.RLock()
+	eligible := make([]string, 0, len(m.replicas))
+	for i := range m.replicas {
+		if lag := m.replicas[i].EffectiveLag(now); lag >= 0 && lag <= limit {
+			eligible = append(eligible, m.replicas[i].Address)
+		}
+	}
+	m.RUnlock()
+
+	if len(eligible) == 0 {
+		return "", false
+	}
+
+	return eligible[m.next.Add(1)%uint64(len(eligible))], true
+}
@@ -0,0 +1,67 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replica
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+)
+
+func TestPick(t *testing.T) {
+	now := time.Now()
+	m := NewManager(config.ReplicaConfig{MaxLag: 2 * time.Second, Routing: config.ReplicaRoutingProxy}, 8081, nil)
+	m.now = func() time.Time { return now }
+
+	_, ok := m.Pick(time.Second)
+	require.False(t, ok)
+
+	m.setReplicas([]metadata.Replica{
+		{Id: "r1", Address: "host1:8081", Lag: 100, HeartbeatAt: now.UnixMilli()},
+		{Id: "r2", Address: "host2:8081", Lag: 500, HeartbeatAt: now.Add(-time.Second).UnixMilli()},
+		// unknown lag
+		{Id: "r3", Address: "host3:8081", Lag: -1, HeartbeatAt: now.UnixMilli()},
+		// stopped sending the heartbeats
+		{Id: "r4", Address: "host4:8081", Lag: 0, HeartbeatAt: now.Add(-time.Minute).UnixMilli()},
+	})
+
+	// the replicas are picked in turns
+	picked := map[string]int{}
+	for i := 0; i < 10; i++ {
+		addr, ok := m.Pick(2 * time.Second)
+		require.True(t, ok)
+		picked[addr]++
+	}
+	require.Equal(t, map[string]int{"host1:8081": 5, "host2:8081": 5}, picked)
+
+	// r2 lags more than tolerated
+	for i := 0; i < 3; i++ {
+		addr, ok := m.Pick(time.Second)
+		require.True(t, ok)
+		require.Equal(t, "host1:8081", addr)
+	}
+
+	_, ok = m.Pick(50 * time.Millisecond)
+	require.False(t, ok)
+
+	// the maximum lag applies whatever the tolerated staleness
+	m.cfg.MaxLag = 200 * time.Millisecond
+	addr, ok := m.Pick(10 * time.Second)
+	require.True(t, ok)
+	require.Equal(t, "host1:8081", addr)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package request
+
+import (
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+const (
+	Issuer            = "iss"
+	defaultEmailClaim = "email"
+)
+
+// MappedClaims is the Tigris identity derived from the token of an external OIDC provider.
+type MappedClaims struct {
+	Namespace string
+	Role      string
+	IsHuman   bool
+}
+
+// GetClaimMapping returns the claim mapping of the issuer, nil if the issuer's tokens carry the Tigris claims.
+func GetClaimMapping(issuer string) *config.ClaimMappingConfig {
+	if len(issuer) == 0 {
+		return nil
+	}
+
+	for _, v := range config.DefaultConfig.Auth.Validators {
+		if v.Issuer == issuer {
+			return v.ClaimMapping
+		}
+	}
+
+	return nil
+}
+
+// MapClaims applies the mapping rules to the claims of the token. The token is rejected if it can't be mapped to a
+// namespace and a role.
+func MapClaims(mapping *config.ClaimMappingConfig, claims map[string]any) (*MappedClaims, error) {
+	namespace := mapping.Namespace
+	if len(mapping.NamespaceClaim) > 0 {
+		if claim, ok := schema.LookupClaim(claims, mapping.NamespaceClaim); ok {
+			if code, ok := claim.(string); ok && len(code) > 0 {
+				namespace = code
+			}
+		}
+	}
+	if len(namespace) == 0 {
+		return nil, errors.PermissionDenied("empty namespace code in token")
+	}
+
+	role := mapRole(mapping, claims)
+	if len(role) == 0 {
+		return nil, errors.PermissionDenied("no role is mapped for the token")
+	}
+
+	emailClaim := mapping.EmailClaim
+	if len(emailClaim) == 0 {
+		emailClaim = defaultEmailClaim
+	}
+	email, _ := schema.LookupClaim(claims, emailClaim)
+	emailStr, _ := email.(string)
+
+	return &MappedClaims{
+		Namespace: namespace,
+		Role:      role,
+		IsHuman:   len(emailStr) > 0,
+	}, nil
+}
+
+func mapRole(mapping *config.ClaimMappingConfig, claims map[string]any) string {
+	var values []string
+	if len(mapping.RoleClaim) > 0 {
+		claim, _ := schema.LookupClaim(claims, mapping.RoleClaim)
+		switch c := claim.(type) {
+		case string:
+			values = append(values, c)
+		case []any:
+			for _, elem := range c {
+				if s, ok := elem.(string); ok {
+					values = append(values, s)
+				}
+			}
+		}
+	}
+
+	for _, rule := range mapping.Roles {
+		for _, v := range values {
+			if v == rule.Value {
+				return rule.Role
+			}
+		}
+	}
+
+	return mapping.DefaultRole
+}
@@ -0,0 +1,115 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestMapClaims(t *testing.T) {
+	keycloak := &config.ClaimMappingConfig{
+		NamespaceClaim: "tenant",
+		RoleClaim:      "realm_access.roles",
+		Roles: []config.RoleMappingRule{
+			{Value: "tigris-owner", Role: "o"},
+			{Value: "tigris-editor", Role: "e"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		mapping  *config.ClaimMappingConfig
+		claims   map[string]any
+		expected *MappedClaims
+		err      error
+	}{
+		{
+			"first matching rule wins",
+			keycloak,
+			map[string]any{
+				"tenant":       "ns1",
+				"email":        "alice@example.com",
+				"realm_access": map[string]any{"roles": []any{"tigris-editor", "tigris-owner"}},
+			},
+			&MappedClaims{Namespace: "ns1", Role: "o", IsHuman: true},
+			nil,
+		},
+		{
+			"machine token",
+			keycloak,
+			map[string]any{
+				"tenant":       "ns1",
+				"realm_access": map[string]any{"roles": []any{"tigris-editor"}},
+			},
+			&MappedClaims{Namespace: "ns1", Role: "e"},
+			nil,
+		},
+		{
+			"no namespace",
+			keycloak,
+			map[string]any{"realm_access": map[string]any{"roles": []any{"tigris-editor"}}},
+			nil,
+			errors.PermissionDenied("empty namespace code in token"),
+		},
+		{
+			"no role",
+			keycloak,
+			map[string]any{"tenant": "ns1", "realm_access": map[string]any{"roles": []any{"other"}}},
+			nil,
+			errors.PermissionDenied("no role is mapped for the token"),
+		},
+		{
+			"pinned namespace, string role claim and default role",
+			&config.ClaimMappingConfig{
+				Namespace:   "ns2",
+				RoleClaim:   "role",
+				Roles:       []config.RoleMappingRule{{Value: "admin", Role: "o"}},
+				DefaultRole: "ro",
+				EmailClaim:  "upn",
+			},
+			map[string]any{"role": "reader", "upn": "bob@example.com"},
+			&MappedClaims{Namespace: "ns2", Role: "ro", IsHuman: true},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mapped, err := MapClaims(c.mapping, c.claims)
+			require.Equal(t, c.err, err)
+			require.Equal(t, c.expected, mapped)
+		})
+	}
+}
+
+func TestGetClaimMapping(t *testing.T) {
+	saved := config.DefaultConfig.Auth.Validators
+	defer func() { config.DefaultConfig.Auth.Validators = saved }()
+
+	mapping := &config.ClaimMappingConfig{Namespace: "ns1", DefaultRole: "ro"}
+	config.DefaultConfig.Auth.Validators = []config.ValidatorConfig{
+		{Issuer: "https://tigris.example.com/"},
+		{Issuer: "https://keycloak.example.com/realms/r1", ClaimMapping: mapping},
+	}
+
+	require.Nil(t, GetClaimMapping(""))
+	require.Nil(t, GetClaimMapping("https://tigris.example.com/"))
+	require.Nil(t, GetClaimMapping("https://other.example.com/"))
+	require.Equal(t, mapping, GetClaimMapping("https://keycloak.example.com/realms/r1"))
+}
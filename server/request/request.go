@@ -18,9 +18,12 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
@@ -46,6 +49,9 @@ const (
 	AcceptTypeApplicationJSON = "application/json"
 )
 
+// SearchOnlyRole is the role of the requests authenticated with a search-only key of a project.
+const SearchOnlyRole = "s"
+
 var (
 	adminMethods = container.NewHashSet(api.CreateNamespaceMethodName, api.ListNamespacesMethodName, api.DeleteNamespaceMethodName, api.VerifyInvitationMethodName)
 	tenantGetter metadata.TenantGetter
@@ -73,6 +79,15 @@ type Metadata struct {
 	// Current user/application
 	Sub  string
 	Role string
+
+	// searchKey is set when the request is authenticated with a search-only key
+	searchKey *SearchKey
+}
+
+// SearchKey is the search-only key of a project the request is authenticated with.
+type SearchKey struct {
+	Project string
+	*metadata.SearchKey
 }
 
 func Init(tg metadata.TenantGetter) {
@@ -147,6 +162,12 @@ func (m *Metadata) GetNamespace() string {
 	return m.namespace
 }
 
+// SetSearchKey marks the request as authenticated with the search-only key of the project.
+func (m *Metadata) SetSearchKey(key *SearchKey) {
+	m.searchKey = key
+	m.Role = SearchOnlyRole
+}
+
 func (m *Metadata) GetNamespaceName() string {
 	return m.namespaceName
 }
@@ -214,6 +235,19 @@ func GetProjectAndBranchAndColl(req any) (string, string, string) {
 	return project, branch, coll
 }
 
+// GetCollections returns the collections of the request, the collections written by a multi write or the collection
+// of the request otherwise. The collection is empty if the request isn't for a collection.
+func GetCollections(req any) []string {
+	if mw, ok := req.(*api.CommitTransactionRequest); ok {
+		if collections := mw.GetWriteCollections(); len(collections) > 0 {
+			return collections
+		}
+	}
+
+	_, _, coll := GetProjectAndBranchAndColl(req)
+	return []string{coll}
+}
+
 func (m *Metadata) GetFullMethod() string {
 	return fmt.Sprintf("/%s/%s", m.serviceName, m.methodInfo.Name)
 }
@@ -286,6 +320,18 @@ func GetAccessToken(ctx context.Context) (*types.AccessToken, error) {
 	return nil, errors.NotFound("Access token not found")
 }
 
+// GetSearchKey returns the search-only key the request is authenticated with, the second return value is false if the
+// request is authenticated with an access token.
+func GetSearchKey(ctx context.Context) (*SearchKey, bool) {
+	if value := ctx.Value(MetadataCtxKey{}); value != nil {
+		if requestMetadata, ok := value.(*Metadata); ok && requestMetadata.searchKey != nil {
+			return requestMetadata.searchKey, true
+		}
+	}
+
+	return nil, false
+}
+
 func GetCurrentSub(ctx context.Context) (string, error) {
 	tkn, err := GetAccessToken(ctx)
 	if err != nil {
@@ -341,21 +387,23 @@ func getTokenFromHeader(header string) (string, error) {
 
 // extracts namespace and type of the user from the token.
 func getMetadataFromToken(token string) (string, bool, string, string) {
-	tokenParts := strings.SplitN(token, ".", 3)
-	if len(tokenParts) < 3 {
-		log.Debug().Msg("Could not split the token into its parts")
-		return defaults.UnknownValue, false, "", ""
-	}
-
-	var decodedToken []byte
-	decodedToken, err := base64.RawStdEncoding.DecodeString(tokenParts[1])
-	if err != nil {
-		stdDecoded, err := base64.StdEncoding.DecodeString(tokenParts[1])
+	if strings.HasPrefix(token, metadata.SearchKeyPrefix) {
+		// search-only key, it is verified by the auth interceptor, same as the JWT
+		namespaceCode, _, id, _, err := metadata.DecodeSearchKey(token)
 		if err != nil {
-			log.Error().Err(err).Msg("Could not base64 decode token")
 			return defaults.UnknownValue, false, "", ""
 		}
-		decodedToken = stdDecoded
+		return namespaceCode, false, metadata.SearchKeyPrefix + id, SearchOnlyRole
+	}
+
+	decodedToken, err := decodeTokenPayload(token)
+	if err != nil {
+		return defaults.UnknownValue, false, "", ""
+	}
+
+	issuer, _ := jsonparser.GetString(decodedToken, Issuer)
+	if mapping := GetClaimMapping(issuer); mapping != nil {
+		return getMetadataFromMappedToken(mapping, decodedToken)
 	}
 
 	var namespaceCode, userEmail, role string
@@ -390,6 +438,75 @@ func getMetadataFromToken(token string) (string, bool, string, string) {
 	return namespaceCode, len(userEmail) > 0, sub, role
 }
 
+// getMetadataFromMappedToken is getMetadataFromToken for the tokens of the external OIDC providers.
+func getMetadataFromMappedToken(mapping *config.ClaimMappingConfig, decodedToken []byte) (string, bool, string, string) {
+	var claims map[string]any
+	if err := jsoniter.Unmarshal(decodedToken, &claims); err != nil {
+		log.Debug().Err(err).Msg("Could not unmarshal token claims")
+		return defaults.UnknownValue, false, "", ""
+	}
+
+	mapped, err := MapClaims(mapping, claims)
+	if err != nil {
+		log.Debug().Err(err).Msg("Could not map token claims")
+		return defaults.UnknownValue, false, "", ""
+	}
+
+	sub, _ := claims[Subject].(string)
+	if len(sub) == 0 {
+		log.Error().Msg("Could not read subject")
+		return defaults.UnknownValue, false, "", ""
+	}
+
+	return mapped.Namespace, mapped.IsHuman, sub, mapped.Role
+}
+
+// decodeTokenPayload returns the claims part of the JWT, the signature is verified by the auth interceptor.
+func decodeTokenPayload(token string) ([]byte, error) {
+	tokenParts := strings.SplitN(token, ".", 3)
+	if len(tokenParts) < 3 {
+		log.Debug().Msg("Could not split the token into its parts")
+		return nil, fmt.Errorf("could not split the token into its parts")
+	}
+
+	decodedToken, err := base64.RawStdEncoding.DecodeString(tokenParts[1])
+	if err != nil {
+		stdDecoded, err := base64.StdEncoding.DecodeString(tokenParts[1])
+		if err != nil {
+			log.Error().Err(err).Msg("Could not base64 decode token")
+			return nil, err
+		}
+		decodedToken = stdDecoded
+	}
+
+	return decodedToken, nil
+}
+
+// GetTokenClaims returns the claims of the access token the request is authenticated with, empty if the request isn't
+// authenticated with a JWT.
+func GetTokenClaims(ctx context.Context) map[string]any {
+	claims := map[string]any{}
+	if _, err := GetAccessToken(ctx); err != nil {
+		return claims
+	}
+
+	token, err := getTokenFromHeader(api.GetHeader(ctx, api.HeaderAuthorization))
+	if err != nil || strings.HasPrefix(token, metadata.SearchKeyPrefix) {
+		return claims
+	}
+
+	payload, err := decodeTokenPayload(token)
+	if err != nil {
+		return claims
+	}
+
+	if err = jsoniter.Unmarshal(payload, &claims); err != nil {
+		log.Debug().Err(err).Msg("Could not unmarshal token claims")
+	}
+
+	return claims
+}
+
 // GetMetadataFromHeader returns the namespaceCode, isHuman, user sub and user role from the header token.
 func GetMetadataFromHeader(ctx context.Context) (string, bool, string, string) {
 	if !config.DefaultConfig.Auth.EnableNamespaceIsolation {
@@ -415,11 +532,12 @@ func isRead(name string) bool {
 	}
 
 	switch name {
-	case api.ReadMethodName, api.SearchMethodName:
+	case api.ReadMethodName, api.SearchMethodName, api.ExportMethodName, api.SubscribeMethodName, api.ReadStreamMethodName:
 		return true
 	case api.ListCollectionsMethodName, api.ListProjectsMethodName:
 		return true
-	case api.DescribeCollectionMethodName, api.DescribeDatabaseMethodName:
+	case api.DescribeCollectionMethodName, api.DescribeDatabaseMethodName, api.BuildIndexStatusMethodName, api.IndexUsageStatsMethodName,
+		api.IndexSuggestionsMethodName, api.SlowQueriesMethodName, api.AuditLogsMethodName, api.UsageMethodName:
 		return true
 	default:
 		return false
@@ -430,6 +548,11 @@ func isWrite(name string) bool {
 	return !isRead(name)
 }
 
+// IsReadMethod returns true if the method only reads the data.
+func IsReadMethod(fullMethod string) bool {
+	return isRead(fullMethod)
+}
+
 func IsRead(ctx context.Context) bool {
 	m, _ := grpc.Method(ctx)
 	return isRead(m)
@@ -448,6 +571,461 @@ func ReadSearchDataFromStorage(ctx context.Context) bool {
 	return api.GetHeader(ctx, api.HeaderReadSearchDataFromStorage) == "true"
 }
 
+// GetSchemaVersion returns the collection schema version requested by the caller. Zero means the caller didn't pin
+// the request to any version and the latest schema should be used.
+func GetSchemaVersion(ctx context.Context) (uint32, error) {
+	ver := api.GetHeader(ctx, api.HeaderSchemaVersion)
+	if len(ver) == 0 {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(ver, 10, 32)
+	if err != nil || v == 0 {
+		return 0, errors.InvalidArgument("invalid schema version '%s'", ver)
+	}
+
+	return uint32(v), nil
+}
+
+// GetReadStaleness returns the staleness the caller tolerates for the reads, zero if the reads should observe the
+// latest commit.
+func GetReadStaleness(ctx context.Context) (time.Duration, error) {
+	ms := api.GetHeader(ctx, api.HeaderReadStaleness)
+	if len(ms) == 0 {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(ms, 10, 32)
+	if err != nil {
+		return 0, errors.InvalidArgument("invalid read staleness '%s'", ms)
+	}
+
+	staleness := time.Duration(v) * time.Millisecond
+	if staleness > config.DefaultConfig.KV.MaxReadStaleness {
+		return 0, errors.InvalidArgument("read staleness should be at most %d ms", config.DefaultConfig.KV.MaxReadStaleness.Milliseconds())
+	}
+
+	return staleness, nil
+}
+
+// GetIndexUnusedFor returns the duration after which an index that is not read is reported as unused, zero if only
+// the indexes that were never read are unused.
+func GetIndexUnusedFor(ctx context.Context) (time.Duration, error) {
+	d := api.GetHeader(ctx, api.HeaderIndexUnusedFor)
+	if len(d) == 0 {
+		return 0, nil
+	}
+
+	unusedFor, err := time.ParseDuration(d)
+	if err != nil || unusedFor < 0 {
+		return 0, errors.InvalidArgument("invalid unused duration '%s'", d)
+	}
+
+	return unusedFor, nil
+}
+
+// GetIfRevision returns the revision the caller expects the modified documents to be at. The second return value is
+// false when the write is unconditional.
+func GetIfRevision(ctx context.Context) (int64, bool, error) {
+	rev := api.GetHeader(ctx, api.HeaderIfRevision)
+	if len(rev) == 0 {
+		return 0, false, nil
+	}
+
+	v, err := strconv.ParseInt(rev, 10, 64)
+	if err != nil || v < 0 {
+		return 0, false, errors.InvalidArgument("invalid revision '%s'", rev)
+	}
+
+	return v, true, nil
+}
+
+// GetExpectedVersion returns the version of the event stream expected by the append of the request, zero expecting
+// a stream without events.
+func GetExpectedVersion(ctx context.Context) (int64, bool, error) {
+	version := api.GetHeader(ctx, api.HeaderExpectedVersion)
+	if len(version) == 0 {
+		return 0, false, nil
+	}
+
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v < 0 {
+		return 0, false, errors.InvalidArgument("invalid expected version '%s'", version)
+	}
+
+	return v, true, nil
+}
+
+func IncludeRevision(ctx context.Context) bool {
+	return api.GetHeader(ctx, api.HeaderIncludeRevision) == "true"
+}
+
+// IncludeQueryStats returns true if the caller asked for the statistics of the execution of the read or the search.
+func IncludeQueryStats(ctx context.Context) bool {
+	return api.GetHeader(ctx, api.HeaderIncludeQueryStats) == "true"
+}
+
+// IndexRepair returns true if the inconsistent index entries found by the consistency check should be repaired.
+func IndexRepair(ctx context.Context) bool {
+	return api.GetHeader(ctx, api.HeaderIndexRepair) == "true"
+}
+
+// GetDiffBase returns the branch a branch diff compares the branch of the request with, the main branch by default.
+func GetDiffBase(ctx context.Context) string {
+	if base := api.GetHeader(ctx, api.HeaderDiffBase); base != "" {
+		return base
+	}
+
+	return metadata.MainBranch
+}
+
+// GetDiffSample returns the number of the changed documents of each collection a branch diff returns, it is capped at
+// api.MaxBranchDiffSample.
+func GetDiffSample(ctx context.Context) (int, error) {
+	value := api.GetHeader(ctx, api.HeaderDiffSample)
+	if value == "" {
+		return 0, nil
+	}
+
+	sample, err := strconv.Atoi(value)
+	if err != nil || sample < 0 {
+		return 0, errors.InvalidArgument("invalid diff sample '%s'", value)
+	}
+	if sample > api.MaxBranchDiffSample {
+		sample = api.MaxBranchDiffSample
+	}
+
+	return sample, nil
+}
+
+// GetMergePolicy returns the conflict policy of a branch merge, api.MergePolicyFail if the request doesn't set it.
+func GetMergePolicy(ctx context.Context) (string, error) {
+	switch policy := api.GetHeader(ctx, api.HeaderMergePolicy); policy {
+	case "":
+		return api.MergePolicyFail, nil
+	case api.MergePolicySourceWins, api.MergePolicyTargetWins, api.MergePolicyFail:
+		return policy, nil
+	default:
+		return "", errors.InvalidArgument("invalid merge policy '%s'", policy)
+	}
+}
+
+// GetBranchAt returns the point in time a new branch copies the documents of the main branch as of, either a time or a
+// version of the store. Both are zero if the branch is created empty.
+func GetBranchAt(ctx context.Context) (time.Time, int64, error) {
+	value := api.GetHeader(ctx, api.HeaderBranchAt)
+	if value == "" {
+		return time.Time{}, 0, nil
+	}
+
+	if version, err := strconv.ParseInt(value, 10, 64); err == nil && version > 0 {
+		return time.Time{}, version, nil
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, 0, errors.InvalidArgument("invalid branch point in time '%s', expecting a RFC3339 timestamp or a version", value)
+	}
+	if at.After(time.Now()) {
+		return time.Time{}, 0, errors.InvalidArgument("branch point in time '%s' is in the future", value)
+	}
+
+	return at, 0, nil
+}
+
+// GetBranchExpiresAfter returns the TTL of a new branch, zero if the branch doesn't expire.
+func GetBranchExpiresAfter(ctx context.Context) (time.Duration, error) {
+	value := api.GetHeader(ctx, api.HeaderBranchExpiresAfter)
+	if value == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, errors.InvalidArgument("invalid branch expiration '%s', expecting a positive duration", value)
+	}
+
+	return ttl, nil
+}
+
+// GetBackupTarget returns the target of a backup or the source of a restore, it is required.
+func GetBackupTarget(ctx context.Context, header string) (string, error) {
+	target := api.GetHeader(ctx, header)
+	if target == "" {
+		return "", errors.InvalidArgument("missing '%s' header", header)
+	}
+
+	return target, nil
+}
+
+// GetRestoreTimestamp returns the moment a point in time restore brings the collections back to, it is required.
+func GetRestoreTimestamp(ctx context.Context) (time.Time, error) {
+	value := api.GetHeader(ctx, api.HeaderRestoreTimestamp)
+	if value == "" {
+		return time.Time{}, errors.InvalidArgument("missing '%s' header", api.HeaderRestoreTimestamp)
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, errors.InvalidArgument("invalid restore point in time '%s', expecting a RFC3339 timestamp", value)
+	}
+	if at.After(time.Now()) {
+		return time.Time{}, errors.InvalidArgument("restore point in time '%s' is in the future", value)
+	}
+
+	return at, nil
+}
+
+// GetCopyIndexes returns false if a collection is copied without its secondary indexes, they are copied by default.
+func GetCopyIndexes(ctx context.Context) (bool, error) {
+	value := api.GetHeader(ctx, api.HeaderCopyIndexes)
+	if value == "" {
+		return true, nil
+	}
+
+	indexes, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.InvalidArgument("invalid '%s' header '%s', expecting a boolean", api.HeaderCopyIndexes, value)
+	}
+
+	return indexes, nil
+}
+
+// GetQueueVisibilityTimeout returns the visibility timeout of the queue created by the request, zero if it isn't set.
+func GetQueueVisibilityTimeout(ctx context.Context) (time.Duration, error) {
+	value := api.GetHeader(ctx, api.HeaderQueueVisibilityTimeout)
+	if value == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, errors.InvalidArgument("invalid '%s' header '%s', expecting a positive duration",
+			api.HeaderQueueVisibilityTimeout, value)
+	}
+
+	return timeout, nil
+}
+
+// GetQueueMaxReceives returns the max receives of the queue created by the request, zero if it isn't set.
+func GetQueueMaxReceives(ctx context.Context) (int, error) {
+	value := api.GetHeader(ctx, api.HeaderQueueMaxReceives)
+	if value == "" {
+		return 0, nil
+	}
+
+	maxReceives, err := strconv.Atoi(value)
+	if err != nil || maxReceives <= 0 {
+		return 0, errors.InvalidArgument("invalid '%s' header '%s', expecting a positive number",
+			api.HeaderQueueMaxReceives, value)
+	}
+
+	return maxReceives, nil
+}
+
+// GetCacheExpected returns the value the CompareAndSwap request expects the key to have, nil if the key is expected
+// not to exist.
+func GetCacheExpected(ctx context.Context) []byte {
+	value := api.GetHeader(ctx, api.HeaderCacheExpected)
+	if value == "" {
+		return nil
+	}
+
+	return []byte(value)
+}
+
+// GetCacheCountEstimate returns whether the CacheKeys request asks for the count estimate of the keys.
+func GetCacheCountEstimate(ctx context.Context) (bool, error) {
+	value := api.GetHeader(ctx, api.HeaderCacheCountEstimate)
+	if value == "" {
+		return false, nil
+	}
+
+	estimate, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.InvalidArgument("invalid '%s' header '%s', expecting a boolean",
+			api.HeaderCacheCountEstimate, value)
+	}
+
+	return estimate, nil
+}
+
+// GetDeleteBatchSize returns the number of the documents deleted in each transaction of a batched delete, zero if the
+// delete runs in a single transaction. It is capped at the maximum batch size of the server.
+func GetDeleteBatchSize(ctx context.Context) (int, error) {
+	return getBatchSize(ctx, api.HeaderDeleteBatchSize, "delete", config.DefaultConfig.Server.BatchDelete.MaxBatchSize)
+}
+
+// GetUpdateBatchSize returns the number of the documents updated in each transaction of a batched update, zero if the
+// update runs in a single transaction. It is capped at the maximum batch size of the server.
+func GetUpdateBatchSize(ctx context.Context) (int, error) {
+	return getBatchSize(ctx, api.HeaderUpdateBatchSize, "update", config.DefaultConfig.Server.BatchUpdate.MaxBatchSize)
+}
+
+func getBatchSize(ctx context.Context, header string, write string, maxSize int) (int, error) {
+	value := api.GetHeader(ctx, header)
+	if value == "" {
+		return 0, nil
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0, errors.InvalidArgument("invalid %s batch size '%s'", write, value)
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+
+	return size, nil
+}
+
+// GetDeleteDryRun returns true if the delete should only count the documents it would delete.
+func GetDeleteDryRun(ctx context.Context) (bool, error) {
+	value := api.GetHeader(ctx, api.HeaderDeleteDryRun)
+	if value == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.InvalidArgument("invalid '%s' header '%s', expecting a boolean", api.HeaderDeleteDryRun, value)
+	}
+
+	return dryRun, nil
+}
+
+// AllowPartialResults returns true if the read should return the results found before its deadline.
+func AllowPartialResults(ctx context.Context) (bool, error) {
+	value := api.GetHeader(ctx, api.HeaderAllowPartialResults)
+	if value == "" {
+		return false, nil
+	}
+
+	allow, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.InvalidArgument("invalid '%s' header '%s', expecting a boolean", api.HeaderAllowPartialResults, value)
+	}
+
+	return allow, nil
+}
+
+// GetReturnDocument returns the image of the modified documents a write should return, "before" or "after", or an
+// empty string if the write returns no documents.
+func GetReturnDocument(ctx context.Context) (string, error) {
+	value := strings.ToLower(api.GetHeader(ctx, api.HeaderReturnDocument))
+	switch value {
+	case "", api.ReturnDocumentBefore, api.ReturnDocumentAfter:
+		return value, nil
+	default:
+		return "", errors.InvalidArgument("invalid '%s' header '%s', expecting '%s' or '%s'", api.HeaderReturnDocument,
+			value, api.ReturnDocumentBefore, api.ReturnDocumentAfter)
+	}
+}
+
+// GetAuditQuery returns the entries of the audit trail an AuditLogs request selects.
+func GetAuditQuery(ctx context.Context) (*api.AuditQuery, error) {
+	var (
+		q   api.AuditQuery
+		err error
+	)
+	for header, at := range map[string]*time.Time{api.HeaderAuditSince: &q.Since, api.HeaderAuditUntil: &q.Until} {
+		if value := api.GetHeader(ctx, header); value != "" {
+			if *at, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				return nil, errors.InvalidArgument("invalid '%s' header '%s', expecting a RFC3339 timestamp", header, value)
+			}
+		}
+	}
+
+	if value := api.GetHeader(ctx, api.HeaderAuditLimit); value != "" {
+		if q.Limit, err = strconv.Atoi(value); err != nil || q.Limit <= 0 {
+			return nil, errors.InvalidArgument("invalid '%s' header '%s', expecting a positive number", api.HeaderAuditLimit, value)
+		}
+	}
+	q.Project = api.GetHeader(ctx, api.HeaderAuditProject)
+	q.Collection = api.GetHeader(ctx, api.HeaderAuditCollection)
+
+	return &q, nil
+}
+
+// GetUsageQuery returns the consumption a Usage request selects, over the last 24 hours by hour if the request sets
+// neither the range nor the rollup.
+func GetUsageQuery(ctx context.Context) (*api.UsageQuery, error) {
+	q := api.UsageQuery{End: time.Now().UTC(), Rollup: api.UsageRollupHour}
+	q.Start = q.End.Add(-24 * time.Hour)
+
+	var err error
+	for header, at := range map[string]*time.Time{api.HeaderUsageStart: &q.Start, api.HeaderUsageEnd: &q.End} {
+		if value := api.GetHeader(ctx, header); value != "" {
+			if *at, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				return nil, errors.InvalidArgument("invalid '%s' header '%s', expecting a RFC3339 timestamp", header, value)
+			}
+		}
+	}
+	if !q.Start.Before(q.End) {
+		return nil, errors.InvalidArgument("invalid usage range, the start '%s' is not before the end '%s'",
+			q.Start.Format(time.RFC3339), q.End.Format(time.RFC3339))
+	}
+
+	switch rollup := api.GetHeader(ctx, api.HeaderUsageRollup); rollup {
+	case "":
+	case api.UsageRollupHour, api.UsageRollupDay:
+		q.Rollup = rollup
+	default:
+		return nil, errors.InvalidArgument("invalid '%s' header '%s', expecting '%s' or '%s'", api.HeaderUsageRollup,
+			rollup, api.UsageRollupHour, api.UsageRollupDay)
+	}
+	q.Project = api.GetHeader(ctx, api.HeaderUsageProject)
+	q.Collection = api.GetHeader(ctx, api.HeaderUsageCollection)
+
+	return &q, nil
+}
+
+// GetSearchConsistency returns the search consistency requested for the writes, empty if the request doesn't set it.
+func GetSearchConsistency(ctx context.Context) (string, error) {
+	switch consistency := api.GetHeader(ctx, api.HeaderSearchConsistency); consistency {
+	case "", api.SearchConsistencyStrong, api.SearchConsistencyEventual:
+		return consistency, nil
+	default:
+		return "", errors.InvalidArgument("invalid search consistency '%s'", consistency)
+	}
+}
+
+// GetCallerRole returns the role of the caller. The second return value is false when auth is disabled, in which case
+// the caller has no role and is not subject to any role based restriction on the data.
+func GetCallerRole(ctx context.Context) (string, bool) {
+	if !config.DefaultConfig.Auth.Enabled {
+		return "", false
+	}
+
+	reqMetadata, err := GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return "", true
+	}
+
+	return reqMetadata.GetRole(), true
+}
+
+// CanDecryptFields returns true if the caller is allowed to read the plain text value of the encrypted fields. When auth
+// is disabled every caller is allowed.
+func CanDecryptFields(ctx context.Context) bool {
+	if !config.DefaultConfig.Auth.Enabled {
+		return true
+	}
+
+	reqMetadata, err := GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return false
+	}
+
+	for _, role := range config.DefaultConfig.Encryption.DecryptRoles {
+		if role == reqMetadata.GetRole() {
+			return true
+		}
+	}
+
+	return false
+}
+
 func IsAcceptApplicationJSON(ctx context.Context) bool {
 	// we need to only check non grpc gateway prefix
 	return api.GetNonGRPCGatewayHeader(ctx, api.HeaderAccept) == AcceptTypeApplicationJSON
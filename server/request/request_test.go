@@ -17,11 +17,15 @@ package request
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/bmizerany/assert"
 	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/types"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestRequestMetadata(t *testing.T) {
@@ -147,3 +151,275 @@ func TestRequestMetadata(t *testing.T) {
 		assert.Equal(t, "ro", role)
 	})
 }
+
+func TestGetIfRevision(t *testing.T) {
+	rev, ok, err := GetIfRevision(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, int64(0), rev)
+
+	for _, c := range []struct {
+		header string
+		rev    int64
+		err    bool
+	}{
+		{"0", 0, false},
+		{"12", 12, false},
+		{"-1", 0, true},
+		{"abc", 0, true},
+	} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderIfRevision, c.header))
+		rev, ok, err = GetIfRevision(ctx)
+		if c.err {
+			require.Equal(t, errors.InvalidArgument("invalid revision '%s'", c.header), err)
+			continue
+		}
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, c.rev, rev)
+	}
+}
+
+func TestGetExpectedVersion(t *testing.T) {
+	version, ok, err := GetExpectedVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, int64(0), version)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderExpectedVersion, "0"))
+	version, ok, err = GetExpectedVersion(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(0), version)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderExpectedVersion, "7"))
+	version, ok, err = GetExpectedVersion(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(7), version)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderExpectedVersion, "-1"))
+	_, _, err = GetExpectedVersion(ctx)
+	require.Equal(t, errors.InvalidArgument("invalid expected version '%s'", "-1"), err)
+}
+
+func TestGetQueueOptions(t *testing.T) {
+	timeout, err := GetQueueVisibilityTimeout(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, timeout)
+	maxReceives, err := GetQueueMaxReceives(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, maxReceives)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		api.HeaderQueueVisibilityTimeout, "45s", api.HeaderQueueMaxReceives, "5"))
+	timeout, err = GetQueueVisibilityTimeout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 45*time.Second, timeout)
+	maxReceives, err = GetQueueMaxReceives(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, maxReceives)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		api.HeaderQueueVisibilityTimeout, "-1s", api.HeaderQueueMaxReceives, "0"))
+	_, err = GetQueueVisibilityTimeout(ctx)
+	require.Equal(t, errors.InvalidArgument("invalid '%s' header '%s', expecting a positive duration",
+		api.HeaderQueueVisibilityTimeout, "-1s"), err)
+	_, err = GetQueueMaxReceives(ctx)
+	require.Equal(t, errors.InvalidArgument("invalid '%s' header '%s', expecting a positive number",
+		api.HeaderQueueMaxReceives, "0"), err)
+}
+
+func TestGetCacheCountEstimate(t *testing.T) {
+	estimate, err := GetCacheCountEstimate(context.Background())
+	require.NoError(t, err)
+	require.False(t, estimate)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderCacheCountEstimate, "true"))
+	estimate, err = GetCacheCountEstimate(ctx)
+	require.NoError(t, err)
+	require.True(t, estimate)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderCacheCountEstimate, "maybe"))
+	_, err = GetCacheCountEstimate(ctx)
+	require.Equal(t, errors.InvalidArgument("invalid '%s' header '%s', expecting a boolean",
+		api.HeaderCacheCountEstimate, "maybe"), err)
+}
+
+func TestGetCopyIndexes(t *testing.T) {
+	indexes, err := GetCopyIndexes(context.Background())
+	require.NoError(t, err)
+	require.True(t, indexes)
+
+	for _, c := range []struct {
+		header  string
+		indexes bool
+		err     bool
+	}{
+		{"true", true, false},
+		{"false", false, false},
+		{"no", false, true},
+	} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderCopyIndexes, c.header))
+		indexes, err = GetCopyIndexes(ctx)
+		if c.err {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, c.indexes, indexes)
+	}
+}
+
+func TestGetDeleteOptions(t *testing.T) {
+	size, err := GetDeleteBatchSize(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	dryRun, err := GetDeleteDryRun(context.Background())
+	require.NoError(t, err)
+	require.False(t, dryRun)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderDeleteBatchSize, "100", api.HeaderDeleteDryRun, "true"))
+	size, err = GetDeleteBatchSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 100, size)
+	dryRun, err = GetDeleteDryRun(ctx)
+	require.NoError(t, err)
+	require.True(t, dryRun)
+
+	// the batch size is capped by the server
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderDeleteBatchSize, "1000000"))
+	size, err = GetDeleteBatchSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, config.DefaultConfig.Server.BatchDelete.MaxBatchSize, size)
+
+	for _, header := range [][]string{{api.HeaderDeleteBatchSize, "0"}, {api.HeaderDeleteBatchSize, "ten"}} {
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(header...))
+		_, err = GetDeleteBatchSize(ctx)
+		require.Error(t, err)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderDeleteDryRun, "maybe"))
+	_, err = GetDeleteDryRun(ctx)
+	require.Error(t, err)
+}
+
+func TestGetUpdateBatchSize(t *testing.T) {
+	size, err := GetUpdateBatchSize(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	// the delete batch size doesn't apply to the updates
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderDeleteBatchSize, "100"))
+	size, err = GetUpdateBatchSize(ctx)
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderUpdateBatchSize, "100"))
+	size, err = GetUpdateBatchSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 100, size)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderUpdateBatchSize, "1000000"))
+	size, err = GetUpdateBatchSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, config.DefaultConfig.Server.BatchUpdate.MaxBatchSize, size)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderUpdateBatchSize, "ten"))
+	_, err = GetUpdateBatchSize(ctx)
+	require.Equal(t, errors.InvalidArgument("invalid update batch size 'ten'"), err)
+}
+
+func TestAllowPartialResults(t *testing.T) {
+	allow, err := AllowPartialResults(context.Background())
+	require.NoError(t, err)
+	require.False(t, allow)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderAllowPartialResults, "true"))
+	allow, err = AllowPartialResults(ctx)
+	require.NoError(t, err)
+	require.True(t, allow)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderAllowPartialResults, "some"))
+	_, err = AllowPartialResults(ctx)
+	require.Error(t, err)
+}
+
+func TestGetReturnDocument(t *testing.T) {
+	image, err := GetReturnDocument(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, image)
+
+	for v, expected := range map[string]string{"before": api.ReturnDocumentBefore, "After": api.ReturnDocumentAfter} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderReturnDocument, v))
+		image, err = GetReturnDocument(ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, image)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderReturnDocument, "both"))
+	_, err = GetReturnDocument(ctx)
+	require.Error(t, err)
+}
+
+func TestGetAuditQuery(t *testing.T) {
+	q, err := GetAuditQuery(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &api.AuditQuery{}, q)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		api.HeaderAuditSince, "2023-05-01T10:00:00Z",
+		api.HeaderAuditLimit, "10",
+		api.HeaderAuditProject, "p1",
+		api.HeaderAuditCollection, "users",
+	))
+	q, err = GetAuditQuery(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &api.AuditQuery{
+		Since:      time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+		Project:    "p1",
+		Collection: "users",
+		Limit:      10,
+	}, q)
+
+	for _, header := range [][]string{{api.HeaderAuditUntil, "yesterday"}, {api.HeaderAuditLimit, "0"}} {
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(header...))
+		_, err = GetAuditQuery(ctx)
+		require.Error(t, err)
+	}
+}
+
+func TestGetUsageQuery(t *testing.T) {
+	q, err := GetUsageQuery(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, api.UsageRollupHour, q.Rollup)
+	require.Equal(t, 24*time.Hour, q.End.Sub(q.Start))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		api.HeaderUsageStart, "2023-05-01T00:00:00Z",
+		api.HeaderUsageEnd, "2023-06-01T00:00:00Z",
+		api.HeaderUsageRollup, "day",
+		api.HeaderUsageProject, "p1",
+		api.HeaderUsageCollection, "users",
+	))
+	q, err = GetUsageQuery(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &api.UsageQuery{
+		Start:      time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		Rollup:     api.UsageRollupDay,
+		Project:    "p1",
+		Collection: "users",
+	}, q)
+
+	for _, headers := range [][]string{
+		{api.HeaderUsageStart, "yesterday"},
+		{api.HeaderUsageRollup, "week"},
+		{api.HeaderUsageStart, "2023-06-01T00:00:00Z", api.HeaderUsageEnd, "2023-05-01T00:00:00Z"},
+	} {
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(headers...))
+		_, err = GetUsageQuery(ctx)
+		require.Error(t, err)
+	}
+}
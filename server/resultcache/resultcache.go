@@ -0,0 +1,230 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resultcache caches the results of the small reads repeated with the same filter, projection, sort and
+// options. The results are the documents read from the storage, before they are projected, so the masking and the
+// decryption of the fields still depend on the caller.
+//
+// A cached result is valid as long as the collection isn't written, every write committed to the collection bumps
+// the generation of the collection on the node, by the write path for the writes of the node and by the change
+// streams for the writes of the other nodes. The results cached at an older generation are ignored.
+package resultcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/cdc"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+const (
+	sourceWrite = "write"
+	sourceCDC   = "cdc"
+)
+
+// Query identifies the read whose result is cached.
+type Query struct {
+	NamespaceId uint32
+	Namespace   string
+	Project     string
+	Branch      string
+	// Database is the name of the database branch, its changes are streamed to invalidate the results
+	Database   string
+	Collection string
+	// Table is the encoded name of the collection, the writes to the table invalidate the results
+	Table []byte
+	// Key identifies the read of the collection, i.e. its schema version, filter, projection, sort and options
+	Key string
+}
+
+// Row is a document of a cached result.
+type Row struct {
+	Key  []byte
+	Data *internal.TableData
+}
+
+// Token is taken by the lookup missing the cache, the result is only cached at the generation of the collection
+// before the read.
+type Token struct {
+	gen   generation
+	valid bool
+}
+
+type generation struct {
+	epoch uint64
+	table uint64
+}
+
+type entry struct {
+	gen    generation
+	rows   []Row
+	cached time.Time
+}
+
+// Cache is the cache of the results of the reads.
+type Cache struct {
+	sync.Mutex
+
+	cfg     atomic.Pointer[config.ResultCacheConfig]
+	lru     gcache.Cache
+	gens    map[string]uint64
+	epoch   uint64
+	watcher *cdc.Watcher
+}
+
+// NewCache returns the cache of the results, the results are only invalidated by the writes done on this node unless
+// Watch is called.
+func NewCache(cfg *config.ResultCacheConfig) *Cache {
+	c := &Cache{
+		lru:  gcache.New(cfg.Size).LRU().Build(),
+		gens: make(map[string]uint64),
+	}
+	c.cfg.Store(cfg)
+
+	return c
+}
+
+// Watch invalidates the results of the collections written on the other nodes by streaming the changes of the
+// databases of the cached results.
+func (c *Cache) Watch(kvStore kv.TxStore, cdcMgr *cdc.Manager) {
+	c.watcher = cdcMgr.NewWatcher(kvStore, func(ctx context.Context, tx cdc.Tx) {
+		c.invalidate(ctx, sourceCDC, tx.Ops)
+	}, c.purge)
+}
+
+var resultCache *Cache
+
+// Init enables the result cache, if it's enabled by the configuration.
+func Init(cfg *config.Config, kvStore kv.TxStore, cdcMgr *cdc.Manager) {
+	if !cfg.ResultCache.Enabled {
+		return
+	}
+
+	c := NewCache(&cfg.ResultCache)
+	if cfg.Cdc.Enabled {
+		c.Watch(kvStore, cdcMgr)
+	}
+	resultCache = c
+
+	config.OnReload("result_cache", func(c *config.Config) any {
+		return []any{c.ResultCache.Default, c.ResultCache.Collections, c.ResultCache.TTL, c.ResultCache.MaxRows}
+	}, func(next *config.Config) error {
+		// the size takes effect on restart
+		c.cfg.Store(&next.ResultCache)
+		return nil
+	})
+
+	log.Info().Msg("Initialized result cache")
+}
+
+// Get returns the cache of the results of the query, nil if the results of the collection aren't cached.
+func Get(q *Query) *Cache {
+	if resultCache == nil || !resultCache.cfg.Load().CollectionEnabled(q.Namespace, q.Project, q.Collection) {
+		return nil
+	}
+
+	return resultCache
+}
+
+// MaxRows is the maximum number of the documents of a cached result.
+func (c *Cache) MaxRows() int {
+	return c.cfg.Load().MaxRows
+}
+
+func (c *Cache) generation(table []byte) generation {
+	c.Lock()
+	defer c.Unlock()
+
+	return generation{epoch: c.epoch, table: c.gens[string(table)]}
+}
+
+// watch makes sure the changes of the database of the query are streamed, the results must not be cached otherwise.
+func (c *Cache) watch(q *Query) bool {
+	if c.watcher == nil {
+		return true
+	}
+
+	if err := c.watcher.Watch(q.NamespaceId, q.Database); err != nil {
+		log.Err(err).Str("database", q.Database).Msg("Failed to stream the changes for the result cache")
+		return false
+	}
+
+	return true
+}
+
+// Lookup returns the cached result of the query and its age, ok is false if the result isn't cached. The token of the
+// lookup is passed to Fill to cache the result read from the storage.
+func (c *Cache) Lookup(q *Query) (rows []Row, age time.Duration, token Token, ok bool) {
+	valid := c.watch(q)
+	gen := c.generation(q.Table)
+
+	if v, err := c.lru.Get(q.Key); err == nil {
+		if e := v.(*entry); e.gen == gen {
+			metrics.ResultCacheLookup(q.Namespace, q.Project, q.Branch, q.Collection, true)
+			return e.rows, time.Since(e.cached), Token{gen: gen, valid: valid}, true
+		}
+	}
+	metrics.ResultCacheLookup(q.Namespace, q.Project, q.Branch, q.Collection, false)
+
+	return nil, 0, Token{gen: gen, valid: valid}, false
+}
+
+// Fill caches the result read after the lookup of the token, unless the collection has been written since.
+func (c *Cache) Fill(q *Query, rows []Row, token Token) {
+	if !token.valid || len(rows) > c.MaxRows() || c.generation(q.Table) != token.gen {
+		return
+	}
+
+	// a write committed after the check bumps the generation, so the result is ignored by the next lookup
+	_ = c.lru.SetWithExpire(q.Key, &entry{gen: token.gen, rows: rows, cached: time.Now()}, c.cfg.Load().TTL)
+}
+
+// invalidate bumps the generation of the tables written by a committed transaction.
+func (c *Cache) invalidate(_ context.Context, source string, events []*kv.Event) {
+	tables := make(map[string]struct{})
+	for _, event := range events {
+		if event.Op == kv.OutboxEvent {
+			continue
+		}
+		tables[string(event.Table)] = struct{}{}
+	}
+	if len(tables) == 0 {
+		return
+	}
+
+	c.Lock()
+	for table := range tables {
+		c.gens[table]++
+	}
+	c.Unlock()
+
+	metrics.ResultCacheInvalidated(source, len(tables))
+}
+
+// purge drops all the results, it's done when the changes of a database may have been missed.
+func (c *Cache) purge(context.Context) {
+	c.Lock()
+	c.epoch++
+	c.Unlock()
+
+	c.lru.Purge()
+}
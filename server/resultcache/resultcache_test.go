@@ -0,0 +1,66 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resultcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(&config.ResultCacheConfig{Enabled: true, Default: true, Size: 10, MaxRows: 2, TTL: time.Minute})
+	q := &Query{Namespace: "ns1", Project: "p1", Branch: "main", Collection: "c1", Table: []byte("t1"), Key: "q1"}
+	rows := []Row{{Key: []byte("k1"), Data: internal.NewTableData([]byte(`{"a":1}`))}}
+
+	_, _, token, ok := c.Lookup(q)
+	require.False(t, ok)
+
+	c.Fill(q, rows, token)
+	cached, _, _, ok := c.Lookup(q)
+	require.True(t, ok)
+	require.Equal(t, rows, cached)
+
+	// the writes to the other tables don't invalidate the result
+	c.invalidate(ctx, sourceWrite, []*kv.Event{{Op: kv.InsertEvent, Table: []byte("t2")}})
+	_, _, _, ok = c.Lookup(q)
+	require.True(t, ok)
+
+	c.invalidate(ctx, sourceCDC, []*kv.Event{{Op: kv.DeleteEvent, Table: []byte("t1")}})
+	_, _, token, ok = c.Lookup(q)
+	require.False(t, ok)
+
+	// the result read before a write is not cached after the write
+	c.invalidate(ctx, sourceWrite, []*kv.Event{{Op: kv.UpdateEvent, Table: []byte("t1")}})
+	c.Fill(q, rows, token)
+	_, _, token, ok = c.Lookup(q)
+	require.False(t, ok)
+
+	// the results with too many rows are not cached
+	c.Fill(q, append(rows, rows[0], rows[0]), token)
+	_, _, token, ok = c.Lookup(q)
+	require.False(t, ok)
+
+	c.Fill(q, rows, token)
+	c.purge(ctx)
+	_, _, _, ok = c.Lookup(q)
+	require.False(t, ok)
+}
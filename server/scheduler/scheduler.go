@@ -0,0 +1,333 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler runs the scheduled jobs of the projects on their cron schedules. The servers elect the one running
+// the jobs with a lease in the metadata, the leader records a run before starting it, so that each scheduled time of
+// a job runs once across the cluster even if the leader changes. The runs missed while no server was the leader are
+// not replayed, only the last one is run.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/cron"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/server/types"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// maxMissedRuns bounds the walk over the scheduled times missed since the last run.
+const maxMissedRuns = 100000
+
+// TaskFunc runs a job of the project, the context carries the namespace of the project. It returns a summary of the
+// work done, recorded with the run.
+type TaskFunc func(ctx context.Context, project string, job *metadata.Job, scheduledAt time.Time) (string, error)
+
+// dueRun is a scheduled time of a job which has not run yet.
+type dueRun struct {
+	namespace   string
+	namespaceId uint32
+	project     string
+	job         metadata.Job
+	scheduledAt time.Time
+}
+
+func (d dueRun) key() string {
+	return fmt.Sprintf("%d/%s/%s", d.namespaceId, d.project, d.job.Name)
+}
+
+// Scheduler checks the jobs of all the tenants periodically and runs the ones due while it holds the lease.
+type Scheduler struct {
+	sync.Mutex
+
+	cfg     config.SchedulerConfig
+	tenants metadata.TenantGetter
+	txMgr   *transaction.Manager
+	store   *metadata.JobSubspace
+	tasks   map[string]TaskFunc
+	node    string
+	now     func() time.Time
+	// running are the jobs with a run in progress on this server, a job doesn't start again until its run finishes
+	running map[string]struct{}
+}
+
+func NewScheduler(cfg config.SchedulerConfig, tenants metadata.TenantGetter, txMgr *transaction.Manager, tasks map[string]TaskFunc) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		tenants: tenants,
+		txMgr:   txMgr,
+		store:   metadata.NewJobStore(metadata.DefaultNameRegistry),
+		tasks:   tasks,
+		node:    fmt.Sprintf("%s/%s", types.MyOrigin, uuid.New().String()),
+		now:     time.Now,
+		running: make(map[string]struct{}),
+	}
+}
+
+func (s *Scheduler) Start() {
+	if s.cfg.Enabled {
+		go s.loop()
+	}
+}
+
+func (s *Scheduler) loop() {
+	log.Info().Dur("interval", s.cfg.Interval).Str("node", s.node).Msg("Starting job scheduler")
+	t := time.NewTicker(s.cfg.Interval)
+	defer t.Stop()
+	for range t.C {
+		s.tick(context.Background())
+	}
+}
+
+// tick renews the lease and starts the due runs if this server is the leader.
+func (s *Scheduler) tick(ctx context.Context) {
+	leader, err := s.acquireLease(ctx)
+	if err != nil {
+		log.Err(err).Msg("failed to acquire the scheduler lease")
+		return
+	}
+	if !leader {
+		return
+	}
+
+	for _, tenant := range s.tenants.AllTenants(ctx) {
+		runs, err := s.dueRuns(ctx, tenant)
+		if err != nil {
+			log.Err(err).Str("ns", tenant.GetNamespace().StrId()).Msg("failed to read the scheduled jobs")
+			continue
+		}
+
+		for _, d := range runs {
+			if s.claim(ctx, d) {
+				go s.run(ctx, d)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) acquireLease(ctx context.Context) (bool, error) {
+	tx, err := s.txMgr.StartTx(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	leader, err := s.store.AcquireLease(ctx, tx, s.node, s.cfg.LeaseTTL, s.now())
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return false, err
+	}
+
+	return leader, tx.Commit(ctx)
+}
+
+// dueRuns returns the runs of the jobs of the tenant due by now, skipping the paused jobs and the jobs already running
+// on this server.
+func (s *Scheduler) dueRuns(ctx context.Context, tenant *metadata.Tenant) ([]dueRun, error) {
+	tx, err := s.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	ns := tenant.GetNamespace()
+	now := s.now()
+
+	var due []dueRun
+	for _, project := range tenant.ListProjects(ctx) {
+		jobs, err := tenant.ListJobs(ctx, tx, project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs {
+			if job.Paused {
+				continue
+			}
+
+			last, err := s.store.ListRuns(ctx, tx, ns.Id(), project, job.Name, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			scheduledAt, ok := dueTime(&job, last, now)
+			if !ok {
+				continue
+			}
+
+			d := dueRun{namespace: ns.StrId(), namespaceId: ns.Id(), project: project, job: job, scheduledAt: scheduledAt}
+			if !s.isRunning(d) {
+				due = append(due, d)
+			}
+		}
+	}
+
+	return due, nil
+}
+
+// dueTime returns the last scheduled time of the job by now which is after its last run, or after the last change of
+// the job if it never ran.
+func dueTime(job *metadata.Job, last []metadata.JobRun, now time.Time) (time.Time, bool) {
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	from := job.UpdatedAt
+	if len(last) > 0 && last[0].ScheduledAt > from {
+		from = last[0].ScheduledAt
+	}
+
+	var due time.Time
+	next := schedule.Next(time.Unix(from, 0))
+	for i := 0; i < maxMissedRuns && !next.IsZero() && !next.After(now); i++ {
+		due = next
+		next = schedule.Next(next)
+	}
+
+	return due, !due.IsZero()
+}
+
+func (s *Scheduler) isRunning(d dueRun) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, ok := s.running[d.key()]
+	return ok
+}
+
+// claim records the start of the run. It fails if another server has taken the lease or already recorded the run of
+// the same scheduled time.
+func (s *Scheduler) claim(ctx context.Context, d dueRun) bool {
+	s.Lock()
+	if _, ok := s.running[d.key()]; ok {
+		s.Unlock()
+		return false
+	}
+	s.running[d.key()] = struct{}{}
+	s.Unlock()
+
+	err := s.claimTx(ctx, d)
+	if err == nil {
+		return true
+	}
+
+	if err != kv.ErrDuplicateKey {
+		log.Err(err).Str("ns", d.namespace).Str("project", d.project).Str("job", d.job.Name).Msg("failed to record the job run")
+	}
+	s.finish(d)
+
+	return false
+}
+
+func (s *Scheduler) claimTx(ctx context.Context, d dueRun) error {
+	tx, err := s.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the lease is read in the transaction, so that the run isn't recorded if another server took the lease over
+	leader, err := s.store.AcquireLease(ctx, tx, s.node, s.cfg.LeaseTTL, s.now())
+	if err == nil && !leader {
+		err = errors.Aborted("scheduler lease is held by another server")
+	}
+	if err == nil {
+		err = s.store.InsertRun(ctx, tx, d.namespaceId, d.project, &metadata.JobRun{
+			Job:         d.job.Name,
+			Task:        d.job.Task,
+			ScheduledAt: d.scheduledAt.Unix(),
+			StartedAt:   s.now().Unix(),
+			State:       metadata.JobRunRunning,
+			Node:        s.node,
+		})
+	}
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Scheduler) finish(d dueRun) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.running, d.key())
+}
+
+// run runs the task of the job and records its outcome, the oldest runs above the history size are pruned.
+func (s *Scheduler) run(ctx context.Context, d dueRun) {
+	defer s.finish(d)
+
+	run := &metadata.JobRun{
+		Job:         d.job.Name,
+		Task:        d.job.Task,
+		ScheduledAt: d.scheduledAt.Unix(),
+		StartedAt:   s.now().Unix(),
+		State:       metadata.JobRunSucceeded,
+		Node:        s.node,
+	}
+
+	result, err := s.execute(ctx, d)
+	run.FinishedAt = s.now().Unix()
+	run.Result = result
+	if err != nil {
+		run.State = metadata.JobRunFailed
+		run.Error = err.Error()
+		log.Err(err).Str("ns", d.namespace).Str("project", d.project).Str("job", d.job.Name).Msg("scheduled job failed")
+	}
+
+	if err = s.record(ctx, d, run); err != nil {
+		log.Err(err).Str("ns", d.namespace).Str("project", d.project).Str("job", d.job.Name).Msg("failed to record the job run")
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, d dueRun) (string, error) {
+	task, ok := s.tasks[d.job.Task]
+	if !ok {
+		return "", errors.Unimplemented("job task '%s' is not supported", d.job.Task)
+	}
+
+	md := request.Metadata{}
+	md.SetNamespace(ctx, d.namespace)
+	ctx = md.SaveToContext(ctx)
+
+	return task(ctx, d.project, &d.job, d.scheduledAt)
+}
+
+func (s *Scheduler) record(ctx context.Context, d dueRun, run *metadata.JobRun) error {
+	tx, err := s.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = s.store.UpdateRun(ctx, tx, d.namespaceId, d.project, run); err == nil {
+		err = s.store.PruneRuns(ctx, tx, d.namespaceId, d.project, d.job.Name, s.cfg.HistorySize)
+	}
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,74 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/metadata"
+)
+
+func TestDueTime(t *testing.T) {
+	created := time.Date(2023, 5, 1, 12, 0, 30, 0, time.UTC)
+	job := &metadata.Job{Name: "purge", Schedule: "*/15 * * * *", UpdatedAt: created.Unix()}
+
+	t.Run("not_due", func(t *testing.T) {
+		_, ok := dueTime(job, nil, created.Add(10*time.Minute))
+		require.False(t, ok)
+	})
+
+	t.Run("first_run", func(t *testing.T) {
+		due, ok := dueTime(job, nil, created.Add(20*time.Minute))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2023, 5, 1, 12, 15, 0, 0, time.UTC), due.UTC())
+	})
+
+	t.Run("after_last_run", func(t *testing.T) {
+		last := []metadata.JobRun{{Job: "purge", ScheduledAt: time.Date(2023, 5, 1, 12, 15, 0, 0, time.UTC).Unix()}}
+
+		_, ok := dueTime(job, last, time.Date(2023, 5, 1, 12, 29, 0, 0, time.UTC))
+		require.False(t, ok)
+
+		due, ok := dueTime(job, last, time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC), due.UTC())
+	})
+
+	t.Run("missed_runs", func(t *testing.T) {
+		last := []metadata.JobRun{{Job: "purge", ScheduledAt: time.Date(2023, 5, 1, 12, 15, 0, 0, time.UTC).Unix()}}
+
+		// only the last of the runs missed is due
+		due, ok := dueTime(job, last, time.Date(2023, 5, 1, 14, 7, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2023, 5, 1, 14, 0, 0, 0, time.UTC), due.UTC())
+	})
+
+	t.Run("updated_after_last_run", func(t *testing.T) {
+		updated := *job
+		updated.UpdatedAt = time.Date(2023, 5, 1, 13, 50, 0, 0, time.UTC).Unix()
+		last := []metadata.JobRun{{Job: "purge", ScheduledAt: time.Date(2023, 5, 1, 12, 15, 0, 0, time.UTC).Unix()}}
+
+		due, ok := dueTime(&updated, last, time.Date(2023, 5, 1, 14, 7, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2023, 5, 1, 14, 0, 0, 0, time.UTC), due.UTC())
+	})
+
+	t.Run("invalid_schedule", func(t *testing.T) {
+		_, ok := dueTime(&metadata.Job{Name: "bad", Schedule: "* *"}, nil, created.Add(time.Hour))
+		require.False(t, ok)
+	})
+}
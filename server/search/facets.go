@@ -24,14 +24,29 @@ import (
 type FacetResponse struct {
 	// count of facet values requested for each field
 	facetSizes map[string]int
+	// fields with the range buckets
+	rangeFields []search.FacetField
 }
 
 func NewFacetResponse(query search.Facets) *FacetResponse {
 	facetSizeRequested := map[string]int{}
 	for _, f := range query.Fields {
-		facetSizeRequested[f.Name] = f.Size
+		if !f.IsRange() {
+			facetSizeRequested[f.Name] = f.Size
+		}
 	}
-	return &FacetResponse{facetSizes: facetSizeRequested}
+	return &FacetResponse{facetSizes: facetSizeRequested, rangeFields: query.RangeFields()}
+}
+
+// SplitFacetRangeResults splits the results of the search store into the results of the query and the results of the
+// range facet buckets of the query, see search.Store.
+func SplitFacetRangeResults(query *search.Query, results []tsApi.SearchResult) ([]tsApi.SearchResult, []tsApi.SearchResult) {
+	n := query.Facets.NumRanges()
+	if n == 0 || len(results) < n {
+		return results, nil
+	}
+
+	return results[:len(results)-n], results[len(results)-n:]
 }
 
 // Build converts search backend response to api.SearchFacet.
@@ -54,28 +69,9 @@ func (fb *FacetResponse) Build(r *[]tsApi.FacetCounts) map[string]*api.SearchFac
 			continue
 		}
 
-		stats := &api.FacetStats{}
-		if fc.Stats != nil {
-			if fc.Stats.Avg != nil {
-				stats.Avg = fc.Stats.Avg
-			}
-			if fc.Stats.Max != nil {
-				stats.Max = fc.Stats.Max
-			}
-			if fc.Stats.Min != nil {
-				stats.Min = fc.Stats.Min
-			}
-			if fc.Stats.Sum != nil {
-				stats.Sum = fc.Stats.Sum
-			}
-			if fc.Stats.TotalValues != nil {
-				stats.Count = int64(*fc.Stats.TotalValues)
-			}
-		}
-
 		facet := &api.SearchFacet{
 			Counts: []*api.FacetCount{},
-			Stats:  stats,
+			Stats:  toFacetStats(fc),
 		}
 
 		if fc.Counts != nil {
@@ -96,3 +92,67 @@ func (fb *FacetResponse) Build(r *[]tsApi.FacetCounts) map[string]*api.SearchFac
 
 	return result
 }
+
+// BuildRanges converts the results of the searches of the range facet buckets to api.SearchFacet. A count per bucket is
+// returned, with the label of the bucket as the value, and the stats of the facet count the documents in all the
+// buckets.
+func (fb *FacetResponse) BuildRanges(results []tsApi.SearchResult) map[string]*api.SearchFacet {
+	result := map[string]*api.SearchFacet{}
+
+	i := 0
+	for _, f := range fb.rangeFields {
+		facet := &api.SearchFacet{
+			Counts: []*api.FacetCount{},
+			Stats:  &api.FacetStats{},
+		}
+		for _, r := range f.Ranges {
+			if i >= len(results) {
+				break
+			}
+
+			count := &api.FacetCount{Value: r.Label}
+			if results[i].Found != nil {
+				count.Count = int64(*results[i].Found)
+			}
+			bucket := &api.FacetBucket{From: r.From, To: r.To, Stats: &api.FacetStats{}}
+			if results[i].FacetCounts != nil {
+				for _, fc := range *results[i].FacetCounts {
+					if fc.FieldName != nil && *fc.FieldName == f.Name {
+						bucket.Stats = toFacetStats(fc)
+					}
+				}
+			}
+			count.SetBucket(bucket)
+
+			facet.Counts = append(facet.Counts, count)
+			facet.Stats.Count += count.Count
+			i++
+		}
+		result[f.Name] = facet
+	}
+
+	return result
+}
+
+func toFacetStats(fc tsApi.FacetCounts) *api.FacetStats {
+	stats := &api.FacetStats{}
+	if fc.Stats != nil {
+		if fc.Stats.Avg != nil {
+			stats.Avg = fc.Stats.Avg
+		}
+		if fc.Stats.Max != nil {
+			stats.Max = fc.Stats.Max
+		}
+		if fc.Stats.Min != nil {
+			stats.Min = fc.Stats.Min
+		}
+		if fc.Stats.Sum != nil {
+			stats.Sum = fc.Stats.Sum
+		}
+		if fc.Stats.TotalValues != nil {
+			stats.Count = int64(*fc.Stats.TotalValues)
+		}
+	}
+
+	return stats
+}
@@ -206,3 +206,51 @@ func TestFacetResponse_Build(t *testing.T) {
 		})
 	})
 }
+
+func TestFacetResponse_BuildRanges(t *testing.T) {
+	facets, err := search.UnmarshalFacet([]byte(`{"brand":{"size":5},"price":[{"from":0,"to":50},{"from":50}]}`))
+	require.NoError(t, err)
+	query := &search.Query{Facets: facets}
+
+	var results []tsApi.SearchResult
+	for _, data := range []string{
+		`{"found":30,"hits":[],"facet_counts":[{"field_name":"brand","counts":[{"count":30,"value":"adidas"}]}]}`,
+		`{"found":20,"hits":[],"facet_counts":[{"field_name":"price","counts":[],"stats":{"total_values":4,"min":5,"max":45,"avg":20,"sum":400}}]}`,
+		`{"found":10,"hits":[]}`,
+	} {
+		var r tsApi.SearchResult
+		require.NoError(t, jsoniter.Unmarshal([]byte(data), &r))
+		results = append(results, r)
+	}
+
+	queryResults, ranges := SplitFacetRangeResults(query, results)
+	require.Len(t, queryResults, 1)
+	require.Len(t, ranges, 2)
+
+	fr := NewFacetResponse(facets)
+	require.Equal(t, map[string]int{"brand": 5}, fr.facetSizes)
+	result := fr.BuildRanges(ranges)
+	require.Len(t, result, 1)
+
+	price := result["price"]
+	require.Len(t, price.Counts, 2)
+	require.Equal(t, int64(30), price.Stats.Count)
+
+	minV, maxV, avg, sum := float64(5), float64(45), float64(20), float64(400)
+	require.Equal(t, int64(20), price.Counts[0].Count)
+	require.Equal(t, "0-50", price.Counts[0].Value)
+	require.Equal(t, &api.FacetBucket{
+		From:  []byte(`0`),
+		To:    []byte(`50`),
+		Stats: &api.FacetStats{Min: &minV, Max: &maxV, Avg: &avg, Sum: &sum, Count: 4},
+	}, price.Counts[0].GetBucket())
+
+	require.Equal(t, int64(10), price.Counts[1].Count)
+	require.Equal(t, "50-*", price.Counts[1].Value)
+	require.Equal(t, &api.FacetBucket{From: []byte(`50`), Stats: &api.FacetStats{}}, price.Counts[1].GetBucket())
+
+	// no range facets
+	queryResults, ranges = SplitFacetRangeResults(&search.Query{}, results)
+	require.Len(t, queryResults, 3)
+	require.Nil(t, ranges)
+}
@@ -16,6 +16,7 @@ package search
 
 import (
 	"fmt"
+	"sort"
 
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
@@ -24,6 +25,7 @@ import (
 
 const (
 	matchedTokensKey = "matched_tokens"
+	snippetKey       = "snippet"
 )
 
 type Hits struct {
@@ -64,6 +66,10 @@ func (h *Hits) HasMoreHits() bool {
 type Hit struct {
 	Document map[string]any
 	Match    *api.Match
+	// Highlights are the snippets of the matched fields, sorted by the field name.
+	Highlights []*api.HighlightSnippet
+	// GeoDistance is the distance in meters of the geopoint fields from the point of the geo distance sort.
+	GeoDistance map[string]int64
 }
 
 // True - field absent in document
@@ -88,11 +94,25 @@ func NewSearchHit(tsHit *tsApi.SearchResultHit) *Hit {
 	}
 
 	var fields []*api.MatchField
+	var snippets []*api.HighlightSnippet
 	if tsHit.Highlight != nil {
 		// check first in highlight
 		fromHighlight(*tsHit.Highlight, &fields)
+		snippetsFromHighlight("", *tsHit.Highlight, &snippets)
 	} else if tsHit.Highlights != nil {
 		fromHighlights(*tsHit.Highlights, &fields)
+		snippetsFromHighlights(*tsHit.Highlights, &snippets)
+	}
+	sort.Slice(snippets, func(i, j int) bool {
+		return snippets[i].Field < snippets[j].Field
+	})
+
+	var geoDistance map[string]int64
+	if tsHit.GeoDistanceMeters != nil {
+		geoDistance = make(map[string]int64, len(*tsHit.GeoDistanceMeters))
+		for f, d := range *tsHit.GeoDistanceMeters {
+			geoDistance[f] = int64(d)
+		}
 	}
 
 	return &Hit{
@@ -102,7 +122,98 @@ func NewSearchHit(tsHit *tsApi.SearchResultHit) *Hit {
 			Score:          score,
 			VectorDistance: tsHit.VectorDistance,
 		},
+		Highlights:  snippets,
+		GeoDistance: geoDistance,
+	}
+}
+
+func snippetsFromHighlights(highlights []tsApi.SearchHighlight, snippets *[]*api.HighlightSnippet) {
+	for _, h := range highlights {
+		if h.Field == nil {
+			continue
+		}
+
+		snippet := &api.HighlightSnippet{Field: *h.Field}
+		if h.Snippet != nil {
+			snippet.Snippet = *h.Snippet
+		}
+		if h.Snippets != nil {
+			snippet.Snippets = *h.Snippets
+		}
+		if len(snippet.Snippet) > 0 || len(snippet.Snippets) > 0 {
+			*snippets = append(*snippets, snippet)
+		}
+	}
+}
+
+// snippetsFromHighlight collects the snippets of the matched string and string array fields, the nested objects are
+// walked and their fields are returned with the dotted names.
+func snippetsFromHighlight(parent string, highlight map[string]any, snippets *[]*api.HighlightSnippet) {
+	for name, value := range highlight {
+		if len(parent) > 0 {
+			name = parent + "." + name
+		}
+
+		if mp, ok := value.(map[string]any); ok {
+			if _, isField := mp[matchedTokensKey]; !isField {
+				snippetsFromHighlight(name, mp, snippets)
+				continue
+			}
+			if snippet, ok := mp[snippetKey].(string); ok && isMatchedToken(mp) {
+				*snippets = append(*snippets, &api.HighlightSnippet{
+					Field:   name,
+					Snippet: snippet,
+				})
+			}
+			continue
+		}
+
+		if mArr, ok := value.([]any); ok {
+			var matched []string
+			var nested []*api.HighlightSnippet
+			for _, each := range mArr {
+				mp, ok := each.(map[string]any)
+				if !ok {
+					continue
+				}
+				if _, isField := mp[matchedTokensKey]; !isField {
+					// array of objects, the snippets of the same field of the objects are merged together
+					snippetsFromHighlight(name, mp, &nested)
+					continue
+				}
+				if snippet, ok := mp[snippetKey].(string); ok && isMatchedToken(mp) {
+					matched = append(matched, snippet)
+				}
+			}
+			if len(matched) > 0 {
+				*snippets = append(*snippets, &api.HighlightSnippet{
+					Field:    name,
+					Snippets: matched,
+				})
+			}
+			*snippets = append(*snippets, mergeSnippets(nested)...)
+		}
+	}
+}
+
+// mergeSnippets combines the snippets of the same field into a single snippet with the list of the snippets.
+func mergeSnippets(snippets []*api.HighlightSnippet) []*api.HighlightSnippet {
+	var merged []*api.HighlightSnippet
+	byField := make(map[string]*api.HighlightSnippet)
+	for _, s := range snippets {
+		m, ok := byField[s.Field]
+		if !ok {
+			m = &api.HighlightSnippet{Field: s.Field}
+			byField[s.Field] = m
+			merged = append(merged, m)
+		}
+		if len(s.Snippet) > 0 {
+			m.Snippets = append(m.Snippets, s.Snippet)
+		}
+		m.Snippets = append(m.Snippets, s.Snippets...)
 	}
+
+	return merged
 }
 
 func fromHighlights(highlights []tsApi.SearchHighlight, fields *[]*api.MatchField) {
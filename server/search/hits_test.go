@@ -24,6 +24,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/lib/date"
 	tsApi "github.com/tigrisdata/typesense-go/typesense/api"
 )
@@ -77,6 +78,16 @@ func TestNewSearchHit(t *testing.T) {
 			TextMatch: nil,
 		})
 		assert.Equal(t, "", hit.Match.Score)
+		assert.Nil(t, hit.GeoDistance)
+	})
+
+	t.Run("with geo distance", func(t *testing.T) {
+		d := make(map[string]any)
+		hit := NewSearchHit(&tsApi.SearchResultHit{
+			Document:          &d,
+			GeoDistanceMeters: &map[string]int{"loc": 3215},
+		})
+		assert.Equal(t, map[string]int64{"loc": 3215}, hit.GeoDistance)
 	})
 }
 
@@ -228,6 +239,51 @@ func TestMatchedFields(t *testing.T) {
 	}
 }
 
+func TestHighlightSnippets(t *testing.T) {
+	resp := []byte(`{
+	"results": [{
+		"hits": [{
+			"document": {},
+			"highlight": {
+				"title": {"matched_tokens": ["Dino"], "snippet": "the <mark>Dino</mark> park"},
+				"summary": {"matched_tokens": [], "snippet": "not matched"},
+				"tags": [{"matched_tokens": [], "snippet": "Skoda"}, {"matched_tokens": ["Dino"], "snippet": "<mark>Dino</mark>"}],
+				"nested": {"city": {"matched_tokens": ["Omaha"], "snippet": "<mark>Omaha</mark>"}},
+				"arr_obj": [{
+					"domain": {"matched_tokens": ["dino"], "snippet": "<mark>dino</mark>.com"}
+				}, {
+					"domain": {"matched_tokens": [], "snippet": "skoda.com"}
+				}, {
+					"domain": {"matched_tokens": ["dino"], "snippet": "<mark>dino</mark>.net"}
+				}]
+			}
+		}, {
+			"document": {},
+			"highlights": [
+				{"field": "title", "matched_tokens": ["Dino"], "snippet": "<mark>Dino</mark>"},
+				{"field": "tags", "matched_tokens": [["Dino"]], "snippets": ["<mark>Dino</mark>"], "indices": [1]}
+			]
+		}]
+	}]
+}`)
+
+	var dest tsApi.MultiSearchResult
+	require.NoError(t, jsoniter.Unmarshal(resp, &dest))
+	hits := *dest.Results[0].Hits
+
+	require.Equal(t, []*api.HighlightSnippet{
+		{Field: "arr_obj.domain", Snippets: []string{"<mark>dino</mark>.com", "<mark>dino</mark>.net"}},
+		{Field: "nested.city", Snippet: "<mark>Omaha</mark>"},
+		{Field: "tags", Snippets: []string{"<mark>Dino</mark>"}},
+		{Field: "title", Snippet: "the <mark>Dino</mark> park"},
+	}, NewSearchHit(&hits[0]).Highlights)
+
+	require.Equal(t, []*api.HighlightSnippet{
+		{Field: "tags", Snippets: []string{"<mark>Dino</mark>"}},
+		{Field: "title", Snippet: "<mark>Dino</mark>"},
+	}, NewSearchHit(&hits[1]).Highlights)
+}
+
 func dateFrom(dateStr string) int64 {
 	// swallowing error as the parameters are not expected to cause side effects
 	d, _ := date.ToUnixNano(time.RFC3339Nano, dateStr)
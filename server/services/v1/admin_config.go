@@ -0,0 +1,110 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/middleware"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const (
+	adminConfigReloadPath = "/admin/config/reload"
+	adminFlagsPath        = "/admin/flags"
+	adminFlagPath         = adminFlagsPath + "/{flag}"
+
+	reloadedStatus = "reloaded"
+)
+
+// registerAdminConfigHTTP registers the REST endpoints to reload the configuration and to override the runtime flags
+// of the node which handles the request, they are allowed to the cluster admins only.
+func (s *apiService) registerAdminConfigHTTP(router chi.Router) {
+	router.Post(apiPathPrefix+adminConfigReloadPath, s.reloadConfig)
+	router.Get(apiPathPrefix+adminFlagsPath, s.listFlags)
+	router.Put(apiPathPrefix+adminFlagPath, s.setFlag)
+	router.Delete(apiPathPrefix+adminFlagPath, s.clearFlag)
+}
+
+func mustBeClusterAdmin(w http.ResponseWriter, r *http.Request) bool {
+	namespace, err := request.GetNamespace(r.Context())
+	if err != nil {
+		writeHTTPError(w, err)
+		return false
+	}
+
+	if !middleware.IsAdminNamespace(namespace) {
+		writeHTTPError(w, errors.PermissionDenied("you are not allowed to perform this action"))
+		return false
+	}
+
+	return true
+}
+
+// reloadConfig reads the configuration of the node again and applies the sections which can be changed at runtime.
+func (*apiService) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	if !mustBeClusterAdmin(w, r) {
+		return
+	}
+
+	sections, err := config.Reload()
+	if err != nil {
+		writeHTTPError(w, errors.FailedPrecondition(err.Error()))
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": reloadedStatus, "sections": sections})
+}
+
+func (*apiService) listFlags(w http.ResponseWriter, r *http.Request) {
+	if !mustBeClusterAdmin(w, r) {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"flags": config.ListFlags()})
+}
+
+// setFlag overrides the flag on the node until it is cleared or the node is restarted.
+func (*apiService) setFlag(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	if !mustBeClusterAdmin(w, r) {
+		return
+	}
+
+	config.SetFlag(chi.URLParam(r, "flag"), req.Enabled)
+
+	writeHTTPResponse(w, map[string]any{"status": database.UpdatedStatus, "flags": config.ListFlags()})
+}
+
+// clearFlag drops the override of the flag, so the configured value is used again.
+func (*apiService) clearFlag(w http.ResponseWriter, r *http.Request) {
+	if !mustBeClusterAdmin(w, r) {
+		return
+	}
+
+	config.ClearFlag(chi.URLParam(r, "flag"))
+
+	writeHTTPResponse(w, map[string]any{"status": database.DeletedStatus, "flags": config.ListFlags()})
+}
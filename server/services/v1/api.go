@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/fullstorydev/grpchan/inprocgrpc"
 	"github.com/go-chi/chi/v5"
@@ -26,19 +28,45 @@ import (
 	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/kafka"
 	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/audit"
 	"github.com/tigrisdata/tigris/server/cdc"
 	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/connector"
+	"github.com/tigrisdata/tigris/server/doccache"
+	"github.com/tigrisdata/tigris/server/expiration"
+	"github.com/tigrisdata/tigris/server/indexadvisor"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
 	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/resultcache"
+	"github.com/tigrisdata/tigris/server/scheduler"
+	"github.com/tigrisdata/tigris/server/services/v1/auditlog"
 	"github.com/tigrisdata/tigris/server/services/v1/auth"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
+	"github.com/tigrisdata/tigris/server/services/v1/branch"
 	"github.com/tigrisdata/tigris/server/services/v1/database"
+	"github.com/tigrisdata/tigris/server/services/v1/eventstream"
+	"github.com/tigrisdata/tigris/server/services/v1/export"
+	"github.com/tigrisdata/tigris/server/services/v1/graphql"
+	"github.com/tigrisdata/tigris/server/services/v1/indexbuild"
+	"github.com/tigrisdata/tigris/server/services/v1/ingest"
+	"github.com/tigrisdata/tigris/server/services/v1/metering"
+	"github.com/tigrisdata/tigris/server/services/v1/multiwrite"
+	"github.com/tigrisdata/tigris/server/services/v1/outbox"
+	"github.com/tigrisdata/tigris/server/services/v1/queue"
+	"github.com/tigrisdata/tigris/server/services/v1/savepoint"
+	"github.com/tigrisdata/tigris/server/slowlog"
 	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/server/usage"
+	"github.com/tigrisdata/tigris/server/webhook"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/store/search"
 	ulog "github.com/tigrisdata/tigris/util/log"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
 )
 
 const (
@@ -46,6 +74,36 @@ const (
 	fullProjectPath        = projectsPath + "/{project}"
 	databasePathPattern    = fullProjectPath + "/database/*"
 	applicationPathPattern = fullProjectPath + "/apps/*"
+	graphqlPath            = fullProjectPath + "/graphql"
+	importDocumentsPath    = fullProjectPath + "/database/collections/{collection}/documents/import/file"
+	exportDocumentsPath    = fullProjectPath + "/database/collections/{collection}/documents/export"
+	appendEventsPath       = fullProjectPath + "/database/collections/{collection}/events/append"
+	multiWritePath         = fullProjectPath + "/database/documents/write"
+	streamAppendPath       = fullProjectPath + "/database/streams/{stream}/append"
+	streamReadPath         = fullProjectPath + "/database/streams/{stream}/read"
+	queueCreatePath        = fullProjectPath + "/database/queues/{queue}/create"
+	queueEnqueuePath       = fullProjectPath + "/database/queues/{queue}/enqueue"
+	queueReceivePath       = fullProjectPath + "/database/queues/{queue}/receive"
+	queueAckPath           = fullProjectPath + "/database/queues/{queue}/ack"
+	savepointPath          = fullProjectPath + "/database/transactions/savepoint"
+	rollbackSavepointPath  = fullProjectPath + "/database/transactions/rollback_to_savepoint"
+	indexBuildStatusPath   = fullProjectPath + "/database/collections/{collection}/indexes/status"
+	indexUsagePath         = fullProjectPath + "/database/collections/{collection}/indexes/usage"
+	indexSuggestionsPath   = fullProjectPath + "/database/indexes/suggestions"
+	slowQueriesPath        = fullProjectPath + "/database/queries/slow"
+	indexConsistencyPath   = fullProjectPath + "/database/collections/{collection}/indexes/check"
+	searchIndexStatusPath  = fullProjectPath + "/database/collections/{collection}/search/status"
+	searchIndexRebuildPath = fullProjectPath + "/database/collections/{collection}/search/rebuild"
+	branchDiffPath         = fullProjectPath + "/database/branches/{branch}/diff"
+	branchMergePath        = fullProjectPath + "/database/branches/{branch}/merge"
+	branchLifetimesPath    = fullProjectPath + "/database/branches/lifetimes"
+	backupPath             = fullProjectPath + "/database/backup"
+	restorePath            = fullProjectPath + "/database/restore"
+	backupStatusPath       = fullProjectPath + "/database/backups"
+	restoreToTimestampPath = fullProjectPath + "/database/restore_to_timestamp"
+	copyCollectionPath     = fullProjectPath + "/database/collections/{collection}/copy"
+	auditLogsPath          = "/audit/logs"
+	usagePath              = "/usage"
 
 	appsPath    = "/apps/*"
 	infoPath    = "/info"
@@ -64,6 +122,10 @@ type apiService struct {
 	versionH      *metadata.VersionHandler
 	searchStore   search.Store
 	authProvider  auth.Provider
+	coalescer     *ingest.Coalescer
+	backupJobs    *backup.Jobs
+	batchDeletes  *batchDeletes
+	batchUpdates  *batchUpdates
 }
 
 func newApiService(kv kv.TxStore, searchStore search.Store, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager, authProvider auth.Provider) *apiService {
@@ -75,6 +137,9 @@ func newApiService(kv kv.TxStore, searchStore search.Store, tenantMgr *metadata.
 		cdcMgr:       cdc.NewManager(),
 		tenantMgr:    tenantMgr,
 		authProvider: authProvider,
+		backupJobs:   backup.NewJobs(),
+		batchDeletes: newBatchDeletes(),
+		batchUpdates: newBatchUpdates(),
 	}
 
 	ctx := context.TODO()
@@ -97,6 +162,29 @@ func newApiService(kv kv.TxStore, searchStore search.Store, tenantMgr *metadata.
 		// just for testing so that we can disable it if needed
 		txListeners = append(txListeners, database.NewSearchIndexer(searchStore, tenantMgr))
 	}
+	if config.DefaultConfig.Backup.Continuous.Enabled {
+		txListeners = append(txListeners, database.NewChangelogWriter())
+	}
+	if config.DefaultConfig.Webhook.Enabled {
+		txListeners = append(txListeners, webhook.NewDispatcher(config.DefaultConfig.Webhook, tenantMgr, u.Import))
+	}
+	if cfg := config.DefaultConfig.Kafka; cfg.Enabled {
+		if config.DefaultConfig.Cdc.Enabled {
+			producer := kafka.NewProducer(kafka.Config{Brokers: cfg.Brokers, ClientID: cfg.ClientID, Timeout: cfg.Timeout})
+			checkpoints := connector.NewCollectionCheckpoints(u.Read, u.Replace, u.Import)
+			txListeners = append(txListeners, connector.NewSink(cfg, u.cdcMgr, kv, tenantMgr, checkpoints, producer))
+		} else {
+			log.Error().Msg("kafka sinks are disabled, they require cdc to be enabled")
+		}
+	}
+	if config.DefaultConfig.DocumentCache.Enabled {
+		doccache.Init(&config.DefaultConfig, kv, u.cdcMgr)
+		txListeners = append(txListeners, &doccache.Invalidator{})
+	}
+	if config.DefaultConfig.ResultCache.Enabled {
+		resultcache.Init(&config.DefaultConfig, kv, u.cdcMgr)
+		txListeners = append(txListeners, &resultcache.Invalidator{})
+	}
 
 	if config.DefaultConfig.Tracing.Enabled {
 		u.sessions = database.NewSessionManagerWithMetrics(u.txMgr, u.tenantMgr, txListeners, metadata.NewCacheTracker(tenantMgr, txMgr))
@@ -105,20 +193,60 @@ func newApiService(kv kv.TxStore, searchStore search.Store, tenantMgr *metadata.
 	}
 	u.runnerFactory = database.NewQueryRunnerFactory(u.txMgr, u.cdcMgr, u.searchStore)
 
+	// the read-only replicas leave the background writes to the other servers
+	if !config.DefaultConfig.Replica.ReadOnly {
+		// the expired documents are deleted like any other documents, so that all the indexes are updated
+		expiration.NewExpirer(config.DefaultConfig.SecondaryIndex.Expiration, tenantMgr, u.Delete).Start()
+		expiration.NewBranchReaper(config.DefaultConfig.Branch.Expiration, tenantMgr, u.DeleteBranch).Start()
+		database.NewChangelogPruner(config.DefaultConfig.Backup.Continuous, tenantMgr, txMgr).Start()
+		scheduler.NewScheduler(config.DefaultConfig.Scheduler, tenantMgr, txMgr, u.jobTasks()).Start()
+	}
+	indexadvisor.Init(config.DefaultConfig.SecondaryIndex.Advisor)
+	slowlog.Init(config.DefaultConfig.Server.SlowQueryLog)
+
+	if cfg := config.DefaultConfig.Server.InsertCoalescing; cfg.Enabled {
+		u.coalescer = ingest.NewCoalescer(u.insert, cfg)
+	}
+
 	return u
 }
 
 func (s *apiService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channel) error {
 	mux := runtime.NewServeMux(
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, &api.CustomMarshaler{JSONBuiltin: &runtime.JSONBuiltin{}}),
+		runtime.WithMarshalerOption(api.MIMEMsgpack, api.NewMsgpackMarshaler()),
+		runtime.WithMarshalerOption(api.MIMEMsgpackAlt, api.NewMsgpackMarshaler()),
+		runtime.WithMarshalerOption(api.MIMEProtobuf, api.NewProtobufMarshaler()),
 		runtime.WithIncomingHeaderMatcher(api.CustomMatcher),
 		runtime.WithOutgoingHeaderMatcher(api.CustomMatcher),
+		runtime.WithMetadata(fieldMaskMetadata),
+		runtime.WithMetadata(readStalenessMetadata),
+		runtime.WithMetadata(ifMatchMetadata),
 	)
 	if err := api.RegisterTigrisHandlerClient(context.TODO(), mux, api.NewTigrisClient(inproc)); err != nil {
 		return err
 	}
 
 	api.RegisterTigrisServer(inproc, s)
+	api.RegisterExportServer(inproc, s)
+	api.RegisterOutboxServer(inproc, s)
+	api.RegisterMultiWriteServer(inproc, s)
+	api.RegisterEventStreamServer(inproc, s)
+	api.RegisterQueuesServer(inproc, s)
+	api.RegisterSavepointsServer(inproc, s)
+	api.RegisterIndexBuildsServer(inproc, s)
+	api.RegisterIndexUsageServer(inproc, s)
+	api.RegisterIndexAdvisorServer(inproc, s)
+	api.RegisterSlowQueryLogServer(inproc, s)
+	api.RegisterIndexConsistencyServer(inproc, s)
+	api.RegisterSearchIndexHealthServer(inproc, s)
+	api.RegisterBranchDiffServer(inproc, s)
+	api.RegisterBranchMergeServer(inproc, s)
+	api.RegisterBranchLifetimeServer(inproc, s)
+	api.RegisterBackupServer(inproc, s)
+	api.RegisterCollectionCopyServer(inproc, s)
+	api.RegisterAuditLogServer(inproc, s)
+	api.RegisterUsageMeteringServer(inproc, s)
 
 	// add list projects path
 	router.HandleFunc(apiPathPrefix+projectsPath, func(w http.ResponseWriter, r *http.Request) {
@@ -145,6 +273,83 @@ func (s *apiService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channel)
 		mux.ServeHTTP(w, r)
 	})
 
+	s.registerTemplateHTTP(router)
+	s.registerSearchKeyHTTP(router)
+	s.registerJobHTTP(router)
+	router.Get(apiPathPrefix+deleteStatusPath, s.deleteStatus)
+	s.registerBatchUpdateHTTP(router)
+	s.registerRBACHTTP(router)
+	s.registerAppKeyScopeHTTP(router)
+	s.registerNetworkHTTP(router)
+	s.registerAdminConfigHTTP(router)
+
+	// GraphQL endpoint generated from the collection schemas of the project
+	gql := graphql.NewHandler(api.NewTigrisClient(inproc))
+	router.Get(apiPathPrefix+graphqlPath, gql.ServeHTTP)
+	router.Post(apiPathPrefix+graphqlPath, gql.ServeHTTP)
+	router.Get(apiPathPrefix+graphqlPath+"/schema", gql.ServeSchema)
+
+	// NDJSON and CSV file import
+	router.Post(apiPathPrefix+importDocumentsPath, ingest.NewImportHandler(api.NewTigrisClient(inproc)).ServeHTTP)
+
+	// NDJSON and Parquet export
+	exportHandler := export.NewHandler(api.NewExportClient(inproc))
+	router.Get(apiPathPrefix+exportDocumentsPath, exportHandler.ServeHTTP)
+	router.Post(apiPathPrefix+exportDocumentsPath, exportHandler.ServeHTTP)
+
+	// transactional outbox events
+	router.Post(apiPathPrefix+appendEventsPath, outbox.NewHandler(api.NewOutboxClient(inproc)).ServeHTTP)
+
+	// writes across the collections in one transaction
+	router.Post(apiPathPrefix+multiWritePath, multiwrite.NewHandler(api.NewMultiWriteClient(inproc)).ServeHTTP)
+
+	// append-only event streams
+	streams := eventstream.NewHandler(api.NewEventStreamClient(inproc))
+	router.Post(apiPathPrefix+streamAppendPath, streams.Append)
+	router.Get(apiPathPrefix+streamReadPath, streams.Read)
+
+	// at-least-once message queues
+	queues := queue.NewHandler(api.NewQueuesClient(inproc))
+	router.Post(apiPathPrefix+queueCreatePath, queues.CreateQueue)
+	router.Post(apiPathPrefix+queueEnqueuePath, queues.Enqueue)
+	router.Post(apiPathPrefix+queueReceivePath, queues.Receive)
+	router.Post(apiPathPrefix+queueAckPath, queues.Ack)
+
+	// savepoints of the explicit transactions
+	savepoints := savepoint.NewHandler(api.NewSavepointsClient(inproc))
+	router.Post(apiPathPrefix+savepointPath, savepoints.Savepoint)
+	router.Post(apiPathPrefix+rollbackSavepointPath, savepoints.RollbackToSavepoint)
+
+	// progress of the background index builds
+	router.Get(apiPathPrefix+indexBuildStatusPath, indexbuild.NewHandler(api.NewIndexBuildsClient(inproc)).ServeHTTP)
+	// usage of the secondary indexes and the unused ones
+	router.Get(apiPathPrefix+indexUsagePath, indexbuild.NewUsageHandler(api.NewIndexUsageClient(inproc)).ServeHTTP)
+	// indexes suggested by the index advisor
+	router.Get(apiPathPrefix+indexSuggestionsPath, indexbuild.NewSuggestionsHandler(api.NewIndexAdvisorClient(inproc)).ServeHTTP)
+	// reads slower than the threshold of the slow query log
+	router.Get(apiPathPrefix+slowQueriesPath, indexbuild.NewSlowQueriesHandler(api.NewSlowQueryLogClient(inproc)).ServeHTTP)
+	// consistency check and repair of the secondary indexes
+	router.Post(apiPathPrefix+indexConsistencyPath, indexbuild.NewConsistencyHandler(api.NewIndexConsistencyClient(inproc)).ServeHTTP)
+	router.Get(apiPathPrefix+searchIndexStatusPath, indexbuild.NewSearchStatusHandler(api.NewSearchIndexHealthClient(inproc)).ServeHTTP)
+	router.Post(apiPathPrefix+searchIndexRebuildPath, indexbuild.NewSearchRebuildHandler(api.NewSearchIndexHealthClient(inproc)).ServeHTTP)
+	router.Get(apiPathPrefix+branchDiffPath, branch.NewDiffHandler(api.NewBranchDiffClient(inproc)).ServeHTTP)
+	router.Post(apiPathPrefix+branchMergePath, branch.NewMergeHandler(api.NewBranchMergeClient(inproc)).ServeHTTP)
+	router.Get(apiPathPrefix+branchLifetimesPath, branch.NewLifetimesHandler(api.NewBranchLifetimeClient(inproc)).ServeHTTP)
+	router.Post(apiPathPrefix+copyCollectionPath, branch.NewCopyHandler(api.NewCollectionCopyClient(inproc)).ServeHTTP)
+
+	// logical backups and restores of the branches
+	backups := backup.NewHandler(api.NewBackupClient(inproc))
+	router.Post(apiPathPrefix+backupPath, backups.Backup)
+	router.Post(apiPathPrefix+restorePath, backups.Restore)
+	router.Get(apiPathPrefix+backupStatusPath, backups.Status)
+	router.Post(apiPathPrefix+restoreToTimestampPath, backups.RestoreToTimestamp)
+
+	// the audit trail of the namespace
+	router.Get(apiPathPrefix+auditLogsPath, auditlog.NewHandler(api.NewAuditLogClient(inproc)).ServeHTTP)
+
+	// the consumption of the namespace
+	router.Get(apiPathPrefix+usagePath, metering.NewHandler(api.NewUsageMeteringClient(inproc)).ServeHTTP)
+
 	if config.DefaultConfig.Metrics.Enabled {
 		router.Handle(metricsPath, metrics.Reporter.HTTPHandler())
 	}
@@ -152,8 +357,59 @@ func (s *apiService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channel)
 	return nil
 }
 
+// fieldMaskMetadata passes the "fields" query parameter as the field mask of the response, it is a shorthand for
+// the Tigris-Fields header.
+func fieldMaskMetadata(_ context.Context, r *http.Request) grpcMetadata.MD {
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		return grpcMetadata.Pairs(api.HeaderFields, fields)
+	}
+
+	return nil
+}
+
+// readStalenessMetadata passes the "staleness_ms" query parameter as the read staleness bound, it is a shorthand for
+// the Tigris-Read-Staleness-Ms header.
+func readStalenessMetadata(_ context.Context, r *http.Request) grpcMetadata.MD {
+	if staleness := r.URL.Query().Get("staleness_ms"); staleness != "" {
+		return grpcMetadata.Pairs(api.HeaderReadStaleness, staleness)
+	}
+
+	return nil
+}
+
+// ifMatchMetadata passes the revision of the If-Match header as the write precondition, it is a shorthand for the
+// Tigris-If-Revision header.
+func ifMatchMetadata(_ context.Context, r *http.Request) grpcMetadata.MD {
+	if rev := strings.Trim(r.Header.Get("If-Match"), `"`); rev != "" {
+		return grpcMetadata.Pairs(api.HeaderIfRevision, rev)
+	}
+
+	return nil
+}
+
 func (s *apiService) RegisterGRPC(grpc *grpc.Server) error {
 	api.RegisterTigrisServer(grpc, s)
+	api.RegisterIngestServer(grpc, s)
+	api.RegisterExportServer(grpc, s)
+	api.RegisterChangeStreamServer(grpc, s)
+	api.RegisterOutboxServer(grpc, s)
+	api.RegisterMultiWriteServer(grpc, s)
+	api.RegisterEventStreamServer(grpc, s)
+	api.RegisterQueuesServer(grpc, s)
+	api.RegisterSavepointsServer(grpc, s)
+	api.RegisterIndexBuildsServer(grpc, s)
+	api.RegisterIndexUsageServer(grpc, s)
+	api.RegisterIndexAdvisorServer(grpc, s)
+	api.RegisterSlowQueryLogServer(grpc, s)
+	api.RegisterIndexConsistencyServer(grpc, s)
+	api.RegisterSearchIndexHealthServer(grpc, s)
+	api.RegisterBranchDiffServer(grpc, s)
+	api.RegisterBranchMergeServer(grpc, s)
+	api.RegisterBranchLifetimeServer(grpc, s)
+	api.RegisterBackupServer(grpc, s)
+	api.RegisterCollectionCopyServer(grpc, s)
+	api.RegisterAuditLogServer(grpc, s)
+	api.RegisterUsageMeteringServer(grpc, s)
 	return nil
 }
 
@@ -205,9 +461,76 @@ func (s *apiService) RollbackTransaction(ctx context.Context, _ *api.RollbackTra
 	return &api.RollbackTransactionResponse{}, nil
 }
 
+// Savepoint sets a savepoint, named by the Tigris-Savepoint header, in the explicit transaction of the request.
+func (s *apiService) Savepoint(ctx context.Context, _ *api.CommitTransactionRequest) (*api.CommitTransactionResponse, error) {
+	session, name, err := s.savepointSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = session.Savepoint(name); err != nil {
+		return nil, database.CreateApiError(err)
+	}
+
+	return &api.CommitTransactionResponse{}, nil
+}
+
+// RollbackToSavepoint undoes the writes done in the explicit transaction of the request after the savepoint, the
+// transaction stays open.
+func (s *apiService) RollbackToSavepoint(ctx context.Context, _ *api.CommitTransactionRequest) (*api.CommitTransactionResponse, error) {
+	session, name, err := s.savepointSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = session.RollbackToSavepoint(name); err != nil {
+		return nil, database.CreateApiError(err)
+	}
+
+	return &api.CommitTransactionResponse{}, nil
+}
+
+func (s *apiService) savepointSession(ctx context.Context) (*database.QuerySession, string, error) {
+	if api.GetTransaction(ctx) == nil {
+		return nil, "", errors.InvalidArgument("savepoints are only supported in explicit transactions")
+	}
+
+	name := api.GetHeader(ctx, api.HeaderSavepoint)
+	if len(name) == 0 {
+		return nil, "", errors.InvalidArgument("savepoint name is required")
+	}
+
+	session, _ := s.sessions.Get(ctx)
+	if session == nil {
+		return nil, "", errors.NotFound("session not found")
+	}
+
+	return session, name, nil
+}
+
 // Insert new object returns an error if object already exists
 // Operations done individually not in actual batch.
 func (s *apiService) Insert(ctx context.Context, r *api.InsertRequest) (*api.InsertResponse, error) {
+	if s.coalescer != nil && api.GetTransaction(ctx) == nil {
+		return s.coalescer.Insert(ctx, coalescingCaller(ctx), r)
+	}
+
+	return s.insert(ctx, r)
+}
+
+// coalescingCaller identifies the tenant and the user of the request, only their own requests are coalesced.
+func coalescingCaller(ctx context.Context) string {
+	namespace, _ := request.GetNamespace(ctx)
+
+	var sub string
+	if token, err := request.GetAccessToken(ctx); err == nil && token != nil {
+		sub = token.Sub
+	}
+
+	return namespace + "/" + sub
+}
+
+func (s *apiService) insert(ctx context.Context, r *api.InsertRequest) (*api.InsertResponse, error) {
 	qm := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
 	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetInsertQueryRunner(r, &qm, accessToken), database.ReqOptions{
@@ -226,6 +549,16 @@ func (s *apiService) Insert(ctx context.Context, r *api.InsertRequest) (*api.Ins
 	}, nil
 }
 
+// InsertStream commits the documents of the client stream in batches and acknowledges every batch.
+func (s *apiService) InsertStream(stream api.Ingest_InsertStreamServer) error {
+	return ingest.InsertStream(stream, s.insert, config.DefaultConfig.Server.InsertStream)
+}
+
+// ImportDocuments imports the NDJSON or CSV file sent over the client stream in batches.
+func (s *apiService) ImportDocuments(stream api.Ingest_ImportDocumentsServer) error {
+	return ingest.ImportDocuments(stream, s.Import)
+}
+
 func (s *apiService) Import(ctx context.Context, r *api.ImportRequest) (*api.ImportResponse, error) {
 	qm := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
@@ -246,6 +579,147 @@ func (s *apiService) Import(ctx context.Context, r *api.ImportRequest) (*api.Imp
 	}, nil
 }
 
+// AppendEvents appends the events to the change stream of the collection in the transaction of the request, so that
+// they are published only if the writes of the transaction commit.
+func (s *apiService) AppendEvents(ctx context.Context, r *api.InsertRequest) (*api.InsertResponse, error) {
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetOutboxQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.InsertResponse{
+		Status: resp.Status,
+		Metadata: &api.ResponseMetadata{
+			CreatedAt: resp.CreatedAt.GetProtoTS(),
+		},
+		Keys: resp.AllKeys,
+	}, nil
+}
+
+// MultiWrite runs the writes of the request across the collections of the branch in one transaction, or in the
+// explicit transaction of the request. The writes are validated against the schemas of their collections and either
+// all of them are committed or none is.
+func (s *apiService) MultiWrite(ctx context.Context, r *api.CommitTransactionRequest) (*httpbody.HttpBody, error) {
+	// the revision and the returned documents of the headers can't apply to the writes of different collections
+	for _, header := range []string{api.HeaderIfRevision, api.HeaderReturnDocument} {
+		if len(api.GetHeader(ctx, header)) > 0 {
+			return nil, errors.InvalidArgument("multi write doesn't support the '%s' header", header)
+		}
+	}
+
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetMultiWriteQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// AppendStream appends the events of the request to the event stream of its collection field, in the transaction of
+// the request. The append fails if the stream isn't at the version of the Tigris-Expected-Version header, if any.
+func (s *apiService) AppendStream(ctx context.Context, r *api.InsertRequest) (*httpbody.HttpBody, error) {
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetAppendStreamQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// CreateQueue creates the queue of the collection field of the request in its branch, with the options of the queue
+// headers.
+func (s *apiService) CreateQueue(ctx context.Context, r *api.DescribeCollectionRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetCreateQueueQueryRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// Enqueue adds the messages of the request to the queue of its collection field, in the transaction of the request.
+func (s *apiService) Enqueue(ctx context.Context, r *api.InsertRequest) (*httpbody.HttpBody, error) {
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetEnqueueQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// Receive returns the visible messages of the queue of the collection field of the request. The receive commits on
+// its own, the messages are hidden from the other receivers once it returns.
+func (s *apiService) Receive(ctx context.Context, r *api.ReadRequest) (*httpbody.HttpBody, error) {
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetReceiveQueryRunner(r, &qm, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// Ack deletes the messages of the receipts of the request from the queue of its collection field, in the
+// transaction of the request.
+func (s *apiService) Ack(ctx context.Context, r *api.InsertRequest) (*httpbody.HttpBody, error) {
+	qm := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetAckQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// ReadStream returns a page of the events of the event stream of the collection field of the request, after the
+// position of the offset of the options.
+func (s *apiService) ReadStream(ctx context.Context, r *api.ReadRequest) (*httpbody.HttpBody, error) {
+	ctx, err := s.withReadSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qm := metrics.StreamingQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetReadStreamQueryRunner(r, &qm, accessToken), database.ReqOptions{
+		TxCtx: api.GetTransaction(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
 func (s *apiService) BuildCollectionIndex(ctx context.Context, r *api.BuildCollectionIndexRequest) (*api.BuildCollectionIndexResponse, error) {
 	qm := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
@@ -261,6 +735,354 @@ func (s *apiService) BuildCollectionIndex(ctx context.Context, r *api.BuildColle
 	return resp.Response.(*api.BuildCollectionIndexResponse), nil
 }
 
+// BuildIndexStatus returns the progress of the background builds of the secondary indexes of the collection.
+func (s *apiService) BuildIndexStatus(ctx context.Context, r *api.BuildCollectionIndexRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetIndexBuildStatusRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// IndexUsageStats returns the usage of the secondary indexes of the collection, to find the unused ones.
+func (s *apiService) IndexUsageStats(ctx context.Context, r *api.DescribeCollectionRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetIndexUsageStatsRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// IndexSuggestions returns the indexes suggested by the index advisor for the collections of the project branch.
+func (s *apiService) IndexSuggestions(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetIndexSuggestionsRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// SlowQueries returns the reads of the project branch which took longer than the threshold of the slow query log.
+func (s *apiService) SlowQueries(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetSlowQueriesRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// CheckIndexConsistency checks the secondary indexes of the collection against its documents and repairs the
+// inconsistent entries on request.
+func (s *apiService) CheckIndexConsistency(ctx context.Context, r *api.DescribeCollectionRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetIndexConsistencyRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// DiffBranches compares the branch with the base branch and returns the schema changes and the number of the
+// documents added, modified and deleted in each collection.
+func (s *apiService) DiffBranches(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetBranchDiffRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// MergeBranch merges the branch into the main branch with the conflict policy of the request and streams the progress
+// of the merge.
+func (s *apiService) MergeBranch(r *api.DescribeDatabaseRequest, stream api.BranchMerge_MergeBranchServer) error {
+	accessToken, _ := request.GetAccessToken(stream.Context())
+
+	notify := func(status *api.BranchMergeStatus) error {
+		data, err := jsoniter.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(&httpbody.HttpBody{ContentType: "application/json", Data: data})
+	}
+
+	_, err := s.sessions.ReadOnlyExecute(stream.Context(), s.runnerFactory.GetBranchMergeRunner(r, s.sessions, notify, accessToken), database.ReqOptions{})
+	return err
+}
+
+// ListBranchLifetimes lists the branches of the project with the remaining lifetime of the ephemeral ones.
+func (s *apiService) ListBranchLifetimes(ctx context.Context, r *api.ListBranchesRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetBranchLifetimesRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// CopyCollection copies the schema and the documents of the collection to the target of the request, within or across
+// the projects and the branches.
+func (s *apiService) CopyCollection(ctx context.Context, r *api.DescribeCollectionRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetCollectionCopyRunner(r, s.sessions, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// Backup starts a backup of the branch to the target of the request. The backup runs in the background, the job
+// returned tracks its progress.
+func (s *apiService) Backup(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	cfg := config.DefaultConfig.Backup
+	if !cfg.Enabled {
+		return nil, errors.Unimplemented("backups are disabled")
+	}
+
+	target, err := s.backupTarget(ctx, api.HeaderBackupTarget)
+	if err != nil {
+		return nil, err
+	}
+	// fail early if the branch doesn't exist
+	if _, err = s.DescribeDatabase(ctx, r); err != nil {
+		return nil, err
+	}
+
+	accessToken, _ := request.GetAccessToken(ctx)
+	return s.startBackupJob(ctx, api.BackupKindBackup, r, target.String(), func(ctx context.Context, job *backup.Job) error {
+		return backup.Backup(ctx, cfg.Dir, target, job, func(ctx context.Context, archive *backup.Writer) error {
+			_, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetBackupRunner(r, archive, job, accessToken), database.ReqOptions{})
+			return err
+		})
+	})
+}
+
+// Restore starts a restore of the archive of the request into the branch, the project and the branch are created if
+// they don't exist. The restore runs in the background, the job returned tracks its progress.
+func (s *apiService) Restore(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	cfg := config.DefaultConfig.Backup
+	if !cfg.Enabled {
+		return nil, errors.Unimplemented("backups are disabled")
+	}
+
+	target, err := s.backupTarget(ctx, api.HeaderBackupSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.CreateProject(ctx, &api.CreateProjectRequest{Project: r.GetProject()}); err != nil && !isAlreadyExists(err) {
+		return nil, err
+	}
+	if !metadata.NewDatabaseNameWithBranch(r.GetProject(), r.GetBranch()).IsMainBranch() {
+		_, err = s.CreateBranch(ctx, &api.CreateBranchRequest{Project: r.GetProject(), Branch: r.GetBranch()})
+		if err != nil && !isAlreadyExists(err) {
+			return nil, err
+		}
+	}
+
+	accessToken, _ := request.GetAccessToken(ctx)
+	return s.startBackupJob(ctx, api.BackupKindRestore, r, target.String(), func(ctx context.Context, job *backup.Job) error {
+		return backup.Restore(ctx, target, job, func(ctx context.Context, archive *backup.Reader) error {
+			_, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetRestoreRunner(r, archive, s.sessions, job, accessToken), database.ReqOptions{})
+			return err
+		})
+	})
+}
+
+// RestoreToTimestamp starts a restore of the collections of the branch to the moment of the request from their
+// changelogs. The restore runs in the background, the job returned tracks its progress.
+func (s *apiService) RestoreToTimestamp(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	if !config.DefaultConfig.Backup.Continuous.Enabled {
+		return nil, errors.Unimplemented("continuous backups are disabled")
+	}
+
+	at, err := request.GetRestoreTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// fail early if the branch doesn't exist
+	if _, err = s.DescribeDatabase(ctx, r); err != nil {
+		return nil, err
+	}
+
+	collection := api.GetHeader(ctx, api.HeaderRestoreCollection)
+	accessToken, _ := request.GetAccessToken(ctx)
+	return s.startBackupJob(ctx, api.BackupKindPointInTime, r, at.UTC().Format(time.RFC3339Nano), func(ctx context.Context, job *backup.Job) error {
+		runner := s.runnerFactory.GetPointInTimeRestoreRunner(r, collection, at, s.sessions, job, accessToken)
+		_, err := s.sessions.ReadOnlyExecute(ctx, runner, database.ReqOptions{})
+		return err
+	})
+}
+
+// BackupStatus returns the backup and restore jobs of the project started on this server.
+func (s *apiService) BackupStatus(ctx context.Context, r *api.DescribeDatabaseRequest) (*httpbody.HttpBody, error) {
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := s.backupJobs.List(namespace, r.GetProject(), api.GetHeader(ctx, api.HeaderBackupId))
+	data, err := jsoniter.Marshal(&api.BackupJobsResponse{Jobs: jobs})
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: data}, nil
+}
+
+// AuditLogs returns the audit trail of the namespace of the caller, the entries selected by the Tigris-Audit headers.
+func (*apiService) AuditLogs(ctx context.Context, _ *api.ListProjectsRequest) (*httpbody.HttpBody, error) {
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := request.GetAuditQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := audit.Query(ctx, namespace, q)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: data}, nil
+}
+
+// Usage returns the consumption of the namespace of the caller, selected by the Tigris-Usage headers.
+func (*apiService) Usage(ctx context.Context, _ *api.ListProjectsRequest) (*httpbody.HttpBody, error) {
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := request.GetUsageQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := usage.Query(ctx, namespace, q)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: data}, nil
+}
+
+func (s *apiService) backupTarget(ctx context.Context, header string) (backup.Target, error) {
+	value, err := request.GetBackupTarget(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.ParseTarget(config.DefaultConfig.Backup, value)
+}
+
+// startBackupJob registers the job and runs it in the background with a context detached from the request.
+func (s *apiService) startBackupJob(ctx context.Context, kind string, r *api.DescribeDatabaseRequest, target string,
+	run func(context.Context, *backup.Job) error,
+) (*httpbody.HttpBody, error) {
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx, cancel, err := backup.Detach(ctx, config.DefaultConfig.Backup.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	branchName := metadata.NewDatabaseNameWithBranch(r.GetProject(), r.GetBranch()).Branch()
+	job, err := s.backupJobs.Start(namespace, kind, r.GetProject(), branchName, target)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		defer cancel()
+
+		err := run(jobCtx, job)
+		if err != nil {
+			log.Err(err).Str("project", r.GetProject()).Str("branch", branchName).Str("job", job.Id()).Msgf("%s failed", kind)
+		}
+		job.Finish(err)
+	}()
+
+	data, err := jsoniter.Marshal(job.Status())
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: data}, nil
+}
+
+func isAlreadyExists(err error) bool {
+	return api.FromStatusError(err).Code == api.Code_ALREADY_EXISTS
+}
+
+// SearchIndexStatus compares the collection with its search index and returns the writes not applied to the index yet.
+func (s *apiService) SearchIndexStatus(ctx context.Context, r *api.DescribeCollectionRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	resp, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetSearchIndexStatusRunner(r, accessToken), database.ReqOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*httpbody.HttpBody), nil
+}
+
+// RebuildSearchIndex recreates the search index of the collection from its documents and streams the progress of the
+// rebuild.
+func (s *apiService) RebuildSearchIndex(r *api.BuildCollectionSearchIndexRequest, stream api.SearchIndexHealth_RebuildSearchIndexServer) error {
+	accessToken, _ := request.GetAccessToken(stream.Context())
+
+	notify := func(status *api.SearchIndexRebuildStatus) error {
+		data, err := jsoniter.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(&httpbody.HttpBody{ContentType: "application/json", Data: data})
+	}
+
+	_, err := s.sessions.ReadOnlyExecute(stream.Context(), s.runnerFactory.GetSearchIndexRebuildRunner(r, notify, accessToken), database.ReqOptions{})
+	return err
+}
+
 func (s *apiService) BuildSearchIndex(ctx context.Context, r *api.BuildCollectionSearchIndexRequest) (*api.BuildCollectionSearchIndexResponse, error) {
 	qm := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
@@ -286,6 +1108,10 @@ func (s *apiService) Replace(ctx context.Context, r *api.ReplaceRequest) (*api.R
 		return nil, err
 	}
 
+	if err = setDocumentsHeader(ctx, resp.Documents); err != nil {
+		return nil, err
+	}
+
 	return &api.ReplaceResponse{
 		Status: resp.Status,
 		Metadata: &api.ResponseMetadata{
@@ -296,6 +1122,21 @@ func (s *apiService) Replace(ctx context.Context, r *api.ReplaceRequest) (*api.R
 }
 
 func (s *apiService) Update(ctx context.Context, r *api.UpdateRequest) (*api.UpdateResponse, error) {
+	batchSize, err := request.GetUpdateBatchSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if batchSize > 0 {
+		image, err := request.GetReturnDocument(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if image != "" {
+			return nil, errors.InvalidArgument("batched update can't return the updated documents")
+		}
+		return s.batchUpdate(ctx, r, batchSize)
+	}
+
 	queryMetrics := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
 	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetUpdateQueryRunner(r, &queryMetrics, accessToken), database.ReqOptions{
@@ -305,6 +1146,10 @@ func (s *apiService) Update(ctx context.Context, r *api.UpdateRequest) (*api.Upd
 		return nil, err
 	}
 
+	if err = setDocumentsHeader(ctx, resp.Documents); err != nil {
+		return nil, err
+	}
+
 	return &api.UpdateResponse{
 		Status:        resp.Status,
 		ModifiedCount: resp.ModifiedCount,
@@ -315,15 +1160,50 @@ func (s *apiService) Update(ctx context.Context, r *api.UpdateRequest) (*api.Upd
 }
 
 func (s *apiService) Delete(ctx context.Context, r *api.DeleteRequest) (*api.DeleteResponse, error) {
+	dryRun, err := request.GetDeleteDryRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := request.GetReturnDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if image == api.ReturnDocumentAfter {
+		return nil, errors.InvalidArgument("delete can only return the documents as they were before the delete")
+	}
+
+	batchSize, err := request.GetDeleteBatchSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if batchSize > 0 && !dryRun {
+		if image != "" {
+			return nil, errors.InvalidArgument("batched delete can't return the deleted documents")
+		}
+		return s.batchDelete(ctx, r, batchSize)
+	}
+
+	return s.delete(ctx, r, dryRun)
+}
+
+func (s *apiService) delete(ctx context.Context, r *api.DeleteRequest, dryRun bool) (*api.DeleteResponse, error) {
 	queryMetrics := metrics.WriteQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
-	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetDeleteQueryRunner(r, &queryMetrics, accessToken), database.ReqOptions{
+	runner := s.runnerFactory.GetDeleteQueryRunner(r, &queryMetrics, accessToken)
+	runner.SetDryRun(dryRun)
+
+	resp, err := s.sessions.Execute(ctx, runner, database.ReqOptions{
 		TxCtx: api.GetTransaction(ctx),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err = setDocumentsHeader(ctx, resp.Documents); err != nil {
+		return nil, err
+	}
+
 	return &api.DeleteResponse{
 		DeletedCount: resp.ModifiedCount,
 		Status:       resp.Status,
@@ -333,6 +1213,23 @@ func (s *apiService) Delete(ctx context.Context, r *api.DeleteRequest) (*api.Del
 	}, nil
 }
 
+// setDocumentsHeader returns the documents modified by a write to the caller which asked for them with the
+// Tigris-Return-Document header.
+func setDocumentsHeader(ctx context.Context, docs []jsoniter.RawMessage) error {
+	if docs == nil {
+		return nil
+	}
+
+	encoded, err := jsoniter.Marshal(docs)
+	if err != nil {
+		return err
+	}
+
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderDocuments, string(encoded)))
+
+	return nil
+}
+
 func (s *apiService) Read(r *api.ReadRequest, stream api.Tigris_ReadServer) error {
 	var err error
 	queryMetrics := metrics.StreamingQueryMetrics{}
@@ -344,12 +1241,105 @@ func (s *apiService) Read(r *api.ReadRequest, stream api.Tigris_ReadServer) erro
 			InstantVerTracking: true,
 		})
 	} else {
-		_, err = s.sessions.ReadOnlyExecute(stream.Context(), s.runnerFactory.GetStreamingQueryRunner(r, stream, &queryMetrics, accessToken), database.ReqOptions{})
+		// reads outside an explicit transaction may be served from a cached read version, up to the
+		// staleness requested by the caller, unless they are pinned to the snapshot of a read session
+		staleness, err := request.GetReadStaleness(stream.Context())
+		if err != nil {
+			return err
+		}
+		ctx, err := s.withReadSession(stream.Context())
+		if err != nil {
+			return err
+		}
+		readCtx, cancel, err := withPartialResults(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		_, err = s.sessions.ReadOnlyExecute(kv.WithMaxStaleness(readCtx, staleness), s.runnerFactory.GetStreamingQueryRunner(r, stream, &queryMetrics, accessToken), database.ReqOptions{})
+		return partialResults(ctx, readCtx, err)
 	}
 	return err
 }
 
+// Export streams the documents matching the read request as a NDJSON or Parquet file, read from a snapshot.
+func (s *apiService) Export(r *api.ReadRequest, stream api.Export_ExportServer) error {
+	return export.Export(r, stream, s.Read, s.DescribeCollection)
+}
+
+// Subscribe streams the changes of a collection, or of all the collections of the branch if the collection isn't set,
+// starting after the resume token passed in the offset of the options.
+func (s *apiService) Subscribe(r *api.ReadRequest, stream api.ChangeStream_SubscribeServer) error {
+	if !config.DefaultConfig.Cdc.Enabled {
+		return errors.Unimplemented("change streams are disabled")
+	}
+	if len(r.GetFilter()) > 0 || len(r.GetFields()) > 0 || len(r.GetSort()) > 0 {
+		return errors.InvalidArgument("filter, fields and sort are not supported by subscriptions")
+	}
+	if r.GetOptions().GetLimit() != 0 || r.GetOptions().GetSkip() != 0 {
+		return errors.InvalidArgument("limit and skip are not supported by subscriptions")
+	}
+
+	var from *cdc.ResumeToken
+	if token := r.GetOptions().GetOffset(); len(token) > 0 {
+		var err error
+		if from, err = cdc.DecodeResumeToken(token); err != nil {
+			return err
+		}
+	}
+
+	ctx := stream.Context()
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	tenant, err := s.tenantMgr.GetTenant(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	project, err := tenant.GetProject(r.GetProject())
+	if err != nil {
+		return database.CreateApiError(err)
+	}
+	db, err := project.GetDatabase(metadata.NewDatabaseNameWithBranch(r.GetProject(), r.GetBranch()))
+	if err != nil {
+		return database.CreateApiError(err)
+	}
+	if len(r.GetCollection()) > 0 && db.GetCollection(r.GetCollection()) == nil {
+		return errors.NotFound("collection doesn't exist '%s'", r.GetCollection())
+	}
+
+	role, mask := request.GetCallerRole(ctx)
+	sub := &cdc.Subscription{
+		NamespaceId: tenant.GetNamespace().Id(),
+		Database:    db.Name(),
+		Collection:  r.GetCollection(),
+		From:        from,
+		Decrypt:     request.CanDecryptFields(ctx),
+		Mask:        mask,
+		Role:        role,
+	}
+
+	return s.cdcMgr.Subscribe(ctx, s.kvStore, s.tenantMgr, sub, func(_ *schema.DefaultCollection, change *cdc.Change, token *cdc.ResumeToken) error {
+		data, err := jsoniter.Marshal(change)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(&api.ReadResponse{
+			Data:        data,
+			ResumeToken: token.Encode(),
+		})
+	})
+}
+
 func (s *apiService) Count(ctx context.Context, r *api.CountRequest) (*api.CountResponse, error) {
+	ctx, err := s.withReadSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	queryMetrics := metrics.StreamingQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(ctx)
 	resp, err := s.sessions.Execute(ctx, s.runnerFactory.GetCountQueryRunner(r, &queryMetrics, accessToken), database.ReqOptions{
@@ -378,14 +1368,17 @@ func (s *apiService) Explain(ctx context.Context, r *api.ReadRequest) (*api.Expl
 func (s *apiService) Search(r *api.SearchRequest, stream api.Tigris_SearchServer) error {
 	queryMetrics := metrics.SearchQueryMetrics{}
 	accessToken, _ := request.GetAccessToken(stream.Context())
-	_, err := s.sessions.ReadOnlyExecute(stream.Context(), s.runnerFactory.GetSearchQueryRunner(r, stream, &queryMetrics, accessToken), database.ReqOptions{
-		InstantVerTracking: true,
-	})
+	readCtx, cancel, err := withPartialResults(stream.Context())
 	if err != nil {
 		return err
 	}
+	defer cancel()
 
-	return nil
+	_, err = s.sessions.ReadOnlyExecute(readCtx, s.runnerFactory.GetSearchQueryRunner(r, stream, &queryMetrics, accessToken), database.ReqOptions{
+		InstantVerTracking: true,
+	})
+
+	return partialResults(stream.Context(), readCtx, err)
 }
 
 func (s *apiService) CreateOrUpdateCollection(ctx context.Context, r *api.CreateOrUpdateCollectionRequest) (*api.CreateOrUpdateCollectionResponse, error) {
@@ -573,6 +1566,11 @@ func (s *apiService) DescribeDatabase(ctx context.Context, r *api.DescribeDataba
 }
 
 func (s *apiService) CreateBranch(ctx context.Context, r *api.CreateBranchRequest) (*api.CreateBranchResponse, error) {
+	at, version, err := request.GetBranchAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	accessToken, _ := request.GetAccessToken(ctx)
 	runner := s.runnerFactory.GetBranchQueryRunner(accessToken)
 	runner.SetCreateBranchReq(r)
@@ -585,6 +1583,18 @@ func (s *apiService) CreateBranch(ctx context.Context, r *api.CreateBranchReques
 		return nil, err
 	}
 
+	if !at.IsZero() || version > 0 {
+		// the branch is created empty, fill it with the documents of the main branch as of the point in time
+		snapshot := s.runnerFactory.GetBranchSnapshotRunner(r, s.sessions, at, version, accessToken)
+		if _, err = s.sessions.ReadOnlyExecute(ctx, snapshot, database.ReqOptions{}); err != nil {
+			log.Err(err).Str("branch", r.GetBranch()).Msg("failed to copy the snapshot of the main branch, deleting the branch")
+			if _, dropErr := s.DeleteBranch(ctx, &api.DeleteBranchRequest{Project: r.GetProject(), Branch: r.GetBranch()}); dropErr != nil {
+				log.Err(dropErr).Str("branch", r.GetBranch()).Msg("failed to delete the branch")
+			}
+			return nil, err
+		}
+	}
+
 	return resp.Response.(*api.CreateBranchResponse), nil
 }
 
@@ -0,0 +1,230 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/middleware"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const (
+	appKeyScopePath    = fullProjectPath + "/apps/keys/{key}/scope"
+	appKeyRolloverPath = fullProjectPath + "/apps/keys/{key}/rollover"
+)
+
+type appKeyScopeInfo struct {
+	Id           string      `json:"id"`
+	Grants       []grantInfo `json:"grants"`
+	ExpiresAt    int64       `json:"expires_at,omitempty"`
+	AllowedCIDRs []string    `json:"allowed_cidrs,omitempty"`
+	UpdatedAt    int64       `json:"updated_at,omitempty"`
+}
+
+func toAppKeyScopeInfo(scope *metadata.AppKeyScope) appKeyScopeInfo {
+	grants := make([]grantInfo, len(scope.Grants))
+	for i, g := range scope.Grants {
+		grants[i] = grantInfo{Permission: g.Permission, Branch: g.Branch, Collection: g.Collection}
+	}
+
+	return appKeyScopeInfo{
+		Id:           scope.Id,
+		Grants:       grants,
+		ExpiresAt:    scope.ExpiresAt,
+		AllowedCIDRs: scope.AllowedCIDRs,
+		UpdatedAt:    scope.UpdatedAt,
+	}
+}
+
+// registerAppKeyScopeHTTP registers the REST endpoints to limit the app keys of a project to the read, write or admin
+// permissions on the databases and the collections of the project, to set their expiry and network allowlist, and to
+// roll them over to a new key while the old key keeps working for an overlap period.
+func (s *apiService) registerAppKeyScopeHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+appKeyScopePath, s.getAppKeyScope)
+	router.Put(apiPathPrefix+appKeyScopePath, s.setAppKeyScope)
+	router.Delete(apiPathPrefix+appKeyScopePath, s.deleteAppKeyScope)
+	router.Post(apiPathPrefix+appKeyRolloverPath, s.rolloverAppKey)
+}
+
+func (s *apiService) runAppKeyScope(w http.ResponseWriter, r *http.Request, set func(*database.AppKeyScopeQueryRunner), change bool) (*database.AppKeyScopeQueryRunner, *database.Response) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return nil, nil
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetAppKeyScopeQueryRunner(accessToken)
+	set(runner)
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: change})
+	if err != nil {
+		writeHTTPError(w, err)
+		return nil, nil
+	}
+
+	if change {
+		middleware.PurgeAppKeyScopes()
+	}
+
+	return runner, &resp
+}
+
+func (s *apiService) getAppKeyScope(w http.ResponseWriter, r *http.Request) {
+	runner, _ := s.runAppKeyScope(w, r, func(runner *database.AppKeyScopeQueryRunner) {
+		runner.SetGetScopeReq(&database.AppKeyScopeRequest{
+			Project: chi.URLParam(r, "project"),
+			Id:      chi.URLParam(r, "key"),
+		})
+	}, false)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"scope": toAppKeyScopeInfo(runner.Scope())})
+}
+
+func (s *apiService) setAppKeyScope(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Grants       []grantInfo `json:"grants"`
+		ExpiresAt    int64       `json:"expires_at"`
+		AllowedCIDRs []string    `json:"allowed_cidrs"`
+	}
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	grants := make([]metadata.Grant, len(req.Grants))
+	for i, g := range req.Grants {
+		grants[i] = metadata.Grant{Permission: g.Permission, Branch: g.Branch, Collection: g.Collection}
+	}
+
+	runner, resp := s.runAppKeyScope(w, r, func(runner *database.AppKeyScopeQueryRunner) {
+		runner.SetSetScopeReq(&database.AppKeyScopeRequest{
+			Project:      chi.URLParam(r, "project"),
+			Id:           chi.URLParam(r, "key"),
+			Grants:       grants,
+			ExpiresAt:    req.ExpiresAt,
+			AllowedCIDRs: req.AllowedCIDRs,
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status, "scope": toAppKeyScopeInfo(runner.Scope())})
+}
+
+func (s *apiService) deleteAppKeyScope(w http.ResponseWriter, r *http.Request) {
+	runner, resp := s.runAppKeyScope(w, r, func(runner *database.AppKeyScopeQueryRunner) {
+		runner.SetDeleteScopeReq(&database.AppKeyScopeRequest{
+			Project: chi.URLParam(r, "project"),
+			Id:      chi.URLParam(r, "key"),
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
+
+// rolloverAppKey creates a new key with the name, the description and the scope of the old key, and makes the old key
+// expire after the overlap. Unlike the secret rotation, the old key keeps working until the clients switch over.
+func (s *apiService) rolloverAppKey(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	var req struct {
+		OverlapSec int64 `json:"overlap_sec"`
+	}
+	if body, err := io.ReadAll(r.Body); err != nil || (len(body) > 0 && jsoniter.Unmarshal(body, &req) != nil) {
+		writeHTTPError(w, errors.InvalidArgument("invalid rollover request"))
+		return
+	}
+	if req.OverlapSec < 0 {
+		writeHTTPError(w, errors.InvalidArgument("overlap can't be negative"))
+		return
+	}
+
+	overlap := config.DefaultConfig.Auth.AppKeys.RolloverOverlap
+	if req.OverlapSec > 0 {
+		overlap = time.Duration(req.OverlapSec) * time.Second
+	}
+
+	project, id := chi.URLParam(r, "project"), chi.URLParam(r, "key")
+	keys, err := s.authProvider.ListAppKeys(r.Context(), &api.ListAppKeysRequest{Project: project})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	var old *api.AppKey
+	for _, k := range keys.GetAppKeys() {
+		if k.GetId() == id {
+			old = k
+			break
+		}
+	}
+	if old == nil {
+		writeHTTPError(w, errors.NotFound("app key not found '%s'", id))
+		return
+	}
+
+	created, err := s.authProvider.CreateAppKey(r.Context(), &api.CreateAppKeyRequest{
+		Project:     project,
+		Name:        old.GetName(),
+		Description: old.GetDescription(),
+	})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	runner, _ := s.runAppKeyScope(w, r, func(runner *database.AppKeyScopeQueryRunner) {
+		runner.SetRolloverReq(&database.AppKeyScopeRequest{
+			Project: project,
+			Id:      id,
+			NewId:   created.GetCreatedAppKey().GetId(),
+			Overlap: overlap,
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	newKey := created.GetCreatedAppKey()
+	writeHTTPResponse(w, map[string]any{
+		"app_key": map[string]any{
+			"id":          newKey.GetId(),
+			"name":        newKey.GetName(),
+			"description": newKey.GetDescription(),
+			"secret":      newKey.GetSecret(),
+			"created_at":  newKey.GetCreatedAt(),
+		},
+		"old_key_expires_at": runner.Scope().ExpiresAt,
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auditlog serves the HTTP variant of the AuditLogs API, the audit trail of the namespace of the caller.
+package auditlog
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// queryHeaders are the headers of the AuditLogs requests which can also be passed as query parameters.
+var queryHeaders = map[string]string{
+	"since":      api.HeaderAuditSince,
+	"until":      api.HeaderAuditUntil,
+	"project":    api.HeaderAuditProject,
+	"collection": api.HeaderAuditCollection,
+	"limit":      api.HeaderAuditLimit,
+}
+
+// Handler returns the audit trail of the namespace, the entries are selected by the "since", "until", "project",
+// "collection" and "limit" query parameters or by the matching Tigris-Audit headers.
+type Handler struct {
+	client api.AuditLogClient
+}
+
+func NewHandler(client api.AuditLogClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.AuditLogs(common.OutgoingContextWithQuery(r, queryHeaders), &api.ListProjectsRequest{})
+	writeResponse(w, resp, err)
+}
+
+func writeResponse(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
@@ -0,0 +1,192 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+const (
+	// ArchiveVersion is the version of the format of the archives written, the restore rejects the newer ones.
+	ArchiveVersion = 1
+	// ArchiveContentType is the content type of the archives, gzip compressed NDJSON.
+	ArchiveContentType = "application/x-ndjson+gzip"
+)
+
+// Manifest is the first record of an archive, it describes the branch the archive was taken from.
+type Manifest struct {
+	Version     int                   `json:"version"`
+	Project     string                `json:"project"`
+	Branch      string                `json:"branch"`
+	CreatedAt   string                `json:"created_at"`
+	Collections []*CollectionManifest `json:"collections"`
+}
+
+// CollectionManifest is the definition of a collection, its documents follow the manifest in the archive.
+type CollectionManifest struct {
+	Name   string              `json:"name"`
+	Schema jsoniter.RawMessage `json:"schema"`
+	// Indexes are the secondary indexes of the collection. They are defined by the schema and rebuilt by the restore,
+	// they are recorded to describe the archive.
+	Indexes []*IndexManifest `json:"indexes,omitempty"`
+}
+
+type IndexManifest struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// record is a line of an archive, the manifest, a document or the trailer closing the archive.
+type record struct {
+	Manifest   *Manifest           `json:"manifest,omitempty"`
+	Collection string              `json:"collection,omitempty"`
+	Doc        jsoniter.RawMessage `json:"doc,omitempty"`
+	End        *trailer            `json:"end,omitempty"`
+}
+
+// trailer is the last record of an archive, an archive without it is truncated.
+type trailer struct {
+	Documents int64 `json:"documents"`
+}
+
+// Writer writes an archive, the manifest first and then the documents collection by collection.
+type Writer struct {
+	gz        *gzip.Writer
+	enc       *jsoniter.Encoder
+	compact   bytes.Buffer
+	documents int64
+}
+
+func NewWriter(w io.Writer) *Writer {
+	gz := gzip.NewWriter(w)
+	return &Writer{gz: gz, enc: jsoniter.NewEncoder(gz)}
+}
+
+func (w *Writer) WriteManifest(m *Manifest) error {
+	m.Version = ArchiveVersion
+	return w.enc.Encode(&record{Manifest: m})
+}
+
+// WriteDocument writes a document, it is compacted as the records are separated by new lines.
+func (w *Writer) WriteDocument(collection string, doc []byte) error {
+	w.compact.Reset()
+	if err := json.Compact(&w.compact, doc); err != nil {
+		return err
+	}
+
+	w.documents++
+	return w.enc.Encode(&record{Collection: collection, Doc: w.compact.Bytes()})
+}
+
+// Close writes the trailer and flushes the archive, it doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.enc.Encode(&record{End: &trailer{Documents: w.documents}}); err != nil {
+		return err
+	}
+
+	return w.gz.Close()
+}
+
+// Reader reads an archive. The manifest is read by NewReader, the documents by Next.
+type Reader struct {
+	buf       *bufio.Reader
+	manifest  *Manifest
+	colls     map[string]struct{}
+	documents int64
+	done      bool
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.InvalidArgument("invalid backup archive: %s", err.Error())
+	}
+
+	reader := &Reader{buf: bufio.NewReader(gz)}
+	rec, err := reader.read()
+	if err != nil {
+		return nil, err
+	}
+	if rec.Manifest == nil {
+		return nil, errors.InvalidArgument("invalid backup archive: missing manifest")
+	}
+	if rec.Manifest.Version > ArchiveVersion {
+		return nil, errors.InvalidArgument("unsupported backup archive version %d", rec.Manifest.Version)
+	}
+
+	reader.manifest = rec.Manifest
+	reader.colls = make(map[string]struct{})
+	for _, c := range rec.Manifest.Collections {
+		reader.colls[c.Name] = struct{}{}
+	}
+
+	return reader, nil
+}
+
+func (r *Reader) Manifest() *Manifest {
+	return r.manifest
+}
+
+// Next returns the next document and its collection, io.EOF once all the documents are read.
+func (r *Reader) Next() (string, []byte, error) {
+	if r.done {
+		return "", nil, io.EOF
+	}
+
+	rec, err := r.read()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if rec.End != nil {
+		if rec.End.Documents != r.documents {
+			return "", nil, errors.InvalidArgument("invalid backup archive: %d documents read, %d expected", r.documents,
+				rec.End.Documents)
+		}
+		r.done = true
+		return "", nil, io.EOF
+	}
+
+	if _, ok := r.colls[rec.Collection]; !ok || len(rec.Doc) == 0 {
+		return "", nil, errors.InvalidArgument("invalid backup archive: unexpected record of collection '%s'", rec.Collection)
+	}
+	r.documents++
+
+	return rec.Collection, rec.Doc, nil
+}
+
+func (r *Reader) read() (*record, error) {
+	line, err := r.buf.ReadBytes('\n')
+	if (err == io.EOF && len(line) == 0) || err == io.ErrUnexpectedEOF {
+		return nil, errors.InvalidArgument("invalid backup archive: truncated")
+	}
+	if err != nil && err != io.EOF {
+		return nil, errors.InvalidArgument("invalid backup archive: %s", err.Error())
+	}
+
+	var rec record
+	if err = jsoniter.Unmarshal(line, &rec); err != nil {
+		return nil, errors.InvalidArgument("invalid backup archive: %s", err.Error())
+	}
+
+	return &rec, nil
+}
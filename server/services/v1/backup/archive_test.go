@@ -0,0 +1,114 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchive(t *testing.T, docs map[string][]string, closed bool) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	m := &Manifest{Project: "p1", Branch: "main"}
+	for _, name := range []string{"c1", "c2"} {
+		m.Collections = append(m.Collections, &CollectionManifest{Name: name, Schema: []byte(`{"title":"` + name + `"}`)})
+	}
+	require.NoError(t, w.WriteManifest(m))
+
+	for _, name := range []string{"c1", "c2"} {
+		for _, doc := range docs[name] {
+			require.NoError(t, w.WriteDocument(name, []byte(doc)))
+		}
+	}
+
+	if closed {
+		require.NoError(t, w.Close())
+	} else {
+		require.NoError(t, w.gz.Flush())
+	}
+
+	return buf.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	docs := map[string][]string{
+		"c1": {"{\n  \"id\": 1\n}", `{"id":2,"s":"a\nb"}`},
+		"c2": {`{"id":3}`},
+	}
+
+	t.Run("round_trip", func(t *testing.T) {
+		r, err := NewReader(bytes.NewReader(writeArchive(t, docs, true)))
+		require.NoError(t, err)
+		require.Equal(t, ArchiveVersion, r.Manifest().Version)
+		require.Equal(t, "p1", r.Manifest().Project)
+		require.Len(t, r.Manifest().Collections, 2)
+		require.JSONEq(t, `{"title":"c2"}`, string(r.Manifest().Collections[1].Schema))
+
+		var read []string
+		for {
+			coll, doc, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			read = append(read, coll+":"+string(doc))
+		}
+		require.Equal(t, []string{`c1:{"id":1}`, `c1:{"id":2,"s":"a\nb"}`, `c2:{"id":3}`}, read)
+
+		_, _, err = r.Next()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		r, err := NewReader(bytes.NewReader(writeArchive(t, docs, false)))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, _, err = r.Next()
+			require.NoError(t, err)
+		}
+		_, _, err = r.Next()
+		require.ErrorContains(t, err, "truncated")
+	})
+
+	t.Run("not_an_archive", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte(`{"id":1}`)))
+		require.ErrorContains(t, err, "invalid backup archive")
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write([]byte("{\"collection\":\"c1\",\"doc\":{}}\n"))
+		require.NoError(t, gz.Close())
+
+		_, err = NewReader(&buf)
+		require.ErrorContains(t, err, "missing manifest")
+	})
+
+	t.Run("newer_version", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write([]byte("{\"manifest\":{\"version\":2}}\n"))
+		require.NoError(t, gz.Close())
+
+		_, err := NewReader(&buf)
+		require.ErrorContains(t, err, "unsupported backup archive version 2")
+	})
+}
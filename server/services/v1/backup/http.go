@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// Handler serves the HTTP variants of the Backup APIs. The branch is the "branch" query parameter, the main branch by
+// default. The target of a backup and the source of a restore are passed in the Tigris-Backup-Target and the
+// Tigris-Backup-Source headers, not in the query, as the URLs of the object stores carry credentials.
+type Handler struct {
+	client api.BackupClient
+}
+
+func NewHandler(client api.BackupClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) Backup(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Backup(common.OutgoingContext(r), describeRequest(r))
+	writeResponse(w, resp, err)
+}
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Restore(common.OutgoingContext(r), describeRequest(r))
+	writeResponse(w, resp, err)
+}
+
+// RestoreToTimestamp restores the branch to the moment of the Tigris-Restore-Timestamp header, only the collection of
+// the "collection" query parameter if it is set.
+func (h *Handler) RestoreToTimestamp(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if collection := r.URL.Query().Get("collection"); collection != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderRestoreCollection, collection)
+	}
+
+	resp, err := h.client.RestoreToTimestamp(ctx, describeRequest(r))
+	writeResponse(w, resp, err)
+}
+
+// Status returns the jobs of the project, or the job of the "id" query parameter.
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if id := r.URL.Query().Get("id"); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderBackupId, id)
+	}
+
+	resp, err := h.client.BackupStatus(ctx, describeRequest(r))
+	writeResponse(w, resp, err)
+}
+
+func describeRequest(r *http.Request) *api.DescribeDatabaseRequest {
+	return &api.DescribeDatabaseRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  r.URL.Query().Get("branch"),
+	}
+}
+
+func writeResponse(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
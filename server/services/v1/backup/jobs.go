@@ -0,0 +1,168 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// maxFinishedJobs is the number of the finished jobs of a namespace kept for the status requests.
+const maxFinishedJobs = 100
+
+// Jobs tracks the backup and restore jobs of this server, the jobs don't survive a restart.
+type Jobs struct {
+	sync.Mutex
+
+	jobs map[string][]*Job
+}
+
+func NewJobs() *Jobs {
+	return &Jobs{jobs: make(map[string][]*Job)}
+}
+
+// Start registers a new job. Only one job at a time runs on a branch, a restore writing to a branch being backed up
+// or restored would make an inconsistent copy.
+func (j *Jobs) Start(namespace string, kind string, project string, branch string, target string) (*Job, error) {
+	j.Lock()
+	defer j.Unlock()
+
+	finished := 0
+	for _, job := range j.jobs[namespace] {
+		job.Lock()
+		running := job.status.State == api.BackupRunning
+		same := job.status.Project == project && job.status.Branch == branch
+		job.Unlock()
+
+		if running && same {
+			return nil, errors.FailedPrecondition("a backup job is already running on branch '%s' of project '%s'", branch, project)
+		}
+		if !running {
+			finished++
+		}
+	}
+	j.prune(namespace, finished)
+
+	job := &Job{
+		status: api.BackupJob{
+			Id:        uuid.New().String(),
+			Kind:      kind,
+			State:     api.BackupRunning,
+			Project:   project,
+			Branch:    branch,
+			Target:    target,
+			StartedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	j.jobs[namespace] = append(j.jobs[namespace], job)
+
+	return job, nil
+}
+
+// prune drops the oldest finished jobs of the namespace above maxFinishedJobs.
+func (j *Jobs) prune(namespace string, finished int) {
+	kept := j.jobs[namespace][:0]
+	for _, job := range j.jobs[namespace] {
+		if finished >= maxFinishedJobs && job.State() != api.BackupRunning {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	j.jobs[namespace] = kept
+}
+
+// List returns the jobs of the project, or its job with the id if it is set, the most recent first.
+func (j *Jobs) List(namespace string, project string, id string) []*api.BackupJob {
+	j.Lock()
+	defer j.Unlock()
+
+	// the jobs are kept in the order they started
+	all, jobs := j.jobs[namespace], make([]*api.BackupJob, 0)
+	for i := len(all) - 1; i >= 0; i-- {
+		status := all[i].Status()
+		if status.Project == project && (id == "" || status.Id == id) {
+			jobs = append(jobs, status)
+		}
+	}
+
+	return jobs
+}
+
+// Job is the progress of a backup or a restore, it is updated by the job and read by the status requests.
+type Job struct {
+	sync.Mutex
+
+	status api.BackupJob
+}
+
+func (j *Job) Id() string {
+	return j.status.Id
+}
+
+func (j *Job) State() string {
+	j.Lock()
+	defer j.Unlock()
+
+	return j.status.State
+}
+
+// Status returns a copy of the progress.
+func (j *Job) Status() *api.BackupJob {
+	j.Lock()
+	defer j.Unlock()
+
+	status := j.status
+	return &status
+}
+
+// StartCollection records that the job moved to the collection.
+func (j *Job) StartCollection(name string) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.status.Collection = name
+	j.status.Collections++
+}
+
+func (j *Job) AddDocuments(n int64) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.status.Documents += n
+}
+
+func (j *Job) SetBytes(n int64) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.status.Bytes = n
+}
+
+// Finish records the end of the job, it failed if err is not nil.
+func (j *Job) Finish(err error) {
+	j.Lock()
+	defer j.Unlock()
+
+	j.status.State, j.status.Collection = api.BackupDone, ""
+	if err != nil {
+		j.status.State, j.status.Error = api.BackupFailed, err.Error()
+	}
+	j.status.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestJobs(t *testing.T) {
+	jobs := NewJobs()
+
+	j1, err := jobs.Start("ns1", api.BackupKindBackup, "p1", "main", "file://p1.gz")
+	require.NoError(t, err)
+	_, err = jobs.Start("ns1", api.BackupKindRestore, "p1", "main", "file://p1.gz")
+	require.ErrorContains(t, err, "already running")
+
+	// other branches, projects and namespaces are independent
+	j2, err := jobs.Start("ns1", api.BackupKindBackup, "p1", "b1", "file://b1.gz")
+	require.NoError(t, err)
+	_, err = jobs.Start("ns1", api.BackupKindBackup, "p2", "main", "file://p2.gz")
+	require.NoError(t, err)
+	_, err = jobs.Start("ns2", api.BackupKindBackup, "p1", "main", "file://p1.gz")
+	require.NoError(t, err)
+
+	j1.StartCollection("c1")
+	j1.AddDocuments(10)
+	j1.StartCollection("c2")
+	j1.AddDocuments(5)
+	j1.SetBytes(100)
+
+	status := j1.Status()
+	require.Equal(t, api.BackupRunning, status.State)
+	require.Equal(t, "c2", status.Collection)
+	require.Equal(t, int64(2), status.Collections)
+	require.Equal(t, int64(15), status.Documents)
+	require.Equal(t, int64(100), status.Bytes)
+
+	j1.Finish(nil)
+	j2.Finish(fmt.Errorf("failed"))
+
+	listed := jobs.List("ns1", "p1", "")
+	require.Len(t, listed, 2)
+	require.Equal(t, j2.Id(), listed[0].Id)
+	require.Equal(t, api.BackupFailed, listed[0].State)
+	require.Equal(t, "failed", listed[0].Error)
+	require.Equal(t, j1.Id(), listed[1].Id)
+	require.Equal(t, api.BackupDone, listed[1].State)
+	require.Empty(t, listed[1].Collection)
+	require.NotEmpty(t, listed[1].FinishedAt)
+
+	listed = jobs.List("ns1", "p1", j1.Id())
+	require.Len(t, listed, 1)
+	require.Equal(t, j1.Id(), listed[0].Id)
+	require.Empty(t, jobs.List("ns2", "p2", ""))
+
+	// a finished job no longer blocks the branch
+	_, err = jobs.Start("ns1", api.BackupKindRestore, "p1", "main", "file://p1.gz")
+	require.NoError(t, err)
+}
+
+func TestJobsPrune(t *testing.T) {
+	jobs := NewJobs()
+
+	running, err := jobs.Start("ns1", api.BackupKindBackup, "p1", "running", "file://p1.gz")
+	require.NoError(t, err)
+	for i := 0; i < maxFinishedJobs+10; i++ {
+		job, err := jobs.Start("ns1", api.BackupKindBackup, "p1", "main", "file://p1.gz")
+		require.NoError(t, err)
+		job.Finish(nil)
+	}
+
+	listed := jobs.List("ns1", "p1", "")
+	require.Len(t, listed, maxFinishedJobs+1)
+	require.Equal(t, running.Id(), listed[len(listed)-1].Id)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// Detach returns a context for a job outliving the request that started it. It carries the namespace and the
+// credentials of the request and is canceled after the timeout.
+func Detach(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc, error) {
+	md, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return nil, nil, errors.Internal("missing request metadata")
+	}
+
+	detached := *md
+	jobCtx, cancel := context.WithTimeout(detached.SaveToContext(context.Background()), timeout)
+
+	return jobCtx, cancel, nil
+}
+
+// Backup writes the archive with write and uploads it to the target. The archive is written to a temporary file under
+// the directory first, so that the documents are read as fast as the file is written regardless of the target, the
+// snapshot the documents are read from is only retained for a short time.
+func Backup(ctx context.Context, dir string, target Target, job *Job, write func(context.Context, *Writer) error) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "backup-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	archive := NewWriter(f)
+	if err = write(ctx, archive); err != nil {
+		return err
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	job.SetBytes(size)
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return target.Upload(ctx, f, size)
+}
+
+// Restore downloads the archive of the target and reads it with read.
+func Restore(ctx context.Context, target Target, job *Job, read func(context.Context, *Reader) error) error {
+	body, err := target.Download(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = body.Close() }()
+
+	counted := &countingReader{r: body, job: job}
+	archive, err := NewReader(counted)
+	if err != nil {
+		return err
+	}
+
+	return read(ctx, archive)
+}
+
+// countingReader records the bytes of the archive read so far in the job.
+type countingReader struct {
+	r     io.Reader
+	job   *Job
+	bytes int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.bytes += int64(n)
+	c.job.SetBytes(c.bytes)
+
+	return n, err
+}
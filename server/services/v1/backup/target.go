@@ -0,0 +1,180 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backup implements the parts of the logical backups that don't depend on the database: the targets the
+// archives are written to and read from, the format of the archives and the tracking of the backup and restore jobs.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+// Target is where an archive is written to by a backup and read from by a restore.
+type Target interface {
+	// Upload writes the archive of size bytes read from r, replacing the previous one.
+	Upload(ctx context.Context, r io.Reader, size int64) error
+	// Download opens the archive.
+	Download(ctx context.Context) (io.ReadCloser, error)
+	// String is the target without its credentials, it is safe to return to the users.
+	String() string
+}
+
+// ParseTarget returns the target of the URL. The "file://<name>" targets are files under the backup directory, the
+// "http(s)://" targets are objects of an object store, usually pre-signed URLs, whose host must be allowed.
+func ParseTarget(cfg config.BackupConfig, target string) (Target, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, errors.InvalidArgument("invalid backup target: %s", err.Error())
+	}
+
+	switch u.Scheme {
+	case "file":
+		return newFileTarget(cfg.Dir, u.Host+u.Path)
+	case "http", "https":
+		return newHTTPTarget(cfg.AllowedHosts, u)
+	default:
+		return nil, errors.InvalidArgument("unsupported backup target scheme '%s'", u.Scheme)
+	}
+}
+
+// fileTarget is a file under the backup directory, it is written to a temporary file renamed once complete.
+type fileTarget struct {
+	name string
+	path string
+}
+
+func newFileTarget(dir string, name string) (*fileTarget, error) {
+	clean := filepath.Clean(name)
+	if name == "" || filepath.IsAbs(name) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, errors.InvalidArgument("invalid backup file '%s', expecting a path relative to the backup directory", name)
+	}
+
+	return &fileTarget{name: clean, path: filepath.Join(dir, clean)}, nil
+}
+
+func (t *fileTarget) Upload(_ context.Context, r io.Reader, _ int64) error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), t.path)
+}
+
+func (t *fileTarget) Download(_ context.Context) (io.ReadCloser, error) {
+	f, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, errors.NotFound("backup '%s' not found", t)
+	}
+
+	return f, err
+}
+
+func (t *fileTarget) String() string {
+	return "file://" + filepath.ToSlash(t.name)
+}
+
+// httpClient doesn't follow the redirects, they could lead to a host that is not allowed.
+var httpClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// httpTarget is an object of an object store, it is uploaded with a PUT and downloaded with a GET of the URL. The
+// credentials are expected to be in the URL, e.g. the signature of a pre-signed URL.
+type httpTarget struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func newHTTPTarget(allowed []string, u *url.URL) (*httpTarget, error) {
+	for _, host := range allowed {
+		if strings.EqualFold(host, u.Hostname()) {
+			return &httpTarget{url: u, client: httpClient}, nil
+		}
+	}
+
+	return nil, errors.InvalidArgument("backup target host '%s' is not allowed", u.Hostname())
+}
+
+func (t *httpTarget) Upload(ctx context.Context, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.url.String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", ArchiveContentType)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("uploading backup to '%s' failed with status %d", t, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (t *httpTarget) Download(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, errors.NotFound("backup '%s' not found", t)
+		}
+		return nil, fmt.Errorf("downloading backup from '%s' failed with status %d", t, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// String drops the query and the user info of the URL, which hold the credentials.
+func (t *httpTarget) String() string {
+	return t.url.Scheme + "://" + t.url.Host + t.url.Path
+}
@@ -0,0 +1,123 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestParseTarget(t *testing.T) {
+	cfg := config.BackupConfig{Dir: "/backups", AllowedHosts: []string{"bucket.example.com"}}
+
+	for _, c := range []struct {
+		target string
+		str    string
+		err    string
+	}{
+		{"file://daily/p1.gz", "file://daily/p1.gz", ""},
+		{"file://p1.gz", "file://p1.gz", ""},
+		{"file:///etc/passwd", "", "invalid backup file"},
+		{"file://../p1.gz", "", "invalid backup file"},
+		{"file://daily/../../p1.gz", "", "invalid backup file"},
+		{"file://", "", "invalid backup file"},
+		{"https://bucket.example.com/p1.gz?X-Sig=secret", "https://bucket.example.com/p1.gz", ""},
+		{"https://BUCKET.example.com:443/p1.gz", "https://BUCKET.example.com:443/p1.gz", ""},
+		{"https://169.254.169.254/latest", "", "is not allowed"},
+		{"s3://bucket/p1.gz", "", "unsupported backup target scheme"},
+	} {
+		target, err := ParseTarget(cfg, c.target)
+		if c.err != "" {
+			require.ErrorContains(t, err, c.err, c.target)
+			continue
+		}
+		require.NoError(t, err, c.target)
+		require.Equal(t, c.str, target.String())
+	}
+}
+
+func TestFileTarget(t *testing.T) {
+	ctx := context.Background()
+	target, err := ParseTarget(config.BackupConfig{Dir: t.TempDir()}, "file://daily/p1.gz")
+	require.NoError(t, err)
+
+	_, err = target.Download(ctx)
+	require.ErrorContains(t, err, "not found")
+
+	require.NoError(t, target.Upload(ctx, bytes.NewReader([]byte("archive")), 7))
+	body, err := target.Download(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	require.Equal(t, "archive", string(data))
+}
+
+func TestHTTPTarget(t *testing.T) {
+	ctx := context.Background()
+
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+				return
+			}
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	cfg := config.BackupConfig{AllowedHosts: []string{u.Hostname()}}
+
+	target, err := ParseTarget(cfg, srv.URL+"/p1.gz")
+	require.NoError(t, err)
+
+	_, err = target.Download(ctx)
+	require.ErrorContains(t, err, "not found")
+
+	require.NoError(t, target.Upload(ctx, bytes.NewReader([]byte("archive")), 7))
+	body, err := target.Download(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	require.Equal(t, "archive", string(data))
+
+	// the redirects are not followed
+	target, err = ParseTarget(cfg, srv.URL+"/redirect")
+	require.NoError(t, err)
+	_, err = target.Download(ctx)
+	require.ErrorContains(t, err, "status 302")
+}
@@ -0,0 +1,220 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+const deleteStatusPath = fullProjectPath + "/database/collections/{collection}/documents/delete/status"
+
+// batchDeletes tracks the progress of the batched deletes run by this server, the finished deletes of a namespace
+// above the configured number are dropped, the oldest first.
+type batchDeletes struct {
+	sync.Mutex
+
+	deletes map[string][]*api.DeleteProgress
+}
+
+func newBatchDeletes() *batchDeletes {
+	return &batchDeletes{deletes: make(map[string][]*api.DeleteProgress)}
+}
+
+// start registers the delete of the request, it fails if a delete with the same id is still running.
+func (b *batchDeletes) start(namespace string, id string, r *api.DeleteRequest) (*api.DeleteProgress, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	finished := 0
+	for _, d := range b.deletes[namespace] {
+		if d.State == api.DeleteRunning && d.Id == id {
+			return nil, errors.AlreadyExists("delete '%s' is already running", id)
+		}
+		if d.State != api.DeleteRunning {
+			finished++
+		}
+	}
+
+	kept := b.deletes[namespace][:0]
+	for _, d := range b.deletes[namespace] {
+		if finished >= config.DefaultConfig.Server.BatchDelete.MaxTracked && d.State != api.DeleteRunning {
+			finished--
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	progress := &api.DeleteProgress{
+		Id:         id,
+		State:      api.DeleteRunning,
+		Project:    r.GetProject(),
+		Branch:     metadata.NewDatabaseNameWithBranch(r.GetProject(), r.GetBranch()).Branch(),
+		Collection: r.GetCollection(),
+		StartedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	b.deletes[namespace] = append(kept, progress)
+
+	return progress, nil
+}
+
+// batchDone records the documents deleted by a committed batch.
+func (b *batchDeletes) batchDone(progress *api.DeleteProgress, deleted int32) {
+	b.Lock()
+	defer b.Unlock()
+
+	progress.Deleted += int64(deleted)
+	progress.Batches++
+}
+
+// finish records the end of the delete, it failed if err is not nil.
+func (b *batchDeletes) finish(progress *api.DeleteProgress, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	progress.State = api.DeleteDone
+	if err != nil {
+		progress.State, progress.Error = api.DeleteFailed, err.Error()
+	}
+	progress.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+}
+
+// list returns the copies of the deletes of the collection, or of its delete with the id if it is set, the most
+// recent first.
+func (b *batchDeletes) list(namespace string, project string, collection string, id string) []api.DeleteProgress {
+	b.Lock()
+	defer b.Unlock()
+
+	all, deletes := b.deletes[namespace], make([]api.DeleteProgress, 0)
+	for i := len(all) - 1; i >= 0; i-- {
+		d := all[i]
+		if d.Project == project && d.Collection == collection && (id == "" || d.Id == id) {
+			deletes = append(deletes, *d)
+		}
+	}
+
+	return deletes
+}
+
+// batchDelete deletes the documents matching the filter of the request in batches of batchSize, each in its own
+// transaction, so that deleting many documents doesn't exceed the limits of a transaction. The filter is evaluated
+// again by every batch, the documents deleted by the previous batches no longer match it. The limit of the request
+// bounds the documents deleted by all the batches.
+func (s *apiService) batchDelete(ctx context.Context, r *api.DeleteRequest, batchSize int) (*api.DeleteResponse, error) {
+	if api.GetTransaction(ctx) != nil {
+		return nil, errors.InvalidArgument("batched delete can't run in an explicit transaction")
+	}
+
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := api.GetHeader(ctx, api.HeaderDeleteId)
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	progress, err := s.batchDeletes.start(namespace, id, r)
+	if err != nil {
+		return nil, err
+	}
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderDeleteId, id))
+
+	resp, err := runBatchedDelete(ctx, r, batchSize, func(ctx context.Context, batch *api.DeleteRequest) (*api.DeleteResponse, error) {
+		resp, err := s.delete(ctx, batch, false)
+		if err == nil {
+			s.batchDeletes.batchDone(progress, resp.DeletedCount)
+		}
+		return resp, err
+	})
+	s.batchDeletes.finish(progress, err)
+
+	return resp, err
+}
+
+// runBatchedDelete runs the batches of the delete with deleteFn until a batch deletes fewer documents than its size,
+// the limit of the request is reached or the request is canceled.
+func runBatchedDelete(ctx context.Context, r *api.DeleteRequest, batchSize int,
+	deleteFn func(context.Context, *api.DeleteRequest) (*api.DeleteResponse, error),
+) (*api.DeleteResponse, error) {
+	limit := r.GetOptions().GetLimit()
+
+	var (
+		deleted int64
+		last    *api.DeleteResponse
+	)
+	for limit == 0 || deleted < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Aborted("delete canceled after deleting %d documents", deleted)
+		}
+
+		size := int64(batchSize)
+		if limit > 0 && limit-deleted < size {
+			size = limit - deleted
+		}
+
+		resp, err := deleteFn(ctx, &api.DeleteRequest{
+			Project:    r.GetProject(),
+			Branch:     r.GetBranch(),
+			Collection: r.GetCollection(),
+			Filter:     r.GetFilter(),
+			Options: &api.DeleteRequestOptions{
+				Limit:     size,
+				Collation: r.GetOptions().GetCollation(),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		deleted += int64(resp.DeletedCount)
+		last = resp
+		if int64(resp.DeletedCount) < size {
+			break
+		}
+	}
+
+	return &api.DeleteResponse{
+		DeletedCount: int32(deleted),
+		Status:       last.GetStatus(),
+		Metadata:     last.GetMetadata(),
+	}, nil
+}
+
+// deleteStatus returns the progress of the batched deletes of the collection run by this server, the delete with the
+// id of the "id" query parameter if it is set.
+func (s *apiService) deleteStatus(w http.ResponseWriter, r *http.Request) {
+	namespace, err := request.GetNamespace(r.Context())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	deletes := s.batchDeletes.list(namespace, chi.URLParam(r, "project"), chi.URLParam(r, "collection"), r.URL.Query().Get("id"))
+	writeHTTPResponse(w, map[string]any{"deletes": deletes})
+}
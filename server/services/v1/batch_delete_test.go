@@ -0,0 +1,138 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+// fakeBatches deletes at most the limit of each batch from the matching documents.
+type fakeBatches struct {
+	matching int64
+	limits   []int64
+	err      error
+}
+
+func (f *fakeBatches) delete(_ context.Context, r *api.DeleteRequest) (*api.DeleteResponse, error) {
+	if f.err != nil && len(f.limits) > 0 {
+		return nil, f.err
+	}
+
+	f.limits = append(f.limits, r.Options.Limit)
+	deleted := f.matching
+	if deleted > r.Options.Limit {
+		deleted = r.Options.Limit
+	}
+	f.matching -= deleted
+
+	return &api.DeleteResponse{DeletedCount: int32(deleted), Status: "deleted"}, nil
+}
+
+func TestRunBatchedDelete(t *testing.T) {
+	r := &api.DeleteRequest{Project: "p1", Collection: "c1", Filter: []byte(`{"a": 1}`)}
+
+	t.Run("all", func(t *testing.T) {
+		f := &fakeBatches{matching: 25}
+		resp, err := runBatchedDelete(context.Background(), r, 10, f.delete)
+		require.NoError(t, err)
+		require.Equal(t, int32(25), resp.DeletedCount)
+		require.Equal(t, "deleted", resp.Status)
+		require.Equal(t, []int64{10, 10, 10}, f.limits)
+	})
+
+	t.Run("exact_batches", func(t *testing.T) {
+		f := &fakeBatches{matching: 20}
+		resp, err := runBatchedDelete(context.Background(), r, 10, f.delete)
+		require.NoError(t, err)
+		require.Equal(t, int32(20), resp.DeletedCount)
+		require.Equal(t, []int64{10, 10, 10}, f.limits)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		f := &fakeBatches{matching: 100}
+		limited := &api.DeleteRequest{Project: "p1", Collection: "c1", Options: &api.DeleteRequestOptions{Limit: 25}}
+		resp, err := runBatchedDelete(context.Background(), limited, 10, f.delete)
+		require.NoError(t, err)
+		require.Equal(t, int32(25), resp.DeletedCount)
+		require.Equal(t, []int64{10, 10, 5}, f.limits)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := &fakeBatches{matching: 100, err: fmt.Errorf("conflict")}
+		_, err := runBatchedDelete(context.Background(), r, 10, f.delete)
+		require.Error(t, err)
+		require.Equal(t, []int64{10}, f.limits)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		f := &fakeBatches{matching: 100}
+		_, err := runBatchedDelete(ctx, r, 10, f.delete)
+		require.Error(t, err)
+		require.Empty(t, f.limits)
+	})
+}
+
+func TestBatchDeletes(t *testing.T) {
+	maxTracked := config.DefaultConfig.Server.BatchDelete.MaxTracked
+	config.DefaultConfig.Server.BatchDelete.MaxTracked = 2
+	defer func() { config.DefaultConfig.Server.BatchDelete.MaxTracked = maxTracked }()
+
+	b := newBatchDeletes()
+	r := &api.DeleteRequest{Project: "p1", Collection: "c1"}
+
+	first, err := b.start("ns1", "d1", r)
+	require.NoError(t, err)
+	_, err = b.start("ns1", "d1", r)
+	require.Error(t, err)
+
+	b.batchDone(first, 10)
+	b.batchDone(first, 5)
+	b.finish(first, nil)
+
+	deletes := b.list("ns1", "p1", "c1", "d1")
+	require.Len(t, deletes, 1)
+	require.Equal(t, api.DeleteDone, deletes[0].State)
+	require.Equal(t, int64(15), deletes[0].Deleted)
+	require.Equal(t, int64(2), deletes[0].Batches)
+	require.Equal(t, "main", deletes[0].Branch)
+
+	// the id of a finished delete can be reused
+	second, err := b.start("ns1", "d1", r)
+	require.NoError(t, err)
+	b.finish(second, fmt.Errorf("conflict"))
+
+	_, err = b.start("ns1", "d3", r)
+	require.NoError(t, err)
+
+	// the oldest finished delete is dropped
+	deletes = b.list("ns1", "p1", "c1", "")
+	require.Len(t, deletes, 2)
+	require.Equal(t, "d3", deletes[0].Id)
+	require.Equal(t, api.DeleteRunning, deletes[0].State)
+	require.Equal(t, api.DeleteFailed, deletes[1].State)
+	require.Equal(t, "conflict", deletes[1].Error)
+
+	require.Empty(t, b.list("ns2", "p1", "c1", ""))
+	require.Empty(t, b.list("ns1", "p1", "c2", ""))
+}
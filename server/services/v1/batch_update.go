@@ -0,0 +1,334 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+const (
+	updateStatusPath        = fullProjectPath + "/database/collections/{collection}/documents/update/status"
+	updateCancelPathPattern = fullProjectPath + "/database/collections/{collection}/documents/update/{id}/cancel"
+)
+
+// batchUpdate is an update run in batches in the background by this server.
+type batchUpdate struct {
+	progress api.UpdateProgress
+	cancel   context.CancelFunc
+}
+
+// batchUpdates tracks the progress of the batched updates run by this server, like batchDeletes does for the deletes.
+// The running updates can be canceled.
+type batchUpdates struct {
+	sync.Mutex
+
+	updates map[string][]*batchUpdate
+}
+
+func newBatchUpdates() *batchUpdates {
+	return &batchUpdates{updates: make(map[string][]*batchUpdate)}
+}
+
+// start registers the update of the request, it fails if an update with the same id is still running. The update is
+// stopped with cancel.
+func (b *batchUpdates) start(namespace string, id string, r *api.UpdateRequest, cancel context.CancelFunc) (*batchUpdate, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	finished := 0
+	for _, u := range b.updates[namespace] {
+		if u.progress.State == api.UpdateRunning && u.progress.Id == id {
+			return nil, errors.AlreadyExists("update '%s' is already running", id)
+		}
+		if u.progress.State != api.UpdateRunning {
+			finished++
+		}
+	}
+
+	kept := b.updates[namespace][:0]
+	for _, u := range b.updates[namespace] {
+		if finished >= config.DefaultConfig.Server.BatchUpdate.MaxTracked && u.progress.State != api.UpdateRunning {
+			finished--
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	update := &batchUpdate{
+		progress: api.UpdateProgress{
+			Id:         id,
+			State:      api.UpdateRunning,
+			Project:    r.GetProject(),
+			Branch:     metadata.NewDatabaseNameWithBranch(r.GetProject(), r.GetBranch()).Branch(),
+			Collection: r.GetCollection(),
+			StartedAt:  time.Now().UTC().Format(time.RFC3339),
+		},
+		cancel: cancel,
+	}
+	b.updates[namespace] = append(kept, update)
+
+	return update, nil
+}
+
+// batchDone records the documents updated and scanned by a committed batch.
+func (b *batchUpdates) batchDone(update *batchUpdate, updated int32, scanned int64) {
+	b.Lock()
+	defer b.Unlock()
+
+	update.progress.Updated += int64(updated)
+	update.progress.Scanned += scanned
+	update.progress.Batches++
+}
+
+// finish records the end of the update, it failed if err is not nil and it is canceled if its context is.
+func (b *batchUpdates) finish(ctx context.Context, update *batchUpdate, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	switch {
+	case err == nil:
+		update.progress.State = api.UpdateDone
+	case ctx.Err() == context.Canceled:
+		update.progress.State = api.UpdateCanceled
+	default:
+		update.progress.State, update.progress.Error = api.UpdateFailed, err.Error()
+	}
+	update.progress.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+}
+
+// list returns the copies of the updates of the collection, or of its update with the id if it is set, the most
+// recent first.
+func (b *batchUpdates) list(namespace string, project string, collection string, id string) []api.UpdateProgress {
+	b.Lock()
+	defer b.Unlock()
+
+	all, updates := b.updates[namespace], make([]api.UpdateProgress, 0)
+	for i := len(all) - 1; i >= 0; i-- {
+		p := all[i].progress
+		if p.Project == project && p.Collection == collection && (id == "" || p.Id == id) {
+			updates = append(updates, p)
+		}
+	}
+
+	return updates
+}
+
+// cancel stops the running update of the collection with the id. The batch in progress is rolled back, the batches
+// committed before it are kept.
+func (b *batchUpdates) cancel(namespace string, project string, collection string, id string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, u := range b.updates[namespace] {
+		p := u.progress
+		if p.Project != project || p.Collection != collection || p.Id != id {
+			continue
+		}
+		if p.State != api.UpdateRunning {
+			return errors.FailedPrecondition("update '%s' is not running", id)
+		}
+
+		u.cancel()
+		return nil
+	}
+
+	return errors.NotFound("update not found '%s'", id)
+}
+
+// batchUpdate starts the update of the documents matching the filter of the request in the background, in batches of
+// batchSize each in its own transaction, so that updating many documents doesn't exceed the limits of a transaction.
+// The batches are throttled to the configured rate. The update is tracked by its id until it finishes or is canceled.
+func (s *apiService) batchUpdate(ctx context.Context, r *api.UpdateRequest, batchSize int) (*api.UpdateResponse, error) {
+	if api.GetTransaction(ctx) != nil {
+		return nil, errors.InvalidArgument("batched update can't run in an explicit transaction")
+	}
+	if filter.None(r.GetFilter()) {
+		return nil, errors.InvalidArgument("updating all documents is not allowed")
+	}
+
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := api.GetHeader(ctx, api.HeaderUpdateId)
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	cfg := config.DefaultConfig.Server.BatchUpdate
+	jobCtx, cancel, err := backup.Detach(ctx, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := s.batchUpdates.start(namespace, id, r, cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderUpdateId, id))
+
+	var limiter *rate.Limiter
+	if cfg.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.Rate), batchSize)
+	}
+
+	go func() {
+		defer cancel()
+
+		_, err := runBatchedUpdate(jobCtx, r, batchSize, cfg.MaxScanned, limiter,
+			func(ctx context.Context, req *api.UpdateRequest, batch *database.UpdateBatch) (int32, error) {
+				modified, err := s.updateBatch(ctx, req, batch)
+				if err == nil {
+					s.batchUpdates.batchDone(update, modified, batch.Scanned)
+				}
+				return modified, err
+			})
+		if err != nil {
+			log.Err(err).Str("project", r.GetProject()).Str("collection", r.GetCollection()).Str("id", id).Msg("batched update failed")
+		}
+		s.batchUpdates.finish(jobCtx, update, err)
+	}()
+
+	return &api.UpdateResponse{Status: database.StartedStatus}, nil
+}
+
+// updateBatch updates the documents of the batch in a transaction.
+func (s *apiService) updateBatch(ctx context.Context, r *api.UpdateRequest, batch *database.UpdateBatch) (int32, error) {
+	queryMetrics := metrics.WriteQueryMetrics{}
+	accessToken, _ := request.GetAccessToken(ctx)
+	runner := s.runnerFactory.GetUpdateQueryRunner(r, &queryMetrics, accessToken)
+	runner.SetBatch(batch)
+
+	resp, err := s.sessions.Execute(ctx, runner, database.ReqOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.ModifiedCount, nil
+}
+
+// runBatchedUpdate runs the batches of the update with updateFn until the scan reaches the end of the collection, the
+// limit of the request is reached or the context is canceled. Each batch waits for the limiter, if any, to allow
+// the documents it may update. It returns the number of the documents updated.
+func runBatchedUpdate(ctx context.Context, r *api.UpdateRequest, batchSize int, maxScanned int, limiter *rate.Limiter,
+	updateFn func(context.Context, *api.UpdateRequest, *database.UpdateBatch) (int32, error),
+) (int64, error) {
+	limit := r.GetOptions().GetLimit()
+
+	var (
+		modified int64
+		after    []byte
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return modified, err
+		}
+
+		size := int64(batchSize)
+		if limit > 0 && limit-modified < size {
+			size = limit - modified
+		}
+		if limiter != nil {
+			if err := limiter.WaitN(ctx, int(size)); err != nil {
+				return modified, err
+			}
+		}
+
+		batch := &database.UpdateBatch{After: after, MaxScanned: maxScanned}
+		n, err := updateFn(ctx, &api.UpdateRequest{
+			Project:    r.GetProject(),
+			Branch:     r.GetBranch(),
+			Collection: r.GetCollection(),
+			Fields:     r.GetFields(),
+			Filter:     r.GetFilter(),
+			Options: &api.UpdateRequestOptions{
+				Limit:     size,
+				Collation: r.GetOptions().GetCollation(),
+			},
+		}, batch)
+		if err != nil {
+			return modified, err
+		}
+
+		modified += int64(n)
+		if batch.Done || (limit > 0 && modified >= limit) {
+			return modified, nil
+		}
+		after = batch.Last
+	}
+}
+
+// registerBatchUpdateHTTP registers the REST endpoints returning the progress of the batched updates and canceling
+// them.
+func (s *apiService) registerBatchUpdateHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+updateStatusPath, s.updateStatus)
+	router.Post(apiPathPrefix+updateCancelPathPattern, s.cancelBatchUpdate)
+}
+
+// updateStatus returns the progress of the batched updates of the collection run by this server, the update with the
+// id of the "id" query parameter if it is set.
+func (s *apiService) updateStatus(w http.ResponseWriter, r *http.Request) {
+	namespace, err := request.GetNamespace(r.Context())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	updates := s.batchUpdates.list(namespace, chi.URLParam(r, "project"), chi.URLParam(r, "collection"), r.URL.Query().Get("id"))
+	writeHTTPResponse(w, map[string]any{"updates": updates})
+}
+
+// cancelBatchUpdate stops the batched update with the id, the batches committed before are kept.
+func (s *apiService) cancelBatchUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	namespace, err := request.GetNamespace(r.Context())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	err = s.batchUpdates.cancel(namespace, chi.URLParam(r, "project"), chi.URLParam(r, "collection"), chi.URLParam(r, "id"))
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": "canceled"})
+}
@@ -0,0 +1,200 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+	"golang.org/x/time/rate"
+)
+
+// fakeUpdateBatches scans a collection of the documents, the matching ones are updated and keep matching.
+type fakeUpdateBatches struct {
+	docs    []bool
+	batches []*database.UpdateBatch
+	err     error
+}
+
+func (f *fakeUpdateBatches) update(_ context.Context, r *api.UpdateRequest, batch *database.UpdateBatch) (int32, error) {
+	if f.err != nil && len(f.batches) > 0 {
+		return 0, f.err
+	}
+
+	start := 0
+	if batch.After != nil {
+		start = int(batch.After[0]) + 1
+	}
+
+	var (
+		modified int32
+		limited  bool
+	)
+	for i := start; int64(modified) < r.Options.Limit; i++ {
+		if batch.MaxScanned > 0 && batch.Scanned == int64(batch.MaxScanned) {
+			limited = true
+			break
+		}
+		if i == len(f.docs) {
+			batch.Done = true
+			break
+		}
+		batch.Scanned++
+		batch.Last = []byte{byte(i)}
+		if f.docs[i] {
+			modified++
+		}
+	}
+	batch.Done = batch.Done && !limited
+	if batch.Last == nil {
+		batch.Last = batch.After
+	}
+	f.batches = append(f.batches, batch)
+
+	return modified, nil
+}
+
+func TestRunBatchedUpdate(t *testing.T) {
+	r := &api.UpdateRequest{Project: "p1", Collection: "c1", Filter: []byte(`{"a": 1}`), Fields: []byte(`{"$set": {"b": 1}}`)}
+
+	matching := func(n int, every int) []bool {
+		docs := make([]bool, n)
+		for i := range docs {
+			docs[i] = i%every == 0
+		}
+		return docs
+	}
+
+	t.Run("all", func(t *testing.T) {
+		f := &fakeUpdateBatches{docs: matching(25, 1)}
+		modified, err := runBatchedUpdate(context.Background(), r, 10, 0, nil, f.update)
+		require.NoError(t, err)
+		require.Equal(t, int64(25), modified)
+		require.Len(t, f.batches, 3)
+		require.Nil(t, f.batches[0].After)
+		require.Equal(t, []byte{9}, f.batches[1].After)
+		require.Equal(t, []byte{19}, f.batches[2].After)
+	})
+
+	t.Run("max_scanned", func(t *testing.T) {
+		// the batches stop after scanning 10 documents with a single match
+		f := &fakeUpdateBatches{docs: matching(30, 10)}
+		modified, err := runBatchedUpdate(context.Background(), r, 5, 10, nil, f.update)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), modified)
+		require.Len(t, f.batches, 4)
+		require.True(t, f.batches[3].Done)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		f := &fakeUpdateBatches{docs: matching(100, 1)}
+		limited := &api.UpdateRequest{Project: "p1", Collection: "c1", Options: &api.UpdateRequestOptions{Limit: 25}}
+		modified, err := runBatchedUpdate(context.Background(), limited, 10, 0, nil, f.update)
+		require.NoError(t, err)
+		require.Equal(t, int64(25), modified)
+		require.Len(t, f.batches, 3)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := &fakeUpdateBatches{docs: matching(100, 1), err: fmt.Errorf("conflict")}
+		modified, err := runBatchedUpdate(context.Background(), r, 10, 0, nil, f.update)
+		require.Error(t, err)
+		require.Equal(t, int64(10), modified)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		f := &fakeUpdateBatches{docs: matching(100, 1)}
+		_, err := runBatchedUpdate(ctx, r, 10, 0, nil, f.update)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, f.batches)
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		// the limiter allows a single batch, the next one waits past the deadline of the context
+		limiter := rate.NewLimiter(rate.Limit(1), 10)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		f := &fakeUpdateBatches{docs: matching(100, 1)}
+		modified, err := runBatchedUpdate(ctx, r, 10, 0, limiter, f.update)
+		require.Error(t, err)
+		require.Equal(t, int64(10), modified)
+		require.Len(t, f.batches, 1)
+	})
+}
+
+func TestBatchUpdates(t *testing.T) {
+	maxTracked := config.DefaultConfig.Server.BatchUpdate.MaxTracked
+	config.DefaultConfig.Server.BatchUpdate.MaxTracked = 2
+	defer func() { config.DefaultConfig.Server.BatchUpdate.MaxTracked = maxTracked }()
+
+	b := newBatchUpdates()
+	r := &api.UpdateRequest{Project: "p1", Collection: "c1"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := b.start("ns1", "u1", r, cancel)
+	require.NoError(t, err)
+	_, err = b.start("ns1", "u1", r, cancel)
+	require.Error(t, err)
+
+	b.batchDone(first, 10, 20)
+	b.batchDone(first, 5, 20)
+	b.finish(ctx, first, nil)
+
+	updates := b.list("ns1", "p1", "c1", "u1")
+	require.Len(t, updates, 1)
+	require.Equal(t, api.UpdateDone, updates[0].State)
+	require.Equal(t, int64(15), updates[0].Updated)
+	require.Equal(t, int64(40), updates[0].Scanned)
+	require.Equal(t, int64(2), updates[0].Batches)
+	require.Equal(t, "main", updates[0].Branch)
+
+	// a finished update can't be canceled, its id can be reused
+	require.Error(t, b.cancel("ns1", "p1", "c1", "u1"))
+	second, err := b.start("ns1", "u1", r, cancel)
+	require.NoError(t, err)
+	b.finish(ctx, second, fmt.Errorf("conflict"))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	third, err := b.start("ns1", "u3", r, cancel)
+	require.NoError(t, err)
+
+	require.Error(t, b.cancel("ns1", "p1", "c1", "u4"))
+	require.Error(t, b.cancel("ns2", "p1", "c1", "u3"))
+	require.NoError(t, b.cancel("ns1", "p1", "c1", "u3"))
+	require.Error(t, ctx.Err())
+	b.finish(ctx, third, ctx.Err())
+
+	// the oldest finished update is dropped
+	updates = b.list("ns1", "p1", "c1", "")
+	require.Len(t, updates, 2)
+	require.Equal(t, "u3", updates[0].Id)
+	require.Equal(t, api.UpdateCanceled, updates[0].State)
+	require.Empty(t, updates[0].Error)
+	require.Equal(t, api.UpdateFailed, updates[1].State)
+	require.Equal(t, "conflict", updates[1].Error)
+
+	require.Empty(t, b.list("ns2", "p1", "c1", ""))
+	require.Empty(t, b.list("ns1", "p1", "c2", ""))
+}
@@ -0,0 +1,120 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package branch serves the HTTP variants of the branch APIs that are not part of the generated gateway: the
+// DiffBranches API comparing a branch with a base branch, the MergeBranch API merging a branch into the main branch
+// the ListBranchLifetimes API listing the remaining lifetime of the ephemeral branches and the CopyCollection API
+// copying a collection within or across the branches.
+package branch
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// CopyHandler copies the collection of the branch of the "branch" query parameter to the target of the
+// "target_project", "target_branch" and "target_collection" query parameters. The "indexes" query parameter set to
+// "false" copies the collection without its secondary indexes.
+type CopyHandler struct {
+	client api.CollectionCopyClient
+}
+
+func NewCopyHandler(client api.CollectionCopyClient) *CopyHandler {
+	return &CopyHandler{client: client}
+}
+
+func (h *CopyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	for param, header := range map[string]string{
+		"target_project":    api.HeaderCopyTargetProject,
+		"target_branch":     api.HeaderCopyTargetBranch,
+		"target_collection": api.HeaderCopyTargetCollection,
+		"indexes":           api.HeaderCopyIndexes,
+	} {
+		if value := r.URL.Query().Get(param); value != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, header, value)
+		}
+	}
+
+	resp, err := h.client.CopyCollection(ctx, &api.DescribeCollectionRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
+
+// DiffHandler compares the branch with the base branch passed in the "base" query parameter, the main branch by
+// default. The "sample" query parameter is the number of the changed documents returned for each collection.
+type DiffHandler struct {
+	client api.BranchDiffClient
+}
+
+func NewDiffHandler(client api.BranchDiffClient) *DiffHandler {
+	return &DiffHandler{client: client}
+}
+
+func (h *DiffHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if base := r.URL.Query().Get("base"); base != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderDiffBase, base)
+	}
+	if sample := r.URL.Query().Get("sample"); sample != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderDiffSample, sample)
+	}
+
+	resp, err := h.client.DiffBranches(ctx, &api.DescribeDatabaseRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  chi.URLParam(r, "branch"),
+	})
+	writeResponse(w, resp, err)
+}
+
+// LifetimesHandler lists the branches of the project with the remaining lifetime of the ephemeral ones.
+type LifetimesHandler struct {
+	client api.BranchLifetimeClient
+}
+
+func NewLifetimesHandler(client api.BranchLifetimeClient) *LifetimesHandler {
+	return &LifetimesHandler{client: client}
+}
+
+func (h *LifetimesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.ListBranchLifetimes(common.OutgoingContext(r), &api.ListBranchesRequest{
+		Project: chi.URLParam(r, "project"),
+	})
+	writeResponse(w, resp, err)
+}
+
+func writeResponse(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
@@ -0,0 +1,89 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/grpc/metadata"
+)
+
+// MergeHandler merges the branch into the main branch with the conflict policy passed in the "policy" query
+// parameter. The response is a NDJSON stream of the progress of the merge, one status per line, flushed as soon as it
+// is received.
+type MergeHandler struct {
+	client api.BranchMergeClient
+}
+
+func NewMergeHandler(client api.BranchMergeClient) *MergeHandler {
+	return &MergeHandler{client: client}
+}
+
+func (h *MergeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if policy := r.URL.Query().Get("policy"); policy != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderMergePolicy, policy)
+	}
+
+	stream, err := h.client.MergeBranch(ctx, &api.DescribeDatabaseRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  chi.URLParam(r, "branch"),
+	})
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	// wait for the first status, so that the errors detected before the merge starts are returned with their status
+	// code
+	status, err := stream.Recv()
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		if _, err = w.Write(append(status.GetData(), '\n')); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if status, err = stream.Recv(); err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the status code is already sent, report the failure as the last line of the stream
+			log.Err(err).Str("branch", chi.URLParam(r, "branch")).Msg("branch merge failed")
+			e := api.FromStatusError(err)
+			data, _ := jsoniter.Marshal(map[string]any{
+				"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+			})
+			_, _ = w.Write(append(data, '\n'))
+			return
+		}
+	}
+}
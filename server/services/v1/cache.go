@@ -22,6 +22,7 @@ import (
 	"github.com/fullstorydev/grpchan/inprocgrpc"
 	"github.com/go-chi/chi/v5"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	jsoniter "github.com/json-iterator/go"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/metadata"
@@ -29,11 +30,15 @@ import (
 	"github.com/tigrisdata/tigris/server/services/v1/cache"
 	"github.com/tigrisdata/tigris/server/transaction"
 	cache2 "github.com/tigrisdata/tigris/store/cache"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc"
 )
 
 const (
 	cachePattern = "/" + version + "/projects/*"
+
+	cacheKeyPath  = "/" + version + "/projects/{project}/caches/{name}/{key}"
+	cacheScanPath = "/" + version + "/projects/{project}/caches/{name}/scan"
 )
 
 type cacheService struct {
@@ -144,6 +149,81 @@ func (c *cacheService) Keys(req *api.KeysRequest, streaming api.Cache_KeysServer
 	return err
 }
 
+// Incr adds the value of the request to the integer value of the key.
+func (c *cacheService) Incr(ctx context.Context, req *api.SetRequest) (*httpbody.HttpBody, error) {
+	return c.incr(ctx, req, false)
+}
+
+// Decr subtracts the value of the request from the integer value of the key.
+func (c *cacheService) Decr(ctx context.Context, req *api.SetRequest) (*httpbody.HttpBody, error) {
+	return c.incr(ctx, req, true)
+}
+
+func (c *cacheService) incr(ctx context.Context, req *api.SetRequest, decrement bool) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+	resp, err := c.sessions.Execute(ctx, c.runnerFactory.GetIncrRunner(req, decrement, accessToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonHttpBody(&api.IncrResponse{Value: resp.Value})
+}
+
+// Expire sets the TTL of the key to the ex or px of the request.
+func (c *cacheService) Expire(ctx context.Context, req *api.SetRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+	resp, err := c.sessions.Execute(ctx, c.runnerFactory.GetExpireRunner(req, accessToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonHttpBody(&api.ExpireResponse{Status: resp.Status})
+}
+
+// GetDel returns the value of the key and deletes it.
+func (c *cacheService) GetDel(ctx context.Context, req *api.GetRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+	resp, err := c.sessions.Execute(ctx, c.runnerFactory.GetGetDelRunner(req, accessToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonHttpBody(&api.GetDelResponse{Value: resp.Data})
+}
+
+// CompareAndSwap sets the value of the key if it has the value of the expected header of the request.
+func (c *cacheService) CompareAndSwap(ctx context.Context, req *api.GetSetRequest) (*httpbody.HttpBody, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+	runner := c.runnerFactory.GetCompareAndSwapRunner(req, request.GetCacheExpected(ctx), accessToken)
+	resp, err := c.sessions.Execute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonHttpBody(&api.CompareAndSwapResponse{Swapped: resp.Swapped, OldValue: resp.OldValue})
+}
+
+// CacheKeys streams the keys of the cache matching the pattern of the request, or their count estimate.
+func (c *cacheService) CacheKeys(req *api.KeysRequest, streaming api.CacheOps_CacheKeysServer) error {
+	accessToken, _ := request.GetAccessToken(streaming.Context())
+	estimate, err := request.GetCacheCountEstimate(streaming.Context())
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sessions.Execute(streaming.Context(), c.runnerFactory.GetCacheKeysRunner(req, estimate, accessToken, streaming))
+	return err
+}
+
+func jsonHttpBody(resp any) (*httpbody.HttpBody, error) {
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: data}, nil
+}
+
 func (c *cacheService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channel) error {
 	mux := runtime.NewServeMux(
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, &api.CustomMarshaler{JSONBuiltin: &runtime.JSONBuiltin{}}),
@@ -153,6 +233,16 @@ func (c *cacheService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channe
 	}
 
 	api.RegisterCacheServer(inproc, c)
+	api.RegisterCacheOpsServer(inproc, c)
+
+	ops := cache.NewHandler(api.NewCacheOpsClient(inproc))
+	router.Post(cacheKeyPath+"/incr", ops.Incr)
+	router.Post(cacheKeyPath+"/decr", ops.Decr)
+	router.Post(cacheKeyPath+"/expire", ops.Expire)
+	router.Post(cacheKeyPath+"/getdel", ops.GetDel)
+	router.Post(cacheKeyPath+"/cas", ops.CompareAndSwap)
+	router.Get(cacheScanPath, ops.CacheKeys)
+
 	router.HandleFunc(cachePattern, func(w http.ResponseWriter, r *http.Request) {
 		mux.ServeHTTP(w, r)
 	})
@@ -161,5 +251,6 @@ func (c *cacheService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channe
 
 func (c *cacheService) RegisterGRPC(grpc *grpc.Server) error {
 	api.RegisterCacheServer(grpc, c)
+	api.RegisterCacheOpsServer(grpc, c)
 	return nil
 }
@@ -17,6 +17,7 @@ package cache
 import (
 	apiErrors "github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/cache"
 )
 
 // createApiError helps construct API errors from internal errors.
@@ -29,6 +30,15 @@ func createApiError(err error) error {
 		case metadata.ErrCodeCacheExists:
 			return apiErrors.AlreadyExists(e.Error())
 		}
+	case cache.Error:
+		switch err {
+		case cache.ErrKeyNotFound:
+			return apiErrors.NotFound(e.Error())
+		case cache.ErrValueNotInteger, cache.ErrValueOverflow:
+			return apiErrors.FailedPrecondition(e.Error())
+		case cache.ErrConcurrentUpdate:
+			return apiErrors.Aborted(e.Error())
+		}
 	default:
 		return err
 	}
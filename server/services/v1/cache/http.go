@@ -0,0 +1,220 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// Handler serves the HTTP variant of the CacheOps APIs on the key of the path. The request bodies are:
+//
+//	incr, decr: {"value": 5}
+//	expire:     {"ex": 60} or {"px": 60000}
+//	getdel:     empty
+//	cas:        {"value": {...}, "expected": {...}}
+//
+// The value of incr and decr is 1 if it isn't set. The key of a cas without an expected value is expected not to
+// exist. The scan of the keys of a cache takes the "pattern", "cursor", "count" and "estimate" query parameters and
+// responds with a NDJSON stream of the pages of the keys.
+type Handler struct {
+	client api.CacheOpsClient
+}
+
+func NewHandler(client api.CacheOpsClient) *Handler {
+	return &Handler{client: client}
+}
+
+type opsRequest struct {
+	Value    jsoniter.RawMessage `json:"value"`
+	Expected jsoniter.RawMessage `json:"expected"`
+	Ex       uint64              `json:"ex"`
+	Px       uint64              `json:"px"`
+}
+
+func (h *Handler) Incr(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *opsRequest) (*httpbody.HttpBody, error) {
+		return h.client.Incr(ctx, setRequest(r, req))
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) Decr(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *opsRequest) (*httpbody.HttpBody, error) {
+		return h.client.Decr(ctx, setRequest(r, req))
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) Expire(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *opsRequest) (*httpbody.HttpBody, error) {
+		return h.client.Expire(ctx, setRequest(r, req))
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) GetDel(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, _ *opsRequest) (*httpbody.HttpBody, error) {
+		return h.client.GetDel(ctx, &api.GetRequest{
+			Project: chi.URLParam(r, "project"),
+			Name:    chi.URLParam(r, "name"),
+			Key:     chi.URLParam(r, "key"),
+		})
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) CompareAndSwap(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *opsRequest) (*httpbody.HttpBody, error) {
+		if len(req.Expected) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderCacheExpected, string(req.Expected))
+		}
+
+		return h.client.CompareAndSwap(ctx, &api.GetSetRequest{
+			Project: chi.URLParam(r, "project"),
+			Name:    chi.URLParam(r, "name"),
+			Key:     chi.URLParam(r, "key"),
+			Value:   req.Value,
+		})
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) CacheKeys(w http.ResponseWriter, r *http.Request) {
+	req := &api.KeysRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "name"),
+	}
+	if pattern := r.URL.Query().Get("pattern"); pattern != "" {
+		req.Pattern = &pattern
+	}
+
+	var err error
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		var cursor uint64
+		if cursor, err = strconv.ParseUint(value, 10, 64); err != nil {
+			write(w, nil, errors.InvalidArgument("invalid cursor '%s'", value))
+			return
+		}
+		req.Cursor = &cursor
+	}
+	if value := r.URL.Query().Get("count"); value != "" {
+		var count int64
+		if count, err = strconv.ParseInt(value, 10, 64); err != nil {
+			write(w, nil, errors.InvalidArgument("invalid count '%s'", value))
+			return
+		}
+		req.Count = &count
+	}
+
+	ctx := common.OutgoingContext(r)
+	if estimate := r.URL.Query().Get("estimate"); estimate != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderCacheCountEstimate, estimate)
+	}
+
+	stream, err := h.client.CacheKeys(ctx, req)
+	if err != nil {
+		write(w, nil, err)
+		return
+	}
+
+	// wait for the first page, so that the errors detected before the scan starts are returned with their status code
+	page, err := stream.Recv()
+	if err != nil {
+		write(w, nil, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		if _, err = w.Write(append(page.GetData(), '\n')); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if page, err = stream.Recv(); err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the status code is already sent, report the failure as the last line of the stream
+			log.Err(err).Str("cache", req.Name).Msg("cache keys scan failed")
+			e := api.FromStatusError(err)
+			data, _ := jsoniter.Marshal(map[string]any{
+				"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+			})
+			_, _ = w.Write(append(data, '\n'))
+			return
+		}
+	}
+}
+
+func setRequest(r *http.Request, req *opsRequest) *api.SetRequest {
+	return &api.SetRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "name"),
+		Key:     chi.URLParam(r, "key"),
+		Value:   req.Value,
+		Ex:      req.Ex,
+		Px:      req.Px,
+	}
+}
+
+func (*Handler) serve(r *http.Request, fn func(context.Context, *opsRequest) (*httpbody.HttpBody, error)) (*httpbody.HttpBody, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req opsRequest
+	if len(body) > 0 {
+		if err = jsoniter.Unmarshal(body, &req); err != nil {
+			return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+		}
+	}
+
+	return fn(common.OutgoingContext(r), &req)
+}
+
+func write(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
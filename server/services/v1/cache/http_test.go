@@ -0,0 +1,240 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	set    *api.SetRequest
+	get    *api.GetRequest
+	getSet *api.GetSetRequest
+	keys   *api.KeysRequest
+	pages  []string
+	op     string
+	md     metadata.MD
+	err    error
+}
+
+type testKeysStream struct {
+	grpc.ClientStream
+
+	pages []string
+	err   error
+}
+
+func (s *testKeysStream) Recv() (*httpbody.HttpBody, error) {
+	if len(s.pages) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+
+	page := s.pages[0]
+	s.pages = s.pages[1:]
+	return &httpbody.HttpBody{ContentType: "application/json", Data: []byte(page)}, nil
+}
+
+func (c *testClient) response(ctx context.Context, op string, data string) (*httpbody.HttpBody, error) {
+	c.op = op
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: []byte(data)}, nil
+}
+
+func (c *testClient) Incr(ctx context.Context, in *api.SetRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.set = in
+	return c.response(ctx, "incr", `{"value":6}`)
+}
+
+func (c *testClient) Decr(ctx context.Context, in *api.SetRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.set = in
+	return c.response(ctx, "decr", `{"value":4}`)
+}
+
+func (c *testClient) Expire(ctx context.Context, in *api.SetRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.set = in
+	return c.response(ctx, "expire", `{"status":"updated"}`)
+}
+
+func (c *testClient) GetDel(ctx context.Context, in *api.GetRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.get = in
+	return c.response(ctx, "getdel", `{"value":{"a":1}}`)
+}
+
+func (c *testClient) CompareAndSwap(ctx context.Context, in *api.GetSetRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.getSet = in
+	return c.response(ctx, "cas", `{"swapped":true,"old_value":{"a":1}}`)
+}
+
+func (c *testClient) CacheKeys(ctx context.Context, in *api.KeysRequest, _ ...grpc.CallOption) (api.CacheOps_CacheKeysClient, error) {
+	c.keys = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	return &testKeysStream{pages: c.pages, err: c.err}, nil
+}
+
+func scan(client *testClient, query string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Get("/v1/projects/{project}/caches/{name}/scan", NewHandler(client).CacheKeys)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/projects/p1/caches/c1/scan?"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func serve(client *testClient, op string, body string) *httptest.ResponseRecorder {
+	h := NewHandler(client)
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/caches/{name}/{key}/incr", h.Incr)
+	router.Post("/v1/projects/{project}/caches/{name}/{key}/decr", h.Decr)
+	router.Post("/v1/projects/{project}/caches/{name}/{key}/expire", h.Expire)
+	router.Post("/v1/projects/{project}/caches/{name}/{key}/getdel", h.GetDel)
+	router.Post("/v1/projects/{project}/caches/{name}/{key}/cas", h.CompareAndSwap)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/caches/c1/k1/"+op, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestIncrDecrExpire(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, "incr", `{"value": 5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"value":6}`, w.Body.String())
+	require.Equal(t, "p1", client.set.Project)
+	require.Equal(t, "c1", client.set.Name)
+	require.Equal(t, "k1", client.set.Key)
+	require.Equal(t, "5", string(client.set.Value))
+
+	w = serve(client, "decr", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "decr", client.op)
+	require.Empty(t, client.set.Value)
+
+	w = serve(client, "expire", `{"px": 1500}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, uint64(1500), client.set.Px)
+
+	w = serve(client, "incr", `{"value": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetDelCompareAndSwap(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, "getdel", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"value":{"a":1}}`, w.Body.String())
+	require.Equal(t, "k1", client.get.Key)
+
+	w = serve(client, "cas", `{"value": {"a": 2}, "expected": {"a": 1}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"a": 2}`, string(client.getSet.Value))
+	require.Equal(t, []string{`{"a": 1}`}, client.md.Get(api.HeaderCacheExpected))
+
+	// the key is expected not to exist without an expected value
+	w = serve(client, "cas", `{"value": {"a": 2}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, client.md.Get(api.HeaderCacheExpected))
+
+	client.err = errors.NotFound("key not found")
+	w = serve(client, "getdel", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"error": {"code": "NOT_FOUND", "message": "key not found"}}`, w.Body.String())
+}
+
+func TestIncrDelta(t *testing.T) {
+	delta, err := incrDelta(nil, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), delta)
+
+	delta, err = incrDelta([]byte(" 7 "), true)
+	require.NoError(t, err)
+	require.Equal(t, int64(-7), delta)
+
+	_, err = incrDelta([]byte(`"7"`), false)
+	require.Equal(t, errors.InvalidArgument("invalid value '%s', expecting an integer", `"7"`), err)
+
+	_, err = incrDelta([]byte("-9223372036854775808"), true)
+	require.Error(t, err)
+
+	delta, err = incrDelta([]byte("-9223372036854775808"), false)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MinInt64), delta)
+}
+
+func TestExpireTTL(t *testing.T) {
+	require.Equal(t, 10*time.Second, expireTTL(&api.SetRequest{Ex: 10, Px: 500}))
+	require.Equal(t, 500*time.Millisecond, expireTTL(&api.SetRequest{Px: 500}))
+	require.Equal(t, time.Duration(0), expireTTL(&api.SetRequest{}))
+}
+
+func TestCacheKeys(t *testing.T) {
+	client := &testClient{pages: []string{`{"keys":["k1","k2"],"cursor":12}`, `{"keys":["k3"],"cursor":0}`}}
+	w := scan(client, "pattern=k*&cursor=3&count=50")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	require.Equal(t, `{"keys":["k1","k2"],"cursor":12}`+"\n"+`{"keys":["k3"],"cursor":0}`+"\n", w.Body.String())
+	require.Equal(t, "p1", client.keys.Project)
+	require.Equal(t, "c1", client.keys.Name)
+	require.Equal(t, "k*", client.keys.GetPattern())
+	require.Equal(t, uint64(3), client.keys.GetCursor())
+	require.Equal(t, int64(50), client.keys.GetCount())
+	require.Empty(t, client.md.Get(api.HeaderCacheCountEstimate))
+
+	client = &testClient{pages: []string{`{"cursor":0,"count":42,"exact":true}`}}
+	w = scan(client, "estimate=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"true"}, client.md.Get(api.HeaderCacheCountEstimate))
+
+	w = scan(client, "cursor=abc")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// an error after the first page is the last line of the stream
+	client = &testClient{pages: []string{`{"keys":["k1"],"cursor":12}`}, err: errors.Internal("scan failed")}
+	w = scan(client, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{"keys":["k1"],"cursor":12}`, lines[0])
+	require.JSONEq(t, `{"error": {"code": "INTERNAL", "message": "scan failed"}}`, lines[1])
+
+	client = &testClient{err: errors.NotFound("cache not found")}
+	w = scan(client, "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -20,6 +20,7 @@ const (
 	SetStatus     string = "set"
 	DeletedStatus string = "deleted"
 	CreatedStatus string = "created"
+	UpdatedStatus string = "updated"
 )
 
 // Response is a wrapper on api.Response.
@@ -32,9 +33,16 @@ type Response struct {
 	DeletedCount int64
 	Caches       []*api.CacheMetadata
 	Cursor       uint64
+	Value        int64
+	Swapped      bool
 }
 
 // StreamingKeys is a wrapper interface for passing around for streaming cache keys.
 type StreamingKeys interface {
 	api.Cache_KeysServer
 }
+
+// StreamingCacheKeys is the stream of the pages of the keys of the CacheKeys API.
+type StreamingCacheKeys interface {
+	api.CacheOps_CacheKeysServer
+}
@@ -15,8 +15,13 @@
 package cache
 
 import (
+	"bytes"
 	"context"
+	"math"
+	"strconv"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
@@ -29,6 +34,7 @@ import (
 	"github.com/tigrisdata/tigris/server/types"
 	"github.com/tigrisdata/tigris/store/cache"
 	ulog "github.com/tigrisdata/tigris/util/log"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 )
 
 // Runner is responsible for executing the current query and return the response.
@@ -97,6 +103,40 @@ type DelRunner struct {
 	req *api.DelRequest
 }
 
+type IncrRunner struct {
+	*BaseRunner
+
+	req       *api.SetRequest
+	decrement bool
+}
+
+type ExpireRunner struct {
+	*BaseRunner
+
+	req *api.SetRequest
+}
+
+type GetDelRunner struct {
+	*BaseRunner
+
+	req *api.GetRequest
+}
+
+type CompareAndSwapRunner struct {
+	*BaseRunner
+
+	req      *api.GetSetRequest
+	expected []byte
+}
+
+type CacheKeysRunner struct {
+	*BaseRunner
+
+	req       *api.KeysRequest
+	estimate  bool
+	streaming StreamingCacheKeys
+}
+
 type KeysRunner struct {
 	*BaseRunner
 	req       *api.KeysRequest
@@ -165,6 +205,45 @@ func (f *RunnerFactory) GetDelRunner(r *api.DelRequest, accessToken *types.Acces
 	}
 }
 
+func (f *RunnerFactory) GetIncrRunner(r *api.SetRequest, decrement bool, accessToken *types.AccessToken) *IncrRunner {
+	return &IncrRunner{
+		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
+		req:        r,
+		decrement:  decrement,
+	}
+}
+
+func (f *RunnerFactory) GetExpireRunner(r *api.SetRequest, accessToken *types.AccessToken) *ExpireRunner {
+	return &ExpireRunner{
+		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
+		req:        r,
+	}
+}
+
+func (f *RunnerFactory) GetGetDelRunner(r *api.GetRequest, accessToken *types.AccessToken) *GetDelRunner {
+	return &GetDelRunner{
+		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
+		req:        r,
+	}
+}
+
+func (f *RunnerFactory) GetCompareAndSwapRunner(r *api.GetSetRequest, expected []byte, accessToken *types.AccessToken) *CompareAndSwapRunner {
+	return &CompareAndSwapRunner{
+		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
+		req:        r,
+		expected:   expected,
+	}
+}
+
+func (f *RunnerFactory) GetCacheKeysRunner(r *api.KeysRequest, estimate bool, accessToken *types.AccessToken, streaming StreamingCacheKeys) *CacheKeysRunner {
+	return &CacheKeysRunner{
+		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
+		req:        r,
+		estimate:   estimate,
+		streaming:  streaming,
+	}
+}
+
 func (f *RunnerFactory) GetKeysRunner(r *api.KeysRequest, accessToken *types.AccessToken, streaming StreamingKeys) *KeysRunner {
 	return &KeysRunner{
 		BaseRunner: NewBaseRunner(f.encoder, accessToken, f.cacheStore),
@@ -318,6 +397,112 @@ func (runner *DelRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Resp
 	}, nil
 }
 
+// incrDelta returns the integer the value of an Incr or Decr request adds to the key, 1 if the value is empty.
+func incrDelta(value []byte, decrement bool) (int64, error) {
+	delta := int64(1)
+	if value = bytes.TrimSpace(value); len(value) > 0 {
+		var err error
+		if delta, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return 0, errors.InvalidArgument("invalid value '%s', expecting an integer", value)
+		}
+	}
+
+	if decrement {
+		if delta == math.MinInt64 {
+			return 0, errors.InvalidArgument("invalid value '%s', the decrement would overflow", value)
+		}
+		delta = -delta
+	}
+
+	return delta, nil
+}
+
+func (runner *IncrRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
+	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
+	if err != nil {
+		return Response{}, err
+	}
+
+	delta, err := incrDelta(runner.req.GetValue(), runner.decrement)
+	if err != nil {
+		return Response{}, err
+	}
+
+	value, err := runner.cacheStore.IncrBy(ctx, tableName, runner.req.GetKey(), delta)
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	return Response{
+		Value: value,
+	}, nil
+}
+
+// expireTTL returns the TTL of an Expire request, ex takes precedence over px like in the Set requests.
+func expireTTL(req *api.SetRequest) time.Duration {
+	if req.GetEx() > 0 {
+		return time.Duration(req.GetEx()) * time.Second
+	}
+
+	return time.Duration(req.GetPx()) * time.Millisecond
+}
+
+func (runner *ExpireRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
+	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err = runner.cacheStore.Expire(ctx, tableName, runner.req.GetKey(), expireTTL(runner.req)); err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	return Response{
+		Status: UpdatedStatus,
+	}, nil
+}
+
+func (runner *GetDelRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
+	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
+	if err != nil {
+		return Response{}, err
+	}
+
+	data, err := runner.cacheStore.Get(ctx, tableName, runner.req.GetKey(), &cache.GetOptions{GetDelete: true})
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	return Response{
+		Status: DeletedStatus,
+		Data:   data.GetRawData(),
+	}, nil
+}
+
+func (runner *CompareAndSwapRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
+	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
+	if err != nil {
+		return Response{}, err
+	}
+
+	oldVal, swapped, err := runner.cacheStore.CompareAndSwap(ctx, tableName, runner.req.GetKey(), runner.expected,
+		internal.NewCacheData(runner.req.GetValue()))
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	result := Response{
+		Swapped: swapped,
+	}
+	if swapped {
+		result.Status = SetStatus
+	}
+	if oldVal != nil && oldVal.RawData != nil {
+		result.OldValue = oldVal.GetRawData()
+	}
+	return result, nil
+}
+
 func (runner *KeysRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
 	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
 	if err != nil {
@@ -331,7 +516,9 @@ func (runner *KeysRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Res
 	cursor := runner.req.GetCursor()
 	var internalKeys []string
 	for {
-		internalKeys, cursor = runner.cacheStore.Scan(ctx, tableName, cursor, runner.req.GetCount(), pattern)
+		if internalKeys, cursor, err = runner.cacheStore.Scan(ctx, tableName, cursor, runner.req.GetCount(), pattern); err != nil {
+			return Response{}, err
+		}
 
 		// transform internal keys to user facing keys
 		userKeys := make([]string, len(internalKeys))
@@ -354,6 +541,70 @@ func (runner *KeysRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Res
 	return Response{}, nil
 }
 
+func (runner *CacheKeysRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
+	tableName, err := getEncodedCacheTableName(ctx, tenant, runner.req.GetProject(), runner.req.GetName(), runner.encoder)
+	if err != nil {
+		return Response{}, err
+	}
+
+	pattern := runner.req.GetPattern()
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	if runner.estimate {
+		count, exact, err := runner.cacheStore.EstimateKeys(ctx, tableName, pattern, config.DefaultConfig.Cache.EstimateMaxScanned)
+		if err != nil {
+			return Response{}, err
+		}
+
+		return Response{}, runner.send(&api.CacheKeysResponse{Count: &count, Exact: exact})
+	}
+
+	count := runner.req.GetCount()
+	if count <= 0 {
+		count = config.DefaultConfig.Cache.MaxScan
+	}
+
+	// the keyspace is scanned a page at a time as the pages are sent, the listing stops if the client goes away
+	var internalKeys []string
+	cursor := runner.req.GetCursor()
+	for {
+		if err = ctx.Err(); err != nil {
+			return Response{}, err
+		}
+
+		if internalKeys, cursor, err = runner.cacheStore.Scan(ctx, tableName, cursor, count, pattern); err != nil {
+			return Response{}, err
+		}
+
+		// the scan returns empty pages of the keys of the other caches, only the last one is sent to end the listing
+		if len(internalKeys) > 0 || cursor == 0 {
+			userKeys := make([]string, len(internalKeys))
+			for i, internalKey := range internalKeys {
+				userKeys[i] = runner.encoder.DecodeInternalCacheKeyNameToExternal(internalKey)
+			}
+
+			if err = runner.send(&api.CacheKeysResponse{Keys: userKeys, Cursor: cursor}); err != nil {
+				return Response{}, err
+			}
+		}
+
+		if cursor == 0 {
+			return Response{}, nil
+		}
+	}
+}
+
+func (runner *CacheKeysRunner) send(resp *api.CacheKeysResponse) error {
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return runner.streaming.Send(&httpbody.HttpBody{ContentType: "application/json", Data: data})
+}
+
 func getEncodedCacheTableName(_ context.Context, tenant *metadata.Tenant, projectName string, cacheName string, encoder metadata.CacheEncoder) (string, error) {
 	project, err := tenant.GetProject(projectName)
 	if err != nil {
@@ -0,0 +1,56 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// OutgoingContext forwards the authorization and the Tigris headers of the HTTP request to the API calls made by the
+// HTTP handlers of the services.
+func OutgoingContext(r *http.Request) context.Context {
+	return metadata.NewOutgoingContext(r.Context(), outgoingMetadata(r))
+}
+
+// OutgoingContextWithQuery is OutgoingContext which also forwards the query parameters of the request as the headers
+// they are mapped to, a header sent with the request takes precedence over the query parameter.
+func OutgoingContextWithQuery(r *http.Request, queryHeaders map[string]string) context.Context {
+	md := outgoingMetadata(r)
+	for param, header := range queryHeaders {
+		if value := r.URL.Query().Get(param); value != "" && len(md.Get(header)) == 0 {
+			md.Set(header, value)
+		}
+	}
+
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+func outgoingMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for k, values := range r.Header {
+		if strings.EqualFold(k, "Authorization") {
+			md.Append("authorization", values...)
+		} else if key, ok := api.CustomMatcher(k); ok {
+			md.Append(key, values...)
+		}
+	}
+
+	return md
+}
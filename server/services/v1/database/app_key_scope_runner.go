@@ -0,0 +1,171 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// AppKeyScopeRequest is used to manage the scope of an app key of a project.
+type AppKeyScopeRequest struct {
+	Project   string
+	Id        string
+	Grants    []metadata.Grant
+	ExpiresAt int64
+	// AllowedCIDRs restricts the client addresses the key is accepted from
+	AllowedCIDRs []string
+	// NewId and Overlap are set on the rollover, the scope of Id is copied to NewId and Id expires after Overlap
+	NewId   string
+	Overlap time.Duration
+}
+
+// AppKeyScopeQueryRunner manages the scopes of the app keys of a project.
+type AppKeyScopeQueryRunner struct {
+	*BaseQueryRunner
+
+	setReq      *AppKeyScopeRequest
+	getReq      *AppKeyScopeRequest
+	deleteReq   *AppKeyScopeRequest
+	rolloverReq *AppKeyScopeRequest
+
+	scope *metadata.AppKeyScope
+}
+
+func (runner *AppKeyScopeQueryRunner) SetSetScopeReq(req *AppKeyScopeRequest) {
+	runner.setReq = req
+}
+
+func (runner *AppKeyScopeQueryRunner) SetGetScopeReq(req *AppKeyScopeRequest) {
+	runner.getReq = req
+}
+
+func (runner *AppKeyScopeQueryRunner) SetDeleteScopeReq(req *AppKeyScopeRequest) {
+	runner.deleteReq = req
+}
+
+func (runner *AppKeyScopeQueryRunner) SetRolloverReq(req *AppKeyScopeRequest) {
+	runner.rolloverReq = req
+}
+
+// Scope returns the scope read or written by the last run, for the rollover it is the scope of the old key.
+func (runner *AppKeyScopeQueryRunner) Scope() *metadata.AppKeyScope {
+	return runner.scope
+}
+
+func (runner *AppKeyScopeQueryRunner) currentSub() string {
+	if runner.accessToken != nil {
+		return runner.accessToken.Sub
+	}
+
+	return ""
+}
+
+func (runner *AppKeyScopeQueryRunner) set(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.setReq
+	if _, err := tenant.GetProject(req.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	scope := &metadata.AppKeyScope{Id: req.Id, Grants: req.Grants, ExpiresAt: req.ExpiresAt, AllowedCIDRs: req.AllowedCIDRs}
+	if err := metadata.ValidateAppKeyScope(scope); err != nil {
+		return Response{}, ctx, err
+	}
+
+	if err := tenant.SetAppKeyScope(ctx, tx, req.Project, scope, runner.currentSub()); err != nil {
+		return Response{}, ctx, err
+	}
+	runner.scope = scope
+
+	return Response{Status: UpdatedStatus}, ctx, nil
+}
+
+func (runner *AppKeyScopeQueryRunner) get(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if _, err := tenant.GetProject(runner.getReq.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	scope, err := tenant.GetAppKeyScope(ctx, tx, runner.getReq.Project, runner.getReq.Id)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if scope == nil {
+		return Response{}, ctx, errors.NotFound("app key scope not found '%s'", runner.getReq.Id)
+	}
+	runner.scope = scope
+
+	return Response{}, ctx, nil
+}
+
+func (runner *AppKeyScopeQueryRunner) delete(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if err := tenant.DeleteAppKeyScope(ctx, tx, runner.deleteReq.Project, runner.deleteReq.Id); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+// rollover copies the scope of the old key to the new key and makes the old key expire after the overlap, so the
+// clients can switch to the new key while the old one keeps working.
+func (runner *AppKeyScopeQueryRunner) rollover(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.rolloverReq
+
+	old, err := tenant.GetAppKeyScope(ctx, tx, req.Project, req.Id)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	expiresAt := time.Now().Add(req.Overlap).Unix()
+	if old == nil {
+		old = &metadata.AppKeyScope{Id: req.Id}
+	} else if old.ExpiresAt != 0 && old.ExpiresAt < expiresAt {
+		expiresAt = old.ExpiresAt
+	}
+
+	// the new key keeps the grants, the allowlist and the original expiry of the old key
+	newScope := &metadata.AppKeyScope{Id: req.NewId, Grants: old.Grants, ExpiresAt: old.ExpiresAt, AllowedCIDRs: old.AllowedCIDRs}
+	if newScope.ExpiresAt != 0 || len(newScope.Grants) > 0 || len(newScope.AllowedCIDRs) > 0 {
+		if err = tenant.SetAppKeyScope(ctx, tx, req.Project, newScope, runner.currentSub()); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	oldScope := &metadata.AppKeyScope{Id: req.Id, Grants: old.Grants, ExpiresAt: expiresAt, AllowedCIDRs: old.AllowedCIDRs}
+	if err = tenant.SetAppKeyScope(ctx, tx, req.Project, oldScope, runner.currentSub()); err != nil {
+		return Response{}, ctx, err
+	}
+	runner.scope = oldScope
+
+	return Response{Status: UpdatedStatus}, ctx, nil
+}
+
+func (runner *AppKeyScopeQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	switch {
+	case runner.setReq != nil:
+		return runner.set(ctx, tx, tenant)
+	case runner.getReq != nil:
+		return runner.get(ctx, tx, tenant)
+	case runner.deleteReq != nil:
+		return runner.delete(ctx, tx, tenant)
+	case runner.rolloverReq != nil:
+		return runner.rollover(ctx, tx, tenant)
+	}
+
+	return Response{}, ctx, errors.Unknown("unknown request path")
+}
@@ -0,0 +1,249 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"io"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// restoreBatchBytes bounds the size of the documents restored in a transaction.
+const restoreBatchBytes = 1024 * 1024
+
+// BackupRunner writes the collections of a branch to a backup archive, the manifest with the schemas and the indexes
+// of the collections first and then their documents. The encrypted fields are decrypted, the restore encrypts them
+// again with the key of the target.
+//
+// All the documents are read at the same version of the store, so the archive is a consistent snapshot of the branch.
+// The snapshot is only readable within the MVCC window of the store, a branch that can't be read within the window
+// fails the backup with FailedPrecondition.
+type BackupRunner struct {
+	*BaseQueryRunner
+
+	req     *api.DescribeDatabaseRequest
+	archive *backup.Writer
+	job     *backup.Job
+}
+
+func (runner *BackupRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	manifest := &backup.Manifest{
+		Project:   runner.req.GetProject(),
+		Branch:    db.BranchName(),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	names := collectionNames(db)
+	for _, name := range names {
+		manifest.Collections = append(manifest.Collections, newCollectionManifest(db.GetCollection(name)))
+	}
+	if err = runner.archive.WriteManifest(manifest); err != nil {
+		return Response{}, ctx, err
+	}
+
+	readCtx := kv.WithReadVersion(ctx)
+	differ := NewBranchDiffer(runner.txMgr, 0)
+	for _, name := range names {
+		coll := db.GetCollection(name)
+		runner.job.StartCollection(name)
+
+		cursor := differ.newCursor(coll)
+		for {
+			rows, err := cursor.take(readCtx)
+			if err == kv.ErrTransactionMaxDurationReached {
+				return Response{}, ctx, errors.FailedPrecondition("the snapshot of branch '%s' is no longer retained, "+
+					"the backup has to be read within the MVCC window of the store", manifest.Branch)
+			}
+			if err != nil {
+				return Response{}, ctx, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			for _, row := range rows {
+				doc := row.Data.RawData
+				if coll.HasEncryptedFields() {
+					if doc, err = coll.DecryptFields(doc); err != nil {
+						return Response{}, ctx, err
+					}
+				}
+				if err = runner.archive.WriteDocument(name, doc); err != nil {
+					return Response{}, ctx, err
+				}
+			}
+			runner.job.AddDocuments(int64(len(rows)))
+		}
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+func newCollectionManifest(coll *schema.DefaultCollection) *backup.CollectionManifest {
+	m := &backup.CollectionManifest{Name: coll.Name, Schema: coll.Schema}
+	if coll.SecondaryIndexes == nil {
+		return m
+	}
+
+	for _, index := range coll.SecondaryIndexes.All {
+		idx := &backup.IndexManifest{Name: index.Name}
+		for _, f := range index.Fields {
+			idx.Fields = append(idx.Fields, f.FieldName)
+		}
+		m.Indexes = append(m.Indexes, idx)
+	}
+
+	return m
+}
+
+// RestoreRunner restores a backup archive into a branch. The collections of the archive are created with their
+// schemas, which rebuilds their indexes as the documents are written, then the documents are written in batches, each
+// in its own transaction so that the search index of the branch is updated like for any other write.
+//
+// The branch must not have any of the collections of the archive, a restore is not merged with existing documents.
+// A restore failing midway leaves the collections and the batches restored so far in the branch.
+type RestoreRunner struct {
+	*BaseQueryRunner
+
+	req      *api.DescribeDatabaseRequest
+	archive  *backup.Reader
+	sessions Session
+	job      *backup.Job
+}
+
+func (runner *RestoreRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	project, branch := runner.req.GetProject(), runner.req.GetBranch()
+	db, err := runner.getDatabase(ctx, nil, tenant, project, branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	manifest := runner.archive.Manifest()
+	for _, c := range manifest.Collections {
+		if db.GetCollection(c.Name) != nil {
+			return Response{}, ctx, errors.FailedPrecondition("collection '%s' already exists in branch '%s' of project '%s', "+
+				"a backup can only be restored into a new project or branch", c.Name, db.BranchName(), project)
+		}
+	}
+
+	for _, c := range manifest.Collections {
+		collRunner := &CollectionQueryRunner{BaseQueryRunner: runner.BaseQueryRunner}
+		collRunner.SetCreateOrUpdateCollectionReq(&api.CreateOrUpdateCollectionRequest{
+			Project:    project,
+			Branch:     branch,
+			Collection: c.Name,
+			Schema:     c.Schema,
+			OnlyCreate: true,
+		})
+		if _, err = runner.sessions.Execute(ctx, collRunner, ReqOptions{
+			MetadataChange:     true,
+			InstantVerTracking: true,
+		}); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	if err = runner.restoreDocuments(ctx); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+// restoreDocuments writes the documents of the archive in batches of the documents of the same collection.
+func (runner *RestoreRunner) restoreDocuments(ctx context.Context) error {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	batch := &restoreBatchRunner{
+		BaseQueryRunner: runner.BaseQueryRunner,
+		project:         runner.req.GetProject(),
+		branch:          runner.req.GetBranch(),
+	}
+	flush := func() error {
+		if len(batch.documents) == 0 {
+			return nil
+		}
+		if _, err := runner.sessions.Execute(ctx, batch, ReqOptions{}); err != nil {
+			return err
+		}
+
+		runner.job.AddDocuments(int64(len(batch.documents)))
+		batch.documents, batch.size = batch.documents[:0], 0
+		return nil
+	}
+
+	for {
+		collection, doc, err := runner.archive.Next()
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		if collection != batch.collection || len(batch.documents) >= batchSize || batch.size+len(doc) > restoreBatchBytes {
+			if err = flush(); err != nil {
+				return err
+			}
+			if collection != batch.collection {
+				batch.collection = collection
+				runner.job.StartCollection(collection)
+			}
+		}
+
+		batch.documents = append(batch.documents, doc)
+		batch.size += len(doc)
+	}
+}
+
+// restoreBatchRunner writes a batch of the documents of an archive to a collection.
+type restoreBatchRunner struct {
+	*BaseQueryRunner
+
+	project    string
+	branch     string
+	collection string
+	documents  [][]byte
+	size       int
+}
+
+func (runner *restoreBatchRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, coll, err := runner.getDBAndCollection(ctx, tx, tenant, runner.project, runner.collection, runner.branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	if _, _, err = runner.insertOrReplace(ctx, tx, tenant, db, coll, runner.documents, false, nil); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
@@ -119,9 +119,27 @@ func (runner *BaseQueryRunner) getDBAndCollection(ctx context.Context, tx transa
 }
 
 func (runner *BaseQueryRunner) insertOrReplace(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant,
-	coll *schema.DefaultCollection, documents [][]byte, insert bool,
+	db *metadata.Database, coll *schema.DefaultCollection, documents [][]byte, insert bool, images *documentImages,
 ) (*internal.Timestamp, [][]byte, error) {
-	var err error
+	precondition, err := newRevisionPrecondition(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the documents are compressed with the algorithm of the collection
+	ctx = kv.CtxWithCompression(ctx, coll.Compression)
+
+	hooks, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policy, err := newRowPolicyChecker(ctx, coll)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var revision int64
 	ts := internal.NewTimestamp()
 	allKeys := make([][]byte, 0, len(documents))
 	indexer := NewSecondaryIndexer(coll)
@@ -132,6 +150,22 @@ func (runner *BaseQueryRunner) insertOrReplace(ctx context.Context, tx transacti
 			return nil, nil, err
 		}
 
+		if doc, err = hooks.apply(ctx, doc); err != nil {
+			return nil, nil, err
+		}
+
+		if err = policy.check(doc); err != nil {
+			return nil, nil, err
+		}
+
+		if err = runner.checkReferences(ctx, tx, db, coll, doc); err != nil {
+			return nil, nil, err
+		}
+
+		if doc, err = coll.EncryptFields(doc); err != nil {
+			return nil, nil, err
+		}
+
 		keyGen := newKeyGenerator(doc, tenant.TableKeyGenerator, coll.GetPrimaryKey())
 		key, err := keyGen.generate(ctx, runner.txMgr, runner.encoder, coll.EncodedName)
 		if err != nil {
@@ -141,21 +175,31 @@ func (runner *BaseQueryRunner) insertOrReplace(ctx context.Context, tx transacti
 		// we need to use keyGen updated document as it may be mutated by adding auto-generated keys.
 		tableData := internal.NewTableDataWithTS(ts, nil, keyGen.document)
 		tableData.SetVersion(int32(coll.GetVersion()))
+		tableData.Revision = 1
 
+		var existing *internal.TableData
 		if insert || keyGen.forceInsert {
 			// we use Insert API, in case user is using autogenerated primary key and has primary key field
 			// as Int64 or timestamp to ensure uniqueness if multiple workers end up generating same timestamp.
 			err = tx.Insert(ctx, key, tableData)
 		} else {
-			szCtx := ctx
 			if config.DefaultConfig.SecondaryIndex.WriteEnabled {
-				sz, err := indexer.ReadDocAndDelete(ctx, tx, key)
-				if err != nil {
-					return nil, nil, err
-				}
-				szCtx = kv.CtxWithSize(ctx, sz)
+				existing, err = indexer.ReadDocAndDelete(ctx, tx, key)
+			} else {
+				existing, err = readDocument(ctx, tx, key)
 			}
-			err = tx.Replace(szCtx, key, tableData, false)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err = precondition.check(existing); err != nil {
+				return nil, nil, err
+			}
+			if err = policy.checkReplaced(existing); err != nil {
+				return nil, nil, err
+			}
+
+			tableData.Revision = nextRevision(existing)
+			err = tx.Replace(kv.CtxWithSize(ctx, int32(len(existing.GetRawData()))), key, tableData, false)
 		}
 		if err != nil {
 			return nil, nil, err
@@ -167,8 +211,18 @@ func (runner *BaseQueryRunner) insertOrReplace(ctx context.Context, tx transacti
 				return nil, nil, err
 			}
 		}
+		if err = images.add(existing, tableData); err != nil {
+			return nil, nil, err
+		}
+
 		allKeys = append(allKeys, keyGen.getKeysForResp())
+		revision = tableData.Revision
+	}
+
+	if len(documents) == 1 {
+		setRevisionHeader(ctx, revision)
 	}
+
 	return ts, allKeys, err
 }
 
@@ -204,10 +258,6 @@ func (*BaseQueryRunner) mutateAndValidatePayload(ctx context.Context, coll *sche
 func (*BaseQueryRunner) buildSecondaryIndexKeysUsingFilter(coll *schema.DefaultCollection,
 	reqFilter []byte, collation *value.Collation, sortFields *sort.Ordering,
 ) (*filter.QueryPlan, error) {
-	if sortFields != nil && len(*sortFields) > 1 {
-		return nil, errors.InvalidArgument("cannot use secondary index with multiple sort fields")
-	}
-
 	if filter.None(reqFilter) && sortFields == nil {
 		return nil, errors.InvalidArgument("cannot query on an empty filter")
 	}
@@ -216,7 +266,7 @@ func (*BaseQueryRunner) buildSecondaryIndexKeysUsingFilter(coll *schema.DefaultC
 		return nil, errors.InvalidArgument("secondary indexes do not support case insensitive collation")
 	}
 
-	filterFactory := filter.NewFactoryForSecondaryIndex(coll.GetActiveIndexedFields())
+	filterFactory := newSecondaryIndexFilterFactory(coll)
 	filters, err := filterFactory.Factorize(reqFilter)
 	if err != nil {
 		return nil, err
@@ -247,6 +297,12 @@ func (*BaseQueryRunner) getSearchOrdering(coll *schema.DefaultCollection, sortRe
 			(*ordering)[i].Name = cf.InMemoryName()
 		}
 
+		if sf.GeoDistance != nil {
+			if cf.DataType != schema.GeoPointType || !cf.SearchIndexed {
+				return nil, errors.InvalidArgument("`%s` sort is only supported on search indexed geopoint fields, found `%s` field", sort.GeoDistanceOP, sf.Name)
+			}
+			continue
+		}
 		if !cf.Sortable {
 			return nil, errors.InvalidArgument("Search results can't be sorted on `%s` field. Enable sorting on this field", sf.Name)
 		}
@@ -273,7 +329,7 @@ func (runner *BaseQueryRunner) getWriteIterator(ctx context.Context, tx transact
 ) (Iterator, error) {
 	reader := NewDatabaseReader(ctx, tx)
 
-	if config.DefaultConfig.SecondaryIndex.MutateEnabled {
+	if config.DefaultConfig.SecondaryIndex.MutateEnabled && secondaryIndexServesCollation(reqFilter, collation) {
 		if skIter, err := runner.getSecondaryWriterIterator(ctx, tx, collection, reqFilter, collation); err == nil {
 			metrics.SetWriteType("secondary")
 			return skIter, nil
@@ -319,7 +375,7 @@ func (runner *BaseQueryRunner) getSecondaryWriterIterator(ctx context.Context, t
 		return nil, err
 	}
 
-	filterFactory := filter.NewFactoryForSecondaryIndex(coll.GetActiveIndexedFields())
+	filterFactory := newSecondaryIndexFilterFactory(coll)
 	filters, err := filterFactory.Factorize(reqFilter)
 	if err != nil {
 		return nil, err
@@ -327,6 +383,35 @@ func (runner *BaseQueryRunner) getSecondaryWriterIterator(ctx context.Context, t
 	return NewSecondaryIndexReader(ctx, tx, coll, filter.NewWrappedFilter(filters), queryPlan)
 }
 
+// secondaryIndexServesCollation returns false if the read or a condition of the filter has a locale, accent insensitive
+// or numeric collation. The index keys are the sort keys of the default collation, so the order of the index doesn't
+// match such a collation and the read falls back to scanning the collection.
+func secondaryIndexServesCollation(reqFilter []byte, collation *value.Collation) bool {
+	if collation != nil && collation.HasOrderingOptions() {
+		return false
+	}
+
+	return !filter.HasOrderingCollation(reqFilter)
+}
+
+// newSecondaryIndexFilterFactory returns the filter factory for the fields of the active single field and composite
+// indexes.
+func newSecondaryIndexFilterFactory(coll *schema.DefaultCollection) *filter.Factory {
+	indexed := coll.GetActiveIndexedFields()
+	seen := make(map[string]struct{}, len(indexed))
+	for _, f := range indexed {
+		seen[f.FieldName] = struct{}{}
+	}
+	for _, f := range coll.GetActiveCompositeIndexedFields() {
+		if _, ok := seen[f.FieldName]; !ok {
+			seen[f.FieldName] = struct{}{}
+			indexed = append(indexed, f)
+		}
+	}
+
+	return filter.NewFactoryForSecondaryIndex(indexed)
+}
+
 func (*BaseQueryRunner) indexToCollectionIndex(all []*schema.Index) []*api.CollectionIndex {
 	indexes := make([]*api.CollectionIndex, len(all))
 	for i, index := range all {
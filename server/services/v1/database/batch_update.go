@@ -0,0 +1,125 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// UpdateBatch is a batch of an update run across multiple transactions. The batches scan the collection in the order
+// of the primary keys, each one resumes after the last document scanned by the previous one, so that the documents
+// which still match the filter once updated are not updated again.
+type UpdateBatch struct {
+	// After is the key of the last document scanned by the previous batch, nil for the first batch.
+	After []byte
+	// MaxScanned bounds the documents read by the batch, zero is unbounded.
+	MaxScanned int
+
+	// Last is the key of the last document scanned by the batch.
+	Last []byte
+	// Scanned is the number of the documents read by the batch.
+	Scanned int64
+	// Done is set if the batch reached the end of the collection.
+	Done bool
+}
+
+// SetBatch makes the runner update the documents of the batch instead of all the documents matching the filter. The
+// updates changing the primary keys are rejected, the documents would move after the position of the scan.
+func (runner *UpdateQueryRunner) SetBatch(batch *UpdateBatch) {
+	runner.batch = batch
+}
+
+// getBatchIterator returns the documents of the batch matching the filter.
+func (runner *UpdateQueryRunner) getBatchIterator(ctx context.Context, tx transaction.Tx, coll *schema.DefaultCollection,
+	collation *value.Collation,
+) (*batchScanIterator, Iterator, error) {
+	// the transaction of the batch may be retried
+	runner.batch.Last, runner.batch.Scanned, runner.batch.Done = nil, 0, false
+
+	from := keys.NewKey(coll.EncodedName)
+	if runner.batch.After != nil {
+		after, err := keys.FromBinary(coll.EncodedName, runner.batch.After)
+		if err != nil {
+			return nil, nil, err
+		}
+		from = after
+	}
+
+	reader := NewDatabaseReader(ctx, tx)
+	scan, err := reader.ScanIterator(from, nil, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filters, err := filter.NewFactory(coll.QueryableFields, collation).Factorize(runner.req.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batchScan := &batchScanIterator{Iterator: scan, batch: runner.batch}
+	iterator, err := reader.FilteredRead(batchScan, filter.NewWrappedFilter(filters))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runner.queryMetrics.SetWriteType("batch")
+	return batchScan, iterator, nil
+}
+
+// batchScanIterator records the position of the scan of a batch and stops it once it has read the maximum number of
+// the documents of the batch.
+type batchScanIterator struct {
+	Iterator
+
+	batch   *UpdateBatch
+	limited bool
+}
+
+func (it *batchScanIterator) Next(row *Row) bool {
+	for {
+		if it.batch.MaxScanned > 0 && it.batch.Scanned >= int64(it.batch.MaxScanned) {
+			it.limited = true
+			return false
+		}
+		if !it.Iterator.Next(row) {
+			return false
+		}
+
+		// the scan starts at the last document of the previous batch
+		if it.batch.After != nil && bytes.Equal(row.Key, it.batch.After) {
+			continue
+		}
+
+		it.batch.Scanned++
+		it.batch.Last = append(it.batch.Last[:0], row.Key...)
+		return true
+	}
+}
+
+// finish records whether the scan of the batch reached the end of the collection.
+func (it *batchScanIterator) finish(exhausted bool) {
+	it.batch.Done = exhausted && !it.limited && it.Interrupted() == nil
+	if it.batch.Last == nil {
+		// nothing is scanned, the next batch resumes at the same position
+		it.batch.Last = it.batch.After
+	}
+}
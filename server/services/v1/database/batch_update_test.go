@@ -0,0 +1,108 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rowsIterator returns the rows with the keys.
+type rowsIterator struct {
+	keys [][]byte
+}
+
+func (it *rowsIterator) Next(row *Row) bool {
+	if len(it.keys) == 0 {
+		return false
+	}
+
+	row.Key, it.keys = it.keys[0], it.keys[1:]
+	return true
+}
+
+func (*rowsIterator) Interrupted() error { return nil }
+
+func TestBatchScanIterator(t *testing.T) {
+	scan := func(batch *UpdateBatch, keys ...string) (*batchScanIterator, []string) {
+		rows := &rowsIterator{}
+		for _, k := range keys {
+			rows.keys = append(rows.keys, []byte(k))
+		}
+
+		it := &batchScanIterator{Iterator: rows, batch: batch}
+		var (
+			row     Row
+			scanned []string
+		)
+		for it.Next(&row) {
+			scanned = append(scanned, string(row.Key))
+		}
+
+		return it, scanned
+	}
+
+	t.Run("first_batch", func(t *testing.T) {
+		batch := &UpdateBatch{}
+		it, scanned := scan(batch, "a", "b", "c")
+		it.finish(true)
+
+		require.Equal(t, []string{"a", "b", "c"}, scanned)
+		require.Equal(t, int64(3), batch.Scanned)
+		require.Equal(t, []byte("c"), batch.Last)
+		require.True(t, batch.Done)
+	})
+
+	t.Run("resumes_after", func(t *testing.T) {
+		// the scan of the next batch starts at the last document of the previous one
+		batch := &UpdateBatch{After: []byte("b")}
+		it, scanned := scan(batch, "b", "c", "d")
+		it.finish(true)
+
+		require.Equal(t, []string{"c", "d"}, scanned)
+		require.Equal(t, int64(2), batch.Scanned)
+		require.Equal(t, []byte("d"), batch.Last)
+		require.True(t, batch.Done)
+	})
+
+	t.Run("max_scanned", func(t *testing.T) {
+		batch := &UpdateBatch{MaxScanned: 2}
+		it, scanned := scan(batch, "a", "b", "c")
+		it.finish(true)
+
+		require.Equal(t, []string{"a", "b"}, scanned)
+		require.Equal(t, []byte("b"), batch.Last)
+		require.False(t, batch.Done)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// the update stopped at its limit before the end of the scan
+		batch := &UpdateBatch{}
+		it, _ := scan(batch, "a")
+		it.finish(false)
+		require.False(t, batch.Done)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		batch := &UpdateBatch{After: []byte("z")}
+		it, scanned := scan(batch, "z")
+		it.finish(true)
+
+		require.Empty(t, scanned)
+		require.Equal(t, []byte("z"), batch.Last)
+		require.True(t, batch.Done)
+	})
+}
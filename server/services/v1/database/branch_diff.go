@@ -0,0 +1,332 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// BranchDiffer compares the documents of a collection in two branches. The collections of both branches are scanned
+// in the primary key order and merged, the documents are matched by their primary key, which is the part of the key
+// following the table name.
+//
+// Each batch is read in its own transaction, so the diff doesn't hold a long transaction, but it is not a snapshot of
+// the branches, the writes committed during the diff may or may not be seen.
+type BranchDiffer struct {
+	txMgr     *transaction.Manager
+	sample    int
+	batchSize int
+	// modifiedOnly samples only the modified documents, the conflicts of a merge.
+	modifiedOnly bool
+}
+
+func NewBranchDiffer(txMgr *transaction.Manager, sample int) *BranchDiffer {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	return &BranchDiffer{
+		txMgr:     txMgr,
+		sample:    sample,
+		batchSize: batchSize,
+	}
+}
+
+// Diff compares the base and the branch collections, either of them is nil if the collection doesn't exist in that
+// branch.
+func (d *BranchDiffer) Diff(ctx context.Context, base *schema.DefaultCollection, branch *schema.DefaultCollection) (*api.CollectionDiff, error) {
+	diff := &api.CollectionDiff{}
+	switch {
+	case base == nil:
+		diff.Collection, diff.Schema = branch.Name, api.BranchDiffAdded
+	case branch == nil:
+		diff.Collection, diff.Schema = base.Name, api.BranchDiffDeleted
+	default:
+		diff.Collection, diff.Schema = base.Name, api.BranchDiffUnchanged
+		if !equalJSON(base.Schema, branch.Schema) {
+			diff.Schema = api.BranchDiffModified
+			diff.BaseSchema, diff.BranchSchema = base.Schema, branch.Schema
+		}
+	}
+
+	baseDocs, branchDocs := d.newCursor(base), d.newCursor(branch)
+	for {
+		baseRow, err := baseDocs.peek(ctx)
+		if err != nil {
+			return nil, err
+		}
+		branchRow, err := branchDocs.peek(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if baseRow == nil && branchRow == nil {
+			return diff, nil
+		}
+
+		var cmp int
+		switch {
+		case baseRow == nil:
+			cmp = 1
+		case branchRow == nil:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(baseDocs.primaryKey(baseRow), branchDocs.primaryKey(branchRow))
+		}
+
+		switch {
+		case cmp < 0:
+			diff.Deleted++
+			d.addSample(diff, api.BranchDiffDeleted, baseRow, nil)
+			baseDocs.pop()
+		case cmp > 0:
+			diff.Added++
+			d.addSample(diff, api.BranchDiffAdded, nil, branchRow)
+			branchDocs.pop()
+		default:
+			if !equalJSON(baseRow.Data.RawData, branchRow.Data.RawData) {
+				diff.Modified++
+				d.addSample(diff, api.BranchDiffModified, baseRow, branchRow)
+			}
+			baseDocs.pop()
+			branchDocs.pop()
+		}
+	}
+}
+
+func (d *BranchDiffer) addSample(diff *api.CollectionDiff, change string, base *Row, branch *Row) {
+	if len(diff.Sample) >= d.sample || (d.modifiedOnly && change != api.BranchDiffModified) {
+		return
+	}
+
+	doc := &api.DocumentDiff{Change: change}
+	if base != nil {
+		doc.Base = base.Data.RawData
+	}
+	if branch != nil {
+		doc.Branch = branch.Data.RawData
+	}
+	diff.Sample = append(diff.Sample, doc)
+}
+
+func (d *BranchDiffer) newCursor(coll *schema.DefaultCollection) *diffCursor {
+	cursor := &diffCursor{txMgr: d.txMgr, batchSize: d.batchSize, done: coll == nil}
+	if coll != nil {
+		cursor.table = coll.EncodedName
+	}
+
+	return cursor
+}
+
+// diffCursor reads the documents of a collection in batches.
+type diffCursor struct {
+	txMgr     *transaction.Manager
+	table     []byte
+	batchSize int
+	rows      []Row
+	pos       int
+	last      []byte
+	done      bool
+}
+
+// peek returns the current document, reading the next batch if the current one is consumed, or nil if there are no
+// more documents.
+func (c *diffCursor) peek(ctx context.Context) (*Row, error) {
+	for c.pos >= len(c.rows) {
+		if c.done {
+			return nil, nil
+		}
+		if err := c.next(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &c.rows[c.pos], nil
+}
+
+func (c *diffCursor) pop() {
+	c.pos++
+}
+
+// take returns the documents of the current batch not consumed yet, reading the next batch if the current one is
+// consumed, or nil if there are no more documents.
+func (c *diffCursor) take(ctx context.Context) ([]Row, error) {
+	row, err := c.peek(ctx)
+	if err != nil || row == nil {
+		return nil, err
+	}
+
+	rows := c.rows[c.pos:]
+	c.pos = len(c.rows)
+
+	return rows, nil
+}
+
+func (c *diffCursor) primaryKey(row *Row) []byte {
+	return row.Key[len(c.table):]
+}
+
+// next reads the batch following the last document read, a batch that fails with a conflict or exceeds the
+// transaction limits is retried with half the documents.
+func (c *diffCursor) next(ctx context.Context) error {
+	for {
+		rows, err := c.batch(ctx)
+		if err == nil {
+			c.rows, c.pos = rows, 0
+			if len(rows) < c.batchSize {
+				c.done = true
+			}
+			if len(rows) > 0 {
+				c.last = rows[len(rows)-1].Key
+			}
+			return nil
+		}
+
+		// a batch read at a pinned version fails the same way when retried
+		if !shouldRetryBulkIndex(err) || (err == kv.ErrTransactionMaxDurationReached && kv.HasReadVersion(ctx)) {
+			return err
+		}
+		if c.batchSize > 1 {
+			c.batchSize /= 2
+		}
+	}
+}
+
+func (c *diffCursor) batch(ctx context.Context) ([]Row, error) {
+	tx, err := c.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := createBulkDocsReader(ctx, tx, c.table, nil, c.last)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		row  Row
+		rows = make([]Row, 0, c.batchSize)
+	)
+	for len(rows) < c.batchSize && iter.Next(&row) {
+		if c.last != nil && bytes.Equal(row.Key, c.last) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, iter.Interrupted()
+}
+
+// equalJSON returns true if the JSON documents are equal regardless of the formatting and the order of the fields.
+func equalJSON(a []byte, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var aValue, bValue any
+	if jsoniter.Unmarshal(a, &aValue) != nil || jsoniter.Unmarshal(b, &bValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// collectionNames returns the names of the collections of the databases, in order.
+func collectionNames(databases ...*metadata.Database) []string {
+	names := make(map[string]struct{})
+	for _, db := range databases {
+		for _, coll := range db.ListCollection() {
+			names[coll.Name] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// BranchDiffRunner compares the branch of the request with the base branch, to preview the changes of the branch
+// before merging it.
+type BranchDiffRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeDatabaseRequest
+}
+
+func (runner *BranchDiffRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	sample, err := request.GetDiffSample(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	baseBranch, branchName := request.GetDiffBase(ctx), runner.req.GetBranch()
+	if branchName == "" {
+		branchName = metadata.MainBranch
+	}
+
+	base, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), baseBranch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	branch, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), branchName)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	sorted := collectionNames(base, branch)
+	resp := &api.BranchDiffResponse{
+		Base:        baseBranch,
+		Branch:      branchName,
+		Collections: make([]*api.CollectionDiff, 0, len(sorted)),
+	}
+	differ := NewBranchDiffer(runner.txMgr, sample)
+	for _, name := range sorted {
+		diff, err := differ.Diff(ctx, base.GetCollection(name), branch.GetCollection(name))
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		resp.Collections = append(resp.Collections, diff)
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestBranchDiffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	base := &schema.DefaultCollection{
+		Name:        "t1",
+		EncodedName: []byte("diff_base_t1"),
+		Schema:      []byte(`{"title":"t1","properties":{"id":{"type":"integer"}}}`),
+	}
+	branch := &schema.DefaultCollection{
+		Name:        "t1",
+		EncodedName: []byte("diff_branch_t1"),
+		Schema:      []byte(`{ "properties": {"id": {"type": "integer"}}, "title": "t1" }`),
+	}
+	for _, table := range [][]byte{base.EncodedName, branch.EncodedName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	write := func(coll *schema.DefaultCollection, docs map[int64]string) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		for id, doc := range docs {
+			require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, "pkey", id), createTD([]byte(doc)), false))
+		}
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	// 1 and 2 are unchanged, 3 is modified, 4 and 6 are deleted, 5 and 7 are added.
+	write(base, map[int64]string{
+		1: `{"id":1,"name":"a"}`,
+		2: `{"id":2,"name":"b"}`,
+		3: `{"id":3,"name":"c"}`,
+		4: `{"id":4,"name":"d"}`,
+		6: `{"id":6,"name":"f"}`,
+	})
+	write(branch, map[int64]string{
+		1: `{"id":1,"name":"a"}`,
+		2: `{"name":"b", "id":2}`,
+		3: `{"id":3,"name":"changed"}`,
+		5: `{"id":5,"name":"e"}`,
+		7: `{"id":7,"name":"g"}`,
+	})
+
+	differ := NewBranchDiffer(tm, 3)
+	differ.batchSize = 2
+
+	diff, err := differ.Diff(ctx, base, branch)
+	require.NoError(t, err)
+	require.Equal(t, "t1", diff.Collection)
+	require.Equal(t, api.BranchDiffUnchanged, diff.Schema)
+	require.Nil(t, diff.BaseSchema)
+	require.Equal(t, int64(2), diff.Added)
+	require.Equal(t, int64(1), diff.Modified)
+	require.Equal(t, int64(2), diff.Deleted)
+
+	require.Len(t, diff.Sample, 3)
+	require.Equal(t, api.BranchDiffModified, diff.Sample[0].Change)
+	require.JSONEq(t, `{"id":3,"name":"c"}`, string(diff.Sample[0].Base))
+	require.JSONEq(t, `{"id":3,"name":"changed"}`, string(diff.Sample[0].Branch))
+	require.Equal(t, api.BranchDiffDeleted, diff.Sample[1].Change)
+	require.JSONEq(t, `{"id":4,"name":"d"}`, string(diff.Sample[1].Base))
+	require.Nil(t, diff.Sample[1].Branch)
+	require.Equal(t, api.BranchDiffAdded, diff.Sample[2].Change)
+	require.JSONEq(t, `{"id":5,"name":"e"}`, string(diff.Sample[2].Branch))
+
+	t.Run("collection_only_in_branch", func(t *testing.T) {
+		diff, err := NewBranchDiffer(tm, 0).Diff(ctx, nil, branch)
+		require.NoError(t, err)
+		require.Equal(t, api.BranchDiffAdded, diff.Schema)
+		require.Equal(t, int64(5), diff.Added)
+		require.Zero(t, diff.Deleted)
+		require.Empty(t, diff.Sample)
+	})
+
+	t.Run("modified_only", func(t *testing.T) {
+		differ := NewBranchDiffer(tm, 3)
+		differ.modifiedOnly = true
+
+		diff, err := differ.Diff(ctx, base, branch)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), diff.Added)
+		require.Len(t, diff.Sample, 1)
+		require.Equal(t, api.BranchDiffModified, diff.Sample[0].Change)
+	})
+
+	t.Run("schema_modified", func(t *testing.T) {
+		modified := *branch
+		modified.Schema = []byte(`{"title":"t1","properties":{"id":{"type":"integer"},"name":{"type":"string"}}}`)
+
+		diff, err := NewBranchDiffer(tm, 0).Diff(ctx, base, &modified)
+		require.NoError(t, err)
+		require.Equal(t, api.BranchDiffModified, diff.Schema)
+		require.JSONEq(t, string(base.Schema), string(diff.BaseSchema))
+		require.JSONEq(t, string(modified.Schema), string(diff.BranchSchema))
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// BranchLifetimesRunner lists the branches of a project with the remaining lifetime of the ephemeral ones.
+type BranchLifetimesRunner struct {
+	*BaseQueryRunner
+
+	req *api.ListBranchesRequest
+}
+
+func (runner *BranchLifetimesRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	project, err := tenant.GetProject(runner.req.GetProject())
+	if err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	now := time.Now()
+	resp := &api.BranchLifetimesResponse{Branches: []*api.BranchLifetime{}}
+	for _, db := range project.GetDatabaseWithBranches() {
+		resp.Branches = append(resp.Branches, newBranchLifetime(db.BranchName(), db.ExpiresAt(), now))
+	}
+	sort.SliceStable(resp.Branches, func(i, j int) bool {
+		if resp.Branches[i].Branch == metadata.MainBranch || resp.Branches[j].Branch == metadata.MainBranch {
+			return resp.Branches[i].Branch == metadata.MainBranch
+		}
+		return resp.Branches[i].Branch < resp.Branches[j].Branch
+	})
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
+
+func newBranchLifetime(branch string, expiresAt int64, now time.Time) *api.BranchLifetime {
+	lifetime := &api.BranchLifetime{Branch: branch}
+	if expiresAt == 0 {
+		return lifetime
+	}
+
+	lifetime.Ephemeral = true
+	lifetime.ExpiresAt = time.Unix(expiresAt, 0).UTC().Format(time.RFC3339)
+	if remaining := expiresAt - now.Unix(); remaining > 0 {
+		lifetime.ExpiresIn = remaining
+	}
+
+	return lifetime
+}
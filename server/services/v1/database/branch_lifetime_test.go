@@ -0,0 +1,43 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestNewBranchLifetime(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, &api.BranchLifetime{Branch: "main"}, newBranchLifetime("main", 0, now))
+
+	require.Equal(t, &api.BranchLifetime{
+		Branch:    "ci-1",
+		Ephemeral: true,
+		ExpiresAt: "2023-05-01T14:00:00Z",
+		ExpiresIn: 7200,
+	}, newBranchLifetime("ci-1", now.Add(2*time.Hour).Unix(), now))
+
+	// an expired branch waiting for the cleanup
+	require.Equal(t, &api.BranchLifetime{
+		Branch:    "ci-2",
+		Ephemeral: true,
+		ExpiresAt: "2023-05-01T11:00:00Z",
+	}, newBranchLifetime("ci-2", now.Add(-time.Hour).Unix(), now))
+}
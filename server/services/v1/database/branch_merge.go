@@ -0,0 +1,299 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// The actions of a merge on a document of the branch.
+const (
+	mergeInsert  = "insert"
+	mergeReplace = "replace"
+	mergeSkip    = "skip"
+)
+
+// mergeAction returns what the merge does with a document of the branch given the document with the same primary key
+// in the main branch, nil if there is none, and whether the documents conflict. A branch starts empty, so a document
+// missing from the branch is not a deletion and the documents only in the main branch are kept.
+func mergeAction(policy string, existing *internal.TableData, doc []byte) (string, bool) {
+	switch {
+	case existing == nil:
+		return mergeInsert, false
+	case equalJSON(existing.RawData, doc):
+		return mergeSkip, false
+	case policy == api.MergePolicySourceWins:
+		return mergeReplace, true
+	default:
+		return mergeSkip, true
+	}
+}
+
+// BranchMergeRunner merges a branch into the main branch. The collections missing from the main branch are created
+// with the schema of the branch, the schema of a collection of both branches is replaced only by the
+// MergePolicySourceWins policy. The documents are then merged collection by collection, each batch in its own
+// transaction, so that the indexes and the search index of the main branch are updated like for any other write.
+//
+// The MergePolicyFail policy compares the branches before changing anything and fails with a conflict report if they
+// conflict. The merge is not atomic, a merge failing midway leaves the batches merged so far in the main branch, and
+// merging again resumes it as the documents merged already are equal in both branches.
+type BranchMergeRunner struct {
+	*BaseQueryRunner
+
+	req      *api.DescribeDatabaseRequest
+	sessions Session
+	notify   func(*api.BranchMergeStatus) error
+}
+
+func (runner *BranchMergeRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	policy, err := request.GetMergePolicy(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	project, branchName := runner.req.GetProject(), runner.req.GetBranch()
+	if metadata.NewDatabaseNameWithBranch(project, branchName).IsMainBranch() {
+		return Response{}, ctx, errors.InvalidArgument("the main branch can't be merged into itself")
+	}
+
+	main, err := runner.getDatabase(ctx, nil, tenant, project, metadata.MainBranch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	branch, err := runner.getDatabase(ctx, nil, tenant, project, branchName)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	status := &api.BranchMergeStatus{State: api.BranchMergePlanning, Branch: branchName, Policy: policy}
+	if err = runner.notify(status); err != nil {
+		return Response{}, ctx, err
+	}
+
+	names := collectionNames(branch)
+	if policy == api.MergePolicyFail {
+		if err = runner.checkConflicts(ctx, status, main, branch, names); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	status.State = api.BranchMergeSchemas
+	if err = runner.notify(status); err != nil {
+		return Response{}, ctx, err
+	}
+	for _, name := range names {
+		source, target := branch.GetCollection(name), main.GetCollection(name)
+		if target != nil && (policy != api.MergePolicySourceWins || equalJSON(target.Schema, source.Schema)) {
+			continue
+		}
+
+		if err = runner.applySchema(ctx, project, name, source.Schema); err != nil {
+			return Response{}, ctx, runner.fail(status, err)
+		}
+	}
+
+	status.State = api.BranchMergeMerging
+	for _, name := range names {
+		status.Collection = name
+		if err = runner.mergeCollection(ctx, status, branch, name, policy); err != nil {
+			log.Err(err).Msgf("Failed to merge collection '%s' of branch '%s'", name, branchName)
+			return Response{}, ctx, runner.fail(status, err)
+		}
+	}
+
+	status.State, status.Collection = api.BranchMergeDone, ""
+	if err = runner.notify(status); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+// checkConflicts compares the collections of the branch with the main branch and fails the merge with a report of the
+// conflicting collections if any.
+func (runner *BranchMergeRunner) checkConflicts(ctx context.Context, status *api.BranchMergeStatus,
+	main *metadata.Database, branch *metadata.Database, names []string,
+) error {
+	differ := NewBranchDiffer(runner.txMgr, api.MaxBranchDiffSample)
+	differ.modifiedOnly = true
+
+	for _, name := range names {
+		target := main.GetCollection(name)
+		if target == nil {
+			continue
+		}
+
+		diff, err := differ.Diff(ctx, target, branch.GetCollection(name))
+		if err != nil {
+			return err
+		}
+		if diff.Schema == api.BranchDiffModified || diff.Modified > 0 {
+			status.Conflicts += diff.Modified
+			status.ConflictReport = append(status.ConflictReport, diff)
+		}
+	}
+
+	if len(status.ConflictReport) == 0 {
+		return nil
+	}
+
+	return runner.fail(status, errors.FailedPrecondition("branch '%s' conflicts with the main branch in %d collections",
+		status.Branch, len(status.ConflictReport)))
+}
+
+// applySchema creates the collection in the main branch or updates its schema.
+func (runner *BranchMergeRunner) applySchema(ctx context.Context, project string, collection string, schema []byte) error {
+	collRunner := &CollectionQueryRunner{BaseQueryRunner: runner.BaseQueryRunner}
+	collRunner.SetCreateOrUpdateCollectionReq(&api.CreateOrUpdateCollectionRequest{
+		Project:    project,
+		Branch:     metadata.MainBranch,
+		Collection: collection,
+		Schema:     schema,
+	})
+
+	_, err := runner.sessions.Execute(ctx, collRunner, ReqOptions{
+		MetadataChange:     true,
+		InstantVerTracking: true,
+	})
+
+	return err
+}
+
+// mergeCollection merges the documents of the collection of the branch into the main branch, notifying the progress
+// after every batch.
+func (runner *BranchMergeRunner) mergeCollection(ctx context.Context, status *api.BranchMergeStatus,
+	branch *metadata.Database, name string, policy string,
+) error {
+	differ := NewBranchDiffer(runner.txMgr, 0)
+	source := differ.newCursor(branch.GetCollection(name))
+
+	for {
+		rows, err := source.take(ctx)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		batch := &branchMergeBatchRunner{
+			BaseQueryRunner: runner.BaseQueryRunner,
+			project:         runner.req.GetProject(),
+			branch:          metadata.MainBranch,
+			collection:      name,
+			sourceTable:     source.table,
+			policy:          policy,
+			rows:            rows,
+		}
+		if _, err = runner.sessions.Execute(ctx, batch, ReqOptions{}); err != nil {
+			return err
+		}
+
+		status.Processed += int64(len(rows))
+		status.Inserted += batch.inserted
+		status.Replaced += batch.replaced
+		status.Skipped += batch.skipped
+		status.Conflicts += batch.conflicts
+		if err = runner.notify(status); err != nil {
+			return err
+		}
+	}
+}
+
+// fail notifies the failure of the merge and returns the error.
+func (runner *BranchMergeRunner) fail(status *api.BranchMergeStatus, err error) error {
+	status.State, status.Error = api.BranchMergeFailed, err.Error()
+	_ = runner.notify(status)
+
+	return err
+}
+
+// branchMergeBatchRunner writes a batch of the documents of a branch to the target branch.
+type branchMergeBatchRunner struct {
+	*BaseQueryRunner
+
+	project     string
+	branch      string
+	collection  string
+	sourceTable []byte
+	policy      string
+	rows        []Row
+
+	inserted  int64
+	replaced  int64
+	skipped   int64
+	conflicts int64
+}
+
+func (runner *branchMergeBatchRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	// the batch may be retried, only the counts of the committed run are kept
+	runner.inserted, runner.replaced, runner.skipped, runner.conflicts = 0, 0, 0, 0
+
+	db, coll, err := runner.getDBAndCollection(ctx, tx, tenant, runner.project, runner.collection, runner.branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	documents := make([][]byte, 0, len(runner.rows))
+	for _, row := range runner.rows {
+		fdbKey := append(append([]byte{}, coll.EncodedName...), row.Key[len(runner.sourceTable):]...)
+		key, err := keys.FromBinary(coll.EncodedName, fdbKey)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		existing, err := readDocument(ctx, tx, key)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		action, conflict := mergeAction(runner.policy, existing, row.Data.RawData)
+		if conflict {
+			if runner.policy == api.MergePolicyFail {
+				return Response{}, ctx, errors.Aborted("a document of collection '%s' was changed in branch '%s' during the merge",
+					runner.collection, runner.branch)
+			}
+			runner.conflicts++
+		}
+
+		switch action {
+		case mergeInsert:
+			runner.inserted++
+		case mergeReplace:
+			runner.replaced++
+		default:
+			runner.skipped++
+			continue
+		}
+		documents = append(documents, row.Data.RawData)
+	}
+
+	if len(documents) > 0 {
+		if _, _, err = runner.insertOrReplace(ctx, tx, tenant, db, coll, documents, false, nil); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
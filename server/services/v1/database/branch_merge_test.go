@@ -0,0 +1,49 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+)
+
+func TestMergeAction(t *testing.T) {
+	existing := &internal.TableData{RawData: []byte(`{"id":1,"name":"a"}`)}
+
+	cases := []struct {
+		name     string
+		policy   string
+		existing *internal.TableData
+		doc      string
+		action   string
+		conflict bool
+	}{
+		{"new", api.MergePolicyFail, nil, `{"id":1,"name":"a"}`, mergeInsert, false},
+		{"equal", api.MergePolicySourceWins, existing, `{"name":"a","id":1}`, mergeSkip, false},
+		{"source_wins", api.MergePolicySourceWins, existing, `{"id":1,"name":"b"}`, mergeReplace, true},
+		{"target_wins", api.MergePolicyTargetWins, existing, `{"id":1,"name":"b"}`, mergeSkip, true},
+		{"fail", api.MergePolicyFail, existing, `{"id":1,"name":"b"}`, mergeSkip, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			action, conflict := mergeAction(c.policy, c.existing, []byte(c.doc))
+			require.Equal(t, c.action, action)
+			require.Equal(t, c.conflict, conflict)
+		})
+	}
+}
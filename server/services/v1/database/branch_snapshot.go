@@ -0,0 +1,101 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// BranchSnapshotRunner copies the documents of the main branch as of a point in time into a newly created branch. The
+// branch is created with the current schemas of the main branch, then the documents of each collection are read at
+// the version of the point in time and written to the branch in batches.
+//
+// The snapshot is read at a fixed version of the store, so it is only readable within the MVCC window of the store
+// and all the batches have to be read before it moves past the version. A point in time outside the window fails the
+// copy with FailedPrecondition.
+type BranchSnapshotRunner struct {
+	*BaseQueryRunner
+
+	req      *api.CreateBranchRequest
+	sessions Session
+	at       time.Time
+	version  int64
+}
+
+func (runner *BranchSnapshotRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	project := runner.req.GetProject()
+	main, err := runner.getDatabase(ctx, nil, tenant, project, metadata.MainBranch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	branch, err := runner.getDatabase(ctx, nil, tenant, project, runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	readCtx := kv.WithReadTime(ctx, runner.at)
+	if runner.version > 0 {
+		readCtx = kv.WithReadVersionAt(ctx, runner.version)
+	}
+
+	differ := NewBranchDiffer(runner.txMgr, 0)
+	for _, coll := range branch.ListCollection() {
+		source := differ.newCursor(main.GetCollection(coll.Name))
+
+		for {
+			rows, err := source.take(readCtx)
+			if err == kv.ErrTransactionMaxDurationReached {
+				return Response{}, ctx, errors.FailedPrecondition("the snapshot of the main branch at '%s' is no longer retained",
+					runner.point())
+			}
+			if err != nil {
+				return Response{}, ctx, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			batch := &branchMergeBatchRunner{
+				BaseQueryRunner: runner.BaseQueryRunner,
+				project:         project,
+				branch:          runner.req.GetBranch(),
+				collection:      coll.Name,
+				sourceTable:     source.table,
+				policy:          api.MergePolicySourceWins,
+				rows:            rows,
+			}
+			if _, err = runner.sessions.Execute(ctx, batch, ReqOptions{}); err != nil {
+				return Response{}, ctx, err
+			}
+		}
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+func (runner *BranchSnapshotRunner) point() string {
+	if runner.version > 0 {
+		return fmt.Sprintf("version %d", runner.version)
+	}
+
+	return runner.at.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,71 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestBranchSnapshotRead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := &schema.DefaultCollection{Name: "t1", EncodedName: []byte("snapshot_t1")}
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedName))
+	require.NoError(t, kvStore.CreateTable(ctx, coll.EncodedName))
+
+	tm := transaction.NewManager(kvStore)
+	write := func(id int64, doc string) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, "pkey", id), createTD([]byte(doc)), false))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	write(1, `{"id":1,"name":"a"}`)
+	write(2, `{"id":2,"name":"b"}`)
+	time.Sleep(50 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(50 * time.Millisecond)
+	write(1, `{"id":1,"name":"changed"}`)
+	write(3, `{"id":3,"name":"c"}`)
+
+	differ := NewBranchDiffer(tm, 0)
+	differ.batchSize = 1
+	cursor := differ.newCursor(coll)
+
+	readCtx := kv.WithReadTime(ctx, at)
+	var docs []string
+	for {
+		rows, err := cursor.take(readCtx)
+		require.NoError(t, err)
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			docs = append(docs, string(row.Data.RawData))
+		}
+	}
+
+	require.Equal(t, []string{`{"id":1,"name":"a"}`, `{"id":2,"name":"b"}`}, docs)
+}
@@ -0,0 +1,138 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
+)
+
+// buildCaseInsensitiveIndexKeys returns the plan of a read with the case-insensitive collation on the active
+// case-insensitive index which narrows the scan with the most fields. The other secondary indexes can't serve such a
+// read as their keys have the values as they are. The index returns the documents in the order of the folded values,
+// so the read can't be sorted, and the documents are filtered again with the collation of the read.
+func (*BaseQueryRunner) buildCaseInsensitiveIndexKeys(coll *schema.DefaultCollection, reqFilter []byte,
+	collation *value.Collation, sorting *sort.Ordering,
+) (*filter.QueryPlan, error) {
+	if collation == nil || !collation.IsCaseInsensitive() || collation.HasOrderingOptions() {
+		return nil, errors.InvalidArgument("case insensitive indexes only serve the case insensitive collation")
+	}
+	if sorting != nil && len(*sorting) > 0 {
+		return nil, errors.InvalidArgument("case insensitive indexes can't sort the documents")
+	}
+
+	filters, err := newSecondaryIndexFilterFactory(coll).Factorize(reqFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *compositeIndexPlan
+	for _, index := range coll.GetActiveCompositeIndexes() {
+		if !index.CaseInsensitive {
+			continue
+		}
+
+		p := planCaseInsensitiveIndex(coll, index, filters)
+		if p != nil && (best == nil || p.fields > best.fields) {
+			best = p
+		}
+	}
+	if best == nil {
+		return nil, errors.InvalidArgument("no case insensitive index can serve the filter")
+	}
+
+	return best.plan, nil
+}
+
+// planCaseInsensitiveIndex returns the plan on the case-insensitive index if the filter has equalities on a prefix of
+// its fields, optionally followed by an anchored "$regex" on the next field. The values starting with the literal
+// prefix of the regex are a range of the index as the folding of a prefix is a prefix of the folded value.
+func planCaseInsensitiveIndex(coll *schema.DefaultCollection, index *schema.Index, queryFilters []filter.Filter) *compositeIndexPlan {
+	selectors := andSelectors(queryFilters)
+	prefix := []any{index.Name}
+
+	eq := 0
+	for ; eq < len(index.Fields); eq++ {
+		sel := findSelector(selectors, index.Fields[eq].FieldName, filter.EQ)
+		if sel == nil {
+			break
+		}
+		prefix = append(prefix, compositeIndexParts(index, eq, sel.Matcher.GetValue())...)
+	}
+
+	begin := append([]any{}, prefix...)
+	end := append(append([]any{}, prefix...), 0xFF)
+
+	fields := eq
+	if eq < len(index.Fields) && index.Fields[eq].DataType == schema.StringType {
+		if p := anchoredPrefix(queryFilters, index.Fields[eq].FieldName); p != "" {
+			folded := value.FoldCase(p)
+			encoder := keyencoding.ForIndex(index)
+
+			// 0xFF is not a byte of a UTF-8 string, so the values starting with the prefix are ordered before it
+			begin = append(begin, encoder.IndexParts(schema.StringType, value.NewStringValue(folded, nil))...)
+			end = append(append([]any{}, prefix...), encoder.IndexParts(schema.StringType, value.NewStringValue(folded+"\xff", nil))...)
+			fields++
+		}
+	}
+
+	if fields == 0 {
+		return nil
+	}
+
+	encode := func(parts []any) keys.Key {
+		return keys.NewKey(coll.EncodedTableIndexName, append([]any{coll.SecondaryIndexKeyword(), KVSubspace}, parts...)...)
+	}
+
+	return &compositeIndexPlan{
+		plan: &filter.QueryPlan{
+			QueryType: filter.RANGE,
+			FieldName: index.Name,
+			DataType:  schema.UnknownType,
+			Keys:      []keys.Key{encode(begin), encode(end)},
+			Ascending: true,
+			IndexType: filter.SecondaryIndex,
+		},
+		fields: fields,
+	}
+}
+
+// anchoredPrefix returns the literal prefix of an anchored "$regex" on the field that every document returned by the
+// filter matches, it is empty if there is none.
+func anchoredPrefix(queryFilters []filter.Filter, field string) string {
+	for _, f := range queryFilters {
+		switch ff := f.(type) {
+		case *filter.LikeFilter:
+			if regex, ok := ff.Matcher.(*filter.RegexMatcher); ok && ff.Field.Name() == field {
+				if p := regex.AnchoredPrefix(); p != "" {
+					return p
+				}
+			}
+		case filter.LogicalFilter:
+			if ff.Type() == filter.AndOP {
+				if p := anchoredPrefix(ff.GetFilters(), field); p != "" {
+					return p
+				}
+			}
+		}
+	}
+
+	return ""
+}
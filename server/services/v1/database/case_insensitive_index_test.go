@@ -0,0 +1,249 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/read"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
+)
+
+var caseInsensitiveSchema = []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"org": { "type": "string" },
+		"email": { "type": "string" },
+		"name": { "type": "string" }
+	},
+	"primary_key": ["id"],
+	"indexes": [
+		{ "name": "email_ci", "fields": [{ "field": "email" }], "case_insensitive": true },
+		{ "name": "org_email_ci", "fields": [{ "field": "org" }, { "field": "email" }], "case_insensitive": true }
+	]
+}`)
+
+func TestCaseInsensitiveIndexKeys(t *testing.T) {
+	indexer := setupActiveIndexTest(t, caseInsensitiveSchema)
+	encoder := keyencoding.Get(keyencoding.Current)
+
+	td, pk := createDoc(`{"id":1, "org":"Acme", "email":"Ann@Example.COM", "name":"Ann"}`)
+	updateSet, err := indexer.buildAddAndRemoveKVs(td, nil, pk)
+	require.NoError(t, err)
+
+	expected := append(append([]any{"skey", KVSubspace, "email_ci"},
+		encoder.IndexParts(schema.StringType, value.NewStringValue("ann@example.com", nil))...), 0, 1)
+	require.Equal(t, [][]any{expected}, indexKeyParts(updateSet.addKeys, "email_ci"))
+
+	// the entries keep the original values
+	var covered []string
+	for _, data := range updateSet.addData {
+		covered = append(covered, string(data.RawData))
+	}
+	require.Len(t, covered, 2)
+	require.Contains(t, covered, `{"email":"Ann@Example.COM","id":1}`)
+	require.Contains(t, covered, `{"org":"Acme","email":"Ann@Example.COM","id":1}`)
+
+	t.Run("update case", func(t *testing.T) {
+		updateTD := td.CloneWithAttributesOnly([]byte(`{"id":1, "org":"Acme", "email":"ann@example.com", "name":"Ann"}`))
+		updateSet, err := indexer.buildAddAndRemoveKVs(updateTD, td, pk)
+		require.NoError(t, err)
+		// the key is the same, the entry is written again with the new original value
+		require.Equal(t, [][]any{expected}, indexKeyParts(updateSet.addKeys, "email_ci"))
+		require.Equal(t, [][]any{expected}, indexKeyParts(updateSet.removeKeys, "email_ci"))
+	})
+}
+
+func TestCaseInsensitiveIndexPlan(t *testing.T) {
+	indexer := setupActiveIndexTest(t, caseInsensitiveSchema)
+	coll := indexer.coll
+	runner := &BaseQueryRunner{}
+	ci := value.NewCollationFrom(&api.Collation{Case: "ci"})
+
+	plan := func(reqFilter string, collation *value.Collation, sorting *sort.Ordering) (*filter.QueryPlan, error) {
+		return runner.buildCaseInsensitiveIndexKeys(coll, []byte(reqFilter), collation, sorting)
+	}
+
+	p, err := plan(`{"email": "ANN@example.com"}`, ci, nil)
+	require.NoError(t, err)
+	require.Equal(t, "email_ci", p.FieldName)
+	require.Equal(t, filter.RANGE, p.QueryType)
+
+	p, err = plan(`{"org": "ACME", "email": "ann@example.com"}`, ci, nil)
+	require.NoError(t, err)
+	require.Equal(t, "org_email_ci", p.FieldName)
+
+	p, err = plan(`{"org": "acme", "email": {"$regex": "^ANN@"}}`, ci, nil)
+	require.NoError(t, err)
+	require.Equal(t, "org_email_ci", p.FieldName)
+
+	p, err = plan(`{"email": {"$regex": "(?i)^Ann"}}`, ci, nil)
+	require.NoError(t, err)
+	require.Equal(t, "email_ci", p.FieldName)
+
+	_, err = plan(`{"email": "ann@example.com"}`, nil, nil)
+	require.Error(t, err)
+	_, err = plan(`{"email": "ann@example.com"}`, value.NewCollationFrom(&api.Collation{Case: "ci,ai"}), nil)
+	require.Error(t, err)
+	_, err = plan(`{"email": "ann@example.com"}`, ci, &sort.Ordering{{Name: "email", Ascending: true}})
+	require.Error(t, err)
+	_, err = plan(`{"email": {"$regex": "ann"}}`, ci, nil)
+	require.Error(t, err)
+	_, err = plan(`{"name": "ann"}`, ci, nil)
+	require.Error(t, err)
+
+	t.Run("case sensitive reads", func(t *testing.T) {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(`{"org": "acme", "email": "ann@example.com"}`))
+		require.NoError(t, err)
+		require.Nil(t, buildCompositeIndexPlan(coll, filters, nil))
+	})
+
+	t.Run("covering", func(t *testing.T) {
+		p, err := plan(`{"email": "ANN@example.com"}`, ci, nil)
+		require.NoError(t, err)
+
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(`{"email": "ANN@example.com"}`))
+		require.NoError(t, err)
+		wrapped, err := filter.NewFactory(coll.QueryableFields, ci).WrappedFilter([]byte(`{"email": "ANN@example.com"}`))
+		require.NoError(t, err)
+
+		fieldFactory, err := read.BuildFields([]byte(`{"email": true, "id": true}`))
+		require.NoError(t, err)
+		require.Equal(t, p, buildCoveringIndexPlan(coll, p, filters, nil, wrapped, fieldFactory))
+
+		fieldFactory, err = read.BuildFields([]byte(`{"name": true}`))
+		require.NoError(t, err)
+		require.Nil(t, buildCoveringIndexPlan(coll, p, filters, nil, wrapped, fieldFactory))
+	})
+}
+
+func TestAnchoredPrefix(t *testing.T) {
+	coll := setupActiveIndexTest(t, caseInsensitiveSchema).coll
+
+	for reqFilter, expected := range map[string]string{
+		`{"email": {"$regex": "^ann@"}}`:                             "ann@",
+		`{"email": {"$regex": "ann@"}}`:                              "",
+		`{"$and": [{"org": "acme"}, {"email": {"$regex": "^An+"}}]}`: "A",
+		`{"$or": [{"org": "acme"}, {"email": {"$regex": "^ann@"}}]}`: "",
+	} {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+		require.Equal(t, expected, anchoredPrefix(filters, "email"), reqFilter)
+	}
+}
+
+func TestOrderingCollationOnIndexedCollection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	readEnabled := config.DefaultConfig.SecondaryIndex.ReadEnabled
+	config.DefaultConfig.SecondaryIndex.ReadEnabled = true
+	defer func() { config.DefaultConfig.SecondaryIndex.ReadEnabled = readEnabled }()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string", "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("collation_t1")
+	coll.EncodedTableIndexName = []byte("collation_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+	for id, name := range []string{"file9", "file10", "Café", "cafe"} {
+		pk := []any{"pkey", int64(id + 1)}
+		td := createTD([]byte(fmt.Sprintf(`{"id":%d, "name":"%s"}`, id+1, name)))
+		require.NoError(t, indexer.Update(ctx, tx, td, nil, pk))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	runner := &BaseQueryRunner{}
+	query := func(reqFilter string, collation *api.Collation) (string, []int64) {
+		req := &api.ReadRequest{Filter: []byte(reqFilter)}
+		if collation != nil {
+			req.Options = &api.ReadRequestOptions{Collation: collation}
+		}
+		options, err := runner.buildReaderOptions(req, coll)
+		require.NoError(t, err)
+		if options.plan != nil {
+			return readPlan(options), nil
+		}
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := NewDatabaseReader(ctx, tx).ScanTable(coll.EncodedName, false)
+		require.NoError(t, err)
+		filtered := NewFilterIterator(iter, options.filter)
+
+		var (
+			row Row
+			ids []int64
+		)
+		for filtered.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, filtered.Interrupted())
+
+		return readPlan(options), ids
+	}
+
+	plan, _ := query(`{"name": {"$gt": "file9"}}`, nil)
+	require.Equal(t, "secondary name", plan)
+
+	for _, c := range []struct {
+		filter    string
+		collation *api.Collation
+		expIds    []int64
+	}{
+		{`{"name": {"$gt": "file9"}}`, &api.Collation{Case: "numeric"}, []int64{2}},
+		{`{"name": {"$gt": "file9", "collation": {"numeric_ordering": true}}}`, nil, []int64{2}},
+		{`{"name": "cafe"}`, &api.Collation{Case: "ci,ai"}, []int64{3, 4}},
+		{`{"name": {"$eq": "CAFE", "collation": {"case": "ci", "accent_insensitive": true}}}`, nil, []int64{3, 4}},
+		{`{"name": {"$lt": "d"}}`, &api.Collation{Case: "de"}, []int64{3, 4}},
+	} {
+		plan, ids := query(c.filter, c.collation)
+		require.Equal(t, "full_scan", plan, c.filter)
+		require.Equal(t, c.expIds, ids, c.filter)
+	}
+}
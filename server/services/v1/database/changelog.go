@@ -0,0 +1,221 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// changelogEntry is a mutation of a document retained in the changelog of its collection. The changelog is keyed by
+// the time of the commit, so it is read backwards from now to undo the mutations done since a moment.
+type changelogEntry struct {
+	Op string `json:"op"`
+	// Key is the key of the document in the table of the collection, without the table name.
+	Key []byte `json:"key"`
+	// Before is the value of the document before the mutation as stored, nil if the document didn't exist.
+	Before []byte `json:"before,omitempty"`
+}
+
+// changelogKey returns the key of the nth mutation of a transaction committed at ts.
+func changelogKey(table []byte, ts time.Time, txId string, n int) keys.Key {
+	return keys.NewKey(table, ts.UnixNano(), txId, int64(n))
+}
+
+// ChangelogWriter retains the mutations of the documents in the changelogs of their collections, in the transaction
+// of the mutations. The entries are keyed by the time of the commit, the mutations of a document are ordered like its
+// commits as the before image read by a mutation conflicts with any concurrent mutation of the document.
+type ChangelogWriter struct {
+	now func() time.Time
+}
+
+func NewChangelogWriter() *ChangelogWriter {
+	return &ChangelogWriter{now: time.Now}
+}
+
+func (w *ChangelogWriter) OnPreCommit(ctx context.Context, tenant *metadata.Tenant, tx transaction.Tx, events kv.EventListener) error {
+	var (
+		ts   time.Time
+		txId string
+	)
+	for i, event := range events.GetEvents() {
+		// event.Key == nil if event comes from drop table, the outbox events are not documents
+		if event.Key == nil || event.Op == kv.OutboxEvent {
+			continue
+		}
+
+		table, ok := tenant.Encoder.ChangelogTableName(event.Table)
+		if !ok {
+			continue
+		}
+
+		if txId == "" {
+			ts, txId = w.now().UTC(), uuid.New().String()
+		}
+
+		parts := make([]any, len(event.Key))
+		for j, part := range event.Key {
+			parts[j] = part
+		}
+
+		entry := &changelogEntry{
+			Op:  event.Op,
+			Key: keys.NewKey(nil, parts...).SerializeToBytes(),
+		}
+		if event.Before != nil {
+			entry.Before = event.Before.RawData
+		}
+
+		data, err := jsoniter.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err = tx.Replace(ctx, changelogKey(table, ts, txId, i), internal.NewTableData(data), false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (*ChangelogWriter) OnPostCommit(context.Context, *metadata.Tenant, kv.EventListener) error {
+	return nil
+}
+
+func (*ChangelogWriter) OnRollback(context.Context, *metadata.Tenant, kv.EventListener) {}
+
+// ChangelogPruner deletes the mutations retained longer than the retention from the changelogs of all the
+// collections. The changelogs of the dropped collections are dropped with them.
+type ChangelogPruner struct {
+	cfg     config.ContinuousBackupConfig
+	tenants metadata.TenantGetter
+	txMgr   *transaction.Manager
+	now     func() time.Time
+}
+
+func NewChangelogPruner(cfg config.ContinuousBackupConfig, tenants metadata.TenantGetter, txMgr *transaction.Manager) *ChangelogPruner {
+	return &ChangelogPruner{
+		cfg:     cfg,
+		tenants: tenants,
+		txMgr:   txMgr,
+		now:     time.Now,
+	}
+}
+
+func (p *ChangelogPruner) Start() {
+	if p.cfg.Enabled {
+		go p.loop()
+	}
+}
+
+func (p *ChangelogPruner) loop() {
+	log.Info().Dur("interval", p.cfg.PruneInterval).Dur("retention", p.cfg.Retention).Msg("Starting changelog pruning")
+	t := time.NewTicker(p.cfg.PruneInterval)
+	defer t.Stop()
+	for range t.C {
+		p.scan(context.Background())
+	}
+}
+
+// scan prunes the changelogs of the collections of all the tenants.
+func (p *ChangelogPruner) scan(ctx context.Context) {
+	cutoff := p.now().UTC().Add(-p.cfg.Retention)
+
+	for _, tenant := range p.tenants.AllTenants(ctx) {
+		for _, name := range tenant.ListProjects(ctx) {
+			project, err := tenant.GetProject(name)
+			if err != nil {
+				continue
+			}
+
+			for _, db := range project.GetDatabaseWithBranches() {
+				for _, coll := range db.ListCollection() {
+					table, err := tenant.Encoder.EncodeChangelogTableName(tenant.GetNamespace(), db, coll)
+					if err == nil {
+						err = p.prune(ctx, table, cutoff)
+					}
+					if err != nil {
+						log.Err(err).Str("ns", tenant.GetNamespace().StrId()).Str("project", name).
+							Str("branch", db.BranchName()).Str("collection", coll.Name).Msg("failed to prune changelog")
+					}
+				}
+			}
+		}
+	}
+}
+
+// prune deletes the mutations of the changelog committed before the cutoff, in batches each in its own transaction.
+func (p *ChangelogPruner) prune(ctx context.Context, table []byte, cutoff time.Time) error {
+	batchSize := p.cfg.PruneBatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	for {
+		deleted, err := p.pruneBatch(ctx, table, cutoff, batchSize)
+		if err != nil || deleted < batchSize {
+			return err
+		}
+	}
+}
+
+func (p *ChangelogPruner) pruneBatch(ctx context.Context, table []byte, cutoff time.Time, batchSize int) (int, error) {
+	tx, err := p.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := p.deleteBefore(ctx, tx, table, cutoff, batchSize)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	return deleted, tx.Commit(ctx)
+}
+
+func (*ChangelogPruner) deleteBefore(ctx context.Context, tx transaction.Tx, table []byte, cutoff time.Time, batchSize int) (int, error) {
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table), keys.NewKey(table, cutoff.UnixNano()), false, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		row     kv.KeyValue
+		deleted int
+	)
+	for deleted < batchSize && iter.Next(&row) {
+		key, err := keys.FromBinary(table, row.FDBKey)
+		if err != nil {
+			return 0, err
+		}
+		if err = tx.Delete(ctx, key); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+
+	return deleted, iter.Err()
+}
@@ -0,0 +1,106 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestChangelog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := append(append([]byte{}, internal.UserTableKeyPrefix...), 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3)
+	changelog := append(append([]byte{}, internal.ChangelogTableKeyPrefix...), table[4:]...)
+	require.NoError(t, kvStore.DropTable(ctx, changelog))
+
+	tenant := &metadata.Tenant{Encoder: metadata.NewEncoder()}
+	tm := transaction.NewManager(kvStore)
+
+	now := time.Now().UTC()
+	writer := NewChangelogWriter()
+	commit := func(at time.Time, events ...*kv.Event) {
+		writer.now = func() time.Time { return at }
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, writer.OnPreCommit(ctx, tenant, tx, &kv.DefaultListener{Events: events}))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	commit(now.Add(-2*time.Hour), &kv.Event{
+		Op: kv.InsertEvent, Table: table, Key: kv.BuildKey("pkey", int64(1)), Data: createTD([]byte(`{"id":1}`)),
+	})
+	commit(now.Add(-time.Hour),
+		&kv.Event{
+			Op: kv.ReplaceEvent, Table: table, Key: kv.BuildKey("pkey", int64(1)), Data: createTD([]byte(`{"id":1,"a":1}`)),
+			Before: createTD([]byte(`{"id":1}`)),
+		},
+		// not documents of a collection
+		&kv.Event{Op: kv.OutboxEvent, Table: table, Key: kv.BuildKey("pkey", "e1")},
+		&kv.Event{Op: kv.DeleteEvent, Table: []byte("idx_other_table"), Key: kv.BuildKey("pkey", int64(1))},
+	)
+
+	read := func() []*changelogEntry {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := tx.ReadRange(ctx, keys.NewKey(changelog), keys.NewKey(changelog, now.UnixNano()), false, false)
+		require.NoError(t, err)
+
+		var (
+			row     kv.KeyValue
+			entries []*changelogEntry
+		)
+		for iter.Next(&row) {
+			var entry changelogEntry
+			require.NoError(t, jsoniter.Unmarshal(row.Data.RawData, &entry))
+			entries = append(entries, &entry)
+		}
+		require.NoError(t, iter.Err())
+
+		return entries
+	}
+
+	entries := read()
+	require.Len(t, entries, 2)
+	require.Equal(t, kv.InsertEvent, entries[0].Op)
+	require.Nil(t, entries[0].Before)
+	require.Equal(t, kv.ReplaceEvent, entries[1].Op)
+	require.Equal(t, []byte(`{"id":1}`), entries[1].Before)
+
+	key, err := keys.FromBinary(table, append(append([]byte{}, table...), entries[1].Key...))
+	require.NoError(t, err)
+	require.Equal(t, []any{"pkey", int64(1)}, key.IndexParts())
+
+	pruner := NewChangelogPruner(config.ContinuousBackupConfig{Retention: 90 * time.Minute, PruneBatchSize: 1}, nil, tm)
+	require.NoError(t, pruner.prune(ctx, changelog, now.Add(-pruner.cfg.Retention)))
+
+	entries = read()
+	require.Len(t, entries, 1)
+	require.Equal(t, kv.ReplaceEvent, entries[0].Op)
+}
@@ -0,0 +1,186 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// CollectionCopyRunner copies a collection to another collection of the same or another project or branch. The target
+// collection is created with the schema of the collection, without its secondary indexes if the request asks so, then
+// the documents are read at the version of the store of the start of the copy and written in batches, each in its own
+// transaction so that the indexes of the target are updated like for any other write.
+//
+// The target collection must not exist. A copy failing midway leaves the target collection with the batches copied so
+// far, it has to be dropped before copying again.
+type CollectionCopyRunner struct {
+	*BaseQueryRunner
+
+	req      *api.DescribeCollectionRequest
+	sessions Session
+}
+
+func (runner *CollectionCopyRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	indexes, err := request.GetCopyIndexes(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	project, branch, collection := runner.req.GetProject(), runner.req.GetBranch(), runner.req.GetCollection()
+	_, coll, err := runner.getDBAndCollection(ctx, nil, tenant, project, collection, branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	target := copyTarget(ctx, project, branch, collection)
+	targetDB, err := runner.getDatabase(ctx, nil, tenant, target.Project, target.Branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if targetDB.GetCollection(target.Collection) != nil {
+		return Response{}, ctx, errors.AlreadyExists("collection '%s' already exists in branch '%s' of project '%s'",
+			target.Collection, targetDB.BranchName(), target.Project)
+	}
+	target.Indexes = indexes
+
+	copySchema, err := schema.CopySchema(coll.Schema, target.Collection, indexes)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	collRunner := &CollectionQueryRunner{BaseQueryRunner: runner.BaseQueryRunner}
+	collRunner.SetCreateOrUpdateCollectionReq(&api.CreateOrUpdateCollectionRequest{
+		Project:    target.Project,
+		Branch:     target.Branch,
+		Collection: target.Collection,
+		Schema:     copySchema,
+		OnlyCreate: true,
+	})
+	if _, err = runner.sessions.Execute(ctx, collRunner, ReqOptions{
+		MetadataChange:     true,
+		InstantVerTracking: true,
+	}); err != nil {
+		return Response{}, ctx, err
+	}
+
+	if target.Documents, err = runner.copyDocuments(ctx, coll, target); err != nil {
+		return Response{}, ctx, err
+	}
+	target.Branch = targetDB.BranchName()
+
+	data, err := jsoniter.Marshal(target)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{ContentType: "application/json", Data: data},
+	}, ctx, nil
+}
+
+// copyDocuments writes the documents of the collection to the target in batches, the encrypted fields are decrypted
+// and encrypted again with the key of the target. It returns the number of the documents copied.
+func (runner *CollectionCopyRunner) copyDocuments(ctx context.Context, coll *schema.DefaultCollection, target *api.CopyCollectionResponse) (int64, error) {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	batch := &restoreBatchRunner{
+		BaseQueryRunner: runner.BaseQueryRunner,
+		project:         target.Project,
+		branch:          target.Branch,
+		collection:      target.Collection,
+	}
+	var copied int64
+	flush := func() error {
+		if len(batch.documents) == 0 {
+			return nil
+		}
+		if _, err := runner.sessions.Execute(ctx, batch, ReqOptions{}); err != nil {
+			return err
+		}
+
+		copied += int64(len(batch.documents))
+		batch.documents, batch.size = batch.documents[:0], 0
+		return nil
+	}
+
+	// all the batches are read at the same version, so the copy is a consistent snapshot of the collection
+	readCtx := kv.WithReadVersion(ctx)
+	cursor := NewBranchDiffer(runner.txMgr, 0).newCursor(coll)
+	for {
+		rows, err := cursor.take(readCtx)
+		if err == kv.ErrTransactionMaxDurationReached {
+			return copied, errors.FailedPrecondition("the snapshot of collection '%s' is no longer retained, "+
+				"the copy has to be read within the MVCC window of the store", coll.Name)
+		}
+		if err != nil {
+			return copied, err
+		}
+		if len(rows) == 0 {
+			return copied, flush()
+		}
+
+		for _, row := range rows {
+			doc := row.Data.RawData
+			if coll.HasEncryptedFields() {
+				if doc, err = coll.DecryptFields(doc); err != nil {
+					return copied, err
+				}
+			}
+
+			if len(batch.documents) >= batchSize || batch.size+len(doc) > restoreBatchBytes {
+				if err = flush(); err != nil {
+					return copied, err
+				}
+			}
+			batch.documents = append(batch.documents, doc)
+			batch.size += len(doc)
+		}
+	}
+}
+
+// copyTarget returns the target of the copy of the request headers. The target project defaults to the project of
+// the collection, the target branch to the main branch of another project or to the branch of the collection and the
+// target collection to the collection.
+func copyTarget(ctx context.Context, project string, branch string, collection string) *api.CopyCollectionResponse {
+	target := &api.CopyCollectionResponse{
+		Project:    api.GetHeader(ctx, api.HeaderCopyTargetProject),
+		Branch:     api.GetHeader(ctx, api.HeaderCopyTargetBranch),
+		Collection: api.GetHeader(ctx, api.HeaderCopyTargetCollection),
+	}
+	if target.Project == "" {
+		target.Project = project
+		if target.Branch == "" {
+			target.Branch = branch
+		}
+	}
+	if target.Collection == "" {
+		target.Collection = collection
+	}
+
+	return target
+}
@@ -0,0 +1,58 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCopyTarget(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		headers []string
+		exp     *api.CopyCollectionResponse
+	}{
+		{
+			"same_branch",
+			[]string{api.HeaderCopyTargetCollection, "c2"},
+			&api.CopyCollectionResponse{Project: "p1", Branch: "b1", Collection: "c2"},
+		},
+		{
+			"other_branch",
+			[]string{api.HeaderCopyTargetBranch, "b2"},
+			&api.CopyCollectionResponse{Project: "p1", Branch: "b2", Collection: "c1"},
+		},
+		{
+			"other_project",
+			[]string{api.HeaderCopyTargetProject, "p2"},
+			&api.CopyCollectionResponse{Project: "p2", Collection: "c1"},
+		},
+		{
+			"other_project_branch",
+			[]string{api.HeaderCopyTargetProject, "p2", api.HeaderCopyTargetBranch, "b2"},
+			&api.CopyCollectionResponse{Project: "p2", Branch: "b2", Collection: "c1"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(c.headers...))
+			require.Equal(t, c.exp, copyTarget(ctx, "p1", "b1", "c1"))
+		})
+	}
+}
@@ -16,7 +16,11 @@ package database
 
 import (
 	"context"
+	"strconv"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/schema"
@@ -26,6 +30,8 @@ import (
 	"github.com/tigrisdata/tigris/server/request"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
 )
 
 type CollectionQueryRunner struct {
@@ -102,7 +108,8 @@ func (runner *CollectionQueryRunner) createOrUpdate(ctx context.Context, tx tran
 	}
 
 	var oldMetadata *metadata.CollectionMetadata
-	if db.GetCollection(req.GetCollection()) == nil {
+	existing := db.GetCollection(req.GetCollection())
+	if existing == nil {
 		collectionExists = true
 		oldMetadata, err = tenant.GetCollectionMetadata(ctx, tx, db, req.GetCollection())
 		if err != nil && err != errors.ErrNotFound {
@@ -115,7 +122,19 @@ func (runner *CollectionQueryRunner) createOrUpdate(ctx context.Context, tx tran
 		return Response{}, ctx, errors.AlreadyExists("collection already exist")
 	}
 
-	schFactory, err := schema.NewFactoryBuilder(true).Build(req.GetCollection(), req.GetSchema())
+	reqSchema := req.GetSchema()
+	if templateName := schema.GetTemplateName(reqSchema); len(templateName) > 0 {
+		template, err := tenant.GetSchemaTemplate(ctx, tx, req.GetProject(), templateName)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		if reqSchema, err = schema.ApplyTemplate(reqSchema, template.Schema, false); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	schFactory, err := schema.NewFactoryBuilder(true).Build(req.GetCollection(), reqSchema)
 	if err != nil {
 		return Response{}, ctx, err
 	}
@@ -165,10 +184,61 @@ func (runner *CollectionQueryRunner) createOrUpdate(ctx context.Context, tx tran
 		}
 	} else {
 		countDDLUpdateUnit(ctx, true)
+
+		if building := newWriteModeIndexes(existing, db.GetCollection(req.GetCollection())); len(building) > 0 {
+			if config.DefaultConfig.SecondaryIndex.WriteEnabled && config.DefaultConfig.SecondaryIndex.BackgroundBuild {
+				runner.buildIndexesOnCommit(tx, tenant, db, req.GetProject(), req.GetBranch(), db.GetCollection(req.GetCollection()), building)
+			}
+		}
 	}
+
+	if existing != nil && existing.Compression != schFactory.Compression {
+		runner.recompressOnCommit(tx, tenant, req.GetProject(), req.GetBranch(), db.GetCollection(req.GetCollection()))
+	}
+
 	return Response{Status: CreatedStatus}, ctx, nil
 }
 
+// recompressOnCommit rewrites the documents of the collection in the background with the new compression setting once
+// the change of the setting is committed.
+func (runner *CollectionQueryRunner) recompressOnCommit(tx transaction.Tx, tenant *metadata.Tenant, project string, branch string, coll *schema.DefaultCollection) {
+	recompressor := NewRecompressor(runner.BaseQueryRunner, tenant, project, branch, coll)
+
+	tx.Context().OnCommit(func() {
+		go func() {
+			if err := recompressor.Run(context.Background()); err != nil {
+				log.Err(err).Msgf("Failed to recompress collection '%s'", coll.Name)
+			}
+		}()
+	})
+}
+
+// buildIndexesOnCommit builds the indexes added by the schema update in the background once the update is committed.
+func (runner *CollectionQueryRunner) buildIndexesOnCommit(tx transaction.Tx, tenant *metadata.Tenant, db *metadata.Database, project string, branch string, coll *schema.DefaultCollection, indexes []string) {
+	builder := NewIndexBuilder(runner.BaseQueryRunner, tenant, db, project, branch, coll, indexes)
+
+	tx.Context().OnCommit(func() {
+		go func() {
+			if err := builder.Run(context.Background()); err != nil {
+				log.Err(err).Msgf("Failed to build the indexes %v of collection '%s'", indexes, coll.Name)
+			}
+		}()
+	})
+}
+
+// newWriteModeIndexes returns the names of the indexes of the updated collection that are not in the existing one and
+// need to be built.
+func newWriteModeIndexes(existing *schema.DefaultCollection, updated *schema.DefaultCollection) []string {
+	var names []string
+	for _, index := range updated.SecondaryIndexes.All {
+		if index.State == schema.INDEX_WRITE_MODE && !schema.HasIndex(existing.SecondaryIndexes.All, index) {
+			names = append(names, index.Name)
+		}
+	}
+
+	return names
+}
+
 func (runner *CollectionQueryRunner) list(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
 	db, err := runner.getDatabase(ctx, tx, tenant, runner.listReq.GetProject(), runner.listReq.GetBranch())
 	if err != nil {
@@ -213,6 +283,39 @@ func (runner *CollectionQueryRunner) describe(ctx context.Context, tx transactio
 	metrics.UpdateCollectionSizeMetrics(namespace, tenantName, db.DbName(), db.BranchName(), coll.GetName(), size.StoredBytes)
 	sch := coll.Schema
 
+	version, err := request.GetSchemaVersion(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if version == 0 {
+		version = coll.GetVersion()
+	} else if version != coll.GetVersion() {
+		historical, err := tenant.GetCollectionSchemaVersion(ctx, tx, db, coll.GetName(), version)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		sch = historical.Schema
+	}
+
+	// let the caller know which version of the schema is returned
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderSchemaVersion, strconv.FormatUint(uint64(version), 10)))
+
+	// the usage of the indexes isn't part of the CollectionIndex message, so it is returned in a header
+	if len(coll.SecondaryIndexes.All) > 0 {
+		if usage, err := jsoniter.Marshal(indexUsageStats(coll, 0, time.Now())); err == nil {
+			_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderIndexUsage, string(usage)))
+		}
+	}
+
+	// the storage stats don't fit the Size of the response either, they are returned in a header as well
+	storage, err := collectionStorageStats(ctx, tx, coll, size)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if encoded, err := jsoniter.Marshal(storage); err == nil {
+		_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderCollectionStats, string(encoded)))
+	}
+
 	// Generate schema in the requested language format
 	if runner.describeReq.SchemaFormat != "" {
 		sch, err = schema.Generate(sch, runner.describeReq.SchemaFormat)
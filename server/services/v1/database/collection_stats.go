@@ -0,0 +1,67 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// collectionStorageStats returns the storage used by the collection, the stats of its table along with the estimated
+// size of each of its secondary indexes. The size of an index is estimated from the range of its entries, the
+// storage only returns a size once the range is large enough to be sampled.
+func collectionStorageStats(ctx context.Context, tx transaction.Tx, coll *schema.DefaultCollection, size *kv.TableStats) (*api.CollectionStorageStats, error) {
+	stats := newCollectionStorageStats(size)
+
+	indexer := newSecondaryIndexerImpl(coll)
+	for _, index := range coll.SecondaryIndexes.All {
+		indexSize, err := indexer.IndexSizeOf(ctx, tx, index.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.IndexesSize += indexSize
+		stats.Indexes = append(stats.Indexes, &api.IndexStorageStats{Name: index.Name, Size: indexSize})
+	}
+
+	return stats, nil
+}
+
+func newCollectionStorageStats(size *kv.TableStats) *api.CollectionStorageStats {
+	stats := &api.CollectionStorageStats{
+		Documents:   size.RowCount,
+		StoredBytes: size.StoredBytes,
+		OnDiskSize:  size.OnDiskSize,
+		Indexes:     []*api.IndexStorageStats{},
+	}
+
+	// the counters are sharded and added to by the concurrent writes, a collection being emptied may be briefly seen
+	// with negative counters
+	if stats.Documents < 0 {
+		stats.Documents = 0
+	}
+	if stats.StoredBytes < 0 {
+		stats.StoredBytes = 0
+	}
+	if stats.Documents > 0 {
+		stats.AverageDocumentSize = stats.StoredBytes / stats.Documents
+	}
+
+	return stats
+}
@@ -0,0 +1,47 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestNewCollectionStorageStats(t *testing.T) {
+	cases := []struct {
+		size     *kv.TableStats
+		expected *api.CollectionStorageStats
+	}{
+		{
+			&kv.TableStats{},
+			&api.CollectionStorageStats{Indexes: []*api.IndexStorageStats{}},
+		}, {
+			&kv.TableStats{RowCount: 4, StoredBytes: 410, OnDiskSize: 2048},
+			&api.CollectionStorageStats{
+				Documents: 4, StoredBytes: 410, OnDiskSize: 2048, AverageDocumentSize: 102,
+				Indexes: []*api.IndexStorageStats{},
+			},
+		}, {
+			&kv.TableStats{RowCount: -1, StoredBytes: -100, OnDiskSize: 100},
+			&api.CollectionStorageStats{OnDiskSize: 100, Indexes: []*api.IndexStorageStats{}},
+		},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expected, newCollectionStorageStats(c.size))
+	}
+}
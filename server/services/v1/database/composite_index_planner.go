@@ -0,0 +1,252 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
+)
+
+// compositeIndexPlan is a query plan on a composite index. The fields are the number of the leading fields of the
+// index the filter narrows the scan with.
+type compositeIndexPlan struct {
+	plan   *filter.QueryPlan
+	fields int
+	// expression is set for a plan on an expression index, the filters on the expression can't use another index
+	expression bool
+}
+
+// buildCompositeIndexPlan returns the plan on the active composite index which narrows the scan with the most fields.
+// A composite index can be used if the filter has equalities on a prefix of its fields, optionally followed by a range
+// on the next field, and the requested sort, if any, is on the fields following the prefix in the order of the index
+// or in the exact reverse order. It returns nil if no composite index can be used.
+func buildCompositeIndexPlan(coll *schema.DefaultCollection, queryFilters []filter.Filter, sorting *sort.Ordering) *compositeIndexPlan {
+	selectors := andSelectors(queryFilters)
+
+	var best *compositeIndexPlan
+	for _, index := range coll.GetActiveCompositeIndexes() {
+		if index.CaseInsensitive {
+			// the keys are case folded, see buildCaseInsensitiveIndexKeys
+			continue
+		}
+
+		p := planCompositeIndex(coll, index, selectors, sorting)
+		if p != nil && (best == nil || p.fields > best.fields) {
+			best = p
+		}
+	}
+
+	return best
+}
+
+// andSelectors returns the selectors that every document returned by the filter matches.
+func andSelectors(queryFilters []filter.Filter) []*filter.Selector {
+	var selectors []*filter.Selector
+	for _, f := range queryFilters {
+		switch ff := f.(type) {
+		case *filter.Selector:
+			selectors = append(selectors, ff)
+		case filter.LogicalFilter:
+			if ff.Type() == filter.AndOP {
+				selectors = append(selectors, andSelectors(ff.GetFilters())...)
+			}
+		}
+	}
+
+	return selectors
+}
+
+func planCompositeIndex(coll *schema.DefaultCollection, index *schema.Index, selectors []*filter.Selector, sorting *sort.Ordering) *compositeIndexPlan {
+	prefix := []any{index.Name}
+
+	eq := 0
+	for ; eq < len(index.Fields); eq++ {
+		sel := findSelector(selectors, index.Fields[eq].FieldName, filter.EQ)
+		if sel == nil || !compositeIndexComparesValue(index, sel.Matcher.GetValue()) {
+			break
+		}
+		prefix = append(prefix, compositeIndexParts(index, eq, sel.Matcher.GetValue())...)
+	}
+
+	reverse, ok := compositeIndexScanOrder(index, eq, sorting)
+	if !ok {
+		return nil
+	}
+
+	begin := append([]any{}, prefix...)
+	end := append(append([]any{}, prefix...), 0xFF)
+
+	fields := eq
+	if eq < len(index.Fields) {
+		var (
+			lower, upper bool
+			typeOrder    any
+		)
+		for _, sel := range selectors {
+			if sel.Field.Name() != index.Fields[eq].FieldName || !compositeIndexComparesValue(index, sel.Matcher.GetValue()) {
+				continue
+			}
+
+			var greater bool
+			switch sel.Matcher.Type() {
+			case filter.GT, filter.GTE:
+				greater = true
+			case filter.LT, filter.LTE:
+			default:
+				continue
+			}
+			exclusive := sel.Matcher.Type() == filter.GT || sel.Matcher.Type() == filter.LT
+			parts := compositeIndexParts(index, eq, sel.Matcher.GetValue())
+			bound := append(append([]any{}, prefix...), parts...)
+			typeOrder = parts[0]
+
+			// the keys of a descending field are ordered in reverse, so a greater value is a lower key
+			if greater != index.IsDescending(eq) {
+				if lower {
+					continue
+				}
+				if exclusive {
+					bound = append(bound, 0xFF)
+				}
+				begin, lower = bound, true
+			} else {
+				if upper {
+					continue
+				}
+				if !exclusive {
+					bound = append(bound, 0xFF)
+				}
+				end, upper = bound, true
+			}
+		}
+		// a range open on one side is still bounded by the values of the same type
+		if lower && !upper {
+			end = append(append([]any{}, prefix...), typeOrder, 0xFF)
+		}
+		if upper && !lower {
+			begin = append(append([]any{}, prefix...), typeOrder)
+		}
+		if lower || upper {
+			fields++
+		}
+	}
+
+	if fields == 0 && (sorting == nil || len(*sorting) == 0) {
+		return nil
+	}
+
+	encode := func(parts []any) keys.Key {
+		return keys.NewKey(coll.EncodedTableIndexName, append([]any{coll.SecondaryIndexKeyword(), KVSubspace}, parts...)...)
+	}
+
+	return &compositeIndexPlan{
+		plan: &filter.QueryPlan{
+			QueryType: filter.RANGE,
+			FieldName: index.Name,
+			DataType:  schema.UnknownType,
+			Keys:      []keys.Key{encode(begin), encode(end)},
+			Ascending: !reverse,
+			IndexType: filter.SecondaryIndex,
+		},
+		fields:     fields,
+		expression: index.Expression != nil,
+	}
+}
+
+func findSelector(selectors []*filter.Selector, field string, matcher string) *filter.Selector {
+	for _, sel := range selectors {
+		if sel.Field.Name() == field && sel.Matcher.Type() == matcher {
+			return sel
+		}
+	}
+
+	return nil
+}
+
+// compositeIndexComparesValue returns false for a date time if the keys of the index have the legacy encoding, see
+// comparesDateTimes.
+func compositeIndexComparesValue(index *schema.Index, val value.Value) bool {
+	return val.DataType() != schema.DateTimeType || keyencoding.ComparesDateTimes(index)
+}
+
+// compositeIndexParts returns the parts of the key of the composite index for the value of the field at the position.
+func compositeIndexParts(index *schema.Index, pos int, val value.Value) []any {
+	if s, ok := val.(*value.StringValue); ok && index.CaseInsensitive {
+		val = value.NewStringValue(value.FoldCase(s.Value), nil)
+	}
+
+	parts := keyencoding.ForIndex(index).IndexParts(val.DataType(), val)
+	if index.IsDescending(pos) {
+		return keyencoding.Descending(parts)
+	}
+
+	return parts
+}
+
+// compositeIndexScanOrder returns whether the index scan needs to be reversed to return the rows in the requested
+// order. The fields compared for equality have a single value, so the sort on them is ignored, the other sort fields
+// must follow the equality prefix in the order of the index fields, all in the order of the index or all reversed.
+func compositeIndexScanOrder(index *schema.Index, eq int, sorting *sort.Ordering) (bool, bool) {
+	if sorting == nil {
+		return false, true
+	}
+
+	var (
+		reverse bool
+		pos     = eq
+	)
+	for _, sf := range *sorting {
+		i := schema.FindIndexField(index, sf.Name)
+		if i >= 0 && i < eq {
+			continue
+		}
+		if i != pos {
+			return false, false
+		}
+
+		fieldReverse := sf.Ascending == index.IsDescending(i)
+		if pos > eq && fieldReverse != reverse {
+			return false, false
+		}
+		reverse = fieldReverse
+		pos++
+	}
+
+	return reverse, true
+}
+
+// primaryKeyPos returns the position of the primary key in the keys of the index of the plan.
+func primaryKeyPos(coll *schema.DefaultCollection, plan *filter.QueryPlan) int {
+	return indexPrimaryKeyPos(schema.FindIndex(coll.SecondaryIndexes.All, plan.FieldName))
+}
+
+// indexPrimaryKeyPos returns the position of the primary key in the keys of the index.
+func indexPrimaryKeyPos(index *schema.Index) int {
+	pos := PrimaryKeyPos
+	if index != nil && index.IsComposite() {
+		// the keyword, the subspace and the name are followed by the parts of the fields and the position
+		pos = 3 + 2*len(index.Fields) + 1
+	}
+	if index != nil && index.IsSharded() {
+		// the shard follows the name
+		pos++
+	}
+
+	return pos
+}
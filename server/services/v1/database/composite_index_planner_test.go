@@ -0,0 +1,237 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
+)
+
+var compositeSchema = []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"city": { "type": "string" },
+		"age": { "type": "integer" },
+		"name": { "type": "string" }
+	},
+	"primary_key": ["id"],
+	"indexes": [
+		{ "name": "city_age", "fields": [{ "field": "city" }, { "field": "age", "sort": "desc" }] }
+	]
+}`)
+
+func compositeKey(city any, age any, pk int) []any {
+	encoder := keyencoding.Get(keyencoding.Current)
+
+	parts := []any{"skey", KVSubspace, "city_age"}
+	if city == nil {
+		parts = append(parts, encoder.IndexParts(schema.NullType, value.NewNullValue())...)
+	} else {
+		parts = append(parts, encoder.IndexParts(schema.StringType, value.NewStringValue(city.(string), value.NewSortKeyCollation()))...)
+	}
+	if age == nil {
+		parts = append(parts, keyencoding.Descending(encoder.IndexParts(schema.NullType, value.NewNullValue()))...)
+	} else {
+		parts = append(parts, keyencoding.Descending(encoder.IndexParts(schema.Int64Type, value.NewIntValue(int64(age.(int)))))...)
+	}
+
+	return append(parts, 0, pk)
+}
+
+func TestCompositeIndexKeys(t *testing.T) {
+	indexer := setupActiveIndexTest(t, compositeSchema)
+
+	td, pk := createDoc(`{"id":1, "city":"sf", "age":30, "name":"a"}`)
+	t.Run("insert", func(t *testing.T) {
+		updateSet, err := indexer.buildAddAndRemoveKVs(td, nil, pk)
+		require.NoError(t, err)
+		require.Equal(t, [][]any{compositeKey("sf", 30, 1)}, indexKeyParts(updateSet.addKeys, "city_age"))
+	})
+
+	t.Run("update not indexed field", func(t *testing.T) {
+		updateTD, _ := createDoc(`{"id":1, "city":"sf", "age":30, "name":"b"}`)
+		updateSet, err := indexer.buildAddAndRemoveKVs(updateTD, td, pk)
+		require.NoError(t, err)
+		require.Empty(t, indexKeyParts(updateSet.addKeys, "city_age"))
+		require.Empty(t, indexKeyParts(updateSet.removeKeys, "city_age"))
+	})
+
+	t.Run("update indexed field", func(t *testing.T) {
+		updateTD, _ := createDoc(`{"id":1, "city":"sf", "age":31, "name":"a"}`)
+		updateSet, err := indexer.buildAddAndRemoveKVs(updateTD, td, pk)
+		require.NoError(t, err)
+		require.Equal(t, [][]any{compositeKey("sf", 31, 1)}, indexKeyParts(updateSet.addKeys, "city_age"))
+		require.Equal(t, [][]any{compositeKey("sf", 30, 1)}, indexKeyParts(updateSet.removeKeys, "city_age"))
+	})
+
+	t.Run("missing and null", func(t *testing.T) {
+		missingTD, _ := createDoc(`{"id":2, "age":null}`)
+		updateSet, err := indexer.buildAddAndRemoveKVs(missingTD, nil, []any{2})
+		require.NoError(t, err)
+		require.Equal(t, [][]any{compositeKey(nil, nil, 2)}, indexKeyParts(updateSet.addKeys, "city_age"))
+	})
+}
+
+func TestCompositeIndexPlanner(t *testing.T) {
+	indexer := setupActiveIndexTest(t, compositeSchema)
+	factory := newSecondaryIndexFilterFactory(indexer.coll)
+
+	cases := []struct {
+		name      string
+		filter    string
+		sort      string
+		noPlan    bool
+		fields    int
+		ascending bool
+	}{
+		{"eq prefix", `{"city": "sf"}`, ``, false, 1, true},
+		{"eq both", `{"city": "sf", "age": 30}`, ``, false, 2, true},
+		{"eq and range", `{"$and": [{"city": "sf"}, {"age": {"$gt": 30}}]}`, ``, false, 2, true},
+		{"range on first field", `{"city": {"$gte": "a"}}`, ``, false, 1, true},
+		{"not a prefix", `{"age": 30}`, ``, true, 0, false},
+		{"or", `{"$or": [{"city": "sf"}, {"city": "la"}]}`, ``, true, 0, false},
+		{"sort eliminated", `{"city": "sf"}`, `[{"age": "$desc"}]`, false, 1, true},
+		{"sort reversed", `{"city": "sf"}`, `[{"age": "$asc"}]`, false, 1, false},
+		{"sort on all fields", `{}`, `[{"city": "$asc"}, {"age": "$desc"}]`, false, 0, true},
+		{"sort on all fields reversed", `{}`, `[{"city": "$desc"}, {"age": "$asc"}]`, false, 0, false},
+		{"sort mixed direction", `{}`, `[{"city": "$asc"}, {"age": "$asc"}]`, true, 0, false},
+		{"sort not following prefix", `{}`, `[{"age": "$desc"}]`, true, 0, false},
+		{"sort on eq field ignored", `{"city": "sf"}`, `[{"city": "$desc"}, {"age": "$asc"}]`, false, 1, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filters, err := factory.Factorize([]byte(c.filter))
+			require.NoError(t, err)
+
+			var sorting *sort.Ordering
+			if c.sort != "" {
+				sorting, err = sort.UnmarshalSort([]byte(c.sort))
+				require.NoError(t, err)
+			}
+
+			p := buildCompositeIndexPlan(indexer.coll, filters, sorting)
+			if c.noPlan {
+				require.Nil(t, p)
+				return
+			}
+			require.NotNil(t, p)
+			require.Equal(t, c.fields, p.fields)
+			require.Equal(t, c.ascending, p.plan.Ascending)
+			require.Equal(t, "city_age", p.plan.FieldName)
+			require.Equal(t, filter.RANGE, p.plan.QueryType)
+		})
+	}
+}
+
+func TestCompositeIndexScan(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, compositeSchema)
+	coll := indexer.coll
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedTableIndexName))
+	require.NoError(t, kvStore.CreateTable(ctx, coll.EncodedTableIndexName))
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+
+	docs := []string{
+		`{"id":1, "city":"sf", "age":30}`,
+		`{"id":2, "city":"sf", "age":45}`,
+		`{"id":3, "city":"sf", "age":8}`,
+		`{"id":4, "city":"la", "age":30}`,
+		`{"id":5, "city":"sfo", "age":50}`,
+		`{"id":6, "city":"sf"}`,
+		`{"id":7, "city":"sf", "age":30}`,
+	}
+	for i, doc := range docs {
+		td, pk := createDoc(doc, i+1)
+		require.NoError(t, indexer.Index(ctx, tx, td, pk))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	factory := newSecondaryIndexFilterFactory(coll)
+	scan := func(reqFilter string, reqSort string) []int64 {
+		filters, err := factory.Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+
+		var sorting *sort.Ordering
+		if reqSort != "" {
+			sorting, err = sort.UnmarshalSort([]byte(reqSort))
+			require.NoError(t, err)
+		}
+
+		plan, err := BuildSecondaryIndexKeys(coll, filters, sorting)
+		require.NoError(t, err)
+		require.Equal(t, "city_age", plan.FieldName)
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := NewScanIterator(ctx, tx, plan.Keys[0], plan.Keys[1], plan.Reverse())
+		require.NoError(t, err)
+
+		var (
+			row Row
+			pks []int64
+		)
+		for iter.Next(&row) {
+			key, err := keys.FromBinary(coll.EncodedTableIndexName, row.Key)
+			require.NoError(t, err)
+			pks = append(pks, key.IndexParts()[primaryKeyPos(coll, plan)].(int64))
+		}
+		require.NoError(t, iter.Interrupted())
+
+		return pks
+	}
+
+	assert.Equal(t, []int64{2, 1, 7, 3, 6}, scan(`{"city": "sf"}`, ``))
+	assert.Equal(t, []int64{1, 7}, scan(`{"city": "sf", "age": 30}`, ``))
+	assert.Equal(t, []int64{2, 1, 7}, scan(`{"$and": [{"city": "sf"}, {"age": {"$gte": 30}}]}`, ``))
+	assert.Equal(t, []int64{2}, scan(`{"$and": [{"city": "sf"}, {"age": {"$gt": 30}}]}`, ``))
+	assert.Equal(t, []int64{3}, scan(`{"$and": [{"city": "sf"}, {"age": {"$lt": 30}}]}`, ``))
+	assert.Equal(t, []int64{1, 7, 3}, scan(`{"$and": [{"city": "sf"}, {"age": {"$lte": 30}}]}`, ``))
+	assert.Equal(t, []int64{6, 3, 7, 1, 2}, scan(`{"city": "sf"}`, `[{"age": "$asc"}]`))
+	assert.Equal(t, []int64{4, 2, 1, 7, 3, 6, 5}, scan(`{}`, `[{"city": "$asc"}, {"age": "$desc"}]`))
+
+	t.Run("explain", func(t *testing.T) {
+		filters, err := factory.Factorize([]byte(`{"city": "sf", "age": 30}`))
+		require.NoError(t, err)
+		plan, err := BuildSecondaryIndexKeys(coll, filters, nil)
+		require.NoError(t, err)
+
+		explain := buildExplainResp(readerOptions{plan: plan}, coll, nil, nil)
+		require.Equal(t, "city_age", explain.Field)
+		require.Len(t, explain.KeyRange, 2)
+		require.True(t, strings.HasSuffix(explain.KeyRange[0], ",30"), explain.KeyRange[0])
+		require.True(t, strings.HasSuffix(explain.KeyRange[1], ",30,$TIGRIS_MAX"), explain.KeyRange[1])
+	})
+}
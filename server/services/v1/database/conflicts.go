@@ -0,0 +1,111 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/grpc"
+)
+
+// encodedTableNameLen is the length of the table name of a collection, the prefix and the namespace, database and
+// collection ids.
+const encodedTableNameLen = 16
+
+// retryBackoff returns how long to sleep before the next attempt of a conflicting transaction, a random duration up to
+// the exponential backoff of the attempt.
+func retryBackoff(policy *config.TxRetryConfig, attempt int) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := 1; i < attempt && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(backoff))) //nolint:gosec
+}
+
+// conflictError converts the conflict of a transaction which ran out of retries into an Aborted error. The key ranges
+// the transaction conflicted on are attached to the error, with the collection and the document they belong to, so
+// that the users can find the documents contended by the concurrent requests.
+func (sessMgr *SessionManager) conflictError(ctx context.Context, err error, attempts int) error {
+	if !IsErrConflictingTransaction(err) {
+		return err
+	}
+
+	apiErr := api.Errorf(api.Code_ABORTED, "transaction not committed due to conflict with another transaction after %d attempts", attempts)
+
+	ce, ok := err.(*kv.ConflictError)
+	if !ok {
+		return apiErr
+	}
+
+	var operation string
+	if method, ok := grpc.Method(ctx); ok {
+		operation = method[strings.LastIndexByte(method, '/')+1:]
+	}
+
+	for _, r := range ce.Ranges {
+		collection, key := sessMgr.decodeConflictingKey(r.Begin)
+		apiErr.WithDetails(api.NewConflictInfo(&api.ConflictInfo{
+			Operation:  operation,
+			Collection: collection,
+			Key:        key,
+			Begin:      fdb.Printable(r.Begin),
+			End:        fdb.Printable(r.End),
+		}))
+	}
+
+	return apiErr
+}
+
+// decodeConflictingKey returns the collection and the document key, as a JSON array of the key fields, of a key
+// stored in FoundationDB. They are empty if the key doesn't belong to a collection.
+func (sessMgr *SessionManager) decodeConflictingKey(key []byte) (string, string) {
+	if len(key) < encodedTableNameLen || sessMgr.tenantMgr == nil {
+		return "", ""
+	}
+
+	_, collection, ok := sessMgr.tenantMgr.DecodeTableName(key[:encodedTableNameLen])
+	if !ok {
+		return "", ""
+	}
+
+	// the first element is the index of the key
+	parts, err := tuple.Unpack(key[encodedTableNameLen:])
+	if err != nil || len(parts) < 2 {
+		return collection, ""
+	}
+
+	fields, err := json.Marshal(parts[1:])
+	if err != nil {
+		return collection, ""
+	}
+
+	return collection, string(fields)
+}
@@ -0,0 +1,169 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"strings"
+
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/read"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+)
+
+// useCoveringIndex switches the secondary index read to a composite index with included fields if there is one that
+// covers the read, see buildCoveringIndexPlan.
+func (*BaseQueryRunner) useCoveringIndex(coll *schema.DefaultCollection, reqFilter []byte, sorting *sort.Ordering, options *readerOptions) {
+	if !projectionCoverable(options.fieldFactory) {
+		return
+	}
+
+	filters, err := newSecondaryIndexFilterFactory(coll).Factorize(reqFilter)
+	if err != nil {
+		return
+	}
+
+	if plan := buildCoveringIndexPlan(coll, options.plan, filters, sorting, options.filter, options.fieldFactory); plan != nil {
+		options.plan, options.covered = plan, true
+	}
+}
+
+// buildCoveringIndexPlan returns the plan on a composite index with included fields that covers the read, i.e. the
+// index entries have all the fields the read projects and filters on, so the documents are returned from the index
+// without reading them. The index the secondary index plan is on is used if it covers the read, otherwise a covering
+// index narrowing the scan with at least one field is preferred as it saves reading the documents. It returns nil if
+// no index covers the read.
+func buildCoveringIndexPlan(coll *schema.DefaultCollection, plan *filter.QueryPlan, queryFilters []filter.Filter,
+	sorting *sort.Ordering, wrapped *filter.WrappedFilter, fieldFactory *read.FieldFactory,
+) *filter.QueryPlan {
+	if !projectionCoverable(fieldFactory) {
+		return nil
+	}
+
+	if plan != nil {
+		if index := schema.FindIndex(coll.SecondaryIndexes.All, plan.FieldName); index != nil && index.CaseInsensitive {
+			// a case-insensitive read is only served by the case-insensitive index it is planned on
+			covered := coveredFields(coll, index)
+			if projectionCovered(fieldFactory, covered) && filterCovered(wrapped.Filter, covered) {
+				return plan
+			}
+			return nil
+		}
+	}
+
+	selectors := andSelectors(queryFilters)
+
+	var best *compositeIndexPlan
+	for _, index := range coll.GetActiveCompositeIndexes() {
+		if len(index.Include) == 0 || index.CaseInsensitive {
+			continue
+		}
+
+		covered := coveredFields(coll, index)
+		if !projectionCovered(fieldFactory, covered) || !filterCovered(wrapped.Filter, covered) {
+			continue
+		}
+
+		if plan != nil && plan.FieldName == index.Name {
+			return plan
+		}
+
+		p := planCompositeIndex(coll, index, selectors, sorting)
+		if p != nil && (best == nil || p.fields > best.fields) {
+			best = p
+		}
+	}
+
+	if best == nil || (best.fields == 0 && !wrapped.None()) {
+		return nil
+	}
+
+	return best.plan
+}
+
+// coveredFields returns the paths of the fields the entries of the index have.
+func coveredFields(coll *schema.DefaultCollection, index *schema.Index) map[string]struct{} {
+	covered := make(map[string]struct{})
+	for _, f := range index.Fields {
+		covered[f.FieldName] = struct{}{}
+	}
+	for _, path := range index.Include {
+		covered[path] = struct{}{}
+	}
+	for _, f := range coll.GetPrimaryKey().Fields {
+		covered[f.FieldName] = struct{}{}
+	}
+
+	return covered
+}
+
+// isCoveredPath returns true if the field or one of its parents is covered. The reserved fields are covered as the
+// index entries keep the attributes of the documents.
+func isCoveredPath(covered map[string]struct{}, path string) bool {
+	if schema.IsReservedField(path) {
+		return true
+	}
+
+	for {
+		if _, ok := covered[path]; ok {
+			return true
+		}
+
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
+
+// projectionCoverable returns true if the read projects only some fields, a read of the whole documents or excluding
+// fields can't be covered.
+func projectionCoverable(fieldFactory *read.FieldFactory) bool {
+	return fieldFactory != nil && len(fieldFactory.Include) > 0 && len(fieldFactory.Exclude) == 0
+}
+
+func projectionCovered(fieldFactory *read.FieldFactory, covered map[string]struct{}) bool {
+	for _, f := range fieldFactory.Include {
+		field, ok := f.(*read.SimpleField)
+		if !ok || !isCoveredPath(covered, field.Name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func filterCovered(f filter.Filter, covered map[string]struct{}) bool {
+	switch ff := f.(type) {
+	case *filter.EmptyFilter:
+		return true
+	case *filter.Selector:
+		return isCoveredPath(covered, ff.Field.Name()) && (ff.Parent == nil || isCoveredPath(covered, ff.Parent.Name()))
+	case *filter.LikeFilter:
+		return isCoveredPath(covered, ff.Field.Name())
+	case *filter.DatePartFilter:
+		return isCoveredPath(covered, ff.Field.Name())
+	case filter.LogicalFilter:
+		for _, child := range ff.GetFilters() {
+			if !filterCovered(child, covered) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
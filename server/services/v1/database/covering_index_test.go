@@ -0,0 +1,162 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/read"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+var coveringSchema = []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"city": { "type": "string" },
+		"age": { "type": "integer" },
+		"name": { "type": "string" },
+		"address": { "type": "object", "properties": { "zip": { "type": "string" } } },
+		"bio": { "type": "string" }
+	},
+	"primary_key": ["id"],
+	"indexes": [
+		{ "name": "city_age", "fields": [{ "field": "city" }, { "field": "age" }], "include": ["name", "address"] }
+	]
+}`)
+
+func TestCoveringIndexEntries(t *testing.T) {
+	indexer := setupActiveIndexTest(t, coveringSchema)
+
+	td, pk := createDoc(`{"id":1, "city":"sf", "age":30, "name":"a b", "address":{"zip":"94107"}, "bio":"long"}`)
+	updateSet, err := indexer.buildAddAndRemoveKVs(td, nil, pk)
+	require.NoError(t, err)
+	require.Len(t, updateSet.addData, 1)
+	for _, data := range updateSet.addData {
+		require.JSONEq(t, `{"id":1, "city":"sf", "age":30, "name":"a b", "address":{"zip":"94107"}}`, string(data.RawData))
+		require.Equal(t, td.CreatedAt, data.CreatedAt)
+		require.Equal(t, td.UpdatedAt, data.UpdatedAt)
+	}
+
+	t.Run("update included field", func(t *testing.T) {
+		updateTD := td.CloneWithAttributesOnly([]byte(`{"id":1, "city":"sf", "age":30, "name":"c", "bio":"long"}`))
+		updateSet, err := indexer.buildAddAndRemoveKVs(updateTD, td, pk)
+		require.NoError(t, err)
+		require.Len(t, indexKeyParts(updateSet.addKeys, "city_age"), 1)
+		require.Len(t, indexKeyParts(updateSet.removeKeys, "city_age"), 1)
+		require.Len(t, updateSet.addData, 1)
+	})
+
+	t.Run("update not covered field", func(t *testing.T) {
+		updateTD := td.CloneWithAttributesOnly([]byte(`{"id":1, "city":"sf", "age":30, "name":"a b", "address":{"zip":"94107"}, "bio":"short"}`))
+		updateSet, err := indexer.buildAddAndRemoveKVs(updateTD, td, pk)
+		require.NoError(t, err)
+		require.Empty(t, indexKeyParts(updateSet.addKeys, "city_age"))
+		require.Empty(t, indexKeyParts(updateSet.removeKeys, "city_age"))
+	})
+}
+
+func TestCoveringIndexPlan(t *testing.T) {
+	indexer := setupActiveIndexTest(t, coveringSchema)
+	coll := indexer.coll
+
+	plan := func(reqFilter string, fields string) *filter.QueryPlan {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+		fieldFactory, err := read.BuildFields([]byte(fields))
+		require.NoError(t, err)
+
+		return buildCoveringIndexPlan(coll, nil, filters, nil, wrapped, fieldFactory)
+	}
+
+	require.Equal(t, "city_age", plan(`{"city": "sf"}`, `{"name": true, "id": true}`).FieldName)
+	require.Equal(t, "city_age", plan(`{"city": "sf", "age": {"$gt": 3}}`, `{"age": true, "address": true}`).FieldName)
+	require.Nil(t, plan(`{"city": "sf"}`, ``))
+	require.Nil(t, plan(`{"city": "sf"}`, `{"bio": false}`))
+	require.Nil(t, plan(`{"city": "sf"}`, `{"bio": true}`))
+
+	covered := coveredFields(coll, schema.FindIndex(coll.SecondaryIndexes.All, "city_age"))
+	filterCoveredBy := func(reqFilter string) bool {
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+
+		return filterCovered(wrapped.Filter, covered)
+	}
+	require.True(t, filterCoveredBy(`{"$or": [{"city": "sf"}, {"address.zip": "94107"}]}`))
+	require.True(t, filterCoveredBy(`{"_tigris_created_at": {"$gt": "2023-01-01T00:00:00Z"}}`))
+	require.False(t, filterCoveredBy(`{"$and": [{"city": "sf"}, {"bio": "long"}]}`))
+}
+
+func TestCoveringIndexReader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, coveringSchema)
+	coll := indexer.coll
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedName))
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedTableIndexName))
+	require.NoError(t, kvStore.CreateTable(ctx, coll.EncodedTableIndexName))
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+
+	docs := []string{
+		`{"id":1, "city":"sf", "age":30, "name":"a", "bio":"x"}`,
+		`{"id":2, "city":"la", "age":45, "name":"b", "bio":"y"}`,
+		`{"id":3, "city":"sf", "age":8, "name":"c", "bio":"z"}`,
+	}
+	for i, doc := range docs {
+		td, pk := createDoc(doc, i+1)
+		// only the index is written, so the documents can only be read from the index entries
+		require.NoError(t, indexer.Index(ctx, tx, td, pk))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(`{"city": "sf"}`))
+	require.NoError(t, err)
+	plan, err := BuildSecondaryIndexKeys(coll, filters, nil)
+	require.NoError(t, err)
+
+	tx, err = tm.StartTx(ctx)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := NewCoveringIndexReader(ctx, tx, coll, filter.NewWrappedFilter(filters), plan)
+	require.NoError(t, err)
+
+	var (
+		row  Row
+		read []string
+	)
+	for iter.Next(&row) {
+		read = append(read, string(row.Data.RawData))
+		require.NotNil(t, row.Data.CreatedAt)
+	}
+	require.NoError(t, iter.Interrupted())
+	require.Len(t, read, 2)
+	require.JSONEq(t, `{"id":3, "city":"sf", "age":8, "name":"c"}`, read[0])
+	require.JSONEq(t, `{"id":1, "city":"sf", "age":30, "name":"a"}`, read[1])
+
+	explain := buildExplainResp(readerOptions{plan: plan, covered: true}, coll, nil, nil)
+	require.Equal(t, COVERING, explain.ReadType)
+}
@@ -198,12 +198,23 @@ func (runner *BranchQueryRunner) SetListBranchReq(listBranch *api.ListBranchesRe
 func (runner *BranchQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
 	switch {
 	case runner.createBranch != nil:
-		dbBranch := metadata.NewDatabaseNameWithBranch(runner.createBranch.GetProject(), runner.createBranch.GetBranch())
-		err := tenant.CreateBranch(ctx, tx, runner.createBranch.GetProject(), dbBranch)
+		expiresAfter, err := request.GetBranchExpiresAfter(ctx)
 		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		dbBranch := metadata.NewDatabaseNameWithBranch(runner.createBranch.GetProject(), runner.createBranch.GetBranch())
+		if err = tenant.CreateBranch(ctx, tx, runner.createBranch.GetProject(), dbBranch); err != nil {
 			return Response{}, ctx, CreateApiError(err)
 		}
 
+		if expiresAfter > 0 {
+			expiresAt := time.Now().Add(expiresAfter).Unix()
+			if err = tenant.SetBranchExpiration(ctx, tx, runner.createBranch.GetProject(), dbBranch, expiresAt); err != nil {
+				return Response{}, ctx, CreateApiError(err)
+			}
+		}
+
 		countDDLCreateUnit(ctx)
 
 		return Response{
@@ -0,0 +1,121 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/doccache"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// docCacheCollection returns the collection of the read if its documents are served by the document cache, nil
+// otherwise. The reads in an explicit transaction or of a snapshot always read the storage.
+func docCacheCollection(ctx context.Context, tenant *metadata.Tenant, db *metadata.Database, req *api.ReadRequest) *doccache.Collection {
+	if api.GetTransaction(ctx) != nil || kv.HasReadVersion(ctx) {
+		return nil
+	}
+
+	coll := &doccache.Collection{
+		NamespaceId: tenant.GetNamespace().Id(),
+		Namespace:   tenant.GetNamespace().StrId(),
+		Project:     req.GetProject(),
+		Branch:      db.BranchName(),
+		Database:    db.Name(),
+		Name:        req.GetCollection(),
+	}
+	if doccache.Get(coll) == nil {
+		return nil
+	}
+
+	return coll
+}
+
+// CachedKeyIterator returns the documents of the keys from the document cache, the documents missing from the cache
+// are read from the storage and cached. The documents read at a stale version are not cached.
+type CachedKeyIterator struct {
+	ctx   context.Context
+	tx    transaction.Tx
+	cache *doccache.Cache
+	coll  *doccache.Collection
+	keys  []keys.Key
+	keyId int
+	rows  []Row
+	err   error
+}
+
+func NewCachedKeyIterator(ctx context.Context, tx transaction.Tx, coll *doccache.Collection, keys []keys.Key) *CachedKeyIterator {
+	return &CachedKeyIterator{
+		ctx:   ctx,
+		tx:    tx,
+		cache: doccache.Get(coll),
+		coll:  coll,
+		keys:  keys,
+	}
+}
+
+func (k *CachedKeyIterator) Next(row *Row) bool {
+	for len(k.rows) == 0 {
+		if k.err != nil || k.keyId == len(k.keys) {
+			return false
+		}
+
+		k.rows, k.err = k.read(k.keys[k.keyId])
+		k.keyId++
+	}
+
+	*row = k.rows[0]
+	k.rows = k.rows[1:]
+
+	return true
+}
+
+// read returns the rows of the key, from the cache if the document is cached.
+func (k *CachedKeyIterator) read(key keys.Key) ([]Row, error) {
+	doc, token := k.cache.Lookup(k.ctx, k.coll, key)
+	if doc != nil {
+		return []Row{{Key: key.SerializeToBytes(), Data: doc}}, nil
+	}
+
+	it, err := k.tx.Read(k.ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rows     []Row
+		keyValue kv.KeyValue
+	)
+	for it.Next(&keyValue) {
+		rows = append(rows, Row{Key: keyValue.FDBKey, Data: keyValue.Data})
+	}
+	if err = it.Err(); err != nil {
+		return nil, err
+	}
+
+	// only the document of the exact key is cached, the key of the plan may be a prefix of the keys of the documents
+	if len(rows) == 1 && bytes.Equal(rows[0].Key, key.SerializeToBytes()) && !kv.HasMaxStaleness(k.ctx) {
+		k.cache.Fill(k.ctx, key, rows[0].Data, token)
+	}
+
+	return rows, nil
+}
+
+func (k *CachedKeyIterator) Interrupted() error { return k.err }
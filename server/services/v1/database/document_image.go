@@ -0,0 +1,133 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// documentImages collects the documents modified by a write, as they were before or after the write, when the caller
+// asks for them with the Tigris-Return-Document header. The documents are decrypted, upgraded to the latest schema and
+// masked the same way the reads return them. A nil documentImages collects nothing.
+type documentImages struct {
+	coll    *schema.DefaultCollection
+	image   string
+	decrypt bool
+	masker  *schema.FieldMasker
+	max     int
+	docs    []jsoniter.RawMessage
+}
+
+func newDocumentImages(ctx context.Context, coll *schema.DefaultCollection) (*documentImages, error) {
+	image, err := request.GetReturnDocument(ctx)
+	if err != nil || image == "" {
+		return nil, err
+	}
+
+	images := &documentImages{
+		coll:    coll,
+		image:   image,
+		decrypt: coll.HasEncryptedFields() && request.CanDecryptFields(ctx),
+		max:     config.DefaultConfig.Server.MaxReturnedDocuments,
+		docs:    []jsoniter.RawMessage{},
+	}
+	if role, ok := request.GetCallerRole(ctx); ok {
+		images.masker = coll.NewFieldMasker(role)
+	}
+
+	return images, nil
+}
+
+// add collects the image of a modified document. The before image of a document which didn't exist is returned as
+// null.
+func (images *documentImages) add(before *internal.TableData, after *internal.TableData) error {
+	if images == nil {
+		return nil
+	}
+
+	if len(images.docs) >= images.max {
+		return errors.InvalidArgument("the write modifies more than %d documents, set a limit to return its documents",
+			images.max)
+	}
+
+	data := before
+	if images.image == api.ReturnDocumentAfter {
+		data = after
+	}
+
+	if data == nil {
+		images.docs = append(images.docs, jsoniter.RawMessage("null"))
+		return nil
+	}
+
+	doc, err := images.encode(data)
+	if err != nil {
+		return err
+	}
+
+	images.docs = append(images.docs, doc)
+
+	return nil
+}
+
+func (images *documentImages) encode(data *internal.TableData) ([]byte, error) {
+	var err error
+
+	doc := data.RawData
+	if images.decrypt {
+		if doc, err = images.coll.DecryptFields(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if !images.coll.CompatibleSchemaSince(uint32(data.Ver)) {
+		if doc, err = images.coll.UpdateRowSchemaRaw(doc, uint32(data.Ver)); err != nil {
+			return nil, err
+		}
+	}
+
+	if images.masker != nil {
+		if doc, err = images.masker.Mask(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	// the documents are returned in a header, which can't span multiple lines
+	var compact bytes.Buffer
+	if err = json.Compact(&compact, doc); err != nil {
+		return nil, err
+	}
+
+	return compact.Bytes(), nil
+}
+
+// documents returns the collected documents, nil if the caller didn't ask for them.
+func (images *documentImages) documents() []jsoniter.RawMessage {
+	if images == nil {
+		return nil
+	}
+
+	return images.docs
+}
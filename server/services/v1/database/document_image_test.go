@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDocumentImages(t *testing.T) {
+	reqSchema := []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": {
+				"type": "integer"
+			},
+			"name": {
+				"type": "string"
+			}
+		},
+		"primary_key": ["id"]
+	}`)
+
+	schFactory, err := schema.NewFactoryBuilder(true).Build("t1", reqSchema)
+	require.NoError(t, err)
+	coll, err := schema.NewDefaultCollection(1, 1, schFactory, nil, nil)
+	require.NoError(t, err)
+
+	before := internal.NewTableData([]byte(`{"id": 1, "name": "a"}`))
+	after := internal.NewTableData([]byte("{\"id\": 1,\n \"name\": \"b\"}"))
+
+	t.Run("not_requested", func(t *testing.T) {
+		images, err := newDocumentImages(context.Background(), coll)
+		require.NoError(t, err)
+		require.Nil(t, images)
+		require.NoError(t, images.add(before, after))
+		require.Nil(t, images.documents())
+	})
+
+	t.Run("before", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderReturnDocument, api.ReturnDocumentBefore))
+		images, err := newDocumentImages(ctx, coll)
+		require.NoError(t, err)
+		require.Equal(t, []jsoniter.RawMessage{}, images.documents())
+
+		require.NoError(t, images.add(before, after))
+		require.NoError(t, images.add(nil, after))
+		require.Equal(t, []jsoniter.RawMessage{[]byte(`{"id":1,"name":"a"}`), []byte(`null`)}, images.documents())
+	})
+
+	t.Run("after", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderReturnDocument, api.ReturnDocumentAfter))
+		images, err := newDocumentImages(ctx, coll)
+		require.NoError(t, err)
+
+		require.NoError(t, images.add(before, after))
+		require.Equal(t, []jsoniter.RawMessage{[]byte(`{"id":1,"name":"b"}`)}, images.documents())
+	})
+
+	t.Run("max_documents", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderReturnDocument, api.ReturnDocumentBefore))
+		images, err := newDocumentImages(ctx, coll)
+		require.NoError(t, err)
+
+		for i := 0; i < config.DefaultConfig.Server.MaxReturnedDocuments; i++ {
+			require.NoError(t, images.add(before, after))
+		}
+
+		var tErr *api.TigrisError
+		require.ErrorAs(t, images.add(before, after), &tErr)
+		require.Equal(t, api.Code_INVALID_ARGUMENT, tErr.Code)
+	})
+}
@@ -15,6 +15,7 @@
 package database
 
 import (
+	"context"
 	"net/http"
 
 	api "github.com/tigrisdata/tigris/api/server/v1"
@@ -26,6 +27,13 @@ import (
 
 // CreateApiError helps construct API errors from internal errors.
 func CreateApiError(err error) error {
+	switch err {
+	case context.DeadlineExceeded:
+		return apiErrors.DeadlineExceeded("the deadline of the request is exceeded")
+	case context.Canceled:
+		return apiErrors.Canceled("the request is canceled")
+	}
+
 	switch e := err.(type) {
 	case nil:
 		return nil
@@ -60,6 +68,10 @@ func CreateApiError(err error) error {
 			return apiErrors.ContentTooLarge(e.Msg())
 		case kv.ErrCodeConflictingTransaction:
 			return apiErrors.Aborted(e.Msg())
+		case kv.ErrCodeSavepointNotFound:
+			return apiErrors.NotFound(e.Msg())
+		case kv.ErrCodeSavepointIrreversible:
+			return apiErrors.InvalidArgument(e.Msg())
 		}
 	default:
 		return err
@@ -68,5 +80,5 @@ func CreateApiError(err error) error {
 }
 
 func IsErrConflictingTransaction(err error) bool {
-	return err == kv.ErrConflictingTransaction
+	return kv.IsConflict(err)
 }
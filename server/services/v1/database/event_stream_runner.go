@@ -0,0 +1,340 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// The event streams of a branch are stored in its event stream table. The head of a stream, (head, stream), holds the
+// version of the stream and the events are keyed by (event, stream, versionstamp, version). An append reads the head,
+// so the concurrent appends to a stream conflict and only one of them commits, and the events are ordered in their
+// stream by their versions.
+const (
+	eventStreamHeadKey  = "head"
+	eventStreamEventKey = "event"
+
+	// streamPositionLen is the length of a position, the versionstamp of the commit followed by the version.
+	streamPositionLen = 20
+)
+
+// streamHead is the head of an event stream.
+type streamHead struct {
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storedEvent is an event as stored in the event stream table, its position is known from its key only.
+type storedEvent struct {
+	Version    int64               `json:"version"`
+	Data       jsoniter.RawMessage `json:"data"`
+	AppendedAt time.Time           `json:"appended_at"`
+}
+
+func streamHeadKey(table []byte, stream string) keys.Key {
+	return keys.NewKey(table, eventStreamHeadKey, stream)
+}
+
+// streamEventsEnd returns the exclusive end of the events of a stream, the versionstamps sort after the other types of
+// the tuple elements.
+func streamEventsEnd(table []byte, stream string) keys.Key {
+	vs := tuple.Versionstamp{UserVersion: 0xFFFF}
+	for i := range vs.TransactionVersion {
+		vs.TransactionVersion[i] = 0xFF
+	}
+
+	return keys.NewKey(table, eventStreamEventKey, stream, vs)
+}
+
+// encodeStreamPosition returns the hex encoded position of the event of a stream at the version committed at the
+// versionstamp.
+func encodeStreamPosition(vs tuple.Versionstamp, version int64) string {
+	buf := make([]byte, streamPositionLen)
+	copy(buf, vs.TransactionVersion[:])
+	binary.BigEndian.PutUint16(buf[10:], vs.UserVersion)
+	binary.BigEndian.PutUint64(buf[12:], uint64(version))
+
+	return hex.EncodeToString(buf)
+}
+
+func decodeStreamPosition(position []byte) (tuple.Versionstamp, int64, error) {
+	var vs tuple.Versionstamp
+
+	buf, err := hex.DecodeString(string(position))
+	if err != nil || len(buf) != streamPositionLen {
+		return vs, 0, errors.InvalidArgument("invalid stream position '%s'", position)
+	}
+
+	copy(vs.TransactionVersion[:], buf)
+	vs.UserVersion = binary.BigEndian.Uint16(buf[10:])
+
+	return vs, int64(binary.BigEndian.Uint64(buf[12:])), nil
+}
+
+func validateStreamAppend(stream string, events [][]byte, cfg config.EventStreamConfig) error {
+	if len(stream) == 0 {
+		return errors.InvalidArgument("stream is required")
+	}
+	if len(events) == 0 {
+		return errors.InvalidArgument("append has no events")
+	}
+	if cfg.MaxAppendEvents > 0 && len(events) > cfg.MaxAppendEvents {
+		return errors.InvalidArgument("append has %d events, the maximum is %d", len(events), cfg.MaxAppendEvents)
+	}
+	for _, event := range events {
+		if _, dataType, _, err := jsonparser.Get(event); err != nil || dataType != jsonparser.Object {
+			return errors.InvalidArgument("stream event should be an object")
+		}
+	}
+
+	return nil
+}
+
+// streamReadLimit returns the number of the events returned by a read with the limit, zero for the default.
+func streamReadLimit(limit int64, cfg config.EventStreamConfig) (int, error) {
+	if limit < 0 {
+		return 0, errors.InvalidArgument("invalid limit %d", limit)
+	}
+	if limit == 0 {
+		return cfg.DefaultReadLimit, nil
+	}
+	if cfg.MaxReadLimit > 0 && limit > int64(cfg.MaxReadLimit) {
+		return cfg.MaxReadLimit, nil
+	}
+
+	return int(limit), nil
+}
+
+func readStreamHead(ctx context.Context, tx transaction.Tx, table []byte, stream string) (*streamHead, error) {
+	iter, err := tx.Read(ctx, streamHeadKey(table, stream), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		row  kv.KeyValue
+		head streamHead
+	)
+	if iter.Next(&row) {
+		if err = jsoniter.Unmarshal(row.Data.RawData, &head); err != nil {
+			return nil, err
+		}
+	}
+
+	return &head, iter.Err()
+}
+
+// AppendStreamQueryRunner appends the events of the request to an event stream. The append fails if the stream isn't
+// at the version expected by the request, if any, so the writers appending after reading the stream detect the events
+// appended concurrently.
+type AppendStreamQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.InsertRequest
+	queryMetrics *metrics.WriteQueryMetrics
+}
+
+func (runner *AppendStreamQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	stream, events := runner.req.GetCollection(), runner.req.GetDocuments()
+	if err := validateStreamAppend(stream, events, config.DefaultConfig.Server.EventStream); err != nil {
+		return Response{}, ctx, err
+	}
+
+	expected, checkVersion, err := request.GetExpectedVersion(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeEventStreamTableName(tenant.GetNamespace(), db)
+	head, err := readStreamHead(ctx, tx, table, stream)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if checkVersion && head.Version != expected {
+		return Response{}, ctx, errors.FailedPrecondition("stream '%s' is at version %d, expected version %d",
+			stream, head.Version, expected)
+	}
+
+	now := time.Now().UTC()
+	first := head.Version + 1
+	for _, event := range events {
+		head.Version++
+
+		key, err := subspace.FromBytes(table).PackWithVersionstamp(tuple.Tuple{
+			eventStreamEventKey, stream, tuple.IncompleteVersionstamp(0), head.Version,
+		})
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		data, err := jsoniter.Marshal(&storedEvent{Version: head.Version, Data: event, AppendedAt: now})
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		enc, err := internal.Encode(internal.NewTableData(data))
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		if err = tx.SetVersionstampedKey(ctx, key, enc); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	head.UpdatedAt = now
+	data, err := jsoniter.Marshal(head)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if err = tx.Replace(ctx, streamHeadKey(table, stream), internal.NewTableData(data), false); err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp, err := jsoniter.Marshal(&api.AppendStreamResponse{
+		Status:       AppendedStatus,
+		Stream:       stream,
+		FirstVersion: first,
+		Version:      head.Version,
+	})
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	runner.queryMetrics.SetWriteType("append_stream")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	return Response{
+		Response: &httpbody.HttpBody{ContentType: "application/json", Data: resp},
+		Status:   AppendedStatus,
+	}, ctx, nil
+}
+
+// ReadStreamQueryRunner returns the events of an event stream in their order in the stream, starting after the
+// position of the offset of the request, if any.
+type ReadStreamQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.ReadRequest
+	queryMetrics *metrics.StreamingQueryMetrics
+}
+
+func (runner *ReadStreamQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	stream := runner.req.GetCollection()
+	if len(stream) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("stream is required")
+	}
+	if len(runner.req.GetFilter()) > 0 || len(runner.req.GetFields()) > 0 || len(runner.req.GetSort()) > 0 {
+		return Response{}, ctx, errors.InvalidArgument("filter, fields and sort are not supported by stream reads")
+	}
+
+	cfg := config.DefaultConfig.Server.EventStream
+	limit, err := streamReadLimit(runner.req.GetOptions().GetLimit(), cfg)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeEventStreamTableName(tenant.GetNamespace(), db)
+	head, err := readStreamHead(ctx, tx, table, stream)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	start := keys.NewKey(table, eventStreamEventKey, stream)
+	if position := runner.req.GetOptions().GetOffset(); len(position) > 0 {
+		vs, version, err := decodeStreamPosition(position)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		start = keys.NewKey(table, eventStreamEventKey, stream, vs, version+1)
+	}
+
+	iter, err := tx.ReadRange(ctx, start, streamEventsEnd(table, stream), false, false)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	var row kv.KeyValue
+	events := make([]*api.StreamEvent, 0, limit)
+	for len(events) < limit && iter.Next(&row) {
+		if len(row.Key) != 4 {
+			continue
+		}
+		vs, ok := row.Key[2].(tuple.Versionstamp)
+		if !ok {
+			continue
+		}
+
+		var event storedEvent
+		if err = jsoniter.Unmarshal(row.Data.RawData, &event); err != nil {
+			return Response{}, ctx, err
+		}
+
+		events = append(events, &api.StreamEvent{
+			Version:    event.Version,
+			Position:   encodeStreamPosition(vs, event.Version),
+			Data:       event.Data,
+			AppendedAt: event.AppendedAt,
+		})
+	}
+	if err = iter.Err(); err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp, err := jsoniter.Marshal(&api.ReadStreamResponse{
+		Stream:  stream,
+		Version: head.Version,
+		Events:  events,
+	})
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	runner.queryMetrics.SetReadType("read_stream")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	return Response{
+		Response: &httpbody.HttpBody{ContentType: "application/json", Data: resp},
+	}, ctx, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestStreamPosition(t *testing.T) {
+	vs := tuple.Versionstamp{TransactionVersion: [10]byte{0, 0, 0, 1, 2, 3, 4, 5, 6, 7}, UserVersion: 3}
+
+	position := encodeStreamPosition(vs, 42)
+	require.Equal(t, "00000001020304050607"+"0003"+"000000000000002a", position)
+
+	decoded, version, err := decodeStreamPosition([]byte(position))
+	require.NoError(t, err)
+	require.Equal(t, vs, decoded)
+	require.Equal(t, int64(42), version)
+
+	// the positions of the later commits and of the later events of a commit are greater
+	later := vs
+	later.TransactionVersion[9]++
+	require.Less(t, position, encodeStreamPosition(later, 1))
+	require.Less(t, position, encodeStreamPosition(vs, 43))
+
+	for _, p := range []string{"abc", "zz", position[:10]} {
+		_, _, err = decodeStreamPosition([]byte(p))
+		require.Equal(t, errors.InvalidArgument("invalid stream position '%s'", p), err)
+	}
+}
+
+func TestValidateStreamAppend(t *testing.T) {
+	cfg := config.EventStreamConfig{MaxAppendEvents: 2}
+	event := []byte(`{"type":"order_created"}`)
+
+	require.NoError(t, validateStreamAppend("order-1", [][]byte{event, event}, cfg))
+	require.Equal(t, errors.InvalidArgument("stream is required"), validateStreamAppend("", [][]byte{event}, cfg))
+	require.Equal(t, errors.InvalidArgument("append has no events"), validateStreamAppend("order-1", nil, cfg))
+	require.Equal(t, errors.InvalidArgument("append has %d events, the maximum is %d", 3, 2),
+		validateStreamAppend("order-1", [][]byte{event, event, event}, cfg))
+	require.Equal(t, errors.InvalidArgument("stream event should be an object"),
+		validateStreamAppend("order-1", [][]byte{[]byte(`[1]`)}, cfg))
+}
+
+func TestStreamReadLimit(t *testing.T) {
+	cfg := config.EventStreamConfig{DefaultReadLimit: 10, MaxReadLimit: 100}
+
+	for _, c := range []struct {
+		limit    int64
+		expected int
+	}{
+		{0, 10},
+		{5, 5},
+		{100, 100},
+		{1000, 100},
+	} {
+		limit, err := streamReadLimit(c.limit, cfg)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, limit)
+	}
+
+	_, err := streamReadLimit(-1, cfg)
+	require.Equal(t, errors.InvalidArgument("invalid limit %d", -1), err)
+}
@@ -0,0 +1,120 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestExpressionIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"email": { "type": "string" },
+			"created": { "type": "string", "format": "date-time" }
+		},
+		"primary_key": ["id"],
+		"indexes": [
+			{ "name": "email_lower", "expression": "lower(email)" },
+			{ "name": "created_year", "expression": "extract_year(created)" }
+		]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("expression_t1")
+	coll.EncodedTableIndexName = []byte("expression_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	docs := map[int64]*internal.TableData{}
+
+	write := func(id int64, doc string) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+
+		pk := []any{"pkey", id}
+		td := createTD([]byte(doc))
+		require.NoError(t, indexer.Update(ctx, tx, td, docs[id], pk))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		require.NoError(t, tx.Commit(ctx))
+		docs[id] = td
+	}
+
+	read := func(reqFilter string, indexName string) []int64 {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+		plan, err := BuildSecondaryIndexKeys(coll, filters, nil)
+		require.NoError(t, err)
+		require.Equal(t, indexName, plan.FieldName)
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := NewSecondaryIndexReader(ctx, tx, coll, wrapped, plan)
+		require.NoError(t, err)
+		filtered := NewFilterIterator(iter, wrapped)
+
+		var (
+			row Row
+			ids []int64
+		)
+		for filtered.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, filtered.Interrupted())
+
+		return ids
+	}
+
+	write(1, `{"id":1, "email":"Jane@Example.com", "created":"2022-12-31T10:00:00Z"}`)
+	write(2, `{"id":2, "email":"john@example.com", "created":"2023-01-02T10:00:00Z"}`)
+	write(3, `{"id":3, "email":"JANE@EXAMPLE.COM", "created":"2023-06-02T10:00:00Z"}`)
+	write(4, `{"id":4}`)
+
+	t.Run("equality", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3}, read(`{"lower(email)": "jane@example.com"}`, "email_lower"))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		require.Equal(t, []int64{2, 3}, read(`{"extract_year(created)": {"$gte": 2023}}`, "created_year"))
+	})
+
+	t.Run("entries removed by update", func(t *testing.T) {
+		write(1, `{"id":1, "email":"jane@other.com", "created":"2024-01-01T00:00:00Z"}`)
+		require.Equal(t, []int64{3}, read(`{"lower(email)": "jane@example.com"}`, "email_lower"))
+		require.Equal(t, []int64{1}, read(`{"extract_year(created)": 2024}`, "created_year"))
+	})
+}
@@ -120,7 +120,7 @@ func (runner *ImportQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
-	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, coll, runner.req.GetDocuments(), true)
+	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, db, coll, runner.req.GetDocuments(), true, nil)
 	if err != nil {
 		if err == kv.ErrDuplicateKey {
 			return Response{}, ctx, errors.AlreadyExists(err.Error())
@@ -147,7 +147,7 @@ func (runner *ImportQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		defer func() { _ = tx.Rollback(ctx) }()
 
 		// Retry insert after updating the schema
-		ts, allKeys, err = runner.insertOrReplace(ctx, tx, tenant, coll, runner.req.GetDocuments(), true)
+		ts, allKeys, err = runner.insertOrReplace(ctx, tx, tenant, db, coll, runner.req.GetDocuments(), true, nil)
 		if err == kv.ErrDuplicateKey {
 			return Response{}, ctx, errors.AlreadyExists(err.(kv.StoreError).Msg())
 		}
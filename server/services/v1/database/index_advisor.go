@@ -0,0 +1,105 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/indexadvisor"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// recordFullScan records the filter of a read served by a full scan of the collection for the index advisor.
+func recordFullScan(tenant *metadata.Tenant, db *metadata.Database, req *api.ReadRequest, options readerOptions) {
+	if options.tablePlan == nil || options.filter == nil || options.filter.None() {
+		return
+	}
+
+	indexadvisor.RecordFullScan(tenant.GetNamespace().StrId(), req.GetProject(), db.BranchName(), req.GetCollection(), req.GetFilter(), req.GetSort())
+}
+
+// IndexSuggestionsRunner returns the indexes suggested by the index advisor for the collections of a project branch.
+type IndexSuggestionsRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeDatabaseRequest
+}
+
+func (runner *IndexSuggestionsRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	suggestions, generatedAt := indexadvisor.Suggestions(tenant.GetNamespace().StrId(), runner.req.GetProject(), db.BranchName())
+
+	resp := &api.IndexSuggestionsResponse{
+		Project:     runner.req.GetProject(),
+		Branch:      runner.req.GetBranch(),
+		Suggestions: []*api.IndexSuggestion{},
+	}
+	if !generatedAt.IsZero() {
+		resp.GeneratedAt = generatedAt.Format(time.RFC3339)
+	}
+	for _, s := range suggestions {
+		// the collections may have been dropped or indexed since the suggestions were ranked
+		if coll := db.GetCollection(s.Collection); coll != nil && !hasIndexWithPrefix(coll, s.Fields) {
+			resp.Suggestions = append(resp.Suggestions, s)
+		}
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
+
+// hasIndexWithPrefix returns true if the collection has an index which fields start with the fields of the suggestion.
+func hasIndexWithPrefix(coll *schema.DefaultCollection, fields []*api.IndexSuggestionField) bool {
+	for _, index := range coll.SecondaryIndexes.All {
+		if index.Expression != nil || len(index.Fields) < len(fields) {
+			continue
+		}
+
+		matches := true
+		for i, f := range fields {
+			name := index.Fields[i].FieldName
+			if !index.IsComposite() {
+				name = index.Name
+			}
+			if name != f.Field || index.IsDescending(i) != (f.Sort == schema.IndexSortDesc) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
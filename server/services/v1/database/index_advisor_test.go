@@ -0,0 +1,53 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestHasIndexWithPrefix(t *testing.T) {
+	indexer := setupTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"city": { "type": "string" },
+			"age": { "type": "integer" },
+			"name": { "type": "string", "index": true }
+		},
+		"primary_key": ["id"],
+		"indexes": [
+			{ "name": "city_age", "fields": [{ "field": "city" }, { "field": "age", "sort": "desc" }] }
+		]
+	}`))
+
+	cases := []struct {
+		fields   []*api.IndexSuggestionField
+		expMatch bool
+	}{
+		{[]*api.IndexSuggestionField{{Field: "name"}}, true},
+		{[]*api.IndexSuggestionField{{Field: "city"}}, true},
+		{[]*api.IndexSuggestionField{{Field: "city"}, {Field: "age", Sort: "desc"}}, true},
+		{[]*api.IndexSuggestionField{{Field: "city"}, {Field: "age"}}, false},
+		{[]*api.IndexSuggestionField{{Field: "age"}}, false},
+		{[]*api.IndexSuggestionField{{Field: "name"}, {Field: "age"}}, false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expMatch, hasIndexWithPrefix(indexer.coll, c.fields), c.fields)
+	}
+}
@@ -0,0 +1,486 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// catchUpSkew widens the catch-up window of the build to the documents written shortly before the build started, the
+// timestamps of a document are taken when the write starts and not when it is committed.
+const catchUpSkew = 5 * time.Second
+
+// indexBuilds tracks the progress of the index builds running on this server, keyed by indexBuildKey.
+var indexBuilds sync.Map
+
+func indexBuildKey(nsId uint32, dbId uint32, collId uint32, index string) string {
+	return fmt.Sprintf("%d/%d/%d/%s", nsId, dbId, collId, index)
+}
+
+// indexBuildProgress is shared by the indexes built together.
+type indexBuildProgress struct {
+	sync.Mutex
+
+	state     string
+	processed int64
+	total     int64
+	err       string
+	startedAt time.Time
+	updatedAt time.Time
+}
+
+func (p *indexBuildProgress) setState(state string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.state = state
+	p.updatedAt = time.Now()
+}
+
+func (p *indexBuildProgress) add(count int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.processed += int64(count)
+	p.updatedAt = time.Now()
+}
+
+func (p *indexBuildProgress) fail(err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.state = api.IndexBuildFailed
+	p.err = err.Error()
+	p.updatedAt = time.Now()
+}
+
+func (p *indexBuildProgress) status(status *api.IndexBuildStatus) {
+	p.Lock()
+	defer p.Unlock()
+
+	status.State = p.state
+	status.Processed = p.processed
+	status.Error = p.err
+	status.StartedAt = p.startedAt.UTC().Format(time.RFC3339)
+	status.UpdatedAt = p.updatedAt.UTC().Format(time.RFC3339)
+
+	switch {
+	case p.state == api.IndexBuildReady:
+		status.Percent = 100
+	case p.total > 0:
+		// the row count of the collection is approximate, the build is not complete until the index is ready
+		status.Percent = 99
+		if percent := p.processed * 100 / p.total; percent < 99 {
+			status.Percent = int32(percent)
+		}
+	}
+}
+
+// indexBuildStatus returns the build status of the secondary indexes of the collection. The progress is known for the
+// builds running on this server, otherwise the status is derived from the state of the index.
+func indexBuildStatus(nsId uint32, dbId uint32, coll *schema.DefaultCollection) []*api.IndexBuildStatus {
+	statuses := make([]*api.IndexBuildStatus, 0, len(coll.SecondaryIndexes.All))
+	for _, index := range coll.SecondaryIndexes.All {
+		status := &api.IndexBuildStatus{Name: index.Name}
+		if index.State == schema.INDEX_ACTIVE {
+			status.State = api.IndexBuildReady
+			status.Percent = 100
+		} else if p, ok := indexBuilds.Load(indexBuildKey(nsId, dbId, coll.Id, index.Name)); ok {
+			p.(*indexBuildProgress).status(status)
+		} else if len(index.BuildError) > 0 {
+			status.State = api.IndexBuildFailed
+			status.Error = index.BuildError
+		} else {
+			status.State = api.IndexBuildPending
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// IndexBuilder builds the secondary indexes added to an existing collection in the background. The new indexes are in
+// the write mode while they are built, so the writes maintain them but the queries don't use them. The build goes
+// through the following states:
+//   - BACKFILL indexes the documents of the collection in batches, in the primary key order.
+//   - CATCH_UP indexes again the documents written since the backfill started, in case they were written by a server
+//     that didn't know about the new indexes yet.
+//   - READY moves the indexes to the active state, from then on the queries use them.
+//
+// The build is FAILED if a batch fails with an error other than a conflict, for example a unique index meets a
+// duplicate value. The error is persisted in the index metadata and the index stays in the write mode.
+type IndexBuilder struct {
+	*BaseQueryRunner
+
+	tenant    *metadata.Tenant
+	db        *metadata.Database
+	project   string
+	branch    string
+	coll      *schema.DefaultCollection
+	indexes   []string
+	batchSize int
+	progress  *indexBuildProgress
+}
+
+func NewIndexBuilder(runner *BaseQueryRunner, tenant *metadata.Tenant, db *metadata.Database, project string, branch string, coll *schema.DefaultCollection, indexes []string) *IndexBuilder {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	return &IndexBuilder{
+		BaseQueryRunner: runner,
+		tenant:          tenant,
+		db:              db,
+		project:         project,
+		branch:          branch,
+		coll:            coll,
+		indexes:         indexes,
+		batchSize:       batchSize,
+		progress:        &indexBuildProgress{state: api.IndexBuildPending},
+	}
+}
+
+// Run builds the indexes. It stops early if the collection is dropped or the indexes are no longer in the write mode,
+// i.e. they are dropped or built by the BuildCollectionIndex API in the meantime.
+func (b *IndexBuilder) Run(ctx context.Context) error {
+	b.start(ctx)
+
+	stopped, err := b.backfill(ctx)
+	if err == nil && !stopped {
+		b.progress.setState(api.IndexBuildCatchUp)
+		stopped, err = b.catchUp(ctx, b.progress.startedAt.Add(-catchUpSkew))
+	}
+	if err == nil && !stopped {
+		err = b.updateIndexes(ctx, func(index *schema.Index) {
+			index.State = schema.INDEX_ACTIVE
+			index.BuildError = ""
+		})
+	}
+
+	if err != nil {
+		b.progress.fail(err)
+		if e := b.updateIndexes(ctx, func(index *schema.Index) { index.BuildError = err.Error() }); e != nil {
+			log.Err(e).Msgf("Failed to persist the build error of the indexes %v of collection '%s'", b.indexes, b.coll.Name)
+		}
+		return err
+	}
+
+	if stopped {
+		log.Info().Msgf("Build of the indexes %v of collection '%s' stopped, the collection has changed", b.indexes, b.coll.Name)
+		return nil
+	}
+
+	b.progress.setState(api.IndexBuildReady)
+	log.Info().Msgf("Built the indexes %v of collection '%s'", b.indexes, b.coll.Name)
+
+	return nil
+}
+
+func (b *IndexBuilder) start(ctx context.Context) {
+	b.progress.startedAt = time.Now()
+	b.progress.setState(api.IndexBuildBackfill)
+	if stats, err := b.tenant.CollectionSize(ctx, b.db, b.coll); err == nil {
+		b.progress.total = stats.RowCount
+	}
+
+	for _, index := range b.indexes {
+		indexBuilds.Store(indexBuildKey(b.tenant.GetNamespace().Id(), b.db.Id(), b.coll.Id, index), b.progress)
+	}
+}
+
+// current returns the collection to index the documents with, or nil if the build should stop. The server may not have
+// reloaded the schema that added the indexes yet, the collection the build was started with is used until then.
+func (b *IndexBuilder) current(ctx context.Context) (*schema.DefaultCollection, error) {
+	_, coll, err := b.getDBAndCollection(ctx, nil, b.tenant, b.project, b.coll.Name, b.branch)
+	if err != nil {
+		return nil, err
+	}
+	if coll.Id != b.coll.Id {
+		return nil, nil
+	}
+	if coll.GetVersion() < b.coll.GetVersion() {
+		coll = b.coll
+	}
+
+	for _, name := range b.indexes {
+		if index := schema.FindIndex(coll.SecondaryIndexes.All, name); index == nil || index.State != schema.INDEX_WRITE_MODE {
+			return nil, nil
+		}
+	}
+
+	return coll, nil
+}
+
+func (b *IndexBuilder) backfill(ctx context.Context) (bool, error) {
+	return b.batches(ctx, func(coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error) {
+		count, next, err := b.backfillBatch(ctx, coll, after, batchSize)
+		if err == nil {
+			b.progress.add(count)
+		}
+		return count, next, err
+	})
+}
+
+// catchUp indexes the documents created or updated since the time using the indexes of the timestamps of the
+// documents. The whole collection is scanned again if these indexes are not usable.
+func (b *IndexBuilder) catchUp(ctx context.Context, since time.Time) (bool, error) {
+	timestamps := []string{schema.ReservedFields[schema.CreatedAt], schema.ReservedFields[schema.UpdatedAt]}
+	for _, name := range timestamps {
+		if index := schema.FindIndex(b.coll.SecondaryIndexes.All, name); index == nil || index.State != schema.INDEX_ACTIVE {
+			return b.batches(ctx, func(coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error) {
+				return b.backfillBatch(ctx, coll, after, batchSize)
+			})
+		}
+	}
+
+	for _, name := range timestamps {
+		stopped, err := b.batches(ctx, func(coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error) {
+			return b.catchUpBatch(ctx, coll, name, since, after, batchSize)
+		})
+		if err != nil || stopped {
+			return stopped, err
+		}
+	}
+
+	return false, nil
+}
+
+type indexBatch func(coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error)
+
+// batches runs the batch until there is nothing left to index. A batch that fails with a conflict or exceeds the
+// transaction limits is retried with half the documents.
+func (b *IndexBuilder) batches(ctx context.Context, batch indexBatch) (bool, error) {
+	var (
+		last      []byte
+		batchSize = b.batchSize
+	)
+
+	for {
+		coll, err := b.current(ctx)
+		if err != nil || coll == nil {
+			return coll == nil, err
+		}
+
+		_, next, err := batch(coll, last, batchSize)
+		if err != nil {
+			if !shouldRetryBulkIndex(err) {
+				return false, err
+			}
+			if batchSize > 1 {
+				batchSize /= 2
+			}
+			continue
+		}
+
+		if next == nil {
+			return false, nil
+		}
+		last = next
+	}
+}
+
+// backfillBatch indexes up to batchSize documents following the primary key after, it returns the last key indexed or
+// nil if there are no more documents.
+func (b *IndexBuilder) backfillBatch(ctx context.Context, coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error) {
+	tx, err := b.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	iter, err := createBulkDocsReader(ctx, tx, coll.EncodedName, nil, after)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	var (
+		row     Row
+		last    []byte
+		count   int
+		indexer = NewSecondaryIndexer(coll)
+	)
+	for count < batchSize && iter.Next(&row) {
+		if after != nil && bytes.Equal(row.Key, after) {
+			continue
+		}
+
+		key, err := keys.FromBinary(coll.EncodedName, row.Key)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		if err = indexer.Index(ctx, tx, row.Data, key.IndexParts()); err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		last = row.Key
+		count++
+	}
+
+	if err = iter.Interrupted(); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if count < batchSize {
+		return count, nil, nil
+	}
+
+	return count, last, nil
+}
+
+// catchUpBatch indexes up to batchSize documents whose timestamp is at or after since, in the order of the index of the
+// timestamp, following the index key after. It returns the last index key read or nil if there are no more documents.
+func (b *IndexBuilder) catchUpBatch(ctx context.Context, coll *schema.DefaultCollection, timestamp string, since time.Time, after []byte, batchSize int) (int, []byte, error) {
+	impl := newSecondaryIndexerImpl(coll)
+	row, err := newIndexRow(schema.DateTimeType, impl.collation, timestamp,
+		[]byte(internal.CreateNewTimestamp(since.UnixNano()).ToRFC3339()), 0, false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// the range covers the timestamps from since to the last one of the date time type
+	parts := impl.buildIndexParts(*row)
+	start := keys.NewKey(coll.EncodedTableIndexName, parts...)
+	end := keys.NewKey(coll.EncodedTableIndexName, append(parts[:len(parts)-1:len(parts)-1], 0xFF)...)
+	if after != nil {
+		if start, err = keys.FromBinary(coll.EncodedTableIndexName, after); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	tx, err := b.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	iter, err := tx.ReadRange(ctx, start, end, false, false)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	var (
+		entry   kv.KeyValue
+		last    []byte
+		count   int
+		indexer = NewSecondaryIndexer(coll)
+	)
+	for count < batchSize && iter.Next(&entry) {
+		if after != nil && bytes.Equal(entry.FDBKey, after) {
+			continue
+		}
+
+		indexKey, err := keys.FromBinary(coll.EncodedTableIndexName, entry.FDBKey)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		// the timestamp is followed by the position and the primary key
+		pks := indexKey.IndexParts()[len(parts)+1:]
+		doc, err := readDocument(ctx, tx, keys.NewKey(coll.EncodedName, pks...))
+		if err == nil && doc != nil {
+			err = indexer.Index(ctx, tx, doc, pks)
+		}
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		last = entry.FDBKey
+		count++
+	}
+
+	if err = iter.Err(); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if count < batchSize {
+		return count, nil, nil
+	}
+
+	return count, last, nil
+}
+
+// updateIndexes applies the change to the metadata of the indexes being built that are still in the write mode.
+func (b *IndexBuilder) updateIndexes(ctx context.Context, change func(*schema.Index)) error {
+	for {
+		err := b.updateIndexesTx(ctx, change)
+		if err == nil || !shouldRetryBulkIndex(err) {
+			return err
+		}
+	}
+}
+
+func (b *IndexBuilder) updateIndexesTx(ctx context.Context, change func(*schema.Index)) error {
+	db, err := b.getDatabase(ctx, nil, b.tenant, b.project, b.branch)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	meta, err := b.tenant.GetCollectionMetadata(ctx, tx, db, b.coll.Name)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	if meta.ID != b.coll.Id {
+		return tx.Rollback(ctx)
+	}
+
+	for _, name := range b.indexes {
+		if index := schema.FindIndex(meta.Indexes, name); index != nil && index.State == schema.INDEX_WRITE_MODE {
+			change(index)
+		}
+	}
+
+	if err = b.tenant.PublishCollectionIndexes(ctx, tx, db, b.coll.Name, meta.Indexes); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,198 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestIndexBuilder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string", "index": true },
+			"city": { "type": "string", "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	city := schema.FindIndex(coll.SecondaryIndexes.All, "city")
+	city.State = schema.INDEX_WRITE_MODE
+	coll.EncodedName = []byte("build_t1")
+	coll.EncodedTableIndexName = []byte("build_sidx1")
+
+	tm := transaction.NewManager(kvStore)
+	builder := &IndexBuilder{BaseQueryRunner: &BaseQueryRunner{txMgr: tm}}
+
+	reset := func() {
+		for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+			require.NoError(t, kvStore.DropTable(ctx, table))
+			require.NoError(t, kvStore.CreateTable(ctx, table))
+		}
+	}
+
+	// write stores the documents, the index of the city is cleared as if they were written before it was added
+	write := func(ts time.Time, ids ...int64) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		for _, id := range ids {
+			pk := []any{"pkey", id}
+			created := internal.CreateNewTimestamp(ts.UnixNano())
+			td := internal.NewTableDataWithTS(created, created, []byte(`{"id":1, "name":"n", "city":"c"}`))
+			require.NoError(t, indexer.Index(ctx, tx, td, pk))
+			require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		}
+		require.NoError(t, indexer.DeleteIndex(ctx, tx, city))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	indexed := func() []any {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := indexer.scanIndex(ctx, tx)
+		require.NoError(t, err)
+
+		var ids []any
+		var row kv.KeyValue
+		for iter.Next(&row) {
+			key, err := keys.FromBinary(coll.EncodedTableIndexName, row.FDBKey)
+			require.NoError(t, err)
+			if parts := key.IndexParts(); parts[2] == "city" {
+				ids = append(ids, parts[len(parts)-1])
+			}
+		}
+		require.NoError(t, iter.Err())
+
+		return ids
+	}
+
+	t.Run("backfill", func(t *testing.T) {
+		reset()
+		write(time.Now(), 1, 2, 3, 4, 5)
+		require.Empty(t, indexed())
+
+		var (
+			after   []byte
+			total   int
+			batches int
+		)
+		for {
+			count, next, err := builder.backfillBatch(ctx, coll, after, 2)
+			require.NoError(t, err)
+			total += count
+			batches++
+			if next == nil {
+				break
+			}
+			after = next
+		}
+
+		require.Equal(t, 5, total)
+		require.Equal(t, 3, batches)
+		require.Equal(t, []any{int64(1), int64(2), int64(3), int64(4), int64(5)}, indexed())
+	})
+
+	t.Run("catch up", func(t *testing.T) {
+		reset()
+		now := time.Now()
+		write(now.Add(-time.Hour), 1, 2, 3)
+		write(now, 4, 5)
+
+		var after []byte
+		for {
+			_, next, err := builder.catchUpBatch(ctx, coll, schema.ReservedFields[schema.CreatedAt], now.Add(-time.Minute), after, 1)
+			require.NoError(t, err)
+			if next == nil {
+				break
+			}
+			after = next
+		}
+
+		require.Equal(t, []any{int64(4), int64(5)}, indexed())
+	})
+}
+
+func TestIndexBuildStatus(t *testing.T) {
+	indexer := setupTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string", "index": true },
+			"city": { "type": "string", "index": true },
+			"email": { "type": "string", "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	coll.Id = 100
+	for _, index := range coll.SecondaryIndexes.All {
+		index.State = schema.INDEX_ACTIVE
+	}
+	schema.FindIndex(coll.SecondaryIndexes.All, "city").State = schema.INDEX_WRITE_MODE
+	email := schema.FindIndex(coll.SecondaryIndexes.All, "email")
+	email.State = schema.INDEX_WRITE_MODE
+	email.BuildError = "duplicate value"
+
+	status := func(name string) *api.IndexBuildStatus {
+		for _, s := range indexBuildStatus(1, 2, coll) {
+			if s.Name == name {
+				return s
+			}
+		}
+		return nil
+	}
+
+	require.Equal(t, &api.IndexBuildStatus{Name: "name", State: api.IndexBuildReady, Percent: 100}, status("name"))
+	require.Equal(t, &api.IndexBuildStatus{Name: "city", State: api.IndexBuildPending}, status("city"))
+	require.Equal(t, &api.IndexBuildStatus{Name: "email", State: api.IndexBuildFailed, Error: "duplicate value"}, status("email"))
+
+	progress := &indexBuildProgress{state: api.IndexBuildBackfill, total: 200, startedAt: time.Now()}
+	indexBuilds.Store(indexBuildKey(1, 2, coll.Id, "city"), progress)
+	defer indexBuilds.Delete(indexBuildKey(1, 2, coll.Id, "city"))
+
+	progress.add(50)
+	require.Equal(t, api.IndexBuildBackfill, status("city").State)
+	require.Equal(t, int32(25), status("city").Percent)
+	require.Equal(t, int64(50), status("city").Processed)
+
+	// the row count is approximate, the build is not complete until the index is ready
+	progress.add(200)
+	progress.setState(api.IndexBuildCatchUp)
+	require.Equal(t, api.IndexBuildCatchUp, status("city").State)
+	require.Equal(t, int32(99), status("city").Percent)
+
+	progress.setState(api.IndexBuildReady)
+	require.Equal(t, int32(100), status("city").Percent)
+
+	progress.fail(api.Errorf(api.Code_ALREADY_EXISTS, "duplicate"))
+	require.Equal(t, api.IndexBuildFailed, status("city").State)
+	require.Equal(t, "duplicate", status("city").Error)
+}
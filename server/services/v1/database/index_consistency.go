@@ -0,0 +1,415 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// IndexConsistencyChecker compares the secondary indexes of a collection with its documents in two passes. The
+// documents are scanned to find the entries missing from the indexes, then the indexes are scanned to find the
+// entries that don't match a document, i.e. the entries of a deleted document or of an old value of a document.
+//
+// Each batch is checked in its own transaction, so the check is consistent within a batch without holding a long
+// transaction. In the repair mode the missing entries are added and the orphaned entries are removed, the repair of
+// a batch is committed with it. Only the active indexes are checked, the ones being built are not complete yet.
+type IndexConsistencyChecker struct {
+	txMgr     *transaction.Manager
+	coll      *schema.DefaultCollection
+	repair    bool
+	batchSize int
+	resp      *api.IndexConsistencyResponse
+}
+
+func NewIndexConsistencyChecker(txMgr *transaction.Manager, coll *schema.DefaultCollection, repair bool) *IndexConsistencyChecker {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	resp := &api.IndexConsistencyResponse{
+		Collection: coll.Name,
+		Repair:     repair,
+		Indexes:    []*api.IndexConsistencyStats{},
+	}
+	for _, index := range coll.SecondaryIndexes.All {
+		if index.State == schema.INDEX_ACTIVE {
+			resp.Indexes = append(resp.Indexes, &api.IndexConsistencyStats{Name: index.Name})
+		}
+	}
+
+	return &IndexConsistencyChecker{
+		txMgr:     txMgr,
+		coll:      coll,
+		repair:    repair,
+		batchSize: batchSize,
+		resp:      resp,
+	}
+}
+
+// Run checks the indexes and returns the number of the inconsistent entries found in each of them.
+func (c *IndexConsistencyChecker) Run(ctx context.Context) (*api.IndexConsistencyResponse, error) {
+	documents, err := c.batches(ctx, c.documentsBatch)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.batches(ctx, c.entriesBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	c.resp.Documents, c.resp.Entries = documents, entries
+	c.resp.Consistent = true
+	for _, stats := range c.resp.Indexes {
+		if stats.Missing > 0 || stats.Orphaned > 0 {
+			c.resp.Consistent = false
+			log.Warn().Msgf("Index '%s' of collection '%s' is inconsistent, missing: %d, orphaned: %d, repaired: %d",
+				stats.Name, c.coll.Name, stats.Missing, stats.Orphaned, stats.Repaired)
+		}
+	}
+
+	return c.resp, nil
+}
+
+// consistencyTally is the result of a batch, it is added to the response once the batch is done, so a batch that is
+// retried is not counted twice.
+type consistencyTally struct {
+	scanned int64
+	indexes map[string]*api.IndexConsistencyStats
+}
+
+func (t *consistencyTally) index(name string) *api.IndexConsistencyStats {
+	stats, ok := t.indexes[name]
+	if !ok {
+		stats = &api.IndexConsistencyStats{Name: name}
+		t.indexes[name] = stats
+	}
+
+	return stats
+}
+
+type consistencyBatch func(ctx context.Context, tally *consistencyTally, after []byte, batchSize int) ([]byte, error)
+
+// batches runs the batch until there is nothing left to check and returns the number of the keys scanned. A batch
+// that fails with a conflict or exceeds the transaction limits is retried with half the keys.
+func (c *IndexConsistencyChecker) batches(ctx context.Context, batch consistencyBatch) (int64, error) {
+	var (
+		last      []byte
+		scanned   int64
+		batchSize = c.batchSize
+	)
+
+	for {
+		tally := &consistencyTally{indexes: make(map[string]*api.IndexConsistencyStats)}
+		next, err := batch(ctx, tally, last, batchSize)
+		if err != nil {
+			if !shouldRetryBulkIndex(err) {
+				return 0, err
+			}
+			if batchSize > 1 {
+				batchSize /= 2
+			}
+			continue
+		}
+
+		scanned += tally.scanned
+		for _, stats := range c.resp.Indexes {
+			if t, ok := tally.indexes[stats.Name]; ok {
+				stats.Missing += t.Missing
+				stats.Orphaned += t.Orphaned
+				stats.Repaired += t.Repaired
+			}
+		}
+
+		if next == nil {
+			return scanned, nil
+		}
+		last = next
+	}
+}
+
+// isChecked returns true if the entries of the index are checked.
+func (c *IndexConsistencyChecker) isChecked(name string) bool {
+	index := schema.FindIndex(c.coll.SecondaryIndexes.All, name)
+	return index != nil && index.State == schema.INDEX_ACTIVE
+}
+
+// done commits the repair of the batch, the transaction of a check only reads, so it is rolled back.
+func (c *IndexConsistencyChecker) done(ctx context.Context, tx transaction.Tx, err error) error {
+	if err != nil || !c.repair {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// documentsBatch checks that the indexes have the entries of up to batchSize documents following the primary key
+// after, it returns the last key checked or nil if there are no more documents.
+func (c *IndexConsistencyChecker) documentsBatch(ctx context.Context, tally *consistencyTally, after []byte, batchSize int) ([]byte, error) {
+	tx, err := c.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := createBulkDocsReader(ctx, tx, c.coll.EncodedName, nil, after)
+	if err != nil {
+		return nil, c.done(ctx, tx, err)
+	}
+
+	var (
+		row     Row
+		last    []byte
+		count   int
+		indexer = newSecondaryIndexerImpl(c.coll)
+	)
+	for count < batchSize && iter.Next(&row) {
+		if after != nil && bytes.Equal(row.Key, after) {
+			continue
+		}
+
+		if err = c.checkDocument(ctx, tx, tally, indexer, row); err != nil {
+			return nil, c.done(ctx, tx, err)
+		}
+
+		last = row.Key
+		count++
+	}
+
+	if err = c.done(ctx, tx, iter.Interrupted()); err != nil {
+		return nil, err
+	}
+	tally.scanned = int64(count)
+
+	if count < batchSize {
+		return nil, nil
+	}
+
+	return last, nil
+}
+
+func (c *IndexConsistencyChecker) checkDocument(ctx context.Context, tx transaction.Tx, tally *consistencyTally, indexer *SecondaryIndexerImpl, row Row) error {
+	key, err := keys.FromBinary(c.coll.EncodedName, row.Key)
+	if err != nil {
+		return err
+	}
+
+	expected, err := indexer.buildAddAndRemoveKVs(row.Data, nil, key.IndexParts())
+	if err != nil {
+		return err
+	}
+
+	for _, indexKey := range expected.addKeys {
+		name, _ := indexKey.IndexParts()[2].(string)
+		if !c.isChecked(name) {
+			continue
+		}
+
+		exists, err := indexEntryExists(ctx, tx, indexKey)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		stats := tally.index(name)
+		stats.Missing++
+		if !c.repair {
+			continue
+		}
+
+		data := internal.EmptyData
+		if covered, ok := expected.addData[string(indexKey.SerializeToBytes())]; ok {
+			data = covered
+		}
+		if err = tx.Replace(ctx, indexKey, data, false); err != nil {
+			return err
+		}
+		stats.Repaired++
+	}
+
+	return nil
+}
+
+// entriesBatch checks that up to batchSize index entries following the index key after match a document, it returns
+// the last key checked or nil if there are no more entries.
+func (c *IndexConsistencyChecker) entriesBatch(ctx context.Context, tally *consistencyTally, after []byte, batchSize int) ([]byte, error) {
+	tx, err := c.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := keys.NewKey(c.coll.EncodedTableIndexName, c.coll.SecondaryIndexKeyword(), KVSubspace)
+	end := keys.NewKey(c.coll.EncodedTableIndexName, c.coll.SecondaryIndexKeyword(), KVSubspace, 0xFF)
+	if after != nil {
+		if start, err = keys.FromBinary(c.coll.EncodedTableIndexName, after); err != nil {
+			return nil, c.done(ctx, tx, err)
+		}
+	}
+
+	iter, err := tx.ReadRange(ctx, start, end, false, false)
+	if err != nil {
+		return nil, c.done(ctx, tx, err)
+	}
+
+	var (
+		entry   kv.KeyValue
+		last    []byte
+		count   int
+		indexer = newSecondaryIndexerImpl(c.coll)
+	)
+	for count < batchSize && iter.Next(&entry) {
+		if after != nil && bytes.Equal(entry.FDBKey, after) {
+			continue
+		}
+
+		if err = c.checkEntry(ctx, tx, tally, indexer, entry.FDBKey); err != nil {
+			return nil, c.done(ctx, tx, err)
+		}
+
+		last = entry.FDBKey
+		count++
+	}
+
+	if err = c.done(ctx, tx, iter.Err()); err != nil {
+		return nil, err
+	}
+	tally.scanned = int64(count)
+
+	if count < batchSize {
+		return nil, nil
+	}
+
+	return last, nil
+}
+
+func (c *IndexConsistencyChecker) checkEntry(ctx context.Context, tx transaction.Tx, tally *consistencyTally, indexer *SecondaryIndexerImpl, fdbKey []byte) error {
+	indexKey, err := keys.FromBinary(c.coll.EncodedTableIndexName, fdbKey)
+	if err != nil {
+		return err
+	}
+
+	parts := indexKey.IndexParts()
+	name, _ := parts[2].(string)
+	if !c.isChecked(name) {
+		return nil
+	}
+
+	orphaned := true
+	if pos := indexPrimaryKeyPos(schema.FindIndex(c.coll.SecondaryIndexes.All, name)); pos < len(parts) {
+		pks := parts[pos:]
+		doc, err := readDocument(ctx, tx, keys.NewKey(c.coll.EncodedName, pks...))
+		if err != nil {
+			return err
+		}
+
+		if doc != nil {
+			expected, err := indexer.buildAddAndRemoveKVs(doc, nil, pks)
+			if err != nil {
+				return err
+			}
+			orphaned = !containsIndexKey(expected.addKeys, fdbKey)
+		}
+	}
+	if !orphaned {
+		return nil
+	}
+
+	stats := tally.index(name)
+	stats.Orphaned++
+	if !c.repair {
+		return nil
+	}
+
+	if err = tx.Delete(ctx, indexKey); err != nil {
+		return err
+	}
+	stats.Repaired++
+
+	return nil
+}
+
+// indexEntryExists returns true if the index has the entry. The entry is the first key of the read of its prefix if
+// it exists.
+func indexEntryExists(ctx context.Context, tx transaction.Tx, indexKey keys.Key) (bool, error) {
+	iter, err := tx.Read(ctx, indexKey, false)
+	if err != nil {
+		return false, err
+	}
+
+	var entry kv.KeyValue
+	if iter.Next(&entry) {
+		return bytes.Equal(entry.FDBKey, indexKey.SerializeToBytes()), nil
+	}
+
+	return false, iter.Err()
+}
+
+func containsIndexKey(indexKeys []keys.Key, fdbKey []byte) bool {
+	for _, key := range indexKeys {
+		if bytes.Equal(key.SerializeToBytes(), fdbKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IndexConsistencyRunner checks the secondary indexes of a collection against its documents and repairs them on
+// request.
+type IndexConsistencyRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeCollectionRequest
+}
+
+func (runner *IndexConsistencyRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	_, coll, err := runner.getDBAndCollection(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp, err := NewIndexConsistencyChecker(runner.txMgr, coll, request.IndexRepair(ctx)).Run(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
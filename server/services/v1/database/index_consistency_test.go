@@ -0,0 +1,115 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestIndexConsistencyChecker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string", "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("consistency_t1")
+	coll.EncodedTableIndexName = []byte("consistency_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	inTx := func(fn func(tx transaction.Tx)) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		fn(tx)
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	docs := map[int64]*internal.TableData{}
+	for id := int64(1); id <= 5; id++ {
+		td := createTD([]byte(fmt.Sprintf(`{"id":%d, "name":"name%d"}`, id, id)))
+		inTx(func(tx transaction.Tx) {
+			pk := []any{"pkey", id}
+			require.NoError(t, indexer.Update(ctx, tx, td, nil, pk))
+			require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		})
+		docs[id] = td
+	}
+
+	check := func(repair bool) map[string]*api.IndexConsistencyStats {
+		checker := NewIndexConsistencyChecker(tm, coll, repair)
+		checker.batchSize = 2
+
+		resp, err := checker.Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), resp.Documents)
+
+		stats := make(map[string]*api.IndexConsistencyStats)
+		for _, s := range resp.Indexes {
+			stats[s.Name] = s
+		}
+		require.Equal(t, resp.Consistent, stats["name"].Missing == 0 && stats["name"].Orphaned == 0)
+
+		return stats
+	}
+
+	require.Equal(t, &api.IndexConsistencyStats{Name: "name"}, check(false)["name"])
+
+	// the entry of a document is lost and an entry of an old value of another document is left behind
+	inTx(func(tx transaction.Tx) {
+		set, err := indexer.buildAddAndRemoveKVs(docs[2], nil, []any{"pkey", int64(2)})
+		require.NoError(t, err)
+		for _, key := range set.addKeys {
+			if key.IndexParts()[2] == "name" {
+				require.NoError(t, tx.Delete(ctx, key))
+			}
+		}
+
+		old := createTD([]byte(`{"id":3, "name":"old"}`))
+		set, err = indexer.buildAddAndRemoveKVs(old, nil, []any{"pkey", int64(3)})
+		require.NoError(t, err)
+		for _, key := range set.addKeys {
+			if key.IndexParts()[2] == "name" {
+				require.NoError(t, tx.Replace(ctx, key, internal.EmptyData, false))
+			}
+		}
+	})
+
+	require.Equal(t, &api.IndexConsistencyStats{Name: "name", Missing: 1, Orphaned: 1}, check(false)["name"])
+	// the check alone doesn't change the index
+	require.Equal(t, &api.IndexConsistencyStats{Name: "name", Missing: 1, Orphaned: 1}, check(false)["name"])
+
+	require.Equal(t, &api.IndexConsistencyStats{Name: "name", Missing: 1, Orphaned: 1, Repaired: 2}, check(true)["name"])
+	require.Equal(t, &api.IndexConsistencyStats{Name: "name"}, check(false)["name"])
+}
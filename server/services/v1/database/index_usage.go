@@ -0,0 +1,136 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// recordIndexRead counts a query served by the index in the usage of the index.
+func recordIndexRead(ctx context.Context, coll *schema.DefaultCollection, index string) {
+	project, branch := requestProjectAndBranch(ctx)
+	metrics.IndexRead(project, branch, coll.Name, coll.EncodedName, index)
+}
+
+// recordIndexWrites counts a document write in the usage of all the indexes of the collection, as the write maintains
+// all of them, along with the number of the entries it added or removed from each index.
+func recordIndexWrites(ctx context.Context, coll *schema.DefaultCollection, entries map[string]int64) {
+	if len(coll.SecondaryIndexes.All) == 0 {
+		return
+	}
+
+	names := make([]string, len(coll.SecondaryIndexes.All))
+	for i, index := range coll.SecondaryIndexes.All {
+		names[i] = index.Name
+	}
+
+	project, branch := requestProjectAndBranch(ctx)
+	metrics.IndexWrites(project, branch, coll.Name, coll.EncodedName, names, entries)
+}
+
+func requestProjectAndBranch(ctx context.Context) (string, string) {
+	md, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return "", ""
+	}
+
+	return md.GetProject(), md.GetBranch()
+}
+
+// indexUsageStats returns the usage of the indexes of the collection. An index is unused if it wasn't read for the
+// unusedFor duration, or at all if the duration is zero. The indexes being built are never reported as unused.
+func indexUsageStats(coll *schema.DefaultCollection, unusedFor time.Duration, now time.Time) []*api.IndexUsageStats {
+	stats := make([]*api.IndexUsageStats, 0, len(coll.SecondaryIndexes.All))
+	for _, index := range coll.SecondaryIndexes.All {
+		usage := metrics.GetIndexUsage(coll.EncodedName, index.Name)
+
+		s := &api.IndexUsageStats{
+			Name:               index.Name,
+			State:              index.StateString(),
+			Reads:              usage.Reads,
+			EntriesWritten:     usage.EntriesWritten,
+			DocumentsWritten:   usage.DocumentsWritten,
+			WriteAmplification: usage.WriteAmplification(),
+		}
+		if !usage.LastUsed.IsZero() {
+			s.LastUsedAt = usage.LastUsed.Format(time.RFC3339)
+		}
+
+		if index.State == schema.INDEX_ACTIVE {
+			if usage.Reads == 0 {
+				// a server that just started can't tell if the index is unused
+				s.Unused = unusedFor == 0 || now.Sub(metrics.IndexUsageSince()) >= unusedFor
+			} else {
+				s.Unused = unusedFor > 0 && now.Sub(usage.LastUsed) >= unusedFor
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+// IndexUsageStatsRunner reports the usage of the secondary indexes of a collection and the indexes that are unused.
+type IndexUsageStatsRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeCollectionRequest
+}
+
+func (runner *IndexUsageStatsRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	_, coll, err := runner.getDBAndCollection(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	unusedFor, err := request.GetIndexUnusedFor(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp := &api.IndexUsageStatsResponse{
+		Collection: coll.Name,
+		Since:      metrics.IndexUsageSince().Format(time.RFC3339),
+		Indexes:    indexUsageStats(coll, unusedFor, time.Now()),
+		Unused:     []string{},
+	}
+	for _, s := range resp.Indexes {
+		if s.Unused {
+			resp.Unused = append(resp.Unused, s.Name)
+		}
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
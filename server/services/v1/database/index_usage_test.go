@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestIndexUsageStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string", "index": true },
+			"tags": { "type": "array", "items": { "type": "string" }, "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("usage_t1")
+	coll.EncodedTableIndexName = []byte("usage_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+	for id, doc := range []string{`{"id":0, "name":"a", "tags":["x", "y"]}`, `{"id":1, "name":"b", "tags":["x", "z", "w"]}`} {
+		pk := []any{"pkey", int64(id)}
+		td := createTD([]byte(doc))
+		require.NoError(t, indexer.Index(ctx, tx, td, pk))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	tx, err = tm.StartTx(ctx)
+	require.NoError(t, err)
+	filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(`{"name": "a"}`))
+	require.NoError(t, err)
+	plan, err := BuildSecondaryIndexKeys(coll, filters, nil)
+	require.NoError(t, err)
+	_, err = NewSecondaryIndexReader(ctx, tx, coll, filter.NewWrappedFilter(filters), plan)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+
+	// the stats of the indexes are keyed by name, the collection has the implicit indexes of the timestamps too
+	byName := func(stats []*api.IndexUsageStats) map[string]*api.IndexUsageStats {
+		m := make(map[string]*api.IndexUsageStats)
+		for _, s := range stats {
+			m[s.Name] = s
+		}
+		return m
+	}
+
+	stats := byName(indexUsageStats(coll, 0, time.Now()))
+	lastUsed := metrics.GetIndexUsage(coll.EncodedName, "name").LastUsed.Format(time.RFC3339)
+	require.Equal(t, &api.IndexUsageStats{
+		Name:               "name",
+		State:              "INDEX ACTIVE",
+		Reads:              1,
+		LastUsedAt:         lastUsed,
+		EntriesWritten:     2,
+		DocumentsWritten:   2,
+		WriteAmplification: 1,
+	}, stats["name"])
+	require.Equal(t, &api.IndexUsageStats{
+		Name:               "tags",
+		State:              "INDEX ACTIVE",
+		EntriesWritten:     5,
+		DocumentsWritten:   2,
+		WriteAmplification: 2.5,
+		Unused:             true,
+	}, stats["tags"])
+
+	// an index read recently is not unused, an index never read is unused once the server tracked it long enough
+	stats = byName(indexUsageStats(coll, time.Hour, time.Now()))
+	require.False(t, stats["name"].Unused)
+	require.False(t, stats["tags"].Unused)
+
+	stats = byName(indexUsageStats(coll, time.Hour, time.Now().Add(2*time.Hour)))
+	require.True(t, stats["name"].Unused)
+	require.True(t, stats["tags"].Unused)
+
+	// the indexes being built are not reported as unused
+	for _, index := range coll.SecondaryIndexes.All {
+		if index.Name == "tags" {
+			index.State = schema.INDEX_WRITE_MODE
+		}
+	}
+	require.False(t, byName(indexUsageStats(coll, 0, time.Now()))["tags"].Unused)
+}
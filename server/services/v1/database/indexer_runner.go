@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
@@ -32,6 +33,8 @@ import (
 	"github.com/tigrisdata/tigris/store/search"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
+	"google.golang.org/genproto/googleapis/api/httpbody"
 )
 
 type IndexerRunner struct {
@@ -58,6 +61,10 @@ func (runner *IndexerRunner) ReadOnly(ctx context.Context, tenant *metadata.Tena
 
 	indexer := NewSecondaryIndexer(coll)
 
+	if err = runner.migrateKeyEncoding(ctx, tenant, db, coll, indexer); err != nil {
+		return Response{}, ctx, err
+	}
+
 	if err = indexer.BuildCollection(ctx, runner.txMgr); err != nil {
 		log.Err(err).Msgf("Failed to index collection \"%s\" for db \"%s\"", coll.Name, db.DbName())
 		return Response{}, ctx, err
@@ -65,6 +72,7 @@ func (runner *IndexerRunner) ReadOnly(ctx context.Context, tenant *metadata.Tena
 
 	for _, index := range coll.SecondaryIndexes.All {
 		index.State = schema.INDEX_ACTIVE
+		index.BuildError = ""
 	}
 
 	tx, err = runner.txMgr.StartTx(ctx)
@@ -90,6 +98,74 @@ func (runner *IndexerRunner) ReadOnly(ctx context.Context, tenant *metadata.Tena
 	}, ctx, nil
 }
 
+// migrateKeyEncoding clears the indexes that are encoded with an older key encoding so that the build that follows
+// writes them again with the current encoding. These indexes are moved to the write mode, therefore, they are not used
+// by the queries until the build is finished.
+func (runner *IndexerRunner) migrateKeyEncoding(ctx context.Context, tenant *metadata.Tenant, db *metadata.Database, coll *schema.DefaultCollection, indexer SecondaryIndexer) error {
+	var stale []*schema.Index
+	for _, index := range coll.SecondaryIndexes.All {
+		if keyencoding.NeedsMigration(index) {
+			stale = append(stale, index)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	tx, err := runner.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range stale {
+		if err = indexer.DeleteIndex(ctx, tx, index); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+
+		index.State = schema.INDEX_WRITE_MODE
+		keyencoding.SetCurrent(index)
+	}
+
+	if err = tenant.UpdateCollectionIndexes(ctx, tx, db, coll.Name, coll.SecondaryIndexes.All); ulog.E(err) {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	log.Info().Msgf("Migrating %d indexes of collection '%s' to key encoding version %d", len(stale), coll.Name, keyencoding.Current)
+
+	return tx.Commit(ctx)
+}
+
+// IndexBuildStatusRunner reports the progress of the background builds of the secondary indexes of a collection.
+type IndexBuildStatusRunner struct {
+	*BaseQueryRunner
+
+	req *api.BuildCollectionIndexRequest
+}
+
+func (runner *IndexBuildStatusRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, coll, err := runner.getDBAndCollection(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	data, err := jsoniter.Marshal(&api.BuildIndexStatusResponse{
+		Collection: coll.Name,
+		Indexes:    indexBuildStatus(tenant.GetNamespace().Id(), db.Id(), coll),
+	})
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
+
 type SearchIndexerRunner struct {
 	*BaseQueryRunner
 
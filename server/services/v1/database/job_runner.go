@@ -0,0 +1,166 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// JobRequest is used to manage the scheduled jobs of a project.
+type JobRequest struct {
+	Project string
+	Name    string
+	Job     *metadata.Job
+	// Limit is the number of the runs returned by the run history, all the recorded runs if zero
+	Limit int
+}
+
+// JobQueryRunner manages the scheduled jobs of a project and reads the history of their runs. The jobs are run by the
+// scheduler of the server holding the scheduler lease.
+type JobQueryRunner struct {
+	*BaseQueryRunner
+
+	createReq *JobRequest
+	listReq   *JobRequest
+	deleteReq *JobRequest
+	runsReq   *JobRequest
+
+	jobs []metadata.Job
+	runs []metadata.JobRun
+}
+
+func (runner *JobQueryRunner) SetCreateOrUpdateJobReq(req *JobRequest) {
+	runner.createReq = req
+}
+
+func (runner *JobQueryRunner) SetListJobsReq(req *JobRequest) {
+	runner.listReq = req
+}
+
+func (runner *JobQueryRunner) SetDeleteJobReq(req *JobRequest) {
+	runner.deleteReq = req
+}
+
+func (runner *JobQueryRunner) SetListJobRunsReq(req *JobRequest) {
+	runner.runsReq = req
+}
+
+// Jobs returns the jobs read or written by the last run.
+func (runner *JobQueryRunner) Jobs() []metadata.Job {
+	return runner.jobs
+}
+
+// Runs returns the job runs read by the last run, the most recent first.
+func (runner *JobQueryRunner) Runs() []metadata.JobRun {
+	return runner.runs
+}
+
+func (runner *JobQueryRunner) create(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.createReq
+
+	project, err := tenant.GetProject(req.Project)
+	if err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	job := req.Job
+	if err = metadata.ValidateJob(job); err != nil {
+		return Response{}, ctx, err
+	}
+
+	if job.Task != metadata.JobTaskExport && len(job.Collection) > 0 {
+		db, err := project.GetDatabase(metadata.NewDatabaseNameWithBranch(req.Project, job.Branch))
+		if err != nil {
+			return Response{}, ctx, CreateApiError(err)
+		}
+		if db.GetCollection(job.Collection) == nil {
+			return Response{}, ctx, errors.NotFound("collection doesn't exist '%s'", job.Collection)
+		}
+	}
+
+	var currentSub string
+	if runner.accessToken != nil {
+		currentSub = runner.accessToken.Sub
+	}
+
+	created, err := tenant.CreateOrUpdateJob(ctx, tx, req.Project, job, currentSub)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.jobs = []metadata.Job{*job}
+
+	if created {
+		return Response{Status: CreatedStatus}, ctx, nil
+	}
+
+	return Response{Status: UpdatedStatus}, ctx, nil
+}
+
+func (runner *JobQueryRunner) list(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if _, err := tenant.GetProject(runner.listReq.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	jobs, err := tenant.ListJobs(ctx, tx, runner.listReq.Project)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.jobs = jobs
+
+	return Response{}, ctx, nil
+}
+
+func (runner *JobQueryRunner) delete(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if err := tenant.DeleteJob(ctx, tx, runner.deleteReq.Project, runner.deleteReq.Name); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+func (runner *JobQueryRunner) listRuns(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.runsReq
+
+	if _, err := tenant.GetProject(req.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	runs, err := metadata.NewJobStore(metadata.DefaultNameRegistry).ListRuns(ctx, tx, tenant.GetNamespace().Id(), req.Project, req.Name, req.Limit)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.runs = runs
+
+	return Response{}, ctx, nil
+}
+
+func (runner *JobQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	switch {
+	case runner.createReq != nil:
+		return runner.create(ctx, tx, tenant)
+	case runner.listReq != nil:
+		return runner.list(ctx, tx, tenant)
+	case runner.deleteReq != nil:
+		return runner.delete(ctx, tx, tenant)
+	case runner.runsReq != nil:
+		return runner.listRuns(ctx, tx, tenant)
+	}
+
+	return Response{}, ctx, errors.Unknown("unknown request path")
+}
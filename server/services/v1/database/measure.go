@@ -17,6 +17,9 @@ package database
 import (
 	"context"
 
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/metrics"
 )
@@ -43,6 +46,56 @@ func countDDLDropUnit(ctx context.Context) {
 	}
 }
 
+// measurePlanning traces the planning of a query as a child span of the request, between the span of the gRPC handler
+// and the spans of the transaction and the search backend calls executing the plan.
+func measurePlanning(ctx context.Context, name string, f func() error) error {
+	measurement := metrics.NewMeasurement(metrics.QueryPlannerServiceName, name, metrics.QueryPlanningSpanType,
+		map[string]string{"query_planner_method": name})
+	ctx = measurement.StartTracing(ctx, true)
+	err := f()
+	if err != nil {
+		_ = measurement.FinishWithError(ctx, err)
+	} else {
+		_ = measurement.FinishTracing(ctx)
+	}
+
+	return err
+}
+
+// planRead builds the reader options of the read request in a query planning span.
+func (runner *BaseQueryRunner) planRead(ctx context.Context, req *api.ReadRequest, coll *schema.DefaultCollection) (options readerOptions, err error) {
+	_ = measurePlanning(ctx, "Read", func() error {
+		options, err = runner.buildReaderOptions(req, coll)
+		return err
+	})
+	if err == nil {
+		countFilterPushdown(&options)
+	}
+
+	return
+}
+
+// countFilterPushdown counts the conditions of the filter served by the index of the plan of the read. The conditions
+// of a composite primary key plan, which has no field name, are counted as a single equality.
+func countFilterPushdown(options *readerOptions) {
+	if !metrics.FilterMetricsEnabled() || options.plan == nil || options.plan.QueryType == filter.FULLRANGE {
+		return
+	}
+
+	index := "primary"
+	if filter.IndexTypeSecondary(options.plan.IndexType) {
+		index = "secondary"
+	}
+
+	operators := options.filter.FieldOperators(options.plan.FieldName)
+	if len(operators) == 0 && options.plan.QueryType == filter.EQUAL {
+		operators = []string{filter.EQ}
+	}
+	for _, operator := range operators {
+		metrics.FilterPushdown(operator, index)
+	}
+}
+
 func countDDLUpdateUnit(ctx context.Context, cond bool) {
 	if config.DefaultConfig.GlobalStatus.Enabled {
 		reqStatus, ok := metrics.RequestStatusFromContext(ctx)
@@ -0,0 +1,171 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+const WrittenStatus string = "written"
+
+// MultiWriteQueryRunner runs the writes of a MultiWrite request across the collections of a database branch in the
+// transaction of the request, either all of them are committed or none is. Every write is run by the runner of its
+// operation, so the documents are validated against the schema of their collection, and the write hooks, the
+// secondary indexes and the row-level security policies apply as for a single write. The writes run in their order
+// in the request, a write sees the documents written by the previous ones.
+type MultiWriteQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.CommitTransactionRequest
+	queryMetrics *metrics.WriteQueryMetrics
+}
+
+func (runner *MultiWriteQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	writes := runner.req.GetWrites()
+	if err := validateMultiWrites(writes, config.DefaultConfig.Server.MultiWrite); err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp := &api.MultiWriteResponse{Status: WrittenStatus, Results: make([]*api.MultiWriteResult, 0, len(writes))}
+	for i, w := range writes {
+		writeResp, writeCtx, err := runner.writeRunner(w).Run(ctx, tx, tenant)
+		ctx = writeCtx
+		if err != nil {
+			return Response{}, ctx, multiWriteError(i, w, err)
+		}
+
+		resp.Results = append(resp.Results, &api.MultiWriteResult{
+			Collection:    w.Collection,
+			Op:            w.Op,
+			Keys:          api.ByteToRawMessage(writeResp.AllKeys),
+			ModifiedCount: writeResp.ModifiedCount,
+		})
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	runner.queryMetrics.SetWriteType("multi_write")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	return Response{
+		Response: &httpbody.HttpBody{ContentType: "application/json", Data: data},
+		Status:   WrittenStatus,
+	}, ctx, nil
+}
+
+// writeRunner returns the runner of the operation of the write, on the database branch of the request.
+func (runner *MultiWriteQueryRunner) writeRunner(w *api.MultiWriteOp) QueryRunner {
+	project, branch := runner.req.GetProject(), runner.req.GetBranch()
+
+	switch w.Op {
+	case api.MultiWriteInsert:
+		return &InsertQueryRunner{
+			BaseQueryRunner: runner.BaseQueryRunner,
+			req:             &api.InsertRequest{Project: project, Branch: branch, Collection: w.Collection, Documents: api.RawMessageToByte(w.Documents)},
+			queryMetrics:    runner.queryMetrics,
+		}
+	case api.MultiWriteReplace:
+		return &ReplaceQueryRunner{
+			BaseQueryRunner: runner.BaseQueryRunner,
+			req:             &api.ReplaceRequest{Project: project, Branch: branch, Collection: w.Collection, Documents: api.RawMessageToByte(w.Documents)},
+			queryMetrics:    runner.queryMetrics,
+		}
+	case api.MultiWriteUpdate:
+		return &UpdateQueryRunner{
+			BaseQueryRunner: runner.BaseQueryRunner,
+			req:             &api.UpdateRequest{Project: project, Branch: branch, Collection: w.Collection, Filter: w.Filter, Fields: w.Fields},
+			queryMetrics:    runner.queryMetrics,
+		}
+	default:
+		return &DeleteQueryRunner{
+			BaseQueryRunner: runner.BaseQueryRunner,
+			req:             &api.DeleteRequest{Project: project, Branch: branch, Collection: w.Collection, Filter: w.Filter},
+			queryMetrics:    runner.queryMetrics,
+		}
+	}
+}
+
+// validateMultiWrites checks the writes before any of them runs, so that a malformed request doesn't do any write.
+func validateMultiWrites(writes []*api.MultiWriteOp, cfg config.MultiWriteConfig) error {
+	if len(writes) == 0 {
+		return errors.InvalidArgument("multi write has no writes")
+	}
+	if cfg.MaxWrites > 0 && len(writes) > cfg.MaxWrites {
+		return errors.InvalidArgument("multi write has %d writes, the maximum is %d", len(writes), cfg.MaxWrites)
+	}
+
+	documents := 0
+	for i, w := range writes {
+		if len(w.Collection) == 0 {
+			return errors.InvalidArgument("write %d of the multi write has no collection", i)
+		}
+
+		switch w.Op {
+		case api.MultiWriteInsert, api.MultiWriteReplace:
+			if len(w.Documents) == 0 {
+				return errors.InvalidArgument("%s %d of the multi write has no documents", w.Op, i)
+			}
+			if len(w.Filter) > 0 || len(w.Fields) > 0 {
+				return errors.InvalidArgument("%s %d of the multi write can't have a filter or fields", w.Op, i)
+			}
+			documents += len(w.Documents)
+		case api.MultiWriteUpdate:
+			if len(w.Filter) == 0 || len(w.Fields) == 0 {
+				return errors.InvalidArgument("update %d of the multi write requires a filter and fields", i)
+			}
+		case api.MultiWriteDelete:
+			if len(w.Filter) == 0 {
+				return errors.InvalidArgument("delete %d of the multi write requires a filter", i)
+			}
+		default:
+			return errors.InvalidArgument("unsupported operation '%s' of write %d of the multi write", w.Op, i)
+		}
+		if (w.Op == api.MultiWriteUpdate || w.Op == api.MultiWriteDelete) && len(w.Documents) > 0 {
+			return errors.InvalidArgument("%s %d of the multi write can't have documents", w.Op, i)
+		}
+	}
+
+	if cfg.MaxDocuments > 0 && documents > cfg.MaxDocuments {
+		return errors.InvalidArgument("multi write has %d documents, the maximum is %d", documents, cfg.MaxDocuments)
+	}
+
+	return nil
+}
+
+// multiWriteError adds the write which failed to the message of the error, keeping its code and its details.
+func multiWriteError(i int, w *api.MultiWriteOp, err error) error {
+	e, ok := err.(*api.TigrisError)
+	if !ok {
+		return err
+	}
+
+	wrapped := *e
+	wrapped.Message = fmt.Sprintf("%s %d of the multi write on the collection '%s': %s", w.Op, i, w.Collection, e.Message)
+
+	return &wrapped
+}
@@ -0,0 +1,104 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestValidateMultiWrites(t *testing.T) {
+	cfg := config.MultiWriteConfig{MaxWrites: 3, MaxDocuments: 2}
+	doc := []jsoniter.RawMessage{[]byte(`{"id":1}`)}
+
+	require.NoError(t, validateMultiWrites([]*api.MultiWriteOp{
+		{Collection: "orders", Op: api.MultiWriteInsert, Documents: doc},
+		{Collection: "order_items", Op: api.MultiWriteReplace, Documents: doc},
+		{Collection: "orders", Op: api.MultiWriteUpdate, Filter: []byte(`{"id":1}`), Fields: []byte(`{"$set":{"paid":true}}`)},
+	}, cfg))
+
+	cases := []struct {
+		writes []*api.MultiWriteOp
+		err    error
+	}{
+		{
+			nil,
+			errors.InvalidArgument("multi write has no writes"),
+		}, {
+			make([]*api.MultiWriteOp, 4),
+			errors.InvalidArgument("multi write has 4 writes, the maximum is 3"),
+		}, {
+			[]*api.MultiWriteOp{{Op: api.MultiWriteInsert, Documents: doc}},
+			errors.InvalidArgument("write 0 of the multi write has no collection"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: "upsert", Documents: doc}},
+			errors.InvalidArgument("unsupported operation 'upsert' of write 0 of the multi write"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: api.MultiWriteInsert}},
+			errors.InvalidArgument("insert 0 of the multi write has no documents"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: api.MultiWriteReplace, Documents: doc, Filter: []byte(`{"id":1}`)}},
+			errors.InvalidArgument("replace 0 of the multi write can't have a filter or fields"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: api.MultiWriteUpdate, Filter: []byte(`{"id":1}`)}},
+			errors.InvalidArgument("update 0 of the multi write requires a filter and fields"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: api.MultiWriteDelete}},
+			errors.InvalidArgument("delete 0 of the multi write requires a filter"),
+		}, {
+			[]*api.MultiWriteOp{{Collection: "orders", Op: api.MultiWriteDelete, Filter: []byte(`{"id":1}`), Documents: doc}},
+			errors.InvalidArgument("delete 0 of the multi write can't have documents"),
+		}, {
+			[]*api.MultiWriteOp{
+				{Collection: "orders", Op: api.MultiWriteInsert, Documents: doc},
+				{Collection: "order_items", Op: api.MultiWriteInsert, Documents: append(doc, doc...)},
+			},
+			errors.InvalidArgument("multi write has 3 documents, the maximum is 2"),
+		},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.err, validateMultiWrites(c.writes, cfg))
+	}
+}
+
+func TestMultiWriteRunner(t *testing.T) {
+	req := &api.CommitTransactionRequest{Project: "p1", Branch: "b1"}
+	runner := &MultiWriteQueryRunner{req: req}
+
+	insert, ok := runner.writeRunner(&api.MultiWriteOp{
+		Collection: "orders", Op: api.MultiWriteInsert, Documents: []jsoniter.RawMessage{[]byte(`{"id":1}`)},
+	}).(*InsertQueryRunner)
+	require.True(t, ok)
+	require.Equal(t, &api.InsertRequest{Project: "p1", Branch: "b1", Collection: "orders", Documents: [][]byte{[]byte(`{"id":1}`)}}, insert.req)
+
+	update, ok := runner.writeRunner(&api.MultiWriteOp{
+		Collection: "orders", Op: api.MultiWriteUpdate, Filter: []byte(`{"id":1}`), Fields: []byte(`{"$set":{"paid":true}}`),
+	}).(*UpdateQueryRunner)
+	require.True(t, ok)
+	require.Equal(t, "b1", update.req.Branch)
+	require.Equal(t, []byte(`{"id":1}`), update.req.Filter)
+
+	_, ok = runner.writeRunner(&api.MultiWriteOp{Collection: "orders", Op: api.MultiWriteDelete, Filter: []byte(`{}`)}).(*DeleteQueryRunner)
+	require.True(t, ok)
+
+	err := multiWriteError(1, &api.MultiWriteOp{Collection: "order_items", Op: api.MultiWriteInsert},
+		errors.AlreadyExists("duplicate key value, violates key constraint"))
+	require.Equal(t, errors.AlreadyExists("insert 1 of the multi write on the collection 'order_items': duplicate key value, violates key constraint"), err)
+}
@@ -0,0 +1,139 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestMultikeyIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"tags": { "type": "array", "items": { "type": "string" }, "index": true }
+		},
+		"primary_key": ["id"]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("multikey_t1")
+	coll.EncodedTableIndexName = []byte("multikey_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	docs := map[int64]*internal.TableData{}
+
+	write := func(id int64, doc string) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+
+		pk := []any{"pkey", id}
+		td := createTD([]byte(doc))
+		require.NoError(t, indexer.Update(ctx, tx, td, docs[id], pk))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		require.NoError(t, tx.Commit(ctx))
+		docs[id] = td
+	}
+
+	read := func(reqFilter string, sortFields *sort.Ordering) []int64 {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+		plan, err := BuildSecondaryIndexKeys(coll, filters, sortFields)
+		require.NoError(t, err)
+		require.Equal(t, "tags", plan.FieldName)
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := NewSecondaryIndexReader(ctx, tx, coll, wrapped, plan)
+		require.NoError(t, err)
+		filtered := NewFilterIterator(iter, wrapped)
+
+		var (
+			row Row
+			ids []int64
+		)
+		for filtered.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, filtered.Interrupted())
+
+		return ids
+	}
+
+	write(1, `{"id":1, "tags":["golang", "database", "golang"]}`)
+	write(2, `{"id":2, "tags":["rust"]}`)
+	write(3, `{"id":3, "tags":["golang", "rust"]}`)
+
+	t.Run("duplicate elements have a single entry", func(t *testing.T) {
+		updateSet, err := indexer.buildAddAndRemoveKVs(docs[1], nil, []any{"pkey", int64(1)})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), updateSet.addCounts["tags"])
+	})
+
+	t.Run("element", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3}, read(`{"tags": "golang"}`, nil))
+	})
+
+	t.Run("in", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3, 2}, read(`{"tags": {"$in": ["rust", "golang", "rust"]}}`, nil))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		require.Equal(t, []int64{3}, read(`{"tags": {"$all": ["rust", "golang"]}}`, nil))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		require.Equal(t, []int64{2, 3}, read(`{"tags": {"$gt": "m"}}`, nil))
+	})
+
+	t.Run("entries removed by update", func(t *testing.T) {
+		write(1, `{"id":1, "tags":["database"]}`)
+		require.Equal(t, []int64{3}, read(`{"tags": "golang"}`, nil))
+		require.Equal(t, []int64{1}, read(`{"tags": "database"}`, nil))
+
+		write(3, `{"id":3, "tags":[]}`)
+		require.Equal(t, []int64{1}, read(`{"tags": {"$in": ["golang", "database"]}}`, nil))
+	})
+
+	t.Run("array is not sorted with the index", func(t *testing.T) {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(`{"tags": "rust"}`))
+		require.NoError(t, err)
+		_, err = BuildSecondaryIndexKeys(coll, filters, &sort.Ordering{{Name: "tags", Ascending: true}})
+		require.Error(t, err)
+	})
+}
@@ -15,8 +15,10 @@
 package database
 
 import (
+	"context"
 	"strings"
 
+	"github.com/tigrisdata/tigris/query/expression/cel"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/services/v1/common"
 )
@@ -38,6 +40,9 @@ type insertPayloadMutator struct {
 	*baseMutator
 
 	createdAt string
+	// doc is the received document the computed defaults are evaluated with
+	doc map[string]any
+	err error
 }
 
 func newInsertPayloadMutator(collection *schema.DefaultCollection, createdAt string) mutator {
@@ -53,7 +58,16 @@ func newInsertPayloadMutator(collection *schema.DefaultCollection, createdAt str
 }
 
 func (mutator *insertPayloadMutator) setDefaultsInIncomingPayload(doc map[string]any) error {
-	return mutator.setDefaultsInternal(mutator.collection.TaggedDefaultsForInsert(), doc, mutator.setDefaults)
+	mutator.err = nil
+	if mutator.collection.HasComputedDefaults() {
+		// the computed defaults are evaluated with the document as it is received, not with the defaults set before
+		mutator.doc = cel.Snapshot(doc)
+	}
+	if err := mutator.setDefaultsInternal(mutator.collection.TaggedDefaultsForInsert(), doc, mutator.setDefaults); err != nil {
+		return err
+	}
+
+	return mutator.err
 }
 
 func (*insertPayloadMutator) setDefaultsInExistingPayload(_ map[string]any) error {
@@ -69,7 +83,14 @@ func (mutator *insertPayloadMutator) setDefaults(doc map[string]any, field *sche
 		mutator.mutated = true
 		doc[field.FieldName] = mutator.createdAt
 	}
-	if defaultValue := field.Defaulter.GetValue(); defaultValue != nil {
+	defaultValue, err := field.Defaulter.Evaluate(context.Background(), mutator.doc)
+	if err != nil {
+		if mutator.err == nil {
+			mutator.err = err
+		}
+		return
+	}
+	if defaultValue != nil {
 		mutator.mutated = true
 		doc[field.FieldName] = defaultValue
 	}
@@ -100,6 +100,63 @@ func TestMutateSetDefaults(t *testing.T) {
 	}
 }
 
+func TestMutateSetComputedDefaults(t *testing.T) {
+	reqSchema := []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": {"type": "integer"},
+			"first": {"type": "string"},
+			"last": {"type": "string"},
+			"name": {"type": "string", "default": {"$cel": "doc.first + ' ' + doc.last"}},
+			"slug": {"type": "string", "default": {"$cel": "has(doc.name) ? doc.name.lowerAscii() : doc.first.lowerAscii()"}}
+		},
+		"primary_key": ["id"]
+	}`)
+
+	schFactory, err := schema.NewFactoryBuilder(true).Build("t1", reqSchema)
+	require.NoError(t, err)
+	coll, err := schema.NewDefaultCollection(1, 1, schFactory, nil, nil)
+	require.NoError(t, err)
+	require.True(t, coll.HasComputedDefaults())
+	p := newInsertPayloadMutator(coll, time.Now().UTC().String())
+
+	cases := []struct {
+		input  []byte
+		output []byte
+	}{
+		{
+			// the defaults are computed from the received document, slug doesn't see the default of name
+			[]byte(`{"first":"Ann","last":"Lee"}`),
+			[]byte(`{"first":"Ann","last":"Lee","name":"Ann Lee","slug":"ann"}`),
+		},
+		{
+			[]byte(`{"first":"Ann","last":"Lee","name":"Ann B. Lee"}`),
+			[]byte(`{"first":"Ann","last":"Lee","name":"Ann B. Lee","slug":"ann b. lee"}`),
+		},
+	}
+	for _, c := range cases {
+		doc, err := util.JSONToMap(c.input)
+		require.NoError(t, err)
+
+		require.NoError(t, p.setDefaultsInIncomingPayload(doc))
+		require.True(t, p.isMutated())
+		actualJS, err := util.MapToJSON(doc)
+		require.NoError(t, err)
+		require.JSONEq(t, string(c.output), string(actualJS))
+	}
+
+	doc, err := util.JSONToMap([]byte(`{"first":"Ann"}`))
+	require.NoError(t, err)
+	require.Error(t, p.setDefaultsInIncomingPayload(doc))
+
+	_, err = schema.NewFactoryBuilder(true).Build("t1", []byte(`{
+		"title": "t1",
+		"properties": {"id": {"type": "integer"}, "name": {"type": "string", "default": {"$cel": "doc.first +"}}},
+		"primary_key": ["id"]
+	}`))
+	require.Error(t, err)
+}
+
 func TestMutateSetDefaultsComplexSchema(t *testing.T) {
 	reqSchema := []byte(`{
 		"title": "t1",
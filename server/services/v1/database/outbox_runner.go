@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/buger/jsonparser"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+const AppendedStatus string = "appended"
+
+// OutboxQueryRunner appends events to the change stream of a collection. The events are buffered in the event
+// listener of the transaction like the writes, so the change stream publishes them in the same commit as the writes
+// of the transaction, and they are never stored as documents of the collection.
+type OutboxQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.InsertRequest
+	queryMetrics *metrics.WriteQueryMetrics
+}
+
+func (runner *OutboxQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if !config.DefaultConfig.Cdc.Enabled {
+		return Response{}, ctx, errors.Unimplemented("outbox events require change streams to be enabled")
+	}
+
+	db, coll, err := runner.getDBAndCollection(ctx, tx, tenant,
+		runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	ctx = runner.cdcMgr.WrapContext(ctx, db.Name())
+
+	if err = runner.mustBeDocumentsCollection(coll, "append events"); err != nil {
+		return Response{}, ctx, err
+	}
+
+	ts := internal.NewTimestamp()
+	listener := kv.GetEventListener(ctx)
+	allKeys := make([][]byte, 0, len(runner.req.GetDocuments()))
+	for _, event := range runner.req.GetDocuments() {
+		if _, dataType, _, err := jsonparser.Get(event); err != nil || dataType != jsonparser.Object {
+			return Response{}, ctx, errors.InvalidArgument("outbox event should be an object")
+		}
+
+		id := uuid.New().String()
+		key, err := runner.encoder.EncodeKey(coll.EncodedName, coll.GetPrimaryKey(), []any{id})
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		listener.OnSet(kv.OutboxEvent, key.Table(), kv.BuildKey(key.IndexParts()...), internal.NewTableDataWithTS(ts, nil, event), nil)
+
+		respKey, err := jsoniter.Marshal(map[string]string{"id": id})
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		allKeys = append(allKeys, respKey)
+	}
+
+	runner.queryMetrics.SetWriteType("append_events")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	return Response{
+		CreatedAt: ts,
+		AllKeys:   allKeys,
+		Status:    AppendedStatus,
+	}, ctx, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// ParallelScanIterator reads the key ranges of a table concurrently, each range in its own transactions, and returns
+// the rows matching the filter in the order of the keys, the rows of a range follow the rows of the ranges before it.
+// The rows are filtered by the readers of the ranges, therefore, the scans with a selective filter benefit the most.
+// A reader buffers a bounded number of rows ahead of the caller, the readers stop once the context is canceled, so
+// the caller cancels the context when it stops iterating before the end.
+type ParallelScanIterator struct {
+	ranges  []*scanRange
+	current int
+	err     error
+}
+
+// scanRange is a range of the keys read by a reader. The reader sets err, if any, before closing rows.
+type scanRange struct {
+	from keys.Key
+	to   keys.Key
+	rows chan Row
+	err  error
+	// scanned is the number of the rows read, before they are filtered
+	scanned atomic.Int64
+}
+
+// NewParallelScanIterator starts a reader for each range between the boundaries, the boundaries are the keys at which
+// the ranges start, in ascending order.
+func NewParallelScanIterator(ctx context.Context, txMgr *transaction.Manager, table []byte, boundaries []keys.Key,
+	filter *filter.WrappedFilter, reverse bool, buffer int,
+) *ParallelScanIterator {
+	ranges := make([]*scanRange, 0, len(boundaries)+1)
+	from := keys.NewKey(table)
+	for _, b := range boundaries {
+		ranges = append(ranges, &scanRange{from: from, to: b, rows: make(chan Row, buffer)})
+		from = b
+	}
+	ranges = append(ranges, &scanRange{from: from, rows: make(chan Row, buffer)})
+
+	if reverse {
+		for i, j := 0, len(ranges)-1; i < j; i, j = i+1, j-1 {
+			ranges[i], ranges[j] = ranges[j], ranges[i]
+		}
+	}
+
+	for _, r := range ranges {
+		go r.scan(ctx, txMgr, filter, reverse)
+	}
+
+	return &ParallelScanIterator{
+		ranges: ranges,
+	}
+}
+
+func (it *ParallelScanIterator) Next(row *Row) bool {
+	for it.err == nil && it.current < len(it.ranges) {
+		r := it.ranges[it.current]
+		if next, ok := <-r.rows; ok {
+			*row = next
+			return true
+		}
+
+		it.err = r.err
+		it.current++
+	}
+
+	return false
+}
+
+func (it *ParallelScanIterator) Interrupted() error { return it.err }
+
+// Scanned returns the number of the rows read so far by the readers of all the ranges, including the rows which didn't
+// match the filter.
+func (it *ParallelScanIterator) Scanned() int64 {
+	var scanned int64
+	for _, r := range it.ranges {
+		scanned += r.scanned.Load()
+	}
+
+	return scanned
+}
+
+// scan reads the range, if the transaction reaches its time limit the read continues from the last key read in a new
+// transaction.
+func (r *scanRange) scan(ctx context.Context, txMgr *transaction.Manager, filter *filter.WrappedFilter, reverse bool) {
+	defer close(r.rows)
+
+	var resumeAfter []byte
+	for {
+		var last []byte
+		last, r.err = r.scanTx(ctx, txMgr, filter, reverse, resumeAfter)
+		if r.err != kv.ErrTransactionMaxDurationReached || kv.HasReadVersion(ctx) || last == nil {
+			return
+		}
+
+		var key keys.Key
+		if key, r.err = keys.FromBinary(r.from.Table(), last); r.err != nil {
+			return
+		}
+		if reverse {
+			// the end of the range is exclusive, the last row read is not read again
+			r.to = key
+		} else {
+			r.from = key
+			resumeAfter = last
+		}
+	}
+}
+
+func (r *scanRange) scanTx(ctx context.Context, txMgr *transaction.Manager, filter *filter.WrappedFilter, reverse bool, resumeAfter []byte) ([]byte, error) {
+	tx, err := txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	scan, err := NewScanIterator(ctx, tx, r.from, r.to, reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := NewFilterIterator(scan, filter)
+
+	var (
+		row  Row
+		last []byte
+	)
+	for scan.Next(&row) {
+		if err = ctx.Err(); err != nil {
+			return last, err
+		}
+
+		last = row.Key
+		r.scanned.Add(1)
+		if (resumeAfter != nil && bytes.Equal(row.Key, resumeAfter)) || !matcher.advanceToMatchingRow(&row) {
+			continue
+		}
+
+		select {
+		case r.rows <- row:
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+
+	return last, scan.Interrupted()
+}
+
+// scanBoundaries converts the boundary keys of the storage shards of the collection to the keys of the documents, so
+// that the chunks of a document are not split between the ranges. At most maxRanges ranges are returned by skipping
+// the boundaries evenly.
+func scanBoundaries(coll *schema.DefaultCollection, boundaries [][]byte, maxRanges int) []keys.Key {
+	docKeyParts := len(coll.GetPrimaryKey().Fields) + 1
+
+	var (
+		res  []keys.Key
+		prev []byte
+	)
+	for _, b := range boundaries {
+		key, err := keys.FromBinary(coll.EncodedName, b)
+		if err != nil || len(key.IndexParts()) < docKeyParts {
+			// not a key of a document
+			continue
+		}
+
+		key = keys.NewKey(coll.EncodedName, key.IndexParts()[:docKeyParts]...)
+		serialized := key.SerializeToBytes()
+		if prev != nil && bytes.Compare(serialized, prev) <= 0 {
+			continue
+		}
+
+		res = append(res, key)
+		prev = serialized
+	}
+
+	if maxRanges > 0 && len(res) >= maxRanges {
+		step := float64(len(res)+1) / float64(maxRanges)
+		sampled := make([]keys.Key, 0, maxRanges-1)
+		for i := 1; i < maxRanges; i++ {
+			sampled = append(sampled, res[int(float64(i)*step)-1])
+		}
+		res = sampled
+	}
+
+	return res
+}
@@ -0,0 +1,132 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func parallelScanTestCollection(t *testing.T) *schema.DefaultCollection {
+	schFactory, err := schema.NewFactoryBuilder(true).Build("t1", []byte(`{
+		"title": "t1",
+		"properties": {"id": {"type": "integer"}, "even": {"type": "boolean"}},
+		"primary_key": ["id"]
+	}`))
+	require.NoError(t, err)
+
+	coll, err := schema.NewDefaultCollection(1, 1, schFactory, nil, nil)
+	require.NoError(t, err)
+	coll.EncodedName = []byte("t_parallel")
+
+	return coll
+}
+
+func TestParallelScanIterator(t *testing.T) {
+	coll := parallelScanTestCollection(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedName))
+	tm := transaction.NewManager(kvStore)
+
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		doc := fmt.Sprintf(`{"id": %d, "even": %t}`, i, i%2 == 0)
+		require.NoError(t, tx.Insert(ctx, keys.NewKey(coll.EncodedName, "pkey", int64(i)), createTD([]byte(doc))))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	f, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(`{"even": true}`))
+	require.NoError(t, err)
+
+	boundaries := []keys.Key{
+		keys.NewKey(coll.EncodedName, "pkey", int64(10)),
+		keys.NewKey(coll.EncodedName, "pkey", int64(11)),
+		keys.NewKey(coll.EncodedName, "pkey", int64(55)),
+	}
+
+	scan := func(reverse bool, buffer int, limit int) []int64 {
+		scanCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		var (
+			ids []int64
+			row Row
+		)
+		it := NewParallelScanIterator(scanCtx, tm, coll.EncodedName, boundaries, f, reverse, buffer)
+		for (limit == 0 || len(ids) < limit) && it.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, it.Interrupted())
+
+		return ids
+	}
+
+	var exp []int64
+	for i := int64(0); i < 100; i += 2 {
+		exp = append(exp, i)
+	}
+	require.Equal(t, exp, scan(false, 4, 0))
+
+	var reversed []int64
+	for i := len(exp) - 1; i >= 0; i-- {
+		reversed = append(reversed, exp[i])
+	}
+	require.Equal(t, reversed, scan(true, 4, 0))
+
+	// the readers of the ranges which are not consumed are stopped
+	require.Equal(t, exp[:3], scan(false, 1, 3))
+}
+
+func TestScanBoundaries(t *testing.T) {
+	coll := parallelScanTestCollection(t)
+
+	key := func(parts ...any) keys.Key {
+		return keys.NewKey(coll.EncodedName, parts...)
+	}
+
+	boundaries := [][]byte{
+		coll.EncodedName,
+		key("pkey", int64(10)).SerializeToBytes(),
+		// a chunk of the document above
+		key("pkey", int64(10), "_C_", int64(1)).SerializeToBytes(),
+		key("pkey", int64(20), "_C_", int64(2)).SerializeToBytes(),
+		key("pkey", int64(30)).SerializeToBytes(),
+	}
+
+	require.Equal(t, []keys.Key{
+		key("pkey", int64(10)),
+		key("pkey", int64(20)),
+		key("pkey", int64(30)),
+	}, scanBoundaries(coll, boundaries, 0))
+
+	require.Equal(t, []keys.Key{
+		key("pkey", int64(20)),
+	}, scanBoundaries(coll, boundaries, 2))
+}
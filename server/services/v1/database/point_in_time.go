@@ -0,0 +1,217 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// PointInTimeRestoreRunner brings the collections of a branch back to an earlier moment within the retention of their
+// changelogs. The mutations committed since the moment are undone from the most recent one, in batches each in its
+// own transaction, so the secondary and the search indexes of the collections are updated like for any other write.
+//
+// The restore is done in place and is itself retained in the changelogs, so it can be undone by restoring to a moment
+// before it started. The mutations committed while the restore runs are kept. A restore failing midway leaves the
+// collections at a moment between the restore point and the start of the restore.
+type PointInTimeRestoreRunner struct {
+	*BaseQueryRunner
+
+	req        *api.DescribeDatabaseRequest
+	collection string
+	at         time.Time
+	sessions   Session
+	job        *backup.Job
+}
+
+func (runner *PointInTimeRestoreRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	start := time.Now().UTC()
+	if retention := config.DefaultConfig.Backup.Continuous.Retention; runner.at.Before(start.Add(-retention)) {
+		return Response{}, ctx, errors.FailedPrecondition("restore point in time '%s' is older than the changelog retention of %s",
+			runner.at.Format(time.RFC3339Nano), retention)
+	}
+
+	db, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	names := collectionNames(db)
+	if runner.collection != "" {
+		if _, err = runner.getCollection(db, runner.collection); err != nil {
+			return Response{}, ctx, err
+		}
+		names = []string{runner.collection}
+	}
+
+	for _, name := range names {
+		runner.job.StartCollection(name)
+		if err = runner.restoreCollection(ctx, name, start); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+// restoreCollection undoes the mutations of the collection committed between the restore point and the start.
+func (runner *PointInTimeRestoreRunner) restoreCollection(ctx context.Context, collection string, start time.Time) error {
+	batchSize := config.DefaultConfig.SecondaryIndex.BuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	batch := &pointInTimeBatchRunner{
+		BaseQueryRunner: runner.BaseQueryRunner,
+		project:         runner.req.GetProject(),
+		branch:          runner.req.GetBranch(),
+		collection:      collection,
+		from:            runner.at,
+		to:              start,
+		batchSize:       batchSize,
+	}
+	for {
+		if _, err := runner.sessions.Execute(ctx, batch, ReqOptions{}); err != nil {
+			return err
+		}
+
+		runner.job.AddDocuments(int64(batch.undone))
+		if batch.last == nil {
+			return nil
+		}
+		batch.before = batch.last
+	}
+}
+
+// pointInTimeBatchRunner undoes a batch of the mutations of a collection, from the most recent one.
+type pointInTimeBatchRunner struct {
+	*BaseQueryRunner
+
+	project    string
+	branch     string
+	collection string
+	from       time.Time
+	to         time.Time
+	batchSize  int
+	// before is the changelog key the batch reads before, the batch starts from the end of the range if it is nil.
+	before []byte
+
+	// last is the oldest changelog key undone by a full batch, nil once the range is undone.
+	last   []byte
+	undone int
+}
+
+func (runner *pointInTimeBatchRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	// the batch may be retried, only the result of the committed run is kept
+	runner.last, runner.undone = nil, 0
+
+	db, coll, err := runner.getDBAndCollection(ctx, tx, tenant, runner.project, runner.collection, runner.branch)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	ctx = runner.cdcMgr.WrapContext(ctx, db.Name())
+
+	table, err := tenant.Encoder.EncodeChangelogTableName(tenant.GetNamespace(), db, coll)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	end := keys.NewKey(table, runner.to.UnixNano())
+	if runner.before != nil {
+		if end, err = keys.FromBinary(table, runner.before); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table, runner.from.UnixNano()), end, false, true)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	var (
+		row  kv.KeyValue
+		last []byte
+	)
+	for runner.undone < runner.batchSize && iter.Next(&row) {
+		var entry changelogEntry
+		if err = jsoniter.Unmarshal(row.Data.RawData, &entry); err != nil {
+			return Response{}, ctx, err
+		}
+		if err = runner.undo(ctx, tx, tenant, db, coll, &entry); err != nil {
+			return Response{}, ctx, err
+		}
+
+		last = row.FDBKey
+		runner.undone++
+	}
+	if err = iter.Err(); err != nil {
+		return Response{}, ctx, err
+	}
+
+	if runner.undone == runner.batchSize {
+		runner.last = last
+	}
+
+	return Response{Status: OkStatus}, ctx, nil
+}
+
+// undo puts the document of the mutation back to its value before the mutation.
+func (runner *pointInTimeBatchRunner) undo(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant,
+	db *metadata.Database, coll *schema.DefaultCollection, entry *changelogEntry,
+) error {
+	if entry.Before != nil {
+		doc := entry.Before
+		if coll.HasEncryptedFields() {
+			var err error
+			if doc, err = coll.DecryptFields(doc); err != nil {
+				return err
+			}
+		}
+
+		_, _, err := runner.insertOrReplace(ctx, tx, tenant, db, coll, [][]byte{doc}, false, nil)
+		return err
+	}
+
+	// the document didn't exist before the mutation
+	key, err := keys.FromBinary(coll.EncodedName, append(append([]byte{}, coll.EncodedName...), entry.Key...))
+	if err != nil {
+		return err
+	}
+
+	var existing *internal.TableData
+	if config.DefaultConfig.SecondaryIndex.WriteEnabled {
+		existing, err = NewSecondaryIndexer(coll).ReadDocAndDelete(ctx, tx, key)
+	} else {
+		existing, err = readDocument(ctx, tx, key)
+	}
+	if err != nil || existing == nil {
+		return err
+	}
+
+	return tx.Delete(kv.CtxWithSize(ctx, existing.Size()), key)
+}
@@ -18,6 +18,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	api "github.com/tigrisdata/tigris/api/server/v1"
@@ -31,6 +34,7 @@ import (
 	"github.com/tigrisdata/tigris/query/update"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/doccache"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
 	"github.com/tigrisdata/tigris/server/request"
@@ -39,6 +43,7 @@ import (
 	"github.com/tigrisdata/tigris/util"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -80,7 +85,7 @@ func (runner *InsertQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
-	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, coll, runner.req.GetDocuments(), true)
+	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, db, coll, runner.req.GetDocuments(), true, nil)
 	if err != nil {
 		if err == kv.ErrDuplicateKey {
 			return Response{}, ctx, errors.AlreadyExists(err.(kv.StoreError).Msg())
@@ -119,7 +124,12 @@ func (runner *ReplaceQueryRunner) Run(ctx context.Context, tx transaction.Tx, te
 		return Response{}, ctx, err
 	}
 
-	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, coll, runner.req.GetDocuments(), false)
+	images, err := newDocumentImages(ctx, coll)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	ts, allKeys, err := runner.insertOrReplace(ctx, tx, tenant, db, coll, runner.req.GetDocuments(), false, images)
 	if err != nil {
 		return Response{}, ctx, err
 	}
@@ -131,6 +141,7 @@ func (runner *ReplaceQueryRunner) Run(ctx context.Context, tx transaction.Tx, te
 		CreatedAt: ts,
 		AllKeys:   allKeys,
 		Status:    ReplacedStatus,
+		Documents: images.documents(),
 	}, ctx, nil
 }
 
@@ -139,6 +150,8 @@ type UpdateQueryRunner struct {
 
 	req          *api.UpdateRequest
 	queryMetrics *metrics.WriteQueryMetrics
+	// batch restricts the update to a batch of a batched update
+	batch *UpdateBatch
 }
 
 func updateDefaultsAndSchema(db string, branch string, collection *schema.DefaultCollection, doc []byte, version uint32, ts *internal.Timestamp) ([]byte, error) {
@@ -193,6 +206,10 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, errors.InvalidArgument("updating all documents is not allowed")
 	}
 
+	if runner.req.Filter, err = applyRowPolicy(ctx, coll, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
 	if err = runner.mustBeDocumentsCollection(coll, "update"); err != nil {
 		return Response{}, ctx, err
 	}
@@ -202,10 +219,26 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
+	precondition, err := newRevisionPrecondition(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	images, err := newDocumentImages(ctx, coll)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	hooks, err := newWriteHooks(db, coll, schema.WriteHookOnUpdate)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
 	var (
 		collation     *value.Collation
 		limit         int32
 		modifiedCount int32
+		revision      int64
 		row           Row
 		ts            = internal.NewTimestamp()
 	)
@@ -225,7 +258,15 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		collation = value.NewCollation()
 	}
 
-	iterator, err := runner.getWriteIterator(ctx, tx, coll, runner.req.Filter, collation, runner.queryMetrics)
+	var (
+		iterator  Iterator
+		batchScan *batchScanIterator
+	)
+	if runner.batch != nil {
+		batchScan, iterator, err = runner.getBatchIterator(ctx, tx, coll, collation)
+	} else {
+		iterator, err = runner.getWriteIterator(ctx, tx, coll, runner.req.Filter, collation, runner.queryMetrics)
+	}
 	if err != nil {
 		return Response{}, ctx, err
 	}
@@ -236,7 +277,17 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 			return Response{}, ctx, err
 		}
 
-		merged, err := updateDefaultsAndSchema(db.DbName(), db.BranchName(), coll, row.Data.RawData, uint32(row.Data.Ver), ts)
+		if err = precondition.check(row.Data); err != nil {
+			return Response{}, ctx, err
+		}
+
+		// the update is applied on the plain text document and then encrypted again before persisting it.
+		existing, err := coll.DecryptFields(row.Data.RawData)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		merged, err := updateDefaultsAndSchema(db.DbName(), db.BranchName(), coll, existing, uint32(row.Data.Ver), ts)
 		if err != nil {
 			return Response{}, ctx, err
 		}
@@ -247,6 +298,18 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		if err != nil {
 			return Response{}, ctx, err
 		}
+
+		if merged, err = hooks.apply(ctx, merged); err != nil {
+			return Response{}, ctx, err
+		}
+
+		if err = runner.checkReferences(ctx, tx, db, coll, merged); err != nil {
+			return Response{}, ctx, err
+		}
+
+		if merged, err = coll.EncryptFields(merged); err != nil {
+			return Response{}, ctx, err
+		}
 		if len(tentativeKeysToRemove) > 0 {
 			// When an object is updated then we need to remove all the keys inside the object that are not part of the
 			// update request. The reason is as we store data in flattened form we need to remove the stale keys.
@@ -258,12 +321,17 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 
 		newData := internal.NewTableDataWithTS(row.Data.CreatedAt, ts, merged)
 		newData.SetVersion(int32(coll.GetVersion()))
+		newData.Revision = nextRevision(row.Data)
+		revision = newData.Revision
 		// as we have merged the data, it is safe to call replace
 
-		szCtx := kv.CtxWithSize(ctx, row.Data.Size())
+		szCtx := kv.CtxWithSize(kv.CtxWithCompression(ctx, coll.Compression), row.Data.Size())
 
 		isUpdate := true
 		newKey := key
+		if primaryKeyMutation && runner.batch != nil {
+			return Response{}, ctx, errors.InvalidArgument("batched update can't change the primary key")
+		}
 		if primaryKeyMutation {
 			// we need to deleteReq old key and build new key from new data
 			keyGen := newKeyGenerator(newData.RawData, tenant.TableKeyGenerator, coll.GetPrimaryKey())
@@ -299,6 +367,18 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		if err = tx.Replace(szCtx, newKey, newData, isUpdate); ulog.E(err) {
 			return Response{}, ctx, err
 		}
+
+		if err = images.add(row.Data, newData); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	if batchScan != nil {
+		batchScan.finish(limit == 0 || modifiedCount < limit)
+	}
+
+	if modifiedCount == 1 {
+		setRevisionHeader(ctx, revision)
 	}
 
 	ctx = metrics.UpdateSpanTags(ctx, runner.queryMetrics)
@@ -306,6 +386,7 @@ func (runner *UpdateQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		Status:        UpdatedStatus,
 		UpdatedAt:     ts,
 		ModifiedCount: modifiedCount,
+		Documents:     images.documents(),
 	}, ctx, err
 }
 
@@ -314,6 +395,14 @@ type DeleteQueryRunner struct {
 
 	req          *api.DeleteRequest
 	queryMetrics *metrics.WriteQueryMetrics
+	// dryRun counts the documents matching the filter instead of deleting them
+	dryRun bool
+}
+
+// SetDryRun makes the runner return the number of the documents the delete would delete, without deleting them. The
+// documents deleted by the cascading references are not counted.
+func (runner *DeleteQueryRunner) SetDryRun(dryRun bool) {
+	runner.dryRun = dryRun
 }
 
 func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
@@ -330,6 +419,10 @@ func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
+	if runner.req.Filter, err = applyRowPolicy(ctx, coll, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
 	ts := internal.NewTimestamp()
 
 	reqStatus, reqStatusFound := metrics.RequestStatusFromContext(ctx)
@@ -338,7 +431,7 @@ func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 	if filter.None(runner.req.Filter) {
 		iterator, err = NewDatabaseReader(ctx, tx).ScanTable(coll.EncodedName, false)
 		// For a delete without filter, do not count the read operations
-		if reqStatusFound {
+		if reqStatusFound && !runner.dryRun {
 			reqStatus.AddDDLDropUnit()
 			reqStatus.SetReadBytes(int64(0))
 			reqStatus.SetWriteBytes(int64(0))
@@ -359,6 +452,16 @@ func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
+	precondition, err := newRevisionPrecondition(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	images, err := newDocumentImages(ctx, coll)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
 	limit := int32(0)
 	if runner.req.Options != nil {
 		limit = int32(runner.req.Options.Limit)
@@ -371,6 +474,19 @@ func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 		if err != nil {
 			return Response{}, ctx, err
 		}
+		if err = precondition.check(row.Data); err != nil {
+			return Response{}, ctx, err
+		}
+		if err = images.add(row.Data, nil); err != nil {
+			return Response{}, ctx, err
+		}
+		if runner.dryRun {
+			modifiedCount++
+			if limit > 0 && modifiedCount == limit {
+				break
+			}
+			continue
+		}
 		if reqStatusFound {
 			reqStatus.AddWriteBytes(int64(row.Data.Size()))
 		}
@@ -388,17 +504,27 @@ func (runner *DeleteQueryRunner) Run(ctx context.Context, tx transaction.Tx, ten
 			return Response{}, ctx, err
 		}
 
+		if err = runner.applyOnDeleteReferences(ctx, tx, db, coll, row.Data.RawData); err != nil {
+			return Response{}, ctx, err
+		}
+
 		modifiedCount++
 		if limit > 0 && modifiedCount == limit {
 			break
 		}
 	}
 
+	status := DeletedStatus
+	if runner.dryRun {
+		status = DryRunStatus
+	}
+
 	ctx = metrics.UpdateSpanTags(ctx, runner.queryMetrics)
 	return Response{
-		Status:        DeletedStatus,
+		Status:        status,
 		DeletedAt:     ts,
 		ModifiedCount: modifiedCount,
+		Documents:     images.documents(),
 	}, ctx, nil
 }
 
@@ -419,6 +545,10 @@ func (runner *CountQueryRunner) Run(ctx context.Context, tx transaction.Tx, tena
 		return Response{}, ctx, err
 	}
 
+	if runner.req.Filter, err = applyRowPolicy(ctx, coll, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
 	reader := NewDatabaseReader(ctx, tx)
 	var iterator Iterator
 	if iterator, err = reader.ScanTable(coll.EncodedName, false); err != nil {
@@ -459,6 +589,17 @@ type StreamingQueryRunner struct {
 	req          *api.ReadRequest
 	streaming    Streaming
 	queryMetrics *metrics.StreamingQueryMetrics
+	// scanned and returned are the number of the rows read and returned by the read, for the slow query log and the
+	// query stats, keysScanned is the number of the index entries read by the reads of a secondary index
+	scanned     atomic.Int64
+	returned    int64
+	keysScanned atomic.Int64
+	planning    time.Duration
+	// results records the rows of the read whose result is cached, resultCache is the outcome of its lookup
+	results     *resultRecorder
+	resultCache *api.ResultCacheStats
+	// skipped is the number of the rows skipped by the read which were not read from the search store
+	skipped int64
 }
 
 type readerOptions struct {
@@ -469,6 +610,12 @@ type readerOptions struct {
 	sorting      *sort.Ordering
 	filter       *filter.WrappedFilter
 	fieldFactory *read.FieldFactory
+	// covered is set if the secondary index plan is on a composite index which entries have all the fields of the read
+	covered bool
+	// docCache is set if the documents of the primary key plan are served by the document cache
+	docCache *doccache.Collection
+	// memSort is set if the rows of the table plan are sorted in memory because the search index can't serve the sort
+	memSort *sort.Ordering
 }
 
 func (runner *BaseQueryRunner) buildReaderOptions(req *api.ReadRequest, collection *schema.DefaultCollection) (readerOptions, error) {
@@ -494,9 +641,15 @@ func (runner *BaseQueryRunner) buildReaderOptions(req *api.ReadRequest, collecti
 		}
 	}
 
-	if from == nil && config.DefaultConfig.SecondaryIndex.ReadEnabled {
-		if secondarySorting, err := runner.getSortOrdering(collection, req.Sort); err == nil {
+	if from == nil && config.DefaultConfig.SecondaryIndex.ReadEnabled && secondaryIndexServesCollation(req.Filter, collation) {
+		// multiple sort fields can be served by a composite index
+		if secondarySorting, err := sort.UnmarshalSort(req.Sort); err == nil {
 			if options.plan, err = runner.buildSecondaryIndexKeysUsingFilter(collection, req.Filter, collation, secondarySorting); err == nil {
+				runner.useCoveringIndex(collection, req.Filter, secondarySorting, &options)
+				return options, nil
+			}
+			if options.plan, err = runner.buildCaseInsensitiveIndexKeys(collection, req.Filter, collation, secondarySorting); err == nil {
+				runner.useCoveringIndex(collection, req.Filter, secondarySorting, &options)
 				return options, nil
 			}
 		}
@@ -506,6 +659,10 @@ func (runner *BaseQueryRunner) buildReaderOptions(req *api.ReadRequest, collecti
 		// error here means we need to check if we handle sort on database level
 		options.sorting = searchSorting
 		options.inMemoryStore = true
+		if !canPushDownToSearch(options) {
+			// the search index can't serve the filter, the rows matching it are sorted in memory
+			useMemSort(&options, req, collection)
+		}
 		return options, nil
 	}
 
@@ -572,6 +729,8 @@ func (runner *StreamingQueryRunner) instrumentRunner(ctx context.Context, option
 	//nolint:gocritic
 	if options.tablePlan != nil {
 		runner.queryMetrics.SetReadType("full_scan")
+	} else if options.covered {
+		runner.queryMetrics.SetReadType("covering")
 	} else if options.plan != nil && filter.IndexTypeSecondary(options.plan.IndexType) {
 		runner.queryMetrics.SetReadType("secondary")
 	} else if options.plan != nil && filter.IndexTypePrimary(options.plan.IndexType) {
@@ -586,6 +745,12 @@ func (runner *StreamingQueryRunner) instrumentRunner(ctx context.Context, option
 // ReadOnly is used by the read query runner to handle long-running reads. This method operates by starting a new
 // transaction when needed which means a single user request may end up creating multiple read only transactions.
 func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if api.IsSystemCollection(runner.req.GetCollection()) {
+		return runner.readSystemCollection(ctx, nil, tenant)
+	}
+
+	start := time.Now()
+
 	db, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetBranch())
 	if err != nil {
 		return Response{}, ctx, err
@@ -598,11 +763,34 @@ func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metada
 		return Response{}, ctx, err
 	}
 
-	options, err := runner.buildReaderOptions(runner.req, collection)
+	options, err := runner.planRead(ctx, runner.req, collection)
 	if err != nil {
 		return Response{}, ctx, err
 	}
 
+	if options.inMemoryStore && options.sorting == nil && kv.HasReadVersion(ctx) {
+		// the search store is not versioned, a read pinned to a snapshot filters the table instead
+		options.inMemoryStore = false
+		options.tablePlan = &filter.TableScanPlan{Table: collection.EncodedName}
+	}
+	if options.inMemoryStore && options.sorting != nil && (kv.HasReadVersion(ctx) || searchIndexStale(tenant, db, collection)) {
+		// the search index may miss the latest writes, the rows are sorted in memory unless only the search index
+		// can sort them
+		useMemSort(&options, runner.req, collection)
+	}
+	if options.plan != nil && !filter.IndexTypeSecondary(options.plan.IndexType) {
+		options.docCache = docCacheCollection(ctx, tenant, db, runner.req)
+	}
+
+	recordFullScan(tenant, db, runner.req, options)
+	runner.planning = time.Since(start)
+	defer func() {
+		runner.recordSlowQuery(tenant, db, options, start)
+		if request.IncludeQueryStats(ctx) {
+			setQueryStats(ctx, runner.queryStats(options, start))
+		}
+	}()
+
 	if options.inMemoryStore {
 		if err = runner.iterateOnSearchStore(ctx, collection, options); err != nil {
 			return Response{}, ctx, CreateApiError(err)
@@ -610,6 +798,23 @@ func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metada
 		return Response{}, ctx, nil
 	}
 
+	if runner.canScanInParallel(options) {
+		scanned, err := runner.iterateInParallel(ctx, tenant, db, collection, options)
+		if err != nil {
+			return Response{}, ctx, CreateApiError(err)
+		}
+		if scanned {
+			return Response{}, runner.instrumentRunner(ctx, options), nil
+		}
+	}
+
+	if hit, err := runner.readCachedResult(ctx, tenant, db, collection, options); hit || err != nil {
+		if err != nil {
+			return Response{}, ctx, CreateApiError(err)
+		}
+		return Response{}, runner.instrumentRunner(ctx, options), nil
+	}
+
 	for {
 		// A for loop is needed to recreate the transaction after exhausting the duration of the previous transaction.
 		// This is mainly needed for long-running transactions, otherwise reads should be small.
@@ -627,7 +832,16 @@ func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metada
 
 		_ = tx.Rollback(ctx)
 
+		if err == kv.ErrTransactionMaxDurationReached && kv.HasReadVersion(ctx) {
+			// the pinned snapshot can't be read by a new transaction either
+			return Response{}, ctx, errors.Aborted("the snapshot expired after the transaction time limit, narrow " +
+				"the read with a filter or read without a snapshot")
+		}
+
 		if err == kv.ErrTransactionMaxDurationReached {
+			// the read isn't small enough for its result to be cached
+			runner.results = nil
+
 			// We have received ErrTransactionMaxDurationReached i.e. 5 second transaction limit, so we need to retry the
 			// transaction.
 			// ToDo:
@@ -651,6 +865,7 @@ func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metada
 			return Response{}, ctx, CreateApiError(err)
 		}
 
+		runner.fillCachedResult(ctx)
 		ctx = runner.instrumentRunner(ctx, options)
 
 		return Response{}, ctx, nil
@@ -661,6 +876,12 @@ func (runner *StreamingQueryRunner) ReadOnly(ctx context.Context, tenant *metada
 // if we see ErrTransactionMaxDurationReached which is expected because we do not expect caller to do long reads in an
 // explicit transaction.
 func (runner *StreamingQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if api.IsSystemCollection(runner.req.GetCollection()) {
+		return runner.readSystemCollection(ctx, tx, tenant)
+	}
+
+	start := time.Now()
+
 	db, coll, err := runner.getDBAndCollection(ctx, tx, tenant,
 		runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
 	if err != nil {
@@ -669,10 +890,26 @@ func (runner *StreamingQueryRunner) Run(ctx context.Context, tx transaction.Tx,
 
 	ctx = runner.cdcMgr.WrapContext(ctx, db.Name())
 
-	options, err := runner.buildReaderOptions(runner.req, coll)
+	if runner.req.Filter, err = applyRowPolicy(ctx, coll, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
+	options, err := runner.planRead(ctx, runner.req, coll)
 	if err != nil {
 		return Response{}, ctx, err
 	}
+	if options.inMemoryStore && options.sorting != nil && searchIndexStale(tenant, db, coll) {
+		useMemSort(&options, runner.req, coll)
+	}
+
+	recordFullScan(tenant, db, runner.req, options)
+	runner.planning = time.Since(start)
+	defer func() {
+		runner.recordSlowQuery(tenant, db, options, start)
+		if request.IncludeQueryStats(ctx) {
+			setQueryStats(ctx, runner.queryStats(options, start))
+		}
+	}()
 
 	ctx = runner.instrumentRunner(ctx, options)
 	if options.inMemoryStore {
@@ -705,16 +942,20 @@ func (runner *StreamingQueryRunner) iterateOnKvStore(ctx context.Context, tx tra
 		case options.tablePlan.From != nil:
 			if iter, err = reader.ScanIterator(options.tablePlan.From, nil, options.tablePlan.Reverse); err == nil {
 				// pass it to filterable
-				iter, err = reader.FilteredRead(iter, options.filter)
+				iter, err = reader.FilteredRead(&countingIterator{Iterator: iter, rows: &runner.scanned}, options.filter)
 			}
 		default:
 			if iter, err = reader.ScanTable(options.tablePlan.Table, options.tablePlan.Reverse); err == nil {
 				// pass it to filterable
-				iter, err = reader.FilteredRead(iter, options.filter)
+				iter, err = reader.FilteredRead(&countingIterator{Iterator: iter, rows: &runner.scanned}, options.filter)
 			}
 		}
+	} else if options.plan != nil && options.docCache != nil {
+		iter = &countingIterator{Iterator: NewCachedKeyIterator(ctx, tx, options.docCache, options.plan.Keys), rows: &runner.scanned}
 	} else if options.plan != nil {
-		iter, err = reader.KeyIterator(options.plan.Keys)
+		if iter, err = reader.KeyIterator(options.plan.Keys); err == nil {
+			iter = &countingIterator{Iterator: iter, rows: &runner.scanned}
+		}
 	} else {
 		return nil, errors.Internal("no plan to execute")
 	}
@@ -722,26 +963,85 @@ func (runner *StreamingQueryRunner) iterateOnKvStore(ctx context.Context, tx tra
 		return nil, err
 	}
 
+	if options.memSort != nil {
+		var collation *value.Collation
+		if runner.req.Options != nil {
+			collation = value.NewCollationFrom(runner.req.Options.Collation)
+		}
+		if iter, err = sortRows(iter, coll, options.memSort, collation); err != nil {
+			return nil, err
+		}
+	}
+
 	return runner.iterate(ctx, coll, iter, options.fieldFactory)
 }
 
+// canScanInParallel returns true for the filtered full scans, the other plans read a small part of the collection.
+func (*StreamingQueryRunner) canScanInParallel(options readerOptions) bool {
+	return config.DefaultConfig.Server.ParallelScan.Enabled &&
+		options.tablePlan != nil && options.tablePlan.From == nil && options.memSort == nil &&
+		options.filter != nil && !options.filter.None()
+}
+
+// iterateInParallel scans the key ranges of the collection stored by different shards concurrently. It returns false
+// without reading if the collection is stored by a single shard.
+func (runner *StreamingQueryRunner) iterateInParallel(ctx context.Context, tenant *metadata.Tenant, db *metadata.Database, coll *schema.DefaultCollection, options readerOptions) (bool, error) {
+	cfg := config.DefaultConfig.Server.ParallelScan
+
+	boundaries, err := tenant.CollectionBoundaryKeys(ctx, db, coll, 0)
+	if err != nil {
+		return false, err
+	}
+
+	splits := scanBoundaries(coll, boundaries, cfg.MaxRanges)
+	if len(splits) == 0 {
+		return false, nil
+	}
+
+	// stops the readers of the ranges if the iteration ends early, because of the limit or an error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	iter := NewParallelScanIterator(ctx, runner.txMgr, coll.EncodedName, splits, options.filter, options.tablePlan.Reverse, cfg.Buffer)
+	_, err = runner.iterate(ctx, coll, iter, options.fieldFactory)
+	runner.scanned.Add(iter.Scanned())
+
+	return true, err
+}
+
 func (runner *StreamingQueryRunner) iterateOnSecondaryIndexStore(ctx context.Context, tx transaction.Tx, coll *schema.DefaultCollection, options readerOptions) ([]byte, error) {
-	iter, err := NewSecondaryIndexReader(ctx, tx, coll, options.filter, options.plan)
+	var (
+		iter Iterator
+		err  error
+	)
+	if options.covered {
+		iter, err = NewCoveringIndexReader(ctx, tx, coll, options.filter, options.plan)
+	} else {
+		iter, err = NewSecondaryIndexReader(ctx, tx, coll, options.filter, options.plan)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return runner.iterate(ctx, coll, NewFilterIterator(iter, options.filter), options.fieldFactory)
+	last, err := runner.iterate(ctx, coll, NewFilterIterator(&countingIterator{Iterator: iter, rows: &runner.scanned}, options.filter), options.fieldFactory)
+	if reader, ok := iter.(interface{ KeysScanned() int64 }); ok {
+		runner.keysScanned.Add(reader.KeysScanned())
+	}
+
+	return last, err
 }
 
 func (runner *StreamingQueryRunner) iterateOnSearchStore(ctx context.Context, coll *schema.DefaultCollection, options readerOptions) error {
+	pageSize, firstPage, skipped := searchPages(ctx, runner.req)
 	rowReader := NewSearchReader(ctx, runner.searchStore, coll, qsearch.NewBuilder().
 		Filter(options.filter).
 		SortOrder(options.sorting).
-		PageSize(defaultPerPage).
+		PageSize(pageSize).
 		Build())
 
-	if _, err := runner.iterate(ctx, coll, rowReader.Iterator(coll, options.filter), options.fieldFactory); err != nil {
+	runner.skipped = skipped
+	iter := &countingIterator{Iterator: rowReader.IteratorFrom(coll, options.filter, firstPage), rows: &runner.scanned}
+	if _, err := runner.iterate(ctx, coll, iter, options.fieldFactory); err != nil {
 		return err
 	}
 
@@ -764,25 +1064,54 @@ func (runner *StreamingQueryRunner) iterate(ctx context.Context, coll *schema.De
 		limit = runner.req.GetOptions().Limit
 	}
 	if runner.req.GetOptions() != nil {
-		skip = runner.req.GetOptions().Skip
+		// the rows skipped by the search store are not read
+		skip = runner.req.GetOptions().Skip - runner.skipped
 	}
 
 	isAcceptApplicationJSON := request.IsAcceptApplicationJSON(ctx)
+	includeRevision := request.IncludeRevision(ctx)
 	if isAcceptApplicationJSON && limit == 0 {
 		limit = defaultReadLimit
 	}
 
+	// when pinned to a schema version, only the documents written under that version are returned as-is, this
+	// allows migration tooling to target a specific cohort of documents.
+	pinnedVersion, err := request.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pinnedVersion > 0 {
+		iterator = NewSchemaVersionIterator(iterator, pinnedVersion)
+	}
+
+	// callers that are not allowed to see encrypted fields receive the ciphertext as stored.
+	decrypt := coll.HasEncryptedFields() && request.CanDecryptFields(ctx)
+
+	var masker *schema.FieldMasker
+	if role, ok := request.GetCallerRole(ctx); ok {
+		masker = coll.NewFieldMasker(role)
+	}
+
+	if runner.results != nil {
+		runner.results.Iterator = iterator
+		iterator = runner.results
+	}
+
 	limit += skip
 	for i := int64(0); (limit == 0 || i < limit) && iterator.Next(&row); i++ {
 		if skip > 0 {
 			skip--
 			continue
 		}
+		runner.returned++
 
 		rawData := row.Data.RawData
-		var err error
-
-		if !coll.CompatibleSchemaSince(uint32(row.Data.Ver)) {
+		if decrypt {
+			if rawData, err = coll.DecryptFields(rawData); err != nil {
+				return row.Key, err
+			}
+		}
+		if pinnedVersion == 0 && !coll.CompatibleSchemaSince(uint32(row.Data.Ver)) {
 			rawData, err = coll.UpdateRowSchemaRaw(rawData, uint32(row.Data.Ver))
 			if err != nil {
 				return row.Key, err
@@ -791,11 +1120,21 @@ func (runner *StreamingQueryRunner) iterate(ctx context.Context, coll *schema.De
 			metrics.SchemaReadOutdated(runner.req.GetProject(), branch, coll.Name)
 		}
 
+		if masker != nil {
+			if rawData, err = masker.Mask(rawData); err != nil {
+				return row.Key, err
+			}
+		}
+
 		newValue, err := fieldFactory.Apply(rawData)
 		if ulog.E(err) {
 			return row.Key, err
 		}
 
+		if includeRevision {
+			newValue = injectRevision(newValue, row.Data.GetRevision())
+		}
+
 		if isAcceptApplicationJSON {
 			if newValue, err = runner.injectMDInsideBody(newValue, row.Data.CreateToProtoTS(), row.Data.UpdatedToProtoTS()); err != nil {
 				return row.Key, err
@@ -898,6 +1237,10 @@ func (runner *ExplainQueryRunner) Run(ctx context.Context, _ transaction.Tx, ten
 		return Response{}, ctx, err
 	}
 
+	if runner.req.Filter, err = applyRowPolicy(ctx, collection, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
 	options, err := runner.buildReaderOptions(runner.req, collection)
 	if err != nil {
 		return Response{}, ctx, err
@@ -911,8 +1254,58 @@ func (runner *ExplainQueryRunner) Run(ctx context.Context, _ transaction.Tx, ten
 const (
 	PRIMARY   = "primary index"
 	SECONDARY = "secondary index"
+	COVERING  = "covering secondary index"
 )
 
+// describeEncodedNumber returns the number of an index key encoded with the order preserving key encoding.
+func describeEncodedNumber(typeOrder any, val any) (string, bool) {
+	encoded, ok := val.([]byte)
+	if !ok || typeOrder != value.ToSecondaryOrder(schema.Int64Type, nil) {
+		return "", false
+	}
+
+	return keyencoding.DecodeNumber(encoded)
+}
+
+func describeIndexValue(typeOrder any, val any) string {
+	switch val {
+	case nil:
+		return "null"
+	case 0xFF:
+		return "$TIGRIS_MAX"
+	default:
+		if number, ok := describeEncodedNumber(typeOrder, val); ok {
+			return number
+		} else if encodedString, ok := val.([]byte); ok {
+			return string(encodedString)
+		}
+		return fmt.Sprint(val)
+	}
+}
+
+// describeCompositeKey describes the values of the fields of the composite index key, separated by commas.
+func describeCompositeKey(index *schema.Index, parts []any) string {
+	var values []string
+	for i := 0; len(parts) > 0; i++ {
+		if len(parts) == 1 || i >= len(index.Fields) {
+			// the range marker or the position of the value
+			if parts[0] == 0xFF {
+				values = append(values, "$TIGRIS_MAX")
+			}
+			break
+		}
+
+		pair := parts[:2]
+		if index.IsDescending(i) && pair[1] != 0xFF {
+			pair = keyencoding.Ascending(pair)
+		}
+		values = append(values, describeIndexValue(pair[0], pair[1]))
+		parts = parts[2:]
+	}
+
+	return strings.Join(values, ",")
+}
+
 func buildExplainResp(options readerOptions, coll *schema.DefaultCollection, filter []byte, sortFields []byte) *api.ExplainResponse {
 	explain := &api.ExplainResponse{
 		Collection: coll.Name,
@@ -922,24 +1315,18 @@ func buildExplainResp(options readerOptions, coll *schema.DefaultCollection, fil
 
 	if options.plan != nil {
 		explain.ReadType = SECONDARY
+		if options.covered {
+			explain.ReadType = COVERING
+		}
 		var keyRange []string
 		for _, key := range options.plan.Keys {
+			if index := schema.FindIndex(coll.SecondaryIndexes.All, options.plan.FieldName); index != nil && index.IsComposite() {
+				keyRange = append(keyRange, describeCompositeKey(index, key.IndexParts()[3:]))
+				continue
+			}
+
 			if len(key.IndexParts()) > 4 {
-				var friendlyVal string
-				val := key.IndexParts()[4]
-				switch val {
-				case nil:
-					friendlyVal = "null"
-				case 0xFF:
-					friendlyVal = "$TIGRIS_MAX"
-				default:
-					if encodedString, ok := val.([]byte); ok {
-						friendlyVal = string(encodedString)
-					} else {
-						friendlyVal = fmt.Sprint(val)
-					}
-				}
-				keyRange = append(keyRange, friendlyVal)
+				keyRange = append(keyRange, describeIndexValue(key.IndexParts()[3], key.IndexParts()[4]))
 			}
 		}
 
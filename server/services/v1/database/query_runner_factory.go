@@ -15,10 +15,13 @@
 package database
 
 import (
+	"time"
+
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/server/cdc"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/server/types"
 	"github.com/tigrisdata/tigris/store/search"
@@ -58,6 +61,70 @@ func (f *QueryRunnerFactory) GetInsertQueryRunner(r *api.InsertRequest, qm *metr
 	}
 }
 
+func (f *QueryRunnerFactory) GetOutboxQueryRunner(r *api.InsertRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *OutboxQueryRunner {
+	return &OutboxQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
+func (f *QueryRunnerFactory) GetAppendStreamQueryRunner(r *api.InsertRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *AppendStreamQueryRunner {
+	return &AppendStreamQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
+func (f *QueryRunnerFactory) GetReadStreamQueryRunner(r *api.ReadRequest, qm *metrics.StreamingQueryMetrics, accessToken *types.AccessToken) *ReadStreamQueryRunner {
+	return &ReadStreamQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
+func (f *QueryRunnerFactory) GetCreateQueueQueryRunner(r *api.DescribeCollectionRequest, accessToken *types.AccessToken) *CreateQueueQueryRunner {
+	return &CreateQueueQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetEnqueueQueryRunner(r *api.InsertRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *EnqueueQueryRunner {
+	return &EnqueueQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
+func (f *QueryRunnerFactory) GetReceiveQueryRunner(r *api.ReadRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *ReceiveQueryRunner {
+	return &ReceiveQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+		now:             time.Now,
+	}
+}
+
+func (f *QueryRunnerFactory) GetAckQueryRunner(r *api.InsertRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *AckQueryRunner {
+	return &AckQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
+func (f *QueryRunnerFactory) GetMultiWriteQueryRunner(r *api.CommitTransactionRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *MultiWriteQueryRunner {
+	return &MultiWriteQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		queryMetrics:    qm,
+	}
+}
+
 func (f *QueryRunnerFactory) GetReplaceQueryRunner(r *api.ReplaceRequest, qm *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *ReplaceQueryRunner {
 	return &ReplaceQueryRunner{
 		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
@@ -123,6 +190,36 @@ func (f *QueryRunnerFactory) GetCollectionQueryRunner(accessToken *types.AccessT
 	}
 }
 
+func (f *QueryRunnerFactory) GetTemplateQueryRunner(accessToken *types.AccessToken) *TemplateQueryRunner {
+	return &TemplateQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+	}
+}
+
+func (f *QueryRunnerFactory) GetSearchKeyQueryRunner(accessToken *types.AccessToken) *SearchKeyQueryRunner {
+	return &SearchKeyQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+	}
+}
+
+func (f *QueryRunnerFactory) GetJobQueryRunner(accessToken *types.AccessToken) *JobQueryRunner {
+	return &JobQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+	}
+}
+
+func (f *QueryRunnerFactory) GetRBACQueryRunner(accessToken *types.AccessToken) *RBACQueryRunner {
+	return &RBACQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+	}
+}
+
+func (f *QueryRunnerFactory) GetAppKeyScopeQueryRunner(accessToken *types.AccessToken) *AppKeyScopeQueryRunner {
+	return &AppKeyScopeQueryRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+	}
+}
+
 func (f *QueryRunnerFactory) GetProjectQueryRunner(accessToken *types.AccessToken) *ProjectQueryRunner {
 	return &ProjectQueryRunner{
 		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
@@ -143,6 +240,127 @@ func (f *QueryRunnerFactory) GetIndexRunner(r *api.BuildCollectionIndexRequest,
 	}
 }
 
+func (f *QueryRunnerFactory) GetIndexBuildStatusRunner(r *api.BuildCollectionIndexRequest, accessToken *types.AccessToken) *IndexBuildStatusRunner {
+	return &IndexBuildStatusRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetIndexUsageStatsRunner(r *api.DescribeCollectionRequest, accessToken *types.AccessToken) *IndexUsageStatsRunner {
+	return &IndexUsageStatsRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetIndexSuggestionsRunner(r *api.DescribeDatabaseRequest, accessToken *types.AccessToken) *IndexSuggestionsRunner {
+	return &IndexSuggestionsRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetSlowQueriesRunner(r *api.DescribeDatabaseRequest, accessToken *types.AccessToken) *SlowQueriesRunner {
+	return &SlowQueriesRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetIndexConsistencyRunner(r *api.DescribeCollectionRequest, accessToken *types.AccessToken) *IndexConsistencyRunner {
+	return &IndexConsistencyRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetBranchDiffRunner(r *api.DescribeDatabaseRequest, accessToken *types.AccessToken) *BranchDiffRunner {
+	return &BranchDiffRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetBranchMergeRunner(r *api.DescribeDatabaseRequest, sessions Session, notify func(*api.BranchMergeStatus) error, accessToken *types.AccessToken) *BranchMergeRunner {
+	return &BranchMergeRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		sessions:        sessions,
+		notify:          notify,
+	}
+}
+
+func (f *QueryRunnerFactory) GetBranchSnapshotRunner(r *api.CreateBranchRequest, sessions Session, at time.Time, version int64, accessToken *types.AccessToken) *BranchSnapshotRunner {
+	return &BranchSnapshotRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		sessions:        sessions,
+		at:              at,
+		version:         version,
+	}
+}
+
+func (f *QueryRunnerFactory) GetBranchLifetimesRunner(r *api.ListBranchesRequest, accessToken *types.AccessToken) *BranchLifetimesRunner {
+	return &BranchLifetimesRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetBackupRunner(r *api.DescribeDatabaseRequest, archive *backup.Writer, job *backup.Job, accessToken *types.AccessToken) *BackupRunner {
+	return &BackupRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		archive:         archive,
+		job:             job,
+	}
+}
+
+func (f *QueryRunnerFactory) GetRestoreRunner(r *api.DescribeDatabaseRequest, archive *backup.Reader, sessions Session, job *backup.Job, accessToken *types.AccessToken) *RestoreRunner {
+	return &RestoreRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		archive:         archive,
+		sessions:        sessions,
+		job:             job,
+	}
+}
+
+func (f *QueryRunnerFactory) GetPointInTimeRestoreRunner(r *api.DescribeDatabaseRequest, collection string, at time.Time, sessions Session, job *backup.Job, accessToken *types.AccessToken) *PointInTimeRestoreRunner {
+	return &PointInTimeRestoreRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		collection:      collection,
+		at:              at,
+		sessions:        sessions,
+		job:             job,
+	}
+}
+
+func (f *QueryRunnerFactory) GetCollectionCopyRunner(r *api.DescribeCollectionRequest, sessions Session, accessToken *types.AccessToken) *CollectionCopyRunner {
+	return &CollectionCopyRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		sessions:        sessions,
+	}
+}
+
+func (f *QueryRunnerFactory) GetSearchIndexStatusRunner(r *api.DescribeCollectionRequest, accessToken *types.AccessToken) *SearchIndexStatusRunner {
+	return &SearchIndexStatusRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+	}
+}
+
+func (f *QueryRunnerFactory) GetSearchIndexRebuildRunner(r *api.BuildCollectionSearchIndexRequest, notify func(*api.SearchIndexRebuildStatus) error, accessToken *types.AccessToken) *SearchIndexRebuildRunner {
+	return &SearchIndexRebuildRunner{
+		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
+		req:             r,
+		notify:          notify,
+	}
+}
+
 func (f *QueryRunnerFactory) GetSearchIndexRunner(r *api.BuildCollectionSearchIndexRequest, queryMetrics *metrics.WriteQueryMetrics, accessToken *types.AccessToken) *SearchIndexerRunner {
 	return &SearchIndexerRunner{
 		BaseQueryRunner: NewBaseQueryRunner(f.encoder, f.cdcMgr, f.txMgr, f.searchStore, accessToken),
@@ -19,6 +19,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	api "github.com/tigrisdata/tigris/api/server/v1"
+	qsearch "github.com/tigrisdata/tigris/query/search"
 	"github.com/tigrisdata/tigris/query/sort"
 	"github.com/tigrisdata/tigris/schema"
 )
@@ -31,6 +32,8 @@ func TestSearchQueryRunner_getFacetFields(t *testing.T) {
 			schema.NewQueryableFieldsBuilder().NewQueryableField("parent.field_2", &schema.Field{DataType: schema.StringType, Faceted: &ptrTrue, SearchIndexed: &ptrTrue}, nil),
 			schema.NewQueryableFieldsBuilder().NewQueryableField("field_3", &schema.Field{DataType: schema.ByteType}, nil),
 			schema.NewQueryableFieldsBuilder().NewQueryableField("field_4", &schema.Field{DataType: schema.StringType}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("price", &schema.Field{DataType: schema.DoubleType}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("created", &schema.Field{DataType: schema.DateTimeType}, nil),
 		},
 	}
 	runner := &SearchQueryRunner{req: &api.SearchRequest{}}
@@ -79,6 +82,125 @@ func TestSearchQueryRunner_getFacetFields(t *testing.T) {
 			assert.Equal(t, ff.Size, 10)
 		}
 	})
+
+	t.Run("range facets", func(t *testing.T) {
+		runner.req.Facet = []byte(`[{"field_1":{"size":5}},{"price":[{"from":0,"to":50},{"from":50}]},{"created":[{"to":"2023-01-01T00:00:00Z"}]}]`)
+		facets, err := runner.getFacetFields(collection)
+		assert.NoError(t, err)
+		assert.Len(t, facets.Fields, 3)
+		assert.False(t, facets.Fields[0].IsRange())
+		assert.Len(t, facets.Fields[1].Ranges, 2)
+		assert.True(t, facets.Fields[1].RangeStats)
+		assert.Len(t, facets.Fields[2].Ranges, 1)
+		assert.False(t, facets.Fields[2].RangeStats)
+	})
+
+	t.Run("range facets of invalid fields", func(t *testing.T) {
+		runner.req.Facet = []byte(`{"field_1":[{"from":0,"to":50}]}`)
+		_, err := runner.getFacetFields(collection)
+		assert.ErrorContains(t, err, "Cannot generate range facets for `field_1`. Only numeric and date-time fields support ranges")
+
+		runner.req.Facet = []byte(`{"price":[{"from":"2023-01-01T00:00:00Z"}]}`)
+		_, err = runner.getFacetFields(collection)
+		assert.ErrorContains(t, err, "range bounds of `price` should be numbers")
+
+		runner.req.Facet = []byte(`{"created":[{"from":10}]}`)
+		_, err = runner.getFacetFields(collection)
+		assert.ErrorContains(t, err, "range bounds of `created` should be RFC 3339 formatted dates")
+	})
+}
+
+func TestSearchQueryRunner_getRelevance(t *testing.T) {
+	ptrTrue := true
+	collection := &schema.DefaultCollection{
+		QueryableFields: []*schema.QueryableField{
+			schema.NewQueryableFieldsBuilder().NewQueryableField("title", &schema.Field{DataType: schema.StringType, SearchIndexed: &ptrTrue}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("body", &schema.Field{DataType: schema.StringType, SearchIndexed: &ptrTrue}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("popularity", &schema.Field{DataType: schema.Int64Type}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("featured", &schema.Field{DataType: schema.BoolType}, nil),
+		},
+	}
+	searchFields := []string{"title", "body"}
+
+	t.Run("no relevance tuning", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{}}
+		relevance, err := runner.getRelevance(collection, searchFields)
+		assert.NoError(t, err)
+		assert.Nil(t, relevance)
+	})
+
+	t.Run("weights and boost", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{}}
+		runner.req.SetFieldWeights(map[string]int32{"title": 3})
+		runner.req.SetBoost(&api.SearchBoost{Buckets: 5, Fields: []string{"popularity"}, Filter: []byte(`{"featured":true}`)})
+		relevance, err := runner.getRelevance(collection, searchFields)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"title": 3}, relevance.FieldWeights)
+		assert.Equal(t, &qsearch.Boost{Buckets: 5, Fields: []string{"popularity"}, Filter: "featured:=true"}, relevance.Boost)
+	})
+
+	t.Run("invalid relevance tuning", func(t *testing.T) {
+		cases := []struct {
+			weights map[string]int32
+			boost   *api.SearchBoost
+			sort    []byte
+			err     string
+		}{
+			{map[string]int32{"title": 0}, nil, nil, "weight of field 'title' should be between 1 and 127"},
+			{map[string]int32{"popularity": 2}, nil, nil, "`popularity` is not a search field of the query. Only search fields can be weighted"},
+			{nil, &api.SearchBoost{}, nil, "boost requires either fields or filter"},
+			{nil, &api.SearchBoost{Fields: []string{"title"}}, nil, "Cannot boost by `title`. Only numeric and date-time fields can boost"},
+			{nil, &api.SearchBoost{Fields: []string{"featured"}}, nil, "Cannot boost by `featured`. Only numeric and date-time fields can boost"},
+			{nil, &api.SearchBoost{Fields: []string{"popularity"}}, []byte(`[{"title":"$asc"}]`), "boost can't be used with sort"},
+		}
+		for _, c := range cases {
+			runner := &SearchQueryRunner{req: &api.SearchRequest{Sort: c.sort}}
+			runner.req.SetFieldWeights(c.weights)
+			runner.req.SetBoost(c.boost)
+			_, err := runner.getRelevance(collection, searchFields)
+			assert.ErrorContains(t, err, c.err)
+		}
+	})
+}
+
+func TestSearchQueryRunner_getGroupBy(t *testing.T) {
+	ptrTrue := true
+	collection := &schema.DefaultCollection{
+		QueryableFields: []*schema.QueryableField{
+			schema.NewQueryableFieldsBuilder().NewQueryableField("product_id", &schema.Field{DataType: schema.StringType, Faceted: &ptrTrue, SearchIndexed: &ptrTrue}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("store_id", &schema.Field{DataType: schema.Int64Type}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("name", &schema.Field{DataType: schema.StringType, SearchIndexed: &ptrTrue}, nil),
+		},
+	}
+
+	t.Run("no group by", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{}}
+		groupBy, err := runner.getGroupBy(collection)
+		assert.NoError(t, err)
+		assert.Empty(t, groupBy.Fields)
+	})
+
+	t.Run("group by with limit", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{GroupBy: []byte(`["product_id", "store_id"]`)}}
+		runner.req.SetGroupLimit(1)
+		groupBy, err := runner.getGroupBy(collection)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"product_id", "store_id"}, groupBy.Fields)
+		assert.Equal(t, int64(1), *groupBy.Limit)
+	})
+
+	t.Run("group by not faceted field", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{GroupBy: []byte(`"name"`)}}
+		_, err := runner.getGroupBy(collection)
+		assert.ErrorContains(t, err, "Cannot group by on `name` field as facet is not enabled")
+	})
+
+	t.Run("group limit out of range", func(t *testing.T) {
+		runner := &SearchQueryRunner{req: &api.SearchRequest{GroupBy: []byte(`"product_id"`)}}
+		runner.req.SetGroupLimit(100)
+		_, err := runner.getGroupBy(collection)
+		assert.ErrorContains(t, err, "group limit should be between 1 and 99")
+	})
 }
 
 func TestSearchQueryRunner_getFieldSelection(t *testing.T) {
@@ -163,10 +285,12 @@ func TestSearchQueryRunner_getSortOrdering(t *testing.T) {
 			schema.NewQueryableFieldsBuilder().NewQueryableField("field_1", &schema.Field{DataType: schema.StringType}, nil),
 			schema.NewQueryableFieldsBuilder().NewQueryableField("parent.field_2", &schema.Field{DataType: schema.StringType}, nil),
 			schema.NewQueryableFieldsBuilder().NewQueryableField("field_3", &schema.Field{DataType: schema.ByteType}, nil),
+			schema.NewQueryableFieldsBuilder().NewQueryableField("loc", &schema.Field{DataType: schema.GeoPointType}, nil),
 		},
 	}
 	collection.QueryableFields[0].Sortable = true
 	collection.QueryableFields[1].Sortable = true
+	collection.QueryableFields[3].SearchIndexed = true
 
 	runner := &SearchQueryRunner{req: &api.SearchRequest{}}
 
@@ -204,6 +328,24 @@ func TestSearchQueryRunner_getSortOrdering(t *testing.T) {
 		assert.Exactly(t, expected, sortOrder)
 	})
 
+	t.Run("geo distance sort", func(t *testing.T) {
+		runner.req.Sort = []byte(`[{"loc":{"$geo_distance":{"lat":48.85,"lng":2.34}}},{"field_1":"$desc"}]`)
+		sortOrder, err := runner.getSearchOrdering(collection, runner.req.Sort)
+		assert.NoError(t, err)
+		assert.Exactly(t, &sort.Ordering{
+			{Name: "loc", Ascending: true, GeoDistance: &sort.GeoDistance{Lat: 48.85, Lng: 2.34}},
+			{Name: "field_1"},
+		}, sortOrder)
+
+		runner.req.Sort = []byte(`[{"field_1":{"$geo_distance":{"lat":48.85,"lng":2.34}}}]`)
+		_, err = runner.getSearchOrdering(collection, runner.req.Sort)
+		assert.ErrorContains(t, err, "`$geo_distance` sort is only supported on search indexed geopoint fields, found `field_1` field")
+
+		runner.req.Sort = []byte(`[{"loc":"$asc"}]`)
+		_, err = runner.getSearchOrdering(collection, runner.req.Sort)
+		assert.ErrorContains(t, err, "Search results can't be sorted on `loc` field")
+	})
+
 	t.Run("Invalid sort input", func(t *testing.T) {
 		runner.req.Sort = []byte(`[{"field_1":"descending"}]`)
 		sort, err := runner.getSearchOrdering(collection, runner.req.Sort)
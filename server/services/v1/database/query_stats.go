@@ -0,0 +1,60 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+// setQueryStats returns the stats of the query in the trailer of the response, the stats are only known once all the
+// results are sent.
+func setQueryStats(ctx context.Context, stats *api.QueryStats) {
+	if encoded, err := jsoniter.Marshal(stats); err == nil {
+		_ = grpc.SetTrailer(ctx, grpcMetadata.Pairs(api.HeaderQueryStats, string(encoded)))
+	}
+}
+
+func newQueryTime(planning time.Duration, total time.Duration) *api.QueryTime {
+	return &api.QueryTime{
+		PlanningUs:  planning.Microseconds(),
+		ExecutionUs: (total - planning).Microseconds(),
+		TotalUs:     total.Microseconds(),
+	}
+}
+
+// queryStats returns the stats of the read. The keys scanned are the index entries for the reads of a secondary index,
+// otherwise the documents read are the keys scanned.
+func (runner *StreamingQueryRunner) queryStats(options readerOptions, start time.Time) *api.QueryStats {
+	stats := &api.QueryStats{
+		KeysScanned:  runner.scanned.Load(),
+		DocsExamined: runner.scanned.Load(),
+		DocsReturned: runner.returned,
+		Index:        readPlan(options),
+		Time:         newQueryTime(runner.planning, time.Since(start)),
+		ResultCache:  runner.resultCache,
+	}
+	if options.tablePlan == nil && options.plan != nil && filter.IndexTypeSecondary(options.plan.IndexType) {
+		stats.KeysScanned = runner.keysScanned.Load()
+	}
+
+	return stats
+}
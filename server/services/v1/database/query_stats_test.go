@@ -0,0 +1,48 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+)
+
+func TestQueryStats(t *testing.T) {
+	require.Equal(t, &api.QueryTime{PlanningUs: 150, ExecutionUs: 850, TotalUs: 1000},
+		newQueryTime(150*time.Microsecond, time.Millisecond))
+
+	runner := &StreamingQueryRunner{returned: 2}
+	runner.scanned.Store(10)
+	runner.keysScanned.Store(25)
+
+	stats := runner.queryStats(readerOptions{tablePlan: &filter.TableScanPlan{}}, time.Now())
+	require.Equal(t, int64(10), stats.KeysScanned)
+	require.Equal(t, int64(10), stats.DocsExamined)
+	require.Equal(t, int64(2), stats.DocsReturned)
+	require.Equal(t, "full_scan", stats.Index)
+
+	stats = runner.queryStats(readerOptions{plan: &filter.QueryPlan{FieldName: "name", IndexType: filter.SecondaryIndex}}, time.Now())
+	require.Equal(t, int64(25), stats.KeysScanned)
+	require.Equal(t, int64(10), stats.DocsExamined)
+	require.Equal(t, "secondary name", stats.Index)
+
+	stats = runner.queryStats(readerOptions{plan: &filter.QueryPlan{IndexType: filter.PrimaryIndex}}, time.Now())
+	require.Equal(t, int64(10), stats.KeysScanned)
+	require.Equal(t, "pkey", stats.Index)
+}
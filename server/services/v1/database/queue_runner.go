@@ -0,0 +1,523 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+const (
+	EnqueuedStatus string = "enqueued"
+	AckedStatus    string = "acked"
+)
+
+// The queues of a branch are stored in its queue table. The options of a queue are keyed by (queue, name) and its
+// messages by (message, name, id). The visible messages are found by (visible, name, visible at, id), an entry per
+// message ordered by the time the message becomes visible. The receives read the entries from a snapshot, so they
+// don't conflict with the enqueues, and then the messages themselves, so the concurrent receives of a message
+// conflict and only one of them commits.
+const (
+	queueOptionsKey = "queue"
+	queueMessageKey = "message"
+	queueVisibleKey = "visible"
+)
+
+// queueOptions is a queue as stored in the queue table.
+type queueOptions struct {
+	Name              string        `json:"name"`
+	VisibilityTimeout time.Duration `json:"visibility_timeout"`
+	MaxReceives       int           `json:"max_receives,omitempty"`
+	DeadLetterQueue   string        `json:"dead_letter_queue,omitempty"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+func (q *queueOptions) toAPI() *api.Queue {
+	return &api.Queue{
+		Name:              q.Name,
+		VisibilityTimeout: q.VisibilityTimeout.String(),
+		MaxReceives:       q.MaxReceives,
+		DeadLetterQueue:   q.DeadLetterQueue,
+		CreatedAt:         q.CreatedAt,
+	}
+}
+
+// queueMessage is a message as stored in the queue table. Lease identifies the last receive of the message, the
+// receipt of the message is valid until the message is received again.
+type queueMessage struct {
+	Id         string              `json:"id"`
+	Data       jsoniter.RawMessage `json:"data"`
+	Receives   int                 `json:"receives"`
+	EnqueuedAt time.Time           `json:"enqueued_at"`
+	VisibleAt  time.Time           `json:"visible_at"`
+	Lease      string              `json:"lease,omitempty"`
+}
+
+func queueOptionsKeyOf(table []byte, queue string) keys.Key {
+	return keys.NewKey(table, queueOptionsKey, queue)
+}
+
+func queueMessageKeyOf(table []byte, queue string, id string) keys.Key {
+	return keys.NewKey(table, queueMessageKey, queue, id)
+}
+
+func queueVisibleKeyOf(table []byte, queue string, msg *queueMessage) keys.Key {
+	return keys.NewKey(table, queueVisibleKey, queue, msg.VisibleAt.UnixNano(), msg.Id)
+}
+
+// encodeReceipt returns the receipt of the receive of the message, its id followed by the lease of the receive.
+func encodeReceipt(msg *queueMessage) string {
+	return msg.Id + ":" + msg.Lease
+}
+
+func decodeReceipt(receipt string) (string, string, error) {
+	id, lease, ok := strings.Cut(receipt, ":")
+	if !ok || len(id) == 0 || len(lease) == 0 {
+		return "", "", errors.InvalidArgument("invalid receipt '%s'", receipt)
+	}
+
+	return id, lease, nil
+}
+
+// newQueueOptions returns the options of the queue created with the visibility timeout and the max receives of the
+// request headers, zero for the defaults.
+func newQueueOptions(name string, timeout time.Duration, maxReceives int, deadLetter string, cfg config.QueueConfig) (*queueOptions, error) {
+	if len(name) == 0 {
+		return nil, errors.InvalidArgument("queue is required")
+	}
+	if deadLetter == name {
+		return nil, errors.InvalidArgument("queue '%s' can't be its own dead-letter queue", name)
+	}
+	if len(deadLetter) > 0 && maxReceives == 0 {
+		return nil, errors.InvalidArgument("dead-letter queue requires the '%s' header", api.HeaderQueueMaxReceives)
+	}
+	if timeout == 0 {
+		timeout = cfg.DefaultVisibilityTimeout
+	}
+	if cfg.MaxVisibilityTimeout > 0 && timeout > cfg.MaxVisibilityTimeout {
+		return nil, errors.InvalidArgument("visibility timeout %s is above the maximum %s", timeout, cfg.MaxVisibilityTimeout)
+	}
+
+	return &queueOptions{
+		Name:              name,
+		VisibilityTimeout: timeout,
+		MaxReceives:       maxReceives,
+		DeadLetterQueue:   deadLetter,
+	}, nil
+}
+
+func validateEnqueue(queue string, messages [][]byte, cfg config.QueueConfig) error {
+	if len(queue) == 0 {
+		return errors.InvalidArgument("queue is required")
+	}
+	if len(messages) == 0 {
+		return errors.InvalidArgument("enqueue has no messages")
+	}
+	if cfg.MaxEnqueueMessages > 0 && len(messages) > cfg.MaxEnqueueMessages {
+		return errors.InvalidArgument("enqueue has %d messages, the maximum is %d", len(messages), cfg.MaxEnqueueMessages)
+	}
+	for _, msg := range messages {
+		if _, dataType, _, err := jsonparser.Get(msg); err != nil || dataType != jsonparser.Object {
+			return errors.InvalidArgument("queue message should be an object")
+		}
+	}
+
+	return nil
+}
+
+// receiveLimit returns the number of the messages returned by a receive with the limit, zero for the default.
+func receiveLimit(limit int64, cfg config.QueueConfig) (int, error) {
+	if limit < 0 {
+		return 0, errors.InvalidArgument("invalid limit %d", limit)
+	}
+	if limit == 0 {
+		return cfg.DefaultReceiveLimit, nil
+	}
+	if cfg.MaxReceiveLimit > 0 && limit > int64(cfg.MaxReceiveLimit) {
+		return cfg.MaxReceiveLimit, nil
+	}
+
+	return int(limit), nil
+}
+
+// readQueueJSON reads the value of the key of the queue table into v, it returns false if the key doesn't exist.
+func readQueueJSON(ctx context.Context, tx transaction.Tx, key keys.Key, v any) (bool, error) {
+	iter, err := tx.Read(ctx, key, false)
+	if err != nil {
+		return false, err
+	}
+
+	var row kv.KeyValue
+	if !iter.Next(&row) {
+		return false, iter.Err()
+	}
+
+	return true, jsoniter.Unmarshal(row.Data.RawData, v)
+}
+
+func writeQueueJSON(ctx context.Context, tx transaction.Tx, key keys.Key, v any) error {
+	data, err := jsoniter.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return tx.Replace(ctx, key, internal.NewTableData(data), false)
+}
+
+func readQueue(ctx context.Context, tx transaction.Tx, table []byte, name string) (*queueOptions, error) {
+	var queue queueOptions
+	found, err := readQueueJSON(ctx, tx, queueOptionsKeyOf(table, name), &queue)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.NotFound("queue doesn't exist '%s'", name)
+	}
+
+	return &queue, nil
+}
+
+// putMessage writes the message and its visible entry.
+func putMessage(ctx context.Context, tx transaction.Tx, table []byte, queue string, msg *queueMessage) error {
+	if err := writeQueueJSON(ctx, tx, queueMessageKeyOf(table, queue, msg.Id), msg); err != nil {
+		return err
+	}
+
+	return tx.Replace(ctx, queueVisibleKeyOf(table, queue, msg), internal.NewTableData(nil), false)
+}
+
+func queueResponse(v any, status string) (Response, error) {
+	data, err := jsoniter.Marshal(v)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{ContentType: "application/json", Data: data},
+		Status:   status,
+	}, nil
+}
+
+// CreateQueueQueryRunner creates a queue of the branch with the options of the request headers.
+type CreateQueueQueryRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeCollectionRequest
+}
+
+func (runner *CreateQueueQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	timeout, err := request.GetQueueVisibilityTimeout(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	maxReceives, err := request.GetQueueMaxReceives(ctx)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	queue, err := newQueueOptions(runner.req.GetCollection(), timeout, maxReceives,
+		api.GetHeader(ctx, api.HeaderQueueDeadLetter), config.DefaultConfig.Server.Queue)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeQueueTableName(tenant.GetNamespace(), db)
+	var existing queueOptions
+	found, err := readQueueJSON(ctx, tx, queueOptionsKeyOf(table, queue.Name), &existing)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	if found {
+		return Response{}, ctx, errors.AlreadyExists("queue already exists '%s'", queue.Name)
+	}
+	if len(queue.DeadLetterQueue) > 0 {
+		if _, err = readQueue(ctx, tx, table, queue.DeadLetterQueue); err != nil {
+			return Response{}, ctx, err
+		}
+	}
+
+	queue.CreatedAt = time.Now().UTC()
+	if err = writeQueueJSON(ctx, tx, queueOptionsKeyOf(table, queue.Name), queue); err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp, err := queueResponse(&api.QueueResponse{Status: CreatedStatus, Queue: queue.toAPI()}, CreatedStatus)
+	return resp, ctx, err
+}
+
+// EnqueueQueryRunner adds the messages of the request to a queue, visible to the receivers once the transaction of
+// the request commits.
+type EnqueueQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.InsertRequest
+	queryMetrics *metrics.WriteQueryMetrics
+}
+
+func (runner *EnqueueQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	name, messages := runner.req.GetCollection(), runner.req.GetDocuments()
+	if err := validateEnqueue(name, messages, config.DefaultConfig.Server.Queue); err != nil {
+		return Response{}, ctx, err
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeQueueTableName(tenant.GetNamespace(), db)
+	if _, err = readQueue(ctx, tx, table, name); err != nil {
+		return Response{}, ctx, err
+	}
+
+	now := time.Now().UTC()
+	ids := make([]string, 0, len(messages))
+	for _, data := range messages {
+		msg := &queueMessage{
+			Id:         uuid.New().String(),
+			Data:       data,
+			EnqueuedAt: now,
+			VisibleAt:  now,
+		}
+		if err = putMessage(ctx, tx, table, name, msg); err != nil {
+			return Response{}, ctx, err
+		}
+		ids = append(ids, msg.Id)
+	}
+
+	runner.queryMetrics.SetWriteType("enqueue")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	resp, err := queueResponse(&api.EnqueueResponse{Status: EnqueuedStatus, Ids: ids}, EnqueuedStatus)
+	return resp, ctx, err
+}
+
+// ReceiveQueryRunner returns the visible messages of a queue and hides them for the visibility timeout of the queue.
+// The messages received more than the max receives of the queue are moved to its dead-letter queue instead, so a
+// receive may return fewer messages than its limit while the queue has visible messages.
+type ReceiveQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.ReadRequest
+	queryMetrics *metrics.WriteQueryMetrics
+	now          func() time.Time
+}
+
+func (runner *ReceiveQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	name := runner.req.GetCollection()
+	if len(name) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("queue is required")
+	}
+
+	limit, err := receiveLimit(runner.req.GetOptions().GetLimit(), config.DefaultConfig.Server.Queue)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeQueueTableName(tenant.GetNamespace(), db)
+	queue, err := readQueue(ctx, tx, table, name)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	now := runner.now().UTC()
+	ids, err := runner.visibleIds(ctx, tx, table, name, now, limit)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	received := make([]*api.QueueMessage, 0, len(ids))
+	for _, id := range ids {
+		var msg queueMessage
+		found, err := readQueueJSON(ctx, tx, queueMessageKeyOf(table, name, id), &msg)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		if !found {
+			continue
+		}
+		if err = tx.Delete(ctx, queueVisibleKeyOf(table, name, &msg)); err != nil {
+			return Response{}, ctx, err
+		}
+
+		msg.Receives++
+		if queue.MaxReceives > 0 && msg.Receives > queue.MaxReceives {
+			if err = runner.deadLetter(ctx, tx, table, queue, &msg, now); err != nil {
+				return Response{}, ctx, err
+			}
+			continue
+		}
+
+		msg.VisibleAt = now.Add(queue.VisibilityTimeout)
+		msg.Lease = uuid.New().String()
+		if err = putMessage(ctx, tx, table, name, &msg); err != nil {
+			return Response{}, ctx, err
+		}
+
+		received = append(received, &api.QueueMessage{
+			Id:         msg.Id,
+			Receipt:    encodeReceipt(&msg),
+			Data:       msg.Data,
+			Receives:   msg.Receives,
+			EnqueuedAt: msg.EnqueuedAt,
+			VisibleAt:  msg.VisibleAt,
+		})
+	}
+
+	runner.queryMetrics.SetWriteType("receive")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	resp, err := queueResponse(&api.ReceiveResponse{Messages: received}, "")
+	return resp, ctx, err
+}
+
+// visibleIds returns the ids of the messages of the queue visible at now, at most limit of them.
+func (*ReceiveQueryRunner) visibleIds(ctx context.Context, tx transaction.Tx, table []byte, queue string, now time.Time, limit int) ([]string, error) {
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table, queueVisibleKey, queue),
+		keys.NewKey(table, queueVisibleKey, queue, now.UnixNano()+1), true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var row kv.KeyValue
+	ids := make([]string, 0, limit)
+	for len(ids) < limit && iter.Next(&row) {
+		if len(row.Key) != 4 {
+			continue
+		}
+		if id, ok := row.Key[3].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, iter.Err()
+}
+
+// deadLetter moves the message to the dead-letter queue of the queue, the message is dropped if the queue has no
+// dead-letter queue or if it was deleted.
+func (*ReceiveQueryRunner) deadLetter(ctx context.Context, tx transaction.Tx, table []byte, queue *queueOptions, msg *queueMessage, now time.Time) error {
+	if err := tx.Delete(ctx, queueMessageKeyOf(table, queue.Name, msg.Id)); err != nil {
+		return err
+	}
+	if len(queue.DeadLetterQueue) == 0 {
+		return nil
+	}
+
+	var dlq queueOptions
+	found, err := readQueueJSON(ctx, tx, queueOptionsKeyOf(table, queue.DeadLetterQueue), &dlq)
+	if err != nil || !found {
+		return err
+	}
+
+	return putMessage(ctx, tx, table, dlq.Name, &queueMessage{
+		Id:         msg.Id,
+		Data:       msg.Data,
+		EnqueuedAt: now,
+		VisibleAt:  now,
+	})
+}
+
+// AckQueryRunner deletes the messages of the receipts of the request. The messages deleted already are skipped, the
+// ack fails if a message was received again after the receive of its receipt.
+type AckQueryRunner struct {
+	*BaseQueryRunner
+
+	req          *api.InsertRequest
+	queryMetrics *metrics.WriteQueryMetrics
+}
+
+func (runner *AckQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	name := runner.req.GetCollection()
+	if len(name) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("queue is required")
+	}
+	if len(runner.req.GetDocuments()) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("ack has no receipts")
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	table := runner.encoder.EncodeQueueTableName(tenant.GetNamespace(), db)
+	if _, err = readQueue(ctx, tx, table, name); err != nil {
+		return Response{}, ctx, err
+	}
+
+	acked := 0
+	for _, doc := range runner.req.GetDocuments() {
+		receipt, err := jsonparser.GetString(doc, "receipt")
+		if err != nil {
+			return Response{}, ctx, errors.InvalidArgument("ack should be an object with the receipt of a message")
+		}
+		id, lease, err := decodeReceipt(receipt)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+
+		var msg queueMessage
+		found, err := readQueueJSON(ctx, tx, queueMessageKeyOf(table, name, id), &msg)
+		if err != nil {
+			return Response{}, ctx, err
+		}
+		if !found {
+			continue
+		}
+		if msg.Lease != lease {
+			return Response{}, ctx, errors.FailedPrecondition("receipt of message '%s' expired, the message was received again", id)
+		}
+
+		if err = tx.Delete(ctx, queueMessageKeyOf(table, name, id)); err != nil {
+			return Response{}, ctx, err
+		}
+		if err = tx.Delete(ctx, queueVisibleKeyOf(table, name, &msg)); err != nil {
+			return Response{}, ctx, err
+		}
+		acked++
+	}
+
+	runner.queryMetrics.SetWriteType("ack")
+	metrics.UpdateSpanTags(ctx, runner.queryMetrics)
+
+	resp, err := queueResponse(&api.AckResponse{Status: AckedStatus, Acked: acked}, AckedStatus)
+	return resp, ctx, err
+}
@@ -0,0 +1,174 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestNewQueueOptions(t *testing.T) {
+	cfg := config.QueueConfig{DefaultVisibilityTimeout: 30 * time.Second, MaxVisibilityTimeout: time.Hour}
+
+	queue, err := newQueueOptions("orders", 0, 0, "", cfg)
+	require.NoError(t, err)
+	require.Equal(t, &queueOptions{Name: "orders", VisibilityTimeout: 30 * time.Second}, queue)
+	require.Equal(t, "30s", queue.toAPI().VisibilityTimeout)
+
+	queue, err = newQueueOptions("orders", time.Minute, 3, "orders-dlq", cfg)
+	require.NoError(t, err)
+	require.Equal(t, &queueOptions{Name: "orders", VisibilityTimeout: time.Minute, MaxReceives: 3, DeadLetterQueue: "orders-dlq"}, queue)
+
+	cases := []struct {
+		name       string
+		timeout    time.Duration
+		maxRecv    int
+		deadLetter string
+		err        error
+	}{
+		{"", 0, 0, "", errors.InvalidArgument("queue is required")},
+		{"orders", 0, 3, "orders", errors.InvalidArgument("queue '%s' can't be its own dead-letter queue", "orders")},
+		{"orders", 0, 0, "orders-dlq", errors.InvalidArgument("dead-letter queue requires the '%s' header", api.HeaderQueueMaxReceives)},
+		{"orders", 2 * time.Hour, 0, "", errors.InvalidArgument("visibility timeout %s is above the maximum %s", 2*time.Hour, time.Hour)},
+	}
+	for _, c := range cases {
+		_, err = newQueueOptions(c.name, c.timeout, c.maxRecv, c.deadLetter, cfg)
+		require.Equal(t, c.err, err)
+	}
+}
+
+func TestValidateEnqueue(t *testing.T) {
+	cfg := config.QueueConfig{MaxEnqueueMessages: 2}
+	msg := []byte(`{"order":1}`)
+
+	require.NoError(t, validateEnqueue("orders", [][]byte{msg, msg}, cfg))
+	require.Equal(t, errors.InvalidArgument("queue is required"), validateEnqueue("", [][]byte{msg}, cfg))
+	require.Equal(t, errors.InvalidArgument("enqueue has no messages"), validateEnqueue("orders", nil, cfg))
+	require.Equal(t, errors.InvalidArgument("enqueue has %d messages, the maximum is %d", 3, 2),
+		validateEnqueue("orders", [][]byte{msg, msg, msg}, cfg))
+	require.Equal(t, errors.InvalidArgument("queue message should be an object"),
+		validateEnqueue("orders", [][]byte{[]byte(`"order"`)}, cfg))
+}
+
+func TestReceiveLimit(t *testing.T) {
+	cfg := config.QueueConfig{DefaultReceiveLimit: 1, MaxReceiveLimit: 10}
+
+	for _, c := range []struct {
+		limit    int64
+		expected int
+	}{
+		{0, 1},
+		{5, 5},
+		{20, 10},
+	} {
+		limit, err := receiveLimit(c.limit, cfg)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, limit)
+	}
+
+	_, err := receiveLimit(-1, cfg)
+	require.Equal(t, errors.InvalidArgument("invalid limit %d", -1), err)
+}
+
+func TestReceipt(t *testing.T) {
+	receipt := encodeReceipt(&queueMessage{Id: "m1", Lease: "l1"})
+	require.Equal(t, "m1:l1", receipt)
+
+	id, lease, err := decodeReceipt(receipt)
+	require.NoError(t, err)
+	require.Equal(t, "m1", id)
+	require.Equal(t, "l1", lease)
+
+	for _, r := range []string{"", "m1", "m1:", ":l1"} {
+		_, _, err = decodeReceipt(r)
+		require.Equal(t, errors.InvalidArgument("invalid receipt '%s'", r), err)
+	}
+}
+
+func TestQueueMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := append(append([]byte{}, internal.QueueTableKeyPrefix...), 0, 0, 0, 1, 0, 0, 0, 2)
+	require.NoError(t, kvStore.DropTable(ctx, table))
+	tm := transaction.NewManager(kvStore)
+
+	inTx := func(fn func(tx transaction.Tx)) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		fn(tx)
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	now := time.Now().UTC()
+	queue := &queueOptions{Name: "orders", VisibilityTimeout: time.Minute, MaxReceives: 1, DeadLetterQueue: "orders-dlq"}
+	inTx(func(tx transaction.Tx) {
+		require.NoError(t, writeQueueJSON(ctx, tx, queueOptionsKeyOf(table, queue.Name), queue))
+		require.NoError(t, writeQueueJSON(ctx, tx, queueOptionsKeyOf(table, "orders-dlq"), &queueOptions{Name: "orders-dlq"}))
+
+		require.NoError(t, putMessage(ctx, tx, table, "orders", &queueMessage{Id: "m1", Data: []byte(`{}`), VisibleAt: now.Add(-time.Second)}))
+		require.NoError(t, putMessage(ctx, tx, table, "orders", &queueMessage{Id: "m2", Data: []byte(`{}`), VisibleAt: now}))
+		require.NoError(t, putMessage(ctx, tx, table, "orders", &queueMessage{Id: "m3", Data: []byte(`{}`), VisibleAt: now.Add(time.Minute)}))
+	})
+
+	runner := &ReceiveQueryRunner{}
+	inTx(func(tx transaction.Tx) {
+		// the messages visible at now in the order they became visible
+		ids, err := runner.visibleIds(ctx, tx, table, "orders", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m1", "m2"}, ids)
+
+		ids, err = runner.visibleIds(ctx, tx, table, "orders", now, 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m1"}, ids)
+
+		found, err := readQueue(ctx, tx, table, "orders")
+		require.NoError(t, err)
+		require.Equal(t, queue, found)
+
+		_, err = readQueue(ctx, tx, table, "payments")
+		require.Equal(t, errors.NotFound("queue doesn't exist '%s'", "payments"), err)
+
+		var msg queueMessage
+		ok, err := readQueueJSON(ctx, tx, queueMessageKeyOf(table, "orders", "m1"), &msg)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, tx.Delete(ctx, queueVisibleKeyOf(table, "orders", &msg)))
+		require.NoError(t, runner.deadLetter(ctx, tx, table, queue, &msg, now))
+	})
+
+	inTx(func(tx transaction.Tx) {
+		ids, err := runner.visibleIds(ctx, tx, table, "orders", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m2"}, ids)
+
+		ids, err = runner.visibleIds(ctx, tx, table, "orders-dlq", now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m1"}, ids)
+
+		var msg queueMessage
+		ok, err := readQueueJSON(ctx, tx, queueMessageKeyOf(table, "orders", "m1"), &msg)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
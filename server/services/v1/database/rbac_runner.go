@@ -0,0 +1,168 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// RBACRequest is used to manage the roles and the role bindings of a project.
+type RBACRequest struct {
+	Project string
+	Role    string
+	Grants  []metadata.Grant
+	Sub     string
+}
+
+// RBACQueryRunner manages the roles of a project and the bindings of the roles to the subjects.
+type RBACQueryRunner struct {
+	*BaseQueryRunner
+
+	putRoleReq       *RBACRequest
+	deleteRoleReq    *RBACRequest
+	createBindingReq *RBACRequest
+	deleteBindingReq *RBACRequest
+	listReq          *RBACRequest
+
+	rbac *metadata.ProjectRBAC
+}
+
+func (runner *RBACQueryRunner) SetCreateOrUpdateRoleReq(req *RBACRequest) {
+	runner.putRoleReq = req
+}
+
+func (runner *RBACQueryRunner) SetDeleteRoleReq(req *RBACRequest) {
+	runner.deleteRoleReq = req
+}
+
+func (runner *RBACQueryRunner) SetCreateRoleBindingReq(req *RBACRequest) {
+	runner.createBindingReq = req
+}
+
+func (runner *RBACQueryRunner) SetDeleteRoleBindingReq(req *RBACRequest) {
+	runner.deleteBindingReq = req
+}
+
+func (runner *RBACQueryRunner) SetListReq(req *RBACRequest) {
+	runner.listReq = req
+}
+
+// RBAC returns the roles and the role bindings read or written by the last run.
+func (runner *RBACQueryRunner) RBAC() *metadata.ProjectRBAC {
+	return runner.rbac
+}
+
+func (runner *RBACQueryRunner) currentSub() string {
+	if runner.accessToken != nil {
+		return runner.accessToken.Sub
+	}
+
+	return ""
+}
+
+func (runner *RBACQueryRunner) putRole(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.putRoleReq
+	if _, err := tenant.GetProject(req.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	role := &metadata.Role{Name: req.Role, Grants: req.Grants}
+	if err := metadata.ValidateRole(role); err != nil {
+		return Response{}, ctx, err
+	}
+
+	created, err := tenant.CreateOrUpdateRole(ctx, tx, req.Project, role, runner.currentSub())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.rbac = &metadata.ProjectRBAC{Roles: []metadata.Role{*role}}
+
+	if created {
+		return Response{Status: CreatedStatus}, ctx, nil
+	}
+
+	return Response{Status: UpdatedStatus}, ctx, nil
+}
+
+func (runner *RBACQueryRunner) deleteRole(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if err := tenant.DeleteRole(ctx, tx, runner.deleteRoleReq.Project, runner.deleteRoleReq.Role); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+func (runner *RBACQueryRunner) createBinding(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.createBindingReq
+	if _, err := tenant.GetProject(req.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	if len(req.Sub) == 0 || len(req.Role) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("role binding requires the sub and the role")
+	}
+
+	binding := &metadata.RoleBinding{Sub: req.Sub, Role: req.Role}
+	if err := tenant.CreateRoleBinding(ctx, tx, req.Project, binding, runner.currentSub()); err != nil {
+		return Response{}, ctx, err
+	}
+	runner.rbac = &metadata.ProjectRBAC{Bindings: []metadata.RoleBinding{*binding}}
+
+	return Response{Status: CreatedStatus}, ctx, nil
+}
+
+func (runner *RBACQueryRunner) deleteBinding(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.deleteBindingReq
+	if err := tenant.DeleteRoleBinding(ctx, tx, req.Project, req.Sub, req.Role); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+func (runner *RBACQueryRunner) list(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if _, err := tenant.GetProject(runner.listReq.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	rbac, err := tenant.GetProjectRBAC(ctx, tx, runner.listReq.Project)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.rbac = rbac
+
+	return Response{}, ctx, nil
+}
+
+func (runner *RBACQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	switch {
+	case runner.putRoleReq != nil:
+		return runner.putRole(ctx, tx, tenant)
+	case runner.deleteRoleReq != nil:
+		return runner.deleteRole(ctx, tx, tenant)
+	case runner.createBindingReq != nil:
+		return runner.createBinding(ctx, tx, tenant)
+	case runner.deleteBindingReq != nil:
+		return runner.deleteBinding(ctx, tx, tenant)
+	case runner.listReq != nil:
+		return runner.list(ctx, tx, tenant)
+	}
+
+	return Response{}, ctx, errors.Unknown("unknown request path")
+}
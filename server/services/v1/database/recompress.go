@@ -0,0 +1,159 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// recompressBatchSize is the number of documents rewritten in a transaction by the recompression job.
+const recompressBatchSize = 500
+
+// Recompressor rewrites the documents of a collection after its compression setting is changed so that the documents
+// written before the change are stored with the new compression as well. The documents are written back as they are
+// read, therefore, neither the revision nor the indexes of the documents change and no change events are published.
+type Recompressor struct {
+	*BaseQueryRunner
+
+	tenant      *metadata.Tenant
+	project     string
+	branch      string
+	collection  string
+	compression string
+}
+
+func NewRecompressor(runner *BaseQueryRunner, tenant *metadata.Tenant, project string, branch string, coll *schema.DefaultCollection) *Recompressor {
+	return &Recompressor{
+		BaseQueryRunner: runner,
+		tenant:          tenant,
+		project:         project,
+		branch:          branch,
+		collection:      coll.Name,
+		compression:     coll.Compression,
+	}
+}
+
+// Run rewrites the documents in batches. It stops early if the collection is dropped or its compression setting is
+// changed again while it runs, the job started for the latest change takes over in that case.
+func (r *Recompressor) Run(ctx context.Context) error {
+	var (
+		id        uint32
+		last      []byte
+		total     int
+		batchSize = recompressBatchSize
+	)
+
+	for {
+		_, coll, err := r.getDBAndCollection(ctx, nil, r.tenant, r.project, r.collection, r.branch)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			id = coll.Id
+		}
+		if coll.Id != id || coll.Compression != r.compression {
+			log.Info().Msgf("Recompression of collection '%s' stopped after %d docs, the collection has changed", r.collection, total)
+			return nil
+		}
+
+		count, next, err := r.rewrite(ctx, coll, last, batchSize)
+		if err != nil {
+			if !shouldRetryBulkIndex(err) {
+				return err
+			}
+			// rewrite fewer documents in the next attempt
+			if batchSize > 1 {
+				batchSize /= 2
+			}
+			continue
+		}
+
+		total += count
+		if next == nil {
+			log.Info().Msgf("Recompressed %d docs of collection '%s' with '%s'", total, r.collection, r.compression)
+			return nil
+		}
+		last = next
+	}
+}
+
+// rewrite writes back up to batchSize documents following the key after, it returns the last key written or nil if
+// there are no more documents.
+func (r *Recompressor) rewrite(ctx context.Context, coll *schema.DefaultCollection, after []byte, batchSize int) (int, []byte, error) {
+	tx, err := r.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	iter, err := createBulkDocsReader(ctx, tx, coll.EncodedName, nil, after)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	var (
+		row   Row
+		last  []byte
+		count int
+	)
+	writeCtx := kv.CtxWithCompression(ctx, r.compression)
+	for count < batchSize && iter.Next(&row) {
+		if after != nil && bytes.Equal(row.Key, after) {
+			continue
+		}
+
+		key, err := keys.FromBinary(coll.EncodedName, row.Key)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		// the payload is decompressed by the read, the attributes of the stored payload are reset so that it is
+		// compressed and chunked again by the write
+		data := row.Data.CloneWithAttributesOnly(row.Data.RawData)
+		data.Compression = nil
+		data.TotalChunks = nil
+
+		if err = tx.Replace(kv.CtxWithSize(writeCtx, row.Data.Size()), key, data, true); err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, nil, err
+		}
+
+		last = row.Key
+		count++
+	}
+
+	if err = iter.Interrupted(); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if count < batchSize {
+		return count, nil, nil
+	}
+
+	return count, last, nil
+}
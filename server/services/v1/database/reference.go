@@ -0,0 +1,177 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	ulog "github.com/tigrisdata/tigris/util/log"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// referenceFilter builds an equality filter on the field using the raw JSON value extracted from the document.
+func referenceFilter(field string, val []byte, dtp jsonparser.ValueType) []byte {
+	f := make([]byte, 0, len(field)+len(val)+8)
+	f = append(f, `{"`...)
+	f = append(f, field...)
+	f = append(f, `":`...)
+	if dtp == jsonparser.String {
+		f = append(f, '"')
+		f = append(f, val...)
+		f = append(f, '"')
+	} else {
+		f = append(f, val...)
+	}
+
+	return append(f, '}')
+}
+
+// checkReferences verifies that the documents referenced by the document exist in the referenced collections. Only
+// the reference fields with validation enabled are checked. The lookup runs in the same transaction as the write.
+func (runner *BaseQueryRunner) checkReferences(ctx context.Context, tx transaction.Tx, db *metadata.Database,
+	coll *schema.DefaultCollection, doc []byte,
+) error {
+	for _, f := range coll.GetReferenceFields() {
+		if !f.Reference.Validate {
+			continue
+		}
+
+		val, dtp, _, err := jsonparser.Get(doc, f.FieldName)
+		if err != nil || dtp == jsonparser.NotExist || dtp == jsonparser.Null {
+			continue
+		}
+
+		referenced := coll
+		if f.Reference.Collection != coll.Name {
+			if referenced = db.GetCollection(f.Reference.Collection); referenced == nil {
+				return errors.InvalidArgument("referenced collection '%s' doesn't exist", f.Reference.Collection)
+			}
+		}
+
+		pkField := referenced.GetPrimaryKey().Fields[0].FieldName
+		planner, err := NewPrimaryIndexQueryPlanner(referenced, runner.encoder, referenceFilter(pkField, val, dtp), nil)
+		if err != nil {
+			return err
+		}
+
+		plan, err := planner.GeneratePlan(nil, nil)
+		if err != nil {
+			return err
+		}
+
+		iterator, err := NewDatabaseReader(ctx, tx).KeyIterator(plan.Keys)
+		if err != nil {
+			return err
+		}
+
+		var row Row
+		found := iterator.Next(&row)
+		if err = iterator.Interrupted(); err != nil {
+			return err
+		}
+		if !found {
+			return errors.InvalidArgument("document referenced by the field '%s' doesn't exist in the collection '%s'",
+				f.FieldName, f.Reference.Collection)
+		}
+	}
+
+	return nil
+}
+
+// applyOnDeleteReferences runs the onDelete action of the fields referencing the document which has just been deleted
+// from the collection. The restrict action fails the delete if there is any referencing document, and the cascade
+// action deletes the referencing documents recursively. Everything is done as part of the same transaction.
+func (runner *BaseQueryRunner) applyOnDeleteReferences(ctx context.Context, tx transaction.Tx, db *metadata.Database,
+	coll *schema.DefaultCollection, doc []byte,
+) error {
+	referencing := schema.GetReferencingFields(coll.Name, db.ListCollection())
+	if len(referencing) == 0 {
+		return nil
+	}
+
+	pkFields := coll.GetPrimaryKey().Fields
+	if len(pkFields) != 1 {
+		return nil
+	}
+
+	val, dtp, _, err := jsonparser.Get(doc, pkFields[0].FieldName)
+	if err != nil || dtp == jsonparser.NotExist {
+		return nil
+	}
+
+	for _, ref := range referencing {
+		action := ref.Field.Reference.OnDelete
+		if action != schema.OnDeleteRestrict && action != schema.OnDeleteCascade {
+			continue
+		}
+
+		iterator, err := runner.getWriteIterator(ctx, tx, ref.Collection, referenceFilter(ref.Field.FieldName, val, dtp),
+			value.NewCollation(), &metrics.WriteQueryMetrics{})
+		if err != nil {
+			return err
+		}
+
+		var rows []Row
+		var row Row
+		for iterator.Next(&row) {
+			if action == schema.OnDeleteRestrict {
+				return errors.InvalidArgument("document is referenced by the field '%s' of collection '%s'",
+					ref.Field.FieldName, ref.Collection.Name)
+			}
+			rows = append(rows, row)
+		}
+		if err = iterator.Interrupted(); err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			if err = runner.deleteReferencingRow(ctx, tx, db, ref.Collection, r); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (runner *BaseQueryRunner) deleteReferencingRow(ctx context.Context, tx transaction.Tx, db *metadata.Database,
+	coll *schema.DefaultCollection, row Row,
+) error {
+	key, err := keys.FromBinary(coll.EncodedName, row.Key)
+	if err != nil {
+		return err
+	}
+
+	if config.DefaultConfig.SecondaryIndex.WriteEnabled {
+		if err = NewSecondaryIndexer(coll).Delete(ctx, tx, row.Data, key.IndexParts()); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Delete(kv.CtxWithSize(ctx, row.Data.Size()), key); ulog.E(err) {
+		return err
+	}
+
+	return runner.applyOnDeleteReferences(ctx, tx, db, coll, row.Data.RawData)
+}
@@ -15,6 +15,7 @@
 package database
 
 import (
+	jsoniter "github.com/json-iterator/go"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/internal"
 )
@@ -27,6 +28,10 @@ const (
 	CreatedStatus  string = "created"
 	DroppedStatus  string = "dropped"
 	OkStatus       string = "success"
+	// DryRunStatus is the status of the writes which only report what they would modify
+	DryRunStatus string = "dry_run"
+	// StartedStatus is the status of the writes which continue in the background
+	StartedStatus string = "started"
 )
 
 // Streaming is a wrapper interface for passing around for streaming reads.
@@ -54,4 +59,6 @@ type Response struct {
 	DeletedAt     *internal.Timestamp
 	ModifiedCount int32
 	AllKeys       [][]byte
+	// Documents are the modified documents the write returns, nil unless the caller asked for them
+	Documents []jsoniter.RawMessage
 }
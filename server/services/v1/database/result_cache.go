@@ -0,0 +1,148 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/resultcache"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// resultRecorder records the rows read for the result of a read to be cached, it stops recording once the read has
+// more rows than a cached result can have.
+type resultRecorder struct {
+	Iterator
+
+	cache    *resultcache.Cache
+	query    *resultcache.Query
+	token    resultcache.Token
+	rows     []resultcache.Row
+	overflow bool
+}
+
+func (r *resultRecorder) Next(row *Row) bool {
+	if !r.Iterator.Next(row) {
+		return false
+	}
+
+	if !r.overflow {
+		if len(r.rows) == r.cache.MaxRows() {
+			r.overflow, r.rows = true, nil
+		} else {
+			r.rows = append(r.rows, resultcache.Row{Key: row.Key, Data: row.Data})
+		}
+	}
+
+	return true
+}
+
+// cachedResultIterator returns the rows of a cached result.
+type cachedResultIterator struct {
+	rows []resultcache.Row
+}
+
+func (it *cachedResultIterator) Next(row *Row) bool {
+	if len(it.rows) == 0 {
+		return false
+	}
+
+	row.Key, row.Data = it.rows[0].Key, it.rows[0].Data
+	it.rows = it.rows[1:]
+
+	return true
+}
+
+func (*cachedResultIterator) Interrupted() error { return nil }
+
+// resultCacheQuery returns the query of the read if its result is cached, nil otherwise. The reads in an explicit
+// transaction or of a snapshot always read the storage.
+func resultCacheQuery(ctx context.Context, tenant *metadata.Tenant, db *metadata.Database, coll *schema.DefaultCollection, req *api.ReadRequest) *resultcache.Query {
+	if api.GetTransaction(ctx) != nil || kv.HasReadVersion(ctx) {
+		return nil
+	}
+
+	// the rows read also depend on the default limit of the JSON responses and on the pinned schema version
+	pinnedVersion, err := request.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil
+	}
+
+	read, err := jsoniter.Marshal(struct {
+		Filter  []byte
+		Fields  []byte
+		Sort    []byte
+		Options *api.ReadRequestOptions
+		JSON    bool
+		Pinned  uint32
+	}{req.GetFilter(), req.GetFields(), req.GetSort(), req.GetOptions(), request.IsAcceptApplicationJSON(ctx), pinnedVersion})
+	if err != nil {
+		return nil
+	}
+
+	q := &resultcache.Query{
+		NamespaceId: tenant.GetNamespace().Id(),
+		Namespace:   tenant.GetNamespace().StrId(),
+		Project:     req.GetProject(),
+		Branch:      db.BranchName(),
+		Database:    db.Name(),
+		Collection:  coll.Name,
+		Table:       coll.EncodedName,
+		Key:         fmt.Sprintf("%d\x00%s\x00%s\x00%d\x00%s", tenant.GetNamespace().Id(), db.Name(), coll.Name, coll.GetVersion(), read),
+	}
+	if resultcache.Get(q) == nil {
+		return nil
+	}
+
+	return q
+}
+
+// readCachedResult serves the read from the result cache if its result is cached. Otherwise, the rows of the read are
+// recorded to be cached by fillCachedResult once the read completes.
+func (runner *StreamingQueryRunner) readCachedResult(ctx context.Context, tenant *metadata.Tenant, db *metadata.Database, coll *schema.DefaultCollection, options readerOptions) (bool, error) {
+	query := resultCacheQuery(ctx, tenant, db, coll, runner.req)
+	if query == nil {
+		return false, nil
+	}
+
+	cache := resultcache.Get(query)
+	rows, age, token, hit := cache.Lookup(query)
+	runner.resultCache = &api.ResultCacheStats{Hit: hit, AgeUs: age.Microseconds()}
+	if !hit {
+		runner.results = &resultRecorder{cache: cache, query: query, token: token}
+		return false, nil
+	}
+
+	_, err := runner.iterate(ctx, coll, &cachedResultIterator{rows: rows}, options.fieldFactory)
+
+	return true, err
+}
+
+// fillCachedResult caches the result of the read recorded by readCachedResult. The results read at a stale version
+// are not cached.
+func (runner *StreamingQueryRunner) fillCachedResult(ctx context.Context) {
+	r := runner.results
+	if r == nil || r.overflow || kv.HasMaxStaleness(ctx) {
+		return
+	}
+
+	r.cache.Fill(r.query, r.rows, r.token)
+}
@@ -0,0 +1,105 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+// revisionPrecondition is the revision the caller expects the documents to be at before they are modified, it is
+// passed by the Tigris-If-Revision header.
+type revisionPrecondition struct {
+	revision int64
+	set      bool
+}
+
+func newRevisionPrecondition(ctx context.Context) (revisionPrecondition, error) {
+	rev, set, err := request.GetIfRevision(ctx)
+	if err != nil {
+		return revisionPrecondition{}, err
+	}
+
+	return revisionPrecondition{revision: rev, set: set}, nil
+}
+
+// check returns the precondition error if the current value of the document, nil if the document doesn't exist, is not
+// at the expected revision.
+func (p revisionPrecondition) check(current *internal.TableData) error {
+	if !p.set || current.GetRevision() == p.revision {
+		return nil
+	}
+
+	if current == nil {
+		return errors.FailedPrecondition("document doesn't exist, expected revision %d", p.revision)
+	}
+
+	return errors.FailedPrecondition("document is at revision %d, expected revision %d", current.GetRevision(), p.revision)
+}
+
+// nextRevision returns the revision of the document that replaces the current value, nil if the document doesn't exist.
+func nextRevision(current *internal.TableData) int64 {
+	return current.GetRevision() + 1
+}
+
+// injectRevision adds the revision of the document as the reserved field at the end of the JSON document.
+func injectRevision(raw []byte, revision int64) []byte {
+	lastIndex := bytes.LastIndex(raw, []byte(`}`))
+	if lastIndex <= 0 {
+		return raw
+	}
+
+	var buf bytes.Buffer
+	_, _ = buf.Write(raw[:lastIndex])
+	if bytes.IndexByte(bytes.TrimSpace(raw[1:lastIndex]), '"') >= 0 {
+		_ = buf.WriteByte(',')
+	}
+	_, _ = buf.WriteString(`"` + schema.ReservedFields[schema.Revision] + `":` + strconv.FormatInt(revision, 10))
+	_ = buf.WriteByte('}')
+
+	return buf.Bytes()
+}
+
+// readDocument returns the current value of the document, nil if the document doesn't exist.
+func readDocument(ctx context.Context, tx transaction.Tx, key keys.Key) (*internal.TableData, error) {
+	iter, err := tx.Read(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc kv.KeyValue
+	if iter.Next(&doc) {
+		return doc.Data, nil
+	}
+
+	return nil, iter.Err()
+}
+
+// setRevisionHeader lets the caller of a single document write know the revision of the document.
+func setRevisionHeader(ctx context.Context, revision int64) {
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderRevision, strconv.FormatInt(revision, 10)))
+}
@@ -0,0 +1,50 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+)
+
+func TestRevisionPrecondition(t *testing.T) {
+	doc := &internal.TableData{Revision: 3}
+
+	require.NoError(t, revisionPrecondition{}.check(doc))
+	require.NoError(t, revisionPrecondition{}.check(nil))
+	require.NoError(t, revisionPrecondition{revision: 3, set: true}.check(doc))
+	require.NoError(t, revisionPrecondition{revision: 0, set: true}.check(nil))
+
+	var tErr *api.TigrisError
+	err := revisionPrecondition{revision: 2, set: true}.check(doc)
+	require.ErrorAs(t, err, &tErr)
+	require.Equal(t, api.Code_FAILED_PRECONDITION, tErr.Code)
+
+	err = revisionPrecondition{revision: 1, set: true}.check(nil)
+	require.ErrorAs(t, err, &tErr)
+	require.Equal(t, api.Code_FAILED_PRECONDITION, tErr.Code)
+
+	require.Equal(t, int64(1), nextRevision(nil))
+	require.Equal(t, int64(4), nextRevision(doc))
+}
+
+func TestInjectRevision(t *testing.T) {
+	require.JSONEq(t, `{"a":1,"_tigris_revision":5}`, string(injectRevision([]byte(`{"a":1}`), 5)))
+	require.JSONEq(t, `{"_tigris_revision":5}`, string(injectRevision([]byte(`{}`), 5)))
+	require.JSONEq(t, `{"a":{"b":1},"_tigris_revision":5}`, string(injectRevision([]byte(`{"a":{"b":1}} `), 5)))
+}
@@ -0,0 +1,99 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/request"
+)
+
+// applyRowPolicy ANDs the row-level security policy of the caller's role into the filter of the request. The filter is
+// returned as-is when auth is disabled or the collection has no policy for the role.
+func applyRowPolicy(ctx context.Context, coll *schema.DefaultCollection, reqFilter []byte) ([]byte, error) {
+	if !coll.HasPolicies() {
+		return reqFilter, nil
+	}
+
+	role, ok := request.GetCallerRole(ctx)
+	if !ok {
+		return reqFilter, nil
+	}
+
+	policy, err := coll.PolicyFilter(role, request.GetTokenClaims(ctx))
+	if err != nil || policy == nil {
+		return reqFilter, err
+	}
+
+	if filter.None(reqFilter) {
+		return policy, nil
+	}
+
+	combined := make([]byte, 0, len(reqFilter)+len(policy)+12)
+	combined = append(combined, `{"$and":[`...)
+	combined = append(combined, reqFilter...)
+	combined = append(combined, ',')
+	combined = append(combined, policy...)
+	combined = append(combined, "]}"...)
+
+	return combined, nil
+}
+
+// rowPolicyChecker rejects the writes of the documents outside the row-level security policy of the caller's role, so
+// that the caller can't insert a document it can't read or replace a document it can't read by its primary key.
+type rowPolicyChecker struct {
+	filter *filter.WrappedFilter
+}
+
+// newRowPolicyChecker returns the checker of the policy of the caller's role, nil if the writes are unrestricted.
+func newRowPolicyChecker(ctx context.Context, coll *schema.DefaultCollection) (*rowPolicyChecker, error) {
+	policy, err := applyRowPolicy(ctx, coll, nil)
+	if err != nil || filter.None(policy) {
+		return nil, err
+	}
+
+	return newRowPolicyFilterChecker(coll, policy)
+}
+
+func newRowPolicyFilterChecker(coll *schema.DefaultCollection, policy []byte) (*rowPolicyChecker, error) {
+	wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowPolicyChecker{filter: wrapped}, nil
+}
+
+// check returns an error if the document written doesn't match the policy.
+func (c *rowPolicyChecker) check(doc []byte) error {
+	if c != nil && !c.filter.Matches(doc, nil) {
+		return errors.PermissionDenied("document doesn't match the row policy of the collection")
+	}
+
+	return nil
+}
+
+// checkReplaced returns an error if the existing document replaced by a write doesn't match the policy.
+func (c *rowPolicyChecker) checkReplaced(existing *internal.TableData) error {
+	if c != nil && existing != nil && !c.filter.Matches(existing.RawData, nil) {
+		return errors.PermissionDenied("document replaced doesn't match the row policy of the collection")
+	}
+
+	return nil
+}
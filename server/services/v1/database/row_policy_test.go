@@ -0,0 +1,57 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/schema"
+)
+
+func TestRowPolicyChecker(t *testing.T) {
+	factory, err := schema.NewFactoryBuilder(true).Build("orders", []byte(`{
+		"title": "orders",
+		"properties": {"id": {"type": "integer"}, "owner": {"type": "string"}},
+		"primary_key": ["id"],
+		"policies": {"e": {"$or": [{"owner": "$$token.sub"}, {"owner": "shared"}]}}
+	}`))
+	require.NoError(t, err)
+	coll, err := schema.NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	policy, err := coll.PolicyFilter("e", map[string]any{"sub": "u1"})
+	require.NoError(t, err)
+	checker, err := newRowPolicyFilterChecker(coll, policy)
+	require.NoError(t, err)
+
+	require.NoError(t, checker.check([]byte(`{"id": 1, "owner": "u1"}`)))
+	require.NoError(t, checker.check([]byte(`{"id": 2, "owner": "shared"}`)))
+	require.Equal(t, errors.PermissionDenied("document doesn't match the row policy of the collection"),
+		checker.check([]byte(`{"id": 3, "owner": "u2"}`)))
+
+	// a replace of a document of another owner by its primary key is rejected, whatever the new document is
+	require.NoError(t, checker.checkReplaced(nil))
+	require.NoError(t, checker.checkReplaced(internal.NewTableData([]byte(`{"id": 1, "owner": "u1"}`))))
+	require.Equal(t, errors.PermissionDenied("document replaced doesn't match the row policy of the collection"),
+		checker.checkReplaced(internal.NewTableData([]byte(`{"id": 3, "owner": "u2"}`))))
+
+	// the writes are unrestricted without a checker
+	var unrestricted *rowPolicyChecker
+	require.NoError(t, unrestricted.check([]byte(`{"id": 3, "owner": "u2"}`)))
+	require.NoError(t, unrestricted.checkReplaced(internal.NewTableData([]byte(`{"id": 3, "owner": "u2"}`))))
+}
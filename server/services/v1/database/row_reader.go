@@ -38,10 +38,15 @@ type Iterator interface {
 	Interrupted() error
 }
 
+// scanCancelInterval is the number of the rows a scan reads between the checks of its context, so that a scan filtering
+// out most of the rows stops soon after the request is canceled or its deadline is exceeded.
+const scanCancelInterval = 64
+
 type ScanIterator struct {
-	ctx context.Context
-	it  kv.Iterator
-	err error
+	ctx  context.Context
+	it   kv.Iterator
+	err  error
+	read int
 }
 
 func NewScanIterator(ctx context.Context, tx transaction.Tx, from keys.Key, to keys.Key, reverse bool) (*ScanIterator, error) {
@@ -61,6 +66,12 @@ func (s *ScanIterator) Next(row *Row) bool {
 		return false
 	}
 
+	if s.read++; s.read%scanCancelInterval == 0 {
+		if s.err = s.ctx.Err(); s.err != nil {
+			return false
+		}
+	}
+
 	var keyValue kv.KeyValue
 	if s.it.Next(&keyValue) {
 		row.Key = keyValue.FDBKey
@@ -169,6 +180,33 @@ func (it *FilterIterator) advanceToMatchingRow(row *Row) bool {
 	return it.filter.Matches(row.Data.RawData, tsJSON)
 }
 
+// SchemaVersionIterator only returns rows that were written under a specific schema version.
+type SchemaVersionIterator struct {
+	iterator Iterator
+	version  int32
+}
+
+func NewSchemaVersionIterator(iterator Iterator, version uint32) *SchemaVersionIterator {
+	return &SchemaVersionIterator{
+		iterator: iterator,
+		version:  int32(version),
+	}
+}
+
+func (it *SchemaVersionIterator) Interrupted() error {
+	return it.iterator.Interrupted()
+}
+
+func (it *SchemaVersionIterator) Next(row *Row) bool {
+	for it.iterator.Next(row) {
+		if row.Data.Ver == it.version {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Reader struct {
 	tx  transaction.Tx
 	ctx context.Context
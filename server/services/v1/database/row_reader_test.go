@@ -0,0 +1,56 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// endlessIterator returns rows until the test stops reading.
+type endlessIterator struct {
+	read int
+}
+
+func (it *endlessIterator) Next(value *kv.KeyValue) bool {
+	it.read++
+	value.Data = internal.NewTableData([]byte(`{}`))
+	return true
+}
+
+func (*endlessIterator) Err() error { return nil }
+
+func TestScanIteratorCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	it := &endlessIterator{}
+	scan := &ScanIterator{ctx: ctx, it: it}
+
+	var row Row
+	for i := 0; i < 3*scanCancelInterval; i++ {
+		require.True(t, scan.Next(&row))
+	}
+
+	cancel()
+	for scan.Next(&row) {
+		require.Less(t, it.read, 5*scanCancelInterval)
+	}
+	require.Equal(t, context.Canceled, scan.Interrupted())
+	require.False(t, scan.Next(&row))
+}
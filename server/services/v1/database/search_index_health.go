@@ -0,0 +1,435 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"github.com/tigrisdata/tigris/store/search"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// searchSyncs tracks how the writes of the collections are applied to their search indexes by this server, keyed by
+// searchSyncKey.
+var searchSyncs sync.Map
+
+func searchSyncKey(tenant *metadata.Tenant, dbId uint32, collId uint32) string {
+	var nsId uint32
+	if tenant != nil {
+		nsId = tenant.GetNamespace().Id()
+	}
+
+	return fmt.Sprintf("%d/%d/%d", nsId, dbId, collId)
+}
+
+func searchSync(key string) *searchSyncState {
+	state, _ := searchSyncs.LoadOrStore(key, &searchSyncState{})
+	return state.(*searchSyncState)
+}
+
+type searchSyncState struct {
+	sync.Mutex
+
+	lastSyncedAt time.Time
+	pending      int64
+	rebuild      *searchRebuildProgress
+}
+
+func (s *searchSyncState) synced() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.lastSyncedAt = time.Now()
+}
+
+func (s *searchSyncState) addPending(count int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.pending += count
+	if s.pending < 0 {
+		// the writes indexed by a rebuild are not pending anymore
+		s.pending = 0
+	}
+}
+
+// startRebuild returns false if a rebuild of the search index is already running on this server.
+func (s *searchSyncState) startRebuild(progress *searchRebuildProgress) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.rebuild != nil && s.rebuild.running() {
+		return false
+	}
+
+	s.rebuild = progress
+	return true
+}
+
+// rebuilt resets the pending writes, the writes that were pending when the rebuild started are indexed by it.
+func (s *searchSyncState) rebuilt(pending int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.pending -= pending
+	if s.pending < 0 {
+		s.pending = 0
+	}
+	s.lastSyncedAt = time.Now()
+}
+
+func (s *searchSyncState) status(resp *api.SearchIndexStatusResponse) {
+	s.Lock()
+	defer s.Unlock()
+
+	resp.Pending = s.pending
+	if !s.lastSyncedAt.IsZero() {
+		resp.LastSyncedAt = s.lastSyncedAt.UTC().Format(time.RFC3339)
+	}
+	if s.rebuild != nil {
+		resp.Rebuild = s.rebuild.status()
+	}
+}
+
+type searchRebuildProgress struct {
+	sync.Mutex
+
+	state     string
+	processed int64
+	total     int64
+	err       string
+	startedAt time.Time
+	updatedAt time.Time
+}
+
+func newSearchRebuildProgress(total int64) *searchRebuildProgress {
+	return &searchRebuildProgress{
+		state:     api.SearchIndexRebuildRecreate,
+		total:     total,
+		startedAt: time.Now(),
+		updatedAt: time.Now(),
+	}
+}
+
+func (p *searchRebuildProgress) running() bool {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.state != api.SearchIndexRebuildReady && p.state != api.SearchIndexRebuildFailed
+}
+
+func (p *searchRebuildProgress) setState(state string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.state = state
+	p.updatedAt = time.Now()
+}
+
+func (p *searchRebuildProgress) add(count int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.processed += int64(count)
+	p.updatedAt = time.Now()
+}
+
+func (p *searchRebuildProgress) fail(err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.state = api.SearchIndexRebuildFailed
+	p.err = err.Error()
+	p.updatedAt = time.Now()
+}
+
+func (p *searchRebuildProgress) status() *api.SearchIndexRebuildStatus {
+	p.Lock()
+	defer p.Unlock()
+
+	status := &api.SearchIndexRebuildStatus{
+		State:     p.state,
+		Processed: p.processed,
+		Error:     p.err,
+		StartedAt: p.startedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: p.updatedAt.UTC().Format(time.RFC3339),
+	}
+
+	switch {
+	case p.state == api.SearchIndexRebuildReady:
+		status.Percent = 100
+	case p.total > 0:
+		// the row count of the collection is approximate, the rebuild is not complete until it is ready
+		status.Percent = 99
+		if percent := p.processed * 100 / p.total; percent < 99 {
+			status.Percent = int32(percent)
+		}
+	}
+
+	return status
+}
+
+// SearchIndexStatusRunner compares the number of the documents in the search index of a collection with the number of
+// the documents in the collection.
+type SearchIndexStatusRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeCollectionRequest
+}
+
+func (runner *SearchIndexStatusRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, coll, err := runner.getDBAndCollection(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	resp := &api.SearchIndexStatusResponse{Collection: coll.Name}
+	stats, err := tenant.CollectionSize(ctx, db, coll)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	resp.Documents = stats.RowCount
+
+	searchColl, err := runner.searchStore.DescribeCollection(ctx, coll.ImplicitSearchIndex.StoreIndexName())
+	if err != nil && !search.IsErrNotFound(err) {
+		return Response{}, ctx, err
+	}
+	if searchColl != nil && searchColl.NumDocuments != nil {
+		resp.SearchDocuments = *searchColl.NumDocuments
+	}
+
+	resp.Drift = resp.Documents - resp.SearchDocuments
+	searchSync(searchSyncKey(tenant, db.Id(), coll.Id)).status(resp)
+	resp.InSync = resp.Drift == 0 && resp.Pending == 0
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
+
+// SearchIndexRebuildRunner rebuilds the search index of a collection, the progress is passed to the notify function
+// after every step of the rebuild.
+type SearchIndexRebuildRunner struct {
+	*BaseQueryRunner
+
+	req    *api.BuildCollectionSearchIndexRequest
+	notify func(*api.SearchIndexRebuildStatus) error
+}
+
+func (runner *SearchIndexRebuildRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, coll, err := runner.getDBAndCollection(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetCollection(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	var total int64
+	if stats, err := tenant.CollectionSize(ctx, db, coll); err == nil {
+		total = stats.RowCount
+	}
+
+	state := searchSync(searchSyncKey(tenant, db.Id(), coll.Id))
+	rebuilder := NewSearchIndexRebuilder(runner.txMgr, runner.searchStore, coll, newSearchRebuildProgress(total), runner.notify)
+	if !state.startRebuild(rebuilder.progress) {
+		return Response{}, ctx, errors.AlreadyExists("search index of collection '%s' is already being rebuilt", coll.Name)
+	}
+
+	state.Lock()
+	pending := state.pending
+	state.Unlock()
+
+	if err = rebuilder.Rebuild(ctx); err != nil {
+		log.Err(err).Msgf("Failed to rebuild the search index of collection '%s'", coll.Name)
+		return Response{}, ctx, err
+	}
+	state.rebuilt(pending)
+
+	return Response{
+		Response: &api.BuildCollectionSearchIndexResponse{
+			Status: OkStatus,
+		},
+	}, ctx, nil
+}
+
+// SearchIndexRebuilder drops the search index of a collection, creates it again and indexes all the documents of the
+// collection in batches, in the primary key order. The writes committed while the rebuild is running are indexed by
+// the SearchIndexer, the rebuild doesn't overwrite the documents they have indexed already.
+type SearchIndexRebuilder struct {
+	txMgr       *transaction.Manager
+	searchStore search.Store
+	coll        *schema.DefaultCollection
+	progress    *searchRebuildProgress
+	notify      func(*api.SearchIndexRebuildStatus) error
+	batchSize   int
+}
+
+func NewSearchIndexRebuilder(txMgr *transaction.Manager, searchStore search.Store, coll *schema.DefaultCollection,
+	progress *searchRebuildProgress, notify func(*api.SearchIndexRebuildStatus) error,
+) *SearchIndexRebuilder {
+	batchSize := config.DefaultConfig.Search.RebuildBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if notify == nil {
+		notify = func(*api.SearchIndexRebuildStatus) error { return nil }
+	}
+
+	return &SearchIndexRebuilder{
+		txMgr:       txMgr,
+		searchStore: searchStore,
+		coll:        coll,
+		progress:    progress,
+		notify:      notify,
+		batchSize:   batchSize,
+	}
+}
+
+func (r *SearchIndexRebuilder) Rebuild(ctx context.Context) error {
+	if err := r.rebuild(ctx); err != nil {
+		r.progress.fail(err)
+		_ = r.notify(r.progress.status())
+		return err
+	}
+
+	r.progress.setState(api.SearchIndexRebuildReady)
+	return r.notify(r.progress.status())
+}
+
+func (r *SearchIndexRebuilder) rebuild(ctx context.Context) error {
+	if err := r.notify(r.progress.status()); err != nil {
+		return err
+	}
+
+	name := r.coll.ImplicitSearchIndex.StoreIndexName()
+	if err := r.searchStore.DropCollection(ctx, name); err != nil && !search.IsErrNotFound(err) {
+		return err
+	}
+	if err := r.searchStore.CreateCollection(ctx, r.coll.ImplicitSearchIndex.StoreSchema); err != nil {
+		return err
+	}
+
+	r.progress.setState(api.SearchIndexRebuildIndexing)
+	var last []byte
+	for {
+		count, next, err := r.batch(ctx, last)
+		if err != nil {
+			return err
+		}
+
+		r.progress.add(count)
+		if err = r.notify(r.progress.status()); err != nil {
+			return err
+		}
+		if next == nil {
+			return nil
+		}
+		last = next
+	}
+}
+
+// batch indexes up to batchSize documents following the primary key after, it returns the last key indexed or nil if
+// there are no more documents.
+func (r *SearchIndexRebuilder) batch(ctx context.Context, after []byte) (int, []byte, error) {
+	tx, err := r.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := createBulkDocsReader(ctx, tx, r.coll.EncodedName, nil, after)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		row   Row
+		last  []byte
+		count int
+		buf   bytes.Buffer
+	)
+	for count < r.batchSize && iter.Next(&row) {
+		if after != nil && bytes.Equal(row.Key, after) {
+			continue
+		}
+
+		key, err := keys.FromBinary(r.coll.EncodedName, row.Key)
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := CreateSearchKey(kv.BuildKey(key.IndexParts()...))
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := PackSearchFields(ctx, row.Data, r.coll, id)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		// the documents are imported as JSON lines, the packed document may end with a newline already
+		buf.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		last = row.Key
+		count++
+	}
+	if err = iter.Interrupted(); err != nil {
+		return 0, nil, err
+	}
+
+	if count > 0 {
+		resp, err := r.searchStore.IndexDocuments(ctx, r.coll.ImplicitSearchIndex.StoreIndexName(), &buf, search.IndexDocumentsOptions{
+			Action:    search.Create,
+			BatchSize: count,
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, res := range resp {
+			// the conflicts are the documents indexed by the writes committed during the rebuild
+			if !res.Success && res.Code != http.StatusConflict {
+				return 0, nil, search.NewSearchError(res.Code, search.ErrCodeUnhandled, res.Error)
+			}
+		}
+	}
+
+	if count < r.batchSize {
+		return count, nil, nil
+	}
+
+	return count, last, nil
+}
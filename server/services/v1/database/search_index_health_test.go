@@ -0,0 +1,161 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/search"
+	tsApi "github.com/tigrisdata/typesense-go/typesense/api"
+)
+
+type rebuildSearchStore struct {
+	search.NoopStore
+
+	dropped  []string
+	created  []string
+	batches  []int
+	conflict bool
+}
+
+func (s *rebuildSearchStore) DropCollection(_ context.Context, name string) error {
+	s.dropped = append(s.dropped, name)
+	return nil
+}
+
+func (s *rebuildSearchStore) CreateCollection(_ context.Context, schema *tsApi.CollectionSchema) error {
+	s.created = append(s.created, schema.Name)
+	return nil
+}
+
+func (s *rebuildSearchStore) IndexDocuments(_ context.Context, _ string, r io.Reader, _ search.IndexDocumentsOptions) ([]search.IndexResp, error) {
+	var resp []search.IndexResp
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if s.conflict {
+			resp = append(resp, search.IndexResp{Code: http.StatusConflict, Error: "already exists"})
+		} else {
+			resp = append(resp, search.IndexResp{Code: http.StatusCreated, Success: true})
+		}
+	}
+	s.batches = append(s.batches, len(resp))
+
+	return resp, scanner.Err()
+}
+
+func TestSearchIndexRebuilder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := setupTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"name": { "type": "string" }
+		},
+		"primary_key": ["id"]
+	}`)).coll
+	coll.EncodedName = []byte("rebuild_t1")
+	coll.ImplicitSearchIndex = schema.NewImplicitSearchIndex("t1", "rebuild_t1", coll.Fields, nil)
+
+	tm := transaction.NewManager(kvStore)
+	require.NoError(t, kvStore.DropTable(ctx, coll.EncodedName))
+	require.NoError(t, kvStore.CreateTable(ctx, coll.EncodedName))
+
+	tx, err := tm.StartTx(ctx)
+	require.NoError(t, err)
+	for id := int64(1); id <= 5; id++ {
+		td := internal.NewTableDataWithTS(internal.NewTimestamp(), nil, []byte(`{"id":1, "name":"n"}`))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, "pkey", id), td, false))
+	}
+	require.NoError(t, tx.Commit(ctx))
+
+	rebuild := func(store *rebuildSearchStore) ([]*api.SearchIndexRebuildStatus, error) {
+		var statuses []*api.SearchIndexRebuildStatus
+		rebuilder := NewSearchIndexRebuilder(tm, store, coll, newSearchRebuildProgress(5), func(s *api.SearchIndexRebuildStatus) error {
+			statuses = append(statuses, s)
+			return nil
+		})
+		rebuilder.batchSize = 2
+
+		return statuses, rebuilder.Rebuild(ctx)
+	}
+
+	t.Run("rebuild", func(t *testing.T) {
+		store := &rebuildSearchStore{}
+		statuses, err := rebuild(store)
+		require.NoError(t, err)
+
+		name := coll.ImplicitSearchIndex.StoreIndexName()
+		require.Equal(t, []string{name}, store.dropped)
+		require.Equal(t, []string{name}, store.created)
+		require.Equal(t, []int{2, 2, 1}, store.batches)
+
+		var states []string
+		var percents []int32
+		for _, s := range statuses {
+			states = append(states, s.State)
+			percents = append(percents, s.Percent)
+		}
+		require.Equal(t, []string{
+			api.SearchIndexRebuildRecreate,
+			api.SearchIndexRebuildIndexing,
+			api.SearchIndexRebuildIndexing,
+			api.SearchIndexRebuildIndexing,
+			api.SearchIndexRebuildReady,
+		}, states)
+		require.Equal(t, []int32{0, 40, 80, 99, 100}, percents)
+		require.Equal(t, int64(5), statuses[len(statuses)-1].Processed)
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		// the documents already indexed by the concurrent writes are not overwritten
+		_, err := rebuild(&rebuildSearchStore{conflict: true})
+		require.NoError(t, err)
+	})
+}
+
+func TestSearchSyncState(t *testing.T) {
+	state := &searchSyncState{}
+	state.addPending(3)
+
+	progress := newSearchRebuildProgress(10)
+	require.True(t, state.startRebuild(progress))
+	require.False(t, state.startRebuild(newSearchRebuildProgress(10)))
+
+	state.addPending(2)
+	progress.setState(api.SearchIndexRebuildReady)
+	state.rebuilt(3)
+
+	resp := &api.SearchIndexStatusResponse{}
+	state.status(resp)
+	require.Equal(t, int64(2), resp.Pending)
+	require.NotEmpty(t, resp.LastSyncedAt)
+	require.Equal(t, api.SearchIndexRebuildReady, resp.Rebuild.State)
+	require.Equal(t, int32(100), resp.Rebuild.Percent)
+
+	require.True(t, state.startRebuild(newSearchRebuildProgress(10)))
+}
@@ -20,15 +20,20 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"time"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/lib/container"
 	"github.com/tigrisdata/tigris/lib/date"
 	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/store/search"
@@ -38,84 +43,213 @@ import (
 
 type TentativeSearchKeysToRemove struct{}
 
+// searchIndexTimeout bounds the search store calls of the writes indexed in the background.
+const searchIndexTimeout = 30 * time.Second
+
+// SearchIndexer applies the writes to the search indexes of the collections once they are committed. The writes are
+// applied by a pool of workers, the writes of a collection always go to the same worker so that they are applied in
+// the commit order. The strong writes wait for their documents to be indexed, the eventual ones return after the
+// commit.
 type SearchIndexer struct {
 	searchStore search.Store
 	tenantMgr   *metadata.TenantManager
+	workers     []chan *searchIndexJob
+}
+
+// searchIndexJob is a write to a search index, prepared with the request context so that only the call to the search
+// store is left to the worker.
+type searchIndexJob struct {
+	ctx       context.Context
+	syncKey   string
+	index     string
+	searchKey string
+	op        string
+	action    search.IndexAction
+	data      []byte
+	// done receives the result of the strong writes, it is nil for the eventual ones.
+	done chan error
 }
 
 func NewSearchIndexer(searchStore search.Store, tenantMgr *metadata.TenantManager) *SearchIndexer {
-	return &SearchIndexer{
+	workers := config.DefaultConfig.Search.IndexingWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	i := &SearchIndexer{
 		searchStore: searchStore,
 		tenantMgr:   tenantMgr,
+		workers:     make([]chan *searchIndexJob, workers),
+	}
+	for n := range i.workers {
+		i.workers[n] = make(chan *searchIndexJob, config.DefaultConfig.Search.IndexingQueueSize)
+		go i.work(i.workers[n])
 	}
+
+	return i
 }
 
-func (i *SearchIndexer) OnPostCommit(ctx context.Context, _ *metadata.Tenant, eventListener kv.EventListener) error {
-	for _, event := range eventListener.GetEvents() {
-		var err error
+func (i *SearchIndexer) OnPostCommit(ctx context.Context, tenant *metadata.Tenant, eventListener kv.EventListener) error {
+	// the header is validated before the commit
+	requested, _ := request.GetSearchConsistency(ctx)
 
+	var strong []*searchIndexJob
+	for _, event := range eventListener.GetEvents() {
 		db, collName, ok := i.tenantMgr.DecodeTableName(event.Table)
 		if !ok {
 			continue
 		}
 
 		collection := db.GetCollection(collName)
-		if collection == nil || event.Key == nil {
-			// event.Key == nil if event comes from drop table
+		if collection == nil || event.Key == nil || event.Op == kv.OutboxEvent {
+			// event.Key == nil if event comes from drop table, the outbox events are not documents
 			continue
 		}
 
-		searchKey, err := CreateSearchKey(event.Key)
+		syncKey := searchSyncKey(tenant, db.Id(), collection.Id)
+		job, err := i.prepare(ctx, collection, event)
 		if err != nil {
+			searchSync(syncKey).addPending(1)
 			return err
 		}
+		job.syncKey = syncKey
 
-		searchIndex := collection.GetImplicitSearchIndex()
-		if searchIndex == nil {
-			return fmt.Errorf("implicit search index not found")
+		if searchConsistency(requested, collection) == api.SearchConsistencyStrong {
+			job.ctx = ctx
+			job.done = make(chan error, 1)
+			strong = append(strong, job)
 		}
-		if event.Op == kv.DeleteEvent {
-			if err = i.searchStore.DeleteDocument(ctx, searchIndex.StoreIndexName(), searchKey); err != nil {
-				if !search.IsErrNotFound(err) {
-					return err
-				}
-				return nil
+
+		searchSync(syncKey).addPending(1)
+		i.workers[searchWorker(syncKey, len(i.workers))] <- job
+	}
+
+	var err error
+	for _, job := range strong {
+		select {
+		case jobErr := <-job.done:
+			if err == nil {
+				err = jobErr
 			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// searchConsistency returns the search consistency of a write, the one requested overrides the one of the collection
+// which overrides the server default.
+func searchConsistency(requested string, collection *schema.DefaultCollection) string {
+	if len(requested) > 0 {
+		return requested
+	}
+	if len(collection.SearchConsistency) > 0 {
+		return collection.SearchConsistency
+	}
+	if config.DefaultConfig.Search.Consistency == api.SearchConsistencyEventual {
+		return api.SearchConsistencyEventual
+	}
+
+	return api.SearchConsistencyStrong
+}
+
+func searchWorker(syncKey string, workers int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(syncKey))
+
+	return int(h.Sum32() % uint32(workers))
+}
+
+func (i *SearchIndexer) work(jobs chan *searchIndexJob) {
+	for job := range jobs {
+		ctx, cancel := job.ctx, func() {}
+		if job.done == nil {
+			// the request of an eventual write may be complete already
+			ctx, cancel = context.WithTimeout(context.Background(), searchIndexTimeout)
+		}
+
+		err := i.apply(ctx, job)
+		cancel()
+
+		state := searchSync(job.syncKey)
+		if err == nil {
+			state.addPending(-1)
+			state.synced()
+		}
+
+		if job.done != nil {
+			job.done <- err
 		} else {
-			var action search.IndexAction
-			switch event.Op {
-			case kv.InsertEvent:
-				action = search.Create
-			case kv.ReplaceEvent:
-				action = search.Replace
-			case kv.UpdateEvent:
-				action = search.Update
-			}
+			log.E(err)
+		}
+	}
+}
 
-			searchData, err := PackSearchFields(ctx, event.Data, collection, searchKey)
-			if err != nil {
-				return err
-			}
+// prepare converts the event to the write of its document to the search index of the collection.
+func (*SearchIndexer) prepare(ctx context.Context, collection *schema.DefaultCollection, event *kv.Event) (*searchIndexJob, error) {
+	searchKey, err := CreateSearchKey(event.Key)
+	if err != nil {
+		return nil, err
+	}
 
-			reader := bytes.NewReader(searchData)
-			var resp []search.IndexResp
-			if resp, err = i.searchStore.IndexDocuments(ctx, searchIndex.StoreIndexName(), reader, search.IndexDocumentsOptions{
-				Action:    action,
-				BatchSize: 1,
-			}); err != nil {
-				return err
-			}
-			if len(resp) == 1 && !resp[0].Success {
-				return search.NewSearchError(resp[0].Code, search.ErrCodeUnhandled, resp[0].Error)
-			}
+	searchIndex := collection.GetImplicitSearchIndex()
+	if searchIndex == nil {
+		return nil, fmt.Errorf("implicit search index not found")
+	}
+
+	job := &searchIndexJob{
+		index:     searchIndex.StoreIndexName(),
+		searchKey: searchKey,
+		op:        event.Op,
+	}
+	if event.Op == kv.DeleteEvent {
+		return job, nil
+	}
+
+	switch event.Op {
+	case kv.InsertEvent:
+		job.action = search.Create
+	case kv.ReplaceEvent:
+		job.action = search.Replace
+	case kv.UpdateEvent:
+		job.action = search.Update
+	}
+
+	if job.data, err = PackSearchFields(ctx, event.Data, collection, searchKey); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (i *SearchIndexer) apply(ctx context.Context, job *searchIndexJob) error {
+	if job.op == kv.DeleteEvent {
+		if err := i.searchStore.DeleteDocument(ctx, job.index, job.searchKey); err != nil && !search.IsErrNotFound(err) {
+			return err
 		}
+		return nil
+	}
+
+	resp, err := i.searchStore.IndexDocuments(ctx, job.index, bytes.NewReader(job.data), search.IndexDocumentsOptions{
+		Action:    job.action,
+		BatchSize: 1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp) == 1 && !resp[0].Success {
+		return search.NewSearchError(resp[0].Code, search.ErrCodeUnhandled, resp[0].Error)
 	}
 
 	return nil
 }
 
-func (*SearchIndexer) OnPreCommit(context.Context, *metadata.Tenant, transaction.Tx, kv.EventListener) error {
-	return nil
+// OnPreCommit rejects the invalid search consistency before the writes are committed.
+func (*SearchIndexer) OnPreCommit(ctx context.Context, _ *metadata.Tenant, _ transaction.Tx, _ kv.EventListener) error {
+	_, err := request.GetSearchConsistency(ctx)
+	return err
 }
 
 func (*SearchIndexer) OnRollback(context.Context, *metadata.Tenant, kv.EventListener) {}
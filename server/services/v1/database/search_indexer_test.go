@@ -26,9 +26,12 @@ import (
 	"github.com/buger/jsonparser"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/lib/container"
 	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/search"
 	"github.com/tigrisdata/tigris/util"
 )
 
@@ -386,3 +389,50 @@ func BenchmarkJSONSet(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestSearchConsistency(t *testing.T) {
+	coll := &schema.DefaultCollection{}
+	require.Equal(t, api.SearchConsistencyStrong, searchConsistency("", coll))
+	require.Equal(t, api.SearchConsistencyEventual, searchConsistency(api.SearchConsistencyEventual, coll))
+
+	coll.SearchConsistency = api.SearchConsistencyEventual
+	require.Equal(t, api.SearchConsistencyEventual, searchConsistency("", coll))
+	require.Equal(t, api.SearchConsistencyStrong, searchConsistency(api.SearchConsistencyStrong, coll))
+
+	defer func(consistency string) { config.DefaultConfig.Search.Consistency = consistency }(config.DefaultConfig.Search.Consistency)
+	config.DefaultConfig.Search.Consistency = api.SearchConsistencyEventual
+	require.Equal(t, api.SearchConsistencyEventual, searchConsistency("", &schema.DefaultCollection{}))
+}
+
+func TestSearchIndexerWorkers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := &rebuildSearchStore{}
+	indexer := NewSearchIndexer(store, nil)
+	state := searchSync("test/search/indexer")
+
+	// the eventual writes are applied in the background, the strong ones are waited for
+	state.addPending(2)
+	worker := indexer.workers[searchWorker("test/search/indexer", len(indexer.workers))]
+	worker <- &searchIndexJob{syncKey: "test/search/indexer", index: "t1", action: search.Create, data: []byte(`{"id":"1"}`)}
+	strong := &searchIndexJob{ctx: ctx, syncKey: "test/search/indexer", index: "t1", action: search.Create, data: []byte(`{"id":"2"}`), done: make(chan error, 1)}
+	worker <- strong
+	require.NoError(t, <-strong.done)
+	require.Equal(t, []int{1, 1}, store.batches)
+
+	resp := &api.SearchIndexStatusResponse{}
+	state.status(resp)
+	require.Equal(t, int64(0), resp.Pending)
+	require.NotEmpty(t, resp.LastSyncedAt)
+
+	// the failed writes stay pending
+	store.conflict = true
+	state.addPending(1)
+	strong = &searchIndexJob{ctx: ctx, syncKey: "test/search/indexer", index: "t1", action: search.Create, data: []byte(`{"id":"2"}`), done: make(chan error, 1)}
+	worker <- strong
+	require.Error(t, <-strong.done)
+
+	state.status(resp)
+	require.Equal(t, int64(1), resp.Pending)
+}
@@ -0,0 +1,147 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// SearchKeyRequest is used to manage the search-only keys of a project.
+type SearchKeyRequest struct {
+	Project   string
+	Id        string
+	Name      string
+	Indexes   []string
+	QPS       int
+	ExpiresAt int64
+}
+
+// SearchKeyQueryRunner manages the search-only keys of a project. The keys can only run the search queries against the
+// collections of the main database and the search indexes they are created for.
+type SearchKeyQueryRunner struct {
+	*BaseQueryRunner
+
+	createReq *SearchKeyRequest
+	listReq   *SearchKeyRequest
+	deleteReq *SearchKeyRequest
+
+	keys   []metadata.SearchKey
+	secret string
+}
+
+func (runner *SearchKeyQueryRunner) SetCreateSearchKeyReq(req *SearchKeyRequest) {
+	runner.createReq = req
+}
+
+func (runner *SearchKeyQueryRunner) SetListSearchKeysReq(req *SearchKeyRequest) {
+	runner.listReq = req
+}
+
+func (runner *SearchKeyQueryRunner) SetDeleteSearchKeyReq(req *SearchKeyRequest) {
+	runner.deleteReq = req
+}
+
+// Keys returns the search keys read or written by the last run.
+func (runner *SearchKeyQueryRunner) Keys() []metadata.SearchKey {
+	return runner.keys
+}
+
+// Secret returns the secret of the key created by the last run.
+func (runner *SearchKeyQueryRunner) Secret() string {
+	return runner.secret
+}
+
+func (runner *SearchKeyQueryRunner) create(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.createReq
+
+	project, err := tenant.GetProject(req.Project)
+	if err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	if len(req.Indexes) == 0 {
+		return Response{}, ctx, errors.InvalidArgument("search key requires at least one collection or search index")
+	}
+	if req.QPS < 0 {
+		return Response{}, ctx, errors.InvalidArgument("search key qps can't be negative")
+	}
+
+	for _, index := range req.Indexes {
+		if project.GetMainDatabase().GetCollection(index) != nil {
+			continue
+		}
+		if _, ok := project.GetSearch().GetIndex(index); ok {
+			continue
+		}
+		return Response{}, ctx, errors.NotFound("collection or search index doesn't exist '%s'", index)
+	}
+
+	key, secret, err := metadata.NewSearchKey(req.Name, req.Indexes, req.QPS, req.ExpiresAt)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	var currentSub string
+	if runner.accessToken != nil {
+		currentSub = runner.accessToken.Sub
+	}
+
+	if err = tenant.CreateSearchKey(ctx, tx, req.Project, key, currentSub); err != nil {
+		return Response{}, ctx, err
+	}
+	runner.keys = []metadata.SearchKey{*key}
+	runner.secret = metadata.EncodeSearchKey(tenant.GetNamespace().StrId(), req.Project, key.Id, secret)
+
+	return Response{Status: CreatedStatus}, ctx, nil
+}
+
+func (runner *SearchKeyQueryRunner) list(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if _, err := tenant.GetProject(runner.listReq.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	keys, err := tenant.ListSearchKeys(ctx, tx, runner.listReq.Project)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.keys = keys
+
+	return Response{}, ctx, nil
+}
+
+func (runner *SearchKeyQueryRunner) delete(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if err := tenant.DeleteSearchKey(ctx, tx, runner.deleteReq.Project, runner.deleteReq.Id); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+func (runner *SearchKeyQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	switch {
+	case runner.createReq != nil:
+		return runner.create(ctx, tx, tenant)
+	case runner.listReq != nil:
+		return runner.list(ctx, tx, tenant)
+	case runner.deleteReq != nil:
+		return runner.delete(ctx, tx, tenant)
+	}
+
+	return Response{}, ctx, errors.Unknown("unknown request path")
+}
@@ -0,0 +1,201 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	gosort "sort"
+
+	"github.com/buger/jsonparser"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/store/kv"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// maxSearchPageSize is the largest page of the results returned by the search store.
+const maxSearchPageSize = 250
+
+// canPushDownToSearch returns true if the filter of the read is served by the search index as well as its sort, the
+// whole read is then sent to the search store.
+func canPushDownToSearch(options readerOptions) bool {
+	return config.DefaultConfig.Search.IsReadEnabled() && (options.filter.None() || options.filter.IsSearchIndexed())
+}
+
+// searchIndexStale returns true if this server has writes of the collection which are not applied to its search index
+// yet or if the search index is being rebuilt.
+func searchIndexStale(tenant *metadata.Tenant, db *metadata.Database, coll *schema.DefaultCollection) bool {
+	state := searchSync(searchSyncKey(tenant, db.Id(), coll.Id))
+
+	state.Lock()
+	defer state.Unlock()
+
+	return state.pending > 0 || (state.rebuild != nil && state.rebuild.running())
+}
+
+// useMemSort moves a read sorted by the search index to a scan of the table which rows matching the filter are sorted
+// in memory. It returns false if the sort can't be done in memory, the sorts on the distance of a geopoint field are
+// only served by the search index.
+func useMemSort(options *readerOptions, req *api.ReadRequest, coll *schema.DefaultCollection) bool {
+	ordering, err := sort.UnmarshalSort(req.Sort)
+	if err != nil || ordering == nil {
+		return false
+	}
+	for _, sf := range *ordering {
+		if sf.GeoDistance != nil {
+			return false
+		}
+		if _, err = coll.GetQueryableField(sf.Name); err != nil {
+			return false
+		}
+	}
+
+	options.inMemoryStore = false
+	options.memSort = ordering
+	options.tablePlan = &filter.TableScanPlan{Table: coll.EncodedName}
+
+	return true
+}
+
+// searchPages returns the size of the pages and the first page of the search query of the read. The rows skipped by
+// the read which fill whole pages are not read from the search store, their number is returned as well.
+func searchPages(ctx context.Context, req *api.ReadRequest) (int, int32, int64) {
+	limit, skip := req.GetOptions().GetLimit(), req.GetOptions().GetSkip()
+	if limit == 0 && request.IsAcceptApplicationJSON(ctx) {
+		limit = defaultReadLimit
+	}
+
+	pageSize := defaultPerPage
+	if limit > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	} else if limit > int64(pageSize) {
+		pageSize = int(limit)
+	}
+
+	// the rows of a read pinned to a schema version are filtered after they are read
+	if version, err := request.GetSchemaVersion(ctx); err != nil || version > 0 || skip < int64(pageSize) {
+		return pageSize, defaultPageNo, 0
+	}
+
+	pages := skip / int64(pageSize)
+	return pageSize, defaultPageNo + int32(pages), pages * int64(pageSize)
+}
+
+// sortRows reads the rows of the iterator and returns them sorted on the ordering. The read fails if there are more
+// rows than the search sort fallback limit.
+func sortRows(iter Iterator, coll *schema.DefaultCollection, ordering *sort.Ordering, collation *value.Collation) (Iterator, error) {
+	fields := make([]*schema.QueryableField, len(*ordering))
+	for i, sf := range *ordering {
+		cf, err := coll.GetQueryableField(sf.Name)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = cf
+	}
+
+	limit := config.DefaultConfig.Search.SortFallbackLimit
+	var (
+		row  Row
+		rows []Row
+		keys [][]value.Value
+	)
+	for iter.Next(&row) {
+		if limit > 0 && len(rows) >= limit {
+			return nil, errors.ResourceExhausted("the read matches more than %d documents to sort while its sort "+
+				"can't be served by the search index, narrow the read with a filter", limit)
+		}
+
+		values := make([]value.Value, len(fields))
+		for i, cf := range fields {
+			values[i] = sortValue(row.Data.RawData, cf, collation)
+		}
+
+		rows = append(rows, row)
+		keys = append(keys, values)
+	}
+	if err := iter.Interrupted(); err != nil {
+		if err == kv.ErrTransactionMaxDurationReached {
+			return nil, errors.Aborted("the read exceeded the transaction time limit while sorting it in memory, " +
+				"narrow the read with a filter")
+		}
+		return nil, err
+	}
+
+	gosort.Stable(&sortedRows{rows: rows, keys: keys, ordering: *ordering})
+
+	return &sliceIterator{rows: rows}, nil
+}
+
+// sortValue returns the value of the field in the document, nil if it is missing, null or it can't be compared.
+func sortValue(doc []byte, cf *schema.QueryableField, collation *value.Collation) value.Value {
+	raw, dtp, _, err := jsonparser.Get(doc, cf.KeyPath()...)
+	if err != nil || dtp == jsonparser.Null {
+		return nil
+	}
+
+	v, err := value.NewValueUsingCollation(cf.DataType, raw, collation)
+	if err != nil {
+		return nil
+	}
+	if _, ok := v.(*value.NullValue); ok {
+		return nil
+	}
+
+	return v
+}
+
+type sortedRows struct {
+	rows     []Row
+	keys     [][]value.Value
+	ordering sort.Ordering
+}
+
+func (s *sortedRows) Len() int { return len(s.rows) }
+
+func (s *sortedRows) Swap(i, j int) {
+	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func (s *sortedRows) Less(i, j int) bool {
+	for f, sf := range s.ordering {
+		a, b := s.keys[i][f], s.keys[j][f]
+		switch {
+		case a == nil && b == nil:
+			continue
+		case a == nil:
+			return sf.MissingValuesFirst
+		case b == nil:
+			return !sf.MissingValuesFirst
+		}
+
+		cmp, err := a.CompareTo(b)
+		if err != nil || cmp == 0 {
+			continue
+		}
+		if sf.Ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+var pushdownSchema = []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"name": { "type": "string", "searchIndex": true, "sort": true },
+		"age": { "type": "integer", "searchIndex": true, "sort": true },
+		"code": { "type": "string" }
+	},
+	"primary_key": ["id"]
+}`)
+
+func pushdownCollection(t *testing.T) *schema.DefaultCollection {
+	factory, err := schema.NewFactoryBuilder(true).Build("t1", pushdownSchema)
+	require.NoError(t, err)
+
+	coll, err := schema.NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	return coll
+}
+
+func TestSearchPushdownPlan(t *testing.T) {
+	readEnabled := config.DefaultConfig.SecondaryIndex.ReadEnabled
+	config.DefaultConfig.SecondaryIndex.ReadEnabled = false
+	defer func() { config.DefaultConfig.SecondaryIndex.ReadEnabled = readEnabled }()
+
+	coll := pushdownCollection(t)
+	runner := &BaseQueryRunner{}
+
+	t.Run("search filter", func(t *testing.T) {
+		options, err := runner.buildReaderOptions(&api.ReadRequest{
+			Filter: []byte(`{"age": {"$gt": 20}}`),
+			Sort:   []byte(`[{"name": "$asc"}]`),
+		}, coll)
+		require.NoError(t, err)
+		require.True(t, options.inMemoryStore)
+		require.Nil(t, options.memSort)
+		require.Nil(t, options.tablePlan)
+	})
+
+	t.Run("no filter", func(t *testing.T) {
+		options, err := runner.buildReaderOptions(&api.ReadRequest{
+			Filter: []byte(`{}`),
+			Sort:   []byte(`[{"age": "$desc"}]`),
+		}, coll)
+		require.NoError(t, err)
+		require.True(t, options.inMemoryStore)
+		require.Nil(t, options.memSort)
+	})
+
+	t.Run("filter not search indexed", func(t *testing.T) {
+		options, err := runner.buildReaderOptions(&api.ReadRequest{
+			Filter: []byte(`{"code": "a"}`),
+			Sort:   []byte(`[{"name": "$asc"}]`),
+		}, coll)
+		require.NoError(t, err)
+		require.False(t, options.inMemoryStore)
+		require.NotNil(t, options.tablePlan)
+		require.Equal(t, &sort.Ordering{{Name: "name", Ascending: true}}, options.memSort)
+		require.Equal(t, "full_scan sorted_in_memory", readPlan(options))
+	})
+}
+
+func TestSearchPages(t *testing.T) {
+	for _, c := range []struct {
+		limit, skip int64
+		pageSize    int
+		firstPage   int32
+		skipped     int64
+	}{
+		{0, 0, defaultPerPage, 1, 0},
+		{10, 15, defaultPerPage, 1, 0},
+		{10, 45, defaultPerPage, 3, 40},
+		{100, 250, 100, 3, 200},
+		{1000, 10, maxSearchPageSize, 1, 0},
+	} {
+		pageSize, firstPage, skipped := searchPages(context.Background(), &api.ReadRequest{
+			Options: &api.ReadRequestOptions{Limit: c.limit, Skip: c.skip},
+		})
+		require.Equal(t, c.pageSize, pageSize, "limit %d skip %d", c.limit, c.skip)
+		require.Equal(t, c.firstPage, firstPage, "limit %d skip %d", c.limit, c.skip)
+		require.Equal(t, c.skipped, skipped, "limit %d skip %d", c.limit, c.skip)
+	}
+}
+
+func TestSortRows(t *testing.T) {
+	coll := pushdownCollection(t)
+
+	rows := func() []Row {
+		var rows []Row
+		for _, doc := range []string{
+			`{"id": 1, "name": "b", "age": 30}`,
+			`{"id": 2, "name": "a", "age": 30}`,
+			`{"id": 3, "age": 20}`,
+			`{"id": 4, "name": "c", "age": 40}`,
+		} {
+			rows = append(rows, Row{Data: internal.NewTableData([]byte(doc))})
+		}
+		return rows
+	}
+
+	ids := func(it Iterator) []int64 {
+		var (
+			row Row
+			ids []int64
+		)
+		for it.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, it.Interrupted())
+		return ids
+	}
+
+	for _, c := range []struct {
+		ordering sort.Ordering
+		expected []int64
+	}{
+		{sort.Ordering{{Name: "name", Ascending: true}}, []int64{2, 1, 4, 3}},
+		{sort.Ordering{{Name: "name", Ascending: true, MissingValuesFirst: true}}, []int64{3, 2, 1, 4}},
+		{sort.Ordering{{Name: "name", Ascending: false}}, []int64{4, 1, 2, 3}},
+		{sort.Ordering{{Name: "age", Ascending: false}, {Name: "name", Ascending: true}}, []int64{4, 2, 1, 3}},
+	} {
+		it, err := sortRows(&sliceIterator{rows: rows()}, coll, &c.ordering, nil)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, ids(it), c.ordering)
+	}
+
+	limit := config.DefaultConfig.Search.SortFallbackLimit
+	config.DefaultConfig.Search.SortFallbackLimit = 3
+	defer func() { config.DefaultConfig.Search.SortFallbackLimit = limit }()
+
+	_, err := sortRows(&sliceIterator{rows: rows()}, coll, &sort.Ordering{{Name: "age", Ascending: true}}, nil)
+	require.ErrorContains(t, err, "more than 3 documents")
+}
@@ -35,38 +35,38 @@ const (
 type page struct {
 	idx  int
 	cap  int
-	hits []*tsearch.Hit
+	rows []*tsearch.ResultRow
 }
 
 func newPage(c int) *page {
 	return &page{
 		idx:  0,
 		cap:  c,
-		hits: []*tsearch.Hit{},
+		rows: []*tsearch.ResultRow{},
 	}
 }
 
-func (p *page) append(h *tsearch.Hit) bool {
+func (p *page) append(row *tsearch.ResultRow) bool {
 	if !p.hasCapacity() {
 		return false
 	}
 
-	p.hits = append(p.hits, h)
+	p.rows = append(p.rows, row)
 	return p.hasCapacity()
 }
 
 func (p *page) hasCapacity() bool {
-	return len(p.hits) < p.cap
+	return len(p.rows) < p.cap
 }
 
 // readRow should be used to read search data because this is the single point where we unpack search fields, apply
-// filter and then pack the document into bytes.
-func (p *page) readRow() map[string]any {
-	for p.idx < len(p.hits) {
-		document := p.hits[p.idx].Document
+// filter and then pack the document into bytes. The row is either a hit or a group of hits in case of group by.
+func (p *page) readRow() *tsearch.ResultRow {
+	for p.idx < len(p.rows) {
+		row := p.rows[p.idx]
 		p.idx++
-		if document != nil {
-			return document
+		if row.Group != nil || (row.Hit != nil && row.Hit.Document != nil) {
+			return row
 		}
 	}
 
@@ -97,30 +97,35 @@ func newPageReader(ctx context.Context, store search.Store, coll *schema.Default
 }
 
 func (p *pageReader) read() error {
+	// the pages are read until the request is canceled or its deadline is exceeded
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
 	result, err := p.store.Search(p.ctx, p.searchIndex.StoreIndexName(), p.query, p.pageNo)
 	if err != nil {
 		return err
 	}
-
-	hits := tsearch.NewResponseFactory(p.query).GetHitsIterator(result)
+	result, ranges := tsearch.SplitFacetRangeResults(p.query, result)
 
 	p.pageNo++
 	pg := newPage(p.query.PageSize)
 
-	for hits.HasMoreHits() {
-		hit, err := hits.Next()
+	response := tsearch.NewResponseFactory(p.query).GetResponse(result)
+	for response.HasMore() {
+		row, err := response.Next()
 		// log and skip to next hit
 		if ulog.E(err) {
 			continue
 		}
-		if !pg.append(hit) {
+		if !pg.append(row) {
 			p.pages = append(p.pages, pg)
 			pg = newPage(p.query.PageSize)
 		}
 	}
 
 	// include the last page in results if it has hits
-	if len(pg.hits) > 0 {
+	if len(pg.rows) > 0 {
 		p.pages = append(p.pages, pg)
 	}
 
@@ -131,6 +136,9 @@ func (p *pageReader) read() error {
 			for field, built := range builder.Build(result[0].FacetCounts) {
 				p.cachedFacets[field] = built
 			}
+			for field, built := range builder.BuildRanges(ranges) {
+				p.cachedFacets[field] = built
+			}
 		}
 	}
 
@@ -166,6 +174,7 @@ type FilterableSearchIterator struct {
 	single     bool
 	last       bool
 	page       *page
+	hit        *tsearch.Hit
 	filter     *filter.WrappedFilter
 	pageReader *pageReader
 	collection *schema.DefaultCollection
@@ -180,47 +189,120 @@ func NewFilterableSearchIterator(collection *schema.DefaultCollection, reader *p
 	}
 }
 
+// SearchGroup is a group of the rows that share the same values of the group by fields.
+type SearchGroup struct {
+	Keys []string
+	Rows []*Row
+
+	hits []*tsearch.Hit
+}
+
+// hit returns the search hit of the i-th row of the group.
+func (g *SearchGroup) hit(i int) *tsearch.Hit {
+	return g.hits[i]
+}
+
+// Next fills the next row matching the filter, it skips the groups which are read through NextGroup.
 func (it *FilterableSearchIterator) Next(row *Row) bool {
-	if it.err != nil {
-		return false
-	}
+	for resultRow := it.nextResultRow(); resultRow != nil; resultRow = it.nextResultRow() {
+		if resultRow.Hit == nil {
+			continue
+		}
 
-	for {
-		if it.page == nil {
-			if it.last, it.page, it.err = it.pageReader.next(); it.err != nil || it.page == nil {
-				return false
-			}
+		if it.readHit(resultRow.Hit, row) {
+			it.hit = resultRow.Hit
+			return true
+		}
+		if it.err != nil {
+			return false
 		}
+	}
 
-		if doc := it.page.readRow(); doc != nil {
-			var searchKey string
-			if searchKey, row.Data, doc, it.err = UnpackSearchFields(doc, it.collection); it.err != nil {
-				return false
-			}
-			row.Key = []byte(searchKey)
+	return false
+}
+
+// NextGroup fills the next group of a group by query with the rows of the group matching the filter.
+func (it *FilterableSearchIterator) NextGroup(group *SearchGroup) bool {
+	for resultRow := it.nextResultRow(); resultRow != nil; resultRow = it.nextResultRow() {
+		if resultRow.Group == nil {
+			continue
+		}
 
-			// now apply the filter
-			if !it.filter.MatchesDoc(doc) {
+		group.Keys, group.Rows, group.hits = resultRow.Group.Keys, nil, nil
+		for _, hit := range resultRow.Group.Hits {
+			if hit == nil || hit.Document == nil {
 				continue
 			}
 
-			var rawData []byte
-			// marshal the doc as bytes
-			if rawData, it.err = util.MapToJSON(doc); it.err != nil {
+			row := &Row{}
+			if it.readHit(hit, row) {
+				group.Rows = append(group.Rows, row)
+				group.hits = append(group.hits, hit)
+			} else if it.err != nil {
 				return false
 			}
-			row.Data.RawData = rawData
-			return true
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// nextResultRow returns the next hit or group from the pages, nil if there are no more results or reading failed.
+func (it *FilterableSearchIterator) nextResultRow() *tsearch.ResultRow {
+	if it.err != nil {
+		return nil
+	}
+
+	for {
+		if it.page == nil {
+			if it.last, it.page, it.err = it.pageReader.next(); it.err != nil || it.page == nil {
+				return nil
+			}
+		}
+
+		if resultRow := it.page.readRow(); resultRow != nil {
+			return resultRow
 		}
 
 		if it.last || it.single {
-			return false
+			return nil
 		}
 
 		it.page = nil
 	}
 }
 
+// readHit unpacks the hit into the row, returns false if the document doesn't match the filter or unpacking failed.
+func (it *FilterableSearchIterator) readHit(hit *tsearch.Hit, row *Row) bool {
+	var searchKey string
+	var doc map[string]any
+	if searchKey, row.Data, doc, it.err = UnpackSearchFields(hit.Document, it.collection); it.err != nil {
+		return false
+	}
+	row.Key = []byte(searchKey)
+
+	// now apply the filter
+	if !it.filter.MatchesDoc(doc) {
+		return false
+	}
+
+	var rawData []byte
+	// marshal the doc as bytes
+	if rawData, it.err = util.MapToJSON(doc); it.err != nil {
+		return false
+	}
+	row.Data.RawData = rawData
+
+	return true
+}
+
+// lastHit returns the search hit of the last row returned by Next.
+func (it *FilterableSearchIterator) lastHit() *tsearch.Hit {
+	return it.hit
+}
+
 func (it *FilterableSearchIterator) getFacets() map[string]*api.SearchFacet {
 	return it.pageReader.cachedFacets
 }
@@ -258,7 +340,12 @@ func (reader *SearchReader) SinglePageIterator(collection *schema.DefaultCollect
 }
 
 func (reader *SearchReader) Iterator(collection *schema.DefaultCollection, filter *filter.WrappedFilter) *FilterableSearchIterator {
-	pageReader := newPageReader(reader.ctx, reader.store, reader.collection, reader.query, defaultPageNo)
+	return reader.IteratorFrom(collection, filter, defaultPageNo)
+}
+
+// IteratorFrom iterates on the search results starting at the page pageNo.
+func (reader *SearchReader) IteratorFrom(collection *schema.DefaultCollection, filter *filter.WrappedFilter, pageNo int32) *FilterableSearchIterator {
+	pageReader := newPageReader(reader.ctx, reader.store, reader.collection, reader.query, pageNo)
 
 	return NewFilterableSearchIterator(collection, pageReader, filter, false)
 }
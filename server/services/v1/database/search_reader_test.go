@@ -0,0 +1,60 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	tsearch "github.com/tigrisdata/tigris/server/search"
+)
+
+func TestFilterableSearchIterator_NextGroup(t *testing.T) {
+	pg := newPage(10)
+	pg.append(&tsearch.ResultRow{Group: tsearch.NewGroup([]string{"p1"}, []*tsearch.Hit{
+		{Document: map[string]any{"id": "1", "product_id": "p1"}},
+		nil,
+		{Document: map[string]any{"id": "2", "product_id": "p1"}},
+	})})
+	pg.append(&tsearch.ResultRow{Group: tsearch.NewGroup([]string{"p2"}, []*tsearch.Hit{
+		{Document: map[string]any{"id": "3", "product_id": "p2"}},
+	})})
+
+	reader := &pageReader{pages: []*page{pg}}
+	it := NewFilterableSearchIterator(&schema.DefaultCollection{}, reader, filter.NewWrappedFilter(nil), true)
+
+	var group SearchGroup
+	require.True(t, it.NextGroup(&group))
+	require.Equal(t, []string{"p1"}, group.Keys)
+	require.Len(t, group.Rows, 2)
+	require.Equal(t, []byte("1"), group.Rows[0].Key)
+	require.Equal(t, []byte("2"), group.Rows[1].Key)
+	require.JSONEq(t, `{"product_id":"p1"}`, string(group.Rows[1].Data.RawData))
+
+	require.True(t, it.NextGroup(&group))
+	require.Equal(t, []string{"p2"}, group.Keys)
+	require.Len(t, group.Rows, 1)
+
+	require.False(t, it.NextGroup(&group))
+	require.NoError(t, it.Interrupted())
+
+	// the hits of the plain queries are not returned as groups and vice versa
+	pg = newPage(10)
+	pg.append(&tsearch.ResultRow{Hit: &tsearch.Hit{Document: map[string]any{"id": "1"}}})
+	it = NewFilterableSearchIterator(&schema.DefaultCollection{}, &pageReader{pages: []*page{pg}}, filter.NewWrappedFilter(nil), true)
+	require.False(t, it.NextGroup(&group))
+}
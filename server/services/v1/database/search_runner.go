@@ -17,6 +17,7 @@ package database
 import (
 	"context"
 	"math"
+	"time"
 
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
@@ -26,6 +27,8 @@ import (
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	tsearch "github.com/tigrisdata/tigris/server/search"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/tigrisdata/tigris/value"
 )
@@ -42,6 +45,7 @@ type SearchQueryRunner struct {
 // ReadOnly on search query runner is implemented as search queries do not need to be inside a transaction; in fact,
 // there is no need to start any transaction for search queries as they are simply forwarded to the indexing store.
 func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	start := time.Now()
 	reqStatus, reqStatusExists := metrics.RequestStatusFromContext(ctx)
 	if reqStatus != nil && reqStatusExists {
 		reqStatus.SetCollectionSearchType()
@@ -59,6 +63,10 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 		return Response{}, ctx, err
 	}
 
+	if runner.req.Filter, err = applyRowPolicy(ctx, collection, runner.req.Filter); err != nil {
+		return Response{}, ctx, err
+	}
+
 	wrappedF, err := filter.NewFactory(collection.QueryableFields, value.NewCollationFrom(runner.req.Collation)).WrappedFilter(runner.req.Filter)
 	if err != nil {
 		return Response{}, ctx, err
@@ -101,6 +109,26 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 		return Response{}, ctx, err
 	}
 
+	highlight, err := runner.getHighlight(collection)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	typo := runner.req.GetTypoTolerance()
+	if err = typo.Validate(); err != nil {
+		return Response{}, ctx, err
+	}
+
+	groupBy, err := runner.getGroupBy(collection)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	relevance, err := runner.getRelevance(collection, searchFields)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
 	ctx = metrics.UpdateSpanTags(ctx, runner.queryMetrics)
 
 	pageSize := int(runner.req.PageSize)
@@ -117,11 +145,16 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 		Filter(wrappedF).
 		ReadFields(fieldSelection).
 		SortOrder(sortOrder).
+		GroupBy(groupBy).
 		VectorSearch(vecSearch).
+		Highlight(highlight).
+		TypoTolerance(typo).
+		Relevance(relevance).
 		Build()
 	if searchQ.IsQAndVectorBoth() {
 		return Response{}, ctx, errors.InvalidArgument("Currently either full text or vector search is supported")
 	}
+	planning := time.Since(start)
 
 	searchReader := NewSearchReader(ctx, runner.searchStore, collection, searchQ)
 	var iterator *FilterableSearchIterator
@@ -134,33 +167,62 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 		return Response{}, ctx, err
 	}
 
+	var masker *schema.FieldMasker
+	if role, ok := request.GetCallerRole(ctx); ok {
+		masker = collection.NewFieldMasker(role)
+	}
+
+	var returned int64
+	if request.IncludeQueryStats(ctx) {
+		defer func() {
+			setQueryStats(ctx, &api.QueryStats{
+				DocsExamined: iterator.getTotalFound(),
+				DocsReturned: returned,
+				Index:        "search",
+				Time:         newQueryTime(planning, time.Since(start)),
+			})
+		}()
+	}
+
 	pageNo := int32(defaultPageNo)
 	if runner.req.Page > 0 {
 		pageNo = runner.req.Page
 	}
 	for {
 		resp := &api.SearchResponse{}
-		var row Row
-		for iterator.Next(&row) {
-			if searchQ.ReadFields != nil {
-				// apply field selection
-				newValue, err := searchQ.ReadFields.Apply(row.Data.RawData)
-				if ulog.E(err) {
-					return Response{}, ctx, err
+		if searchQ.IsGroupByQuery() {
+			var group SearchGroup
+			for iterator.NextGroup(&group) {
+				grouped := &api.GroupedSearchHits{
+					GroupKeys: group.Keys,
 				}
-				row.Data.RawData = newValue
-			}
+				for i, row := range group.Rows {
+					hit, err := runner.toSearchHit(collection, masker, searchQ, row, group.hit(i))
+					if err != nil {
+						return Response{}, ctx, err
+					}
+					grouped.Hits = append(grouped.Hits, hit)
+				}
+				returned += int64(len(grouped.Hits))
 
-			resp.Hits = append(resp.Hits, &api.SearchHit{
-				Data: row.Data.RawData,
-				Metadata: &api.SearchHitMeta{
-					CreatedAt: row.Data.CreateToProtoTS(),
-					UpdatedAt: row.Data.UpdatedToProtoTS(),
-				},
-			})
+				resp.Group = append(resp.Group, grouped)
+				if len(resp.Group) == pageSize {
+					break
+				}
+			}
+		} else {
+			var row Row
+			for iterator.Next(&row) {
+				hit, err := runner.toSearchHit(collection, masker, searchQ, &row, iterator.lastHit())
+				if err != nil {
+					return Response{}, ctx, err
+				}
 
-			if len(resp.Hits) == pageSize {
-				break
+				resp.Hits = append(resp.Hits, hit)
+				returned++
+				if len(resp.Hits) == pageSize {
+					break
+				}
 			}
 		}
 
@@ -182,7 +244,7 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 		// if no hits, no error, at least one response and break
 		// if some hits, got an error, send current hits and then error (will be zero hits next time)
 		// if some hits, no error, continue to send response
-		if len(resp.Hits) == 0 {
+		if len(resp.Hits) == 0 && len(resp.Group) == 0 {
 			if iterator.Interrupted() != nil {
 				return Response{}, ctx, iterator.Interrupted()
 			}
@@ -201,6 +263,43 @@ func (runner *SearchQueryRunner) ReadOnly(ctx context.Context, tenant *metadata.
 	return Response{}, ctx, nil
 }
 
+// toSearchHit masks the row for the caller, applies the field selection and attaches the metadata of the hit, the
+// relevance of the match is returned so that the hits of different collections can be merged by a multi search.
+func (runner *SearchQueryRunner) toSearchHit(coll *schema.DefaultCollection, masker *schema.FieldMasker, searchQ *qsearch.Query, row *Row, hit *tsearch.Hit) (*api.SearchHit, error) {
+	var err error
+	if masker != nil {
+		if row.Data.RawData, err = masker.Mask(row.Data.RawData); err != nil {
+			return nil, err
+		}
+	}
+
+	if searchQ.ReadFields != nil {
+		// apply field selection
+		newValue, err := searchQ.ReadFields.Apply(row.Data.RawData)
+		if ulog.E(err) {
+			return nil, err
+		}
+		row.Data.RawData = newValue
+	}
+
+	hitMeta := &api.SearchHitMeta{
+		CreatedAt: row.Data.CreateToProtoTS(),
+		UpdatedAt: row.Data.UpdatedToProtoTS(),
+	}
+	if hit != nil {
+		hitMeta.Match = hit.Match
+		if searchQ.IsHighlightQuery() {
+			hitMeta.SetHighlights(runner.hitHighlights(coll, masker, hit.Highlights))
+		}
+		hitMeta.SetGeoDistance(hitGeoDistance(masker, hit.GeoDistance))
+	}
+
+	return &api.SearchHit{
+		Data:     row.Data.RawData,
+		Metadata: hitMeta,
+	}, nil
+}
+
 func (runner *SearchQueryRunner) getSearchFields(coll *schema.DefaultCollection) ([]string, error) {
 	searchFields := runner.req.SearchFields
 	if len(searchFields) == 0 {
@@ -227,6 +326,138 @@ func (runner *SearchQueryRunner) getSearchFields(coll *schema.DefaultCollection)
 	return searchFields, nil
 }
 
+// getHighlight returns the highlight option of the query, nil if the request doesn't ask for highlighting. The
+// highlighted fields must be search indexed.
+func (runner *SearchQueryRunner) getHighlight(coll *schema.DefaultCollection) (*qsearch.Highlight, error) {
+	h := runner.req.GetHighlight()
+	if h == nil {
+		return nil, nil
+	}
+
+	highlight := &qsearch.Highlight{
+		StartTag: h.GetStartTag(),
+		EndTag:   h.GetEndTag(),
+	}
+	for _, hf := range h.Fields {
+		cf, err := coll.GetQueryableField(hf)
+		if err != nil {
+			return nil, err
+		}
+		if !cf.SearchIndexed {
+			return nil, errors.InvalidArgument("`%s` is not a searchable field. Only searchable fields can be highlighted", hf)
+		}
+		highlight.Fields = append(highlight.Fields, cf.InMemoryName())
+	}
+
+	return highlight, nil
+}
+
+// getRelevance returns the relevance tuning of the query, nil if the request doesn't tune it. The weighted fields must
+// be the search fields of the query and the boost fields must be numeric or date-time fields.
+func (runner *SearchQueryRunner) getRelevance(coll *schema.DefaultCollection, searchFields []string) (*qsearch.Relevance, error) {
+	weights, boost := runner.req.GetFieldWeights(), runner.req.GetBoost()
+	if len(weights) == 0 && boost == nil {
+		return nil, nil
+	}
+	if err := api.ValidateFieldWeights(weights); err != nil {
+		return nil, err
+	}
+	if err := boost.Validate(); err != nil {
+		return nil, err
+	}
+
+	isSearchField := make(map[string]bool, len(searchFields))
+	for _, f := range searchFields {
+		isSearchField[f] = true
+	}
+
+	relevance := &qsearch.Relevance{}
+	for name, w := range weights {
+		cf, err := coll.GetQueryableField(name)
+		if err != nil {
+			return nil, err
+		}
+		if !isSearchField[cf.InMemoryName()] {
+			return nil, errors.InvalidArgument("`%s` is not a search field of the query. Only search fields can be weighted", name)
+		}
+		if relevance.FieldWeights == nil {
+			relevance.FieldWeights = make(map[string]int)
+		}
+		relevance.FieldWeights[cf.InMemoryName()] = int(w)
+	}
+
+	if boost != nil {
+		if len(runner.req.Sort) > 0 {
+			return nil, errors.InvalidArgument("boost can't be used with sort")
+		}
+		if len(runner.req.Vector) > 0 {
+			return nil, errors.InvalidArgument("boost is not supported by vector search")
+		}
+
+		relevance.Boost = &qsearch.Boost{Buckets: int(boost.Buckets)}
+		for _, name := range boost.Fields {
+			cf, err := coll.GetQueryableField(name)
+			if err != nil {
+				return nil, err
+			}
+			// the numeric and date-time fields of the collections are sortable in the search store by default
+			if !schema.DefaultSortableType(cf.DataType) || cf.DataType == schema.BoolType {
+				return nil, errors.InvalidArgument("Cannot boost by `%s`. Only numeric and date-time fields can boost", name)
+			}
+			relevance.Boost.Fields = append(relevance.Boost.Fields, cf.InMemoryName())
+		}
+
+		if len(boost.Filter) > 0 {
+			boostF, err := filter.NewFactory(coll.QueryableFields, value.NewCollationFrom(runner.req.Collation)).WrappedFilter(boost.Filter)
+			if err != nil {
+				return nil, err
+			}
+			if !boostF.IsSearchIndexed() {
+				return nil, errors.InvalidArgument("boost filter can only use the search indexed fields")
+			}
+			relevance.Boost.Filter = boostF.SearchFilter()
+		}
+	}
+
+	return relevance, nil
+}
+
+// hitHighlights maps the snippets of the search store back to the field names of the schema and drops the snippets of
+// the fields masked for the caller.
+func (*SearchQueryRunner) hitHighlights(coll *schema.DefaultCollection, masker *schema.FieldMasker, snippets []*api.HighlightSnippet) []*api.HighlightSnippet {
+	highlights := make([]*api.HighlightSnippet, 0, len(snippets))
+	for _, snippet := range snippets {
+		for _, cf := range coll.GetQueryableFields() {
+			if cf.InMemoryName() == snippet.Field {
+				snippet.Field = cf.Name()
+				break
+			}
+		}
+		if masker != nil && masker.IsMasked(snippet.Field) {
+			continue
+		}
+
+		highlights = append(highlights, snippet)
+	}
+
+	return highlights
+}
+
+// hitGeoDistance drops the distances of the geopoint fields masked for the caller.
+func hitGeoDistance(masker *schema.FieldMasker, distance map[string]int64) map[string]int64 {
+	if masker == nil {
+		return distance
+	}
+
+	for f := range distance {
+		if masker.IsMasked(f) {
+			delete(distance, f)
+		}
+	}
+
+	return distance
+}
+
 func (runner *SearchQueryRunner) getFacetFields(coll *schema.DefaultCollection) (qsearch.Facets, error) {
 	facets, err := qsearch.UnmarshalFacet(runner.req.Facet)
 	if err != nil {
@@ -238,10 +469,19 @@ func (runner *SearchQueryRunner) getFacetFields(coll *schema.DefaultCollection)
 		if err != nil {
 			return qsearch.Facets{}, err
 		}
-		if !cf.Faceted {
+		if ff.IsRange() {
+			// the numeric and date-time fields of the collections are always indexed in the search store
+			if err = ff.ValidateRanges(ff.Name, cf.DataType); err != nil {
+				return qsearch.Facets{}, err
+			}
+			facets.Fields[i].RangeStats = cf.Faceted || schema.DefaultFacetableType(cf.DataType)
+		} else if !cf.Faceted {
 			return qsearch.Facets{}, errors.InvalidArgument(
 				"Cannot generate facets for `%s`. Enable faceting on this field", ff.Name)
 		}
+		if err = ff.ValidateQuery(ff.Name, cf.DataType, cf.SubType); err != nil {
+			return qsearch.Facets{}, err
+		}
 		if cf.InMemoryName() != cf.Name() {
 			facets.Fields[i].Name = cf.InMemoryName()
 		}
@@ -283,6 +523,37 @@ func (runner *SearchQueryRunner) getFieldSelection(coll *schema.DefaultCollectio
 	return factory, nil
 }
 
+// getGroupBy returns the group by of the query with the field names mapped to the search store. Only the faceted
+// fields can be used to group the hits.
+func (runner *SearchQueryRunner) getGroupBy(coll *schema.DefaultCollection) (qsearch.GroupBy, error) {
+	groupBy, err := qsearch.UnmarshalGroupBy(runner.req.GroupBy)
+	if err != nil {
+		return groupBy, err
+	}
+	if limit := runner.req.GetGroupLimit(); limit != 0 {
+		l := int64(limit)
+		groupBy.Limit = &l
+	}
+	if err = groupBy.Validate(); err != nil {
+		return qsearch.GroupBy{}, err
+	}
+
+	for i, f := range groupBy.Fields {
+		cf, err := coll.GetQueryableField(f)
+		if err != nil {
+			return qsearch.GroupBy{}, err
+		}
+		if !cf.Faceted && !schema.DefaultFacetableType(cf.DataType) {
+			return qsearch.GroupBy{}, errors.InvalidArgument("Cannot group by on `%s` field as facet is not enabled", f)
+		}
+		if cf.InMemoryName() != cf.Name() {
+			groupBy.Fields[i] = cf.InMemoryName()
+		}
+	}
+
+	return groupBy, nil
+}
+
 func (runner *SearchQueryRunner) getVectorSearch(coll *schema.DefaultCollection) (qsearch.VectorSearch, error) {
 	vectorSearch, err := qsearch.UnmarshalVectorSearch(runner.req.Vector)
 	if err != nil {
@@ -27,6 +27,7 @@ import (
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
 )
 
 var PrimaryKeyPos = 6
@@ -39,6 +40,14 @@ type SecondaryIndexReaderImpl struct {
 	err       error
 	queryPlan *filter.QueryPlan
 	kvIter    Iterator
+	pkPos     int
+	// covered returns the documents from the entries of a composite index with included fields
+	covered bool
+	// seen has the primary keys already returned when a document can have multiple entries in the scanned keys, i.e.
+	// the entries of the elements of an array or the lookups of the values of "$in"
+	seen map[string]struct{}
+	// keysScanned is the number of the index entries read so far
+	keysScanned int64
 }
 
 func newSecondaryIndexReaderImpl(ctx context.Context, tx transaction.Tx, coll *schema.DefaultCollection, f *filter.WrappedFilter, queryPlan *filter.QueryPlan) (*SecondaryIndexReaderImpl, error) {
@@ -52,9 +61,30 @@ func newSecondaryIndexReaderImpl(ctx context.Context, tx transaction.Tx, coll *s
 		filter:    f,
 		err:       nil,
 		queryPlan: queryPlan,
+		pkPos:     primaryKeyPos(coll, queryPlan),
 	}
+	if queryPlan.DataType == schema.ArrayType || (len(queryPlan.Keys) > 1 && queryPlan.QueryType == filter.EQUAL) {
+		reader.seen = make(map[string]struct{})
+	}
+
+	if _, err := reader.createIter(); err != nil {
+		return nil, err
+	}
+	recordIndexRead(ctx, coll, queryPlan.FieldName)
+
+	return reader, nil
+}
+
+// NewCoveringIndexReader returns the reader of a composite index with included fields, the documents are returned
+// from the index entries, so they have only the indexed, the included and the primary key fields.
+func NewCoveringIndexReader(ctx context.Context, tx transaction.Tx, coll *schema.DefaultCollection, f *filter.WrappedFilter, queryPlan *filter.QueryPlan) (Iterator, error) {
+	reader, err := newSecondaryIndexReaderImpl(ctx, tx, coll, f, queryPlan)
+	if err != nil {
+		return nil, err
+	}
+	reader.covered = true
 
-	return reader.createIter()
+	return reader, nil
 }
 
 func (r *SecondaryIndexReaderImpl) createIter() (*SecondaryIndexReaderImpl, error) {
@@ -62,6 +92,13 @@ func (r *SecondaryIndexReaderImpl) createIter() (*SecondaryIndexReaderImpl, erro
 
 	log.Debug().Msgf("Query Plan Keys %v ascending: %v", r.queryPlan.GetKeyInterfaceParts(), r.queryPlan.Ascending)
 
+	if index := schema.FindIndex(r.coll.SecondaryIndexes.All, r.queryPlan.FieldName); index != nil && index.IsSharded() {
+		if r.kvIter, err = newShardedIndexIterator(r.ctx, r.tx, index, r.queryPlan); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
 	switch r.queryPlan.QueryType {
 	case filter.FULLRANGE, filter.RANGE:
 		r.kvIter, err = NewScanIterator(r.ctx, r.tx, r.queryPlan.Keys[0], r.queryPlan.Keys[1], r.queryPlan.Reverse())
@@ -85,6 +122,28 @@ func BuildSecondaryIndexKeys(coll *schema.DefaultCollection, queryFilters []filt
 		return nil, errors.InvalidArgument("Cannot index with an empty filter")
 	}
 
+	// a composite index narrowing the scan with multiple fields, or returning the rows sorted on multiple fields, is
+	// preferred over the single field indexes, otherwise it is the fallback if none of them can be used. An expression
+	// index narrowing the scan is preferred too.
+	composite := buildCompositeIndexPlan(coll, queryFilters, sortFields)
+	if composite != nil && (composite.fields > 1 || (composite.expression && composite.fields > 0) ||
+		(sortFields != nil && len(*sortFields) > 1)) {
+		return composite.plan, nil
+	}
+
+	plan, err := buildSingleFieldIndexKeys(coll, queryFilters, sortFields)
+	if err != nil && composite != nil {
+		return composite.plan, nil
+	}
+
+	return plan, err
+}
+
+func buildSingleFieldIndexKeys(coll *schema.DefaultCollection, queryFilters []filter.Filter, sortFields *sort.Ordering) (*filter.QueryPlan, error) {
+	if sortFields != nil && len(*sortFields) > 1 {
+		return nil, errors.InvalidArgument("cannot use secondary index with multiple sort fields")
+	}
+
 	indexeableFields := coll.GetActiveIndexedFields()
 	if len(indexeableFields) == 0 {
 		return nil, errors.InvalidArgument("No indexable fields")
@@ -95,14 +154,17 @@ func BuildSecondaryIndexKeys(coll *schema.DefaultCollection, queryFilters []filt
 	}
 
 	buildIndexParts := func(fieldName string, val value.Value) []any {
-		typeOrder := value.ToSecondaryOrder(val.DataType(), val)
-		return []any{fieldName, typeOrder, val.AsInterface()}
+		encoder := keyencoding.ForIndex(schema.FindIndex(coll.SecondaryIndexes.All, fieldName))
+		return append([]any{fieldName}, encoder.IndexParts(val.DataType(), val)...)
 	}
 
 	sortQueryPlan, err := filter.QueryPlanFromSort(sortFields, indexeableFields, encoder, buildIndexParts, filter.SecondaryIndex)
 	if err != nil {
 		return nil, err
 	}
+	if sortQueryPlan != nil && !comparesDateTimes(coll, *sortQueryPlan) {
+		return nil, errors.InvalidArgument("the index on '%s' can't sort date times until its keys are migrated", sortQueryPlan.FieldName)
+	}
 
 	eqKeyBuilder := filter.NewSecondaryKeyEqBuilder[*schema.QueryableField](encoder, buildIndexParts)
 	eqPlans, err := eqKeyBuilder.Build(queryFilters, indexeableFields)
@@ -110,7 +172,7 @@ func BuildSecondaryIndexKeys(coll *schema.DefaultCollection, queryFilters []filt
 		for _, plan := range eqPlans {
 			// If a user specifies an $eq with the same fields as the field defined in sort
 			// we want to use the eq to narrow down the search
-			if indexedDataType(plan) && worksWithSortPlan(plan, sortQueryPlan) {
+			if indexedDataType(plan) && comparesDateTimes(coll, plan) && worksWithSortPlan(plan, sortQueryPlan) {
 				return mergeWithSortPlan(plan, sortQueryPlan), nil
 			}
 		}
@@ -132,7 +194,7 @@ func BuildSecondaryIndexKeys(coll *schema.DefaultCollection, queryFilters []filt
 
 	rangePlans = filter.SortQueryPlans(rangePlans)
 	for _, plan := range rangePlans {
-		if indexedDataType(plan) && worksWithSortPlan(plan, sortQueryPlan) {
+		if indexedDataType(plan) && comparesDateTimes(coll, plan) && worksWithSortPlan(plan, sortQueryPlan) {
 			return mergeWithSortPlan(plan, sortQueryPlan), nil
 		}
 	}
@@ -146,13 +208,21 @@ func BuildSecondaryIndexKeys(coll *schema.DefaultCollection, queryFilters []filt
 
 func indexedDataType(queryPlan filter.QueryPlan) bool {
 	switch queryPlan.DataType {
-	case schema.ByteType, schema.UnknownType, schema.ArrayType:
+	case schema.UnknownType:
 		return false
 	default:
 		return true
 	}
 }
 
+// comparesDateTimes returns false for a plan on the date times of an index with the legacy key encoding, the keys
+// are the date times in their original offset, so the plan would miss the documents with a different offset. The
+// documents are then scanned and filtered until the index is migrated, see migrateKeyEncoding.
+func comparesDateTimes(coll *schema.DefaultCollection, plan filter.QueryPlan) bool {
+	return plan.DataType != schema.DateTimeType ||
+		keyencoding.ComparesDateTimes(schema.FindIndex(coll.SecondaryIndexes.All, plan.FieldName))
+}
+
 func worksWithSortPlan(plan filter.QueryPlan, sortPlan *filter.QueryPlan) bool {
 	if sortPlan == nil {
 		return true
@@ -172,6 +242,14 @@ func mergeWithSortPlan(plan filter.QueryPlan, sortPlan *filter.QueryPlan) *filte
 	}
 
 	plan.Ascending = sortPlan.Ascending
+	if plan.QueryType == filter.EQUAL && !plan.Ascending {
+		// the lookups of "$in" are in the ascending order of the values
+		reversed := make([]keys.Key, len(plan.Keys))
+		for i, key := range plan.Keys {
+			reversed[len(plan.Keys)-1-i] = key
+		}
+		plan.Keys = reversed
+	}
 	return &plan
 }
 
@@ -186,15 +264,29 @@ func (r *SecondaryIndexReaderImpl) Next(row *Row) bool {
 	}
 
 	var indexRow Row
-	if r.kvIter.Next(&indexRow) {
+	for r.kvIter.Next(&indexRow) {
+		r.keysScanned++
 		indexKey, err := keys.FromBinary(r.coll.EncodedTableIndexName, indexRow.Key)
 		if err != nil {
 			r.err = err
 			return false
 		}
 
-		pks := indexKey.IndexParts()[PrimaryKeyPos:]
+		pks := indexKey.IndexParts()[r.pkPos:]
 		pkIndexParts := keys.NewKey(r.coll.EncodedName, pks...)
+		if r.seen != nil {
+			pk := string(pkIndexParts.SerializeToBytes())
+			if _, ok := r.seen[pk]; ok {
+				continue
+			}
+			r.seen[pk] = struct{}{}
+		}
+
+		if r.covered && indexRow.Data != nil && len(indexRow.Data.RawData) > 0 {
+			row.Data = indexRow.Data
+			row.Key = pkIndexParts.SerializeToBytes()
+			return true
+		}
 
 		docIter, err := r.tx.Read(r.ctx, pkIndexParts, false)
 		if err != nil {
@@ -208,12 +300,17 @@ func (r *SecondaryIndexReaderImpl) Next(row *Row) bool {
 			row.Key = keyValue.FDBKey
 			return true
 		}
+
+		return false
 	}
 	return false
 }
 
 func (r *SecondaryIndexReaderImpl) Interrupted() error { return r.err }
 
+// KeysScanned returns the number of the index entries read so far, including the ones of the documents already returned.
+func (r *SecondaryIndexReaderImpl) KeysScanned() int64 { return r.keysScanned }
+
 // For local debugging and testing.
 //
 //nolint:unused
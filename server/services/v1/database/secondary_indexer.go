@@ -15,6 +15,7 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -24,11 +25,13 @@ import (
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/expression"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/metrics"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
 )
 
 var (
@@ -43,7 +46,7 @@ type SecondaryIndexer interface {
 	// Bulk build the indexes in the collection
 	BuildCollection(ctx context.Context, txMgr *transaction.Manager) error
 	// Read the document from the primary store and delete it from secondary indexes
-	ReadDocAndDelete(ctx context.Context, tx transaction.Tx, key keys.Key) (int32, error)
+	ReadDocAndDelete(ctx context.Context, tx transaction.Tx, key keys.Key) (*internal.TableData, error)
 	// Delete document from the secondary index
 	Delete(ctx context.Context, tx transaction.Tx, td *internal.TableData, primaryKey []any) error
 	// Index new document
@@ -61,6 +64,15 @@ type IndexRow struct {
 	stub     bool
 	dataType schema.FieldType
 	null     bool
+	// composite is the values of the fields of a composite index, the row is named after the index then
+	composite []compositeValue
+	// covered is the value of the entry of a composite index with included fields, see coveredDocument
+	covered *internal.TableData
+}
+
+type compositeValue struct {
+	value    value.Value
+	dataType schema.FieldType
 }
 
 func newIndexRow(dataType schema.FieldType, collation *value.Collation, name string, rawValue []byte, pos int, stub bool) (*IndexRow, error) {
@@ -69,12 +81,11 @@ func newIndexRow(dataType schema.FieldType, collation *value.Collation, name str
 		return nil, err
 	}
 	return &IndexRow{
-		value,
-		name,
-		pos,
-		stub,
-		dataType,
-		false,
+		value:    value,
+		name:     name,
+		pos:      pos,
+		stub:     stub,
+		dataType: dataType,
 	}, nil
 }
 
@@ -108,6 +119,11 @@ func (f IndexRow) Name() string {
 }
 
 func (f IndexRow) IsEqual(b IndexRow) bool {
+	if f.composite != nil || b.composite != nil {
+		return f.Name() == b.Name() && f.pos == b.pos && compositeEqual(f.composite, b.composite) &&
+			coveredEqual(f.covered, b.covered)
+	}
+
 	compare, err := f.value.CompareTo(b.value)
 	if err != nil {
 		return false
@@ -115,6 +131,42 @@ func (f IndexRow) IsEqual(b IndexRow) bool {
 	return compare == 0 && f.Name() == b.Name() && f.pos == b.pos
 }
 
+func compositeEqual(a []compositeValue, b []compositeValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].dataType != b[i].dataType {
+			return false
+		}
+		if compare, err := a[i].value.CompareTo(b[i].value); err != nil || compare != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// coveredEqual returns true if the entries store the same covered document, the attributes are compared too as the
+// covered reads return them along with the document.
+func coveredEqual(a *internal.TableData, b *internal.TableData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return bytes.Equal(a.RawData, b.RawData) && a.Ver == b.Ver && a.Revision == b.Revision &&
+		timestampEqual(a.CreatedAt, b.CreatedAt) && timestampEqual(a.UpdatedAt, b.UpdatedAt)
+}
+
+func timestampEqual(a *internal.Timestamp, b *internal.Timestamp) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.UnixNano() == b.UnixNano()
+}
+
 type SecondaryIndexInfo struct {
 	Rows int64
 	Size int64
@@ -124,10 +176,16 @@ type IndexerUpdateSet struct {
 	addKeys   []keys.Key
 	addSizes  map[string]int64
 	addCounts map[string]int64
+	// addData is the values of the added keys that are not empty, keyed by the serialized key
+	addData map[string]*internal.TableData
 
 	removeKeys   []keys.Key
 	removeSizes  map[string]int64
 	removeCounts map[string]int64
+
+	uniqueChecks []uniqueCheck
+	// entries is the number of the added and removed entries of each index
+	entries map[string]int64
 }
 
 type SecondaryIndexerImpl struct {
@@ -238,6 +296,9 @@ var retryErrors = []error{
 }
 
 func shouldRetryBulkIndex(err error) bool {
+	if kv.IsConflict(err) {
+		return true
+	}
 	for _, kvErr := range retryErrors {
 		if kvErr == err {
 			return true
@@ -248,6 +309,7 @@ func shouldRetryBulkIndex(err error) bool {
 
 func (q *SecondaryIndexerImpl) DeleteIndex(ctx context.Context, tx transaction.Tx, index *schema.Index) error {
 	indexKey := keys.NewKey(q.coll.EncodedTableIndexName, q.coll.SecondaryIndexKeyword(), KVSubspace, index.Name)
+	metrics.ResetIndexUsage(q.coll.EncodedName, index.Name)
 
 	return tx.Delete(ctx, indexKey)
 }
@@ -264,6 +326,13 @@ func (q *SecondaryIndexerImpl) IndexSize(ctx context.Context, tx transaction.Tx)
 	return tx.RangeSize(ctx, q.coll.EncodedTableIndexName, lKey, rKey)
 }
 
+// IndexSizeOf returns the estimated size of the entries of a single index of the collection.
+func (q *SecondaryIndexerImpl) IndexSizeOf(ctx context.Context, tx transaction.Tx, name string) (int64, error) {
+	lKey := keys.NewKey(q.coll.EncodedTableIndexName, q.coll.SecondaryIndexKeyword(), KVSubspace, name)
+	rKey := keys.NewKey(q.coll.EncodedTableIndexName, q.coll.SecondaryIndexKeyword(), KVSubspace, name, 0xFF)
+	return tx.RangeSize(ctx, q.coll.EncodedTableIndexName, lKey, rKey)
+}
+
 // The count of the number of rows in the index is not efficient
 // it will read through the whole index and count the number of rows.
 // The size of the index is an estimate and will need at least 100 rows before it will start returning
@@ -296,22 +365,17 @@ func (q *SecondaryIndexerImpl) IndexInfo(ctx context.Context, tx transaction.Tx)
 	}, nil
 }
 
-func (q *SecondaryIndexerImpl) ReadDocAndDelete(ctx context.Context, tx transaction.Tx, key keys.Key) (int32, error) {
-	iter, err := tx.Read(ctx, key, false)
-	if err != nil {
-		return 0, err
+func (q *SecondaryIndexerImpl) ReadDocAndDelete(ctx context.Context, tx transaction.Tx, key keys.Key) (*internal.TableData, error) {
+	oldDoc, err := readDocument(ctx, tx, key)
+	if err != nil || oldDoc == nil {
+		return nil, err
 	}
 
-	var oldDoc kv.KeyValue
-	if iter.Next(&oldDoc) {
-		if err = q.Delete(ctx, tx, oldDoc.Data, key.IndexParts()); err != nil {
-			return 0, err
-		}
-
-		return oldDoc.Data.Size(), nil
+	if err = q.Delete(ctx, tx, oldDoc, key.IndexParts()); err != nil {
+		return nil, err
 	}
 
-	return 0, iter.Err()
+	return oldDoc, nil
 }
 
 func (q *SecondaryIndexerImpl) Delete(ctx context.Context, tx transaction.Tx, td *internal.TableData, primaryKey []any) error {
@@ -344,16 +408,28 @@ func (q *SecondaryIndexerImpl) Update(ctx context.Context, tx transaction.Tx, ne
 		}
 	}
 
+	// the old values are removed first, so that a document can keep its own value
+	if err = q.checkUnique(ctx, tx, updateSet.uniqueChecks, primaryKey); err != nil {
+		return err
+	}
+
 	for _, indexKey := range updateSet.addKeys {
 		if reqStatus != nil && reqStatusExists {
 			if !reqStatus.IsSecondaryIndexFieldIgnored(indexKey.SerializeToBytes()) {
 				reqStatus.AddWriteBytes(int64(len(indexKey.SerializeToBytes())))
 			}
 		}
-		if err := tx.Replace(ctx, indexKey, internal.EmptyData, false); err != nil {
+		data := internal.EmptyData
+		if covered, ok := updateSet.addData[string(indexKey.SerializeToBytes())]; ok {
+			data = covered
+		}
+		if err := tx.Replace(ctx, indexKey, data, false); err != nil {
 			return err
 		}
 	}
+
+	recordIndexWrites(ctx, q.coll, updateSet.entries)
+
 	return nil
 }
 
@@ -383,15 +459,32 @@ func (q *SecondaryIndexerImpl) buildAddAndRemoveKVs(newTableData *internal.Table
 	mergeDuplicates(addCounts, removeCounts)
 
 	return &IndexerUpdateSet{
-		addKeys,
-		addSizes,
-		addCounts,
-		removeKeys,
-		removeSizes,
-		removeCounts,
+		addKeys:      addKeys,
+		addSizes:     addSizes,
+		addCounts:    addCounts,
+		addData:      q.buildCoveredData(primaryKey, rowsToAdd),
+		removeKeys:   removeKeys,
+		removeSizes:  removeSizes,
+		removeCounts: removeCounts,
+		uniqueChecks: q.buildUniqueChecks(rowsToAdd),
+		entries:      countIndexEntries(rowsToAdd, rowsToRemove),
 	}, nil
 }
 
+// countIndexEntries returns the number of the entries written to each index, the stubs of the arrays are not counted.
+func countIndexEntries(added []IndexRow, removed []IndexRow) map[string]int64 {
+	entries := make(map[string]int64)
+	for _, rows := range [][]IndexRow{added, removed} {
+		for _, row := range rows {
+			if !row.stub {
+				entries[row.name]++
+			}
+		}
+	}
+
+	return entries
+}
+
 func (q *SecondaryIndexerImpl) buildTableRows(tableData *internal.TableData) ([]IndexRow, error) {
 	if tableData == nil {
 		return []IndexRow{}, nil
@@ -427,9 +520,134 @@ func (q *SecondaryIndexerImpl) buildTableRows(tableData *internal.TableData) ([]
 			rows = append(rows, *row)
 		}
 	}
+
+	for _, index := range q.coll.GetCompositeIndexes() {
+		row, err := q.indexComposite(tableData, index)
+		if err != nil {
+			log.Err(err).Msgf("Failed to index composite index: %s", index.Name)
+			return nil, err
+		}
+		rows = append(rows, *row)
+	}
+
 	return rows, nil
 }
 
+// indexComposite builds the row of the composite index, a missing or null field is indexed as null.
+func (q *SecondaryIndexerImpl) indexComposite(tableData *internal.TableData, index *schema.Index) (*IndexRow, error) {
+	doc := tableData.RawData
+	values, err := q.compositeValues(doc, index)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &IndexRow{
+		value:     value.NewNullValue(),
+		name:      index.Name,
+		dataType:  schema.NullType,
+		composite: values,
+	}
+
+	if len(index.Include) > 0 || index.CaseInsensitive {
+		// the entries of a case-insensitive index keep the original values of the fields which keys are case folded
+		covered, err := q.coveredDocument(doc, index)
+		if err != nil {
+			return nil, err
+		}
+		// the entry keeps the attributes of the document, but not the ones of its stored representation
+		row.covered = tableData.CloneWithAttributesOnly(covered)
+		row.covered.Compression = nil
+		row.covered.TotalChunks = nil
+	}
+
+	return row, nil
+}
+
+// compositeValues returns the values of the fields of the composite index, or the value of the expression of an
+// expression index.
+func (q *SecondaryIndexerImpl) compositeValues(doc []byte, index *schema.Index) ([]compositeValue, error) {
+	if index.Expression != nil {
+		v, err := expression.Evaluate(index.Expression, doc, q.collation)
+		if err != nil {
+			return nil, err
+		}
+		return []compositeValue{{value: v, dataType: v.DataType()}}, nil
+	}
+
+	values := make([]compositeValue, 0, len(index.Fields))
+	for _, field := range index.Fields {
+		val, dt, _, err := jsonparser.Get(doc, strings.Split(field.FieldName, ".")...)
+		if dt == jsonparser.NotExist || dt == jsonparser.Null {
+			values = append(values, compositeValue{value: value.NewNullValue(), dataType: schema.NullType})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var v value.Value
+		if index.CaseInsensitive && field.DataType == schema.StringType {
+			v = value.NewStringValue(value.FoldCase(string(val)), nil)
+		} else if v, err = value.NewValueUsingCollation(field.DataType, val, q.collation); err != nil {
+			return nil, err
+		}
+		values = append(values, compositeValue{value: v, dataType: field.DataType})
+	}
+
+	return values, nil
+}
+
+// coveredDocument returns the part of the document stored in the entries of a composite index with included fields,
+// it has the indexed, the included and the primary key fields, so the reads of these fields don't need the document.
+func (q *SecondaryIndexerImpl) coveredDocument(doc []byte, index *schema.Index) ([]byte, error) {
+	paths := make([]string, 0, len(index.Fields)+len(index.Include))
+	for _, f := range index.Fields {
+		paths = append(paths, f.FieldName)
+	}
+	paths = append(paths, index.Include...)
+	for _, f := range q.coll.GetPrimaryKey().Fields {
+		paths = append(paths, f.FieldName)
+	}
+
+	covered := []byte("{}")
+	for _, path := range paths {
+		keyPath := strings.Split(path, ".")
+		val, dt, offset, err := jsonparser.Get(doc, keyPath...)
+		if dt == jsonparser.NotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dt == jsonparser.String {
+			// the value is returned without the quotes
+			val = doc[offset-len(val)-2 : offset]
+		}
+
+		if covered, err = jsonparser.Set(covered, val, keyPath...); err != nil {
+			return nil, err
+		}
+	}
+
+	return covered, nil
+}
+
+// buildCoveredData returns the values of the entries of the composite indexes with included fields.
+func (q *SecondaryIndexerImpl) buildCoveredData(primaryKey []any, rows []IndexRow) map[string]*internal.TableData {
+	var data map[string]*internal.TableData
+	for _, row := range rows {
+		if row.covered == nil {
+			continue
+		}
+		if data == nil {
+			data = make(map[string]*internal.TableData)
+		}
+		data[string(q.buildIndexKey(row, primaryKey).SerializeToBytes())] = row.covered
+	}
+
+	return data
+}
+
 func (q *SecondaryIndexerImpl) buildTSRows(tableData *internal.TableData) ([]IndexRow, error) {
 	timeStamps := []struct {
 		ts    *internal.Timestamp
@@ -468,12 +686,32 @@ func (q *SecondaryIndexerImpl) buildTSRows(tableData *internal.TableData) ([]Ind
 }
 
 func (q *SecondaryIndexerImpl) buildIndexKey(row IndexRow, primaryKey []any) keys.Key {
-	if row.null {
-		return newKeyWithPrimaryKey(primaryKey, q.coll.EncodedTableIndexName, q.coll.SecondaryIndexKeyword(), KVSubspace, row.Name(), value.SecondaryNullOrder(), row.value.AsInterface(), row.pos)
+	return newKeyWithPrimaryKey(primaryKey, q.coll.EncodedTableIndexName, append(q.buildIndexParts(row), row.pos)...)
+}
+
+// buildIndexParts returns the parts of the index key of the row up to the value, i.e. without the position and the
+// primary key. The parts of a sharded index have the shard of the value after the name of the index.
+func (q *SecondaryIndexerImpl) buildIndexParts(row IndexRow) []any {
+	// the key is encoded with the version the index was built with
+	index := schema.FindIndex(q.coll.SecondaryIndexes.All, row.name)
+	encoder := keyencoding.ForIndex(index)
+
+	if row.composite != nil {
+		indexParts := []any{q.coll.SecondaryIndexKeyword(), KVSubspace, row.Name()}
+		for i, v := range row.composite {
+			parts := encoder.IndexParts(v.dataType, v.value)
+			if index.IsDescending(i) {
+				parts = keyencoding.Descending(parts)
+			}
+			indexParts = append(indexParts, parts...)
+		}
+
+		return withIndexShard(index, indexParts)
 	}
 
-	dataTypeOrder := value.ToSecondaryOrder(row.dataType, row.value)
-	return newKeyWithPrimaryKey(primaryKey, q.coll.EncodedTableIndexName, q.coll.SecondaryIndexKeyword(), KVSubspace, row.Name(), dataTypeOrder, row.value.AsInterface(), row.pos)
+	parts := encoder.IndexParts(row.dataType, row.value)
+
+	return withIndexShard(index, []any{q.coll.SecondaryIndexKeyword(), KVSubspace, row.Name(), parts[0], parts[1]})
 }
 
 func (q *SecondaryIndexerImpl) createKeysAndIndexInfo(primaryKey []any, rows []IndexRow) ([]keys.Key, map[string]int64, map[string]int64) {
@@ -509,9 +747,6 @@ func (q *SecondaryIndexerImpl) createKeysAndIndexInfo(primaryKey []any, rows []I
 }
 
 func (q *SecondaryIndexerImpl) indexField(doc []byte, fieldName string, dataType schema.FieldType, pos int, keyPath ...string) (*IndexRow, error) {
-	if dataType == schema.ByteType {
-		return nil, fmt.Errorf("do not index byte field %s", fieldName)
-	}
 	val, dt, _, err := jsonparser.Get(doc, keyPath...)
 	if dt == jsonparser.NotExist && !q.sparse {
 		return newMissingRow(fieldName), nil
@@ -580,7 +815,9 @@ func (q *SecondaryIndexerImpl) indexArray(doc []byte, field *schema.QueryableFie
 		toFieldType := schema.ToFieldType(dt.String(), "", "")
 		switch toFieldType {
 		case schema.NullType:
-			rows = append(rows, *newNullRow(field.FieldName, pos))
+			if nullRow := newNullRow(field.FieldName, pos); !containsElementRow(rows, *nullRow) {
+				rows = append(rows, *nullRow)
+			}
 		case schema.ObjectType:
 			indexedFields, err := q.indexNestedField(value, field.FieldName, pos)
 			if err != nil && !isIgnoreableError(err) {
@@ -601,16 +838,18 @@ func (q *SecondaryIndexerImpl) indexArray(doc []byte, field *schema.QueryableFie
 				errProcessor = err
 				return
 			}
-			rows = append(rows, *indexedField)
+			// the duplicate elements have a single entry, so a document is found once by the lookup of the element
+			if !containsElementRow(rows, *indexedField) {
+				rows = append(rows, *indexedField)
+			}
 		}
 		pos++
 	}
 
-	if errProcessor != nil {
+	_, err := jsonparser.ArrayEach(doc, processor, keyPath...)
+	if err == nil && errProcessor != nil {
 		return nil, errProcessor
 	}
-
-	_, err := jsonparser.ArrayEach(doc, processor, keyPath...)
 	if err != nil {
 		if isKeyPathNotFound(err) {
 			return []IndexRow{*newMissingRow(field.FieldName)}, nil
@@ -636,6 +875,20 @@ func newKeyWithPrimaryKey(id []any, table []byte, indexParts ...any) keys.Key {
 	return keys.NewKey(table, indexParts...)
 }
 
+// containsElementRow returns true if an element of the array with the same value is already indexed, unlike
+// containsIndexRow the position of the element in the array is not compared.
+func containsElementRow(rows []IndexRow, element IndexRow) bool {
+	for _, row := range rows {
+		if row.name != element.name || row.stub || row.dataType != element.dataType {
+			continue
+		}
+		if res, _ := row.value.CompareTo(element.value); res == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func containsIndexRow(rows []IndexRow, field IndexRow) bool {
 	for _, row := range rows {
 		if row.IsEqual(field) {
@@ -672,7 +925,7 @@ func isKeyPathNotFound(err error) bool {
 // These are acceptable errors during indexing. A field could be missing from the index or
 // the field could be of type binary.
 func isIgnoreableError(err error) bool {
-	if isKeyPathNotFound(err) || strings.Contains(err.Error(), "do not index byte field") {
+	if isKeyPathNotFound(err) {
 		return true
 	}
 	return false
@@ -21,15 +21,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/buger/jsonparser"
 	"github.com/stretchr/testify/assert"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
 	"github.com/tigrisdata/tigris/schema"
 	"github.com/tigrisdata/tigris/server/config"
 	"github.com/tigrisdata/tigris/server/transaction"
 	"github.com/tigrisdata/tigris/store/kv"
 	ulog "github.com/tigrisdata/tigris/util/log"
 	"github.com/tigrisdata/tigris/value"
+	"github.com/tigrisdata/tigris/value/keyencoding"
 	"golang.org/x/text/collate"
 	"golang.org/x/text/language"
 )
@@ -84,6 +87,7 @@ func TestIndexingCreateSimpleKVsforDoc(t *testing.T) {
 			{"skey", KVSubspace, "double_f", value.ToSecondaryOrder(schema.DoubleType, nil), float64(2), 0, 1},
 			{"skey", KVSubspace, "created", value.ToSecondaryOrder(schema.DateTimeType, nil), "2023-01-16T12:55:17.304154Z", 0, 1},
 			{"skey", KVSubspace, "updated", value.ToSecondaryOrder(schema.DateTimeType, nil), "2023-01-16T12:55:17.304154Z", 0, 1},
+			{"skey", KVSubspace, "binary_val", value.ToSecondaryOrder(schema.ByteType, nil), []byte("peek-a-boo\n"), 0, 1},
 			{"skey", KVSubspace, "arr", value.ToSecondaryOrder(schema.Int64Type, nil), int64(1), 0, 1},
 			{"skey", KVSubspace, "arr", value.ToSecondaryOrder(schema.Int64Type, nil), int64(2), 1, 1},
 		}
@@ -124,6 +128,7 @@ func TestIndexingCreateSimpleKVsforDoc(t *testing.T) {
 			{"skey", KVSubspace, "double_f", value.ToSecondaryOrder(schema.DoubleType, nil), float64(2), 0, 1},
 			{"skey", KVSubspace, "created", value.ToSecondaryOrder(schema.DateTimeType, nil), "2023-01-16T12:55:17.304154Z", 0, 1},
 			{"skey", KVSubspace, "updated", value.ToSecondaryOrder(schema.DateTimeType, nil), "2023-01-16T12:55:17.304154Z", 0, 1},
+			{"skey", KVSubspace, "binary_val", value.ToSecondaryOrder(schema.ByteType, nil), []byte("peek-a-boo\n"), 0, 1},
 			{"skey", KVSubspace, "arr", value.ToSecondaryOrder(schema.Int64Type, nil), int64(1), 0, 1},
 			{"skey", KVSubspace, "arr", value.ToSecondaryOrder(schema.Int64Type, nil), int64(2), 1, 1},
 		}
@@ -244,7 +249,6 @@ func TestIndexingNull(t *testing.T) {
 			{"skey", KVSubspace, "created", value.SecondaryNullOrder(), nil, 0, 1},
 			{"skey", KVSubspace, "updated", value.SecondaryNullOrder(), nil, 0, 1},
 			{"skey", KVSubspace, "arr", value.SecondaryNullOrder(), nil, 0, 1},
-			{"skey", KVSubspace, "arr", value.SecondaryNullOrder(), nil, 1, 1},
 		}
 		assertKVs(t, expected, updateSet.addKeys, updateSet.addCounts)
 	})
@@ -266,7 +270,6 @@ func TestIndexingNull(t *testing.T) {
 			{"skey", KVSubspace, "_tigris_updated_at", value.SecondaryNullOrder(), nil, 0, 1},
 			{"skey", KVSubspace, "double_f", value.SecondaryNullOrder(), nil, 0, 1},
 			{"skey", KVSubspace, "updated", value.SecondaryNullOrder(), nil, 0, 1},
-			{"skey", KVSubspace, "arr", value.SecondaryNullOrder(), nil, 1, 1},
 		}
 		assertKVs(t, expectedRemoved, updateSet.removeKeys, nil)
 	})
@@ -846,6 +849,31 @@ func setupTest(t *testing.T, reqSchema []byte) *SecondaryIndexerImpl {
 	return indexer
 }
 
+// setupActiveIndexTest returns the indexer of the collection which only writes the keys of the indexes defined in
+// the schema, as if they were built with the current key encoding.
+func setupActiveIndexTest(t *testing.T, reqSchema []byte) *SecondaryIndexerImpl {
+	indexer := setupTest(t, reqSchema)
+	indexer.indexAll = false
+	for _, index := range indexer.coll.SecondaryIndexes.All {
+		index.State = schema.INDEX_ACTIVE
+		keyencoding.SetCurrent(index)
+	}
+
+	return indexer
+}
+
+// indexKeyParts returns the parts of the keys of the index.
+func indexKeyParts(indexKeys []keys.Key, name string) [][]any {
+	var parts [][]any
+	for _, k := range indexKeys {
+		if k.IndexParts()[2] == name {
+			parts = append(parts, k.IndexParts())
+		}
+	}
+
+	return parts
+}
+
 func assertKVs(t *testing.T, expected [][]any, indexKeys []keys.Key, counts map[string]int64) {
 	assert.Equal(t, len(expected), len(indexKeys))
 	calculatedCounts := map[string]int64{}
@@ -873,6 +901,111 @@ func createDoc(doc string, primaryKey ...any) (*internal.TableData, []any) {
 	return td, primaryKey
 }
 
+func TestByteIndexPlan(t *testing.T) {
+	indexer := setupActiveIndexTest(t, []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"b": { "type": "string", "format": "byte", "index": true }
+	},
+	"primary_key": ["id"]
+}`))
+
+	td, pk := createDoc(`{"id":1, "b":"cGVlay1hLWJvbwo="}`)
+	updateSet, err := indexer.buildAddAndRemoveKVs(td, nil, pk)
+	assert.NoError(t, err)
+	encoder := keyencoding.Get(keyencoding.Current)
+	assert.Equal(t, [][]any{
+		append(append([]any{"skey", KVSubspace, "b"}, encoder.IndexParts(schema.ByteType, value.NewBytesValue([]byte("peek-a-boo\n")))...), 0, 1),
+	}, indexKeyParts(updateSet.addKeys, "b"))
+
+	// the base64 literal is decoded, so the range is served by the index on the byte field
+	filters, err := newSecondaryIndexFilterFactory(indexer.coll).Factorize([]byte(`{"b": {"$gt": "cGVlaw=="}}`))
+	assert.NoError(t, err)
+	plan, err := BuildSecondaryIndexKeys(indexer.coll, filters, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", plan.FieldName)
+	assert.Equal(t, schema.ByteType, plan.DataType)
+	assert.Equal(t, filter.RANGE, plan.QueryType)
+}
+
+func TestDateTimeIndexMixedOffsets(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+	"title": "t1",
+	"properties": {
+		"id": { "type": "integer" },
+		"created": { "type": "string", "format": "date-time", "index": true }
+	},
+	"primary_key": ["id"]
+}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("datetime_t1")
+	coll.EncodedTableIndexName = []byte("datetime_sidx1")
+	tm := transaction.NewManager(kvStore)
+
+	// 10:00+02:00 is before 09:00Z, but the strings are in the opposite order
+	docs := []string{`{"id":1, "created":"2023-01-01T10:00:00+02:00"}`, `{"id":2, "created":"2023-01-01T09:00:00Z"}`}
+	reqFilter := []byte(`{"created": {"$gt": "2023-01-01T08:30:00Z"}}`)
+
+	for _, version := range []keyencoding.Version{keyencoding.Current, keyencoding.Legacy} {
+		t.Run(fmt.Sprintf("key encoding %d", version), func(t *testing.T) {
+			schema.FindIndex(coll.SecondaryIndexes.All, "created").KeyEncoding = uint8(version)
+			for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+				assert.NoError(t, kvStore.DropTable(ctx, table))
+				assert.NoError(t, kvStore.CreateTable(ctx, table))
+			}
+
+			tx, err := tm.StartTx(ctx)
+			assert.NoError(t, err)
+			for i, doc := range docs {
+				pk := []any{"pkey", int64(i + 1)}
+				td := createTD([]byte(doc))
+				assert.NoError(t, indexer.Update(ctx, tx, td, nil, pk))
+				assert.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+			}
+			assert.NoError(t, tx.Commit(ctx))
+
+			wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter(reqFilter)
+			assert.NoError(t, err)
+			filters, err := newSecondaryIndexFilterFactory(coll).Factorize(reqFilter)
+			assert.NoError(t, err)
+			plan, planErr := BuildSecondaryIndexKeys(coll, filters, nil)
+
+			tx, err = tm.StartTx(ctx)
+			assert.NoError(t, err)
+			defer func() { _ = tx.Rollback(ctx) }()
+
+			var iter Iterator
+			if version == keyencoding.Legacy {
+				// the legacy keys are in the original offsets, the documents are scanned instead
+				assert.Error(t, planErr)
+				iter, err = NewDatabaseReader(ctx, tx).ScanTable(coll.EncodedName, false)
+			} else {
+				assert.NoError(t, planErr)
+				assert.Equal(t, "created", plan.FieldName)
+				iter, err = NewSecondaryIndexReader(ctx, tx, coll, wrapped, plan)
+			}
+			assert.NoError(t, err)
+			filtered := NewFilterIterator(iter, wrapped)
+
+			var (
+				row Row
+				ids []int64
+			)
+			for filtered.Next(&row) {
+				id, err := jsonparser.GetInt(row.Data.RawData, "id")
+				assert.NoError(t, err)
+				ids = append(ids, id)
+			}
+			assert.NoError(t, filtered.Interrupted())
+			assert.Equal(t, []int64{2}, ids)
+		})
+	}
+}
+
 func createTD(doc []byte) *internal.TableData {
 	return internal.NewTableDataWithTS(internal.NewTimestamp(), internal.NewTimestamp(), doc)
 }
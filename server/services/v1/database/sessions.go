@@ -19,7 +19,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"sync"
 	"time"
@@ -234,7 +233,12 @@ func (sessMgr *SessionManager) Create(ctx context.Context, trackVerInOwnTxn bool
 	}
 	txCtx := tx.GetTxCtx()
 	sessCtx, cancel := context.WithCancel(ctx)
-	sessCtx = kv.WrapEventListenerCtx(sessCtx)
+	if config.DefaultConfig.Cdc.Enabled || config.DefaultConfig.Backup.Continuous.Enabled {
+		// change streams and changelogs carry the value of the documents before the change
+		sessCtx = kv.WrapEventListenerCtxWithBeforeImages(sessCtx)
+	} else {
+		sessCtx = kv.WrapEventListenerCtx(sessCtx)
+	}
 
 	q := &QuerySession{
 		tx:             tx,
@@ -295,7 +299,8 @@ func (sessMgr *SessionManager) ReadOnlyExecute(ctx context.Context, runner ReadO
 func (sessMgr *SessionManager) executeWithRetry(ctx context.Context, runner QueryRunner, req ReqOptions) (resp Response, err error) {
 	delta := time.Duration(50) * time.Millisecond
 	start := time.Now()
-	for {
+	policy := config.DefaultConfig.Server.TxRetry
+	for attempt := 1; ; attempt++ {
 		var session *QuerySession
 		// implicit sessions doesn't need tracking
 		if session, err = sessMgr.Create(ctx, req.MetadataChange, req.InstantVerTracking, false); err != nil {
@@ -317,24 +322,31 @@ func (sessMgr *SessionManager) executeWithRetry(ctx context.Context, runner Quer
 		if !IsErrConflictingTransaction(err) && !search.IsErrDuplicateFieldNames(err) {
 			return
 		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			err = sessMgr.conflictError(ctx, err, attempt)
+			return
+		}
 
 		select {
 		case <-ctx.Done():
 			session.cancel()
+			err = sessMgr.conflictError(ctx, err, attempt)
 			return
 		default:
 			d, ok := ctx.Deadline()
 			if ok && time.Until(d) <= delta {
 				// if remaining is less than delta then probably not worth retrying
+				err = sessMgr.conflictError(ctx, err, attempt)
 				return
 			}
 			if !ok && time.Since(start) > (middleware.DefaultTimeout-delta) {
 				// this should not happen, adding a safeguard
+				err = sessMgr.conflictError(ctx, err, attempt)
 				return
 			}
 
-			log.Debug().Msgf("retrying transactions id: %s, since: %v", session.txCtx.Id, time.Since(start))
-			time.Sleep(time.Duration(rand.Intn(25)) * time.Millisecond) //nolint:gosec
+			log.Debug().Msgf("retrying transactions id: %s, attempt: %d, since: %v", session.txCtx.Id, attempt, time.Since(start))
+			time.Sleep(retryBackoff(&policy, attempt))
 		}
 	}
 }
@@ -380,6 +392,16 @@ func (s *QuerySession) Rollback() error {
 	return s.tx.Rollback(s.ctx)
 }
 
+// Savepoint sets a savepoint in the transaction of the session.
+func (s *QuerySession) Savepoint(name string) error {
+	return s.tx.Savepoint(s.ctx, name)
+}
+
+// RollbackToSavepoint undoes the writes done by the session after the savepoint, the transaction stays open.
+func (s *QuerySession) RollbackToSavepoint(name string) error {
+	return s.tx.RollbackToSavepoint(s.ctx, name)
+}
+
 func (s *QuerySession) Commit(versionMgr *metadata.VersionHandler, incVersion bool, err error) error {
 	defer s.cancel()
 
@@ -413,6 +435,10 @@ func (s *QuerySession) Commit(versionMgr *metadata.VersionHandler, incVersion bo
 			}
 		}
 
+		for _, fn := range s.tx.Context().GetCommitHooks() {
+			fn()
+		}
+
 		for _, listener := range s.txListeners {
 			if err = listener.OnPostCommit(s.ctx, s.tenant, kv.GetEventListener(s.ctx)); ulog.E(err) {
 				return errors.DeadlineExceeded(err.Error())
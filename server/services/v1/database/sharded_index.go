@@ -0,0 +1,184 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// indexShardPos is the position of the shard in the keys of a sharded index, it follows the keyword, the subspace and
+// the name of the index. The keys of a sharded index are:
+//
+//	[keyword, subspace, name, shard, type order, value, ..., position, primary key]
+//
+// The shard is the hash bucket of the value of the first field, so the keys of a monotonically increasing value are
+// spread over the shards instead of being appended at the end of the index, and the keys of the same value of the first
+// field are in the same shard.
+const indexShardPos = 3
+
+// indexShard returns the shard of the type order and the value of the first field of the index.
+func indexShard(shards int, typeOrder any, val any) int {
+	h := fnv.New32a()
+	_, _ = h.Write(keys.NewKey(nil, typeOrder, val).SerializeToBytes())
+
+	return int(h.Sum32() % uint32(shards))
+}
+
+// withIndexShard returns the parts of the key of a sharded index, the shard is inserted after the name of the index and
+// computed from the value of the first field. The parts are returned as is if the index is not sharded.
+func withIndexShard(index *schema.Index, parts []any) []any {
+	if index == nil || !index.IsSharded() || len(parts) < indexShardPos+2 {
+		return parts
+	}
+
+	return insertIndexShard(parts, indexShard(index.Shards, parts[indexShardPos], parts[indexShardPos+1]))
+}
+
+func insertIndexShard(parts []any, shard int) []any {
+	sharded := make([]any, 0, len(parts)+1)
+	sharded = append(sharded, parts[:indexShardPos]...)
+	sharded = append(sharded, shard)
+
+	return append(sharded, parts[indexShardPos:]...)
+}
+
+func shardKey(key keys.Key, shard int) keys.Key {
+	return keys.NewKey(key.Table(), insertIndexShard(key.IndexParts(), shard)...)
+}
+
+// sameIndexShard returns true if all the keys between the keys of the plan are in the same shard i.e. both keys have
+// the same value of the first field.
+func sameIndexShard(from keys.Key, to keys.Key) bool {
+	fromParts, toParts := from.IndexParts(), to.IndexParts()
+	if len(fromParts) < indexShardPos+2 || len(toParts) < indexShardPos+2 {
+		return false
+	}
+
+	return bytes.Equal(keys.NewKey(nil, fromParts[indexShardPos:indexShardPos+2]...).SerializeToBytes(),
+		keys.NewKey(nil, toParts[indexShardPos:indexShardPos+2]...).SerializeToBytes())
+}
+
+// newShardedIndexIterator returns the iterator of the keys of the plan in a sharded index. The keys of the plan don't
+// have the shard. The lookups of the values are done in the shards of the values, a range of values of the first field
+// is scanned in all the shards and the keys are merged in the order of the index.
+func newShardedIndexIterator(ctx context.Context, tx transaction.Tx, index *schema.Index, plan *filter.QueryPlan) (Iterator, error) {
+	switch plan.QueryType {
+	case filter.EQUAL:
+		sharded := make([]keys.Key, 0, len(plan.Keys))
+		for _, key := range plan.Keys {
+			sharded = append(sharded, keys.NewKey(key.Table(), withIndexShard(index, key.IndexParts())...))
+		}
+
+		return NewKeyIterator(ctx, tx, sharded, plan.Reverse())
+	case filter.FULLRANGE, filter.RANGE:
+		from, to := plan.Keys[0], plan.Keys[1]
+		if sameIndexShard(from, to) {
+			shard := indexShard(index.Shards, from.IndexParts()[indexShardPos], from.IndexParts()[indexShardPos+1])
+			return NewScanIterator(ctx, tx, shardKey(from, shard), shardKey(to, shard), plan.Reverse())
+		}
+
+		iters := make([]Iterator, 0, index.Shards)
+		for shard := 0; shard < index.Shards; shard++ {
+			iter, err := NewScanIterator(ctx, tx, shardKey(from, shard), shardKey(to, shard), plan.Reverse())
+			if err != nil {
+				return nil, err
+			}
+			iters = append(iters, iter)
+		}
+
+		return &shardMergeIterator{table: from.Table(), reverse: plan.Reverse(), iters: iters}, nil
+	default:
+		return nil, errors.InvalidArgument("Incorrectly created query key range")
+	}
+}
+
+// shardMergeIterator merges the keys scanned in the shards of an index in the order of the index without the shard.
+type shardMergeIterator struct {
+	table   []byte
+	reverse bool
+	iters   []Iterator
+	heads   []*Row
+	order   [][]byte
+	err     error
+}
+
+func (it *shardMergeIterator) Next(row *Row) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.heads == nil {
+		it.heads = make([]*Row, len(it.iters))
+		it.order = make([][]byte, len(it.iters))
+		for i := range it.iters {
+			if it.advance(i); it.err != nil {
+				return false
+			}
+		}
+	}
+
+	next := -1
+	for i, head := range it.heads {
+		if head == nil {
+			continue
+		}
+		if next < 0 {
+			next = i
+			continue
+		}
+		if cmp := bytes.Compare(it.order[i], it.order[next]); (cmp < 0 && !it.reverse) || (cmp > 0 && it.reverse) {
+			next = i
+		}
+	}
+	if next < 0 {
+		return false
+	}
+
+	*row = *it.heads[next]
+	it.advance(next)
+
+	return true
+}
+
+// advance reads the next key of the shard, the shard is done once it has no more keys.
+func (it *shardMergeIterator) advance(i int) {
+	var row Row
+	if !it.iters[i].Next(&row) {
+		it.heads[i] = nil
+		it.err = it.iters[i].Interrupted()
+		return
+	}
+
+	key, err := keys.FromBinary(it.table, row.Key)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	parts := key.IndexParts()
+	unsharded := append(append([]any{}, parts[:indexShardPos]...), parts[indexShardPos+1:]...)
+	it.heads[i] = &row
+	it.order[i] = keys.NewKey(nil, unsharded...).SerializeToBytes()
+}
+
+func (it *shardMergeIterator) Interrupted() error { return it.err }
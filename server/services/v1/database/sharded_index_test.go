@@ -0,0 +1,144 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+func TestShardedIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"ts": { "type": "integer", "indexShards": 4 },
+			"kind": { "type": "string" }
+		},
+		"primary_key": ["id"],
+		"indexes": [{"name": "ts_kind", "shards": 4, "fields": [{"field": "ts"}, {"field": "kind"}]}]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("sharded_t1")
+	coll.EncodedTableIndexName = []byte("sharded_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	for id := int64(1); id <= 20; id++ {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+
+		kind := "a"
+		if id%2 == 0 {
+			kind = "b"
+		}
+		pk := []any{"pkey", id}
+		td := createTD([]byte(fmt.Sprintf(`{"id":%d, "ts":%d, "kind":"%s"}`, id, id, kind)))
+		require.NoError(t, indexer.Update(ctx, tx, td, nil, pk))
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	read := func(reqFilter string, sortFields *sort.Ordering, index string) []int64 {
+		filters, err := newSecondaryIndexFilterFactory(coll).Factorize([]byte(reqFilter))
+		require.NoError(t, err)
+		plan, err := BuildSecondaryIndexKeys(coll, filters, sortFields)
+		require.NoError(t, err)
+		require.Equal(t, index, plan.FieldName)
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := NewSecondaryIndexReader(ctx, tx, coll, wrapped, plan)
+		require.NoError(t, err)
+		filtered := NewFilterIterator(iter, wrapped)
+
+		var (
+			row Row
+			ids []int64
+		)
+		for filtered.Next(&row) {
+			id, err := jsonparser.GetInt(row.Data.RawData, "id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, filtered.Interrupted())
+
+		return ids
+	}
+
+	t.Run("keys are spread over the shards", func(t *testing.T) {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		iter, err := indexer.scanIndex(ctx, tx)
+		require.NoError(t, err)
+
+		shards := map[string]map[int64]int{"ts": {}, "ts_kind": {}}
+		var entry kv.KeyValue
+		for iter.Next(&entry) {
+			key, err := keys.FromBinary(coll.EncodedTableIndexName, entry.FDBKey)
+			require.NoError(t, err)
+			if counts, ok := shards[key.IndexParts()[2].(string)]; ok {
+				counts[key.IndexParts()[indexShardPos].(int64)]++
+			}
+		}
+		require.NoError(t, iter.Err())
+
+		for name, counts := range shards {
+			require.Greater(t, len(counts), 1, name)
+			for shard := range counts {
+				require.Less(t, shard, int64(4), name)
+			}
+		}
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		require.Equal(t, []int64{5}, read(`{"ts": 5}`, nil, "ts"))
+		require.Equal(t, []int64{3, 9, 14}, read(`{"ts": {"$in": [3, 9, 14]}}`, nil, "ts"))
+	})
+
+	t.Run("range is merged in the order of the index", func(t *testing.T) {
+		require.Equal(t, []int64{3, 4, 5, 6, 7}, read(`{"$and": [{"ts": {"$gte": 3}}, {"ts": {"$lt": 8}}]}`, nil, "ts"))
+		require.Equal(t, []int64{20, 19, 18, 17}, read(`{"ts": {"$gt": 16}}`, &sort.Ordering{{Name: "ts", Ascending: false}}, "ts"))
+	})
+
+	t.Run("composite", func(t *testing.T) {
+		require.Equal(t, []int64{4}, read(`{"ts": 4, "kind": {"$gte": "a"}}`, nil, "ts_kind"))
+		require.Equal(t, []int64{20, 19, 18, 17, 16}, read(`{"ts": {"$gte": 16}}`,
+			&sort.Ordering{{Name: "ts", Ascending: false}, {Name: "kind", Ascending: false}}, "ts_kind"))
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/slowlog"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// countingIterator counts the rows read from the iterator, it wraps the iterators before the rows are filtered so that
+// the rows scanned by a read can be compared with the rows it returned.
+type countingIterator struct {
+	Iterator
+
+	rows *atomic.Int64
+}
+
+func (it *countingIterator) Next(row *Row) bool {
+	if !it.Iterator.Next(row) {
+		return false
+	}
+
+	it.rows.Add(1)
+	return true
+}
+
+// readPlan describes how the read is served for the slow query log, the reads of a secondary index have the name of
+// the index.
+func readPlan(options readerOptions) string {
+	switch {
+	case options.inMemoryStore:
+		return "search"
+	case options.memSort != nil:
+		return "full_scan sorted_in_memory"
+	case options.tablePlan != nil:
+		return "full_scan"
+	case options.plan != nil && options.covered:
+		return "covering " + options.plan.FieldName
+	case options.plan != nil && filter.IndexTypeSecondary(options.plan.IndexType):
+		return "secondary " + options.plan.FieldName
+	default:
+		return "pkey"
+	}
+}
+
+// recordSlowQuery records the read in the slow query log if it took longer than the threshold of the log.
+func (runner *StreamingQueryRunner) recordSlowQuery(tenant *metadata.Tenant, db *metadata.Database, options readerOptions, start time.Time) {
+	slowlog.Record(&slowlog.Query{
+		Namespace:  tenant.GetNamespace().StrId(),
+		Project:    runner.req.GetProject(),
+		Branch:     db.BranchName(),
+		Collection: runner.req.GetCollection(),
+		Filter:     runner.req.GetFilter(),
+		Sort:       runner.req.GetSort(),
+		Plan:       readPlan(options),
+		Scanned:    runner.scanned.Load(),
+		Returned:   runner.returned,
+		Duration:   time.Since(start),
+	})
+}
+
+// SlowQueriesRunner returns the slow reads of the collections of a project branch recorded by the slow query log.
+type SlowQueriesRunner struct {
+	*BaseQueryRunner
+
+	req *api.DescribeDatabaseRequest
+}
+
+func (runner *SlowQueriesRunner) ReadOnly(ctx context.Context, tenant *metadata.Tenant) (Response, context.Context, error) {
+	db, err := runner.getDatabase(ctx, nil, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	data, err := jsoniter.Marshal(&api.SlowQueriesResponse{
+		Project:   runner.req.GetProject(),
+		Branch:    runner.req.GetBranch(),
+		Threshold: slowlog.Threshold().String(),
+		Queries:   slowlog.Entries(tenant.GetNamespace().StrId(), runner.req.GetProject(), db.BranchName()),
+	})
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{
+		Response: &httpbody.HttpBody{
+			ContentType: "application/json",
+			Data:        data,
+		},
+	}, ctx, nil
+}
@@ -0,0 +1,368 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/read"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// systemCollection is a read-only collection of the catalog metadata, see api.IsSystemCollection. Its documents are
+// built from the in-memory catalog on every read, they are filtered like the documents of the user collections using
+// the collection built from its schema.
+type systemCollection struct {
+	name   string
+	schema []byte
+	docs   func(tenant *metadata.Tenant, db *metadata.Database) ([]any, error)
+
+	once sync.Once
+	coll *schema.DefaultCollection
+	err  error
+}
+
+var systemCollections = map[string]*systemCollection{
+	api.SystemCollections: {
+		name: api.SystemCollections,
+		schema: []byte(`{
+			"title": "_tigris.collections",
+			"properties": {
+				"name": { "type": "string" },
+				"type": { "type": "string" },
+				"schema_version": { "type": "integer" },
+				"primary_key": { "type": "array", "items": { "type": "string" } },
+				"secondary_indexes": { "type": "integer" },
+				"encrypted_fields": { "type": "array", "items": { "type": "string" } },
+				"compression": { "type": "string" },
+				"search_consistency": { "type": "string" }
+			},
+			"primary_key": ["name"]
+		}`),
+		docs: collectionDocs,
+	},
+	api.SystemIndexes: {
+		name: api.SystemIndexes,
+		schema: []byte(`{
+			"title": "_tigris.indexes",
+			"properties": {
+				"collection": { "type": "string" },
+				"name": { "type": "string" },
+				"type": { "type": "string" },
+				"fields": { "type": "array", "items": { "type": "string" } },
+				"state": { "type": "string" },
+				"unique": { "type": "boolean" },
+				"build_error": { "type": "string" }
+			},
+			"primary_key": ["collection", "name"]
+		}`),
+		docs: indexDocs,
+	},
+	api.SystemBranches: {
+		name: api.SystemBranches,
+		schema: []byte(`{
+			"title": "_tigris.branches",
+			"properties": {
+				"name": { "type": "string" },
+				"main": { "type": "boolean" },
+				"collections": { "type": "integer" },
+				"expires_at": { "type": "string", "format": "date-time" }
+			},
+			"primary_key": ["name"]
+		}`),
+		docs: branchDocs,
+	},
+}
+
+func getSystemCollection(name string) (*systemCollection, error) {
+	sys, ok := systemCollections[name]
+	if !ok {
+		return nil, errors.NotFound("system collection doesn't exist '%s'", name)
+	}
+
+	return sys, nil
+}
+
+// collection returns the collection the documents are filtered with, it is built once from the schema.
+func (sys *systemCollection) collection() (*schema.DefaultCollection, error) {
+	sys.once.Do(func() {
+		var factory *schema.Factory
+		if factory, sys.err = schema.NewFactoryBuilder(true).Build(sys.name, sys.schema); sys.err != nil {
+			return
+		}
+
+		sys.coll, sys.err = schema.NewDefaultCollection(0, 1, factory, nil, nil)
+	})
+
+	return sys.coll, sys.err
+}
+
+// rows returns the documents of the database branch in the order of their primary key.
+func (sys *systemCollection) rows(tenant *metadata.Tenant, db *metadata.Database) ([]Row, error) {
+	coll, err := sys.collection()
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := sys.docs(tenant, db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(docs))
+	for _, doc := range docs {
+		raw, err := jsoniter.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		data := internal.NewTableData(raw)
+		data.SetVersion(int32(coll.GetVersion()))
+		rows = append(rows, Row{Data: data})
+	}
+
+	return rows, nil
+}
+
+type systemCollectionDoc struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	SchemaVersion     uint32   `json:"schema_version"`
+	PrimaryKey        []string `json:"primary_key"`
+	SecondaryIndexes  int      `json:"secondary_indexes"`
+	EncryptedFields   []string `json:"encrypted_fields,omitempty"`
+	Compression       string   `json:"compression,omitempty"`
+	SearchConsistency string   `json:"search_consistency,omitempty"`
+}
+
+func collectionDocs(_ *metadata.Tenant, db *metadata.Database) ([]any, error) {
+	collections := db.ListCollection()
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+
+	docs := make([]any, 0, len(collections))
+	for _, coll := range collections {
+		doc := &systemCollectionDoc{
+			Name:              coll.Name,
+			Type:              string(coll.Type()),
+			SchemaVersion:     coll.GetVersion(),
+			PrimaryKey:        indexFieldNames(coll.GetPrimaryKey()),
+			EncryptedFields:   coll.EncryptedFields,
+			Compression:       coll.Compression,
+			SearchConsistency: coll.SearchConsistency,
+		}
+		if coll.SecondaryIndexes != nil {
+			doc.SecondaryIndexes = len(coll.SecondaryIndexes.All)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+type systemIndexDoc struct {
+	Collection string   `json:"collection"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Fields     []string `json:"fields"`
+	State      string   `json:"state,omitempty"`
+	Unique     bool     `json:"unique,omitempty"`
+	BuildError string   `json:"build_error,omitempty"`
+}
+
+func indexDocs(_ *metadata.Tenant, db *metadata.Database) ([]any, error) {
+	collections := db.ListCollection()
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+
+	var docs []any
+	for _, coll := range collections {
+		indexes := []*schema.Index{coll.GetPrimaryKey()}
+		if coll.SecondaryIndexes != nil {
+			secondary := append([]*schema.Index{}, coll.SecondaryIndexes.All...)
+			sort.Slice(secondary, func(i, j int) bool { return secondary[i].Name < secondary[j].Name })
+			indexes = append(indexes, secondary...)
+		}
+
+		for _, index := range indexes {
+			if index == nil {
+				continue
+			}
+
+			doc := &systemIndexDoc{
+				Collection: coll.Name,
+				Name:       index.Name,
+				Type:       "primary",
+				Fields:     indexFieldNames(index),
+			}
+			if index.IsSecondaryIndex() {
+				doc.Type = "secondary"
+				doc.State = indexStateName(index.State)
+				doc.Unique = index.Unique
+				doc.BuildError = index.BuildError
+			}
+
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs, nil
+}
+
+type systemBranchDoc struct {
+	Name        string `json:"name"`
+	Main        bool   `json:"main"`
+	Collections int    `json:"collections"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+func branchDocs(tenant *metadata.Tenant, db *metadata.Database) ([]any, error) {
+	project, err := tenant.GetProject(db.DbName())
+	if err != nil {
+		return nil, CreateApiError(err)
+	}
+
+	branches := tenant.ListDatabaseBranches(db.DbName())
+	sort.Strings(branches)
+
+	docs := make([]any, 0, len(branches))
+	for _, name := range branches {
+		branch, err := project.GetDatabase(metadata.NewDatabaseNameWithBranch(db.DbName(), name))
+		if err != nil {
+			// deleted since the branches were listed
+			continue
+		}
+
+		doc := &systemBranchDoc{
+			Name:        name,
+			Main:        !branch.IsBranch(),
+			Collections: len(branch.ListCollection()),
+		}
+		if expiresAt := branch.ExpiresAt(); expiresAt != 0 {
+			doc.ExpiresAt = time.Unix(expiresAt, 0).UTC().Format(time.RFC3339)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func indexFieldNames(index *schema.Index) []string {
+	if index == nil {
+		return nil
+	}
+
+	names := make([]string, len(index.Fields))
+	for i, f := range index.Fields {
+		names[i] = f.FieldName
+	}
+
+	return names
+}
+
+func indexStateName(state schema.IndexState) string {
+	switch state {
+	case schema.INDEX_ACTIVE:
+		return "ACTIVE"
+	case schema.INDEX_WRITE_MODE:
+		return "WRITE_MODE"
+	case schema.INDEX_DELETED:
+		return "DELETED"
+	case schema.NOT_INDEXED:
+		return "NOT_INDEXED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// sliceIterator iterates the rows built in memory.
+type sliceIterator struct {
+	rows []Row
+}
+
+func (it *sliceIterator) Next(row *Row) bool {
+	if len(it.rows) == 0 {
+		return false
+	}
+
+	*row = it.rows[0]
+	it.rows = it.rows[1:]
+
+	return true
+}
+
+func (*sliceIterator) Interrupted() error { return nil }
+
+// readSystemCollection streams the documents of the system collection of the database branch of the request which
+// match its filter. The system collections don't support sorting, the documents are returned in the order of their
+// primary key.
+func (runner *StreamingQueryRunner) readSystemCollection(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	sys, err := getSystemCollection(runner.req.GetCollection())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	if len(runner.req.GetSort()) > 0 {
+		return Response{}, ctx, errors.InvalidArgument("sorting is not supported on the system collections")
+	}
+
+	db, err := runner.getDatabase(ctx, tx, tenant, runner.req.GetProject(), runner.req.GetBranch())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	coll, err := sys.collection()
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	var collation *value.Collation
+	if runner.req.Options != nil {
+		collation = value.NewCollationFrom(runner.req.Options.Collation)
+	}
+
+	wrapped, err := filter.NewFactory(coll.QueryableFields, collation).WrappedFilter(runner.req.Filter)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	fieldFactory, err := read.BuildFields(runner.req.GetFields())
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	rows, err := sys.rows(tenant, db)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+
+	runner.queryMetrics.SetReadType("system")
+	if _, err = runner.iterate(ctx, coll, NewFilterIterator(&sliceIterator{rows: rows}, wrapped), fieldFactory); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	return Response{}, ctx, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/util"
+)
+
+func TestSystemCollections(t *testing.T) {
+	for name, sys := range systemCollections {
+		coll, err := sys.collection()
+		require.NoError(t, err, name)
+		require.Equal(t, name, coll.Name)
+	}
+
+	_, err := getSystemCollection("_tigris.unknown")
+	require.Error(t, err)
+
+	sys := &systemCollection{
+		name:   systemCollections["_tigris.indexes"].name,
+		schema: systemCollections["_tigris.indexes"].schema,
+		docs: func(*metadata.Tenant, *metadata.Database) ([]any, error) {
+			return []any{
+				&systemIndexDoc{Collection: "c1", Name: "pkey", Type: "primary", Fields: []string{"id"}},
+				&systemIndexDoc{Collection: "c1", Name: "a", Type: "secondary", Fields: []string{"a"}, State: indexStateName(schema.INDEX_ACTIVE)},
+				&systemIndexDoc{Collection: "c2", Name: "b", Type: "secondary", Fields: []string{"b"}, State: indexStateName(schema.INDEX_WRITE_MODE)},
+			}, nil
+		},
+	}
+
+	coll, err := sys.collection()
+	require.NoError(t, err)
+
+	rows, err := sys.rows(nil, nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	for _, c := range []struct {
+		filter   string
+		expected []string
+	}{
+		{`{}`, []string{"pkey", "a", "b"}},
+		{`{"type": "secondary"}`, []string{"a", "b"}},
+		{`{"$and": [{"collection": "c1"}, {"state": "ACTIVE"}]}`, []string{"a"}},
+		{`{"state": "DELETED"}`, nil},
+	} {
+		wrapped, err := filter.NewFactory(coll.QueryableFields, nil).WrappedFilter([]byte(c.filter))
+		require.NoError(t, err)
+
+		var (
+			row   Row
+			names []string
+		)
+		it := NewFilterIterator(&sliceIterator{rows: rows}, wrapped)
+		for it.Next(&row) {
+			doc, err := util.JSONToMap(row.Data.RawData)
+			require.NoError(t, err)
+			names = append(names, doc["name"].(string))
+		}
+		require.NoError(t, it.Interrupted())
+		require.Equal(t, c.expected, names, c.filter)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// TemplateRequest is used to manage the schema templates of a project.
+type TemplateRequest struct {
+	Project string
+	Name    string
+	Schema  []byte
+}
+
+// TemplateQueryRunner manages the project level schema templates. Updating a template fans out the change to all the
+// collections extending the template in every branch of the project, as part of the same transaction.
+type TemplateQueryRunner struct {
+	*BaseQueryRunner
+
+	createOrUpdateReq *TemplateRequest
+	getReq            *TemplateRequest
+	listReq           *TemplateRequest
+	deleteReq         *TemplateRequest
+
+	templates []metadata.SchemaTemplate
+}
+
+func (runner *TemplateQueryRunner) SetCreateOrUpdateTemplateReq(req *TemplateRequest) {
+	runner.createOrUpdateReq = req
+}
+
+func (runner *TemplateQueryRunner) SetGetTemplateReq(req *TemplateRequest) {
+	runner.getReq = req
+}
+
+func (runner *TemplateQueryRunner) SetListTemplatesReq(req *TemplateRequest) {
+	runner.listReq = req
+}
+
+func (runner *TemplateQueryRunner) SetDeleteTemplateReq(req *TemplateRequest) {
+	runner.deleteReq = req
+}
+
+// Templates returns the templates read or written by the last run.
+func (runner *TemplateQueryRunner) Templates() []metadata.SchemaTemplate {
+	return runner.templates
+}
+
+func (runner *TemplateQueryRunner) createOrUpdate(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	req := runner.createOrUpdateReq
+
+	project, err := tenant.GetProject(req.Project)
+	if err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	if err = schema.ValidateTemplate(req.Name, req.Schema); err != nil {
+		return Response{}, ctx, err
+	}
+
+	var currentSub string
+	if runner.accessToken != nil {
+		currentSub = runner.accessToken.Sub
+	}
+
+	template, err := tenant.CreateOrUpdateSchemaTemplate(ctx, tx, req.Project, req.Name, req.Schema, currentSub)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.templates = []metadata.SchemaTemplate{*template}
+
+	var modified int32
+	for _, db := range project.GetDatabaseWithBranches() {
+		var cloned *metadata.Database
+		for _, coll := range db.ListCollection() {
+			if schema.GetTemplateName(coll.Schema) != req.Name {
+				continue
+			}
+
+			if cloned == nil {
+				// do not modify the actual database object, the tenant is reloaded once the transaction commits
+				cloned = db.Clone()
+			}
+
+			if err = runner.evolveCollection(ctx, tx, tenant, cloned, coll, template.Schema); err != nil {
+				return Response{}, ctx, err
+			}
+			modified++
+		}
+	}
+
+	return Response{Status: CreatedStatus, ModifiedCount: modified}, ctx, nil
+}
+
+// evolveCollection re-applies the template on the collection schema and runs it through the regular schema update
+// path, so all the compatibility checks of the schema evolution apply to the template changes as well.
+func (*TemplateQueryRunner) evolveCollection(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant,
+	db *metadata.Database, coll *schema.DefaultCollection, template []byte,
+) error {
+	reqSchema, err := schema.ApplyTemplate(coll.Schema, template, true)
+	if err != nil {
+		return err
+	}
+
+	if db.CurrentSchemaVersion != 0 {
+		// database is versioned, so the fanned out schema is the next version of the database schema
+		version := db.CurrentSchemaVersion + 1
+		if db.PendingSchemaVersion != 0 {
+			version = db.PendingSchemaVersion
+		}
+		if reqSchema, err = jsonparser.Set(reqSchema, []byte(strconv.FormatUint(uint64(version), 10)), "version"); err != nil {
+			return err
+		}
+	}
+
+	schFactory, err := schema.NewFactoryBuilder(true).Build(coll.Name, reqSchema)
+	if err != nil {
+		return errors.InvalidArgument("collection '%s': %s", coll.Name, err.Error())
+	}
+
+	if err = metadata.UpdateSchemaVersion(ctx, tenant.MetaStore, tx, tenant.GetNamespace().Id(), db, schFactory); err != nil {
+		return err
+	}
+
+	return tenant.CreateCollection(ctx, tx, db, schFactory)
+}
+
+func (runner *TemplateQueryRunner) get(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	template, err := tenant.GetSchemaTemplate(ctx, tx, runner.getReq.Project, runner.getReq.Name)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.templates = []metadata.SchemaTemplate{*template}
+
+	return Response{}, ctx, nil
+}
+
+func (runner *TemplateQueryRunner) list(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if _, err := tenant.GetProject(runner.listReq.Project); err != nil {
+		return Response{}, ctx, CreateApiError(err)
+	}
+
+	templates, err := tenant.ListSchemaTemplates(ctx, tx, runner.listReq.Project)
+	if err != nil {
+		return Response{}, ctx, err
+	}
+	runner.templates = templates
+
+	return Response{}, ctx, nil
+}
+
+func (runner *TemplateQueryRunner) delete(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	if err := tenant.DeleteSchemaTemplate(ctx, tx, runner.deleteReq.Project, runner.deleteReq.Name); err != nil {
+		return Response{}, ctx, err
+	}
+
+	return Response{Status: DeletedStatus}, ctx, nil
+}
+
+func (runner *TemplateQueryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, context.Context, error) {
+	switch {
+	case runner.createOrUpdateReq != nil:
+		return runner.createOrUpdate(ctx, tx, tenant)
+	case runner.getReq != nil:
+		return runner.get(ctx, tx, tenant)
+	case runner.listReq != nil:
+		return runner.list(ctx, tx, tenant)
+	case runner.deleteReq != nil:
+		return runner.delete(ctx, tx, tenant)
+	}
+
+	return Response{}, ctx, errors.Unknown("unknown request path")
+}
@@ -0,0 +1,192 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// uniqueCheck is a value added to a unique index by a write. The prefix is the index key of the value without the
+// position and the primary key, the keys of the other documents with the same value share it.
+type uniqueCheck struct {
+	index  *schema.Index
+	row    IndexRow
+	prefix []any
+}
+
+// buildUniqueChecks returns the checks of the rows added to the unique indexes. Null and missing values are not
+// considered duplicates, so they are not checked.
+func (q *SecondaryIndexerImpl) buildUniqueChecks(rows []IndexRow) []uniqueCheck {
+	var checks []uniqueCheck
+	for _, row := range rows {
+		index := schema.FindIndex(q.coll.SecondaryIndexes.All, row.name)
+		if index == nil || !index.Unique || row.stub || hasNullValue(row) {
+			continue
+		}
+
+		checks = append(checks, uniqueCheck{index: index, row: row, prefix: q.buildIndexParts(row)})
+	}
+
+	return checks
+}
+
+// checkUnique fails the write if a value added to a unique index is already indexed for another document. The range of
+// the value is read inside the transaction of the write, so the concurrent writes of the same value conflict and only
+// one of them is committed.
+func (q *SecondaryIndexerImpl) checkUnique(ctx context.Context, tx transaction.Tx, checks []uniqueCheck, primaryKey []any) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	docKey := keys.NewKey(q.coll.EncodedName, primaryKey...).SerializeToBytes()
+	for _, check := range checks {
+		// the prefix range covers every position, a 0xFF end bound would stop at the positions that pack above it
+		iter, err := tx.Read(ctx, keys.NewKey(q.coll.EncodedTableIndexName, check.prefix...), false)
+		if err != nil {
+			return err
+		}
+
+		var indexKV kv.KeyValue
+		for iter.Next(&indexKV) {
+			indexKey, err := keys.FromBinary(q.coll.EncodedTableIndexName, indexKV.FDBKey)
+			if err != nil {
+				return err
+			}
+
+			// the value is followed by the position and the primary key
+			pks := indexKey.IndexParts()[len(check.prefix)+1:]
+			if bytes.Equal(keys.NewKey(q.coll.EncodedName, pks...).SerializeToBytes(), docKey) {
+				continue
+			}
+
+			duplicate, err := q.isDuplicate(ctx, tx, check, pks)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				return uniqueViolation(q.coll, check, pks)
+			}
+		}
+		if err = iter.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isDuplicate compares the value with the value of the indexed document. The index keys of the values may be the same
+// even if the values are not, long strings are truncated in the index keys.
+func (q *SecondaryIndexerImpl) isDuplicate(ctx context.Context, tx transaction.Tx, check uniqueCheck, pks []any) (bool, error) {
+	doc, err := readDocument(ctx, tx, keys.NewKey(q.coll.EncodedName, pks...))
+	if err != nil || doc == nil {
+		return false, err
+	}
+
+	rows, err := q.buildTableRows(doc)
+	if err != nil {
+		return false, err
+	}
+
+	for _, row := range rows {
+		if row.name == check.row.name && sameIndexValues(row, check.row) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func hasNullValue(row IndexRow) bool {
+	if row.composite == nil {
+		return row.null || row.dataType == schema.NullType
+	}
+
+	for _, v := range row.composite {
+		if v.dataType == schema.NullType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameIndexValues(a IndexRow, b IndexRow) bool {
+	if a.composite == nil || b.composite == nil {
+		return a.composite == nil && b.composite == nil && sameValue(a.value, b.value)
+	}
+
+	if len(a.composite) != len(b.composite) {
+		return false
+	}
+	for i := range a.composite {
+		if !sameValue(a.composite[i].value, b.composite[i].value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameValue(a value.Value, b value.Value) bool {
+	if sa, ok := a.(*value.StringValue); ok {
+		sb, ok := b.(*value.StringValue)
+		return ok && sa.Value == sb.Value
+	}
+
+	c, err := a.CompareTo(b)
+
+	return err == nil && c == 0
+}
+
+// uniqueViolation returns the error of the value of the check which already exists in the document with the primary
+// key.
+func uniqueViolation(coll *schema.DefaultCollection, check uniqueCheck, pks []any) error {
+	var values []string
+	if check.row.composite != nil {
+		for _, v := range check.row.composite {
+			values = append(values, v.value.String())
+		}
+	} else {
+		values = append(values, check.row.value.String())
+	}
+	val := strings.Join(values, ",")
+
+	// the first part is the primary key index
+	var key string
+	if len(pks) > 1 {
+		if fields, err := json.Marshal(pks[1:]); err == nil {
+			key = string(fields)
+		}
+	}
+
+	return api.Errorf(api.Code_ALREADY_EXISTS, "duplicate value '%s' violates unique index '%s', the value exists in document %s",
+		val, check.index.Name, key).WithDetails(api.NewUniqueViolationInfo(&api.UniqueViolationInfo{
+		Collection: coll.Name,
+		Index:      check.index.Name,
+		Value:      val,
+		Key:        key,
+	}))
+}
@@ -0,0 +1,161 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+func TestUniqueIndex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexer := setupActiveIndexTest(t, []byte(`{
+		"title": "t1",
+		"properties": {
+			"id": { "type": "integer" },
+			"email": { "type": "string", "unique": true },
+			"city": { "type": "string" },
+			"name": { "type": "string" }
+		},
+		"primary_key": ["id"],
+		"indexes": [
+			{ "name": "city_name", "unique": true, "fields": [{ "field": "city" }, { "field": "name" }] }
+		]
+	}`))
+	coll := indexer.coll
+	coll.EncodedName = []byte("unique_t1")
+	coll.EncodedTableIndexName = []byte("unique_sidx1")
+
+	for _, table := range [][]byte{coll.EncodedName, coll.EncodedTableIndexName} {
+		require.NoError(t, kvStore.DropTable(ctx, table))
+		require.NoError(t, kvStore.CreateTable(ctx, table))
+	}
+
+	tm := transaction.NewManager(kvStore)
+	docs := map[int64]*internal.TableData{}
+
+	write := func(id int64, doc string) error {
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+
+		pk := []any{"pkey", id}
+		td := createTD([]byte(doc))
+		if err = indexer.Update(ctx, tx, td, docs[id], pk); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		require.NoError(t, tx.Commit(ctx))
+		docs[id] = td
+
+		return nil
+	}
+
+	requireViolation := func(err error, index string, val string, key string) {
+		require.Error(t, err)
+		apiErr, ok := err.(*api.TigrisError)
+		require.True(t, ok)
+		require.Equal(t, api.Code_ALREADY_EXISTS, apiErr.Code)
+		require.Equal(t, &api.UniqueViolationInfo{Collection: "t1", Index: index, Value: val, Key: key}, apiErr.UniqueViolation())
+	}
+
+	require.NoError(t, write(1, `{"id":1, "email":"a@b.c", "city":"sf", "name":"a"}`))
+
+	t.Run("duplicate", func(t *testing.T) {
+		requireViolation(write(2, `{"id":2, "email":"a@b.c", "city":"la", "name":"a"}`), "email", "a@b.c", "[1]")
+	})
+
+	t.Run("case sensitive", func(t *testing.T) {
+		require.NoError(t, write(2, `{"id":2, "email":"A@b.c", "city":"la", "name":"a"}`))
+	})
+
+	t.Run("update to duplicate", func(t *testing.T) {
+		requireViolation(write(2, `{"id":2, "email":"a@b.c", "city":"la", "name":"a"}`), "email", "a@b.c", "[1]")
+	})
+
+	t.Run("update keeping own value", func(t *testing.T) {
+		require.NoError(t, write(1, `{"id":1, "email":"a@b.c", "city":"sf", "name":"a", "extra": 1}`))
+	})
+
+	t.Run("value freed by update", func(t *testing.T) {
+		require.NoError(t, write(1, `{"id":1, "email":"c@b.c", "city":"sf", "name":"a"}`))
+		require.NoError(t, write(3, `{"id":3, "email":"a@b.c"}`))
+	})
+
+	t.Run("null and missing", func(t *testing.T) {
+		require.NoError(t, write(4, `{"id":4, "email":null}`))
+		require.NoError(t, write(5, `{"id":5, "email":null}`))
+		require.NoError(t, write(6, `{"id":6}`))
+	})
+
+	t.Run("long strings with the same prefix", func(t *testing.T) {
+		prefix := strings.Repeat("x", 100)
+		require.NoError(t, write(7, `{"id":7, "email":"`+prefix+`1"}`))
+		require.NoError(t, write(8, `{"id":8, "email":"`+prefix+`2"}`))
+		requireViolation(write(9, `{"id":9, "email":"`+prefix+`1"}`), "email", prefix+"1", "[7]")
+	})
+
+	t.Run("composite", func(t *testing.T) {
+		require.NoError(t, write(10, `{"id":10, "email":"e10", "city":"sf", "name":"b"}`))
+		requireViolation(write(11, `{"id":11, "email":"e11", "city":"sf", "name":"b"}`), "city_name", "sf,b", "[10]")
+		// a null field is not a duplicate
+		require.NoError(t, write(12, `{"id":12, "email":"e12", "city":"sf"}`))
+		require.NoError(t, write(13, `{"id":13, "email":"e13", "city":"sf"}`))
+	})
+
+	t.Run("position above 255", func(t *testing.T) {
+		// the value is indexed at the position of an element of an array with more than 256 elements
+		pk := []any{"pkey", int64(30)}
+		td := createTD([]byte(`{"id":30, "email":"pos"}`))
+		rows, err := indexer.buildTableRows(td)
+		require.NoError(t, err)
+
+		tx, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		for _, row := range rows {
+			if row.name == "email" {
+				row.pos = 300
+				require.NoError(t, tx.Replace(ctx, indexer.buildIndexKey(row, pk), internal.EmptyData, false))
+			}
+		}
+		require.NoError(t, tx.Replace(ctx, keys.NewKey(coll.EncodedName, pk...), td, false))
+		require.NoError(t, tx.Commit(ctx))
+
+		requireViolation(write(31, `{"id":31, "email":"pos"}`), "email", "pos", "[30]")
+	})
+
+	t.Run("concurrent writes conflict", func(t *testing.T) {
+		tx1, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+		tx2, err := tm.StartTx(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, indexer.Index(ctx, tx1, createTD([]byte(`{"id":20, "email":"same"}`)), []any{"pkey", int64(20)}))
+		require.NoError(t, indexer.Index(ctx, tx2, createTD([]byte(`{"id":21, "email":"same"}`)), []any{"pkey", int64(21)}))
+
+		require.NoError(t, tx1.Commit(ctx))
+		require.Error(t, tx2.Commit(ctx))
+	})
+}
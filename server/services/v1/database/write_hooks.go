@@ -0,0 +1,279 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/expression"
+	"github.com/tigrisdata/tigris/query/expression/cel"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/util"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// writeHooks are the write hooks of the collection running on the documents of a write request, the conditions of the
+// hooks are built once per request.
+type writeHooks struct {
+	project    string
+	branch     string
+	coll       *schema.DefaultCollection
+	hooks      []*compiledWriteHook
+	maxRunTime time.Duration
+}
+
+type compiledWriteHook struct {
+	*schema.WriteHook
+
+	when    *writeHookCondition
+	require *writeHookCondition
+	set     []writeHookAssignment
+}
+
+// writeHookCondition is either a filter or a CEL condition.
+type writeHookCondition struct {
+	filter *filter.WrappedFilter
+	expr   *cel.Program
+}
+
+// writeHookAssignment sets the field at the path either to the JSON value or to the value of one of the expressions.
+type writeHookAssignment struct {
+	path    []string
+	value   []byte
+	expr    *schema.IndexExpression
+	program *cel.Program
+}
+
+// newWriteHooks returns the hooks of the collection running on the write, nil if there is none or the hooks are
+// disabled.
+func newWriteHooks(db *metadata.Database, coll *schema.DefaultCollection, write string) (*writeHooks, error) {
+	if !config.DefaultConfig.WriteHooks.Enabled || !coll.HasWriteHooks(write) {
+		return nil, nil
+	}
+
+	hooks := &writeHooks{
+		project:    db.DbName(),
+		branch:     db.BranchName(),
+		coll:       coll,
+		maxRunTime: config.DefaultConfig.WriteHooks.MaxDuration,
+	}
+	factory := filter.NewFactory(coll.QueryableFields, nil)
+	for _, h := range coll.WriteHooks {
+		if !h.RunsOn(write) {
+			continue
+		}
+
+		compiled := &compiledWriteHook{WriteHook: h}
+		for _, f := range []struct {
+			raw    jsoniter.RawMessage
+			target **writeHookCondition
+		}{{h.When, &compiled.when}, {h.Require, &compiled.require}} {
+			if len(f.raw) == 0 {
+				continue
+			}
+
+			if expr, ok := schema.ConditionExpression(f.raw); ok {
+				program, err := cel.CompileBool(expr)
+				if err != nil {
+					return nil, err
+				}
+				*f.target = &writeHookCondition{expr: program}
+				continue
+			}
+
+			wrapped, err := factory.WrappedFilter(f.raw)
+			if err != nil {
+				return nil, errors.InvalidArgument("invalid filter of hook '%s': %s", h.Name, err.Error())
+			}
+			*f.target = &writeHookCondition{filter: wrapped}
+		}
+
+		paths := make([]string, 0, len(h.Set))
+		for path := range h.Set {
+			paths = append(paths, path)
+		}
+		// the values are set in a deterministic order
+		sort.Strings(paths)
+		for _, path := range paths {
+			a := writeHookAssignment{path: strings.Split(path, "."), value: h.Set[path]}
+
+			var err error
+			if expr, ok := schema.SetCELExpression(a.value); ok {
+				a.program, err = cel.Compile(expr)
+			} else {
+				a.expr, err = h.SetExpression(a.value)
+			}
+			if err != nil {
+				return nil, err
+			}
+			compiled.set = append(compiled.set, a)
+		}
+
+		hooks.hooks = append(hooks.hooks, compiled)
+	}
+
+	return hooks, nil
+}
+
+// apply runs the hooks on the document and returns the document with the values set by them. The document is
+// validated against the schema again if a hook set a value. The write is rejected if a hook requires a condition the
+// document doesn't match, or if running the hooks takes longer than the configured maximum duration.
+func (h *writeHooks) apply(ctx context.Context, doc []byte) ([]byte, error) {
+	if h == nil {
+		return doc, nil
+	}
+
+	var (
+		err     error
+		mutated bool
+		start   = time.Now()
+	)
+	for _, hook := range h.hooks {
+		hookStart := time.Now()
+
+		outcome := metrics.WriteHookSkipped
+		matches := true
+		if hook.when != nil {
+			matches, err = hook.when.matches(ctx, doc)
+		}
+		if matches && err == nil {
+			var set bool
+			if doc, set, err = hook.run(ctx, doc); err == nil {
+				outcome = metrics.WriteHookApplied
+				mutated = mutated || set
+			}
+
+			if hook.require != nil && err == nil {
+				if matches, err = hook.require.matches(ctx, doc); err == nil && !matches {
+					outcome = metrics.WriteHookRejected
+					err = hook.rejected()
+				}
+			}
+		}
+		if err != nil && outcome != metrics.WriteHookRejected {
+			outcome = metrics.WriteHookFailed
+		}
+
+		metrics.WriteHookRun(h.project, h.branch, h.coll.Name, hook.Name, outcome, time.Since(hookStart))
+		if err != nil {
+			return nil, err
+		}
+
+		if h.maxRunTime > 0 && time.Since(start) > h.maxRunTime {
+			return nil, errors.ResourceExhausted("hooks of the collection '%s' took longer than %s", h.coll.Name, h.maxRunTime)
+		}
+	}
+
+	if mutated && request.NeedSchemaValidation(ctx) {
+		deserialized, err := util.JSONToMap(doc)
+		if err != nil {
+			return nil, err
+		}
+		if err = h.coll.Validate(deserialized); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// run sets the values of the hook on the document, it returns true if any value is set. The expressions are computed
+// from the document before the hook runs, an expression which value is null, like a function of a missing field,
+// doesn't set the value.
+func (hook *compiledWriteHook) run(ctx context.Context, doc []byte) ([]byte, bool, error) {
+	if len(hook.set) == 0 {
+		return doc, false, nil
+	}
+
+	// the values are set on a copy as setting a value may overwrite the bytes of the document
+	original := doc
+	doc = append([]byte(nil), doc...)
+
+	var decoded map[string]any
+	set := false
+	for _, a := range hook.set {
+		v := a.value
+		if a.program != nil {
+			if decoded == nil {
+				var err error
+				if decoded, err = util.JSONToMap(original); err != nil {
+					return nil, false, err
+				}
+			}
+
+			computed, err := a.program.Eval(ctx, cel.Vars{Doc: decoded})
+			if err != nil {
+				return nil, false, err
+			}
+			if computed == nil {
+				continue
+			}
+			if v, err = jsoniter.Marshal(computed); err != nil {
+				return nil, false, err
+			}
+		} else if a.expr != nil {
+			computed, err := expression.Evaluate(a.expr, original, nil)
+			if err != nil {
+				return nil, false, err
+			}
+			if _, ok := computed.(*value.NullValue); ok {
+				continue
+			}
+			if v, err = jsoniter.Marshal(computed.AsInterface()); err != nil {
+				return nil, false, err
+			}
+		}
+
+		var err error
+		if doc, err = jsonparser.Set(doc, v, a.path...); err != nil {
+			return nil, false, errors.InvalidArgument("hook '%s' can't set the field '%s'", hook.Name, strings.Join(a.path, "."))
+		}
+		set = true
+	}
+
+	return doc, set, nil
+}
+
+func (c *writeHookCondition) matches(ctx context.Context, doc []byte) (bool, error) {
+	if c.expr == nil {
+		return c.filter.Matches(doc, nil), nil
+	}
+
+	decoded, err := util.JSONToMap(doc)
+	if err != nil {
+		return false, err
+	}
+
+	return c.expr.EvalBool(ctx, cel.Vars{Doc: decoded})
+}
+
+func (hook *compiledWriteHook) rejected() error {
+	if len(hook.Message) > 0 {
+		return errors.InvalidArgument("document is rejected by the hook '%s': %s", hook.Name, hook.Message)
+	}
+
+	return errors.InvalidArgument("document is rejected by the hook '%s'", hook.Name)
+}
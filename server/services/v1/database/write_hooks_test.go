@@ -0,0 +1,129 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+)
+
+func setupWriteHooksTest(t *testing.T, hooks string) *schema.DefaultCollection {
+	factory, err := schema.NewFactoryBuilder(true).Build("orders", []byte(`{
+		"title": "orders",
+		"properties": {
+			"id": {"type": "integer"},
+			"email": {"type": "string"},
+			"status": {"type": "string", "maxLength": 8},
+			"total": {"type": "number"}
+		},
+		"primary_key": ["id"],
+		"hooks": `+hooks+`
+	}`))
+	require.NoError(t, err)
+
+	coll, err := schema.NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	return coll
+}
+
+func TestWriteHooks(t *testing.T) {
+	db := metadata.NewDatabase(1, "db1")
+	coll := setupWriteHooksTest(t, `[
+		{"name": "normalize", "on": ["insert", "update"], "set": {"email": {"$expr": "lower(email)"}}},
+		{"name": "defaults", "on": ["insert"], "when": {"status": "draft"}, "set": {"total": 0}},
+		{"name": "paid", "on": ["insert", "update"], "when": {"status": "paid"}, "require": {"total": {"$gt": 0}}, "message": "a paid order has a total"}
+	]`)
+
+	inserts, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+	require.NoError(t, err)
+	require.Len(t, inserts.hooks, 3)
+
+	updates, err := newWriteHooks(db, coll, schema.WriteHookOnUpdate)
+	require.NoError(t, err)
+	require.Len(t, updates.hooks, 2)
+
+	doc := []byte(`{"id":1,"email":"Ann@Example.com","status":"draft","total":10}`)
+	mutated, err := inserts.apply(context.Background(), doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"email":"ann@example.com","status":"draft","total":0}`, string(mutated))
+	// the input document is not modified
+	require.Equal(t, `{"id":1,"email":"Ann@Example.com","status":"draft","total":10}`, string(doc))
+
+	mutated, err = updates.apply(context.Background(), []byte(`{"id":1,"status":"paid","total":5}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"status":"paid","total":5}`, string(mutated))
+
+	_, err = updates.apply(context.Background(), []byte(`{"id":1,"email":"a@b.c","status":"paid","total":0}`))
+	require.Equal(t, errors.InvalidArgument("document is rejected by the hook 'paid': a paid order has a total"), err)
+
+	t.Run("expressions", func(t *testing.T) {
+		coll := setupWriteHooksTest(t, `[
+			{"name": "normalize", "on": ["insert"], "when": "has(doc.email)", "set": {"email": {"$cel": "doc.email.trim().lowerAscii()"}, "status": {"$cel": "has(doc.status) ? doc.status : 'new'"}}},
+			{"name": "paid", "on": ["insert"], "require": "doc.status != 'paid' || doc.total > 0"}
+		]`)
+		hooks, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+		require.NoError(t, err)
+
+		mutated, err := hooks.apply(context.Background(), []byte(`{"id":1,"email":" Ann@Example.com "}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1,"email":"ann@example.com","status":"new"}`, string(mutated))
+
+		mutated, err = hooks.apply(context.Background(), []byte(`{"id":1,"status":"paid","total":1.5}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1,"status":"paid","total":1.5}`, string(mutated))
+
+		_, err = hooks.apply(context.Background(), []byte(`{"id":1,"status":"paid","total":0}`))
+		require.Equal(t, errors.InvalidArgument("document is rejected by the hook 'paid'"), err)
+	})
+
+	t.Run("schema validation", func(t *testing.T) {
+		coll := setupWriteHooksTest(t, `[{"name": "status", "on": ["insert"], "set": {"status": "too long status"}}]`)
+		hooks, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+		require.NoError(t, err)
+
+		_, err = hooks.apply(context.Background(), []byte(`{"id":1,"status":"new"}`))
+		require.Error(t, err)
+	})
+
+	t.Run("time limit", func(t *testing.T) {
+		hooks, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+		require.NoError(t, err)
+		hooks.maxRunTime = time.Nanosecond
+
+		_, err = hooks.apply(context.Background(), []byte(`{"id":1,"email":"a@b.c"}`))
+		require.Equal(t, errors.ResourceExhausted("hooks of the collection 'orders' took longer than 1ns"), err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		config.DefaultConfig.WriteHooks.Enabled = false
+		defer func() { config.DefaultConfig.WriteHooks.Enabled = true }()
+
+		hooks, err := newWriteHooks(db, coll, schema.WriteHookOnInsert)
+		require.NoError(t, err)
+		require.Nil(t, hooks)
+
+		mutated, err := hooks.apply(context.Background(), doc)
+		require.NoError(t, err)
+		require.Equal(t, doc, mutated)
+	})
+}
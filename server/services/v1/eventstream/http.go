@@ -0,0 +1,115 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventstream serves the HTTP variant of the AppendStream and ReadStream APIs of the event streams.
+package eventstream
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// Handler appends the events of the request body, {"events": [...], "branch": "..."}, to the stream of the path, and
+// reads the events of the stream after the position of the "after" query parameter, at most "limit" of them. The
+// appends check the version of the stream expected by the Tigris-Expected-Version header, if any.
+type Handler struct {
+	client api.EventStreamClient
+}
+
+func NewHandler(client api.EventStreamClient) *Handler {
+	return &Handler{client: client}
+}
+
+type appendRequest struct {
+	Branch string                `json:"branch"`
+	Events []jsoniter.RawMessage `json:"events"`
+}
+
+func (h *Handler) Append(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.append(r)
+	write(w, resp, err)
+}
+
+func (h *Handler) Read(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.read(r)
+	write(w, resp, err)
+}
+
+func (h *Handler) append(r *http.Request) (*httpbody.HttpBody, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req appendRequest
+	if err = jsoniter.Unmarshal(body, &req); err != nil {
+		return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+	}
+
+	events := make([][]byte, 0, len(req.Events))
+	for _, e := range req.Events {
+		events = append(events, e)
+	}
+
+	return h.client.AppendStream(common.OutgoingContext(r), &api.InsertRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "stream"),
+		Branch:     req.Branch,
+		Documents:  events,
+	})
+}
+
+func (h *Handler) read(r *http.Request) (*httpbody.HttpBody, error) {
+	query := r.URL.Query()
+
+	options := &api.ReadRequestOptions{Offset: []byte(query.Get("after"))}
+	if limit := query.Get("limit"); limit != "" {
+		v, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || v < 0 {
+			return nil, errors.InvalidArgument("invalid limit '%s'", limit)
+		}
+		options.Limit = v
+	}
+
+	return h.client.ReadStream(common.OutgoingContext(r), &api.ReadRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "stream"),
+		Branch:     query.Get("branch"),
+		Options:    options,
+	})
+}
+
+func write(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
@@ -0,0 +1,119 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	appendReq *api.InsertRequest
+	readReq   *api.ReadRequest
+	md        metadata.MD
+	err       error
+}
+
+func (c *testClient) AppendStream(ctx context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.appendReq = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &httpbody.HttpBody{
+		ContentType: "application/json",
+		Data:        []byte(`{"status":"appended","stream":"order-1","first_version":3,"version":4}`),
+	}, nil
+}
+
+func (c *testClient) ReadStream(ctx context.Context, in *api.ReadRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.readReq = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &httpbody.HttpBody{
+		ContentType: "application/json",
+		Data:        []byte(`{"stream":"order-1","version":4,"events":[]}`),
+	}, nil
+}
+
+func serve(client *testClient, method string, target string, body string) *httptest.ResponseRecorder {
+	h := NewHandler(client)
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/database/streams/{stream}/append", h.Append)
+	router.Get("/v1/projects/{project}/database/streams/{stream}/read", h.Read)
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set(api.HeaderExpectedVersion, "2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestAppend(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, http.MethodPost, "/v1/projects/p1/database/streams/order-1/append",
+		`{"branch": "b1", "events": [{"type": "order_created"}, {"type": "order_paid"}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"status":"appended","stream":"order-1","first_version":3,"version":4}`, w.Body.String())
+
+	require.Equal(t, "p1", client.appendReq.Project)
+	require.Equal(t, "order-1", client.appendReq.Collection)
+	require.Equal(t, "b1", client.appendReq.Branch)
+	require.Len(t, client.appendReq.Documents, 2)
+	require.JSONEq(t, `{"type": "order_paid"}`, string(client.appendReq.Documents[1]))
+	require.Equal(t, []string{"2"}, client.md.Get(api.HeaderExpectedVersion))
+
+	w = serve(client, http.MethodPost, "/v1/projects/p1/database/streams/order-1/append", `{"events": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	client.err = errors.FailedPrecondition("stream 'order-1' is at version 4, expected version 2")
+	w = serve(client, http.MethodPost, "/v1/projects/p1/database/streams/order-1/append", `{"events": [{}]}`)
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+	require.JSONEq(t, `{"error": {"code": "FAILED_PRECONDITION", "message": "stream 'order-1' is at version 4, expected version 2"}}`,
+		w.Body.String())
+}
+
+func TestRead(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, http.MethodGet, "/v1/projects/p1/database/streams/order-1/read?branch=b1&after=00ff&limit=10", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"stream":"order-1","version":4,"events":[]}`, w.Body.String())
+
+	require.Equal(t, "p1", client.readReq.Project)
+	require.Equal(t, "order-1", client.readReq.Collection)
+	require.Equal(t, "b1", client.readReq.Branch)
+	require.Equal(t, []byte("00ff"), client.readReq.Options.Offset)
+	require.Equal(t, int64(10), client.readReq.Options.Limit)
+
+	w = serve(client, http.MethodGet, "/v1/projects/p1/database/streams/order-1/read?limit=abc", "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error": {"code": "INVALID_ARGUMENT", "message": "invalid limit 'abc'"}}`, w.Body.String())
+}
@@ -0,0 +1,210 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/parquet"
+)
+
+// encoder writes the exported documents in the format of the export.
+type encoder interface {
+	Encode(doc []byte) error
+	Close() error
+}
+
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+func (e *ndjsonEncoder) Encode(doc []byte) error {
+	if _, err := e.w.Write(doc); err != nil {
+		return err
+	}
+	_, err := e.w.Write([]byte{'\n'})
+
+	return err
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// parquetEncoder writes a column per top level field of the collection schema. The nested objects and the arrays
+// are stored as JSON columns.
+type parquetEncoder struct {
+	columns []parquet.Column
+	writer  *parquet.Writer
+	row     []any
+}
+
+func newParquetEncoder(w io.Writer, schema []byte, fields []byte) (*parquetEncoder, error) {
+	columns, err := schemaColumns(schema, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parquetEncoder{
+		columns: columns,
+		writer:  parquet.NewWriter(w, columns, parquet.Snappy),
+		row:     make([]any, len(columns)),
+	}, nil
+}
+
+func (e *parquetEncoder) Encode(doc []byte) error {
+	for i, c := range e.columns {
+		value, dataType, _, err := jsonparser.Get(doc, c.Name)
+		if dataType == jsonparser.NotExist || dataType == jsonparser.Null {
+			e.row[i] = nil
+			continue
+		}
+		if err != nil {
+			return errors.Internal("failed to read field '%s' of the document: %s", c.Name, err.Error())
+		}
+
+		if e.row[i], err = columnValue(c, value, dataType); err != nil {
+			return errors.Internal("failed to convert field '%s' of the document: %s", c.Name, err.Error())
+		}
+	}
+
+	return e.writer.Write(e.row)
+}
+
+func (e *parquetEncoder) Close() error {
+	return e.writer.Close()
+}
+
+func columnValue(c parquet.Column, value []byte, dataType jsonparser.ValueType) (any, error) {
+	switch c.Type {
+	case parquet.Boolean:
+		return jsonparser.ParseBoolean(value)
+	case parquet.Int64:
+		return strconv.ParseInt(string(value), 10, 64)
+	case parquet.Double:
+		return strconv.ParseFloat(string(value), 64)
+	case parquet.String:
+		return jsonparser.ParseString(value)
+	case parquet.Bytes:
+		s, err := jsonparser.ParseString(value)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	case parquet.Timestamp:
+		s, err := jsonparser.ParseString(value)
+		if err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		if dataType == jsonparser.String {
+			// jsonparser strips the quotes of the strings, the value is still escaped
+			return append(append([]byte{'"'}, value...), '"'), nil
+		}
+		return value, nil
+	}
+}
+
+// schemaColumns maps the top level properties of the collection schema to Parquet columns, in the order of the
+// schema. The fields excluded by the projection of the read request have no column.
+func schemaColumns(schema []byte, fields []byte) ([]parquet.Column, error) {
+	include, exclude, err := projection(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []parquet.Column
+	err = jsonparser.ObjectEach(schema, func(key []byte, value []byte, _ jsonparser.ValueType, _ int) error {
+		name := string(key)
+		if _, ok := exclude[name]; ok {
+			return nil
+		}
+		if _, ok := include[name]; len(include) > 0 && !ok {
+			return nil
+		}
+
+		columns = append(columns, parquet.Column{Name: name, Type: columnType(value)})
+		return nil
+	}, "properties")
+	if err != nil {
+		return nil, errors.Internal("failed to parse the collection schema: %s", err.Error())
+	}
+	if len(columns) == 0 {
+		return nil, errors.InvalidArgument("the projection excludes all the fields of the collection")
+	}
+
+	return columns, nil
+}
+
+func columnType(property []byte) parquet.Type {
+	typ, _ := jsonparser.GetString(property, "type")
+	format, _ := jsonparser.GetString(property, "format")
+
+	switch typ {
+	case "boolean":
+		return parquet.Boolean
+	case "integer":
+		return parquet.Int64
+	case "number":
+		return parquet.Double
+	case "string":
+		switch format {
+		case "date-time":
+			return parquet.Timestamp
+		case "byte":
+			return parquet.Bytes
+		default:
+			return parquet.String
+		}
+	default:
+		return parquet.JSON
+	}
+}
+
+// projection returns the top level fields included and excluded by the fields of a read request. A nested field
+// included by the projection includes the column of its top level field.
+func projection(fields []byte) (map[string]struct{}, map[string]struct{}, error) {
+	include, exclude := map[string]struct{}{}, map[string]struct{}{}
+	if len(fields) == 0 {
+		return include, exclude, nil
+	}
+
+	err := jsonparser.ObjectEach(fields, func(key []byte, value []byte, _ jsonparser.ValueType, _ int) error {
+		included, err := jsonparser.ParseBoolean(value)
+		if err != nil {
+			return err
+		}
+
+		top, _, nested := strings.Cut(string(key), ".")
+		if included {
+			include[top] = struct{}{}
+		} else if !nested {
+			exclude[top] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.InvalidArgument("invalid projection of the export: %s", err.Error())
+	}
+
+	return include, exclude, nil
+}
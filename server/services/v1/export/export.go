@@ -0,0 +1,198 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// Content types of the exported files.
+const (
+	ContentTypeNDJSON  = "application/x-ndjson"
+	ContentTypeParquet = "application/vnd.apache.parquet"
+)
+
+// chunkSize is the size of the HttpBody messages the exported file is streamed in.
+const chunkSize = 64 * 1024
+
+// ReadFunc streams the documents matching the read request.
+type ReadFunc func(r *api.ReadRequest, stream api.Tigris_ReadServer) error
+
+// DescribeFunc returns the schema of the exported collection.
+type DescribeFunc func(ctx context.Context, r *api.DescribeCollectionRequest) (*api.DescribeCollectionResponse, error)
+
+// Options of an export, passed in the request headers.
+type Options struct {
+	Format string
+	// Snapshot reads all the documents at the same read version. It is the default, without it a long export is
+	// read from consecutive snapshots, so it may observe the writes done while it runs.
+	Snapshot bool
+}
+
+// ParseOptions reads the export options from the headers of the request.
+func ParseOptions(ctx context.Context) (*Options, error) {
+	opts := &Options{
+		Format:   strings.ToLower(api.GetHeader(ctx, api.HeaderExportFormat)),
+		Snapshot: true,
+	}
+
+	switch opts.Format {
+	case "":
+		opts.Format = api.ExportFormatNDJSON
+	case api.ExportFormatNDJSON, api.ExportFormatParquet:
+	default:
+		return nil, errors.InvalidArgument("unsupported export format '%s'", opts.Format)
+	}
+
+	if s := api.GetHeader(ctx, api.HeaderExportSnapshot); s != "" {
+		var err error
+		if opts.Snapshot, err = strconv.ParseBool(s); err != nil {
+			return nil, errors.InvalidArgument("invalid %s header value '%s'", api.HeaderExportSnapshot, s)
+		}
+	}
+
+	return opts, nil
+}
+
+// Export streams the documents of the collection matching the filter and the projection of the read request in the
+// requested format. With the snapshot option the documents are read at a fixed read version, so the export sees a
+// consistent state of the collection without holding any lock, the writes proceed while it runs.
+func Export(r *api.ReadRequest, stream api.Export_ExportServer, read ReadFunc, describe DescribeFunc) error {
+	opts, err := ParseOptions(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	if r.GetOptions().GetLimit() != 0 || r.GetOptions().GetSkip() != 0 || len(r.GetOptions().GetOffset()) > 0 {
+		return errors.InvalidArgument("limit, skip and offset are not supported by export")
+	}
+	if len(r.Sort) > 0 && opts.Snapshot {
+		return errors.InvalidArgument("sorted export can't be read from a snapshot")
+	}
+
+	ctx := exportContext(stream.Context(), opts)
+
+	var enc encoder
+	out := &chunkWriter{stream: stream}
+	switch opts.Format {
+	case api.ExportFormatParquet:
+		out.contentType = ContentTypeParquet
+
+		resp, err := describe(ctx, &api.DescribeCollectionRequest{
+			Project:    r.Project,
+			Collection: r.Collection,
+			Branch:     r.Branch,
+		})
+		if err != nil {
+			return err
+		}
+		if enc, err = newParquetEncoder(out, resp.Schema, r.Fields); err != nil {
+			return err
+		}
+	default:
+		out.contentType = ContentTypeNDJSON
+		enc = &ndjsonEncoder{w: out}
+	}
+
+	if err = read(r, &readStream{Export_ExportServer: stream, ctx: ctx, enc: enc}); err != nil {
+		return err
+	}
+	if err = enc.Close(); err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
+
+// exportContext pins the reads to a snapshot, if requested, and drops the Accept header so that the documents are
+// streamed one by one even if the export is requested over HTTP with "Accept: application/json".
+func exportContext(ctx context.Context, opts *Options) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(api.HeaderAccept)) > 0 {
+		md = md.Copy()
+		md.Delete(api.HeaderAccept)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	if opts.Snapshot {
+		ctx = kv.WithReadVersion(ctx)
+	}
+
+	return ctx
+}
+
+// readStream passes the documents read to the encoder of the export.
+type readStream struct {
+	api.Export_ExportServer
+
+	ctx context.Context
+	enc encoder
+}
+
+func (s *readStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *readStream) Send(resp *api.ReadResponse) error {
+	return s.enc.Encode(resp.Data)
+}
+
+// chunkWriter buffers the exported file and sends it in chunks.
+type chunkWriter struct {
+	stream      api.Export_ExportServer
+	contentType string
+	buf         []byte
+	sent        bool
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= chunkSize {
+		if err := w.send(w.buf[:chunkSize]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[chunkSize:]
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the rest of the buffer. An empty export sends a single empty chunk, which carries the content type.
+func (w *chunkWriter) Flush() error {
+	if len(w.buf) == 0 && w.sent {
+		return nil
+	}
+
+	err := w.send(w.buf)
+	w.buf = w.buf[:0]
+
+	return err
+}
+
+func (w *chunkWriter) send(data []byte) error {
+	w.sent = true
+
+	return w.stream.Send(&httpbody.HttpBody{
+		ContentType: w.contentType,
+		Data:        append([]byte(nil), data...),
+	})
+}
@@ -0,0 +1,238 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/lib/parquet"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	chunks []*httpbody.HttpBody
+}
+
+func newTestStream(headers ...string) *testStream {
+	return &testStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(headers...))}
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) Send(m *httpbody.HttpBody) error {
+	s.chunks = append(s.chunks, m)
+	return nil
+}
+
+func (s *testStream) body() []byte {
+	var b []byte
+	for _, c := range s.chunks {
+		b = append(b, c.Data...)
+	}
+
+	return b
+}
+
+const testSchema = `{
+	"title": "users",
+	"properties": {
+		"id": {"type": "integer", "format": "int64"},
+		"name": {"type": "string"},
+		"score": {"type": "number"},
+		"active": {"type": "boolean"},
+		"created": {"type": "string", "format": "date-time"},
+		"avatar": {"type": "string", "format": "byte"},
+		"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"primary_key": ["id"]
+}`
+
+type testReader struct {
+	docs     [][]byte
+	snapshot bool
+	accept   string
+}
+
+func (tr *testReader) read(_ *api.ReadRequest, stream api.Tigris_ReadServer) error {
+	tr.snapshot = kv.HasReadVersion(stream.Context())
+	tr.accept = api.GetHeader(stream.Context(), api.HeaderAccept)
+
+	for _, doc := range tr.docs {
+		if err := stream.Send(&api.ReadResponse{Data: doc}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func describe(_ context.Context, r *api.DescribeCollectionRequest) (*api.DescribeCollectionResponse, error) {
+	return &api.DescribeCollectionResponse{Collection: r.Collection, Schema: []byte(testSchema)}, nil
+}
+
+func TestExportNDJSON(t *testing.T) {
+	tr := &testReader{}
+	for i := 0; i < 5000; i++ {
+		tr.docs = append(tr.docs, []byte(fmt.Sprintf(`{"id":%d,"name":"user %d"}`, i, i)))
+	}
+
+	stream := newTestStream(api.HeaderAccept, "application/json")
+	require.NoError(t, Export(&api.ReadRequest{Collection: "users"}, stream, tr.read, describe))
+
+	require.True(t, tr.snapshot)
+	require.Empty(t, tr.accept)
+	require.Greater(t, len(stream.chunks), 1)
+	for _, c := range stream.chunks {
+		require.Equal(t, ContentTypeNDJSON, c.ContentType)
+		require.LessOrEqual(t, len(c.Data), chunkSize)
+	}
+	require.Equal(t, append(bytes.Join(tr.docs, []byte("\n")), '\n'), stream.body())
+}
+
+func TestExportEmpty(t *testing.T) {
+	stream := newTestStream(api.HeaderExportSnapshot, "false")
+	tr := &testReader{}
+	require.NoError(t, Export(&api.ReadRequest{Collection: "users"}, stream, tr.read, describe))
+
+	require.False(t, tr.snapshot)
+	require.Len(t, stream.chunks, 1)
+	require.Equal(t, ContentTypeNDJSON, stream.chunks[0].ContentType)
+	require.Empty(t, stream.chunks[0].Data)
+}
+
+func TestExportParquet(t *testing.T) {
+	tr := &testReader{docs: [][]byte{
+		[]byte(`{"id":1,"name":"a\"b","score":1.5,"active":true,"created":"2023-05-01T10:00:00Z","avatar":"AQI=","address":{"city":"x"},"tags":["t"]}`),
+		[]byte(`{"id":2,"name":null}`),
+	}}
+
+	stream := newTestStream(api.HeaderExportFormat, "Parquet")
+	require.NoError(t, Export(&api.ReadRequest{Collection: "users"}, stream, tr.read, describe))
+
+	body := stream.body()
+	require.Equal(t, ContentTypeParquet, stream.chunks[0].ContentType)
+	require.Equal(t, []byte("PAR1"), body[:4])
+	require.Equal(t, []byte("PAR1"), body[len(body)-4:])
+
+	stream = newTestStream(api.HeaderExportFormat, "parquet")
+	tr.docs = [][]byte{[]byte(`{"id":"not a number"}`)}
+	err := Export(&api.ReadRequest{Collection: "users"}, stream, tr.read, describe)
+	require.Equal(t, api.Code_INTERNAL, api.FromStatusError(err).Code)
+}
+
+func TestColumnValue(t *testing.T) {
+	cases := []struct {
+		doc      string
+		column   parquet.Column
+		expected any
+	}{
+		{`{"f":"a\"b"}`, parquet.Column{Name: "f", Type: parquet.String}, `a"b`},
+		{`{"f":"a\"b"}`, parquet.Column{Name: "f", Type: parquet.JSON}, []byte(`"a\"b"`)},
+		{`{"f":{"a":[1,2]}}`, parquet.Column{Name: "f", Type: parquet.JSON}, []byte(`{"a":[1,2]}`)},
+		{`{"f":-12}`, parquet.Column{Name: "f", Type: parquet.Int64}, int64(-12)},
+		{`{"f":1e3}`, parquet.Column{Name: "f", Type: parquet.Double}, float64(1000)},
+		{`{"f":false}`, parquet.Column{Name: "f", Type: parquet.Boolean}, false},
+		{`{"f":"AQI="}`, parquet.Column{Name: "f", Type: parquet.Bytes}, []byte{1, 2}},
+		{`{"f":null}`, parquet.Column{Name: "f", Type: parquet.String}, nil},
+		{`{"g":1}`, parquet.Column{Name: "f", Type: parquet.Int64}, nil},
+	}
+
+	for _, c := range cases {
+		e := &parquetEncoder{columns: []parquet.Column{c.column}, row: make([]any, 1)}
+		e.writer = parquet.NewWriter(&bytes.Buffer{}, e.columns, parquet.Uncompressed)
+
+		require.NoError(t, e.Encode([]byte(c.doc)), c.doc)
+		require.Equal(t, c.expected, e.row[0], c.doc)
+	}
+}
+
+func TestSchemaColumns(t *testing.T) {
+	all := []parquet.Column{
+		{Name: "id", Type: parquet.Int64},
+		{Name: "name", Type: parquet.String},
+		{Name: "score", Type: parquet.Double},
+		{Name: "active", Type: parquet.Boolean},
+		{Name: "created", Type: parquet.Timestamp},
+		{Name: "avatar", Type: parquet.Bytes},
+		{Name: "address", Type: parquet.JSON},
+		{Name: "tags", Type: parquet.JSON},
+	}
+
+	columns, err := schemaColumns([]byte(testSchema), nil)
+	require.NoError(t, err)
+	require.Equal(t, all, columns)
+
+	columns, err = schemaColumns([]byte(testSchema), []byte(`{"name":true,"address.city":true}`))
+	require.NoError(t, err)
+	require.Equal(t, []parquet.Column{all[1], all[6]}, columns)
+
+	columns, err = schemaColumns([]byte(testSchema), []byte(`{"name":false,"address.city":false}`))
+	require.NoError(t, err)
+	require.Equal(t, append(all[:1:1], all[2:]...), columns)
+
+	_, err = schemaColumns([]byte(testSchema), []byte(`{"missing":true}`))
+	require.Equal(t, errors.InvalidArgument("the projection excludes all the fields of the collection"), err)
+
+	_, err = schemaColumns([]byte(testSchema), []byte(`{"name":1}`))
+	require.Error(t, err)
+}
+
+func TestExportErrors(t *testing.T) {
+	tr := &testReader{}
+
+	err := Export(&api.ReadRequest{}, newTestStream(api.HeaderExportFormat, "xml"), tr.read, describe)
+	require.Equal(t, errors.InvalidArgument("unsupported export format 'xml'"), err)
+
+	err = Export(&api.ReadRequest{}, newTestStream(api.HeaderExportSnapshot, "maybe"), tr.read, describe)
+	require.Equal(t, errors.InvalidArgument("invalid Tigris-Export-Snapshot header value 'maybe'"), err)
+
+	err = Export(&api.ReadRequest{Options: &api.ReadRequestOptions{Limit: 10}}, newTestStream(), tr.read, describe)
+	require.Equal(t, errors.InvalidArgument("limit, skip and offset are not supported by export"), err)
+
+	err = Export(&api.ReadRequest{Sort: []byte(`[{"id":"$asc"}]`)}, newTestStream(), tr.read, describe)
+	require.Equal(t, errors.InvalidArgument("sorted export can't be read from a snapshot"), err)
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("")
+	require.NoError(t, err)
+	require.Empty(t, fields)
+
+	fields, err = parseFields(" a, b.c ")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":true,"b.c":true}`, string(fields))
+
+	fields, err = parseFields(`{"a":false}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":false}`, string(fields))
+
+	_, err = parseFields("a,,b")
+	require.Error(t, err)
+}
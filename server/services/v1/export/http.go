@@ -0,0 +1,137 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// Handler serves the HTTP variant of Export. The response body is the exported file. The options are the query
+// parameters:
+//   - format: "ndjson" (default) or "parquet"
+//   - filter: the filter of the documents, as in the read requests
+//   - fields: the projection, either a comma separated list of the included fields or a JSON object
+//   - branch: the database branch
+//   - snapshot: "false" to not read the whole export at the same version
+type Handler struct {
+	client api.ExportClient
+}
+
+func NewHandler(client api.ExportClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stream, chunk, err := h.start(r)
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(&api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	ext := api.ExportFormatNDJSON
+	if chunk.ContentType == ContentTypeParquet {
+		ext = api.ExportFormatParquet
+	}
+	w.Header().Set("Content-Type", chunk.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, chi.URLParam(r, "collection"), ext))
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		if _, err = w.Write(chunk.Data); err != nil {
+			return
+		}
+		if chunk, err = stream.Recv(); err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the status is already sent, abort the response so that the client doesn't take it for a complete file
+			log.Err(err).Str("collection", chi.URLParam(r, "collection")).Msg("export failed")
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// start starts the export and waits for its first chunk, so that the errors detected before any document is read are
+// returned with their status code.
+func (h *Handler) start(r *http.Request) (api.Export_ExportClient, *httpbody.HttpBody, error) {
+	values := r.URL.Query()
+
+	req := &api.ReadRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     values.Get("branch"),
+		Filter:     []byte(values.Get("filter")),
+	}
+
+	var err error
+	if req.Fields, err = parseFields(values.Get("fields")); err != nil {
+		return nil, nil, err
+	}
+
+	ctx := common.OutgoingContext(r)
+	if format := values.Get("format"); format != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderExportFormat, format)
+	}
+	if snapshot := values.Get("snapshot"); snapshot != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderExportSnapshot, snapshot)
+	}
+
+	stream, err := h.client.Export(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stream, chunk, nil
+}
+
+// parseFields converts the list of the included fields to the projection of a read request.
+func parseFields(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "{") {
+		return []byte(s), nil
+	}
+
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			return nil, errors.InvalidArgument("invalid fields '%s'", s)
+		}
+		fields[f] = true
+	}
+
+	return jsoniter.Marshal(fields)
+}
@@ -0,0 +1,163 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+// Document is a parsed GraphQL request document, it holds the executable definitions only.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription definition.
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+type VariableDefinition struct {
+	Name    string
+	Type    *TypeRef
+	Default Value
+}
+
+// TypeRef is a type reference as written in a variable definition, e.g. [String!]!.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+type Directive struct {
+	Name string
+	Args []*Argument
+}
+
+type Argument struct {
+	Name  string
+	Value Value
+}
+
+// Selection is one of *Field, *FragmentSpread or *InlineFragment.
+type Selection interface {
+	selection()
+}
+
+type Field struct {
+	Alias        string
+	Name         string
+	Args         []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// ResponseKey is the key under which the field is returned, the alias if there is one.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+func (*Field) selection()          {}
+func (*FragmentSpread) selection() {}
+func (*InlineFragment) selection() {}
+
+// Value is an input value literal, one of the *XxxValue types or a *Variable.
+type Value interface {
+	value()
+}
+
+type Variable struct {
+	Name string
+}
+
+type IntValue struct {
+	Raw string
+}
+
+type FloatValue struct {
+	Raw string
+}
+
+type StringValue struct {
+	Value string
+}
+
+type BooleanValue struct {
+	Value bool
+}
+
+type NullValue struct{}
+
+type EnumValue struct {
+	Name string
+}
+
+type ListValue struct {
+	Values []Value
+}
+
+type ObjectValue struct {
+	Fields []*ObjectField
+}
+
+type ObjectField struct {
+	Name  string
+	Value Value
+}
+
+func (*Variable) value()     {}
+func (*IntValue) value()     {}
+func (*FloatValue) value()   {}
+func (*StringValue) value()  {}
+func (*BooleanValue) value() {}
+func (*NullValue) value()    {}
+func (*EnumValue) value()    {}
+func (*ListValue) value()    {}
+func (*ObjectValue) value()  {}
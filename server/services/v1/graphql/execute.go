@@ -0,0 +1,665 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string              `json:"query"`
+	OperationName string              `json:"operationName"`
+	Variables     jsoniter.RawMessage `json:"variables"`
+
+	// readOnly is set for the requests sent with GET, which are not allowed to run mutations.
+	readOnly bool
+}
+
+// Error is a GraphQL error. The Tigris error code is returned in the "code" extension.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Response is the result of a GraphQL request. Data is not returned if the request failed before the execution
+// started, for example because it could not be parsed.
+type Response struct {
+	Data     any
+	Errors   []*Error
+	executed bool
+}
+
+func (r *Response) MarshalJSON() ([]byte, error) {
+	m := newOrderedMap()
+	if len(r.Errors) > 0 {
+		m.set("errors", r.Errors)
+	}
+	if r.executed {
+		m.set("data", r.Data)
+	}
+
+	return m.MarshalJSON()
+}
+
+func toError(err error, path []any) *Error {
+	te := api.FromStatusError(err)
+	return &Error{
+		Message:    te.Message,
+		Path:       path,
+		Extensions: map[string]any{"code": api.CodeToString(te.Code)},
+	}
+}
+
+func requestError(err error) *Response {
+	return &Response{Errors: []*Error{toError(err, nil)}}
+}
+
+// fieldError is an error raised while executing a field, it carries the path of the field.
+type fieldError struct {
+	err  error
+	path []any
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// enumLiteral is an enum value written in the query, as opposed to a string.
+type enumLiteral string
+
+type executor struct {
+	ctx     context.Context
+	client  api.TigrisClient
+	schema  *Schema
+	project string
+	branch  string
+	doc     *Document
+	vars    map[string]any
+	errs    []*Error
+}
+
+// Execute runs the GraphQL request against the collections of a project branch. Collection operations are executed
+// through the client, so they go through the same authorization and validation as the API calls.
+func Execute(ctx context.Context, client api.TigrisClient, schema *Schema, project string, branch string, req *Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return requestError(err)
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+	if op.Type == "mutation" && req.readOnly {
+		return requestError(errors.MethodNotAllowed("mutations can only be sent with POST"))
+	}
+	if err = validate(schema, doc, op); err != nil {
+		return requestError(err)
+	}
+
+	e := &executor{
+		ctx:     ctx,
+		client:  client,
+		schema:  schema,
+		project: project,
+		branch:  branch,
+		doc:     doc,
+	}
+	if e.vars, err = e.coerceVariables(op, req.Variables); err != nil {
+		return requestError(err)
+	}
+
+	root := schema.Query
+	if op.Type == "mutation" {
+		root = schema.Mutation
+	}
+
+	resp := &Response{executed: true}
+	// fields are executed in order, as required for mutations
+	data, err := e.executeSelectionSet(root, nil, op.SelectionSet, nil)
+	if err != nil {
+		e.addError(err, nil)
+	} else {
+		resp.Data = data
+	}
+	resp.Errors = e.errs
+
+	return resp
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, errors.InvalidArgument("Must provide operation name if query contains multiple operations.")
+		}
+		return doc.Operations[0], nil
+	}
+
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+
+	return nil, errors.InvalidArgument("Unknown operation named %q.", name)
+}
+
+func (e *executor) addError(err error, path []any) {
+	if fe, ok := err.(*fieldError); ok {
+		err, path = fe.err, fe.path
+	}
+
+	e.errs = append(e.errs, toError(err, path))
+}
+
+func appendPath(path []any, elem any) []any {
+	return append(path[:len(path):len(path)], elem)
+}
+
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// collectFields groups the fields of the selection set by response key, expanding the fragments and applying the
+// @skip and @include directives.
+func (e *executor) collectFields(t *Type, sels []Selection, groups []*fieldGroup, visited map[string]bool) ([]*fieldGroup, error) {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *Field:
+			include, err := e.include(s.Directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include {
+				continue
+			}
+
+			key := s.ResponseKey()
+			found := false
+			for _, g := range groups {
+				if g.key == key {
+					g.fields = append(g.fields, s)
+					found = true
+					break
+				}
+			}
+			if !found {
+				groups = append(groups, &fieldGroup{key: key, fields: []*Field{s}})
+			}
+		case *FragmentSpread:
+			include, err := e.include(s.Directives)
+			if err != nil {
+				return nil, err
+			}
+			frag := e.doc.Fragments[s.Name]
+			if !include || visited[s.Name] || frag.TypeCondition != t.Name {
+				continue
+			}
+			visited[s.Name] = true
+
+			if groups, err = e.collectFields(t, frag.SelectionSet, groups, visited); err != nil {
+				return nil, err
+			}
+		case *InlineFragment:
+			include, err := e.include(s.Directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || (s.TypeCondition != "" && s.TypeCondition != t.Name) {
+				continue
+			}
+
+			if groups, err = e.collectFields(t, s.SelectionSet, groups, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+func (e *executor) include(dirs []*Directive) (bool, error) {
+	for _, d := range dirs {
+		for _, a := range d.Args {
+			if a.Name != "if" {
+				continue
+			}
+
+			v, _ := e.valueFromAST(a.Value)
+			cond, err := coerceInput(nonNull(e.schema.types[scalarBoolean]), v)
+			if err != nil {
+				return false, err
+			}
+			if (d.Name == "skip") == cond.(bool) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func (e *executor) executeSelectionSet(t *Type, parent any, sels []Selection, path []any) (*orderedMap, error) {
+	groups, err := e.collectFields(t, sels, nil, make(map[string]bool))
+	if err != nil {
+		return nil, &fieldError{err: err, path: path}
+	}
+
+	out := newOrderedMap()
+	for _, g := range groups {
+		f := g.fields[0]
+		if f.Name == "__typename" {
+			out.set(g.key, t.Name)
+			continue
+		}
+
+		def := e.schema.fieldDef(t, f.Name)
+		fieldPath := appendPath(path, g.key)
+		v, err := e.executeField(t, parent, def, g.fields, fieldPath)
+		if err != nil {
+			if def.Type.Kind == KindNonNull {
+				return nil, err
+			}
+			e.addError(err, fieldPath)
+			v = nil
+		}
+		out.set(g.key, v)
+	}
+
+	return out, nil
+}
+
+func (e *executor) executeField(t *Type, parent any, def *FieldDef, fields []*Field, path []any) (any, error) {
+	args, err := e.coerceArgs(def, fields[0].Args)
+	if err != nil {
+		return nil, &fieldError{err: err, path: path}
+	}
+
+	v, err := e.resolve(t, parent, def, args, fields)
+	if err != nil {
+		return nil, &fieldError{err: err, path: path}
+	}
+
+	return e.completeValue(def.Type, v, fields, path)
+}
+
+func (e *executor) resolve(t *Type, parent any, def *FieldDef, args *orderedMap, fields []*Field) (any, error) {
+	if def.op != opNone {
+		return e.resolveCollection(def, args, fields)
+	}
+
+	if t == e.schema.Query {
+		switch def.Name {
+		case "__schema":
+			return schemaValue{e.schema}, nil
+		case "__type":
+			name, _ := args.get("name")
+			if typ := e.schema.types[name.(string)]; typ != nil {
+				return typeValue{typ}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	switch p := parent.(type) {
+	case resolver:
+		return p.resolveField(def.Name, args), nil
+	case *orderedMap:
+		v, _ := p.get(def.key)
+		return v, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (e *executor) completeValue(t *Type, v any, fields []*Field, path []any) (any, error) {
+	if t.Kind == KindNonNull {
+		r, err := e.completeValue(t.OfType, v, fields, path)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			return nil, &fieldError{err: errors.Internal("Cannot return null for non-nullable field."), path: path}
+		}
+		return r, nil
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t.Kind {
+	case KindList:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, &fieldError{err: errors.Internal("Expected a list of %s.", t.OfType), path: path}
+		}
+
+		out := make([]any, len(items))
+		for i, item := range items {
+			itemPath := appendPath(path, i)
+			r, err := e.completeValue(t.OfType, item, fields, itemPath)
+			if err != nil {
+				if t.OfType.Kind == KindNonNull {
+					return nil, err
+				}
+				e.addError(err, itemPath)
+				r = nil
+			}
+			out[i] = r
+		}
+		return out, nil
+	case KindObject:
+		var sels []Selection
+		for _, f := range fields {
+			sels = append(sels, f.SelectionSet...)
+		}
+
+		m, err := e.executeSelectionSet(t, v, sels, path)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *executor) coerceVariables(op *Operation, raw jsoniter.RawMessage) (map[string]any, error) {
+	provided := newOrderedMap()
+	if len(raw) > 0 && string(raw) != "null" {
+		v, err := decodeJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := v.(*orderedMap)
+		if !ok {
+			return nil, errors.InvalidArgument("variables must be a JSON object")
+		}
+		provided = m
+	}
+
+	vars := make(map[string]any)
+	for _, def := range op.Variables {
+		t := e.schema.resolveTypeRef(def.Type)
+
+		v, ok := provided.get(def.Name)
+		if !ok {
+			if def.Default != nil {
+				lit, _ := e.valueFromAST(def.Default)
+				c, err := coerceInput(t, lit)
+				if err != nil {
+					return nil, errors.InvalidArgument("Variable \"$%s\" got invalid default value: %s", def.Name, errMessage(err))
+				}
+				vars[def.Name] = c
+			} else if t.Kind == KindNonNull {
+				return nil, errors.InvalidArgument("Variable \"$%s\" of required type \"%s\" was not provided.", def.Name, t)
+			}
+			continue
+		}
+
+		c, err := coerceInput(t, v)
+		if err != nil {
+			return nil, errors.InvalidArgument("Variable \"$%s\" got invalid value: %s", def.Name, errMessage(err))
+		}
+		vars[def.Name] = c
+	}
+
+	return vars, nil
+}
+
+func (e *executor) coerceArgs(def *FieldDef, args []*Argument) (*orderedMap, error) {
+	out := newOrderedMap()
+	for _, ad := range def.Args {
+		var (
+			v       any
+			present bool
+		)
+		for _, a := range args {
+			if a.Name == ad.Name {
+				v, present = e.valueFromAST(a.Value)
+				break
+			}
+		}
+
+		if !present {
+			if ad.defaultValue != nil {
+				out.set(ad.Name, ad.defaultValue)
+			} else if ad.Type.Kind == KindNonNull {
+				return nil, errors.InvalidArgument("Argument %q of required type \"%s\" was not provided.", ad.Name, ad.Type)
+			}
+			continue
+		}
+
+		c, err := coerceInput(ad.Type, v)
+		if err != nil {
+			return nil, errors.InvalidArgument("Argument %q has invalid value: %s", ad.Name, errMessage(err))
+		}
+		out.set(ad.Name, c)
+	}
+
+	return out, nil
+}
+
+// valueFromAST converts a literal to a Go value, substituting the variables. It returns false for a variable that
+// was not provided.
+func (e *executor) valueFromAST(v Value) (any, bool) {
+	switch val := v.(type) {
+	case *Variable:
+		c, ok := e.vars[val.Name]
+		return c, ok
+	case *IntValue:
+		return json.Number(val.Raw), true
+	case *FloatValue:
+		return json.Number(val.Raw), true
+	case *StringValue:
+		return val.Value, true
+	case *BooleanValue:
+		return val.Value, true
+	case *EnumValue:
+		return enumLiteral(val.Name), true
+	case *ListValue:
+		list := make([]any, len(val.Values))
+		for i, item := range val.Values {
+			list[i], _ = e.valueFromAST(item)
+		}
+		return list, true
+	case *ObjectValue:
+		m := newOrderedMap()
+		for _, f := range val.Fields {
+			if fv, ok := e.valueFromAST(f.Value); ok {
+				m.set(f.Name, fv)
+			}
+		}
+		return m, true
+	default:
+		return nil, true
+	}
+}
+
+func errMessage(err error) string {
+	return api.FromStatusError(err).Message
+}
+
+// coerceInput coerces a value to an input type. Values are either literals converted by valueFromAST or decoded
+// from the JSON variables.
+func coerceInput(t *Type, v any) (any, error) {
+	if t.Kind == KindNonNull {
+		if v == nil {
+			return nil, errors.InvalidArgument("Expected value of type \"%s\", found null.", t)
+		}
+		return coerceInput(t.OfType, v)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t.Kind {
+	case KindList:
+		items, ok := v.([]any)
+		if !ok {
+			c, err := coerceInput(t.OfType, v)
+			if err != nil {
+				return nil, err
+			}
+			return []any{c}, nil
+		}
+
+		out := make([]any, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.OfType, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case KindEnum:
+		var name string
+		switch x := v.(type) {
+		case enumLiteral:
+			name = string(x)
+		case string:
+			name = x
+		default:
+			return nil, errors.InvalidArgument("Enum \"%s\" cannot represent non-enum value.", t.Name)
+		}
+
+		for _, ev := range t.EnumValues {
+			if ev == name {
+				return name, nil
+			}
+		}
+		return nil, errors.InvalidArgument("Value %q does not exist in \"%s\" enum.", name, t.Name)
+	case KindInputObject:
+		m, ok := v.(*orderedMap)
+		if !ok {
+			return nil, errors.InvalidArgument("Expected type \"%s\" to be an object.", t.Name)
+		}
+
+		out := newOrderedMap()
+		for _, k := range m.keys {
+			f := t.field(k)
+			if f == nil {
+				return nil, errors.InvalidArgument("Field %q is not defined by type \"%s\".", k, t.Name)
+			}
+
+			c, err := coerceInput(f.Type, m.values[k])
+			if err != nil {
+				return nil, err
+			}
+			out.set(k, c)
+		}
+		for _, f := range t.Fields {
+			if _, ok := out.get(f.Name); !ok && f.Type.Kind == KindNonNull {
+				return nil, errors.InvalidArgument("Field \"%s.%s\" of required type \"%s\" was not provided.", t.Name, f.Name, f.Type)
+			}
+		}
+		return out, nil
+	default:
+		return coerceScalar(t.Name, v)
+	}
+}
+
+func coerceScalar(name string, v any) (any, error) {
+	invalid := func() error {
+		return errors.InvalidArgument("%s cannot represent value: %v", name, v)
+	}
+
+	switch name {
+	case scalarInt, scalarInt64:
+		var n int64
+		switch x := v.(type) {
+		case json.Number:
+			i, err := strconv.ParseInt(string(x), 10, 64)
+			if err != nil {
+				return nil, invalid()
+			}
+			n = i
+		case int64:
+			n = x
+		case float64:
+			if x != math.Trunc(x) || math.Abs(x) > math.MaxInt64 {
+				return nil, invalid()
+			}
+			n = int64(x)
+		case string:
+			// 64-bit integers don't survive the JavaScript numbers, so Int64 can also be sent as a string
+			i, err := strconv.ParseInt(x, 10, 64)
+			if name != scalarInt64 || err != nil {
+				return nil, invalid()
+			}
+			n = i
+		default:
+			return nil, invalid()
+		}
+		if name == scalarInt && (n < math.MinInt32 || n > math.MaxInt32) {
+			return nil, invalid()
+		}
+		return n, nil
+	case scalarFloat:
+		switch x := v.(type) {
+		case json.Number:
+			f, err := strconv.ParseFloat(string(x), 64)
+			if err != nil {
+				return nil, invalid()
+			}
+			return f, nil
+		case float64:
+			return x, nil
+		case int64:
+			return float64(x), nil
+		}
+	case scalarString, scalarBytes:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case scalarDateTime:
+		if s, ok := v.(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return nil, invalid()
+			}
+			return s, nil
+		}
+	case scalarBoolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case scalarJSON:
+		if e, ok := v.(enumLiteral); ok {
+			return string(e), nil
+		}
+		return v, nil
+	}
+
+	return nil, invalid()
+}
@@ -0,0 +1,289 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+)
+
+type readStream struct {
+	grpc.ClientStream
+
+	docs []string
+}
+
+func (r *readStream) Recv() (*api.ReadResponse, error) {
+	if len(r.docs) == 0 {
+		return nil, io.EOF
+	}
+	resp := &api.ReadResponse{Data: []byte(r.docs[0])}
+	r.docs = r.docs[1:]
+
+	return resp, nil
+}
+
+type fakeClient struct {
+	api.TigrisClient
+
+	docs     []string
+	read     *api.ReadRequest
+	count    *api.CountRequest
+	inserted *api.InsertRequest
+	updated  *api.UpdateRequest
+	deleted  *api.DeleteRequest
+}
+
+func (f *fakeClient) Read(_ context.Context, in *api.ReadRequest, _ ...grpc.CallOption) (api.Tigris_ReadClient, error) {
+	f.read = in
+	return &readStream{docs: f.docs}, nil
+}
+
+func (f *fakeClient) Count(_ context.Context, in *api.CountRequest, _ ...grpc.CallOption) (*api.CountResponse, error) {
+	f.count = in
+	return &api.CountResponse{Count: 42}, nil
+}
+
+func (f *fakeClient) Insert(_ context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*api.InsertResponse, error) {
+	f.inserted = in
+	return &api.InsertResponse{Status: "inserted", Keys: [][]byte{[]byte(`{"id":1}`)}}, nil
+}
+
+func (f *fakeClient) Update(_ context.Context, in *api.UpdateRequest, _ ...grpc.CallOption) (*api.UpdateResponse, error) {
+	f.updated = in
+	return &api.UpdateResponse{Status: "updated", ModifiedCount: 2}, nil
+}
+
+func (f *fakeClient) Delete(_ context.Context, in *api.DeleteRequest, _ ...grpc.CallOption) (*api.DeleteResponse, error) {
+	f.deleted = in
+	if in.Collection != "users" {
+		return nil, api.Errorf(api.Code_NOT_FOUND, "collection doesn't exist")
+	}
+	return &api.DeleteResponse{Status: "deleted", DeletedCount: 3}, nil
+}
+
+func execute(t *testing.T, client *fakeClient, query string, vars string) string {
+	t.Helper()
+
+	req := &Request{Query: query}
+	if vars != "" {
+		req.Variables = jsoniter.RawMessage(vars)
+	}
+
+	resp, err := jsoniter.Marshal(Execute(context.Background(), client, BuildSchema(testCollections()), "app", "feature", req))
+	require.NoError(t, err)
+
+	return string(resp)
+}
+
+func TestExecuteQuery(t *testing.T) {
+	client := &fakeClient{docs: []string{
+		`{"id":9007199254740993,"name":"alice","age":30,"address":{"city":"Paris","zip-code":"75001"},"extra":{"b":1,"a":[true]}}`,
+		`{"id":2,"name":"bob"}`,
+	}}
+
+	resp := execute(t, client, `query ($city: String) {
+		list: users(filter: {name: {in: ["alice", "bob"]}, address: {city: {eq: $city}}, _or: [{age: {gt: 18}}, {age: {ne: 3}}]},
+		            order_by: [{address: {city: desc}}, {name: asc}], limit: 10, offset: 5) {
+			__typename
+			name
+			...rest
+		}
+	}
+	fragment rest on users {
+		id
+		address { zip_code }
+		extra
+		skipped: age @skip(if: true)
+	}`, `{"city":"Paris"}`)
+
+	require.JSONEq(t, `{"data":{"list":[
+		{"__typename":"users","name":"alice","id":9007199254740993,"address":{"zip_code":"75001"},"extra":{"b":1,"a":[true]}},
+		{"__typename":"users","name":"bob","id":2,"address":null,"extra":null}
+	]}}`, resp)
+	require.Contains(t, resp, `"id":9007199254740993`)
+
+	require.Equal(t, "app", client.read.Project)
+	require.Equal(t, "feature", client.read.Branch)
+	require.Equal(t, "users", client.read.Collection)
+	require.JSONEq(t, `{"$and":[
+		{"$or":[{"name":"alice"},{"name":"bob"}]},
+		{"address.city":"Paris"},
+		{"$or":[{"age":{"$gt":18}},{"$or":[{"age":{"$lt":3}},{"age":{"$gt":3}}]}]}
+	]}`, string(client.read.Filter))
+	require.Equal(t, `[{"address.city":"$desc"},{"name":"$asc"}]`, string(client.read.Sort))
+	require.Equal(t, `{"name":true,"id":true,"address":true,"extra":true}`, string(client.read.Fields))
+	require.Equal(t, int64(10), client.read.Options.Limit)
+	require.Equal(t, int64(5), client.read.Options.Skip)
+}
+
+func TestExecuteCount(t *testing.T) {
+	client := &fakeClient{}
+	resp := execute(t, client, `{ users_count(filter: {name: {regex: "^a", not_contains: "x"}, active: {eq: null}}) }`, "")
+
+	require.JSONEq(t, `{"data":{"users_count":42}}`, resp)
+	require.JSONEq(t, `{"$and":[{"name":{"$regex":"^a"}},{"name":{"$not":"x"}},{"active":null}]}`, string(client.count.Filter))
+}
+
+func TestExecuteMutation(t *testing.T) {
+	client := &fakeClient{}
+	resp := execute(t, client, `mutation ($docs: [users_input!]!) {
+		insert_users(documents: $docs) { status keys }
+		update_users(filter: {id: {eq: "9007199254740993"}}, set: {address: {zip_code: "1"}}, unset: ["tags"], increment: {age: 1}, limit: 1) {
+			modified_count
+		}
+		delete_users(filter: {}) { status deleted_count }
+	}`, `{"docs":[{"id":1,"name":"alice","created":"2023-01-02T03:04:05Z","tags":"a"}]}`)
+
+	require.JSONEq(t, `{"data":{
+		"insert_users":{"status":"inserted","keys":[{"id":1}]},
+		"update_users":{"modified_count":2},
+		"delete_users":{"status":"deleted","deleted_count":3}
+	}}`, resp)
+
+	require.Equal(t, `{"id":1,"name":"alice","created":"2023-01-02T03:04:05Z","tags":["a"]}`, string(client.inserted.Documents[0]))
+	require.Equal(t, `{"id":9007199254740993}`, string(client.updated.Filter))
+	require.Equal(t, `{"$set":{"address":{"zip-code":"1"}},"$unset":["tags"],"$increment":{"age":1}}`, string(client.updated.Fields))
+	require.Equal(t, int64(1), client.updated.Options.Limit)
+	require.Equal(t, `{}`, string(client.deleted.Filter))
+}
+
+func TestExecuteErrors(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		vars  string
+		resp  string
+	}{
+		{
+			`{ users { nope } }`, "",
+			`{"errors":[{"message":"Cannot query field \"nope\" on type \"users\".","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`{ users }`, "",
+			`{"errors":[{"message":"Field \"users\" of type \"[users!]!\" must have a selection of subfields. Did you mean \"users { ... }\"?","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`{ users_count { id } }`, "",
+			`{"errors":[{"message":"Field \"users_count\" must not have a selection since type \"Int64!\" has no subfields.","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`mutation { delete_users { status } }`, "",
+			`{"errors":[{"message":"Field \"delete_users\" argument \"filter\" of type \"users_filter!\" is required, but it was not provided.","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`query ($n: Int!) { users(limit: $n) { id } }`, "",
+			`{"errors":[{"message":"Variable \"$n\" of required type \"Int!\" was not provided.","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`{ users(limit: $n) { id } }`, "",
+			`{"errors":[{"message":"Variable \"$n\" is not defined.","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`{ ...f } fragment f on Query { ...f }`, "",
+			`{"errors":[{"message":"Cannot spread fragment \"f\" within itself.","extensions":{"code":"INVALID_ARGUMENT"}}]}`,
+		},
+		{
+			`subscription { users { id } }`, "",
+			`{"errors":[{"message":"subscriptions are not supported","extensions":{"code":"UNIMPLEMENTED"}}]}`,
+		},
+		{
+			// field errors null the closest nullable field, users_count is not null so the whole data is null
+			`{ users_count(filter: {age: {gt: 3000000000}}) }`, "",
+			`{"errors":[{"message":"Argument \"filter\" has invalid value: Int cannot represent value: 3000000000","path":["users_count"],"extensions":{"code":"INVALID_ARGUMENT"}}],"data":null}`,
+		},
+		{
+			`{ __type(name: "users") { name } users(filter: {name: {in: []}}) { id } }`, "",
+			`{"errors":[{"message":"in of \"name\" must have at least one value","path":["users"],"extensions":{"code":"INVALID_ARGUMENT"}}],"data":null}`,
+		},
+	} {
+		require.JSONEq(t, c.resp, execute(t, &fakeClient{}, c.query, c.vars), c.query)
+	}
+
+	// a nullable field is nulled and the rest of the data is returned
+	resp := execute(t, &fakeClient{}, `{ __type(name: "users") { name } a: __type(name: "missing") { name } }`, "")
+	require.JSONEq(t, `{"data":{"__type":{"name":"users"},"a":null}}`, resp)
+}
+
+func TestExecuteReadOnly(t *testing.T) {
+	resp := Execute(context.Background(), &fakeClient{}, BuildSchema(testCollections()), "app", "", &Request{
+		Query:    `mutation { delete_users(filter: {}) { status } }`,
+		readOnly: true,
+	})
+	require.Equal(t, "mutations can only be sent with POST", resp.Errors[0].Message)
+}
+
+func TestIntrospection(t *testing.T) {
+	resp := execute(t, &fakeClient{}, `{
+		__schema {
+			queryType { name }
+			mutationType { name }
+			directives { name locations args { name type { kind ofType { name } } } }
+		}
+		users: __type(name: "users") {
+			kind
+			fields { name type { kind name ofType { name } } }
+		}
+		order: __type(name: "SortOrder") { enumValues { name } }
+		filter: __type(name: "users_address_filter") { inputFields { name type { name } defaultValue } }
+	}`, "")
+
+	var out struct {
+		Data struct {
+			Schema struct {
+				QueryType    struct{ Name string }
+				MutationType struct{ Name string }
+				Directives   []struct {
+					Name      string
+					Locations []string
+				}
+			} `json:"__schema"`
+			Users struct {
+				Kind   string
+				Fields []struct {
+					Name string
+					Type struct {
+						Kind string
+						Name *string
+					}
+				}
+			}
+			Order struct {
+				EnumValues []struct{ Name string }
+			}
+			Filter jsoniter.RawMessage
+		}
+	}
+	require.NoError(t, jsoniter.Unmarshal([]byte(resp), &out))
+
+	require.Equal(t, "Query", out.Data.Schema.QueryType.Name)
+	require.Equal(t, "Mutation", out.Data.Schema.MutationType.Name)
+	require.Equal(t, "include", out.Data.Schema.Directives[0].Name)
+	require.Equal(t, "OBJECT", out.Data.Users.Kind)
+	require.Equal(t, "id", out.Data.Users.Fields[0].Name)
+	require.Equal(t, "Int64", *out.Data.Users.Fields[0].Type.Name)
+	require.Equal(t, "LIST", out.Data.Users.Fields[6].Type.Kind)
+	require.Nil(t, out.Data.Users.Fields[6].Type.Name)
+	require.Len(t, out.Data.Order.EnumValues, 2)
+	require.JSONEq(t, `{"inputFields":[
+		{"name":"city","type":{"name":"String_comparison"},"defaultValue":null},
+		{"name":"zip_code","type":{"name":"String_comparison"},"defaultValue":null}
+	]}`, string(out.Data.Filter))
+}
@@ -0,0 +1,181 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// maxCachedSchemas bounds the number of project branches the generated schemas are kept for.
+const maxCachedSchemas = 1024
+
+type cachedSchema struct {
+	hash   [sha256.Size]byte
+	schema *Schema
+}
+
+// Handler serves the GraphQL endpoint of a project. The GraphQL schema is generated from the collection schemas of
+// the project branch and regenerated whenever one of them changes.
+type Handler struct {
+	client api.TigrisClient
+
+	mu      sync.Mutex
+	schemas map[string]cachedSchema
+}
+
+func NewHandler(client api.TigrisClient) *Handler {
+	return &Handler{
+		client:  client,
+		schemas: make(map[string]cachedSchema),
+	}
+}
+
+// ServeHTTP executes a GraphQL request sent either as a POST with a JSON or application/graphql body or as a GET
+// with the query in the URL. The database branch is selected with the "branch" query parameter.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(r)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, requestError(err))
+		return
+	}
+
+	ctx := common.OutgoingContext(r)
+	project, branch := chi.URLParam(r, "project"), r.URL.Query().Get("branch")
+
+	schema, err := h.schema(ctx, project, branch)
+	if err != nil {
+		writeResponse(w, api.ToHTTPCode(api.FromStatusError(err).Code), requestError(err))
+		return
+	}
+
+	writeResponse(w, http.StatusOK, Execute(ctx, h.client, schema, project, branch, req))
+}
+
+// ServeSchema returns the GraphQL schema of the project branch in the schema definition language.
+func (h *Handler) ServeSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := h.schema(common.OutgoingContext(r), chi.URLParam(r, "project"), r.URL.Query().Get("branch"))
+	if err != nil {
+		writeResponse(w, api.ToHTTPCode(api.FromStatusError(err).Code), requestError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, schema.SDL())
+}
+
+// schema returns the GraphQL schema of the project branch, it is only regenerated if the collections changed.
+func (h *Handler) schema(ctx context.Context, project string, branch string) (*Schema, error) {
+	resp, err := h.client.DescribeDatabase(ctx, &api.DescribeDatabaseRequest{Project: project, Branch: branch})
+	if err != nil {
+		return nil, err
+	}
+
+	key := project + "/" + branch
+	hash := schemasHash(resp.Collections)
+
+	h.mu.Lock()
+	cached, ok := h.schemas[key]
+	h.mu.Unlock()
+	if ok && cached.hash == hash {
+		return cached.schema, nil
+	}
+
+	schema := BuildSchema(resp.Collections)
+
+	h.mu.Lock()
+	if len(h.schemas) >= maxCachedSchemas {
+		h.schemas = make(map[string]cachedSchema)
+	}
+	h.schemas[key] = cachedSchema{hash: hash, schema: schema}
+	h.mu.Unlock()
+
+	return schema, nil
+}
+
+func schemasHash(collections []*api.CollectionDescription) [sha256.Size]byte {
+	sorted := append([]*api.CollectionDescription(nil), collections...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetCollection() < sorted[j].GetCollection()
+	})
+
+	h := sha256.New()
+	for _, c := range sorted {
+		_, _ = h.Write([]byte(c.GetCollection()))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(c.GetSchema())
+		_, _ = h.Write([]byte{0})
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+
+	return sum
+}
+
+func readRequest(r *http.Request) (*Request, error) {
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req := &Request{Query: q.Get("query"), OperationName: q.Get("operationName"), readOnly: true}
+		if vars := q.Get("variables"); vars != "" {
+			req.Variables = jsoniter.RawMessage(vars)
+		}
+		if req.Query == "" {
+			return nil, errors.InvalidArgument("missing query")
+		}
+		return req, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument("failed to read request body")
+	}
+
+	req := &Request{}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+		req.Query = string(body)
+	} else if err = jsoniter.Unmarshal(body, req); err != nil {
+		return nil, errors.InvalidArgument("invalid GraphQL request: %s", err.Error())
+	}
+	if req.Query == "" {
+		return nil, errors.InvalidArgument("missing query")
+	}
+
+	return req, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp *Response) {
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = jsoniter.Marshal(requestError(errors.Internal("failed to encode the response")))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
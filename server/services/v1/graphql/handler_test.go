@@ -0,0 +1,111 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type describeClient struct {
+	fakeClient
+
+	schema string
+	auth   []string
+}
+
+func (c *describeClient) DescribeDatabase(ctx context.Context, in *api.DescribeDatabaseRequest, _ ...grpc.CallOption) (*api.DescribeDatabaseResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.auth = md.Get("authorization")
+	if in.Project != "app" {
+		return nil, api.Errorf(api.Code_NOT_FOUND, "project doesn't exist")
+	}
+
+	return &api.DescribeDatabaseResponse{
+		Collections: []*api.CollectionDescription{{Collection: "users", Schema: []byte(c.schema)}},
+	}, nil
+}
+
+func TestHandlerSchemaCache(t *testing.T) {
+	client := &describeClient{schema: testSchema}
+	h := NewHandler(client)
+
+	s1, err := h.schema(context.Background(), "app", "")
+	require.NoError(t, err)
+	s2, err := h.schema(context.Background(), "app", "")
+	require.NoError(t, err)
+	require.Same(t, s1, s2)
+
+	client.schema = `{"properties":{"id":{"type":"integer"},"email":{"type":"string"}}}`
+	s3, err := h.schema(context.Background(), "app", "")
+	require.NoError(t, err)
+	require.NotSame(t, s1, s3)
+	require.NotNil(t, s3.Type("users").field("email"))
+}
+
+func TestHandler(t *testing.T) {
+	client := &describeClient{schema: testSchema}
+	h := NewHandler(client)
+
+	router := chi.NewRouter()
+	router.Get("/v1/projects/{project}/graphql", h.ServeHTTP)
+	router.Post("/v1/projects/{project}/graphql", h.ServeHTTP)
+	router.Get("/v1/projects/{project}/graphql/schema", h.ServeSchema)
+
+	serve := func(method string, target string, contentType string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer token")
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/v1/projects/app/graphql", "application/json", `{"query":"query Q($n: Int) { users_count }","operationName":"Q","variables":{"n":1}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":{"users_count":42}}`, w.Body.String())
+	require.Equal(t, []string{"Bearer token"}, client.auth)
+
+	w = serve(http.MethodPost, "/v1/projects/app/graphql?branch=dev", "application/graphql", `{ users_count }`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "dev", client.count.Branch)
+
+	w = serve(http.MethodGet, "/v1/projects/app/graphql?query="+url.QueryEscape(`mutation { delete_users(filter: {}) { status } }`), "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"errors":[{"message":"mutations can only be sent with POST","extensions":{"code":"METHOD_NOT_ALLOWED"}}]}`, w.Body.String())
+
+	w = serve(http.MethodPost, "/v1/projects/app/graphql", "application/json", `{"query":`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodPost, "/v1/projects/missing/graphql", "application/json", `{"query":"{ users_count }"}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"errors":[{"message":"project doesn't exist","extensions":{"code":"NOT_FOUND"}}]}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/v1/projects/app/graphql/schema", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "type users {")
+}
@@ -0,0 +1,280 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+// resolver is implemented by the values whose fields are computed rather than read from a document, the values of
+// the introspection types.
+type resolver interface {
+	resolveField(name string, args *orderedMap) any
+}
+
+var directiveLocations = []string{
+	"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT",
+	"VARIABLE_DEFINITION", "SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE",
+	"UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION",
+}
+
+// directives are the directives supported by the executor.
+var directives = []struct {
+	name string
+	desc string
+}{
+	{"include", "Directs the executor to include this field or fragment only when the `if` argument is true."},
+	{"skip", "Directs the executor to skip this field or fragment when the `if` argument is true."},
+}
+
+// addIntrospectionTypes adds the types of the introspection system to the schema.
+func addIntrospectionTypes(s *Schema) {
+	str := s.types[scalarString]
+	boolean := s.types[scalarBoolean]
+	includeDeprecated := func() []*FieldDef {
+		return []*FieldDef{{Name: "includeDeprecated", Type: boolean, DefaultValue: "false", defaultValue: false}}
+	}
+
+	typeKind := s.add(&Type{Kind: KindEnum, Name: "__TypeKind", EnumValues: []string{
+		string(KindScalar), string(KindObject), "INTERFACE", "UNION", string(KindEnum), string(KindInputObject),
+		string(KindList), string(KindNonNull),
+	}})
+	location := s.add(&Type{Kind: KindEnum, Name: "__DirectiveLocation", EnumValues: directiveLocations})
+
+	typ := s.add(&Type{Kind: KindObject, Name: "__Type"})
+	inputValue := s.add(&Type{Kind: KindObject, Name: "__InputValue", Fields: []*FieldDef{
+		{Name: "name", Type: nonNull(str)},
+		{Name: "description", Type: str},
+		{Name: "type", Type: nonNull(typ)},
+		{Name: "defaultValue", Type: str},
+		{Name: "isDeprecated", Type: nonNull(boolean)},
+		{Name: "deprecationReason", Type: str},
+	}})
+	enumValue := s.add(&Type{Kind: KindObject, Name: "__EnumValue", Fields: []*FieldDef{
+		{Name: "name", Type: nonNull(str)},
+		{Name: "description", Type: str},
+		{Name: "isDeprecated", Type: nonNull(boolean)},
+		{Name: "deprecationReason", Type: str},
+	}})
+	field := s.add(&Type{Kind: KindObject, Name: "__Field", Fields: []*FieldDef{
+		{Name: "name", Type: nonNull(str)},
+		{Name: "description", Type: str},
+		{Name: "args", Type: nonNull(listOf(nonNull(inputValue))), Args: includeDeprecated()},
+		{Name: "type", Type: nonNull(typ)},
+		{Name: "isDeprecated", Type: nonNull(boolean)},
+		{Name: "deprecationReason", Type: str},
+	}})
+	typ.Fields = []*FieldDef{
+		{Name: "kind", Type: nonNull(typeKind)},
+		{Name: "name", Type: str},
+		{Name: "description", Type: str},
+		{Name: "specifiedByURL", Type: str},
+		{Name: "fields", Type: listOf(nonNull(field)), Args: includeDeprecated()},
+		{Name: "interfaces", Type: listOf(nonNull(typ))},
+		{Name: "possibleTypes", Type: listOf(nonNull(typ))},
+		{Name: "enumValues", Type: listOf(nonNull(enumValue)), Args: includeDeprecated()},
+		{Name: "inputFields", Type: listOf(nonNull(inputValue)), Args: includeDeprecated()},
+		{Name: "ofType", Type: typ},
+	}
+	directive := s.add(&Type{Kind: KindObject, Name: "__Directive", Fields: []*FieldDef{
+		{Name: "name", Type: nonNull(str)},
+		{Name: "description", Type: str},
+		{Name: "isRepeatable", Type: nonNull(boolean)},
+		{Name: "locations", Type: nonNull(listOf(nonNull(location)))},
+		{Name: "args", Type: nonNull(listOf(nonNull(inputValue))), Args: includeDeprecated()},
+	}})
+	s.add(&Type{Kind: KindObject, Name: "__Schema", Fields: []*FieldDef{
+		{Name: "description", Type: str},
+		{Name: "types", Type: nonNull(listOf(nonNull(typ)))},
+		{Name: "queryType", Type: nonNull(typ)},
+		{Name: "mutationType", Type: typ},
+		{Name: "subscriptionType", Type: typ},
+		{Name: "directives", Type: nonNull(listOf(nonNull(directive)))},
+	}})
+}
+
+// introspectionField returns the implicit fields of the query root type.
+func (s *Schema) introspectionField(name string) *FieldDef {
+	switch name {
+	case "__schema":
+		return &FieldDef{Name: name, Type: nonNull(s.types["__Schema"])}
+	case "__type":
+		return &FieldDef{
+			Name: name,
+			Type: s.types["__Type"],
+			Args: []*FieldDef{{Name: "name", Type: nonNull(s.types[scalarString])}},
+		}
+	default:
+		return nil
+	}
+}
+
+type schemaValue struct {
+	s *Schema
+}
+
+func (v schemaValue) resolveField(name string, _ *orderedMap) any {
+	switch name {
+	case "types":
+		types := make([]any, 0, len(v.s.order))
+		for _, n := range v.s.order {
+			if t := v.s.types[n]; t.Kind != KindObject || len(t.Fields) > 0 || t == v.s.Query {
+				types = append(types, typeValue{t})
+			}
+		}
+		return types
+	case "queryType":
+		return typeValue{v.s.Query}
+	case "mutationType":
+		if v.s.Mutation != nil {
+			return typeValue{v.s.Mutation}
+		}
+	case "directives":
+		boolean := v.s.types[scalarBoolean]
+		list := make([]any, len(directives))
+		for i, d := range directives {
+			list[i] = directiveValue{
+				name: d.name,
+				desc: d.desc,
+				args: []*FieldDef{{Name: "if", Type: nonNull(boolean)}},
+			}
+		}
+		return list
+	}
+
+	return nil
+}
+
+type typeValue struct {
+	t *Type
+}
+
+func (v typeValue) resolveField(name string, _ *orderedMap) any {
+	t := v.t
+	switch name {
+	case "kind":
+		return string(t.Kind)
+	case "name":
+		if t.Name != "" {
+			return t.Name
+		}
+	case "description":
+		if t.Description != "" {
+			return t.Description
+		}
+	case "fields":
+		if t.Kind == KindObject {
+			list := make([]any, len(t.Fields))
+			for i, f := range t.Fields {
+				list[i] = fieldValue{f}
+			}
+			return list
+		}
+	case "inputFields":
+		if t.Kind == KindInputObject {
+			list := make([]any, len(t.Fields))
+			for i, f := range t.Fields {
+				list[i] = fieldValue{f}
+			}
+			return list
+		}
+	case "interfaces":
+		if t.Kind == KindObject {
+			return []any{}
+		}
+	case "enumValues":
+		if t.Kind == KindEnum {
+			list := make([]any, len(t.EnumValues))
+			for i, e := range t.EnumValues {
+				list[i] = enumValue(e)
+			}
+			return list
+		}
+	case "ofType":
+		if t.OfType != nil {
+			return typeValue{t.OfType}
+		}
+	}
+
+	return nil
+}
+
+// fieldValue is the introspection value of a field, an argument or an input field.
+type fieldValue struct {
+	f *FieldDef
+}
+
+func (v fieldValue) resolveField(name string, _ *orderedMap) any {
+	switch name {
+	case "name":
+		return v.f.Name
+	case "description":
+		if v.f.Description != "" {
+			return v.f.Description
+		}
+	case "args":
+		list := make([]any, len(v.f.Args))
+		for i, a := range v.f.Args {
+			list[i] = fieldValue{a}
+		}
+		return list
+	case "type":
+		return typeValue{v.f.Type}
+	case "defaultValue":
+		if v.f.DefaultValue != "" {
+			return v.f.DefaultValue
+		}
+	case "isDeprecated":
+		return false
+	}
+
+	return nil
+}
+
+type enumValue string
+
+func (v enumValue) resolveField(name string, _ *orderedMap) any {
+	switch name {
+	case "name":
+		return string(v)
+	case "isDeprecated":
+		return false
+	default:
+		return nil
+	}
+}
+
+type directiveValue struct {
+	name string
+	desc string
+	args []*FieldDef
+}
+
+func (v directiveValue) resolveField(name string, _ *orderedMap) any {
+	switch name {
+	case "name":
+		return v.name
+	case "description":
+		return v.desc
+	case "isRepeatable":
+		return false
+	case "locations":
+		return []any{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"}
+	case "args":
+		list := make([]any, len(v.args))
+		for i, a := range v.args {
+			list[i] = fieldValue{a}
+		}
+		return list
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,666 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return "\"" + t.text + "\""
+	}
+}
+
+func syntaxError(pos int, format string, args ...any) error {
+	return errors.InvalidArgument("Syntax Error: "+format+" at position %d", append(args, pos)...)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lex splits the source into tokens. Whitespace, commas and comments are insignificant and dropped.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "\xef\xbb\xbf"):
+			i += 3
+		case c == '.':
+			if !strings.HasPrefix(src[i:], "...") {
+				return nil, syntaxError(i, "unexpected \".\"")
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: "...", pos: i})
+			i += 3
+		case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+			tokens = append(tokens, token{kind: tokPunct, text: string(c), pos: i})
+			i++
+		case isNameStart(c):
+			start := i
+			for i < len(src) && isNameContinue(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokName, text: src[start:i], pos: start})
+		case c == '-' || isDigit(c):
+			tok, n, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = n
+		case c == '"':
+			var (
+				s   string
+				n   int
+				err error
+			)
+			if strings.HasPrefix(src[i:], `"""`) {
+				s, n, err = lexBlockString(src, i)
+			} else {
+				s, n, err = lexString(src, i)
+			}
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: i})
+			i = n
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, syntaxError(i, "unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func lexNumber(src string, start int) (token, int, error) {
+	i := start
+	if src[i] == '-' {
+		i++
+	}
+
+	digits := func() bool {
+		begin := i
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+		return i > begin
+	}
+
+	if i < len(src) && src[i] == '0' {
+		i++
+		if i < len(src) && isDigit(src[i]) {
+			return token{}, 0, syntaxError(i, "invalid number, unexpected digit after 0")
+		}
+	} else if !digits() {
+		return token{}, 0, syntaxError(i, "invalid number, expected digit")
+	}
+
+	kind := tokInt
+	if i < len(src) && src[i] == '.' {
+		kind = tokFloat
+		i++
+		if !digits() {
+			return token{}, 0, syntaxError(i, "invalid number, expected digit")
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		kind = tokFloat
+		i++
+		if i < len(src) && (src[i] == '+' || src[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return token{}, 0, syntaxError(i, "invalid number, expected digit")
+		}
+	}
+	if i < len(src) && (isNameStart(src[i]) || src[i] == '.') {
+		return token{}, 0, syntaxError(i, "invalid number, unexpected %q", src[i])
+	}
+
+	return token{kind: kind, text: src[start:i], pos: start}, i, nil
+}
+
+func lexString(src string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			return sb.String(), i + 1, nil
+		case c == '\n' || c == '\r':
+			return "", 0, syntaxError(i, "unterminated string")
+		case c == '\\':
+			if i+1 >= len(src) {
+				return "", 0, syntaxError(i, "unterminated string")
+			}
+			switch e := src[i+1]; e {
+			case '"', '\\', '/':
+				sb.WriteByte(e)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if i+6 > len(src) {
+					return "", 0, syntaxError(i, "invalid unicode escape sequence")
+				}
+				r, err := strconv.ParseUint(src[i+2:i+6], 16, 32)
+				if err != nil {
+					return "", 0, syntaxError(i, "invalid unicode escape sequence")
+				}
+				sb.WriteRune(rune(r))
+				i += 4
+			default:
+				return "", 0, syntaxError(i, "invalid escape sequence \\%c", e)
+			}
+			i += 2
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return "", 0, syntaxError(len(src), "unterminated string")
+}
+
+func lexBlockString(src string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 3; i < len(src); {
+		switch {
+		case strings.HasPrefix(src[i:], `\"""`):
+			sb.WriteString(`"""`)
+			i += 4
+		case strings.HasPrefix(src[i:], `"""`):
+			return blockStringValue(sb.String()), i + 3, nil
+		default:
+			sb.WriteByte(src[i])
+			i++
+		}
+	}
+
+	return "", 0, syntaxError(len(src), "unterminated string")
+}
+
+// blockStringValue removes the common indentation and the leading and trailing blank lines of a block string.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses an executable GraphQL document. Type system definitions are not accepted.
+func Parse(src string) (*Document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	for p.peek().kind != tokEOF {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokPunct && tok.text == "{":
+			set, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", SelectionSet: set})
+		case tok.kind == tokName && (tok.text == "query" || tok.text == "mutation" || tok.text == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case tok.kind == tokName && tok.text == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[frag.Name]; ok {
+				return nil, errors.InvalidArgument("There can be only one fragment named %q.", frag.Name)
+			}
+			doc.Fragments[frag.Name] = frag
+		default:
+			return nil, syntaxError(tok.pos, "unexpected %s", tok.describe())
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, errors.InvalidArgument("document does not contain any operation")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *parser) skipPunct(text string) bool {
+	if p.isPunct(text) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expectPunct(text string) error {
+	if !p.skipPunct(text) {
+		tok := p.peek()
+		return syntaxError(tok.pos, "expected \"%s\", found %s", text, tok.describe())
+	}
+
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	tok := p.peek()
+	if tok.kind != tokName {
+		return "", syntaxError(tok.pos, "expected name, found %s", tok.describe())
+	}
+	p.pos++
+
+	return tok.text, nil
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := &Operation{Type: p.next().text}
+
+	var err error
+	if p.peek().kind == tokName {
+		op.Name = p.next().text
+	}
+	if p.isPunct("(") {
+		if op.Variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if op.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+
+	var defs []*VariableDefinition
+	for !p.skipPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+
+		def := &VariableDefinition{Name: name}
+		if def.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if p.skipPunct("=") {
+			if def.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = p.directives(); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+func (p *parser) typeRef() (*TypeRef, error) {
+	t := &TypeRef{}
+	if p.skipPunct("[") {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+		t.Elem = elem
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.Name = name
+	}
+	t.NonNull = p.skipPunct("!")
+
+	return t, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	p.next()
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, syntaxError(p.tokens[p.pos-1].pos, "unexpected \"on\"")
+	}
+
+	frag := &Fragment{Name: name}
+	if tok := p.next(); tok.kind != tokName || tok.text != "on" {
+		return nil, syntaxError(tok.pos, "expected \"on\", found %s", tok.describe())
+	}
+	if frag.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var set []Selection
+	for !p.skipPunct("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, sel)
+	}
+
+	if len(set) == 0 {
+		return nil, syntaxError(p.tokens[p.pos-1].pos, "expected name, found \"}\"")
+	}
+
+	return set, nil
+}
+
+func (p *parser) selection() (Selection, error) {
+	var err error
+	if p.skipPunct("...") {
+		tok := p.peek()
+		if tok.kind == tokName && tok.text != "on" {
+			spread := &FragmentSpread{Name: p.next().text}
+			if spread.Directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			return spread, nil
+		}
+
+		inline := &InlineFragment{}
+		if tok.kind == tokName {
+			p.next()
+			if inline.TypeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if inline.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	field := &Field{}
+	if field.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.skipPunct(":") {
+		field.Alias = field.Name
+		if field.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunct("(") {
+		if field.Args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if field.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.isPunct("{") {
+		if field.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return field, nil
+}
+
+func (p *parser) arguments(isConst bool) ([]*Argument, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+
+	var args []*Argument
+	for !p.skipPunct(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		val, err := p.value(isConst)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, &Argument{Name: name, Value: val})
+	}
+
+	if len(args) == 0 {
+		return nil, syntaxError(p.tokens[p.pos-1].pos, "expected name, found \")\"")
+	}
+
+	return args, nil
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var dirs []*Directive
+	for p.skipPunct("@") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		dir := &Directive{Name: name}
+		if p.isPunct("(") {
+			if dir.Args, err = p.arguments(false); err != nil {
+				return nil, err
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return dirs, nil
+}
+
+// value parses an input value, variables are not allowed in constant contexts like variable defaults.
+func (p *parser) value(isConst bool) (Value, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokInt:
+		return &IntValue{Raw: tok.text}, nil
+	case tokFloat:
+		return &FloatValue{Raw: tok.text}, nil
+	case tokString:
+		return &StringValue{Value: tok.text}, nil
+	case tokName:
+		switch tok.text {
+		case "true", "false":
+			return &BooleanValue{Value: tok.text == "true"}, nil
+		case "null":
+			return &NullValue{}, nil
+		default:
+			return &EnumValue{Name: tok.text}, nil
+		}
+	case tokPunct:
+		switch tok.text {
+		case "$":
+			if isConst {
+				return nil, syntaxError(tok.pos, "unexpected variable in constant value")
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return &Variable{Name: name}, nil
+		case "[":
+			list := &ListValue{}
+			for !p.skipPunct("]") {
+				val, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				list.Values = append(list.Values, val)
+			}
+			return list, nil
+		case "{":
+			obj := &ObjectValue{}
+			for !p.skipPunct("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err = p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				val, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				obj.Fields = append(obj.Fields, &ObjectField{Name: name, Value: val})
+			}
+			return obj, nil
+		}
+	}
+
+	return nil, syntaxError(tok.pos, "unexpected %s", tok.describe())
+}
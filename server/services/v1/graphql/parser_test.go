@@ -0,0 +1,103 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# fetch users
+		query Users($limit: Int = 10, $names: [String!]!) @skip(if: false) {
+			list: users(filter: {name: {in: $names}, age: {gte: -1.5e3}}, limit: $limit) {
+				id
+				...userFields
+				... on users @include(if: true) { address { city } }
+			}
+		}
+
+		fragment userFields on users {
+			name
+			bio
+		}`)
+	require.NoError(t, err)
+	require.Len(t, doc.Operations, 1)
+
+	op := doc.Operations[0]
+	require.Equal(t, "query", op.Type)
+	require.Equal(t, "Users", op.Name)
+	require.Len(t, op.Variables, 2)
+	require.Equal(t, "Int", op.Variables[0].Type.String())
+	require.Equal(t, &IntValue{Raw: "10"}, op.Variables[0].Default)
+	require.Equal(t, "[String!]!", op.Variables[1].Type.String())
+	require.Equal(t, "skip", op.Directives[0].Name)
+
+	field := op.SelectionSet[0].(*Field)
+	require.Equal(t, "list", field.ResponseKey())
+	require.Equal(t, "users", field.Name)
+	require.Equal(t, &ObjectValue{Fields: []*ObjectField{
+		{Name: "name", Value: &ObjectValue{Fields: []*ObjectField{{Name: "in", Value: &Variable{Name: "names"}}}}},
+		{Name: "age", Value: &ObjectValue{Fields: []*ObjectField{{Name: "gte", Value: &FloatValue{Raw: "-1.5e3"}}}}},
+	}}, field.Args[0].Value)
+	require.Equal(t, &Variable{Name: "limit"}, field.Args[1].Value)
+
+	require.Len(t, field.SelectionSet, 3)
+	require.Equal(t, &FragmentSpread{Name: "userFields"}, field.SelectionSet[1])
+	inline := field.SelectionSet[2].(*InlineFragment)
+	require.Equal(t, "users", inline.TypeCondition)
+	require.Equal(t, "include", inline.Directives[0].Name)
+
+	require.Equal(t, "users", doc.Fragments["userFields"].TypeCondition)
+	require.Len(t, doc.Fragments["userFields"].SelectionSet, 2)
+}
+
+func TestParseValues(t *testing.T) {
+	doc, err := Parse(`{ f(a: "x\n\"é", b: true, c: null, d: ASC, e: [1, 2], g: """
+		block
+		  indented
+	""") }`)
+	require.NoError(t, err)
+
+	args := doc.Operations[0].SelectionSet[0].(*Field).Args
+	require.Equal(t, &StringValue{Value: "x\n\"é"}, args[0].Value)
+	require.Equal(t, &BooleanValue{Value: true}, args[1].Value)
+	require.Equal(t, &NullValue{}, args[2].Value)
+	require.Equal(t, &EnumValue{Name: "ASC"}, args[3].Value)
+	require.Equal(t, &ListValue{Values: []Value{&IntValue{Raw: "1"}, &IntValue{Raw: "2"}}}, args[4].Value)
+	require.Equal(t, &StringValue{Value: "block\n  indented"}, args[5].Value)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		err   string
+	}{
+		{`{ users `, `Syntax Error: expected name, found <EOF> at position 8`},
+		{`{ }`, `Syntax Error: expected name, found "}" at position 2`},
+		{`{ f(a: 01) }`, `Syntax Error: invalid number, unexpected digit after 0 at position 8`},
+		{`{ f(a: "x) }`, `Syntax Error: unterminated string at position 12`},
+		{`query ($a: Int = $b) { f }`, `Syntax Error: unexpected variable in constant value at position 17`},
+		{`type Query { f: Int }`, `Syntax Error: unexpected "type" at position 0`},
+		{`fragment f on T { a } fragment f on T { b } { f }`, `There can be only one fragment named "f".`},
+		{`fragment f on T { a }`, `document does not contain any operation`},
+		{`{ f ~ }`, `Syntax Error: unexpected character '~' at position 4`},
+	} {
+		_, err := Parse(c.query)
+		require.EqualError(t, err, c.err, c.query)
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func (e *executor) resolveCollection(def *FieldDef, args *orderedMap, fields []*Field) (any, error) {
+	switch def.op {
+	case opFind:
+		return e.find(def, args, fields)
+	case opCount:
+		return e.count(def, args)
+	case opInsert:
+		return e.insert(def, args)
+	case opUpdate:
+		return e.update(def, args)
+	case opDelete:
+		return e.delete(def, args)
+	default:
+		return nil, errors.Internal("unknown collection operation")
+	}
+}
+
+func (e *executor) find(def *FieldDef, args *orderedMap, fields []*Field) (any, error) {
+	filter, err := filterArg(def, args)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.ReadRequest{
+		Project:    e.project,
+		Branch:     e.branch,
+		Collection: def.collection,
+		Filter:     filter,
+		Options:    &api.ReadRequestOptions{},
+	}
+
+	if limit, ok := intArg(args, "limit"); ok {
+		if limit < 0 {
+			return nil, errors.InvalidArgument("limit must not be negative")
+		}
+		if limit == 0 {
+			return []any{}, nil
+		}
+		req.Options.Limit = limit
+	}
+	if offset, ok := intArg(args, "offset"); ok {
+		if offset < 0 {
+			return nil, errors.InvalidArgument("offset must not be negative")
+		}
+		req.Options.Skip = offset
+	}
+	if orderBy, ok := args.get("order_by"); ok && orderBy != nil {
+		if req.Sort, err = translateSort(def.arg("order_by").Type.named(), orderBy.([]any)); err != nil {
+			return nil, err
+		}
+	}
+	if req.Fields, err = e.projection(def.Type.named(), fields); err != nil {
+		return nil, err
+	}
+
+	stream, err := e.client.Read(e.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []any{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := decodeJSON(resp.Data)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// projection returns the top level document fields the selection set reads, so that only they are fetched.
+func (e *executor) projection(t *Type, fields []*Field) ([]byte, error) {
+	var sels []Selection
+	for _, f := range fields {
+		sels = append(sels, f.SelectionSet...)
+	}
+
+	groups, err := e.collectFields(t, sels, nil, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	proj := newOrderedMap()
+	for _, g := range groups {
+		if f := t.field(g.fields[0].Name); f != nil {
+			proj.set(f.key, true)
+		}
+	}
+	if proj.len() == 0 {
+		return nil, nil
+	}
+
+	return jsoniter.Marshal(proj)
+}
+
+func (e *executor) count(def *FieldDef, args *orderedMap) (any, error) {
+	filter, err := filterArg(def, args)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Count(e.ctx, &api.CountRequest{
+		Project:    e.project,
+		Branch:     e.branch,
+		Collection: def.collection,
+		Filter:     filter,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Count, nil
+}
+
+func (e *executor) insert(def *FieldDef, args *orderedMap) (any, error) {
+	input, _ := args.get("documents")
+	docs := input.([]any)
+	if len(docs) == 0 {
+		return nil, errors.InvalidArgument("documents must not be empty")
+	}
+
+	docType := def.arg("documents").Type
+	req := &api.InsertRequest{
+		Project:    e.project,
+		Branch:     e.branch,
+		Collection: def.collection,
+		Documents:  make([][]byte, len(docs)),
+	}
+	for i, doc := range docs {
+		data, err := jsoniter.Marshal(toDocument(docType, doc))
+		if err != nil {
+			return nil, err
+		}
+		req.Documents[i] = data
+	}
+
+	resp, err := e.client.Insert(e.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]any, len(resp.Keys))
+	for i, k := range resp.Keys {
+		if keys[i], err = decodeJSON(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return newOrderedMap().set("status", resp.Status).set("keys", keys), nil
+}
+
+func (e *executor) update(def *FieldDef, args *orderedMap) (any, error) {
+	filter, err := filterArg(def, args)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := newOrderedMap()
+	if set, ok := args.get("set"); ok && set != nil && set.(*orderedMap).len() > 0 {
+		fields.set("$set", toDocument(def.arg("set").Type, set))
+	}
+	if unset, ok := args.get("unset"); ok && unset != nil && len(unset.([]any)) > 0 {
+		fields.set("$unset", unset)
+	}
+	if inc, ok := args.get("increment"); ok && inc != nil && inc.(*orderedMap).len() > 0 {
+		fields.set("$increment", toDocument(def.arg("increment").Type, inc))
+	}
+	if fields.len() == 0 {
+		return nil, errors.InvalidArgument("one of set, unset or increment is required")
+	}
+
+	limit, err := writeLimit(args)
+	if err != nil {
+		return nil, err
+	}
+	update, err := jsoniter.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Update(e.ctx, &api.UpdateRequest{
+		Project:    e.project,
+		Branch:     e.branch,
+		Collection: def.collection,
+		Filter:     filter,
+		Fields:     update,
+		Options:    &api.UpdateRequestOptions{Limit: limit},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newOrderedMap().set("status", resp.Status).set("modified_count", int64(resp.ModifiedCount)), nil
+}
+
+func (e *executor) delete(def *FieldDef, args *orderedMap) (any, error) {
+	filter, err := filterArg(def, args)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := writeLimit(args)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Delete(e.ctx, &api.DeleteRequest{
+		Project:    e.project,
+		Branch:     e.branch,
+		Collection: def.collection,
+		Filter:     filter,
+		Options:    &api.DeleteRequestOptions{Limit: limit},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newOrderedMap().set("status", resp.Status).set("deleted_count", int64(resp.DeletedCount)), nil
+}
+
+func intArg(args *orderedMap, name string) (int64, bool) {
+	v, ok := args.get(name)
+	if !ok || v == nil {
+		return 0, false
+	}
+
+	return v.(int64), true
+}
+
+func writeLimit(args *orderedMap) (int64, error) {
+	limit, ok := intArg(args, "limit")
+	if ok && limit <= 0 {
+		return 0, errors.InvalidArgument("limit must be positive")
+	}
+
+	return limit, nil
+}
+
+// toDocument renames the fields of an input value to the document fields they map to.
+func toDocument(t *Type, v any) any {
+	t = t.named()
+	if t.Kind != KindInputObject {
+		return v
+	}
+
+	switch x := v.(type) {
+	case *orderedMap:
+		doc := newOrderedMap()
+		for _, k := range x.keys {
+			f := t.field(k)
+			doc.set(f.key, toDocument(f.Type, x.values[k]))
+		}
+		return doc
+	case []any:
+		list := make([]any, len(x))
+		for i, item := range x {
+			list[i] = toDocument(t, item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// filterArg translates the filter argument of the field into the Tigris filter grammar. The filter is empty when
+// the argument is not set.
+func filterArg(def *FieldDef, args *orderedMap) ([]byte, error) {
+	v, ok := args.get("filter")
+	if !ok || v == nil {
+		if def.op == opUpdate || def.op == opDelete {
+			return []byte("{}"), nil
+		}
+		return nil, nil
+	}
+
+	conds, err := translateFilter(def.arg("filter").Type.named(), v.(*orderedMap), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return jsoniter.Marshal(and(conds))
+}
+
+func translateFilter(t *Type, filter *orderedMap, prefix string) ([]any, error) {
+	var conds []any
+	for _, k := range filter.keys {
+		v := filter.values[k]
+		if v == nil {
+			continue
+		}
+
+		f := t.field(k)
+		if f.key == "" {
+			// _and and _or
+			var subs []any
+			for _, item := range v.([]any) {
+				sub, err := translateFilter(t, item.(*orderedMap), prefix)
+				if err != nil {
+					return nil, err
+				}
+				subs = append(subs, and(sub))
+			}
+
+			switch {
+			case len(subs) == 1:
+				conds = append(conds, subs[0])
+			case len(subs) > 1 && k == filterOr:
+				conds = append(conds, newOrderedMap().set("$or", subs))
+			case len(subs) > 1:
+				conds = append(conds, newOrderedMap().set("$and", subs))
+			}
+			continue
+		}
+
+		var (
+			sub []any
+			err error
+		)
+		if ft := f.Type.named(); ft.comparison {
+			sub, err = translateComparison(prefix+f.key, v.(*orderedMap))
+		} else {
+			sub, err = translateFilter(ft, v.(*orderedMap), prefix+f.key+".")
+		}
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, sub...)
+	}
+
+	return conds, nil
+}
+
+func translateComparison(path string, ops *orderedMap) ([]any, error) {
+	cond := func(v any) *orderedMap {
+		return newOrderedMap().set(path, v)
+	}
+
+	var conds []any
+	for _, op := range ops.keys {
+		v := ops.values[op]
+		if v == nil && op != "eq" {
+			return nil, errors.InvalidArgument("%s of %q can't be null", op, path)
+		}
+
+		switch op {
+		case "eq":
+			conds = append(conds, cond(v))
+		case "ne":
+			conds = append(conds, newOrderedMap().set("$or", []any{
+				cond(newOrderedMap().set("$lt", v)),
+				cond(newOrderedMap().set("$gt", v)),
+			}))
+		case "gt", "gte", "lt", "lte", "regex", "contains":
+			conds = append(conds, cond(newOrderedMap().set("$"+op, v)))
+		case "not_contains":
+			conds = append(conds, cond(newOrderedMap().set("$not", v)))
+		case "in":
+			values := v.([]any)
+			switch len(values) {
+			case 0:
+				return nil, errors.InvalidArgument("in of %q must have at least one value", path)
+			case 1:
+				conds = append(conds, cond(values[0]))
+			default:
+				or := make([]any, len(values))
+				for i, val := range values {
+					or[i] = cond(val)
+				}
+				conds = append(conds, newOrderedMap().set("$or", or))
+			}
+		}
+	}
+
+	return conds, nil
+}
+
+// and combines the conditions of a filter, an empty filter matches all the documents.
+func and(conds []any) any {
+	switch len(conds) {
+	case 0:
+		return newOrderedMap()
+	case 1:
+		return conds[0]
+	default:
+		return newOrderedMap().set("$and", conds)
+	}
+}
+
+func translateSort(t *Type, orderBy []any) ([]byte, error) {
+	var sort []any
+	for _, item := range orderBy {
+		sort = appendSort(sort, t, item.(*orderedMap), "")
+	}
+	if len(sort) == 0 {
+		return nil, nil
+	}
+
+	return jsoniter.Marshal(sort)
+}
+
+func appendSort(sort []any, t *Type, orderBy *orderedMap, prefix string) []any {
+	for _, k := range orderBy.keys {
+		f := t.field(k)
+		switch v := orderBy.values[k].(type) {
+		case string:
+			sort = append(sort, newOrderedMap().set(prefix+f.key, "$"+v))
+		case *orderedMap:
+			sort = appendSort(sort, f.Type.named(), v, prefix+f.key+".")
+		}
+	}
+
+	return sort
+}
@@ -0,0 +1,688 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+// Kind is the kind of GraphQL type, named after the values of the __TypeKind introspection enum.
+type Kind string
+
+const (
+	KindScalar      Kind = "SCALAR"
+	KindObject      Kind = "OBJECT"
+	KindEnum        Kind = "ENUM"
+	KindInputObject Kind = "INPUT_OBJECT"
+	KindList        Kind = "LIST"
+	KindNonNull     Kind = "NON_NULL"
+)
+
+const (
+	scalarInt      = "Int"
+	scalarInt64    = "Int64"
+	scalarFloat    = "Float"
+	scalarString   = "String"
+	scalarBoolean  = "Boolean"
+	scalarDateTime = "DateTime"
+	scalarBytes    = "Bytes"
+	scalarJSON     = "JSON"
+
+	sortOrderEnum = "SortOrder"
+	sortAsc       = "asc"
+	sortDesc      = "desc"
+
+	filterAnd = "_and"
+	filterOr  = "_or"
+)
+
+// collectionOp is the collection operation a root field resolves to.
+type collectionOp int
+
+const (
+	opNone collectionOp = iota
+	opFind
+	opCount
+	opInsert
+	opUpdate
+	opDelete
+)
+
+// Type is a GraphQL type. Named types are shared through the schema, list and non-null types wrap another type.
+type Type struct {
+	Kind        Kind
+	Name        string
+	Description string
+	// Fields are the fields of an object type or the input fields of an input object type.
+	Fields     []*FieldDef
+	EnumValues []string
+	OfType     *Type
+
+	// comparison is set on the input types holding the comparison operators of a field in a filter.
+	comparison bool
+}
+
+func listOf(t *Type) *Type {
+	return &Type{Kind: KindList, OfType: t}
+}
+
+func nonNull(t *Type) *Type {
+	return &Type{Kind: KindNonNull, OfType: t}
+}
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindList:
+		return "[" + t.OfType.String() + "]"
+	case KindNonNull:
+		return t.OfType.String() + "!"
+	default:
+		return t.Name
+	}
+}
+
+// named unwraps the list and non-null types.
+func (t *Type) named() *Type {
+	for t.OfType != nil {
+		t = t.OfType
+	}
+
+	return t
+}
+
+func (t *Type) field(name string) *FieldDef {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func (t *Type) isLeaf() bool {
+	k := t.named().Kind
+	return k == KindScalar || k == KindEnum
+}
+
+func (t *Type) isInput() bool {
+	k := t.named().Kind
+	return k == KindScalar || k == KindEnum || k == KindInputObject
+}
+
+// FieldDef is a field of an object type, an argument or an input field.
+type FieldDef struct {
+	Name        string
+	Description string
+	Args        []*FieldDef
+	Type        *Type
+	// DefaultValue is the default of an argument or input field as a GraphQL literal.
+	DefaultValue string
+
+	defaultValue any
+	// key is the document field the GraphQL field maps to.
+	key string
+	// op and collection are set on the root fields backed by a collection.
+	op         collectionOp
+	collection string
+}
+
+func (f *FieldDef) arg(name string) *FieldDef {
+	for _, a := range f.Args {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// Schema is the GraphQL schema of a database branch. It is generated from the collection schemas: every collection
+// gets an object type for its documents, query fields to read and count them and mutations to insert, update and
+// delete them.
+type Schema struct {
+	Query    *Type
+	Mutation *Type
+
+	types map[string]*Type
+	order []string
+}
+
+// Type returns the named type or nil if the schema does not define it.
+func (s *Schema) Type(name string) *Type {
+	return s.types[name]
+}
+
+func (s *Schema) add(t *Type) *Type {
+	s.types[t.Name] = t
+	s.order = append(s.order, t.Name)
+
+	return t
+}
+
+func (s *Schema) remove(name string) {
+	delete(s.types, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Schema) uniqueName(name string) string {
+	n := name
+	for i := 1; s.types[n] != nil; i++ {
+		n = name + "_" + strconv.Itoa(i)
+	}
+
+	return n
+}
+
+func newSchema() *Schema {
+	s := &Schema{types: make(map[string]*Type)}
+
+	for _, sc := range []struct{ name, desc string }{
+		{scalarInt, "The `Int` scalar type represents non-fractional signed whole numeric values between -2^31 and 2^31-1."},
+		{scalarFloat, "The `Float` scalar type represents signed double-precision fractional values."},
+		{scalarString, "The `String` scalar type represents textual data as UTF-8 character sequences."},
+		{scalarBoolean, "The `Boolean` scalar type represents `true` or `false`."},
+		{scalarInt64, "A signed 64-bit integer, the type of the `integer` fields."},
+		{scalarDateTime, "A timestamp in RFC 3339 format."},
+		{scalarBytes, "Binary data, base64 encoded."},
+		{scalarJSON, "An arbitrary JSON value."},
+	} {
+		s.add(&Type{Kind: KindScalar, Name: sc.name, Description: sc.desc})
+	}
+	s.add(&Type{
+		Kind:        KindEnum,
+		Name:        sortOrderEnum,
+		Description: "The direction of a sort.",
+		EnumValues:  []string{sortAsc, sortDesc},
+	})
+
+	addIntrospectionTypes(s)
+
+	s.Query = s.add(&Type{Kind: KindObject, Name: "Query"})
+
+	return s
+}
+
+// isSpecified returns true for the types every GraphQL schema has, which are not printed in the SDL.
+func isSpecified(t *Type) bool {
+	switch t.Name {
+	case scalarInt, scalarFloat, scalarString, scalarBoolean:
+		return true
+	default:
+		return strings.HasPrefix(t.Name, "__")
+	}
+}
+
+// gqlName maps a collection or field name to a valid GraphQL name by replacing the characters GraphQL does not
+// allow. Names starting with "__" are reserved for introspection and can't be mapped.
+func gqlName(name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return "", false
+	}
+
+	b := []byte(name)
+	for i, c := range b {
+		if !isNameContinue(c) {
+			b[i] = '_'
+		}
+	}
+	if isDigit(b[0]) {
+		b = append([]byte{'_'}, b...)
+	}
+
+	return string(b), true
+}
+
+// property is a field of a collection schema.
+type property struct {
+	key    string
+	typ    string
+	format string
+	props  []*property
+	items  *property
+}
+
+func parseProperties(data []byte) []*property {
+	var props []*property
+	_ = jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, _ int) error {
+		if dataType == jsonparser.Object {
+			props = append(props, parseProperty(string(key), value))
+		}
+		return nil
+	}, "properties")
+
+	return props
+}
+
+func parseProperty(key string, data []byte) *property {
+	p := &property{key: key}
+	p.typ, _ = jsonparser.GetString(data, "type")
+	p.format, _ = jsonparser.GetString(data, "format")
+
+	switch p.typ {
+	case "object":
+		p.props = parseProperties(data)
+	case "array":
+		if items, dataType, _, err := jsonparser.Get(data, "items"); err == nil && dataType == jsonparser.Object {
+			p.items = parseProperty(key, items)
+		}
+	}
+
+	return p
+}
+
+// scalarOf returns the scalar type of the property. Objects without properties and arrays are represented as JSON.
+func scalarOf(p *property) string {
+	switch p.typ {
+	case "string":
+		switch p.format {
+		case "date-time":
+			return scalarDateTime
+		case "byte":
+			return scalarBytes
+		default:
+			return scalarString
+		}
+	case "integer":
+		if p.format == "int32" {
+			return scalarInt
+		}
+		return scalarInt64
+	case "number":
+		return scalarFloat
+	case "boolean":
+		return scalarBoolean
+	default:
+		return scalarJSON
+	}
+}
+
+type builder struct {
+	s           *Schema
+	comparisons map[string]*Type
+	results     map[collectionOp]*Type
+}
+
+// BuildSchema generates the GraphQL schema from the collections of a database branch.
+func BuildSchema(collections []*api.CollectionDescription) *Schema {
+	b := &builder{
+		s:           newSchema(),
+		comparisons: make(map[string]*Type),
+		results:     make(map[collectionOp]*Type),
+	}
+
+	sorted := append([]*api.CollectionDescription(nil), collections...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetCollection() < sorted[j].GetCollection()
+	})
+	for _, c := range sorted {
+		b.addCollection(c.GetCollection(), c.GetSchema())
+	}
+
+	return b.s
+}
+
+func (b *builder) addCollection(collection string, schema []byte) {
+	base, ok := gqlName(collection)
+	if !ok || b.s.Query.field(base) != nil {
+		return
+	}
+
+	props := parseProperties(schema)
+	doc := b.composite(KindObject, base, "", props, b.outputType)
+	if doc == nil {
+		return
+	}
+	doc.Description = "A document of the " + strconv.Quote(collection) + " collection."
+
+	filter := b.filterType(base, props)
+	input := b.composite(KindInputObject, base, "_input", props, b.inputType)
+	orderBy := b.composite(KindInputObject, base, "_order_by", props, b.orderType)
+	increment := b.composite(KindInputObject, base, "_inc_input", props, b.incrementType)
+
+	findArgs := []*FieldDef{{Name: "filter", Type: filter, Description: "Only return the documents matching the filter."}}
+	if orderBy != nil {
+		findArgs = append(findArgs, &FieldDef{Name: "order_by", Type: listOf(nonNull(orderBy)), Description: "Sort the documents by these fields."})
+	}
+	findArgs = append(findArgs,
+		&FieldDef{Name: "limit", Type: b.s.types[scalarInt], Description: "The maximum number of documents to return."},
+		&FieldDef{Name: "offset", Type: b.s.types[scalarInt], Description: "The number of documents to skip."},
+	)
+
+	b.s.Query.Fields = append(b.s.Query.Fields,
+		&FieldDef{
+			Name:        base,
+			Description: "Reads the documents of the " + strconv.Quote(collection) + " collection.",
+			Args:        findArgs,
+			Type:        nonNull(listOf(nonNull(doc))),
+			op:          opFind,
+			collection:  collection,
+		},
+		&FieldDef{
+			Name:        base + "_count",
+			Description: "Counts the documents of the " + strconv.Quote(collection) + " collection.",
+			Args:        []*FieldDef{{Name: "filter", Type: filter, Description: "Only count the documents matching the filter."}},
+			Type:        nonNull(b.s.types[scalarInt64]),
+			op:          opCount,
+			collection:  collection,
+		},
+	)
+
+	if b.s.Mutation == nil {
+		b.s.Mutation = b.s.add(&Type{Kind: KindObject, Name: "Mutation"})
+	}
+	if input != nil {
+		b.s.Mutation.Fields = append(b.s.Mutation.Fields, &FieldDef{
+			Name:        "insert_" + base,
+			Description: "Inserts documents into the " + strconv.Quote(collection) + " collection.",
+			Args:        []*FieldDef{{Name: "documents", Type: nonNull(listOf(nonNull(input)))}},
+			Type:        nonNull(b.resultType(opInsert)),
+			op:          opInsert,
+			collection:  collection,
+		})
+	}
+
+	updateArgs := []*FieldDef{{Name: "filter", Type: nonNull(filter), Description: "Update the documents matching the filter."}}
+	if input != nil {
+		updateArgs = append(updateArgs, &FieldDef{Name: "set", Type: input, Description: "The fields to set."})
+	}
+	updateArgs = append(updateArgs, &FieldDef{Name: "unset", Type: listOf(nonNull(b.s.types[scalarString])), Description: "The fields to remove."})
+	if increment != nil {
+		updateArgs = append(updateArgs, &FieldDef{Name: "increment", Type: increment, Description: "The fields to increment by the given amount."})
+	}
+	updateArgs = append(updateArgs, &FieldDef{Name: "limit", Type: b.s.types[scalarInt], Description: "The maximum number of documents to update."})
+
+	b.s.Mutation.Fields = append(b.s.Mutation.Fields,
+		&FieldDef{
+			Name:        "update_" + base,
+			Description: "Updates the documents of the " + strconv.Quote(collection) + " collection.",
+			Args:        updateArgs,
+			Type:        nonNull(b.resultType(opUpdate)),
+			op:          opUpdate,
+			collection:  collection,
+		},
+		&FieldDef{
+			Name:        "delete_" + base,
+			Description: "Deletes documents from the " + strconv.Quote(collection) + " collection.",
+			Args: []*FieldDef{
+				{Name: "filter", Type: nonNull(filter), Description: "Delete the documents matching the filter."},
+				{Name: "limit", Type: b.s.types[scalarInt], Description: "The maximum number of documents to delete."},
+			},
+			Type:       nonNull(b.resultType(opDelete)),
+			op:         opDelete,
+			collection: collection,
+		},
+	)
+}
+
+// composite builds an object or input object type named base+suffix from the properties. The type of every field
+// is returned by fieldType, which returns nil for the properties the type can't represent. Nil is returned if no
+// field is left.
+func (b *builder) composite(kind Kind, base string, suffix string, props []*property, fieldType func(string, *property) *Type) *Type {
+	t := b.s.add(&Type{Kind: kind, Name: b.s.uniqueName(base + suffix)})
+	for _, p := range props {
+		name, ok := gqlName(p.key)
+		if !ok || t.field(name) != nil {
+			continue
+		}
+
+		ft := fieldType(base+"_"+name, p)
+		if ft == nil {
+			continue
+		}
+		t.Fields = append(t.Fields, &FieldDef{Name: name, Type: ft, key: p.key})
+	}
+
+	if len(t.Fields) == 0 {
+		b.s.remove(t.Name)
+		return nil
+	}
+
+	return t
+}
+
+func (b *builder) outputType(base string, p *property) *Type {
+	switch p.typ {
+	case "object":
+		if t := b.composite(KindObject, base, "", p.props, b.outputType); t != nil {
+			return t
+		}
+	case "array":
+		if p.items != nil {
+			return listOf(b.outputType(base, p.items))
+		}
+	}
+
+	return b.s.types[scalarOf(p)]
+}
+
+func (b *builder) inputType(base string, p *property) *Type {
+	switch p.typ {
+	case "object":
+		if t := b.composite(KindInputObject, base, "_input", p.props, b.inputType); t != nil {
+			return t
+		}
+	case "array":
+		if p.items != nil {
+			return listOf(b.inputType(base, p.items))
+		}
+	}
+
+	return b.s.types[scalarOf(p)]
+}
+
+// filterType builds the filter input type of a collection, its fields are combined with AND and the _and and _or
+// fields combine nested filters.
+func (b *builder) filterType(base string, props []*property) *Type {
+	t := b.composite(KindInputObject, base, "_filter", props, b.fieldFilterType)
+	if t == nil {
+		t = b.s.add(&Type{Kind: KindInputObject, Name: b.s.uniqueName(base + "_filter")})
+	}
+	t.Description = "A filter on the documents, all the conditions must match."
+
+	for _, name := range []string{filterAnd, filterOr} {
+		if t.field(name) != nil {
+			continue
+		}
+
+		desc := "Matches if all the filters match."
+		if name == filterOr {
+			desc = "Matches if any of the filters match."
+		}
+		t.Fields = append(t.Fields, &FieldDef{Name: name, Type: listOf(nonNull(t)), Description: desc})
+	}
+
+	return t
+}
+
+func (b *builder) fieldFilterType(base string, p *property) *Type {
+	switch p.typ {
+	case "object":
+		return b.composite(KindInputObject, base, "_filter", p.props, b.fieldFilterType)
+	case "array":
+		if p.items == nil || p.items.typ == "object" || p.items.typ == "array" {
+			return nil
+		}
+		return b.comparison(scalarOf(p.items))
+	default:
+		return b.comparison(scalarOf(p))
+	}
+}
+
+// comparison returns the input type with the comparison operators of a scalar, nil if the scalar can't be filtered.
+func (b *builder) comparison(scalar string) *Type {
+	if t, ok := b.comparisons[scalar]; ok {
+		return t
+	}
+
+	st := b.s.types[scalar]
+	var fields []*FieldDef
+	switch scalar {
+	case scalarBoolean:
+		fields = []*FieldDef{{Name: "eq", Type: st, Description: "Equal to."}}
+	case scalarInt, scalarInt64, scalarFloat, scalarString, scalarDateTime:
+		fields = []*FieldDef{
+			{Name: "eq", Type: st, Description: "Equal to."},
+			{Name: "ne", Type: st, Description: "Not equal to."},
+			{Name: "gt", Type: st, Description: "Greater than."},
+			{Name: "gte", Type: st, Description: "Greater than or equal to."},
+			{Name: "lt", Type: st, Description: "Less than."},
+			{Name: "lte", Type: st, Description: "Less than or equal to."},
+			{Name: "in", Type: listOf(nonNull(st)), Description: "Equal to any of the values."},
+		}
+		if scalar == scalarString {
+			fields = append(fields,
+				&FieldDef{Name: "regex", Type: st, Description: "Matches the regular expression."},
+				&FieldDef{Name: "contains", Type: st, Description: "Contains the substring."},
+				&FieldDef{Name: "not_contains", Type: st, Description: "Does not contain the substring."},
+			)
+		}
+	}
+
+	var t *Type
+	if fields != nil {
+		t = b.s.add(&Type{
+			Kind:        KindInputObject,
+			Name:        b.s.uniqueName(scalar + "_comparison"),
+			Description: "The conditions on a field of type " + scalar + ", all of them must match.",
+			Fields:      fields,
+			comparison:  true,
+		})
+	}
+	b.comparisons[scalar] = t
+
+	return t
+}
+
+func (b *builder) orderType(base string, p *property) *Type {
+	switch p.typ {
+	case "object":
+		return b.composite(KindInputObject, base, "_order_by", p.props, b.orderType)
+	case "array":
+		return nil
+	}
+
+	if s := scalarOf(p); s == scalarJSON || s == scalarBytes {
+		return nil
+	}
+
+	return b.s.types[sortOrderEnum]
+}
+
+func (b *builder) incrementType(_ string, p *property) *Type {
+	switch s := scalarOf(p); s {
+	case scalarInt, scalarInt64, scalarFloat:
+		return b.s.types[s]
+	default:
+		return nil
+	}
+}
+
+func (b *builder) resultType(op collectionOp) *Type {
+	if t, ok := b.results[op]; ok {
+		return t
+	}
+
+	status := &FieldDef{Name: "status", Type: nonNull(b.s.types[scalarString]), key: "status"}
+	var t *Type
+	switch op {
+	case opInsert:
+		t = &Type{Name: "InsertResult", Fields: []*FieldDef{
+			status,
+			{Name: "keys", Type: nonNull(listOf(nonNull(b.s.types[scalarJSON]))), key: "keys", Description: "The primary keys of the inserted documents."},
+		}}
+	case opUpdate:
+		t = &Type{Name: "UpdateResult", Fields: []*FieldDef{
+			status,
+			{Name: "modified_count", Type: nonNull(b.s.types[scalarInt]), key: "modified_count"},
+		}}
+	default:
+		t = &Type{Name: "DeleteResult", Fields: []*FieldDef{
+			status,
+			{Name: "deleted_count", Type: nonNull(b.s.types[scalarInt]), key: "deleted_count"},
+		}}
+	}
+	t.Kind = KindObject
+	t.Name = b.s.uniqueName(t.Name)
+	b.results[op] = b.s.add(t)
+
+	return t
+}
+
+// SDL prints the schema in the GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	var sb strings.Builder
+	for _, name := range s.order {
+		t := s.types[name]
+		if isSpecified(t) || (t.Kind == KindObject && len(t.Fields) == 0) {
+			continue
+		}
+
+		writeDescription(&sb, t.Description, "")
+		switch t.Kind {
+		case KindScalar:
+			sb.WriteString("scalar " + t.Name + "\n\n")
+		case KindEnum:
+			sb.WriteString("enum " + t.Name + " {\n")
+			for _, v := range t.EnumValues {
+				sb.WriteString("  " + v + "\n")
+			}
+			sb.WriteString("}\n\n")
+		case KindObject, KindInputObject:
+			if t.Kind == KindObject {
+				sb.WriteString("type " + t.Name + " {\n")
+			} else {
+				sb.WriteString("input " + t.Name + " {\n")
+			}
+			for _, f := range t.Fields {
+				writeDescription(&sb, f.Description, "  ")
+				sb.WriteString("  " + f.Name)
+				if len(f.Args) > 0 {
+					sb.WriteString("(")
+					for i, a := range f.Args {
+						if i > 0 {
+							sb.WriteString(", ")
+						}
+						sb.WriteString(a.Name + ": " + a.Type.String())
+						if a.DefaultValue != "" {
+							sb.WriteString(" = " + a.DefaultValue)
+						}
+					}
+					sb.WriteString(")")
+				}
+				sb.WriteString(": " + f.Type.String() + "\n")
+			}
+			sb.WriteString("}\n\n")
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func writeDescription(sb *strings.Builder, desc string, indent string) {
+	if desc != "" {
+		sb.WriteString(indent + strconv.Quote(desc) + "\n")
+	}
+}
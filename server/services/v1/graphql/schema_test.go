@@ -0,0 +1,134 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+const testSchema = `{
+	"title": "users",
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string"},
+		"age": {"type": "integer", "format": "int32"},
+		"score": {"type": "number"},
+		"active": {"type": "boolean"},
+		"created": {"type": "string", "format": "date-time"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"address": {"type": "object", "properties": {"city": {"type": "string"}, "zip-code": {"type": "string"}}},
+		"extra": {"type": "object"}
+	},
+	"primary_key": ["id"]
+}`
+
+func testCollections() []*api.CollectionDescription {
+	return []*api.CollectionDescription{{Collection: "users", Schema: []byte(testSchema)}}
+}
+
+func TestBuildSchema(t *testing.T) {
+	s := BuildSchema(testCollections())
+
+	users := s.Type("users")
+	require.NotNil(t, users)
+	require.Equal(t, KindObject, users.Kind)
+
+	types := map[string]string{}
+	for _, f := range users.Fields {
+		types[f.Name] = f.Type.String()
+	}
+	require.Equal(t, map[string]string{
+		"id":      "Int64",
+		"name":    "String",
+		"age":     "Int",
+		"score":   "Float",
+		"active":  "Boolean",
+		"created": "DateTime",
+		"tags":    "[String]",
+		"address": "users_address",
+		"extra":   "JSON",
+	}, types)
+
+	zip := s.Type("users_address").field("zip_code")
+	require.NotNil(t, zip)
+	require.Equal(t, "zip-code", zip.key)
+
+	find := s.Query.field("users")
+	require.Equal(t, "[users!]!", find.Type.String())
+	require.Equal(t, "users_filter", find.arg("filter").Type.String())
+	require.Equal(t, "[users_order_by!]", find.arg("order_by").Type.String())
+	require.Equal(t, "Int64!", s.Query.field("users_count").Type.String())
+
+	filter := s.Type("users_filter")
+	require.Equal(t, "String_comparison", filter.field("name").Type.String())
+	require.Equal(t, "String_comparison", filter.field("tags").Type.String())
+	require.Equal(t, "users_address_filter", filter.field("address").Type.String())
+	require.Nil(t, filter.field("extra"))
+	require.Equal(t, "[users_filter!]", filter.field("_or").Type.String())
+
+	require.Nil(t, s.Type("users_order_by").field("tags"))
+	require.Equal(t, "SortOrder", s.Type("users_order_by").field("name").Type.String())
+
+	require.Equal(t, "[users_input!]!", s.Mutation.field("insert_users").arg("documents").Type.String())
+	require.Equal(t, "users_address_input", s.Type("users_input").field("address").Type.String())
+	require.Equal(t, "users_filter!", s.Mutation.field("update_users").arg("filter").Type.String())
+	require.Equal(t, "users_inc_input", s.Mutation.field("update_users").arg("increment").Type.String())
+	require.Len(t, s.Type("users_inc_input").Fields, 3)
+	require.Equal(t, "DeleteResult!", s.Mutation.field("delete_users").Type.String())
+}
+
+func TestBuildSchemaNames(t *testing.T) {
+	s := BuildSchema([]*api.CollectionDescription{
+		{Collection: "Query", Schema: []byte(`{"properties":{"id":{"type":"integer"}}}`)},
+		{Collection: "1st", Schema: []byte(`{"properties":{"__id":{"type":"integer"},"x":{"type":"string"}}}`)},
+	})
+
+	require.Equal(t, "[_1st!]!", s.Query.field("_1st").Type.String())
+	require.Nil(t, s.Type("_1st").field("__id"))
+	require.Equal(t, "[Query_1!]!", s.Query.field("Query").Type.String())
+}
+
+func TestBuildSchemaEmpty(t *testing.T) {
+	s := BuildSchema(nil)
+	require.Nil(t, s.Mutation)
+	require.Empty(t, s.Query.Fields)
+	require.NotContains(t, s.SDL(), "type Query")
+}
+
+func TestSDL(t *testing.T) {
+	sdl := BuildSchema(testCollections()).SDL()
+
+	require.Contains(t, sdl, `"A document of the \"users\" collection."
+type users {
+  id: Int64
+  name: String
+  age: Int
+  score: Float
+  active: Boolean
+  created: DateTime
+  tags: [String]
+  address: users_address
+  extra: JSON
+}`)
+	require.Contains(t, sdl, "  users(filter: users_filter, order_by: [users_order_by!], limit: Int, offset: Int): [users!]!\n")
+	require.Contains(t, sdl, "  delete_users(filter: users_filter!, limit: Int): DeleteResult!\n")
+	require.Contains(t, sdl, "enum SortOrder {\n  asc\n  desc\n}")
+	require.Contains(t, sdl, "scalar Int64\n")
+	require.NotContains(t, sdl, "__Type")
+	require.NotContains(t, sdl, "scalar String")
+}
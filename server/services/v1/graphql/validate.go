@@ -0,0 +1,263 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// fieldDef returns the definition of a field of the type, including the introspection fields of the query type.
+func (s *Schema) fieldDef(t *Type, name string) *FieldDef {
+	if f := t.field(name); f != nil {
+		return f
+	}
+	if t == s.Query {
+		return s.introspectionField(name)
+	}
+
+	return nil
+}
+
+// resolveTypeRef returns the type a variable definition refers to, nil if the named type does not exist.
+func (s *Schema) resolveTypeRef(ref *TypeRef) *Type {
+	var t *Type
+	if ref.Elem != nil {
+		elem := s.resolveTypeRef(ref.Elem)
+		if elem == nil {
+			return nil
+		}
+		t = listOf(elem)
+	} else if t = s.types[ref.Name]; t == nil {
+		return nil
+	}
+
+	if ref.NonNull {
+		t = nonNull(t)
+	}
+
+	return t
+}
+
+type validator struct {
+	s        *Schema
+	doc      *Document
+	vars     map[string]*VariableDefinition
+	visiting map[string]bool
+}
+
+// validate checks the operation against the schema before it is executed. The values of the arguments are checked
+// when they are coerced during the execution.
+func validate(s *Schema, doc *Document, op *Operation) error {
+	v := &validator{
+		s:        s,
+		doc:      doc,
+		vars:     make(map[string]*VariableDefinition),
+		visiting: make(map[string]bool),
+	}
+
+	var root *Type
+	switch op.Type {
+	case "query":
+		root = s.Query
+	case "mutation":
+		if s.Mutation == nil {
+			return errors.InvalidArgument("Schema is not configured for mutations.")
+		}
+		root = s.Mutation
+	default:
+		return errors.Unimplemented("subscriptions are not supported")
+	}
+
+	for _, def := range op.Variables {
+		if _, ok := v.vars[def.Name]; ok {
+			return errors.InvalidArgument("There can be only one variable named \"$%s\".", def.Name)
+		}
+
+		t := s.resolveTypeRef(def.Type)
+		if t == nil {
+			return errors.InvalidArgument("Unknown type \"%s\".", def.Type)
+		}
+		if !t.isInput() {
+			return errors.InvalidArgument("Variable \"$%s\" cannot be non-input type \"%s\".", def.Name, def.Type)
+		}
+		v.vars[def.Name] = def
+	}
+
+	if err := v.directives(op.Directives, false); err != nil {
+		return err
+	}
+
+	return v.selections(root, op.SelectionSet)
+}
+
+func (v *validator) selections(t *Type, sels []Selection) error {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *Field:
+			if err := v.field(t, s); err != nil {
+				return err
+			}
+		case *FragmentSpread:
+			if err := v.directives(s.Directives, true); err != nil {
+				return err
+			}
+
+			frag, ok := v.doc.Fragments[s.Name]
+			if !ok {
+				return errors.InvalidArgument("Unknown fragment %q.", s.Name)
+			}
+			if v.visiting[s.Name] {
+				return errors.InvalidArgument("Cannot spread fragment %q within itself.", s.Name)
+			}
+			if err := v.typeCondition(t, frag.TypeCondition); err != nil {
+				return err
+			}
+
+			v.visiting[s.Name] = true
+			if err := v.selections(t, frag.SelectionSet); err != nil {
+				return err
+			}
+			delete(v.visiting, s.Name)
+		case *InlineFragment:
+			if err := v.directives(s.Directives, true); err != nil {
+				return err
+			}
+			if s.TypeCondition != "" {
+				if err := v.typeCondition(t, s.TypeCondition); err != nil {
+					return err
+				}
+			}
+			if err := v.selections(t, s.SelectionSet); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) field(t *Type, f *Field) error {
+	if err := v.directives(f.Directives, true); err != nil {
+		return err
+	}
+
+	if f.Name == "__typename" {
+		if len(f.SelectionSet) > 0 {
+			return errors.InvalidArgument("Field \"__typename\" must not have a selection since type \"String!\" has no subfields.")
+		}
+		return nil
+	}
+
+	def := v.s.fieldDef(t, f.Name)
+	if def == nil {
+		return errors.InvalidArgument("Cannot query field %q on type %q.", f.Name, t.Name)
+	}
+
+	if err := v.arguments(def, f.Args); err != nil {
+		return err
+	}
+
+	if def.Type.isLeaf() {
+		if len(f.SelectionSet) > 0 {
+			return errors.InvalidArgument("Field %q must not have a selection since type \"%s\" has no subfields.", f.Name, def.Type)
+		}
+		return nil
+	}
+	if len(f.SelectionSet) == 0 {
+		return errors.InvalidArgument("Field %q of type \"%s\" must have a selection of subfields. Did you mean \"%s { ... }\"?", f.Name, def.Type, f.Name)
+	}
+
+	return v.selections(def.Type.named(), f.SelectionSet)
+}
+
+func (v *validator) arguments(def *FieldDef, args []*Argument) error {
+	seen := make(map[string]bool)
+	for _, a := range args {
+		if def.arg(a.Name) == nil {
+			return errors.InvalidArgument("Unknown argument %q on field %q.", a.Name, def.Name)
+		}
+		if seen[a.Name] {
+			return errors.InvalidArgument("There can be only one argument named %q.", a.Name)
+		}
+		seen[a.Name] = true
+
+		if err := v.value(a.Value); err != nil {
+			return err
+		}
+	}
+
+	for _, ad := range def.Args {
+		if ad.Type.Kind == KindNonNull && ad.defaultValue == nil && !seen[ad.Name] {
+			return errors.InvalidArgument("Field %q argument %q of type \"%s\" is required, but it was not provided.", def.Name, ad.Name, ad.Type)
+		}
+	}
+
+	return nil
+}
+
+// directives checks that only @skip and @include are used and only on fields and fragments.
+func (v *validator) directives(dirs []*Directive, allowed bool) error {
+	for _, d := range dirs {
+		if d.Name != "skip" && d.Name != "include" {
+			return errors.InvalidArgument("Unknown directive \"@%s\".", d.Name)
+		}
+		if !allowed {
+			return errors.InvalidArgument("Directive \"@%s\" may not be used on operations.", d.Name)
+		}
+		if len(d.Args) != 1 || d.Args[0].Name != "if" {
+			return errors.InvalidArgument("Directive \"@%s\" argument \"if\" of type \"Boolean!\" is required, but it was not provided.", d.Name)
+		}
+		if err := v.value(d.Args[0].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) typeCondition(t *Type, cond string) error {
+	if cond == t.Name {
+		return nil
+	}
+	if v.s.types[cond] == nil {
+		return errors.InvalidArgument("Unknown type %q.", cond)
+	}
+
+	return errors.InvalidArgument("Fragment cannot be spread here as objects of type %q can never be of type %q.", t.Name, cond)
+}
+
+// value checks that the variables used in the value are defined by the operation.
+func (v *validator) value(val Value) error {
+	switch x := val.(type) {
+	case *Variable:
+		if _, ok := v.vars[x.Name]; !ok {
+			return errors.InvalidArgument("Variable \"$%s\" is not defined.", x.Name)
+		}
+	case *ListValue:
+		for _, item := range x.Values {
+			if err := v.value(item); err != nil {
+				return err
+			}
+		}
+	case *ObjectValue:
+		for _, f := range x.Fields {
+			if err := v.value(f.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"bytes"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// orderedMap is a JSON object that keeps the order of its keys. It is used for documents, coerced input objects
+// and the response, where GraphQL requires the fields in the order they were selected.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+func (m *orderedMap) set(key string, value any) *orderedMap {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+
+	return m
+}
+
+func (m *orderedMap) get(key string) (any, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *orderedMap) len() int {
+	return len(m.keys)
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := jsoniter.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := jsoniter.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// decodeJSON decodes a JSON value keeping the order of the object keys. Numbers are returned as json.Number so that
+// 64-bit integers are not rounded.
+func decodeJSON(data []byte) (any, error) {
+	it := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(it)
+
+	v := readJSON(it)
+	if it.Error != nil {
+		return nil, errors.InvalidArgument("invalid JSON: %s", it.Error.Error())
+	}
+
+	return v, nil
+}
+
+func readJSON(it *jsoniter.Iterator) any {
+	switch it.WhatIsNext() {
+	case jsoniter.ObjectValue:
+		m := newOrderedMap()
+		it.ReadObjectCB(func(it *jsoniter.Iterator, key string) bool {
+			m.set(key, readJSON(it))
+			return true
+		})
+		return m
+	case jsoniter.ArrayValue:
+		arr := []any{}
+		it.ReadArrayCB(func(it *jsoniter.Iterator) bool {
+			arr = append(arr, readJSON(it))
+			return true
+		})
+		return arr
+	case jsoniter.StringValue:
+		return it.ReadString()
+	case jsoniter.NumberValue:
+		return it.ReadNumber()
+	case jsoniter.BoolValue:
+		return it.ReadBool()
+	case jsoniter.NilValue:
+		it.ReadNil()
+		return nil
+	default:
+		it.ReportError("decode", "unexpected JSON value")
+		return nil
+	}
+}
@@ -23,13 +23,22 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/health"
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/cache"
+	"github.com/tigrisdata/tigris/store/search"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 const (
 	healthPath = "/health"
+	// the liveness and the readiness endpoints with the status of the dependencies, outside the api path like the
+	// metrics endpoint
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
 )
 
 type healthService struct {
@@ -37,12 +46,22 @@ type healthService struct {
 
 	versionH *metadata.VersionHandler
 	txMgr    *transaction.Manager
+	checker  *health.Checker
 }
 
-func newHealthService(txMgr *transaction.Manager) *healthService {
+func newHealthService(txMgr *transaction.Manager, searchStore search.Store) *healthService {
+	cfg := config.DefaultConfig.Health
+	checker := health.NewChecker(cfg,
+		health.FDB(cfg, txMgr),
+		health.Search(cfg, searchStore),
+		health.Cache(cfg, cache.NewCache(&config.DefaultConfig.Cache)),
+	)
+	checker.Start()
+
 	return &healthService{
 		versionH: &metadata.VersionHandler{},
 		txMgr:    txMgr,
+		checker:  checker,
 	}
 }
 
@@ -68,10 +87,13 @@ func (h *healthService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Chann
 	router.HandleFunc(apiPathPrefix+healthPath, func(w http.ResponseWriter, r *http.Request) {
 		mux.ServeHTTP(w, r)
 	})
+	router.Get(healthzPath, h.checker.Healthz)
+	router.Get(readyzPath, h.checker.Readyz)
 	return nil
 }
 
 func (h *healthService) RegisterGRPC(grpc *grpc.Server) error {
 	api.RegisterHealthAPIServer(grpc, h)
+	healthpb.RegisterHealthServer(grpc, h.checker.GRPCServer())
 	return nil
 }
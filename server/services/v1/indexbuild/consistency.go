@@ -0,0 +1,48 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexbuild
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/grpc/metadata"
+)
+
+// ConsistencyHandler checks the secondary indexes of the collection against its documents. The branch is passed in
+// the "branch" query parameter and the inconsistent entries are repaired if the "repair" one is true.
+type ConsistencyHandler struct {
+	client api.IndexConsistencyClient
+}
+
+func NewConsistencyHandler(client api.IndexConsistencyClient) *ConsistencyHandler {
+	return &ConsistencyHandler{client: client}
+}
+
+func (h *ConsistencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if r.URL.Query().Get("repair") == "true" {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderIndexRepair, "true")
+	}
+
+	resp, err := h.client.CheckIndexConsistency(ctx, &api.DescribeCollectionRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
@@ -0,0 +1,63 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indexbuild serves the HTTP variants of the index management APIs: the BuildIndexStatus API of the background
+// index builds, the IndexUsageStats API, the IndexSuggestions API of the index advisor, the SlowQueries API of the slow
+// query log, and the status and rebuild APIs of the search indexes.
+package indexbuild
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// Handler returns the build status of the secondary indexes of the collection, the branch is passed in the "branch"
+// query parameter.
+type Handler struct {
+	client api.IndexBuildsClient
+}
+
+func NewHandler(client api.IndexBuildsClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.BuildIndexStatus(common.OutgoingContext(r), &api.BuildCollectionIndexRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
+
+func writeResponse(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
@@ -0,0 +1,102 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexbuild
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// SearchStatusHandler returns the health of the search index of the collection, the branch is passed in the "branch"
+// query parameter.
+type SearchStatusHandler struct {
+	client api.SearchIndexHealthClient
+}
+
+func NewSearchStatusHandler(client api.SearchIndexHealthClient) *SearchStatusHandler {
+	return &SearchStatusHandler{client: client}
+}
+
+func (h *SearchStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.SearchIndexStatus(common.OutgoingContext(r), &api.DescribeCollectionRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
+
+// SearchRebuildHandler rebuilds the search index of the collection. The response is a NDJSON stream of the progress
+// of the rebuild, one status per line, flushed as soon as it is received.
+type SearchRebuildHandler struct {
+	client api.SearchIndexHealthClient
+}
+
+func NewSearchRebuildHandler(client api.SearchIndexHealthClient) *SearchRebuildHandler {
+	return &SearchRebuildHandler{client: client}
+}
+
+func (h *SearchRebuildHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stream, err := h.client.RebuildSearchIndex(common.OutgoingContext(r), &api.BuildCollectionSearchIndexRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	// wait for the first status, so that the errors detected before the rebuild starts are returned with their status
+	// code
+	status, err := stream.Recv()
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		if _, err = w.Write(append(status.GetData(), '\n')); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if status, err = stream.Recv(); err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the status code is already sent, report the failure as the last line of the stream
+			log.Err(err).Str("collection", chi.URLParam(r, "collection")).Msg("search index rebuild failed")
+			e := api.FromStatusError(err)
+			data, _ := jsoniter.Marshal(map[string]any{
+				"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+			})
+			_, _ = w.Write(append(data, '\n'))
+			return
+		}
+	}
+}
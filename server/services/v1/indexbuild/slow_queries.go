@@ -0,0 +1,41 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexbuild
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// SlowQueriesHandler returns the slow reads of the collections of the project, the branch is passed in the
+// "branch" query parameter.
+type SlowQueriesHandler struct {
+	client api.SlowQueryLogClient
+}
+
+func NewSlowQueriesHandler(client api.SlowQueryLogClient) *SlowQueriesHandler {
+	return &SlowQueriesHandler{client: client}
+}
+
+func (h *SlowQueriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.SlowQueries(common.OutgoingContext(r), &api.DescribeDatabaseRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
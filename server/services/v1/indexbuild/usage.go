@@ -0,0 +1,48 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexbuild
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/grpc/metadata"
+)
+
+// UsageHandler returns the usage of the secondary indexes of the collection. The branch is passed in the "branch"
+// query parameter and the duration after which an index that is not read is unused in the "unused_for" one.
+type UsageHandler struct {
+	client api.IndexUsageClient
+}
+
+func NewUsageHandler(client api.IndexUsageClient) *UsageHandler {
+	return &UsageHandler{client: client}
+}
+
+func (h *UsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.OutgoingContext(r)
+	if unusedFor := r.URL.Query().Get("unused_for"); len(unusedFor) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderIndexUnusedFor, unusedFor)
+	}
+
+	resp, err := h.client.IndexUsageStats(ctx, &api.DescribeCollectionRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     r.URL.Query().Get("branch"),
+	})
+	writeResponse(w, resp, err)
+}
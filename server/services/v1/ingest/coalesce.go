@@ -0,0 +1,247 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Coalescer groups the insert requests of a caller targeting the same collection which arrive within a short window
+// into a single insert, so that many small concurrent inserts are committed in fewer transactions. The first request
+// of a group waits for the window to elapse, or for the group to fill up, and inserts the documents of the group on
+// behalf of all of its requests. Every request is acknowledged with the keys of its own documents only after the
+// transaction of its group is committed.
+//
+// If the insert of a group fails because of the documents, for example one of them already exists, the requests of the
+// group are inserted one by one, so that a request only fails because of its own documents.
+type Coalescer struct {
+	sync.Mutex
+
+	cfg     config.InsertCoalescingConfig
+	insert  InsertFunc
+	pending map[string]*group
+}
+
+// group is the requests coalesced into an insert, done is closed once the outcome of every request is set.
+type group struct {
+	inserts []*coalescedInsert
+	docs    int
+	bytes   int
+	sealed  chan struct{}
+	done    chan struct{}
+}
+
+type coalescedInsert struct {
+	ctx  context.Context
+	req  *api.InsertRequest
+	resp *api.InsertResponse
+	err  error
+}
+
+func NewCoalescer(insert InsertFunc, cfg config.InsertCoalescingConfig) *Coalescer {
+	return &Coalescer{
+		cfg:     cfg,
+		insert:  insert,
+		pending: make(map[string]*group),
+	}
+}
+
+// Insert inserts the documents of the request along with the documents of the concurrent requests of the same caller
+// for the same collection. The caller identifies the tenant and the user sending the request, the requests of
+// different callers are never coalesced. Neither are the requests sent with different options or Tigris headers, as
+// they are inserted with the options and the headers of the group.
+func (c *Coalescer) Insert(ctx context.Context, caller string, r *api.InsertRequest) (*api.InsertResponse, error) {
+	options, err := proto.MarshalOptions{Deterministic: true}.Marshal(r.GetOptions())
+	if err != nil {
+		return c.insert(ctx, r)
+	}
+
+	key := strings.Join([]string{
+		caller, r.GetProject(), r.GetBranch(), r.GetCollection(), string(options), headersKey(ctx),
+	}, "\x00")
+	ins := &coalescedInsert{ctx: ctx, req: r}
+
+	c.Lock()
+	g, ok := c.pending[key]
+	if !ok {
+		g = &group{sealed: make(chan struct{}), done: make(chan struct{})}
+		c.pending[key] = g
+	}
+	g.add(ins)
+	if g.docs >= c.cfg.MaxDocs || g.bytes >= c.cfg.MaxBytes {
+		// the group doesn't accept more requests, the first request inserts it right away
+		delete(c.pending, key)
+		close(g.sealed)
+	}
+	c.Unlock()
+
+	if ok {
+		<-g.done
+		return ins.resp, ins.err
+	}
+
+	timer := time.NewTimer(c.cfg.Window)
+	select {
+	case <-timer.C:
+	case <-g.sealed:
+	case <-ctx.Done():
+	}
+	timer.Stop()
+
+	c.Lock()
+	if c.pending[key] == g {
+		delete(c.pending, key)
+	}
+	c.Unlock()
+
+	c.flush(g)
+	close(g.done)
+
+	return ins.resp, ins.err
+}
+
+func (g *group) add(ins *coalescedInsert) {
+	g.inserts = append(g.inserts, ins)
+	g.docs += len(ins.req.Documents)
+	for _, doc := range ins.req.Documents {
+		g.bytes += len(doc)
+	}
+}
+
+// headersKey returns the Tigris headers of the request sorted by name.
+func headersKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var headers []string
+	for k, v := range md {
+		name := strings.TrimPrefix(k, "grpcgateway-")
+		if strings.HasPrefix(name, strings.ToLower(api.HeaderPrefix)) {
+			headers = append(headers, name+"="+strings.Join(v, ","))
+		}
+	}
+	sort.Strings(headers)
+
+	return strings.Join(headers, "\x00")
+}
+
+// groupContext returns the context the documents of a group are inserted in. It doesn't belong to any of the requests,
+// so that a request that is canceled doesn't fail the others, but it carries the namespace, the credentials and the
+// headers which are the same for all the requests of the group. The insert is canceled after the latest deadline of
+// the requests.
+func groupContext(g *group) (context.Context, context.CancelFunc) {
+	first := g.inserts[0].ctx
+
+	ctx := context.Background()
+	if md, err := request.GetRequestMetadataFromContext(first); err == nil {
+		detached := *md
+		ctx = detached.SaveToContext(ctx)
+	}
+	if md, ok := metadata.FromIncomingContext(first); ok {
+		ctx = metadata.NewIncomingContext(ctx, md.Copy())
+	}
+
+	var deadline time.Time
+	for _, ins := range g.inserts {
+		d, ok := ins.ctx.Deadline()
+		if !ok {
+			return context.WithCancel(ctx)
+		}
+		if d.After(deadline) {
+			deadline = d
+		}
+	}
+
+	return context.WithDeadline(ctx, deadline)
+}
+
+// flush inserts the documents of the group in a context detached from its requests and splits the keys of the
+// response between the requests.
+func (c *Coalescer) flush(g *group) {
+	first := g.inserts[0]
+	if len(g.inserts) == 1 {
+		first.resp, first.err = c.insert(first.ctx, first.req)
+		return
+	}
+
+	combined := &api.InsertRequest{
+		Project:    first.req.Project,
+		Branch:     first.req.Branch,
+		Collection: first.req.Collection,
+		Options:    first.req.Options,
+		Documents:  make([][]byte, 0, g.docs),
+	}
+	for _, ins := range g.inserts {
+		combined.Documents = append(combined.Documents, ins.req.Documents...)
+	}
+
+	ctx, cancel := groupContext(g)
+	resp, err := c.insert(ctx, combined)
+	cancel()
+	if err != nil {
+		if !insertedIndividually(err) {
+			for _, ins := range g.inserts {
+				ins.err = err
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, ins := range g.inserts {
+			wg.Add(1)
+			go func(ins *coalescedInsert) {
+				defer wg.Done()
+				ins.resp, ins.err = c.insert(ins.ctx, ins.req)
+			}(ins)
+		}
+		wg.Wait()
+
+		return
+	}
+
+	offset := 0
+	for _, ins := range g.inserts {
+		ins.resp = &api.InsertResponse{
+			Status:   resp.Status,
+			Metadata: resp.Metadata,
+		}
+		if len(resp.Keys) == len(combined.Documents) {
+			ins.resp.Keys = resp.Keys[offset : offset+len(ins.req.Documents)]
+		}
+		offset += len(ins.req.Documents)
+	}
+}
+
+// insertedIndividually returns true if the error means that the insert of the group was rejected because of its
+// documents, the transaction is known not to be committed then. Any other error, like a timeout which leaves the
+// outcome of the transaction unknown, is returned to all the requests of the group.
+func insertedIndividually(err error) bool {
+	switch api.FromStatusError(err).Code {
+	case api.Code_INVALID_ARGUMENT, api.Code_ALREADY_EXISTS, api.Code_CONFLICT, api.Code_FAILED_PRECONDITION,
+		api.Code_CONTENT_TOO_LARGE:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/metadata"
+)
+
+type coalescingInserter struct {
+	sync.Mutex
+
+	batches []*api.InsertRequest
+	errs    []error
+	fail    func(r *api.InsertRequest) error
+}
+
+func (ci *coalescingInserter) insert(ctx context.Context, r *api.InsertRequest) (*api.InsertResponse, error) {
+	ci.Lock()
+	ci.batches = append(ci.batches, r)
+	ci.errs = append(ci.errs, ctx.Err())
+	ci.Unlock()
+
+	if ci.fail != nil {
+		if err := ci.fail(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return &api.InsertResponse{Status: "inserted", Keys: r.Documents}, nil
+}
+
+type coalescedResult struct {
+	resp *api.InsertResponse
+	err  error
+}
+
+func insertConcurrently(c *Coalescer, callers []string, reqs []*api.InsertRequest) []coalescedResult {
+	ctxs := make([]context.Context, len(reqs))
+	for i := range ctxs {
+		ctxs[i] = context.Background()
+	}
+
+	return insertConcurrentlyWithContexts(c, ctxs, callers, reqs)
+}
+
+func insertConcurrentlyWithContexts(c *Coalescer, ctxs []context.Context, callers []string, reqs []*api.InsertRequest) []coalescedResult {
+	res := make([]coalescedResult, len(reqs))
+
+	var wg sync.WaitGroup
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res[i].resp, res[i].err = c.Insert(ctxs[i], callers[i], reqs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return res
+}
+
+func TestCoalescer(t *testing.T) {
+	cfg := config.InsertCoalescingConfig{Enabled: true, Window: 100 * time.Millisecond, MaxDocs: 100, MaxBytes: 1024 * 1024}
+
+	t.Run("coalesced", func(t *testing.T) {
+		ti := &coalescingInserter{}
+		c := NewCoalescer(ti.insert, cfg)
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 2)},
+			{Project: "p1", Collection: "c1", Documents: docs(2, 3)},
+			{Project: "p1", Collection: "c1", Documents: docs(3, 6)},
+		}
+		res := insertConcurrently(c, []string{"ns/u1", "ns/u1", "ns/u1"}, reqs)
+
+		require.Len(t, ti.batches, 1)
+		require.Len(t, ti.batches[0].Documents, 6)
+		for i, r := range res {
+			require.NoError(t, r.err)
+			require.Equal(t, "inserted", r.resp.Status)
+			require.Equal(t, reqs[i].Documents, r.resp.Keys)
+		}
+	})
+
+	t.Run("different_collections_and_callers", func(t *testing.T) {
+		ti := &coalescingInserter{}
+		c := NewCoalescer(ti.insert, cfg)
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 1)},
+			{Project: "p1", Collection: "c2", Documents: docs(1, 2)},
+			{Project: "p1", Collection: "c1", Documents: docs(2, 3)},
+		}
+		res := insertConcurrently(c, []string{"ns/u1", "ns/u1", "ns/u2"}, reqs)
+
+		require.Len(t, ti.batches, 3)
+		for i, r := range res {
+			require.NoError(t, r.err)
+			require.Equal(t, reqs[i].Documents, r.resp.Keys)
+		}
+	})
+
+	t.Run("different_options_and_headers", func(t *testing.T) {
+		ti := &coalescingInserter{}
+		c := NewCoalescer(ti.insert, cfg)
+
+		strong := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderSearchConsistency, "strong"))
+		ctxs := []context.Context{context.Background(), strong, strong, context.Background()}
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 1)},
+			{Project: "p1", Collection: "c1", Documents: docs(1, 2)},
+			{Project: "p1", Collection: "c1", Documents: docs(2, 3)},
+			{Project: "p1", Collection: "c1", Documents: docs(3, 4), Options: &api.InsertRequestOptions{
+				WriteOptions: &api.WriteOptions{},
+			}},
+		}
+		res := insertConcurrentlyWithContexts(c, ctxs, []string{"ns/u1", "ns/u1", "ns/u1", "ns/u1"}, reqs)
+
+		require.Len(t, ti.batches, 3)
+		for i, r := range res {
+			require.NoError(t, r.err)
+			require.Equal(t, reqs[i].Documents, r.resp.Keys)
+		}
+	})
+
+	t.Run("first_request_canceled", func(t *testing.T) {
+		ti := &coalescingInserter{}
+		c := NewCoalescer(ti.insert, cfg)
+
+		first, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(cfg.Window / 2)
+			cancel()
+		}()
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 1)},
+			{Project: "p1", Collection: "c1", Documents: docs(1, 2)},
+		}
+		res := insertConcurrentlyWithContexts(c, []context.Context{first, context.Background()},
+			[]string{"ns/u1", "ns/u1"}, reqs)
+
+		// the group is inserted once the first request is canceled, but not in its context
+		require.Len(t, ti.batches, 1)
+		require.NoError(t, ti.errs[0])
+		for i, r := range res {
+			require.NoError(t, r.err)
+			require.Equal(t, reqs[i].Documents, r.resp.Keys)
+		}
+	})
+
+	t.Run("max_docs", func(t *testing.T) {
+		ti := &coalescingInserter{}
+		c := NewCoalescer(ti.insert, config.InsertCoalescingConfig{
+			Enabled: true, Window: time.Minute, MaxDocs: 4, MaxBytes: 1024 * 1024,
+		})
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 2)},
+			{Project: "p1", Collection: "c1", Documents: docs(2, 4)},
+		}
+
+		start := time.Now()
+		res := insertConcurrently(c, []string{"ns/u1", "ns/u1"}, reqs)
+		require.Less(t, time.Since(start), time.Minute)
+
+		require.Len(t, ti.batches, 1)
+		for i, r := range res {
+			require.NoError(t, r.err)
+			require.Equal(t, reqs[i].Documents, r.resp.Keys)
+		}
+	})
+
+	t.Run("inserted_individually", func(t *testing.T) {
+		ti := &coalescingInserter{
+			fail: func(r *api.InsertRequest) error {
+				for _, doc := range r.Documents {
+					if string(doc) == `{"id":1}` {
+						return errors.AlreadyExists("duplicate key value, violates key constraint")
+					}
+				}
+				return nil
+			},
+		}
+		c := NewCoalescer(ti.insert, cfg)
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 1)},
+			{Project: "p1", Collection: "c1", Documents: docs(1, 2)},
+			{Project: "p1", Collection: "c1", Documents: docs(2, 3)},
+		}
+		res := insertConcurrently(c, []string{"ns/u1", "ns/u1", "ns/u1"}, reqs)
+
+		require.Len(t, ti.batches, 4)
+		require.NoError(t, res[0].err)
+		require.Equal(t, api.Code_ALREADY_EXISTS, api.FromStatusError(res[1].err).Code)
+		require.NoError(t, res[2].err)
+		require.Equal(t, reqs[2].Documents, res[2].resp.Keys)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ti := &coalescingInserter{
+			fail: func(r *api.InsertRequest) error {
+				return errors.DeadlineExceeded("deadline exceeded")
+			},
+		}
+		c := NewCoalescer(ti.insert, cfg)
+
+		reqs := []*api.InsertRequest{
+			{Project: "p1", Collection: "c1", Documents: docs(0, 1)},
+			{Project: "p1", Collection: "c1", Documents: docs(1, 2)},
+		}
+		res := insertConcurrently(c, []string{"ns/u1", "ns/u1"}, reqs)
+
+		require.Len(t, ti.batches, 1)
+		for _, r := range res {
+			require.Equal(t, api.Code_DEADLINE_EXCEEDED, api.FromStatusError(r.err).Code)
+		}
+	})
+}
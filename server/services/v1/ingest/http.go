@@ -0,0 +1,146 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// maxFormValue bounds the size of the options sent as multipart form fields.
+const maxFormValue = 64 * 1024
+
+// ImportHandler serves the HTTP variant of ImportDocuments. The file is either the request body or the "file" part
+// of a multipart form, the options are the query parameters or the form fields preceding the file.
+type ImportHandler struct {
+	client api.TigrisClient
+}
+
+func NewImportHandler(client api.TigrisClient) *ImportHandler {
+	return &ImportHandler{client: client}
+}
+
+type importResponse struct {
+	Status      string            `json:"status,omitempty"`
+	Imported    int64             `json:"imported"`
+	ResumeToken string            `json:"resume_token,omitempty"`
+	Error       *api.ErrorDetails `json:"error,omitempty"`
+}
+
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.serve(r)
+	if progress == nil {
+		progress = &Progress{}
+	}
+
+	resp := &importResponse{Imported: progress.Imported, ResumeToken: progress.ResumeToken}
+	status := http.StatusOK
+	if err != nil {
+		e := api.FromStatusError(err)
+		status = api.ToHTTPCode(e.Code)
+		resp.Error = &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message}
+	} else {
+		resp.Status = ImportedStatus
+	}
+
+	data, _ := jsoniter.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func (h *ImportHandler) serve(r *http.Request) (*Progress, error) {
+	values := r.URL.Query()
+	body, contentType, fileName := io.Reader(r.Body), r.Header.Get("Content-Type"), ""
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, errors.InvalidArgument(err.Error())
+		}
+
+		for body = nil; body == nil; {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, errors.InvalidArgument("multipart form has no file part")
+			}
+			if err != nil {
+				return nil, errors.InvalidArgument(err.Error())
+			}
+
+			if part.FormName() == "file" || part.FileName() != "" {
+				body, contentType, fileName = part, part.Header.Get("Content-Type"), part.FileName()
+				continue
+			}
+
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValue))
+			if err != nil {
+				return nil, errors.InvalidArgument(err.Error())
+			}
+			values.Set(part.FormName(), string(value))
+		}
+	}
+
+	opts, err := ParseImportOptions(values.Get)
+	if err != nil {
+		return nil, err
+	}
+	if err = opts.detectFormat(contentType, fileName); err != nil {
+		return nil, err
+	}
+
+	req := &api.ImportRequest{
+		Project:          chi.URLParam(r, "project"),
+		Collection:       chi.URLParam(r, "collection"),
+		Branch:           values.Get("branch"),
+		CreateCollection: true,
+		PrimaryKey:       splitList(values.Get("primary_key")),
+		Autogenerated:    splitList(values.Get("autogenerated")),
+	}
+	if c := values.Get("create_collection"); c != "" {
+		if req.CreateCollection, err = strconv.ParseBool(c); err != nil {
+			return nil, errors.InvalidArgument("invalid create_collection value '%s'", c)
+		}
+	}
+
+	ctx := common.OutgoingContext(r)
+
+	return Import(ctx, body, req, opts, func(ctx context.Context, r *api.ImportRequest) (*api.ImportResponse, error) {
+		return h.client.Import(ctx, r)
+	})
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	list := strings.Split(s, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+
+	return list
+}
@@ -0,0 +1,340 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// Formats of the imported files.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+const (
+	defaultImportBatchSize = 256
+	maxImportBatchSize     = 10000
+	// maxImportBatchBytes keeps a batch well under the transaction size limit.
+	maxImportBatchBytes = 4 * 1024 * 1024
+
+	resumeTokenPrefix = "import:"
+)
+
+// ImportFunc imports a batch of documents in a single transaction.
+type ImportFunc func(ctx context.Context, r *api.ImportRequest) (*api.ImportResponse, error)
+
+// ImportOptions control how the records of an imported file are converted to documents.
+type ImportOptions struct {
+	Format    string
+	Delimiter rune
+	// Mapping renames the CSV columns or the top level NDJSON fields. Dotted names create nested objects and the
+	// columns mapped to "-" are not imported.
+	Mapping map[string]string
+	// Types maps the document fields, after renaming, to the type their values are coerced to.
+	Types     map[string]string
+	BatchSize int
+	// Skip is the number of records imported by a previous attempt, decoded from the resume token.
+	Skip int64
+}
+
+// ParseImportOptions reads the options using get, which returns the value of the option with the given name:
+//   - format: "ndjson" or "csv"
+//   - delimiter: the CSV field delimiter, comma by default
+//   - mapping: comma separated list of column:field pairs
+//   - types: comma separated list of field:type pairs, see the Type constants
+//   - batch_size: number of records committed in a transaction
+//   - resume_token: the token returned by an interrupted import, the records it covers are skipped
+func ParseImportOptions(get func(name string) string) (*ImportOptions, error) {
+	opts := &ImportOptions{
+		Format:    strings.ToLower(get("format")),
+		Delimiter: ',',
+		BatchSize: defaultImportBatchSize,
+	}
+
+	if d := get("delimiter"); d != "" {
+		if d == `\t` {
+			d = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(d)
+		if size != len(d) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+			return nil, errors.InvalidArgument("invalid delimiter '%s'", d)
+		}
+		opts.Delimiter = r
+	}
+
+	var err error
+	if opts.Mapping, err = parsePairs(get("mapping"), "mapping"); err != nil {
+		return nil, err
+	}
+	if opts.Types, err = parsePairs(get("types"), "types"); err != nil {
+		return nil, err
+	}
+	for field, typ := range opts.Types {
+		switch typ {
+		case TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeDateTime, TypeJSON:
+		default:
+			return nil, errors.InvalidArgument("unsupported type '%s' of field '%s'", typ, field)
+		}
+	}
+
+	if b := get("batch_size"); b != "" {
+		if opts.BatchSize, err = strconv.Atoi(b); err != nil || opts.BatchSize <= 0 || opts.BatchSize > maxImportBatchSize {
+			return nil, errors.InvalidArgument("batch size should be between 1 and %d", maxImportBatchSize)
+		}
+	}
+
+	if token := get("resume_token"); token != "" {
+		if opts.Skip, err = decodeResumeToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+func parsePairs(s string, option string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, ":")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, errors.InvalidArgument("invalid %s '%s', expected name:value", option, pair)
+		}
+		pairs[k] = v
+	}
+
+	return pairs, nil
+}
+
+// field returns the document field of a CSV column or a NDJSON field.
+func (opts *ImportOptions) field(name string) string {
+	if f, ok := opts.Mapping[name]; ok {
+		return f
+	}
+
+	return name
+}
+
+// detectFormat sets the format from the content type or the file name, if it wasn't given explicitly.
+func (opts *ImportOptions) detectFormat(contentType string, fileName string) error {
+	if opts.Format == "" {
+		contentType, _, _ = strings.Cut(contentType, ";")
+		switch strings.TrimSpace(strings.ToLower(contentType)) {
+		case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+			opts.Format = FormatNDJSON
+		case "text/csv", "application/csv":
+			opts.Format = FormatCSV
+		}
+	}
+
+	if opts.Format == "" {
+		switch name := strings.ToLower(fileName); {
+		case strings.HasSuffix(name, ".ndjson"), strings.HasSuffix(name, ".jsonl"):
+			opts.Format = FormatNDJSON
+		case strings.HasSuffix(name, ".csv"):
+			opts.Format = FormatCSV
+		case strings.HasSuffix(name, ".tsv"):
+			opts.Format, opts.Delimiter = FormatCSV, '\t'
+		}
+	}
+
+	switch opts.Format {
+	case FormatNDJSON, FormatCSV:
+		return nil
+	case "":
+		return errors.InvalidArgument("unknown format of the imported data, set the format option to ndjson or csv")
+	default:
+		return errors.InvalidArgument("unsupported import format '%s'", opts.Format)
+	}
+}
+
+func encodeResumeToken(records int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(resumeTokenPrefix + strconv.FormatInt(records, 10)))
+}
+
+func decodeResumeToken(token string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil && strings.HasPrefix(string(b), resumeTokenPrefix) {
+		var n int64
+		if n, err = strconv.ParseInt(strings.TrimPrefix(string(b), resumeTokenPrefix), 10, 64); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+
+	return 0, errors.InvalidArgument("invalid resume token")
+}
+
+// Progress of an import.
+type Progress struct {
+	// Imported is the number of records imported by this call.
+	Imported int64
+	// ResumeToken resumes the import after the last committed batch, when the same input is sent again.
+	ResumeToken string
+}
+
+// Import converts the records of src to documents and imports them in batches. Every batch is committed in its own
+// transaction, the collection is created and its schema is evolved as in the Import API if the request allows it.
+// The req carries the target collection and the import settings, its documents are ignored. On error the returned
+// progress tells how far the import went.
+func Import(ctx context.Context, src io.Reader, req *api.ImportRequest, opts *ImportOptions, imp ImportFunc) (*Progress, error) {
+	progress := &Progress{ResumeToken: encodeResumeToken(opts.Skip)}
+
+	records, err := newRecordReader(src, opts)
+	if err != nil {
+		return progress, err
+	}
+
+	var (
+		batch      [][]byte
+		batchBytes int
+		read       int64
+	)
+
+	commit := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		_, err := imp(ctx, &api.ImportRequest{
+			Project:          req.Project,
+			Branch:           req.Branch,
+			Collection:       req.Collection,
+			Options:          req.Options,
+			CreateCollection: req.CreateCollection,
+			PrimaryKey:       req.PrimaryKey,
+			Autogenerated:    req.Autogenerated,
+			Documents:        batch,
+		})
+		if err != nil {
+			return err
+		}
+
+		progress.Imported += int64(len(batch))
+		progress.ResumeToken = encodeResumeToken(opts.Skip + progress.Imported)
+		batch, batchBytes = nil, 0
+
+		return nil
+	}
+
+	for {
+		doc, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*api.TigrisError); ok {
+				return progress, errors.InvalidArgument("record %d: %s", read+1, api.FromStatusError(err).Message)
+			}
+			return progress, err
+		}
+
+		if read++; read <= opts.Skip {
+			continue
+		}
+
+		batch = append(batch, doc)
+		if batchBytes += len(doc); len(batch) >= opts.BatchSize || batchBytes >= maxImportBatchBytes {
+			if err = commit(); err != nil {
+				return progress, err
+			}
+		}
+	}
+
+	return progress, commit()
+}
+
+// ImportDocuments imports the file sent over the client stream. The first request names the target collection and
+// carries the import settings, the documents of all the requests are the consecutive chunks of the file. The import
+// options are passed in the Tigris-Import-* headers. The resume token is returned in the trailer, also when the
+// import fails.
+func ImportDocuments(stream api.Ingest_ImportDocumentsServer, imp ImportFunc) error {
+	ctx := stream.Context()
+
+	opts, err := ParseImportOptions(func(name string) string {
+		return api.GetHeader(ctx, api.HeaderImportPrefix+strings.ReplaceAll(name, "_", "-"))
+	})
+	if err != nil {
+		return err
+	}
+	if err = opts.detectFormat("", ""); err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return errors.InvalidArgument("no import request received")
+	}
+	if err != nil {
+		return err
+	}
+
+	progress, err := Import(ctx, &chunkReader{stream: stream, chunks: first.Documents}, first, opts, imp)
+	stream.SetTrailer(trailer(progress))
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(&api.ImportResponse{Status: ImportedStatus})
+}
+
+// ImportedStatus is the status of a successful import.
+const ImportedStatus = "imported"
+
+func trailer(progress *Progress) metadata.MD {
+	return metadata.Pairs(
+		api.HeaderImportResumeToken, progress.ResumeToken,
+		api.HeaderImportImported, strconv.FormatInt(progress.Imported, 10),
+	)
+}
+
+// chunkReader reads the chunks of the file carried by the documents of the stream requests.
+type chunkReader struct {
+	stream api.Ingest_ImportDocumentsServer
+	chunks [][]byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.chunks) == 0 || len(c.chunks[0]) == 0 {
+		if len(c.chunks) > 0 {
+			c.chunks = c.chunks[1:]
+			continue
+		}
+
+		req, err := c.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		c.chunks = req.Documents
+	}
+
+	n := copy(p, c.chunks[0])
+	c.chunks[0] = c.chunks[0][n:]
+
+	return n, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func options(t *testing.T, values map[string]string) *ImportOptions {
+	t.Helper()
+
+	opts, err := ParseImportOptions(func(name string) string { return values[name] })
+	require.NoError(t, err)
+
+	return opts
+}
+
+type testImporter struct {
+	batches []*api.ImportRequest
+	failAt  int
+}
+
+func (ti *testImporter) imp(_ context.Context, r *api.ImportRequest) (*api.ImportResponse, error) {
+	if ti.failAt > 0 && len(ti.batches)+1 == ti.failAt {
+		return nil, errors.Unavailable("try again")
+	}
+	ti.batches = append(ti.batches, r)
+
+	return &api.ImportResponse{Status: "inserted"}, nil
+}
+
+func (ti *testImporter) docs() []string {
+	var res []string
+	for _, b := range ti.batches {
+		for _, d := range b.Documents {
+			res = append(res, string(d))
+		}
+	}
+
+	return res
+}
+
+func TestParseImportOptions(t *testing.T) {
+	opts := options(t, map[string]string{
+		"format":     "CSV",
+		"delimiter":  `\t`,
+		"mapping":    "Name:name, Zip:address.zip,unused:-",
+		"types":      "address.zip:string",
+		"batch_size": "10",
+	})
+	require.Equal(t, &ImportOptions{
+		Format:    FormatCSV,
+		Delimiter: '\t',
+		Mapping:   map[string]string{"Name": "name", "Zip": "address.zip", "unused": "-"},
+		Types:     map[string]string{"address.zip": "string"},
+		BatchSize: 10,
+	}, opts)
+
+	for _, values := range []map[string]string{
+		{"delimiter": "ab"},
+		{"mapping": "a"},
+		{"mapping": "a:"},
+		{"types": "a:uuid"},
+		{"batch_size": "0"},
+		{"batch_size": "1000000"},
+		{"resume_token": "abc"},
+	} {
+		_, err := ParseImportOptions(func(name string) string { return values[name] })
+		require.Error(t, err, values)
+	}
+
+	opts = options(t, map[string]string{"resume_token": encodeResumeToken(42)})
+	require.Equal(t, int64(42), opts.Skip)
+
+	opts = options(t, nil)
+	require.Error(t, opts.detectFormat("application/octet-stream", "data.bin"))
+	require.NoError(t, opts.detectFormat("text/csv; charset=utf-8", ""))
+	require.Equal(t, FormatCSV, opts.Format)
+
+	opts = options(t, nil)
+	require.NoError(t, opts.detectFormat("", "Data.JSONL"))
+	require.Equal(t, FormatNDJSON, opts.Format)
+}
+
+func TestImportCSV(t *testing.T) {
+	input := "\ufeffid,Name,Zip,score,active,tags,created,unused\n" +
+		"1,\"Smith, J\",01234,1.5,true,\"[\"\"a\"\"]\",2023-01-02,x\n" +
+		"2,Doe,,7,false,[],2023-01-02T10:00:00Z,y\n"
+
+	opts := options(t, map[string]string{
+		"format":  "csv",
+		"mapping": "Name:name,Zip:address.zip,unused:-",
+		"types":   "address.zip:string,score:number,tags:json,created:datetime",
+	})
+
+	ti := &testImporter{}
+	progress, err := Import(context.Background(), strings.NewReader(input), &api.ImportRequest{Project: "p1", Collection: "c1", CreateCollection: true}, opts, ti.imp)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), progress.Imported)
+	require.Equal(t, []string{
+		`{"id":1,"name":"Smith, J","address":{"zip":"01234"},"score":1.5,"active":true,"tags":["a"],"created":"2023-01-02T00:00:00Z"}`,
+		`{"id":2,"name":"Doe","address":{"zip":""},"score":7,"active":false,"tags":[],"created":"2023-01-02T10:00:00Z"}`,
+	}, ti.docs())
+	require.Equal(t, "c1", ti.batches[0].Collection)
+	require.True(t, ti.batches[0].CreateCollection)
+
+	// inferred types keep the values which are not canonical numbers as strings
+	ti = &testImporter{}
+	_, err = Import(context.Background(), strings.NewReader("zip;n\n01234;-1e3\n"), &api.ImportRequest{}, options(t, map[string]string{"format": "csv", "delimiter": ";"}), ti.imp)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"zip":"01234","n":-1e3}`}, ti.docs())
+
+	_, err = Import(context.Background(), strings.NewReader("id,n\n1,x\n"), &api.ImportRequest{}, options(t, map[string]string{"format": "csv", "types": "n:integer"}), ti.imp)
+	require.Equal(t, errors.InvalidArgument("record 1: cannot convert 'x' to integer"), err)
+
+	_, err = Import(context.Background(), strings.NewReader("id,n\n1\n"), &api.ImportRequest{}, options(t, map[string]string{"format": "csv"}), ti.imp)
+	require.Error(t, err)
+}
+
+func TestImportNDJSON(t *testing.T) {
+	input := `{"id":1,"name":"a \"b\"","zip":12345,"count":"3","meta":{"x":1}}` + "\n\n" +
+		`{"id":2,"name":null,"zip":"0123","count":4,"drop":true}`
+
+	ti := &testImporter{}
+	_, err := Import(context.Background(), strings.NewReader(input), &api.ImportRequest{}, options(t, map[string]string{
+		"format":  "ndjson",
+		"mapping": "name:person.name,drop:-",
+		"types":   "zip:string,count:integer",
+	}), ti.imp)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{"id":1,"person":{"name":"a \"b\""},"zip":"12345","count":3,"meta":{"x":1}}`,
+		`{"id":2,"person":{"name":null},"zip":"0123","count":4}`,
+	}, ti.docs())
+
+	// without mapping the lines are imported as is
+	ti = &testImporter{}
+	_, err = Import(context.Background(), strings.NewReader(input), &api.ImportRequest{}, options(t, map[string]string{"format": "ndjson"}), ti.imp)
+	require.NoError(t, err)
+	require.Equal(t, strings.Split(strings.ReplaceAll(input, "\n\n", "\n"), "\n"), ti.docs())
+
+	_, err = Import(context.Background(), strings.NewReader("[1]\n"), &api.ImportRequest{}, options(t, map[string]string{"format": "ndjson"}), ti.imp)
+	require.Equal(t, errors.InvalidArgument("record 1: the line is not a JSON object"), err)
+}
+
+func TestImportResume(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("id\n")
+	for i := 1; i <= 10; i++ {
+		input.WriteString(strings.Repeat("1", i) + "\n")
+	}
+
+	ti := &testImporter{failAt: 3}
+	progress, err := Import(context.Background(), strings.NewReader(input.String()), &api.ImportRequest{}, options(t, map[string]string{"format": "csv", "batch_size": "3"}), ti.imp)
+	require.Equal(t, errors.Unavailable("try again"), err)
+	require.Equal(t, int64(6), progress.Imported)
+	require.Len(t, ti.docs(), 6)
+
+	ti.failAt = 0
+	progress, err = Import(context.Background(), strings.NewReader(input.String()), &api.ImportRequest{}, options(t, map[string]string{
+		"format": "csv", "batch_size": "3", "resume_token": progress.ResumeToken,
+	}), ti.imp)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), progress.Imported)
+	require.Equal(t, encodeResumeToken(10), progress.ResumeToken)
+	require.Len(t, ti.docs(), 10)
+	require.Equal(t, `{"id":1111111}`, ti.docs()[6])
+}
+
+type importClient struct {
+	api.TigrisClient
+	testImporter
+}
+
+func (c *importClient) Import(ctx context.Context, r *api.ImportRequest, _ ...grpc.CallOption) (*api.ImportResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, errors.Unauthenticated("no token")
+	}
+
+	return c.imp(ctx, r)
+}
+
+func TestImportHandler(t *testing.T) {
+	client := &importClient{}
+	router := chi.NewRouter()
+	router.Post("/projects/{project}/collections/{collection}/import", NewImportHandler(client).ServeHTTP)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("primary_key", "id, sub"))
+	require.NoError(t, mw.WriteField("mapping", "Name:name"))
+	fw, err := mw.CreateFormFile("file", "users.csv")
+	require.NoError(t, err)
+	_, _ = io.WriteString(fw, "id,sub,Name\n1,2,a\n")
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/projects/p1/collections/users/import?branch=b1", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"imported","imported":1,"resume_token":"`+encodeResumeToken(1)+`"}`, rec.Body.String())
+	require.Equal(t, []string{`{"id":1,"sub":2,"name":"a"}`}, client.docs())
+	require.Equal(t, "p1", client.batches[0].Project)
+	require.Equal(t, "b1", client.batches[0].Branch)
+	require.Equal(t, "users", client.batches[0].Collection)
+	require.Equal(t, []string{"id", "sub"}, client.batches[0].PrimaryKey)
+	require.True(t, client.batches[0].CreateCollection)
+
+	req = httptest.NewRequest(http.MethodPost, "/projects/p1/collections/users/import?create_collection=false", strings.NewReader(`{"id":3}`))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, client.batches[1].CreateCollection)
+
+	req = httptest.NewRequest(http.MethodPost, "/projects/p1/collections/users/import", strings.NewReader(`{"id":3}`))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.JSONEq(t, `{"imported":0,"resume_token":"`+encodeResumeToken(0)+`","error":{"code":"UNAUTHENTICATED","message":"no token"}}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/projects/p1/collections/users/import", strings.NewReader(`{"id":3}`))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+type importStream struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	reqs    []*api.ImportRequest
+	resp    *api.ImportResponse
+	trailer metadata.MD
+}
+
+func (s *importStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *importStream) Recv() (*api.ImportRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+
+	r := s.reqs[0]
+	s.reqs = s.reqs[1:]
+
+	return r, nil
+}
+
+func (s *importStream) SendAndClose(r *api.ImportResponse) error {
+	s.resp = r
+	return nil
+}
+
+func (s *importStream) SetTrailer(md metadata.MD) {
+	s.trailer = md
+}
+
+func TestImportDocuments(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		api.HeaderImportPrefix+"Format", "csv",
+		api.HeaderImportPrefix+"Batch-Size", "1",
+	))
+
+	s := &importStream{ctx: ctx, reqs: []*api.ImportRequest{
+		{Project: "p1", Collection: "c1", Documents: [][]byte{[]byte("id,na"), []byte("me\n1,")}},
+		{Documents: [][]byte{[]byte("a\n2,b")}},
+	}}
+
+	ti := &testImporter{}
+	require.NoError(t, ImportDocuments(s, ti.imp))
+	require.Equal(t, ImportedStatus, s.resp.Status)
+	require.Equal(t, []string{`{"id":1,"name":"a"}`, `{"id":2,"name":"b"}`}, ti.docs())
+	require.Equal(t, "c1", ti.batches[1].Collection)
+	require.Equal(t, []string{encodeResumeToken(2)}, s.trailer.Get(api.HeaderImportResumeToken))
+	require.Equal(t, []string{"2"}, s.trailer.Get(api.HeaderImportImported))
+
+	s = &importStream{ctx: context.Background()}
+	require.Error(t, ImportDocuments(s, ti.imp))
+}
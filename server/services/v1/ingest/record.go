@@ -0,0 +1,334 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// Coercion rules of the imported values.
+const (
+	TypeString   = "string"
+	TypeInteger  = "integer"
+	TypeNumber   = "number"
+	TypeBoolean  = "boolean"
+	TypeDateTime = "datetime"
+	TypeJSON     = "json"
+)
+
+// skipField is the mapping target of the columns and fields which are not imported.
+const skipField = "-"
+
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// recordReader returns the records of the input converted to JSON documents, io.EOF is returned after the last one.
+type recordReader interface {
+	next() ([]byte, error)
+}
+
+func newRecordReader(r io.Reader, opts *ImportOptions) (recordReader, error) {
+	switch opts.Format {
+	case FormatNDJSON:
+		return &ndjsonReader{r: bufio.NewReader(r), opts: opts}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.Comma = opts.Delimiter
+		cr.ReuseRecord = true
+
+		header, err := cr.Read()
+		if err == io.EOF {
+			return &csvReader{r: cr}, nil
+		}
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid CSV header: %s", err.Error())
+		}
+
+		columns := make([]string, len(header))
+		for i, h := range header {
+			if i == 0 {
+				h = strings.TrimPrefix(h, "\ufeff")
+			}
+			if columns[i] = opts.field(strings.TrimSpace(h)); columns[i] == "" {
+				return nil, errors.InvalidArgument("empty CSV column name at position %d", i+1)
+			}
+		}
+		cr.FieldsPerRecord = len(columns)
+
+		return &csvReader{r: cr, columns: columns, opts: opts}, nil
+	default:
+		return nil, errors.InvalidArgument("unsupported import format '%s'", opts.Format)
+	}
+}
+
+type ndjsonReader struct {
+	r    *bufio.Reader
+	opts *ImportOptions
+}
+
+func (n *ndjsonReader) next() ([]byte, error) {
+	for {
+		line, err := n.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' {
+			return nil, errors.InvalidArgument("the line is not a JSON object")
+		}
+
+		if len(n.opts.Mapping) == 0 && len(n.opts.Types) == 0 {
+			return line, nil
+		}
+
+		return n.convert(line)
+	}
+}
+
+func (n *ndjsonReader) convert(line []byte) ([]byte, error) {
+	var doc object
+	err := jsonparser.ObjectEach(line, func(key []byte, value []byte, dataType jsonparser.ValueType, end int) error {
+		name, err := jsonparser.ParseString(key)
+		if err != nil {
+			return err
+		}
+		if name = n.opts.field(name); name == skipField {
+			return nil
+		}
+
+		if dataType == jsonparser.String {
+			// keep the quotes, so that the value is passed on as is
+			value = line[end-len(value)-2 : end]
+		}
+		raw, err := coerceJSON(value, dataType, n.opts.Types[name])
+		if err != nil || raw == nil {
+			return err
+		}
+
+		return doc.set(name, raw)
+	})
+	if err != nil {
+		return nil, toInvalidArgument(err)
+	}
+
+	return doc.marshal(nil), nil
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+	opts    *ImportOptions
+}
+
+func (c *csvReader) next() ([]byte, error) {
+	if c.columns == nil {
+		return nil, io.EOF
+	}
+
+	record, err := c.r.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, toInvalidArgument(err)
+	}
+
+	var doc object
+	for i, value := range record {
+		if c.columns[i] == skipField {
+			continue
+		}
+
+		raw, err := coerceText(value, c.opts.Types[c.columns[i]])
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		if err = doc.set(c.columns[i], raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc.marshal(nil), nil
+}
+
+// coerceText converts a textual value to JSON according to the rule. Without a rule the type is inferred from the
+// value. Nil is returned for empty values, they are not imported unless the rule is string.
+func coerceText(s string, rule string) ([]byte, error) {
+	if rule == TypeString {
+		return quote(s), nil
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	switch rule {
+	case "":
+		if s == "true" || s == "false" || jsonNumber.MatchString(s) {
+			return []byte(s), nil
+		}
+		return quote(s), nil
+	case TypeInteger:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.InvalidArgument("cannot convert '%s' to %s", s, rule)
+		}
+		return strconv.AppendInt(nil, i, 10), nil
+	case TypeNumber:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errors.InvalidArgument("cannot convert '%s' to %s", s, rule)
+		}
+		return strconv.AppendFloat(nil, f, 'g', -1, 64), nil
+	case TypeBoolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, errors.InvalidArgument("cannot convert '%s' to %s", s, rule)
+		}
+		return strconv.AppendBool(nil, b), nil
+	case TypeDateTime:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return quote(t.Format(time.RFC3339Nano)), nil
+			}
+		}
+		return nil, errors.InvalidArgument("cannot convert '%s' to %s", s, rule)
+	case TypeJSON:
+		if !jsoniter.Valid([]byte(s)) {
+			return nil, errors.InvalidArgument("cannot convert '%s' to %s", s, rule)
+		}
+		return []byte(s), nil
+	default:
+		return nil, errors.InvalidArgument("unsupported type '%s'", rule)
+	}
+}
+
+// coerceJSON converts a JSON value according to the rule, raw is the JSON encoded value.
+func coerceJSON(raw []byte, dataType jsonparser.ValueType, rule string) ([]byte, error) {
+	if rule == "" || dataType == jsonparser.Null {
+		return raw, nil
+	}
+
+	if dataType == jsonparser.String {
+		s, err := jsonparser.ParseString(raw[1 : len(raw)-1])
+		if err != nil {
+			return nil, toInvalidArgument(err)
+		}
+		if rule == TypeString {
+			return raw, nil
+		}
+		return coerceText(s, rule)
+	}
+
+	switch rule {
+	case TypeString:
+		return quote(string(raw)), nil
+	case TypeJSON:
+		return raw, nil
+	default:
+		return coerceText(string(raw), rule)
+	}
+}
+
+func quote(s string) []byte {
+	b, _ := jsoniter.Marshal(s)
+	return b
+}
+
+func toInvalidArgument(err error) error {
+	if _, ok := err.(*api.TigrisError); ok {
+		return err
+	}
+
+	return errors.InvalidArgument(err.Error())
+}
+
+// object builds a JSON object preserving the order in which the fields are set. Dotted field names set the fields of
+// nested objects.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) set(path string, raw []byte) error {
+	name, rest, nested := strings.Cut(path, ".")
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+
+	existing, ok := o.values[name]
+	if !ok {
+		o.keys = append(o.keys, name)
+	}
+
+	if !nested {
+		if ok {
+			return errors.InvalidArgument("field '%s' is set more than once", name)
+		}
+		o.values[name] = raw
+		return nil
+	}
+
+	child, isObject := existing.(*object)
+	if ok && !isObject {
+		return errors.InvalidArgument("field '%s' is both a value and an object", name)
+	}
+	if !ok {
+		child = &object{}
+		o.values[name] = child
+	}
+
+	return child.set(rest, raw)
+}
+
+func (o *object) marshal(buf []byte) []byte {
+	buf = append(buf, '{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, quote(k)...)
+		buf = append(buf, ':')
+
+		switch v := o.values[k].(type) {
+		case *object:
+			buf = v.marshal(buf)
+		case []byte:
+			buf = append(buf, v...)
+		}
+	}
+
+	return append(buf, '}')
+}
@@ -0,0 +1,167 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"io"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+// InsertFunc inserts a batch of documents in a single transaction.
+type InsertFunc func(ctx context.Context, r *api.InsertRequest) (*api.InsertResponse, error)
+
+type received struct {
+	req *api.InsertRequest
+	err error
+}
+
+// batcher accumulates the documents of consecutive requests targeting the same collection.
+type batcher struct {
+	cfg   config.InsertStreamConfig
+	req   *api.InsertRequest
+	bytes int
+}
+
+func (b *batcher) empty() bool {
+	return b.req == nil
+}
+
+func (b *batcher) full() bool {
+	return len(b.req.Documents) >= b.cfg.BatchDocs || b.bytes >= b.cfg.BatchBytes
+}
+
+// accepts returns false if the request targets a different collection than the pending batch.
+func (b *batcher) accepts(r *api.InsertRequest) bool {
+	return b.empty() || (b.req.Project == r.Project && b.req.Branch == r.Branch && b.req.Collection == r.Collection)
+}
+
+func (b *batcher) add(r *api.InsertRequest) {
+	if b.empty() {
+		b.req = &api.InsertRequest{
+			Project:    r.Project,
+			Branch:     r.Branch,
+			Collection: r.Collection,
+			Options:    r.Options,
+		}
+	}
+
+	b.req.Documents = append(b.req.Documents, r.Documents...)
+	for _, doc := range r.Documents {
+		b.bytes += len(doc)
+	}
+}
+
+func (b *batcher) take() *api.InsertRequest {
+	req := b.req
+	b.req, b.bytes = nil, 0
+
+	return req
+}
+
+// InsertStream reads the insert requests of the stream until the client closes its side, committing the documents in
+// batches bounded by the config and sending back the insert response of every batch. A batch is also committed when
+// the flush interval elapses after its first document was received or when the next request targets another
+// collection. On error the stream is terminated, the batches acknowledged so far stay committed.
+func InsertStream(stream api.Ingest_InsertStreamServer, insert InsertFunc, cfg config.InsertStreamConfig) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	recvCh := make(chan received)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			select {
+			case recvCh <- received{req: req, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(cfg.FlushInterval)
+	stopTimer(timer)
+	defer timer.Stop()
+
+	b := &batcher{cfg: cfg}
+	flush := func() error {
+		stopTimer(timer)
+
+		resp, err := insert(ctx, b.take())
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(resp)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			if !b.empty() {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case r := <-recvCh:
+			if r.err == io.EOF {
+				if b.empty() {
+					return nil
+				}
+				return flush()
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if len(r.req.Documents) == 0 {
+				continue
+			}
+
+			if !b.accepts(r.req) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if b.empty() {
+				timer.Reset(cfg.FlushInterval)
+			}
+
+			b.add(r.req)
+			if b.full() {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// stopTimer stops the timer and drains its channel, so that it can be safely reset.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc"
+)
+
+type testStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	reqs chan *api.InsertRequest
+	acks []*api.InsertResponse
+}
+
+func newTestStream() *testStream {
+	return &testStream{ctx: context.Background(), reqs: make(chan *api.InsertRequest, 16)}
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) Recv() (*api.InsertRequest, error) {
+	r, ok := <-s.reqs
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return r, nil
+}
+
+func (s *testStream) Send(r *api.InsertResponse) error {
+	s.acks = append(s.acks, r)
+	return nil
+}
+
+func docs(from, to int) [][]byte {
+	var res [][]byte
+	for i := from; i < to; i++ {
+		res = append(res, []byte(fmt.Sprintf(`{"id":%d}`, i)))
+	}
+
+	return res
+}
+
+type testInserter struct {
+	batches []*api.InsertRequest
+}
+
+func (ti *testInserter) insert(_ context.Context, r *api.InsertRequest) (*api.InsertResponse, error) {
+	ti.batches = append(ti.batches, r)
+
+	return &api.InsertResponse{Status: "inserted", Keys: r.Documents}, nil
+}
+
+func TestInsertStream(t *testing.T) {
+	cfg := config.InsertStreamConfig{BatchDocs: 4, BatchBytes: 1024, FlushInterval: time.Hour}
+
+	t.Run("batch_docs", func(t *testing.T) {
+		s, ti := newTestStream(), &testInserter{}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(0, 3)}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(3, 6)}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1"}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(6, 7)}
+		close(s.reqs)
+
+		require.NoError(t, InsertStream(s, ti.insert, cfg))
+		require.Len(t, ti.batches, 2)
+		require.Equal(t, docs(0, 6), ti.batches[0].Documents)
+		require.Equal(t, docs(6, 7), ti.batches[1].Documents)
+		require.Len(t, s.acks, 2)
+		require.Equal(t, docs(6, 7), s.acks[1].Keys)
+	})
+
+	t.Run("batch_bytes", func(t *testing.T) {
+		s, ti := newTestStream(), &testInserter{}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(0, 1)}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(1, 2)}
+		close(s.reqs)
+
+		require.NoError(t, InsertStream(s, ti.insert, config.InsertStreamConfig{BatchDocs: 100, BatchBytes: 1, FlushInterval: time.Hour}))
+		require.Len(t, ti.batches, 2)
+	})
+
+	t.Run("collection_change", func(t *testing.T) {
+		s, ti := newTestStream(), &testInserter{}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(0, 1)}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c2", Documents: docs(1, 2)}
+		s.reqs <- &api.InsertRequest{Project: "p1", Branch: "b1", Collection: "c2", Documents: docs(2, 3)}
+		close(s.reqs)
+
+		require.NoError(t, InsertStream(s, ti.insert, cfg))
+		require.Len(t, ti.batches, 3)
+		require.Equal(t, "c1", ti.batches[0].Collection)
+		require.Equal(t, "c2", ti.batches[1].Collection)
+		require.Equal(t, "b1", ti.batches[2].Branch)
+	})
+
+	t.Run("flush_interval", func(t *testing.T) {
+		s, ti := newTestStream(), &testInserter{}
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(0, 1)}
+
+		done := make(chan error)
+		go func() {
+			done <- InsertStream(s, ti.insert, config.InsertStreamConfig{BatchDocs: 100, BatchBytes: 1024, FlushInterval: 10 * time.Millisecond})
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(1, 2)}
+		close(s.reqs)
+
+		require.NoError(t, <-done)
+		require.Len(t, ti.batches, 2)
+		require.Equal(t, docs(0, 1), ti.batches[0].Documents)
+		require.Equal(t, docs(1, 2), ti.batches[1].Documents)
+	})
+
+	t.Run("insert_error", func(t *testing.T) {
+		s := newTestStream()
+		s.reqs <- &api.InsertRequest{Project: "p1", Collection: "c1", Documents: docs(0, 1)}
+		close(s.reqs)
+
+		err := InsertStream(s, func(context.Context, *api.InsertRequest) (*api.InsertResponse, error) {
+			return nil, errors.AlreadyExists("duplicate key value, violates key constraint")
+		}, cfg)
+		require.Equal(t, errors.AlreadyExists("duplicate key value, violates key constraint"), err)
+		require.Empty(t, s.acks)
+	})
+}
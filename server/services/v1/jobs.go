@@ -0,0 +1,284 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/scheduler"
+	"github.com/tigrisdata/tigris/server/services/v1/backup"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const (
+	jobsPath           = fullProjectPath + "/jobs"
+	jobPathPattern     = jobsPath + "/{job}"
+	jobRunsPathPattern = jobPathPattern + "/runs"
+
+	// jobTimeLayout formats the scheduled time of the run substituted in the target of the export jobs
+	jobTimeLayout = "20060102T150405Z"
+)
+
+type jobInfo struct {
+	Name       string              `json:"name"`
+	Schedule   string              `json:"schedule"`
+	Task       string              `json:"task"`
+	Branch     string              `json:"branch,omitempty"`
+	Collection string              `json:"collection,omitempty"`
+	Filter     jsoniter.RawMessage `json:"filter,omitempty"`
+	Target     string              `json:"target,omitempty"`
+	Paused     bool                `json:"paused,omitempty"`
+	Creator    string              `json:"creator,omitempty"`
+	CreatedAt  int64               `json:"created_at,omitempty"`
+	UpdatedAt  int64               `json:"updated_at,omitempty"`
+}
+
+func toJobInfo(jobs []metadata.Job) []jobInfo {
+	info := make([]jobInfo, len(jobs))
+	for i, j := range jobs {
+		info[i] = jobInfo{
+			Name:       j.Name,
+			Schedule:   j.Schedule,
+			Task:       j.Task,
+			Branch:     j.Branch,
+			Collection: j.Collection,
+			Filter:     j.Filter,
+			Target:     j.Target,
+			Paused:     j.Paused,
+			Creator:    j.Creator,
+			CreatedAt:  j.CreatedAt,
+			UpdatedAt:  j.UpdatedAt,
+		}
+	}
+
+	return info
+}
+
+// registerJobHTTP registers the REST endpoints to manage the scheduled jobs of a project and to read the history of
+// their runs. The jobs are run by the scheduler, on the server holding the scheduler lease.
+func (s *apiService) registerJobHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+jobsPath, s.listJobs)
+	router.Post(apiPathPrefix+jobsPath, s.createOrUpdateJob)
+	router.Delete(apiPathPrefix+jobPathPattern, s.deleteJob)
+	router.Get(apiPathPrefix+jobRunsPathPattern, s.listJobRuns)
+}
+
+func (s *apiService) listJobs(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetJobQueryRunner(accessToken)
+	runner.SetListJobsReq(&database.JobRequest{Project: chi.URLParam(r, "project")})
+
+	if _, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{}); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"jobs": toJobInfo(runner.Jobs())})
+}
+
+func (s *apiService) createOrUpdateJob(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	var req jobInfo
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetJobQueryRunner(accessToken)
+	runner.SetCreateOrUpdateJobReq(&database.JobRequest{
+		Project: chi.URLParam(r, "project"),
+		Job: &metadata.Job{
+			Name:       req.Name,
+			Schedule:   req.Schedule,
+			Task:       req.Task,
+			Branch:     req.Branch,
+			Collection: req.Collection,
+			Filter:     req.Filter,
+			Target:     req.Target,
+			Paused:     req.Paused,
+		},
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: true})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status, "job": toJobInfo(runner.Jobs())[0]})
+}
+
+func (s *apiService) deleteJob(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetJobQueryRunner(accessToken)
+	runner.SetDeleteJobReq(&database.JobRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "job"),
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: true})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
+
+// listJobRuns returns the recorded runs of the job, the most recent first. The number of the runs is limited by the
+// "limit" query parameter.
+func (s *apiService) listJobRuns(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	var limit int
+	if v := r.URL.Query().Get("limit"); len(v) > 0 {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			writeHTTPError(w, errors.InvalidArgument("invalid limit '%s'", v))
+			return
+		}
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetJobQueryRunner(accessToken)
+	runner.SetListJobRunsReq(&database.JobRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "job"),
+		Limit:   limit,
+	})
+
+	if _, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{}); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"runs": runner.Runs()})
+}
+
+// jobTasks returns the tasks run by the scheduled jobs.
+func (s *apiService) jobTasks() map[string]scheduler.TaskFunc {
+	return map[string]scheduler.TaskFunc{
+		metadata.JobTaskDelete:  s.runDeleteJob,
+		metadata.JobTaskExport:  s.runExportJob,
+		metadata.JobTaskReindex: s.runReindexJob,
+	}
+}
+
+// runDeleteJob deletes the documents matching the filter of the job in batches, each in its own transaction. It stops
+// after the configured number of batches, the rest is deleted by the next runs.
+func (s *apiService) runDeleteJob(ctx context.Context, project string, job *metadata.Job, _ time.Time) (string, error) {
+	cfg := config.DefaultConfig.Scheduler
+
+	var deleted int64
+	for i := 0; i < cfg.DeleteMaxBatches; i++ {
+		resp, err := s.Delete(ctx, &api.DeleteRequest{
+			Project:    project,
+			Branch:     job.Branch,
+			Collection: job.Collection,
+			Filter:     job.Filter,
+			Options:    &api.DeleteRequestOptions{Limit: int64(cfg.DeleteBatchSize)},
+		})
+		if err != nil {
+			return fmt.Sprintf("deleted %d documents", deleted), err
+		}
+
+		deleted += int64(resp.DeletedCount)
+		if resp.DeletedCount < int32(cfg.DeleteBatchSize) {
+			break
+		}
+	}
+
+	return fmt.Sprintf("deleted %d documents", deleted), nil
+}
+
+// runExportJob backs the branch of the job up to its target. The backup is registered with the backup jobs, so that
+// it shows in the backup status of the project and doesn't overlap with the other backups and restores of the branch.
+func (s *apiService) runExportJob(ctx context.Context, project string, job *metadata.Job, scheduledAt time.Time) (string, error) {
+	cfg := config.DefaultConfig.Backup
+	if !cfg.Enabled {
+		return "", errors.Unimplemented("backups are disabled")
+	}
+
+	target, err := backup.ParseTarget(cfg, strings.ReplaceAll(job.Target, "{time}", scheduledAt.UTC().Format(jobTimeLayout)))
+	if err != nil {
+		return "", err
+	}
+
+	namespace, err := request.GetNamespace(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	r := &api.DescribeDatabaseRequest{Project: project, Branch: job.Branch}
+	branchName := metadata.NewDatabaseNameWithBranch(project, job.Branch).Branch()
+	backupJob, err := s.backupJobs.Start(namespace, api.BackupKindBackup, project, branchName, target.String())
+	if err != nil {
+		return "", err
+	}
+
+	err = backup.Backup(ctx, cfg.Dir, target, backupJob, func(ctx context.Context, archive *backup.Writer) error {
+		_, err := s.sessions.ReadOnlyExecute(ctx, s.runnerFactory.GetBackupRunner(r, archive, backupJob, nil), database.ReqOptions{})
+		return err
+	})
+	backupJob.Finish(err)
+
+	return target.String(), err
+}
+
+// runReindexJob rebuilds the secondary indexes of the collection of the job.
+func (s *apiService) runReindexJob(ctx context.Context, project string, job *metadata.Job, _ time.Time) (string, error) {
+	_, err := s.BuildCollectionIndex(ctx, &api.BuildCollectionIndexRequest{
+		Project:    project,
+		Branch:     job.Branch,
+		Collection: job.Collection,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("rebuilt the indexes of collection '%s'", job.Collection), nil
+}
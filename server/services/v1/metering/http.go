@@ -0,0 +1,65 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metering serves the HTTP variant of the Usage API, the consumption of the namespace of the caller.
+package metering
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// queryHeaders are the headers of the Usage requests which can also be passed as query parameters.
+var queryHeaders = map[string]string{
+	"start":      api.HeaderUsageStart,
+	"end":        api.HeaderUsageEnd,
+	"rollup":     api.HeaderUsageRollup,
+	"project":    api.HeaderUsageProject,
+	"collection": api.HeaderUsageCollection,
+}
+
+// Handler returns the consumption of the namespace, selected by the "start", "end", "rollup", "project" and
+// "collection" query parameters or by the matching Tigris-Usage headers.
+type Handler struct {
+	client api.UsageMeteringClient
+}
+
+func NewHandler(client api.UsageMeteringClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Usage(common.OutgoingContextWithQuery(r, queryHeaders), &api.ListProjectsRequest{})
+	writeResponse(w, resp, err)
+}
+
+func writeResponse(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
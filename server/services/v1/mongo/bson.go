@@ -0,0 +1,376 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+)
+
+// BSON element types understood by the wire listener. Anything outside this set is rejected while decoding
+// because it can't be mapped to a Tigris JSON document anyway.
+const (
+	bsonDouble    byte = 0x01
+	bsonString    byte = 0x02
+	bsonDocument  byte = 0x03
+	bsonArray     byte = 0x04
+	bsonBinary    byte = 0x05
+	bsonUndefined byte = 0x06
+	bsonObjectID  byte = 0x07
+	bsonBool      byte = 0x08
+	bsonDateTime  byte = 0x09
+	bsonNull      byte = 0x0A
+	bsonRegex     byte = 0x0B
+	bsonInt32     byte = 0x10
+	bsonTimestamp byte = 0x11
+	bsonInt64     byte = 0x12
+	bsonMinKey    byte = 0xFF
+	bsonMaxKey    byte = 0x7F
+)
+
+// Doc is an ordered BSON document. Order matters for commands, the first element of a command document is the
+// command name.
+type Doc []Elem
+
+// Elem is a single key/value pair of a Doc.
+type Elem struct {
+	Key   string
+	Value any
+}
+
+// RawDoc is an already encoded BSON document, it is written as is when encoding.
+type RawDoc []byte
+
+// ObjectID is the 12 byte BSON object id.
+type ObjectID [12]byte
+
+func (o ObjectID) Hex() string {
+	return hex.EncodeToString(o[:])
+}
+
+// Binary is BSON binary data along with its subtype.
+type Binary struct {
+	Subtype byte
+	Data    []byte
+}
+
+// Regex is a BSON regular expression.
+type Regex struct {
+	Pattern string
+	Options string
+}
+
+// Timestamp is the internal BSON timestamp type, drivers only send it as part of cluster time gossip.
+type Timestamp struct {
+	T uint32
+	I uint32
+}
+
+// Get returns the value of the first element with the given key.
+func (d Doc) Get(key string) (any, bool) {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Name returns the first key of the document, which is the command name for command documents.
+func (d Doc) Name() string {
+	if len(d) == 0 {
+		return ""
+	}
+
+	return d[0].Key
+}
+
+// DecodeDoc decodes a single BSON document, the input must contain exactly one document.
+func DecodeDoc(b []byte) (Doc, error) {
+	d, n, err := decodeDoc(b)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(b) {
+		return nil, fmt.Errorf("bson: %d trailing bytes after document", len(b)-n)
+	}
+
+	return d, nil
+}
+
+func decodeDoc(b []byte) (Doc, int, error) {
+	if len(b) < 5 {
+		return nil, 0, fmt.Errorf("bson: document too short")
+	}
+	size := int(int32(binary.LittleEndian.Uint32(b)))
+	if size < 5 || size > len(b) {
+		return nil, 0, fmt.Errorf("bson: invalid document size %d", size)
+	}
+	if b[size-1] != 0 {
+		return nil, 0, fmt.Errorf("bson: document is not null terminated")
+	}
+
+	var doc Doc
+	pos := 4
+	for pos < size-1 {
+		t := b[pos]
+		pos++
+		key, n, err := readCString(b[pos : size-1])
+		if err != nil {
+			return nil, 0, err
+		}
+		pos += n
+
+		val, n, err := decodeValue(t, b[pos:size-1])
+		if err != nil {
+			return nil, 0, fmt.Errorf("bson: field '%s': %w", key, err)
+		}
+		pos += n
+
+		doc = append(doc, Elem{Key: key, Value: val})
+	}
+
+	return doc, size, nil
+}
+
+func decodeValue(t byte, b []byte) (any, int, error) {
+	switch t {
+	case bsonDouble:
+		if len(b) < 8 {
+			return nil, 0, errShortValue
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), 8, nil
+	case bsonString:
+		return readString(b)
+	case bsonDocument:
+		return decodeDoc(b)
+	case bsonArray:
+		d, n, err := decodeDoc(b)
+		if err != nil {
+			return nil, 0, err
+		}
+		arr := make([]any, 0, len(d))
+		for _, e := range d {
+			arr = append(arr, e.Value)
+		}
+		return arr, n, nil
+	case bsonBinary:
+		if len(b) < 5 {
+			return nil, 0, errShortValue
+		}
+		l := int(int32(binary.LittleEndian.Uint32(b)))
+		if l < 0 || len(b) < 5+l {
+			return nil, 0, errShortValue
+		}
+		data := make([]byte, l)
+		copy(data, b[5:5+l])
+		return Binary{Subtype: b[4], Data: data}, 5 + l, nil
+	case bsonUndefined, bsonNull, bsonMinKey, bsonMaxKey:
+		return nil, 0, nil
+	case bsonObjectID:
+		if len(b) < 12 {
+			return nil, 0, errShortValue
+		}
+		var o ObjectID
+		copy(o[:], b)
+		return o, 12, nil
+	case bsonBool:
+		if len(b) < 1 {
+			return nil, 0, errShortValue
+		}
+		return b[0] == 1, 1, nil
+	case bsonDateTime:
+		if len(b) < 8 {
+			return nil, 0, errShortValue
+		}
+		return time.UnixMilli(int64(binary.LittleEndian.Uint64(b))).UTC(), 8, nil
+	case bsonRegex:
+		pattern, n1, err := readCString(b)
+		if err != nil {
+			return nil, 0, err
+		}
+		options, n2, err := readCString(b[n1:])
+		if err != nil {
+			return nil, 0, err
+		}
+		return Regex{Pattern: pattern, Options: options}, n1 + n2, nil
+	case bsonInt32:
+		if len(b) < 4 {
+			return nil, 0, errShortValue
+		}
+		return int32(binary.LittleEndian.Uint32(b)), 4, nil
+	case bsonTimestamp:
+		if len(b) < 8 {
+			return nil, 0, errShortValue
+		}
+		return Timestamp{I: binary.LittleEndian.Uint32(b), T: binary.LittleEndian.Uint32(b[4:])}, 8, nil
+	case bsonInt64:
+		if len(b) < 8 {
+			return nil, 0, errShortValue
+		}
+		return int64(binary.LittleEndian.Uint64(b)), 8, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported bson type 0x%02x", t)
+	}
+}
+
+var errShortValue = fmt.Errorf("value truncated")
+
+func readCString(b []byte) (string, int, error) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", 0, fmt.Errorf("bson: cstring is not null terminated")
+	}
+
+	return string(b[:i]), i + 1, nil
+}
+
+func readString(b []byte) (string, int, error) {
+	if len(b) < 4 {
+		return "", 0, errShortValue
+	}
+	l := int(int32(binary.LittleEndian.Uint32(b)))
+	if l < 1 || len(b) < 4+l || b[3+l] != 0 {
+		return "", 0, fmt.Errorf("bson: invalid string length %d", l)
+	}
+
+	return string(b[4 : 3+l]), 4 + l, nil
+}
+
+// EncodeDoc encodes the document as BSON.
+func EncodeDoc(d Doc) ([]byte, error) {
+	return appendDoc(nil, d)
+}
+
+func appendDoc(dst []byte, d Doc) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+
+	var err error
+	for _, e := range d {
+		if dst, err = appendElem(dst, e.Key, e.Value); err != nil {
+			return nil, err
+		}
+	}
+	dst = append(dst, 0)
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start))
+
+	return dst, nil
+}
+
+func appendElem(dst []byte, key string, v any) ([]byte, error) {
+	typePos := len(dst)
+	dst = append(dst, 0)
+	dst = append(dst, key...)
+	dst = append(dst, 0)
+
+	var t byte
+	switch val := v.(type) {
+	case nil:
+		t = bsonNull
+	case float64:
+		t = bsonDouble
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(val))
+	case float32:
+		t = bsonDouble
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(float64(val)))
+	case string:
+		t = bsonString
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(val)+1))
+		dst = append(dst, val...)
+		dst = append(dst, 0)
+	case Doc:
+		t = bsonDocument
+		var err error
+		if dst, err = appendDoc(dst, val); err != nil {
+			return nil, err
+		}
+	case RawDoc:
+		t = bsonDocument
+		dst = append(dst, val...)
+	case []any:
+		t = bsonArray
+		arr := make(Doc, len(val))
+		for i, a := range val {
+			arr[i] = Elem{Key: fmt.Sprint(i), Value: a}
+		}
+		var err error
+		if dst, err = appendDoc(dst, arr); err != nil {
+			return nil, err
+		}
+	case []RawDoc:
+		t = bsonArray
+		arr := make(Doc, len(val))
+		for i, a := range val {
+			arr[i] = Elem{Key: fmt.Sprint(i), Value: a}
+		}
+		var err error
+		if dst, err = appendDoc(dst, arr); err != nil {
+			return nil, err
+		}
+	case Binary:
+		t = bsonBinary
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(val.Data)))
+		dst = append(dst, val.Subtype)
+		dst = append(dst, val.Data...)
+	case []byte:
+		t = bsonBinary
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(val)))
+		dst = append(dst, 0)
+		dst = append(dst, val...)
+	case ObjectID:
+		t = bsonObjectID
+		dst = append(dst, val[:]...)
+	case bool:
+		t = bsonBool
+		if val {
+			dst = append(dst, 1)
+		} else {
+			dst = append(dst, 0)
+		}
+	case time.Time:
+		t = bsonDateTime
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(val.UnixMilli()))
+	case Regex:
+		t = bsonRegex
+		dst = append(dst, val.Pattern...)
+		dst = append(dst, 0)
+		dst = append(dst, val.Options...)
+		dst = append(dst, 0)
+	case int32:
+		t = bsonInt32
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(val))
+	case int:
+		t = bsonInt64
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(val))
+	case int64:
+		t = bsonInt64
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(val))
+	case Timestamp:
+		t = bsonTimestamp
+		dst = binary.LittleEndian.AppendUint32(dst, val.I)
+		dst = binary.LittleEndian.AppendUint32(dst, val.T)
+	default:
+		return nil, fmt.Errorf("bson: unsupported value of type %T for key '%s'", v, key)
+	}
+	dst[typePos] = t
+
+	return dst, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBSONRoundTrip(t *testing.T) {
+	ts := time.UnixMilli(1680000000123).UTC()
+	doc := Doc{
+		{Key: "_id", Value: ObjectID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{Key: "double", Value: 1.5},
+		{Key: "string", Value: "hello"},
+		{Key: "nested", Value: Doc{{Key: "a", Value: int32(1)}, {Key: "b", Value: nil}}},
+		{Key: "array", Value: []any{int32(1), "two", Doc{{Key: "three", Value: true}}}},
+		{Key: "binary", Value: Binary{Subtype: 4, Data: []byte{0xde, 0xad}}},
+		{Key: "bool", Value: false},
+		{Key: "date", Value: ts},
+		{Key: "regex", Value: Regex{Pattern: "^a", Options: "i"}},
+		{Key: "int64", Value: int64(1) << 40},
+		{Key: "ts", Value: Timestamp{T: 10, I: 2}},
+	}
+
+	b, err := EncodeDoc(doc)
+	require.NoError(t, err)
+	require.Equal(t, len(b), int(binary.LittleEndian.Uint32(b)))
+
+	decoded, err := DecodeDoc(b)
+	require.NoError(t, err)
+	require.Equal(t, doc, decoded)
+
+	_, err = DecodeDoc(b[:len(b)-1])
+	require.Error(t, err)
+
+	_, err = DecodeDoc(append(b, 0))
+	require.Error(t, err)
+}
+
+func TestJSONConversion(t *testing.T) {
+	oid := ObjectID{0x64, 0x2a, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	doc := Doc{
+		{Key: "_id", Value: oid},
+		{Key: "name", Value: "a \"quoted\" name"},
+		{Key: "int", Value: int32(10)},
+		{Key: "big", Value: int64(1) << 40},
+		{Key: "double", Value: 1.25},
+		{Key: "created", Value: time.Date(2023, 4, 1, 10, 0, 0, 500000000, time.UTC)},
+		{Key: "bin", Value: Binary{Data: []byte("abc")}},
+		{Key: "tags", Value: []any{"x", nil}},
+		{Key: "nested", Value: Doc{{Key: "z", Value: true}, {Key: "a", Value: false}}},
+	}
+
+	js, err := DocToJSON(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"_id":"642a00000000000000000001","name":"a \"quoted\" name","int":10,"big":1099511627776,"double":1.25,"created":"2023-04-01T10:00:00.5Z","bin":"YWJj","tags":["x",null],"nested":{"z":true,"a":false}}`, string(js))
+
+	back, err := JSONToDoc(js)
+	require.NoError(t, err)
+	require.Equal(t, Doc{
+		{Key: "_id", Value: oid},
+		{Key: "name", Value: "a \"quoted\" name"},
+		{Key: "int", Value: int32(10)},
+		{Key: "big", Value: int64(1) << 40},
+		{Key: "double", Value: 1.25},
+		{Key: "created", Value: "2023-04-01T10:00:00.5Z"},
+		{Key: "bin", Value: "YWJj"},
+		{Key: "tags", Value: []any{"x", nil}},
+		{Key: "nested", Value: Doc{{Key: "z", Value: true}, {Key: "a", Value: false}}},
+	}, back)
+
+	// only a top level hex id is converted back to an object id
+	back, err = JSONToDoc([]byte(`{"_id":"not-an-object-id","nested":{"_id":"642a00000000000000000001"}}`))
+	require.NoError(t, err)
+	require.Equal(t, Doc{
+		{Key: "_id", Value: "not-an-object-id"},
+		{Key: "nested", Value: Doc{{Key: "_id", Value: "642a00000000000000000001"}}},
+	}, back)
+}
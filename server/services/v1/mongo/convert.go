@@ -0,0 +1,189 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// IDField is the primary key field Mongo drivers generate for every document.
+const IDField = "_id"
+
+// DocToJSON converts a BSON document into the JSON document accepted by the Tigris APIs. The field order of the
+// BSON document is preserved. BSON only types are mapped to their Tigris JSON representation: object ids become
+// hex strings, datetimes become RFC3339 strings and binary values are base64 encoded.
+func DocToJSON(d Doc) ([]byte, error) {
+	return toJSON(d)
+}
+
+func toJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, v any) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(val))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(val), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(val, 10))
+	case int:
+		buf.WriteString(strconv.Itoa(val))
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return fmt.Errorf("'%v' can't be represented in a JSON document", val)
+		}
+		buf.WriteString(strconv.FormatFloat(val, 'g', -1, 64))
+	case string:
+		return writeJSONString(buf, val)
+	case ObjectID:
+		return writeJSONString(buf, val.Hex())
+	case time.Time:
+		return writeJSONString(buf, val.UTC().Format(time.RFC3339Nano))
+	case Binary:
+		return writeJSONString(buf, base64.StdEncoding.EncodeToString(val.Data))
+	case []byte:
+		return writeJSONString(buf, base64.StdEncoding.EncodeToString(val))
+	case Doc:
+		buf.WriteByte('{')
+		for i, e := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONString(buf, e.Key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSON(buf, e.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, a := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, a); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return fmt.Errorf("values of type %T are not supported", v)
+	}
+
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	b, err := jsoniter.Marshal(s)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+
+	return nil
+}
+
+// JSONToDoc converts a JSON document returned by the Tigris APIs into a BSON document, keeping the field order.
+// Integers that fit into 32 bits are returned as int32, larger ones as int64 and everything else as double. A
+// top level "_id" holding a hex encoded object id is converted back to an ObjectID so that documents inserted by
+// Mongo drivers round trip with the same identity.
+func JSONToDoc(data []byte) (Doc, error) {
+	iter := jsoniter.ParseBytes(jsoniter.ConfigCompatibleWithStandardLibrary, data)
+	if iter.WhatIsNext() != jsoniter.ObjectValue {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	v := readJSONValue(iter)
+	if iter.Error != nil {
+		return nil, iter.Error
+	}
+
+	doc := v.(Doc)
+	for i, e := range doc {
+		if e.Key != IDField {
+			continue
+		}
+		if s, ok := e.Value.(string); ok && len(s) == 24 {
+			var o ObjectID
+			if _, err := hex.Decode(o[:], []byte(s)); err == nil {
+				doc[i].Value = o
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+func readJSONValue(iter *jsoniter.Iterator) any {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		return iter.ReadString()
+	case jsoniter.NumberValue:
+		number := iter.ReadNumber()
+		if i, err := number.Int64(); err == nil {
+			if i >= math.MinInt32 && i <= math.MaxInt32 {
+				return int32(i)
+			}
+			return i
+		}
+		f, err := number.Float64()
+		if err != nil {
+			iter.ReportError("number", err.Error())
+		}
+		return f
+	case jsoniter.BoolValue:
+		return iter.ReadBool()
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return nil
+	case jsoniter.ArrayValue:
+		arr := []any{}
+		iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			arr = append(arr, readJSONValue(iter))
+			return iter.Error == nil
+		})
+		return arr
+	case jsoniter.ObjectValue:
+		doc := Doc{}
+		iter.ReadMapCB(func(iter *jsoniter.Iterator, key string) bool {
+			doc = append(doc, Elem{Key: key, Value: readJSONValue(iter)})
+			return iter.Error == nil
+		})
+		return doc
+	default:
+		iter.ReportError("value", "invalid JSON value")
+		return nil
+	}
+}
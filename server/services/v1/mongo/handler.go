@@ -0,0 +1,852 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/util"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// wire versions advertised in the handshake, 17 corresponds to MongoDB 6.0 which is the command set the
+	// listener is modelled after.
+	minWireVersion = 0
+	maxWireVersion = 17
+
+	compatibleVersion = "6.0.0"
+
+	defaultBatchSize = 101
+	maxBatchBytes    = MaxDocumentSize - 1024*1024
+
+	plainMechanism = "PLAIN"
+)
+
+// Mongo error codes returned to the drivers.
+const (
+	mongoInternalError        int32 = 1
+	mongoBadValue             int32 = 2
+	mongoUnauthorized         int32 = 13
+	mongoAuthenticationFailed int32 = 18
+	mongoNamespaceNotFound    int32 = 26
+	mongoCursorNotFound       int32 = 43
+	mongoMaxTimeMSExpired     int32 = 50
+	mongoCommandNotFound      int32 = 59
+	mongoDuplicateKey         int32 = 11000
+)
+
+var mongoCodeNames = map[int32]string{
+	mongoInternalError:        "InternalError",
+	mongoBadValue:             "BadValue",
+	mongoUnauthorized:         "Unauthorized",
+	mongoAuthenticationFailed: "AuthenticationFailed",
+	mongoNamespaceNotFound:    "NamespaceNotFound",
+	mongoCursorNotFound:       "CursorNotFound",
+	mongoMaxTimeMSExpired:     "MaxTimeMSExpired",
+	mongoCommandNotFound:      "CommandNotFound",
+	mongoDuplicateKey:         "DuplicateKey",
+}
+
+type commandFunc func(s *session, ctx context.Context, db string, cmd Doc) (Doc, error)
+
+var commands map[string]commandFunc
+
+func init() {
+	commands = map[string]commandFunc{
+		"hello":            (*session).hello,
+		"ismaster":         (*session).hello,
+		"ping":             (*session).ping,
+		"buildinfo":        (*session).buildInfo,
+		"whatsmyuri":       (*session).whatsMyURI,
+		"getparameter":     (*session).ping,
+		"endsessions":      (*session).ping,
+		"killsessions":     (*session).ping,
+		"connectionstatus": (*session).connectionStatus,
+		"saslstart":        (*session).saslStart,
+		"logout":           (*session).logout,
+		"listdatabases":    (*session).listDatabases,
+		"listcollections":  (*session).listCollections,
+		"find":             (*session).find,
+		"aggregate":        (*session).aggregate,
+		"getmore":          (*session).getMore,
+		"killcursors":      (*session).killCursors,
+		"count":            (*session).count,
+		"insert":           (*session).insert,
+		"update":           (*session).update,
+		"delete":           (*session).delete,
+		"drop":             (*session).drop,
+		"dropdatabase":     (*session).dropDatabase,
+	}
+}
+
+// cursor holds the documents of a find/aggregate that didn't fit into the first batch.
+type cursor struct {
+	ns   string
+	docs []RawDoc
+}
+
+// session is the state of a single client connection. Mongo cursors and authentication are scoped to the
+// connection, so is the session.
+type session struct {
+	client     api.TigrisClient
+	connID     int32
+	remoteAddr string
+
+	// token is the Tigris access token supplied through SASL PLAIN as the password.
+	token      string
+	cursors    map[int64]*cursor
+	nextCursor int64
+}
+
+func newSession(client api.TigrisClient, connID int32, remoteAddr string) *session {
+	return &session{
+		client:     client,
+		connID:     connID,
+		remoteAddr: remoteAddr,
+		cursors:    make(map[int64]*cursor),
+	}
+}
+
+// handle executes a command and returns its reply document. Failures are reported in the reply document, the
+// way the drivers expect them, never as a protocol error.
+func (s *session) handle(ctx context.Context, cmd Doc) Doc {
+	name := cmd.Name()
+	fn, ok := commands[strings.ToLower(name)]
+	if !ok {
+		return errorReply(mongoCommandNotFound, fmt.Sprintf("no such command: '%s'", name))
+	}
+
+	db, _ := cmd.Get("$db")
+	dbName, _ := db.(string)
+
+	// the requests are made through the in-process client, the network allowlists check the address of the connection
+	ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderClientAddr, s.remoteAddr)
+	if len(s.token) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "bearer "+s.token)
+	}
+
+	reply, err := fn(s, ctx, dbName, cmd)
+	if err != nil {
+		return toErrorReply(err)
+	}
+
+	return append(reply, Elem{Key: "ok", Value: 1.0})
+}
+
+func (s *session) hello(_ context.Context, _ string, _ Doc) (Doc, error) {
+	return Doc{
+		{Key: "ismaster", Value: true},
+		{Key: "isWritablePrimary", Value: true},
+		{Key: "helloOk", Value: true},
+		{Key: "maxBsonObjectSize", Value: int32(MaxDocumentSize)},
+		{Key: "maxMessageSizeBytes", Value: int32(MaxMessageSize)},
+		{Key: "maxWriteBatchSize", Value: int32(100000)},
+		{Key: "localTime", Value: time.Now().UTC()},
+		{Key: "connectionId", Value: s.connID},
+		{Key: "minWireVersion", Value: int32(minWireVersion)},
+		{Key: "maxWireVersion", Value: int32(maxWireVersion)},
+		{Key: "readOnly", Value: false},
+		{Key: "saslSupportedMechs", Value: []any{plainMechanism}},
+	}, nil
+}
+
+func (*session) ping(_ context.Context, _ string, _ Doc) (Doc, error) {
+	return Doc{}, nil
+}
+
+func (*session) buildInfo(_ context.Context, _ string, _ Doc) (Doc, error) {
+	return Doc{
+		{Key: "version", Value: compatibleVersion},
+		{Key: "gitVersion", Value: util.Version},
+		{Key: "versionArray", Value: []any{int32(6), int32(0), int32(0), int32(0)}},
+		{Key: "bits", Value: int32(64)},
+		{Key: "maxBsonObjectSize", Value: int32(MaxDocumentSize)},
+	}, nil
+}
+
+func (s *session) whatsMyURI(_ context.Context, _ string, _ Doc) (Doc, error) {
+	return Doc{{Key: "you", Value: s.remoteAddr}}, nil
+}
+
+func (*session) connectionStatus(_ context.Context, _ string, _ Doc) (Doc, error) {
+	return Doc{{Key: "authInfo", Value: Doc{
+		{Key: "authenticatedUsers", Value: []any{}},
+		{Key: "authenticatedUserRoles", Value: []any{}},
+	}}}, nil
+}
+
+// saslStart implements the single step SASL PLAIN exchange. The password is a Tigris access token which is then
+// forwarded with every request of the connection, so authentication is still done by the regular auth middleware.
+func (s *session) saslStart(_ context.Context, _ string, cmd Doc) (Doc, error) {
+	mechanism, _ := cmd.Get("mechanism")
+	if mechanism != plainMechanism {
+		return nil, &commandError{code: mongoAuthenticationFailed, msg: fmt.Sprintf("unsupported mechanism '%v', only PLAIN is supported", mechanism)}
+	}
+
+	payload, _ := cmd.Get("payload")
+	bin, ok := payload.(Binary)
+	if !ok {
+		return nil, &commandError{code: mongoAuthenticationFailed, msg: "invalid SASL payload"}
+	}
+
+	// authzid NUL authcid NUL passwd
+	parts := bytes.SplitN(bin.Data, []byte{0}, 3)
+	if len(parts) != 3 || len(parts[2]) == 0 {
+		return nil, &commandError{code: mongoAuthenticationFailed, msg: "invalid SASL payload"}
+	}
+	s.token = string(parts[2])
+
+	return Doc{
+		{Key: "conversationId", Value: int32(1)},
+		{Key: "done", Value: true},
+		{Key: "payload", Value: Binary{}},
+	}, nil
+}
+
+func (s *session) logout(_ context.Context, _ string, _ Doc) (Doc, error) {
+	s.token = ""
+	return Doc{}, nil
+}
+
+func (s *session) listDatabases(ctx context.Context, _ string, _ Doc) (Doc, error) {
+	resp, err := s.client.ListProjects(ctx, &api.ListProjectsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	dbs := make([]any, 0, len(resp.Projects))
+	for _, p := range resp.Projects {
+		dbs = append(dbs, Doc{
+			{Key: "name", Value: p.Project},
+			{Key: "sizeOnDisk", Value: int64(0)},
+			{Key: "empty", Value: false},
+		})
+	}
+
+	return Doc{{Key: "databases", Value: dbs}, {Key: "totalSize", Value: int64(0)}}, nil
+}
+
+func (s *session) listCollections(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	resp, err := s.client.ListCollections(ctx, &api.ListCollectionsRequest{Project: db})
+	if err != nil {
+		return nil, err
+	}
+
+	// drivers filter by name to check for a single collection, that is the only filter supported here
+	var nameFilter any
+	if f, ok := cmd.Get("filter"); ok {
+		if fd, ok := f.(Doc); ok {
+			nameFilter, _ = fd.Get("name")
+		}
+	}
+
+	var docs []RawDoc
+	for _, c := range resp.Collections {
+		if nameFilter != nil && nameFilter != c.Collection {
+			continue
+		}
+		raw, err := EncodeDoc(Doc{
+			{Key: "name", Value: c.Collection},
+			{Key: "type", Value: "collection"},
+			{Key: "options", Value: Doc{}},
+			{Key: "info", Value: Doc{{Key: "readOnly", Value: false}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, raw)
+	}
+
+	return s.firstBatch(db+".$cmd.listCollections", docs, cmd), nil
+}
+
+func (s *session) find(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.ReadRequest{Project: db, Collection: collection, Options: &api.ReadRequestOptions{}}
+	if req.Filter, err = TranslateFilter(docField(cmd, "filter")); err != nil {
+		return nil, err
+	}
+	if req.Fields, err = TranslateProjection(docField(cmd, "projection")); err != nil {
+		return nil, err
+	}
+	if req.Sort, err = TranslateSort(docField(cmd, "sort")); err != nil {
+		return nil, err
+	}
+	if v, ok := cmd.Get("skip"); ok {
+		req.Options.Skip, _ = toInt64(v)
+	}
+	if v, ok := cmd.Get("limit"); ok {
+		limit, _ := toInt64(v)
+		if limit < 0 {
+			limit = -limit
+		}
+		req.Options.Limit = limit
+	}
+
+	docs, err := s.read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.firstBatch(db+"."+collection, docs, cmd), nil
+}
+
+// aggregate supports the pipelines that can be expressed as a single Tigris read: a leading $match followed by
+// $sort, $skip, $limit, $project and a trailing $count.
+func (s *session) aggregate(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := cmd.Get("pipeline")
+	pipeline, ok := p.([]any)
+	if !ok {
+		return nil, errors.InvalidArgument("'pipeline' must be an array")
+	}
+
+	var (
+		match      Doc
+		countField string
+		leading    = true
+		seen       = map[string]bool{}
+	)
+	req := &api.ReadRequest{Project: db, Collection: collection, Options: &api.ReadRequestOptions{}}
+	for _, st := range pipeline {
+		stage, ok := st.(Doc)
+		if !ok || len(stage) != 1 {
+			return nil, errors.InvalidArgument("each pipeline stage must be a document with a single operator")
+		}
+		if len(countField) > 0 {
+			return nil, errors.InvalidArgument("'$count' must be the last stage of the pipeline")
+		}
+
+		name, arg := stage[0].Key, stage[0].Value
+		if name != "$match" {
+			leading = false
+		}
+
+		switch name {
+		case "$match":
+			if !leading {
+				return nil, errors.InvalidArgument("'$match' is only supported at the beginning of the pipeline")
+			}
+			m, ok := arg.(Doc)
+			if !ok {
+				return nil, errors.InvalidArgument("'$match' expects a document")
+			}
+			// consecutive $match stages are a conjunction of their conditions
+			match = append(match, m...)
+		case "$sort":
+			if seen["$sort"] || seen["$skip"] || seen["$limit"] || seen["$project"] {
+				return nil, errors.InvalidArgument("'$sort' must come right after '$match'")
+			}
+			spec, ok := arg.(Doc)
+			if !ok {
+				return nil, errors.InvalidArgument("'$sort' expects a document")
+			}
+			if req.Sort, err = TranslateSort(spec); err != nil {
+				return nil, err
+			}
+		case "$skip":
+			if seen["$limit"] {
+				return nil, errors.InvalidArgument("'$skip' after '$limit' is not supported")
+			}
+			skip, ok := toInt64(arg)
+			if !ok || skip < 0 {
+				return nil, errors.InvalidArgument("'$skip' expects a non negative integer")
+			}
+			req.Options.Skip += skip
+		case "$limit":
+			limit, ok := toInt64(arg)
+			if !ok || limit <= 0 {
+				return nil, errors.InvalidArgument("'$limit' expects a positive integer")
+			}
+			if req.Options.Limit == 0 || limit < req.Options.Limit {
+				req.Options.Limit = limit
+			}
+		case "$project":
+			if seen["$project"] {
+				return nil, errors.InvalidArgument("only a single '$project' stage is supported")
+			}
+			spec, ok := arg.(Doc)
+			if !ok {
+				return nil, errors.InvalidArgument("'$project' expects a document")
+			}
+			if req.Fields, err = TranslateProjection(spec); err != nil {
+				return nil, err
+			}
+		case "$count":
+			if countField, ok = arg.(string); !ok || len(countField) == 0 {
+				return nil, errors.InvalidArgument("'$count' expects a field name")
+			}
+		default:
+			return nil, errors.InvalidArgument("aggregation stage '%s' is not supported", name)
+		}
+		seen[name] = true
+	}
+
+	if req.Filter, err = TranslateFilter(match); err != nil {
+		return nil, err
+	}
+
+	docs, err := s.read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(countField) > 0 {
+		n := len(docs)
+		docs = docs[:0]
+		if n > 0 {
+			raw, err := EncodeDoc(Doc{{Key: countField, Value: int64(n)}})
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, raw)
+		}
+	}
+
+	batchOpts, _ := cmd.Get("cursor")
+	opts, _ := batchOpts.(Doc)
+
+	return s.firstBatch(db+"."+collection, docs, opts), nil
+}
+
+func (s *session) getMore(_ context.Context, _ string, cmd Doc) (Doc, error) {
+	id, _ := toInt64(cmd[0].Value)
+	c, ok := s.cursors[id]
+	if !ok {
+		return nil, &commandError{code: mongoCursorNotFound, msg: fmt.Sprintf("cursor id %d not found", id)}
+	}
+
+	batch, rest := splitBatch(c.docs, batchSize(cmd))
+	c.docs = rest
+	if len(rest) == 0 {
+		delete(s.cursors, id)
+		id = 0
+	}
+
+	return Doc{{Key: "cursor", Value: Doc{
+		{Key: "id", Value: id},
+		{Key: "ns", Value: c.ns},
+		{Key: "nextBatch", Value: batch},
+	}}}, nil
+}
+
+func (s *session) killCursors(_ context.Context, _ string, cmd Doc) (Doc, error) {
+	ids, _ := cmd.Get("cursors")
+	arr, _ := ids.([]any)
+
+	killed, notFound := []any{}, []any{}
+	for _, v := range arr {
+		id, _ := toInt64(v)
+		if _, ok := s.cursors[id]; ok {
+			delete(s.cursors, id)
+			killed = append(killed, id)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return Doc{
+		{Key: "cursorsKilled", Value: killed},
+		{Key: "cursorsNotFound", Value: notFound},
+		{Key: "cursorsAlive", Value: []any{}},
+		{Key: "cursorsUnknown", Value: []any{}},
+	}, nil
+}
+
+func (s *session) count(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.CountRequest{Project: db, Collection: collection}
+	if req.Filter, err = TranslateFilter(docField(cmd, "query")); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Count(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	n := resp.Count
+	if v, ok := cmd.Get("skip"); ok {
+		skip, _ := toInt64(v)
+		n -= skip
+	}
+	if v, ok := cmd.Get("limit"); ok {
+		if limit, _ := toInt64(v); limit > 0 && n > limit {
+			n = limit
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+
+	return Doc{{Key: "n", Value: n}}, nil
+}
+
+func (s *session) insert(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	docsField, _ := cmd.Get("documents")
+	docs, _ := docsField.([]any)
+	if len(docs) == 0 {
+		return nil, errors.InvalidArgument("'documents' must be a non empty array")
+	}
+
+	req := &api.InsertRequest{Project: db, Collection: collection}
+	for _, d := range docs {
+		doc, ok := d.(Doc)
+		if !ok {
+			return nil, errors.InvalidArgument("'documents' must only contain documents")
+		}
+		js, err := DocToJSON(doc)
+		if err != nil {
+			return nil, errors.InvalidArgument(err.Error())
+		}
+		req.Documents = append(req.Documents, js)
+	}
+
+	// Tigris inserts the batch atomically, so a failure is reported against the first document and nothing is
+	// written.
+	if _, err = s.client.Insert(ctx, req); err != nil {
+		return Doc{{Key: "n", Value: int32(0)}, {Key: "writeErrors", Value: []any{writeError(0, err)}}}, nil
+	}
+
+	return Doc{{Key: "n", Value: int32(len(docs))}}, nil
+}
+
+func (s *session) update(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	updatesField, _ := cmd.Get("updates")
+	updates, _ := updatesField.([]any)
+
+	var (
+		modified    int64
+		writeErrors []any
+	)
+	for i, u := range updates {
+		stmt, ok := u.(Doc)
+		if !ok {
+			return nil, errors.InvalidArgument("'updates' must only contain documents")
+		}
+
+		n, err := s.updateOne(ctx, db, collection, stmt)
+		if err != nil {
+			writeErrors = append(writeErrors, writeError(i, err))
+			if ordered(cmd) {
+				break
+			}
+			continue
+		}
+		modified += n
+	}
+
+	reply := Doc{{Key: "n", Value: modified}, {Key: "nModified", Value: modified}}
+	if len(writeErrors) > 0 {
+		reply = append(reply, Elem{Key: "writeErrors", Value: writeErrors})
+	}
+
+	return reply, nil
+}
+
+func (s *session) updateOne(ctx context.Context, db string, collection string, stmt Doc) (int64, error) {
+	if upsert, _ := stmt.Get("upsert"); upsert == true {
+		return 0, errors.InvalidArgument("upserts are not supported")
+	}
+
+	u, _ := stmt.Get("u")
+	fields, err := TranslateUpdate(u)
+	if err != nil {
+		return 0, err
+	}
+	filter, err := TranslateFilter(docField(stmt, "q"))
+	if err != nil {
+		return 0, err
+	}
+
+	req := &api.UpdateRequest{Project: db, Collection: collection, Fields: fields, Filter: filter, Options: &api.UpdateRequestOptions{}}
+	if multi, _ := stmt.Get("multi"); multi != true {
+		req.Options.Limit = 1
+	}
+
+	resp, err := s.client.Update(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(resp.ModifiedCount), nil
+}
+
+func (s *session) delete(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	deletesField, _ := cmd.Get("deletes")
+	deletes, _ := deletesField.([]any)
+
+	var (
+		deleted     int64
+		writeErrors []any
+	)
+	for i, d := range deletes {
+		stmt, ok := d.(Doc)
+		if !ok {
+			return nil, errors.InvalidArgument("'deletes' must only contain documents")
+		}
+
+		n, err := s.deleteOne(ctx, db, collection, stmt)
+		if err != nil {
+			writeErrors = append(writeErrors, writeError(i, err))
+			if ordered(cmd) {
+				break
+			}
+			continue
+		}
+		deleted += n
+	}
+
+	reply := Doc{{Key: "n", Value: deleted}}
+	if len(writeErrors) > 0 {
+		reply = append(reply, Elem{Key: "writeErrors", Value: writeErrors})
+	}
+
+	return reply, nil
+}
+
+func (s *session) deleteOne(ctx context.Context, db string, collection string, stmt Doc) (int64, error) {
+	filter, err := TranslateFilter(docField(stmt, "q"))
+	if err != nil {
+		return 0, err
+	}
+
+	req := &api.DeleteRequest{Project: db, Collection: collection, Filter: filter, Options: &api.DeleteRequestOptions{}}
+	if limit, _ := stmt.Get("limit"); limit != nil {
+		req.Options.Limit, _ = toInt64(limit)
+	}
+
+	resp, err := s.client.Delete(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(resp.DeletedCount), nil
+}
+
+func (s *session) drop(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	collection, err := collectionName(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.client.DropCollection(ctx, &api.DropCollectionRequest{Project: db, Collection: collection}); err != nil {
+		return nil, err
+	}
+
+	return Doc{{Key: "ns", Value: db + "." + collection}}, nil
+}
+
+func (s *session) dropDatabase(ctx context.Context, db string, _ Doc) (Doc, error) {
+	if _, err := s.client.DeleteProject(ctx, &api.DeleteProjectRequest{Project: db}); err != nil {
+		return nil, err
+	}
+
+	return Doc{{Key: "dropped", Value: db}}, nil
+}
+
+// read drains the Tigris read stream and returns the documents BSON encoded.
+func (s *session) read(ctx context.Context, req *api.ReadRequest) ([]RawDoc, error) {
+	stream, err := s.client.Read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []RawDoc
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := JSONToDoc(resp.Data)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := EncodeDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, raw)
+	}
+}
+
+// firstBatch returns the first batch of the result and keeps the rest in a cursor for subsequent getMore calls.
+func (s *session) firstBatch(ns string, docs []RawDoc, opts Doc) Doc {
+	size := batchSize(opts)
+	if single, _ := opts.Get("singleBatch"); single == true {
+		size = len(docs)
+	}
+
+	batch, rest := splitBatch(docs, size)
+
+	var id int64
+	if len(rest) > 0 {
+		s.nextCursor++
+		id = s.nextCursor
+		s.cursors[id] = &cursor{ns: ns, docs: rest}
+	}
+
+	return Doc{{Key: "cursor", Value: Doc{
+		{Key: "id", Value: id},
+		{Key: "ns", Value: ns},
+		{Key: "firstBatch", Value: batch},
+	}}}
+}
+
+// splitBatch returns at most size documents without exceeding the maximum reply size. At least one document is
+// always returned so that the client makes progress.
+func splitBatch(docs []RawDoc, size int) ([]RawDoc, []RawDoc) {
+	if size <= 0 || size > len(docs) {
+		size = len(docs)
+	}
+
+	total := 0
+	for i := 0; i < size; i++ {
+		total += len(docs[i])
+		if total > maxBatchBytes && i > 0 {
+			size = i
+			break
+		}
+	}
+
+	return docs[:size:size], docs[size:]
+}
+
+func batchSize(opts Doc) int {
+	v, ok := opts.Get("batchSize")
+	if !ok {
+		return defaultBatchSize
+	}
+	n, _ := toInt64(v)
+
+	return int(n)
+}
+
+func collectionName(cmd Doc) (string, error) {
+	name, ok := cmd[0].Value.(string)
+	if !ok || len(name) == 0 {
+		return "", errors.InvalidArgument("collection name must be a non empty string")
+	}
+
+	return name, nil
+}
+
+func docField(d Doc, key string) Doc {
+	v, _ := d.Get(key)
+	doc, _ := v.(Doc)
+
+	return doc
+}
+
+func ordered(cmd Doc) bool {
+	v, ok := cmd.Get("ordered")
+	return !ok || v == true
+}
+
+// commandError is a failure that already carries a Mongo error code.
+type commandError struct {
+	code int32
+	msg  string
+}
+
+func (e *commandError) Error() string {
+	return e.msg
+}
+
+func toMongoError(err error) (int32, string) {
+	if ce, ok := err.(*commandError); ok {
+		return ce.code, ce.msg
+	}
+
+	te := api.FromStatusError(err)
+	switch te.Code {
+	case api.Code_INVALID_ARGUMENT, api.Code_FAILED_PRECONDITION, api.Code_OUT_OF_RANGE:
+		return mongoBadValue, te.Message
+	case api.Code_NOT_FOUND:
+		return mongoNamespaceNotFound, te.Message
+	case api.Code_ALREADY_EXISTS, api.Code_CONFLICT:
+		return mongoDuplicateKey, te.Message
+	case api.Code_UNAUTHENTICATED:
+		return mongoAuthenticationFailed, te.Message
+	case api.Code_PERMISSION_DENIED:
+		return mongoUnauthorized, te.Message
+	case api.Code_DEADLINE_EXCEEDED:
+		return mongoMaxTimeMSExpired, te.Message
+	default:
+		return mongoInternalError, te.Message
+	}
+}
+
+func toErrorReply(err error) Doc {
+	return errorReply(toMongoError(err))
+}
+
+func errorReply(code int32, msg string) Doc {
+	return Doc{
+		{Key: "ok", Value: 0.0},
+		{Key: "errmsg", Value: msg},
+		{Key: "code", Value: code},
+		{Key: "codeName", Value: mongoCodeNames[code]},
+	}
+}
+
+func writeError(index int, err error) Doc {
+	code, msg := toMongoError(err)
+
+	return Doc{
+		{Key: "index", Value: int32(index)},
+		{Key: "code", Value: code},
+		{Key: "errmsg", Value: msg},
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type readStream struct {
+	grpc.ClientStream
+
+	docs []string
+}
+
+func (r *readStream) Recv() (*api.ReadResponse, error) {
+	if len(r.docs) == 0 {
+		return nil, io.EOF
+	}
+	resp := &api.ReadResponse{Data: []byte(r.docs[0])}
+	r.docs = r.docs[1:]
+
+	return resp, nil
+}
+
+type fakeClient struct {
+	api.TigrisClient
+
+	docs     []string
+	read     *api.ReadRequest
+	inserted *api.InsertRequest
+	updated  *api.UpdateRequest
+	md       metadata.MD
+}
+
+func (f *fakeClient) Read(_ context.Context, in *api.ReadRequest, _ ...grpc.CallOption) (api.Tigris_ReadClient, error) {
+	f.read = in
+	return &readStream{docs: f.docs}, nil
+}
+
+func (f *fakeClient) Insert(ctx context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*api.InsertResponse, error) {
+	f.inserted = in
+	f.md, _ = metadata.FromOutgoingContext(ctx)
+	if in.Collection == "missing" {
+		return nil, api.Errorf(api.Code_NOT_FOUND, "collection doesn't exist")
+	}
+
+	return &api.InsertResponse{}, nil
+}
+
+func (f *fakeClient) Update(_ context.Context, in *api.UpdateRequest, _ ...grpc.CallOption) (*api.UpdateResponse, error) {
+	f.updated = in
+	return &api.UpdateResponse{ModifiedCount: 1}, nil
+}
+
+func newOpMsg(t *testing.T, requestID int32, cmd Doc) []byte {
+	out := make([]byte, headerSize, 128)
+	out = binary.LittleEndian.AppendUint32(out, 0)
+	out = append(out, 0)
+	out, err := appendDoc(out, cmd)
+	require.NoError(t, err)
+
+	binary.LittleEndian.PutUint32(out[0:], uint32(len(out)))
+	binary.LittleEndian.PutUint32(out[4:], uint32(requestID))
+	binary.LittleEndian.PutUint32(out[12:], uint32(opMsg))
+
+	return out
+}
+
+func roundTrip(t *testing.T, conn net.Conn, req []byte) (msgHeader, Doc) {
+	_, err := conn.Write(req)
+	require.NoError(t, err)
+
+	var hdr [headerSize]byte
+	_, err = io.ReadFull(conn, hdr[:])
+	require.NoError(t, err)
+	h := msgHeader{
+		Length:     int32(binary.LittleEndian.Uint32(hdr[0:])),
+		ResponseTo: int32(binary.LittleEndian.Uint32(hdr[8:])),
+		OpCode:     int32(binary.LittleEndian.Uint32(hdr[12:])),
+	}
+
+	body := make([]byte, h.Length-headerSize)
+	_, err = io.ReadFull(conn, body)
+	require.NoError(t, err)
+
+	skip := 5
+	if h.OpCode == opReply {
+		skip = 20
+	}
+	reply, err := DecodeDoc(body[skip:])
+	require.NoError(t, err)
+
+	return h, reply
+}
+
+func startServer(t *testing.T, client api.TigrisClient) net.Conn {
+	server, conn := net.Pipe()
+	go NewServer(client).serveConn(server)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestHandshake(t *testing.T) {
+	conn := startServer(t, &fakeClient{})
+
+	// legacy OP_QUERY handshake against admin.$cmd
+	req := make([]byte, headerSize, 128)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	req = append(req, "admin.$cmd"...)
+	req = append(req, 0)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	req = binary.LittleEndian.AppendUint32(req, ^uint32(0))
+	req, err := appendDoc(req, Doc{{Key: "isMaster", Value: int32(1)}})
+	require.NoError(t, err)
+	binary.LittleEndian.PutUint32(req[0:], uint32(len(req)))
+	binary.LittleEndian.PutUint32(req[4:], 7)
+	binary.LittleEndian.PutUint32(req[12:], uint32(opQuery))
+
+	h, reply := roundTrip(t, conn, req)
+	require.Equal(t, opReply, h.OpCode)
+	require.Equal(t, int32(7), h.ResponseTo)
+	v, _ := reply.Get("maxWireVersion")
+	require.Equal(t, int32(maxWireVersion), v)
+	v, _ = reply.Get("ok")
+	require.Equal(t, 1.0, v)
+
+	h, reply = roundTrip(t, conn, newOpMsg(t, 8, Doc{{Key: "ping", Value: int32(1)}, {Key: "$db", Value: "admin"}}))
+	require.Equal(t, opMsg, h.OpCode)
+	require.Equal(t, int32(8), h.ResponseTo)
+	require.Equal(t, Doc{{Key: "ok", Value: 1.0}}, reply)
+
+	_, reply = roundTrip(t, conn, newOpMsg(t, 9, Doc{{Key: "shutdown", Value: int32(1)}, {Key: "$db", Value: "admin"}}))
+	require.Equal(t, errorReply(mongoCommandNotFound, "no such command: 'shutdown'"), reply)
+}
+
+func TestFindWithCursor(t *testing.T) {
+	client := &fakeClient{docs: []string{`{"_id":1,"name":"a"}`, `{"_id":2,"name":"b"}`, `{"_id":3,"name":"c"}`}}
+	conn := startServer(t, client)
+
+	_, reply := roundTrip(t, conn, newOpMsg(t, 1, Doc{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: Doc{{Key: "name", Value: Doc{{Key: "$in", Value: []any{"a", "b", "c"}}}}}},
+		{Key: "sort", Value: Doc{{Key: "_id", Value: int32(-1)}}},
+		{Key: "batchSize", Value: int32(2)},
+		{Key: "$db", Value: "app"},
+	}))
+	require.Equal(t, "app", client.read.Project)
+	require.Equal(t, "users", client.read.Collection)
+	require.JSONEq(t, `{"$or":[{"name":"a"},{"name":"b"},{"name":"c"}]}`, string(client.read.Filter))
+	require.JSONEq(t, `[{"_id":"$desc"}]`, string(client.read.Sort))
+
+	c, _ := reply.Get("cursor")
+	cur := c.(Doc)
+	id, _ := cur.Get("id")
+	require.NotEqual(t, int64(0), id)
+	batch, _ := cur.Get("firstBatch")
+	require.Equal(t, []any{
+		Doc{{Key: "_id", Value: int32(1)}, {Key: "name", Value: "a"}},
+		Doc{{Key: "_id", Value: int32(2)}, {Key: "name", Value: "b"}},
+	}, batch)
+
+	_, reply = roundTrip(t, conn, newOpMsg(t, 2, Doc{{Key: "getMore", Value: id}, {Key: "collection", Value: "users"}, {Key: "$db", Value: "app"}}))
+	c, _ = reply.Get("cursor")
+	cur = c.(Doc)
+	id, _ = cur.Get("id")
+	require.Equal(t, int64(0), id)
+	batch, _ = cur.Get("nextBatch")
+	require.Equal(t, []any{Doc{{Key: "_id", Value: int32(3)}, {Key: "name", Value: "c"}}}, batch)
+}
+
+func TestWrites(t *testing.T) {
+	client := &fakeClient{}
+	conn := startServer(t, client)
+
+	// documents are sent as an OP_MSG document sequence by the drivers
+	req := make([]byte, headerSize, 256)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	req = append(req, 0)
+	req, err := appendDoc(req, Doc{{Key: "insert", Value: "users"}, {Key: "$db", Value: "app"}})
+	require.NoError(t, err)
+	seqStart := len(req)
+	req = append(req, 1, 0, 0, 0, 0)
+	req = append(req, "documents"...)
+	req = append(req, 0)
+	for _, d := range []Doc{{{Key: "_id", Value: ObjectID{1}}, {Key: "n", Value: int32(1)}}, {{Key: "n", Value: int32(2)}}} {
+		req, err = appendDoc(req, d)
+		require.NoError(t, err)
+	}
+	binary.LittleEndian.PutUint32(req[seqStart+1:], uint32(len(req)-seqStart-1))
+	binary.LittleEndian.PutUint32(req[0:], uint32(len(req)))
+	binary.LittleEndian.PutUint32(req[12:], uint32(opMsg))
+
+	_, reply := roundTrip(t, conn, req)
+	require.Equal(t, Doc{{Key: "n", Value: int32(2)}, {Key: "ok", Value: 1.0}}, reply)
+	require.Len(t, client.inserted.Documents, 2)
+	require.JSONEq(t, `{"_id":"010000000000000000000000","n":1}`, string(client.inserted.Documents[0]))
+
+	_, reply = roundTrip(t, conn, newOpMsg(t, 2, Doc{
+		{Key: "insert", Value: "missing"},
+		{Key: "documents", Value: []any{Doc{{Key: "n", Value: int32(1)}}}},
+		{Key: "$db", Value: "app"},
+	}))
+	require.Equal(t, Doc{
+		{Key: "n", Value: int32(0)},
+		{Key: "writeErrors", Value: []any{Doc{
+			{Key: "index", Value: int32(0)},
+			{Key: "code", Value: mongoNamespaceNotFound},
+			{Key: "errmsg", Value: "collection doesn't exist"},
+		}}},
+		{Key: "ok", Value: 1.0},
+	}, reply)
+
+	_, reply = roundTrip(t, conn, newOpMsg(t, 3, Doc{
+		{Key: "update", Value: "users"},
+		{Key: "updates", Value: []any{Doc{
+			{Key: "q", Value: Doc{{Key: "n", Value: int32(1)}}},
+			{Key: "u", Value: Doc{{Key: "$inc", Value: Doc{{Key: "n", Value: int32(1)}}}}},
+		}}},
+		{Key: "$db", Value: "app"},
+	}))
+	require.Equal(t, Doc{{Key: "n", Value: int64(1)}, {Key: "nModified", Value: int64(1)}, {Key: "ok", Value: 1.0}}, reply)
+	require.JSONEq(t, `{"n":1}`, string(client.updated.Filter))
+	require.JSONEq(t, `{"$increment":{"n":1}}`, string(client.updated.Fields))
+	require.Equal(t, int64(1), client.updated.Options.Limit)
+}
+
+func TestClientAddrForwarded(t *testing.T) {
+	client := &fakeClient{}
+	sess := newSession(client, 1, "10.0.0.1:5000")
+
+	reply := sess.handle(context.Background(), Doc{
+		{Key: "insert", Value: "users"},
+		{Key: "documents", Value: []any{Doc{{Key: "n", Value: int32(1)}}}},
+		{Key: "$db", Value: "app"},
+	})
+	require.Equal(t, Doc{{Key: "n", Value: int32(1)}, {Key: "ok", Value: 1.0}}, reply)
+	// the network allowlists of the in-process requests check the address of the connection
+	require.Equal(t, []string{"10.0.0.1:5000"}, client.md.Get(api.HeaderClientAddr))
+}
@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"go.uber.org/atomic"
+)
+
+// Server speaks the MongoDB wire protocol and translates the commands into calls to the Tigris API. Requests go
+// through the same client as the HTTP gateway, so they run all the server interceptors, including authentication
+// and quota.
+type Server struct {
+	client api.TigrisClient
+	connID atomic.Int32
+}
+
+func NewServer(client api.TigrisClient) *Server {
+	return &Server{client: client}
+}
+
+// Serve accepts connections on the listener until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	sess := newSession(s.client, s.connID.Inc(), conn.RemoteAddr().String())
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	var requestID int32
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Debug().Err(err).Str("remote", sess.remoteAddr).Msg("mongo wire protocol error")
+			}
+			return
+		}
+
+		reply := sess.handle(context.Background(), msg.cmd)
+		if msg.moreToCome {
+			continue
+		}
+
+		requestID++
+		out, err := encodeReply(msg, requestID, reply)
+		if err != nil {
+			log.Err(err).Str("command", msg.cmd.Name()).Msg("encoding mongo reply failed")
+			if out, err = encodeReply(msg, requestID, toErrorReply(err)); err != nil {
+				return
+			}
+		}
+
+		if _, err = w.Write(out); err != nil {
+			return
+		}
+		if err = w.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"strings"
+
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/query/update"
+)
+
+// Mongo query and update operators that have a direct Tigris counterpart.
+const (
+	mongoEq      = "$eq"
+	mongoNe      = "$ne"
+	mongoGt      = "$gt"
+	mongoGte     = "$gte"
+	mongoLt      = "$lt"
+	mongoLte     = "$lte"
+	mongoIn      = "$in"
+	mongoNin     = "$nin"
+	mongoRegex   = "$regex"
+	mongoOptions = "$options"
+	mongoAnd     = "$and"
+	mongoOr      = "$or"
+	mongoSet     = "$set"
+	mongoUnset   = "$unset"
+	mongoInc     = "$inc"
+	mongoMul     = "$mul"
+)
+
+// TranslateFilter converts a Mongo query document into the Tigris filter grammar. Comparison and logical operators
+// map one to one, "$in"/"$nin" are expanded into "$or"/"$and" of equality checks and "$ne" is expressed as a range
+// exclusion which also matches null. An empty query matches all the documents, but "$ne" and "$nin" don't match the
+// documents missing the field.
+func TranslateFilter(query Doc) ([]byte, error) {
+	translated, err := translateFilterDoc(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return DocToJSON(translated)
+}
+
+func translateFilterDoc(query Doc) (Doc, error) {
+	var clauses []any
+	for _, e := range query {
+		switch {
+		case e.Key == mongoAnd || e.Key == mongoOr:
+			arr, ok := e.Value.([]any)
+			if !ok || len(arr) == 0 {
+				return nil, errors.InvalidArgument("'%s' expects a non empty array of expressions", e.Key)
+			}
+
+			var children []any
+			for _, a := range arr {
+				child, ok := a.(Doc)
+				if !ok {
+					return nil, errors.InvalidArgument("'%s' expects a non empty array of expressions", e.Key)
+				}
+				translated, err := translateFilterDoc(child)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, translated)
+			}
+			clauses = append(clauses, Doc{{Key: e.Key, Value: children}})
+		case strings.HasPrefix(e.Key, "$"):
+			return nil, errors.InvalidArgument("query operator '%s' is not supported", e.Key)
+		default:
+			fieldClauses, err := translateField(e.Key, e.Value)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, fieldClauses...)
+		}
+	}
+
+	switch len(clauses) {
+	case 0:
+		return Doc{}, nil
+	case 1:
+		return clauses[0].(Doc), nil
+	default:
+		return Doc{{Key: mongoAnd, Value: clauses}}, nil
+	}
+}
+
+func translateField(field string, v any) ([]any, error) {
+	if re, ok := v.(Regex); ok {
+		clause, err := regexClause(field, re.Pattern, re.Options)
+		if err != nil {
+			return nil, err
+		}
+		return []any{clause}, nil
+	}
+
+	ops, ok := v.(Doc)
+	if !ok || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		if err := validateLiteral(field, v); err != nil {
+			return nil, err
+		}
+		return []any{Doc{{Key: field, Value: v}}}, nil
+	}
+
+	var clauses []any
+	for _, op := range ops {
+		switch op.Key {
+		case mongoEq, mongoGt, mongoGte, mongoLt, mongoLte:
+			if err := validateLiteral(field, op.Value); err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, Doc{{Key: field, Value: Doc{{Key: op.Key, Value: op.Value}}}})
+		case mongoNe:
+			clause, err := notEqualClause(field, op.Value)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, clause)
+		case mongoIn, mongoNin:
+			arr, ok := op.Value.([]any)
+			if !ok {
+				return nil, errors.InvalidArgument("'%s' expects an array", op.Key)
+			}
+			if len(arr) == 0 {
+				if op.Key == mongoIn {
+					return nil, errors.InvalidArgument("'%s' with an empty array is not supported", op.Key)
+				}
+				// nothing to exclude
+				continue
+			}
+
+			var children []any
+			for _, a := range arr {
+				var (
+					clause Doc
+					err    error
+				)
+				if op.Key == mongoIn {
+					err = validateLiteral(field, a)
+					clause = Doc{{Key: field, Value: a}}
+				} else {
+					clause, err = notEqualClause(field, a)
+				}
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, clause)
+			}
+
+			logical := mongoOr
+			if op.Key == mongoNin {
+				logical = mongoAnd
+			}
+			if len(children) == 1 {
+				clauses = append(clauses, children[0])
+			} else {
+				clauses = append(clauses, Doc{{Key: logical, Value: children}})
+			}
+		case mongoRegex:
+			var pattern, options string
+			switch re := op.Value.(type) {
+			case string:
+				pattern = re
+			case Regex:
+				pattern, options = re.Pattern, re.Options
+			default:
+				return nil, errors.InvalidArgument("'%s' expects a string or a regular expression", op.Key)
+			}
+			if o, ok := ops.Get(mongoOptions); ok {
+				if options, ok = o.(string); !ok {
+					return nil, errors.InvalidArgument("'%s' expects a string", mongoOptions)
+				}
+			}
+
+			clause, err := regexClause(field, pattern, options)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, clause)
+		case mongoOptions:
+			if _, ok := ops.Get(mongoRegex); !ok {
+				return nil, errors.InvalidArgument("'%s' needs '%s'", mongoOptions, mongoRegex)
+			}
+		default:
+			return nil, errors.InvalidArgument("query operator '%s' is not supported", op.Key)
+		}
+	}
+
+	return clauses, nil
+}
+
+// notEqualClause builds the Tigris equivalent of {field: {$ne: v}} by matching everything below or above the value,
+// or a null value. Tigris "$not" can't be used for strings as it excludes substrings rather than only the value
+// itself. {field: {$ne: null}} is the not-null check {field: {$gt: null}}, as every value is ordered after null.
+//
+// Unlike Mongo, the documents without the field are not matched, a Tigris filter never matches a missing field.
+func notEqualClause(field string, v any) (Doc, error) {
+	if err := validateLiteral(field, v); err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return Doc{{Key: field, Value: Doc{{Key: filter.GT, Value: nil}}}}, nil
+	}
+
+	return Doc{{Key: mongoOr, Value: []any{
+		Doc{{Key: field, Value: Doc{{Key: filter.LT, Value: v}}}},
+		Doc{{Key: field, Value: Doc{{Key: filter.GT, Value: v}}}},
+		Doc{{Key: field, Value: nil}},
+	}}}, nil
+}
+
+// regexClause converts Mongo regular expression options into inline Go regexp flags.
+func regexClause(field string, pattern string, options string) (Doc, error) {
+	var flags string
+	for _, o := range options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		default:
+			return nil, errors.InvalidArgument("regular expression option '%c' is not supported", o)
+		}
+	}
+	if len(flags) > 0 {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	return Doc{{Key: field, Value: Doc{{Key: filter.REGEX, Value: pattern}}}}, nil
+}
+
+func validateLiteral(field string, v any) error {
+	switch v.(type) {
+	case Doc, []any:
+		return errors.InvalidArgument("comparing field '%s' against an embedded document or an array is not supported", field)
+	case Regex, Timestamp:
+		return errors.InvalidArgument("unsupported value for field '%s'", field)
+	}
+
+	return nil
+}
+
+// TranslateProjection converts a Mongo projection into Tigris read fields. Only inclusion and exclusion of fields
+// is supported.
+func TranslateProjection(projection Doc) ([]byte, error) {
+	if len(projection) == 0 {
+		return nil, nil
+	}
+
+	fields := make(Doc, 0, len(projection))
+	for _, e := range projection {
+		include, ok := truthy(e.Value)
+		if !ok {
+			return nil, errors.InvalidArgument("projection of field '%s' only supports inclusion or exclusion", e.Key)
+		}
+		fields = append(fields, Elem{Key: e.Key, Value: include})
+	}
+
+	return DocToJSON(fields)
+}
+
+// TranslateSort converts a Mongo sort specification into the Tigris sort array.
+func TranslateSort(spec Doc) ([]byte, error) {
+	if len(spec) == 0 {
+		return nil, nil
+	}
+
+	orders := make([]any, 0, len(spec))
+	for _, e := range spec {
+		dir, ok := toInt64(e.Value)
+		if !ok || (dir != 1 && dir != -1) {
+			return nil, errors.InvalidArgument("sort direction for field '%s' must be 1 or -1", e.Key)
+		}
+
+		order := sort.ASC
+		if dir == -1 {
+			order = sort.DESC
+		}
+		orders = append(orders, Doc{{Key: e.Key, Value: order}})
+	}
+
+	return toJSON(orders)
+}
+
+// TranslateUpdate converts Mongo update operators into Tigris update fields. Replacement documents and pipeline
+// updates are rejected because Tigris updates always operate on individual fields.
+func TranslateUpdate(u any) ([]byte, error) {
+	ops, ok := u.(Doc)
+	if !ok || len(ops) == 0 {
+		return nil, errors.InvalidArgument("update must be a non empty document of update operators")
+	}
+
+	var fields Doc
+	for _, op := range ops {
+		arg, ok := op.Value.(Doc)
+		if !ok {
+			if strings.HasPrefix(op.Key, "$") {
+				return nil, errors.InvalidArgument("'%s' expects a document", op.Key)
+			}
+			return nil, errors.InvalidArgument("replacement documents are not supported, use update operators instead")
+		}
+
+		switch op.Key {
+		case mongoSet:
+			fields = append(fields, Elem{Key: string(update.Set), Value: arg})
+		case mongoInc:
+			fields = append(fields, Elem{Key: string(update.Increment), Value: arg})
+		case mongoMul:
+			fields = append(fields, Elem{Key: string(update.Multiply), Value: arg})
+		case mongoUnset:
+			names := make([]any, 0, len(arg))
+			for _, e := range arg {
+				names = append(names, e.Key)
+			}
+			fields = append(fields, Elem{Key: string(update.UnSet), Value: names})
+		default:
+			if strings.HasPrefix(op.Key, "$") {
+				return nil, errors.InvalidArgument("update operator '%s' is not supported", op.Key)
+			}
+			return nil, errors.InvalidArgument("replacement documents are not supported, use update operators instead")
+		}
+	}
+
+	return DocToJSON(fields)
+}
+
+func truthy(v any) (bool, bool) {
+	if b, ok := v.(bool); ok {
+		return b, true
+	}
+	if f, ok := v.(float64); ok {
+		return f != 0, true
+	}
+	if i, ok := toInt64(v); ok {
+		return i != 0, true
+	}
+
+	return false, false
+}
+
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,208 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+)
+
+func TestTranslateFilter(t *testing.T) {
+	cases := []struct {
+		query  Doc
+		filter string
+	}{
+		{
+			nil,
+			`{}`,
+		}, {
+			Doc{{Key: "a", Value: int32(1)}},
+			`{"a":1}`,
+		}, {
+			Doc{{Key: "a", Value: int32(1)}, {Key: "b", Value: "x"}},
+			`{"$and":[{"a":1},{"b":"x"}]}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$gt", Value: int32(1)}, {Key: "$lte", Value: 5.5}}}},
+			`{"$and":[{"a":{"$gt":1}},{"a":{"$lte":5.5}}]}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$eq", Value: ObjectID{}}}}},
+			`{"a":{"$eq":"000000000000000000000000"}}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$ne", Value: "x"}}}},
+			`{"$or":[{"a":{"$lt":"x"}},{"a":{"$gt":"x"}},{"a":null}]}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$ne", Value: int32(3)}}}},
+			`{"$or":[{"a":{"$lt":3}},{"a":{"$gt":3}},{"a":null}]}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$ne", Value: nil}}}},
+			`{"a":{"$gt":null}}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$in", Value: []any{int32(1), int32(2)}}}}},
+			`{"$or":[{"a":1},{"a":2}]}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$in", Value: []any{"x"}}}}},
+			`{"a":"x"}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$nin", Value: []any{"x", "y"}}}}},
+			`{"$and":[{"$or":[{"a":{"$lt":"x"}},{"a":{"$gt":"x"}},{"a":null}]},{"$or":[{"a":{"$lt":"y"}},{"a":{"$gt":"y"}},{"a":null}]}]}`,
+		}, {
+			Doc{{Key: "a", Value: Regex{Pattern: "^ab", Options: "i"}}},
+			`{"a":{"$regex":"(?i)^ab"}}`,
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$regex", Value: "^ab"}, {Key: "$options", Value: "m"}}}},
+			`{"a":{"$regex":"(?m)^ab"}}`,
+		}, {
+			Doc{{Key: "$or", Value: []any{
+				Doc{{Key: "a", Value: int32(1)}},
+				Doc{{Key: "b.c", Value: Doc{{Key: "$gte", Value: int64(2)}}}},
+			}}},
+			`{"$or":[{"a":1},{"b.c":{"$gte":2}}]}`,
+		},
+	}
+	for _, c := range cases {
+		filter, err := TranslateFilter(c.query)
+		require.NoError(t, err)
+		require.JSONEq(t, c.filter, string(filter))
+	}
+
+	errCases := []struct {
+		query Doc
+		err   error
+	}{
+		{
+			Doc{{Key: "$nor", Value: []any{Doc{{Key: "a", Value: int32(1)}}}}},
+			errors.InvalidArgument("query operator '$nor' is not supported"),
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$exists", Value: true}}}},
+			errors.InvalidArgument("query operator '$exists' is not supported"),
+		}, {
+			Doc{{Key: "$and", Value: []any{}}},
+			errors.InvalidArgument("'$and' expects a non empty array of expressions"),
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "b", Value: int32(1)}}}},
+			errors.InvalidArgument("comparing field 'a' against an embedded document or an array is not supported"),
+		}, {
+			Doc{{Key: "a", Value: Regex{Pattern: "x", Options: "x"}}},
+			errors.InvalidArgument("regular expression option 'x' is not supported"),
+		},
+	}
+	for _, c := range errCases {
+		_, err := TranslateFilter(c.query)
+		require.Equal(t, c.err, err)
+	}
+}
+
+func TestTranslateNotEqualMatches(t *testing.T) {
+	fields := []*schema.QueryableField{
+		schema.NewQueryableFieldsBuilder().NewQueryableField("a", &schema.Field{DataType: schema.StringType}, nil),
+	}
+
+	for _, query := range []Doc{
+		{{Key: "a", Value: Doc{{Key: "$ne", Value: "x"}}}},
+		{{Key: "a", Value: Doc{{Key: "$nin", Value: []any{"x", "z"}}}}},
+	} {
+		translated, err := TranslateFilter(query)
+		require.NoError(t, err)
+		wrapped, err := filter.NewFactory(fields, nil).WrappedFilter(translated)
+		require.NoError(t, err)
+
+		require.True(t, wrapped.Matches([]byte(`{"a":"y"}`), nil))
+		require.True(t, wrapped.Matches([]byte(`{"a":null}`), nil))
+		require.False(t, wrapped.Matches([]byte(`{"a":"x"}`), nil))
+		// unlike Mongo, a document missing the field is not matched
+		require.False(t, wrapped.Matches([]byte(`{"b":"y"}`), nil))
+	}
+}
+
+func TestTranslateNotNullMatches(t *testing.T) {
+	builder := schema.NewQueryableFieldsBuilder()
+	fields := []*schema.QueryableField{
+		builder.NewQueryableField("a", &schema.Field{DataType: schema.StringType}, nil),
+		builder.NewQueryableField("n", &schema.Field{DataType: schema.Int64Type}, nil),
+	}
+
+	for _, c := range []struct {
+		query   Doc
+		matched []string
+		other   []string
+	}{
+		{
+			Doc{{Key: "a", Value: Doc{{Key: "$ne", Value: nil}}}},
+			[]string{`{"a":"x"}`, `{"a":"A"}`},
+			[]string{`{"a":null}`, `{"b":"x"}`},
+		}, {
+			Doc{{Key: "n", Value: Doc{{Key: "$ne", Value: nil}}}},
+			[]string{`{"n":-5}`, `{"n":0}`},
+			[]string{`{"n":null}`, `{"b":1}`},
+		}, {
+			Doc{{Key: "a", Value: Doc{{Key: "$nin", Value: []any{nil, "x"}}}}},
+			[]string{`{"a":"y"}`},
+			[]string{`{"a":"x"}`, `{"a":null}`, `{"b":"y"}`},
+		},
+	} {
+		translated, err := TranslateFilter(c.query)
+		require.NoError(t, err)
+		wrapped, err := filter.NewFactory(fields, nil).WrappedFilter(translated)
+		require.NoError(t, err)
+
+		for _, doc := range c.matched {
+			require.True(t, wrapped.Matches([]byte(doc), nil), "%s %s", translated, doc)
+		}
+		// a null value and, unlike Mongo, a document missing the field are not matched
+		for _, doc := range c.other {
+			require.False(t, wrapped.Matches([]byte(doc), nil), "%s %s", translated, doc)
+		}
+	}
+}
+
+func TestTranslateReadOptions(t *testing.T) {
+	fields, err := TranslateProjection(Doc{{Key: "a", Value: int32(1)}, {Key: "_id", Value: 0.0}, {Key: "b", Value: true}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":true,"_id":false,"b":true}`, string(fields))
+
+	_, err = TranslateProjection(Doc{{Key: "a", Value: Doc{{Key: "$slice", Value: int32(1)}}}})
+	require.Equal(t, errors.InvalidArgument("projection of field 'a' only supports inclusion or exclusion"), err)
+
+	sort, err := TranslateSort(Doc{{Key: "a", Value: int32(1)}, {Key: "b", Value: -1.0}})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":"$asc"},{"b":"$desc"}]`, string(sort))
+
+	_, err = TranslateSort(Doc{{Key: "a", Value: "text"}})
+	require.Equal(t, errors.InvalidArgument("sort direction for field 'a' must be 1 or -1"), err)
+}
+
+func TestTranslateUpdate(t *testing.T) {
+	fields, err := TranslateUpdate(Doc{
+		{Key: "$set", Value: Doc{{Key: "a", Value: "x"}}},
+		{Key: "$inc", Value: Doc{{Key: "n", Value: int32(-1)}}},
+		{Key: "$mul", Value: Doc{{Key: "m", Value: 2.0}}},
+		{Key: "$unset", Value: Doc{{Key: "b", Value: ""}, {Key: "c.d", Value: int32(1)}}},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"$set":{"a":"x"},"$increment":{"n":-1},"$multiply":{"m":2},"$unset":["b","c.d"]}`, string(fields))
+
+	_, err = TranslateUpdate(Doc{{Key: "a", Value: int32(1)}})
+	require.Equal(t, errors.InvalidArgument("replacement documents are not supported, use update operators instead"), err)
+
+	_, err = TranslateUpdate(Doc{{Key: "$push", Value: Doc{{Key: "a", Value: int32(1)}}}})
+	require.Equal(t, errors.InvalidArgument("update operator '$push' is not supported"), err)
+
+	_, err = TranslateUpdate([]any{Doc{{Key: "$set", Value: Doc{}}}})
+	require.Equal(t, errors.InvalidArgument("update must be a non empty document of update operators"), err)
+}
@@ -0,0 +1,236 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Wire protocol opcodes. OP_MSG is used for everything by current drivers, OP_QUERY is only used for the initial
+// handshake, which is answered with OP_REPLY.
+const (
+	opReply      int32 = 1
+	opQuery      int32 = 2004
+	opCompressed int32 = 2012
+	opMsg        int32 = 2013
+)
+
+const (
+	headerSize = 16
+
+	// MaxMessageSize is advertised to the drivers in the handshake and enforced on incoming messages.
+	MaxMessageSize = 48000000
+	// MaxDocumentSize is the largest BSON document the drivers may send.
+	MaxDocumentSize = 16 * 1024 * 1024
+
+	msgFlagChecksumPresent uint32 = 1 << 0
+	msgFlagMoreToCome      uint32 = 1 << 1
+)
+
+type msgHeader struct {
+	Length     int32
+	RequestID  int32
+	ResponseTo int32
+	OpCode     int32
+}
+
+// message is a decoded client request.
+type message struct {
+	header msgHeader
+	// cmd is the command document with all the OP_MSG document sequences folded into it as arrays.
+	cmd Doc
+	// moreToCome is set when the client doesn't expect a reply.
+	moreToCome bool
+}
+
+func readMessage(r io.Reader) (*message, error) {
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	h := msgHeader{
+		Length:     int32(binary.LittleEndian.Uint32(hdr[0:])),
+		RequestID:  int32(binary.LittleEndian.Uint32(hdr[4:])),
+		ResponseTo: int32(binary.LittleEndian.Uint32(hdr[8:])),
+		OpCode:     int32(binary.LittleEndian.Uint32(hdr[12:])),
+	}
+	if h.Length < headerSize || h.Length > MaxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", h.Length)
+	}
+
+	body := make([]byte, h.Length-headerSize)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	switch h.OpCode {
+	case opMsg:
+		return parseOpMsg(h, body)
+	case opQuery:
+		return parseOpQuery(h, body)
+	case opCompressed:
+		return nil, fmt.Errorf("compressed messages are not supported")
+	default:
+		return nil, fmt.Errorf("unsupported opcode %d", h.OpCode)
+	}
+}
+
+func parseOpMsg(h msgHeader, body []byte) (*message, error) {
+	if len(body) < 4 {
+		return nil, fmt.Errorf("OP_MSG too short")
+	}
+	flags := binary.LittleEndian.Uint32(body)
+	body = body[4:]
+	if flags&msgFlagChecksumPresent != 0 {
+		if len(body) < 4 {
+			return nil, fmt.Errorf("OP_MSG checksum missing")
+		}
+		body = body[:len(body)-4]
+	}
+
+	var (
+		cmd       Doc
+		sequences []Elem
+	)
+	for len(body) > 0 {
+		kind := body[0]
+		body = body[1:]
+
+		switch kind {
+		case 0:
+			d, n, err := decodeDoc(body)
+			if err != nil {
+				return nil, err
+			}
+			if cmd != nil {
+				return nil, fmt.Errorf("OP_MSG has more than one body section")
+			}
+			cmd = d
+			body = body[n:]
+		case 1:
+			if len(body) < 4 {
+				return nil, fmt.Errorf("OP_MSG document sequence too short")
+			}
+			size := int(int32(binary.LittleEndian.Uint32(body)))
+			if size < 4 || size > len(body) {
+				return nil, fmt.Errorf("invalid OP_MSG document sequence size %d", size)
+			}
+			section := body[4:size]
+			body = body[size:]
+
+			id, n, err := readCString(section)
+			if err != nil {
+				return nil, err
+			}
+			section = section[n:]
+
+			var docs []any
+			for len(section) > 0 {
+				d, n, err := decodeDoc(section)
+				if err != nil {
+					return nil, err
+				}
+				docs = append(docs, d)
+				section = section[n:]
+			}
+			sequences = append(sequences, Elem{Key: id, Value: docs})
+		default:
+			return nil, fmt.Errorf("unsupported OP_MSG section kind %d", kind)
+		}
+	}
+	if cmd == nil {
+		return nil, fmt.Errorf("OP_MSG without body section")
+	}
+
+	return &message{
+		header:     h,
+		cmd:        append(cmd, sequences...),
+		moreToCome: flags&msgFlagMoreToCome != 0,
+	}, nil
+}
+
+// parseOpQuery accepts legacy OP_QUERY commands sent to "<db>.$cmd", drivers use it for the first handshake.
+func parseOpQuery(h msgHeader, body []byte) (*message, error) {
+	if len(body) < 4 {
+		return nil, fmt.Errorf("OP_QUERY too short")
+	}
+	body = body[4:]
+
+	ns, n, err := readCString(body)
+	if err != nil {
+		return nil, err
+	}
+	body = body[n:]
+
+	db, coll, _ := strings.Cut(ns, ".")
+	if coll != "$cmd" {
+		return nil, fmt.Errorf("OP_QUERY is only supported for commands")
+	}
+
+	if len(body) < 8 {
+		return nil, fmt.Errorf("OP_QUERY too short")
+	}
+	body = body[8:]
+
+	cmd, _, err := decodeDoc(body)
+	if err != nil {
+		return nil, err
+	}
+	if wrapped, ok := cmd.Get("$query"); ok {
+		if cmd, ok = wrapped.(Doc); !ok {
+			return nil, fmt.Errorf("invalid $query")
+		}
+	}
+	if _, ok := cmd.Get("$db"); !ok {
+		cmd = append(cmd, Elem{Key: "$db", Value: db})
+	}
+
+	return &message{header: h, cmd: cmd}, nil
+}
+
+// encodeReply encodes the reply to the request in the same protocol the request used.
+func encodeReply(req *message, requestID int32, reply Doc) ([]byte, error) {
+	out := make([]byte, headerSize, 256)
+	opCode := opMsg
+
+	if req.header.OpCode == opQuery {
+		opCode = opReply
+		// responseFlags, cursorID, startingFrom, numberReturned
+		out = binary.LittleEndian.AppendUint32(out, 0)
+		out = binary.LittleEndian.AppendUint64(out, 0)
+		out = binary.LittleEndian.AppendUint32(out, 0)
+		out = binary.LittleEndian.AppendUint32(out, 1)
+	} else {
+		// flags, followed by a single body section
+		out = binary.LittleEndian.AppendUint32(out, 0)
+		out = append(out, 0)
+	}
+
+	out, err := appendDoc(out, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	binary.LittleEndian.PutUint32(out[0:], uint32(len(out)))
+	binary.LittleEndian.PutUint32(out[4:], uint32(requestID))
+	binary.LittleEndian.PutUint32(out[8:], uint32(req.header.RequestID))
+	binary.LittleEndian.PutUint32(out[12:], uint32(opCode))
+
+	return out, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+
+	"github.com/fullstorydev/grpchan/inprocgrpc"
+	"github.com/go-chi/chi/v5"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/services/v1/multisearch"
+	"google.golang.org/grpc"
+)
+
+const (
+	multiSearchPath = fullProjectPath + "/search/multi"
+)
+
+// multiSearchService searches the collections through the api service and the search indexes through the search
+// service, so the sources are searched the same way as by the search requests of the collections and the indexes.
+type multiSearchService struct {
+	apiSvc    *apiService
+	searchSvc *searchService
+}
+
+func newMultiSearchService(apiSvc *apiService, searchSvc *searchService) *multiSearchService {
+	return &multiSearchService{
+		apiSvc:    apiSvc,
+		searchSvc: searchSvc,
+	}
+}
+
+func (s *multiSearchService) MultiSearch(ctx context.Context, req *api.SearchRequest) (*api.SearchResponse, error) {
+	return multisearch.Search(ctx, req, s.apiSvc.Search, s.searchSvc.Search)
+}
+
+func (s *multiSearchService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Channel) error {
+	api.RegisterMultiSearchServer(inproc, s)
+
+	router.Post(apiPathPrefix+multiSearchPath, multisearch.NewHandler(api.NewMultiSearchClient(inproc)).Search)
+
+	return nil
+}
+
+func (s *multiSearchService) RegisterGRPC(grpc *grpc.Server) error {
+	api.RegisterMultiSearchServer(grpc, s)
+	return nil
+}
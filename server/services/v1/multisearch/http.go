@@ -0,0 +1,75 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multisearch
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// Handler serves the multi search of the project of the request path, the body of the POST request is the JSON
+// search request with the "sources" to search.
+type Handler struct {
+	client api.MultiSearchClient
+}
+
+func NewHandler(client api.MultiSearchClient) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponse(w, nil, errors.InvalidArgument(err.Error()))
+		return
+	}
+
+	req := &api.SearchRequest{}
+	if err = jsoniter.Unmarshal(body, req); err != nil {
+		writeResponse(w, nil, errors.InvalidArgument("invalid request body: %s", err.Error()))
+		return
+	}
+	req.Project = chi.URLParam(r, "project")
+
+	resp, err := h.client.MultiSearch(common.OutgoingContext(r), req)
+	writeResponse(w, resp, err)
+}
+
+func writeResponse(w http.ResponseWriter, resp any, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,287 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package multisearch implements the search of several collections and search indexes of a project in one request.
+package multisearch
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+const (
+	// MaxSources is the maximum number of the collections and the search indexes searched by a request.
+	MaxSources = 16
+
+	defaultPageSize = 20
+	// maxHits is the maximum number of the hits read from a source, it is the page size limit of the search store.
+	maxHits = 250
+)
+
+// CollectionSearchFunc searches a collection.
+type CollectionSearchFunc func(r *api.SearchRequest, stream api.Tigris_SearchServer) error
+
+// IndexSearchFunc searches a search index.
+type IndexSearchFunc func(r *api.SearchIndexRequest, stream api.Search_SearchServer) error
+
+// Search runs the query of the request on all its sources concurrently and merges their hits by the relevance score.
+// The scores of the search store are comparable across the sources for the same query, so the merged hits are ordered
+// the same way as if the sources were one index. A source with no score for its hits, for example, a filter only
+// query, is ordered by the rank of its hits, interleaved with the other sources.
+//
+// The page of the merged hits is cut from the top page*page_size hits of every source, hence the page is limited to
+// the first hits of the sources. The filter and the fields of the request apply to all the sources, unless a source
+// sets its own. Faceting, sorting, grouping and vector search are not supported as they depend on the schema of a
+// source.
+func Search(ctx context.Context, r *api.SearchRequest, searchCollection CollectionSearchFunc, searchIndex IndexSearchFunc) (*api.SearchResponse, error) {
+	sources := r.GetSources()
+	if err := validate(r, sources); err != nil {
+		return nil, err
+	}
+
+	pageSize := int(r.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	page := int(r.Page)
+	if page == 0 {
+		page = 1
+	}
+	limit := page * pageSize
+	if limit > maxHits {
+		return nil, errors.InvalidArgument("page %d of size %d is beyond the first %d hits of the sources", page, pageSize, maxHits)
+	}
+
+	results := make([]*sourceResult, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		results[i] = &sourceResult{stream: stream{ctx: ctx}}
+
+		wg.Add(1)
+		go func(source *api.MultiSearchSource, res *sourceResult) {
+			defer wg.Done()
+
+			if len(source.Collection) > 0 {
+				res.err = searchCollection(collectionRequest(r, source, limit), &collectionStream{stream: &res.stream})
+			} else {
+				res.err = searchIndex(indexRequest(r, source, limit), &indexStream{stream: &res.stream})
+			}
+		}(source, results[i])
+	}
+	wg.Wait()
+
+	var found int64
+	var hits []*rankedHit
+	for i, res := range results {
+		if res.err != nil {
+			return nil, res.err
+		}
+
+		found += res.found
+		for rank, hit := range res.hits {
+			if rank == limit {
+				break
+			}
+			hits = append(hits, &rankedHit{hit: hit, score: score(hit), rank: rank, source: i})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		if hits[i].rank != hits[j].rank {
+			return hits[i].rank < hits[j].rank
+		}
+
+		return hits[i].source < hits[j].source
+	})
+
+	resp := &api.SearchResponse{
+		Hits: []*api.SearchHit{},
+		Meta: &api.SearchMetadata{
+			Found:      found,
+			TotalPages: int32(math.Ceil(float64(found) / float64(pageSize))),
+			Page: &api.Page{
+				Current: int32(page),
+				Size:    int32(pageSize),
+			},
+		},
+	}
+	for i := (page - 1) * pageSize; i < len(hits) && i < limit; i++ {
+		hit := hits[i].hit
+		if hit.Metadata == nil {
+			hit.Metadata = &api.SearchHitMeta{}
+		}
+		hit.Metadata.SetSource(sources[hits[i].source].GetLabel())
+		resp.Hits = append(resp.Hits, hit)
+	}
+
+	return resp, nil
+}
+
+func validate(r *api.SearchRequest, sources []*api.MultiSearchSource) error {
+	if len(sources) == 0 {
+		return errors.InvalidArgument("multi search requires at least one source")
+	}
+	if len(sources) > MaxSources {
+		return errors.InvalidArgument("multi search supports up to %d sources", MaxSources)
+	}
+	if len(r.Facet) > 0 || len(r.Sort) > 0 || len(r.GroupBy) > 0 || len(r.Vector) > 0 {
+		return errors.InvalidArgument("facet, sort, group_by and vector are not supported by multi search")
+	}
+
+	labels := make(map[string]struct{}, len(sources))
+	for _, s := range sources {
+		if s == nil || (len(s.Collection) > 0) == (len(s.Index) > 0) {
+			return errors.InvalidArgument("source of multi search should have either collection or index")
+		}
+		if len(s.Branch) > 0 && len(s.Index) > 0 {
+			return errors.InvalidArgument("branch is not supported for search index '%s'", s.Index)
+		}
+		if _, ok := labels[s.GetLabel()]; ok {
+			return errors.InvalidArgument("duplicate source label '%s', set the label of the source", s.GetLabel())
+		}
+		labels[s.GetLabel()] = struct{}{}
+	}
+
+	return nil
+}
+
+func collectionRequest(r *api.SearchRequest, source *api.MultiSearchSource, limit int) *api.SearchRequest {
+	req := &api.SearchRequest{
+		Project:       r.Project,
+		Collection:    source.Collection,
+		Branch:        source.Branch,
+		Q:             r.Q,
+		SearchFields:  fields(source.SearchFields, r.SearchFields),
+		Filter:        filter(source, r),
+		IncludeFields: fields(source.IncludeFields, r.IncludeFields),
+		ExcludeFields: fields(source.ExcludeFields, r.ExcludeFields),
+		PageSize:      int32(limit),
+		Page:          1,
+		Collation:     r.Collation,
+	}
+	req.SetTypoTolerance(r.GetTypoTolerance())
+	req.SetHighlight(r.GetHighlight())
+
+	return req
+}
+
+func indexRequest(r *api.SearchRequest, source *api.MultiSearchSource, limit int) *api.SearchIndexRequest {
+	req := &api.SearchIndexRequest{
+		Project:       r.Project,
+		Index:         source.Index,
+		Q:             r.Q,
+		SearchFields:  fields(source.SearchFields, r.SearchFields),
+		Filter:        filter(source, r),
+		IncludeFields: fields(source.IncludeFields, r.IncludeFields),
+		ExcludeFields: fields(source.ExcludeFields, r.ExcludeFields),
+		PageSize:      int32(limit),
+		Page:          1,
+		Collation:     r.Collation,
+	}
+	req.SetTypoTolerance(r.GetTypoTolerance())
+
+	return req
+}
+
+func fields(source []string, request []string) []string {
+	if len(source) > 0 {
+		return source
+	}
+
+	return request
+}
+
+func filter(source *api.MultiSearchSource, r *api.SearchRequest) []byte {
+	if len(source.Filter) > 0 {
+		return source.Filter
+	}
+
+	return r.Filter
+}
+
+// score returns the text match score of the hit, zero if the hit has no score.
+func score(hit *api.SearchHit) uint64 {
+	s, err := strconv.ParseUint(hit.GetMetadata().GetMatch().GetScore(), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return s
+}
+
+type rankedHit struct {
+	hit    *api.SearchHit
+	score  uint64
+	rank   int
+	source int
+}
+
+type sourceResult struct {
+	stream
+
+	err error
+}
+
+// stream collects the hits of the responses of a source search.
+type stream struct {
+	ctx   context.Context
+	hits  []*api.SearchHit
+	found int64
+}
+
+func (s *stream) add(resp *api.SearchResponse) {
+	s.hits = append(s.hits, resp.Hits...)
+	if resp.Meta != nil && resp.Meta.Found > s.found {
+		s.found = resp.Meta.Found
+	}
+}
+
+type collectionStream struct {
+	api.Tigris_SearchServer
+
+	stream *stream
+}
+
+func (s *collectionStream) Context() context.Context {
+	return s.stream.ctx
+}
+
+func (s *collectionStream) Send(resp *api.SearchResponse) error {
+	s.stream.add(resp)
+	return nil
+}
+
+type indexStream struct {
+	api.Search_SearchServer
+
+	stream *stream
+}
+
+func (s *indexStream) Context() context.Context {
+	return s.stream.ctx
+}
+
+func (s *indexStream) Send(resp *api.SearchIndexResponse) error {
+	s.stream.add(&api.SearchResponse{Hits: resp.Hits, Meta: resp.Meta})
+	return nil
+}
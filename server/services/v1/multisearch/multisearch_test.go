@@ -0,0 +1,216 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multisearch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func testHit(id string, score string) *api.SearchHit {
+	hit := &api.SearchHit{Data: []byte(fmt.Sprintf(`{"id":"%s"}`, id))}
+	if len(score) > 0 {
+		hit.Metadata = &api.SearchHitMeta{Match: &api.Match{Score: score}}
+	}
+
+	return hit
+}
+
+type testSearch struct {
+	sync.Mutex
+
+	collections map[string][]*api.SearchHit
+	indexes     map[string][]*api.SearchHit
+	collReqs    map[string]*api.SearchRequest
+	indexReqs   map[string]*api.SearchIndexRequest
+	err         error
+}
+
+func newTestSearch() *testSearch {
+	return &testSearch{
+		collections: map[string][]*api.SearchHit{},
+		indexes:     map[string][]*api.SearchHit{},
+		collReqs:    map[string]*api.SearchRequest{},
+		indexReqs:   map[string]*api.SearchIndexRequest{},
+	}
+}
+
+func (s *testSearch) searchCollection(r *api.SearchRequest, stream api.Tigris_SearchServer) error {
+	s.Lock()
+	s.collReqs[r.Collection] = r
+	hits := s.collections[r.Collection]
+	s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if len(hits) > int(r.PageSize) {
+		hits = hits[:r.PageSize]
+	}
+
+	return stream.Send(&api.SearchResponse{Hits: hits, Meta: &api.SearchMetadata{Found: int64(len(s.collections[r.Collection]))}})
+}
+
+func (s *testSearch) searchIndex(r *api.SearchIndexRequest, stream api.Search_SearchServer) error {
+	s.Lock()
+	s.indexReqs[r.Index] = r
+	hits := s.indexes[r.Index]
+	s.Unlock()
+
+	if len(hits) > int(r.PageSize) {
+		hits = hits[:r.PageSize]
+	}
+
+	return stream.Send(&api.SearchIndexResponse{Hits: hits, Meta: &api.SearchMetadata{Found: int64(len(s.indexes[r.Index]))}})
+}
+
+func hitIds(resp *api.SearchResponse) []string {
+	var ids []string
+	for _, h := range resp.Hits {
+		ids = append(ids, fmt.Sprintf("%s:%s", h.Metadata.GetSource(), h.Data))
+	}
+
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestSearch()
+	s.collections["toys"] = []*api.SearchHit{testHit("t1", "300"), testHit("t2", "100")}
+	s.indexes["articles"] = []*api.SearchHit{testHit("a1", "500"), testHit("a2", "200"), testHit("a3", "50")}
+
+	req := &api.SearchRequest{
+		Project:      "p1",
+		Q:            "dino",
+		SearchFields: []string{"name"},
+		Filter:       []byte(`{"active":true}`),
+		PageSize:     3,
+	}
+	req.SetSources([]*api.MultiSearchSource{
+		{Collection: "toys", Branch: "staging"},
+		{Index: "articles", Label: "blog", SearchFields: []string{"title"}, Filter: []byte(`{"draft":false}`)},
+	})
+
+	resp, err := Search(context.Background(), req, s.searchCollection, s.searchIndex)
+	require.NoError(t, err)
+	require.Equal(t, []string{`blog:{"id":"a1"}`, `toys:{"id":"t1"}`, `blog:{"id":"a2"}`}, hitIds(resp))
+	require.Equal(t, int64(5), resp.Meta.Found)
+	require.Equal(t, int32(2), resp.Meta.TotalPages)
+	require.Equal(t, &api.Page{Current: 1, Size: 3}, resp.Meta.Page)
+
+	coll := s.collReqs["toys"]
+	require.Equal(t, "p1", coll.Project)
+	require.Equal(t, "staging", coll.Branch)
+	require.Equal(t, "dino", coll.Q)
+	require.Equal(t, []string{"name"}, coll.SearchFields)
+	require.Equal(t, []byte(`{"active":true}`), coll.Filter)
+	require.Equal(t, int32(3), coll.PageSize)
+	require.Equal(t, int32(1), coll.Page)
+
+	index := s.indexReqs["articles"]
+	require.Equal(t, "p1", index.Project)
+	require.Equal(t, []string{"title"}, index.SearchFields)
+	require.Equal(t, []byte(`{"draft":false}`), index.Filter)
+
+	// the second page is cut from the first six hits of every source
+	req.Page = 2
+	resp, err = Search(context.Background(), req, s.searchCollection, s.searchIndex)
+	require.NoError(t, err)
+	require.Equal(t, []string{`toys:{"id":"t2"}`, `blog:{"id":"a3"}`}, hitIds(resp))
+	require.Equal(t, int32(6), s.collReqs["toys"].PageSize)
+}
+
+func TestSearchWithoutScore(t *testing.T) {
+	s := newTestSearch()
+	s.collections["toys"] = []*api.SearchHit{testHit("t1", ""), testHit("t2", "")}
+	s.collections["games"] = []*api.SearchHit{testHit("g1", "")}
+
+	req := &api.SearchRequest{Project: "p1"}
+	req.SetSources([]*api.MultiSearchSource{{Collection: "toys"}, {Collection: "games"}})
+
+	resp, err := Search(context.Background(), req, s.searchCollection, s.searchIndex)
+	require.NoError(t, err)
+	require.Equal(t, []string{`toys:{"id":"t1"}`, `games:{"id":"g1"}`, `toys:{"id":"t2"}`}, hitIds(resp))
+	require.Equal(t, int32(defaultPageSize), s.collReqs["toys"].PageSize)
+}
+
+func TestSearchError(t *testing.T) {
+	s := newTestSearch()
+	s.err = errors.NotFound("collection doesn't exist")
+
+	req := &api.SearchRequest{Project: "p1"}
+	req.SetSources([]*api.MultiSearchSource{{Collection: "toys"}, {Index: "articles"}})
+
+	_, err := Search(context.Background(), req, s.searchCollection, s.searchIndex)
+	require.Equal(t, errors.NotFound("collection doesn't exist"), err)
+}
+
+func TestSearchValidation(t *testing.T) {
+	cases := []struct {
+		sources []*api.MultiSearchSource
+		modify  func(r *api.SearchRequest)
+		err     error
+	}{
+		{
+			nil,
+			nil,
+			errors.InvalidArgument("multi search requires at least one source"),
+		}, {
+			make([]*api.MultiSearchSource, MaxSources+1),
+			nil,
+			errors.InvalidArgument("multi search supports up to 16 sources"),
+		}, {
+			[]*api.MultiSearchSource{{Collection: "toys", Index: "toys"}},
+			nil,
+			errors.InvalidArgument("source of multi search should have either collection or index"),
+		}, {
+			[]*api.MultiSearchSource{{Label: "toys"}},
+			nil,
+			errors.InvalidArgument("source of multi search should have either collection or index"),
+		}, {
+			[]*api.MultiSearchSource{{Index: "articles", Branch: "staging"}},
+			nil,
+			errors.InvalidArgument("branch is not supported for search index 'articles'"),
+		}, {
+			[]*api.MultiSearchSource{{Collection: "toys"}, {Index: "toys"}},
+			nil,
+			errors.InvalidArgument("duplicate source label 'toys', set the label of the source"),
+		}, {
+			[]*api.MultiSearchSource{{Collection: "toys"}},
+			func(r *api.SearchRequest) { r.Sort = []byte(`[{"name":"$asc"}]`) },
+			errors.InvalidArgument("facet, sort, group_by and vector are not supported by multi search"),
+		}, {
+			[]*api.MultiSearchSource{{Collection: "toys"}},
+			func(r *api.SearchRequest) { r.PageSize, r.Page = 100, 3 },
+			errors.InvalidArgument("page 3 of size 100 is beyond the first 250 hits of the sources"),
+		},
+	}
+	for _, c := range cases {
+		req := &api.SearchRequest{Project: "p1"}
+		req.SetSources(c.sources)
+		if c.modify != nil {
+			c.modify(req)
+		}
+
+		s := newTestSearch()
+		_, err := Search(context.Background(), req, s.searchCollection, s.searchIndex)
+		require.Equal(t, c.err, err)
+	}
+}
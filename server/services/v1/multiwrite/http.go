@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package multiwrite serves the HTTP variant of the MultiWrite API writing across the collections in one transaction.
+package multiwrite
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// Handler runs the writes of the request body, {"branch": "...", "writes": [{"collection": "orders", "op": "insert",
+// "documents": [...]}, ...]}, in one transaction. It joins the explicit transaction of the transaction headers of the
+// request, if any.
+type Handler struct {
+	client api.MultiWriteClient
+}
+
+func NewHandler(client api.MultiWriteClient) *Handler {
+	return &Handler{client: client}
+}
+
+type writeRequest struct {
+	Branch string              `json:"branch"`
+	Writes []*api.MultiWriteOp `json:"writes"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r)
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
+
+func (h *Handler) serve(r *http.Request) (*httpbody.HttpBody, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req writeRequest
+	if err = jsoniter.Unmarshal(body, &req); err != nil {
+		return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+	}
+
+	in := &api.CommitTransactionRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  req.Branch,
+	}
+	in.SetWrites(req.Writes)
+
+	return h.client.MultiWrite(common.OutgoingContext(r), in)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiwrite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	req *api.CommitTransactionRequest
+	md  metadata.MD
+	err error
+}
+
+func (c *testClient) MultiWrite(ctx context.Context, in *api.CommitTransactionRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.req = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &httpbody.HttpBody{
+		ContentType: "application/json",
+		Data:        []byte(`{"status":"written","results":[{"collection":"orders","op":"insert","keys":[{"id":1}]}]}`),
+	}, nil
+}
+
+func serve(client *testClient, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/database/documents/write", NewHandler(client).ServeHTTP)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/database/documents/write", strings.NewReader(body))
+	r.Header.Set(api.HeaderTxID, "tx1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, `{"branch": "b1", "writes": [
+		{"collection": "orders", "op": "insert", "documents": [{"id": 1}]},
+		{"collection": "order_items", "op": "delete", "filter": {"order_id": 1}}
+	]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"status":"written","results":[{"collection":"orders","op":"insert","keys":[{"id":1}]}]}`, w.Body.String())
+
+	require.Equal(t, "p1", client.req.Project)
+	require.Equal(t, "b1", client.req.Branch)
+	writes := client.req.GetWrites()
+	require.Len(t, writes, 2)
+	require.Equal(t, api.MultiWriteInsert, writes[0].Op)
+	require.JSONEq(t, `{"id": 1}`, string(writes[0].Documents[0]))
+	require.Equal(t, "order_items", writes[1].Collection)
+	require.JSONEq(t, `{"order_id": 1}`, string(writes[1].Filter))
+	require.Equal(t, []string{"tx1"}, client.md.Get(api.HeaderTxID))
+
+	w = serve(client, `{"writes": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	client.err = errors.InvalidArgument("multi write has no writes")
+	w = serve(client, `{"writes": []}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error": {"code": "INVALID_ARGUMENT", "message": "multi write has no writes"}}`, w.Body.String())
+}
@@ -0,0 +1,110 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/go-chi/chi/v5"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const networkAllowlistPath = "/network/allowlist"
+
+// registerNetworkHTTP registers the REST endpoints to pin the namespace to the network ranges its clients connect
+// from. The allowlists of the app keys are set with their scope.
+func (s *apiService) registerNetworkHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+networkAllowlistPath, s.getNetworkAllowlist)
+	router.Put(apiPathPrefix+networkAllowlistPath, s.setNetworkAllowlist)
+}
+
+func (s *apiService) callerTenant(w http.ResponseWriter, r *http.Request) *metadata.Tenant {
+	if err := mustBeOwner(r); err != nil {
+		writeHTTPError(w, err)
+		return nil
+	}
+
+	namespace, err := request.GetNamespace(r.Context())
+	if err != nil {
+		writeHTTPError(w, err)
+		return nil
+	}
+
+	tenant, err := s.tenantMgr.GetTenant(r.Context(), namespace)
+	if err != nil {
+		writeHTTPError(w, err)
+		return nil
+	}
+	if tenant == nil {
+		writeHTTPError(w, request.ErrNamespaceNotFound)
+		return nil
+	}
+
+	return tenant
+}
+
+func (s *apiService) getNetworkAllowlist(w http.ResponseWriter, r *http.Request) {
+	tenant := s.callerTenant(w, r)
+	if tenant == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"allowed_cidrs": tenant.GetNamespace().Metadata().AllowedCIDRs})
+}
+
+// setNetworkAllowlist replaces the allowlist of the namespace, an empty list allows all the addresses. The allowlist
+// which doesn't contain the address of the caller is rejected unless it is forced, so the owner doesn't lock itself out
+// by mistake.
+func (s *apiService) setNetworkAllowlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AllowedCIDRs []string `json:"allowed_cidrs"`
+		Force        bool     `json:"force"`
+	}
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	if err := metadata.ValidateCIDRs(req.AllowedCIDRs); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	tenant := s.callerTenant(w, r)
+	if tenant == nil {
+		return
+	}
+
+	if len(req.AllowedCIDRs) > 0 && !req.Force {
+		addr, err := netip.ParseAddr(r.Header.Get(api.HeaderClientAddr))
+		if err != nil || !metadata.AllowsAddr(req.AllowedCIDRs, addr) {
+			writeHTTPError(w, errors.FailedPrecondition("allowlist doesn't contain the address of the caller, set force to apply it anyway"))
+			return
+		}
+	}
+
+	meta := tenant.GetNamespace().Metadata()
+	meta.AllowedCIDRs = req.AllowedCIDRs
+	if err := s.tenantMgr.UpdateNamespaceMetadata(r.Context(), meta); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": database.UpdatedStatus, "allowed_cidrs": meta.AllowedCIDRs})
+}
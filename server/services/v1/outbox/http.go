@@ -0,0 +1,88 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox serves the HTTP variant of the AppendEvents API of the transactional outbox.
+package outbox
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// Handler appends the events of the request body, {"events": [...], "branch": "..."}, to the change stream of the
+// collection. It joins the explicit transaction of the transaction headers of the request, if any.
+type Handler struct {
+	client api.OutboxClient
+}
+
+func NewHandler(client api.OutboxClient) *Handler {
+	return &Handler{client: client}
+}
+
+type appendRequest struct {
+	Branch string                `json:"branch"`
+	Events []jsoniter.RawMessage `json:"events"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r)
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (h *Handler) serve(r *http.Request) (*api.InsertResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req appendRequest
+	if err = jsoniter.Unmarshal(body, &req); err != nil {
+		return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+	}
+
+	events := make([][]byte, 0, len(req.Events))
+	for _, e := range req.Events {
+		events = append(events, e)
+	}
+
+	return h.client.AppendEvents(common.OutgoingContext(r), &api.InsertRequest{
+		Project:    chi.URLParam(r, "project"),
+		Collection: chi.URLParam(r, "collection"),
+		Branch:     req.Branch,
+		Documents:  events,
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	req *api.InsertRequest
+	md  metadata.MD
+	err error
+}
+
+func (c *testClient) AppendEvents(ctx context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*api.InsertResponse, error) {
+	c.req = in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &api.InsertResponse{Status: "appended", Keys: [][]byte{[]byte(`{"id":"1"}`)}}, nil
+}
+
+func serve(client *testClient, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/database/collections/{collection}/events/append", NewHandler(client).ServeHTTP)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/database/collections/orders/events/append", strings.NewReader(body))
+	r.Header.Set(api.HeaderTxID, "tx1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, `{"branch": "b1", "events": [{"type": "order_created", "id": 1}, {"type": "order_paid"}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"metadata": {}, "status": "appended", "keys": [{"id": "1"}]}`, w.Body.String())
+
+	require.Equal(t, "p1", client.req.Project)
+	require.Equal(t, "orders", client.req.Collection)
+	require.Equal(t, "b1", client.req.Branch)
+	require.Len(t, client.req.Documents, 2)
+	require.JSONEq(t, `{"type": "order_created", "id": 1}`, string(client.req.Documents[0]))
+	require.Equal(t, []string{"tx1"}, client.md.Get(api.HeaderTxID))
+
+	w = serve(client, `{"events": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	client.err = errors.Unimplemented("outbox events require change streams to be enabled")
+	w = serve(client, `{"events": [{}]}`)
+	require.Equal(t, http.StatusNotImplemented, w.Code)
+	require.JSONEq(t, `{"error": {"code": "UNIMPLEMENTED", "message": "outbox events require change streams to be enabled"}}`, w.Body.String())
+}
@@ -0,0 +1,56 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/request"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+// withPartialResults returns the context a read allowing partial results runs with. It expires the margin before the
+// deadline of the request, so that the read stops while there is still time to return the results found so far. The
+// context of the reads without a deadline or not allowing partial results is returned as is.
+func withPartialResults(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	allow, err := request.AllowPartialResults(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !allow || !ok {
+		return ctx, func() {}, nil
+	}
+
+	readCtx, cancel := context.WithDeadline(ctx, deadline.Add(-config.DefaultConfig.Server.PartialResultsMargin))
+
+	return readCtx, cancel, nil
+}
+
+// partialResults turns the error of a read stopped by the deadline of the read context into a success, as the
+// results found so far have already been streamed, and flags them as partial in the trailer.
+func partialResults(ctx context.Context, readCtx context.Context, err error) error {
+	if err == nil || readCtx == ctx || readCtx.Err() != context.DeadlineExceeded || ctx.Err() != nil {
+		return err
+	}
+
+	_ = grpc.SetTrailer(ctx, grpcMetadata.Pairs(api.HeaderPartialResults, "true"))
+
+	return nil
+}
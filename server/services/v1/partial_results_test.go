@@ -0,0 +1,67 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPartialResults(t *testing.T) {
+	readErr := fmt.Errorf("read failed")
+
+	// without the header the read runs until the deadline of the request
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	readCtx, readCancel, err := withPartialResults(ctx)
+	require.NoError(t, err)
+	readCancel()
+	require.Equal(t, ctx, readCtx)
+	require.Equal(t, readErr, partialResults(ctx, readCtx, readErr))
+
+	// without a deadline there is nothing to stop the read at
+	noDeadline := metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.HeaderAllowPartialResults, "true"))
+	readCtx, readCancel, err = withPartialResults(noDeadline)
+	require.NoError(t, err)
+	readCancel()
+	require.Equal(t, noDeadline, readCtx)
+
+	ctx, cancel = context.WithTimeout(noDeadline, time.Second)
+	defer cancel()
+	readCtx, readCancel, err = withPartialResults(ctx)
+	require.NoError(t, err)
+	defer readCancel()
+
+	deadline, _ := ctx.Deadline()
+	readDeadline, ok := readCtx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, deadline.Add(-config.DefaultConfig.Server.PartialResultsMargin), readDeadline)
+
+	// the read fails until its deadline is exceeded
+	require.Equal(t, readErr, partialResults(ctx, readCtx, readErr))
+	<-readCtx.Done()
+	require.NoError(t, partialResults(ctx, readCtx, readErr))
+	require.NoError(t, partialResults(ctx, readCtx, nil))
+
+	_, _, err = withPartialResults(metadata.NewIncomingContext(ctx, metadata.Pairs(api.HeaderAllowPartialResults, "maybe")))
+	require.Error(t, err)
+}
@@ -0,0 +1,326 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+const (
+	protocolVersion3 = 196608
+	sslRequestCode   = 80877103
+	gssRequestCode   = 80877104
+	cancelRequest    = 80877102
+
+	maxMessageSize = 64 * 1024 * 1024
+)
+
+// Backend message types.
+const (
+	msgAuthentication       byte = 'R'
+	msgBackendKeyData       byte = 'K'
+	msgBindComplete         byte = '2'
+	msgCloseComplete        byte = '3'
+	msgCommandComplete      byte = 'C'
+	msgDataRow              byte = 'D'
+	msgEmptyQueryResponse   byte = 'I'
+	msgErrorResponse        byte = 'E'
+	msgNoData               byte = 'n'
+	msgParameterDescription byte = 't'
+	msgParameterStatus      byte = 'S'
+	msgParseComplete        byte = '1'
+	msgReadyForQuery        byte = 'Z'
+	msgRowDescription       byte = 'T'
+)
+
+// Frontend message types.
+const (
+	msgBind      byte = 'B'
+	msgClose     byte = 'C'
+	msgDescribe  byte = 'D'
+	msgExecute   byte = 'E'
+	msgFlush     byte = 'H'
+	msgParse     byte = 'P'
+	msgPassword  byte = 'p'
+	msgQuery     byte = 'Q'
+	msgSync      byte = 'S'
+	msgTerminate byte = 'X'
+)
+
+// Authentication request codes.
+const (
+	authOK                int32 = 0
+	authCleartextPassword int32 = 3
+)
+
+// SQLSTATE codes reported for Tigris errors.
+const (
+	sqlStateInvalidParameterValue = "22023"
+	sqlStateUndefinedTable        = "42P01"
+	sqlStateInvalidCatalogName    = "3D000"
+	sqlStateUniqueViolation       = "23505"
+	sqlStateInvalidPassword       = "28P01"
+	sqlStateInsufficientPrivilege = "42501"
+	sqlStateFeatureNotSupported   = "0A000"
+	sqlStateQueryCanceled         = "57014"
+	sqlStateProtocolViolation     = "08P01"
+	sqlStateInternalError         = "XX000"
+)
+
+// pgError is an error carrying a SQLSTATE code.
+type pgError struct {
+	code string
+	msg  string
+}
+
+func (e *pgError) Error() string {
+	return e.msg
+}
+
+func toPGError(err error) *pgError {
+	if pe, ok := err.(*pgError); ok {
+		return pe
+	}
+
+	te := api.FromStatusError(err)
+	code := sqlStateInternalError
+	switch te.Code {
+	case api.Code_INVALID_ARGUMENT, api.Code_FAILED_PRECONDITION, api.Code_OUT_OF_RANGE:
+		code = sqlStateInvalidParameterValue
+	case api.Code_NOT_FOUND:
+		code = sqlStateUndefinedTable
+	case api.Code_ALREADY_EXISTS, api.Code_CONFLICT:
+		code = sqlStateUniqueViolation
+	case api.Code_UNAUTHENTICATED:
+		code = sqlStateInvalidPassword
+	case api.Code_PERMISSION_DENIED:
+		code = sqlStateInsufficientPrivilege
+	case api.Code_UNIMPLEMENTED:
+		code = sqlStateFeatureNotSupported
+	case api.Code_DEADLINE_EXCEEDED:
+		code = sqlStateQueryCanceled
+	}
+
+	return &pgError{code: code, msg: te.Message}
+}
+
+// conn wraps the client connection with the framing of the PostgreSQL protocol.
+type conn struct {
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// readStartup reads the untyped startup packet.
+func (c *conn) readStartup() (int32, []byte, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+
+	l := int32(binary.BigEndian.Uint32(hdr[:]))
+	if l < 8 || l > maxMessageSize {
+		return 0, nil, fmt.Errorf("invalid startup packet length %d", l)
+	}
+	code := int32(binary.BigEndian.Uint32(hdr[4:]))
+
+	body := make([]byte, l-8)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return code, body, nil
+}
+
+func (c *conn) readMessage() (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+
+	l := int32(binary.BigEndian.Uint32(hdr[1:]))
+	if l < 4 || l > maxMessageSize {
+		return 0, nil, fmt.Errorf("invalid message length %d", l)
+	}
+
+	body := make([]byte, l-4)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return hdr[0], body, nil
+}
+
+func (c *conn) send(typ byte, payload []byte) error {
+	var hdr [5]byte
+	hdr[0] = typ
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)+4))
+	if _, err := c.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := c.w.Write(payload)
+
+	return err
+}
+
+func (c *conn) flush() error {
+	return c.w.Flush()
+}
+
+func (c *conn) sendAuth(code int32) error {
+	return c.send(msgAuthentication, binary.BigEndian.AppendUint32(nil, uint32(code)))
+}
+
+func (c *conn) sendParameterStatus(name string, value string) error {
+	return c.send(msgParameterStatus, appendCString(appendCString(nil, name), value))
+}
+
+func (c *conn) sendReadyForQuery() error {
+	return c.send(msgReadyForQuery, []byte{'I'})
+}
+
+func (c *conn) sendError(severity string, err *pgError) error {
+	var b []byte
+	b = append(b, 'S')
+	b = appendCString(b, severity)
+	b = append(b, 'V')
+	b = appendCString(b, severity)
+	b = append(b, 'C')
+	b = appendCString(b, err.code)
+	b = append(b, 'M')
+	b = appendCString(b, err.msg)
+	b = append(b, 0)
+
+	return c.send(msgErrorResponse, b)
+}
+
+func (c *conn) sendRowDescription(columns []column, formats []int16) error {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(columns)))
+	for i, col := range columns {
+		b = appendCString(b, col.name)
+		// table oid and column attribute number
+		b = binary.BigEndian.AppendUint32(b, 0)
+		b = binary.BigEndian.AppendUint16(b, 0)
+		b = binary.BigEndian.AppendUint32(b, col.oid)
+		b = binary.BigEndian.AppendUint16(b, uint16(typeSize(col.oid)))
+		// type modifier
+		b = binary.BigEndian.AppendUint32(b, ^uint32(0))
+		b = binary.BigEndian.AppendUint16(b, uint16(resultFormat(formats, i)))
+	}
+
+	return c.send(msgRowDescription, b)
+}
+
+func (c *conn) sendDataRow(columns []column, values [][]byte, formats []int16) error {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(values)))
+	for i, v := range values {
+		if v == nil {
+			b = binary.BigEndian.AppendUint32(b, ^uint32(0))
+			continue
+		}
+		if resultFormat(formats, i) == formatBinary {
+			var err error
+			if v, err = textToBinary(columns[i].oid, v); err != nil {
+				return err
+			}
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+
+	return c.send(msgDataRow, b)
+}
+
+// resultFormat returns the format of the i-th column, a single format code applies to all the columns.
+func resultFormat(formats []int16, i int) int16 {
+	switch {
+	case len(formats) == 0:
+		return formatText
+	case len(formats) == 1:
+		return formats[0]
+	case i < len(formats):
+		return formats[i]
+	default:
+		return formatText
+	}
+}
+
+func appendCString(b []byte, s string) []byte {
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+// reader decodes the fields of a frontend message.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) cstring() string {
+	for i, c := range r.b {
+		if c == 0 {
+			s := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return s
+		}
+	}
+	r.fail()
+
+	return ""
+}
+
+func (r *reader) int16() int16 {
+	if len(r.b) < 2 {
+		r.fail()
+		return 0
+	}
+	v := int16(binary.BigEndian.Uint16(r.b))
+	r.b = r.b[2:]
+
+	return v
+}
+
+func (r *reader) int32() int32 {
+	if len(r.b) < 4 {
+		r.fail()
+		return 0
+	}
+	v := int32(binary.BigEndian.Uint32(r.b))
+	r.b = r.b[4:]
+
+	return v
+}
+
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || len(r.b) < n {
+		r.fail()
+		return nil
+	}
+	v := r.b[:n:n]
+	r.b = r.b[n:]
+
+	return v
+}
+
+func (r *reader) fail() {
+	if r.err == nil {
+		r.err = &pgError{code: sqlStateProtocolViolation, msg: "malformed message"}
+	}
+	r.b = nil
+}
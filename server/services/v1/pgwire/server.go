@@ -0,0 +1,66 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"bufio"
+	"net"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"go.uber.org/atomic"
+)
+
+// Server speaks the PostgreSQL wire protocol and executes a subset of SQL against Tigris collections, so that psql
+// and BI tools can query Tigris directly. Statements are translated into calls to the Tigris API and go through
+// the regular server interceptors. With authentication enabled the password of the connection is a Tigris access
+// token.
+type Server struct {
+	client      api.TigrisClient
+	authEnabled bool
+	connID      atomic.Int32
+}
+
+func NewServer(client api.TigrisClient, authEnabled bool) *Server {
+	return &Server{client: client, authEnabled: authEnabled}
+}
+
+// Serve accepts connections on the listener until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.serveConn(c)
+	}
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer func() { _ = c.Close() }()
+
+	sess := &session{
+		conn:       &conn{r: bufio.NewReader(c), w: bufio.NewWriter(c)},
+		client:     s.client,
+		remoteAddr: c.RemoteAddr().String(),
+		statements: make(map[string]*preparedStmt),
+		portals:    make(map[string]*portal),
+	}
+	if !sess.startup(s.authEnabled, s.connID.Inc()) {
+		return
+	}
+
+	sess.serve()
+}
@@ -0,0 +1,688 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/util"
+	"google.golang.org/grpc/metadata"
+)
+
+const serverVersion = "14.0"
+
+// result is the outcome of a statement, rows are in the text format.
+type result struct {
+	columns []column
+	rows    [][][]byte
+	tag     string
+}
+
+type preparedStmt struct {
+	sql       string
+	stmt      Statement
+	paramOIDs []uint32
+}
+
+type portal struct {
+	stmt    Statement
+	formats []int16
+	// res is populated when the portal is described before it is executed
+	res *result
+}
+
+// session is the state of a client connection. The PostgreSQL database of the connection is the Tigris project
+// and tables are collections.
+type session struct {
+	*conn
+
+	client     api.TigrisClient
+	remoteAddr string
+	user       string
+	db         string
+	token      string
+	statements map[string]*preparedStmt
+	portals    map[string]*portal
+}
+
+func (s *session) context() context.Context {
+	// the requests are made through the in-process client, the network allowlists check the address of the connection
+	ctx := metadata.AppendToOutgoingContext(context.Background(), api.HeaderClientAddr, s.remoteAddr)
+	if len(s.token) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "bearer "+s.token)
+	}
+
+	return ctx
+}
+
+// startup runs the startup and authentication phase, it returns false if the connection must be closed.
+func (s *session) startup(authEnabled bool, connID int32) bool {
+	for {
+		code, body, err := s.readStartup()
+		if err != nil {
+			return false
+		}
+
+		switch code {
+		case sslRequestCode, gssRequestCode:
+			// encryption is not supported, the client continues in plain text
+			if _, err = s.w.Write([]byte{'N'}); err != nil || s.flush() != nil {
+				return false
+			}
+			continue
+		case cancelRequest:
+			return false
+		case protocolVersion3:
+		default:
+			_ = s.sendError("FATAL", &pgError{code: sqlStateProtocolViolation, msg: fmt.Sprintf("unsupported frontend protocol %d", code)})
+			_ = s.flush()
+			return false
+		}
+
+		r := &reader{b: body}
+		for len(r.b) > 1 {
+			key, value := r.cstring(), r.cstring()
+			switch key {
+			case "user":
+				s.user = value
+			case "database":
+				s.db = value
+			}
+		}
+		if len(s.db) == 0 {
+			s.db = s.user
+		}
+		break
+	}
+
+	if authEnabled {
+		if s.sendAuth(authCleartextPassword) != nil || s.flush() != nil {
+			return false
+		}
+		typ, body, err := s.readMessage()
+		if err != nil || typ != msgPassword {
+			return false
+		}
+		// the password is a Tigris access token
+		s.token = (&reader{b: body}).cstring()
+	}
+
+	// validates both the credentials and the database
+	if _, err := s.client.ListCollections(s.context(), &api.ListCollectionsRequest{Project: s.db}); err != nil {
+		pe := toPGError(err)
+		if pe.code == sqlStateUndefinedTable {
+			pe = &pgError{code: sqlStateInvalidCatalogName, msg: fmt.Sprintf("database \"%s\" does not exist", s.db)}
+		}
+		_ = s.sendError("FATAL", pe)
+		_ = s.flush()
+		return false
+	}
+
+	if s.sendAuth(authOK) != nil {
+		return false
+	}
+	for _, p := range [][2]string{
+		{"server_version", serverVersion},
+		{"server_encoding", "UTF8"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO, MDY"},
+		{"TimeZone", "UTC"},
+		{"integer_datetimes", "on"},
+		{"standard_conforming_strings", "on"},
+	} {
+		if s.sendParameterStatus(p[0], p[1]) != nil {
+			return false
+		}
+	}
+
+	// cancel requests are not supported, the secret key is never checked
+	key := binary.BigEndian.AppendUint32(nil, uint32(connID))
+	key = binary.BigEndian.AppendUint32(key, 0)
+	if s.send(msgBackendKeyData, key) != nil || s.sendReadyForQuery() != nil {
+		return false
+	}
+
+	return s.flush() == nil
+}
+
+// serve processes messages until the client terminates or the connection fails.
+func (s *session) serve() {
+	// after an error in the extended query protocol all the messages up to the next Sync are ignored
+	skipUntilSync := false
+
+	for {
+		typ, body, err := s.readMessage()
+		if err != nil {
+			return
+		}
+
+		if skipUntilSync && typ != msgSync {
+			continue
+		}
+
+		switch typ {
+		case msgQuery:
+			err = s.simpleQuery(&reader{b: body})
+		case msgParse:
+			err = s.parse(&reader{b: body})
+		case msgBind:
+			err = s.bind(&reader{b: body})
+		case msgDescribe:
+			err = s.describe(&reader{b: body})
+		case msgExecute:
+			err = s.execute(&reader{b: body})
+		case msgClose:
+			err = s.close(&reader{b: body})
+		case msgSync:
+			skipUntilSync = false
+			err = s.sendReadyForQuery()
+			if err == nil {
+				err = s.flush()
+			}
+		case msgFlush:
+			err = s.flush()
+		case msgTerminate:
+			return
+		default:
+			err = &pgError{code: sqlStateProtocolViolation, msg: fmt.Sprintf("unsupported message type '%c'", typ)}
+		}
+
+		if err != nil {
+			if sendErr := s.sendError("ERROR", toPGError(err)); sendErr != nil {
+				return
+			}
+			if typ == msgQuery {
+				if s.sendReadyForQuery() != nil {
+					return
+				}
+			} else {
+				skipUntilSync = true
+			}
+			if s.flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *session) simpleQuery(r *reader) error {
+	sql := r.cstring()
+	if r.err != nil {
+		return r.err
+	}
+
+	stmts, err := ParseAll(sql)
+	if err != nil {
+		return err
+	}
+	if len(stmts) == 0 {
+		if err = s.send(msgEmptyQueryResponse, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range stmts {
+		res, err := s.run(stmt)
+		if err != nil {
+			return err
+		}
+		if res.columns != nil {
+			if err = s.sendRowDescription(res.columns, nil); err != nil {
+				return err
+			}
+		}
+		if err = s.sendResult(res, nil); err != nil {
+			return err
+		}
+	}
+
+	if err = s.sendReadyForQuery(); err != nil {
+		return err
+	}
+
+	return s.flush()
+}
+
+func (s *session) parse(r *reader) error {
+	name, sql := r.cstring(), r.cstring()
+	n := int(r.int16())
+	oids := make([]uint32, 0, n)
+	for i := 0; i < n; i++ {
+		oids = append(oids, uint32(r.int32()))
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	stmt, params, err := Parse(sql, nil)
+	if err != nil {
+		return err
+	}
+	for len(oids) < params {
+		oids = append(oids, oidUnknown)
+	}
+	s.statements[name] = &preparedStmt{sql: sql, stmt: stmt, paramOIDs: oids}
+
+	return s.send(msgParseComplete, nil)
+}
+
+func (s *session) bind(r *reader) error {
+	portalName, stmtName := r.cstring(), r.cstring()
+
+	nFormats := int(r.int16())
+	paramFormats := make([]int16, 0, nFormats)
+	for i := 0; i < nFormats; i++ {
+		paramFormats = append(paramFormats, r.int16())
+	}
+
+	ps, ok := s.statements[stmtName]
+	if !ok {
+		return &pgError{code: "26000", msg: fmt.Sprintf("prepared statement \"%s\" does not exist", stmtName)}
+	}
+
+	nParams := int(r.int16())
+	params := make([]any, 0, nParams)
+	for i := 0; i < nParams; i++ {
+		l := r.int32()
+		var data []byte
+		if l >= 0 {
+			data = r.bytes(int(l))
+		}
+
+		var oid uint32
+		if i < len(ps.paramOIDs) {
+			oid = ps.paramOIDs[i]
+		}
+		v, err := decodeParam(oid, resultFormat(paramFormats, i), data)
+		if err != nil {
+			return err
+		}
+		params = append(params, v)
+	}
+
+	nResult := int(r.int16())
+	formats := make([]int16, 0, nResult)
+	for i := 0; i < nResult; i++ {
+		formats = append(formats, r.int16())
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	stmt, _, err := Parse(ps.sql, params)
+	if err != nil {
+		return err
+	}
+	s.portals[portalName] = &portal{stmt: stmt, formats: formats}
+
+	return s.send(msgBindComplete, nil)
+}
+
+func (s *session) describe(r *reader) error {
+	kind, name := r.bytes(1), r.cstring()
+	if r.err != nil {
+		return r.err
+	}
+
+	if kind[0] == 'S' {
+		ps, ok := s.statements[name]
+		if !ok {
+			return &pgError{code: "26000", msg: fmt.Sprintf("prepared statement \"%s\" does not exist", name)}
+		}
+
+		b := binary.BigEndian.AppendUint16(nil, uint16(len(ps.paramOIDs)))
+		for _, oid := range ps.paramOIDs {
+			if oid == oidUnknown {
+				oid = oidText
+			}
+			b = binary.BigEndian.AppendUint32(b, oid)
+		}
+		if err := s.send(msgParameterDescription, b); err != nil {
+			return err
+		}
+
+		columns, err := s.describeColumns(ps.stmt)
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			return s.send(msgNoData, nil)
+		}
+		return s.sendRowDescription(columns, nil)
+	}
+
+	p, ok := s.portals[name]
+	if !ok {
+		return &pgError{code: "34000", msg: fmt.Sprintf("portal \"%s\" does not exist", name)}
+	}
+	if _, ok := p.stmt.(*SelectStmt); !ok {
+		return s.send(msgNoData, nil)
+	}
+
+	// the result is kept for the following Execute so that the columns match the described ones
+	res, err := s.run(p.stmt)
+	if err != nil {
+		return err
+	}
+	p.res = res
+
+	return s.sendRowDescription(res.columns, p.formats)
+}
+
+func (s *session) execute(r *reader) error {
+	name := r.cstring()
+	_ = r.int32()
+	if r.err != nil {
+		return r.err
+	}
+
+	p, ok := s.portals[name]
+	if !ok {
+		return &pgError{code: "34000", msg: fmt.Sprintf("portal \"%s\" does not exist", name)}
+	}
+
+	res := p.res
+	if res == nil {
+		var err error
+		if res, err = s.run(p.stmt); err != nil {
+			return err
+		}
+	}
+	// portals can't be resumed, all the rows are returned by the first Execute
+	p.res = &result{columns: res.columns, tag: res.tag}
+
+	return s.sendResult(res, p.formats)
+}
+
+func (s *session) close(r *reader) error {
+	kind, name := r.bytes(1), r.cstring()
+	if r.err != nil {
+		return r.err
+	}
+
+	if kind[0] == 'S' {
+		delete(s.statements, name)
+	} else {
+		delete(s.portals, name)
+	}
+
+	return s.send(msgCloseComplete, nil)
+}
+
+func (s *session) sendResult(res *result, formats []int16) error {
+	if len(res.tag) == 0 {
+		return s.send(msgEmptyQueryResponse, nil)
+	}
+
+	for _, row := range res.rows {
+		if err := s.sendDataRow(res.columns, row, formats); err != nil {
+			return err
+		}
+	}
+
+	return s.send(msgCommandComplete, appendCString(nil, res.tag))
+}
+
+// describeColumns returns the result columns of a statement without executing it, nil if it doesn't return rows.
+func (s *session) describeColumns(stmt Statement) ([]column, error) {
+	sel, ok := stmt.(*SelectStmt)
+	if !ok {
+		return nil, nil
+	}
+
+	return s.selectColumns(s.context(), sel)
+}
+
+func (s *session) run(stmt Statement) (*result, error) {
+	ctx := s.context()
+
+	switch st := stmt.(type) {
+	case *SelectStmt:
+		return s.runSelect(ctx, st)
+	case *InsertStmt:
+		return s.runInsert(ctx, st)
+	case *UpdateStmt:
+		return s.runUpdate(ctx, st)
+	case *DeleteStmt:
+		return s.runDelete(ctx, st)
+	case *SetStmt:
+		return &result{tag: st.Tag()}, nil
+	default:
+		return &result{}, nil
+	}
+}
+
+func (s *session) schema(ctx context.Context, table string) (collectionSchema, error) {
+	resp, err := s.client.DescribeCollection(ctx, &api.DescribeCollectionRequest{Project: s.db, Collection: table})
+	if err != nil {
+		if toPGError(err).code == sqlStateUndefinedTable {
+			return collectionSchema{}, &pgError{code: sqlStateUndefinedTable, msg: fmt.Sprintf("relation \"%s\" does not exist", table)}
+		}
+		return collectionSchema{}, err
+	}
+
+	return collectionSchema{raw: resp.Schema}, nil
+}
+
+func (s *session) selectColumns(ctx context.Context, st *SelectStmt) ([]column, error) {
+	if len(st.From) == 0 {
+		columns := make([]column, 0, len(st.Items))
+		for _, item := range st.Items {
+			oid := oidText
+			if len(item.Func) == 0 {
+				oid = literalOID(item.Value)
+			}
+			columns = append(columns, column{name: item.Name(), oid: oid})
+		}
+		return columns, nil
+	}
+
+	if st.Count {
+		return []column{{name: st.Items[0].Name(), oid: oidInt8}}, nil
+	}
+
+	sch, err := s.schema(ctx, st.From)
+	if err != nil {
+		return nil, err
+	}
+	if st.Star {
+		return sch.topLevelColumns()
+	}
+
+	columns := make([]column, 0, len(st.Items))
+	for _, item := range st.Items {
+		col, ok := sch.lookup(item.Field)
+		if !ok {
+			return nil, &pgError{code: "42703", msg: fmt.Sprintf("column \"%s\" does not exist", item.Field)}
+		}
+		col.name = item.Name()
+		columns = append(columns, col)
+	}
+
+	return columns, nil
+}
+
+func (s *session) runSelect(ctx context.Context, st *SelectStmt) (*result, error) {
+	columns, err := s.selectColumns(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(st.From) == 0 {
+		row := make([][]byte, 0, len(st.Items))
+		for _, item := range st.Items {
+			switch item.Func {
+			case "version":
+				row = append(row, []byte(fmt.Sprintf("PostgreSQL %s (Tigris %s)", serverVersion, util.Version)))
+			case "current_database":
+				row = append(row, []byte(s.db))
+			case "current_schema":
+				row = append(row, []byte("public"))
+			case "current_user":
+				row = append(row, []byte(s.user))
+			default:
+				row = append(row, literalText(item.Value))
+			}
+		}
+		return &result{columns: columns, rows: [][][]byte{row}, tag: "SELECT 1"}, nil
+	}
+
+	filter, err := TranslateWhere(st.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.Count {
+		resp, err := s.client.Count(ctx, &api.CountRequest{Project: s.db, Collection: st.From, Filter: filter})
+		if err != nil {
+			return nil, err
+		}
+		row := [][]byte{literalText(resp.Count)}
+		return &result{columns: columns, rows: [][][]byte{row}, tag: "SELECT 1"}, nil
+	}
+
+	req := &api.ReadRequest{
+		Project:    s.db,
+		Collection: st.From,
+		Filter:     filter,
+		Options:    &api.ReadRequestOptions{Limit: st.Limit, Skip: st.Offset},
+	}
+	if req.Sort, err = TranslateOrderBy(st.OrderBy); err != nil {
+		return nil, err
+	}
+	if !st.Star {
+		fields := make(map[string]bool, len(columns))
+		for _, item := range st.Items {
+			fields[item.Field] = true
+		}
+		if req.Fields, err = jsoniter.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := s.client.Read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{columns: columns}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([][]byte, 0, len(columns))
+		for _, col := range columns {
+			row = append(row, fieldText(resp.Data, col))
+		}
+		res.rows = append(res.rows, row)
+	}
+	res.tag = fmt.Sprintf("SELECT %d", len(res.rows))
+
+	return res, nil
+}
+
+func (s *session) runInsert(ctx context.Context, st *InsertStmt) (*result, error) {
+	columns := st.Columns
+	if len(columns) == 0 {
+		sch, err := s.schema(ctx, st.Table)
+		if err != nil {
+			return nil, err
+		}
+		all, err := sch.topLevelColumns()
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range all {
+			columns = append(columns, col.name)
+		}
+	}
+
+	req := &api.InsertRequest{Project: s.db, Collection: st.Table}
+	for _, row := range st.Rows {
+		if len(row) > len(columns) {
+			return nil, errors.InvalidArgument("INSERT has more expressions than target columns")
+		}
+
+		doc := make(map[string]any, len(row))
+		for i, v := range row {
+			if err := validateValue(v); err != nil {
+				return nil, err
+			}
+			if strings.Contains(columns[i], ".") {
+				return nil, errors.InvalidArgument("column '%s': nested fields can't be inserted individually", columns[i])
+			}
+			// missing and null fields are the same for Tigris
+			if v != nil {
+				doc[columns[i]] = v
+			}
+		}
+
+		js, err := jsoniter.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		req.Documents = append(req.Documents, js)
+	}
+
+	if _, err := s.client.Insert(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return &result{tag: fmt.Sprintf("INSERT 0 %d", len(st.Rows))}, nil
+}
+
+func (s *session) runUpdate(ctx context.Context, st *UpdateStmt) (*result, error) {
+	fields, err := TranslateSet(st.Set)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := TranslateWhere(st.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Update(ctx, &api.UpdateRequest{Project: s.db, Collection: st.Table, Fields: fields, Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{tag: fmt.Sprintf("UPDATE %d", resp.ModifiedCount)}, nil
+}
+
+func (s *session) runDelete(ctx context.Context, st *DeleteStmt) (*result, error) {
+	filter, err := TranslateWhere(st.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Delete(ctx, &api.DeleteRequest{Project: s.db, Collection: st.Table, Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{tag: fmt.Sprintf("DELETE %d", resp.DeletedCount)}, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const testSchema = `{"title":"users","properties":{"id":{"type":"integer"},"name":{"type":"string"},"score":{"type":"number"},"created":{"type":"string","format":"date-time"},"address":{"type":"object","properties":{"city":{"type":"string"}}}},"primary_key":["id"]}`
+
+type readStream struct {
+	grpc.ClientStream
+
+	docs []string
+}
+
+func (r *readStream) Recv() (*api.ReadResponse, error) {
+	if len(r.docs) == 0 {
+		return nil, io.EOF
+	}
+	resp := &api.ReadResponse{Data: []byte(r.docs[0])}
+	r.docs = r.docs[1:]
+
+	return resp, nil
+}
+
+type fakeClient struct {
+	api.TigrisClient
+
+	docs     []string
+	read     *api.ReadRequest
+	inserted *api.InsertRequest
+}
+
+func (*fakeClient) ListCollections(_ context.Context, in *api.ListCollectionsRequest, _ ...grpc.CallOption) (*api.ListCollectionsResponse, error) {
+	if in.Project != "app" {
+		return nil, api.Errorf(api.Code_NOT_FOUND, "project doesn't exist")
+	}
+
+	return &api.ListCollectionsResponse{}, nil
+}
+
+func (*fakeClient) DescribeCollection(_ context.Context, in *api.DescribeCollectionRequest, _ ...grpc.CallOption) (*api.DescribeCollectionResponse, error) {
+	if in.Collection != "users" {
+		return nil, api.Errorf(api.Code_NOT_FOUND, "collection doesn't exist")
+	}
+
+	return &api.DescribeCollectionResponse{Collection: in.Collection, Schema: []byte(testSchema)}, nil
+}
+
+func (f *fakeClient) Read(_ context.Context, in *api.ReadRequest, _ ...grpc.CallOption) (api.Tigris_ReadClient, error) {
+	f.read = in
+	return &readStream{docs: f.docs}, nil
+}
+
+func (f *fakeClient) Insert(_ context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*api.InsertResponse, error) {
+	f.inserted = in
+	return &api.InsertResponse{}, nil
+}
+
+type testClient struct {
+	t *testing.T
+	c *conn
+}
+
+type backendMsg struct {
+	typ  byte
+	body []byte
+}
+
+func connect(t *testing.T, client api.TigrisClient, db string) (*testClient, []backendMsg) {
+	server, c := net.Pipe()
+	go NewServer(client, false).serveConn(server)
+	t.Cleanup(func() { _ = c.Close() })
+
+	tc := &testClient{t: t, c: &conn{r: bufio.NewReader(c), w: bufio.NewWriter(c)}}
+
+	// the SSL request is refused with a single byte
+	_, err := tc.c.w.Write([]byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f})
+	require.NoError(t, err)
+	require.NoError(t, tc.c.flush())
+	b, err := tc.c.r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte('N'), b)
+
+	params := appendCString(appendCString(nil, "user"), "tester")
+	params = appendCString(appendCString(params, "database"), db)
+	params = append(params, 0)
+	startup := binary.BigEndian.AppendUint32(nil, uint32(len(params)+8))
+	startup = binary.BigEndian.AppendUint32(startup, protocolVersion3)
+	_, err = tc.c.w.Write(append(startup, params...))
+	require.NoError(t, err)
+	require.NoError(t, tc.c.flush())
+
+	return tc, tc.readUntilReady()
+}
+
+func (tc *testClient) send(typ byte, body []byte) {
+	require.NoError(tc.t, tc.c.send(typ, body))
+	require.NoError(tc.t, tc.c.flush())
+}
+
+func (tc *testClient) readUntilReady() []backendMsg {
+	var msgs []backendMsg
+	for {
+		typ, body, err := tc.c.readMessage()
+		if err != nil {
+			return msgs
+		}
+		msgs = append(msgs, backendMsg{typ: typ, body: body})
+		if typ == msgReadyForQuery {
+			return msgs
+		}
+	}
+}
+
+func msgTypes(msgs []backendMsg) string {
+	var s []byte
+	for _, m := range msgs {
+		s = append(s, m.typ)
+	}
+
+	return string(s)
+}
+
+// dataRow decodes the columns of a DataRow message.
+func dataRow(body []byte) [][]byte {
+	r := &reader{b: body}
+	n := int(r.int16())
+	values := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		l := r.int32()
+		if l < 0 {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, r.bytes(int(l)))
+	}
+
+	return values
+}
+
+func TestStartup(t *testing.T) {
+	_, msgs := connect(t, &fakeClient{}, "app")
+	require.Equal(t, "RSSSSSSSKZ", msgTypes(msgs))
+
+	_, msgs = connect(t, &fakeClient{}, "missing")
+	require.Equal(t, "E", msgTypes(msgs))
+	require.Contains(t, string(msgs[0].body), sqlStateInvalidCatalogName)
+	require.Contains(t, string(msgs[0].body), `database "missing" does not exist`)
+}
+
+func TestClientAddrForwarded(t *testing.T) {
+	sess := &session{remoteAddr: "10.0.0.1:5000", token: "t1"}
+
+	// the network allowlists of the in-process requests check the address of the connection
+	md, _ := metadata.FromOutgoingContext(sess.context())
+	require.Equal(t, []string{"10.0.0.1:5000"}, md.Get(api.HeaderClientAddr))
+	require.Equal(t, []string{"bearer t1"}, md.Get(api.HeaderAuthorization))
+}
+
+func TestSimpleQuery(t *testing.T) {
+	client := &fakeClient{docs: []string{
+		`{"id":1,"name":"a","score":1.5,"created":"2023-04-01T10:00:00Z","address":{"city":"NY"}}`,
+		`{"id":2,"name":"b"}`,
+	}}
+	tc, _ := connect(t, client, "app")
+
+	tc.send(msgQuery, appendCString(nil, `SELECT id, name, created, address.city AS city FROM users WHERE score > 1 ORDER BY id LIMIT 5`))
+	msgs := tc.readUntilReady()
+	require.Equal(t, "TDDCZ", msgTypes(msgs))
+	require.JSONEq(t, `{"score":{"$gt":1}}`, string(client.read.Filter))
+	require.JSONEq(t, `[{"id":"$asc"}]`, string(client.read.Sort))
+	require.JSONEq(t, `{"id":true,"name":true,"created":true,"address.city":true}`, string(client.read.Fields))
+	require.Equal(t, int64(5), client.read.Options.Limit)
+
+	require.Equal(t, [][]byte{[]byte("1"), []byte("a"), []byte("2023-04-01 10:00:00+00"), []byte("NY")}, dataRow(msgs[1].body))
+	require.Equal(t, [][]byte{[]byte("2"), []byte("b"), nil, nil}, dataRow(msgs[2].body))
+	require.Equal(t, "SELECT 2\x00", string(msgs[3].body))
+
+	tc.send(msgQuery, appendCString(nil, `SELECT * FROM missing`))
+	msgs = tc.readUntilReady()
+	require.Equal(t, "EZ", msgTypes(msgs))
+	require.Contains(t, string(msgs[0].body), `relation "missing" does not exist`)
+
+	tc.send(msgQuery, appendCString(nil, `INSERT INTO users VALUES (3, 'c', 2.5)`))
+	msgs = tc.readUntilReady()
+	require.Equal(t, "CZ", msgTypes(msgs))
+	require.Equal(t, "INSERT 0 1\x00", string(msgs[0].body))
+	require.JSONEq(t, `{"id":3,"name":"c","score":2.5}`, string(client.inserted.Documents[0]))
+
+	tc.send(msgQuery, appendCString(nil, ``))
+	require.Equal(t, "IZ", msgTypes(tc.readUntilReady()))
+}
+
+func TestExtendedQuery(t *testing.T) {
+	client := &fakeClient{docs: []string{`{"id":7,"name":"x","score":0.25}`}}
+	tc, _ := connect(t, client, "app")
+
+	parse := appendCString(nil, "s1")
+	parse = appendCString(parse, `SELECT id, score FROM users WHERE id = $1`)
+	parse = binary.BigEndian.AppendUint16(parse, 1)
+	parse = binary.BigEndian.AppendUint32(parse, oidInt8)
+	require.NoError(t, tc.c.send(msgParse, parse))
+
+	bind := appendCString(nil, "")
+	bind = appendCString(bind, "s1")
+	// one binary parameter
+	bind = binary.BigEndian.AppendUint16(bind, 1)
+	bind = binary.BigEndian.AppendUint16(bind, uint16(formatBinary))
+	bind = binary.BigEndian.AppendUint16(bind, 1)
+	bind = binary.BigEndian.AppendUint32(bind, 8)
+	bind = binary.BigEndian.AppendUint64(bind, 7)
+	// binary results
+	bind = binary.BigEndian.AppendUint16(bind, 1)
+	bind = binary.BigEndian.AppendUint16(bind, uint16(formatBinary))
+	require.NoError(t, tc.c.send(msgBind, bind))
+
+	require.NoError(t, tc.c.send(msgDescribe, appendCString([]byte{'P'}, "")))
+	require.NoError(t, tc.c.send(msgExecute, binary.BigEndian.AppendUint32(appendCString(nil, ""), 0)))
+	tc.send(msgSync, nil)
+
+	msgs := tc.readUntilReady()
+	require.Equal(t, "12TDCZ", msgTypes(msgs))
+	require.JSONEq(t, `{"id":7}`, string(client.read.Filter))
+
+	row := dataRow(msgs[3].body)
+	require.Equal(t, uint64(7), binary.BigEndian.Uint64(row[0]))
+	require.Equal(t, 0.25, math.Float64frombits(binary.BigEndian.Uint64(row[1])))
+
+	// errors skip the remaining messages up to Sync
+	bind = appendCString(nil, "")
+	bind = appendCString(bind, "unknown")
+	bind = append(bind, 0, 0, 0, 0, 0, 0)
+	require.NoError(t, tc.c.send(msgBind, bind))
+	require.NoError(t, tc.c.send(msgExecute, binary.BigEndian.AppendUint32(appendCString(nil, ""), 0)))
+	tc.send(msgSync, nil)
+	require.Equal(t, "EZ", msgTypes(tc.readUntilReady()))
+}
@@ -0,0 +1,919 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tigrisdata/tigris/errors"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits a SQL statement into tokens. Unquoted identifiers keep their case, because Tigris field names are
+// case-sensitive; keywords are matched case-insensitively by the parser.
+func lex(sql string) ([]token, error) {
+	var tokens []token
+	rs := []rune(sql)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(rs[start:i])})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.' || rs[i] == 'e' || rs[i] == 'E' ||
+				((rs[i] == '+' || rs[i] == '-') && (rs[i-1] == 'e' || rs[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(rs[start:i])})
+		case r == '\'' || r == '"':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(rs) {
+					return nil, errors.InvalidArgument("unterminated quoted string")
+				}
+				if rs[i] == r {
+					// a doubled quote is an escaped quote
+					if i+1 < len(rs) && rs[i+1] == r {
+						sb.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(rs[i])
+				i++
+			}
+			kind := tokString
+			if r == '"' {
+				kind = tokQuotedIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String()})
+		case r == '$' && i+1 < len(rs) && unicode.IsDigit(rs[i+1]):
+			start := i + 1
+			i++
+			for i < len(rs) && unicode.IsDigit(rs[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokParam, text: string(rs[start:i])})
+		default:
+			if i+1 < len(rs) {
+				switch two := string(rs[i : i+2]); two {
+				case "<>", "!=", "<=", ">=":
+					tokens = append(tokens, token{kind: tokSymbol, text: two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("(),*=<>;.-+", r) {
+				return nil, errors.InvalidArgument("syntax error at or near '%c'", r)
+			}
+			tokens = append(tokens, token{kind: tokSymbol, text: string(r)})
+			i++
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// Statement is a parsed SQL statement.
+type Statement interface {
+	// Tag is the command tag reported back to the client.
+	Tag() string
+}
+
+// SelectItem is a single entry of the SELECT list, either a document field, a constant or a function call.
+type SelectItem struct {
+	Field string
+	Func  string
+	Value any
+	Alias string
+}
+
+// Name is the column name of the item in the result.
+func (s SelectItem) Name() string {
+	switch {
+	case len(s.Alias) > 0:
+		return s.Alias
+	case len(s.Field) > 0:
+		return s.Field
+	case len(s.Func) > 0:
+		return s.Func
+	default:
+		return "?column?"
+	}
+}
+
+type OrderItem struct {
+	Field string
+	Desc  bool
+}
+
+type SelectStmt struct {
+	Items   []SelectItem
+	Star    bool
+	Count   bool
+	From    string
+	Where   Expr
+	OrderBy []OrderItem
+	Limit   int64
+	Offset  int64
+}
+
+func (*SelectStmt) Tag() string { return "SELECT" }
+
+type InsertStmt struct {
+	Table   string
+	Columns []string
+	Rows    [][]any
+}
+
+func (*InsertStmt) Tag() string { return "INSERT" }
+
+type Assignment struct {
+	Column string
+	Value  any
+}
+
+type UpdateStmt struct {
+	Table string
+	Set   []Assignment
+	Where Expr
+}
+
+func (*UpdateStmt) Tag() string { return "UPDATE" }
+
+type DeleteStmt struct {
+	Table string
+	Where Expr
+}
+
+func (*DeleteStmt) Tag() string { return "DELETE" }
+
+// SetStmt is accepted and ignored, drivers and tools issue it to configure session parameters.
+type SetStmt struct{}
+
+func (*SetStmt) Tag() string { return "SET" }
+
+// EmptyStmt is a query string without any statement.
+type EmptyStmt struct{}
+
+func (*EmptyStmt) Tag() string { return "" }
+
+// Expr is a node of a WHERE clause.
+type Expr any
+
+// LogicalExpr combines two expressions with AND or OR.
+type LogicalExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// ComparisonExpr compares a field against a constant.
+type ComparisonExpr struct {
+	Field string
+	Op    string
+	Value any
+}
+
+type InExpr struct {
+	Field  string
+	Values []any
+	Not    bool
+}
+
+type LikeExpr struct {
+	Field           string
+	Pattern         string
+	Not             bool
+	CaseInsensitive bool
+}
+
+type NullExpr struct {
+	Field string
+	Not   bool
+}
+
+// Param is a placeholder of a prepared statement which hasn't been bound yet.
+type Param int
+
+type parser struct {
+	tokens []token
+	pos    int
+	// params are the bound values of the placeholders, nil while only preparing the statement
+	params []any
+	// maxParam is the highest placeholder number seen
+	maxParam int
+}
+
+// Parse parses a single SQL statement of a prepared statement. Placeholders are replaced with params, when params
+// is nil they are kept as Param values so that a statement can be prepared before its parameters are known. The
+// number of placeholders is returned along with the statement.
+func Parse(sql string, params []any) (Statement, int, error) {
+	tokens, err := lex(sql)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p := &parser{tokens: tokens, params: params}
+	stmts, err := p.statements()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch len(stmts) {
+	case 0:
+		return &EmptyStmt{}, 0, nil
+	case 1:
+		return stmts[0], p.maxParam, nil
+	default:
+		return nil, 0, errors.InvalidArgument("cannot insert multiple commands into a prepared statement")
+	}
+}
+
+// ParseAll parses the semicolon separated statements of a simple query, placeholders are not allowed.
+func ParseAll(sql string) ([]Statement, error) {
+	tokens, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, params: []any{}}
+	return p.statements()
+}
+
+func (p *parser) statements() ([]Statement, error) {
+	var stmts []Statement
+	for {
+		for p.acceptSymbol(";") {
+		}
+		if p.peek().kind == tokEOF {
+			return stmts, nil
+		}
+
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+
+		if !p.acceptSymbol(";") && p.peek().kind != tokEOF {
+			return nil, p.syntaxError()
+		}
+	}
+}
+
+func (p *parser) statement() (Statement, error) {
+	switch {
+	case p.acceptKeyword("SELECT"):
+		return p.selectStmt()
+	case p.acceptKeyword("INSERT"):
+		return p.insertStmt()
+	case p.acceptKeyword("UPDATE"):
+		return p.updateStmt()
+	case p.acceptKeyword("DELETE"):
+		return p.deleteStmt()
+	case p.acceptKeyword("SET"):
+		// the value of the parameter is irrelevant
+		for t := p.peek(); t.kind != tokEOF && !(t.kind == tokSymbol && t.text == ";"); t = p.peek() {
+			p.pos++
+		}
+		return &SetStmt{}, nil
+	case p.isKeyword("BEGIN") || p.isKeyword("START") || p.isKeyword("COMMIT") || p.isKeyword("ROLLBACK"):
+		return nil, errors.Unimplemented("transactions are not supported, every statement is executed on its own")
+	default:
+		return nil, errors.Unimplemented("statement '%s' is not supported", p.peek().text)
+	}
+}
+
+func (p *parser) selectStmt() (Statement, error) {
+	stmt := &SelectStmt{}
+
+	if p.acceptSymbol("*") {
+		stmt.Star = true
+	} else {
+		for {
+			item, err := p.selectItem()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Items = append(stmt.Items, item)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if !p.acceptKeyword("FROM") {
+		if stmt.Star {
+			return nil, errors.InvalidArgument("SELECT * with no tables specified is not valid")
+		}
+		for _, item := range stmt.Items {
+			if len(item.Field) > 0 {
+				return nil, errors.InvalidArgument("column '%s' does not exist", item.Field)
+			}
+		}
+		return stmt, nil
+	}
+
+	var err error
+	if stmt.From, err = p.tableName(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range stmt.Items {
+		if item.Func == "count" {
+			if len(stmt.Items) > 1 {
+				return nil, errors.InvalidArgument("count(*) can't be combined with other columns")
+			}
+			stmt.Count = true
+		} else if len(item.Field) == 0 {
+			return nil, errors.InvalidArgument("only fields can be selected from a table")
+		}
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if stmt.Where, err = p.orExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if !p.acceptKeyword("BY") {
+			return nil, p.syntaxError()
+		}
+		for {
+			field, err := p.fieldName()
+			if err != nil {
+				return nil, err
+			}
+			item := OrderItem{Field: field}
+			if p.acceptKeyword("DESC") {
+				item.Desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			stmt.OrderBy = append(stmt.OrderBy, item)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	for {
+		switch {
+		case p.acceptKeyword("LIMIT"):
+			if p.acceptKeyword("ALL") {
+				continue
+			}
+			if stmt.Limit, err = p.nonNegativeInt("LIMIT"); err != nil {
+				return nil, err
+			}
+		case p.acceptKeyword("OFFSET"):
+			if stmt.Offset, err = p.nonNegativeInt("OFFSET"); err != nil {
+				return nil, err
+			}
+		default:
+			return stmt, nil
+		}
+	}
+}
+
+func (p *parser) selectItem() (SelectItem, error) {
+	var item SelectItem
+
+	t := p.peek()
+	switch {
+	case t.kind == tokIdent && p.peekAt(1).kind == tokSymbol && p.peekAt(1).text == "(":
+		p.pos += 2
+		item.Func = strings.ToLower(t.text)
+		switch item.Func {
+		case "count":
+			if !p.acceptSymbol("*") {
+				return item, errors.InvalidArgument("only count(*) is supported")
+			}
+		case "version", "current_database", "current_schema", "current_user":
+		default:
+			return item, errors.Unimplemented("function '%s' is not supported", t.text)
+		}
+		if !p.acceptSymbol(")") {
+			return item, p.syntaxError()
+		}
+	case t.kind == tokQuotedIdent || (t.kind == tokIdent && !isReserved(t.text)):
+		field, err := p.fieldName()
+		if err != nil {
+			return item, err
+		}
+		item.Field = field
+	default:
+		v, err := p.literal()
+		if err != nil {
+			return item, err
+		}
+		item.Value = v
+	}
+
+	if p.acceptKeyword("AS") {
+		alias, err := p.identifier()
+		if err != nil {
+			return item, err
+		}
+		item.Alias = alias
+	}
+
+	return item, nil
+}
+
+func (p *parser) insertStmt() (Statement, error) {
+	if !p.acceptKeyword("INTO") {
+		return nil, p.syntaxError()
+	}
+
+	stmt := &InsertStmt{}
+	var err error
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+
+	if p.acceptSymbol("(") {
+		for {
+			col, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Columns = append(stmt.Columns, col)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if !p.acceptSymbol(")") {
+			return nil, p.syntaxError()
+		}
+	}
+
+	if !p.acceptKeyword("VALUES") {
+		return nil, p.syntaxError()
+	}
+	for {
+		values, err := p.literalList()
+		if err != nil {
+			return nil, err
+		}
+		if len(stmt.Columns) > 0 && len(values) != len(stmt.Columns) {
+			return nil, errors.InvalidArgument("INSERT has %d target columns but %d expressions", len(stmt.Columns), len(values))
+		}
+		stmt.Rows = append(stmt.Rows, values)
+		if !p.acceptSymbol(",") {
+			return stmt, nil
+		}
+	}
+}
+
+func (p *parser) updateStmt() (Statement, error) {
+	stmt := &UpdateStmt{}
+	var err error
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if !p.acceptKeyword("SET") {
+		return nil, p.syntaxError()
+	}
+
+	for {
+		col, err := p.fieldName()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptSymbol("=") {
+			return nil, p.syntaxError()
+		}
+		v, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Set = append(stmt.Set, Assignment{Column: col, Value: v})
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if stmt.Where, err = p.orExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (p *parser) deleteStmt() (Statement, error) {
+	if !p.acceptKeyword("FROM") {
+		return nil, p.syntaxError()
+	}
+
+	stmt := &DeleteStmt{}
+	var err error
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("WHERE") {
+		if stmt.Where, err = p.orExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (p *parser) orExpr() (Expr, error) {
+	left, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpr{Op: "OR", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) andExpr() (Expr, error) {
+	left, err := p.predicate()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.predicate()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpr{Op: "AND", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) predicate() (Expr, error) {
+	if p.acceptSymbol("(") {
+		e, err := p.orExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptSymbol(")") {
+			return nil, p.syntaxError()
+		}
+		return e, nil
+	}
+	if p.isKeyword("NOT") {
+		return nil, errors.Unimplemented("NOT is only supported as part of NOT IN, NOT LIKE and IS NOT NULL")
+	}
+
+	field, err := p.fieldName()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") {
+			return nil, p.syntaxError()
+		}
+		return &NullExpr{Field: field, Not: not}, nil
+	}
+
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("IN"):
+		values, err := p.literalList()
+		if err != nil {
+			return nil, err
+		}
+		return &InExpr{Field: field, Values: values, Not: not}, nil
+	case p.isKeyword("LIKE") || p.isKeyword("ILIKE"):
+		ci := p.next().text
+		v, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := v.(string)
+		if !ok {
+			return nil, errors.InvalidArgument("LIKE expects a string pattern")
+		}
+		return &LikeExpr{Field: field, Pattern: pattern, Not: not, CaseInsensitive: strings.EqualFold(ci, "ILIKE")}, nil
+	case p.acceptKeyword("BETWEEN"):
+		if not {
+			return nil, errors.Unimplemented("NOT BETWEEN is not supported")
+		}
+		low, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptKeyword("AND") {
+			return nil, p.syntaxError()
+		}
+		high, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return &LogicalExpr{
+			Op:    "AND",
+			Left:  &ComparisonExpr{Field: field, Op: ">=", Value: low},
+			Right: &ComparisonExpr{Field: field, Op: "<=", Value: high},
+		}, nil
+	}
+	if not {
+		return nil, p.syntaxError()
+	}
+
+	op := p.next()
+	switch op.text {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+	default:
+		return nil, errors.InvalidArgument("syntax error at or near '%s'", op.text)
+	}
+	v, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, errors.InvalidArgument("comparison with NULL is always unknown, use IS NULL instead")
+	}
+	if op.text == "!=" {
+		op.text = "<>"
+	}
+
+	return &ComparisonExpr{Field: field, Op: op.text, Value: v}, nil
+}
+
+func (p *parser) literalList() ([]any, error) {
+	if !p.acceptSymbol("(") {
+		return nil, p.syntaxError()
+	}
+
+	var values []any
+	for {
+		v, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if !p.acceptSymbol(")") {
+		return nil, p.syntaxError()
+	}
+
+	return values, nil
+}
+
+// literal parses a constant: a string, a number, TRUE/FALSE, NULL or a placeholder.
+func (p *parser) literal() (any, error) {
+	start := p.pos
+	negative := p.acceptSymbol("-")
+	if !negative {
+		p.acceptSymbol("+")
+	}
+
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		s := t.text
+		if negative {
+			s = "-" + s
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid number '%s'", t.text)
+		}
+		return f, nil
+	case tokString:
+		if !negative {
+			return t.text, nil
+		}
+	case tokParam:
+		n, _ := strconv.Atoi(t.text)
+		if n < 1 {
+			return nil, errors.InvalidArgument("invalid parameter $%s", t.text)
+		}
+		if n > p.maxParam {
+			p.maxParam = n
+		}
+		if p.params == nil {
+			return Param(n), nil
+		}
+		if n > len(p.params) {
+			return nil, errors.InvalidArgument("there is no parameter $%d", n)
+		}
+		v := p.params[n-1]
+		if negative {
+			switch n := v.(type) {
+			case int64:
+				return -n, nil
+			case float64:
+				return -n, nil
+			default:
+				return nil, errors.InvalidArgument("parameter $%d is not a number", n)
+			}
+		}
+		return v, nil
+	case tokIdent:
+		if negative {
+			break
+		}
+		switch strings.ToUpper(t.text) {
+		case "TRUE":
+			return true, nil
+		case "FALSE":
+			return false, nil
+		case "NULL":
+			return nil, nil
+		}
+	}
+
+	p.pos = start
+	return nil, p.syntaxError()
+}
+
+func (p *parser) nonNegativeInt(clause string) (int64, error) {
+	v, err := p.literal()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := v.(Param); ok {
+		return 0, nil
+	}
+	n, ok := v.(int64)
+	if !ok || n < 0 {
+		return 0, errors.InvalidArgument("%s must be a non negative integer", clause)
+	}
+
+	return n, nil
+}
+
+// tableName parses a collection name, optionally qualified with the "public" schema.
+func (p *parser) tableName() (string, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	if p.acceptSymbol(".") {
+		if name != "public" {
+			return "", errors.InvalidArgument("schema '%s' does not exist", name)
+		}
+		return p.identifier()
+	}
+
+	return name, nil
+}
+
+// fieldName parses a possibly dotted field path.
+func (p *parser) fieldName() (string, error) {
+	parts := []string{}
+	for {
+		part, err := p.identifier()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+		if !p.acceptSymbol(".") {
+			return strings.Join(parts, "."), nil
+		}
+	}
+}
+
+func (p *parser) identifier() (string, error) {
+	start := p.pos
+	t := p.next()
+	switch {
+	case t.kind == tokQuotedIdent:
+		return t.text, nil
+	case t.kind == tokIdent && !isReserved(t.text):
+		return t.text, nil
+	}
+
+	p.pos = start
+	return "", p.syntaxError()
+}
+
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return token{kind: tokEOF}
+	}
+
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) acceptSymbol(s string) bool {
+	t := p.peek()
+	if t.kind == tokSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) syntaxError() error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return errors.InvalidArgument("syntax error at end of input")
+	}
+
+	return errors.InvalidArgument("syntax error at or near '%s'", t.text)
+}
+
+var reserved = map[string]struct{}{}
+
+func init() {
+	for _, kw := range []string{
+		"SELECT", "FROM", "WHERE", "ORDER", "BY", "ASC", "DESC", "LIMIT", "OFFSET", "INSERT", "INTO", "VALUES",
+		"UPDATE", "SET", "DELETE", "AND", "OR", "NOT", "IN", "IS", "NULL", "LIKE", "ILIKE", "BETWEEN", "AS",
+		"TRUE", "FALSE", "ALL",
+	} {
+		reserved[kw] = struct{}{}
+	}
+}
+
+func isReserved(s string) bool {
+	_, ok := reserved[strings.ToUpper(s)]
+	return ok
+}
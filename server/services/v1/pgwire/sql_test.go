@@ -0,0 +1,151 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		sql  string
+		stmt Statement
+	}{
+		{
+			`SELECT * FROM users`,
+			&SelectStmt{Star: true, From: "users"},
+		}, {
+			`select id, "firstName" AS name, address.city from public.users where age >= 18 and (city = 'NY' or city = 'SF') order by age desc, id limit 10 offset 5;`,
+			&SelectStmt{
+				Items: []SelectItem{{Field: "id"}, {Field: "firstName", Alias: "name"}, {Field: "address.city"}},
+				From:  "users",
+				Where: &LogicalExpr{
+					Op:   "AND",
+					Left: &ComparisonExpr{Field: "age", Op: ">=", Value: int64(18)},
+					Right: &LogicalExpr{
+						Op:    "OR",
+						Left:  &ComparisonExpr{Field: "city", Op: "=", Value: "NY"},
+						Right: &ComparisonExpr{Field: "city", Op: "=", Value: "SF"},
+					},
+				},
+				OrderBy: []OrderItem{{Field: "age", Desc: true}, {Field: "id"}},
+				Limit:   10,
+				Offset:  5,
+			},
+		}, {
+			`SELECT count(*) FROM users WHERE name LIKE 'a%' AND id NOT IN (1, 2) AND deleted IS NULL AND score BETWEEN -1.5 AND 2`,
+			&SelectStmt{
+				Items: []SelectItem{{Func: "count"}},
+				Count: true,
+				From:  "users",
+				Where: &LogicalExpr{
+					Op: "AND",
+					Left: &LogicalExpr{
+						Op: "AND",
+						Left: &LogicalExpr{
+							Op:    "AND",
+							Left:  &LikeExpr{Field: "name", Pattern: "a%"},
+							Right: &InExpr{Field: "id", Values: []any{int64(1), int64(2)}, Not: true},
+						},
+						Right: &NullExpr{Field: "deleted"},
+					},
+					Right: &LogicalExpr{
+						Op:    "AND",
+						Left:  &ComparisonExpr{Field: "score", Op: ">=", Value: -1.5},
+						Right: &ComparisonExpr{Field: "score", Op: "<=", Value: int64(2)},
+					},
+				},
+			},
+		}, {
+			`SELECT 1, 'it''s', version()`,
+			&SelectStmt{Items: []SelectItem{{Value: int64(1)}, {Value: "it's"}, {Func: "version"}}},
+		}, {
+			`INSERT INTO users (id, name) VALUES (1, 'a'), (2, NULL)`,
+			&InsertStmt{Table: "users", Columns: []string{"id", "name"}, Rows: [][]any{{int64(1), "a"}, {int64(2), nil}}},
+		}, {
+			`UPDATE users SET name = 'b', active = true WHERE id != 1`,
+			&UpdateStmt{
+				Table: "users",
+				Set:   []Assignment{{Column: "name", Value: "b"}, {Column: "active", Value: true}},
+				Where: &ComparisonExpr{Field: "id", Op: "<>", Value: int64(1)},
+			},
+		}, {
+			`DELETE FROM users`,
+			&DeleteStmt{Table: "users"},
+		}, {
+			`SET extra_float_digits = 3`,
+			&SetStmt{},
+		}, {
+			` ; -- nothing to do`,
+			&EmptyStmt{},
+		},
+	}
+	for _, c := range cases {
+		stmt, _, err := Parse(c.sql, []any{})
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.stmt, stmt, c.sql)
+	}
+
+	errCases := []struct {
+		sql string
+		err error
+	}{
+		{`SELECT * FROM`, errors.InvalidArgument("syntax error at end of input")},
+		{`SELECT * FROM users WHERE`, errors.InvalidArgument("syntax error at end of input")},
+		{`SELECT * FROM users WHERE a = NULL`, errors.InvalidArgument("comparison with NULL is always unknown, use IS NULL instead")},
+		{`SELECT * FROM other.users`, errors.InvalidArgument("schema 'other' does not exist")},
+		{`SELECT lower(name) FROM users`, errors.Unimplemented("function 'lower' is not supported")},
+		{`SELECT * FROM users WHERE a = $1`, errors.InvalidArgument("there is no parameter $1")},
+		{`BEGIN`, errors.Unimplemented("transactions are not supported, every statement is executed on its own")},
+		{`CREATE TABLE t (a int)`, errors.Unimplemented("statement 'CREATE' is not supported")},
+		{`SELECT 'abc`, errors.InvalidArgument("unterminated quoted string")},
+		{`SELECT 1; SELECT 2`, errors.InvalidArgument("cannot insert multiple commands into a prepared statement")},
+	}
+	for _, c := range errCases {
+		_, _, err := Parse(c.sql, []any{})
+		require.Equal(t, c.err, err, c.sql)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	stmt, n, err := Parse(`SELECT * FROM users WHERE id = $1 AND name = $2 LIMIT $3`, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, &LogicalExpr{
+		Op:    "AND",
+		Left:  &ComparisonExpr{Field: "id", Op: "=", Value: Param(1)},
+		Right: &ComparisonExpr{Field: "name", Op: "=", Value: Param(2)},
+	}, stmt.(*SelectStmt).Where)
+
+	stmt, _, err = Parse(`SELECT * FROM users WHERE id = $1 AND name = $2 LIMIT $3`, []any{int64(5), "a", int64(1)})
+	require.NoError(t, err)
+	require.Equal(t, &SelectStmt{
+		Star: true,
+		From: "users",
+		Where: &LogicalExpr{
+			Op:    "AND",
+			Left:  &ComparisonExpr{Field: "id", Op: "=", Value: int64(5)},
+			Right: &ComparisonExpr{Field: "name", Op: "=", Value: "a"},
+		},
+		Limit: 1,
+	}, stmt)
+
+	stmts, err := ParseAll(`SET a = 1; SELECT 1;`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+}
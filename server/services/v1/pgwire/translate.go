@@ -0,0 +1,197 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"regexp"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/query/update"
+)
+
+var comparisonOps = map[string]string{
+	"<":  filter.LT,
+	"<=": filter.LTE,
+	">":  filter.GT,
+	">=": filter.GTE,
+}
+
+// TranslateWhere converts a WHERE clause into the Tigris filter grammar. A missing clause matches all the documents.
+func TranslateWhere(where Expr) ([]byte, error) {
+	if where == nil {
+		return []byte(`{}`), nil
+	}
+
+	f, err := translateExpr(where)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsoniter.Marshal(f)
+}
+
+func translateExpr(e Expr) (map[string]any, error) {
+	switch expr := e.(type) {
+	case *LogicalExpr:
+		op := string(filter.AndOP)
+		if expr.Op == "OR" {
+			op = string(filter.OrOP)
+		}
+
+		// flatten chains of the same operator, "a AND b AND c" is parsed as "(a AND b) AND c"
+		var children []any
+		for _, side := range []Expr{expr.Left, expr.Right} {
+			child, err := translateExpr(side)
+			if err != nil {
+				return nil, err
+			}
+			if nested, ok := side.(*LogicalExpr); ok && nested.Op == expr.Op {
+				children = append(children, child[op].([]any)...)
+			} else {
+				children = append(children, child)
+			}
+		}
+
+		return map[string]any{op: children}, nil
+	case *ComparisonExpr:
+		if err := validateValue(expr.Value); err != nil {
+			return nil, err
+		}
+
+		switch expr.Op {
+		case "=":
+			return map[string]any{expr.Field: expr.Value}, nil
+		case "<>":
+			return notEqual(expr.Field, expr.Value), nil
+		default:
+			return map[string]any{expr.Field: map[string]any{comparisonOps[expr.Op]: expr.Value}}, nil
+		}
+	case *InExpr:
+		var children []any
+		for _, v := range expr.Values {
+			if err := validateValue(v); err != nil {
+				return nil, err
+			}
+			if v == nil {
+				return nil, errors.InvalidArgument("NULL is not supported in an IN list")
+			}
+			if expr.Not {
+				children = append(children, notEqual(expr.Field, v))
+			} else {
+				children = append(children, map[string]any{expr.Field: v})
+			}
+		}
+		if len(children) == 1 {
+			return children[0].(map[string]any), nil
+		}
+		if expr.Not {
+			return map[string]any{string(filter.AndOP): children}, nil
+		}
+		return map[string]any{string(filter.OrOP): children}, nil
+	case *LikeExpr:
+		if expr.Not {
+			return nil, errors.Unimplemented("NOT LIKE is not supported")
+		}
+		return map[string]any{expr.Field: map[string]any{filter.REGEX: LikeToRegex(expr.Pattern, expr.CaseInsensitive)}}, nil
+	case *NullExpr:
+		if expr.Not {
+			return nil, errors.Unimplemented("IS NOT NULL is not supported")
+		}
+		return map[string]any{expr.Field: nil}, nil
+	default:
+		return nil, errors.InvalidArgument("unsupported expression in WHERE clause")
+	}
+}
+
+// notEqual excludes the value by matching everything below or above it, Tigris doesn't have an inequality operator.
+func notEqual(field string, v any) map[string]any {
+	return map[string]any{string(filter.OrOP): []any{
+		map[string]any{field: map[string]any{filter.LT: v}},
+		map[string]any{field: map[string]any{filter.GT: v}},
+	}}
+}
+
+func validateValue(v any) error {
+	if _, ok := v.(Param); ok {
+		return errors.InvalidArgument("parameter $%d is not bound", v)
+	}
+
+	return nil
+}
+
+// LikeToRegex converts a LIKE pattern into an anchored regular expression. "%" matches any sequence of
+// characters, "_" a single character and a backslash escapes the next character.
+func LikeToRegex(pattern string, caseInsensitive bool) string {
+	var sb strings.Builder
+	if caseInsensitive {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+
+	rs := []rune(pattern)
+	for i := 0; i < len(rs); i++ {
+		switch rs[i] {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		case '\\':
+			if i+1 < len(rs) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(string(rs[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(rs[i])))
+		}
+	}
+	sb.WriteString("$")
+
+	return sb.String()
+}
+
+// TranslateOrderBy converts ORDER BY into the Tigris sort array.
+func TranslateOrderBy(items []OrderItem) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	orders := make([]any, 0, len(items))
+	for _, item := range items {
+		order := sort.ASC
+		if item.Desc {
+			order = sort.DESC
+		}
+		orders = append(orders, map[string]any{item.Field: order})
+	}
+
+	return jsoniter.Marshal(orders)
+}
+
+// TranslateSet converts the SET list of an UPDATE into Tigris update fields.
+func TranslateSet(set []Assignment) ([]byte, error) {
+	fields := make(map[string]any, len(set))
+	for _, a := range set {
+		if err := validateValue(a.Value); err != nil {
+			return nil, err
+		}
+		fields[a.Column] = a.Value
+	}
+
+	return jsoniter.Marshal(map[string]any{string(update.Set): fields})
+}
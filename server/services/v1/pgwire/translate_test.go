@@ -0,0 +1,86 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+func TestTranslateWhere(t *testing.T) {
+	cases := []struct {
+		sql    string
+		filter string
+	}{
+		{`SELECT * FROM t`, `{}`},
+		{`SELECT * FROM t WHERE a = 1`, `{"a":1}`},
+		{`SELECT * FROM t WHERE a > 1 AND b <= 'x' AND c = true`, `{"$and":[{"a":{"$gt":1}},{"b":{"$lte":"x"}},{"c":true}]}`},
+		{`SELECT * FROM t WHERE a = 1 OR b = 2 OR c.d = 3`, `{"$or":[{"a":1},{"b":2},{"c.d":3}]}`},
+		{`SELECT * FROM t WHERE a = 1 AND (b = 2 OR c = 3)`, `{"$and":[{"a":1},{"$or":[{"b":2},{"c":3}]}]}`},
+		{`SELECT * FROM t WHERE a <> 'x'`, `{"$or":[{"a":{"$lt":"x"}},{"a":{"$gt":"x"}}]}`},
+		{`SELECT * FROM t WHERE a IN ('x', 'y')`, `{"$or":[{"a":"x"},{"a":"y"}]}`},
+		{`SELECT * FROM t WHERE a IN (1)`, `{"a":1}`},
+		{`SELECT * FROM t WHERE a NOT IN (1, 2)`, `{"$and":[{"$or":[{"a":{"$lt":1}},{"a":{"$gt":1}}]},{"$or":[{"a":{"$lt":2}},{"a":{"$gt":2}}]}]}`},
+		{`SELECT * FROM t WHERE a LIKE 'ab%_.'`, `{"a":{"$regex":"^ab.*.\\.$"}}`},
+		{`SELECT * FROM t WHERE a ILIKE '50\%'`, `{"a":{"$regex":"(?i)^50%$"}}`},
+		{`SELECT * FROM t WHERE a IS NULL`, `{"a":null}`},
+		{`SELECT * FROM t WHERE a BETWEEN 1 AND 5`, `{"$and":[{"a":{"$gte":1}},{"a":{"$lte":5}}]}`},
+	}
+	for _, c := range cases {
+		stmt, _, err := Parse(c.sql, []any{})
+		require.NoError(t, err, c.sql)
+
+		filter, err := TranslateWhere(stmt.(*SelectStmt).Where)
+		require.NoError(t, err, c.sql)
+		require.JSONEq(t, c.filter, string(filter), c.sql)
+	}
+
+	for sql, expErr := range map[string]error{
+		`SELECT * FROM t WHERE a NOT LIKE 'x'`: errors.Unimplemented("NOT LIKE is not supported"),
+		`SELECT * FROM t WHERE a IS NOT NULL`:  errors.Unimplemented("IS NOT NULL is not supported"),
+		`SELECT * FROM t WHERE a = $1`:         errors.InvalidArgument("parameter $1 is not bound"),
+	} {
+		stmt, _, err := Parse(sql, nil)
+		require.NoError(t, err, sql)
+
+		_, err = TranslateWhere(stmt.(*SelectStmt).Where)
+		require.Equal(t, expErr, err, sql)
+	}
+}
+
+func TestLikeToRegex(t *testing.T) {
+	re := regexp.MustCompile(LikeToRegex("a_c%", false))
+	require.True(t, re.MatchString("abc"))
+	require.True(t, re.MatchString("abcdef"))
+	require.False(t, re.MatchString("xabc"))
+	require.False(t, re.MatchString("ac"))
+
+	re = regexp.MustCompile(LikeToRegex("(1+1)%", true))
+	require.True(t, re.MatchString("(1+1) = 2"))
+	require.False(t, re.MatchString("11"))
+}
+
+func TestTranslateOrderByAndSet(t *testing.T) {
+	sort, err := TranslateOrderBy([]OrderItem{{Field: "a"}, {Field: "b.c", Desc: true}})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":"$asc"},{"b.c":"$desc"}]`, string(sort))
+
+	fields, err := TranslateSet([]Assignment{{Column: "a", Value: int64(1)}, {Column: "b.c", Value: nil}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"$set":{"a":1,"b.c":null}}`, string(fields))
+}
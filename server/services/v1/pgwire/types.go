@@ -0,0 +1,369 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgwire
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/tigrisdata/tigris/errors"
+)
+
+// PostgreSQL type OIDs of the columns and parameters the gateway understands.
+const (
+	oidUnknown     uint32 = 0
+	oidBool        uint32 = 16
+	oidBytea       uint32 = 17
+	oidInt8        uint32 = 20
+	oidInt2        uint32 = 21
+	oidInt4        uint32 = 23
+	oidText        uint32 = 25
+	oidJSON        uint32 = 114
+	oidFloat4      uint32 = 700
+	oidFloat8      uint32 = 701
+	oidVarchar     uint32 = 1043
+	oidTimestampTZ uint32 = 1184
+	oidNumeric     uint32 = 1700
+	oidUUID        uint32 = 2950
+)
+
+const (
+	formatText   int16 = 0
+	formatBinary int16 = 1
+
+	timestampTextFormat = "2006-01-02 15:04:05.999999-07"
+)
+
+// microseconds between the Unix epoch and the PostgreSQL epoch of 2000-01-01.
+const pgEpochMicros = 946684800000000
+
+// column describes a result column along with the document field it is read from.
+type column struct {
+	name string
+	path []string
+	oid  uint32
+}
+
+func typeSize(oid uint32) int16 {
+	switch oid {
+	case oidBool:
+		return 1
+	case oidInt8, oidFloat8, oidTimestampTZ:
+		return 8
+	case oidUUID:
+		return 16
+	default:
+		return -1
+	}
+}
+
+// collectionSchema gives access to the property types of a Tigris collection schema.
+type collectionSchema struct {
+	raw []byte
+}
+
+// topLevelColumns returns the top level properties in schema order, this is what "SELECT *" returns.
+func (c collectionSchema) topLevelColumns() ([]column, error) {
+	var columns []column
+	err := jsonparser.ObjectEach(c.raw, func(key []byte, prop []byte, _ jsonparser.ValueType, _ int) error {
+		name := string(key)
+		columns = append(columns, column{name: name, path: []string{name}, oid: propertyOID(prop)})
+		return nil
+	}, "properties")
+	if err != nil {
+		return nil, errors.Internal("invalid collection schema")
+	}
+
+	return columns, nil
+}
+
+// lookup returns the type of a possibly dotted field path, ok is false if the schema doesn't have the field.
+func (c collectionSchema) lookup(field string) (column, bool) {
+	path := strings.Split(field, ".")
+	keys := make([]string, 0, 2*len(path))
+	for _, p := range path {
+		keys = append(keys, "properties", p)
+	}
+
+	prop, dt, _, err := jsonparser.Get(c.raw, keys...)
+	if err != nil || dt != jsonparser.Object {
+		return column{}, false
+	}
+
+	return column{name: field, path: path, oid: propertyOID(prop)}, true
+}
+
+func propertyOID(prop []byte) uint32 {
+	typ, dt, _, _ := jsonparser.Get(prop, "type")
+	if dt == jsonparser.Array {
+		// nullable types are declared as ["<type>", "null"]
+		_, _ = jsonparser.ArrayEach(typ, func(v []byte, _ jsonparser.ValueType, _ int, _ error) {
+			if string(v) != "null" {
+				typ = v
+			}
+		})
+	}
+	format, _ := jsonparser.GetString(prop, "format")
+
+	switch string(typ) {
+	case "integer":
+		return oidInt8
+	case "number":
+		return oidFloat8
+	case "boolean":
+		return oidBool
+	case "object", "array":
+		return oidJSON
+	case "string":
+		switch format {
+		case "date-time":
+			return oidTimestampTZ
+		case "uuid":
+			return oidUUID
+		case "byte":
+			return oidBytea
+		}
+	}
+
+	return oidText
+}
+
+// literalOID returns the type of a constant selected without a table.
+func literalOID(v any) uint32 {
+	switch v.(type) {
+	case int64:
+		return oidInt8
+	case float64:
+		return oidFloat8
+	case bool:
+		return oidBool
+	default:
+		return oidText
+	}
+}
+
+// fieldText renders a document field in the PostgreSQL text format, nil means NULL.
+func fieldText(doc []byte, col column) []byte {
+	v, dt, _, err := jsonparser.Get(doc, col.path...)
+	if err != nil {
+		return nil
+	}
+
+	switch dt {
+	case jsonparser.Null, jsonparser.NotExist:
+		return nil
+	case jsonparser.Boolean:
+		if string(v) == "true" {
+			return []byte("t")
+		}
+		return []byte("f")
+	case jsonparser.String:
+		s, err := jsonparser.ParseString(v)
+		if err != nil {
+			return v
+		}
+		switch col.oid {
+		case oidTimestampTZ:
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return []byte(t.Format(timestampTextFormat))
+			}
+		case oidBytea:
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return []byte(`\x` + hex.EncodeToString(b))
+			}
+		}
+		return []byte(s)
+	default:
+		return v
+	}
+}
+
+// literalText renders a constant in the PostgreSQL text format.
+func literalText(v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		if val {
+			return []byte("t")
+		}
+		return []byte("f")
+	case int64:
+		return []byte(strconv.FormatInt(val, 10))
+	case float64:
+		return []byte(strconv.FormatFloat(val, 'g', -1, 64))
+	case string:
+		return []byte(val)
+	default:
+		return nil
+	}
+}
+
+// textToBinary converts a text formatted value into the binary format of the type.
+func textToBinary(oid uint32, text []byte) ([]byte, error) {
+	switch oid {
+	case oidBool:
+		if string(text) == "t" {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case oidInt8:
+		i, err := strconv.ParseInt(string(text), 10, 64)
+		if err != nil {
+			// integers that were stored as doubles
+			f, ferr := strconv.ParseFloat(string(text), 64)
+			if ferr != nil {
+				return nil, errors.InvalidArgument("invalid integer '%s'", text)
+			}
+			i = int64(f)
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(i)), nil
+	case oidFloat8:
+		f, err := strconv.ParseFloat(string(text), 64)
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid number '%s'", text)
+		}
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(f)), nil
+	case oidTimestampTZ:
+		t, err := time.Parse(timestampTextFormat, string(text))
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid timestamp '%s'", text)
+		}
+		return binary.BigEndian.AppendUint64(nil, uint64(t.UnixMicro()-pgEpochMicros)), nil
+	case oidUUID:
+		b, err := hex.DecodeString(strings.ReplaceAll(string(text), "-", ""))
+		if err != nil || len(b) != 16 {
+			return nil, errors.InvalidArgument("invalid uuid '%s'", text)
+		}
+		return b, nil
+	case oidBytea:
+		b, err := hex.DecodeString(strings.TrimPrefix(string(text), `\x`))
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid bytea value")
+		}
+		return b, nil
+	default:
+		return text, nil
+	}
+}
+
+// decodeParam converts a bound parameter into the constant used in the statement.
+func decodeParam(oid uint32, format int16, data []byte) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if format == formatBinary {
+		switch oid {
+		case oidBool:
+			if len(data) != 1 {
+				return nil, errors.InvalidArgument("invalid binary bool parameter")
+			}
+			return data[0] != 0, nil
+		case oidInt2:
+			if len(data) != 2 {
+				return nil, errors.InvalidArgument("invalid binary int2 parameter")
+			}
+			return int64(int16(binary.BigEndian.Uint16(data))), nil
+		case oidInt4:
+			if len(data) != 4 {
+				return nil, errors.InvalidArgument("invalid binary int4 parameter")
+			}
+			return int64(int32(binary.BigEndian.Uint32(data))), nil
+		case oidInt8:
+			if len(data) != 8 {
+				return nil, errors.InvalidArgument("invalid binary int8 parameter")
+			}
+			return int64(binary.BigEndian.Uint64(data)), nil
+		case oidFloat4:
+			if len(data) != 4 {
+				return nil, errors.InvalidArgument("invalid binary float4 parameter")
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+		case oidFloat8:
+			if len(data) != 8 {
+				return nil, errors.InvalidArgument("invalid binary float8 parameter")
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+		case oidTimestampTZ:
+			if len(data) != 8 {
+				return nil, errors.InvalidArgument("invalid binary timestamptz parameter")
+			}
+			micros := int64(binary.BigEndian.Uint64(data)) + pgEpochMicros
+			return time.UnixMicro(micros).UTC().Format(time.RFC3339Nano), nil
+		case oidUUID:
+			if len(data) != 16 {
+				return nil, errors.InvalidArgument("invalid binary uuid parameter")
+			}
+			h := hex.EncodeToString(data)
+			return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+		case oidText, oidVarchar, oidUnknown, oidJSON:
+			return string(data), nil
+		case oidBytea:
+			return base64.StdEncoding.EncodeToString(data), nil
+		default:
+			return nil, errors.InvalidArgument("binary parameters of type %d are not supported", oid)
+		}
+	}
+
+	s := string(data)
+	switch oid {
+	case oidBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			switch strings.ToLower(s) {
+			case "t", "yes", "on":
+				return true, nil
+			case "f", "no", "off":
+				return false, nil
+			}
+			return nil, errors.InvalidArgument("invalid boolean parameter '%s'", s)
+		}
+		return b, nil
+	case oidInt2, oidInt4, oidInt8:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid integer parameter '%s'", s)
+		}
+		return i, nil
+	case oidFloat4, oidFloat8, oidNumeric:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid numeric parameter '%s'", s)
+		}
+		return f, nil
+	case oidTimestampTZ:
+		for _, layout := range []string{time.RFC3339Nano, timestampTextFormat, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC().Format(time.RFC3339Nano), nil
+			}
+		}
+		return nil, errors.InvalidArgument("invalid timestamp parameter '%s'", s)
+	case oidBytea:
+		b, err := hex.DecodeString(strings.TrimPrefix(s, `\x`))
+		if err != nil {
+			return nil, errors.InvalidArgument("invalid bytea parameter")
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	default:
+		return s, nil
+	}
+}
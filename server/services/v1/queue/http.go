@@ -0,0 +1,160 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue serves the HTTP variant of the CreateQueue, Enqueue, Receive and Ack APIs of the message queues.
+package queue
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/metadata"
+)
+
+// Handler serves the requests to the queue of the path. The request bodies are:
+//
+//	create:  {"branch": "...", "visibility_timeout": "30s", "max_receives": 5, "dead_letter_queue": "..."}
+//	enqueue: {"branch": "...", "messages": [...]}
+//	receive: {"branch": "...", "limit": 10}
+//	ack:     {"branch": "...", "receipts": ["..."]}
+//
+// The enqueues and the acks join the explicit transaction of the transaction headers of the request, if any.
+type Handler struct {
+	client api.QueuesClient
+}
+
+func NewHandler(client api.QueuesClient) *Handler {
+	return &Handler{client: client}
+}
+
+type queueRequest struct {
+	Branch            string                `json:"branch"`
+	VisibilityTimeout string                `json:"visibility_timeout"`
+	MaxReceives       int                   `json:"max_receives"`
+	DeadLetterQueue   string                `json:"dead_letter_queue"`
+	Messages          []jsoniter.RawMessage `json:"messages"`
+	Limit             int64                 `json:"limit"`
+	Receipts          []string              `json:"receipts"`
+}
+
+func (h *Handler) CreateQueue(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *queueRequest) (*httpbody.HttpBody, error) {
+		if len(req.VisibilityTimeout) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderQueueVisibilityTimeout, req.VisibilityTimeout)
+		}
+		if req.MaxReceives != 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderQueueMaxReceives, strconv.Itoa(req.MaxReceives))
+		}
+		if len(req.DeadLetterQueue) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderQueueDeadLetter, req.DeadLetterQueue)
+		}
+
+		return h.client.CreateQueue(ctx, &api.DescribeCollectionRequest{
+			Project:    chi.URLParam(r, "project"),
+			Collection: chi.URLParam(r, "queue"),
+			Branch:     req.Branch,
+		})
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) Enqueue(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *queueRequest) (*httpbody.HttpBody, error) {
+		messages := make([][]byte, 0, len(req.Messages))
+		for _, m := range req.Messages {
+			messages = append(messages, m)
+		}
+
+		return h.client.Enqueue(ctx, &api.InsertRequest{
+			Project:    chi.URLParam(r, "project"),
+			Collection: chi.URLParam(r, "queue"),
+			Branch:     req.Branch,
+			Documents:  messages,
+		})
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) Receive(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *queueRequest) (*httpbody.HttpBody, error) {
+		return h.client.Receive(ctx, &api.ReadRequest{
+			Project:    chi.URLParam(r, "project"),
+			Collection: chi.URLParam(r, "queue"),
+			Branch:     req.Branch,
+			Options:    &api.ReadRequestOptions{Limit: req.Limit},
+		})
+	})
+	write(w, resp, err)
+}
+
+func (h *Handler) Ack(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.serve(r, func(ctx context.Context, req *queueRequest) (*httpbody.HttpBody, error) {
+		receipts := make([][]byte, 0, len(req.Receipts))
+		for _, receipt := range req.Receipts {
+			doc, err := jsoniter.Marshal(map[string]string{"receipt": receipt})
+			if err != nil {
+				return nil, err
+			}
+			receipts = append(receipts, doc)
+		}
+
+		return h.client.Ack(ctx, &api.InsertRequest{
+			Project:    chi.URLParam(r, "project"),
+			Collection: chi.URLParam(r, "queue"),
+			Branch:     req.Branch,
+			Documents:  receipts,
+		})
+	})
+	write(w, resp, err)
+}
+
+func (*Handler) serve(r *http.Request, fn func(context.Context, *queueRequest) (*httpbody.HttpBody, error)) (*httpbody.HttpBody, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req queueRequest
+	if len(body) > 0 {
+		if err = jsoniter.Unmarshal(body, &req); err != nil {
+			return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+		}
+	}
+
+	return fn(common.OutgoingContext(r), &req)
+}
+
+func write(w http.ResponseWriter, resp *httpbody.HttpBody, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.GetContentType())
+	_, _ = w.Write(resp.GetData())
+}
@@ -0,0 +1,131 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	describe *api.DescribeCollectionRequest
+	insert   *api.InsertRequest
+	read     *api.ReadRequest
+	md       metadata.MD
+	err      error
+}
+
+func (c *testClient) response(ctx context.Context, data string) (*httpbody.HttpBody, error) {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &httpbody.HttpBody{ContentType: "application/json", Data: []byte(data)}, nil
+}
+
+func (c *testClient) CreateQueue(ctx context.Context, in *api.DescribeCollectionRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.describe = in
+	return c.response(ctx, `{"status":"created"}`)
+}
+
+func (c *testClient) Enqueue(ctx context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.insert = in
+	return c.response(ctx, `{"status":"enqueued","ids":["m1"]}`)
+}
+
+func (c *testClient) Receive(ctx context.Context, in *api.ReadRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.read = in
+	return c.response(ctx, `{"messages":[]}`)
+}
+
+func (c *testClient) Ack(ctx context.Context, in *api.InsertRequest, _ ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	c.insert = in
+	return c.response(ctx, `{"status":"acked","acked":1}`)
+}
+
+func serve(client *testClient, op string, body string) *httptest.ResponseRecorder {
+	h := NewHandler(client)
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/database/queues/{queue}/create", h.CreateQueue)
+	router.Post("/v1/projects/{project}/database/queues/{queue}/enqueue", h.Enqueue)
+	router.Post("/v1/projects/{project}/database/queues/{queue}/receive", h.Receive)
+	router.Post("/v1/projects/{project}/database/queues/{queue}/ack", h.Ack)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/database/queues/orders/"+op, strings.NewReader(body))
+	r.Header.Set(api.HeaderTxID, "tx1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestCreateQueue(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, "create", `{"branch": "b1", "visibility_timeout": "1m", "max_receives": 3, "dead_letter_queue": "orders-dlq"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"status":"created"}`, w.Body.String())
+
+	require.Equal(t, "p1", client.describe.Project)
+	require.Equal(t, "orders", client.describe.Collection)
+	require.Equal(t, "b1", client.describe.Branch)
+	require.Equal(t, []string{"1m"}, client.md.Get(api.HeaderQueueVisibilityTimeout))
+	require.Equal(t, []string{"3"}, client.md.Get(api.HeaderQueueMaxReceives))
+	require.Equal(t, []string{"orders-dlq"}, client.md.Get(api.HeaderQueueDeadLetter))
+
+	// the defaults of the server without a body
+	w = serve(client, "create", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, client.md.Get(api.HeaderQueueVisibilityTimeout))
+}
+
+func TestEnqueueReceiveAck(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, "enqueue", `{"messages": [{"order": 1}, {"order": 2}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"status":"enqueued","ids":["m1"]}`, w.Body.String())
+	require.Len(t, client.insert.Documents, 2)
+	require.JSONEq(t, `{"order": 2}`, string(client.insert.Documents[1]))
+	require.Equal(t, []string{"tx1"}, client.md.Get(api.HeaderTxID))
+
+	w = serve(client, "receive", `{"limit": 5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "orders", client.read.Collection)
+	require.Equal(t, int64(5), client.read.Options.Limit)
+
+	w = serve(client, "ack", `{"receipts": ["m1:l1"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, client.insert.Documents, 1)
+	require.JSONEq(t, `{"receipt": "m1:l1"}`, string(client.insert.Documents[0]))
+
+	w = serve(client, "ack", `{"receipts": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	client.err = errors.NotFound("queue doesn't exist '%s'", "orders")
+	w = serve(client, "receive", `{}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"error": {"code": "NOT_FOUND", "message": "queue doesn't exist 'orders'"}}`, w.Body.String())
+}
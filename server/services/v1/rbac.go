@@ -0,0 +1,240 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/middleware"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const (
+	rolesPath        = fullProjectPath + "/rbac/roles"
+	rolePathPattern  = rolesPath + "/{role}"
+	roleBindingsPath = fullProjectPath + "/rbac/bindings"
+
+	editorRole = "e"
+)
+
+type grantInfo struct {
+	Permission string `json:"permission"`
+	Branch     string `json:"branch,omitempty"`
+	Collection string `json:"collection,omitempty"`
+}
+
+type roleInfo struct {
+	Name      string      `json:"name"`
+	Grants    []grantInfo `json:"grants"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+type roleBindingInfo struct {
+	Sub       string `json:"sub"`
+	Role      string `json:"role"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func toRoleInfo(roles []metadata.Role) []roleInfo {
+	info := make([]roleInfo, len(roles))
+	for i, r := range roles {
+		grants := make([]grantInfo, len(r.Grants))
+		for j, g := range r.Grants {
+			grants[j] = grantInfo{Permission: g.Permission, Branch: g.Branch, Collection: g.Collection}
+		}
+		info[i] = roleInfo{Name: r.Name, Grants: grants, CreatedAt: r.CreatedAt}
+	}
+
+	return info
+}
+
+func toRoleBindingInfo(bindings []metadata.RoleBinding) []roleBindingInfo {
+	info := make([]roleBindingInfo, len(bindings))
+	for i, b := range bindings {
+		info[i] = roleBindingInfo{Sub: b.Sub, Role: b.Role, CreatedAt: b.CreatedAt}
+	}
+
+	return info
+}
+
+// registerRBACHTTP registers the REST endpoints to manage the roles of a project and their bindings to the subjects.
+// The subjects bound to the roles are limited to the read, write or admin permissions granted on the databases and the
+// collections of the project.
+func (s *apiService) registerRBACHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+rolesPath, s.listRoles)
+	router.Put(apiPathPrefix+rolesPath, s.createOrUpdateRole)
+	router.Delete(apiPathPrefix+rolePathPattern, s.deleteRole)
+	router.Get(apiPathPrefix+roleBindingsPath, s.listRoleBindings)
+	router.Post(apiPathPrefix+roleBindingsPath, s.createRoleBinding)
+	router.Delete(apiPathPrefix+roleBindingsPath, s.deleteRoleBinding)
+}
+
+// mustBeOwner rejects the callers that aren't allowed to manage the access to the project.
+func mustBeOwner(r *http.Request) error {
+	if role, ok := request.GetCallerRole(r.Context()); ok && (role == readOnlyRole || role == editorRole || role == request.SearchOnlyRole) {
+		return errors.PermissionDenied("you are not allowed to perform this action")
+	}
+
+	return nil
+}
+
+func (s *apiService) runRBAC(w http.ResponseWriter, r *http.Request, set func(*database.RBACQueryRunner), change bool) (*database.RBACQueryRunner, *database.Response) {
+	if err := mustBeOwner(r); err != nil {
+		writeHTTPError(w, err)
+		return nil, nil
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetRBACQueryRunner(accessToken)
+	set(runner)
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: change})
+	if err != nil {
+		writeHTTPError(w, err)
+		return nil, nil
+	}
+
+	if change {
+		middleware.PurgeRBACDecisions()
+	}
+
+	return runner, &resp
+}
+
+func readJSONBody(w http.ResponseWriter, r *http.Request, req any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeHTTPError(w, errors.InvalidArgument("failed to read request body"))
+		return false
+	}
+
+	if err = jsoniter.Unmarshal(body, req); err != nil {
+		writeHTTPError(w, errors.InvalidArgument("invalid request body"))
+		return false
+	}
+
+	return true
+}
+
+func (s *apiService) listRoles(w http.ResponseWriter, r *http.Request) {
+	runner, _ := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetListReq(&database.RBACRequest{Project: chi.URLParam(r, "project")})
+	}, false)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"roles": toRoleInfo(runner.RBAC().Roles)})
+}
+
+func (s *apiService) createOrUpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string      `json:"name"`
+		Grants []grantInfo `json:"grants"`
+	}
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	grants := make([]metadata.Grant, len(req.Grants))
+	for i, g := range req.Grants {
+		grants[i] = metadata.Grant{Permission: g.Permission, Branch: g.Branch, Collection: g.Collection}
+	}
+
+	runner, resp := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetCreateOrUpdateRoleReq(&database.RBACRequest{
+			Project: chi.URLParam(r, "project"),
+			Role:    req.Name,
+			Grants:  grants,
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status, "role": toRoleInfo(runner.RBAC().Roles)[0]})
+}
+
+func (s *apiService) deleteRole(w http.ResponseWriter, r *http.Request) {
+	runner, resp := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetDeleteRoleReq(&database.RBACRequest{
+			Project: chi.URLParam(r, "project"),
+			Role:    chi.URLParam(r, "role"),
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
+
+func (s *apiService) listRoleBindings(w http.ResponseWriter, r *http.Request) {
+	runner, _ := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetListReq(&database.RBACRequest{Project: chi.URLParam(r, "project")})
+	}, false)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"bindings": toRoleBindingInfo(runner.RBAC().Bindings)})
+}
+
+func (s *apiService) createRoleBinding(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sub  string `json:"sub"`
+		Role string `json:"role"`
+	}
+	if !readJSONBody(w, r, &req) {
+		return
+	}
+
+	runner, resp := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetCreateRoleBindingReq(&database.RBACRequest{
+			Project: chi.URLParam(r, "project"),
+			Sub:     req.Sub,
+			Role:    req.Role,
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status, "binding": toRoleBindingInfo(runner.RBAC().Bindings)[0]})
+}
+
+// deleteRoleBinding takes the sub and the role as the query parameters, the subs can have the characters that aren't
+// allowed in a path segment.
+func (s *apiService) deleteRoleBinding(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	runner, resp := s.runRBAC(w, r, func(runner *database.RBACQueryRunner) {
+		runner.SetDeleteRoleBindingReq(&database.RBACRequest{
+			Project: chi.URLParam(r, "project"),
+			Sub:     query.Get("sub"),
+			Role:    query.Get("role"),
+		})
+	}, true)
+	if runner == nil {
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
@@ -0,0 +1,110 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/kv"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+)
+
+// readSession is the snapshot shared by the reads of a read session, it is passed around by the clients as an opaque
+// token. The session doesn't hold any state on the server, so the reads of a session can be served by any server.
+type readSession struct {
+	// Version is the read version of the snapshot.
+	Version int64 `json:"v"`
+	// StartedAt is the Unix time in milliseconds the snapshot was taken at.
+	StartedAt int64 `json:"t"`
+}
+
+func (r readSession) encode() (string, error) {
+	encoded, err := jsoniter.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodeReadSession returns the session of the token, the sessions older than maxAge are rejected as their snapshot
+// may no longer be readable.
+func decodeReadSession(token string, now time.Time, maxAge time.Duration) (readSession, error) {
+	var session readSession
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = jsoniter.Unmarshal(decoded, &session)
+	}
+	if err != nil || session.Version < 0 {
+		return readSession{}, errors.InvalidArgument("invalid '%s' header '%s'", api.HeaderReadSession, token)
+	}
+
+	if now.Sub(time.UnixMilli(session.StartedAt)) > maxAge {
+		return readSession{}, errors.FailedPrecondition("the read session expired, start a new session")
+	}
+
+	return session, nil
+}
+
+// withReadSession pins the reads of the request to the snapshot of its read session, if it has one. A new session
+// takes the latest snapshot. The token of the session is returned to the caller in the response header.
+func (s *apiService) withReadSession(ctx context.Context) (context.Context, error) {
+	token := api.GetHeader(ctx, api.HeaderReadSession)
+	if token == "" {
+		return ctx, nil
+	}
+
+	if api.GetTransaction(ctx) != nil {
+		return nil, errors.InvalidArgument("the reads in an explicit transaction can't be part of a read session")
+	}
+
+	if token != api.ReadSessionNew {
+		session, err := decodeReadSession(token, time.Now(), config.DefaultConfig.KV.ReadSessionMaxAge)
+		if err != nil {
+			return nil, err
+		}
+
+		_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderReadSession, token))
+
+		return kv.WithReadVersionAt(ctx, session.Version), nil
+	}
+
+	// the first transaction of the context takes the read version, it is taken here so that the token is returned
+	// before the documents
+	startedAt := time.Now()
+	ctx = kv.WithReadVersion(ctx)
+	tx, err := s.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = tx.Rollback(ctx)
+
+	token, err = readSession{Version: kv.GetReadVersion(ctx), StartedAt: startedAt.UnixMilli()}.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = grpc.SetHeader(ctx, grpcMetadata.Pairs(api.HeaderReadSession, token))
+
+	return ctx, nil
+}
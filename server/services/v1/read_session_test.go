@@ -0,0 +1,44 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestReadSession(t *testing.T) {
+	now := time.Now()
+
+	token, err := readSession{Version: 12345, StartedAt: now.UnixMilli()}.encode()
+	require.NoError(t, err)
+
+	session, err := decodeReadSession(token, now.Add(time.Second), 4*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, int64(12345), session.Version)
+
+	var tErr *api.TigrisError
+	_, err = decodeReadSession(token, now.Add(5*time.Second), 4*time.Second)
+	require.ErrorAs(t, err, &tErr)
+	require.Equal(t, api.Code_FAILED_PRECONDITION, tErr.Code)
+
+	for _, token := range []string{"new-session", "e30", "eyJ2IjotMX0"} {
+		_, err = decodeReadSession(token, now, 4*time.Second)
+		require.ErrorAs(t, err, &tErr, token)
+	}
+}
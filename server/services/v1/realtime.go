@@ -53,11 +53,17 @@ func newRealtimeService(_ kv.TxStore, _ search.Store, tenantMgr *metadata.Tenant
 	encoder := metadata.NewCacheEncoder()
 	heartbeatF := realtime.NewHeartbeatFactory(cacheS, encoder)
 	channelFactory := realtime.NewChannelFactory(cacheS, encoder, heartbeatF)
+	history := realtime.NewHistory(config.DefaultConfig.Realtime.History, tenantMgr, txMgr)
+
+	// the read-only replicas leave the background writes to the other servers
+	if !config.DefaultConfig.Replica.ReadOnly {
+		history.Start()
+	}
 
 	return &realtimeService{
 		cache:     cacheS,
-		rtmRunner: realtime.NewRTMRunnerFactory(cacheS, channelFactory),
-		devices:   realtime.NewSessionMgr(cacheS, tenantMgr, txMgr, heartbeatF, channelFactory),
+		rtmRunner: realtime.NewRTMRunnerFactory(cacheS, channelFactory, history),
+		devices:   realtime.NewSessionMgr(cacheS, tenantMgr, txMgr, heartbeatF, channelFactory, history),
 	}
 }
 
@@ -44,6 +44,7 @@ type Session struct {
 	heartbeat    *HeartbeatTable
 	tenant       *metadata.Tenant
 	project      *metadata.Project
+	history      *History
 	watchers     map[string]*ChannelWatcher
 }
 
@@ -89,6 +90,7 @@ func (s *Sessions) CreateDeviceSession(ctx context.Context, conn *websocket.Conn
 		project:   proj,
 		encType:   params.ToEncodingType(),
 		chFactory: s.channelFactory,
+		history:   s.history,
 		watchers:  make(map[string]*ChannelWatcher),
 		heartbeat: s.heartbeatFactory.GetHeartbeatTable(tenant.GetNamespace().Id(), proj.Id()),
 	}, nil
@@ -241,16 +243,36 @@ func (session *Session) handleMessage(ctx context.Context, req *api.RealTimeMess
 			return nil
 		}
 
-		watcher, err := channel.GetWatcher(ctx, session.id, event.Position)
+		options, err := DecodeSubscribeOptions(session.encType, req.Event)
+		if err != nil {
+			return errors.InternalWS(err.Error())
+		}
+
+		// the replayed messages are pushed before the watcher starts after the last of them
+		position := event.Position
+		var replayed []*HistoryMessage
+		if options.Replay {
+			if replayed, err = session.history.Replay(ctx, session.tenant, session.project, event.Channel, event.Position); err != nil {
+				return errors.InternalWS(err.Error())
+			}
+			if len(replayed) > 0 {
+				position = replayed[len(replayed)-1].Id
+			}
+		}
+
+		watcher, err := channel.GetWatcher(ctx, session.id, position)
 		if err != nil {
 			return nil
 		}
 		session.watchers[event.Channel] = watcher
-		watcher.StartWatching(NewDevicePusher(session, event.Channel).Watch)
 		err = SendReply(session.conn, session.encType, api.EventType_subscribed, &api.SubscribedEvent{
 			Channel: event.Channel,
 		})
 		log.Err(err).Msgf("failed to send subscribe message")
+
+		pusher := NewDevicePusher(session, event.Channel)
+		pusher.Replay(replayed)
+		watcher.StartWatching(pusher.Watch)
 		return nil
 	case api.EventType_message:
 		event, ok := decoded.(*api.MessageEvent)
@@ -267,7 +289,11 @@ func (session *Session) handleMessage(ctx context.Context, req *api.RealTimeMess
 		if err != nil {
 			return errors.InternalWS(err.Error())
 		}
-		if _, err := ch.PublishMessage(ctx, streamData); err != nil {
+		id, err := ch.PublishMessage(ctx, streamData)
+		if err != nil {
+			return errors.InternalWS(err.Error())
+		}
+		if err = session.history.Append(ctx, session.tenant, session.project, event.Channel, id, streamData); err != nil {
 			return errors.InternalWS(err.Error())
 		}
 		return nil
@@ -0,0 +1,283 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package realtime
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// The history of the channels of a project is stored in its realtime history table. The messages are keyed by
+// (message, channel, ms, seq), the parts of their id in the stream of the channel, so they are replayed in the order
+// of the stream from any position of the stream. The retention prunes them by (time, ms, channel, seq), an entry per
+// message ordered by the time it was published.
+const (
+	historyMessageKey = "message"
+	historyTimeKey    = "time"
+)
+
+// HistoryMessage is a message replayed from the history of a channel.
+type HistoryMessage struct {
+	Id   string
+	Data *internal.StreamData
+}
+
+// History retains the messages published to the realtime channels in the key-value store, so they outlive the streams
+// of the channels in the cache, which are dropped with the inactive channels. The subscribers replay the messages
+// published after a position of the stream before they receive the new messages.
+type History struct {
+	cfg     config.RealtimeHistoryConfig
+	tenants metadata.TenantGetter
+	txMgr   *transaction.Manager
+	now     func() time.Time
+}
+
+func NewHistory(cfg config.RealtimeHistoryConfig, tenants metadata.TenantGetter, txMgr *transaction.Manager) *History {
+	return &History{
+		cfg:     cfg,
+		tenants: tenants,
+		txMgr:   txMgr,
+		now:     time.Now,
+	}
+}
+
+func (h *History) Enabled() bool {
+	return h != nil && h.cfg.Enabled
+}
+
+// parseStreamId returns the parts of a stream id, "<ms>-<seq>". A position without the sequence is the first message
+// of the millisecond.
+func parseStreamId(id string) (int64, int64, error) {
+	msPart, seqPart, hasSeq := strings.Cut(id, "-")
+
+	ms, err := strconv.ParseInt(msPart, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, 0, errors.InvalidArgument("invalid position '%s'", id)
+	}
+	if !hasSeq {
+		return ms, 0, nil
+	}
+
+	seq, err := strconv.ParseInt(seqPart, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, 0, errors.InvalidArgument("invalid position '%s'", id)
+	}
+
+	return ms, seq, nil
+}
+
+func historyTable(tenant *metadata.Tenant, project *metadata.Project) []byte {
+	return tenant.Encoder.EncodeRealtimeHistoryTableName(tenant.GetNamespace(), project.GetMainDatabase())
+}
+
+// Append retains the message published to the channel with the id of the stream of the channel.
+func (h *History) Append(ctx context.Context, tenant *metadata.Tenant, project *metadata.Project, channel string, id string, data *internal.StreamData) error {
+	if !h.Enabled() {
+		return nil
+	}
+
+	ms, seq, err := parseStreamId(id)
+	if err != nil {
+		return err
+	}
+
+	enc, err := internal.EncodeStreamData(data)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.txMgr.StartTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := historyTable(tenant, project)
+	if err = tx.Replace(ctx, keys.NewKey(table, historyMessageKey, channel, ms, seq), internal.NewTableData(enc), false); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	if err = tx.Replace(ctx, keys.NewKey(table, historyTimeKey, ms, channel, seq), internal.NewTableData(nil), false); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Replay returns the messages of the channel published after the position, the whole retained history if the
+// position is empty. At most MaxReplay messages are returned, in the order of the stream.
+func (h *History) Replay(ctx context.Context, tenant *metadata.Tenant, project *metadata.Project, channel string, position string) ([]*HistoryMessage, error) {
+	if !h.Enabled() {
+		return nil, errors.FailedPrecondition("realtime history is disabled")
+	}
+
+	table := historyTable(tenant, project)
+	start := keys.NewKey(table, historyMessageKey, channel)
+	if len(position) > 0 {
+		ms, seq, err := parseStreamId(position)
+		if err != nil {
+			return nil, err
+		}
+		start = keys.NewKey(table, historyMessageKey, channel, ms, seq+1)
+	}
+
+	tx, err := h.txMgr.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := tx.ReadRange(ctx, start, keys.NewKey(table, historyMessageKey, channel, int64(math.MaxInt64)), true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// the messages older than the retention are skipped until they are pruned
+	cutoff := h.now().Add(-h.cfg.Retention).UnixMilli()
+
+	var (
+		row      kv.KeyValue
+		messages []*HistoryMessage
+	)
+	for (h.cfg.MaxReplay <= 0 || len(messages) < h.cfg.MaxReplay) && iter.Next(&row) {
+		if len(row.Key) != 4 {
+			continue
+		}
+		ms, ok1 := row.Key[2].(int64)
+		seq, ok2 := row.Key[3].(int64)
+		if !ok1 || !ok2 || ms < cutoff {
+			continue
+		}
+
+		data, err := internal.DecodeStreamData(row.Data.RawData)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &HistoryMessage{
+			Id:   strconv.FormatInt(ms, 10) + "-" + strconv.FormatInt(seq, 10),
+			Data: data,
+		})
+	}
+
+	return messages, iter.Err()
+}
+
+// Start prunes the messages older than the retention periodically, if the history is enabled.
+func (h *History) Start() {
+	if h.Enabled() {
+		go h.loop()
+	}
+}
+
+func (h *History) loop() {
+	log.Info().Dur("interval", h.cfg.PruneInterval).Dur("retention", h.cfg.Retention).Msg("Starting realtime history pruning")
+	t := time.NewTicker(h.cfg.PruneInterval)
+	defer t.Stop()
+	for range t.C {
+		h.scan(context.Background())
+	}
+}
+
+// scan prunes the history of the channels of all the projects of all the tenants.
+func (h *History) scan(ctx context.Context) {
+	cutoff := h.now().Add(-h.cfg.Retention)
+
+	for _, tenant := range h.tenants.AllTenants(ctx) {
+		for _, name := range tenant.ListProjects(ctx) {
+			project, err := tenant.GetProject(name)
+			if err != nil {
+				continue
+			}
+
+			if err = h.prune(ctx, historyTable(tenant, project), cutoff); err != nil {
+				log.Err(err).Str("ns", tenant.GetNamespace().StrId()).Str("project", name).
+					Msg("failed to prune realtime history")
+			}
+		}
+	}
+}
+
+// prune deletes the messages published before the cutoff, in batches each in its own transaction.
+func (h *History) prune(ctx context.Context, table []byte, cutoff time.Time) error {
+	batchSize := h.cfg.PruneBatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	for {
+		deleted, err := h.pruneBatch(ctx, table, cutoff, batchSize)
+		if err != nil || deleted < batchSize {
+			return err
+		}
+	}
+}
+
+func (h *History) pruneBatch(ctx context.Context, table []byte, cutoff time.Time, batchSize int) (int, error) {
+	tx, err := h.txMgr.StartTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := deleteHistoryBefore(ctx, tx, table, cutoff, batchSize)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	return deleted, tx.Commit(ctx)
+}
+
+func deleteHistoryBefore(ctx context.Context, tx transaction.Tx, table []byte, cutoff time.Time, batchSize int) (int, error) {
+	iter, err := tx.ReadRange(ctx, keys.NewKey(table, historyTimeKey), keys.NewKey(table, historyTimeKey, cutoff.UnixMilli()), false, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		row     kv.KeyValue
+		deleted int
+	)
+	for deleted < batchSize && iter.Next(&row) {
+		if len(row.Key) != 4 {
+			continue
+		}
+		timeKey, err := keys.FromBinary(table, row.FDBKey)
+		if err != nil {
+			return 0, err
+		}
+		if err = tx.Delete(ctx, keys.NewKey(table, historyMessageKey, row.Key[2], row.Key[1], row.Key[3])); err != nil {
+			return 0, err
+		}
+		if err = tx.Delete(ctx, timeKey); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+
+	return deleted, iter.Err()
+}
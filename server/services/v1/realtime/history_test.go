@@ -0,0 +1,57 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package realtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/internal"
+)
+
+func TestParseStreamId(t *testing.T) {
+	ms, seq, err := parseStreamId("1678900000000-3")
+	require.NoError(t, err)
+	require.Equal(t, int64(1678900000000), ms)
+	require.Equal(t, int64(3), seq)
+
+	ms, seq, err = parseStreamId("1678900000000")
+	require.NoError(t, err)
+	require.Equal(t, int64(1678900000000), ms)
+	require.Equal(t, int64(0), seq)
+
+	for _, id := range []string{"", "abc", "1-x", "-1", "1--1"} {
+		_, _, err = parseStreamId(id)
+		require.Equal(t, errors.InvalidArgument("invalid position '%s'", id), err)
+	}
+}
+
+func TestDecodeSubscribeOptions(t *testing.T) {
+	options, err := DecodeSubscribeOptions(internal.JsonEncoding, []byte(`{"channel": "c", "position": "1-0", "replay": true}`))
+	require.NoError(t, err)
+	require.True(t, options.Replay)
+
+	options, err = DecodeSubscribeOptions(internal.JsonEncoding, []byte(`{"channel": "c"}`))
+	require.NoError(t, err)
+	require.False(t, options.Replay)
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	var history *History
+	require.False(t, history.Enabled())
+	require.NoError(t, history.Append(context.TODO(), nil, nil, "c", "1-0", nil))
+}
@@ -66,6 +66,21 @@ func (pusher *DevicePusher) Watch(events *cache.StreamMessages, err error) ([]st
 	return processed, nil
 }
 
+// Replay pushes the messages replayed from the history of the channel, the messages published by this session are
+// skipped as they are when watching the channel.
+func (pusher *DevicePusher) Replay(messages []*HistoryMessage) {
+	for _, m := range messages {
+		md, err := DecodeStreamMD(m.Data.Md)
+		if err != nil {
+			continue
+		}
+		if md.ClientId == pusher.sessionId {
+			continue
+		}
+		pusher.sendMessage(m.Id, md, m.Data)
+	}
+}
+
 func (pusher *DevicePusher) sendMessage(msgId string, md *StreamMessageMD, data *internal.StreamData) {
 	rawData, err := SanitizeUserData(pusher.encType, data)
 	if err != nil {
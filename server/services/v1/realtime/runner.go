@@ -35,13 +35,15 @@ type RTMRunner interface {
 type RTMRunnerFactory struct {
 	cache   cache.Cache
 	factory *ChannelFactory
+	history *History
 }
 
 // NewRTMRunnerFactory returns RTMRunnerFactory object.
-func NewRTMRunnerFactory(cache cache.Cache, factory *ChannelFactory) *RTMRunnerFactory {
+func NewRTMRunnerFactory(cache cache.Cache, factory *ChannelFactory, history *History) *RTMRunnerFactory {
 	return &RTMRunnerFactory{
 		cache:   cache,
 		factory: factory,
+		history: history,
 	}
 }
 
@@ -49,6 +51,7 @@ func (f *RTMRunnerFactory) GetMessagesRunner(r *api.MessagesRequest) *MessagesRu
 	return &MessagesRunner{
 		baseRunner: newBaseRunner(f.cache, f.factory),
 		req:        r,
+		history:    f.history,
 	}
 }
 
@@ -90,7 +93,8 @@ func (*baseRunner) getProject(tenant *metadata.Tenant, project string) (*metadat
 type MessagesRunner struct {
 	*baseRunner
 
-	req *api.MessagesRequest
+	req     *api.MessagesRequest
+	history *History
 }
 
 func (runner *MessagesRunner) Run(ctx context.Context, tenant *metadata.Tenant) (Response, error) {
@@ -123,6 +127,10 @@ func (runner *MessagesRunner) Run(ctx context.Context, tenant *metadata.Tenant)
 			return Response{}, err
 		}
 
+		if err = runner.history.Append(ctx, tenant, project, runner.req.Channel, id, streamData); err != nil {
+			return Response{}, err
+		}
+
 		ids[i] = id
 	}
 
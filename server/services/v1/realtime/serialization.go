@@ -158,6 +158,27 @@ func DecodeEvent(encodingType internal.UserDataEncType, eventType api.EventType,
 	return nil, fmt.Errorf("unsupported encoding '%d'", encodingType)
 }
 
+// SubscribeOptions are the options of the subscribe event which are not part of the SubscribeEvent.
+type SubscribeOptions struct {
+	// Replay the history of the channel after the position of the subscribe event before the new messages.
+	Replay bool `json:"replay" codec:"replay"`
+}
+
+// DecodeSubscribeOptions decodes the options of a subscribe event.
+func DecodeSubscribeOptions(encodingType internal.UserDataEncType, message []byte) (*SubscribeOptions, error) {
+	var options SubscribeOptions
+	switch encodingType {
+	case internal.MsgpackEncoding:
+		err := codec.NewDecoderBytes(message, &msgpackHandle).Decode(&options)
+		return &options, err
+	case internal.JsonEncoding:
+		err := jsoniter.Unmarshal(message, &options)
+		return &options, err
+	}
+
+	return nil, fmt.Errorf("unsupported encoding '%d'", encodingType)
+}
+
 // SanitizeUserData is an optimization so that we can store received raw data and return as-is in case encoding that is
 // used during writing is same as during reading. In case encoding changed then this method is doing a conversion. A typical
 // case of needing this conversion is when the message is published through websocket and encoding used was msgpack and
@@ -41,11 +41,12 @@ type Sessions struct {
 	tenantMgr        *metadata.TenantManager
 	channelFactory   *ChannelFactory
 	heartbeatFactory *HeartbeatFactory
+	history          *History
 	versionH         *metadata.VersionHandler
 	tenantTracker    *metadata.CacheTracker
 }
 
-func NewSessionMgr(cache cache.Cache, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager, heartbeatF *HeartbeatFactory, factory *ChannelFactory) *Sessions {
+func NewSessionMgr(cache cache.Cache, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager, heartbeatF *HeartbeatFactory, factory *ChannelFactory, history *History) *Sessions {
 	return &Sessions{
 		cache:            cache,
 		txMgr:            txMgr,
@@ -53,6 +54,7 @@ func NewSessionMgr(cache cache.Cache, tenantMgr *metadata.TenantManager, txMgr *
 		heartbeatFactory: heartbeatF,
 		devices:          make(map[string]*Session),
 		channelFactory:   factory,
+		history:          history,
 		tenantTracker:    metadata.NewCacheTracker(tenantMgr, txMgr),
 	}
 }
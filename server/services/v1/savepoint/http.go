@@ -0,0 +1,100 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package savepoint serves the HTTP variant of the Savepoint and RollbackToSavepoint APIs of the explicit
+// transactions.
+package savepoint
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Handler sets or rolls back to the savepoint of the request body, {"name": "...", "branch": "..."}, in the explicit
+// transaction of the transaction headers of the request.
+type Handler struct {
+	client api.SavepointsClient
+}
+
+func NewHandler(client api.SavepointsClient) *Handler {
+	return &Handler{client: client}
+}
+
+type savepointRequest struct {
+	Branch string `json:"branch"`
+	Name   string `json:"name"`
+}
+
+func (h *Handler) Savepoint(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.client.Savepoint)
+}
+
+func (h *Handler) RollbackToSavepoint(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.client.RollbackToSavepoint)
+}
+
+type call func(context.Context, *api.CommitTransactionRequest, ...grpc.CallOption) (*api.CommitTransactionResponse, error)
+
+func (*Handler) serve(w http.ResponseWriter, r *http.Request, fn call) {
+	resp, err := invoke(r, fn)
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func invoke(r *http.Request, fn call) (*api.CommitTransactionResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.InvalidArgument(err.Error())
+	}
+
+	var req savepointRequest
+	if err = jsoniter.Unmarshal(body, &req); err != nil {
+		return nil, errors.InvalidArgument("invalid request body: %s", err.Error())
+	}
+
+	ctx := common.OutgoingContext(r)
+	if len(req.Name) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.HeaderSavepoint, req.Name)
+	}
+
+	return fn(ctx, &api.CommitTransactionRequest{
+		Project: chi.URLParam(r, "project"),
+		Branch:  req.Branch,
+	})
+}
@@ -0,0 +1,93 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package savepoint
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testClient struct {
+	method string
+	req    *api.CommitTransactionRequest
+	md     metadata.MD
+	err    error
+}
+
+func (c *testClient) call(ctx context.Context, method string, in *api.CommitTransactionRequest) (*api.CommitTransactionResponse, error) {
+	c.method, c.req = method, in
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &api.CommitTransactionResponse{}, nil
+}
+
+func (c *testClient) Savepoint(ctx context.Context, in *api.CommitTransactionRequest, _ ...grpc.CallOption) (*api.CommitTransactionResponse, error) {
+	return c.call(ctx, "savepoint", in)
+}
+
+func (c *testClient) RollbackToSavepoint(ctx context.Context, in *api.CommitTransactionRequest, _ ...grpc.CallOption) (*api.CommitTransactionResponse, error) {
+	return c.call(ctx, "rollback", in)
+}
+
+func serve(client *testClient, path string, body string) *httptest.ResponseRecorder {
+	h := NewHandler(client)
+	router := chi.NewRouter()
+	router.Post("/v1/projects/{project}/database/transactions/savepoint", h.Savepoint)
+	router.Post("/v1/projects/{project}/database/transactions/rollback_to_savepoint", h.RollbackToSavepoint)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/projects/p1/database/transactions/"+path, strings.NewReader(body))
+	r.Header.Set(api.HeaderTxID, "tx1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, "savepoint", `{"branch": "b1", "name": "before_payment"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "savepoint", client.method)
+	require.Equal(t, "p1", client.req.Project)
+	require.Equal(t, "b1", client.req.Branch)
+	require.Equal(t, []string{"before_payment"}, client.md.Get(api.HeaderSavepoint))
+	require.Equal(t, []string{"tx1"}, client.md.Get(api.HeaderTxID))
+
+	w = serve(client, "rollback_to_savepoint", `{"name": "before_payment"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "rollback", client.method)
+	require.Equal(t, []string{"before_payment"}, client.md.Get(api.HeaderSavepoint))
+
+	w = serve(client, "savepoint", `{"name": `)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	client.err = errors.NotFound("savepoint 'missing' not found")
+	w = serve(client, "rollback_to_savepoint", `{"name": "missing"}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"error": {"code": "NOT_FOUND", "message": "savepoint 'missing' not found"}}`, w.Body.String())
+}
@@ -25,13 +25,16 @@ import (
 	"github.com/tigrisdata/tigris/server/metadata"
 	"github.com/tigrisdata/tigris/server/request"
 	"github.com/tigrisdata/tigris/server/services/v1/search"
+	"github.com/tigrisdata/tigris/server/services/v1/searchdict"
 	"github.com/tigrisdata/tigris/server/transaction"
 	searchStore "github.com/tigrisdata/tigris/store/search"
 	"google.golang.org/grpc"
 )
 
 const (
-	searchPathPattern = fullProjectPath + "/search/*"
+	searchPathPattern   = fullProjectPath + "/search/*"
+	searchSynonymsPath  = fullProjectPath + "/search/indexes/{index}/synonyms"
+	searchStopwordsPath = fullProjectPath + "/search/indexes/{index}/stopwords"
 )
 
 type searchService struct {
@@ -66,6 +69,17 @@ func (s *searchService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Chann
 	}
 
 	api.RegisterSearchServer(inproc, s)
+	api.RegisterSearchDictionaryServer(inproc, s)
+
+	// synonym sets and stopword lists of the search indexes
+	synonyms := searchdict.NewHandler(api.NewSearchDictionaryClient(inproc), false)
+	router.Put(apiPathPrefix+searchSynonymsPath, synonyms.CreateOrReplace)
+	router.Get(apiPathPrefix+searchSynonymsPath, synonyms.Get)
+	router.Delete(apiPathPrefix+searchSynonymsPath, synonyms.Delete)
+	stopwords := searchdict.NewHandler(api.NewSearchDictionaryClient(inproc), true)
+	router.Put(apiPathPrefix+searchStopwordsPath, stopwords.CreateOrReplace)
+	router.Get(apiPathPrefix+searchStopwordsPath, stopwords.Get)
+	router.Delete(apiPathPrefix+searchStopwordsPath, stopwords.Delete)
 
 	router.HandleFunc(apiPathPrefix+searchPathPattern, func(w http.ResponseWriter, r *http.Request) {
 		mux.ServeHTTP(w, r)
@@ -76,6 +90,7 @@ func (s *searchService) RegisterHTTP(router chi.Router, inproc *inprocgrpc.Chann
 
 func (s *searchService) RegisterGRPC(grpc *grpc.Server) error {
 	api.RegisterSearchServer(grpc, s)
+	api.RegisterSearchDictionaryServer(grpc, s)
 	return nil
 }
 
@@ -242,3 +257,87 @@ func (s *searchService) Search(req *api.SearchIndexRequest, stream api.Search_Se
 
 	return nil
 }
+
+func (s *searchService) CreateOrReplaceSynonyms(ctx context.Context, req *api.CreateOrReplaceDocumentRequest) (*api.CreateOrReplaceDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetSynonymsRunner(accessToken)
+	runner.SetCreateOrReplaceReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.CreateOrReplaceDocumentResponse), nil
+}
+
+func (s *searchService) GetSynonyms(ctx context.Context, req *api.GetDocumentRequest) (*api.GetDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetSynonymsRunner(accessToken)
+	runner.SetGetReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.GetDocumentResponse), nil
+}
+
+func (s *searchService) DeleteSynonyms(ctx context.Context, req *api.DeleteDocumentRequest) (*api.DeleteDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetSynonymsRunner(accessToken)
+	runner.SetDeleteReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.DeleteDocumentResponse), nil
+}
+
+func (s *searchService) CreateOrReplaceStopwords(ctx context.Context, req *api.CreateOrReplaceDocumentRequest) (*api.CreateOrReplaceDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetStopwordsRunner(accessToken)
+	runner.SetCreateOrReplaceReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.CreateOrReplaceDocumentResponse), nil
+}
+
+func (s *searchService) GetStopwords(ctx context.Context, req *api.GetDocumentRequest) (*api.GetDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetStopwordsRunner(accessToken)
+	runner.SetGetReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.GetDocumentResponse), nil
+}
+
+func (s *searchService) DeleteStopwords(ctx context.Context, req *api.DeleteDocumentRequest) (*api.DeleteDocumentResponse, error) {
+	accessToken, _ := request.GetAccessToken(ctx)
+
+	runner := s.runnerFactory.GetStopwordsRunner(accessToken)
+	runner.SetDeleteReq(req)
+
+	resp, err := s.sessions.TxExecute(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Response.(*api.DeleteDocumentResponse), nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/transaction"
+)
+
+// dictionaryEntry is a synonym set or a stopword list of the dictionary of a search index.
+type dictionaryEntry interface {
+	Validate() error
+}
+
+// DictionaryRunner manages either the synonym sets or the stopword lists of a search index. The entries are passed
+// and returned as the documents of the search document requests.
+type DictionaryRunner struct {
+	*baseRunner
+
+	stopwords       bool
+	createOrReplace *api.CreateOrReplaceDocumentRequest
+	get             *api.GetDocumentRequest
+	delete          *api.DeleteDocumentRequest
+}
+
+func (runner *DictionaryRunner) SetCreateOrReplaceReq(req *api.CreateOrReplaceDocumentRequest) {
+	runner.createOrReplace = req
+}
+
+func (runner *DictionaryRunner) SetGetReq(req *api.GetDocumentRequest) {
+	runner.get = req
+}
+
+func (runner *DictionaryRunner) SetDeleteReq(req *api.DeleteDocumentRequest) {
+	runner.delete = req
+}
+
+func (runner *DictionaryRunner) Run(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, error) {
+	switch {
+	case runner.createOrReplace != nil:
+		return runner.runCreateOrReplace(ctx, tx, tenant)
+	case runner.get != nil:
+		return runner.runGet(ctx, tx, tenant)
+	case runner.delete != nil:
+		return runner.runDelete(ctx, tx, tenant)
+	}
+
+	return Response{}, nil
+}
+
+func (runner *DictionaryRunner) runCreateOrReplace(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, error) {
+	project, err := tenant.GetProject(runner.createOrReplace.GetProject())
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	status := make([]*api.DocStatus, len(runner.createOrReplace.GetDocuments()))
+	var entries []dictionaryEntry
+	for i, doc := range runner.createOrReplace.GetDocuments() {
+		id, entry, err := runner.decodeEntry(doc)
+		status[i] = &api.DocStatus{Id: id}
+		if err != nil {
+			status[i].Error = &api.Error{Code: api.Code_INVALID_ARGUMENT, Message: err.Error()}
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) > 0 {
+		err = tenant.UpdateSearchDictionary(ctx, tx, project, runner.createOrReplace.GetIndex(), func(d *schema.SearchDictionary) error {
+			for _, entry := range entries {
+				switch e := entry.(type) {
+				case *schema.SearchSynonym:
+					d.UpsertSynonym(e)
+				case *schema.SearchStopwords:
+					d.UpsertStopwords(e)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return Response{}, createApiError(err)
+		}
+	}
+
+	return Response{
+		Response: &api.CreateOrReplaceDocumentResponse{
+			Status: status,
+		},
+	}, nil
+}
+
+func (runner *DictionaryRunner) runGet(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, error) {
+	project, err := tenant.GetProject(runner.get.GetProject())
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	index, err := tenant.GetSearchIndex(ctx, tx, project, runner.get.GetIndex())
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	var entries []any
+	if ids := runner.get.GetIds(); len(ids) > 0 {
+		// the order of the response is the order of the ids of the request, nil for the missing entries
+		entries = make([]any, len(ids))
+		for i, id := range ids {
+			if entry := runner.getEntry(index.Dictionary, id); entry != nil {
+				entries[i] = entry
+			}
+		}
+	} else if index.Dictionary != nil {
+		if runner.stopwords {
+			for _, s := range index.Dictionary.Stopwords {
+				entries = append(entries, s)
+			}
+		} else {
+			for _, s := range index.Dictionary.Synonyms {
+				entries = append(entries, s)
+			}
+		}
+	}
+
+	documents := make([]*api.SearchHit, len(entries))
+	for i, entry := range entries {
+		if entry == nil {
+			continue
+		}
+
+		data, err := jsoniter.Marshal(entry)
+		if err != nil {
+			return Response{}, err
+		}
+		documents[i] = &api.SearchHit{Data: data}
+	}
+
+	return Response{
+		Response: &api.GetDocumentResponse{
+			Documents: documents,
+		},
+	}, nil
+}
+
+func (runner *DictionaryRunner) runDelete(ctx context.Context, tx transaction.Tx, tenant *metadata.Tenant) (Response, error) {
+	project, err := tenant.GetProject(runner.delete.GetProject())
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	status := make([]*api.DocStatus, len(runner.delete.GetIds()))
+	err = tenant.UpdateSearchDictionary(ctx, tx, project, runner.delete.GetIndex(), func(d *schema.SearchDictionary) error {
+		for i, id := range runner.delete.GetIds() {
+			status[i] = &api.DocStatus{Id: id}
+
+			var deleted bool
+			if runner.stopwords {
+				deleted = d.DeleteStopwords(id)
+			} else {
+				deleted = d.DeleteSynonym(id)
+			}
+			if !deleted {
+				status[i].Error = &api.Error{Code: api.Code_NOT_FOUND, Message: runner.kind() + " '" + id + "' doesn't exist"}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Response{}, createApiError(err)
+	}
+
+	return Response{
+		Response: &api.DeleteDocumentResponse{
+			Status: status,
+		},
+	}, nil
+}
+
+func (runner *DictionaryRunner) decodeEntry(doc []byte) (string, dictionaryEntry, error) {
+	var (
+		id    string
+		entry dictionaryEntry
+		err   error
+	)
+	if runner.stopwords {
+		var s schema.SearchStopwords
+		err = jsoniter.Unmarshal(doc, &s)
+		id, entry = s.ID, &s
+	} else {
+		var s schema.SearchSynonym
+		err = jsoniter.Unmarshal(doc, &s)
+		id, entry = s.ID, &s
+	}
+	if err != nil {
+		return id, nil, errors.InvalidArgument("invalid %s: %s", runner.kind(), err.Error())
+	}
+
+	return id, entry, entry.Validate()
+}
+
+func (runner *DictionaryRunner) getEntry(d *schema.SearchDictionary, id string) any {
+	if runner.stopwords {
+		if s := d.GetStopwords(id); s != nil {
+			return s
+		}
+	} else if s := d.GetSynonym(id); s != nil {
+		return s
+	}
+
+	return nil
+}
+
+func (runner *DictionaryRunner) kind() string {
+	if runner.stopwords {
+		return "stopword list"
+	}
+
+	return "synonym set"
+}
@@ -45,6 +45,8 @@ type Row struct {
 	UpdatedAt *internal.Timestamp
 	Document  []byte
 	Match     *api.Match
+	// GeoDistance is the distance in meters of the geopoint fields from the point of the geo distance sort.
+	GeoDistance map[string]int64
 }
 
 type page struct {
@@ -116,6 +118,7 @@ func (p *pageReader) read() error {
 	if err != nil {
 		return err
 	}
+	result, ranges := tsearch.SplitFacetRangeResults(p.query, result)
 
 	p.pageNo++
 	pg := newPage(p.query.PageSize)
@@ -146,6 +149,9 @@ func (p *pageReader) read() error {
 			for field, built := range builder.Build(result[0].FacetCounts) {
 				p.cachedFacets[field] = built
 			}
+			for field, built := range builder.BuildRanges(ranges) {
+				p.cachedFacets[field] = built
+			}
 		}
 	}
 
@@ -243,10 +249,11 @@ func (it *FilterableSearchIterator) Next(row *ResultRow) bool {
 				}
 
 				rows = append(rows, &Row{
-					CreatedAt: createdAt,
-					UpdatedAt: updatedAt,
-					Document:  rawData,
-					Match:     hits[i].Match,
+					CreatedAt:   createdAt,
+					UpdatedAt:   updatedAt,
+					Document:    rawData,
+					Match:       hits[i].Match,
+					GeoDistance: hits[i].GeoDistance,
 				})
 			}
 
@@ -76,6 +76,19 @@ func (f *RunnerFactory) GetIndexRunner(accessToken *types.AccessToken) *IndexRun
 	}
 }
 
+func (f *RunnerFactory) GetSynonymsRunner(accessToken *types.AccessToken) *DictionaryRunner {
+	return &DictionaryRunner{
+		baseRunner: newBaseRunner(f.store, f.encoder, f.txMgr, accessToken),
+	}
+}
+
+func (f *RunnerFactory) GetStopwordsRunner(accessToken *types.AccessToken) *DictionaryRunner {
+	return &DictionaryRunner{
+		baseRunner: newBaseRunner(f.store, f.encoder, f.txMgr, accessToken),
+		stopwords:  true,
+	}
+}
+
 func (f *RunnerFactory) GetReadRunner(r *api.GetDocumentRequest, accessToken *types.AccessToken) *ReadRunner {
 	return &ReadRunner{
 		baseRunner: newBaseRunner(f.store, f.encoder, f.txMgr, accessToken),
@@ -617,6 +630,17 @@ func (runner *SearchRunner) Run(ctx context.Context, tenant *metadata.Tenant) (R
 		return Response{}, err
 	}
 
+	relevance, err := runner.getRelevance(index, searchFields)
+	if err != nil {
+		return Response{}, err
+	}
+
+	// the options of the request take precedence over the defaults of the index
+	typo := runner.req.GetTypoTolerance()
+	if err = typo.Validate(); err != nil {
+		return Response{}, err
+	}
+
 	pageSize := int(runner.req.PageSize)
 	if pageSize == 0 {
 		pageSize = defaultPerPage
@@ -624,7 +648,7 @@ func (runner *SearchRunner) Run(ctx context.Context, tenant *metadata.Tenant) (R
 	var totalPages *int32
 
 	searchQ := qsearch.NewBuilder().
-		Query(runner.req.Q).
+		Query(index.Dictionary.RemoveStopwords(runner.req.Q)).
 		SearchFields(searchFields).
 		Facets(facets).
 		PageSize(pageSize).
@@ -633,6 +657,8 @@ func (runner *SearchRunner) Run(ctx context.Context, tenant *metadata.Tenant) (R
 		SortOrder(sortOrder).
 		GroupBy(groupBy).
 		VectorSearch(vecSearch).
+		TypoTolerance(typo.WithDefaults(index.TypoTolerance)).
+		Relevance(relevance).
 		Build()
 	if searchQ.IsQAndVectorBoth() {
 		return Response{}, errors.InvalidArgument("Currently either full text or vector search is supported")
@@ -678,6 +704,7 @@ func (runner *SearchRunner) Run(ctx context.Context, tenant *metadata.Tenant) (R
 					metadata.UpdatedAt = row.UpdatedAt.GetProtoTS()
 				}
 				metadata.Match = row.Match
+				metadata.SetGeoDistance(row.GeoDistance)
 				if metadata.Match != nil {
 					for _, f := range metadata.Match.Fields {
 						if f != nil {
@@ -773,6 +800,75 @@ func (runner *SearchRunner) getSearchFields(index *schema.SearchIndex) ([]string
 	return searchFields, nil
 }
 
+// getRelevance returns the relevance tuning of the query, nil if the request doesn't tune it. The weighted fields must
+// be the search fields of the query and the boost fields must be sortable numeric or date-time fields.
+func (runner *SearchRunner) getRelevance(index *schema.SearchIndex, searchFields []string) (*qsearch.Relevance, error) {
+	weights, boost := runner.req.GetFieldWeights(), runner.req.GetBoost()
+	if len(weights) == 0 && boost == nil {
+		return nil, nil
+	}
+	if err := api.ValidateFieldWeights(weights); err != nil {
+		return nil, err
+	}
+	if err := boost.Validate(); err != nil {
+		return nil, err
+	}
+
+	isSearchField := make(map[string]bool, len(searchFields))
+	for _, f := range searchFields {
+		isSearchField[f] = true
+	}
+
+	relevance := &qsearch.Relevance{}
+	for name, w := range weights {
+		cf, err := index.GetQueryableField(name)
+		if err != nil {
+			return nil, err
+		}
+		if !isSearchField[cf.InMemoryName()] {
+			return nil, errors.InvalidArgument("`%s` is not a search field of the query. Only search fields can be weighted", name)
+		}
+		if relevance.FieldWeights == nil {
+			relevance.FieldWeights = make(map[string]int)
+		}
+		relevance.FieldWeights[cf.InMemoryName()] = int(w)
+	}
+
+	if boost != nil {
+		if len(runner.req.Sort) > 0 {
+			return nil, errors.InvalidArgument("boost can't be used with sort")
+		}
+		if len(runner.req.Vector) > 0 {
+			return nil, errors.InvalidArgument("boost is not supported by vector search")
+		}
+
+		relevance.Boost = &qsearch.Boost{Buckets: int(boost.Buckets)}
+		for _, name := range boost.Fields {
+			cf, err := index.GetQueryableField(name)
+			if err != nil {
+				return nil, err
+			}
+			if !schema.DefaultSortableType(cf.DataType) || cf.DataType == schema.BoolType {
+				return nil, errors.InvalidArgument("Cannot boost by `%s`. Only numeric and date-time fields can boost", name)
+			}
+			if !cf.Sortable {
+				return nil, errors.InvalidArgument("Cannot boost by `%s`. Enable sorting on this field", name)
+			}
+			relevance.Boost.Fields = append(relevance.Boost.Fields, cf.InMemoryName())
+		}
+
+		if len(boost.Filter) > 0 {
+			boostF, err := filter.NewFactory(index.QueryableFields, value.NewCollationFrom(runner.req.Collation)).WrappedFilter(boost.Filter)
+			if err != nil {
+				return nil, err
+			}
+			relevance.Boost.Filter = boostF.SearchFilter()
+		}
+	}
+
+	return relevance, nil
+}
+
 func (runner *SearchRunner) getFacetFields(index *schema.SearchIndex) (qsearch.Facets, error) {
 	facets, err := qsearch.UnmarshalFacet(runner.req.Facet)
 	if err != nil {
@@ -784,10 +880,22 @@ func (runner *SearchRunner) getFacetFields(index *schema.SearchIndex) (qsearch.F
 		if err != nil {
 			return qsearch.Facets{}, err
 		}
-		if !cf.Faceted {
+		if ff.IsRange() {
+			if !cf.SearchIndexed {
+				return qsearch.Facets{}, errors.InvalidArgument(
+					"Cannot generate range facets for `%s`. Enable search indexing on this field", ff.Name)
+			}
+			if err = ff.ValidateRanges(ff.Name, cf.DataType); err != nil {
+				return qsearch.Facets{}, err
+			}
+			facets.Fields[i].RangeStats = cf.Faceted
+		} else if !cf.Faceted {
 			return qsearch.Facets{}, errors.InvalidArgument(
 				"Cannot generate facets for `%s`. Faceting is only supported for numeric and text fields", ff.Name)
 		}
+		if err = ff.ValidateQuery(ff.Name, cf.DataType, cf.SubType); err != nil {
+			return qsearch.Facets{}, err
+		}
 		if cf.InMemoryName() != cf.Name() {
 			facets.Fields[i].Name = cf.InMemoryName()
 		}
@@ -844,6 +952,12 @@ func (runner *SearchRunner) getSortOrdering(index *schema.SearchIndex) (*sort.Or
 			(*ordering)[i].Name = cf.InMemoryName()
 		}
 
+		if sf.GeoDistance != nil {
+			if cf.DataType != schema.GeoPointType || !cf.SearchIndexed {
+				return nil, errors.InvalidArgument("`%s` sort is only supported on search indexed geopoint fields, found `%s` field", sort.GeoDistanceOP, sf.Name)
+			}
+			continue
+		}
 		if !cf.Sortable {
 			return nil, errors.InvalidArgument("Cannot sort on `%s` field", sf.Name)
 		}
@@ -856,6 +970,13 @@ func (runner *SearchRunner) getGroupBy(index *schema.SearchIndex) (qsearch.Group
 	if err != nil {
 		return groupBy, err
 	}
+	if limit := runner.req.GetGroupLimit(); limit != 0 {
+		l := int64(limit)
+		groupBy.Limit = &l
+	}
+	if err = groupBy.Validate(); err != nil {
+		return qsearch.GroupBy{}, err
+	}
 
 	for i, f := range groupBy.Fields {
 		cf, err := index.GetQueryableField(f)
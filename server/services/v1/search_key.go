@@ -0,0 +1,153 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+)
+
+const (
+	searchKeysPath       = fullProjectPath + "/search/keys"
+	searchKeyPathPattern = searchKeysPath + "/{key}"
+)
+
+type searchKeyInfo struct {
+	Id        string   `json:"id"`
+	Name      string   `json:"name,omitempty"`
+	Indexes   []string `json:"indexes"`
+	QPS       int      `json:"qps,omitempty"`
+	CreatedAt int64    `json:"created_at"`
+	ExpiresAt int64    `json:"expires_at,omitempty"`
+}
+
+func toSearchKeyInfo(keys []metadata.SearchKey) []searchKeyInfo {
+	info := make([]searchKeyInfo, len(keys))
+	for i, k := range keys {
+		info[i] = searchKeyInfo{
+			Id:        k.Id,
+			Name:      k.Name,
+			Indexes:   k.Indexes,
+			QPS:       k.QPS,
+			CreatedAt: k.CreatedAt,
+			ExpiresAt: k.ExpiresAt,
+		}
+	}
+
+	return info
+}
+
+// registerSearchKeyHTTP registers the REST endpoints to manage the search-only keys of a project. The key is passed as
+// the bearer token and can only run the search queries against the collections and the search indexes it is created
+// for, so it can be embedded in the browser clients.
+func (s *apiService) registerSearchKeyHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+searchKeysPath, s.listSearchKeys)
+	router.Post(apiPathPrefix+searchKeysPath, s.createSearchKey)
+	router.Delete(apiPathPrefix+searchKeyPathPattern, s.deleteSearchKey)
+}
+
+func (s *apiService) listSearchKeys(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetSearchKeyQueryRunner(accessToken)
+	runner.SetListSearchKeysReq(&database.SearchKeyRequest{Project: chi.URLParam(r, "project")})
+
+	if _, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{}); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"keys": toSearchKeyInfo(runner.Keys())})
+}
+
+func (s *apiService) createSearchKey(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeHTTPError(w, errors.InvalidArgument("failed to read request body"))
+		return
+	}
+
+	var req struct {
+		Name      string   `json:"name"`
+		Indexes   []string `json:"indexes"`
+		QPS       int      `json:"qps"`
+		ExpiresAt int64    `json:"expires_at"`
+	}
+	if err = jsoniter.Unmarshal(body, &req); err != nil {
+		writeHTTPError(w, errors.InvalidArgument("invalid search key request"))
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetSearchKeyQueryRunner(accessToken)
+	runner.SetCreateSearchKeyReq(&database.SearchKeyRequest{
+		Project:   chi.URLParam(r, "project"),
+		Name:      req.Name,
+		Indexes:   req.Indexes,
+		QPS:       req.QPS,
+		ExpiresAt: req.ExpiresAt,
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: true})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	// the key is only returned once, only the hash of its secret is stored
+	writeHTTPResponse(w, map[string]any{
+		"status":     resp.Status,
+		"search_key": toSearchKeyInfo(runner.Keys())[0],
+		"key":        runner.Secret(),
+	})
+}
+
+func (s *apiService) deleteSearchKey(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetSearchKeyQueryRunner(accessToken)
+	runner.SetDeleteSearchKeyReq(&database.SearchKeyRequest{
+		Project: chi.URLParam(r, "project"),
+		Id:      chi.URLParam(r, "key"),
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: true})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
@@ -0,0 +1,146 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package searchdict serves the HTTP variant of the SearchDictionary APIs that manage the synonym sets and the
+// stopword lists of the search indexes.
+package searchdict
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/services/v1/common"
+)
+
+// Handler manages either the synonym sets or the stopword lists of the search index of the request path. The entries
+// are created or replaced by a PUT of the JSON array of the entries, returned by a GET and deleted by a DELETE. The
+// GET and DELETE requests take the comma separated ids of the entries in the "ids" query parameter, the GET returns
+// all the entries without it.
+type Handler struct {
+	client    api.SearchDictionaryClient
+	stopwords bool
+}
+
+func NewHandler(client api.SearchDictionaryClient, stopwords bool) *Handler {
+	return &Handler{client: client, stopwords: stopwords}
+}
+
+func (h *Handler) CreateOrReplace(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponse(w, nil, errors.InvalidArgument(err.Error()))
+		return
+	}
+
+	var entries []jsoniter.RawMessage
+	if err = jsoniter.Unmarshal(body, &entries); err != nil {
+		writeResponse(w, nil, errors.InvalidArgument("invalid request body, expecting an array: %s", err.Error()))
+		return
+	}
+
+	req := &api.CreateOrReplaceDocumentRequest{
+		Project: chi.URLParam(r, "project"),
+		Index:   chi.URLParam(r, "index"),
+	}
+	for _, e := range entries {
+		req.Documents = append(req.Documents, e)
+	}
+
+	var resp *api.CreateOrReplaceDocumentResponse
+	if h.stopwords {
+		resp, err = h.client.CreateOrReplaceStopwords(common.OutgoingContext(r), req)
+	} else {
+		resp, err = h.client.CreateOrReplaceSynonyms(common.OutgoingContext(r), req)
+	}
+	writeResponse(w, resp, err)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	req := &api.GetDocumentRequest{
+		Project: chi.URLParam(r, "project"),
+		Index:   chi.URLParam(r, "index"),
+		Ids:     queryIds(r),
+	}
+
+	var (
+		resp *api.GetDocumentResponse
+		err  error
+	)
+	if h.stopwords {
+		resp, err = h.client.GetStopwords(common.OutgoingContext(r), req)
+	} else {
+		resp, err = h.client.GetSynonyms(common.OutgoingContext(r), req)
+	}
+	writeResponse(w, resp, err)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	req := &api.DeleteDocumentRequest{
+		Project: chi.URLParam(r, "project"),
+		Index:   chi.URLParam(r, "index"),
+		Ids:     queryIds(r),
+	}
+	if len(req.Ids) == 0 {
+		writeResponse(w, nil, errors.InvalidArgument("missing the 'ids' query parameter"))
+		return
+	}
+
+	var (
+		resp *api.DeleteDocumentResponse
+		err  error
+	)
+	if h.stopwords {
+		resp, err = h.client.DeleteStopwords(common.OutgoingContext(r), req)
+	} else {
+		resp, err = h.client.DeleteSynonyms(common.OutgoingContext(r), req)
+	}
+	writeResponse(w, resp, err)
+}
+
+func queryIds(r *http.Request) []string {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); len(id) > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func writeResponse(w http.ResponseWriter, resp any, err error) {
+	if err != nil {
+		e := api.FromStatusError(err)
+		data, _ := jsoniter.Marshal(map[string]any{
+			"error": &api.ErrorDetails{Code: api.CodeToString(e.Code), Message: e.Message},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.ToHTTPCode(e.Code))
+		_, _ = w.Write(data)
+		return
+	}
+
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package searchdict
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"google.golang.org/grpc"
+)
+
+type testClient struct {
+	method string
+	req    any
+	err    error
+}
+
+func (c *testClient) CreateOrReplaceSynonyms(_ context.Context, in *api.CreateOrReplaceDocumentRequest, _ ...grpc.CallOption) (*api.CreateOrReplaceDocumentResponse, error) {
+	c.method, c.req = "CreateOrReplaceSynonyms", in
+	return &api.CreateOrReplaceDocumentResponse{Status: []*api.DocStatus{{Id: "shoes"}}}, c.err
+}
+
+func (c *testClient) GetSynonyms(_ context.Context, in *api.GetDocumentRequest, _ ...grpc.CallOption) (*api.GetDocumentResponse, error) {
+	c.method, c.req = "GetSynonyms", in
+	return &api.GetDocumentResponse{Documents: []*api.SearchHit{{Data: []byte(`{"id":"shoes","synonyms":["sneaker","trainer"]}`)}}}, c.err
+}
+
+func (c *testClient) DeleteSynonyms(_ context.Context, in *api.DeleteDocumentRequest, _ ...grpc.CallOption) (*api.DeleteDocumentResponse, error) {
+	c.method, c.req = "DeleteSynonyms", in
+	return &api.DeleteDocumentResponse{}, c.err
+}
+
+func (c *testClient) CreateOrReplaceStopwords(_ context.Context, in *api.CreateOrReplaceDocumentRequest, _ ...grpc.CallOption) (*api.CreateOrReplaceDocumentResponse, error) {
+	c.method, c.req = "CreateOrReplaceStopwords", in
+	return &api.CreateOrReplaceDocumentResponse{}, c.err
+}
+
+func (c *testClient) GetStopwords(_ context.Context, in *api.GetDocumentRequest, _ ...grpc.CallOption) (*api.GetDocumentResponse, error) {
+	c.method, c.req = "GetStopwords", in
+	return &api.GetDocumentResponse{}, c.err
+}
+
+func (c *testClient) DeleteStopwords(_ context.Context, in *api.DeleteDocumentRequest, _ ...grpc.CallOption) (*api.DeleteDocumentResponse, error) {
+	c.method, c.req = "DeleteStopwords", in
+	return &api.DeleteDocumentResponse{}, c.err
+}
+
+func serve(client *testClient, method string, path string, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	for p, h := range map[string]*Handler{"synonyms": NewHandler(client, false), "stopwords": NewHandler(client, true)} {
+		router.Put("/v1/projects/{project}/search/indexes/{index}/"+p, h.CreateOrReplace)
+		router.Get("/v1/projects/{project}/search/indexes/{index}/"+p, h.Get)
+		router.Delete("/v1/projects/{project}/search/indexes/{index}/"+p, h.Delete)
+	}
+
+	r := httptest.NewRequest(method, "/v1/projects/p1/search/indexes/products/"+path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	client := &testClient{}
+	w := serve(client, http.MethodPut, "synonyms", `[{"id":"shoes","synonyms":["sneaker","trainer"]}]`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "CreateOrReplaceSynonyms", client.method)
+	require.Equal(t, &api.CreateOrReplaceDocumentRequest{
+		Project:   "p1",
+		Index:     "products",
+		Documents: [][]byte{[]byte(`{"id":"shoes","synonyms":["sneaker","trainer"]}`)},
+	}, client.req)
+	require.JSONEq(t, `{"status":[{"id":"shoes","error":null}]}`, w.Body.String())
+
+	w = serve(client, http.MethodGet, "synonyms?ids=shoes,%20cars", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "GetSynonyms", client.method)
+	require.Equal(t, []string{"shoes", "cars"}, client.req.(*api.GetDocumentRequest).Ids)
+	require.JSONEq(t, `{"documents":[{"data":{"id":"shoes","synonyms":["sneaker","trainer"]}}]}`, w.Body.String())
+
+	w = serve(client, http.MethodGet, "stopwords", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "GetStopwords", client.method)
+	require.Empty(t, client.req.(*api.GetDocumentRequest).Ids)
+
+	w = serve(client, http.MethodDelete, "stopwords?ids=common", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "DeleteStopwords", client.method)
+	require.Equal(t, []string{"common"}, client.req.(*api.DeleteDocumentRequest).Ids)
+
+	client.method = ""
+	w = serve(client, http.MethodDelete, "synonyms", "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Empty(t, client.method)
+
+	w = serve(client, http.MethodPut, "stopwords", `{"id":"common"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Empty(t, client.method)
+
+	client.err = errors.NotFound("search index not found 'products'")
+	w = serve(client, http.MethodPut, "stopwords", `[{"id":"common","stopwords":["the"]}]`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"error": {"code": "NOT_FOUND", "message": "search index not found 'products'"}}`, w.Body.String())
+}
@@ -37,19 +37,20 @@ type Service interface {
 func GetRegisteredServicesRealtime(kvStore kv.TxStore, searchStore search.Store, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager) []Service {
 	var v1Services []Service
 	v1Services = append(v1Services, newRealtimeService(kvStore, searchStore, tenantMgr, txMgr))
-	v1Services = append(v1Services, newHealthService(txMgr))
+	v1Services = append(v1Services, newHealthService(txMgr, searchStore))
 	v1Services = append(v1Services, newObservabilityService(tenantMgr))
 	return v1Services
 }
 
 func GetRegisteredServices(kvStore kv.TxStore, searchStore search.Store, tenantMgr *metadata.TenantManager, txMgr *transaction.Manager, forSearchTxMgr *transaction.Manager, bProvider billing.Provider) []Service {
 	var v1Services []Service
-	v1Services = append(v1Services, newHealthService(txMgr))
+	v1Services = append(v1Services, newHealthService(txMgr, searchStore))
 
 	userStore := metadata.NewUserStore(metadata.DefaultNameRegistry)
 
 	authProvider := auth.NewProvider(userStore, txMgr)
-	v1Services = append(v1Services, newApiService(kvStore, searchStore, tenantMgr, txMgr, authProvider))
+	apiSvc := newApiService(kvStore, searchStore, tenantMgr, txMgr, authProvider)
+	v1Services = append(v1Services, apiSvc)
 
 	if config.DefaultConfig.Auth.EnableOauth {
 		v1Services = append(v1Services, newAuthService(authProvider, auth.NewDefaultUsersManager(tenantMgr)))
@@ -60,7 +61,9 @@ func GetRegisteredServices(kvStore kv.TxStore, searchStore search.Store, tenantM
 
 	v1Services = append(v1Services, newObservabilityService(tenantMgr))
 	v1Services = append(v1Services, newCacheService(tenantMgr, txMgr))
-	v1Services = append(v1Services, newSearchService(searchStore, tenantMgr, forSearchTxMgr))
+	searchSvc := newSearchService(searchStore, tenantMgr, forSearchTxMgr)
+	v1Services = append(v1Services, searchSvc)
+	v1Services = append(v1Services, newMultiSearchService(apiSvc, searchSvc))
 	v1Services = append(v1Services, newBillingService(bProvider, tenantMgr))
 
 	return v1Services
@@ -0,0 +1,217 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	jsoniter "github.com/json-iterator/go"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/services/v1/database"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	templatesPath       = fullProjectPath + "/templates"
+	templatePathPattern = templatesPath + "/{template}"
+
+	readOnlyRole = "ro"
+)
+
+type templateInfo struct {
+	Name      string              `json:"name"`
+	Schema    jsoniter.RawMessage `json:"schema"`
+	Version   uint32              `json:"version"`
+	CreatedAt int64               `json:"created_at"`
+	UpdatedAt int64               `json:"updated_at"`
+}
+
+func toTemplateInfo(templates []metadata.SchemaTemplate) []templateInfo {
+	info := make([]templateInfo, len(templates))
+	for i, t := range templates {
+		info[i] = templateInfo{
+			Name:      t.Name,
+			Schema:    t.Schema,
+			Version:   t.Version,
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		}
+	}
+
+	return info
+}
+
+// registerTemplateHTTP registers the REST endpoints to manage the schema templates of a project. A collection extends
+// a template by setting "extends" in its schema.
+func (s *apiService) registerTemplateHTTP(router chi.Router) {
+	router.Get(apiPathPrefix+templatesPath, s.listTemplates)
+	router.Get(apiPathPrefix+templatePathPattern, s.getTemplate)
+	router.Post(apiPathPrefix+templatePathPattern, s.createOrUpdateTemplate)
+	router.Delete(apiPathPrefix+templatePathPattern, s.deleteTemplate)
+}
+
+func (s *apiService) listTemplates(w http.ResponseWriter, r *http.Request) {
+	if err := mustNotBeSearchOnly(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetTemplateQueryRunner(accessToken)
+	runner.SetListTemplatesReq(&database.TemplateRequest{Project: chi.URLParam(r, "project")})
+
+	if _, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{}); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"templates": toTemplateInfo(runner.Templates())})
+}
+
+func (s *apiService) getTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := mustNotBeSearchOnly(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetTemplateQueryRunner(accessToken)
+	runner.SetGetTemplateReq(&database.TemplateRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "template"),
+	})
+
+	if _, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{}); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, toTemplateInfo(runner.Templates())[0])
+}
+
+func (s *apiService) createOrUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeHTTPError(w, errors.InvalidArgument("failed to read request body"))
+		return
+	}
+
+	var req struct {
+		Schema jsoniter.RawMessage `json:"schema"`
+	}
+	if err = jsoniter.Unmarshal(body, &req); err != nil || len(req.Schema) == 0 {
+		writeHTTPError(w, errors.InvalidArgument("missing template schema"))
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetTemplateQueryRunner(accessToken)
+	runner.SetCreateOrUpdateTemplateReq(&database.TemplateRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "template"),
+		Schema:  req.Schema,
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{
+		MetadataChange:     true,
+		InstantVerTracking: true,
+	})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{
+		"status":               resp.Status,
+		"template":             toTemplateInfo(runner.Templates())[0],
+		"collections_modified": resp.ModifiedCount,
+	})
+}
+
+func (s *apiService) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := mustBeEditor(r); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	accessToken, _ := request.GetAccessToken(r.Context())
+	runner := s.runnerFactory.GetTemplateQueryRunner(accessToken)
+	runner.SetDeleteTemplateReq(&database.TemplateRequest{
+		Project: chi.URLParam(r, "project"),
+		Name:    chi.URLParam(r, "template"),
+	})
+
+	resp, err := s.sessions.Execute(r.Context(), runner, database.ReqOptions{MetadataChange: true})
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeHTTPResponse(w, map[string]any{"status": resp.Status})
+}
+
+// mustBeEditor rejects the requests of the read-only users and the search-only keys, as these endpoints are not going
+// through the gRPC authorization interceptor.
+func mustBeEditor(r *http.Request) error {
+	if role, ok := request.GetCallerRole(r.Context()); ok && (role == readOnlyRole || role == request.SearchOnlyRole) {
+		return errors.PermissionDenied("you are not allowed to perform this action")
+	}
+
+	return nil
+}
+
+// mustNotBeSearchOnly rejects the requests made with the search-only keys, which can only run the search queries.
+func mustNotBeSearchOnly(r *http.Request) error {
+	if role, ok := request.GetCallerRole(r.Context()); ok && role == request.SearchOnlyRole {
+		return errors.PermissionDenied("you are not allowed to perform this action")
+	}
+
+	return nil
+}
+
+func writeHTTPResponse(w http.ResponseWriter, resp any) {
+	data, err := jsoniter.Marshal(resp)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	data, mErr := api.MarshalStatus(st.Proto())
+	if mErr != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(st.Code()))
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,81 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slowlog
+
+import (
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/query/filter"
+)
+
+// NormalizeFilter returns the filter with its values replaced by "?" and the fields of its objects sorted, so that the
+// reads differing only by the values of their filter have the same shape. The conditions of "$and" and "$or" keep their
+// order. An invalid filter is returned as is.
+func NormalizeFilter(reqFilter []byte) string {
+	if len(reqFilter) == 0 {
+		return "{}"
+	}
+
+	var parsed any
+	if err := jsoniter.Unmarshal(reqFilter, &parsed); err != nil {
+		return string(reqFilter)
+	}
+
+	var sb strings.Builder
+	writeShape(&sb, parsed)
+
+	return sb.String()
+}
+
+func writeShape(sb *strings.Builder, value any) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		sb.WriteString(`"?"`)
+		return
+	}
+
+	fields := make([]string, 0, len(object))
+	for field := range object {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	sb.WriteString("{")
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		name, _ := jsoniter.MarshalToString(field)
+		sb.WriteString(name + ":")
+
+		conditions, isArray := object[field].([]any)
+		if !isArray || (field != string(filter.AndOP) && field != string(filter.OrOP)) {
+			writeShape(sb, object[field])
+			continue
+		}
+
+		sb.WriteString("[")
+		for j, c := range conditions {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			writeShape(sb, c)
+		}
+		sb.WriteString("]")
+	}
+	sb.WriteString("}")
+}
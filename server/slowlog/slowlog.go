@@ -0,0 +1,153 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slowlog records the reads which take longer than a threshold, with their normalized filter, the plan that
+// served them and the number of the rows they scanned and returned. The last reads are kept in memory by each server,
+// to be returned by the SlowQueries API, and each one is logged as it is recorded.
+package slowlog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+// Query is a read as measured by the query runner.
+type Query struct {
+	Namespace  string
+	Project    string
+	Branch     string
+	Collection string
+	Filter     []byte
+	Sort       []byte
+	Plan       string
+	Scanned    int64
+	Returned   int64
+	Duration   time.Duration
+}
+
+type entry struct {
+	namespace string
+	project   string
+	branch    string
+	query     *api.SlowQuery
+}
+
+// Log keeps the last slow reads in a ring.
+type Log struct {
+	sync.Mutex
+
+	cfg     config.SlowQueryLogConfig
+	entries []*entry
+	next    int
+	now     func() time.Time
+}
+
+var slowLog = NewLog(config.SlowQueryLogConfig{})
+
+func NewLog(cfg config.SlowQueryLogConfig) *Log {
+	return &Log{
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Init sets the log of the server, the reads are not recorded if it is disabled.
+func Init(cfg config.SlowQueryLogConfig) {
+	slowLog = NewLog(cfg)
+}
+
+// Record records the read if it took longer than the threshold.
+func Record(q *Query) {
+	slowLog.Record(q)
+}
+
+// Entries returns the slow reads of the project branch, the latest first.
+func Entries(namespace string, project string, branch string) []*api.SlowQuery {
+	return slowLog.Entries(namespace, project, branch)
+}
+
+// Threshold returns the duration after which a read is recorded.
+func Threshold() time.Duration {
+	return slowLog.cfg.Threshold
+}
+
+// IsSlow returns true if a read taking the duration is recorded.
+func (l *Log) IsSlow(duration time.Duration) bool {
+	return l.cfg.Enabled && l.cfg.MaxEntries > 0 && duration >= l.cfg.Threshold
+}
+
+func (l *Log) Record(q *Query) {
+	if !l.IsSlow(q.Duration) {
+		return
+	}
+
+	e := &entry{
+		namespace: q.Namespace,
+		project:   q.Project,
+		branch:    q.Branch,
+		query: &api.SlowQuery{
+			Collection: q.Collection,
+			Filter:     NormalizeFilter(q.Filter),
+			Sort:       string(q.Sort),
+			Plan:       q.Plan,
+			Scanned:    q.Scanned,
+			Returned:   q.Returned,
+			DurationMs: q.Duration.Milliseconds(),
+			At:         l.now().UTC().Format(time.RFC3339Nano),
+		},
+	}
+
+	log.Warn().
+		Str("namespace", e.namespace).
+		Str("project", e.project).
+		Str("branch", e.branch).
+		Str("collection", e.query.Collection).
+		Str("filter", e.query.Filter).
+		Str("sort", e.query.Sort).
+		Str("plan", e.query.Plan).
+		Int64("scanned", e.query.Scanned).
+		Int64("returned", e.query.Returned).
+		Dur("duration", q.Duration).
+		Msg("slow query")
+
+	l.Lock()
+	defer l.Unlock()
+
+	if len(l.entries) < l.cfg.MaxEntries {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+func (l *Log) Entries(namespace string, project string, branch string) []*api.SlowQuery {
+	l.Lock()
+	defer l.Unlock()
+
+	queries := []*api.SlowQuery{}
+	// the entries before next are the latest ones once the ring is full
+	for i := 1; i <= len(l.entries); i++ {
+		e := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if e.namespace == namespace && e.project == project && e.branch == branch {
+			queries = append(queries, e.query)
+		}
+	}
+
+	return queries
+}
@@ -0,0 +1,91 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slowlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestLog(t *testing.T) {
+	l := NewLog(config.SlowQueryLogConfig{Enabled: true, Threshold: 100 * time.Millisecond, MaxEntries: 2})
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	record := func(collection string, duration time.Duration) {
+		l.Record(&Query{
+			Namespace:  "ns1",
+			Project:    "p1",
+			Branch:     "main",
+			Collection: collection,
+			Filter:     []byte(`{"a":1}`),
+			Plan:       "full_scan",
+			Scanned:    100,
+			Returned:   1,
+			Duration:   duration,
+		})
+	}
+
+	record("c1", 50*time.Millisecond)
+	require.Empty(t, l.Entries("ns1", "p1", "main"))
+
+	record("c1", 150*time.Millisecond)
+	entries := l.Entries("ns1", "p1", "main")
+	require.Len(t, entries, 1)
+	require.Equal(t, "c1", entries[0].Collection)
+	require.Equal(t, `{"a":"?"}`, entries[0].Filter)
+	require.Equal(t, "full_scan", entries[0].Plan)
+	require.Equal(t, int64(100), entries[0].Scanned)
+	require.Equal(t, int64(1), entries[0].Returned)
+	require.Equal(t, int64(150), entries[0].DurationMs)
+	require.Equal(t, "2023-01-02T03:04:05Z", entries[0].At)
+	require.Empty(t, l.Entries("ns1", "p1", "other"))
+	require.Empty(t, l.Entries("ns2", "p1", "main"))
+
+	// the oldest entries are replaced once the log is full
+	record("c2", time.Second)
+	record("c3", time.Second)
+	entries = l.Entries("ns1", "p1", "main")
+	require.Len(t, entries, 2)
+	require.Equal(t, "c3", entries[0].Collection)
+	require.Equal(t, "c2", entries[1].Collection)
+
+	disabled := NewLog(config.SlowQueryLogConfig{Threshold: 100 * time.Millisecond, MaxEntries: 2})
+	disabled.Record(&Query{Namespace: "ns1", Project: "p1", Branch: "main", Duration: time.Second})
+	require.Empty(t, disabled.Entries("ns1", "p1", "main"))
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	cases := []struct {
+		filter   string
+		expShape string
+	}{
+		{``, `{}`},
+		{`{}`, `{}`},
+		{`{"b":1,"a":"x"}`, `{"a":"?","b":"?"}`},
+		{`{"a":{"$gt":1,"$lt":5}}`, `{"a":{"$gt":"?","$lt":"?"}}`},
+		{`{"a":{"$in":[1,2,3]}}`, `{"a":{"$in":"?"}}`},
+		{`{"address":{"city":"x"}}`, `{"address":{"city":"?"}}`},
+		{`{"$or":[{"b":1},{"a":{"$eq":null}}]}`, `{"$or":[{"b":"?"},{"a":{"$eq":"?"}}]}`},
+		{`{"$and":[{"a":1},{"$or":[{"b":true},{"c":[1]}]}]}`, `{"$and":[{"a":"?"},{"$or":[{"b":"?"},{"c":"?"}]}]}`},
+		{`{"a":`, `{"a":`},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expShape, NormalizeFilter([]byte(c.filter)), c.filter)
+	}
+}
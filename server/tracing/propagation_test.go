@@ -0,0 +1,45 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestExtractIncoming(t *testing.T) {
+	t.Run("traceparent", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		))
+
+		sc := trace.SpanContextFromContext(ExtractIncoming(ctx))
+		require.True(t, sc.IsRemote())
+		require.True(t, sc.IsSampled())
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+		require.Equal(t, "00f067aa0ba902b7", sc.SpanID().String())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", "00-invalid"))
+		require.False(t, trace.SpanContextFromContext(ExtractIncoming(ctx)).IsValid())
+	})
+	t.Run("no_metadata", func(t *testing.T) {
+		require.False(t, trace.SpanContextFromContext(ExtractIncoming(context.Background())).IsValid())
+	})
+}
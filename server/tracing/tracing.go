@@ -23,6 +23,8 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
@@ -79,15 +81,33 @@ func initDatadog(config *config.Config) (func(), error) {
 	return func() { tracer.Stop(); profiler.Stop() }, nil
 }
 
-func tracerProvider(url string) error {
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	if err != nil {
-		return err
-	}
+func tracerProvider(c *config.Config) error {
+	var opts []tracesdk.TracerProviderOption
 
-	OpenTracerProvider = tracesdk.NewTracerProvider(
+	// Jaeger samples all the traces, as it always did, without it the OTLP sample rate applies. Either way the
+	// sampling decision of the caller of a request with a traceparent header is honored.
+	sampler := tracesdk.ParentBased(tracesdk.AlwaysSample())
+	if IsJaegerTracingEnabled(c) {
+		exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(c.Tracing.Jaeger.Url)))
+		if err != nil {
+			return err
+		}
 		// Always be sure to batch in production.
-		tracesdk.WithBatcher(exp),
+		opts = append(opts, tracesdk.WithBatcher(exp))
+	} else {
+		sampler = tracesdk.ParentBased(tracesdk.TraceIDRatioBased(c.Tracing.OTLP.SampleRate))
+	}
+
+	if IsOTLPTracingEnabled(c) {
+		exp, err := otlptracegrpc.New(context.Background(), getOTLPOptions(c)...)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, tracesdk.WithBatcher(exp))
+	}
+
+	OpenTracerProvider = tracesdk.NewTracerProvider(append(opts,
+		tracesdk.WithSampler(sampler),
 		// Record information about this application in a Resource.
 		tracesdk.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
@@ -95,16 +115,31 @@ func tracerProvider(url string) error {
 			attribute.String("environment", config.GetEnvironment()),
 			attribute.Int64("ID", 1),
 		)),
-	)
+	)...)
 	return nil
 }
 
-func initJaeger(config *config.Config) func() {
-	err := tracerProvider(config.Tracing.Jaeger.Url)
+func getOTLPOptions(c *config.Config) []otlptracegrpc.Option {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.Tracing.OTLP.Endpoint)}
+	if c.Tracing.OTLP.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(c.Tracing.OTLP.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(c.Tracing.OTLP.Headers))
+	}
+
+	return opts
+}
+
+func initOpenTelemetry(config *config.Config) func() {
+	err := tracerProvider(config)
 	if err != nil {
 		ulog.E(err)
+		// spans are still created, only not exported
+		OpenTracerProvider = tracesdk.NewTracerProvider()
 	}
 	otel.SetTracerProvider(OpenTracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
 
 	OpenTracer = OpenTracerProvider.Tracer(util.Service)
 
@@ -119,20 +154,30 @@ func IsJaegerTracingEnabled(config *config.Config) bool {
 	return config.Tracing.Enabled && config.Tracing.Jaeger.Enabled
 }
 
+func IsOTLPTracingEnabled(config *config.Config) bool {
+	return config.Tracing.Enabled && config.Tracing.OTLP.Enabled
+}
+
+// IsOpenTelemetryEnabled returns true if the spans are exported to Jaeger or to an OTLP collector.
+func IsOpenTelemetryEnabled(config *config.Config) bool {
+	return IsJaegerTracingEnabled(config) || IsOTLPTracingEnabled(config)
+}
+
 func IsDatadogTracingEnabled(config *config.Config) bool {
 	return config.Tracing.Enabled && config.Tracing.Datadog.Enabled
 }
 
 func IsTracingEnabled(config *config.Config) bool {
-	return config.Tracing.Enabled && (config.Tracing.Datadog.Enabled || config.Tracing.Jaeger.Enabled)
+	return config.Tracing.Enabled && (config.Tracing.Datadog.Enabled || config.Tracing.Jaeger.Enabled ||
+		config.Tracing.OTLP.Enabled)
 }
 
 func InitTracer(config *config.Config) (func(), error) {
 	var tracerClosers []func()
 
-	if IsJaegerTracingEnabled(config) {
-		jaegerCloser := initJaeger(config)
-		tracerClosers = append(tracerClosers, jaegerCloser)
+	if IsOpenTelemetryEnabled(config) {
+		openTelemetryCloser := initOpenTelemetry(config)
+		tracerClosers = append(tracerClosers, openTelemetryCloser)
 	}
 
 	if IsDatadogTracingEnabled(config) {
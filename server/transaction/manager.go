@@ -57,12 +57,15 @@ type Tx interface {
 
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
+	Savepoint(ctx context.Context, name string) error
+	RollbackToSavepoint(ctx context.Context, name string) error
 }
 
 // SessionCtx is used to store any baggage for the lifetime of the transaction. We use it to stage the database inside
 // a transaction when the transaction is performing any DDLs.
 type SessionCtx struct {
-	db any
+	db       any
+	onCommit []func()
 }
 
 func (c *SessionCtx) StageDatabase(db any) {
@@ -73,6 +76,17 @@ func (c *SessionCtx) GetStagedDatabase() any {
 	return c.db
 }
 
+// OnCommit registers a function to call once the transaction is committed, it is not called if the transaction is
+// rolled back.
+func (c *SessionCtx) OnCommit(fn func()) {
+	c.onCommit = append(c.onCommit, fn)
+}
+
+// GetCommitHooks returns the functions registered to be called once the transaction is committed.
+func (c *SessionCtx) GetCommitHooks() []func() {
+	return c.onCommit
+}
+
 // Manager is used to track all the sessions and provide all the functionality related to transactions. Once created
 // this will create a session tracker for tracking the sessions.
 
@@ -119,6 +133,13 @@ type TxSession struct {
 	kTx     kv.Tx
 	state   sessionState
 	txCtx   *api.TransactionCtx
+	// stagedAt is the database staged when each savepoint was set.
+	stagedAt []stagedAtSavepoint
+}
+
+type stagedAtSavepoint struct {
+	name string
+	db   any
 }
 
 func newTxSession(kv kv.TxStore) (*TxSession, error) {
@@ -327,6 +348,54 @@ func (s *TxSession) Rollback(ctx context.Context) error {
 	return err
 }
 
+// Savepoint marks the state of the transaction, the writes done after it can be undone by RollbackToSavepoint without
+// aborting the transaction.
+func (s *TxSession) Savepoint(ctx context.Context, name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.validateSession(); err != nil {
+		return err
+	}
+
+	if err := s.kTx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	s.stagedAt = append(s.stagedAt, stagedAtSavepoint{name: name, db: s.context.db})
+
+	return nil
+}
+
+// RollbackToSavepoint undoes the writes done after the last savepoint with the name. The schema changes staged in
+// the transaction can't be undone, rolling back to a savepoint set before a schema change fails.
+func (s *TxSession) RollbackToSavepoint(ctx context.Context, name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.validateSession(); err != nil {
+		return err
+	}
+
+	i := len(s.stagedAt) - 1
+	for i >= 0 && s.stagedAt[i].name != name {
+		i--
+	}
+	if i >= 0 && s.stagedAt[i].db != s.context.db {
+		return errors.InvalidArgument("schema changes done after savepoint '%s' can't be rolled back", name)
+	}
+
+	if err := s.kTx.RollbackToSavepoint(ctx, name); err != nil {
+		return err
+	}
+
+	if i >= 0 {
+		s.stagedAt = s.stagedAt[:i+1]
+	}
+
+	return nil
+}
+
 func (s *TxSession) Context() *SessionCtx {
 	return s.context
 }
@@ -0,0 +1,174 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// sample is the value of a counter of the hourly totals of a collection.
+type sample struct {
+	hour       int64
+	project    string
+	collection string
+	counter    string
+	value      int64
+}
+
+// Query returns the consumption of the namespace selected by the query. The range of the query is widened to the
+// periods of the rollup it overlaps.
+func (m *Meter) Query(ctx context.Context, namespace string, q *api.UsageQuery) (*api.UsageResponse, error) {
+	table, err := m.table(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	period := rollupPeriod(q.Rollup)
+	start := q.Start.UTC().Truncate(period)
+	end := q.End.UTC().Truncate(period)
+	if end.Before(q.End) {
+		end = end.Add(period)
+	}
+
+	tx, err := m.kvStore.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	iter, err := tx.AtomicReadRange(ctx, table, kv.BuildKey(start.Unix()), kv.BuildKey(end.Unix()), true)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		row     kv.FdbBaseKeyValue[int64]
+		samples []*sample
+	)
+	for iter.Next(&row) {
+		if s, ok := decodeSample(&row); ok {
+			samples = append(samples, s)
+		}
+	}
+	if err = iter.Err(); err != nil {
+		return nil, err
+	}
+
+	buckets, totals := aggregate(samples, q, period)
+
+	return &api.UsageResponse{
+		Namespace: namespace,
+		Rollup:    q.Rollup,
+		Start:     start,
+		End:       end,
+		Buckets:   buckets,
+		Totals:    totals,
+	}, nil
+}
+
+func rollupPeriod(rollup string) time.Duration {
+	if rollup == api.UsageRollupDay {
+		return 24 * time.Hour
+	}
+
+	return time.Hour
+}
+
+func decodeSample(row *kv.FdbBaseKeyValue[int64]) (*sample, bool) {
+	if len(row.Key) != 4 {
+		return nil, false
+	}
+
+	hour, ok := row.Key[0].(int64)
+	if !ok {
+		return nil, false
+	}
+	project, _ := row.Key[1].(string)
+	collection, _ := row.Key[2].(string)
+	counter, _ := row.Key[3].(string)
+
+	return &sample{hour: hour, project: project, collection: collection, counter: counter, value: row.Data}, true
+}
+
+// aggregate rolls the hourly samples up by period and collection. The units are summed, the stored bytes of a
+// collection in a period is the peak of its hourly samples. The totals of a period are the sums of its buckets.
+func aggregate(samples []*sample, q *api.UsageQuery, period time.Duration) ([]*api.UsageBucket, []*api.UsageBucket) {
+	type bucketKey struct {
+		start      int64
+		project    string
+		collection string
+	}
+
+	buckets := map[bucketKey]*api.UsageBucket{}
+	for _, s := range samples {
+		if (q.Project != "" && q.Project != s.project) || (q.Collection != "" && q.Collection != s.collection) {
+			continue
+		}
+
+		start := time.Unix(s.hour, 0).UTC().Truncate(period)
+		key := bucketKey{start: start.Unix(), project: s.project, collection: s.collection}
+		b, ok := buckets[key]
+		if !ok {
+			b = &api.UsageBucket{Start: start, Project: s.project, Collection: s.collection}
+			buckets[key] = b
+		}
+
+		switch s.counter {
+		case readUnits:
+			b.ReadUnits += s.value
+		case writeUnits:
+			b.WriteUnits += s.value
+		case searchUnits:
+			b.SearchUnits += s.value
+		case storageBytes:
+			if s.value > b.StorageBytes {
+				b.StorageBytes = s.value
+			}
+		}
+	}
+
+	sorted := make([]*api.UsageBucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, b)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].Start.Equal(sorted[j].Start) {
+			return sorted[i].Start.Before(sorted[j].Start)
+		}
+		if sorted[i].Project != sorted[j].Project {
+			return sorted[i].Project < sorted[j].Project
+		}
+		return sorted[i].Collection < sorted[j].Collection
+	})
+
+	totals := []*api.UsageBucket{}
+	for _, b := range sorted {
+		if len(totals) == 0 || !totals[len(totals)-1].Start.Equal(b.Start) {
+			totals = append(totals, &api.UsageBucket{Start: b.Start})
+		}
+		t := totals[len(totals)-1]
+		t.ReadUnits += b.ReadUnits
+		t.WriteUnits += b.WriteUnits
+		t.SearchUnits += b.SearchUnits
+		t.StorageBytes += b.StorageBytes
+	}
+
+	return sorted, totals
+}
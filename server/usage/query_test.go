@@ -0,0 +1,62 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+func TestAggregate(t *testing.T) {
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	hour := func(h int) int64 { return day.Add(time.Duration(h) * time.Hour).Unix() }
+
+	samples := []*sample{
+		{hour: hour(1), project: "p1", collection: "users", counter: readUnits, value: 10},
+		{hour: hour(2), project: "p1", collection: "users", counter: readUnits, value: 5},
+		{hour: hour(2), project: "p1", collection: "users", counter: writeUnits, value: 3},
+		{hour: hour(1), project: "p1", collection: "users", counter: storageBytes, value: 100},
+		{hour: hour(2), project: "p1", collection: "users", counter: storageBytes, value: 300},
+		{hour: hour(3), project: "p1", collection: "users", counter: storageBytes, value: 200},
+		{hour: hour(1), project: "p1", collection: "orders", counter: searchUnits, value: 7},
+		{hour: hour(25), project: "p2", collection: "events", counter: writeUnits, value: 1},
+	}
+
+	t.Run("hour", func(t *testing.T) {
+		buckets, totals := aggregate(samples, &api.UsageQuery{Project: "p1", Collection: "users"}, time.Hour)
+		require.Equal(t, []*api.UsageBucket{
+			{Start: day.Add(time.Hour), Project: "p1", Collection: "users", ReadUnits: 10, StorageBytes: 100},
+			{Start: day.Add(2 * time.Hour), Project: "p1", Collection: "users", ReadUnits: 5, WriteUnits: 3, StorageBytes: 300},
+			{Start: day.Add(3 * time.Hour), Project: "p1", Collection: "users", StorageBytes: 200},
+		}, buckets)
+		require.Len(t, totals, 3)
+	})
+
+	t.Run("day", func(t *testing.T) {
+		buckets, totals := aggregate(samples, &api.UsageQuery{}, 24*time.Hour)
+		require.Equal(t, []*api.UsageBucket{
+			{Start: day, Project: "p1", Collection: "orders", SearchUnits: 7},
+			{Start: day, Project: "p1", Collection: "users", ReadUnits: 15, WriteUnits: 3, StorageBytes: 300},
+			{Start: day.Add(24 * time.Hour), Project: "p2", Collection: "events", WriteUnits: 1},
+		}, buckets)
+		require.Equal(t, []*api.UsageBucket{
+			{Start: day, ReadUnits: 15, WriteUnits: 3, SearchUnits: 7, StorageBytes: 300},
+			{Start: day.Add(24 * time.Hour), WriteUnits: 1},
+		}, totals)
+	})
+}
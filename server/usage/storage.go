@@ -0,0 +1,127 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+type collectionKey struct {
+	project    string
+	collection string
+}
+
+func (m *Meter) storageLoop() {
+	interval := m.cfg.StorageInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.sampleStorage(context.Background())
+	}
+}
+
+// sampleStorage sets the stored bytes of the collections of all the namespaces for the current hour.
+func (m *Meter) sampleStorage(ctx context.Context) {
+	hour := m.now().Truncate(time.Hour).Unix()
+
+	for _, tenant := range m.tenants.AllTenants(ctx) {
+		sizes, err := collectionSizes(ctx, tenant)
+		if err == nil {
+			table := tenant.Encoder.EncodeUsageTableName(tenant.GetNamespace())
+			err = m.setStorage(ctx, table, hour, sizes)
+		}
+		if err != nil {
+			log.Err(err).Str("ns", tenant.GetNamespace().StrId()).Msg("failed to sample the storage usage")
+		}
+	}
+}
+
+// collectionSizes returns the stored bytes of the collections of the namespace, summed over their branches.
+func collectionSizes(ctx context.Context, tenant *metadata.Tenant) (map[collectionKey]int64, error) {
+	sizes := map[collectionKey]int64{}
+	for _, projName := range tenant.ListProjects(ctx) {
+		project, err := tenant.GetProject(projName)
+		if err != nil {
+			// project was dropped in the meantime
+			continue
+		}
+
+		for _, db := range project.GetDatabaseWithBranches() {
+			for _, coll := range db.ListCollection() {
+				size, err := tenant.CollectionSize(ctx, db, coll)
+				if err != nil {
+					return nil, err
+				}
+				sizes[collectionKey{project: projName, collection: coll.Name}] += size.StoredBytes
+			}
+		}
+	}
+
+	return sizes, nil
+}
+
+// setStorage sets the stored bytes counters of the hour. The counters are atomic like the units, so the sample is
+// set by adding its difference with the current value, read in the transaction.
+func (m *Meter) setStorage(ctx context.Context, table []byte, hour int64, sizes map[collectionKey]int64) error {
+	tx, err := m.kvStore.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for coll, size := range sizes {
+		key := counterKeyParts(counterKey{hour: hour, project: coll.project, collection: coll.collection}, storageBytes)
+
+		current, err := readCounter(ctx, tx, table, key)
+		if err != nil {
+			return err
+		}
+		if size != current {
+			if err = tx.AtomicAdd(ctx, table, key, size-current); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// readCounter returns the value of the counter, zero if it is not set. The counter is read as a prefix because
+// reading a missing counter with AtomicRead fails.
+func readCounter(ctx context.Context, tx kv.Tx, table []byte, key kv.Key) (int64, error) {
+	iter, err := tx.AtomicReadPrefix(ctx, table, key, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		row   kv.FdbBaseKeyValue[int64]
+		value int64
+	)
+	for iter.Next(&row) {
+		value += row.Data
+	}
+
+	return value, iter.Err()
+}
@@ -0,0 +1,225 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usage meters the consumption of the namespaces for the internal chargeback: the read, the write and the
+// search units of the requests and the stored bytes of the collections. The units are counted in memory by project
+// and collection and added in the background to the hourly totals of the namespace, stored as atomic counters in the
+// usage table of the namespace so that the servers add to them without conflicting. The stored bytes are sampled
+// periodically, the hourly value is the last sample of the hour.
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/errors"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/metrics"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// The counters of the hourly totals, the last part of their keys.
+const (
+	readUnits    = "read_units"
+	writeUnits   = "write_units"
+	searchUnits  = "search_units"
+	storageBytes = "storage_bytes"
+)
+
+type counterKey struct {
+	namespace  string
+	hour       int64
+	project    string
+	collection string
+}
+
+type counters struct {
+	read   int64
+	write  int64
+	search int64
+}
+
+// Meter counts the units of the requests and adds them to the hourly totals of their namespaces.
+type Meter struct {
+	sync.Mutex
+
+	cfg     config.UsageConfig
+	kvStore kv.TxStore
+	tenants metadata.TenantGetter
+	pending map[counterKey]*counters
+	now     func() time.Time
+}
+
+var meter *Meter
+
+func NewMeter(cfg config.UsageConfig, kvStore kv.TxStore, tenants metadata.TenantGetter) *Meter {
+	return &Meter{
+		cfg:     cfg,
+		kvStore: kvStore,
+		tenants: tenants,
+		pending: map[counterKey]*counters{},
+		now:     time.Now,
+	}
+}
+
+// Init starts the metering of the server, the consumption is not metered if it is disabled.
+func Init(cfg config.UsageConfig, kvStore kv.TxStore, tenants metadata.TenantGetter) {
+	if !cfg.Enabled {
+		return
+	}
+
+	meter = NewMeter(cfg, kvStore, tenants)
+	meter.Start()
+}
+
+// Record counts the units of the request toward the hourly totals of the collection.
+func Record(namespace string, project string, collection string, status *metrics.RequestStatus) {
+	if meter != nil {
+		meter.Record(namespace, project, collection, status)
+	}
+}
+
+// Query returns the consumption of the namespace the query selects.
+func Query(ctx context.Context, namespace string, q *api.UsageQuery) (*api.UsageResponse, error) {
+	if meter == nil {
+		return nil, errors.Unimplemented("usage metering is disabled")
+	}
+
+	return meter.Query(ctx, namespace, q)
+}
+
+func (m *Meter) Start() {
+	go m.loop()
+	go m.storageLoop()
+}
+
+func (m *Meter) Record(namespace string, project string, collection string, status *metrics.RequestStatus) {
+	if namespace == "" || status == nil {
+		return
+	}
+
+	read, write := status.GetReadUnits(), status.GetWriteUnits()
+	search := status.GetApiSearchUnits() + status.GetCollectionSearchUnits()
+	if read == 0 && write == 0 && search == 0 {
+		return
+	}
+
+	m.add(counterKey{
+		namespace:  namespace,
+		hour:       m.now().Truncate(time.Hour).Unix(),
+		project:    project,
+		collection: collection,
+	}, &counters{read: read, write: write, search: search})
+}
+
+func (m *Meter) add(key counterKey, c *counters) {
+	m.Lock()
+	defer m.Unlock()
+
+	if p, ok := m.pending[key]; ok {
+		p.read += c.read
+		p.write += c.write
+		p.search += c.search
+	} else {
+		m.pending[key] = c
+	}
+}
+
+func (m *Meter) loop() {
+	interval := m.cfg.FlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	log.Info().Dur("interval", interval).Msg("Starting usage metering")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.flush(context.Background())
+	}
+}
+
+// flush adds the counted units to the hourly totals, a transaction per namespace. The units of a namespace which
+// failed to be added are counted again to be retried by the next flush, the units of an unknown namespace are
+// dropped.
+func (m *Meter) flush(ctx context.Context) {
+	m.Lock()
+	pending := m.pending
+	m.pending = map[counterKey]*counters{}
+	m.Unlock()
+
+	byNamespace := map[string]map[counterKey]*counters{}
+	for key, c := range pending {
+		if _, ok := byNamespace[key.namespace]; !ok {
+			byNamespace[key.namespace] = map[counterKey]*counters{}
+		}
+		byNamespace[key.namespace][key] = c
+	}
+
+	for namespace, batch := range byNamespace {
+		table, err := m.table(ctx, namespace)
+		if err != nil {
+			log.Err(err).Str("ns", namespace).Msg("dropping the usage of an unknown namespace")
+			continue
+		}
+
+		if err = m.addTotals(ctx, table, batch); err != nil {
+			log.Err(err).Str("ns", namespace).Msg("failed to add the usage to the hourly totals")
+			for key, c := range batch {
+				m.add(key, c)
+			}
+		}
+	}
+}
+
+func (m *Meter) addTotals(ctx context.Context, table []byte, batch map[counterKey]*counters) error {
+	tx, err := m.kvStore.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for key, c := range batch {
+		for counter, value := range map[string]int64{readUnits: c.read, writeUnits: c.write, searchUnits: c.search} {
+			if value == 0 {
+				continue
+			}
+			if err = tx.AtomicAdd(ctx, table, counterKeyParts(key, counter), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func counterKeyParts(key counterKey, counter string) kv.Key {
+	return kv.BuildKey(key.hour, key.project, key.collection, counter)
+}
+
+func (m *Meter) table(ctx context.Context, namespace string) ([]byte, error) {
+	tenant, err := m.tenants.GetTenant(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, errors.NotFound("namespace '%s' not found", namespace)
+	}
+
+	return tenant.Encoder.EncodeUsageTableName(tenant.GetNamespace()), nil
+}
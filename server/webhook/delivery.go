@@ -0,0 +1,170 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+)
+
+// Headers of the posted events. The signature is the hex encoded HMAC-SHA256 of the timestamp header, a dot and
+// the body, keyed by the webhook secret, the receiver should reject the events with a stale timestamp.
+const (
+	HeaderEventId   = "Tigris-Webhook-Id"
+	HeaderTimestamp = "Tigris-Webhook-Timestamp"
+	HeaderSignature = "Tigris-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+var errQueueFull = fmt.Errorf("webhook delivery queue is full")
+
+// Sign returns the signature of the body posted at the timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte{'.'})
+	_, _ = mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts the event. A failed delivery is scheduled for a retry after the backoff, or dead-lettered once the
+// attempts are exhausted or the webhook rejects the event with a client error.
+func (d *Dispatcher) deliver(dl *delivery) {
+	dl.attempts++
+
+	retry, err := d.post(dl)
+	if err == nil {
+		return
+	}
+	dl.lastErr = err
+
+	if !retry || dl.attempts >= d.cfg.MaxAttempts {
+		d.writeDeadLetter(dl)
+		return
+	}
+
+	time.AfterFunc(d.backoff(dl.attempts), func() {
+		select {
+		case <-d.closed:
+		default:
+			d.enqueue(dl)
+		}
+	})
+}
+
+// post returns whether the failed delivery can be retried.
+func (d *Dispatcher) post(dl *delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, dl.webhook.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventId, dl.event.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(dl.webhook.Secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(dl.webhook.Secret, timestamp, dl.body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook rejected the event with status %d", resp.StatusCode)
+	}
+}
+
+// backoff returns the delay before the retry following the attempt, with a random jitter of up to a half of it.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.InitialBackoff
+	for i := 1; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	//nolint:gosec
+	return delay - time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// deadLetter is the document written to the dead letter collection for an undelivered event.
+type deadLetter struct {
+	Id         string `json:"id"`
+	URL        string `json:"url"`
+	Collection string `json:"collection"`
+	Op         string `json:"op"`
+	// Payload is the body of the event as it was posted.
+	Payload  string `json:"payload"`
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+	FailedAt string `json:"failed_at"`
+}
+
+func (d *Dispatcher) writeDeadLetter(dl *delivery) {
+	doc, err := jsoniter.Marshal(&deadLetter{
+		Id:         dl.event.Id,
+		URL:        dl.webhook.URL,
+		Collection: dl.event.Collection,
+		Op:         dl.event.Op,
+		Payload:    string(dl.body),
+		Error:      dl.lastErr.Error(),
+		Attempts:   dl.attempts,
+		FailedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err == nil {
+		_, err = d.deadLetter(dl.ctx, &api.ImportRequest{
+			Project:          dl.event.Project,
+			Branch:           dl.event.Branch,
+			Collection:       dl.webhook.GetDeadLetterCollection(dl.event.Collection),
+			Documents:        [][]byte{doc},
+			PrimaryKey:       []string{"id"},
+			CreateCollection: true,
+		})
+	}
+	if err != nil {
+		log.Err(err).Str("collection", dl.event.Collection).Str("url", dl.webhook.URL).Str("event", dl.event.Id).
+			Msg("failed to write webhook dead letter, the event is lost")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook posts the changes of the collection documents to the webhooks defined in the collection schemas.
+// The events are delivered asynchronously after the commit, at least once, with retries and exponential backoff. The
+// events that can't be delivered are written to the dead letter collection of the webhook.
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/query/filter"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/server/metadata"
+	"github.com/tigrisdata/tigris/server/request"
+	"github.com/tigrisdata/tigris/server/transaction"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+// ImportFunc writes the dead-lettered events, it creates the dead letter collection if it doesn't exist.
+type ImportFunc func(ctx context.Context, r *api.ImportRequest) (*api.ImportResponse, error)
+
+// TableDecoder returns the database and the collection name of the table of a change event.
+type TableDecoder interface {
+	DecodeTableName(table []byte) (*metadata.Database, string, bool)
+}
+
+// Event is the body posted to the webhook.
+type Event struct {
+	Id         string              `json:"id"`
+	Project    string              `json:"project"`
+	Branch     string              `json:"branch,omitempty"`
+	Collection string              `json:"collection"`
+	Op         string              `json:"op"`
+	Key        jsoniter.RawMessage `json:"key"`
+	Document   jsoniter.RawMessage `json:"document,omitempty"`
+	Timestamp  time.Time           `json:"timestamp"`
+}
+
+type delivery struct {
+	// ctx carries the request metadata of the write, it is used to write the dead letter.
+	ctx      context.Context
+	webhook  *schema.Webhook
+	event    *Event
+	body     []byte
+	attempts int
+	lastErr  error
+}
+
+// Dispatcher is a transaction listener that queues the events of the committed changes for delivery.
+type Dispatcher struct {
+	sync.WaitGroup
+
+	cfg        config.WebhookConfig
+	tables     TableDecoder
+	deadLetter ImportFunc
+	client     *http.Client
+	queue      chan *delivery
+	closed     chan struct{}
+}
+
+func NewDispatcher(cfg config.WebhookConfig, tables TableDecoder, deadLetter ImportFunc) *Dispatcher {
+	d := &Dispatcher{
+		cfg:        cfg,
+		tables:     tables,
+		deadLetter: deadLetter,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan *delivery, cfg.QueueSize),
+		closed:     make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		d.Add(1)
+		go d.worker()
+	}
+
+	return d
+}
+
+// Close stops the workers, the queued events are not delivered.
+func (d *Dispatcher) Close() {
+	close(d.closed)
+	d.Wait()
+}
+
+func (*Dispatcher) OnPreCommit(context.Context, *metadata.Tenant, transaction.Tx, kv.EventListener) error {
+	return nil
+}
+
+func (d *Dispatcher) OnPostCommit(ctx context.Context, _ *metadata.Tenant, listener kv.EventListener) error {
+	for _, event := range listener.GetEvents() {
+		db, collName, ok := d.tables.DecodeTableName(event.Table)
+		if !ok || event.Key == nil || event.Op == kv.OutboxEvent {
+			// event.Key == nil if event comes from drop table, the outbox events are only published to the change
+			// streams
+			continue
+		}
+
+		collection := db.GetCollection(collName)
+		if collection == nil || !collection.HasWebhooks() {
+			continue
+		}
+
+		d.Dispatch(ctx, db.DbName(), db.BranchName(), collection, event)
+	}
+
+	return nil
+}
+
+func (*Dispatcher) OnRollback(context.Context, *metadata.Tenant, kv.EventListener) {}
+
+// Dispatch queues the event for the webhooks of the collection whose filter matches the changed document.
+func (d *Dispatcher) Dispatch(ctx context.Context, project string, branch string, collection *schema.DefaultCollection, event *kv.Event) {
+	var body []byte
+	var ev *Event
+	for _, w := range collection.Webhooks {
+		if !matches(collection, w, event) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			if ev, body, err = newEvent(project, branch, collection.Name, event); err != nil {
+				log.Err(err).Str("collection", collection.Name).Msg("failed to build webhook event")
+				return
+			}
+			ctx = detach(ctx)
+		}
+
+		d.enqueue(&delivery{ctx: ctx, webhook: w, event: ev, body: body})
+	}
+}
+
+func matches(collection *schema.DefaultCollection, w *schema.Webhook, event *kv.Event) bool {
+	if len(w.Filter) == 0 || event.Op == kv.DeleteEvent || event.Data == nil {
+		return true
+	}
+
+	f, err := filter.NewFactory(collection.QueryableFields, nil).WrappedFilter(w.Filter)
+	if err != nil {
+		log.Err(err).Str("collection", collection.Name).Str("url", w.URL).Msg("invalid webhook filter")
+		return false
+	}
+
+	ts, err := event.Data.TimeStampsToJSON()
+	if err != nil {
+		return false
+	}
+
+	return f.Matches(event.Data.RawData, ts)
+}
+
+func newEvent(project string, branch string, collection string, event *kv.Event) (*Event, []byte, error) {
+	// the zeroth element of the key is the index name
+	key, err := jsoniter.Marshal(event.Key[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ev := &Event{
+		Id:         uuid.New().String(),
+		Project:    project,
+		Branch:     branch,
+		Collection: collection,
+		Op:         event.Op,
+		Key:        key,
+		Timestamp:  time.Now().UTC(),
+	}
+	if event.Op != kv.DeleteEvent && event.Data != nil {
+		ev.Document = event.Data.RawData
+	}
+
+	body, err := jsoniter.Marshal(ev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ev, body, nil
+}
+
+// detach returns a context that outlives the request and keeps its metadata.
+func detach(ctx context.Context) context.Context {
+	md, err := request.GetRequestMetadataFromContext(ctx)
+	if err != nil {
+		return context.Background()
+	}
+
+	return md.SaveToContext(context.Background())
+}
+
+// enqueue queues the delivery without blocking, the delivery is dead-lettered if the queue is full.
+func (d *Dispatcher) enqueue(dl *delivery) {
+	select {
+	case d.queue <- dl:
+	default:
+		if dl.lastErr == nil {
+			dl.lastErr = errQueueFull
+		}
+		go d.writeDeadLetter(dl)
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.Done()
+
+	for {
+		select {
+		case <-d.closed:
+			return
+		case dl := <-d.queue:
+			d.deliver(dl)
+		}
+	}
+}
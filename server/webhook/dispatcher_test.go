@@ -0,0 +1,230 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/server/config"
+	"github.com/tigrisdata/tigris/store/kv"
+)
+
+type testReceiver struct {
+	sync.Mutex
+
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+	received chan struct{}
+}
+
+func newTestReceiver(statuses ...int) (*testReceiver, *httptest.Server) {
+	tr := &testReceiver{statuses: statuses, received: make(chan struct{}, 16)}
+
+	return tr, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		tr.Lock()
+		status := http.StatusOK
+		if len(tr.statuses) > 0 {
+			status, tr.statuses = tr.statuses[0], tr.statuses[1:]
+		}
+		tr.requests = append(tr.requests, r)
+		tr.bodies = append(tr.bodies, body)
+		tr.Unlock()
+
+		w.WriteHeader(status)
+		tr.received <- struct{}{}
+	}))
+}
+
+type testDeadLetters struct {
+	reqs chan *api.ImportRequest
+}
+
+func (td *testDeadLetters) write(_ context.Context, r *api.ImportRequest) (*api.ImportResponse, error) {
+	td.reqs <- r
+	return &api.ImportResponse{}, nil
+}
+
+func testCollection(t *testing.T, webhooks string) *schema.DefaultCollection {
+	sch := []byte(`{
+		"title": "orders",
+		"properties": {
+			"id": {"type": "integer"},
+			"status": {"type": "string"}
+		},
+		"primary_key": ["id"],
+		"webhooks": ` + webhooks + `
+	}`)
+
+	factory, err := schema.NewFactoryBuilder(true).Build("orders", sch)
+	require.NoError(t, err)
+	coll, err := schema.NewDefaultCollection(1, 1, factory, nil, nil)
+	require.NoError(t, err)
+
+	return coll
+}
+
+func testConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		Workers:        2,
+		QueueSize:      16,
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+func insertEvent(id int64, doc string) *kv.Event {
+	return &kv.Event{
+		Op:   kv.InsertEvent,
+		Key:  kv.Key{"pkey", id},
+		Data: internal.NewTableData([]byte(doc)),
+	}
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the delivery %d", i+1)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	tr, srv := newTestReceiver()
+	defer srv.Close()
+
+	coll := testCollection(t, `[{"url": "`+srv.URL+`", "secret": "s3cret", "filter": {"status": "paid"}}]`)
+	td := &testDeadLetters{reqs: make(chan *api.ImportRequest, 1)}
+	d := NewDispatcher(testConfig(), nil, td.write)
+	defer d.Close()
+
+	d.Dispatch(context.Background(), "p1", "main", coll, insertEvent(1, `{"id":1,"status":"new"}`))
+	d.Dispatch(context.Background(), "p1", "main", coll, insertEvent(2, `{"id":2,"status":"paid"}`))
+	d.Dispatch(context.Background(), "p1", "main", coll, &kv.Event{Op: kv.DeleteEvent, Key: kv.Key{"pkey", int64(3)}})
+	waitFor(t, tr.received, 2)
+
+	tr.Lock()
+	defer tr.Unlock()
+	require.Len(t, tr.bodies, 2)
+
+	var ops []string
+	for i, r := range tr.requests {
+		body := tr.bodies[i]
+		require.Equal(t, Sign("s3cret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+		require.NotEmpty(t, r.Header.Get(HeaderEventId))
+
+		var ev Event
+		require.NoError(t, jsoniter.Unmarshal(body, &ev))
+		require.Equal(t, r.Header.Get(HeaderEventId), ev.Id)
+		require.Equal(t, "p1", ev.Project)
+		require.Equal(t, "orders", ev.Collection)
+		ops = append(ops, ev.Op)
+
+		switch ev.Op {
+		case kv.InsertEvent:
+			require.JSONEq(t, `[2]`, string(ev.Key))
+			require.JSONEq(t, `{"id":2,"status":"paid"}`, string(ev.Document))
+		case kv.DeleteEvent:
+			require.JSONEq(t, `[3]`, string(ev.Key))
+			require.Empty(t, ev.Document)
+		}
+	}
+	require.ElementsMatch(t, []string{kv.InsertEvent, kv.DeleteEvent}, ops)
+	require.Empty(t, td.reqs)
+}
+
+func TestDispatchRetries(t *testing.T) {
+	tr, srv := newTestReceiver(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	defer srv.Close()
+
+	td := &testDeadLetters{reqs: make(chan *api.ImportRequest, 1)}
+	d := NewDispatcher(testConfig(), nil, td.write)
+	defer d.Close()
+
+	d.Dispatch(context.Background(), "p1", "main", testCollection(t, `[{"url": "`+srv.URL+`"}]`), insertEvent(1, `{"id":1}`))
+	waitFor(t, tr.received, 3)
+
+	tr.Lock()
+	defer tr.Unlock()
+	require.Len(t, tr.requests, 3)
+	require.Empty(t, tr.requests[0].Header.Get(HeaderSignature))
+	require.Equal(t, tr.bodies[0], tr.bodies[2])
+	require.Empty(t, td.reqs)
+}
+
+func TestDispatchDeadLetter(t *testing.T) {
+	for _, statuses := range [][]int{
+		{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+		{http.StatusBadRequest},
+	} {
+		tr, srv := newTestReceiver(statuses...)
+
+		td := &testDeadLetters{reqs: make(chan *api.ImportRequest, 1)}
+		d := NewDispatcher(testConfig(), nil, td.write)
+
+		coll := testCollection(t, `[{"url": "`+srv.URL+`", "dead_letter_collection": "failed"}]`)
+		d.Dispatch(context.Background(), "p1", "main", coll, insertEvent(1, `{"id":1}`))
+		waitFor(t, tr.received, len(statuses))
+
+		var r *api.ImportRequest
+		select {
+		case r = <-td.reqs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the dead letter")
+		}
+
+		require.Equal(t, "p1", r.Project)
+		require.Equal(t, "failed", r.Collection)
+		require.True(t, r.CreateCollection)
+		require.Len(t, r.Documents, 1)
+
+		var dl deadLetter
+		require.NoError(t, jsoniter.Unmarshal(r.Documents[0], &dl))
+		require.Equal(t, len(statuses), dl.Attempts)
+		require.Equal(t, srv.URL, dl.URL)
+		require.Equal(t, string(tr.bodies[0]), dl.Payload)
+		require.Contains(t, dl.Error, "status")
+
+		d.Close()
+		srv.Close()
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: config.WebhookConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	for attempts, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: 10 * time.Second} {
+		delay := d.backoff(attempts)
+		require.LessOrEqual(t, delay, max)
+		require.GreaterOrEqual(t, delay, max/2)
+	}
+}
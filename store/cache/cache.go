@@ -15,8 +15,12 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
 	"strings"
 	"time"
 
@@ -118,6 +122,133 @@ func (c *cache) Get(ctx context.Context, tableName string, key string, options *
 	return internal.DecodeCacheData(value)
 }
 
+// maxWatchRetries is the number of the times a read-modify-write of a key is retried when the key is modified
+// concurrently.
+const maxWatchRetries = 16
+
+// update reads the value of the key and writes the value returned by fn, nil if the key doesn't exist. The value is
+// written only if the key isn't modified concurrently, in which case the update is retried. The TTL of the key is
+// kept. The write is skipped if fn returns nil.
+func (c *cache) update(ctx context.Context, cacheKey string, fn func(*internal.CacheData) (*internal.CacheData, error)) error {
+	txf := func(tx *xredis.Tx) error {
+		var current *internal.CacheData
+		value, err := tx.Get(ctx, cacheKey).Bytes()
+		switch {
+		case err == xredis.Nil:
+		case err != nil:
+			return err
+		default:
+			if current, err = internal.DecodeCacheData(value); err != nil {
+				return err
+			}
+		}
+
+		updated, err := fn(current)
+		if err != nil || updated == nil {
+			return err
+		}
+
+		enc, err := internal.EncodeCacheData(updated)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe xredis.Pipeliner) error {
+			pipe.SetArgs(ctx, cacheKey, enc, xredis.SetArgs{KeepTTL: true})
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxWatchRetries; i++ {
+		err := c.Client.Watch(ctx, txf, cacheKey)
+		if err != xredis.TxFailedErr {
+			return err
+		}
+	}
+
+	return ErrConcurrentUpdate
+}
+
+func (c *cache) IncrBy(ctx context.Context, tableName string, key string, delta int64) (int64, error) {
+	var result int64
+	err := c.update(ctx, encodeToCacheKey(tableName, key), func(current *internal.CacheData) (*internal.CacheData, error) {
+		var value int64
+		if current != nil {
+			var err error
+			if value, err = strconv.ParseInt(string(bytes.TrimSpace(current.RawData)), 10, 64); err != nil {
+				return nil, ErrValueNotInteger
+			}
+		}
+
+		if (delta > 0 && value > math.MaxInt64-delta) || (delta < 0 && value < math.MinInt64-delta) {
+			return nil, ErrValueOverflow
+		}
+		result = value + delta
+
+		raw := []byte(strconv.FormatInt(result, 10))
+		if current == nil {
+			return internal.NewCacheData(raw), nil
+		}
+		current.RawData = raw
+		return current, nil
+	})
+
+	return result, err
+}
+
+func (c *cache) Expire(ctx context.Context, tableName string, key string, ttl time.Duration) error {
+	cacheKey := encodeToCacheKey(tableName, key)
+
+	var (
+		updated bool
+		err     error
+	)
+	if ttl > 0 {
+		updated, err = c.Client.PExpire(ctx, cacheKey, ttl).Result()
+	} else {
+		// persist doesn't update a key without a TTL, so the key is checked to exist
+		if _, err = c.Client.Persist(ctx, cacheKey).Result(); err != nil {
+			return err
+		}
+		var exists int64
+		exists, err = c.Client.Exists(ctx, cacheKey).Result()
+		updated = exists > 0
+	}
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func (c *cache) CompareAndSwap(ctx context.Context, tableName string, key string, expected []byte, value *internal.CacheData) (*internal.CacheData, bool, error) {
+	var (
+		old     *internal.CacheData
+		swapped bool
+	)
+	err := c.update(ctx, encodeToCacheKey(tableName, key), func(current *internal.CacheData) (*internal.CacheData, error) {
+		old, swapped = current, false
+		if current == nil && expected != nil {
+			return nil, nil
+		}
+		if current != nil && (expected == nil || !bytes.Equal(current.RawData, expected)) {
+			return nil, nil
+		}
+
+		swapped = true
+		return value, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return old, swapped, nil
+}
+
 func (c *cache) Delete(ctx context.Context, tableName string, keys ...string) (int64, error) {
 	if len(keys) == 0 {
 		return 0, ErrEmptyKey
@@ -148,12 +279,55 @@ func (c *cache) Keys(ctx context.Context, tableName string, pattern string) ([]s
 	return c.Client.Keys(ctx, encodeToCacheKey(tableName, pattern)).Result()
 }
 
-func (c *cache) Scan(ctx context.Context, tableName string, cursor uint64, count int64, pattern string) ([]string, uint64) {
+func (c *cache) Scan(ctx context.Context, tableName string, cursor uint64, count int64, pattern string) ([]string, uint64, error) {
 	if count > config.DefaultConfig.Cache.MaxScan {
 		count = config.DefaultConfig.Cache.MaxScan
 	}
-	scanCmd := c.Client.Scan(ctx, cursor, encodeToCacheKey(tableName, pattern), count)
-	return scanCmd.Val()
+	return c.Client.Scan(ctx, cursor, encodeToCacheKey(tableName, pattern), count).Result()
+}
+
+// EstimateKeys counts the keys matching the pattern, scanning at most maxScanned keys of the keyspace. The count is
+// exact if the scan completes within the budget. Otherwise, it is extrapolated from the share of the keyspace
+// scanned, which is known from the cursor: SCAN walks the buckets of the hash table of the keyspace in the order of
+// their bit-reversed index, so the bit-reversed cursor is the number of the buckets scanned so far.
+func (c *cache) EstimateKeys(ctx context.Context, tableName string, pattern string, maxScanned int64) (int64, bool, error) {
+	size, err := c.Client.DBSize(ctx).Result()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var (
+		keys    []string
+		cursor  uint64
+		matched int64
+		scanned int64
+	)
+	for {
+		if keys, cursor, err = c.Scan(ctx, tableName, cursor, config.DefaultConfig.Cache.MaxScan, pattern); err != nil {
+			return 0, false, err
+		}
+		matched += int64(len(keys))
+		scanned += config.DefaultConfig.Cache.MaxScan
+
+		if cursor == 0 {
+			return matched, true, nil
+		}
+		if scanned >= maxScanned {
+			break
+		}
+	}
+
+	// the hash table has at least as many buckets as keys, in a power of two
+	tableBits := bits.Len64(uint64(size))
+	if cursorBits := bits.Len64(cursor); cursorBits > tableBits {
+		tableBits = cursorBits
+	}
+	visited := bits.Reverse64(cursor) >> (64 - tableBits)
+	if visited == 0 {
+		return matched, false, nil
+	}
+
+	return int64(float64(matched) * float64(uint64(1)<<tableBits) / float64(visited)), false, nil
 }
 
 func (c *cache) ListStreams(ctx context.Context, streamNamePrefix string) ([]string, error) {
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/tigrisdata/tigris/internal"
@@ -134,11 +135,13 @@ func TestRedis(t *testing.T) {
 		var totalKeys []string
 		var cursor uint64
 
-		keys, cursor := c.Scan(ctx, tableName, cursor, 10, "*")
+		keys, cursor, err := c.Scan(ctx, tableName, cursor, 10, "*")
+		require.NoError(t, err)
 		totalKeys = append(totalKeys, keys...)
 
 		for cursor != 0 {
-			keys, cursor = c.Scan(ctx, tableName, cursor, 10, "*")
+			keys, cursor, err = c.Scan(ctx, tableName, cursor, 10, "*")
+			require.NoError(t, err)
 			totalKeys = append(totalKeys, keys...)
 		}
 		require.Equal(t, 50, len(totalKeys))
@@ -155,4 +158,104 @@ func TestRedis(t *testing.T) {
 			require.Truef(t, contains, "key %s not found", keyToSearch)
 		}
 	})
+
+	t.Run("incr", func(t *testing.T) {
+		defer dropCacheTable(t, c, tableName)
+
+		v, err := c.IncrBy(ctx, tableName, "counter", 5)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), v)
+
+		v, err = c.IncrBy(ctx, tableName, "counter", -7)
+		require.NoError(t, err)
+		require.Equal(t, int64(-2), v)
+
+		g, err := c.Get(ctx, tableName, "counter", nil)
+		require.NoError(t, err)
+		require.Equal(t, []byte("-2"), g.RawData)
+
+		require.NoError(t, c.Set(ctx, tableName, "key1", internal.NewCacheData([]byte(`{"a": "b"}`)), nil))
+		_, err = c.IncrBy(ctx, tableName, "key1", 1)
+		require.Equal(t, ErrValueNotInteger, err)
+
+		require.NoError(t, c.Set(ctx, tableName, "max", internal.NewCacheData([]byte("9223372036854775807")), nil))
+		_, err = c.IncrBy(ctx, tableName, "max", 1)
+		require.Equal(t, ErrValueOverflow, err)
+	})
+
+	t.Run("incr_keeps_ttl", func(t *testing.T) {
+		defer dropCacheTable(t, c, tableName)
+
+		require.NoError(t, c.Set(ctx, tableName, "counter", internal.NewCacheData([]byte("1")), &SetOptions{EX: 100}))
+		_, err := c.IncrBy(ctx, tableName, "counter", 1)
+		require.NoError(t, err)
+
+		ttl, err := c.Client.TTL(ctx, encodeToCacheKey(tableName, "counter")).Result()
+		require.NoError(t, err)
+		require.Greater(t, ttl, time.Duration(0))
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		defer dropCacheTable(t, c, tableName)
+
+		require.Equal(t, ErrKeyNotFound, c.Expire(ctx, tableName, "key1", time.Minute))
+
+		require.NoError(t, c.Set(ctx, tableName, "key1", internal.NewCacheData([]byte(`{"a": "b"}`)), nil))
+		require.NoError(t, c.Expire(ctx, tableName, "key1", time.Minute))
+		ttl, err := c.Client.TTL(ctx, encodeToCacheKey(tableName, "key1")).Result()
+		require.NoError(t, err)
+		require.Greater(t, ttl, time.Duration(0))
+
+		require.NoError(t, c.Expire(ctx, tableName, "key1", 0))
+		ttl, err = c.Client.TTL(ctx, encodeToCacheKey(tableName, "key1")).Result()
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(-1), ttl)
+
+		require.Equal(t, ErrKeyNotFound, c.Expire(ctx, tableName, "key2", 0))
+	})
+
+	t.Run("compare_and_swap", func(t *testing.T) {
+		defer dropCacheTable(t, c, tableName)
+
+		s1 := []byte(`{"a": "b"}`)
+		s2 := []byte(`{"a": "c"}`)
+		old, swapped, err := c.CompareAndSwap(ctx, tableName, "key1", s1, internal.NewCacheData(s2))
+		require.NoError(t, err)
+		require.False(t, swapped)
+		require.Nil(t, old)
+
+		old, swapped, err = c.CompareAndSwap(ctx, tableName, "key1", nil, internal.NewCacheData(s1))
+		require.NoError(t, err)
+		require.True(t, swapped)
+		require.Nil(t, old)
+
+		old, swapped, err = c.CompareAndSwap(ctx, tableName, "key1", s2, internal.NewCacheData(s2))
+		require.NoError(t, err)
+		require.False(t, swapped)
+		require.Equal(t, s1, old.RawData)
+
+		old, swapped, err = c.CompareAndSwap(ctx, tableName, "key1", s1, internal.NewCacheData(s2))
+		require.NoError(t, err)
+		require.True(t, swapped)
+		require.Equal(t, s1, old.RawData)
+
+		g, err := c.Get(ctx, tableName, "key1", nil)
+		require.NoError(t, err)
+		require.Equal(t, s2, g.RawData)
+	})
+
+	t.Run("estimate_keys", func(t *testing.T) {
+		defer dropCacheTable(t, c, tableName)
+
+		s1 := []byte(`{"a": "b"}`)
+		for i := 1; i <= 20; i++ {
+			require.NoError(t, c.Set(ctx, tableName, fmt.Sprintf("key%d", i), internal.NewCacheData(s1), nil))
+			require.NoError(t, c.Set(ctx, tableName, fmt.Sprintf("other%d", i), internal.NewCacheData(s1), nil))
+		}
+
+		count, exact, err := c.EstimateKeys(ctx, tableName, "key*", 100000)
+		require.NoError(t, err)
+		require.True(t, exact)
+		require.Equal(t, int64(20), count)
+	})
 }
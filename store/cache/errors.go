@@ -31,6 +31,9 @@ const (
 	ErrCodeKeyNotFound      ErrCode = 0x03
 	ErrCodeKeyAlreadyExists ErrCode = 0x04
 	ErrCodeEmptyKey         ErrCode = 0x05
+	ErrCodeNotInteger       ErrCode = 0x06
+	ErrCodeOverflow         ErrCode = 0x07
+	ErrCodeConcurrentUpdate ErrCode = 0x08
 )
 
 var (
@@ -41,6 +44,10 @@ var (
 	ErrKeyNotFound      = NewCacheError(ErrCodeKeyNotFound, "key not found")
 	ErrKeyAlreadyExists = NewCacheError(ErrCodeKeyAlreadyExists, "key already exists")
 	ErrEmptyKey         = NewCacheError(ErrCodeEmptyKey, "key is empty")
+	ErrValueNotInteger  = NewCacheError(ErrCodeNotInteger, "value is not an integer")
+	ErrValueOverflow    = NewCacheError(ErrCodeOverflow, "increment or decrement would overflow")
+	// ErrConcurrentUpdate is returned when a key is modified concurrently more than the retries of an update.
+	ErrConcurrentUpdate = NewCacheError(ErrCodeConcurrentUpdate, "key is modified concurrently")
 )
 
 type Error struct {
@@ -73,12 +73,25 @@ type Cache interface {
 	GetSet(ctx context.Context, tableName string, key string, value *internal.CacheData) (*internal.CacheData, error)
 	// Get the value of key
 	Get(ctx context.Context, tableName string, key string, options *GetOptions) (*internal.CacheData, error)
+	// IncrBy adds delta to the integer value of the key and returns the result, a missing key is set to delta. The TTL
+	// of the key is kept.
+	IncrBy(ctx context.Context, tableName string, key string, delta int64) (int64, error)
+	// Expire sets the TTL of the key, a zero TTL removes the TTL of the key.
+	Expire(ctx context.Context, tableName string, key string, ttl time.Duration) error
+	// CompareAndSwap sets the value of the key only if its current value is expected, or if the key doesn't exist
+	// when expected is nil. It returns the previous value, nil if the key didn't exist, and whether the value was set.
+	CompareAndSwap(ctx context.Context, tableName string, key string, expected []byte, value *internal.CacheData) (*internal.CacheData, bool, error)
 	// Delete deletes one or more keys
 	Delete(ctx context.Context, tableName string, keys ...string) (int64, error)
 	// Exists returns if the key exists, for multiple keys it returns the count of the number of keys that exists
 	Exists(ctx context.Context, tableName string, key ...string) (int64, error)
 	Keys(ctx context.Context, tableName string, pattern string) ([]string, error)
-	Scan(ctx context.Context, tableName string, cursor uint64, count int64, pattern string) ([]string, uint64)
+	// Scan returns a page of the keys matching the pattern from the cursor, and the cursor of the next page which is
+	// zero once all the keys are scanned.
+	Scan(ctx context.Context, tableName string, cursor uint64, count int64, pattern string) ([]string, uint64, error)
+	// EstimateKeys returns the number of the keys matching the pattern, and whether it's exact. At most maxScanned keys
+	// are scanned, the count of a larger keyspace is an estimate.
+	EstimateKeys(ctx context.Context, tableName string, pattern string, maxScanned int64) (int64, bool, error)
 
 	// CreateStream creates and returns a stream object, throws an error if stream already exists
 	CreateStream(ctx context.Context, streamName string) (Stream, error)
@@ -49,15 +49,25 @@ type baseIterator interface {
 
 type baseTx interface {
 	baseKV
+	AtomicReadPrefix(ctx context.Context, table []byte, key Key, isSnapshot bool) (AtomicIterator, error)
+	RangeSize(ctx context.Context, table []byte, lkey Key, rkey Key) (int64, error)
 	Commit(context.Context) error
 	Rollback(context.Context) error
 	IsRetriable() bool
+	Savepoint(ctx context.Context, name string) error
+	RollbackToSavepoint(ctx context.Context, name string) error
 }
 
+// baseKVStore is the storage backend the layers of the kv package are built on, see newBackend.
 type baseKVStore interface {
 	baseKV
 	BeginTx(ctx context.Context) (baseTx, error)
 	CreateTable(ctx context.Context, name []byte) error
 	DropTable(ctx context.Context, name []byte) error
 	TableSize(ctx context.Context, name []byte) (int64, error)
+	// BoundaryKeys returns up to limit keys of the table at which the shards of the storage start, in ascending order.
+	BoundaryKeys(ctx context.Context, name []byte, limit int) ([][]byte, error)
+	// internalDatabase returns the handle of the underlying database for the components which need to access it
+	// directly, like the change streams.
+	internalDatabase() (any, error)
 }
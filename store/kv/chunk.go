@@ -113,6 +113,12 @@ func (tx *ChunkTx) Insert(ctx context.Context, table []byte, key Key, data *inte
 
 func (tx *ChunkTx) Replace(ctx context.Context, table []byte, key Key, data *internal.TableData, isUpdate bool) error {
 	if !tx.isChunkingNeeded(data) {
+		// the previous value may have been chunked, the chunks beyond the first one are not overwritten by the
+		// new value, so they need to be removed.
+		if err := tx.deleteChunks(ctx, table, key); err != nil {
+			return err
+		}
+
 		return tx.KeyValueTx.Replace(ctx, table, key, data, isUpdate)
 	}
 
@@ -139,6 +145,14 @@ func (tx *ChunkTx) Replace(ctx context.Context, table []byte, key Key, data *int
 	})
 }
 
+// deleteChunks removes all the chunks of the value except the first one which is stored under the key itself.
+func (tx *ChunkTx) deleteChunks(ctx context.Context, table []byte, key Key) error {
+	chunksKey := make(Key, 0, len(key)+1)
+	chunksKey = append(chunksKey, key...)
+
+	return tx.KeyValueTx.Delete(ctx, table, append(chunksKey, chunkIdentifier))
+}
+
 // Read needs to return chunk iterator so that it can merge and returned merged chunk to caller.
 func (tx *ChunkTx) Read(ctx context.Context, table []byte, key Key, reverse bool) (Iterator, error) {
 	iterator, err := tx.KeyValueTx.Read(ctx, table, key, reverse)
@@ -148,6 +162,7 @@ func (tx *ChunkTx) Read(ctx context.Context, table []byte, key Key, reverse bool
 
 	return &ChunkIterator{
 		Iterator: iterator,
+		reverse:  reverse,
 	}, nil
 }
 
@@ -159,16 +174,22 @@ func (tx *ChunkTx) ReadRange(ctx context.Context, table []byte, lKey Key, rKey K
 
 	return &ChunkIterator{
 		Iterator: iterator,
+		reverse:  reverse,
 	}, nil
 }
 
 type ChunkIterator struct {
 	Iterator
 
-	err error
+	err     error
+	reverse bool
 }
 
 func (it *ChunkIterator) Next(value *KeyValue) bool {
+	if it.reverse {
+		return it.nextReverse(value)
+	}
+
 	if !it.Iterator.Next(value) {
 		return false
 	}
@@ -209,6 +230,59 @@ func (it *ChunkIterator) Next(value *KeyValue) bool {
 	return hasNext
 }
 
+// nextReverse merges the chunks of the value when the iterator is reading in reverse order. In this case the chunks are
+// returned in the descending order of the chunk number, and the first chunk, which has the attributes of the value,
+// comes last.
+func (it *ChunkIterator) nextReverse(value *KeyValue) bool {
+	var chunks []KeyValue
+	for {
+		if !it.Iterator.Next(value) {
+			if it.Iterator.Err() == nil && len(chunks) > 0 {
+				it.err = fmt.Errorf("first chunk not found for the chunked key '%v'", chunks[0].Key)
+			}
+			return false
+		}
+
+		if !isChunkKey(value.Key) {
+			break
+		}
+
+		chunks = append(chunks, *value)
+	}
+
+	totalChunks := int32(1)
+	if value.Data.IsChunkedData() {
+		totalChunks = *value.Data.TotalChunks
+	}
+
+	if int32(len(chunks))+1 != totalChunks {
+		it.err = fmt.Errorf("mismatch in total chunk read '%d' versus total chunks expected '%d'",
+			len(chunks)+1, totalChunks)
+		return false
+	}
+
+	if len(chunks) == 0 {
+		return true
+	}
+
+	var buf bytes.Buffer
+	_, _ = buf.Write(value.Data.RawData)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		if it.validChunkKey(chunks[i].Key, int32(len(chunks)-i)); it.err != nil {
+			return false
+		}
+
+		_, _ = buf.Write(chunks[i].Data.RawData)
+	}
+
+	value.Data.RawData = buf.Bytes()
+	return true
+}
+
+func isChunkKey(key Key) bool {
+	return len(key) >= 2 && key[len(key)-2] == chunkIdentifier
+}
+
 func (it *ChunkIterator) validChunkKey(key Key, expChunk int32) {
 	switch {
 	case len(key) < 2:
@@ -172,6 +172,60 @@ func TestChunkStore(t *testing.T) {
 	}
 }
 
+func TestChunkStoreReplaceRemovesChunks(t *testing.T) {
+	cfg, err := config.GetTestFDBConfig("../..")
+	require.NoError(t, err)
+
+	kv, err := newFoundationDB(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	table := []byte("t1")
+	require.NoError(t, kv.DropTable(ctx, table))
+	require.NoError(t, kv.CreateTable(ctx, table))
+
+	chunkStore := NewChunkStore(NewTxStore(kv), true)
+
+	defer func(size int) { chunkSize = size }(chunkSize)
+	chunkSize = 4
+
+	replace := func(key Key, doc string) {
+		tx, err := chunkStore.BeginTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Replace(ctx, table, key, internal.NewTableData([]byte(doc)), false))
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	readAll := func(reverse bool) []string {
+		tx, err := chunkStore.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		it, err := tx.ReadRange(ctx, table, nil, nil, false, reverse)
+		require.NoError(t, err)
+
+		var docs []string
+		var keyValue KeyValue
+		for it.Next(&keyValue) {
+			docs = append(docs, string(keyValue.Data.RawData))
+		}
+		require.NoError(t, it.Err())
+
+		return docs
+	}
+
+	replace(BuildKey("p1", 1), `{"a":"0123456789"}`)
+	replace(BuildKey("p1", 2), `{"b":"0123456789"}`)
+	require.Equal(t, []string{`{"a":"0123456789"}`, `{"b":"0123456789"}`}, readAll(false))
+	require.Equal(t, []string{`{"b":"0123456789"}`, `{"a":"0123456789"}`}, readAll(true))
+
+	// shrinking the value must not leave the chunks of the previous value behind
+	replace(BuildKey("p1", 1), `{}`)
+	require.Equal(t, []string{`{}`, `{"b":"0123456789"}`}, readAll(false))
+	require.Equal(t, []string{`{"b":"0123456789"}`, `{}`}, readAll(true))
+}
+
 func TestChunkStoreIterator(t *testing.T) {
 	ptr1, ptr2 := int32(1), int32(2)
 	cases := []struct {
@@ -231,6 +285,63 @@ func TestChunkStoreIterator(t *testing.T) {
 	}
 }
 
+func TestChunkStoreReverseIterator(t *testing.T) {
+	ptr2, ptr3 := int32(2), int32(3)
+	cases := []struct {
+		values   []*KeyValue
+		expData  []string
+		expError error
+	}{
+		{
+			[]*KeyValue{
+				{Key: BuildKey("k2"), Data: &internal.TableData{RawData: []byte(`b`)}},
+				{Key: BuildKey("k1", "_C_", int64(2)), Data: &internal.TableData{RawData: []byte(`3`)}},
+				{Key: BuildKey("k1", "_C_", int64(1)), Data: &internal.TableData{RawData: []byte(`2`)}},
+				{Key: BuildKey("k1"), Data: &internal.TableData{RawData: []byte(`1`), TotalChunks: &ptr3}},
+				{Key: BuildKey("k0"), Data: &internal.TableData{RawData: []byte(`a`)}},
+			},
+			[]string{"b", "123", "a"},
+			nil,
+		}, {
+			[]*KeyValue{
+				{Key: BuildKey("k1", "_C_", int64(1)), Data: &internal.TableData{RawData: []byte(`2`)}},
+				{Key: BuildKey("k1"), Data: &internal.TableData{RawData: []byte(`1`), TotalChunks: &ptr3}},
+			},
+			nil,
+			fmt.Errorf("mismatch in total chunk read '2' versus total chunks expected '3'"),
+		}, {
+			[]*KeyValue{
+				{Key: BuildKey("k1", "_C_", int64(2)), Data: &internal.TableData{RawData: []byte(`2`)}},
+				{Key: BuildKey("k1"), Data: &internal.TableData{RawData: []byte(`1`), TotalChunks: &ptr2}},
+			},
+			nil,
+			fmt.Errorf("chunk number mismatch found: '2' exp: '1'"),
+		}, {
+			[]*KeyValue{
+				{Key: BuildKey("k1", "_C_", int64(1)), Data: &internal.TableData{RawData: []byte(`2`)}},
+			},
+			nil,
+			fmt.Errorf("first chunk not found for the chunked key '[k1 _C_ 1]'"),
+		},
+	}
+	for _, c := range cases {
+		it := &ChunkIterator{
+			Iterator: &mockedIterator{
+				values: c.values,
+			},
+			reverse: true,
+		}
+
+		var data []string
+		var keyValue KeyValue
+		for it.Next(&keyValue) {
+			data = append(data, string(keyValue.Data.RawData))
+		}
+		require.Equal(t, c.expError, it.err)
+		require.Equal(t, c.expData, data)
+	}
+}
+
 type mockedIterator struct {
 	idx    int
 	values []*KeyValue
@@ -16,9 +16,14 @@ package kv
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 
+	"github.com/klauspost/compress/snappy"
 	"github.com/klauspost/compress/zstd"
 	"github.com/tigrisdata/tigris/internal"
+	"github.com/tigrisdata/tigris/server/metrics"
+	ulog "github.com/tigrisdata/tigris/util/log"
 )
 
 const (
@@ -30,6 +35,21 @@ const (
 	zstdLevel2 = zstd.SpeedDefault
 )
 
+// Compression algorithms of the payloads, the algorithm of a table is passed by the caller in the context of the write,
+// see CtxWithCompression.
+const (
+	CompressionNone   = "none"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+)
+
+// Formats of the compressed payloads. The format is stored alongside the payload in the Compression attribute, so
+// that a payload is decompressed with the algorithm it was written with, irrespective of the current setting.
+const (
+	formatZstd   = int32(zstdLevel2)
+	formatSnappy = int32(16)
+)
+
 var (
 	zStdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel2))
 	zStdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
@@ -66,30 +86,58 @@ type CompressTx struct {
 	enabled bool
 }
 
-func (tx *CompressTx) shouldCompress(data *internal.TableData) bool {
-	return data.ActualUserPayloadSize() > minCompressionThreshold && tx.enabled
+// algorithm returns the compression algorithm of the write, the one set in the context takes precedence over the
+// store default.
+func (tx *CompressTx) algorithm(ctx context.Context) string {
+	if algorithm := GetCompressionFromCtx(ctx); len(algorithm) > 0 {
+		return algorithm
+	}
+
+	if tx.enabled {
+		return CompressionZstd
+	}
+
+	return CompressionNone
 }
 
-func (tx *CompressTx) compress(data *internal.TableData) *internal.TableData {
-	if !tx.shouldCompress(data) {
+func (tx *CompressTx) compress(ctx context.Context, data *internal.TableData) *internal.TableData {
+	algorithm := tx.algorithm(ctx)
+	if algorithm == CompressionNone || data.ActualUserPayloadSize() <= minCompressionThreshold {
+		return data
+	}
+
+	var (
+		compressed []byte
+		format     int32
+	)
+	switch algorithm {
+	case CompressionSnappy:
+		compressed = snappy.Encode(nil, data.RawData)
+		format = formatSnappy
+	default:
+		compressed = zStdEncoder.EncodeAll(data.RawData, nil)
+		format = formatZstd
+	}
+
+	metrics.UpdateCompressionMetrics(algorithm, len(data.RawData), len(compressed))
+
+	if len(compressed) >= len(data.RawData) {
+		// incompressible payload, storing it as-is is cheaper to read
 		return data
 	}
 
-	var compressed []byte
-	compressed = zStdEncoder.EncodeAll(data.RawData, compressed)
 	compressedData := data.CloneWithAttributesOnly(compressed)
-	compressionType := int32(zstdLevel2)
-	compressedData.Compression = &compressionType
+	compressedData.Compression = &format
 
 	return compressedData
 }
 
 func (tx *CompressTx) Insert(ctx context.Context, table []byte, key Key, data *internal.TableData) error {
-	return tx.Tx.Insert(ctx, table, key, tx.compress(data))
+	return tx.Tx.Insert(ctx, table, key, tx.compress(ctx, data))
 }
 
 func (tx *CompressTx) Replace(ctx context.Context, table []byte, key Key, data *internal.TableData, isUpdate bool) error {
-	return tx.Tx.Replace(ctx, table, key, tx.compress(data), isUpdate)
+	return tx.Tx.Replace(ctx, table, key, tx.compress(ctx, data), isUpdate)
 }
 
 func (tx *CompressTx) Read(ctx context.Context, table []byte, key Key, reverse bool) (Iterator, error) {
@@ -129,7 +177,7 @@ func (it *DecompressIterator) Next(value *KeyValue) bool {
 		return true
 	}
 
-	uncompressed, err := zStdDecoder.DecodeAll(value.Data.RawData, nil)
+	uncompressed, err := decompress(*value.Data.Compression, value.Data.RawData)
 	if err != nil {
 		it.err = err
 		return false
@@ -145,3 +193,35 @@ func (it *DecompressIterator) Err() error {
 
 	return it.Iterator.Err()
 }
+
+func decompress(format int32, compressed []byte) ([]byte, error) {
+	switch format {
+	case formatZstd:
+		return zStdDecoder.DecodeAll(compressed, nil)
+	case formatSnappy:
+		return snappy.Decode(nil, compressed)
+	default:
+		return nil, fmt.Errorf("unknown compression format '%d'", format)
+	}
+}
+
+type CtxValueCompression struct{}
+
+// GetCompressionFromCtx returns the compression algorithm set in the context, empty if it is not set.
+func GetCompressionFromCtx(ctx context.Context) string {
+	if v := ctx.Value(CtxValueCompression{}); v != nil {
+		if vv, ok := v.(string); ok {
+			return vv
+		}
+
+		_ = ulog.CE("unexpected ctx value %v", reflect.TypeOf(v))
+	}
+
+	return ""
+}
+
+// CtxWithCompression sets the compression algorithm of the writes done with the context, it overrides the store
+// default. An empty algorithm falls back to the store default.
+func CtxWithCompression(ctx context.Context, algorithm string) context.Context {
+	return context.WithValue(ctx, CtxValueCompression{}, algorithm)
+}
@@ -15,9 +15,11 @@
 package kv
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
+	"github.com/klauspost/compress/snappy"
 	"github.com/stretchr/testify/require"
 	"github.com/tigrisdata/tigris/internal"
 	"github.com/tigrisdata/tigris/server/config"
@@ -142,3 +144,55 @@ func TestCompression(t *testing.T) {
 		require.NoError(t, tx.Commit(ctx))
 	}
 }
+
+func TestCompressionAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	table := []byte("t1")
+	doc := bytes.Repeat([]byte(`{"name": "compressible", "value": 1234567890}`), 64)
+
+	zstdFormat, snappyFormat := formatZstd, formatSnappy
+	cases := []struct {
+		enabled   bool
+		algorithm string
+		doc       []byte
+		exp       *int32
+	}{
+		{true, "", doc, &zstdFormat},
+		{false, "", doc, nil},
+		{false, CompressionZstd, doc, &zstdFormat},
+		{false, CompressionSnappy, doc, &snappyFormat},
+		{true, CompressionSnappy, doc, &snappyFormat},
+		{true, CompressionNone, doc, nil},
+		// below the threshold
+		{true, CompressionSnappy, doc[:100], nil},
+	}
+	for _, c := range cases {
+		store := NewCompressionStore(NewTxStore(newEphemeralKV()), c.enabled)
+
+		tx, err := store.BeginTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Insert(CtxWithCompression(ctx, c.algorithm), table, BuildKey("p1_", 1), internal.NewTableData(c.doc)))
+		require.NoError(t, tx.Commit(ctx))
+
+		tx, err = store.BeginTx(ctx)
+		require.NoError(t, err)
+		it, err := tx.Read(ctx, table, BuildKey("p1_", 1), false)
+		require.NoError(t, err)
+
+		var keyValue KeyValue
+		require.True(t, it.Next(&keyValue))
+		require.NoError(t, it.Err())
+		require.Equal(t, c.doc, keyValue.Data.RawData)
+		require.Equal(t, c.exp, keyValue.Data.Compression, c.algorithm)
+		require.NoError(t, tx.Commit(ctx))
+	}
+
+	// a payload is decompressed with the format it was written with irrespective of the current setting
+	compressed := snappy.Encode(nil, doc)
+	uncompressed, err := decompress(formatSnappy, compressed)
+	require.NoError(t, err)
+	require.Equal(t, doc, uncompressed)
+
+	_, err = decompress(100, compressed)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/rs/zerolog/log"
+)
+
+// conflictingKeysPrefix is the special key space where FoundationDB reports the conflicting key ranges of a
+// transaction which failed to commit because of a conflict. A key marks the beginning of a range if its value is "1"
+// and the end if it is "0".
+var conflictingKeysPrefix = []byte("\xff\xff/transaction/conflicting_keys/")
+
+// conflictingRanges reads the conflicting key ranges of the transaction, it must be called after the commit failed
+// and before the transaction is reset or canceled.
+func (t *ftx) conflictingRanges() []KeyRange {
+	end := append(append([]byte{}, conflictingKeysPrefix...), 0xff)
+
+	kvs, err := t.tx.GetRange(fdb.KeyRange{Begin: fdb.Key(conflictingKeysPrefix), End: fdb.Key(end)}, fdb.RangeOptions{}).GetSliceWithError()
+	if err != nil {
+		log.Err(err).Msg("failed to read the conflicting keys")
+		return nil
+	}
+
+	return parseConflictingKeys(kvs)
+}
+
+func parseConflictingKeys(kvs []fdb.KeyValue) []KeyRange {
+	var ranges []KeyRange
+	for _, kv := range kvs {
+		key := bytes.TrimPrefix(kv.Key, conflictingKeysPrefix)
+		switch {
+		case bytes.Equal(kv.Value, []byte("1")):
+			ranges = append(ranges, KeyRange{Begin: key})
+		case len(ranges) > 0 && ranges[len(ranges)-1].End == nil:
+			ranges[len(ranges)-1].End = key
+		}
+	}
+
+	return ranges
+}
@@ -0,0 +1,48 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictingKeys(t *testing.T) {
+	key := func(k string) fdb.Key {
+		return append(append(fdb.Key{}, conflictingKeysPrefix...), k...)
+	}
+
+	require.Nil(t, parseConflictingKeys(nil))
+
+	ranges := parseConflictingKeys([]fdb.KeyValue{
+		{Key: key("a"), Value: []byte("1")},
+		{Key: key("a\x00"), Value: []byte("0")},
+		{Key: key("c"), Value: []byte("1")},
+		{Key: key("d"), Value: []byte("0")},
+	})
+	require.Equal(t, []KeyRange{
+		{Begin: []byte("a"), End: []byte("a\x00")},
+		{Begin: []byte("c"), End: []byte("d")},
+	}, ranges)
+}
+
+func TestIsConflict(t *testing.T) {
+	require.True(t, IsConflict(ErrConflictingTransaction))
+	require.True(t, IsConflict(&ConflictError{StoreError: ErrConflictingTransaction.(StoreError)}))
+	require.False(t, IsConflict(ErrDuplicateKey))
+	require.False(t, IsConflict(nil))
+}
@@ -0,0 +1,1084 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
+	"github.com/rs/zerolog/log"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+const (
+	// memTxMaxAge is the lifetime of the transactions, as in FoundationDB a transaction older than that fails with
+	// ErrTransactionMaxDurationReached.
+	memTxMaxAge = 5 * time.Second
+	// memMaxValueSize and memMaxTxSize mirror the limits of FoundationDB, so that the issues caused by them show up in
+	// development as well.
+	memMaxValueSize = 100000
+	memMaxTxSize    = 10000000
+)
+
+var (
+	sharedMemKV     *memkv
+	sharedMemKVOnce sync.Once
+
+	errMemTxDone = fmt.Errorf("transaction is already committed or rolled back")
+
+	// memShardKeys is the number of keys in a shard reported by BoundaryKeys.
+	memShardKeys = 1000
+)
+
+// memkv is the ephemeral kv backend, it keeps the data in the memory of the process and loses it on restart. It is
+// meant for the tests and the throwaway development servers which can't run a FoundationDB cluster, it is not an
+// embedded storage engine: the FoundationDB client library is still required as the keys are packed by its tuple
+// layer, and the features which read the FoundationDB database directly, like CDC, are not available. Like
+// FoundationDB, the transactions read the snapshot of the data at their start and fail to commit with
+// ErrConflictingTransaction if the keys they read have been modified by a transaction committed in the meantime.
+type memkv struct {
+	sync.RWMutex
+
+	// keys are all the keys having at least one version, in ascending order.
+	keys   []string
+	values map[string][]memValue
+
+	version uint64
+	// horizon is the oldest version the transactions can read at, the versions older than it are garbage collected.
+	horizon uint64
+	// horizonAt is the commit time of the horizon version.
+	horizonAt time.Time
+	// committed keeps the write ranges of the recently committed transactions to detect the conflicts.
+	committed []memCommit
+}
+
+// memValue is the value of a key as of a version, a nil value means the key was deleted.
+type memValue struct {
+	version uint64
+	value   []byte
+}
+
+type memCommit struct {
+	version uint64
+	at      time.Time
+	writes  []KeyRange
+	// keys are the keys written by the commit, their old versions are garbage collected once the commit expires.
+	keys []string
+}
+
+// newEphemeralKV creates an empty ephemeral kv. The client API version is still selected, and so libfdb_c loaded,
+// because the versionstamped keys are packed by the FoundationDB tuple layer.
+func newEphemeralKV() *memkv {
+	fdb.MustAPIVersion(fdbAPIVersion)
+
+	return &memkv{values: make(map[string][]memValue)}
+}
+
+// sharedEphemeralKV returns the ephemeral kv of the process, the stores built in the process share it the same way they
+// share a FoundationDB cluster.
+func sharedEphemeralKV() *memkv {
+	sharedMemKVOnce.Do(func() {
+		log.Warn().Msg("using ephemeral kv backend, the data is lost on restart")
+		sharedMemKV = newEphemeralKV()
+	})
+
+	return sharedMemKV
+}
+
+// get returns the value of the key as of the version. Caller must hold the lock.
+func (d *memkv) get(key string, version uint64) []byte {
+	versions := d.values[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].version <= version {
+			return versions[i].value
+		}
+	}
+
+	return nil
+}
+
+// scan returns the keys of the range, in ascending order, with a value as of the version. Caller must hold the lock.
+func (d *memkv) scan(kr KeyRange, version uint64) []fdb.KeyValue {
+	begin, end := string(kr.Begin), string(kr.End)
+
+	var kvs []fdb.KeyValue
+	for i := sort.SearchStrings(d.keys, begin); i < len(d.keys) && d.keys[i] < end; i++ {
+		if value := d.get(d.keys[i], version); value != nil {
+			kvs = append(kvs, fdb.KeyValue{Key: fdb.Key(d.keys[i]), Value: value})
+		}
+	}
+
+	return kvs
+}
+
+// put adds a version of the key. Caller must hold the write lock.
+func (d *memkv) put(key string, version uint64, value []byte) {
+	if _, ok := d.values[key]; !ok {
+		i := sort.SearchStrings(d.keys, key)
+		d.keys = append(d.keys, "")
+		copy(d.keys[i+1:], d.keys[i:])
+		d.keys[i] = key
+	}
+
+	d.values[key] = append(d.values[key], memValue{version: version, value: value})
+}
+
+// gc drops the versions of the key which no transaction can read anymore. Caller must hold the write lock.
+func (d *memkv) gc(key string) {
+	versions := d.values[key]
+
+	// keep the last version visible at the horizon and all the versions after it
+	first := 0
+	for first+1 < len(versions) && versions[first+1].version <= d.horizon {
+		first++
+	}
+	versions = versions[first:]
+
+	if len(versions) == 1 && versions[0].value == nil && versions[0].version <= d.horizon {
+		delete(d.values, key)
+		i := sort.SearchStrings(d.keys, key)
+		d.keys = append(d.keys[:i], d.keys[i+1:]...)
+		return
+	}
+
+	d.values[key] = versions
+}
+
+// expire forgets the commits older than the lifetime of the transactions. Caller must hold the write lock.
+func (d *memkv) expire(now time.Time) {
+	i := 0
+	for ; i < len(d.committed) && now.Sub(d.committed[i].at) > memTxMaxAge; i++ {
+		d.horizon, d.horizonAt = d.committed[i].version, d.committed[i].at
+	}
+
+	for _, c := range d.committed[:i] {
+		for _, k := range c.keys {
+			if _, ok := d.values[k]; ok {
+				d.gc(k)
+			}
+		}
+	}
+
+	d.committed = d.committed[i:]
+}
+
+func (d *memkv) BeginTx(ctx context.Context) (baseTx, error) {
+	return d.beginTx(ctx)
+}
+
+func (d *memkv) beginTx(ctx context.Context) (*memtx, error) {
+	ms := getCtxTimeout(ctx)
+	if ms < 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	tx := &memtx{
+		d:           d,
+		readVersion: d.version,
+		local:       make(map[string]*memWrite),
+	}
+	if rv, ok := ctx.Value(readVersionCtxKey{}).(*ReadVersion); ok {
+		tx.readVersion = d.pinnedVersion(rv)
+	}
+	if ms > 0 {
+		tx.deadline = time.Now().Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	return tx, nil
+}
+
+// pinnedVersion returns the version the context pins the transactions to, fixing it on the first transaction. Caller
+// must hold the lock.
+func (d *memkv) pinnedVersion(rv *ReadVersion) uint64 {
+	rv.Lock()
+	defer rv.Unlock()
+
+	if rv.version == 0 {
+		rv.version = int64(d.versionAt(rv.at))
+	}
+
+	return uint64(rv.version)
+}
+
+// versionAt returns the latest version committed before the time, the current version for the zero time. The version
+// of a time before the horizon is unknown, zero is returned then, which the transactions fail to read at. Caller must
+// hold the lock.
+func (d *memkv) versionAt(at time.Time) uint64 {
+	if at.IsZero() {
+		return d.version
+	}
+
+	var version uint64
+	for _, c := range d.committed {
+		if c.at.After(at) {
+			break
+		}
+		version = c.version
+	}
+	if version == 0 && !at.Before(d.horizonAt) {
+		version = d.horizon
+	}
+
+	return version
+}
+
+func (d *memkv) txWithRetry(ctx context.Context, fn func(*memtx) (any, error)) (any, error) {
+	for {
+		tx, err := d.beginTx(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := fn(tx)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, err
+		}
+
+		if err = tx.Commit(ctx); err == nil {
+			return res, nil
+		}
+
+		if !tx.IsRetriable() {
+			return nil, err
+		}
+	}
+}
+
+func (d *memkv) Read(ctx context.Context, table []byte, key Key, isSnapshot bool, reverse bool) (baseIterator, error) {
+	tx, err := d.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.Read(ctx, table, key, isSnapshot, reverse)
+}
+
+func (d *memkv) ReadRange(ctx context.Context, table []byte, lKey Key, rKey Key, isSnapshot bool, reverse bool) (baseIterator, error) {
+	tx, err := d.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.ReadRange(ctx, table, lKey, rKey, isSnapshot, reverse)
+}
+
+func (d *memkv) Insert(ctx context.Context, table []byte, key Key, data []byte) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.Insert(ctx, table, key, data)
+	})
+	return err
+}
+
+func (d *memkv) Replace(ctx context.Context, table []byte, key Key, data []byte, isUpdate bool) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.Replace(ctx, table, key, data, isUpdate)
+	})
+	return err
+}
+
+func (d *memkv) Delete(ctx context.Context, table []byte, key Key) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.Delete(ctx, table, key)
+	})
+	return err
+}
+
+func (d *memkv) SetVersionstampedValue(ctx context.Context, key []byte, value []byte) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.SetVersionstampedValue(ctx, key, value)
+	})
+	return err
+}
+
+func (d *memkv) SetVersionstampedKey(ctx context.Context, key []byte, value []byte) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.SetVersionstampedKey(ctx, key, value)
+	})
+	return err
+}
+
+func (d *memkv) AtomicAdd(ctx context.Context, table []byte, key Key, value int64) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.AtomicAdd(ctx, table, key, value)
+	})
+	return err
+}
+
+func (d *memkv) AtomicRead(ctx context.Context, table []byte, key Key) (int64, error) {
+	val, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return tx.AtomicRead(ctx, table, key)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return val.(int64), nil
+}
+
+func (d *memkv) AtomicReadRange(ctx context.Context, table []byte, lKey Key, rKey Key, isSnapshot bool) (AtomicIterator, error) {
+	tx, err := d.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.AtomicReadRange(ctx, table, lKey, rKey, isSnapshot)
+}
+
+func (d *memkv) Get(ctx context.Context, key []byte, isSnapshot bool) Future {
+	tx, err := d.beginTx(ctx)
+	if err != nil {
+		return &memFuture{err: err}
+	}
+
+	return tx.Get(ctx, key, isSnapshot)
+}
+
+func (*memkv) CreateTable(_ context.Context, name []byte) error {
+	log.Debug().Str("name", string(name)).Msg("table created")
+	return nil
+}
+
+func (d *memkv) DropTable(ctx context.Context, name []byte) error {
+	_, err := d.txWithRetry(ctx, func(tx *memtx) (any, error) {
+		return nil, tx.clearRange(tableRange(name))
+	})
+
+	log.Err(err).Str("name", string(name)).Msg("table dropped")
+
+	return nil
+}
+
+// TableSize returns the size of the keys and the values of the tables with the name as a prefix.
+func (d *memkv) TableSize(_ context.Context, name []byte) (int64, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	return kvsSize(d.scan(tableRange(name), d.version)), nil
+}
+
+// BoundaryKeys splits the table every memShardKeys keys, like the shards of FoundationDB the ranges are only
+// approximately equal as the keys are added and removed.
+func (d *memkv) BoundaryKeys(_ context.Context, name []byte, limit int) ([][]byte, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	var boundaries [][]byte
+	for i, kv := range d.scan(tableRange(name), d.version) {
+		if limit > 0 && len(boundaries) == limit {
+			break
+		}
+		if i > 0 && i%memShardKeys == 0 {
+			boundaries = append(boundaries, kv.Key)
+		}
+	}
+
+	return boundaries, nil
+}
+
+func (*memkv) internalDatabase() (any, error) {
+	return nil, fmt.Errorf("direct database access is not supported by the ephemeral kv backend")
+}
+
+// memWrite is the pending write of a key by a transaction.
+type memWrite struct {
+	value []byte
+	// add is set if the key is only atomically added to, the addition is then applied to the value as of the commit.
+	add   bool
+	delta int64
+}
+
+// memStamped is a versionstamped write, the versionstamp is only known on commit.
+type memStamped struct {
+	key      []byte
+	value    []byte
+	stampKey bool
+}
+
+type memtx struct {
+	d           *memkv
+	readVersion uint64
+	deadline    time.Time
+
+	local map[string]*memWrite
+	// clears are the ranges cleared by the transaction, the keys written after the clear are in local.
+	clears  []KeyRange
+	stamped []memStamped
+
+	reads  []KeyRange
+	writes []KeyRange
+	size   int
+
+	err  error
+	done bool
+	sp   savepoints
+}
+
+// check returns the error which prevents the transaction from doing any further operation.
+func (t *memtx) check() error {
+	switch {
+	case t.err != nil:
+		return t.err
+	case t.done:
+		return errMemTxDone
+	case !t.deadline.IsZero() && time.Now().After(t.deadline):
+		t.err = ErrTransactionTimedOut
+	}
+
+	return t.err
+}
+
+func (t *memtx) cleared(key string) bool {
+	for _, kr := range t.clears {
+		if key >= string(kr.Begin) && key < string(kr.End) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// get returns the value of the key including the pending writes of the transaction.
+func (t *memtx) get(key fdb.Key, isSnapshot bool) ([]byte, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	if !isSnapshot {
+		t.reads = append(t.reads, keyRange(key))
+	}
+
+	k := string(key)
+	w, ok := t.local[k]
+	if ok && !w.add {
+		return w.value, nil
+	}
+
+	var value []byte
+	if !t.cleared(k) {
+		t.d.RLock()
+		if t.readVersion < t.d.horizon {
+			t.err = ErrTransactionMaxDurationReached
+		}
+		value = t.d.get(k, t.readVersion)
+		t.d.RUnlock()
+	}
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	if ok {
+		return addInt64(value, w.delta), nil
+	}
+
+	return value, nil
+}
+
+// getRange returns the values of the range including the pending writes of the transaction.
+func (t *memtx) getRange(kr KeyRange, isSnapshot bool, reverse bool) ([]fdb.KeyValue, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	if !isSnapshot {
+		t.reads = append(t.reads, kr)
+	}
+
+	t.d.RLock()
+	if t.readVersion < t.d.horizon {
+		t.err = ErrTransactionMaxDurationReached
+	}
+	snapshot := t.d.scan(kr, t.readVersion)
+	t.d.RUnlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	merged := make(map[string][]byte, len(snapshot))
+	for _, kv := range snapshot {
+		if k := string(kv.Key); !t.cleared(k) {
+			merged[k] = kv.Value
+		}
+	}
+
+	for k, w := range t.local {
+		if k < string(kr.Begin) || k >= string(kr.End) {
+			continue
+		}
+
+		if w.add {
+			merged[k] = addInt64(merged[k], w.delta)
+		} else {
+			merged[k] = w.value
+		}
+	}
+
+	kvs := make([]fdb.KeyValue, 0, len(merged))
+	for k, v := range merged {
+		kvs = append(kvs, fdb.KeyValue{Key: fdb.Key(k), Value: v})
+	}
+
+	sort.Slice(kvs, func(i, j int) bool {
+		if reverse {
+			return bytes.Compare(kvs[i].Key, kvs[j].Key) > 0
+		}
+		return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+	})
+
+	return kvs, nil
+}
+
+func (t *memtx) set(key fdb.Key, value []byte) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	if len(value) > memMaxValueSize {
+		return ErrValueSizeExceeded
+	}
+
+	t.local[string(key)] = &memWrite{value: value}
+	t.writes = append(t.writes, keyRange(key))
+	t.size += len(key) + len(value)
+
+	return nil
+}
+
+func (t *memtx) clearRange(kr KeyRange) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	for k := range t.local {
+		if k >= string(kr.Begin) && k < string(kr.End) {
+			delete(t.local, k)
+		}
+	}
+
+	t.clears = append(t.clears, kr)
+	t.writes = append(t.writes, kr)
+	t.size += len(kr.Begin) + len(kr.End)
+
+	return nil
+}
+
+// undoKey keeps the current value of the key before it is overwritten if there is a savepoint.
+func (t *memtx) undoKey(key fdb.Key) error {
+	if !t.sp.active() {
+		return nil
+	}
+
+	value, err := t.get(key, true)
+	if err != nil {
+		return err
+	}
+
+	t.sp.record(keyUndo(key, value))
+
+	return nil
+}
+
+// undoRange keeps the current values of the range before it is cleared if there is a savepoint.
+func (t *memtx) undoRange(kr KeyRange) error {
+	if !t.sp.active() {
+		return nil
+	}
+
+	kvs, err := t.getRange(kr, true, false)
+	if err != nil {
+		return err
+	}
+
+	t.sp.record(undoEntry{kr: fdb.KeyRange{Begin: fdb.Key(kr.Begin), End: fdb.Key(kr.End)}, kvs: kvs})
+
+	return nil
+}
+
+func (t *memtx) Insert(_ context.Context, table []byte, key Key, data []byte) error {
+	k := getFDBKey(table, key)
+
+	value, err := t.get(k, false)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		return ErrDuplicateKey
+	}
+
+	if err = t.set(k, data); err != nil {
+		return err
+	}
+	t.sp.record(keyUndo(k, nil))
+
+	log.Debug().Str("table", string(table)).Interface("key", key).Msg("Insert")
+
+	return nil
+}
+
+func (t *memtx) Replace(_ context.Context, table []byte, key Key, data []byte, _ bool) error {
+	k := getFDBKey(table, key)
+
+	if err := t.undoKey(k); err != nil {
+		return err
+	}
+
+	log.Debug().Str("table", string(table)).Interface("key", key).Msg("tx Replace")
+
+	return t.set(k, data)
+}
+
+func (t *memtx) Delete(_ context.Context, table []byte, key Key) error {
+	kr, err := prefixRange(getFDBKey(table, key))
+	if err != nil {
+		return err
+	}
+
+	if err = t.undoRange(kr); err != nil {
+		return err
+	}
+
+	log.Debug().Str("table", string(table)).Interface("key", key).Msg("tx delete")
+
+	return t.clearRange(kr)
+}
+
+func (t *memtx) DeleteRange(_ context.Context, table []byte, lKey Key, rKey Key) error {
+	// the range may be too large to be kept in the undo log
+	t.sp.record(undoEntry{irreversible: true})
+
+	log.Debug().Str("table", string(table)).Interface("lKey", lKey).Interface("rKey", rKey).Msg("tx delete range")
+
+	return t.clearRange(KeyRange{Begin: getFDBKey(table, lKey), End: getFDBKey(table, rKey)})
+}
+
+func (t *memtx) Read(_ context.Context, table []byte, key Key, isSnapshot bool, reverse bool) (baseIterator, error) {
+	kr, err := prefixRange(getFDBKey(table, key))
+	if err != nil {
+		return nil, err
+	}
+
+	kvs, err := t.getRange(kr, isSnapshot, reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memIterator{kvs: kvs, subspace: subspace.FromBytes(table)}, nil
+}
+
+func (t *memtx) ReadRange(_ context.Context, table []byte, lKey Key, rKey Key, isSnapshot bool, reverse bool) (baseIterator, error) {
+	kr := KeyRange{Begin: getFDBKey(table, lKey), End: getFDBRangeEnd(table, rKey)}
+
+	kvs, err := t.getRange(kr, isSnapshot, reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace().Str("table", string(table)).Interface("lKey", lKey).Interface("rKey", rKey).Msg("tx read range")
+
+	return &memIterator{kvs: kvs, subspace: subspace.FromBytes(table)}, nil
+}
+
+func (t *memtx) SetVersionstampedValue(_ context.Context, key []byte, value []byte) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	if _, err := resolveVersionstamp(value, nil); err != nil {
+		return err
+	}
+
+	if err := t.undoKey(key); err != nil {
+		return err
+	}
+
+	// the value is only known on commit, the pending write of the key is overwritten
+	delete(t.local, string(key))
+	t.stamped = append(t.stamped, memStamped{key: key, value: value})
+	t.writes = append(t.writes, keyRange(key))
+	t.size += len(key) + len(value)
+
+	return nil
+}
+
+func (t *memtx) SetVersionstampedKey(_ context.Context, key []byte, value []byte) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	if _, err := resolveVersionstamp(key, nil); err != nil {
+		return err
+	}
+
+	t.sp.record(undoEntry{irreversible: true})
+
+	t.stamped = append(t.stamped, memStamped{key: key, value: value, stampKey: true})
+	t.size += len(key) + len(value)
+
+	return nil
+}
+
+func (t *memtx) AtomicAdd(_ context.Context, table []byte, key Key, value int64) error {
+	k := getFDBKey(table, key)
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	if err := t.undoKey(k); err != nil {
+		return err
+	}
+
+	if w, ok := t.local[string(k)]; ok {
+		if w.add {
+			w.delta += value
+		} else {
+			w.value = addInt64(w.value, value)
+		}
+	} else if t.cleared(string(k)) {
+		t.local[string(k)] = &memWrite{value: addInt64(nil, value)}
+	} else {
+		t.local[string(k)] = &memWrite{add: true, delta: value}
+	}
+
+	t.writes = append(t.writes, keyRange(k))
+	t.size += len(k) + 8
+
+	return nil
+}
+
+func (t *memtx) AtomicRead(_ context.Context, table []byte, key Key) (int64, error) {
+	value, err := t.get(getFDBKey(table, key), false)
+	if err != nil || value == nil {
+		return 0, err
+	}
+
+	return fdbByteToInt64(value)
+}
+
+func (t *memtx) AtomicReadPrefix(ctx context.Context, table []byte, key Key, isSnapshot bool) (AtomicIterator, error) {
+	iter, err := t.Read(ctx, table, key, isSnapshot, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicIteratorImpl{ctx, iter, nil}, nil
+}
+
+func (t *memtx) AtomicReadRange(ctx context.Context, table []byte, lkey Key, rkey Key, isSnapshot bool) (AtomicIterator, error) {
+	iter, err := t.ReadRange(ctx, table, lkey, rkey, isSnapshot, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicIteratorImpl{ctx, iter, nil}, nil
+}
+
+func (t *memtx) Get(_ context.Context, key []byte, isSnapshot bool) Future {
+	value, err := t.get(key, isSnapshot)
+
+	return &memFuture{value: value, err: err}
+}
+
+// RangeSize returns the size of the keys and the values of the range.
+func (t *memtx) RangeSize(_ context.Context, table []byte, lKey Key, rKey Key) (int64, error) {
+	kvs, err := t.getRange(KeyRange{Begin: getFDBKey(table, lKey), End: getFDBRangeEnd(table, rKey)}, true, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return kvsSize(kvs), nil
+}
+
+func (t *memtx) Commit(_ context.Context) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+	t.done = true
+
+	if len(t.writes) == 0 && len(t.stamped) == 0 {
+		return nil
+	}
+
+	if t.size > memMaxTxSize {
+		t.err = ErrTransactionSizeExceeded
+		return t.err
+	}
+
+	d := t.d
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+	d.expire(now)
+
+	if t.readVersion < d.horizon {
+		t.err = ErrTransactionMaxDurationReached
+		return t.err
+	}
+
+	if ranges := t.conflicts(); len(ranges) > 0 {
+		t.err = ErrConflictingTransaction
+		if config.DefaultConfig.KV.ReportConflicts {
+			t.err = &ConflictError{StoreError: ErrConflictingTransaction.(StoreError), Ranges: ranges}
+		}
+		return t.err
+	}
+
+	version := d.version + 1
+	touched := make(map[string]struct{})
+
+	for _, kr := range t.clears {
+		for _, kv := range d.scan(kr, d.version) {
+			d.put(string(kv.Key), version, nil)
+			touched[string(kv.Key)] = struct{}{}
+		}
+	}
+
+	for k, w := range t.local {
+		if w.add {
+			d.put(k, version, addInt64(d.get(k, d.version), w.delta))
+		} else {
+			d.put(k, version, w.value)
+		}
+		touched[k] = struct{}{}
+	}
+
+	writes := t.writes
+	var stamp [10]byte
+	binary.BigEndian.PutUint64(stamp[:8], version)
+	for _, s := range t.stamped {
+		key, value := s.key, s.value
+		if s.stampKey {
+			key, _ = resolveVersionstamp(key, stamp[:])
+			writes = append(writes, keyRange(key))
+		} else {
+			value, _ = resolveVersionstamp(value, stamp[:])
+		}
+		d.put(string(key), version, value)
+		touched[string(key)] = struct{}{}
+	}
+
+	keys := make([]string, 0, len(touched))
+	for k := range touched {
+		d.gc(k)
+		keys = append(keys, k)
+	}
+
+	d.version = version
+	d.committed = append(d.committed, memCommit{version: version, at: now, writes: writes, keys: keys})
+
+	return nil
+}
+
+// conflicts returns the ranges read by the transaction which were written by the transactions committed after its
+// read version. Caller must hold the lock.
+func (t *memtx) conflicts() []KeyRange {
+	var ranges []KeyRange
+	for _, c := range t.d.committed {
+		if c.version <= t.readVersion {
+			continue
+		}
+
+		for _, w := range c.writes {
+			for _, r := range t.reads {
+				if bytes.Compare(w.Begin, r.End) < 0 && bytes.Compare(r.Begin, w.End) < 0 {
+					ranges = append(ranges, r)
+				}
+			}
+		}
+	}
+
+	return ranges
+}
+
+func (t *memtx) Rollback(_ context.Context) error {
+	t.done = true
+
+	log.Debug().Msg("tx Rollback")
+
+	return nil
+}
+
+// IsRetriable returns true if transaction can be retried after error.
+func (t *memtx) IsRetriable() bool {
+	return IsConflict(t.err) || t.err == ErrTransactionMaxDurationReached
+}
+
+func (t *memtx) Savepoint(_ context.Context, name string) error {
+	t.sp.add(name)
+
+	log.Debug().Str("name", name).Msg("tx savepoint")
+
+	return nil
+}
+
+func (t *memtx) RollbackToSavepoint(_ context.Context, name string) error {
+	entries, err := t.sp.rollbackTo(name)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err = t.clearRange(KeyRange{Begin: e.kr.Begin.FDBKey(), End: e.kr.End.FDBKey()}); err != nil {
+			return err
+		}
+		for _, kv := range e.kvs {
+			if err = t.set(kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Debug().Str("name", name).Int("undone", len(entries)).Msg("tx rollback to savepoint")
+
+	return nil
+}
+
+type memIterator struct {
+	kvs      []fdb.KeyValue
+	subspace subspace.Subspace
+	err      error
+}
+
+func (i *memIterator) Next(kv *baseKeyValue) bool {
+	if i.err != nil || len(i.kvs) == 0 {
+		return false
+	}
+
+	next := i.kvs[0]
+	i.kvs = i.kvs[1:]
+
+	t, err := i.subspace.Unpack(next.Key)
+	if err != nil {
+		i.err = err
+		return false
+	}
+
+	if kv != nil {
+		kv.Key = tupleToKey(&t)
+		kv.FDBKey = next.Key
+		kv.Value = next.Value
+	}
+
+	return true
+}
+
+func (i *memIterator) Err() error {
+	return i.err
+}
+
+// memFuture is an already resolved future.
+type memFuture struct {
+	value []byte
+	err   error
+}
+
+func (f *memFuture) Get() ([]byte, error) {
+	return f.value, f.err
+}
+
+func (f *memFuture) MustGet() []byte {
+	if f.err != nil {
+		panic(f.err)
+	}
+
+	return f.value
+}
+
+func (*memFuture) BlockUntilReady() {}
+
+func (*memFuture) IsReady() bool { return true }
+
+func (*memFuture) Cancel() {}
+
+// keyRange returns the range containing only the key.
+func keyRange(key []byte) KeyRange {
+	end := make([]byte, len(key)+1)
+	copy(end, key)
+
+	return KeyRange{Begin: key, End: end}
+}
+
+func prefixRange(prefix []byte) (KeyRange, error) {
+	kr, err := fdb.PrefixRange(prefix)
+	if err != nil {
+		return KeyRange{}, err
+	}
+
+	return KeyRange{Begin: kr.Begin.FDBKey(), End: kr.End.FDBKey()}, nil
+}
+
+// tableRange returns the range of the tables with the name as a prefix.
+func tableRange(name []byte) KeyRange {
+	begin, end := subspace.FromBytes(name).FDBRangeKeys()
+
+	return KeyRange{Begin: begin.FDBKey(), End: end.FDBKey()}
+}
+
+func kvsSize(kvs []fdb.KeyValue) int64 {
+	var size int64
+	for _, kv := range kvs {
+		size += int64(len(kv.Key) + len(kv.Value))
+	}
+
+	return size
+}
+
+// addInt64 adds to a little endian integer, like the atomic add of FoundationDB, a missing value is zero.
+func addInt64(value []byte, delta int64) []byte {
+	var current int64
+	if len(value) >= 8 {
+		current = int64(binary.LittleEndian.Uint64(value))
+	}
+
+	res := make([]byte, 8)
+	binary.LittleEndian.PutUint64(res, uint64(current+delta))
+
+	return res
+}
+
+// resolveVersionstamp replaces the placeholder of a versionstamped key or value with the stamp. Like FoundationDB, the
+// last four bytes are the little endian position of the ten bytes placeholder. A nil stamp only validates the position.
+func resolveVersionstamp(b []byte, stamp []byte) ([]byte, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("versionstamp position not found")
+	}
+
+	pos := int(binary.LittleEndian.Uint32(b[len(b)-4:]))
+	if pos+10 > len(b)-4 {
+		return nil, fmt.Errorf("versionstamp position %d out of bounds", pos)
+	}
+
+	if stamp == nil {
+		return b, nil
+	}
+
+	res := make([]byte, len(b)-4)
+	copy(res, b[:len(b)-4])
+	copy(res[pos:], stamp)
+
+	return res, nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/server/config"
+)
+
+func TestKVMemory(t *testing.T) {
+	kv := newEphemeralKV()
+	kvStore := NewTxStore(kv)
+
+	t.Run("TestKVMemoryBasic", func(t *testing.T) {
+		testKVBasic(t, kv)
+	})
+	t.Run("TestKeyValueStoreBasic", func(t *testing.T) {
+		testKeyValueStoreBasic(t, kvStore)
+	})
+	t.Run("TestKVMemoryFullScan", func(t *testing.T) {
+		testFullScan(t, kv)
+	})
+	t.Run("TestKeyValueStoreFullScan", func(t *testing.T) {
+		testKeyValueStoreFullScan(t, kvStore)
+	})
+	t.Run("TestKVMemoryTimeout", func(t *testing.T) {
+		testKVTimeout(t, kv)
+	})
+	t.Run("TestAtomicAdd", func(t *testing.T) {
+		testKVAddAtomicValue(t, kv)
+	})
+}
+
+func TestMemoryIsolation(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+	table := []byte("t1")
+
+	require.NoError(t, kv.Insert(ctx, table, BuildKey("k1"), []byte("v1")))
+
+	tx1, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+	tx2, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+
+	// tx1 reads its own writes, tx2 keeps reading the snapshot
+	require.NoError(t, tx1.Replace(ctx, table, BuildKey("k1"), []byte("v2"), false))
+	require.NoError(t, tx1.Replace(ctx, table, BuildKey("k2"), []byte("v2"), false))
+	require.Equal(t, []baseKeyValue{
+		{Key: BuildKey("k1"), FDBKey: getFDBKey(table, BuildKey("k1")), Value: []byte("v2")},
+		{Key: BuildKey("k2"), FDBKey: getFDBKey(table, BuildKey("k2")), Value: []byte("v2")},
+	}, readAllOrFail(t)(tx1.ReadRange(ctx, table, nil, nil, false, false)))
+
+	require.Equal(t, []baseKeyValue{
+		{Key: BuildKey("k1"), FDBKey: getFDBKey(table, BuildKey("k1")), Value: []byte("v1")},
+	}, readAllOrFail(t)(tx2.Read(ctx, table, BuildKey("k1"), false, false)))
+
+	require.NoError(t, tx1.Commit(ctx))
+
+	require.Equal(t, []baseKeyValue{
+		{Key: BuildKey("k1"), FDBKey: getFDBKey(table, BuildKey("k1")), Value: []byte("v1")},
+	}, readAllOrFail(t)(tx2.Read(ctx, table, BuildKey("k1"), false, false)))
+
+	// tx2 read k1 which tx1 has modified since
+	require.NoError(t, tx2.Replace(ctx, table, BuildKey("k3"), []byte("v3"), false))
+	err = tx2.Commit(ctx)
+	require.True(t, IsConflict(err))
+	require.True(t, tx2.IsRetriable())
+
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.Equal(t, []byte(getFDBKey(table, BuildKey("k1"))), conflict.Ranges[0].Begin)
+
+	// snapshot reads don't conflict
+	tx3, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+	_ = readAllOrFail(t)(tx3.Read(ctx, table, BuildKey("k1"), true, false))
+	require.NoError(t, kv.Replace(ctx, table, BuildKey("k1"), []byte("v4"), false))
+	require.NoError(t, tx3.Replace(ctx, table, BuildKey("k3"), []byte("v3"), false))
+	require.NoError(t, tx3.Commit(ctx))
+}
+
+func TestMemoryDeleteAndSavepoint(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+	table := []byte("t1")
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, kv.Insert(ctx, table, BuildKey("k", i), []byte{byte(i)}))
+	}
+
+	tx, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Savepoint(ctx, "sp1"))
+	require.NoError(t, tx.Delete(ctx, table, BuildKey("k")))
+	require.NoError(t, tx.Replace(ctx, table, BuildKey("k", 2), []byte{20}, false))
+	require.Len(t, readAllOrFail(t)(tx.Read(ctx, table, nil, false, false)), 1)
+
+	require.NoError(t, tx.RollbackToSavepoint(ctx, "sp1"))
+	require.Len(t, readAllOrFail(t)(tx.Read(ctx, table, nil, false, false)), 3)
+
+	require.NoError(t, tx.Delete(ctx, table, BuildKey("k", 1)))
+	require.NoError(t, tx.Commit(ctx))
+
+	require.Equal(t, []baseKeyValue{
+		{Key: BuildKey("k", int64(2)), FDBKey: getFDBKey(table, BuildKey("k", 2)), Value: []byte{2}},
+		{Key: BuildKey("k", int64(3)), FDBKey: getFDBKey(table, BuildKey("k", 3)), Value: []byte{3}},
+	}, readAllOrFail(t)(kv.Read(ctx, table, nil, false, false)))
+
+	size, err := kv.TableSize(ctx, table)
+	require.NoError(t, err)
+	require.Equal(t, int64(2*len(getFDBKey(table, BuildKey("k", 2)))+2), size)
+
+	require.NoError(t, kv.DropTable(ctx, table))
+	require.Empty(t, readAllOrFail(t)(kv.Read(ctx, table, nil, false, false)))
+}
+
+func TestMemoryVersionstamp(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+
+	require.Error(t, kv.SetVersionstampedValue(ctx, []byte("foo"), []byte("bar")))
+
+	key, err := tuple.Tuple{"events", tuple.IncompleteVersionstamp(0)}.PackWithVersionstamp(nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, kv.SetVersionstampedKey(ctx, key, []byte{byte(i)}))
+	}
+
+	var keys []tuple.Tuple
+	tx, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+	kvs, err := tx.(*memtx).getRange(prefixKeyRange(t, tuple.Tuple{"events"}.Pack()), true, false)
+	require.NoError(t, err)
+	for _, kv := range kvs {
+		tp, err := tuple.Unpack(kv.Key)
+		require.NoError(t, err)
+		keys = append(keys, tp)
+	}
+
+	require.Len(t, keys, 2)
+	first, second := keys[0][1].(tuple.Versionstamp), keys[1][1].(tuple.Versionstamp)
+	require.Less(t, binary.BigEndian.Uint64(first.TransactionVersion[:8]), binary.BigEndian.Uint64(second.TransactionVersion[:8]))
+}
+
+func TestMemoryExpiredTransaction(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+	table := []byte("t1")
+
+	tx, err := kv.BeginTx(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, kv.Replace(ctx, table, BuildKey("k1"), []byte("v1"), false))
+	kv.expire(time.Now().Add(2 * memTxMaxAge))
+
+	_, err = tx.Read(ctx, table, BuildKey("k1"), false, false)
+	require.Equal(t, ErrTransactionMaxDurationReached, err)
+	require.True(t, tx.IsRetriable())
+}
+
+func TestMemoryReadAt(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+	table := []byte("t1")
+
+	require.NoError(t, kv.Replace(ctx, table, BuildKey("k1"), []byte("v1"), false))
+	version := kv.version
+	time.Sleep(10 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, kv.Replace(ctx, table, BuildKey("k1"), []byte("v2"), false))
+
+	read := func(ctx context.Context) []byte {
+		tx, err := kv.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		kvs := readAllOrFail(t)(tx.Read(ctx, table, BuildKey("k1"), false, false))
+		require.Len(t, kvs, 1)
+		return kvs[0].Value
+	}
+
+	require.Equal(t, []byte("v2"), read(ctx))
+	require.Equal(t, []byte("v1"), read(WithReadTime(ctx, at)))
+	require.Equal(t, []byte("v1"), read(WithReadVersionAt(ctx, int64(version))))
+
+	// the snapshot before the horizon is no longer retained
+	kv.Lock()
+	kv.expire(time.Now().Add(2 * memTxMaxAge))
+	kv.Unlock()
+
+	tx, err := kv.BeginTx(WithReadTime(ctx, at))
+	require.NoError(t, err)
+	_, err = tx.Read(ctx, table, BuildKey("k1"), false, false)
+	require.Equal(t, ErrTransactionMaxDurationReached, err)
+}
+
+func readAllOrFail(t *testing.T) func(baseIterator, error) []baseKeyValue {
+	return func(it baseIterator, err error) []baseKeyValue {
+		require.NoError(t, err)
+		return readAll(t, it)
+	}
+}
+
+func prefixKeyRange(t *testing.T, prefix []byte) KeyRange {
+	kr, err := prefixRange(prefix)
+	require.NoError(t, err)
+	return kr
+}
+
+func TestMemoryBoundaryKeys(t *testing.T) {
+	ctx := context.Background()
+	kv := newEphemeralKV()
+	table := []byte("t1")
+
+	defer func(keys int) { memShardKeys = keys }(memShardKeys)
+	memShardKeys = 4
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, kv.Insert(ctx, table, BuildKey("k", i), []byte{byte(i)}))
+	}
+
+	boundaries, err := kv.BoundaryKeys(ctx, table, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		getFDBKey(table, BuildKey("k", 4)),
+		getFDBKey(table, BuildKey("k", 8)),
+	}, boundaries)
+
+	boundaries, err = kv.BoundaryKeys(ctx, table, 1)
+	require.NoError(t, err)
+	require.Len(t, boundaries, 1)
+}
+
+func TestEphemeralBackendRejectsCDC(t *testing.T) {
+	backend, cdcEnabled := config.DefaultConfig.KV.Backend, config.DefaultConfig.Cdc.Enabled
+	defer func() {
+		config.DefaultConfig.KV.Backend, config.DefaultConfig.Cdc.Enabled = backend, cdcEnabled
+	}()
+
+	config.DefaultConfig.KV.Backend, config.DefaultConfig.Cdc.Enabled = BackendEphemeral, true
+	_, err := NewBuilder().Build(&config.DefaultConfig.FoundationDB)
+	require.EqualError(t, err, "cdc requires the foundationdb kv backend, it is not supported by the 'ephemeral' backend")
+
+	config.DefaultConfig.Cdc.Enabled = false
+	_, err = NewBuilder().Build(&config.DefaultConfig.FoundationDB)
+	require.NoError(t, err)
+}
@@ -33,6 +33,8 @@ const (
 	ErrCodeValueSizeExceeded       StoreErrCode = 0x06
 	ErrCodeTransactionSizeExceeded StoreErrCode = 0x07
 	ErrCodeNotFound                StoreErrCode = 0x08
+	ErrCodeSavepointNotFound       StoreErrCode = 0x09
+	ErrCodeSavepointIrreversible   StoreErrCode = 0x0a
 )
 
 var (
@@ -50,6 +52,26 @@ var (
 	ErrNotFound                = NewStoreError(0, ErrCodeNotFound, "not found")
 )
 
+// KeyRange is a range of FoundationDB keys, the end is exclusive.
+type KeyRange struct {
+	Begin []byte
+	End   []byte
+}
+
+// ConflictError is ErrConflictingTransaction along with the key ranges read by the transaction which were written by
+// the conflicting transactions. It is only returned if the conflicting keys are reported, see KVConfig.ReportConflicts.
+type ConflictError struct {
+	StoreError
+
+	Ranges []KeyRange
+}
+
+// IsConflict returns true if the transaction wasn't committed because of a conflict with another transaction.
+func IsConflict(err error) bool {
+	var ce *ConflictError
+	return err == ErrConflictingTransaction || errors.As(err, &ce)
+}
+
 type StoreError struct {
 	code    StoreErrCode
 	fdbCode int
@@ -36,12 +36,15 @@ const (
 // fdbkv is an implementation of kv on top of FoundationDB.
 type fdbkv struct {
 	db fdb.Database
+	// versions serves the read versions of the transactions that tolerate stale reads.
+	versions versionCache
 }
 
 type ftx struct {
 	d   *fdbkv
 	tx  *fdb.Transaction
 	err error
+	sp  savepoints
 }
 
 type fdbIterator struct {
@@ -210,6 +213,10 @@ func (d *fdbkv) Get(ctx context.Context, key []byte, isSnapshot bool) Future {
 	return val.(Future)
 }
 
+func (d *fdbkv) internalDatabase() (any, error) {
+	return d.db, nil
+}
+
 func (*fdbkv) CreateTable(_ context.Context, name []byte) error {
 	log.Debug().Str("name", string(name)).Msg("table created")
 	return nil
@@ -247,6 +254,22 @@ func (d *fdbkv) TableSize(ctx context.Context, name []byte) (int64, error) {
 	return sz, err
 }
 
+// BoundaryKeys returns the keys of the table at which the storage shards start, the ranges between them are stored
+// by different servers.
+func (d *fdbkv) BoundaryKeys(_ context.Context, name []byte, limit int) ([][]byte, error) {
+	boundaries, err := d.db.LocalityGetBoundaryKeys(subspace.FromBytes(name), limit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([][]byte, len(boundaries))
+	for i, b := range boundaries {
+		res[i] = b
+	}
+
+	return res, nil
+}
+
 func (d *fdbkv) BeginTx(ctx context.Context) (baseTx, error) {
 	tx, err := d.db.CreateTransaction()
 	if ulog.E(err) {
@@ -257,6 +280,20 @@ func (d *fdbkv) BeginTx(ctx context.Context) (baseTx, error) {
 		return nil, err
 	}
 
+	if config.DefaultConfig.KV.ReportConflicts {
+		if err = tx.Options().SetReportConflictingKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = pinReadVersion(ctx, &tx); err != nil {
+		return nil, convertFDBToStoreErr(err)
+	}
+
+	if err = d.versions.staleReadVersion(ctx, &tx); err != nil {
+		return nil, convertFDBToStoreErr(err)
+	}
+
 	log.Trace().Msg("create transaction")
 
 	return &ftx{d: d, tx: &tx}, nil
@@ -275,6 +312,7 @@ func (t *ftx) Insert(_ context.Context, table []byte, key Key, data []byte) erro
 		return ErrDuplicateKey
 	}
 
+	t.sp.record(keyUndo(k, nil))
 	t.tx.Set(k, data)
 
 	log.Debug().Str("table", string(table)).Interface("key", key).Msg("Insert")
@@ -285,6 +323,10 @@ func (t *ftx) Insert(_ context.Context, table []byte, key Key, data []byte) erro
 func (t *ftx) Replace(_ context.Context, table []byte, key Key, data []byte, _ bool) error {
 	k := getFDBKey(table, key)
 
+	if err := t.undoKey(k); err != nil {
+		return convertFDBToStoreErr(err)
+	}
+
 	t.tx.Set(k, data)
 
 	log.Debug().Str("table", string(table)).Interface("key", key).Msg("tx Replace")
@@ -298,6 +340,10 @@ func (t *ftx) Delete(_ context.Context, table []byte, key Key) error {
 		return convertFDBToStoreErr(err)
 	}
 
+	if err = t.undoRange(kr); err != nil {
+		return convertFDBToStoreErr(err)
+	}
+
 	t.tx.ClearRange(kr)
 
 	log.Debug().Str("table", string(table)).Interface("key", key).Msg("tx delete")
@@ -309,6 +355,9 @@ func (t *ftx) DeleteRange(_ context.Context, table []byte, lKey Key, rKey Key) e
 	lk := getFDBKey(table, lKey)
 	rk := getFDBKey(table, rKey)
 
+	// the range may be too large to be kept in the undo log
+	t.sp.record(undoEntry{irreversible: true})
+
 	t.tx.ClearRange(fdb.KeyRange{Begin: lk, End: rk})
 
 	log.Debug().Str("table", string(table)).Interface("lKey", lKey).Interface("rKey", rKey).Msg("tx delete range")
@@ -337,19 +386,7 @@ func (t *ftx) Read(_ context.Context, table []byte, key Key, isSnapshot bool, re
 }
 
 func (t *ftx) ReadRange(_ context.Context, table []byte, lKey Key, rKey Key, isSnapshot bool, reverse bool) (baseIterator, error) {
-	lk := getFDBKey(table, lKey)
-	var rk fdb.Key
-	if rKey == nil {
-		// add a table boundary
-		rk1 := make([]byte, len(table)+1)
-		copy(rk1, table)
-		rk1[len(rk1)-1] = byte(0xFF)
-		rk = rk1
-	} else {
-		rk = getFDBKey(table, rKey)
-	}
-
-	kr := fdb.KeyRange{Begin: lk, End: rk}
+	kr := fdb.KeyRange{Begin: getFDBKey(table, lKey), End: getFDBRangeEnd(table, rKey)}
 	ro := fdb.RangeOptions{Reverse: reverse}
 
 	var r fdb.RangeResult
@@ -365,12 +402,18 @@ func (t *ftx) ReadRange(_ context.Context, table []byte, lKey Key, rKey Key, isS
 }
 
 func (t *ftx) SetVersionstampedValue(_ context.Context, key []byte, value []byte) error {
+	if err := t.undoKey(key); err != nil {
+		return convertFDBToStoreErr(err)
+	}
+
 	t.tx.SetVersionstampedValue(fdb.Key(key), value)
 
 	return nil
 }
 
 func (t *ftx) SetVersionstampedKey(_ context.Context, key []byte, value []byte) error {
+	t.sp.record(undoEntry{irreversible: true})
+
 	t.tx.SetVersionstampedKey(fdb.Key(key), value)
 
 	return nil
@@ -381,7 +424,12 @@ func (t *ftx) AtomicAdd(_ context.Context, table []byte, key Key, value int64) e
 
 	binary.LittleEndian.PutUint64(buf[:], uint64(value))
 
-	t.tx.Add(getFDBKey(table, key), buf[:])
+	k := getFDBKey(table, key)
+	if err := t.undoKey(k); err != nil {
+		return convertFDBToStoreErr(err)
+	}
+
+	t.tx.Add(k, buf[:])
 
 	return nil
 }
@@ -453,19 +501,7 @@ func (t *ftx) Get(_ context.Context, key []byte, isSnapshot bool) Future {
 // RangeSize calculates approximate range table size in bytes - this is an estimate
 // and a range smaller than 3mb will not be that accurate.
 func (t *ftx) RangeSize(_ context.Context, table []byte, lKey Key, rKey Key) (int64, error) {
-	lk := getFDBKey(table, lKey)
-	var rk fdb.Key
-	if rKey == nil {
-		// add a table boundary
-		rk1 := make([]byte, len(table)+1)
-		copy(rk1, table)
-		rk1[len(rk1)-1] = byte(0xFF)
-		rk = rk1
-	} else {
-		rk = getFDBKey(table, rKey)
-	}
-
-	kr := fdb.KeyRange{Begin: lk, End: rk}
+	kr := fdb.KeyRange{Begin: getFDBKey(table, lKey), End: getFDBRangeEnd(table, rKey)}
 	sz, err := t.tx.GetEstimatedRangeSizeBytes(kr).Get()
 	log.Trace().Str("table", string(table)).Interface("lKey", lKey).Interface("rKey", rKey).Int64("size", sz).Msg("tx range size")
 	if err != nil {
@@ -487,6 +523,11 @@ func (t *ftx) Commit(_ context.Context) error {
 	log.Err(t.err).Msg("tx Commit")
 
 	t.err = convertFDBToStoreErr(t.err)
+	if t.err == ErrConflictingTransaction && config.DefaultConfig.KV.ReportConflicts {
+		if ranges := t.conflictingRanges(); len(ranges) > 0 {
+			t.err = &ConflictError{StoreError: ErrConflictingTransaction.(StoreError), Ranges: ranges}
+		}
+	}
 
 	t.tx.Cancel()
 
@@ -585,6 +626,19 @@ func getFDBKey(table []byte, key Key) fdb.Key {
 	return k
 }
 
+// getFDBRangeEnd returns the exclusive end of a range read, the table boundary if the key is nil.
+func getFDBRangeEnd(table []byte, key Key) fdb.Key {
+	if key == nil {
+		// add a table boundary
+		rk := make([]byte, len(table)+1)
+		copy(rk, table)
+		rk[len(rk)-1] = byte(0xFF)
+		return rk
+	}
+
+	return getFDBKey(table, key)
+}
+
 // getCtxTimeout returns timeout in ms if it's set in the context
 // returns 0 if timeout is not set
 // returns negative number if timeout has expired.
@@ -16,6 +16,7 @@ package kv
 
 import (
 	"context"
+	"fmt"
 	"unsafe"
 
 	"github.com/tigrisdata/tigris/internal"
@@ -70,6 +71,11 @@ type Tx interface {
 	Rollback(context.Context) error
 	IsRetriable() bool
 	RangeSize(ctx context.Context, table []byte, lkey Key, rkey Key) (int64, error)
+	// Savepoint marks the state of the transaction, the writes done after it can be undone by RollbackToSavepoint
+	// without aborting the transaction.
+	Savepoint(ctx context.Context, name string) error
+	// RollbackToSavepoint undoes the writes done after the last savepoint with the name. The savepoint is kept.
+	RollbackToSavepoint(ctx context.Context, name string) error
 }
 
 type TxStore interface {
@@ -78,6 +84,9 @@ type TxStore interface {
 	DropTable(ctx context.Context, name []byte) error
 	GetInternalDatabase() (any, error) // TODO: CDC remove workaround
 	GetTableStats(ctx context.Context, name []byte) (*TableStats, error)
+	// GetBoundaryKeys returns up to limit keys of the table at which the shards of the storage start, the ranges
+	// between them can be read concurrently. A zero limit returns all the boundaries.
+	GetBoundaryKeys(ctx context.Context, name []byte, limit int) ([][]byte, error)
 }
 
 type Iterator interface {
@@ -105,6 +114,11 @@ func (k Key) AddPart(part any) Key {
 	return k
 }
 
+const (
+	BackendFoundationDB = "foundationdb"
+	BackendEphemeral    = "ephemeral"
+)
+
 type Builder struct {
 	isCompression bool
 	isChunking    bool
@@ -121,7 +135,7 @@ func NewBuilder() *Builder {
 // using this simple kv. Listener enabled will be added after chunking so that it is called before chunking. Finally,
 // the measure at the end.
 func (b *Builder) Build(cfg *config.FoundationDBConfig) (TxStore, error) {
-	kv, err := newFoundationDB(cfg)
+	kv, err := newBackend(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -151,6 +165,22 @@ func (b *Builder) Build(cfg *config.FoundationDBConfig) (TxStore, error) {
 	return store, nil
 }
 
+// newBackend creates the storage backend selected by the configuration.
+func newBackend(cfg *config.FoundationDBConfig) (baseKVStore, error) {
+	switch config.DefaultConfig.KV.Backend {
+	case BackendFoundationDB, "":
+		return newFoundationDB(cfg)
+	case BackendEphemeral:
+		if config.DefaultConfig.Cdc.Enabled {
+			return nil, fmt.Errorf("cdc requires the foundationdb kv backend, it is not supported by the '%s' backend",
+				BackendEphemeral)
+		}
+		return sharedEphemeralKV(), nil
+	default:
+		return nil, fmt.Errorf("unknown kv backend '%s'", config.DefaultConfig.KV.Backend)
+	}
+}
+
 func (b *Builder) WithMeasure() *Builder {
 	b.isMeasure = true
 	return b
@@ -22,30 +22,30 @@ import (
 )
 
 type KeyValueTxStore struct {
-	*fdbkv
+	baseKVStore
 }
 
-func NewTxStore(kv *fdbkv) TxStore {
+func NewTxStore(kv baseKVStore) TxStore {
 	return newTxStore(kv)
 }
 
-func newTxStore(kv *fdbkv) *KeyValueTxStore {
-	return &KeyValueTxStore{fdbkv: kv}
+func newTxStore(kv baseKVStore) *KeyValueTxStore {
+	return &KeyValueTxStore{baseKVStore: kv}
 }
 
 func (k *KeyValueTxStore) BeginTx(ctx context.Context) (Tx, error) {
-	btx, err := k.fdbkv.BeginTx(ctx)
+	btx, err := k.baseKVStore.BeginTx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KeyValueTx{
-		ftx: btx.(*ftx),
+		baseTx: btx,
 	}, nil
 }
 
 func (k *KeyValueTxStore) GetInternalDatabase() (any, error) {
-	return k.db, nil
+	return k.internalDatabase()
 }
 
 func (k *KeyValueTxStore) GetTableStats(ctx context.Context, table []byte) (*TableStats, error) {
@@ -57,8 +57,12 @@ func (k *KeyValueTxStore) GetTableStats(ctx context.Context, table []byte) (*Tab
 	return &TableStats{OnDiskSize: sz}, nil
 }
 
+func (k *KeyValueTxStore) GetBoundaryKeys(ctx context.Context, table []byte, limit int) ([][]byte, error) {
+	return k.BoundaryKeys(ctx, table, limit)
+}
+
 type KeyValueTx struct {
-	*ftx
+	baseTx
 }
 
 func (tx *KeyValueTx) Insert(ctx context.Context, table []byte, key Key, data *internal.TableData) error {
@@ -67,7 +71,7 @@ func (tx *KeyValueTx) Insert(ctx context.Context, table []byte, key Key, data *i
 		return err
 	}
 
-	return tx.ftx.Insert(ctx, table, key, enc)
+	return tx.baseTx.Insert(ctx, table, key, enc)
 }
 
 func (tx *KeyValueTx) Replace(ctx context.Context, table []byte, key Key, data *internal.TableData, isUpdate bool) error {
@@ -76,11 +80,11 @@ func (tx *KeyValueTx) Replace(ctx context.Context, table []byte, key Key, data *
 		return err
 	}
 
-	return tx.ftx.Replace(ctx, table, key, enc, isUpdate)
+	return tx.baseTx.Replace(ctx, table, key, enc, isUpdate)
 }
 
 func (tx *KeyValueTx) Read(ctx context.Context, table []byte, key Key, reverse bool) (Iterator, error) {
-	iter, err := tx.ftx.Read(ctx, table, key, false, reverse)
+	iter, err := tx.baseTx.Read(ctx, table, key, false, reverse)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +93,7 @@ func (tx *KeyValueTx) Read(ctx context.Context, table []byte, key Key, reverse b
 }
 
 func (tx *KeyValueTx) ReadRange(ctx context.Context, table []byte, lkey Key, rkey Key, isSnapshot bool, reverse bool) (Iterator, error) {
-	iter, err := tx.ftx.ReadRange(ctx, table, lkey, rkey, isSnapshot, reverse)
+	iter, err := tx.baseTx.ReadRange(ctx, table, lkey, rkey, isSnapshot, reverse)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +102,7 @@ func (tx *KeyValueTx) ReadRange(ctx context.Context, table []byte, lkey Key, rke
 }
 
 func (tx *KeyValueTx) GetMetadata(ctx context.Context, table []byte, key Key) (*internal.TableData, error) {
-	b, err := tx.ftx.Get(ctx, getFDBKey(table, key), true).Get()
+	b, err := tx.baseTx.Get(ctx, getFDBKey(table, key), true).Get()
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +26,8 @@ const (
 	ReplaceEvent = "replace"
 	UpdateEvent  = "update"
 	DeleteEvent  = "delete"
+	// OutboxEvent is an event appended to the change stream of a table without a write to the table.
+	OutboxEvent = "event"
 )
 
 type EventListenerCtxKey struct{}
@@ -35,14 +37,20 @@ type EventListenerCtxKey struct{}
 // i.e. EventListener has no knowledge whether the transaction was committed or rolled back. The lifecycle of this
 // listener is managed by QuerySession in server package.
 type EventListener interface {
-	// OnSet buffers insert/replace/update events
-	OnSet(op string, table []byte, key Key, data *internal.TableData)
-	// OnClear buffers delete events
-	OnClear(op string, table []byte, key Key)
+	// OnSet buffers insert/replace/update events, before is the replaced value if the listener needs it
+	OnSet(op string, table []byte, key Key, data *internal.TableData, before *internal.TableData)
+	// OnClear buffers delete events, before is the deleted value if the listener needs it
+	OnClear(op string, table []byte, key Key, before *internal.TableData)
+	// NeedsBeforeImage returns true if the listener buffers the value of the rows of the table as it was before the
+	// change. The store reads the current value of the row before replacing or deleting it only if this is true.
+	NeedsBeforeImage(table []byte) bool
 	// GetEvents is used to access buffered events. These events may be shared by different participants callers are
 	// strongly discourage to modify the event and if needed copy it to some other buffer. Once transaction completes
 	// session may discard all the buffered events.
 	GetEvents() []*Event
+	// Truncate discards the events buffered after the first n ones, it is called when the transaction rolls back to
+	// a savepoint.
+	Truncate(n int)
 }
 
 type Event struct {
@@ -50,11 +58,15 @@ type Event struct {
 	Table []byte
 	Key   Key                 `json:",omitempty"`
 	Data  *internal.TableData `json:",omitempty"`
-	Last  bool
+	// Before is the value of the row before the replace, update or delete. It is only set if the listener needs it.
+	Before *internal.TableData `json:",omitempty"`
+	Last   bool
 }
 
 type DefaultListener struct {
 	Events []*Event
+	// BeforeImages enables buffering of the values of the rows before the change.
+	BeforeImages bool
 }
 
 func (*DefaultListener) skip(table []byte) bool {
@@ -62,45 +74,64 @@ func (*DefaultListener) skip(table []byte) bool {
 		!bytes.Equal(table[0:4], internal.PartitionKeyPrefix)
 }
 
-func (l *DefaultListener) OnSet(op string, table []byte, key Key, data *internal.TableData) {
+func (l *DefaultListener) OnSet(op string, table []byte, key Key, data *internal.TableData, before *internal.TableData) {
 	if l.skip(table) {
 		return
 	}
 
 	l.Events = append(l.Events, &Event{
-		Op:    op,
-		Table: table,
-		Key:   key,
-		Data:  data,
+		Op:     op,
+		Table:  table,
+		Key:    key,
+		Data:   data,
+		Before: before,
 	})
 }
 
-func (l *DefaultListener) OnClear(op string, table []byte, key Key) {
+func (l *DefaultListener) OnClear(op string, table []byte, key Key, before *internal.TableData) {
 	if l.skip(table) {
 		return
 	}
 
 	l.Events = append(l.Events, &Event{
-		Op:    op,
-		Table: table,
-		Key:   key,
+		Op:     op,
+		Table:  table,
+		Key:    key,
+		Before: before,
 	})
 }
 
+func (l *DefaultListener) NeedsBeforeImage(table []byte) bool {
+	return l.BeforeImages && !l.skip(table)
+}
+
 func (l *DefaultListener) GetEvents() []*Event {
 	return l.Events
 }
 
+func (l *DefaultListener) Truncate(n int) {
+	if n < len(l.Events) {
+		l.Events = l.Events[:n]
+	}
+}
+
 type NoopEventListener struct{}
 
-func (*NoopEventListener) OnSet(string, []byte, Key, *internal.TableData) {}
-func (*NoopEventListener) OnClear(string, []byte, Key)                    {}
-func (*NoopEventListener) GetEvents() []*Event                            { return nil }
+func (*NoopEventListener) OnSet(string, []byte, Key, *internal.TableData, *internal.TableData) {}
+func (*NoopEventListener) OnClear(string, []byte, Key, *internal.TableData)                    {}
+func (*NoopEventListener) NeedsBeforeImage([]byte) bool                                        { return false }
+func (*NoopEventListener) GetEvents() []*Event                                                 { return nil }
+func (*NoopEventListener) Truncate(int)                                                        {}
 
 func WrapEventListenerCtx(ctx context.Context) context.Context {
 	return context.WithValue(ctx, EventListenerCtxKey{}, &DefaultListener{})
 }
 
+// WrapEventListenerCtxWithBeforeImages attaches a listener that also buffers the values of the rows before the change.
+func WrapEventListenerCtxWithBeforeImages(ctx context.Context) context.Context {
+	return context.WithValue(ctx, EventListenerCtxKey{}, &DefaultListener{BeforeImages: true})
+}
+
 func GetEventListener(ctx context.Context) EventListener {
 	a := ctx.Value(EventListenerCtxKey{})
 	if a != nil {
@@ -45,21 +45,34 @@ func (store *ListenerStore) BeginTx(ctx context.Context) (Tx, error) {
 // ListenerTx is the tx created for ListenerStore.
 type ListenerTx struct {
 	Tx
+
+	// savepoints are the number of events buffered when the savepoints were set.
+	savepoints []eventsMark
+}
+
+type eventsMark struct {
+	name   string
+	events int
 }
 
 func (tx *ListenerTx) Insert(ctx context.Context, table []byte, key Key, data *internal.TableData) error {
 	listener := GetEventListener(ctx)
-	listener.OnSet(InsertEvent, table, key, data)
+	listener.OnSet(InsertEvent, table, key, data, nil)
 
 	return tx.Tx.Insert(ctx, table, key, data)
 }
 
 func (tx *ListenerTx) Replace(ctx context.Context, table []byte, key Key, data *internal.TableData, isUpdate bool) error {
 	listener := GetEventListener(ctx)
+	before, err := tx.beforeImage(ctx, listener, table, key)
+	if err != nil {
+		return err
+	}
+
 	if isUpdate {
-		listener.OnSet(UpdateEvent, table, key, data)
+		listener.OnSet(UpdateEvent, table, key, data, before)
 	} else {
-		listener.OnSet(ReplaceEvent, table, key, data)
+		listener.OnSet(ReplaceEvent, table, key, data, before)
 	}
 
 	return tx.Tx.Replace(ctx, table, key, data, isUpdate)
@@ -67,8 +80,58 @@ func (tx *ListenerTx) Replace(ctx context.Context, table []byte, key Key, data *
 
 func (tx *ListenerTx) Delete(ctx context.Context, table []byte, key Key) error {
 	listener := GetEventListener(ctx)
+	before, err := tx.beforeImage(ctx, listener, table, key)
+	if err != nil {
+		return err
+	}
 
-	listener.OnClear(DeleteEvent, table, key)
+	listener.OnClear(DeleteEvent, table, key, before)
 
 	return tx.Tx.Delete(ctx, table, key)
 }
+
+// beforeImage reads the current value of the row if the listener needs it, nil if the row doesn't exist.
+func (tx *ListenerTx) beforeImage(ctx context.Context, listener EventListener, table []byte, key Key) (*internal.TableData, error) {
+	if !listener.NeedsBeforeImage(table) {
+		return nil, nil
+	}
+
+	it, err := tx.Tx.Read(ctx, table, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var row KeyValue
+	if it.Next(&row) {
+		return row.Data, nil
+	}
+
+	return nil, it.Err()
+}
+
+func (tx *ListenerTx) Savepoint(ctx context.Context, name string) error {
+	if err := tx.Tx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	tx.savepoints = append(tx.savepoints, eventsMark{name: name, events: len(GetEventListener(ctx).GetEvents())})
+
+	return nil
+}
+
+// RollbackToSavepoint also discards the events of the writes undone, they are not published on commit.
+func (tx *ListenerTx) RollbackToSavepoint(ctx context.Context, name string) error {
+	if err := tx.Tx.RollbackToSavepoint(ctx, name); err != nil {
+		return err
+	}
+
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i].name == name {
+			GetEventListener(ctx).Truncate(tx.savepoints[i].events)
+			tx.savepoints = tx.savepoints[:i+1]
+			break
+		}
+	}
+
+	return nil
+}
@@ -108,6 +108,14 @@ func (m *TxStoreWithMetrics) GetTableStats(ctx context.Context, name []byte) (st
 	return
 }
 
+func (m *TxStoreWithMetrics) GetBoundaryKeys(ctx context.Context, name []byte, limit int) (boundaries [][]byte, err error) {
+	m.measure(ctx, "GetBoundaryKeys", func() error {
+		boundaries, err = m.kv.GetBoundaryKeys(ctx, name, limit)
+		return err
+	})
+	return
+}
+
 func (m *TxStoreWithMetrics) BeginTx(ctx context.Context) (Tx, error) {
 	// This needs to be a special case in order to have the tx metrics as well
 	var btx Tx
@@ -235,6 +243,22 @@ func (m *TxImplWithMetrics) IsRetriable() bool {
 	return m.tx.IsRetriable()
 }
 
+func (m *TxImplWithMetrics) Savepoint(ctx context.Context, name string) (err error) {
+	m.measure(ctx, "Savepoint", func() error {
+		err = m.tx.Savepoint(ctx, name)
+		return err
+	})
+	return
+}
+
+func (m *TxImplWithMetrics) RollbackToSavepoint(ctx context.Context, name string) (err error) {
+	m.measure(ctx, "RollbackToSavepoint", func() error {
+		err = m.tx.RollbackToSavepoint(ctx, name)
+		return err
+	})
+	return
+}
+
 func (m *TxImplWithMetrics) Insert(ctx context.Context, table []byte, key Key, data *internal.TableData) (err error) {
 	m.measure(ctx, "Insert", func() error {
 		err = m.tx.Insert(ctx, table, key, data)
@@ -38,6 +38,9 @@ func (*NoopTx) Commit(context.Context) error   { return nil }
 func (*NoopTx) Rollback(context.Context) error { return nil }
 func (*NoopTx) IsRetriable() bool              { return false }
 
+func (*NoopTx) Savepoint(context.Context, string) error           { return nil }
+func (*NoopTx) RollbackToSavepoint(context.Context, string) error { return nil }
+
 // NoopKVStore is a noop store, useful if we need to profile/debug only compute and not with the storage. This can be
 // initialized in main.go instead of using default kvStore.
 type NoopKVStore struct {
@@ -50,6 +53,10 @@ func (*NoopKVStore) DropTable(_ context.Context, _ []byte) error          { retu
 func (*NoopKVStore) GetInternalDatabase() (any, error)                    { return nil, nil }
 func (*NoopKVStore) TableSize(_ context.Context, _ []byte) (int64, error) { return 0, nil }
 
+func (*NoopKVStore) GetBoundaryKeys(_ context.Context, _ []byte, _ int) ([][]byte, error) {
+	return nil, nil
+}
+
 func (*NoopKVStore) GetTableStats(_ context.Context, _ []byte) (*TableStats, error) {
 	return &TableStats{}, nil
 }
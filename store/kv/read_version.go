@@ -0,0 +1,185 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+)
+
+type readVersionCtxKey struct{}
+
+// ReadVersion pins all the transactions started with a context carrying it to the same read version, so that they
+// observe the same snapshot of the database. The version is taken from the first transaction. The transactions can
+// only read at the pinned version within the transaction time limit, after that they fail with
+// ErrTransactionMaxDurationReached.
+type ReadVersion struct {
+	sync.Mutex
+
+	version int64
+	// at is the time of the snapshot to read, the version is derived from it by the first transaction.
+	at time.Time
+}
+
+// WithReadVersion returns a context whose transactions read at the same version.
+func WithReadVersion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readVersionCtxKey{}, &ReadVersion{})
+}
+
+// WithReadVersionAt returns a context whose transactions read at the version. Like the transactions outliving the
+// transaction time limit, the transactions fail with ErrTransactionMaxDurationReached if the version is older than the
+// versions the store retains.
+func WithReadVersionAt(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, readVersionCtxKey{}, &ReadVersion{version: version})
+}
+
+// WithReadTime returns a context whose transactions read the snapshot of the database as of the time, i.e. at the
+// latest version committed before it. Only the snapshots within the MVCC window of the store are readable, which is
+// the transaction time limit by default, the transactions fail with ErrTransactionMaxDurationReached otherwise.
+func WithReadTime(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, readVersionCtxKey{}, &ReadVersion{at: at})
+}
+
+// HasReadVersion returns true if the transactions of the context are pinned to a read version.
+func HasReadVersion(ctx context.Context) bool {
+	_, ok := ctx.Value(readVersionCtxKey{}).(*ReadVersion)
+	return ok
+}
+
+// GetReadVersion returns the version the transactions of the context are pinned to, zero if it is not fixed yet.
+func GetReadVersion(ctx context.Context) int64 {
+	rv, ok := ctx.Value(readVersionCtxKey{}).(*ReadVersion)
+	if !ok {
+		return 0
+	}
+
+	rv.Lock()
+	defer rv.Unlock()
+
+	return rv.version
+}
+
+// pinReadVersion applies the read version of the context to the transaction.
+func pinReadVersion(ctx context.Context, tx *fdb.Transaction) error {
+	rv, ok := ctx.Value(readVersionCtxKey{}).(*ReadVersion)
+	if !ok {
+		return nil
+	}
+
+	rv.Lock()
+	defer rv.Unlock()
+
+	if rv.version != 0 {
+		tx.SetReadVersion(rv.version)
+		return nil
+	}
+
+	version, err := tx.GetReadVersion().Get()
+	if err != nil {
+		return err
+	}
+	rv.version = version
+
+	if !rv.at.IsZero() {
+		// the cluster advances the versions by about a million per second
+		rv.version -= int64(time.Since(rv.at) / time.Microsecond)
+		if rv.version < 0 {
+			rv.version = 0
+		}
+		tx.SetReadVersion(rv.version)
+	}
+
+	return nil
+}
+
+type maxStalenessCtxKey struct{}
+
+// WithMaxStaleness returns a context whose transactions may read at a version acquired by a previous transaction up
+// to the staleness ago, instead of acquiring the latest version. It saves the read version round trip of the
+// latency-sensitive reads that tolerate stale data. The transactions of the context should only read, the commit of
+// a write at a stale version fails if the data read has changed since.
+func WithMaxStaleness(ctx context.Context, staleness time.Duration) context.Context {
+	if staleness <= 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, maxStalenessCtxKey{}, staleness)
+}
+
+// HasMaxStaleness returns true if the transactions of the context may read stale data.
+func HasMaxStaleness(ctx context.Context) bool {
+	return getMaxStaleness(ctx) > 0
+}
+
+func getMaxStaleness(ctx context.Context) time.Duration {
+	staleness, _ := ctx.Value(maxStalenessCtxKey{}).(time.Duration)
+	return staleness
+}
+
+// versionCache keeps the last read version acquired for the transactions that tolerate stale reads.
+type versionCache struct {
+	sync.Mutex
+
+	version int64
+	// acquired is the time the version was requested, the version is at least as recent.
+	acquired time.Time
+}
+
+// get returns the cached version if it was acquired within the staleness.
+func (c *versionCache) get(staleness time.Duration) (int64, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.version == 0 || time.Since(c.acquired) > staleness {
+		return 0, false
+	}
+
+	return c.version, true
+}
+
+func (c *versionCache) set(version int64, acquired time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	if version > c.version {
+		c.version, c.acquired = version, acquired
+	}
+}
+
+// staleReadVersion sets a cached read version on the transaction if the context tolerates stale reads, otherwise it
+// acquires the version and caches it for the next transactions.
+func (c *versionCache) staleReadVersion(ctx context.Context, tx *fdb.Transaction) error {
+	staleness := getMaxStaleness(ctx)
+	if staleness == 0 || HasReadVersion(ctx) {
+		return nil
+	}
+
+	if version, ok := c.get(staleness); ok {
+		tx.SetReadVersion(version)
+		return nil
+	}
+
+	acquired := time.Now()
+	version, err := tx.GetReadVersion().Get()
+	if err != nil {
+		return err
+	}
+	c.set(version, acquired)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCache(t *testing.T) {
+	var c versionCache
+
+	_, ok := c.get(time.Second)
+	require.False(t, ok)
+
+	c.set(10, time.Now())
+	v, ok := c.get(time.Second)
+	require.True(t, ok)
+	require.Equal(t, int64(10), v)
+
+	// an older version doesn't replace the cached one
+	c.set(5, time.Now())
+	v, _ = c.get(time.Second)
+	require.Equal(t, int64(10), v)
+
+	c.set(20, time.Now().Add(-2*time.Second))
+	_, ok = c.get(time.Second)
+	require.False(t, ok)
+	v, ok = c.get(3 * time.Second)
+	require.True(t, ok)
+	require.Equal(t, int64(20), v)
+}
+
+func TestMaxStaleness(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, time.Duration(0), getMaxStaleness(ctx))
+	require.Equal(t, ctx, WithMaxStaleness(ctx, 0))
+	require.Equal(t, 100*time.Millisecond, getMaxStaleness(WithMaxStaleness(ctx, 100*time.Millisecond)))
+}
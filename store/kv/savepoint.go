@@ -0,0 +1,159 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/rs/zerolog/log"
+)
+
+// savepoints is the undo log of a transaction. Once a savepoint is set, the transaction keeps the values overwritten
+// by its writes so that it can roll back to the savepoint by writing them back, without aborting the transaction and
+// losing its reads. Nothing is kept while there is no savepoint.
+type savepoints struct {
+	names []string
+	// marks are the positions of the savepoints in the undo log.
+	marks []int
+	undo  []undoEntry
+}
+
+// undoEntry restores a range of keys by clearing it and setting back the values it contained.
+type undoEntry struct {
+	kr  fdb.KeyRange
+	kvs []fdb.KeyValue
+	// irreversible is set for the writes which can't be undone, like the versionstamped keys whose key is only
+	// known on commit.
+	irreversible bool
+}
+
+func (s *savepoints) active() bool {
+	return len(s.names) > 0
+}
+
+// add sets a savepoint at the current position of the undo log. A savepoint with the same name as an existing one
+// hides it until the transaction rolls back to a savepoint set before.
+func (s *savepoints) add(name string) {
+	s.names = append(s.names, name)
+	s.marks = append(s.marks, len(s.undo))
+}
+
+func (s *savepoints) record(e undoEntry) {
+	if s.active() {
+		s.undo = append(s.undo, e)
+	}
+}
+
+// rollbackTo returns the entries to apply, most recent first, to restore the state of the savepoint. The savepoints
+// set after it are discarded but the savepoint itself is kept, the transaction can roll back to it again.
+func (s *savepoints) rollbackTo(name string) ([]undoEntry, error) {
+	i := len(s.names) - 1
+	for i >= 0 && s.names[i] != name {
+		i--
+	}
+	if i < 0 {
+		return nil, NewStoreError(0, ErrCodeSavepointNotFound, "savepoint '%s' not found", name)
+	}
+
+	entries := s.undo[s.marks[i]:]
+	for _, e := range entries {
+		if e.irreversible {
+			return nil, NewStoreError(0, ErrCodeSavepointIrreversible, "the writes done after savepoint '%s' can't be rolled back", name)
+		}
+	}
+
+	reversed := make([]undoEntry, 0, len(entries))
+	for j := len(entries) - 1; j >= 0; j-- {
+		reversed = append(reversed, entries[j])
+	}
+
+	s.undo = s.undo[:s.marks[i]]
+	s.names, s.marks = s.names[:i+1], s.marks[:i+1]
+
+	return reversed, nil
+}
+
+// keyUndo returns the entry restoring the value of a single key, nil if the key doesn't exist.
+func keyUndo(key fdb.Key, value []byte) undoEntry {
+	end := make(fdb.Key, len(key)+1)
+	copy(end, key)
+
+	e := undoEntry{kr: fdb.KeyRange{Begin: key, End: end}}
+	if value != nil {
+		e.kvs = []fdb.KeyValue{{Key: key, Value: value}}
+	}
+
+	return e
+}
+
+// undoKey keeps the current value of the key before it is overwritten if there is a savepoint. The value is read at
+// snapshot isolation to not add read conflicts to the transaction, the write conflicts on the key anyway.
+func (t *ftx) undoKey(key fdb.Key) error {
+	if !t.sp.active() {
+		return nil
+	}
+
+	value, err := t.tx.Snapshot().Get(key).Get()
+	if err != nil {
+		return err
+	}
+
+	t.sp.record(keyUndo(key, value))
+
+	return nil
+}
+
+// undoRange keeps the current values of the range before it is cleared if there is a savepoint.
+func (t *ftx) undoRange(kr fdb.KeyRange) error {
+	if !t.sp.active() {
+		return nil
+	}
+
+	kvs, err := t.tx.Snapshot().GetRange(kr, fdb.RangeOptions{}).GetSliceWithError()
+	if err != nil {
+		return err
+	}
+
+	t.sp.record(undoEntry{kr: kr, kvs: kvs})
+
+	return nil
+}
+
+func (t *ftx) Savepoint(_ context.Context, name string) error {
+	t.sp.add(name)
+
+	log.Debug().Str("name", name).Msg("tx savepoint")
+
+	return nil
+}
+
+func (t *ftx) RollbackToSavepoint(_ context.Context, name string) error {
+	entries, err := t.sp.rollbackTo(name)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		t.tx.ClearRange(e.kr)
+		for _, kv := range e.kvs {
+			t.tx.Set(kv.Key, kv.Value)
+		}
+	}
+
+	log.Debug().Str("name", name).Int("undone", len(entries)).Msg("tx rollback to savepoint")
+
+	return nil
+}
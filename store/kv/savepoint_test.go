@@ -0,0 +1,94 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/internal"
+)
+
+func TestSavepoints(t *testing.T) {
+	var s savepoints
+
+	// nothing is kept until the first savepoint
+	s.record(keyUndo(fdb.Key("k0"), nil))
+	require.Empty(t, s.undo)
+
+	s.add("sp1")
+	s.record(keyUndo(fdb.Key("k1"), nil))
+	s.add("sp2")
+	s.record(keyUndo(fdb.Key("k2"), []byte("v2")))
+	s.record(keyUndo(fdb.Key("k3"), nil))
+
+	entries, err := s.rollbackTo("sp2")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, fdb.Key("k3"), entries[0].kr.Begin)
+	require.Equal(t, fdb.Key("k3\x00"), entries[0].kr.End)
+	require.Empty(t, entries[0].kvs)
+	require.Equal(t, []fdb.KeyValue{{Key: fdb.Key("k2"), Value: []byte("v2")}}, entries[1].kvs)
+
+	// the savepoint is kept
+	entries, err = s.rollbackTo("sp2")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	entries, err = s.rollbackTo("sp1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, fdb.Key("k1"), entries[0].kr.Begin)
+
+	// the savepoints set after the one rolled back to are released
+	_, err = s.rollbackTo("sp2")
+	require.Equal(t, ErrCodeSavepointNotFound, err.(StoreError).Code())
+
+	s.record(undoEntry{irreversible: true})
+	_, err = s.rollbackTo("sp1")
+	require.Equal(t, ErrCodeSavepointIrreversible, err.(StoreError).Code())
+
+	// a savepoint set after the irreversible write can be rolled back to
+	s.add("sp3")
+	s.record(keyUndo(fdb.Key("k4"), nil))
+	entries, err = s.rollbackTo("sp3")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestSavepointsSameName(t *testing.T) {
+	var s savepoints
+
+	s.add("sp")
+	s.record(keyUndo(fdb.Key("k1"), nil))
+	s.add("sp")
+	s.record(keyUndo(fdb.Key("k2"), nil))
+
+	entries, err := s.rollbackTo("sp")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, fdb.Key("k2"), entries[0].kr.Begin)
+}
+
+func TestListenerTruncate(t *testing.T) {
+	l := &DefaultListener{}
+	l.OnSet(InsertEvent, internal.UserTableKeyPrefix, BuildKey("k1"), nil, nil)
+	l.OnSet(InsertEvent, internal.UserTableKeyPrefix, BuildKey("k2"), nil, nil)
+	l.Truncate(1)
+	require.Len(t, l.GetEvents(), 1)
+	l.Truncate(3)
+	require.Len(t, l.GetEvents(), 1)
+}
@@ -0,0 +1,702 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tigrisdata/tigris/query/filter"
+	qsearch "github.com/tigrisdata/tigris/query/search"
+	tsApi "github.com/tigrisdata/typesense-go/typesense/api"
+)
+
+// The search backends, selected by the "backend" of the search config.
+const (
+	BackendTypesense = "typesense"
+	BackendEmbedded  = "embedded"
+)
+
+const embeddedDefaultPageSize = 10
+
+// EmbeddedStore is a search store running inside the server, for the development and the single node deployments
+// that don't run a Typesense server. The search indexes are kept in memory, so they are empty after a restart and are
+// rebuilt from the collections with the RebuildSearchIndex API.
+//
+// The documents are matched by the prefixes of the words of the searched fields and filtered and sorted the same way
+// as Typesense does it. Typo tolerance, highlighting, grouping and vector search are not supported.
+type EmbeddedStore struct {
+	sync.RWMutex
+
+	collections map[string]*embeddedCollection
+}
+
+type embeddedCollection struct {
+	schema    tsApi.CollectionSchema
+	createdAt int64
+	docs      map[string]*embeddedDoc
+	synonyms  map[string]tsApi.SearchSynonymSchema
+	// seq orders the documents by their first indexing, it is the order of the results that are not sorted.
+	seq uint64
+}
+
+type embeddedDoc struct {
+	seq    uint64
+	fields map[string]any
+}
+
+type embeddedHit struct {
+	doc   *embeddedDoc
+	score int64
+	// distance is the distance in meters of the geopoint field sorted by the distance from a point.
+	distance map[string]int
+}
+
+func NewEmbeddedStore() *EmbeddedStore {
+	return &EmbeddedStore{
+		collections: make(map[string]*embeddedCollection),
+	}
+}
+
+func errEmbeddedNotFound(msg string, args ...any) error {
+	return NewSearchError(http.StatusNotFound, ErrCodeNotFound, msg, args...)
+}
+
+func (s *EmbeddedStore) collection(name string) (*embeddedCollection, error) {
+	c, ok := s.collections[name]
+	if !ok {
+		return nil, errEmbeddedNotFound("Collection `%s` not found.", name)
+	}
+
+	return c, nil
+}
+
+func (s *EmbeddedStore) AllCollections(context.Context) (map[string]*tsApi.CollectionResponse, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	resp := make(map[string]*tsApi.CollectionResponse, len(s.collections))
+	for name, c := range s.collections {
+		resp[name] = c.describe()
+	}
+
+	return resp, nil
+}
+
+func (s *EmbeddedStore) DescribeCollection(_ context.Context, name string) (*tsApi.CollectionResponse, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	c, err := s.collection(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.describe(), nil
+}
+
+func (c *embeddedCollection) describe() *tsApi.CollectionResponse {
+	numDocuments, createdAt := int64(len(c.docs)), c.createdAt
+	enableNested := true
+
+	return &tsApi.CollectionResponse{
+		Name:                c.schema.Name,
+		Fields:              append([]tsApi.Field(nil), c.schema.Fields...),
+		DefaultSortingField: c.schema.DefaultSortingField,
+		EnableNestedFields:  &enableNested,
+		NumDocuments:        &numDocuments,
+		CreatedAt:           &createdAt,
+	}
+}
+
+func (s *EmbeddedStore) CreateCollection(_ context.Context, schema *tsApi.CollectionSchema) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.collections[schema.Name]; ok {
+		return NewSearchError(http.StatusConflict, ErrCodeDuplicate, "A collection with name `%s` already exists.", schema.Name)
+	}
+
+	names := make(map[string]struct{}, len(schema.Fields))
+	for _, f := range schema.Fields {
+		if _, ok := names[f.Name]; ok {
+			return NewSearchError(http.StatusBadRequest, ErrCodeInvalid, errDuplicateFields)
+		}
+		names[f.Name] = struct{}{}
+	}
+
+	s.collections[schema.Name] = &embeddedCollection{
+		schema:    *schema,
+		createdAt: time.Now().Unix(),
+		docs:      make(map[string]*embeddedDoc),
+		synonyms:  make(map[string]tsApi.SearchSynonymSchema),
+	}
+
+	return nil
+}
+
+func (s *EmbeddedStore) UpdateCollection(_ context.Context, name string, schema *tsApi.CollectionUpdateSchema) error {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(name)
+	if err != nil {
+		return err
+	}
+
+	fields := c.schema.Fields
+	for _, update := range schema.Fields {
+		kept := fields[:0:0]
+		for _, f := range fields {
+			if f.Name != update.Name {
+				kept = append(kept, f)
+			}
+		}
+		if update.Drop == nil || !*update.Drop {
+			kept = append(kept, update)
+		}
+		fields = kept
+	}
+	c.schema.Fields = fields
+
+	return nil
+}
+
+func (s *EmbeddedStore) DropCollection(_ context.Context, table string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := s.collection(table); err != nil {
+		return err
+	}
+	delete(s.collections, table)
+
+	return nil
+}
+
+func (s *EmbeddedStore) CreateDocument(_ context.Context, table string, doc map[string]any) error {
+	data, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return err
+	}
+
+	if resp := c.index(data, Create); !resp.Success {
+		return NewSearchError(resp.Code, ErrCodeIndexingDocuments, resp.Error)
+	}
+
+	return nil
+}
+
+func (s *EmbeddedStore) IndexDocuments(_ context.Context, table string, documents io.Reader, options IndexDocumentsOptions) ([]IndexResp, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []IndexResp
+	reader := bufio.NewReader(documents)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			responses = append(responses, c.index(line, options.Action))
+		}
+		if err == io.EOF {
+			return responses, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// index applies the action to a single document, the failures are reported in the response the way Typesense reports
+// them in the responses of the imports.
+func (c *embeddedCollection) index(data []byte, action IndexAction) IndexResp {
+	fields, err := decodeEmbeddedDoc(data)
+	if err != nil {
+		return IndexResp{Code: http.StatusBadRequest, Document: string(data), Error: err.Error()}
+	}
+
+	id, ok := fields["id"].(string)
+	if !ok || len(id) == 0 {
+		return IndexResp{Code: http.StatusBadRequest, Document: string(data), Error: "Document's `id` field should be a string."}
+	}
+
+	existing, exists := c.docs[id]
+	switch action {
+	case Create:
+		if exists {
+			return IndexResp{Code: http.StatusConflict, Document: string(data), Error: "A document with id " + id + " already exists."}
+		}
+	case Update:
+		if !exists {
+			return IndexResp{Code: http.StatusNotFound, Document: string(data), Error: "Could not find a document with id: " + id}
+		}
+		merged := make(map[string]any, len(existing.fields)+len(fields))
+		for k, v := range existing.fields {
+			merged[k] = v
+		}
+		for k, v := range fields {
+			if v == nil {
+				delete(merged, k)
+			} else {
+				merged[k] = v
+			}
+		}
+		fields = merged
+	}
+
+	if exists {
+		existing.fields = fields
+	} else {
+		c.seq++
+		c.docs[id] = &embeddedDoc{seq: c.seq, fields: fields}
+	}
+
+	return IndexResp{Success: true}
+}
+
+func decodeEmbeddedDoc(data []byte) (map[string]any, error) {
+	decoder := jsoniter.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (s *EmbeddedStore) DeleteDocument(_ context.Context, table string, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.docs[key]; !ok {
+		return errEmbeddedNotFound("Could not find a document with id: %s", key)
+	}
+	delete(c.docs, key)
+
+	return nil
+}
+
+func (s *EmbeddedStore) DeleteDocuments(_ context.Context, table string, filter *filter.WrappedFilter) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	for id, doc := range c.docs {
+		if filter == nil || matchesEmbeddedFilter(filter.Filter, doc.fields) {
+			delete(c.docs, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *EmbeddedStore) GetDocuments(_ context.Context, table string, ids []string) (*tsApi.SearchResult, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]tsApi.SearchResultHit, 0, len(ids))
+	for _, id := range ids {
+		if doc, ok := c.docs[id]; ok {
+			hits = append(hits, (&embeddedHit{doc: doc}).toHit())
+		}
+	}
+	found, outOf, page := len(hits), len(c.docs), 1
+
+	return &tsApi.SearchResult{Hits: &hits, Found: &found, OutOf: &outOf, Page: &page}, nil
+}
+
+func (s *EmbeddedStore) UpsertSynonym(_ context.Context, table string, id string, synonym *tsApi.SearchSynonymSchema) error {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return err
+	}
+	c.synonyms[id] = *synonym
+
+	return nil
+}
+
+func (s *EmbeddedStore) DeleteSynonym(_ context.Context, table string, id string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.synonyms[id]; !ok {
+		return errEmbeddedNotFound("Could not find that `id`.")
+	}
+	delete(c.synonyms, id)
+
+	return nil
+}
+
+func (s *EmbeddedStore) Search(_ context.Context, table string, query *qsearch.Query, pageNo int) ([]tsApi.SearchResult, error) {
+	if query.IsGroupByQuery() {
+		return nil, NewSearchError(http.StatusBadRequest, ErrCodeInvalid, "group by is not supported by the embedded search store")
+	}
+	if query.IsVectorSearch() {
+		return nil, NewSearchError(http.StatusBadRequest, ErrCodeInvalid, "vector search is not supported by the embedded search store")
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	c, err := s.collection(table)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := c.match(query)
+	sortEmbeddedHits(hits, query)
+
+	results := []tsApi.SearchResult{c.result(query, hits, pageNo)}
+	// the range facet buckets follow the result of the query, as the searches of the buckets do with Typesense
+	for _, f := range query.Facets.RangeFields() {
+		for _, r := range f.Ranges {
+			from, to := r.Bounds()
+
+			var inRange []*embeddedHit
+			for _, h := range hits {
+				if inEmbeddedRange(h.doc.fields[f.Name], from, to) {
+					inRange = append(inRange, h)
+				}
+			}
+
+			found, outOf, page := len(inRange), len(c.docs), 1
+			result := tsApi.SearchResult{Hits: &[]tsApi.SearchResultHit{}, Found: &found, OutOf: &outOf, Page: &page}
+			if f.RangeStats {
+				result.FacetCounts = &[]tsApi.FacetCounts{embeddedFacetCounts(f.Name, inRange, 1, "")}
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// match returns the documents matching the text and the filter of the query, in the order of their first indexing.
+func (c *embeddedCollection) match(query *qsearch.Query) []*embeddedHit {
+	tokens := c.queryTokens(query.Q)
+
+	var hits []*embeddedHit
+	for _, doc := range c.docs {
+		if query.WrappedF != nil && !matchesEmbeddedFilter(query.WrappedF.Filter, doc.fields) {
+			continue
+		}
+
+		score, ok := textScore(doc.fields, query.SearchFields, tokens)
+		if !ok {
+			continue
+		}
+		hits = append(hits, &embeddedHit{doc: doc, score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].doc.seq < hits[j].doc.seq
+	})
+
+	return hits
+}
+
+// queryTokens returns the words of the query, each with its synonyms.
+func (c *embeddedCollection) queryTokens(q string) [][]string {
+	if q == "*" {
+		return nil
+	}
+
+	var tokens [][]string
+	for _, word := range tokenize(q) {
+		alternatives := []string{word}
+		for _, synonym := range c.synonyms {
+			isSynonym := synonym.Root != nil && strings.ToLower(*synonym.Root) == word
+			for _, s := range synonym.Synonyms {
+				isSynonym = isSynonym || (synonym.Root == nil && strings.ToLower(s) == word)
+			}
+			if !isSynonym {
+				continue
+			}
+			for _, s := range synonym.Synonyms {
+				if words := tokenize(s); len(words) == 1 && words[0] != word {
+					alternatives = append(alternatives, words[0])
+				}
+			}
+		}
+		tokens = append(tokens, alternatives)
+	}
+
+	return tokens
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// textScore returns false if a word of the query doesn't match the searched fields of the document. The score counts
+// the words matched, the exact matches count more than the matches of a prefix.
+func textScore(doc map[string]any, fields []string, tokens [][]string) (int64, bool) {
+	if len(tokens) == 0 {
+		return 0, true
+	}
+
+	var words []string
+	addWords := func(v any) {
+		switch t := v.(type) {
+		case string:
+			words = append(words, tokenize(t)...)
+		case []any:
+			for _, item := range t {
+				if str, ok := item.(string); ok {
+					words = append(words, tokenize(str)...)
+				}
+			}
+		}
+	}
+	if len(fields) == 0 {
+		for _, v := range doc {
+			addWords(v)
+		}
+	}
+	for _, f := range fields {
+		addWords(doc[f])
+	}
+
+	var score int64
+	for _, alternatives := range tokens {
+		var best int64
+		for _, alt := range alternatives {
+			for _, w := range words {
+				if w == alt {
+					best = 2
+				} else if best == 0 && strings.HasPrefix(w, alt) {
+					best = 1
+				}
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += best
+	}
+
+	return score, true
+}
+
+func (c *embeddedCollection) result(query *qsearch.Query, hits []*embeddedHit, pageNo int) tsApi.SearchResult {
+	pageSize := query.PageSize
+	if pageSize < 0 {
+		pageSize = embeddedDefaultPageSize
+	}
+	if pageNo < 1 {
+		pageNo = 1
+	}
+
+	page := make([]tsApi.SearchResultHit, 0, pageSize)
+	for i := (pageNo - 1) * pageSize; i < len(hits) && len(page) < pageSize; i++ {
+		page = append(page, hits[i].toHit())
+	}
+
+	found, outOf := len(hits), len(c.docs)
+	result := tsApi.SearchResult{Hits: &page, Found: &found, OutOf: &outOf, Page: &pageNo}
+
+	var facets []tsApi.FacetCounts
+	size := query.ToSearchFacetSize()
+	for _, f := range query.Facets.Fields {
+		if !f.IsRange() {
+			facets = append(facets, embeddedFacetCounts(f.Name, hits, size, f.Query))
+		}
+	}
+	if len(facets) > 0 {
+		result.FacetCounts = &facets
+	}
+
+	return result
+}
+
+func (h *embeddedHit) toHit() tsApi.SearchResultHit {
+	// the readers of the hits unpack the documents in place
+	doc := make(map[string]any, len(h.doc.fields))
+	for k, v := range h.doc.fields {
+		doc[k] = v
+	}
+
+	hit := tsApi.SearchResultHit{Document: &doc, TextMatch: &h.score}
+	if len(h.distance) > 0 {
+		hit.GeoDistanceMeters = &h.distance
+	}
+
+	return hit
+}
+
+func inEmbeddedRange(v any, from string, to string) bool {
+	n, ok := embeddedNumber(v)
+	if !ok {
+		return false
+	}
+	if bound, ok := embeddedNumber(jsonNumber(from)); len(from) > 0 && (!ok || n < bound) {
+		return false
+	}
+	if bound, ok := embeddedNumber(jsonNumber(to)); len(to) > 0 && (!ok || n >= bound) {
+		return false
+	}
+
+	return true
+}
+
+// matchesFacetQuery returns true if a word of the facet value starts with every word of the query.
+func matchesFacetQuery(value string, query string) bool {
+	words := tokenize(value)
+	for _, q := range tokenize(query) {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// embeddedFacetCounts counts the values of the field in the hits, the size most frequent ones are returned. The
+// stats are set if all the values are numbers. If the query is set, only the values with a word starting with the
+// query are counted, same as the facet query of Typesense.
+func embeddedFacetCounts(field string, hits []*embeddedHit, size int, query string) tsApi.FacetCounts {
+	counts := make(map[string]int)
+	var (
+		numeric        = true
+		sum, low, high float64
+		numbers        int
+	)
+	count := func(v any) {
+		if v == nil || (len(query) > 0 && !matchesFacetQuery(fmt.Sprint(v), query)) {
+			return
+		}
+		counts[fmt.Sprint(v)]++
+		if n, ok := embeddedNumber(v); ok {
+			if numbers == 0 || n < low {
+				low = n
+			}
+			if numbers == 0 || n > high {
+				high = n
+			}
+			sum += n
+			numbers++
+		} else {
+			numeric = false
+		}
+	}
+	for _, h := range hits {
+		if arr, ok := h.doc.fields[field].([]any); ok {
+			for _, item := range arr {
+				count(item)
+			}
+		} else {
+			count(h.doc.fields[field])
+		}
+	}
+
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if size > 0 && len(values) > size {
+		values = values[:size]
+	}
+
+	facet := tsApi.FacetCounts{FieldName: &field}
+	facetValues := make([]struct {
+		Count       *int    `json:"count,omitempty"`
+		Highlighted *string `json:"highlighted,omitempty"`
+		Value       *string `json:"value,omitempty"`
+	}, len(values))
+	for i := range values {
+		n := counts[values[i]]
+		facetValues[i].Count = &n
+		facetValues[i].Value = &values[i]
+		facetValues[i].Highlighted = &values[i]
+	}
+	facet.Counts = &facetValues
+
+	totalValues := len(counts)
+	facet.Stats = &struct {
+		Avg         *float64 `json:"avg,omitempty"`
+		Max         *float64 `json:"max,omitempty"`
+		Min         *float64 `json:"min,omitempty"`
+		Sum         *float64 `json:"sum,omitempty"`
+		TotalValues *int     `json:"total_values,omitempty"`
+	}{TotalValues: &totalValues}
+	if numeric && numbers > 0 {
+		avg := sum / float64(numbers)
+		facet.Stats.Avg, facet.Stats.Max, facet.Stats.Min, facet.Stats.Sum = &avg, &high, &low, &sum
+	}
+
+	return facet
+}
@@ -0,0 +1,275 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tigrisdata/tigris/query/filter"
+	qsearch "github.com/tigrisdata/tigris/query/search"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// matchesEmbeddedFilter evaluates the filter on a document of the embedded store. The documents are packed for the
+// search store, their fields are flattened and the dates are unix nanoseconds, so the values are converted to the
+// types of the filter before they are compared.
+func matchesEmbeddedFilter(f filter.Filter, doc map[string]any) bool {
+	switch t := f.(type) {
+	case *filter.AndFilter:
+		for _, nested := range t.GetFilters() {
+			if !matchesEmbeddedFilter(nested, doc) {
+				return false
+			}
+		}
+		return true
+	case *filter.OrFilter:
+		for _, nested := range t.GetFilters() {
+			if matchesEmbeddedFilter(nested, doc) {
+				return true
+			}
+		}
+		return false
+	case *filter.Selector:
+		return matchesEmbeddedSelector(t, doc)
+	case *filter.GeoFilter:
+		if _, ok := doc[t.Field.InMemoryName()]; !ok {
+			return false
+		}
+		return t.MatchesDoc(doc)
+	default:
+		return f.MatchesDoc(doc)
+	}
+}
+
+func matchesEmbeddedSelector(s *filter.Selector, doc map[string]any) bool {
+	if s.Field.Expression != nil {
+		// the expressions are not indexed, the documents are filtered by them once they are read
+		return true
+	}
+
+	like := s.Matcher.GetValue()
+	if set, ok := s.Matcher.(filter.SetMatcher); ok && len(set.GetValues()) > 0 {
+		like = set.GetValues()[0]
+	}
+
+	raw, ok := doc[s.Field.InMemoryName()]
+	if like == nil || like.AsInterface() == nil {
+		return !ok || raw == nil
+	}
+	if !ok || raw == nil {
+		return false
+	}
+
+	if arr, ok := raw.([]any); ok {
+		elements := make([]any, len(arr))
+		for i, e := range arr {
+			elements[i] = embeddedNative(e, like)
+		}
+
+		if list, ok := like.(*value.ArrayValue); ok {
+			// an array in the filter matches the arrays that have all its elements
+			items, _ := list.AsInterface().([]any)
+			for _, item := range items {
+				if !containsEmbedded(elements, item) {
+					return false
+				}
+			}
+			return true
+		}
+
+		return s.Matcher.ArrMatches(elements)
+	}
+
+	v := embeddedValue(raw, like, s.Collation)
+	if v == nil {
+		return false
+	}
+
+	return s.Matcher.Matches(v)
+}
+
+func containsEmbedded(elements []any, item any) bool {
+	for _, e := range elements {
+		if fmt.Sprint(e) == fmt.Sprint(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// embeddedNative converts a value of a search document to the Go type of the value it is compared with.
+func embeddedNative(raw any, like value.Value) any {
+	switch like.(type) {
+	case *value.IntValue:
+		if n, ok := raw.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				return i
+			}
+			if f, err := n.Float64(); err == nil {
+				return int64(f)
+			}
+		}
+	case *value.DoubleValue:
+		if f, ok := embeddedNumber(raw); ok {
+			return f
+		}
+	case *value.DateTimeValue:
+		if n, ok := raw.(json.Number); ok {
+			if nsec, err := n.Int64(); err == nil {
+				return time.Unix(0, nsec).UTC().Format(schema.DateTimeFormat)
+			}
+		}
+	}
+
+	return raw
+}
+
+func embeddedValue(raw any, like value.Value, collation *value.Collation) value.Value {
+	native := embeddedNative(raw, like)
+
+	switch like.(type) {
+	case *value.IntValue:
+		if i, ok := native.(int64); ok {
+			return value.NewIntValue(i)
+		}
+	case *value.DoubleValue:
+		if f, ok := native.(float64); ok {
+			return value.NewDoubleUsingFloat(f)
+		}
+	case *value.DateTimeValue:
+		if str, ok := native.(string); ok {
+			return value.NewDateTimeValue(str)
+		}
+	case *value.BoolValue:
+		if b, ok := native.(bool); ok {
+			return value.NewBoolValue(b)
+		}
+	case *value.StringValue:
+		if str, ok := native.(string); ok {
+			return value.NewStringValue(str, collation)
+		}
+	}
+
+	return nil
+}
+
+func embeddedNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case float64:
+		return t, true
+	case int64:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	}
+
+	return 0, false
+}
+
+func jsonNumber(s string) any {
+	return json.Number(s)
+}
+
+// sortEmbeddedHits sorts the hits by the sort order of the query, the hits matching more words of the query come
+// first if the query has no sort order.
+func sortEmbeddedHits(hits []*embeddedHit, query *qsearch.Query) {
+	if query.SortOrder == nil {
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].score > hits[j].score
+		})
+		return
+	}
+
+	for _, f := range *query.SortOrder {
+		if f.GeoDistance == nil {
+			continue
+		}
+		for _, h := range hits {
+			if lat, lng, err := schema.ParseGeoPoint(h.doc.fields[f.Name]); err == nil {
+				if h.distance == nil {
+					h.distance = make(map[string]int)
+				}
+				h.distance[f.Name] = int(math.Round(filter.HaversineKm(f.GeoDistance.Lat, f.GeoDistance.Lng, lat, lng) * 1000))
+			}
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, f := range *query.SortOrder {
+			var a, b any
+			if f.GeoDistance != nil {
+				if d, ok := hits[i].distance[f.Name]; ok {
+					a = float64(d)
+				}
+				if d, ok := hits[j].distance[f.Name]; ok {
+					b = float64(d)
+				}
+			} else {
+				a, b = hits[i].doc.fields[f.Name], hits[j].doc.fields[f.Name]
+			}
+
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				return f.MissingValuesFirst
+			case b == nil:
+				return !f.MissingValuesFirst
+			}
+
+			if cmp := compareEmbedded(a, b); cmp != 0 {
+				return (cmp < 0) == f.Ascending
+			}
+		}
+
+		return false
+	})
+}
+
+func compareEmbedded(a any, b any) int {
+	if x, ok := embeddedNumber(a); ok {
+		if y, ok := embeddedNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok && x != y {
+			if !x {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,154 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/tigrisdata/tigris/query/filter"
+	qsearch "github.com/tigrisdata/tigris/query/search"
+	"github.com/tigrisdata/tigris/query/sort"
+	"github.com/tigrisdata/tigris/schema"
+	tsApi "github.com/tigrisdata/typesense-go/typesense/api"
+)
+
+func TestEmbeddedStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewEmbeddedStore()
+
+	require.NoError(t, store.CreateCollection(ctx, &tsApi.CollectionSchema{Name: "books"}))
+	require.True(t, IsErrDuplicateEntity(store.CreateCollection(ctx, &tsApi.CollectionSchema{Name: "books"})))
+
+	resp, err := store.IndexDocuments(ctx, "books", strings.NewReader(strings.Join([]string{
+		`{"id":"1","title":"The Go Programming Language","price":30,"tags":["go","programming"],"published":1262304000000000000}`,
+		`{"id":"2","title":"Programming Pearls","price":25,"tags":["programming"],"published":946684800000000000}`,
+		`{"id":"3","title":"Gone with the Wind","price":10,"tags":["novel"],"published":-1041379200000000000}`,
+	}, "\n")), IndexDocumentsOptions{Action: Create})
+	require.NoError(t, err)
+	require.Len(t, resp, 3)
+	for _, r := range resp {
+		require.True(t, r.Success)
+	}
+
+	resp, err = store.IndexDocuments(ctx, "books", strings.NewReader(`{"id":"1","title":"duplicate"}`), IndexDocumentsOptions{Action: Create})
+	require.NoError(t, err)
+	require.Equal(t, 409, resp[0].Code)
+
+	resp, err = store.IndexDocuments(ctx, "books", strings.NewReader(`{"id":"3","price":12}`), IndexDocumentsOptions{Action: Update})
+	require.NoError(t, err)
+	require.True(t, resp[0].Success)
+
+	coll, err := store.DescribeCollection(ctx, "books")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), *coll.NumDocuments)
+
+	fields := []*schema.QueryableField{
+		{FieldName: "title", InMemoryAlias: "title", DataType: schema.StringType, SearchIndexed: true},
+		{FieldName: "price", InMemoryAlias: "price", DataType: schema.Int64Type, SearchIndexed: true},
+		{FieldName: "tags", InMemoryAlias: "tags", DataType: schema.ArrayType, SubType: schema.StringType, SearchIndexed: true},
+		{FieldName: "published", InMemoryAlias: "published", DataType: schema.DateTimeType, SearchIndexed: true},
+	}
+	search := func(q string, reqFilter string, ordering *sort.Ordering) []string {
+		wrapped, err := filter.NewFactory(fields, nil).WrappedFilter([]byte(reqFilter))
+		require.NoError(t, err)
+
+		results, err := store.Search(ctx, "books", qsearch.NewBuilder().
+			Query(q).
+			SearchFields([]string{"title"}).
+			Filter(wrapped).
+			SortOrder(ordering).
+			PageSize(10).
+			Build(), 1)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, hit := range *results[0].Hits {
+			ids = append(ids, (*hit.Document)["id"].(string))
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"1", "2", "3"}, search("*", `{}`, nil))
+	require.Equal(t, []string{"1", "2"}, search("program", `{}`, nil))
+	require.Equal(t, []string{"1", "3"}, search("go", `{}`, nil))
+	require.Equal(t, []string{"1"}, search("go", `{"price": {"$gt": 20}}`, nil))
+	require.Equal(t, []string{"3"}, search("*", `{"price": 12}`, nil))
+	require.Equal(t, []string{"1", "2"}, search("*", `{"tags": "programming"}`, nil))
+	require.Equal(t, []string{"2", "3"}, search("*", `{"$or": [{"price": {"$lt": 15}}, {"tags": {"$in": ["programming"]}}], "published": {"$lt": "2005-01-01T00:00:00Z"}}`, nil))
+	require.Equal(t, []string{"3", "2", "1"}, search("*", `{}`, &sort.Ordering{{Name: "price", Ascending: true}}))
+
+	facets, err := qsearch.UnmarshalFacet([]byte(`{"tags": {"size": 10}, "price": [{"to": 20}, {"from": 20}]}`))
+	require.NoError(t, err)
+	results, err := store.Search(ctx, "books", qsearch.NewBuilder().Query("*").Facets(facets).PageSize(0).Build(), 1)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	counts, err := jsoniter.Marshal((*results[0].FacetCounts)[0].Counts)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"count": 2, "value": "programming", "highlighted": "programming"},
+		{"count": 1, "value": "go", "highlighted": "go"},
+		{"count": 1, "value": "novel", "highlighted": "novel"}
+	]`, string(counts))
+	require.Equal(t, 1, *results[1].Found)
+	require.Equal(t, 2, *results[2].Found)
+
+	// the values of the facet are searched within the hits of the query
+	facets, err = qsearch.UnmarshalFacet([]byte(`{"tags": {"size": 10, "query": "prog"}}`))
+	require.NoError(t, err)
+	results, err = store.Search(ctx, "books", qsearch.NewBuilder().Query("*").Facets(facets).PageSize(0).Build(), 1)
+	require.NoError(t, err)
+	counts, err = jsoniter.Marshal((*results[0].FacetCounts)[0].Counts)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"count": 2, "value": "programming", "highlighted": "programming"}]`, string(counts))
+
+	deleted, err := store.DeleteDocuments(ctx, "books", mustWrappedFilter(t, fields, `{"price": {"$gte": 25}}`))
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	require.True(t, IsErrNotFound(store.DeleteDocument(ctx, "books", "1")))
+	require.NoError(t, store.DeleteDocument(ctx, "books", "3"))
+
+	require.NoError(t, store.DropCollection(ctx, "books"))
+	_, err = store.DescribeCollection(ctx, "books")
+	require.True(t, IsErrNotFound(err))
+}
+
+func TestEmbeddedStoreSynonyms(t *testing.T) {
+	ctx := context.Background()
+	store := NewEmbeddedStore()
+	require.NoError(t, store.CreateCollection(ctx, &tsApi.CollectionSchema{Name: "products"}))
+	require.NoError(t, store.CreateDocument(ctx, "products", map[string]any{"id": "1", "name": "blue sneakers"}))
+
+	query := qsearch.NewBuilder().Query("shoes").SearchFields([]string{"name"}).PageSize(10).Build()
+	results, err := store.Search(ctx, "products", query, 1)
+	require.NoError(t, err)
+	require.Equal(t, 0, *results[0].Found)
+
+	require.NoError(t, store.UpsertSynonym(ctx, "products", "shoes", &tsApi.SearchSynonymSchema{Synonyms: []string{"shoes", "sneakers"}}))
+	results, err = store.Search(ctx, "products", query, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, *results[0].Found)
+}
+
+func mustWrappedFilter(t *testing.T, fields []*schema.QueryableField, reqFilter string) *filter.WrappedFilter {
+	wrapped, err := filter.NewFactory(fields, nil).WrappedFilter([]byte(reqFilter))
+	require.NoError(t, err)
+
+	return wrapped
+}
@@ -34,6 +34,11 @@ type storeImplWithMetrics struct {
 }
 
 func NewStoreWithMetrics(config *config.SearchConfig) (Store, error) {
+	if config.Backend == BackendEmbedded {
+		log.Info().Msg("initialized embedded search store")
+		return &storeImplWithMetrics{NewEmbeddedStore()}, nil
+	}
+
 	client := typesense.NewClient(
 		typesense.WithServer(fmt.Sprintf("http://%s", net.JoinHostPort(config.Host, fmt.Sprintf("%d", config.Port)))),
 		typesense.WithAPIKey(config.AuthKey))
@@ -112,6 +117,22 @@ func (m *storeImplWithMetrics) DropCollection(ctx context.Context, table string)
 	return
 }
 
+func (m *storeImplWithMetrics) UpsertSynonym(ctx context.Context, table string, id string, synonym *tsApi.SearchSynonymSchema) (err error) {
+	m.measure(ctx, "UpsertSynonym", func(ctx context.Context) error {
+		err = m.s.UpsertSynonym(ctx, table, id, synonym)
+		return err
+	})
+	return
+}
+
+func (m *storeImplWithMetrics) DeleteSynonym(ctx context.Context, table string, id string) (err error) {
+	m.measure(ctx, "DeleteSynonym", func(ctx context.Context) error {
+		err = m.s.DeleteSynonym(ctx, table, id)
+		return err
+	})
+	return
+}
+
 func (m *storeImplWithMetrics) IndexDocuments(ctx context.Context, table string, documents io.Reader, options IndexDocumentsOptions) (resp []IndexResp, err error) {
 	// TODO: count the bytes written in global status
 	m.measure(ctx, "IndexDocuments", func(ctx context.Context) error {
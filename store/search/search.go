@@ -55,13 +55,23 @@ type Store interface {
 	DeleteDocument(ctx context.Context, table string, key string) error
 	// DeleteDocuments is to delete multiple documents using filter.
 	DeleteDocuments(ctx context.Context, table string, filter *filter.WrappedFilter) (int, error)
-	// Search is to search using Query.
+	// Search is to search using Query. The results of the range facet buckets of the query, in the order of the
+	// buckets, follow the result of the query.
 	Search(ctx context.Context, table string, query *qsearch.Query, pageNo int) ([]tsApi.SearchResult, error)
 	// GetDocuments is to get a single or multiple documents by id.
 	GetDocuments(ctx context.Context, table string, ids []string) (*tsApi.SearchResult, error)
+	// UpsertSynonym is to create or replace a synonym set of the search index.
+	UpsertSynonym(ctx context.Context, table string, id string, synonym *tsApi.SearchSynonymSchema) error
+	// DeleteSynonym is to delete a synonym set of the search index.
+	DeleteSynonym(ctx context.Context, table string, id string) error
 }
 
 func NewStore(config *config.SearchConfig) (Store, error) {
+	if config.Backend == BackendEmbedded {
+		log.Info().Msg("initialized embedded search store")
+		return NewEmbeddedStore(), nil
+	}
+
 	client := typesense.NewClient(
 		typesense.WithServer(fmt.Sprintf("http://%s", net.JoinHostPort(config.Host, fmt.Sprintf("%d", config.Port)))),
 		typesense.WithAPIKey(config.AuthKey))
@@ -104,3 +114,11 @@ func (*NoopStore) GetDocuments(_ context.Context, _ string, _ []string) (*tsApi.
 func (*NoopStore) CreateDocument(_ context.Context, _ string, _ map[string]any) error {
 	return nil
 }
+
+func (*NoopStore) UpsertSynonym(context.Context, string, string, *tsApi.SearchSynonymSchema) error {
+	return nil
+}
+
+func (*NoopStore) DeleteSynonym(context.Context, string, string) error {
+	return nil
+}
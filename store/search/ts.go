@@ -18,11 +18,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog/log"
+	api "github.com/tigrisdata/tigris/api/server/v1"
 	"github.com/tigrisdata/tigris/query/filter"
 	qsearch "github.com/tigrisdata/tigris/query/search"
 	"github.com/tigrisdata/tigris/util"
@@ -124,17 +127,27 @@ func (*storeImpl) getBaseSearchParam(table string, query *qsearch.Query, pageNo
 	if fields := query.ToSearchFields(); len(fields) > 0 {
 		baseParam.QueryBy = &fields
 	}
+	if weights := query.ToSearchFieldWeights(); len(weights) > 0 {
+		baseParam.QueryByWeights = &weights
+	}
 	if facets := query.ToSearchFacets(); len(facets) > 0 {
 		baseParam.FacetBy = &facets
 		if size := query.ToSearchFacetSize(); size > 0 {
 			baseParam.MaxFacetValues = &size
 		}
+		if facetQuery := query.ToSearchFacetQuery(); len(facetQuery) > 0 {
+			baseParam.FacetQuery = &facetQuery
+		}
 	}
 	if sortBy := query.ToSortFields(); len(sortBy) > 0 {
 		baseParam.SortBy = &sortBy
 	}
 	if groupBy := query.ToSearchGroupBy(); len(groupBy) > 0 {
 		baseParam.GroupBy = &groupBy
+		if query.GroupBy.Limit != nil {
+			limit := int(*query.GroupBy.Limit)
+			baseParam.GroupLimit = &limit
+		}
 	}
 	if searchFilter := query.WrappedF.SearchFilter(); len(searchFilter) > 1 {
 		baseParam.FilterBy = &searchFilter
@@ -142,13 +155,86 @@ func (*storeImpl) getBaseSearchParam(table string, query *qsearch.Query, pageNo
 	if vector := query.ToSearchVector(); len(vector) > 0 {
 		baseParam.VectorQuery = &vector
 	}
+	if query.Typo != nil {
+		setTypoToleranceParams(&baseParam, query.Typo)
+	}
+	if query.IsHighlightQuery() {
+		if fields := query.ToSearchHighlightFields(); len(fields) > 0 {
+			baseParam.HighlightFields = &fields
+		}
+		if len(query.Highlight.StartTag) > 0 {
+			baseParam.HighlightStartTag = &query.Highlight.StartTag
+		}
+		if len(query.Highlight.EndTag) > 0 {
+			baseParam.HighlightEndTag = &query.Highlight.EndTag
+		}
+	}
 
 	return baseParam
 }
 
+// getFacetRangeParams returns a search of every range facet bucket of the query, which counts the documents matching the
+// query with the values in the bucket. The field is faceted in the search, if possible, to get the stats of the values
+// in the bucket.
+func getFacetRangeParams(baseParam tsApi.MultiSearchCollectionParameters, query *qsearch.Query) []tsApi.MultiSearchCollectionParameters {
+	var params []tsApi.MultiSearchCollectionParameters
+	for _, f := range query.Facets.RangeFields() {
+		for _, r := range f.Ranges {
+			param := baseParam
+			page, perPage := 1, 0
+			param.Page = &page
+			param.PerPage = &perPage
+			param.FacetBy = nil
+			param.MaxFacetValues = nil
+			param.FacetQuery = nil
+			if f.RangeStats {
+				facetBy, maxFacetValues := f.Name, 1
+				param.FacetBy = &facetBy
+				param.MaxFacetValues = &maxFacetValues
+			}
+			param.SortBy = nil
+			param.GroupBy = nil
+			param.GroupLimit = nil
+			param.HighlightFields = nil
+
+			rangeFilter := r.ToSearchFilter(f.Name)
+			if param.FilterBy != nil {
+				rangeFilter = fmt.Sprintf("(%s)&&%s", *param.FilterBy, rangeFilter)
+			}
+			param.FilterBy = &rangeFilter
+
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
+func setTypoToleranceParams(baseParam *tsApi.MultiSearchCollectionParameters, typo *api.TypoTolerance) {
+	if typo.NumTypos != nil {
+		numTypos := int(*typo.NumTypos)
+		baseParam.NumTypos = &numTypos
+	}
+	if typo.MinLen1Typo != nil {
+		minLen := int(*typo.MinLen1Typo)
+		baseParam.MinLen1typo = &minLen
+	}
+	if typo.MinLen2Typo != nil {
+		minLen := int(*typo.MinLen2Typo)
+		baseParam.MinLen2typo = &minLen
+	}
+	if typo.Prefix != nil {
+		prefix := strconv.FormatBool(*typo.Prefix)
+		baseParam.Prefix = &prefix
+	}
+	baseParam.ExhaustiveSearch = typo.ExhaustiveSearch
+}
+
 func (s *storeImpl) Search(_ context.Context, table string, query *qsearch.Query, pageNo int) ([]tsApi.SearchResult, error) {
 	var params []tsApi.MultiSearchCollectionParameters
-	params = append(params, s.getBaseSearchParam(table, query, pageNo))
+	baseParam := s.getBaseSearchParam(table, query, pageNo)
+	params = append(params, baseParam)
+	params = append(params, getFacetRangeParams(baseParam, query)...)
 
 	res, err := s.client.MultiSearch.PerformWithContentType(&tsApi.MultiSearchParams{
 		MaxCandidates: &maxCandidates,
@@ -230,6 +316,16 @@ func (s *storeImpl) DropCollection(_ context.Context, table string) error {
 	return s.convertToInternalError(err)
 }
 
+func (s *storeImpl) UpsertSynonym(_ context.Context, table string, id string, synonym *tsApi.SearchSynonymSchema) error {
+	_, err := s.client.Collection(table).Synonyms().Upsert(id, synonym)
+	return s.convertToInternalError(err)
+}
+
+func (s *storeImpl) DeleteSynonym(_ context.Context, table string, id string) error {
+	_, err := s.client.Collection(table).Synonym(id).Delete()
+	return s.convertToInternalError(err)
+}
+
 func (s *storeImpl) GetDocuments(_ context.Context, table string, ids []string) (*tsApi.SearchResult, error) {
 	filterBy := "id: ["
 	for i, id := range ids {
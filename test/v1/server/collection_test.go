@@ -126,7 +126,7 @@ func TestCreateCollection(t *testing.T) {
 			"schema": Map{
 				"title": coll,
 				"properties": Map{
-					"arr": Map{"type": "array", "items": Map{"type": "string"}, "index": true},
+					"arr": Map{"type": "array", "items": Map{"type": "string", "format": "byte"}, "index": true},
 				},
 			},
 		}
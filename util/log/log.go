@@ -56,15 +56,29 @@ func Configure(config LogConfig) {
 		log.Error().Err(err).Msg("error parsing log level. defaulting to info level")
 		lvl = zerolog.InfoLevel
 	}
+	// the level is set globally, so it can be changed at runtime by SetLevel
+	zerolog.SetGlobalLevel(lvl)
 	if config.Format == "console" {
 		output := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
 		output.FormatCaller = consoleFormatCaller
-		log.Logger = zerolog.New(output).Level(lvl).With().Timestamp().CallerWithSkipFrameCount(2).Stack().Logger()
+		log.Logger = zerolog.New(output).With().Timestamp().CallerWithSkipFrameCount(2).Stack().Logger()
 	} else {
-		log.Logger = zerolog.New(os.Stdout).Level(lvl).With().Timestamp().CallerWithSkipFrameCount(2).Stack().Logger()
+		log.Logger = zerolog.New(os.Stdout).With().Timestamp().CallerWithSkipFrameCount(2).Stack().Logger()
 	}
 }
 
+// SetLevel changes the level of the default logger at runtime.
+func SetLevel(level string) error {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(lvl)
+
+	return nil
+}
+
 // E is a helper function to shortcut condition checking and logging
 // in the case of error
 // Used like this:
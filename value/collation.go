@@ -15,9 +15,16 @@
 package value
 
 import (
+	"strings"
+	"unicode"
+
 	api "github.com/tigrisdata/tigris/api/server/v1"
+	"golang.org/x/text/cases"
 	"golang.org/x/text/collate"
 	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
@@ -27,6 +34,11 @@ const (
 type Collation struct {
 	collator     collate.Collator
 	apiCollation *api.Collation
+	caseType     api.CollationType
+	// locale is only set for the locale-aware collations.
+	locale            *language.Tag
+	accentInsensitive bool
+	numericOrdering   bool
 }
 
 var EmptyCollation = NewCollation()
@@ -43,37 +55,126 @@ func NewSortKeyCollation() *Collation {
 }
 
 func NewCollationFrom(apiCollation *api.Collation) *Collation {
-	var options []collate.Option
-
 	if apiCollation == nil {
 		apiCollation = &api.Collation{}
 	}
 
-	if apiCollation.IsCaseInsensitive() {
-		options = append(options, collate.IgnoreCase)
+	return NewCollationFromOptions(apiCollation, apiCollation.Options())
+}
+
+// NewCollationFromOptions creates the collation with the options, which are parsed from the api collation or set by
+// the attributes of the collation object of a filter.
+func NewCollationFromOptions(apiCollation *api.Collation, options api.CollationOptions) *Collation {
+	var collateOptions []collate.Option
+	if options.Case == api.CaseInsensitive {
+		collateOptions = append(collateOptions, collate.IgnoreCase)
+	}
+	if options.AccentInsensitive {
+		collateOptions = append(collateOptions, collate.IgnoreDiacritics)
+	}
+	if options.NumericOrdering {
+		collateOptions = append(collateOptions, collate.Numeric)
+	}
+
+	tag := language.English
+	var locale *language.Tag
+	if len(options.Locale) > 0 {
+		// the collation is validated as part of the request validation, an unknown locale falls back to the default
+		if parsed, err := api.ParseLocale(options.Locale); err == nil {
+			tag, locale = parsed, &parsed
+		}
 	}
 
 	return &Collation{
-		collator:     *collate.New(language.English, options...),
-		apiCollation: apiCollation,
+		collator:          *collate.New(tag, collateOptions...),
+		apiCollation:      apiCollation,
+		caseType:          options.Case,
+		locale:            locale,
+		accentInsensitive: options.AccentInsensitive,
+		numericOrdering:   options.NumericOrdering,
 	}
 }
 
 // CompareString returns an integer comparing the two strings. The result will be 0 if a==b, -1 if a < b, and +1 if a > b.
 func (x *Collation) CompareString(a string, b string) int {
+	if x.IsAccentInsensitive() {
+		// the collator ignores the diacritics only at the secondary level, the combining marks may still differ at the
+		// tertiary level, so these are removed before comparing
+		a, b = removeAccents(a), removeAccents(b)
+	}
+
 	return x.collator.CompareString(a, b)
 }
 
 func (x *Collation) IsCaseSensitive() bool {
-	return x.apiCollation.IsCaseSensitive()
+	return x.caseType == api.CaseSensitive
 }
 
 func (x *Collation) IsCaseInsensitive() bool {
-	return x.apiCollation.IsCaseInsensitive()
+	return x.caseType == api.CaseInsensitive
+}
+
+func (x *Collation) IsAccentInsensitive() bool {
+	return x.accentInsensitive
+}
+
+func (x *Collation) IsNumericOrdering() bool {
+	return x.numericOrdering
 }
 
 func (x *Collation) IsCollationSortKey() bool {
-	return x.apiCollation.IsCollationSortKey()
+	return x.caseType == api.CollationSortKey
+}
+
+// HasLocale returns true for the locale-aware collations. The strings are then compared using the rules of the locale
+// instead of the default ones.
+func (x *Collation) HasLocale() bool {
+	return x.locale != nil
+}
+
+// HasOrderingOptions returns true if the collation changes the default ordering of the strings by using a locale, or
+// by ignoring the accents or by comparing the digits numerically.
+func (x *Collation) HasOrderingOptions() bool {
+	return x.HasLocale() || x.IsAccentInsensitive() || x.IsNumericOrdering()
+}
+
+// ToLower lowercases the string using the casing rules of the locale, if any. This is used by the case-insensitive
+// matchers, so that for example the dotted and dotless "i" are handled as per the Turkish rules in "tr".
+func (x *Collation) ToLower(s string) string {
+	if x.locale == nil {
+		return strings.ToLower(s)
+	}
+
+	return cases.Lower(*x.locale).String(s)
+}
+
+// Fold returns the string as seen by the substring matchers, i.e. lowercased for the case-insensitive collation and
+// without the diacritics for the accent-insensitive collation.
+func (x *Collation) Fold(s string) string {
+	if x.IsCaseInsensitive() {
+		s = x.ToLower(s)
+	}
+
+	if x.IsAccentInsensitive() {
+		s = removeAccents(s)
+	}
+
+	return s
+}
+
+// FoldCase returns the Unicode case folding of the string, the strings which only differ in case have the same
+// folding. The folding of a prefix of a string is a prefix of the folding of the string.
+func FoldCase(s string) string {
+	return cases.Fold().String(s)
+}
+
+func removeAccents(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if removed, _, err := transform.String(t, s); err == nil {
+		return removed
+	}
+
+	return s
 }
 
 func (x *Collation) IsValid() error {
@@ -86,6 +187,10 @@ func (x *Collation) GenerateSortKey(input string) []byte {
 		input = input[:INDEX_MAX_STRING_LEN]
 	}
 
+	if x.IsAccentInsensitive() {
+		input = removeAccents(input)
+	}
+
 	var buf collate.Buffer
 	collated := x.collator.KeyFromString(&buf, input)
 	return collated
@@ -68,7 +68,8 @@ func AnyCompare(element any, v Value) int {
 		}
 	case *DateTimeValue:
 		if e, ok := element.(string); ok {
-			return strings.Compare(e, converted.Value)
+			cmp, _ := NewDateTimeValue(e).CompareTo(converted)
+			return cmp
 		}
 	case *BoolValue:
 		if e, ok := element.(bool); ok {
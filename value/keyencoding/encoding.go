@@ -0,0 +1,181 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyencoding converts the values to the parts of the secondary index keys. The keys are compared byte wise by
+// the store, so an encoding must guarantee that the order of the encoded keys is the same as the order of the values.
+// The encoding is versioned and the version is persisted with every index. An index that is built with an older
+// version keeps using it until it is rebuilt, see NeedsMigration.
+package keyencoding
+
+import (
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// Version is the version of the key encoding.
+type Version uint8
+
+const (
+	// Legacy is the encoding of the indexes built before the encoding was versioned. The values are added to the key
+	// as is, which means numbers of different types and a few other values are not ordered correctly.
+	Legacy Version = 1
+	// OrderPreserving encodes every value to bytes whose order is the same as the order of the values, including the
+	// numbers of different types.
+	OrderPreserving Version = 2
+
+	// Current is the encoding used for the indexes that are created or rebuilt.
+	Current = OrderPreserving
+)
+
+// Encoder returns the parts of the secondary index key for a value. The parts always have the same length, the type
+// order followed by the encoded value, so that the position of the primary key in the index key doesn't depend on
+// the encoding.
+type Encoder interface {
+	Version() Version
+	IndexParts(dataType schema.FieldType, val value.Value) []any
+}
+
+var encoders = map[Version]Encoder{
+	Legacy:          &legacyEncoder{},
+	OrderPreserving: &orderPreservingEncoder{},
+}
+
+// Get returns the encoder of the version. An unknown version falls back to the current encoding, such an index
+// always needs a migration.
+func Get(v Version) Encoder {
+	if e, ok := encoders[v]; ok {
+		return e
+	}
+
+	return encoders[Current]
+}
+
+// IndexVersion returns the version with which the index is encoded.
+func IndexVersion(index *schema.Index) Version {
+	if index == nil || index.KeyEncoding == 0 {
+		return Legacy
+	}
+
+	return Version(index.KeyEncoding)
+}
+
+// ForIndex returns the encoder of the index. A nil index is a field that is not tracked in the index metadata, and it
+// is encoded with the legacy encoding.
+func ForIndex(index *schema.Index) Encoder {
+	return Get(IndexVersion(index))
+}
+
+// NeedsMigration returns true if the index is encoded with a version other than the current one. Such an index needs
+// to be cleared and built again to use the current encoding.
+func NeedsMigration(index *schema.Index) bool {
+	return IndexVersion(index) != Current
+}
+
+// ComparesDateTimes returns true if the date times of the index are ordered, and equal, as the instants they are. The
+// legacy encoding has them as the strings stored in the documents, which only compare like the instants if they have
+// the same offset, so such an index can't serve the predicates on date times until it is migrated.
+func ComparesDateTimes(index *schema.Index) bool {
+	return IndexVersion(index) != Legacy
+}
+
+// SetCurrent marks the index as encoded with the current version.
+func SetCurrent(index *schema.Index) {
+	index.KeyEncoding = uint8(Current)
+}
+
+type legacyEncoder struct{}
+
+func (*legacyEncoder) Version() Version {
+	return Legacy
+}
+
+// IndexParts of the legacy encoding add the date times as the strings that are stored in the documents, i.e. in their
+// original offset. The existing indexes are read with the keys they were written with, and they are ordered
+// chronologically once they are migrated to the order preserving encoding.
+func (*legacyEncoder) IndexParts(dataType schema.FieldType, val value.Value) []any {
+	return []any{value.ToSecondaryOrder(dataType, val), val.AsInterface()}
+}
+
+// Descending converts the parts of the index key of a value to the parts whose order is reversed. It is used for the
+// fields of the composite indexes sorted in the descending order. The type order is negated and the encoded value is
+// inverted, the value is escaped and terminated first so that a value is still ordered correctly with the values it
+// is a prefix of.
+func Descending(parts []any) []any {
+	desc := make([]any, len(parts))
+	for i, p := range parts {
+		switch v := p.(type) {
+		case int:
+			desc[i] = -v
+		case []byte:
+			desc[i] = invertBytes(v)
+		default:
+			desc[i] = p
+		}
+	}
+
+	return desc
+}
+
+func invertBytes(b []byte) []byte {
+	buf := make([]byte, 0, len(b)+2)
+	for _, c := range b {
+		buf = append(buf, c)
+		if c == 0 {
+			buf = append(buf, 0xFF)
+		}
+	}
+	buf = append(buf, 0x00, 0x01)
+
+	for i := range buf {
+		buf[i] = ^buf[i]
+	}
+
+	return buf
+}
+
+// Ascending converts the parts returned by Descending back to the parts of the value.
+func Ascending(parts []any) []any {
+	asc := make([]any, len(parts))
+	for i, p := range parts {
+		switch v := p.(type) {
+		case int:
+			asc[i] = -v
+		case []byte:
+			asc[i] = restoreBytes(v)
+		default:
+			asc[i] = p
+		}
+	}
+
+	return asc
+}
+
+func restoreBytes(b []byte) []byte {
+	buf := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		c := ^b[i]
+		if c == 0 && i+1 < len(b) {
+			next := ^b[i+1]
+			if next == 0x01 {
+				// the terminator
+				break
+			}
+			// the escaped zero byte
+			i++
+		}
+		buf = append(buf, c)
+	}
+
+	return buf
+}
@@ -0,0 +1,219 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyencoding
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/keys"
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+func serialize(e Encoder, val value.Value) []byte {
+	return keys.NewKey([]byte("t"), e.IndexParts(val.DataType(), val)...).SerializeToBytes()
+}
+
+// requireSameOrder checks that the values are sorted in the same order by the comparator and the encoded keys.
+func requireSameOrder(t *testing.T, e Encoder, values []value.Value) {
+	t.Helper()
+
+	for i := range values {
+		for j := range values {
+			exp, err := values[i].CompareTo(values[j])
+			require.NoError(t, err)
+
+			actual := bytes.Compare(serialize(e, values[i]), serialize(e, values[j]))
+			require.Equal(t, exp, actual, "%v %v", values[i], values[j])
+		}
+	}
+}
+
+func mustDouble(t *testing.T, raw string) value.Value {
+	v, err := value.NewDoubleValue(raw)
+	require.NoError(t, err)
+	return v
+}
+
+func mustBigInt(t *testing.T, raw string) value.Value {
+	v, err := value.NewBigIntValue(raw)
+	require.NoError(t, err)
+	return v
+}
+
+func mustDecimal(t *testing.T, raw string) value.Value {
+	v, err := value.NewDecimalValue(raw)
+	require.NoError(t, err)
+	return v
+}
+
+// Empty strings and bytes are never indexed, they are treated as null by the comparators.
+
+func TestVersions(t *testing.T) {
+	require.Equal(t, Legacy, IndexVersion(nil))
+	require.Equal(t, Legacy, IndexVersion(&schema.Index{}))
+	require.True(t, NeedsMigration(&schema.Index{}))
+
+	idx := &schema.Index{}
+	SetCurrent(idx)
+	require.Equal(t, Current, IndexVersion(idx))
+	require.False(t, NeedsMigration(idx))
+	require.Equal(t, Current, ForIndex(idx).Version())
+
+	// unknown version is encoded with the current encoding but still needs to be rebuilt
+	idx.KeyEncoding = 100
+	require.Equal(t, Current, ForIndex(idx).Version())
+	require.True(t, NeedsMigration(idx))
+
+	// the legacy encoding is kept as is for the existing indexes
+	require.Equal(t, []any{10, int64(5)}, Get(Legacy).IndexParts(schema.Int64Type, value.NewIntValue(5)))
+	require.Equal(t, []any{value.SecondaryNullOrder(), nil}, Get(Legacy).IndexParts(schema.NullType, value.NewNullValue()))
+	offset := value.NewDateTimeValue("2020-10-12T19:42:34+02:00")
+	require.Equal(t, []any{30, "2020-10-12T19:42:34+02:00"}, Get(Legacy).IndexParts(schema.DateTimeType, offset))
+	require.Equal(t, []any{30, []byte("2020-10-12T17:42:34.000000000Z")}, Get(OrderPreserving).IndexParts(schema.DateTimeType, offset))
+}
+
+func TestOrderPreserving(t *testing.T) {
+	e := Get(OrderPreserving)
+
+	t.Run("ints", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			value.NewIntValue(math.MinInt64), value.NewIntValue(-1000), value.NewIntValue(-10), value.NewIntValue(-9),
+			value.NewIntValue(-1), value.NewIntValue(0), value.NewIntValue(1), value.NewIntValue(9), value.NewIntValue(10),
+			value.NewIntValue(100), value.NewIntValue(101), value.NewIntValue(math.MaxInt64),
+		})
+	})
+	t.Run("doubles", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			mustDouble(t, "-1e300"), mustDouble(t, "-10.5"), mustDouble(t, "-10.25"), mustDouble(t, "-1"),
+			mustDouble(t, "-0.001"), mustDouble(t, "0"), mustDouble(t, "-0"), mustDouble(t, "0.001"), mustDouble(t, "0.5"),
+			mustDouble(t, "1"), mustDouble(t, "1.5"), mustDouble(t, "10"), mustDouble(t, "1e300"),
+		})
+	})
+	t.Run("bigints", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			mustBigInt(t, "-123456789012345678901234567890"), mustBigInt(t, "-9223372036854775809"),
+			mustBigInt(t, "0"), mustBigInt(t, "9223372036854775808"), mustBigInt(t, "123456789012345678901234567890"),
+		})
+	})
+	t.Run("decimals", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			mustDecimal(t, "-12345678901234567890.123456789"), mustDecimal(t, "-0.1"), mustDecimal(t, "-0.01"),
+			mustDecimal(t, "0"), mustDecimal(t, "0.00"), mustDecimal(t, "0.1"), mustDecimal(t, "0.10000000000000000001"),
+			mustDecimal(t, "12345678901234567890.123456789"),
+		})
+	})
+	t.Run("strings", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			value.NewStringValue("a", nil), value.NewStringValue("ab", nil), value.NewStringValue("b", nil),
+		})
+
+		csk := value.NewCollationFrom(&api.Collation{Case: "csk"})
+		requireSameOrder(t, e, []value.Value{
+			value.NewStringValue("a", csk), value.NewStringValue("B", csk), value.NewStringValue("c", csk),
+		})
+	})
+	t.Run("datetimes", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			value.NewDateTimeValue("2023-01-01T00:00:00+05:00"), value.NewDateTimeValue("2023-01-01T00:00:00Z"),
+			value.NewDateTimeValue("2022-12-31T20:00:00-05:00"), value.NewDateTimeValue("2023-01-01T01:00:00.5Z"),
+			value.NewDateTimeValue("2023-01-01T01:00:00.25+00:00"),
+		})
+	})
+	t.Run("bytes_and_bools", func(t *testing.T) {
+		requireSameOrder(t, e, []value.Value{
+			value.NewBytesValue([]byte{0}), value.NewBytesValue([]byte{0, 0}),
+			value.NewBytesValue([]byte{1}), value.NewBytesValue([]byte{0xFF}),
+		})
+		requireSameOrder(t, e, []value.Value{value.NewBoolValue(false), value.NewBoolValue(true)})
+	})
+	t.Run("mixed_numbers", func(t *testing.T) {
+		sorted := []value.Value{
+			mustBigInt(t, "-100000000000000000000"), value.NewIntValue(-2), mustDouble(t, "-1.5"), mustDecimal(t, "-1.25"),
+			value.NewIntValue(0), mustDouble(t, "0.5"), value.NewIntValue(1), mustDouble(t, "1.5"), mustDecimal(t, "1.75"),
+			mustBigInt(t, "100000000000000000000"),
+		}
+		for i := 1; i < len(sorted); i++ {
+			require.Negative(t, bytes.Compare(serialize(e, sorted[i-1]), serialize(e, sorted[i])), "%v %v", sorted[i-1], sorted[i])
+		}
+
+		// equal numbers of different types are encoded to the same bytes
+		require.Equal(t, Encode(value.NewIntValue(42)), Encode(mustDouble(t, "42")))
+		require.Equal(t, Encode(value.NewIntValue(42)), Encode(mustBigInt(t, "42")))
+		require.Equal(t, Encode(mustDouble(t, "0.5")), Encode(mustDecimal(t, "0.50")))
+	})
+	t.Run("types", func(t *testing.T) {
+		// values of different types are ordered by the type order, null is always first
+		sorted := [][]byte{
+			serialize(e, value.NewNullValue()),
+			serialize(e, value.NewIntValue(math.MaxInt64)),
+			serialize(e, value.NewStringValue("a", nil)),
+			serialize(e, value.NewBytesValue([]byte{0xFF})),
+			serialize(e, value.NewBoolValue(true)),
+			serialize(e, value.NewDateTimeValue("2023-01-01T00:00:00Z")),
+			serialize(e, value.MaxOrderValue()),
+		}
+		require.True(t, sort.SliceIsSorted(sorted, func(i, j int) bool {
+			return bytes.Compare(sorted[i], sorted[j]) < 0
+		}))
+	})
+}
+
+func TestEncodeNumberLayout(t *testing.T) {
+	require.Equal(t, []byte{numZero}, EncodeBigInt(big.NewInt(0)))
+	require.Equal(t, []byte{numPosInf}, EncodeFloat64(math.Inf(1)))
+	require.Equal(t, []byte{numNegInf}, EncodeFloat64(math.Inf(-1)))
+	// 120 = 0.12 * 10^3
+	require.Equal(t, []byte{numPos, 0x80, 0, 0, 3, 2, 3, 0}, EncodeBigInt(big.NewInt(120)))
+	require.Equal(t, []byte{numNeg, 0x7F, 0xFF, 0xFF, 0xFC, 0xFD, 0xFC, 0xFF}, EncodeBigInt(big.NewInt(-120)))
+
+	for _, n := range []string{"0", "120", "-120", "0.5", "-12345678901234567890.125"} {
+		d, err := value.NewDecimalValue(n)
+		require.NoError(t, err)
+
+		decoded, ok := DecodeNumber(Encode(d))
+		require.True(t, ok)
+		require.Equal(t, n, decoded)
+	}
+}
+
+func TestDescending(t *testing.T) {
+	e := Get(OrderPreserving)
+	desc := func(val value.Value) []byte {
+		return keys.NewKey([]byte("t"), Descending(e.IndexParts(val.DataType(), val))...).SerializeToBytes()
+	}
+
+	values := []value.Value{
+		value.NewNullValue(), value.NewIntValue(-10), value.NewIntValue(0), mustDouble(t, "0.5"), value.NewIntValue(7),
+		value.NewStringValue("a", nil), value.NewStringValue("a\x00", nil), value.NewStringValue("ab", nil),
+		value.NewStringValue("b", nil), value.NewBoolValue(false), value.NewBoolValue(true),
+	}
+
+	for i := range values {
+		for j := range values {
+			asc := bytes.Compare(serialize(e, values[i]), serialize(e, values[j]))
+			require.Equal(t, -asc, bytes.Compare(desc(values[i]), desc(values[j])), "%v %v", values[i], values[j])
+		}
+
+		parts := e.IndexParts(values[i].DataType(), values[i])
+		require.Equal(t, parts, Ascending(Descending(parts)), "%v", values[i])
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyencoding
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	api "github.com/tigrisdata/tigris/api/server/v1"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// The fuzz tests check that the order of the encoded keys is always the same as the order returned by the value
+// comparators. Run them with "go test -fuzz=FuzzInt ./value/keyencoding".
+
+func requireFuzzOrder(t *testing.T, a value.Value, b value.Value) {
+	t.Helper()
+
+	e := Get(Current)
+	exp, err := a.CompareTo(b)
+	require.NoError(t, err)
+	require.Equal(t, exp, bytes.Compare(serialize(e, a), serialize(e, b)), "%v %v", a, b)
+}
+
+func FuzzInt(f *testing.F) {
+	f.Add(int64(0), int64(1))
+	f.Add(int64(-10), int64(9))
+	f.Add(int64(math.MinInt64), int64(math.MaxInt64))
+	f.Fuzz(func(t *testing.T, a int64, b int64) {
+		requireFuzzOrder(t, value.NewIntValue(a), value.NewIntValue(b))
+	})
+}
+
+func FuzzDouble(f *testing.F) {
+	f.Add(0.0, -0.0)
+	f.Add(0.1, 0.01)
+	f.Add(-1e300, 1e-300)
+	f.Add(1152921504606846976.0, 1152921504606847000.0)
+	f.Fuzz(func(t *testing.T, a float64, b float64) {
+		if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+			t.Skip()
+		}
+		requireFuzzOrder(t, value.NewDoubleUsingFloat(a), value.NewDoubleUsingFloat(b))
+	})
+}
+
+// FuzzIntAndDouble checks that the numbers of different types are ordered by their exact values.
+func FuzzIntAndDouble(f *testing.F) {
+	f.Add(int64(1), 1.0)
+	f.Add(int64(1152921504606846980), 1152921504606846976.0)
+	f.Add(int64(-3), -2.5)
+	f.Fuzz(func(t *testing.T, i int64, d float64) {
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			t.Skip()
+		}
+
+		exp := new(big.Float).SetInt64(i).Cmp(big.NewFloat(d))
+		require.Equal(t, exp, bytes.Compare(Encode(value.NewIntValue(i)), Encode(value.NewDoubleUsingFloat(d))), "%d %v", i, d)
+	})
+}
+
+func FuzzBigInt(f *testing.F) {
+	f.Add("0", "-0")
+	f.Add("123456789012345678901234567890", "-123456789012345678901234567890")
+	f.Add("99", "100")
+	f.Fuzz(func(t *testing.T, a string, b string) {
+		va, errA := value.NewBigIntValue(a)
+		vb, errB := value.NewBigIntValue(b)
+		if errA != nil || errB != nil {
+			t.Skip()
+		}
+		requireFuzzOrder(t, va, vb)
+	})
+}
+
+func FuzzDecimal(f *testing.F) {
+	f.Add("0.1", "0.10")
+	f.Add("-12345678901234567890.5", "12345678901234567890.5")
+	f.Add("1e-400", "-1e400")
+	f.Fuzz(func(t *testing.T, a string, b string) {
+		va, errA := value.NewDecimalValue(a)
+		vb, errB := value.NewDecimalValue(b)
+		if errA != nil || errB != nil || va.Decimal.IsInf() || vb.Decimal.IsInf() {
+			t.Skip()
+		}
+		requireFuzzOrder(t, va, vb)
+	})
+}
+
+func FuzzString(f *testing.F) {
+	f.Add("B", "a")
+	f.Add("a\x00", "a")
+	f.Add("abc", "abd")
+	// strings are indexed using the collation sort key
+	csk := value.NewCollationFrom(&api.Collation{Case: "csk"})
+	f.Fuzz(func(t *testing.T, a string, b string) {
+		if a == "" || b == "" || !utf8.ValidString(a) || !utf8.ValidString(b) {
+			t.Skip()
+		}
+		requireFuzzOrder(t, value.NewStringValue(a, csk), value.NewStringValue(b, csk))
+	})
+}
+
+func FuzzBytes(f *testing.F) {
+	f.Add([]byte{1}, []byte{0})
+	f.Add([]byte{0, 0xFF}, []byte{0, 0})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		if len(a) == 0 || len(b) == 0 {
+			t.Skip()
+		}
+		requireFuzzOrder(t, value.NewBytesValue(a), value.NewBytesValue(b))
+	})
+}
+
+func FuzzDateTime(f *testing.F) {
+	f.Add(int64(0), int16(0), int64(0), int16(60))
+	f.Add(int64(1673000000123456789), int16(-300), int64(1673000000123456789), int16(330))
+	f.Fuzz(func(t *testing.T, a int64, offsetA int16, b int64, offsetB int16) {
+		format := func(nsec int64, offset int16) value.Value {
+			// keep the offset within a day and the year within what RFC3339 allows
+			zone := time.FixedZone("", int(offset%(24*60))*60)
+			return value.NewDateTimeValue(time.Unix(0, nsec).In(zone).Format(time.RFC3339Nano))
+		}
+		requireFuzzOrder(t, format(a, offsetA), format(b, offsetB))
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright 2022-2023 Tigris Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyencoding
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tigrisdata/tigris/schema"
+	"github.com/tigrisdata/tigris/value"
+)
+
+// Classes of the numbers, the class is the first byte of an encoded number.
+const (
+	numNegInf byte = 0x01
+	numNeg    byte = 0x02
+	numZero   byte = 0x03
+	numPos    byte = 0x04
+	numPosInf byte = 0x05
+)
+
+type orderPreservingEncoder struct{}
+
+func (*orderPreservingEncoder) Version() Version {
+	return OrderPreserving
+}
+
+func (*orderPreservingEncoder) IndexParts(dataType schema.FieldType, val value.Value) []any {
+	order := value.ToSecondaryOrder(dataType, val)
+	switch val.(type) {
+	case *value.NullValue:
+		return []any{order, nil}
+	case *value.MaxValue:
+		return []any{order, val.AsInterface()}
+	}
+
+	return []any{order, Encode(val)}
+}
+
+// Encode returns the bytes of the value whose byte wise order is the same as the order of the values of the same
+// type. All the numeric types share the same encoding, so an integer and a double are also ordered correctly.
+func Encode(val value.Value) []byte {
+	switch v := val.(type) {
+	case *value.IntValue:
+		return EncodeBigInt(big.NewInt(int64(*v)))
+	case *value.DoubleValue:
+		return EncodeFloat64(v.Double)
+	case *value.BigIntValue:
+		return EncodeBigInt(v.Int)
+	case *value.DecimalValue:
+		return EncodeBigFloat(v.Decimal)
+	case *value.StringValue:
+		// the collation sort key is already ordered byte wise
+		if sortKey, ok := v.AsInterface().([]byte); ok {
+			return sortKey
+		}
+		return []byte(v.Value)
+	case *value.DateTimeValue:
+		return []byte(v.UTC())
+	case *value.BytesValue:
+		return *v
+	case *value.BoolValue:
+		if *v {
+			return []byte{1}
+		}
+		return []byte{0}
+	default:
+		// arrays and objects are never part of the index key
+		return []byte(val.String())
+	}
+}
+
+// EncodeFloat64 encodes a double. An integral double is encoded with all its digits so that it is ordered correctly
+// with the integers, otherwise the shortest representation that identifies the double is used.
+func EncodeFloat64(f float64) []byte {
+	switch {
+	case math.IsInf(f, 1):
+		return []byte{numPosInf}
+	case math.IsInf(f, -1):
+		return []byte{numNegInf}
+	case f == 0 || math.IsNaN(f):
+		return []byte{numZero}
+	case f == math.Trunc(f):
+		i, _ := big.NewFloat(f).Int(nil)
+		return EncodeBigInt(i)
+	}
+
+	return encodeScientific(strconv.FormatFloat(f, 'e', -1, 64))
+}
+
+// EncodeBigInt encodes an integer of any size.
+func EncodeBigInt(i *big.Int) []byte {
+	if i.Sign() == 0 {
+		return []byte{numZero}
+	}
+
+	digits := i.Text(10)
+	neg := digits[0] == '-'
+	if neg {
+		digits = digits[1:]
+	}
+
+	return encodeNumber(neg, digits, len(digits))
+}
+
+// EncodeBigFloat encodes a decimal using the shortest representation that identifies the value at its precision.
+func EncodeBigFloat(f *big.Float) []byte {
+	switch {
+	case f.IsInf() && f.Sign() > 0:
+		return []byte{numPosInf}
+	case f.IsInf():
+		return []byte{numNegInf}
+	case f.Sign() == 0:
+		return []byte{numZero}
+	}
+
+	return encodeScientific(f.Text('e', -1))
+}
+
+// encodeScientific encodes a number in the form of "-d.ddde±dd".
+func encodeScientific(s string) []byte {
+	neg := s[0] == '-'
+	if neg {
+		s = s[1:]
+	}
+
+	idx := strings.IndexByte(s, 'e')
+	exp, _ := strconv.Atoi(s[idx+1:])
+	digits := strings.Replace(s[:idx], ".", "", 1)
+
+	// the mantissa has a single digit before the decimal point
+	return encodeNumber(neg, digits, exp+1)
+}
+
+// encodeNumber encodes the number 0.digits * 10^exp. The class is followed by the exponent and the digits, a longer
+// number with the same prefix is greater, so the digits are terminated by a byte that is smaller than all the digits.
+// For a negative number the bytes after the class are inverted, so a larger magnitude is smaller.
+func encodeNumber(neg bool, digits string, exp int) []byte {
+	digits = strings.TrimRight(digits, "0")
+
+	buf := make([]byte, 0, 1+4+len(digits)+1)
+	if neg {
+		buf = append(buf, numNeg)
+	} else {
+		buf = append(buf, numPos)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(int32(exp))^(1<<31))
+	for i := 0; i < len(digits); i++ {
+		buf = append(buf, digits[i]-'0'+1)
+	}
+	buf = append(buf, 0)
+
+	if neg {
+		for i := 1; i < len(buf); i++ {
+			buf[i] = ^buf[i]
+		}
+	}
+
+	return buf
+}
+
+// DecodeNumber returns the decimal representation of an encoded number, it is used to describe the index keys.
+func DecodeNumber(b []byte) (string, bool) {
+	if len(b) == 0 {
+		return "", false
+	}
+
+	switch b[0] {
+	case numZero:
+		return "0", true
+	case numPosInf:
+		return "+Inf", true
+	case numNegInf:
+		return "-Inf", true
+	case numNeg, numPos:
+	default:
+		return "", false
+	}
+
+	if len(b) < 1+4+1 {
+		return "", false
+	}
+
+	buf := append([]byte{}, b[1:]...)
+	neg := b[0] == numNeg
+	if neg {
+		for i := range buf {
+			buf[i] = ^buf[i]
+		}
+	}
+
+	exp := int32(binary.BigEndian.Uint32(buf) ^ (1 << 31))
+	var digits strings.Builder
+	for _, d := range buf[4 : len(buf)-1] {
+		digits.WriteByte(d - 1 + '0')
+	}
+
+	f, _, err := big.ParseFloat("0."+digits.String()+"e"+strconv.Itoa(int(exp)), 10, 1000, big.ToNearestEven)
+	if err != nil {
+		return "", false
+	}
+	if neg {
+		f.Neg(f)
+	}
+
+	return f.Text('f', -1), true
+}
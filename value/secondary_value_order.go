@@ -53,12 +53,15 @@ func ToSecondaryOrder(dataType schema.FieldType, val Value) int {
 	switch dataType {
 	case schema.NullType:
 		return 5
-	case schema.DoubleType, schema.Int32Type, schema.Int64Type:
+	case schema.DoubleType, schema.Int32Type, schema.Int64Type, schema.BigIntType, schema.DecimalType:
 		return 10
 	case schema.StringType, schema.UUIDType:
 		return 15
 	case schema.ObjectType:
 		return 20
+	case schema.ByteType:
+		// byte values are ordered lexicographically on the decoded bytes
+		return 22
 	case schema.BoolType:
 		if val != nil && val.String() == "true" {
 			return 26
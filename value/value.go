@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/tigrisdata/tigris/errors"
@@ -30,6 +31,9 @@ const (
 	// SmallestNonZeroNormalFloat32 is the smallest positive non-zero floating number. The go package version
 	// has the denormalized form which is higher than this.
 	SmallestNonZeroNormalFloat32 = 0x1p-126
+
+	// DateTimeUTCFormat is the fixed width format used to encode a date time in UTC.
+	DateTimeUTCFormat = "2006-01-02T15:04:05.000000000Z"
 )
 
 type Comparable interface {
@@ -88,6 +92,10 @@ func NewValue(fieldType schema.FieldType, value []byte) (Value, error) {
 		}
 
 		return NewIntValue(val), nil
+	case schema.BigIntType:
+		return NewBigIntValue(string(value))
+	case schema.DecimalType:
+		return NewDecimalValue(string(value))
 	case schema.StringType, schema.UUIDType:
 		return NewStringValue(string(value), nil), nil
 	case schema.DateTimeType:
@@ -273,6 +281,98 @@ func (d *DoubleValue) String() string {
 	return d.asString
 }
 
+type BigIntValue struct {
+	Int *big.Int
+}
+
+func NewBigIntValue(raw string) (*BigIntValue, error) {
+	i, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return nil, errors.InvalidArgument("unsupported value type: invalid bigint '%s'", raw)
+	}
+
+	return &BigIntValue{
+		Int: i,
+	}, nil
+}
+
+func (b *BigIntValue) CompareTo(v Value) (int, error) {
+	if isNullValue(v) {
+		return 1, nil
+	}
+
+	converted, ok := v.(*BigIntValue)
+	if !ok {
+		return -2, fmt.Errorf("wrong type compared ")
+	}
+
+	return b.Int.Cmp(converted.Int), nil
+}
+
+func (b *BigIntValue) AsInterface() any {
+	return b.Int
+}
+
+func (*BigIntValue) DataType() schema.FieldType {
+	return schema.BigIntType
+}
+
+func (b *BigIntValue) String() string {
+	if b == nil {
+		return ""
+	}
+
+	return b.Int.String()
+}
+
+// DecimalValue is an arbitrary precision decimal. Similar to DoubleValue, it is parsed with 1000 bits of precision
+// but unlike DoubleValue it is not converted to a float64.
+type DecimalValue struct {
+	Decimal  *big.Float
+	asString string
+}
+
+func NewDecimalValue(raw string) (*DecimalValue, error) {
+	d, _, err := big.ParseFloat(raw, 10, 1000, big.ToNearestEven)
+	if err != nil {
+		return nil, errors.InvalidArgument(fmt.Errorf("unsupported value type: %w ", err).Error())
+	}
+
+	return &DecimalValue{
+		Decimal:  d,
+		asString: raw,
+	}, nil
+}
+
+func (d *DecimalValue) CompareTo(v Value) (int, error) {
+	if isNullValue(v) {
+		return 1, nil
+	}
+
+	converted, ok := v.(*DecimalValue)
+	if !ok {
+		return -2, fmt.Errorf("wrong type compared ")
+	}
+
+	return d.Decimal.Cmp(converted.Decimal), nil
+}
+
+func (d *DecimalValue) AsInterface() any {
+	return d.asString
+}
+
+func (*DecimalValue) DataType() schema.FieldType {
+	return schema.DecimalType
+}
+
+func (d *DecimalValue) String() string {
+	if d == nil {
+		return ""
+	}
+
+	return d.asString
+}
+
 type StringValue struct {
 	Value     string
 	Collation *Collation
@@ -324,14 +424,26 @@ func (s *StringValue) String() string {
 	return s.Value
 }
 
+// DateTimeValue keeps the RFC3339 string as it was sent by the user so that the original offset is preserved when
+// the value is returned back or when date parts are extracted from it. Comparisons are performed on the instant i.e.
+// in UTC, so the same point in time represented with different offsets is considered equal.
 type DateTimeValue struct {
 	Value string
+
+	time   time.Time
+	parsed bool
 }
 
 func NewDateTimeValue(v string) *DateTimeValue {
-	return &DateTimeValue{
+	d := &DateTimeValue{
 		Value: v,
 	}
+	if t, err := time.Parse(schema.DateTimeFormat, v); err == nil {
+		d.time = t
+		d.parsed = true
+	}
+
+	return d
 }
 
 func (d *DateTimeValue) CompareTo(v Value) (int, error) {
@@ -344,6 +456,10 @@ func (d *DateTimeValue) CompareTo(v Value) (int, error) {
 		return -2, fmt.Errorf("wrong type compared ")
 	}
 
+	if d.parsed && converted.parsed {
+		return d.time.Compare(converted.time), nil
+	}
+
 	if d.Value == converted.Value {
 		return 0, nil
 	} else if d.Value < converted.Value {
@@ -353,6 +469,23 @@ func (d *DateTimeValue) CompareTo(v Value) (int, error) {
 	return 1, nil
 }
 
+// Time returns the parsed time in the original offset of the value. The boolean is false if the value is not a valid
+// RFC3339 timestamp.
+func (d *DateTimeValue) Time() (time.Time, bool) {
+	return d.time, d.parsed
+}
+
+// UTC returns the value converted to UTC in a fixed width format. Unlike RFC3339Nano, the fractional seconds are
+// never trimmed so the lexicographic order of the returned strings is the same as the chronological order. If the
+// value is not a valid timestamp then the original string is returned.
+func (d *DateTimeValue) UTC() string {
+	if !d.parsed {
+		return d.Value
+	}
+
+	return d.time.UTC().Format(DateTimeUTCFormat)
+}
+
 func (d *DateTimeValue) AsInterface() any {
 	return d.Value
 }
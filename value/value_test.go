@@ -15,6 +15,7 @@
 package value
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"testing"
@@ -225,6 +226,55 @@ func TestStringCollation(t *testing.T) {
 	})
 }
 
+func TestLocaleCollation(t *testing.T) {
+	t.Run("german phonebook", func(t *testing.T) {
+		// "ä" is ordered as "ae" in the phonebook collation
+		phonebook := NewCollationFrom(&api.Collation{Case: "de@phonebook"})
+		require.True(t, phonebook.HasLocale())
+		require.Equal(t, -1, phonebook.CompareString("Ärger", "Af"))
+
+		standard := NewCollationFrom(&api.Collation{Case: "de"})
+		require.Equal(t, 1, standard.CompareString("Ärger", "Af"))
+	})
+	t.Run("turkish", func(t *testing.T) {
+		tr := NewCollationFrom(&api.Collation{Case: "ci,tr"})
+		require.True(t, tr.IsCaseInsensitive())
+		require.Equal(t, "ıstanbul", tr.ToLower("ISTANBUL"))
+		require.Equal(t, "istanbul", NewCollationFrom(&api.Collation{Case: "ci"}).ToLower("ISTANBUL"))
+	})
+	t.Run("sort key", func(t *testing.T) {
+		c := NewCollationFrom(&api.Collation{Case: "csk,de@phonebook"})
+		require.True(t, c.IsCollationSortKey())
+		require.Equal(t, c.GenerateSortKey("Ärger"), c.GenerateSortKey("Ärger"))
+		require.Equal(t, -1, bytes.Compare(c.GenerateSortKey("Ärger"), c.GenerateSortKey("Af")))
+	})
+	t.Run("accent insensitive", func(t *testing.T) {
+		c := NewCollationFrom(&api.Collation{Case: "ci,ai"})
+		require.True(t, c.IsAccentInsensitive())
+		require.True(t, c.HasOrderingOptions())
+		require.Equal(t, 0, c.CompareString("Résumé", "resume"))
+		require.Equal(t, "resume", c.Fold("Résumé"))
+		require.Equal(t, c.GenerateSortKey("Résumé"), c.GenerateSortKey("resume"))
+	})
+	t.Run("numeric ordering", func(t *testing.T) {
+		c := NewCollationFrom(&api.Collation{Case: "numeric"})
+		require.True(t, c.IsNumericOrdering())
+		require.Equal(t, 1, c.CompareString("file10", "file9"))
+		require.Equal(t, -1, NewCollation().CompareString("file10", "file9"))
+
+		sk := NewCollationFrom(&api.Collation{Case: "csk,numeric"})
+		require.Equal(t, 1, bytes.Compare(sk.GenerateSortKey("file10"), sk.GenerateSortKey("file9")))
+	})
+	t.Run("validation", func(t *testing.T) {
+		require.NoError(t, (&api.Collation{Case: "sv-u-co-trad"}).IsValid())
+		require.NoError(t, (&api.Collation{Case: "de@collation=phonebook"}).IsValid())
+		require.Error(t, (&api.Collation{Case: "de@unknown"}).IsValid())
+		require.Error(t, (&api.Collation{Case: "ci,cs"}).IsValid())
+		require.Error(t, (&api.Collation{Case: "tr,de"}).IsValid())
+		require.Error(t, (&api.Collation{Case: "not a locale"}).IsValid())
+	})
+}
+
 func TestUUIDAndDateValues(t *testing.T) {
 	t.Run("datetime", func(t *testing.T) {
 		v1, err := NewValue(schema.DateTimeType, []byte("2020-10-12T17:42:34Z"))
@@ -243,6 +293,30 @@ func TestUUIDAndDateValues(t *testing.T) {
 		require.Equal(t, 1, r)
 	})
 
+	t.Run("datetime_with_offset", func(t *testing.T) {
+		utc := NewDateTimeValue("2020-10-12T17:42:34Z")
+		offset := NewDateTimeValue("2020-10-12T19:42:34+02:00")
+		r, _ := utc.CompareTo(offset)
+		require.Equal(t, 0, r)
+
+		// lexicographically greater but earlier in time
+		later := NewDateTimeValue("2020-10-12T18:00:00Z")
+		r, _ = offset.CompareTo(later)
+		require.Equal(t, -1, r)
+
+		// original offset is preserved
+		require.Equal(t, "2020-10-12T19:42:34+02:00", offset.String())
+		tm, ok := offset.Time()
+		require.True(t, ok)
+		_, off := tm.Zone()
+		require.Equal(t, 2*60*60, off)
+
+		require.Equal(t, "2020-10-12T17:42:34.000000000Z", offset.UTC())
+		require.Less(t, NewDateTimeValue("2020-10-12T17:42:34Z").UTC(), NewDateTimeValue("2020-10-12T17:42:34.5Z").UTC())
+
+		require.Equal(t, 0, AnyCompare("2020-10-12T12:42:34-05:00", utc))
+	})
+
 	t.Run("uuid", func(t *testing.T) {
 		v1, err := NewValue(schema.UUIDType, []byte("6f64e028-2ff5-490a-b10a-7c44c4595a8b"))
 		require.NoError(t, err)